@@ -0,0 +1,295 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package addc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/pkg/ad"
+)
+
+func NewADDCCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "addc",
+		Short: "Manage users and groups in the self-hosted AD DC",
+		Long:  `Create and manage users and groups in the self-hosted Samba AD DC using samba-tool`,
+	}
+
+	cmd.AddCommand(newUsersCmd())
+	cmd.AddCommand(newGroupsCmd())
+	cmd.AddCommand(newPasswordPolicyCmd())
+
+	return cmd
+}
+
+// withSambaTool runs a command with a samba-tool client, failing when the DC
+// is not enabled
+func withSambaTool(
+	run func(tool *ad.SambaTool, args []string) error,
+) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		tool, err := ad.NewSambaTool()
+		if err != nil {
+			return err
+		}
+		return run(tool, args)
+	}
+}
+
+func printLines(lines []string) {
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
+
+func newUsersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "users",
+		Short: "Manage AD DC users",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List users",
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			users, err := tool.ListUsers(context.Background())
+			if err != nil {
+				return err
+			}
+			printLines(users)
+			return nil
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "show <username>",
+		Short: "Show a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			user, err := tool.GetUser(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Username:     %s\n", user.Username)
+			fmt.Printf("Name:         %s %s\n", user.GivenName, user.Surname)
+			fmt.Printf("Mail:         %s\n", user.Mail)
+			fmt.Printf("Description:  %s\n", user.Description)
+			fmt.Printf("Enabled:      %t\n", user.Enabled)
+			return nil
+		}),
+	})
+
+	var spec ad.DCUserSpec
+	createCmd := &cobra.Command{
+		Use:   "create <username>",
+		Short: "Create a user",
+		Long:  `Create a user. The password is checked against the domain password policy.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			spec.Username = args[0]
+			if err := tool.CreateUser(context.Background(), &spec); err != nil {
+				return err
+			}
+			fmt.Printf("Created user %s\n", spec.Username)
+			return nil
+		}),
+	}
+	createCmd.Flags().StringVar(&spec.Password, "password", "", "Initial password")
+	createCmd.Flags().StringVar(&spec.GivenName, "given-name", "", "Given name")
+	createCmd.Flags().StringVar(&spec.Surname, "surname", "", "Surname")
+	createCmd.Flags().StringVar(&spec.Mail, "mail", "", "Mail address")
+	createCmd.Flags().StringVar(&spec.Description, "description", "", "Description")
+	createCmd.Flags().BoolVar(&spec.MustChangePassword, "must-change-password", false, "Require a password change at next login")
+	createCmd.MarkFlagRequired("password")
+	cmd.AddCommand(createCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete <username>",
+		Short: "Delete a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.DeleteUser(context.Background(), args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted user %s\n", args[0])
+			return nil
+		}),
+	})
+
+	var (
+		password   string
+		mustChange bool
+	)
+	passwdCmd := &cobra.Command{
+		Use:   "reset-password <username>",
+		Short: "Reset a user's password",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.ResetPassword(context.Background(), args[0], password, mustChange); err != nil {
+				return err
+			}
+			fmt.Printf("Password reset for %s\n", args[0])
+			return nil
+		}),
+	}
+	passwdCmd.Flags().StringVar(&password, "password", "", "New password")
+	passwdCmd.Flags().BoolVar(&mustChange, "must-change-password", false, "Require a password change at next login")
+	passwdCmd.MarkFlagRequired("password")
+	cmd.AddCommand(passwdCmd)
+
+	cmd.AddCommand(newUserEnableCmd(true))
+	cmd.AddCommand(newUserEnableCmd(false))
+
+	return cmd
+}
+
+// newUserEnableCmd returns the enable or disable user command
+func newUserEnableCmd(enable bool) *cobra.Command {
+	action, short := "disable", "Disable a user account"
+	if enable {
+		action, short = "enable", "Enable a user account"
+	}
+
+	return &cobra.Command{
+		Use:   action + " <username>",
+		Short: short,
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.SetUserEnabled(context.Background(), args[0], enable); err != nil {
+				return err
+			}
+			fmt.Printf("User %s %sd\n", args[0], action)
+			return nil
+		}),
+	}
+}
+
+func newGroupsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "groups",
+		Short: "Manage AD DC groups",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List groups",
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			groups, err := tool.ListGroups(context.Background())
+			if err != nil {
+				return err
+			}
+			printLines(groups)
+			return nil
+		}),
+	})
+
+	var description string
+	createCmd := &cobra.Command{
+		Use:   "create <group>",
+		Short: "Create a group",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.CreateGroup(context.Background(), args[0], description); err != nil {
+				return err
+			}
+			fmt.Printf("Created group %s\n", args[0])
+			return nil
+		}),
+	}
+	createCmd.Flags().StringVar(&description, "description", "", "Description")
+	cmd.AddCommand(createCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "delete <group>",
+		Short: "Delete a group",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.DeleteGroup(context.Background(), args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted group %s\n", args[0])
+			return nil
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "members <group>",
+		Short: "List group members",
+		Args:  cobra.ExactArgs(1),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			members, err := tool.ListGroupMembers(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+			printLines(members)
+			return nil
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "add-members <group> <member>...",
+		Short: "Add users or groups to a group",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.AddGroupMembers(context.Background(), args[0], args[1:]); err != nil {
+				return err
+			}
+			fmt.Printf("Added %d member(s) to %s\n", len(args)-1, args[0])
+			return nil
+		}),
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "remove-members <group> <member>...",
+		Short: "Remove users or groups from a group",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			if err := tool.RemoveGroupMembers(context.Background(), args[0], args[1:]); err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d member(s) from %s\n", len(args)-1, args[0])
+			return nil
+		}),
+	})
+
+	return cmd
+}
+
+func newPasswordPolicyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "password-policy",
+		Short: "Show the domain password policy",
+		RunE: withSambaTool(func(tool *ad.SambaTool, args []string) error {
+			policy, err := tool.GetPasswordPolicy(context.Background())
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Complexity:        %t\n", policy.Complexity)
+			fmt.Printf("Minimum length:    %d\n", policy.MinLength)
+			fmt.Printf("History length:    %d\n", policy.HistoryLength)
+			fmt.Printf("Minimum age:       %d days\n", policy.MinAgeDays)
+			fmt.Printf("Maximum age:       %d days\n", policy.MaxAgeDays)
+			fmt.Printf("Lockout threshold: %d\n", policy.LockoutAttempt)
+			return nil
+		}),
+	}
+}
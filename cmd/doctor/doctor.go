@@ -0,0 +1,152 @@
+/*
+ * Copyright 2024-2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2024-2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/cmd/cmdutil"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/doctor"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+func NewDoctorCmd() *cobra.Command {
+	var (
+		output      string
+		exclude     []string
+		maxFileMiB  int64
+		maxTotalMiB int64
+		yes         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Gather a diagnostic bundle for support",
+		Long: `Collect the configuration with secrets redacted, recent logs, policy and
+transfer state, zpool and zfs status, Samba's testparm output and domain status
+into a single tarball to attach to a support request. The included data is
+listed for confirmation before anything is written.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, s := range exclude {
+				if !slices.Contains(doctor.Sections, s) {
+					return errors.New(errors.ServerRequestValidation,
+						fmt.Sprintf("unknown section %q, expected one of %s",
+							s, strings.Join(doctor.Sections, ", ")))
+				}
+			}
+
+			// Failed checks are reported in the bundle, not logged
+			cfg := config.GetConfig()
+			logCfg := config.NewLoggerConfig(cfg)
+			logCfg.LogLevel = "error"
+			l, err := logger.NewTag(logCfg, "doctor")
+			if err != nil {
+				return fmt.Errorf("failed to create logger: %w", err)
+			}
+
+			hostname, _ := os.Hostname()
+			now := time.Now()
+			name := fmt.Sprintf("rodent-doctor-%s-%s", hostname, now.Format("20060102-150405"))
+			if output == "" {
+				output = name + ".tar.gz"
+			}
+			opts := doctor.Options{
+				MaxFileBytes:  maxFileMiB << 20,
+				MaxTotalBytes: maxTotalMiB << 20,
+			}
+
+			items := doctor.Filter(doctor.Items(cfg, l), exclude)
+			if !yes {
+				printItems(cmd, items, opts)
+				if !cmdutil.Confirm(cmd, fmt.Sprintf("Write these to %s?", output)) {
+					return fmt.Errorf("aborted, no bundle written")
+				}
+			}
+
+			f, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			manifest := &doctor.Manifest{
+				CreatedAt: now,
+				Version:   constants.Version,
+				Hostname:  hostname,
+			}
+			if err := doctor.Write(context.Background(), f, name, items, manifest, opts); err != nil {
+				os.Remove(output)
+				return fmt.Errorf("failed to write bundle: %w", err)
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+
+			counts := make(map[string]int)
+			for _, e := range manifest.Entries {
+				counts[e.Status]++
+			}
+			fmt.Fprintf(cmd.OutOrStdout(),
+				"Wrote %s: %d files, %d bytes before compression (%d truncated, %d failed, %d skipped)\n",
+				output, counts[doctor.StatusOK]+counts[doctor.StatusTruncated]+counts[doctor.StatusFailed],
+				manifest.TotalBytes, counts[doctor.StatusTruncated], counts[doctor.StatusFailed],
+				counts[doctor.StatusSkipped])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Bundle path (default rodent-doctor-<host>-<time>.tar.gz)")
+	cmd.Flags().StringSliceVar(&exclude, "exclude", nil,
+		"Sections to leave out: "+strings.Join(doctor.Sections, ", "))
+	cmd.Flags().Int64Var(&maxFileMiB, "max-file-size", doctor.DefaultMaxFileBytes>>20, "Size cap of each file, in MiB")
+	cmd.Flags().Int64Var(&maxTotalMiB, "max-size", doctor.DefaultMaxTotalBytes>>20, "Size cap of the bundle before compression, in MiB")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Write the bundle without confirmation")
+
+	return cmd
+}
+
+// printItems lists the files a bundle will include, by section
+func printItems(cmd *cobra.Command, items []doctor.Item, opts doctor.Options) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "The bundle will include, with passwords, tokens and other secrets redacted:")
+	for _, section := range doctor.Sections {
+		first := true
+		for _, item := range items {
+			if item.Section != section {
+				continue
+			}
+			if first {
+				fmt.Fprintf(out, "\n%s:\n", section)
+				first = false
+			}
+			fmt.Fprintf(out, "  %-36s %s\n", item.Name, item.Description)
+		}
+	}
+	fmt.Fprintf(out, "\nFiles are capped at %d MiB and the bundle at %d MiB; use --exclude to leave sections out.\n",
+		opts.MaxFileBytes>>20, opts.MaxTotalBytes>>20)
+}
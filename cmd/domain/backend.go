@@ -0,0 +1,140 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/internal/services/domain"
+)
+
+// membership runs domain operations, either through the daemon or, with
+// --local, in this process
+type membership interface {
+	Status(ctx context.Context) (domain.StatusResponse, error)
+	Join(ctx context.Context, req domain.JoinRequest) error
+	PreviewKerberos(ctx context.Context, req domain.JoinRequest) (string, error)
+	Leave(ctx context.Context, req domain.LeaveRequest) error
+	Check(ctx context.Context, req domain.CheckRequest) (*domain.HealthReport, error)
+	Trusts(ctx context.Context) ([]domain.TrustedDomain, error)
+}
+
+// newMembership returns the daemon's membership API, or a domain client of
+// this process when local is set. Local operations bypass the daemon's
+// health monitor and should only be used when the daemon is not running.
+func newMembership(local bool) (membership, error) {
+	cfg := config.GetConfig()
+	if !local {
+		return &daemonMembership{client: daemon.NewClient(cfg)}, nil
+	}
+
+	l, err := logger.NewTag(config.NewLoggerConfig(cfg), "domain")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+	client, err := domain.NewClient(l)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create domain client: %w", err)
+	}
+	return &localMembership{client: client}, nil
+}
+
+// daemonMembership calls the daemon's domain API
+type daemonMembership struct {
+	client *daemon.Client
+}
+
+func (m *daemonMembership) Status(ctx context.Context) (domain.StatusResponse, error) {
+	var status domain.StatusResponse
+	err := m.client.Do(ctx, http.MethodGet, constants.APIDomain+"/status", nil, &status)
+	return status, err
+}
+
+func (m *daemonMembership) Join(ctx context.Context, req domain.JoinRequest) error {
+	req.Preview = false
+	return m.client.Do(ctx, http.MethodPost, constants.APIDomain+"/join", req, nil)
+}
+
+func (m *daemonMembership) PreviewKerberos(ctx context.Context, req domain.JoinRequest) (string, error) {
+	req.Preview = true
+	var resp struct {
+		Diff string `json:"diff"`
+	}
+	err := m.client.Do(ctx, http.MethodPost, constants.APIDomain+"/join", req, &resp)
+	return resp.Diff, err
+}
+
+func (m *daemonMembership) Leave(ctx context.Context, req domain.LeaveRequest) error {
+	return m.client.Do(ctx, http.MethodPost, constants.APIDomain+"/leave", req, nil)
+}
+
+func (m *daemonMembership) Check(ctx context.Context, req domain.CheckRequest) (*domain.HealthReport, error) {
+	var report domain.HealthReport
+	if err := m.client.Do(ctx, http.MethodPost, constants.APIDomain+"/check", req, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func (m *daemonMembership) Trusts(ctx context.Context) ([]domain.TrustedDomain, error) {
+	var resp struct {
+		Trusts []domain.TrustedDomain `json:"trusts"`
+	}
+	err := m.client.Do(ctx, http.MethodGet, constants.APIDomain+"/trusts", nil, &resp)
+	return resp.Trusts, err
+}
+
+// localMembership runs domain operations with a client of its own
+type localMembership struct {
+	client *domain.Client
+}
+
+func (m *localMembership) Status(ctx context.Context) (domain.StatusResponse, error) {
+	joined, name, err := m.client.Status(ctx)
+	return domain.StatusResponse{Joined: joined, Domain: name}, err
+}
+
+func (m *localMembership) Join(ctx context.Context, req domain.JoinRequest) error {
+	return m.client.Join(ctx, req.Config())
+}
+
+func (m *localMembership) PreviewKerberos(ctx context.Context, req domain.JoinRequest) (string, error) {
+	return m.client.PreviewKerberos(ctx, req.Config())
+}
+
+func (m *localMembership) Leave(ctx context.Context, req domain.LeaveRequest) error {
+	return m.client.Leave(ctx, req.Config())
+}
+
+func (m *localMembership) Check(ctx context.Context, req domain.CheckRequest) (*domain.HealthReport, error) {
+	cfg, err := req.Config()
+	if err != nil {
+		return nil, err
+	}
+	return m.client.CheckHealth(ctx, cfg, req.Repair), nil
+}
+
+func (m *localMembership) Trusts(ctx context.Context) ([]domain.TrustedDomain, error) {
+	return m.client.TrustedDomains(ctx)
+}
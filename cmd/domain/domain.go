@@ -20,203 +20,228 @@ package domain
 import (
 	"context"
 	"fmt"
-	"os"
-	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/stratastor/logger"
-	"github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/internal/services/domain"
+	"github.com/stratastor/rodent/pkg/errors"
 )
 
 func NewDomainCmd() *cobra.Command {
+	var local bool
+
 	cmd := &cobra.Command{
 		Use:   "domain",
 		Short: "Manage Active Directory domain membership",
-		Long:  `Join, leave, or check status of Active Directory domain membership`,
+		Long: `Join, leave, or check status of Active Directory domain membership.
+
+Commands run through the Rodent daemon, so its health monitor and caches see
+every change. Use --local to run them in this process when the daemon is not
+running.`,
 	}
+	cmd.PersistentFlags().BoolVar(&local, "local", false, "Run without the daemon, for when it is not running")
 
-	cmd.AddCommand(newJoinCmd())
-	cmd.AddCommand(newLeaveCmd())
-	cmd.AddCommand(newStatusCmd())
+	cmd.AddCommand(newJoinCmd(&local))
+	cmd.AddCommand(newLeaveCmd(&local))
+	cmd.AddCommand(newStatusCmd(&local))
+	cmd.AddCommand(newCheckCmd(&local))
+	cmd.AddCommand(newTrustsCmd(&local))
 
 	return cmd
 }
 
-func newJoinCmd() *cobra.Command {
+func newJoinCmd(local *bool) *cobra.Command {
 	var (
-		realm         string
-		dcServers     []string
-		adminUser     string
-		adminPassword string
-		waitTimeout   int
+		req     domain.JoinRequest
+		preview bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "join",
 		Short: "Join the host to an Active Directory domain",
 		Long:  `Join this host to an Active Directory domain using the specified credentials`,
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
 
-			// Setup logger
-			cfg := config.GetConfig()
-			logCfg := config.NewLoggerConfig(cfg)
-			l, err := logger.NewTag(logCfg, "domain")
+			m, err := newMembership(*local)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
-			// Create domain client
-			client, err := domain.NewClient(l)
-			if err != nil {
-				l.Error("Failed to create domain client", "error", err)
-				os.Exit(1)
-			}
-
-			// Get configuration
-			var domainCfg *domain.DomainConfig
-			if realm != "" {
-				// Use command-line parameters
-				domainCfg = &domain.DomainConfig{
-					Realm:         realm,
-					DCServers:     dcServers,
-					AdminUser:     adminUser,
-					AdminPassword: adminPassword,
-				}
-			} else {
-				// Use global configuration
-				domainCfg = domain.GetConfigFromGlobal()
-			}
-
-			// Wait for DC to be ready if specified
-			if waitTimeout > 0 && len(domainCfg.DCServers) > 0 {
-				l.Info("Waiting for domain controller to be ready...",
-					"dc", domainCfg.DCServers[0],
-					"timeout", waitTimeout)
-				if err := client.WaitForDC(ctx, domainCfg.DCServers[0],
-					time.Duration(waitTimeout)*time.Second); err != nil {
-					l.Warn("Domain controller may not be ready", "error", err)
-				} else {
-					l.Info("Domain controller is ready")
+			// Show the Kerberos changes without joining
+			if preview {
+				diff, err := m.PreviewKerberos(ctx, req)
+				if err != nil {
+					return fmt.Errorf("failed to preview Kerberos configuration: %w", err)
 				}
+				fmt.Print(diff)
+				return nil
 			}
 
-			// Join domain
-			l.Info("Joining domain", "realm", domainCfg.Realm)
-			if err := client.Join(ctx, domainCfg); err != nil {
-				l.Error("Failed to join domain", "error", err)
-				os.Exit(1)
+			realm := req.Config().Realm
+			if err := m.Join(ctx, req); err != nil {
+				return fmt.Errorf("failed to join domain: %w", err)
 			}
 
-			l.Info("Successfully joined domain", "realm", domainCfg.Realm)
-			fmt.Printf("Successfully joined domain: %s\n", domainCfg.Realm)
+			fmt.Printf("Successfully joined domain: %s\n", realm)
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&realm, "realm", "", "AD realm (e.g., AD.CORP.COM)")
-	cmd.Flags().StringSliceVar(&dcServers, "dc", []string{}, "Domain controller servers (can be specified multiple times)")
-	cmd.Flags().StringVar(&adminUser, "user", "Administrator", "Admin username for domain join")
-	cmd.Flags().StringVar(&adminPassword, "password", "", "Admin password for domain join")
-	cmd.Flags().IntVar(&waitTimeout, "wait", 0, "Wait for DC to be ready (seconds, 0 = no wait)")
+	cmd.Flags().StringVar(&req.Realm, "realm", "", "AD realm (e.g., AD.CORP.COM)")
+	cmd.Flags().StringSliceVar(&req.DCServers, "dc", []string{}, "Domain controller servers in order of preference (can be specified multiple times)")
+	cmd.Flags().StringVar(&req.AdminUser, "user", "Administrator", "Admin username for domain join")
+	cmd.Flags().StringVar(&req.AdminPassword, "password", "", "Admin password for domain join")
+	cmd.Flags().BoolVar(&preview, "preview", false, "Show the changes to /etc/krb5.conf without joining")
+	cmd.Flags().IntVar(&req.WaitSeconds, "wait", 0, "Wait for each DC to be ready (seconds, 0 = default of 10)")
+	cmd.Flags().StringVar(&req.Backend, "backend", "", "Membership backend: winbind or sssd (defaults to config)")
 
 	return cmd
 }
 
-func newLeaveCmd() *cobra.Command {
-	var (
-		adminUser     string
-		adminPassword string
-	)
+func newLeaveCmd(local *bool) *cobra.Command {
+	var req domain.LeaveRequest
 
 	cmd := &cobra.Command{
 		Use:   "leave",
 		Short: "Leave the Active Directory domain",
 		Long:  `Remove this host from the Active Directory domain`,
-		Run: func(cmd *cobra.Command, args []string) {
-			ctx := context.Background()
-
-			// Setup logger
-			cfg := config.GetConfig()
-			logCfg := config.NewLoggerConfig(cfg)
-			l, err := logger.NewTag(logCfg, "domain")
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := newMembership(*local)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
-			// Create domain client
-			client, err := domain.NewClient(l)
-			if err != nil {
-				l.Error("Failed to create domain client", "error", err)
-				os.Exit(1)
+			if err := m.Leave(context.Background(), req); err != nil {
+				return fmt.Errorf("failed to leave domain: %w", err)
 			}
 
-			// Get configuration
-			domainCfg := domain.GetConfigFromGlobal()
-			if adminUser != "" {
-				domainCfg.AdminUser = adminUser
-			}
-			if adminPassword != "" {
-				domainCfg.AdminPassword = adminPassword
-			}
-
-			// Leave domain
-			l.Info("Leaving domain")
-			if err := client.Leave(ctx, domainCfg); err != nil {
-				l.Error("Failed to leave domain", "error", err)
-				os.Exit(1)
-			}
-
-			l.Info("Successfully left domain")
 			fmt.Println("Successfully left domain")
+			return nil
 		},
 	}
 
-	cmd.Flags().StringVar(&adminUser, "user", "", "Admin username (defaults to config)")
-	cmd.Flags().StringVar(&adminPassword, "password", "", "Admin password (defaults to config)")
+	cmd.Flags().StringVar(&req.AdminUser, "user", "", "Admin username (defaults to config)")
+	cmd.Flags().StringVar(&req.AdminPassword, "password", "", "Admin password (defaults to config)")
+	cmd.Flags().StringVar(&req.Backend, "backend", "", "Membership backend: winbind or sssd (defaults to config)")
 
 	return cmd
 }
 
-func newStatusCmd() *cobra.Command {
+func newStatusCmd(local *bool) *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Check domain membership status",
 		Long:  `Check if this host is joined to an Active Directory domain`,
-		Run: func(cmd *cobra.Command, args []string) {
-			ctx := context.Background()
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := newMembership(*local)
+			if err != nil {
+				return err
+			}
+
+			status, err := m.Status(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to check domain status: %w", err)
+			}
+
+			if status.Joined {
+				fmt.Printf("Domain: %s\n", status.Domain)
+				fmt.Println("Status: Joined")
+			} else {
+				fmt.Println("Status: Not joined to any domain")
+			}
+			return nil
+		},
+	}
+}
 
-			// Setup logger
-			cfg := config.GetConfig()
-			logCfg := config.NewLoggerConfig(cfg)
-			l, err := logger.NewTag(logCfg, "domain")
+func newCheckCmd(local *bool) *cobra.Command {
+	var req domain.CheckRequest
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Check domain membership health",
+		Long: `Verify the machine account with testjoin, a machine keytab kinit and
+winbind (or sssd) checks, without admin credentials. With --repair, a broken
+winbind trust is rejoined with 'net ads join -k' using the machine keytab.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := newMembership(*local)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
-				os.Exit(1)
+				return err
 			}
 
-			// Create domain client
-			client, err := domain.NewClient(l)
+			report, err := m.Check(context.Background(), req)
 			if err != nil {
-				l.Error("Failed to create domain client", "error", err)
-				os.Exit(1)
+				return err
+			}
+
+			for _, step := range report.Steps {
+				result := "OK"
+				if !step.OK {
+					result = "FAILED: " + step.Error
+				}
+				fmt.Printf("%-16s %s\n", step.Name, result)
 			}
 
-			// Check status
-			joined, domainInfo, err := client.Status(ctx)
+			if report.Repaired {
+				fmt.Println("Trust repaired with the machine keytab")
+			}
+			if report.RepairError != "" {
+				fmt.Printf("Repair failed: %s\n", report.RepairError)
+			}
+			if !report.Healthy {
+				if report.Action != "" {
+					fmt.Printf("Action: %s\n", report.Action)
+				}
+				return errors.New(errors.HealthCheckFailed, "domain membership unhealthy").
+					WithMetadata("realm", report.Realm)
+			}
+			fmt.Printf("Domain membership healthy: %s\n", report.Realm)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&req.Repair, "repair", false, "Rejoin with the machine keytab if the trust is broken")
+	cmd.Flags().StringVar(&req.Backend, "backend", "", "Membership backend: winbind or sssd (defaults to config)")
+
+	return cmd
+}
+
+func newTrustsCmd(local *bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   "trusts",
+		Short: "List trusted domains",
+		Long:  `List the joined domain and its trusted domains as seen by winbind`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := newMembership(*local)
 			if err != nil {
-				l.Error("Failed to check domain status", "error", err)
-				os.Exit(1)
+				return err
 			}
 
-			if joined {
-				fmt.Printf("Domain: %s\n", domainInfo)
-				fmt.Println("Status: Joined")
-			} else {
-				fmt.Println("Status: Not joined to any domain")
+			domains, err := m.Trusts(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to list trusted domains: %w", err)
+			}
+
+			fmt.Printf("%-16s %-32s %-10s %-10s %-9s %s\n",
+				"DOMAIN", "DNS NAME", "TYPE", "TRANSITIVE", "DIRECTION", "STATUS")
+			for _, d := range domains {
+				direction := "-"
+				switch {
+				case d.Inbound && d.Outbound:
+					direction = "both"
+				case d.Inbound:
+					direction = "inbound"
+				case d.Outbound:
+					direction = "outbound"
+				}
+				status := "offline"
+				if d.Online {
+					status = "online"
+				}
+				fmt.Printf("%-16s %-32s %-10s %-10t %-9s %s\n",
+					d.Name, d.DNSName, d.TrustType, d.Transitive, direction, status)
 			}
+			return nil
 		},
 	}
 }
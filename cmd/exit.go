@@ -0,0 +1,115 @@
+/*
+ * Copyright 2024-2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2024-2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Exit codes of the rodent command, so scripts can branch on the kind of
+// failure. Documented in docs/INSTALLATION.md; do not renumber.
+const (
+	ExitOK         = 0
+	ExitFailure    = 1 // Any failure not classified below
+	ExitUsage      = 2 // Invalid arguments, flags or input
+	ExitConfig     = 3 // Configuration missing or invalid
+	ExitNotFound   = 4 // A named resource does not exist
+	ExitPermission = 5 // Not permitted, by the OS or Rodent's policy
+	ExitRemote     = 6 // A remote service failed, timed out or is unreachable
+	ExitUnhealthy  = 7 // A check ran and found a problem
+)
+
+// Execute runs the root command, prints any error and returns the exit code
+// for it
+func Execute() int {
+	rootCmd := NewRootCmd()
+
+	// Errors before a command starts running are usage errors
+	started := false
+	markStarted(rootCmd, &started)
+
+	if c, err := rootCmd.ExecuteC(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if !started {
+			fmt.Fprintf(os.Stderr, "Run '%s --help' for usage.\n", c.CommandPath())
+			return ExitUsage
+		}
+		return ExitCode(err)
+	}
+	return ExitOK
+}
+
+// markStarted wraps the RunE of every command to record that it started
+func markStarted(c *cobra.Command, started *bool) {
+	if runE := c.RunE; runE != nil {
+		c.RunE = func(cmd *cobra.Command, args []string) error {
+			*started = true
+			return runE(cmd, args)
+		}
+	}
+	for _, sub := range c.Commands() {
+		markStarted(sub, started)
+	}
+}
+
+// ExitCode returns the exit code for an error returned by a command
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var rerr *errors.RodentError
+	if stderrors.As(err, &rerr) {
+		switch rerr.Domain {
+		case errors.DomainConfig:
+			return ExitConfig
+		case errors.DomainHealth:
+			return ExitUnhealthy
+		}
+
+		switch rerr.HTTPStatus {
+		case http.StatusBadRequest, http.StatusUnprocessableEntity:
+			return ExitUsage
+		case http.StatusNotFound:
+			return ExitNotFound
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return ExitPermission
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return ExitRemote
+		}
+		return ExitFailure
+	}
+
+	switch {
+	case stderrors.Is(err, fs.ErrNotExist):
+		return ExitNotFound
+	case stderrors.Is(err, fs.ErrPermission):
+		return ExitPermission
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return ExitRemote
+	}
+	return ExitFailure
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright 2024-2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2024-2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, ExitOK},
+		{"plain", fmt.Errorf("boom"), ExitFailure},
+		{"config", errors.New(errors.ConfigNotFound, ""), ExitConfig},
+		{"config permission", errors.New(errors.ConfigPermissionDenied, ""), ExitConfig},
+		{"unhealthy", errors.New(errors.HealthCheckFailed, ""), ExitUnhealthy},
+		{"not found", errors.New(errors.ZFSPoolNotFound, ""), ExitNotFound},
+		{"policy permission", errors.New(errors.PermissionDenied, ""), ExitPermission},
+		{"remote", errors.New(errors.NetworkTimeout, ""), ExitRemote},
+		{"invalid input", errors.New(errors.ServerRequestValidation, ""), ExitUsage},
+		{"wrapped", fmt.Errorf("failed to join domain: %w", errors.New(errors.ZFSPoolNotFound, "")), ExitNotFound},
+		{"missing file", fmt.Errorf("open: %w", fs.ErrNotExist), ExitNotFound},
+		{"os permission", fs.ErrPermission, ExitPermission},
+		{"timeout", context.DeadlineExceeded, ExitRemote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+			}
+		})
+	}
+}
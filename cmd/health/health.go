@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
 	"github.com/stratastor/rodent/pkg/health"
 )
 
@@ -34,8 +35,7 @@ func NewHealthCmd() *cobra.Command {
 			checker := health.NewHealthChecker(cfg)
 			ret, err := checker.CheckHealth()
 			if err != nil {
-				fmt.Println("Health check failed: ", err)
-				return nil
+				return errors.Wrap(err, errors.HealthCheckFailed)
 			}
 			fmt.Println(ret)
 			return nil
@@ -24,6 +24,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
 )
 
 func NewLogsCmd() *cobra.Command {
@@ -32,22 +33,20 @@ func NewLogsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "logs",
 		Short: "View Rodent server logs",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 			cfg := config.GetConfig()
 			if cfg == nil {
-				fmt.Println("No configuration loaded")
-				return
+				return errors.New(errors.ConfigNotFound, "no configuration loaded")
 			}
 
 			if cfg.Logs.Output == "stdout" {
 				fmt.Println("Logs are being written to stdout.")
-				return
+				return nil
 			}
 
 			logFile := cfg.Logs.Path
-			if _, err := os.Stat(logFile); os.IsNotExist(err) {
-				fmt.Println("Log file does not exist:", logFile)
-				return
+			if _, err := os.Stat(logFile); err != nil {
+				return err
 			}
 
 			tailCmd := "tail"
@@ -61,8 +60,9 @@ func NewLogsCmd() *cobra.Command {
 			execCmd.Stdout = os.Stdout
 			execCmd.Stderr = os.Stderr
 			if err := execCmd.Run(); err != nil {
-				fmt.Printf("Failed to read logs: %v\n", err)
+				return fmt.Errorf("failed to read logs: %w", err)
 			}
+			return nil
 		},
 	}
 
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/pkg/maintenance"
+)
+
+// requestTimeout bounds a request to the daemon; changing share access
+// reloads Samba
+const requestTimeout = time.Minute
+
+func NewMaintenanceCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Put the node in maintenance mode",
+		Long: `Maintenance mode pauses every scheduler, refuses new transfers, mutes
+notifications other than critical ones and, optionally, makes shares read
+only or unavailable. Turning it off resumes everything and runs the
+scheduled tasks that were missed.`,
+	}
+
+	cmd.AddCommand(newOnCmd())
+	cmd.AddCommand(newOffCmd())
+	cmd.AddCommand(newStatusCmd())
+
+	return cmd
+}
+
+func newOnCmd() *cobra.Command {
+	var (
+		reason     string
+		readOnly   bool
+		disconnect bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "on",
+		Short: "Enter maintenance mode",
+		Example: `  rodent maintenance on --reason "disk swap"
+  rodent maintenance on --reason "pool upgrade" --read-only-shares`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req := maintenance.OnRequest{Reason: reason}
+			if readOnly {
+				req.Shares = maintenance.SharesReadOnly
+			}
+			if disconnect {
+				req.Shares = maintenance.SharesDisconnected
+			}
+
+			ctx, cancel := context.WithTimeout(cmd.Context(), requestTimeout)
+			defer cancel()
+			var w maintenance.Window
+			client := daemon.NewClient(config.GetConfig())
+			if err := client.Do(ctx, http.MethodPost, constants.APIMaintenance+"/on", req, &w); err != nil {
+				return fmt.Errorf("failed to enter maintenance mode: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Node is in maintenance: %s\n", w.Reason)
+			printErrors(out, w.Errors)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "Why the node is in maintenance, kept with the window")
+	cmd.Flags().BoolVar(&readOnly, "read-only-shares", false, "Make every share read only")
+	cmd.Flags().BoolVar(&disconnect, "disconnect-shares", false, "Make every share unavailable and disconnect its clients")
+	_ = cmd.MarkFlagRequired("reason")
+	cmd.MarkFlagsMutuallyExclusive("read-only-shares", "disconnect-shares")
+
+	return cmd
+}
+
+func newOffCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "off",
+		Short: "Leave maintenance mode and run missed scheduled tasks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), requestTimeout)
+			defer cancel()
+			var w maintenance.Window
+			client := daemon.NewClient(config.GetConfig())
+			if err := client.Do(ctx, http.MethodPost, constants.APIMaintenance+"/off", nil, &w); err != nil {
+				return fmt.Errorf("failed to leave maintenance mode: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			duration := time.Duration(0)
+			if w.EndedAt != nil {
+				duration = w.EndedAt.Sub(w.StartedAt).Round(time.Second)
+			}
+			fmt.Fprintf(out, "Node left maintenance after %s, %d missed scheduled runs started\n",
+				duration, w.CaughtUp)
+			printErrors(out, w.Errors)
+			return nil
+		},
+	}
+}
+
+func newStatusCmd() *cobra.Command {
+	var history int
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the node is in maintenance, and past windows",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(cmd.Context(), requestTimeout)
+			defer cancel()
+			var status maintenance.Status
+			client := daemon.NewClient(config.GetConfig())
+			if err := client.Do(ctx, http.MethodGet, constants.APIMaintenance, nil, &status); err != nil {
+				return fmt.Errorf("failed to get maintenance status: %w", err)
+			}
+
+			out := cmd.OutOrStdout()
+			if w := status.Window; w != nil {
+				fmt.Fprintf(out, "In maintenance since %s by %s: %s\n",
+					w.StartedAt.Local().Format(time.DateTime), by(w.StartedBy.User, w.StartedBy.Source), w.Reason)
+				if w.Shares != "" {
+					fmt.Fprintf(out, "Shares: %s\n", w.Shares)
+				}
+				for _, s := range status.Schedulers {
+					fmt.Fprintf(out, "Scheduler %s: %d missed runs held\n", s.Name, s.Held)
+				}
+				printErrors(out, w.Errors)
+			} else {
+				fmt.Fprintln(out, "Not in maintenance")
+			}
+
+			if history <= 0 || len(status.History) == 0 {
+				return nil
+			}
+			fmt.Fprintf(out, "\n%-20s %-20s %-16s %-9s %s\n", "STARTED", "ENDED", "BY", "CAUGHT UP", "REASON")
+			for i, w := range status.History {
+				if i == history {
+					break
+				}
+				ended := "-"
+				if w.EndedAt != nil {
+					ended = w.EndedAt.Local().Format(time.DateTime)
+				}
+				fmt.Fprintf(out, "%-20s %-20s %-16s %-9d %s\n",
+					w.StartedAt.Local().Format(time.DateTime),
+					ended,
+					by(w.StartedBy.User, w.StartedBy.Source),
+					w.CaughtUp,
+					w.Reason)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&history, "history", 10, "How many past windows to list")
+
+	return cmd
+}
+
+// by names who opened a window
+func by(user, source string) string {
+	if user != "" {
+		return user
+	}
+	if source != "" {
+		return source
+	}
+	return "-"
+}
+
+// printErrors prints the parts of the node a window could not change
+func printErrors(w io.Writer, errs []string) {
+	for _, e := range errs {
+		fmt.Fprintf(w, "Warning: %s\n", e)
+	}
+}
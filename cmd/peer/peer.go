@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package peer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/cmd/cmdutil"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/keys/ssh"
+)
+
+func NewPeerCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "peer",
+		Short: "Manage trust in transfer peers",
+	}
+
+	cmd.AddCommand(newTrustCmd())
+
+	return cmd
+}
+
+func newTrustCmd() *cobra.Command {
+	var (
+		port        int
+		peeringID   string
+		fingerprint string
+		yes         bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "trust <host>",
+		Short: "Record the SSH host key of a transfer peer",
+		Long: `Fetch the SSH host keys of a host and pin one for a peering in Rodent's
+known_hosts, which transfers check host keys against. Compare the fingerprint
+with the one reported on the host by 'ssh-keygen -lf /etc/ssh/ssh_host_ed25519_key.pub',
+or pass it with --fingerprint to pin only a key that matches. Pinning replaces
+the keys recorded for the host and peering before.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			host := args[0]
+			if peeringID == "" {
+				return errors.New(errors.SSHKeyPairInvalidPeeringID, "--peering-id is required")
+			}
+
+			cfg := config.GetConfig()
+			l, err := logger.NewTag(config.NewLoggerConfig(cfg), "peer")
+			if err != nil {
+				return fmt.Errorf("failed to create logger: %w", err)
+			}
+			manager, err := ssh.NewSSHKeyManager(l)
+			if err != nil {
+				return fmt.Errorf("failed to open known_hosts: %w", err)
+			}
+			defer manager.Close()
+
+			ctx := context.Background()
+			keys, err := ssh.ScanHostKeys(ctx, host, port)
+			if err != nil {
+				return fmt.Errorf("failed to fetch host keys of %s: %w", host, err)
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Host keys offered by %s:\n", host)
+			for _, key := range keys {
+				note := ""
+				if len(key.Issues) > 0 {
+					note = "  (" + strings.Join(key.Issues, "; ") + ")"
+				}
+				fmt.Fprintf(out, "  %-24s %5d  %s%s\n", key.Type, key.Bits, key.Fingerprint, note)
+			}
+
+			key, err := ssh.SelectHostKey(keys, fingerprint)
+			if err != nil {
+				return err
+			}
+
+			knownHost := ssh.KnownHostName(host, port)
+			if fingerprint == "" && !yes {
+				question := fmt.Sprintf("Trust %s key %s for %s (peering %s)?",
+					key.Type, key.Fingerprint, knownHost, peeringID)
+				if !cmdutil.Confirm(cmd, question) {
+					return fmt.Errorf("aborted, no host key pinned")
+				}
+			}
+
+			if err := manager.PinHostKey(ctx, knownHost, key.PublicKey, peeringID); err != nil {
+				return fmt.Errorf("failed to pin host key: %w", err)
+			}
+			fmt.Fprintf(out, "Pinned %s key %s for %s (peering %s)\n",
+				key.Type, key.Fingerprint, knownHost, peeringID)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVarP(&port, "port", "p", 22, "SSH port of the host")
+	cmd.Flags().StringVar(&peeringID, "peering-id", "", "Peering the host key is pinned for (required)")
+	cmd.Flags().StringVar(&fingerprint, "fingerprint", "", "Expected SHA256 fingerprint; only a matching key is pinned")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Pin the preferred key without confirmation")
+
+	return cmd
+}
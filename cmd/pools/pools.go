@@ -0,0 +1,208 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package pools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/cmd/cmdutil"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/pkg/disk"
+)
+
+func NewPoolsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pools",
+		Short: "Create ZFS pools from discovered disks",
+	}
+
+	cmd.AddCommand(newPlanCmd())
+	cmd.AddCommand(newCreateCmd())
+
+	return cmd
+}
+
+// planFlags are the layout flags shared by plan and create
+type planFlags struct {
+	layout     string
+	width      int
+	dataDisks  int
+	spares     int
+	properties []string
+	mountPoint string
+	force      bool
+}
+
+func (f *planFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.layout, "layout", "", "Redundancy: mirror, raidz1, raidz2, raidz3, draid1, draid2 or draid3 (required)")
+	cmd.Flags().IntVar(&f.width, "width", 0, "Disks per mirror or raidz vdev; mirror pairs and one raidz vdev by default")
+	cmd.Flags().IntVar(&f.dataDisks, "data-disks", 0, "Data disks per dRAID redundancy group; up to 8 by default")
+	cmd.Flags().IntVar(&f.spares, "spares", 0, "Hot spares taken from the last disks, or distributed spares for dRAID")
+	cmd.Flags().StringArrayVarP(&f.properties, "property", "o", nil, "Pool property as name=value; may be repeated")
+	cmd.Flags().StringVarP(&f.mountPoint, "mountpoint", "m", "", "Mountpoint of the pool's root dataset")
+	cmd.Flags().BoolVar(&f.force, "force", false, "Accept disks of mixed sizes or media")
+	cmd.MarkFlagRequired("layout")
+}
+
+// request builds the plan request for a pool name and disks
+func (f *planFlags) request(name string, deviceIDs []string) (disk.PoolPlanRequest, error) {
+	req := disk.PoolPlanRequest{
+		Name:       name,
+		Layout:     disk.PoolLayout(f.layout),
+		DeviceIDs:  deviceIDs,
+		Width:      f.width,
+		DataDisks:  f.dataDisks,
+		Spares:     f.spares,
+		MountPoint: f.mountPoint,
+		Force:      f.force,
+	}
+	for _, property := range f.properties {
+		k, v, ok := strings.Cut(property, "=")
+		if !ok || k == "" {
+			return req, fmt.Errorf("property %q is not name=value", property)
+		}
+		if req.Properties == nil {
+			req.Properties = make(map[string]string)
+		}
+		req.Properties[k] = v
+	}
+	return req, nil
+}
+
+// planPool asks the daemon to plan a pool
+func planPool(ctx context.Context, client *daemon.Client, req disk.PoolPlanRequest) (*disk.PoolPlan, error) {
+	var resp struct {
+		Result *disk.PoolPlan `json:"result"`
+	}
+	if err := client.Do(ctx, http.MethodPost, constants.APIDisk+"/pools/plan", req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+func newPlanCmd() *cobra.Command {
+	var flags planFlags
+
+	cmd := &cobra.Command{
+		Use:   "plan <pool> <device-id>...",
+		Short: "Preview a pool built from discovered disks",
+		Long: `Check that the disks are free and healthy and fit the layout, and print
+the vdevs, usable space, issues, warnings and the zpool create command that
+'rodent pools create' would run. Disks are grouped into vdevs in the order
+given. Device IDs are those listed by the disks API.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := flags.request(args[0], args[1:])
+			if err != nil {
+				return err
+			}
+
+			plan, err := planPool(cmd.Context(), daemon.NewClient(config.GetConfig()), req)
+			if err != nil {
+				return fmt.Errorf("failed to plan pool %s: %w", req.Name, err)
+			}
+			printPlan(cmd.OutOrStdout(), plan)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+
+	return cmd
+}
+
+func newCreateCmd() *cobra.Command {
+	var (
+		flags planFlags
+		yes   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "create <pool> <device-id>...",
+		Short: "Create a pool from discovered disks",
+		Long: `Plan the pool as 'rodent pools plan' does and, once confirmed, create it
+with the disks' /dev/disk/by-id paths. A plan with issues is not created.`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			req, err := flags.request(args[0], args[1:])
+			if err != nil {
+				return err
+			}
+			client := daemon.NewClient(config.GetConfig())
+			out := cmd.OutOrStdout()
+
+			plan, err := planPool(cmd.Context(), client, req)
+			if err != nil {
+				return fmt.Errorf("failed to plan pool %s: %w", req.Name, err)
+			}
+			printPlan(out, plan)
+			if !plan.Valid {
+				return fmt.Errorf("pool %s not created: the plan has issues", req.Name)
+			}
+			if !yes && !cmdutil.Confirm(cmd, fmt.Sprintf("Create pool %s? Data on these disks is lost.", req.Name)) {
+				return fmt.Errorf("aborted, pool %s not created", req.Name)
+			}
+
+			if err := client.Do(cmd.Context(), http.MethodPost, constants.APIDisk+"/pools", req, nil); err != nil {
+				return fmt.Errorf("failed to create pool %s: %w", req.Name, err)
+			}
+			fmt.Fprintf(out, "Pool %s created\n", req.Name)
+			return nil
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Create the pool without confirmation")
+
+	return cmd
+}
+
+// printPlan prints the vdevs, issues, warnings and command of a plan
+func printPlan(w io.Writer, plan *disk.PoolPlan) {
+	fmt.Fprintf(w, "Pool %s (%s), about %s usable\n", plan.Name, plan.Layout, common.FormatBytes(plan.UsableBytes))
+	for _, vdev := range plan.Vdevs {
+		fmt.Fprintf(w, "  %s\n", vdev.Type)
+		printDisks(w, vdev.Disks)
+	}
+	if len(plan.Spares) > 0 {
+		fmt.Fprintln(w, "  spares")
+		printDisks(w, plan.Spares)
+	}
+
+	for _, issue := range plan.Issues {
+		fmt.Fprintf(w, "%s: %s\n", strings.ToUpper(issue.Severity), issue.Message)
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+	fmt.Fprintf(w, "\n%s\n", plan.Command)
+}
+
+func printDisks(w io.Writer, disks []disk.PlannedDisk) {
+	for _, d := range disks {
+		fmt.Fprintf(w, "    %-56s %-4s %8s\n", d.Path, d.Type, common.FormatBytes(d.SizeBytes))
+	}
+}
@@ -0,0 +1,113 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package privilege
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/system/privilege"
+)
+
+func NewPrivilegeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "privilege",
+		Short: "Inspect the privileged operations policy",
+		Long:  `Inspect the policy that limits which commands and files Rodent may access as root`,
+	}
+
+	cmd.AddCommand(newSudoersCmd())
+	cmd.AddCommand(newCheckCmd())
+	cmd.AddCommand(newHelperCmd())
+
+	return cmd
+}
+
+func newSudoersCmd() *cobra.Command {
+	var user, helper string
+
+	cmd := &cobra.Command{
+		Use:   "sudoers",
+		Short: "Print a sudoers policy limited to Rodent's privileged operations",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if helper == "" {
+				executable, err := os.Executable()
+				if err != nil {
+					return fmt.Errorf("failed to find the rodent executable: %w", err)
+				}
+				if helper, err = filepath.EvalSymlinks(executable); err != nil {
+					return fmt.Errorf("failed to resolve the rodent executable: %w", err)
+				}
+			}
+			fmt.Print(privilege.DefaultConfig().Sudoers(user, helper, nil))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&user, "user", "rodent", "User the policy applies to")
+	cmd.Flags().StringVar(&helper, "helper", "", "Path of the rodent executable run as the root helper (default: this executable)")
+	return cmd
+}
+
+func newCheckCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "check <command> [args...]",
+		Short: "Check whether the policy allows running a command as root",
+		Args:  cobra.MinimumNArgs(1),
+		// Arguments are passed through to the checked command
+		DisableFlagParsing: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			broker := privilege.NewBroker(common.Log, privilege.DefaultConfig())
+			if err := broker.CheckCommand(args[0], args[1:]); err != nil {
+				return fmt.Errorf("denied: %w", err)
+			}
+			fmt.Println("Allowed")
+			return nil
+		},
+	}
+}
+
+func newHelperCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:    "helper <operation> [args...]",
+		Short:  "Perform a privileged operation as root on Rodent's behalf",
+		Hidden: true,
+		Args:   cobra.MinimumNArgs(1),
+		// Arguments are passed through to the commands the helper runs
+		DisableFlagParsing: true,
+		Run: func(cmd *cobra.Command, args []string) {
+			// stdout carries file contents, so the helper logs to stderr
+			l := slog.New(slog.NewJSONHandler(os.Stderr, nil)).With("tag", "privilege-helper")
+			helper := privilege.NewHelper(l, privilege.HelperConfig(), os.Stdin, os.Stdout)
+			err := helper.Run(args[0], args[1:])
+			switch {
+			case err == nil:
+			case errors.Is(err, privilege.ErrHelperNotExist):
+				os.Exit(privilege.HelperExitNotExist)
+			default:
+				fmt.Fprintf(os.Stderr, "rodent privilege helper: %v\n", err)
+				os.Exit(privilege.HelperExitFailed)
+			}
+		},
+	}
+}
@@ -0,0 +1,151 @@
+/*
+ * Copyright 2024-2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2024-2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package provision
+
+import (
+	"context"
+	"fmt"
+	"maps"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/provision"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+func NewInitCmd() *cobra.Command {
+	var (
+		answersFile string
+		poolName    string
+		vdevs       []string
+		poolProps   map[string]string
+		datasets    []string
+		jwt         string
+		force       bool
+		noSnapshots bool
+		noService   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Provision this host as a Rodent NAS",
+		Long: `Bootstrap a new host without prompts: write the configuration, optionally
+create the first pool and datasets with default snapshot policies, and enable
+the Rodent service. Choices come from an answers file, flags or both; flags
+override the file. Steps already done are skipped, so init can be rerun.`,
+		Example: `  rodent init --answers /etc/rodent/answers.yml
+  rodent init --jwt "$JWT" --pool tank --vdev mirror:/dev/disk/by-id/a,/dev/disk/by-id/b \
+    --dataset tank/shares --dataset tank/backups`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			answers, err := provision.LoadAnswers(answersFile)
+			if err != nil {
+				return err
+			}
+
+			if jwt != "" {
+				if answers.Config == nil {
+					answers.Config = make(map[string]any)
+				}
+				answers.Config["toggle"] = map[string]any{"jwt": jwt}
+			}
+			if poolName != "" || len(vdevs) > 0 || len(poolProps) > 0 {
+				if answers.Pool == nil {
+					answers.Pool = &provision.PoolAnswers{}
+				}
+				if poolName != "" {
+					answers.Pool.Name = poolName
+				}
+				if len(vdevs) > 0 {
+					answers.Pool.VDevs = nil
+				}
+				for _, v := range vdevs {
+					spec, err := provision.ParseVDev(v)
+					if err != nil {
+						return err
+					}
+					answers.Pool.VDevs = append(answers.Pool.VDevs, spec)
+				}
+				if answers.Pool.Properties == nil {
+					answers.Pool.Properties = make(map[string]string)
+				}
+				maps.Copy(answers.Pool.Properties, poolProps)
+			}
+			if answers.Pool != nil && force {
+				answers.Pool.Force = true
+			}
+			for _, name := range datasets {
+				answers.Datasets = append(answers.Datasets, provision.DatasetAnswers{Name: name})
+			}
+			if noSnapshots {
+				answers.Snapshots.Enabled = false
+			}
+			if noService {
+				answers.Service.Enable = false
+			}
+
+			if err := answers.Validate(); err != nil {
+				return err
+			}
+
+			cfg := config.GetConfig()
+			l, err := logger.NewTag(config.NewLoggerConfig(cfg), "init")
+			if err != nil {
+				return fmt.Errorf("failed to create logger: %w", err)
+			}
+
+			ctx := context.Background()
+			executor := command.NewCommandExecutor(true, logger.Config{LogLevel: cfg.Server.LogLevel})
+			if _, err := executor.DetectVersion(ctx); err != nil {
+				l.Warn("Failed to detect OpenZFS version", "error", err)
+			}
+
+			steps, err := provision.NewProvisioner(l, executor).Run(ctx, answers)
+			for _, step := range steps {
+				line := fmt.Sprintf("%-8s %s", step.Status, step.Name)
+				if step.Detail != "" {
+					line += ": " + step.Detail
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), line)
+			}
+			if err != nil {
+				return err
+			}
+
+			if !answers.Service.Enable {
+				fmt.Fprintf(cmd.OutOrStdout(), "Start Rodent with: sudo systemctl enable --now %s\n",
+					provision.ServiceName)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&answersFile, "answers", "f", "", "Answers file (YAML)")
+	cmd.Flags().StringVar(&jwt, "jwt", "", "Toggle JWT that registers this host with Strata")
+	cmd.Flags().StringVar(&poolName, "pool", "", "Name of the first pool to create")
+	cmd.Flags().StringArrayVar(&vdevs, "vdev", nil,
+		"Pool vdev as [type:]device,device... (repeatable), e.g. mirror:/dev/sdb,/dev/sdc")
+	cmd.Flags().StringToStringVar(&poolProps, "pool-property", nil, "Pool property as name=value (repeatable)")
+	cmd.Flags().BoolVar(&force, "force", false, "Create the pool even if devices appear to be in use")
+	cmd.Flags().StringArrayVar(&datasets, "dataset", nil, "Filesystem to create (repeatable)")
+	cmd.Flags().BoolVar(&noSnapshots, "no-snapshots", false, "Do not add the default snapshot policies")
+	cmd.Flags().BoolVar(&noService, "no-service", false, "Do not enable and start the Rodent service")
+
+	return cmd
+}
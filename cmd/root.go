@@ -20,22 +20,41 @@ package cmd
 import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/stratastor/rodent/cmd/addc"
 	"github.com/stratastor/rodent/cmd/config"
+	"github.com/stratastor/rodent/cmd/doctor"
 	"github.com/stratastor/rodent/cmd/domain"
 	"github.com/stratastor/rodent/cmd/health"
 	"github.com/stratastor/rodent/cmd/logs"
+	"github.com/stratastor/rodent/cmd/maintenance"
+	"github.com/stratastor/rodent/cmd/peer"
+	"github.com/stratastor/rodent/cmd/pools"
+	"github.com/stratastor/rodent/cmd/privilege"
+	"github.com/stratastor/rodent/cmd/provision"
 	"github.com/stratastor/rodent/cmd/serve"
+	"github.com/stratastor/rodent/cmd/shares"
 	"github.com/stratastor/rodent/cmd/status"
+	"github.com/stratastor/rodent/cmd/transfers"
 	"github.com/stratastor/rodent/cmd/version"
+	rodentCfg "github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/command"
 )
 
 func NewRootCmd() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:   "rodent",
 		Short: "Rodent: StrataSTOR Node Agent",
+		// Execute prints errors once and maps them to exit codes
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			command.SetDryRun(rodentCfg.GetConfig().Server.DryRun)
+		},
 	}
 	rootCmd.PersistentFlags().Bool("dev", false, "Enable development mode")
 	viper.BindPFlag("development.enabled", rootCmd.PersistentFlags().Lookup("dev"))
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Log commands and file writes that would change the system instead of running them")
+	viper.BindPFlag("server.dryRun", rootCmd.PersistentFlags().Lookup("dry-run"))
 
 	rootCmd.AddCommand(serve.NewServeCmd())
 	rootCmd.AddCommand(version.NewVersionCmd())
@@ -44,6 +63,15 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(logs.NewLogsCmd())
 	rootCmd.AddCommand(config.NewConfigCmd())
 	rootCmd.AddCommand(domain.NewDomainCmd())
+	rootCmd.AddCommand(addc.NewADDCCmd())
+	rootCmd.AddCommand(shares.NewSharesCmd())
+	rootCmd.AddCommand(peer.NewPeerCmd())
+	rootCmd.AddCommand(pools.NewPoolsCmd())
+	rootCmd.AddCommand(transfers.NewTransfersCmd())
+	rootCmd.AddCommand(maintenance.NewMaintenanceCmd())
+	rootCmd.AddCommand(privilege.NewPrivilegeCmd())
+	rootCmd.AddCommand(doctor.NewDoctorCmd())
+	rootCmd.AddCommand(provision.NewInitCmd())
 
 	return rootCmd
 }
@@ -26,6 +26,7 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/crash"
 	"github.com/stratastor/rodent/pkg/lifecycle"
 	"github.com/stratastor/rodent/pkg/server"
 )
@@ -36,14 +37,14 @@ func NewServeCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "Start the Rodent server",
-		Run:   runServe,
+		RunE:  runServe,
 	}
 
 	cmd.Flags().BoolVarP(&detached, "detach", "d", false, "Run as a daemon")
 	return cmd
 }
 
-func runServe(cmd *cobra.Command, args []string) {
+func runServe(cmd *cobra.Command, args []string) error {
 	rc := config.GetConfig()
 	
 	// Use environment variable for PID file path if set
@@ -54,8 +55,7 @@ func runServe(cmd *cobra.Command, args []string) {
 	
 	// Check for existing instance before proceeding
 	if err := lifecycle.EnsureSingleInstance(pidFile); err != nil {
-		fmt.Printf("Failed to start: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to start: %w", err)
 	}
 
 	if detached {
@@ -71,21 +71,22 @@ func runServe(cmd *cobra.Command, args []string) {
 
 		d, err := ctx.Reborn()
 		if err != nil {
-			fmt.Printf("Failed to start daemon: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("failed to start daemon: %w", err)
 		}
 
 		if d != nil {
 			fmt.Println("Rodent is running as a daemon")
-			return
+			return nil
 		}
 		defer ctx.Release()
 	}
 
-	startServer()
+	return startServer()
 }
 
-func startServer() {
+func startServer() error {
+	defer crash.Recover("serve")
+
 	cfg := config.GetConfig()
 
 	// Context for graceful shutdown
@@ -109,6 +110,7 @@ func startServer() {
 	// Start the server
 	fmt.Printf("Starting Rodent server on port %d\n", cfg.Server.Port)
 	if err := server.Start(ctx, cfg.Server.Port); err != nil {
-		fmt.Printf("Failed to start server: %v", err)
+		return fmt.Errorf("failed to start server: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,150 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+// shareConfigs reads and updates share configurations, either through the
+// daemon or, with --local, in this process
+type shareConfigs interface {
+	Get(ctx context.Context, name string) (*smb.SMBShareConfig, error)
+	Update(ctx context.Context, config *smb.SMBShareConfig) error
+}
+
+// newShareConfigs returns the daemon's shares API, or an SMB manager of this
+// process when local is set
+func newShareConfigs(local bool) (shareConfigs, error) {
+	if !local {
+		return &daemonShareConfigs{client: daemon.NewClient(config.GetConfig())}, nil
+	}
+
+	manager, err := newLocalManager()
+	if err != nil {
+		return nil, err
+	}
+	return &localShareConfigs{manager: manager}, nil
+}
+
+// daemonShareConfigs calls the daemon's shares API
+type daemonShareConfigs struct {
+	client *daemon.Client
+}
+
+func (s *daemonShareConfigs) Get(ctx context.Context, name string) (*smb.SMBShareConfig, error) {
+	var detail smb.SMBShareDetail
+	path := constants.APIShares + "/smb/" + url.PathEscape(name)
+	if err := s.client.Do(ctx, http.MethodGet, path, nil, &detail); err != nil {
+		return nil, err
+	}
+	return &detail.SMBShareConfig, nil
+}
+
+func (s *daemonShareConfigs) Update(ctx context.Context, config *smb.SMBShareConfig) error {
+	path := constants.APIShares + "/smb/" + url.PathEscape(config.Name)
+	return s.client.Do(ctx, http.MethodPut, path, config, nil)
+}
+
+// localShareConfigs uses an SMB manager of its own
+type localShareConfigs struct {
+	manager *smb.Manager
+}
+
+func (s *localShareConfigs) Get(ctx context.Context, name string) (*smb.SMBShareConfig, error) {
+	return s.manager.GetSMBShare(ctx, name)
+}
+
+func (s *localShareConfigs) Update(ctx context.Context, config *smb.SMBShareConfig) error {
+	return s.manager.UpdateShare(ctx, config.Name, config)
+}
+
+func newGuestCmd() *cobra.Command {
+	var (
+		local       bool
+		disable     bool
+		guestOnly   bool
+		fixPerms    bool
+		acknowledge bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "guest <name>",
+		Short: "Enable or disable guest access to an SMB share",
+		Long: `Let anyone who can reach the server use an SMB share without credentials.
+Enabling it requires --i-understand-guest-is-insecure; --disable turns it off.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !disable && !acknowledge {
+				return fmt.Errorf("guest access lets anyone on the network use share %s; "+
+					"pass --i-understand-guest-is-insecure to enable it", args[0])
+			}
+
+			s, err := newShareConfigs(local)
+			if err != nil {
+				return err
+			}
+			ctx := context.Background()
+			share, err := s.Get(ctx, args[0])
+			if err != nil {
+				return fmt.Errorf("failed to get share %s: %w", args[0], err)
+			}
+
+			if disable {
+				share.GuestAccess = nil
+			} else {
+				share.GuestAccess = &smb.SMBGuestAccess{
+					Enabled:             true,
+					GuestOnly:           guestOnly,
+					FixPermissions:      fixPerms,
+					AcknowledgeInsecure: acknowledge,
+				}
+			}
+			if err := s.Update(ctx, share); err != nil {
+				return fmt.Errorf("failed to update share %s: %w", args[0], err)
+			}
+
+			if disable {
+				fmt.Printf("Guest access to share %s disabled\n", args[0])
+			} else {
+				fmt.Printf("Guest access to share %s enabled\n", args[0])
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&acknowledge, "i-understand-guest-is-insecure", false,
+		"Confirm that anyone on the network may use the share")
+	cmd.Flags().BoolVar(&disable, "disable", false, "Turn guest access off")
+	cmd.Flags().BoolVar(&guestOnly, "guest-only", false, "Map every connection to the guest account")
+	cmd.Flags().BoolVar(&fixPerms, "fix-permissions", false, "Make the share path owned by the guest account")
+	cmd.Flags().BoolVar(&local, "local", false, "Run without the daemon, for when it is not running")
+	cmd.MarkFlagsMutuallyExclusive("disable", "guest-only")
+	cmd.MarkFlagsMutuallyExclusive("disable", "fix-permissions")
+
+	return cmd
+}
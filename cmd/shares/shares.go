@@ -0,0 +1,58 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shares
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+func NewSharesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "shares",
+		Short: "File share helpers",
+	}
+
+	cmd.AddCommand(newTrashCmd())
+	cmd.AddCommand(newRestoreCmd())
+	cmd.AddCommand(newGuestCmd())
+	cmd.AddCommand(newVirusReportCmd())
+
+	return cmd
+}
+
+// newVirusReportCmd is run by smbd as the vfs_virusfilter infected file command
+func newVirusReportCmd() *cobra.Command {
+	var spoolDir string
+
+	cmd := &cobra.Command{
+		Use:    "virus-report",
+		Short:  "Report an infected file detected by vfs_virusfilter",
+		Long:   `Reads the detection from the VIRUSFILTER_* environment set by smbd and queues it for the Rodent daemon`,
+		Hidden: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return smb.WriteVirusEvent(spoolDir, smb.VirusEventFromEnv(os.Getenv))
+		},
+	}
+
+	cmd.Flags().StringVar(&spoolDir, "spool-dir", smb.VirusEventSpoolDir, "Directory the daemon reads detections from")
+
+	return cmd
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package shares
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+// shareTrash lists and restores deleted shares, either through the daemon
+// or, with --local, in this process
+type shareTrash interface {
+	List(ctx context.Context) ([]smb.TrashedShare, error)
+	Restore(ctx context.Context, name, id string) error
+}
+
+// newShareTrash returns the daemon's shares API, or an SMB manager of this
+// process when local is set
+func newShareTrash(local bool) (shareTrash, error) {
+	if !local {
+		return &daemonShareTrash{client: daemon.NewClient(config.GetConfig())}, nil
+	}
+
+	manager, err := newLocalManager()
+	if err != nil {
+		return nil, err
+	}
+	return &localShareTrash{manager: manager}, nil
+}
+
+// newLocalManager returns an SMB manager of this process, for commands run
+// with --local
+func newLocalManager() (*smb.Manager, error) {
+	l, err := logger.NewTag(config.NewLoggerConfig(config.GetConfig()), "shares")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create logger: %w", err)
+	}
+	manager, err := smb.NewManager(l, command.NewCommandExecutor(true), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create SMB manager: %w", err)
+	}
+	return manager, nil
+}
+
+// daemonShareTrash calls the daemon's shares API
+type daemonShareTrash struct {
+	client *daemon.Client
+}
+
+func (t *daemonShareTrash) List(ctx context.Context) ([]smb.TrashedShare, error) {
+	var resp struct {
+		Shares []smb.TrashedShare `json:"shares"`
+	}
+	err := t.client.Do(ctx, http.MethodGet, constants.APIShares+"/smb/trash", nil, &resp)
+	return resp.Shares, err
+}
+
+func (t *daemonShareTrash) Restore(ctx context.Context, name, id string) error {
+	path := constants.APIShares + "/smb/" + url.PathEscape(name) + "/restore"
+	if id != "" {
+		path += "?id=" + url.QueryEscape(id)
+	}
+	return t.client.Do(ctx, http.MethodPost, path, nil, nil)
+}
+
+// localShareTrash uses an SMB manager of its own
+type localShareTrash struct {
+	manager *smb.Manager
+}
+
+func (t *localShareTrash) List(ctx context.Context) ([]smb.TrashedShare, error) {
+	return t.manager.ListTrashedShares(ctx)
+}
+
+func (t *localShareTrash) Restore(ctx context.Context, name, id string) error {
+	_, err := t.manager.RestoreShare(ctx, name, id)
+	return err
+}
+
+func newTrashCmd() *cobra.Command {
+	var local bool
+
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "List deleted SMB shares that can be restored",
+		Long:  `List the SMB shares deleted within the trash retention (trash.retention), newest first`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := newShareTrash(local)
+			if err != nil {
+				return err
+			}
+
+			trashed, err := t.List(context.Background())
+			if err != nil {
+				return fmt.Errorf("failed to list deleted shares: %w", err)
+			}
+
+			fmt.Printf("%-24s %-36s %-20s %-20s %s\n", "NAME", "ID", "DELETED", "EXPIRES", "DELETED BY")
+			for _, s := range trashed {
+				by := s.DeletedBy.User
+				if by == "" {
+					by = s.DeletedBy.Source
+				}
+				if by == "" {
+					by = "-"
+				}
+				fmt.Printf("%-24s %-36s %-20s %-20s %s\n",
+					s.Name,
+					s.ID,
+					s.DeletedAt.Local().Format(time.DateTime),
+					s.ExpiresAt.Local().Format(time.DateTime),
+					by)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&local, "local", false, "Run without the daemon, for when it is not running")
+
+	return cmd
+}
+
+func newRestoreCmd() *cobra.Command {
+	var (
+		local bool
+		id    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Restore a deleted SMB share",
+		Long: `Recreate a deleted SMB share from the trash, with its revision history.
+The most recent deletion of the name is restored unless --id picks another.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			t, err := newShareTrash(local)
+			if err != nil {
+				return err
+			}
+
+			if err := t.Restore(context.Background(), args[0], id); err != nil {
+				return fmt.Errorf("failed to restore share %s: %w", args[0], err)
+			}
+
+			fmt.Printf("Share %s restored\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&id, "id", "", "Trash entry to restore, as listed by 'rodent shares trash'")
+	cmd.Flags().BoolVar(&local, "local", false, "Run without the daemon, for when it is not running")
+
+	return cmd
+}
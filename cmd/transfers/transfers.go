@@ -0,0 +1,193 @@
+/*
+ * Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+ * Copyright 2025 The StrataSTOR Authors and Contributors
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package transfers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/daemon"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+const (
+	// progressRequestTimeout bounds one progress request to the daemon
+	progressRequestTimeout = 10 * time.Second
+
+	// stallWarning is how long a running transfer may send nothing before
+	// watch points it out
+	stallWarning = time.Minute
+)
+
+func NewTransfersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transfers",
+		Short: "Follow ZFS transfers",
+	}
+
+	cmd.AddCommand(newWatchCmd())
+
+	return cmd
+}
+
+// progressSource reads the progress of a transfer, either through the
+// daemon or, with --local, from the transfers directory
+type progressSource func(ctx context.Context, transferID string) (*dataset.TransferProgressReport, error)
+
+func newProgressSource(local bool) progressSource {
+	if local {
+		return func(_ context.Context, transferID string) (*dataset.TransferProgressReport, error) {
+			return dataset.ReadProgressFile(config.GetTransfersDir(), transferID)
+		}
+	}
+
+	client := daemon.NewClient(config.GetConfig())
+	return func(ctx context.Context, transferID string) (*dataset.TransferProgressReport, error) {
+		var resp struct {
+			Result *dataset.TransferProgressReport `json:"result"`
+		}
+		path := constants.APIDataset + "/transfer/" + url.PathEscape(transferID) + "/progress"
+		if err := client.Do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+			return nil, err
+		}
+		return resp.Result, nil
+	}
+}
+
+func newWatchCmd() *cobra.Command {
+	var (
+		local        bool
+		interval     time.Duration
+		showDatasets bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch <transfer-id>",
+		Short: "Follow the progress of a transfer until it ends",
+		Long: `Print the progress of a transfer, its phase, bytes sent, rate and ETA,
+and for replication streams the snapshot being sent and how many datasets
+are done, until the transfer completes, fails or is paused. Add --datasets
+to list each dataset of a replication stream.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			transferID := args[0]
+			source := newProgressSource(local)
+			out := cmd.OutOrStdout()
+
+			for {
+				ctx, cancel := context.WithTimeout(cmd.Context(), progressRequestTimeout)
+				report, err := source(ctx, transferID)
+				cancel()
+				if err != nil {
+					return fmt.Errorf("failed to get progress of transfer %s: %w", transferID, err)
+				}
+
+				printProgress(out, report)
+				if showDatasets {
+					printDatasets(out, report.Datasets)
+				}
+				if !inProgress(report.Status) {
+					return nil
+				}
+
+				select {
+				case <-cmd.Context().Done():
+					return nil
+				case <-time.After(interval):
+				}
+			}
+		},
+	}
+
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "How often progress is read")
+	cmd.Flags().BoolVar(&local, "local", false, "Read the saved progress without the daemon")
+	cmd.Flags().BoolVar(&showDatasets, "datasets", false, "List each dataset of a replication stream")
+
+	return cmd
+}
+
+// inProgress reports whether a transfer in status may still make progress
+func inProgress(status dataset.TransferStatus) bool {
+	return status == dataset.TransferStatusStarting || status == dataset.TransferStatusRunning
+}
+
+// printProgress prints one line of progress
+func printProgress(w io.Writer, report *dataset.TransferProgressReport) {
+	parts := []string{string(report.Status)}
+	if report.Phase != "" {
+		parts = append(parts, report.Phase)
+	}
+
+	sent := common.FormatBytes(report.BytesTransferred)
+	if report.TotalBytes > 0 {
+		parts = append(parts, fmt.Sprintf("%5.1f%%", report.PercentComplete))
+		sent += " / " + common.FormatBytes(report.TotalBytes)
+	}
+	parts = append(parts, sent)
+
+	if report.TransferRate > 0 {
+		parts = append(parts, common.FormatBytes(report.TransferRate)+"/s")
+	}
+	if report.EstimatedETA > 0 {
+		parts = append(parts, "ETA "+(time.Duration(report.EstimatedETA)*time.Second).String())
+	}
+	if report.CurrentSnapshot != "" {
+		current := report.CurrentSnapshot
+		if report.SnapshotsTotal > 1 {
+			current += fmt.Sprintf(" (%d/%d)", report.SnapshotsSent+1, report.SnapshotsTotal)
+		}
+		parts = append(parts, current)
+	}
+	if len(report.Datasets) > 0 {
+		sent := 0
+		for _, ds := range report.Datasets {
+			if ds.State == dataset.DatasetProgressSent {
+				sent++
+			}
+		}
+		parts = append(parts, fmt.Sprintf("datasets %d/%d", sent, len(report.Datasets)))
+	}
+	if report.LastAdvanceAt != nil && inProgress(report.Status) {
+		if idle := time.Since(*report.LastAdvanceAt); idle >= stallWarning {
+			parts = append(parts, "no data sent for "+idle.Round(time.Second).String())
+		}
+	}
+
+	fmt.Fprintf(w, "%s  %s\n", time.Now().Format(time.TimeOnly), strings.Join(parts, "  "))
+}
+
+// printDatasets prints the progress of each dataset of a replication stream
+func printDatasets(w io.Writer, datasets []dataset.DatasetProgress) {
+	for _, ds := range datasets {
+		sent := common.FormatBytes(ds.BytesSent)
+		if ds.EstimatedBytes > 0 {
+			sent += " / " + common.FormatBytes(ds.EstimatedBytes)
+		}
+		fmt.Fprintf(w, "    %-8s %-40s %3d/%-3d %s\n",
+			ds.State, ds.Dataset, ds.SnapshotsSent, ds.SnapshotsTotal, sent)
+	}
+}
@@ -35,6 +35,20 @@ type Config struct {
 		Port      int    `mapstructure:"port"`
 		LogLevel  string `mapstructure:"logLevel"`
 		Daemonize bool   `mapstructure:"daemonize"`
+		DryRun    bool   `mapstructure:"dryRun"` // Log state-changing commands instead of running them
+
+		// Restrict the API to one IPv4 or IPv6 address, or to the addresses
+		// of one interface; unset listens on all. A bound API also listens
+		// on 127.0.0.1 for local clients.
+		BindAddress   string `mapstructure:"bindAddress"`
+		BindInterface string `mapstructure:"bindInterface"`
+
+		// Also serve the API on a Unix socket, for local clients such as
+		// the CLI. Root, the Rodent user and members of SocketGroup may
+		// connect; DisableTCP serves the API on the socket only.
+		Socket      string `mapstructure:"socket"`
+		SocketGroup string `mapstructure:"socketGroup"`
+		DisableTCP  bool   `mapstructure:"disableTCP"`
 	} `mapstructure:"server"`
 
 	Tunnel struct {
@@ -92,7 +106,27 @@ type Config struct {
 			Subnet          string `mapstructure:"subnet"`          // Subnet for macvlan mode (e.g., "172.31.0.0/20")
 			ShimIP          string `mapstructure:"shimIP"`          // IP for macvlan-shim interface (defaults to auto-assigned if empty)
 			AutoJoin        bool   `mapstructure:"autoJoin"`        // Automatically join domain after starting DC
+
+			HealthCheck struct {
+				Enabled  bool   `mapstructure:"enabled"`  // Periodically check replication, FSMO roles, sysvol and DNS
+				Interval string `mapstructure:"interval"` // Check interval (e.g., "15m")
+			} `mapstructure:"healthCheck"`
 		} `mapstructure:"dc"`
+
+		// Domain membership backend: "winbind" (default) or "sssd"
+		MembershipBackend string `mapstructure:"membershipBackend"`
+
+		// How net ads join/leave get the admin credentials: "kerberos"
+		// (default, kinit with the password on stdin) or "file" (a samba
+		// authentication file, for hosts without kinit)
+		AdminAuth string `mapstructure:"adminAuth"`
+
+		HealthCheck struct {
+			Enabled    bool   `mapstructure:"enabled"`    // Periodically verify domain membership
+			Interval   string `mapstructure:"interval"`   // Check interval (e.g., "1h")
+			AutoRepair bool   `mapstructure:"autoRepair"` // Rejoin with the machine keytab when the trust breaks
+		} `mapstructure:"healthCheck"`
+
 		External struct {
 			DomainControllers []string `mapstructure:"domainControllers"` // List of DC servers (IP or FQDN)
 			AdminUser         string   `mapstructure:"adminUser"`         // Admin username (default: "Administrator")
@@ -114,9 +148,103 @@ type Config struct {
 			// Toggle hardcodes ~/.rodent/ssh/<peeringID>/id_ed25519 for transfers.
 			// See references.go GetSSHDir() and GetKnownHostsFilePath() for paths.
 			AuthorizedKeysFile string `mapstructure:"authorizedKeysFile"`
+
+			// Security is the policy for transfers over SSH and the keys
+			// generated for them
+			Security struct {
+				RequirePinnedHostKeys bool     `mapstructure:"requirePinnedHostKeys"` // Refuse remote hosts without a host key pinned for a peering
+				KeyTypes              []string `mapstructure:"keyTypes"`              // Key types allowed for generated key pairs
+				MinRSABits            int      `mapstructure:"minRSABits"`            // Minimum size of RSA keys, generated or pinned
+			} `mapstructure:"security"`
 		} `mapstructure:"ssh"`
 	} `mapstructure:"keys"`
 
+	Privilege struct {
+		Enforce      bool     `mapstructure:"enforce"`      // Reject sudo operations outside the privilege policy instead of only auditing them
+		AllowedPaths []string `mapstructure:"allowedPaths"` // Extra paths (glob patterns allowed) for privileged file operations
+	} `mapstructure:"privilege"`
+
+	// Commands bounds how long system commands may run
+	Commands struct {
+		Timeouts  map[string]string `mapstructure:"timeouts"`  // Timeouts by command class, e.g. "zfs list": "2m" or smbcontrol: "15s"; classes not listed keep their defaults
+		KillGrace string            `mapstructure:"killGrace"` // How long a timed out command has after SIGTERM before it is killed (e.g., "5s")
+	} `mapstructure:"commands"`
+
+	// Features turns whole subsystems on or off. A disabled subsystem is not
+	// initialized and its API answers with a feature-disabled error.
+	Features struct {
+		SMB         bool `mapstructure:"smb"`
+		SMBPrinting bool `mapstructure:"smbPrinting"` // Manage the [printers] and print$ sections instead of preserving them
+		NFS         bool `mapstructure:"nfs"`
+		ZFS         struct {
+			AutoSnapshots bool `mapstructure:"autosnapshots"` // Also required by auto-attach rules and transfer policies
+			AutoTransfers bool `mapstructure:"autotransfers"`
+		} `mapstructure:"zfs"`
+		Disks  bool `mapstructure:"disks"`
+		Domain bool `mapstructure:"domain"` // AD management API and domain membership checks
+		ADDC   bool `mapstructure:"addc"`   // Self-hosted AD DC container
+		API    bool `mapstructure:"api"`    // Local REST API; Toggle commands are unaffected
+	} `mapstructure:"features"`
+
+	SelfTest struct {
+		Enabled     bool              `mapstructure:"enabled"`     // Probe tools, kernel modules and permissions on startup
+		Enforce     bool              `mapstructure:"enforce"`     // Leave subsystems with missing dependencies disabled
+		MinVersions map[string]string `mapstructure:"minVersions"` // Minimum tool versions, keyed by binary name (e.g., zfs: "2.1.0")
+	} `mapstructure:"selfTest"`
+
+	// Debug exposes runtime profiling for troubleshooting
+	Debug struct {
+		Pprof     bool `mapstructure:"pprof"` // Serve /debug/pprof to loopback clients, such as a Toggle tunnel, with mutex and block profiling on
+		LockWatch struct {
+			Enabled   bool   `mapstructure:"enabled"`   // Log a warning with a goroutine dump when manager locks are contended
+			Interval  string `mapstructure:"interval"`  // How often lock waits are checked (e.g., "10s")
+			Threshold string `mapstructure:"threshold"` // Wait or write hold time that triggers a warning (e.g., "5s")
+		} `mapstructure:"lockWatch"`
+	} `mapstructure:"debug"`
+
+	// Webhooks lists endpoints that receive snapshot and transfer events
+	Webhooks struct {
+		Endpoints []WebhookEndpoint `mapstructure:"endpoints"`
+	} `mapstructure:"webhooks"`
+
+	// Guards are rules checked before destructive operations, such as
+	// dataset destroys, share deletes, policy removals and transfers
+	Guards struct {
+		Rules       []GuardRule `mapstructure:"rules"`
+		OverrideTTL string      `mapstructure:"overrideTTL"` // How long an override token is valid (e.g., "15m"), defaults to 15m
+	} `mapstructure:"guards"`
+
+	// Trash keeps deleted shares and snapshot policies for a while so they
+	// can be restored
+	Trash struct {
+		Retention string `mapstructure:"retention"` // How long deleted items are kept (e.g., "168h"); "0" deletes them outright
+	} `mapstructure:"trash"`
+
+	// Reports are digests of snapshot, transfer, scrub and disk activity,
+	// sent on a schedule by email or as webhook events
+	Reports struct {
+		SMTP    ReportSMTP     `mapstructure:"smtp"`
+		Digests []ReportDigest `mapstructure:"digests"`
+	} `mapstructure:"reports"`
+
+	// Consistency checks look for policies, shares and disks that
+	// reference objects which no longer exist
+	Consistency struct {
+		Interval string `mapstructure:"interval"` // Check interval (e.g., "6h"); "0" only checks on request
+	} `mapstructure:"consistency"`
+
+	// Triggers are API keys that let external systems, such as backup
+	// orchestrators and CI pipelines, run snapshot policies on demand
+	Triggers struct {
+		Keys []TriggerKey `mapstructure:"keys"`
+	} `mapstructure:"triggers"`
+
+	// Delegation gives API keys to administrators who may manage only the
+	// shares, datasets and snapshot policies named in their key
+	Delegation struct {
+		Keys []DelegatedKey `mapstructure:"keys"`
+	} `mapstructure:"delegation"`
+
 	Events struct {
 		Profile        string `mapstructure:"profile"`        // Event system profile: "default", "high-throughput", "low-latency", "minimal"
 		BufferSize     *int   `mapstructure:"bufferSize"`     // Max events held in memory before dropping (default: 20000)
@@ -135,6 +263,65 @@ type TunnelService struct {
 	Timeout        string   `mapstructure:"timeout"`        // Request timeout (e.g., "30s"), defaults to 30s
 }
 
+// WebhookEndpoint defines an HTTP endpoint that receives events as signed JSON POSTs
+type WebhookEndpoint struct {
+	Name        string   `mapstructure:"name"`        // Unique name, used in logs and the webhooks API
+	URL         string   `mapstructure:"url"`         // http(s) URL events are POSTed to
+	Secret      string   `mapstructure:"secret"`      // HMAC-SHA256 key for the X-Rodent-Signature header (empty: unsigned)
+	Events      []string `mapstructure:"events"`      // Event types to deliver, e.g. "snapshot.created" (empty: all)
+	Timeout     string   `mapstructure:"timeout"`     // Per-attempt request timeout (e.g., "10s"), defaults to 10s
+	MaxAttempts int      `mapstructure:"maxAttempts"` // Delivery attempts before giving up, defaults to 5
+}
+
+// TriggerKey is an API key accepted by the snapshot policy trigger endpoint
+type TriggerKey struct {
+	Name     string   `mapstructure:"name"`     // Unique name, recorded as the actor of triggered runs
+	Key      string   `mapstructure:"key"`      // Secret sent in the X-API-Key header or as a bearer token
+	Policies []string `mapstructure:"policies"` // Snapshot policy IDs the key may trigger (empty: all)
+}
+
+// DelegatedKey is an API key limited to the objects it names. Datasets
+// include their children, snapshots and bookmarks; policies are also
+// allowed when their dataset is.
+type DelegatedKey struct {
+	Name     string   `mapstructure:"name"`     // Unique name, recorded as the actor of the key's requests
+	Key      string   `mapstructure:"key"`      // Secret sent in the X-API-Key header or as a bearer token
+	Shares   []string `mapstructure:"shares"`   // SMB share names the key may manage
+	Datasets []string `mapstructure:"datasets"` // Datasets the key may manage, with their children
+	Policies []string `mapstructure:"policies"` // Snapshot policy IDs the key may manage
+}
+
+// ReportSMTP is the mail server digests are sent through
+type ReportSMTP struct {
+	Host     string `mapstructure:"host"`     // Mail server host name
+	Port     int    `mapstructure:"port"`     // Mail server port, defaults to 587
+	Username string `mapstructure:"username"` // Login for SMTP authentication (empty: none)
+	Password string `mapstructure:"password"` // Password for SMTP authentication
+	From     string `mapstructure:"from"`     // Sender address
+	TLS      bool   `mapstructure:"tls"`      // Connect with TLS, as on port 465; otherwise STARTTLS is used when offered
+}
+
+// ReportDigest defines a scheduled digest and who receives it
+type ReportDigest struct {
+	Name       string   `mapstructure:"name"`       // Unique name, used in logs and the reports API
+	Period     string   `mapstructure:"period"`     // "daily" or "weekly", defaults to daily
+	At         string   `mapstructure:"at"`         // Local time the digest is sent, "HH:MM", defaults to 07:00
+	Weekday    string   `mapstructure:"weekday"`    // Day weekly digests are sent, e.g. "monday", defaults to monday
+	Format     string   `mapstructure:"format"`     // Email body, "html" or "text", defaults to html
+	Recipients []string `mapstructure:"recipients"` // Email addresses the digest is sent to
+	Webhook    bool     `mapstructure:"webhook"`    // Also publish the digest as a report.digest webhook event
+}
+
+// GuardRule denies, or asks for confirmation of, operations on matching targets
+type GuardRule struct {
+	Name           string   `mapstructure:"name"`           // Unique name, reported when the rule blocks an operation
+	Operations     []string `mapstructure:"operations"`     // Operations the rule covers, e.g. "dataset.destroy" (empty: all)
+	Targets        []string `mapstructure:"targets"`        // Glob patterns of datasets, share names or policy IDs (empty: all)
+	MinFreePercent float64  `mapstructure:"minFreePercent"` // Only transfers whose target pool has less free space, in percent
+	Action         string   `mapstructure:"action"`         // "deny" (default) or "confirm"
+	Message        string   `mapstructure:"message"`        // Shown when the rule blocks an operation
+}
+
 // LoadConfig loads the configuration with precedence rules.
 func LoadConfig(configFilePath string) *Config {
 	once.Do(func() {
@@ -188,6 +375,10 @@ func LoadConfig(configFilePath string) *Config {
 		viper.SetDefault("server.port", 8042)
 		viper.SetDefault("server.logLevel", "debug")
 		viper.SetDefault("server.daemonize", false)
+		viper.SetDefault("server.dryRun", false)
+		viper.SetDefault("server.socket", "")
+		viper.SetDefault("server.socketGroup", "")
+		viper.SetDefault("server.disableTCP", false)
 		viper.SetDefault("health.interval", "30s")
 		viper.SetDefault("health.endpoint", "/health")
 		viper.SetDefault("logs.path", "/var/log/rodent/rodent.log")
@@ -207,6 +398,11 @@ func LoadConfig(configFilePath string) *Config {
 		viper.SetDefault("ad.userOU", "OU=StrataUsers")         // Will be appended to BaseDN
 		viper.SetDefault("ad.groupOU", "OU=StrataGroups")       // Will be appended to BaseDN
 		viper.SetDefault("ad.computerOU", "OU=StrataComputers") // Will be appended to BaseDN
+		viper.SetDefault("ad.membershipBackend", "winbind")
+		viper.SetDefault("ad.adminAuth", "kerberos")
+		viper.SetDefault("ad.healthCheck.enabled", true)
+		viper.SetDefault("ad.healthCheck.interval", "1h")
+		viper.SetDefault("ad.healthCheck.autoRepair", true)
 
 		// Set defaults for AD DC configuration
 		viper.SetDefault("ad.dc.enabled", false)
@@ -225,12 +421,61 @@ func LoadConfig(configFilePath string) *Config {
 		viper.SetDefault("ad.dc.subnet", "")
 		viper.SetDefault("ad.dc.shimIP", "")
 		viper.SetDefault("ad.dc.autoJoin", true)
+		viper.SetDefault("ad.dc.healthCheck.enabled", true)
+		viper.SetDefault("ad.dc.healthCheck.interval", "15m")
 
 		// Set defaults for external AD configuration
 		viper.SetDefault("ad.external.domainControllers", []string{})
 		viper.SetDefault("ad.external.adminUser", "Administrator")
 		viper.SetDefault("ad.external.autoJoin", false)
 
+		// Privileged operations outside the policy are refused; set enforce to
+		// false to only audit them
+		viper.SetDefault("privilege.enforce", true)
+		viper.SetDefault("privilege.allowedPaths", []string{})
+
+		// Commands use the timeouts of their class unless overridden
+		viper.SetDefault("commands.timeouts", map[string]string{})
+		viper.SetDefault("commands.killGrace", "5s")
+
+		// All subsystems are enabled unless a vendor turns them off
+		viper.SetDefault("features.smb", true)
+		viper.SetDefault("features.nfs", true)
+		viper.SetDefault("features.zfs.autosnapshots", true)
+		viper.SetDefault("features.zfs.autotransfers", true)
+		viper.SetDefault("features.disks", true)
+		viper.SetDefault("features.domain", true)
+		viper.SetDefault("features.addc", true)
+		viper.SetDefault("features.api", true)
+		// Print services are rare on storage servers and opt-in
+		viper.SetDefault("features.smbPrinting", false)
+
+		// Subsystems whose dependencies are missing are not started
+		viper.SetDefault("selfTest.enabled", true)
+		viper.SetDefault("selfTest.enforce", true)
+		viper.SetDefault("selfTest.minVersions", map[string]string{
+			"zfs":      "2.1.0",
+			"smartctl": "7.0",
+		})
+
+		// Profiling is opt-in; lock contention warnings are cheap and on
+		viper.SetDefault("debug.pprof", false)
+		viper.SetDefault("debug.lockWatch.enabled", true)
+		viper.SetDefault("debug.lockWatch.interval", "10s")
+		viper.SetDefault("debug.lockWatch.threshold", "5s")
+
+		// Guard rules are opt-in; override tokens are short-lived
+		viper.SetDefault("guards.overrideTTL", "15m")
+
+		// Deleted shares and policies can be restored for a week
+		viper.SetDefault("trash.retention", "168h")
+
+		// Digests are opt-in; submission is the usual mail port
+		viper.SetDefault("reports.smtp.port", 587)
+
+		// Dangling references are looked for a few times a day
+		viper.SetDefault("consistency.interval", "6h")
+
 		// Set defaults for Toggle configuration
 		viper.SetDefault("toggle.enabled", true)
 		viper.SetDefault("toggle.jwt", "")
@@ -242,6 +487,9 @@ func LoadConfig(configFilePath string) *Config {
 
 		// Set defaults for SSH keys (paths are hardcoded in references.go, not configurable)
 		viper.SetDefault("keys.ssh.authorizedKeysFile", "~/.ssh/authorized_keys")
+		viper.SetDefault("keys.ssh.security.requirePinnedHostKeys", false)
+		viper.SetDefault("keys.ssh.security.keyTypes", []string{"ed25519", "rsa"})
+		viper.SetDefault("keys.ssh.security.minRSABits", 3072)
 
 		viper.SetDefault("development.enabled", false)
 
@@ -340,6 +588,17 @@ func LoadConfig(configFilePath string) *Config {
 		// Log config values for debugging (redact sensitive data)
 		debugCfg := *instance
 		debugCfg.AD.AdminPassword = "[REDACTED]"
+		debugCfg.Reports.SMTP.Password = "[REDACTED]"
+		debugCfg.Triggers.Keys = make([]TriggerKey, len(instance.Triggers.Keys))
+		for i, k := range instance.Triggers.Keys {
+			k.Key = "[REDACTED]"
+			debugCfg.Triggers.Keys[i] = k
+		}
+		debugCfg.Delegation.Keys = make([]DelegatedKey, len(instance.Delegation.Keys))
+		for i, k := range instance.Delegation.Keys {
+			k.Key = "[REDACTED]"
+			debugCfg.Delegation.Keys[i] = k
+		}
 		if debug {
 			l.Debug("Loaded configuration", "config", fmt.Sprintf("%+v", debugCfg))
 		}
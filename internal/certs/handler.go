@@ -7,13 +7,15 @@
 package certs
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
-	"strings"
 
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/system/privilege"
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
 	"github.com/stratastor/toggle-rodent-proto/proto"
@@ -49,35 +51,39 @@ func handleCertDeliver() client.CommandHandler {
 			payload.DestDir = "/etc/dremio/tls"
 		}
 
-		// Ensure destination directory exists (Rodent runs as 'rodent' user, need sudo)
-		if err := exec.Command("sudo", "mkdir", "-p", payload.DestDir).Run(); err != nil {
+		if command.SkipInDryRun(nil, "tee", []string{
+			filepath.Join(payload.DestDir, "cert.pem"),
+			filepath.Join(payload.DestDir, "key.pem"),
+		}) {
+			return &proto.CommandResponse{
+				RequestId: req.RequestId,
+				Success:   true,
+				Message:   fmt.Sprintf("Dry run: certificate for %s not delivered to %s", payload.Domain, payload.DestDir),
+			}, nil
+		}
+
+		// Rodent runs as the 'rodent' user; the privilege broker checks the
+		// paths the certificate is written to
+		ctx := context.Background()
+		executor := command.NewCommandExecutor(true)
+		fileOps := privilege.NewSudoFileOperations(common.Log, executor, privilege.AllowedPaths())
+		if _, err := executor.ExecuteWithCombinedOutput(ctx, "mkdir", "-p", payload.DestDir); err != nil {
 			return nil, errors.Wrap(err, errors.ServerInternalError)
 		}
 
 		certPath := filepath.Join(payload.DestDir, "cert.pem")
 		keyPath := filepath.Join(payload.DestDir, "key.pem")
 
-		// Write via sudo tee (Rodent user can't write to /etc/dremio/tls directly)
-		writePEM := func(path, content, mode string) error {
-			cmd := exec.Command("sudo", "tee", path)
-			cmd.Stdin = strings.NewReader(content)
-			cmd.Stdout = nil // discard tee's stdout copy
-			if err := cmd.Run(); err != nil {
-				return err
-			}
-			return exec.Command("sudo", "chmod", mode, path).Run()
-		}
-
-		if err := writePEM(certPath, payload.CertPEM, "0644"); err != nil {
+		if err := fileOps.WriteFile(ctx, certPath, []byte(payload.CertPEM), 0644); err != nil {
 			return nil, errors.Wrap(err, errors.ServerInternalError)
 		}
-		if err := writePEM(keyPath, payload.KeyPEM, "0600"); err != nil {
+		if err := fileOps.WriteFile(ctx, keyPath, []byte(payload.KeyPEM), 0600); err != nil {
 			return nil, errors.Wrap(err, errors.ServerInternalError)
 		}
 
 		// Traefik watches the file directory and auto-reloads when certs change.
 		// Signal as a safety net — Docker volume mount picks up changes automatically.
-		_ = exec.Command("sudo", "docker", "kill", "--signal=HUP", "traefik-traefik-1").Run()
+		_, _ = executor.ExecuteWithCombinedOutput(ctx, "docker", "kill", "--signal=HUP", "traefik-traefik-1")
 
 		resp := map[string]interface{}{
 			"domain":    payload.Domain,
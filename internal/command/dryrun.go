@@ -0,0 +1,171 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/common"
+)
+
+// dryRun is set when Rodent runs with --dry-run or server.dryRun
+var dryRun atomic.Bool
+
+// SetDryRun enables or disables dry-run mode. In dry-run mode commands that
+// change system state are logged instead of run and report success with
+// empty output; read-only commands still run so callers see real state.
+func SetDryRun(enabled bool) {
+	dryRun.Store(enabled)
+}
+
+// DryRun reports whether dry-run mode is enabled
+func DryRun() bool {
+	return dryRun.Load()
+}
+
+// readOnlyCommands never change system state, whatever their arguments
+var readOnlyCommands = []string{
+	"cat", "test", "grep", "ls", "stat", "df", "findmnt", "id", "getent",
+	"lsblk", "lscpu", "lsscsi", "uname", "uptime", "last", "which", "groups",
+	"hostname", "dmidecode", "systemd-detect-virt", "journalctl", "getfacl",
+	"smbstatus", "testparm", "wbinfo", "klist", "smartctl", "ssh-keyscan",
+}
+
+// readOnlySubcommands lists, per command, the subcommands that only read
+// system state. The subcommand is the first argument that is not an option.
+var readOnlySubcommands = map[string][]string{
+	"zfs": {
+		"list", "get", "holds", "diff", "userspace", "groupspace",
+		"projectspace", "version",
+	},
+	"zpool": {"list", "get", "status", "iostat", "history", "events", "version"},
+	"systemctl": {
+		"status", "show", "cat", "is-enabled", "is-active", "is-failed",
+		"is-system-running", "list-units", "list-unit-files", "list-timers",
+	},
+	"docker":      {"ps", "inspect", "logs", "images", "info", "version"},
+	"netplan":     {"get", "info"},
+	"networkctl":  {"list", "status", "lldp"},
+	"resolvectl":  {"status", "query", "statistics"},
+	"hostnamectl": {"status"},
+	"timedatectl": {"status", "show", "list-timezones", "timesync-status"},
+	"localectl":   {"status", "list-locales", "list-keymaps"},
+	"udevadm":     {"info", "monitor"},
+	"sssctl":      {"domain-list", "domain-status", "user-checks", "config-check"},
+	"openssl":     {"x509", "verify", "version", "s_client"},
+	"samba-tool":  {"testparm"},
+}
+
+// readOnlyNestedSubcommands covers commands whose read-only verbs are a
+// level below the subcommand, keyed by "command subcommand"
+var readOnlyNestedSubcommands = map[string][]string{
+	"net ads":             {"info", "testjoin", "status", "lookup", "search", "dn", "workgroup"},
+	"docker compose":      {"ps", "config", "logs", "images", "ls", "version"},
+	"samba-tool user":     {"list", "show", "getgroups"},
+	"samba-tool group":    {"list", "show", "listmembers"},
+	"samba-tool computer": {"list", "show"},
+	"samba-tool drs":      {"showrepl"},
+	"samba-tool fsmo":     {"show"},
+	"samba-tool ntacl":    {"get", "sysvolcheck"},
+	"samba-tool domain":   {"info", "level", "passwordsettings"},
+}
+
+// ipReadOnlyVerbs are the ip object verbs that only display state. An ip
+// command without a verb, such as 'ip -j addr', shows the object as well.
+var ipReadOnlyVerbs = []string{"show", "list", "lst", "get"}
+
+// IsReadOnly reports whether running cmd with args only reads system state.
+// Commands it does not know are treated as changing state. Wrappers such as
+// sudo, env and 'docker exec' are looked through.
+func IsReadOnly(cmd string, args []string) bool {
+	name := filepath.Base(cmd)
+
+	switch name {
+	case "sudo":
+		if len(args) == 0 {
+			return true
+		}
+		return IsReadOnly(args[0], args[1:])
+	case "env":
+		for i, arg := range args {
+			if !strings.Contains(arg, "=") {
+				return IsReadOnly(arg, args[i+1:])
+			}
+		}
+		return true
+	}
+
+	if slices.Contains(readOnlyCommands, name) {
+		return true
+	}
+
+	operands := positionalArgs(args)
+
+	switch name {
+	case "ip":
+		for _, operand := range operands[min(1, len(operands)):] {
+			if slices.Contains(ipReadOnlyVerbs, operand) {
+				return true
+			}
+		}
+		return len(operands) <= 1
+	case "pdbedit":
+		return slices.Contains(args, "-L") || slices.Contains(args, "--list")
+	case "docker":
+		// 'docker exec <container> <cmd>' runs cmd in the container; the
+		// container name is the first operand after the subcommand
+		if len(operands) >= 3 && operands[0] == "exec" {
+			i := slices.Index(args, operands[2])
+			return IsReadOnly(operands[2], args[i+1:])
+		}
+	}
+
+	if len(operands) == 0 {
+		return false
+	}
+	if subcommands, ok := readOnlySubcommands[name]; ok && slices.Contains(subcommands, operands[0]) {
+		return true
+	}
+	if len(operands) > 1 {
+		nested := readOnlyNestedSubcommands[name+" "+operands[0]]
+		if slices.Contains(nested, operands[1]) {
+			return true
+		}
+	}
+
+	// samba-tool dbcheck only repairs the database with --fix
+	return name == "samba-tool" && operands[0] == "dbcheck" && !slices.Contains(args, "--fix")
+}
+
+// positionalArgs returns the arguments that are not options
+func positionalArgs(args []string) []string {
+	operands := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg != "" && !strings.HasPrefix(arg, "-") {
+			operands = append(operands, arg)
+		}
+	}
+	return operands
+}
+
+// SkipInDryRun reports whether a command must not run because dry-run mode is
+// enabled and the command changes state. Skipped commands are logged with
+// their secrets redacted.
+func SkipInDryRun(l logger.Logger, cmd string, args []string) bool {
+	if !DryRun() || IsReadOnly(cmd, args) {
+		return false
+	}
+
+	if l == nil {
+		l = common.Log
+	}
+	l.Info("Dry run: would execute command",
+		"cmd", cmd+" "+strings.Join(RedactArgs(args), " "))
+	return true
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		args []string
+		want bool
+	}{
+		{"zfs", []string{"list", "-H", "-o", "name"}, true},
+		{"/usr/sbin/zfs", []string{"get", "-j", "all", "tank"}, true},
+		{"zfs", []string{"destroy", "-r", "tank/data"}, false},
+		{"sudo", []string{"zpool", "status", "tank"}, true},
+		{"sudo", []string{"zpool", "create", "tank", "sdb"}, false},
+		{"systemctl", []string{"--no-pager", "status", "smbd"}, true},
+		{"systemctl", []string{"restart", "smbd"}, false},
+		{"ip", []string{"-j", "addr", "show", "dev", "eth0"}, true},
+		{"ip", []string{"-j", "link"}, true},
+		{"ip", []string{"addr", "add", "10.0.0.2/24", "dev", "eth0"}, false},
+		{"env", []string{"LC_ALL=C", "lsblk", "-J"}, true},
+		{"docker", []string{"exec", "dc", "samba-tool", "drs", "showrepl", "--summary"}, true},
+		{"docker", []string{"exec", "dc", "samba-tool", "user", "create", "alice"}, false},
+		{"docker", []string{"exec", "dc", "samba-tool", "dbcheck", "--cross-ncs"}, true},
+		{"docker", []string{"exec", "dc", "samba-tool", "dbcheck", "--cross-ncs", "--fix"}, false},
+		{"docker", []string{"compose", "-f", "dc.yml", "up", "-d"}, false},
+		{"net", []string{"ads", "testjoin"}, true},
+		{"net", []string{"ads", "join", "-U", "admin"}, false},
+		{"pdbedit", []string{"-L", "-v"}, true},
+		{"useradd", []string{"bob"}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsReadOnly(tt.cmd, tt.args), "%s %s", tt.cmd, strings.Join(tt.args, " "))
+	}
+}
+
+func TestExecuteDryRun(t *testing.T) {
+	SetDryRun(true)
+	defer SetDryRun(false)
+
+	// A state-changing command is skipped and reports success
+	executor := NewCommandExecutor(false)
+	output, err := executor.Execute(context.Background(), "rm", "-rf", "/nonexistent/rodent-dry-run")
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	// A read-only command still runs
+	output, err = executor.Execute(context.Background(), "uname")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, output)
+}
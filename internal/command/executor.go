@@ -21,9 +21,6 @@ import (
 // Dangerous characters that could enable command injection
 var dangerousChars = "&|><$`\\[];{}"
 
-// Command execution timeout
-const defaultCommandTimeout = 30 * time.Second
-
 // ExecCommand executes a system command with proper security checks
 func ExecCommand(
 	ctx context.Context,
@@ -41,25 +38,44 @@ func ExecCommand(
 	}
 
 	// Apply timeout if not already set
-	var cancel context.CancelFunc
-	if _, ok := ctx.Deadline(); !ok {
-		ctx, cancel = context.WithTimeout(ctx, defaultCommandTimeout)
+	timeout := CommandTimeout(name, args)
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
 		defer cancel()
 	}
 
+	if SkipInDryRun(logger, name, args) {
+		return nil, nil
+	}
+
 	// Log the command being executed
-	cmdString := name + " " + strings.Join(args, " ")
+	cmdString := strings.Join(RedactArgs(append([]string{name}, args...)), " ")
 	logger.Debug("Executing command", "cmd", cmdString)
 
 	// Create command with context for cancellation support
-	cmd := exec.CommandContext(ctx, name, args...)
+	cmd, release := GroupCommand(ctx, name, args...)
 
 	// Prevent shell expansion
 	cmd.Env = []string{}
 
 	// Execute the command
+	start := time.Now()
 	output, err := cmd.CombinedOutput()
+	release()
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			logger.Error("Command execution timed out",
+				"cmd", cmdString,
+				"timeout", timeout,
+				"output", string(output))
+
+			return output, TimeoutError(cmdString, timeout, time.Since(start)).
+				WithMetadata("output", string(output))
+		}
+
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			logger.Error("Command execution failed with exit code",
@@ -130,6 +146,10 @@ func validateCommand(name string, args []string) error {
 // CommandExecutor provides a general-purpose command execution service
 type CommandExecutor struct {
 	UseSudo bool
+
+	// Timeout bounds commands the caller has not set a deadline for. Zero
+	// uses the default timeout of each command's class, and NoTimeout
+	// leaves commands to the caller's context.
 	Timeout time.Duration
 	WorkDir string
 	Env     []string
@@ -139,30 +159,22 @@ type CommandExecutor struct {
 func NewCommandExecutor(useSudo bool) *CommandExecutor {
 	return &CommandExecutor{
 		UseSudo: useSudo,
-		Timeout: 30 * time.Second,
 	}
 }
 
 // Execute runs a command and returns its output
 func (e *CommandExecutor) Execute(ctx context.Context, cmd string, args ...string) ([]byte, error) {
 	// Apply timeout if not already set in context
-	if _, ok := ctx.Deadline(); !ok && e.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
-		defer cancel()
-	}
+	ctx, cancel, timeout := e.withTimeout(ctx, cmd, args)
+	defer cancel()
 
-	// Prepend sudo if needed
-	cmdArgs := make([]string, 0, len(args)+1)
-	if e.UseSudo {
-		cmdArgs = append(cmdArgs, "sudo", cmd)
-	} else {
-		cmdArgs = append(cmdArgs, cmd)
+	run, audit, err := PrepareRun(ctx, nil, e.UseSudo, cmd, args)
+	if !run {
+		return nil, err
 	}
-	cmdArgs = append(cmdArgs, args...)
 
-	// Create command
-	execCmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	// Create command, with sudo if needed
+	execCmd, release := e.command(ctx, cmd, args)
 	execCmd.Env = append(execCmd.Env, e.Env...)
 	if e.WorkDir != "" {
 		execCmd.Dir = e.WorkDir
@@ -174,8 +186,18 @@ func (e *CommandExecutor) Execute(ctx context.Context, cmd string, args ...strin
 	execCmd.Stderr = &stderr
 
 	// Execute command
-	err := execCmd.Run()
+	start := time.Now()
+	err = execCmd.Run()
+	release()
+	audit(time.Since(start), err)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return stderr.Bytes(), e.timeoutError(cmd, args, timeout, time.Since(start)).
+				WithMetadata("stderr", stderr.String())
+		}
+		if sudoErr := e.sudoFailure(cmd, stderr.String()); sudoErr != nil {
+			return stderr.Bytes(), sudoErr
+		}
 		return stderr.Bytes(), fmt.Errorf("command failed: %w: %s", err, stderr.String())
 	}
 
@@ -187,42 +209,70 @@ func (e *CommandExecutor) ExecuteWithCombinedOutput(
 	ctx context.Context,
 	cmd string,
 	args ...string,
+) ([]byte, error) {
+	return e.executeCombined(ctx, "", cmd, args...)
+}
+
+// ExecuteWithInput runs a command with input written to its stdin and returns
+// combined stdout/stderr. Use it for secrets that must not appear in the
+// process arguments.
+func (e *CommandExecutor) ExecuteWithInput(
+	ctx context.Context,
+	input string,
+	cmd string,
+	args ...string,
+) ([]byte, error) {
+	return e.executeCombined(ctx, input, cmd, args...)
+}
+
+func (e *CommandExecutor) executeCombined(
+	ctx context.Context,
+	input string,
+	cmd string,
+	args ...string,
 ) ([]byte, error) {
 	// Apply timeout if not already set in context
-	if _, ok := ctx.Deadline(); !ok && e.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, e.Timeout)
-		defer cancel()
-	}
+	ctx, cancel, timeout := e.withTimeout(ctx, cmd, args)
+	defer cancel()
 
-	// Prepend sudo if needed
-	cmdArgs := make([]string, 0, len(args)+1)
-	if e.UseSudo {
-		cmdArgs = append(cmdArgs, "sudo", cmd)
-	} else {
-		cmdArgs = append(cmdArgs, cmd)
+	run, audit, err := PrepareRun(ctx, nil, e.UseSudo, cmd, args)
+	if !run {
+		return nil, err
 	}
-	cmdArgs = append(cmdArgs, args...)
 
-	// Create command
-	execCmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	// Create command, with sudo if needed
+	execCmd, release := e.command(ctx, cmd, args)
 	execCmd.Env = append(execCmd.Env, e.Env...)
 	if e.WorkDir != "" {
 		execCmd.Dir = e.WorkDir
 	}
 
+	if input != "" {
+		execCmd.Stdin = strings.NewReader(input)
+	}
+
 	// Capture combined output
 	var combinedOutput bytes.Buffer
 	execCmd.Stdout = &combinedOutput
 	execCmd.Stderr = &combinedOutput
 
 	// Execute command
-	err := execCmd.Run()
+	start := time.Now()
+	err = execCmd.Run()
+	release()
+	audit(time.Since(start), err)
 	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return combinedOutput.Bytes(), e.timeoutError(cmd, args, timeout, time.Since(start)).
+				WithMetadata("output", combinedOutput.String())
+		}
+		if sudoErr := e.sudoFailure(cmd, combinedOutput.String()); sudoErr != nil {
+			return combinedOutput.Bytes(), sudoErr
+		}
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			return combinedOutput.Bytes(), rterrors.NewCommandError(
-				cmd+" "+strings.Join(args, " "),
+				strings.Join(RedactArgs(append([]string{cmd}, args...)), " "),
 				exitErr.ExitCode(),
 				combinedOutput.String(),
 			)
@@ -236,3 +286,54 @@ func (e *CommandExecutor) ExecuteWithCombinedOutput(
 
 	return combinedOutput.Bytes(), nil
 }
+
+// command creates the process for cmd, run through sudo as the installed
+// guard routes it when the executor uses sudo
+func (e *CommandExecutor) command(ctx context.Context, cmd string, args []string) (*exec.Cmd, func()) {
+	if !e.UseSudo {
+		return GroupCommand(ctx, cmd, args...)
+	}
+	name, routed := SudoCommand(cmd, args)
+	return GroupCommand(ctx, "sudo", append([]string{name}, routed...)...)
+}
+
+// withTimeout bounds ctx by the executor's timeout, or by the default timeout
+// of the command's class, unless the caller has set a deadline. It returns
+// how long the command is allowed, or zero when only the caller can stop it.
+func (e *CommandExecutor) withTimeout(
+	ctx context.Context,
+	cmd string,
+	args []string,
+) (context.Context, context.CancelFunc, time.Duration) {
+	if deadline, ok := ctx.Deadline(); ok {
+		return ctx, func() {}, time.Until(deadline)
+	}
+
+	timeout := e.Timeout
+	switch {
+	case timeout < 0:
+		return ctx, func() {}, 0
+	case timeout == 0:
+		timeout = CommandTimeout(cmd, args)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout
+}
+
+// timeoutError reports a command stopped by its deadline
+func (e *CommandExecutor) timeoutError(
+	cmd string,
+	args []string,
+	timeout, elapsed time.Duration,
+) *rterrors.RodentError {
+	return TimeoutError(strings.Join(RedactArgs(append([]string{cmd}, args...)), " "), timeout, elapsed)
+}
+
+// sudoFailure returns the structured error for a sudo command that sudo
+// itself refused
+func (e *CommandExecutor) sudoFailure(cmd, output string) error {
+	if !e.UseSudo {
+		return nil
+	}
+	return SudoFailure(cmd, output)
+}
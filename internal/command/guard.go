@@ -0,0 +1,107 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+)
+
+// SudoGuard authorizes and audits commands a CommandExecutor runs with sudo.
+// It is implemented by the privilege broker, which cannot be imported here
+// without an import cycle.
+type SudoGuard interface {
+	// Authorize returns an error if the command must not be run
+	Authorize(cmd string, args []string) error
+
+	// Audit records the outcome of a command that was run
+	Audit(cmd string, args []string, elapsed time.Duration, err error)
+}
+
+// SudoRouter is implemented by a SudoGuard that runs some commands through
+// another program rather than directly with sudo, such as a helper that
+// checks arguments sudoers cannot
+type SudoRouter interface {
+	// SudoCommand returns the command and arguments sudo runs for cmd
+	SudoCommand(cmd string, args []string) (string, []string)
+}
+
+var (
+	guardMu   sync.RWMutex
+	sudoGuard SudoGuard
+)
+
+// SetSudoGuard installs the guard consulted by every CommandExecutor that uses
+// sudo. Passing nil removes it.
+func SetSudoGuard(guard SudoGuard) {
+	guardMu.Lock()
+	defer guardMu.Unlock()
+	sudoGuard = guard
+}
+
+// currentSudoGuard returns the installed guard, or nil
+func currentSudoGuard() SudoGuard {
+	guardMu.RLock()
+	defer guardMu.RUnlock()
+	return sudoGuard
+}
+
+// SudoCommand returns the command and arguments sudo runs for cmd, as routed
+// by the installed guard. Without a guard, or one that does not route, they
+// are cmd and args unchanged.
+func SudoCommand(cmd string, args []string) (string, []string) {
+	if router, ok := currentSudoGuard().(SudoRouter); ok {
+		return router.SudoCommand(cmd, args)
+	}
+	return cmd, args
+}
+
+// PrepareRun performs the checks that precede running a command. With sudo,
+// the installed guard authorizes the command first. In dry-run mode commands
+// that change state are skipped, and run is false. Otherwise sudo is probed so
+// a missing sudoers rule fails with remediation rather than on a password
+// prompt. audit records the outcome of a command that was run.
+func PrepareRun(
+	ctx context.Context,
+	l logger.Logger,
+	useSudo bool,
+	cmd string,
+	args []string,
+) (run bool, audit func(elapsed time.Duration, err error), err error) {
+	audit = func(time.Duration, error) {}
+
+	var guard SudoGuard
+	if useSudo {
+		guard = currentSudoGuard()
+	}
+	if guard != nil {
+		if err := guard.Authorize(cmd, args); err != nil {
+			return false, audit, err
+		}
+	}
+
+	if SkipInDryRun(l, cmd, args) {
+		return false, audit, nil
+	}
+
+	if useSudo {
+		// A routed command is probed as the command sudo runs, with the
+		// arguments that route it
+		name, routed := SudoCommand(cmd, args)
+		if err := CheckSudo(ctx, name, routed[:len(routed)-len(args)]...); err != nil {
+			return false, audit, err
+		}
+	}
+
+	if guard != nil {
+		audit = func(elapsed time.Duration, err error) {
+			guard.Audit(cmd, args, elapsed, err)
+		}
+	}
+	return true, audit, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type denyGuard struct {
+	audited int
+}
+
+func (g *denyGuard) Authorize(cmd string, args []string) error {
+	return assert.AnError
+}
+
+func (g *denyGuard) Audit(cmd string, args []string, elapsed time.Duration, err error) {
+	g.audited++
+}
+
+func TestPrepareRun(t *testing.T) {
+	guard := &denyGuard{}
+	SetSudoGuard(guard)
+	defer SetSudoGuard(nil)
+
+	run, _, err := PrepareRun(context.Background(), nil, true, "zfs", []string{"destroy", "tank/a"})
+	assert.False(t, run)
+	assert.ErrorIs(t, err, assert.AnError)
+
+	// The guard only sees commands run with sudo
+	run, audit, err := PrepareRun(context.Background(), nil, false, "true", nil)
+	assert.True(t, run)
+	assert.NoError(t, err)
+	audit(time.Second, nil)
+	assert.Zero(t, guard.audited)
+}
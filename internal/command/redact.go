@@ -0,0 +1,130 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// secretOptionWords are the substrings of option names carrying secrets
+var secretOptionWords = []string{"password", "passwd", "secret"}
+
+// sshValueOptions are the ssh options taking a value as the next argument
+const sshValueOptions = "BbcDEeFIiJLlmOoPpRSWw"
+
+// RedactArgs hides secrets before arguments are logged: the values of
+// password options, the password of samba "-U user%password" credentials,
+// ssh identity files, and the positional arguments after "--" other than
+// the first, which is how samba-tool takes a new user's password
+func RedactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	redactNext, afterSeparator := false, -1
+	credentialsNext, sshOptionNext, valueNext := false, false, false
+	// ssh is set from an ssh command to its destination
+	ssh := false
+
+	for i, arg := range args {
+		isValue := redactNext || credentialsNext || sshOptionNext || valueNext
+		valueNext = false
+
+		switch {
+		case redactNext:
+			redacted[i] = "***"
+			redactNext = false
+		case credentialsNext:
+			redacted[i] = redactCredentials(arg)
+			credentialsNext = false
+		case sshOptionNext:
+			redacted[i] = redactSSHOption(arg)
+			sshOptionNext = false
+		case afterSeparator >= 1:
+			redacted[i] = "***"
+		case isPasswordOption(arg):
+			if key, _, ok := strings.Cut(arg, "="); ok {
+				redacted[i] = key + "=***"
+			} else {
+				redacted[i] = arg
+				redactNext = true
+			}
+		case arg == "-U" || arg == "--user":
+			redacted[i] = arg
+			credentialsNext = true
+		case strings.HasPrefix(arg, "--user="):
+			redacted[i] = "--user=" + redactCredentials(strings.TrimPrefix(arg, "--user="))
+		case ssh && arg == "-i":
+			redacted[i] = arg
+			redactNext = true
+		case ssh && arg == "-o":
+			redacted[i] = arg
+			sshOptionNext = true
+		case ssh && strings.HasPrefix(arg, "-o"):
+			redacted[i] = "-o" + redactSSHOption(strings.TrimPrefix(arg, "-o"))
+		case ssh && strings.HasPrefix(arg, "-i"):
+			redacted[i] = "-i***"
+		default:
+			redacted[i] = arg
+			if ssh && len(arg) == 2 && arg[0] == '-' && strings.IndexByte(sshValueOptions, arg[1]) >= 0 {
+				valueNext = true
+			}
+		}
+
+		// The ssh options end at the destination
+		if filepath.Base(arg) == "ssh" {
+			ssh = true
+		} else if ssh && !isValue && !strings.HasPrefix(arg, "-") {
+			ssh = false
+		}
+
+		if afterSeparator >= 0 {
+			afterSeparator++
+		} else if arg == "--" {
+			afterSeparator = 0
+		}
+	}
+
+	return redacted
+}
+
+// RedactCommandLine hides the secrets of a command line joined with spaces,
+// such as the shell pipelines of transfers. Runs of spaces are collapsed.
+func RedactCommandLine(line string) string {
+	return strings.Join(RedactArgs(strings.Fields(line)), " ")
+}
+
+// isPasswordOption reports whether arg is an option carrying a password
+func isPasswordOption(arg string) bool {
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	lower := strings.ToLower(arg)
+	for _, word := range secretOptionWords {
+		if strings.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactCredentials hides the password of samba "user%password" credentials
+func redactCredentials(arg string) string {
+	if user, _, ok := strings.Cut(arg, "%"); ok {
+		return user + "%***"
+	}
+	return arg
+}
+
+// redactSSHOption hides the value of an ssh IdentityFile option, and the
+// secrets of the ssh command in a ProxyCommand option
+func redactSSHOption(opt string) string {
+	key, value, ok := strings.Cut(opt, "=")
+	switch {
+	case ok && strings.EqualFold(key, "IdentityFile"):
+		return key + "=***"
+	case ok && strings.EqualFold(key, "ProxyCommand"):
+		return key + "=" + RedactCommandLine(value)
+	}
+	return opt
+}
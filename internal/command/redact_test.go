@@ -0,0 +1,42 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"user", "setpassword", "alice", "--newpassword=s3cret"}, "user setpassword alice --newpassword=***"},
+		{[]string{"usermod", "--password", "hash", "bob"}, "usermod --password *** bob"},
+		{[]string{"user", "create", "--given-name=A", "--", "alice", "s3cret"}, "user create --given-name=A -- alice ***"},
+		{[]string{"list", "-H"}, "list -H"},
+		{[]string{"ads", "leave", "-U", "admin%s3cret"}, "ads leave -U admin%***"},
+		{[]string{"ads", "join", "--user=admin%s3cret"}, "ads join --user=admin%***"},
+		{[]string{"-i", "s/a/b/", "/etc/hosts"}, "-i s/a/b/ /etc/hosts"},
+		{[]string{"ssh", "-i", "/keys/id", "-o", "IdentityFile=/keys/id", "-oBatchMode=yes", "root@nas"},
+			"ssh -i *** -o IdentityFile=*** -oBatchMode=yes root@nas"},
+		{[]string{"ssh", "-o", "ProxyCommand=ssh -i /keys/jump -W nas:22 jump@bastion", "root@nas"},
+			"ssh -o ProxyCommand=ssh -i *** -W nas:22 jump@bastion root@nas"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, strings.Join(RedactArgs(tt.args), " "))
+	}
+}
+
+func TestRedactCommandLine(t *testing.T) {
+	line := "sudo zfs send tank/a@s1 | ssh -i /home/rodent/.ssh/id_ed25519 root@nas sudo zfs receive -F -i tank/b"
+	assert.Equal(t,
+		"sudo zfs send tank/a@s1 | ssh -i *** root@nas sudo zfs receive -F -i tank/b",
+		RedactCommandLine(line))
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	rterrors "github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// sudoProbeTimeout bounds a single sudo probe
+	sudoProbeTimeout = 5 * time.Second
+
+	// Probe results are kept for a while so commands do not each probe.
+	// Failures are kept briefly so a fixed sudoers policy is noticed soon.
+	sudoProbeOKTTL   = 10 * time.Minute
+	sudoProbeFailTTL = 30 * time.Second
+)
+
+// SudoStatus is the result of probing whether a binary may be run with sudo
+// without a password
+type SudoStatus struct {
+	Binary      string    `json:"binary"`
+	OK          bool      `json:"ok"`
+	Error       string    `json:"error,omitempty"`
+	Remediation string    `json:"remediation,omitempty"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// sudoProbe is the outcome of a probe; a zero code means sudo works. The
+// error is rebuilt for each caller, which may add metadata of its own.
+type sudoProbe struct {
+	code    rterrors.ErrorCode
+	details string
+	at      time.Time
+}
+
+func (p sudoProbe) err(binary string) error {
+	if p.code == 0 {
+		return nil
+	}
+	return sudoError(p.code, binary, p.details)
+}
+
+var (
+	sudoProbesMu sync.Mutex
+	sudoProbes   = make(map[string]sudoProbe)
+)
+
+// sudoRemediation tells the operator how to let Rodent run binary with sudo
+func sudoRemediation(binary string) string {
+	return fmt.Sprintf("Allow the Rodent user to run %s with sudo without a password: "+
+		"install the policy printed by 'rodent privilege sudoers' as /etc/sudoers.d/rodent "+
+		"and check it with 'visudo -c'", binary)
+}
+
+// sudoError returns the structured error for a binary sudo will not run
+// without a password, or does not allow at all
+func sudoError(code rterrors.ErrorCode, binary, details string) error {
+	return rterrors.New(code, details).
+		WithMetadata("binary", binary).
+		WithMetadata("remediation", sudoRemediation(binary))
+}
+
+// SudoFailure returns a structured error when the output of a command run
+// with sudo shows that sudo itself refused it, or nil when it did not. The
+// refusal is recorded like a failed probe.
+func SudoFailure(binary, output string) error {
+	err := classifySudoOutput(binary, output)
+	if err != nil {
+		recordSudoProbe(binary, err)
+	}
+	return err
+}
+
+// classifySudoOutput returns the structured error for sudo's own refusal
+// messages in output, or nil
+func classifySudoOutput(binary, output string) error {
+	lower := strings.ToLower(output)
+	switch {
+	case strings.Contains(lower, "a password is required"),
+		strings.Contains(lower, "a terminal is required"),
+		strings.Contains(lower, "no tty present"):
+		return sudoError(rterrors.SudoPasswordRequired, binary,
+			fmt.Sprintf("sudo requires a password to run %s", binary))
+	case strings.Contains(lower, "is not allowed to execute"),
+		strings.Contains(lower, "is not in the sudoers file"),
+		strings.Contains(lower, "may not run sudo"):
+		return sudoError(rterrors.SudoNotPermitted, binary,
+			fmt.Sprintf("sudo does not allow running %s", binary))
+	}
+	return nil
+}
+
+// ProbeSudo checks whether binary may be run with sudo without a password,
+// with 'sudo -n -l', which never prompts. Arguments narrow the probe to a
+// command line, such as the helper subcommand a sudoers rule allows. The
+// result is recorded for CheckSudo and SudoStatuses. Running as root always
+// passes.
+func ProbeSudo(ctx context.Context, binary string, args ...string) error {
+	if os.Geteuid() == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sudoProbeTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "sudo",
+		append([]string{"-n", "-l", binary}, args...)...).CombinedOutput()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, exec.ErrNotFound):
+		err = sudoError(rterrors.SudoNotPermitted, binary, "sudo is not installed")
+	case ctx.Err() != nil:
+		// Inconclusive; the command itself will tell
+		return nil
+	default:
+		if err = classifySudoOutput(binary, string(output)); err == nil {
+			// sudo -l exits non-zero without output for commands it does
+			// not allow
+			err = sudoError(rterrors.SudoNotPermitted, binary,
+				fmt.Sprintf("sudo does not allow running %s", binary))
+		}
+	}
+
+	recordSudoProbe(binary, err)
+	return err
+}
+
+// CheckSudo returns the recent result of probing binary, probing it when
+// there is none. Executors call it before running a command with sudo, so a
+// missing sudoers rule fails at once with remediation instead of deep inside
+// a manager, or with sudo waiting on a password prompt.
+func CheckSudo(ctx context.Context, binary string, args ...string) error {
+	if os.Geteuid() == 0 {
+		return nil
+	}
+
+	sudoProbesMu.Lock()
+	probe, ok := sudoProbes[sudoProbeKey(binary)]
+	sudoProbesMu.Unlock()
+	if ok {
+		ttl := sudoProbeOKTTL
+		if probe.code != 0 {
+			ttl = sudoProbeFailTTL
+		}
+		if time.Since(probe.at) < ttl {
+			return probe.err(binary)
+		}
+	}
+	return ProbeSudo(ctx, binary, args...)
+}
+
+// recordSudoProbe keeps the outcome of a probe or of a command sudo refused
+func recordSudoProbe(binary string, err error) {
+	probe := sudoProbe{at: time.Now()}
+	var re *rterrors.RodentError
+	if errors.As(err, &re) {
+		probe.code, probe.details = re.Code, re.Details
+	}
+
+	sudoProbesMu.Lock()
+	defer sudoProbesMu.Unlock()
+	sudoProbes[sudoProbeKey(binary)] = probe
+}
+
+// sudoProbeKey names a binary by its base name, so zfs and /usr/sbin/zfs
+// share a result
+func sudoProbeKey(binary string) string {
+	return filepath.Base(binary)
+}
+
+// SudoStatuses returns the latest probe result of every binary checked so
+// far, sorted by binary
+func SudoStatuses() []SudoStatus {
+	sudoProbesMu.Lock()
+	defer sudoProbesMu.Unlock()
+
+	statuses := make([]SudoStatus, 0, len(sudoProbes))
+	for binary, probe := range sudoProbes {
+		status := SudoStatus{Binary: binary, OK: probe.code == 0, CheckedAt: probe.at}
+		if err := probe.err(binary); err != nil {
+			status.Error = err.Error()
+			status.Remediation = sudoRemediation(binary)
+		}
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b SudoStatus) int {
+		return strings.Compare(a.Binary, b.Binary)
+	})
+	return statuses
+}
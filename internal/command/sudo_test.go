@@ -0,0 +1,74 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	rterrors "github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSudoFailure(t *testing.T) {
+	tests := []struct {
+		output string
+		code   rterrors.ErrorCode
+	}{
+		{"sudo: a password is required\n", rterrors.SudoPasswordRequired},
+		{"sudo: a terminal is required to read the password; either use the -S option", rterrors.SudoPasswordRequired},
+		{"Sorry, user rodent is not allowed to execute '/usr/sbin/zfs destroy tank' as root on nas.", rterrors.SudoNotPermitted},
+		{"rodent is not in the sudoers file.  This incident will be reported.", rterrors.SudoNotPermitted},
+		{"cannot open 'tank/missing': dataset does not exist", 0},
+	}
+
+	for _, tt := range tests {
+		err := classifySudoOutput("/usr/sbin/zfs", tt.output)
+		if tt.code == 0 {
+			assert.NoError(t, err, tt.output)
+			continue
+		}
+		code, ok := rterrors.GetCode(err)
+		require.True(t, ok, tt.output)
+		assert.Equal(t, tt.code, code, tt.output)
+		assert.Equal(t, rterrors.CategoryPermission, rterrors.CategoryOf(err))
+
+		var re *rterrors.RodentError
+		require.ErrorAs(t, err, &re)
+		assert.Contains(t, re.Metadata["remediation"], "rodent privilege sudoers")
+	}
+}
+
+func TestCheckSudoUsesRecordedResult(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("sudo is not probed as root")
+	}
+	t.Cleanup(func() {
+		sudoProbesMu.Lock()
+		delete(sudoProbes, "rodent-test-binary")
+		sudoProbesMu.Unlock()
+	})
+
+	// A refused command is recorded under the binary's base name
+	require.Error(t, SudoFailure("/usr/sbin/rodent-test-binary", "sudo: a password is required"))
+	err := CheckSudo(context.Background(), "rodent-test-binary")
+	code, _ := rterrors.GetCode(err)
+	assert.Equal(t, rterrors.ErrorCode(rterrors.SudoPasswordRequired), code)
+
+	var found bool
+	for _, s := range SudoStatuses() {
+		if s.Binary == "rodent-test-binary" {
+			found = true
+			assert.False(t, s.OK)
+			assert.NotEmpty(t, s.Remediation)
+		}
+	}
+	assert.True(t, found)
+
+	recordSudoProbe("rodent-test-binary", nil)
+	assert.NoError(t, CheckSudo(context.Background(), "rodent-test-binary"))
+}
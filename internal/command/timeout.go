@@ -0,0 +1,182 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	rterrors "github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// DefaultCommandTimeout bounds commands without a timeout of their own
+	DefaultCommandTimeout = 30 * time.Second
+
+	// DefaultKillGrace is how long a command stopped by its context has to
+	// exit after SIGTERM before its process group is killed
+	DefaultKillGrace = 5 * time.Second
+
+	// NoTimeout, as a CommandExecutor timeout, leaves bounding commands to
+	// the caller's context
+	NoTimeout time.Duration = -1
+)
+
+// defaultCommandTimeouts bounds classes of commands, keyed by binary name or
+// by binary name and subcommand, such as "zfs list". Listing is quick, while
+// receiving a stream or joining a domain may take much longer.
+var defaultCommandTimeouts = map[string]time.Duration{
+	"zfs":            DefaultCommandTimeout,
+	"zfs list":       2 * time.Minute,
+	"zfs get":        2 * time.Minute,
+	"zfs diff":       10 * time.Minute,
+	"zfs destroy":    10 * time.Minute,
+	"zfs rollback":   10 * time.Minute,
+	"zfs send":       24 * time.Hour,
+	"zfs receive":    24 * time.Hour,
+	"zfs recv":       24 * time.Hour,
+	"zpool":          DefaultCommandTimeout,
+	"zpool status":   time.Minute,
+	"zpool create":   5 * time.Minute,
+	"zpool import":   10 * time.Minute,
+	"zpool export":   5 * time.Minute,
+	"zpool destroy":  5 * time.Minute,
+	"zpool wait":     24 * time.Hour,
+	"smbcontrol":     15 * time.Second,
+	"smbstatus":      15 * time.Second,
+	"testparm":       15 * time.Second,
+	"net":            2 * time.Minute,
+	"samba-tool":     2 * time.Minute,
+	"systemctl":      2 * time.Minute,
+	"smartctl":       time.Minute,
+	"udevadm settle": 2 * time.Minute,
+	"docker":         5 * time.Minute,
+}
+
+var (
+	timeoutsMu      sync.RWMutex
+	commandTimeouts = maps.Clone(defaultCommandTimeouts)
+	killGrace       = DefaultKillGrace
+)
+
+// SetCommandTimeouts replaces the timeouts of command classes in overrides,
+// keyed like "zfs list" or "smbcontrol", and the grace period between
+// SIGTERM and SIGKILL; a zero grace keeps the current one. Classes not
+// overridden keep their defaults.
+func SetCommandTimeouts(overrides map[string]time.Duration, grace time.Duration) {
+	timeoutsMu.Lock()
+	defer timeoutsMu.Unlock()
+
+	commandTimeouts = maps.Clone(defaultCommandTimeouts)
+	maps.Copy(commandTimeouts, overrides)
+	if grace > 0 {
+		killGrace = grace
+	}
+}
+
+// CommandTimeout returns the default timeout for running cmd with args: that
+// of its subcommand, of its binary, or DefaultCommandTimeout. sudo is looked
+// through.
+func CommandTimeout(cmd string, args []string) time.Duration {
+	name := filepath.Base(cmd)
+	if name == "sudo" && len(args) > 0 {
+		name, args = filepath.Base(args[0]), args[1:]
+	}
+
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+
+	if operands := positionalArgs(args); len(operands) > 0 {
+		if timeout, ok := commandTimeouts[name+" "+operands[0]]; ok {
+			return timeout
+		}
+	}
+	if timeout, ok := commandTimeouts[name]; ok {
+		return timeout
+	}
+	return DefaultCommandTimeout
+}
+
+// KillGrace returns how long a stopped command has to exit after SIGTERM
+func KillGrace() time.Duration {
+	timeoutsMu.RLock()
+	defer timeoutsMu.RUnlock()
+	return killGrace
+}
+
+// KillProcessGroupOnCancel starts cmd in a process group of its own and,
+// when its context is done, stops the whole group: SIGTERM first, which
+// sudo relays to the command it runs, then SIGKILL after the kill grace.
+// Children such as the two sides of a pipeline are stopped with it, so a
+// stuck command cannot hold up a manager that waits on it. Call release
+// once the command has been waited for.
+func KillProcessGroupOnCancel(cmd *exec.Cmd) (release func()) {
+	grace := KillGrace()
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	var (
+		mu       sync.Mutex
+		timer    *time.Timer
+		released bool
+	)
+	cmd.Cancel = func() error {
+		pgid := cmd.Process.Pid
+		err := syscall.Kill(-pgid, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if !released {
+			timer = time.AfterFunc(grace, func() {
+				_ = syscall.Kill(-pgid, syscall.SIGKILL)
+			})
+		}
+		return err
+	}
+	// Wait stops waiting for the output of children that outlive the kill
+	cmd.WaitDelay = grace + time.Second
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		released = true
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// GroupCommand creates the command for name and args with
+// KillProcessGroupOnCancel applied, so it and its children are stopped
+// together when ctx is done. Call release once it has been waited for.
+func GroupCommand(ctx context.Context, name string, args ...string) (cmd *exec.Cmd, release func()) {
+	cmd = exec.CommandContext(ctx, name, args...)
+	return cmd, KillProcessGroupOnCancel(cmd)
+}
+
+// TimeoutError reports a command stopped because it ran past its deadline,
+// with how long it was allowed and how long it ran
+func TimeoutError(cmdString string, timeout, elapsed time.Duration) *rterrors.RodentError {
+	err := rterrors.New(rterrors.CommandTimeout, "command execution timed out").
+		WithMetadata("command", cmdString).
+		WithMetadata("elapsed", elapsed.Round(time.Millisecond).String()).
+		WithMetadata("kill_grace", KillGrace().String())
+	if timeout > 0 {
+		err = err.WithMetadata("timeout", timeout.Round(time.Millisecond).String())
+	}
+	return err
+}
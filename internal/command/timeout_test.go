@@ -0,0 +1,55 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	rterrors "github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandTimeout(t *testing.T) {
+	t.Cleanup(func() { SetCommandTimeouts(nil, DefaultKillGrace) })
+
+	assert.Equal(t, 2*time.Minute, CommandTimeout("zfs", []string{"list", "-H"}))
+	assert.Equal(t, 24*time.Hour, CommandTimeout("/usr/sbin/zfs", []string{"receive", "-F", "tank/backup"}))
+	assert.Equal(t, 24*time.Hour, CommandTimeout("sudo", []string{"/usr/sbin/zfs", "recv", "tank/backup"}))
+	assert.Equal(t, DefaultCommandTimeout, CommandTimeout("zfs", []string{"snapshot", "tank@now"}))
+	assert.Equal(t, 15*time.Second, CommandTimeout("sudo", []string{"smbcontrol", "smbd", "reload-config"}))
+	assert.Equal(t, DefaultCommandTimeout, CommandTimeout("unknown-tool", nil))
+
+	SetCommandTimeouts(map[string]time.Duration{"zfs list": 5 * time.Minute}, time.Second)
+	assert.Equal(t, 5*time.Minute, CommandTimeout("zfs", []string{"list"}))
+	assert.Equal(t, 24*time.Hour, CommandTimeout("zfs", []string{"receive"}), "classes not overridden keep their defaults")
+	assert.Equal(t, time.Second, KillGrace())
+}
+
+func TestExecuteTimeoutKillsProcessGroup(t *testing.T) {
+	SetCommandTimeouts(nil, 200*time.Millisecond)
+	t.Cleanup(func() { SetCommandTimeouts(nil, DefaultKillGrace) })
+
+	executor := NewCommandExecutor(false)
+	executor.Timeout = 200 * time.Millisecond
+
+	// The shell ignores SIGTERM, so only the SIGKILL of its group stops it
+	// and the sleep it started
+	start := time.Now()
+	_, err := executor.ExecuteWithCombinedOutput(context.Background(),
+		"sh", "-c", "trap '' TERM; sleep 30 & wait")
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	var re *rterrors.RodentError
+	require.True(t, errors.As(err, &re), "got %T: %v", err, err)
+	assert.EqualValues(t, rterrors.CommandTimeout, re.Code)
+	assert.Equal(t, "200ms", re.Metadata["timeout"])
+	assert.Equal(t, "200ms", re.Metadata["kill_grace"])
+	assert.NotEmpty(t, re.Metadata["elapsed"])
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"context"
+)
+
+// Actor sources
+const (
+	ActorSourceAPI     = "api"     // REST API request
+	ActorSourceToggle  = "toggle"  // Command from Toggle over gRPC
+	ActorSourceTrigger = "trigger" // Snapshot policy trigger, authenticated by API key
+	ActorSourceScoped  = "scoped"  // REST API request authenticated by a delegated key
+)
+
+// ActorUserHeader lets REST clients name the user a request is made for.
+// Rodent does not authenticate it; it is recorded for change histories.
+const ActorUserHeader = "X-Rodent-User"
+
+// Actor identifies who asked for a change, for audit trails and change
+// histories
+type Actor struct {
+	Source    string `json:"source,omitempty"`
+	User      string `json:"user,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Address   string `json:"address,omitempty"` // Client address of REST requests
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by ctx, or a zero Actor for
+// changes Rodent makes on its own
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
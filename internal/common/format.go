@@ -0,0 +1,28 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import "fmt"
+
+// FormatBytes formats a byte count with binary units, as zfs does: "512B",
+// "1.5G". Negative counts, such as shrinking usage, keep their sign.
+func FormatBytes[T ~int | ~int64 | ~uint64](n T) string {
+	v := float64(n)
+	sign := ""
+	if v < 0 {
+		sign, v = "-", -v
+	}
+
+	units := []string{"B", "K", "M", "G", "T", "P"}
+	i := 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%s%.0f%s", sign, v, units[i])
+	}
+	return fmt.Sprintf("%s%.1f%s", sign, v, units[i])
+}
@@ -0,0 +1,20 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "0B", FormatBytes(0))
+	assert.Equal(t, "1023B", FormatBytes(int64(1023)))
+	assert.Equal(t, "1.0K", FormatBytes(uint64(1024)))
+	assert.Equal(t, "1.5G", FormatBytes(uint64(3<<29)))
+	assert.Equal(t, "-2.0M", FormatBytes(int64(-2<<20)))
+	assert.Equal(t, "16384.0P", FormatBytes(uint64(1<<64-1)))
+}
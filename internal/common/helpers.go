@@ -22,6 +22,7 @@ func init() {
 	if err != nil {
 		panic("Failed to initialize logger: " + err.Error())
 	}
+	Log = WithRedaction(Log)
 }
 
 // GenUUID generates a new UUID using V7, but falls back to V4 if V7 errors
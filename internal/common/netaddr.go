@@ -0,0 +1,82 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// interfaceName matches Linux network interface names
+var interfaceName = regexp.MustCompile(`^[A-Za-z0-9_.:@-]{1,15}$`)
+
+// UnbracketHost removes the brackets around an IPv6 literal, as in
+// "[2001:db8::1]". Other hosts are returned as given.
+func UnbracketHost(host string) string {
+	if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		return host[1 : len(host)-1]
+	}
+	return host
+}
+
+// JoinHostPort joins a host and port, bracketing IPv6 literals. The host may
+// be given with or without brackets.
+func JoinHostPort(host string, port int) string {
+	return net.JoinHostPort(UnbracketHost(host), strconv.Itoa(port))
+}
+
+// HostIP returns the IP of an address given as an IP, an IPv6 literal in
+// brackets, or an IP with a prefix length such as "192.168.1.20/24"
+func HostIP(addr string) (netip.Addr, error) {
+	addr = UnbracketHost(strings.TrimSpace(addr))
+	if prefix, err := netip.ParsePrefix(addr); err == nil {
+		return prefix.Addr(), nil
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid IP address %q", addr)
+	}
+	return ip, nil
+}
+
+// ValidInterfaceName reports whether name can be a network interface name
+func ValidInterfaceName(name string) bool {
+	return interfaceName.MatchString(name)
+}
+
+// InterfaceAddrs returns the IPs of a network interface, IPv4 first. IPv6
+// link-local addresses carry the interface as their zone.
+func InterfaceAddrs(name string) ([]netip.Addr, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s not found: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses of %s: %w", name, err)
+	}
+
+	var v4, v6 []netip.Addr
+	for _, a := range addrs {
+		prefix, err := netip.ParsePrefix(a.String())
+		if err != nil {
+			continue
+		}
+		ip := prefix.Addr().Unmap()
+		switch {
+		case ip.Is4():
+			v4 = append(v4, ip)
+		case ip.IsLinkLocalUnicast():
+			v6 = append(v6, ip.WithZone(name))
+		default:
+			v6 = append(v6, ip)
+		}
+	}
+	return append(v4, v6...), nil
+}
@@ -0,0 +1,46 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJoinHostPort(t *testing.T) {
+	assert.Equal(t, "dc1.ad.example:636", JoinHostPort("dc1.ad.example", 636))
+	assert.Equal(t, "192.168.1.20:636", JoinHostPort("192.168.1.20", 636))
+	assert.Equal(t, "[2001:db8::20]:636", JoinHostPort("2001:db8::20", 636))
+	assert.Equal(t, "[2001:db8::20]:636", JoinHostPort("[2001:db8::20]", 636))
+	assert.Equal(t, "[fe80::1%eth0]:22", JoinHostPort("fe80::1%eth0", 22))
+}
+
+func TestHostIP(t *testing.T) {
+	for addr, want := range map[string]string{
+		"192.168.1.20":    "192.168.1.20",
+		"192.168.1.20/24": "192.168.1.20",
+		"2001:db8::20":    "2001:db8::20",
+		"[2001:db8::20]":  "2001:db8::20",
+		"2001:db8::20/64": "2001:db8::20",
+		" fe80::1%eth0 ":  "fe80::1%eth0",
+	} {
+		ip, err := HostIP(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, want, ip.String(), addr)
+	}
+
+	_, err := HostIP("dc1.ad.example")
+	assert.Error(t, err)
+}
+
+func TestValidInterfaceName(t *testing.T) {
+	assert.True(t, ValidInterfaceName("eth0"))
+	assert.True(t, ValidInterfaceName("enp3s0.100"))
+	assert.False(t, ValidInterfaceName(""))
+	assert.False(t, ValidInterfaceName("eth0; reboot"))
+	assert.False(t, ValidInterfaceName("averyveryverylongname"))
+}
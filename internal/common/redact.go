@@ -0,0 +1,126 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/stratastor/logger"
+)
+
+// Redacted replaces secret values
+const Redacted = "[REDACTED]"
+
+// secretKeys are the substrings of attribute and configuration keys holding
+// secrets
+var secretKeys = []string{
+	"password", "passwd", "secret", "token", "jwt", "dsn", "apikey", "api_key", "privatekey", "private_key",
+}
+
+var (
+	// secretAssignment matches a secret-named key followed by its value, as
+	// in key=value, key: value and "key": "value"
+	secretAssignment = regexp.MustCompile(
+		`(?i)((?:password|passwd|secret|token|jwt|dsn|apikey|api_key)[\w-]*["']?\s*[:=]\s*["']?)[^\s"',}]+`)
+
+	// bearerToken matches HTTP bearer credentials
+	bearerToken = regexp.MustCompile(`(?i)(bearer\s+)[\w.~+/=-]+`)
+
+	// jwtToken matches JSON web tokens wherever they appear
+	jwtToken = regexp.MustCompile(`eyJ[\w-]+\.[\w-]+\.[\w-]+`)
+)
+
+// RedactText hides secrets in free-form text such as logs and command output
+func RedactText(text string) string {
+	text = jwtToken.ReplaceAllString(text, Redacted)
+	text = bearerToken.ReplaceAllString(text, "${1}"+Redacted)
+	return secretAssignment.ReplaceAllString(text, "${1}"+Redacted)
+}
+
+// IsSecretKey reports whether an attribute or configuration key names a
+// secret
+func IsSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(lower, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRedaction returns a logger that hides secrets: the values of
+// secret-named attributes, and secrets written into messages and string
+// attributes, such as --password=... in a logged command
+func WithRedaction(l logger.Logger) logger.Logger {
+	if l == nil {
+		return nil
+	}
+	if _, ok := l.Handler().(*redactingHandler); ok {
+		return l
+	}
+	return slog.New(&redactingHandler{next: l.Handler()})
+}
+
+// redactingHandler redacts records before passing them on
+type redactingHandler struct {
+	next slog.Handler
+}
+
+func (h *redactingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactingHandler) Handle(ctx context.Context, r slog.Record) error {
+	redacted := slog.NewRecord(r.Time, r.Level, RedactText(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		redacted.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *redactingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &redactingHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *redactingHandler) WithGroup(name string) slog.Handler {
+	return &redactingHandler{next: h.next.WithGroup(name)}
+}
+
+// redactAttr hides the value of a secret-named attribute and secrets in
+// string values, descending into groups
+func redactAttr(a slog.Attr) slog.Attr {
+	value := a.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, ga := range group {
+			redacted[i] = redactAttr(ga)
+		}
+		return slog.Group(a.Key, redacted...)
+	case slog.KindString:
+		if IsSecretKey(a.Key) && value.String() != "" {
+			return slog.String(a.Key, Redacted)
+		}
+		return slog.String(a.Key, RedactText(value.String()))
+	case slog.KindAny:
+		if IsSecretKey(a.Key) {
+			return slog.String(a.Key, Redacted)
+		}
+		if err, ok := value.Any().(error); ok {
+			return slog.String(a.Key, RedactText(err.Error()))
+		}
+	}
+	return slog.Attr{Key: a.Key, Value: value}
+}
@@ -0,0 +1,34 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := WithRedaction(slog.New(slog.NewJSONHandler(&buf, nil)))
+	assert.Same(t, l, WithRedaction(l), "loggers are wrapped once")
+
+	l.With("admin_password", "hunter2").Info("Joining domain",
+		"cmd", "net ads join --password=hunter2",
+		"realm", "AD.EXAMPLE",
+		slog.Group("remote", "private_key", "/keys/id", "host", "nas"),
+		"error", fmt.Errorf("bind failed: password=hunter2"))
+
+	out := buf.String()
+	assert.NotContains(t, out, "hunter2")
+	assert.NotContains(t, out, "/keys/id")
+	assert.Contains(t, out, `"realm":"AD.EXAMPLE"`)
+	assert.Contains(t, out, `"host":"nas"`)
+	assert.Contains(t, out, `"admin_password":"`+Redacted+`"`)
+	assert.Contains(t, out, "net ads join --password="+Redacted)
+}
@@ -28,6 +28,12 @@ const (
 
 	APIAD = APIBase + "/ad"
 
+	// APIDomain is the base path for domain membership endpoints
+	APIDomain = APIBase + "/domain"
+
+	// APIADDC is the base path for self-hosted AD DC provisioning endpoints
+	APIADDC = APIBase + "/addc"
+
 	// APIServices is the base path for service management API endpoints
 	APIServices = APIBase + "/services"
 
@@ -51,6 +57,30 @@ const (
 	// APIInventory is the base path for inventory API endpoints
 	APIInventory = APIBase + "/inventory"
 
+	// APIJobs is the base path for background job inspection endpoints
+	APIJobs = APIBase + "/jobs"
+
+	// APIOperations is the base path clients poll for requests run in the background
+	APIOperations = APIBase + "/operations"
+
+	// APISelfTest is the path of the startup self-test report
+	APISelfTest = APIBase + "/selftest"
+
+	// APIWebhooks is the base path for outbound webhook endpoints
+	APIWebhooks = APIBase + "/webhooks"
+
+	// APIGuards is the base path for guard rules and override tokens
+	APIGuards = APIBase + "/guards"
+
+	// APIReports is the base path for scheduled digest reports
+	APIReports = APIBase + "/reports"
+
+	// APIConsistency is the base path for consistency checks and repairs
+	APIConsistency = APIBase + "/consistency"
+
+	// APIMaintenance is the base path for node maintenance mode
+	APIMaintenance = APIBase + "/maintenance"
+
 	// Template paths - relative paths
 	TemplatesBasePath = "internal/templates"
 )
@@ -0,0 +1,331 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package crash turns panics in the daemon into crash reports. A report holds
+// the panic, its stack and a snapshot of the state subsystems register, such
+// as recent events and active transfers, and is written to the crash
+// directory for `rodent doctor` to pick up. Panics in HTTP handlers, job
+// handlers and scheduled policy tasks are survived; a panic in a background
+// goroutine started with Go runs the fatal hooks, which persist state for
+// recovery, and exits so the service manager restarts Rodent.
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/metrics"
+)
+
+// Where a panic was recovered
+const (
+	SourceHTTP      = "http"      // A REST handler; the request fails with a 500
+	SourceJob       = "job"       // A background job handler; the job fails
+	SourceScheduler = "scheduler" // A scheduled policy task; the policy's run fails
+	SourceGoroutine = "goroutine" // A background goroutine; Rodent exits
+)
+
+const (
+	// ExitCode is the status Rodent exits with after a fatal panic, the same
+	// as the Go runtime's for an unrecovered panic
+	ExitCode = 2
+
+	// maxReports bounds the reports kept in the crash directory
+	maxReports = 20
+
+	// stateTimeout bounds each state provider and fatal hook, as the
+	// panicking goroutine may hold the lock they need
+	stateTimeout = 2 * time.Second
+)
+
+// Report describes one recovered panic
+type Report struct {
+	ID            string            `json:"id"`
+	Time          time.Time         `json:"time"`
+	Source        string            `json:"source"`
+	Component     string            `json:"component"`
+	CorrelationID string            `json:"correlation_id,omitempty"` // Request or job ID
+	Fatal         bool              `json:"fatal"`
+	Panic         string            `json:"panic"`
+	Stack         string            `json:"stack"`
+	Version       string            `json:"version"`
+	State         map[string]any    `json:"state,omitempty"`
+	StateErrors   map[string]string `json:"state_errors,omitempty"`
+	Path          string            `json:"-"` // Empty if the report could not be written
+}
+
+// provider is a named state snapshot taken for every report
+type provider struct {
+	name string
+	fn   func() any
+}
+
+var (
+	mu        sync.RWMutex
+	log       logger.Logger
+	dir       string
+	providers []provider
+	hooks     []func()
+	counts    = make(map[string]uint64)
+
+	// exit is replaced by tests
+	exit = os.Exit
+
+	registerOnce sync.Once
+)
+
+// DefaultDir returns the directory crash reports are written to
+func DefaultDir() string {
+	return filepath.Join(config.GetConfigDir(), "crash")
+}
+
+// Setup sets the logger and report directory and registers the crash
+// counter with the metrics endpoint. Until it is called panics are logged
+// with the global logger and reported to DefaultDir.
+func Setup(l logger.Logger, reportDir string) {
+	mu.Lock()
+	log, dir = l, reportDir
+	mu.Unlock()
+
+	registerOnce.Do(func() {
+		metrics.Register(collector{})
+	})
+}
+
+// AddState registers a snapshot included in every report under name. The
+// function must return a value that encodes to JSON.
+func AddState(name string, fn func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers = append(providers, provider{name: name, fn: fn})
+}
+
+// OnFatal registers a hook run before Rodent exits after a fatal panic, to
+// persist state the next start recovers from
+func OnFatal(fn func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	hooks = append(hooks, fn)
+}
+
+// Go runs fn in a new goroutine whose panics are fatal, reported by Recover
+func Go(component string, fn func()) {
+	go func() {
+		defer Recover(component)
+		fn()
+	}()
+}
+
+// Recover must be deferred directly. It reports a panic of the calling
+// goroutine, runs the fatal hooks and exits.
+func Recover(component string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	Handle(SourceGoroutine, component, "", r, debug.Stack())
+	runFatalHooks()
+	exit(ExitCode)
+}
+
+// Handle reports a recovered panic: it logs the stack with the correlation
+// ID, counts it and writes a report with the registered state
+func Handle(source, component, correlationID string, value any, stack []byte) *Report {
+	report := &Report{
+		ID:            common.UUID7(),
+		Time:          time.Now(),
+		Source:        source,
+		Component:     component,
+		CorrelationID: correlationID,
+		Fatal:         source == SourceGoroutine,
+		Panic:         fmt.Sprint(value),
+		Stack:         string(stack),
+		Version:       constants.Version,
+	}
+
+	mu.Lock()
+	counts[source]++
+	l, reportDir := log, dir
+	snapshot := slices.Clone(providers)
+	mu.Unlock()
+
+	if l == nil {
+		l = common.Log
+	}
+	if reportDir == "" {
+		reportDir = DefaultDir()
+	}
+
+	l.Error("Recovered from panic",
+		"crash_id", report.ID,
+		"source", source,
+		"component", component,
+		"correlation_id", correlationID,
+		"panic", report.Panic,
+		"stack", report.Stack)
+
+	report.State, report.StateErrors = collectState(snapshot)
+
+	path, err := writeReport(reportDir, report)
+	if err != nil {
+		l.Error("Failed to write crash report", "crash_id", report.ID, "error", err)
+		return report
+	}
+	report.Path = path
+	l.Error("Crash report written", "crash_id", report.ID, "path", path)
+	return report
+}
+
+// collectState takes every registered snapshot, recording providers that
+// panic or time out instead of their state
+func collectState(snapshot []provider) (map[string]any, map[string]string) {
+	if len(snapshot) == 0 {
+		return nil, nil
+	}
+
+	state := make(map[string]any)
+	stateErrors := make(map[string]string)
+	for _, p := range snapshot {
+		value, err := runBounded(func() any { return p.fn() })
+		if err != nil {
+			stateErrors[p.name] = err.Error()
+			continue
+		}
+		state[p.name] = value
+	}
+	if len(stateErrors) == 0 {
+		stateErrors = nil
+	}
+	return state, stateErrors
+}
+
+// runFatalHooks runs the fatal hooks in registration order
+func runFatalHooks() {
+	mu.RLock()
+	snapshot := slices.Clone(hooks)
+	l := log
+	mu.RUnlock()
+
+	for _, hook := range snapshot {
+		if _, err := runBounded(func() any { hook(); return nil }); err != nil && l != nil {
+			l.Error("Crash hook failed", "error", err)
+		}
+	}
+}
+
+// runBounded runs fn with a timeout, turning a panic into an error
+func runBounded(fn func() any) (any, error) {
+	type result struct {
+		value any
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{err: fmt.Errorf("panicked: %v", r)}
+			}
+		}()
+		done <- result{value: fn()}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-time.After(stateTimeout):
+		return nil, fmt.Errorf("timed out after %s", stateTimeout)
+	}
+}
+
+// writeReport writes the report as JSON and prunes the oldest reports beyond
+// maxReports
+func writeReport(reportDir string, report *Report) (string, error) {
+	if err := os.MkdirAll(reportDir, 0750); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		// A state value that does not encode must not lose the stack
+		report.StateErrors = map[string]string{"encode": err.Error()}
+		report.State = nil
+		if data, err = json.MarshalIndent(report, "", "  "); err != nil {
+			return "", err
+		}
+	}
+
+	name := fmt.Sprintf("crash-%s-%s.json", report.Time.UTC().Format("20060102T150405Z"), report.ID)
+	path := filepath.Join(reportDir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", err
+	}
+
+	prune(reportDir)
+	return path, nil
+}
+
+// prune removes the oldest reports beyond maxReports. Report names sort by
+// time.
+func prune(reportDir string) {
+	entries, err := os.ReadDir(reportDir)
+	if err != nil {
+		return
+	}
+
+	var reports []string
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasPrefix(e.Name(), "crash-") &&
+			strings.HasSuffix(e.Name(), ".json") {
+			reports = append(reports, e.Name())
+		}
+	}
+	if len(reports) <= maxReports {
+		return
+	}
+	sort.Strings(reports)
+	for _, name := range reports[:len(reports)-maxReports] {
+		os.Remove(filepath.Join(reportDir, name))
+	}
+}
+
+// Counts returns the number of panics recovered since start, by source
+func Counts() map[string]uint64 {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return maps.Clone(counts)
+}
+
+// collector exports the crash counter
+type collector struct{}
+
+// WritePrometheus writes rodent_panics_total by source
+func (collector) WritePrometheus(w io.Writer) error {
+	var b strings.Builder
+	metrics.WriteHeader(&b, "rodent_panics_total", "counter",
+		"Panics recovered since Rodent started, by where they happened")
+
+	c := Counts()
+	for _, source := range []string{SourceHTTP, SourceJob, SourceScheduler, SourceGoroutine} {
+		metrics.WriteSample(&b, "rodent_panics_total", float64(c[source]), "source", source)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reset points the package at a fresh report directory
+func reset(t *testing.T) string {
+	t.Helper()
+
+	reportDir := t.TempDir()
+	mu.Lock()
+	log, dir = common.Log, reportDir
+	providers, hooks = nil, nil
+	clear(counts)
+	mu.Unlock()
+
+	t.Cleanup(func() { exit = os.Exit })
+	return reportDir
+}
+
+func TestHandleWritesReport(t *testing.T) {
+	reset(t)
+	AddState("transfers", func() any { return []string{"t1"} })
+	AddState("broken", func() any { panic("no state") })
+
+	report := Handle(SourceHTTP, "GET /api/v1/rodent/zfs/pools", "req-1", "boom", []byte("stack"))
+	require.NotEmpty(t, report.Path)
+
+	data, err := os.ReadFile(report.Path)
+	require.NoError(t, err)
+	var written Report
+	require.NoError(t, json.Unmarshal(data, &written))
+
+	assert.Equal(t, report.ID, written.ID)
+	assert.Equal(t, "req-1", written.CorrelationID)
+	assert.Equal(t, "boom", written.Panic)
+	assert.Equal(t, "stack", written.Stack)
+	assert.False(t, written.Fatal)
+	assert.Equal(t, []any{"t1"}, written.State["transfers"])
+	assert.Contains(t, written.StateErrors["broken"], "no state")
+	assert.Equal(t, uint64(1), Counts()[SourceHTTP])
+}
+
+func TestRecoverRunsHooksAndExits(t *testing.T) {
+	reportDir := reset(t)
+
+	var hooked bool
+	OnFatal(func() { hooked = true })
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+
+	func() {
+		defer Recover("scheduler")
+		panic(fmt.Errorf("nil map"))
+	}()
+
+	assert.True(t, hooked)
+	assert.Equal(t, ExitCode, exitCode)
+	assert.Equal(t, uint64(1), Counts()[SourceGoroutine])
+
+	reports, err := filepath.Glob(filepath.Join(reportDir, "crash-*.json"))
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	data, err := os.ReadFile(reports[0])
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"fatal": true`)
+	assert.Contains(t, string(data), `"component": "scheduler"`)
+}
+
+func TestReportsArePruned(t *testing.T) {
+	reportDir := reset(t)
+	for i := range maxReports + 3 {
+		Handle(SourceJob, "jobs/test", fmt.Sprint(i), "boom", nil)
+	}
+
+	reports, err := filepath.Glob(filepath.Join(reportDir, "crash-*.json"))
+	require.NoError(t, err)
+	assert.Len(t, reports, maxReports)
+}
+
+func TestWritePrometheus(t *testing.T) {
+	reset(t)
+	Handle(SourceJob, "jobs/test", "", "boom", nil)
+
+	var b strings.Builder
+	require.NoError(t, collector{}.WritePrometheus(&b))
+	assert.Contains(t, b.String(), `rodent_panics_total{source="job"} 1`)
+	assert.Contains(t, b.String(), `rodent_panics_total{source="http"} 0`)
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package daemon is the CLI's client for the running Rodent daemon. CLI
+// commands that change state go through the daemon's REST API, so the
+// daemon's managers, schedulers and caches stay the single source of truth.
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/httpclient"
+)
+
+// DefaultTimeout bounds a request to the daemon. Domain joins and service
+// reloads run inside the request, so it is generous.
+const DefaultTimeout = 10 * time.Minute
+
+// ClientConfig returns an HTTP client configuration for the daemon's API:
+// its Unix socket when one is configured, and the IPv4 loopback otherwise,
+// which the API listens on whether it is bound or not
+func ClientConfig(cfg *config.Config) httpclient.ClientConfig {
+	clientConfig := httpclient.NewClientConfig()
+	clientConfig.BaseURL = fmt.Sprintf("http://127.0.0.1:%d", cfg.Server.Port)
+	if cfg.Server.Socket != "" {
+		clientConfig.BaseURL = "http://rodent"
+		clientConfig.UnixSocket = cfg.Server.Socket
+	}
+	return clientConfig
+}
+
+// Client calls the daemon's REST API
+type Client struct {
+	http    *httpclient.Client
+	address string // For error messages
+}
+
+// NewClient creates a client for the daemon described by cfg. Requests are
+// not retried, as most of them change state.
+func NewClient(cfg *config.Config) *Client {
+	clientConfig := ClientConfig(cfg)
+	clientConfig.Timeout = DefaultTimeout
+	clientConfig.RetryCount = 0
+
+	address := clientConfig.BaseURL
+	if clientConfig.UnixSocket != "" {
+		address = "unix:" + clientConfig.UnixSocket
+	}
+	return &Client{http: httpclient.NewClient(clientConfig), address: address}
+}
+
+// apiError is the error body written by the API
+type apiError struct {
+	Error *struct {
+		Code     errors.ErrorCode  `json:"code"`
+		Domain   errors.Domain     `json:"domain"`
+		Message  string            `json:"message"`
+		Details  string            `json:"details"`
+		Metadata map[string]string `json:"metadata"`
+	} `json:"error"`
+}
+
+// Do sends a request with body encoded as JSON, if not nil, and decodes a
+// successful response into out, if not nil. Errors returned by the daemon are
+// returned as they were raised; a daemon that cannot be reached is reported
+// with ServerDaemonUnreachable.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	req := c.http.R().SetContext(ctx)
+	if body != nil {
+		req.SetHeader("Content-Type", "application/json").SetBody(body)
+	}
+
+	resp, err := req.Execute(method, path)
+	if err != nil {
+		return errors.New(errors.ServerDaemonUnreachable,
+			"Rodent daemon is not reachable; is it running? Use --local to run without it").
+			WithMetadata("address", c.address).
+			WithMetadata("error", err.Error())
+	}
+
+	if resp.IsError() {
+		var apiErr apiError
+		if err := json.Unmarshal(resp.Body(), &apiErr); err != nil || apiErr.Error == nil {
+			return errors.New(errors.ServerResponseError,
+				fmt.Sprintf("daemon returned %s", resp.Status())).
+				WithMetadata("path", path)
+		}
+		e := apiErr.Error
+		if e.Code == 0 {
+			e.Code = errors.ServerInternalError
+			e.Domain = errors.DomainServer
+		}
+		return &errors.RodentError{
+			Code:       e.Code,
+			Domain:     e.Domain,
+			Message:    e.Message,
+			Details:    e.Details,
+			Metadata:   e.Metadata,
+			HTTPStatus: resp.StatusCode(),
+		}
+	}
+
+	if out != nil && resp.StatusCode() != http.StatusNoContent {
+		if err := json.Unmarshal(resp.Body(), out); err != nil {
+			return errors.Wrap(err, errors.ServerResponseError).WithMetadata("path", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientOverSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "rodent.sock")
+	ln, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST("/echo", func(c *gin.Context) {
+		var body map[string]string
+		require.NoError(t, c.ShouldBindJSON(&body))
+		c.JSON(http.StatusOK, body)
+	})
+	engine.GET("/fail", func(c *gin.Context) {
+		common.APIError(c, errors.New(errors.ConfigValidationFailed, "no realm configured"))
+	})
+	srv := &http.Server{Handler: engine}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	cfg := &config.Config{}
+	cfg.Server.Port = 1
+	cfg.Server.Socket = socket
+	client := NewClient(cfg)
+	ctx := context.Background()
+
+	var out map[string]string
+	require.NoError(t, client.Do(ctx, http.MethodPost, "/echo", map[string]string{"realm": "AD.EXAMPLE"}, &out))
+	assert.Equal(t, "AD.EXAMPLE", out["realm"])
+
+	err = client.Do(ctx, http.MethodGet, "/fail", nil, nil)
+	var re *errors.RodentError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.ConfigValidationFailed), re.Code)
+	assert.Equal(t, http.StatusBadRequest, re.HTTPStatus)
+
+	cfg.Server.Socket = filepath.Join(t.TempDir(), "missing.sock")
+	err = NewClient(cfg).Do(ctx, http.MethodGet, "/echo", nil, nil)
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.ServerDaemonUnreachable), re.Code)
+}
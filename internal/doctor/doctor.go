@@ -0,0 +1,228 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package doctor gathers diagnostics for support into a single tarball:
+// configuration with secrets redacted, recent logs, policy and transfer state,
+// ZFS, SMB and domain status. Every file is capped in size, as is the bundle,
+// and secrets are redacted from all text before it is written.
+package doctor
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"time"
+)
+
+// Sections group the items of a bundle, so whole sections can be left out
+const (
+	SectionConfig = "config"
+	SectionLogs   = "logs"
+	SectionState  = "state"
+	SectionZFS    = "zfs"
+	SectionSMB    = "smb"
+	SectionDomain = "domain"
+	SectionSystem = "system"
+)
+
+// Sections lists every section in bundle order
+var Sections = []string{
+	SectionConfig,
+	SectionLogs,
+	SectionState,
+	SectionZFS,
+	SectionSMB,
+	SectionDomain,
+	SectionSystem,
+}
+
+// Entry statuses in the manifest
+const (
+	StatusOK        = "ok"
+	StatusTruncated = "truncated" // Cut to the per-file size cap
+	StatusFailed    = "failed"    // Could not be collected; the error is kept instead
+	StatusSkipped   = "skipped"   // Left out because the bundle reached its size cap
+)
+
+const (
+	// DefaultMaxFileBytes caps each file in the bundle
+	DefaultMaxFileBytes = 5 << 20
+
+	// DefaultMaxTotalBytes caps the uncompressed size of the bundle
+	DefaultMaxTotalBytes = 50 << 20
+
+	// collectTimeout bounds the collection of each item
+	collectTimeout = 30 * time.Second
+
+	manifestName = "manifest.json"
+)
+
+// Item is one file of the bundle and how to collect it
+type Item struct {
+	Name        string // Path in the bundle
+	Section     string
+	Description string
+
+	// Tail keeps the end of the content when it is over the size cap, for
+	// logs where the latest lines matter most
+	Tail bool
+
+	collect func(ctx context.Context, limit int64) ([]byte, error)
+}
+
+// Options limits what goes into a bundle
+type Options struct {
+	MaxFileBytes  int64
+	MaxTotalBytes int64
+}
+
+// Entry records what was written for an item
+type Entry struct {
+	Name    string `json:"name"`
+	Section string `json:"section"`
+	Status  string `json:"status"`
+	Bytes   int64  `json:"bytes"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Manifest describes a bundle; it is written into the bundle as manifest.json
+type Manifest struct {
+	CreatedAt     time.Time `json:"created_at"`
+	Version       string    `json:"version"`
+	Hostname      string    `json:"hostname"`
+	MaxFileBytes  int64     `json:"max_file_bytes"`
+	MaxTotalBytes int64     `json:"max_total_bytes"`
+	TotalBytes    int64     `json:"total_bytes"`
+	Entries       []Entry   `json:"entries"`
+}
+
+// Filter returns the items not in the excluded sections
+func Filter(items []Item, exclude []string) []Item {
+	excluded := make(map[string]bool, len(exclude))
+	for _, s := range exclude {
+		excluded[s] = true
+	}
+
+	var kept []Item
+	for _, item := range items {
+		if !excluded[item.Section] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// Write collects the items and writes them as a gzipped tarball under dir,
+// with the manifest first. Items that fail to collect are written with their
+// error, so a partial bundle is still useful.
+func Write(
+	ctx context.Context,
+	w io.Writer,
+	dir string,
+	items []Item,
+	manifest *Manifest,
+	opts Options,
+) error {
+	if opts.MaxFileBytes <= 0 {
+		opts.MaxFileBytes = DefaultMaxFileBytes
+	}
+	if opts.MaxTotalBytes <= 0 {
+		opts.MaxTotalBytes = DefaultMaxTotalBytes
+	}
+	manifest.MaxFileBytes = opts.MaxFileBytes
+	manifest.MaxTotalBytes = opts.MaxTotalBytes
+
+	contents := make([][]byte, len(items))
+	for i, item := range items {
+		entry := Entry{Name: item.Name, Section: item.Section, Status: StatusOK}
+		if manifest.TotalBytes >= opts.MaxTotalBytes {
+			entry.Status = StatusSkipped
+			manifest.Entries = append(manifest.Entries, entry)
+			continue
+		}
+
+		data, err := collect(ctx, item, opts.MaxFileBytes)
+		if err != nil {
+			entry.Status = StatusFailed
+			entry.Error = RedactText(err.Error())
+			data = append(data, []byte("\nerror: "+entry.Error+"\n")...)
+		}
+		data = []byte(RedactText(string(data)))
+
+		limit := min(opts.MaxFileBytes, opts.MaxTotalBytes-manifest.TotalBytes)
+		if int64(len(data)) > limit {
+			data = truncate(data, limit, item.Tail)
+			if entry.Status == StatusOK {
+				entry.Status = StatusTruncated
+			}
+		}
+
+		entry.Bytes = int64(len(data))
+		manifest.TotalBytes += entry.Bytes
+		manifest.Entries = append(manifest.Entries, entry)
+		contents[i] = data
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, path.Join(dir, manifestName), manifestData, manifest.CreatedAt); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if manifest.Entries[i].Status == StatusSkipped {
+			continue
+		}
+		if err := writeFile(tw, path.Join(dir, item.Name), contents[i], manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// collect runs an item's collector with a timeout, reading at most a little
+// over the limit so truncation can be detected
+func collect(ctx context.Context, item Item, limit int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, collectTimeout)
+	defer cancel()
+	return item.collect(ctx, limit)
+}
+
+// truncate cuts data to limit bytes, keeping the head or the tail, and notes
+// how much was cut
+func truncate(data []byte, limit int64, tail bool) []byte {
+	note := fmt.Sprintf("\n[truncated %d bytes]\n", int64(len(data))-limit)
+	keep := max(0, limit-int64(len(note)))
+	if tail {
+		return append([]byte(note), data[int64(len(data))-keep:]...)
+	}
+	return append(data[:keep:keep], note...)
+}
+
+// writeFile adds a regular file to the tarball
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
@@ -0,0 +1,182 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package doctor
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactText(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"adminPassword: hunter2", "adminPassword: " + Redacted},
+		{`{"jwt":"abc.def","port":8042}`, `{"jwt":"` + Redacted + `","port":8042}`},
+		{"net ads join --password=hunter2 -k", "net ads join --password=" + Redacted + " -k"},
+		{"Authorization: Bearer abc123", "Authorization: Bearer " + Redacted},
+		{"token eyJhbGciOi.eyJzdWIiOi.c2lnbmF0dXJl sent", "token " + Redacted + " sent"},
+		{"pool tank is ONLINE", "pool tank is ONLINE"},
+	}
+	for _, tt := range tests {
+		if got := RedactText(tt.in); got != tt.want {
+			t.Errorf("RedactText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	cfg := struct {
+		Toggle struct {
+			JWT     string
+			BaseURL string
+		}
+		AD struct {
+			AdminPassword string
+			Realm         string
+		}
+		Logger struct {
+			SentryDSN string
+		}
+	}{}
+	cfg.Toggle.JWT = "secret-jwt"
+	cfg.Toggle.BaseURL = "https://strata.example"
+	cfg.AD.Realm = "AD.EXAMPLE.COM"
+
+	out, err := RedactConfig(cfg)
+	if err != nil {
+		t.Fatalf("RedactConfig: %v", err)
+	}
+	text := string(out)
+
+	if strings.Contains(text, "secret-jwt") {
+		t.Errorf("JWT not redacted:\n%s", text)
+	}
+	for _, want := range []string{
+		"jwt: '" + Redacted + "'",
+		"baseurl: https://strata.example",
+		"realm: AD.EXAMPLE.COM",
+		`adminpassword: ""`, // Unset secrets stay visible as unset
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	content := func(data string, err error) func(context.Context, int64) ([]byte, error) {
+		return func(context.Context, int64) ([]byte, error) { return []byte(data), err }
+	}
+	items := []Item{
+		{Name: "config/rodent.yml", Section: SectionConfig, collect: content("password: x\n", nil)},
+		{Name: "logs/rodent.log", Section: SectionLogs, Tail: true,
+			collect: content(strings.Repeat("old\n", 50)+"latest\n", nil)},
+		{Name: "zfs/zpool-status.txt", Section: SectionZFS,
+			collect: content("partial", fmt.Errorf("zpool: exit status 1"))},
+		{Name: "smb/testparm.txt", Section: SectionSMB, collect: content(strings.Repeat("x", 100), nil)},
+		{Name: "system/uname.txt", Section: SectionSystem, collect: content("Linux", nil)},
+	}
+
+	var buf bytes.Buffer
+	manifest := &Manifest{CreatedAt: time.Now()}
+	opts := Options{MaxFileBytes: 64, MaxTotalBytes: 180}
+	if err := Write(context.Background(), &buf, "bundle", items, manifest, opts); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	statuses := make(map[string]string)
+	for _, e := range manifest.Entries {
+		statuses[e.Name] = e.Status
+	}
+	wantStatuses := map[string]string{
+		"config/rodent.yml":    StatusOK,
+		"logs/rodent.log":      StatusTruncated,
+		"zfs/zpool-status.txt": StatusFailed,
+		"smb/testparm.txt":     StatusTruncated,
+		"system/uname.txt":     StatusSkipped,
+	}
+	for name, want := range wantStatuses {
+		if statuses[name] != want {
+			t.Errorf("%s status = %q, want %q", name, statuses[name], want)
+		}
+	}
+	if manifest.TotalBytes > opts.MaxTotalBytes {
+		t.Errorf("total %d bytes over the %d byte cap", manifest.TotalBytes, opts.MaxTotalBytes)
+	}
+
+	files := readBundle(t, buf.Bytes())
+	if _, ok := files["bundle/system/uname.txt"]; ok {
+		t.Error("skipped item written to the bundle")
+	}
+	if got := files["bundle/config/rodent.yml"]; got != "password: "+Redacted+"\n" {
+		t.Errorf("config = %q, want secrets redacted", got)
+	}
+	if got := files["bundle/logs/rodent.log"]; !strings.HasSuffix(got, "latest\n") ||
+		!strings.HasPrefix(got, "\n[truncated") || len(got) > 64 {
+		t.Errorf("log = %q, want its tail within 64 bytes", got)
+	}
+	if got := files["bundle/zfs/zpool-status.txt"]; !strings.Contains(got, "partial") ||
+		!strings.Contains(got, "error: zpool: exit status 1") {
+		t.Errorf("failed item = %q, want output and error", got)
+	}
+
+	var written Manifest
+	if err := json.Unmarshal([]byte(files["bundle/manifest.json"]), &written); err != nil {
+		t.Fatalf("manifest: %v", err)
+	}
+	if len(written.Entries) != len(items) {
+		t.Errorf("manifest has %d entries, want %d", len(written.Entries), len(items))
+	}
+}
+
+func TestFilter(t *testing.T) {
+	items := []Item{
+		{Name: "a", Section: SectionConfig},
+		{Name: "b", Section: SectionLogs},
+		{Name: "c", Section: SectionZFS},
+	}
+	kept := Filter(items, []string{SectionLogs})
+	if len(kept) != 2 || kept[0].Name != "a" || kept[1].Name != "c" {
+		t.Errorf("Filter kept %v", kept)
+	}
+}
+
+// readBundle returns the files of a gzipped tarball by name
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("tar: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", hdr.Name, err)
+		}
+		files[hdr.Name] = string(content)
+	}
+	return files
+}
@@ -0,0 +1,64 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package doctor
+
+import (
+	"github.com/stratastor/rodent/internal/common"
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secret values
+const Redacted = common.Redacted
+
+// RedactText hides secrets in free-form text such as logs and command output
+func RedactText(text string) string {
+	return common.RedactText(text)
+}
+
+// RedactConfig renders a configuration as YAML with the values of
+// secret-named keys replaced
+func RedactConfig(cfg any) ([]byte, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(redactTree(tree))
+}
+
+// redactTree replaces non-empty values under secret-named keys
+func redactTree(node any) any {
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if common.IsSecretKey(key) && !isEmpty(value) {
+				n[key] = Redacted
+				continue
+			}
+			n[key] = redactTree(value)
+		}
+	case []any:
+		for i, value := range n {
+			n[i] = redactTree(value)
+		}
+	}
+	return node
+}
+
+// isEmpty reports whether a value is unset, so unset secrets stay visible as
+// unset
+func isEmpty(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	}
+	return false
+}
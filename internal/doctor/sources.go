@@ -0,0 +1,190 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stratastor/rodent/internal/selftest"
+)
+
+// Items lists everything a bundle can include for the configuration
+func Items(cfg *config.Config, l logger.Logger) []Item {
+	items := []Item{
+		{
+			Name:        "config/rodent.yml",
+			Section:     SectionConfig,
+			Description: "Loaded configuration, secrets redacted",
+			collect: func(ctx context.Context, limit int64) ([]byte, error) {
+				return RedactConfig(cfg)
+			},
+		},
+	}
+
+	if cfg.Logs.Output != "stdout" && cfg.Logs.Path != "" {
+		items = append(items, fileItem("logs/rodent.log", SectionLogs,
+			"End of the Rodent log file", cfg.Logs.Path, true))
+	}
+	items = append(items, commandItem("logs/journal.txt", SectionLogs,
+		"Recent Rodent service journal", true,
+		"journalctl", "-u", "rodent.service", "-n", "5000", "--no-pager"))
+
+	items = append(items, dirItems("state/policies", SectionState,
+		"Snapshot and transfer policy", config.GetPoliciesDir())...)
+	items = append(items, dirItems("state/transfers", SectionState,
+		"Transfer state", config.GetTransfersDir())...)
+	items = append(items, dirItems("state/crash", SectionState,
+		"Crash report", crash.DefaultDir())...)
+
+	items = append(items,
+		commandItem("zfs/version.txt", SectionZFS, "OpenZFS version", false,
+			"zfs", "version"),
+		commandItem("zfs/zpool-status.txt", SectionZFS, "Pool status and errors", false,
+			"zpool", "status", "-v"),
+		commandItem("zfs/zpool-list.txt", SectionZFS, "Pool and vdev capacity", false,
+			"zpool", "list", "-v"),
+		commandItem("zfs/zpool-get.txt", SectionZFS, "Pool properties", false,
+			"zpool", "get", "all"),
+		commandItem("zfs/zfs-list.txt", SectionZFS, "Filesystems and volumes", false,
+			"zfs", "list", "-t", "filesystem,volume",
+			"-o", "name,used,avail,refer,mountpoint,compression,encryption"),
+		commandItem("zfs/zfs-get-local.txt", SectionZFS, "Locally set and received dataset properties", false,
+			"zfs", "get", "-s", "local,received", "all"),
+		fileItem("zfs/arcstats.txt", SectionZFS, "ARC statistics",
+			"/proc/spl/kstat/zfs/arcstats", false),
+
+		commandItem("smb/testparm.txt", SectionSMB, "Effective Samba configuration", false,
+			"testparm", "-s"),
+
+		commandItem("domain/net-ads-info.txt", SectionDomain, "Joined domain and its DC", false,
+			"net", "ads", "info"),
+		commandItem("domain/net-ads-testjoin.txt", SectionDomain, "Machine account check", false,
+			"net", "ads", "testjoin"),
+		commandItem("domain/wbinfo-online.txt", SectionDomain, "Winbind domain online status", false,
+			"wbinfo", "--online-status"),
+		fileItem("domain/krb5.conf", SectionDomain, "Kerberos configuration",
+			"/etc/krb5.conf", false),
+
+		Item{
+			Name:        "system/version.txt",
+			Section:     SectionSystem,
+			Description: "Rodent version",
+			collect: func(ctx context.Context, limit int64) ([]byte, error) {
+				return fmt.Appendf(nil, "Version: %s\nCommit: %s\nBuild Time: %s\n",
+					constants.Version, constants.CommitSHA, constants.BuildTime), nil
+			},
+		},
+		Item{
+			Name:        "system/selftest.json",
+			Section:     SectionSystem,
+			Description: "Dependency self-test report",
+			collect: func(ctx context.Context, limit int64) ([]byte, error) {
+				return json.MarshalIndent(selftest.Run(ctx, l, selftest.Options{}), "", "  ")
+			},
+		},
+		commandItem("system/uname.txt", SectionSystem, "Kernel", false, "uname", "-a"),
+		fileItem("system/os-release", SectionSystem, "Distribution", "/etc/os-release", false),
+		commandItem("system/service.txt", SectionSystem, "Rodent service status", false,
+			"systemctl", "status", "rodent.service", "--no-pager"),
+	)
+
+	return items
+}
+
+// fileItem collects a file, reading only its head or tail past the limit
+func fileItem(name, section, description, file string, tail bool) Item {
+	return Item{
+		Name:        name,
+		Section:     section,
+		Description: description,
+		Tail:        tail,
+		collect: func(ctx context.Context, limit int64) ([]byte, error) {
+			return readFile(file, limit, tail)
+		},
+	}
+}
+
+// dirItems lists a file item for every regular file under dir. A missing
+// directory yields no items.
+func dirItems(prefix, section, description, dir string) []Item {
+	var items []Item
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return nil
+		}
+		items = append(items, fileItem(path.Join(prefix, filepath.ToSlash(rel)),
+			section, description, p, false))
+		return nil
+	})
+	return items
+}
+
+// commandItem collects the combined output of a command. Output is kept when
+// the command fails, as diagnostics often fail on the broken system they
+// describe.
+func commandItem(name, section, description string, tail bool, bin string, args ...string) Item {
+	return Item{
+		Name:        name,
+		Section:     section,
+		Description: description,
+		Tail:        tail,
+		collect: func(ctx context.Context, limit int64) ([]byte, error) {
+			var out bytes.Buffer
+			cmd := exec.CommandContext(ctx, bin, args...)
+			cmd.Stdout = &out
+			cmd.Stderr = &out
+			err := cmd.Run()
+
+			header := "$ " + bin + " " + strings.Join(args, " ") + "\n"
+			data := append([]byte(header), out.Bytes()...)
+			if err != nil {
+				return data, fmt.Errorf("%s: %w", bin, err)
+			}
+			return data, nil
+		},
+	}
+}
+
+// readFile reads at most limit+1 bytes from the head or tail of a file, so
+// the caller can tell it was cut
+func readFile(name string, limit int64, tail bool) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if tail {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		// Files in /proc report a size of 0 and are read from the start
+		if offset := info.Size() - limit - 1; offset > 0 {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return io.ReadAll(io.LimitReader(f, limit+1))
+}
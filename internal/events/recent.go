@@ -0,0 +1,62 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package events
+
+import (
+	"sync"
+	"time"
+
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// maxRecentEvents bounds the events kept for crash reports
+const maxRecentEvents = 100
+
+// RecentEvent summarises an emitted event
+type RecentEvent struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Level    string    `json:"level"`
+	Category string    `json:"category"`
+	Source   string    `json:"source"`
+}
+
+var (
+	recentMu   sync.Mutex
+	recent     [maxRecentEvents]RecentEvent
+	recentNext int
+	recentLen  int
+)
+
+// recordRecent adds an event to the ring of recent events, overwriting the
+// oldest once it is full
+func recordRecent(event *eventspb.Event) {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+
+	recent[recentNext] = RecentEvent{
+		ID:       event.EventId,
+		Time:     time.UnixMilli(event.Timestamp),
+		Level:    event.Level.String(),
+		Category: event.Category.String(),
+		Source:   event.Source,
+	}
+	recentNext = (recentNext + 1) % maxRecentEvents
+	recentLen = min(recentLen+1, maxRecentEvents)
+}
+
+// Recent returns the most recently emitted events, oldest first. Events are
+// recorded even when the event system is not initialized.
+func Recent() []RecentEvent {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+
+	events := make([]RecentEvent, 0, recentLen)
+	start := (recentNext - recentLen + maxRecentEvents) % maxRecentEvents
+	for i := range recentLen {
+		events = append(events, recent[(start+i)%maxRecentEvents])
+	}
+	return events
+}
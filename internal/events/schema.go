@@ -221,6 +221,31 @@ func EmitSystemUser(
 	emitStructuredEvent(event)
 }
 
+// Security Events
+
+func EmitSecurityAuth(
+	level eventspb.EventLevel,
+	payload *eventspb.SecurityAuthPayload,
+	metadata map[string]string,
+) {
+	event := &eventspb.Event{
+		EventId:   generateEventID(),
+		Level:     level,
+		Category:  eventspb.EventCategory_EVENT_CATEGORY_SECURITY,
+		Source:    "security",
+		Timestamp: time.Now().UnixMilli(),
+		Metadata:  metadata,
+		EventPayload: &eventspb.Event_SecurityEvent{
+			SecurityEvent: &eventspb.SecurityEvent{
+				EventType: &eventspb.SecurityEvent_AuthEvent{
+					AuthEvent: payload,
+				},
+			},
+		},
+	}
+	emitStructuredEvent(event)
+}
+
 // Service Events
 
 func EmitServiceConfigChange(
@@ -344,6 +369,31 @@ func EmitDataTransfer(
 	emitStructuredEvent(event)
 }
 
+// Sharing Events
+
+func EmitSharingFileAccess(
+	level eventspb.EventLevel,
+	payload *eventspb.SharingFileAccessPayload,
+	metadata map[string]string,
+) {
+	event := &eventspb.Event{
+		EventId:   generateEventID(),
+		Level:     level,
+		Category:  eventspb.EventCategory_EVENT_CATEGORY_SHARING,
+		Source:    "smb-share-manager",
+		Timestamp: time.Now().UnixMilli(),
+		Metadata:  metadata,
+		EventPayload: &eventspb.Event_SharingEvent{
+			SharingEvent: &eventspb.SharingEvent{
+				EventType: &eventspb.SharingEvent_FileAccessEvent{
+					FileAccessEvent: payload,
+				},
+			},
+		},
+	}
+	emitStructuredEvent(event)
+}
+
 // Add more emission functions for other categories as needed...
 
 // HELPER FUNCTIONS
@@ -360,6 +410,9 @@ func emitStructuredEvent(event *eventspb.Event) {
 		event.Metadata = make(map[string]string)
 	}
 
+	// Kept for crash reports whether or not the event can be sent
+	recordRecent(event)
+
 	// Send directly to the global event bus
 	if GlobalEventBus != nil {
 		GlobalEventBus.EmitStructuredEvent(event)
@@ -0,0 +1,211 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package lockwatch finds lock contention in the long-lived managers. Their
+// RWMutex records how long callers wait for it and how long the write lock
+// is held, and a Watcher logs a warning with a goroutine dump when either
+// passes a threshold, showing who holds the lock and who is stuck behind it.
+package lockwatch
+
+import (
+	"bytes"
+	"maps"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stratastor/logger"
+)
+
+const (
+	// DefaultInterval and DefaultThreshold apply to zero Watcher settings
+	DefaultInterval  = 10 * time.Second
+	DefaultThreshold = 5 * time.Second
+
+	// dumpInterval limits goroutine dumps to one per lock in this period;
+	// warnings in between are logged without one
+	dumpInterval = 5 * time.Minute
+
+	// maxDumpBytes caps a goroutine dump in the log
+	maxDumpBytes = 256 << 10
+)
+
+// RWMutex is a sync.RWMutex that records its wait and hold times. The zero
+// value is an unlocked mutex; Watch names it for the Watcher.
+type RWMutex struct {
+	mu        sync.RWMutex
+	heldSince atomic.Int64 // UnixNano the write lock was taken, 0 when not held
+	maxWait   atomic.Int64 // Longest wait since the last Stats, in nanoseconds
+	waiting   atomic.Int32
+}
+
+// Lock locks m for writing
+func (m *RWMutex) Lock() {
+	start := time.Now()
+	m.waiting.Add(1)
+	m.mu.Lock()
+	m.waiting.Add(-1)
+
+	now := time.Now()
+	m.recordWait(now.Sub(start))
+	m.heldSince.Store(now.UnixNano())
+}
+
+// Unlock unlocks m for writing
+func (m *RWMutex) Unlock() {
+	m.heldSince.Store(0)
+	m.mu.Unlock()
+}
+
+// RLock locks m for reading
+func (m *RWMutex) RLock() {
+	start := time.Now()
+	m.waiting.Add(1)
+	m.mu.RLock()
+	m.waiting.Add(-1)
+	m.recordWait(time.Since(start))
+}
+
+// RUnlock undoes a single RLock call
+func (m *RWMutex) RUnlock() {
+	m.mu.RUnlock()
+}
+
+// recordWait keeps the longest wait
+func (m *RWMutex) recordWait(wait time.Duration) {
+	for {
+		current := m.maxWait.Load()
+		if int64(wait) <= current || m.maxWait.CompareAndSwap(current, int64(wait)) {
+			return
+		}
+	}
+}
+
+// Stats are a mutex's contention since the previous Stats call
+type Stats struct {
+	MaxWait time.Duration // Longest wait for the lock
+	HeldFor time.Duration // How long the write lock has been held; 0 when not held
+	Waiting int           // Callers blocked on the lock now
+}
+
+// Stats returns the contention since the previous call and starts a new
+// period
+func (m *RWMutex) Stats(now time.Time) Stats {
+	s := Stats{
+		MaxWait: time.Duration(m.maxWait.Swap(0)),
+		Waiting: int(m.waiting.Load()),
+	}
+	if since := m.heldSince.Load(); since != 0 {
+		s.HeldFor = now.Sub(time.Unix(0, since))
+	}
+	return s
+}
+
+var (
+	watchedMu sync.Mutex
+	watched   = make(map[string]*RWMutex)
+)
+
+// Watch names a mutex for the Watcher. A later mutex with the same name
+// replaces the earlier one.
+func Watch(name string, m *RWMutex) {
+	watchedMu.Lock()
+	defer watchedMu.Unlock()
+	watched[name] = m
+}
+
+// Watcher periodically checks the watched mutexes
+type Watcher struct {
+	logger    logger.Logger
+	interval  time.Duration
+	threshold time.Duration
+
+	lastDump map[string]time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewWatcher creates a watcher warning when a wait or write hold exceeds
+// threshold. Zero durations take the defaults.
+func NewWatcher(l logger.Logger, interval, threshold time.Duration) *Watcher {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+	return &Watcher{
+		logger:    l,
+		interval:  interval,
+		threshold: threshold,
+		lastDump:  make(map[string]time.Time),
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start checks the watched mutexes in the background until Stop is called
+func (w *Watcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stopCh:
+				return
+			case now := <-ticker.C:
+				w.check(now)
+			}
+		}
+	}()
+}
+
+// Stop stops the background checks
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stopCh) })
+}
+
+// check warns about every watched mutex over the threshold, reporting the
+// names warned about
+func (w *Watcher) check(now time.Time) []string {
+	watchedMu.Lock()
+	locks := maps.Clone(watched)
+	watchedMu.Unlock()
+
+	var contended []string
+	for _, name := range slices.Sorted(maps.Keys(locks)) {
+		s := locks[name].Stats(now)
+		if s.MaxWait < w.threshold && s.HeldFor < w.threshold {
+			continue
+		}
+		contended = append(contended, name)
+
+		args := []any{
+			"lock", name,
+			"max_wait", s.MaxWait,
+			"held_for", s.HeldFor,
+			"waiting", s.Waiting,
+			"threshold", w.threshold,
+		}
+		if now.Sub(w.lastDump[name]) >= dumpInterval {
+			w.lastDump[name] = now
+			args = append(args, "goroutines", goroutineDump())
+		}
+		w.logger.Warn("Lock contention", args...)
+	}
+	return contended
+}
+
+// goroutineDump returns the stacks of all goroutines, capped at maxDumpBytes
+func goroutineDump() string {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 2)
+	if buf.Len() > maxDumpBytes {
+		buf.Truncate(maxDumpBytes)
+		buf.WriteString("\n[truncated]")
+	}
+	return buf.String()
+}
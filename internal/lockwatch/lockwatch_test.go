@@ -0,0 +1,67 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package lockwatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	var m RWMutex
+
+	m.Lock()
+	acquired := make(chan struct{})
+	go func() {
+		m.RLock()
+		close(acquired)
+		m.RUnlock()
+	}()
+	require.Eventually(t, func() bool { return m.Stats(time.Now()).Waiting == 1 },
+		time.Second, time.Millisecond)
+
+	held := m.Stats(time.Now().Add(time.Minute))
+	assert.GreaterOrEqual(t, held.HeldFor, time.Minute)
+
+	time.Sleep(20 * time.Millisecond)
+	m.Unlock()
+	<-acquired
+
+	s := m.Stats(time.Now())
+	assert.GreaterOrEqual(t, s.MaxWait, 20*time.Millisecond)
+	assert.Zero(t, s.HeldFor)
+	assert.Zero(t, s.Waiting)
+
+	// A new period starts with every Stats call
+	assert.Zero(t, m.Stats(time.Now()).MaxWait)
+}
+
+func TestWatcherCheck(t *testing.T) {
+	var busy, idle RWMutex
+	Watch("test-busy", &busy)
+	Watch("test-idle", &idle)
+
+	w := NewWatcher(common.Log, time.Second, time.Second)
+
+	busy.Lock()
+	defer busy.Unlock()
+	now := time.Now().Add(2 * time.Second)
+
+	assert.Equal(t, []string{"test-busy"}, w.check(now))
+	assert.Equal(t, now, w.lastDump["test-busy"], "first warning includes a goroutine dump")
+
+	// Still held; warned again without another dump
+	later := now.Add(time.Minute)
+	assert.Equal(t, []string{"test-busy"}, w.check(later))
+	assert.Equal(t, now, w.lastDump["test-busy"])
+}
+
+func TestGoroutineDump(t *testing.T) {
+	assert.Contains(t, goroutineDump(), "TestGoroutineDump")
+}
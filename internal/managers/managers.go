@@ -18,6 +18,7 @@ import (
 	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
 	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
 )
 
 var (
@@ -30,6 +31,9 @@ var (
 	// Policy managers
 	snapshotManager       *autosnapshots.Manager
 	transferPolicyManager *autotransfers.Manager
+
+	// Path to dataset resolution, shared with the shares subsystem
+	pathResolver *resolver.Resolver
 )
 
 // SetDatasetManager sets the shared dataset manager instance
@@ -87,3 +91,17 @@ func GetTransferPolicyManager() *autotransfers.Manager {
 	defer mu.RUnlock()
 	return transferPolicyManager
 }
+
+// SetPathResolver sets the shared path to dataset resolver instance
+func SetPathResolver(r *resolver.Resolver) {
+	mu.Lock()
+	defer mu.Unlock()
+	pathResolver = r
+}
+
+// GetPathResolver returns the shared path to dataset resolver, or nil if not set
+func GetPathResolver() *resolver.Resolver {
+	mu.RLock()
+	defer mu.RUnlock()
+	return pathResolver
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics serves the metrics of Rodent's subsystems in the Prometheus
+// text exposition format. Subsystems register a collector when they start.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Collector writes its metrics in the Prometheus text format
+type Collector interface {
+	WritePrometheus(w io.Writer) error
+}
+
+var (
+	mu         sync.RWMutex
+	collectors []Collector
+)
+
+// Register adds a collector to those written by WriteAll
+func Register(c Collector) {
+	mu.Lock()
+	defer mu.Unlock()
+	collectors = append(collectors, c)
+}
+
+// WriteAll writes the metrics of every registered collector, in the order
+// they were registered
+func WriteAll(w io.Writer) error {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	for _, c := range collectors {
+		if err := c.WritePrometheus(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteHeader writes the HELP and TYPE lines of a metric
+func WriteHeader(b *strings.Builder, name, metricType, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, metricType)
+}
+
+// WriteSample writes a sample line with label name and value pairs
+func WriteSample(b *strings.Builder, name string, value float64, labels ...string) {
+	b.WriteString(name)
+	if len(labels) > 1 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=%s", labels[i], strconv.Quote(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
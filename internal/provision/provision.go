@@ -0,0 +1,368 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package provision bootstraps a new host from an answers file: it writes the
+// configuration, creates the first pool and datasets, adds default snapshot
+// policies and enables the Rodent service. Every step checks what already
+// exists first, so provisioning can be rerun with the same answers after a
+// failure.
+package provision
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/services/systemd"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+)
+
+// ServiceName is the systemd unit the installer sets up
+const ServiceName = "rodent.service"
+
+// Answers are the choices for a new host. Config holds settings for
+// rodent.yml, in the same layout as that file.
+type Answers struct {
+	Config    map[string]any   `mapstructure:"config"`
+	Pool      *PoolAnswers     `mapstructure:"pool"`
+	Datasets  []DatasetAnswers `mapstructure:"datasets"`
+	Snapshots SnapshotAnswers  `mapstructure:"snapshots"`
+	Service   ServiceAnswers   `mapstructure:"service"`
+}
+
+// PoolAnswers describe the first pool
+type PoolAnswers struct {
+	Name       string            `mapstructure:"name"`
+	VDevs      []pool.VDevSpec   `mapstructure:"vdevs"`
+	Properties map[string]string `mapstructure:"properties"`
+	MountPoint string            `mapstructure:"mountpoint"`
+	Force      bool              `mapstructure:"force"`
+}
+
+// DatasetAnswers describe a filesystem to create
+type DatasetAnswers struct {
+	Name       string            `mapstructure:"name"`
+	Properties map[string]string `mapstructure:"properties"`
+}
+
+// SnapshotAnswers choose whether the datasets get the default snapshot
+// policies
+type SnapshotAnswers struct {
+	Enabled bool `mapstructure:"enabled"`
+}
+
+// ServiceAnswers choose whether the Rodent service is enabled and started
+type ServiceAnswers struct {
+	Enable bool `mapstructure:"enable"`
+}
+
+// Step statuses
+const (
+	StatusDone    = "done"
+	StatusSkipped = "skipped" // Already in place, or a dry run
+	StatusFailed  = "failed"
+)
+
+// Step is the outcome of one provisioning step
+type Step struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// LoadAnswers reads an answers file. Snapshot policies and the service
+// default to enabled.
+func LoadAnswers(path string) (*Answers, error) {
+	v := viper.New()
+	v.SetDefault("snapshots.enabled", true)
+	v.SetDefault("service.enable", true)
+
+	if path != "" {
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, errors.Wrap(err, errors.ConfigLoadFailed).WithMetadata("path", path)
+		}
+	}
+
+	answers := &Answers{}
+	if err := v.Unmarshal(answers); err != nil {
+		return nil, errors.Wrap(err, errors.ConfigUnmarshalFailed).WithMetadata("path", path)
+	}
+	return answers, nil
+}
+
+// Validate checks the answers before anything is changed
+func (a *Answers) Validate() error {
+	if a.Pool != nil {
+		if a.Pool.Name == "" {
+			return errors.New(errors.ConfigValidationFailed, "pool name is required")
+		}
+		if len(a.Pool.VDevs) == 0 {
+			return errors.New(errors.ConfigValidationFailed, "pool needs at least one vdev").
+				WithMetadata("pool", a.Pool.Name)
+		}
+		for _, vdev := range a.Pool.VDevs {
+			if len(vdev.Devices) == 0 && len(vdev.Children) == 0 {
+				return errors.New(errors.ConfigValidationFailed, "vdev has no devices").
+					WithMetadata("pool", a.Pool.Name).
+					WithMetadata("type", vdev.Type)
+			}
+		}
+	}
+
+	for _, ds := range a.Datasets {
+		if ds.Name == "" {
+			return errors.New(errors.ConfigValidationFailed, "dataset name is required")
+		}
+		if a.Pool != nil && !strings.HasPrefix(ds.Name, a.Pool.Name+"/") {
+			return errors.New(errors.ConfigValidationFailed, "dataset is not in the pool").
+				WithMetadata("dataset", ds.Name).
+				WithMetadata("pool", a.Pool.Name)
+		}
+	}
+	return nil
+}
+
+// ParseVDev parses a vdev flag, "[type:]device,device...", where the type is
+// mirror, raidz, raidz2, raidz3, spare, log, cache or special and plain
+// stripes have none
+func ParseVDev(value string) (pool.VDevSpec, error) {
+	vdevType, devices, ok := strings.Cut(value, ":")
+	if !ok {
+		vdevType, devices = "", value
+	}
+
+	var spec pool.VDevSpec
+	spec.Type = vdevType
+	for _, d := range strings.Split(devices, ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			spec.Devices = append(spec.Devices, d)
+		}
+	}
+	if len(spec.Devices) == 0 {
+		return spec, errors.New(errors.ConfigValidationFailed, "vdev has no devices").
+			WithMetadata("vdev", value)
+	}
+	return spec, nil
+}
+
+// DefaultPolicies returns the default snapshot policies of a dataset: hourly
+// snapshots kept for a day and daily snapshots kept for a month
+func DefaultPolicies(name string) []autosnapshots.EditPolicyParams {
+	prefix := strings.ReplaceAll(name, "/", "-")
+	return []autosnapshots.EditPolicyParams{
+		{
+			Name:        prefix + "-hourly",
+			Description: "Default hourly snapshots, added by rodent init",
+			Dataset:     name,
+			Schedules: []autosnapshots.ScheduleSpec{
+				{Type: autosnapshots.ScheduleTypeHourly, Interval: 1, Enabled: true},
+			},
+			RetentionPolicy: autosnapshots.RetentionPolicy{Count: 24},
+			Enabled:         true,
+		},
+		{
+			Name:        prefix + "-daily",
+			Description: "Default daily snapshots, added by rodent init",
+			Dataset:     name,
+			Schedules: []autosnapshots.ScheduleSpec{
+				{Type: autosnapshots.ScheduleTypeDaily, AtTime: "00:00", Enabled: true},
+			},
+			RetentionPolicy: autosnapshots.RetentionPolicy{Count: 30},
+			Enabled:         true,
+		},
+	}
+}
+
+// Provisioner applies answers to the host
+type Provisioner struct {
+	logger   logger.Logger
+	pools    *pool.Manager
+	datasets *dataset.Manager
+}
+
+// NewProvisioner creates a provisioner running ZFS commands through executor
+func NewProvisioner(l logger.Logger, executor *command.CommandExecutor) *Provisioner {
+	return &Provisioner{
+		logger:   l,
+		pools:    pool.NewManager(executor),
+		datasets: dataset.NewManager(executor),
+	}
+}
+
+// Run applies the answers step by step, stopping at the first failure. The
+// steps run so far are returned either way.
+func (p *Provisioner) Run(ctx context.Context, a *Answers) ([]Step, error) {
+	var steps []Step
+	record := func(name, detail string, skipped bool, err error) error {
+		step := Step{Name: name, Status: StatusDone, Detail: detail}
+		switch {
+		case err != nil:
+			step.Status = StatusFailed
+			step.Detail = err.Error()
+		case skipped:
+			step.Status = StatusSkipped
+		}
+		steps = append(steps, step)
+		return err
+	}
+
+	// Dry runs skip the file writes; ZFS and systemctl commands skip themselves
+	dryRun := generalCmd.DryRun()
+
+	if dryRun {
+		record("config", "dry run", true, nil)
+	} else {
+		path, err := p.writeConfig(a.Config)
+		if err := record("config", path, false, err); err != nil {
+			return steps, err
+		}
+	}
+
+	if a.Pool != nil {
+		exists, err := p.createPool(ctx, a.Pool)
+		if err := record("pool "+a.Pool.Name, "", exists, err); err != nil {
+			return steps, err
+		}
+	}
+
+	for _, ds := range a.Datasets {
+		exists, err := p.createDataset(ctx, ds)
+		if err := record("dataset "+ds.Name, "", exists, err); err != nil {
+			return steps, err
+		}
+	}
+
+	switch {
+	case !a.Snapshots.Enabled || len(a.Datasets) == 0:
+	case dryRun:
+		record("snapshot policies", "dry run", true, nil)
+	default:
+		added, err := p.addSnapshotPolicies(a.Datasets)
+		detail := fmt.Sprintf("%d policies added", added)
+		if err := record("snapshot policies", detail, added == 0, err); err != nil {
+			return steps, err
+		}
+	}
+
+	if a.Service.Enable {
+		if err := record("service "+ServiceName, "enabled and started", false,
+			p.enableService(ctx)); err != nil {
+			return steps, err
+		}
+	}
+
+	return steps, nil
+}
+
+// writeConfig merges the answered settings into the loaded configuration and
+// saves it, creating the configuration directories. It returns the path
+// written.
+func (p *Provisioner) writeConfig(settings map[string]any) (string, error) {
+	if err := config.EnsureDirectories(); err != nil {
+		return "", errors.Wrap(err, errors.ConfigDirectoryError)
+	}
+
+	cfg := config.GetConfig()
+	if len(settings) > 0 {
+		v := viper.New()
+		if err := v.MergeConfigMap(settings); err != nil {
+			return "", errors.Wrap(err, errors.ConfigInvalid)
+		}
+		if err := v.Unmarshal(cfg); err != nil {
+			return "", errors.Wrap(err, errors.ConfigUnmarshalFailed)
+		}
+	}
+
+	if err := config.SaveConfig(config.GetLoadedConfigPath()); err != nil {
+		return "", errors.Wrap(err, errors.ConfigWriteFailed)
+	}
+	return config.GetLoadedConfigPath(), nil
+}
+
+// createPool creates the pool unless it exists, reporting whether it did
+func (p *Provisioner) createPool(ctx context.Context, a *PoolAnswers) (bool, error) {
+	result, err := p.pools.List(ctx)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := result.Pools[a.Name]; ok {
+		return true, nil
+	}
+
+	p.logger.Info("Creating pool", "pool", a.Name, "vdevs", len(a.VDevs))
+	return false, p.pools.Create(ctx, pool.CreateConfig{
+		Name:       a.Name,
+		VDevSpec:   a.VDevs,
+		Properties: a.Properties,
+		MountPoint: a.MountPoint,
+		Force:      a.Force,
+	})
+}
+
+// createDataset creates the filesystem unless it exists, reporting whether
+// it did
+func (p *Provisioner) createDataset(ctx context.Context, a DatasetAnswers) (bool, error) {
+	exists, err := p.datasets.Exists(ctx, a.Name)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return true, nil
+	}
+
+	p.logger.Info("Creating dataset", "dataset", a.Name)
+	_, err = p.datasets.CreateFilesystem(ctx, dataset.FilesystemConfig{
+		NameConfig: dataset.NameConfig{Name: a.Name},
+		Properties: a.Properties,
+		Parents:    true,
+	})
+	return false, err
+}
+
+// addSnapshotPolicies adds the default policies to datasets that have none,
+// returning how many were added. The daemon schedules them when it starts.
+func (p *Provisioner) addSnapshotPolicies(datasets []DatasetAnswers) (int, error) {
+	manager, err := autosnapshots.GetManager(p.datasets, "")
+	if err != nil {
+		return 0, err
+	}
+	if err := manager.LoadConfig(); err != nil {
+		return 0, err
+	}
+
+	added := 0
+	for _, ds := range datasets {
+		if len(manager.PoliciesForDataset(ds.Name)) > 0 {
+			continue
+		}
+		for _, params := range DefaultPolicies(ds.Name) {
+			if _, err := manager.AddPolicy(params); err != nil {
+				return added, err
+			}
+			added++
+		}
+	}
+	return added, nil
+}
+
+// enableService enables the Rodent service at boot and starts it
+func (p *Provisioner) enableService(ctx context.Context) error {
+	client, err := systemd.NewClient(p.logger)
+	if err != nil {
+		return err
+	}
+	if err := client.EnableService(ctx, ServiceName); err != nil {
+		return err
+	}
+	return client.StartService(ctx, ServiceName)
+}
@@ -0,0 +1,151 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package provision
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+)
+
+func TestLoadAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yml")
+	data := `
+config:
+  toggle:
+    jwt: token
+  server:
+    port: 8043
+pool:
+  name: tank
+  vdevs:
+    - type: mirror
+      devices: [/dev/sdb, /dev/sdc]
+  properties:
+    ashift: "12"
+datasets:
+  - name: tank/shares
+    properties:
+      compression: lz4
+  - name: tank/backups
+service:
+  enable: false
+`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := LoadAnswers(path)
+	if err != nil {
+		t.Fatalf("LoadAnswers: %v", err)
+	}
+	if err := a.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+
+	if a.Pool == nil || a.Pool.Name != "tank" || len(a.Pool.VDevs) != 1 {
+		t.Fatalf("pool = %+v", a.Pool)
+	}
+	if v := a.Pool.VDevs[0]; v.Type != "mirror" || len(v.Devices) != 2 {
+		t.Errorf("vdev = %+v", v)
+	}
+	if a.Pool.Properties["ashift"] != "12" {
+		t.Errorf("pool properties = %v", a.Pool.Properties)
+	}
+	if len(a.Datasets) != 2 || a.Datasets[0].Properties["compression"] != "lz4" {
+		t.Errorf("datasets = %+v", a.Datasets)
+	}
+	if !a.Snapshots.Enabled {
+		t.Error("snapshots should default to enabled")
+	}
+	if a.Service.Enable {
+		t.Error("service enable = true, want false from answers")
+	}
+	if _, ok := a.Config["toggle"]; !ok {
+		t.Errorf("config = %v, want toggle settings", a.Config)
+	}
+}
+
+func TestLoadAnswersDefaults(t *testing.T) {
+	a, err := LoadAnswers("")
+	if err != nil {
+		t.Fatalf("LoadAnswers: %v", err)
+	}
+	if a.Pool != nil || len(a.Datasets) != 0 {
+		t.Errorf("answers = %+v, want no pool or datasets", a)
+	}
+	if !a.Snapshots.Enabled || !a.Service.Enable {
+		t.Errorf("answers = %+v, want snapshots and service enabled", a)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	mirror, _ := ParseVDev("mirror:/dev/sdb,/dev/sdc")
+	tests := []struct {
+		name    string
+		answers Answers
+		wantErr bool
+	}{
+		{"empty", Answers{}, false},
+		{"pool without vdevs", Answers{Pool: &PoolAnswers{Name: "tank"}}, true},
+		{"pool without name", Answers{Pool: &PoolAnswers{VDevs: []pool.VDevSpec{mirror}}}, true},
+		{"dataset outside pool", Answers{
+			Pool:     &PoolAnswers{Name: "tank", VDevs: []pool.VDevSpec{mirror}},
+			Datasets: []DatasetAnswers{{Name: "other/shares"}},
+		}, true},
+		{"dataset in pool", Answers{
+			Pool:     &PoolAnswers{Name: "tank", VDevs: []pool.VDevSpec{mirror}},
+			Datasets: []DatasetAnswers{{Name: "tank/shares"}},
+		}, false},
+		{"dataset in existing pool", Answers{Datasets: []DatasetAnswers{{Name: "tank/shares"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.answers.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseVDev(t *testing.T) {
+	spec, err := ParseVDev("raidz2:/dev/sdb, /dev/sdc,/dev/sdd")
+	if err != nil {
+		t.Fatalf("ParseVDev: %v", err)
+	}
+	if spec.Type != "raidz2" || len(spec.Devices) != 3 || spec.Devices[1] != "/dev/sdc" {
+		t.Errorf("spec = %+v", spec)
+	}
+
+	spec, err = ParseVDev("/dev/sdb")
+	if err != nil || spec.Type != "" || len(spec.Devices) != 1 {
+		t.Errorf("stripe = %+v, %v", spec, err)
+	}
+
+	if _, err := ParseVDev("mirror:"); err == nil {
+		t.Error("expected an error for a vdev without devices")
+	}
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	policies := DefaultPolicies("tank/shares")
+	if len(policies) != 2 {
+		t.Fatalf("got %d policies, want 2", len(policies))
+	}
+	for _, params := range policies {
+		if err := autosnapshots.ValidatePolicy(autosnapshots.NewSnapshotPolicy(params)); err != nil {
+			t.Errorf("policy %s is invalid: %v", params.Name, err)
+		}
+		if params.Dataset != "tank/shares" {
+			t.Errorf("policy %s dataset = %q", params.Name, params.Dataset)
+		}
+	}
+	if policies[0].Name != "tank-shares-hourly" {
+		t.Errorf("name = %q, want no slashes", policies[0].Name)
+	}
+}
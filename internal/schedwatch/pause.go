@@ -0,0 +1,117 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package schedwatch
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/rodent/internal/crash"
+)
+
+// pause is the node-wide pause of scheduled tasks. Jobs keep firing while
+// paused; their tasks hand the run to Hold, which keeps the latest run of
+// each job for Resume.
+var pause struct {
+	sync.Mutex
+	reason string
+	since  *time.Time
+}
+
+// Pause holds the runs of every scheduler's tasks that go through Hold until
+// Resume is called. reason is reported to the tasks that are held.
+func Pause(reason string) {
+	now := time.Now()
+	pause.Lock()
+	defer pause.Unlock()
+	pause.reason = reason
+	if pause.since == nil {
+		pause.since = &now
+	}
+}
+
+// Paused reports whether scheduled tasks are paused, and why
+func Paused() (bool, string) {
+	pause.Lock()
+	defer pause.Unlock()
+	return pause.since != nil, pause.reason
+}
+
+// Resume ends the pause and runs, once each, the runs held meanwhile so
+// jobs catch up on what they missed. It returns how many runs it started.
+func Resume() int {
+	pause.Lock()
+	pause.since = nil
+	pause.reason = ""
+	pause.Unlock()
+
+	registryMu.Lock()
+	schedulers := slices.Collect(maps.Values(registry))
+	registryMu.Unlock()
+
+	started := 0
+	for _, s := range schedulers {
+		for key, run := range s.takeHeld() {
+			crash.Go("scheduler/"+s.name, run)
+			s.logger.Info("Running missed scheduled task",
+				"scheduler", s.name,
+				"job", key)
+			started++
+		}
+	}
+	return started
+}
+
+// Hold keeps a run due now when scheduled tasks are paused, returning true
+// and the reason of the pause. Only the latest run of each key is kept, so
+// a job that fired several times while paused catches up once. It is safe
+// to call on a nil scheduler, which holds nothing.
+func (s *Scheduler) Hold(key string, run func()) (bool, string) {
+	if s == nil {
+		return false, ""
+	}
+	pause.Lock()
+	paused, reason := pause.since != nil, pause.reason
+	pause.Unlock()
+	if !paused {
+		return false, ""
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.held == nil {
+		s.held = make(map[string]func())
+	}
+	s.held[key] = run
+	s.status.Held = len(s.held)
+	return true, "scheduled tasks are paused: " + reason
+}
+
+// DropHeld forgets the held runs of the keys with a prefix, such as those
+// of a policy being removed. It is safe to call on a nil scheduler.
+func (s *Scheduler) DropHeld(prefix string) {
+	if s == nil {
+		return
+	}
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	maps.DeleteFunc(s.held, func(key string, _ func()) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+	s.status.Held = len(s.held)
+}
+
+// takeHeld removes and returns the held runs
+func (s *Scheduler) takeHeld() map[string]func() {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	held := s.held
+	s.held = nil
+	s.status.Held = 0
+	return held
+}
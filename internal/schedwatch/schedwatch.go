@@ -0,0 +1,425 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schedwatch keeps the gocron schedulers of the policy managers
+// apart and alive. Each subsystem owns a Scheduler; a panic in one policy's
+// task fails that policy's run with a crash report instead of reaching the
+// scheduler, and a scheduler that stops answering is replaced by a new one
+// with the same jobs. The state of every scheduler is reported in /health.
+package schedwatch
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+var (
+	// probeInterval is how often a started scheduler is probed
+	probeInterval = 30 * time.Second
+
+	// probeTimeout bounds a probe; a scheduler whose loop does not answer
+	// in time is considered stuck
+	probeTimeout = 10 * time.Second
+
+	// maxMissedProbes is how many probes in a row a scheduler may miss
+	// before it is restarted
+	maxMissedProbes = 2
+)
+
+// Panic describes the last panic of a scheduled task
+type Panic struct {
+	At          time.Time `json:"at"`
+	Job         string    `json:"job"` // Key the task ran under, such as a policy ID
+	Value       string    `json:"value"`
+	CrashReport string    `json:"crash_report"`
+}
+
+// Status is the health of a scheduler
+type Status struct {
+	Name          string     `json:"name"`
+	Running       bool       `json:"running"`
+	Responsive    bool       `json:"responsive"`
+	Jobs          int        `json:"jobs"`
+	LastProbeAt   *time.Time `json:"last_probe_at,omitempty"`
+	Restarts      int        `json:"restarts"`
+	LastRestartAt *time.Time `json:"last_restart_at,omitempty"`
+	Panics        uint64     `json:"panics"`
+	LastPanic     *Panic     `json:"last_panic,omitempty"`
+	Paused        bool       `json:"paused"`
+	Held          int        `json:"held"` // Runs held while paused, run on resume
+}
+
+// Healthy reports whether a scheduler is running and answering, or stopped
+func (s Status) Healthy() bool {
+	return !s.Running || s.Responsive
+}
+
+// jobSpec is what a job was created with, to recreate it on restart
+type jobSpec struct {
+	def  gocron.JobDefinition
+	task gocron.Task
+	opts []gocron.JobOption
+}
+
+// Scheduler is a gocron.Scheduler that remembers its jobs, so it can be
+// replaced by a new one when it gets stuck, and recovers the panics of the
+// tasks run through Run
+type Scheduler struct {
+	name   string
+	logger logger.Logger
+	opts   []gocron.SchedulerOption
+
+	mu      sync.Mutex
+	current gocron.Scheduler
+	jobs    map[uuid.UUID]jobSpec
+	started bool
+	stop    chan struct{} // Closes to stop the supervisor; nil when not started
+
+	statusMu sync.Mutex
+	status   Status
+	missed   int
+	held     map[string]func() // Runs held while paused, by key
+}
+
+var _ gocron.Scheduler = (*Scheduler)(nil)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Scheduler)
+)
+
+// New creates a scheduler for a subsystem, reported under name
+func New(name string, l logger.Logger, opts ...gocron.SchedulerOption) (*Scheduler, error) {
+	current, err := gocron.NewScheduler(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Scheduler{
+		name:    name,
+		logger:  l,
+		opts:    opts,
+		current: current,
+		jobs:    make(map[uuid.UUID]jobSpec),
+		status:  Status{Name: name},
+	}
+
+	registryMu.Lock()
+	registry[name] = s
+	registryMu.Unlock()
+	return s, nil
+}
+
+// Statuses returns the health of every scheduler, by name
+func Statuses() []Status {
+	registryMu.Lock()
+	schedulers := slices.Collect(maps.Values(registry))
+	registryMu.Unlock()
+
+	statuses := make([]Status, 0, len(schedulers))
+	for _, s := range schedulers {
+		statuses = append(statuses, s.Status())
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return statuses
+}
+
+// Status returns the health of the scheduler as of its last probe
+func (s *Scheduler) Status() Status {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	status := s.status
+	if status.LastPanic != nil {
+		p := *status.LastPanic
+		status.LastPanic = &p
+	}
+	status.Paused, _ = Paused()
+	return status
+}
+
+// Run runs the task of a job, turning a panic into an error of the run and a
+// crash report, so only the job's policy fails. key names the job, such as
+// its policy ID, in the report.
+func (s *Scheduler) Run(key string, fn func() error) (err error) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		report := crash.Handle(crash.SourceScheduler, "scheduler/"+s.name, key, r, debug.Stack())
+
+		s.statusMu.Lock()
+		s.status.Panics++
+		s.status.LastPanic = &Panic{
+			At:          report.Time,
+			Job:         key,
+			Value:       report.Panic,
+			CrashReport: report.ID,
+		}
+		s.statusMu.Unlock()
+
+		err = errors.New(errors.SchedulerError,
+			fmt.Sprintf("scheduled task panicked: %v", r)).
+			WithMetadata("crash_report", report.ID)
+	}()
+	return fn()
+}
+
+// scheduler returns the current gocron scheduler. Calls into it are made
+// without holding mu, so a stuck scheduler does not block its restart.
+func (s *Scheduler) scheduler() gocron.Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current
+}
+
+// Jobs returns the jobs of the scheduler
+func (s *Scheduler) Jobs() []gocron.Job {
+	return s.scheduler().Jobs()
+}
+
+// NewJob creates a job. Jobs are given an identifier, kept across restarts;
+// options must not set one.
+func (s *Scheduler) NewJob(def gocron.JobDefinition, task gocron.Task, opts ...gocron.JobOption) (gocron.Job, error) {
+	opts = append(slices.Clip(opts), gocron.WithIdentifier(uuid.New()))
+	return s.addJob(jobSpec{def: def, task: task, opts: opts}, func(cur gocron.Scheduler) (gocron.Job, error) {
+		return cur.NewJob(def, task, opts...)
+	})
+}
+
+// Update replaces the definition of a job
+func (s *Scheduler) Update(id uuid.UUID, def gocron.JobDefinition, task gocron.Task, opts ...gocron.JobOption) (gocron.Job, error) {
+	spec := jobSpec{
+		def:  def,
+		task: task,
+		opts: append(slices.Clip(opts), gocron.WithIdentifier(id)),
+	}
+	return s.addJob(spec, func(cur gocron.Scheduler) (gocron.Job, error) {
+		return cur.Update(id, def, task, opts...)
+	})
+}
+
+// addJob adds or updates a job and records it, again on the new scheduler
+// when the scheduler was restarted meanwhile
+func (s *Scheduler) addJob(spec jobSpec, add func(cur gocron.Scheduler) (gocron.Job, error)) (gocron.Job, error) {
+	for {
+		cur := s.scheduler()
+		job, err := add(cur)
+
+		s.mu.Lock()
+		if s.current != cur {
+			s.mu.Unlock()
+			continue
+		}
+		if err == nil {
+			s.jobs[job.ID()] = spec
+		}
+		s.mu.Unlock()
+		return job, err
+	}
+}
+
+// RemoveJob removes a job
+func (s *Scheduler) RemoveJob(id uuid.UUID) error {
+	s.mu.Lock()
+	delete(s.jobs, id)
+	cur := s.current
+	s.mu.Unlock()
+	return cur.RemoveJob(id)
+}
+
+// RemoveByTags removes the jobs having any of the tags
+func (s *Scheduler) RemoveByTags(tags ...string) {
+	cur := s.scheduler()
+	cur.RemoveByTags(tags...)
+
+	remaining := make(map[uuid.UUID]bool)
+	for _, job := range cur.Jobs() {
+		remaining[job.ID()] = true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.current == cur {
+		maps.DeleteFunc(s.jobs, func(id uuid.UUID, _ jobSpec) bool {
+			return !remaining[id]
+		})
+	}
+}
+
+// Start starts scheduling jobs and probing the scheduler
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.started = true
+	if s.stop == nil {
+		s.stop = make(chan struct{})
+		go s.supervise(s.stop)
+	}
+	cur := s.current
+	s.mu.Unlock()
+
+	cur.Start()
+
+	s.statusMu.Lock()
+	s.status.Running = true
+	s.status.Responsive = true
+	s.statusMu.Unlock()
+}
+
+// StopJobs stops running jobs until Start is called again
+func (s *Scheduler) StopJobs() error {
+	s.mu.Lock()
+	s.started = false
+	cur := s.current
+	s.mu.Unlock()
+	return cur.StopJobs()
+}
+
+// Shutdown stops the scheduler for good
+func (s *Scheduler) Shutdown() error {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	s.started = false
+	cur := s.current
+	s.mu.Unlock()
+
+	s.statusMu.Lock()
+	s.status.Running = false
+	s.statusMu.Unlock()
+	return cur.Shutdown()
+}
+
+// JobsWaitingInQueue returns the number of jobs waiting to run
+func (s *Scheduler) JobsWaitingInQueue() int {
+	return s.scheduler().JobsWaitingInQueue()
+}
+
+// supervise probes the scheduler until stop closes, restarting it when it
+// misses maxMissedProbes probes in a row
+func (s *Scheduler) supervise(stop chan struct{}) {
+	ticker := time.NewTicker(probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if s.probe() {
+				continue
+			}
+			if err := s.restart(); err != nil {
+				s.logger.Error("Failed to restart stuck scheduler",
+					"scheduler", s.name,
+					"error", err)
+			}
+		}
+	}
+}
+
+// probe asks the scheduler for its jobs, which its loop answers, and
+// records the outcome. It returns false once the scheduler should be
+// restarted.
+func (s *Scheduler) probe() bool {
+	s.mu.Lock()
+	current, started := s.current, s.started
+	s.mu.Unlock()
+
+	answered := make(chan int, 1)
+	go func() { answered <- len(current.Jobs()) }()
+
+	now := time.Now()
+	jobs, ok := 0, false
+	select {
+	case jobs = <-answered:
+		ok = true
+	case <-time.After(probeTimeout):
+	}
+
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.status.LastProbeAt = &now
+	s.status.Responsive = ok
+	if ok {
+		s.status.Jobs = jobs
+		s.missed = 0
+		return true
+	}
+
+	if !started {
+		return true
+	}
+	s.missed++
+	s.logger.Warn("Scheduler did not answer a probe",
+		"scheduler", s.name,
+		"timeout", probeTimeout,
+		"missed", s.missed)
+	return s.missed < maxMissedProbes
+}
+
+// restart replaces the scheduler with a new one running the same jobs,
+// under the same IDs. The old scheduler is shut down in the background, as
+// a stuck one may not return.
+func (s *Scheduler) restart() error {
+	next, err := gocron.NewScheduler(s.opts...)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	for id, spec := range s.jobs {
+		if _, err := next.NewJob(spec.def, spec.task, spec.opts...); err != nil {
+			// One-time jobs that are due have run or will never run
+			s.logger.Warn("Failed to recreate job on scheduler restart",
+				"scheduler", s.name,
+				"job_id", id.String(),
+				"error", err)
+			delete(s.jobs, id)
+		}
+	}
+	if s.started {
+		next.Start()
+	}
+	old := s.current
+	s.current = next
+	jobs := len(s.jobs)
+	s.mu.Unlock()
+
+	go func() {
+		if err := old.Shutdown(); err != nil {
+			s.logger.Debug("Stuck scheduler shut down with error",
+				"scheduler", s.name,
+				"error", err)
+		}
+	}()
+
+	now := time.Now()
+	s.statusMu.Lock()
+	s.status.Restarts++
+	s.status.LastRestartAt = &now
+	s.status.Responsive = true
+	s.status.Jobs = jobs
+	s.missed = 0
+	s.statusMu.Unlock()
+
+	s.logger.Warn("Restarted stuck scheduler",
+		"scheduler", s.name,
+		"jobs", jobs)
+	return nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package schedwatch
+
+import (
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	crash.Setup(common.Log, t.TempDir())
+	s, err := New("test-run", common.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown() })
+
+	want := stderrors.New("snapshot failed")
+	assert.Equal(t, want, s.Run("p1", func() error { return want }))
+	assert.Zero(t, s.Status().Panics)
+
+	err = s.Run("p2", func() error { panic("boom") })
+	var rerr *errors.RodentError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, errors.ErrorCode(errors.SchedulerError), rerr.Code)
+	assert.NotEmpty(t, rerr.Metadata["crash_report"])
+
+	status := s.Status()
+	assert.Equal(t, uint64(1), status.Panics)
+	require.NotNil(t, status.LastPanic)
+	assert.Equal(t, "p2", status.LastPanic.Job)
+	assert.Equal(t, "boom", status.LastPanic.Value)
+}
+
+func TestRestart(t *testing.T) {
+	s, err := New("test-restart", common.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown() })
+
+	kept, err := s.NewJob(gocron.DurationJob(time.Hour), gocron.NewTask(func() {}))
+	require.NoError(t, err)
+	removed, err := s.NewJob(gocron.DurationJob(time.Hour), gocron.NewTask(func() {}))
+	require.NoError(t, err)
+	require.NoError(t, s.RemoveJob(removed.ID()))
+	s.Start()
+
+	require.True(t, s.probe())
+	assert.Equal(t, 1, s.Status().Jobs)
+
+	require.NoError(t, s.restart())
+
+	// The new scheduler runs the same jobs under the same IDs
+	jobs := s.Jobs()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, kept.ID(), jobs[0].ID())
+
+	status := s.Status()
+	assert.Equal(t, 1, status.Restarts)
+	assert.True(t, status.Running)
+	assert.True(t, status.Healthy())
+
+	found := false
+	for _, st := range Statuses() {
+		found = found || st.Name == "test-restart"
+	}
+	assert.True(t, found)
+}
+
+func TestPauseHoldsRuns(t *testing.T) {
+	s, err := New("test-pause", common.Log)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Shutdown() })
+
+	held, _ := s.Hold("p1", func() { t.Error("run while not paused") })
+	assert.False(t, held)
+
+	Pause("disk swap")
+	t.Cleanup(func() { Resume() })
+
+	ran := make(chan string, 4)
+	held, reason := s.Hold("p1", func() { ran <- "p1-first" })
+	assert.True(t, held)
+	assert.Contains(t, reason, "disk swap")
+	s.Hold("p1", func() { ran <- "p1" })
+	s.Hold("p2", func() { ran <- "p2" })
+	s.Hold("removed:1", func() { ran <- "removed" })
+	s.DropHeld("removed:")
+
+	status := s.Status()
+	assert.True(t, status.Paused)
+	assert.Equal(t, 2, status.Held)
+
+	assert.Equal(t, 2, Resume())
+	got := []string{<-ran, <-ran}
+	assert.ElementsMatch(t, []string{"p1", "p2"}, got, "each key catches up once, with its latest run")
+	assert.False(t, s.Status().Paused)
+	assert.Zero(t, s.Status().Held)
+}
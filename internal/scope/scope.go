@@ -0,0 +1,126 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package scope limits requests made with a delegated API key to the shares,
+// datasets and snapshot policies the key names. Requests without a scope
+// keep full access.
+package scope
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Object kinds, used in denials
+const (
+	KindShare    = "share"
+	KindDataset  = "dataset"
+	KindPolicy   = "snapshot_policy"
+	KindEndpoint = "endpoint"
+)
+
+// Scope is the set of objects a delegated key may manage
+type Scope struct {
+	Key      string   // Name of the delegated key
+	Shares   []string // SMB share names
+	Datasets []string // Datasets, with their children, snapshots and bookmarks
+	Policies []string // Snapshot policy IDs
+}
+
+// FromKey returns the scope of a delegated key
+func FromKey(key config.DelegatedKey) *Scope {
+	return &Scope{
+		Key:      key.Name,
+		Shares:   key.Shares,
+		Datasets: key.Datasets,
+		Policies: key.Policies,
+	}
+}
+
+type scopeKey struct{}
+
+// WithScope returns a context carrying the scope
+func WithScope(ctx context.Context, s *Scope) context.Context {
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// FromContext returns the scope carried by ctx, or nil when the request has
+// full access
+func FromContext(ctx context.Context) *Scope {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(scopeKey{}).(*Scope)
+	return s
+}
+
+// AllowsShare reports whether the share may be managed. A nil scope allows
+// everything.
+func (s *Scope) AllowsShare(name string) bool {
+	return s == nil || slices.Contains(s.Shares, name)
+}
+
+// AllowsDataset reports whether the dataset, snapshot or bookmark lies in
+// one of the scope's datasets
+func (s *Scope) AllowsDataset(name string) bool {
+	if s == nil {
+		return true
+	}
+	if i := strings.IndexAny(name, "@#"); i >= 0 {
+		name = name[:i]
+	}
+	for _, d := range s.Datasets {
+		d = strings.TrimSuffix(d, "/")
+		if d != "" && (name == d || strings.HasPrefix(name, d+"/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsPolicy reports whether the snapshot policy may be managed, either by
+// its ID or because its dataset is in scope
+func (s *Scope) AllowsPolicy(id, dataset string) bool {
+	if s == nil || slices.Contains(s.Policies, id) {
+		return true
+	}
+	return dataset != "" && s.AllowsDataset(dataset)
+}
+
+// Denied returns the error for an object outside the scope
+func (s *Scope) Denied(kind, name string) *errors.RodentError {
+	err := errors.New(errors.PermissionDenied, "API key may not manage this "+
+		strings.ReplaceAll(kind, "_", " ")).
+		WithMetadata("kind", kind).
+		WithMetadata("name", name)
+	if s != nil {
+		err = err.WithMetadata("key", s.Key)
+	}
+	return err
+}
+
+// MatchKey returns the configured key equal to presented. Keys are compared
+// as hashes in constant time, so neither their contents nor their lengths
+// leak through response times.
+func MatchKey(keys []config.DelegatedKey, presented string) (config.DelegatedKey, bool) {
+	want := sha256.Sum256([]byte(presented))
+	var found config.DelegatedKey
+	ok := false
+	for _, k := range keys {
+		if k.Key == "" {
+			continue
+		}
+		have := sha256.Sum256([]byte(k.Key))
+		if subtle.ConstantTimeCompare(want[:], have[:]) == 1 && !ok {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
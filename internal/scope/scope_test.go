@@ -0,0 +1,78 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package scope
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeAllows(t *testing.T) {
+	s := &Scope{
+		Key:      "eng-lead",
+		Shares:   []string{"eng"},
+		Datasets: []string{"tank/eng/"},
+		Policies: []string{"p-1"},
+	}
+
+	tests := []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"share in scope", s.AllowsShare("eng"), true},
+		{"share out of scope", s.AllowsShare("finance"), false},
+		{"dataset itself", s.AllowsDataset("tank/eng"), true},
+		{"child dataset", s.AllowsDataset("tank/eng/build"), true},
+		{"snapshot", s.AllowsDataset("tank/eng@daily"), true},
+		{"bookmark", s.AllowsDataset("tank/eng/build#mark"), true},
+		{"sibling with shared prefix", s.AllowsDataset("tank/engineering"), false},
+		{"parent", s.AllowsDataset("tank"), false},
+		{"snapshot of parent", s.AllowsDataset("tank@tank/eng"), false},
+		{"policy by ID", s.AllowsPolicy("p-1", "tank/other"), true},
+		{"policy by dataset", s.AllowsPolicy("p-2", "tank/eng/build"), true},
+		{"policy out of scope", s.AllowsPolicy("p-3", "tank/other"), false},
+		{"policy without dataset", s.AllowsPolicy("p-3", ""), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.got)
+		})
+	}
+}
+
+func TestNilScopeAllowsAll(t *testing.T) {
+	s := FromContext(context.Background())
+	assert.Nil(t, s)
+	assert.True(t, s.AllowsShare("any"))
+	assert.True(t, s.AllowsDataset("tank/any"))
+	assert.True(t, s.AllowsPolicy("any", ""))
+}
+
+func TestWithScope(t *testing.T) {
+	s := &Scope{Key: "k"}
+	assert.Same(t, s, FromContext(WithScope(context.Background(), s)))
+}
+
+func TestMatchKey(t *testing.T) {
+	keys := []config.DelegatedKey{
+		{Name: "empty"},
+		{Name: "eng", Key: "secret-eng"},
+		{Name: "ops", Key: "secret-ops"},
+	}
+
+	k, ok := MatchKey(keys, "secret-ops")
+	assert.True(t, ok)
+	assert.Equal(t, "ops", k.Name)
+
+	_, ok = MatchKey(keys, "")
+	assert.False(t, ok, "an empty key must not match keys without a secret")
+
+	_, ok = MatchKey(keys, "secret")
+	assert.False(t, ok)
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package selftest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIHandler serves the startup self-test report
+type APIHandler struct {
+	report *Report
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool        `json:"success"`
+	Result  interface{} `json:"result,omitempty"`
+}
+
+// NewAPIHandler creates a handler for the given report. A nil report means the
+// self-test is disabled.
+func NewAPIHandler(report *Report) *APIHandler {
+	return &APIHandler{
+		report: report,
+	}
+}
+
+// RegisterRoutes registers HTTP routes for the self-test report
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.getReport)
+}
+
+// getReport returns the self-test report
+func (h *APIHandler) getReport(c *gin.Context) {
+	var result interface{} = h.report
+	if h.report == nil {
+		result = gin.H{"enabled": false}
+	}
+	c.JSON(http.StatusOK, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
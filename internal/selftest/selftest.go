@@ -0,0 +1,327 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package selftest probes the host on startup for the binaries, kernel modules,
+// tool versions and permissions that Rodent's subsystems depend on. The report
+// decides which subsystems are enabled, so a missing dependency is reported
+// once at startup instead of failing every API call that needs it.
+package selftest
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	StatusPass Status = "pass" // Dependency is present and usable
+	StatusWarn Status = "warn" // Usable, but something could not be verified
+	StatusFail Status = "fail" // Missing or unusable
+)
+
+// Kind is the type of dependency a check probes
+type Kind string
+
+const (
+	KindBinary     Kind = "binary"
+	KindModule     Kind = "module"
+	KindPermission Kind = "permission"
+)
+
+// Check is the result of probing one dependency
+type Check struct {
+	Kind       Kind   `json:"kind"`
+	Name       string `json:"name"`
+	Status     Status `json:"status"`
+	Path       string `json:"path,omitempty"`
+	Version    string `json:"version,omitempty"`
+	MinVersion string `json:"min_version,omitempty"`
+	Message    string `json:"message,omitempty"`
+	// Remediation says how to fix a failed or warned check, when known
+	Remediation string `json:"remediation,omitempty"`
+}
+
+// id identifies the check in subsystem dependency lists
+func (c Check) id() string {
+	return string(c.Kind) + ":" + c.Name
+}
+
+// Subsystem reports whether a subsystem may be enabled
+type Subsystem struct {
+	Name    string   `json:"name"`
+	Enabled bool     `json:"enabled"`
+	Missing []string `json:"missing,omitempty"` // Failed dependencies, as kind:name
+}
+
+// Report is the result of a self-test run
+type Report struct {
+	RanAt      time.Time             `json:"ran_at"`
+	Duration   string                `json:"duration"`
+	Enforced   bool                  `json:"enforced"` // Whether failed dependencies disable subsystems
+	Healthy    bool                  `json:"healthy"`  // No check failed
+	Checks     []Check               `json:"checks"`
+	Subsystems map[string]*Subsystem `json:"subsystems"`
+}
+
+// Enabled reports whether the named subsystem may be started. Subsystems the
+// self-test does not know about, and all subsystems when no report exists, are
+// enabled.
+func (r *Report) Enabled(subsystem string) bool {
+	if r == nil {
+		return true
+	}
+	s, ok := r.Subsystems[subsystem]
+	return !ok || s.Enabled
+}
+
+// Options configures a self-test run
+type Options struct {
+	// Enforce disables subsystems whose dependencies failed. Without it the
+	// report is informational only.
+	Enforce bool
+
+	// MinVersions maps binary names to the minimum required version
+	MinVersions map[string]string
+}
+
+// Subsystem names used in the report
+const (
+	SubsystemZFS    = "zfs"
+	SubsystemShares = "shares"
+	SubsystemDisk   = "disk"
+	SubsystemDomain = "domain"
+)
+
+// subsystemDeps lists the checks each subsystem requires, as kind:name
+var subsystemDeps = map[string][]string{
+	SubsystemZFS:    {"binary:zfs", "binary:zpool", "module:zfs"},
+	SubsystemShares: {"binary:smbd"},
+	SubsystemDisk:   {"binary:smartctl"},
+	SubsystemDomain: {"binary:net", "binary:resolvectl"},
+}
+
+// binaries lists the probed binaries with the arguments that print their
+// version
+var binaries = []struct {
+	name        string
+	versionArgs []string
+}{
+	{"zfs", []string{"version"}},
+	{"zpool", []string{"version"}},
+	{"smbd", []string{"--version"}},
+	{"net", []string{"--version"}},
+	{"resolvectl", []string{"--version"}},
+	{"smartctl", []string{"--version"}},
+}
+
+// sudoBinaries lists the binaries Rodent runs with sudo whose sudoers rules
+// are probed
+var sudoBinaries = []string{"zfs", "zpool", "smartctl", "systemctl", "net"}
+
+// modules lists the probed kernel modules
+var modules = []string{"zfs"}
+
+// modulesDir is where loaded kernel modules are listed; tests override it
+var modulesDir = "/sys/module"
+
+// probeTimeout bounds each version and permission probe
+const probeTimeout = 5 * time.Second
+
+var versionRegex = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Run probes all dependencies and decides which subsystems are enabled.
+// Failed checks are logged as warnings.
+func Run(ctx context.Context, l logger.Logger, opts Options) *Report {
+	start := time.Now()
+	report := &Report{
+		RanAt:      start,
+		Enforced:   opts.Enforce,
+		Healthy:    true,
+		Subsystems: make(map[string]*Subsystem),
+	}
+
+	for _, b := range binaries {
+		report.Checks = append(report.Checks,
+			checkBinary(ctx, b.name, b.versionArgs, opts.MinVersions[b.name]))
+	}
+	for _, m := range modules {
+		report.Checks = append(report.Checks, checkModule(m))
+	}
+	report.Checks = append(report.Checks, checkPrivileges(ctx))
+	if os.Geteuid() != 0 {
+		for _, name := range sudoBinaries {
+			if c, ok := checkSudo(ctx, name); ok {
+				report.Checks = append(report.Checks, c)
+			}
+		}
+	}
+
+	failed := make(map[string]bool)
+	for _, c := range report.Checks {
+		switch c.Status {
+		case StatusFail:
+			failed[c.id()] = true
+			report.Healthy = false
+			l.Warn("Self-test check failed", "check", c.id(), "message", c.Message)
+		case StatusWarn:
+			l.Warn("Self-test check passed with warnings", "check", c.id(), "message", c.Message,
+				"remediation", c.Remediation)
+		}
+	}
+
+	for name, deps := range subsystemDeps {
+		s := &Subsystem{Name: name, Enabled: true}
+		for _, dep := range deps {
+			if failed[dep] {
+				s.Missing = append(s.Missing, dep)
+			}
+		}
+		if len(s.Missing) > 0 && opts.Enforce {
+			s.Enabled = false
+			l.Warn("Subsystem disabled by self-test", "subsystem", name, "missing", s.Missing)
+		}
+		report.Subsystems[name] = s
+	}
+
+	report.Duration = time.Since(start).String()
+	return report
+}
+
+// checkBinary looks up a binary in PATH and compares its version against the
+// minimum, if one is configured
+func checkBinary(ctx context.Context, name string, versionArgs []string, minVersion string) Check {
+	c := Check{Kind: KindBinary, Name: name, MinVersion: minVersion}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		c.Status = StatusFail
+		c.Message = "not found in PATH"
+		return c
+	}
+	c.Path = path
+
+	output, err := probe(ctx, path, versionArgs...)
+	c.Version = parseVersion(output)
+	if c.Version == "" {
+		c.Status = StatusWarn
+		c.Message = "could not determine version"
+		if err != nil {
+			c.Message = fmt.Sprintf("could not determine version: %v", err)
+		}
+		return c
+	}
+
+	if minVersion != "" && compareVersions(c.Version, minVersion) < 0 {
+		c.Status = StatusFail
+		c.Message = fmt.Sprintf("version %s is older than the required %s", c.Version, minVersion)
+		return c
+	}
+
+	c.Status = StatusPass
+	return c
+}
+
+// checkModule reports whether a kernel module is loaded or built in
+func checkModule(name string) Check {
+	c := Check{Kind: KindModule, Name: name, Status: StatusPass}
+	if _, err := os.Stat(filepath.Join(modulesDir, name)); err != nil {
+		c.Status = StatusFail
+		c.Message = "kernel module is not loaded"
+	}
+	return c
+}
+
+// checkPrivileges verifies that Rodent runs as root or may use sudo without
+// a password. A failure is only a warning: commands that need sudo report
+// their own errors, and development setups often prompt for a password.
+func checkPrivileges(ctx context.Context) Check {
+	c := Check{Kind: KindPermission, Name: "sudo", Status: StatusPass}
+	if os.Geteuid() == 0 {
+		c.Message = "running as root"
+		return c
+	}
+	if _, err := probe(ctx, "sudo", "-n", "true"); err != nil {
+		c.Status = StatusWarn
+		c.Message = "passwordless sudo is not available"
+		c.Remediation = "install the policy printed by 'rodent privilege sudoers' as /etc/sudoers.d/rodent"
+	}
+	return c
+}
+
+// checkSudo verifies that sudo runs an installed binary without a password.
+// Binaries that are not installed are skipped. Like checkPrivileges, a
+// failure is only a warning; commands run with sudo fail with the same
+// remediation.
+func checkSudo(ctx context.Context, name string) (Check, bool) {
+	if _, err := exec.LookPath(name); err != nil {
+		return Check{}, false
+	}
+	c := Check{Kind: KindPermission, Name: "sudo:" + name, Status: StatusPass}
+	if err := command.ProbeSudo(ctx, name); err != nil {
+		c.Status = StatusWarn
+		c.Message = err.Error()
+		var re *errors.RodentError
+		if stderrors.As(err, &re) {
+			c.Remediation = re.Metadata["remediation"]
+		}
+	}
+	return c, true
+}
+
+// probe runs a read-only command directly, bypassing the command executors so
+// that probes also run in dry-run mode
+func probe(ctx context.Context, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	return string(output), err
+}
+
+// parseVersion returns the first version number in the first non-empty line
+// of a tool's version output, e.g. "2.1.5" from "zfs-2.1.5-1ubuntu6"
+func parseVersion(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return versionRegex.FindString(line)
+		}
+	}
+	return ""
+}
+
+// compareVersions compares dotted numeric versions, returning -1, 0 or 1.
+// Missing components count as zero, so "2.1" equals "2.1.0".
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package selftest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBinary writes an executable to dir that prints output
+func fakeBinary(t *testing.T, dir, name, output string) {
+	t.Helper()
+	script := "#!/bin/sh\necho '" + output + "'\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(script), 0755))
+}
+
+func TestRun(t *testing.T) {
+	binDir := t.TempDir()
+	fakeBinary(t, binDir, "zfs", "zfs-2.0.7-1")
+	fakeBinary(t, binDir, "zpool", "zfs-2.0.7-1")
+	fakeBinary(t, binDir, "smbd", "Version 4.15.13-Ubuntu")
+	fakeBinary(t, binDir, "smartctl", "smartctl 7.2 2020-12-30 r5155")
+	t.Setenv("PATH", binDir)
+
+	modulesDir = t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(modulesDir, "zfs"), 0755))
+	t.Cleanup(func() { modulesDir = "/sys/module" })
+
+	report := Run(context.Background(), common.Log, Options{
+		Enforce:     true,
+		MinVersions: map[string]string{"zfs": "2.1.0", "smartctl": "7.0"},
+	})
+
+	checks := make(map[string]Check)
+	for _, c := range report.Checks {
+		checks[c.id()] = c
+	}
+	assert.Equal(t, StatusFail, checks["binary:zfs"].Status)
+	assert.Equal(t, "2.0.7", checks["binary:zfs"].Version)
+	assert.Equal(t, StatusPass, checks["binary:smartctl"].Status)
+	assert.Equal(t, StatusFail, checks["binary:net"].Status)
+	assert.Equal(t, StatusPass, checks["module:zfs"].Status)
+	assert.False(t, report.Healthy)
+
+	assert.False(t, report.Enabled(SubsystemZFS))
+	assert.Equal(t, []string{"binary:zfs"}, report.Subsystems[SubsystemZFS].Missing)
+	assert.True(t, report.Enabled(SubsystemShares))
+	assert.True(t, report.Enabled(SubsystemDisk))
+	assert.False(t, report.Enabled(SubsystemDomain))
+	assert.True(t, report.Enabled("unknown"))
+
+	report = Run(context.Background(), common.Log, Options{Enforce: false})
+	assert.True(t, report.Enabled(SubsystemDomain))
+	assert.NotEmpty(t, report.Subsystems[SubsystemDomain].Missing)
+
+	var nilReport *Report
+	assert.True(t, nilReport.Enabled(SubsystemZFS))
+}
+
+func TestParseVersion(t *testing.T) {
+	assert.Equal(t, "2.1.5", parseVersion("zfs-2.1.5-1ubuntu6\nzfs-kmod-2.1.5-1ubuntu6\n"))
+	assert.Equal(t, "7.2", parseVersion("smartctl 7.2 2020-12-30 r5155 [x86_64-linux]"))
+	assert.Equal(t, "249", parseVersion("\nsystemd 249 (249.11-0ubuntu3)"))
+	assert.Equal(t, "", parseVersion(""))
+}
+
+func TestCompareVersions(t *testing.T) {
+	assert.Equal(t, 0, compareVersions("2.1", "2.1.0"))
+	assert.Equal(t, -1, compareVersions("2.0.7", "2.1.0"))
+	assert.Equal(t, 1, compareVersions("2.10.0", "2.9"))
+	assert.Equal(t, 1, compareVersions("7.2", "7"))
+}
@@ -62,6 +62,13 @@
 // The domain join logic is delegated to the internal/services/domain package for
 // separation of concerns and to support both self-hosted and external AD modes.
 //
+// # Health Monitoring
+//
+// HealthMonitor periodically checks the container state, replication
+// ('samba-tool drs showrepl'), FSMO role owners, sysvol ACLs, the DNS forwarder
+// and, once a day, database integrity ('samba-tool dbcheck'). The latest report
+// is served by the /health endpoint and state changes are emitted as events.
+//
 // # External vs Self-Hosted Mode
 //
 // This package handles self-hosted AD DC. For external AD (client organization's DC),
@@ -75,6 +82,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -88,6 +96,7 @@ import (
 	"github.com/stratastor/rodent/internal/services/config"
 	"github.com/stratastor/rodent/internal/services/domain"
 	"github.com/stratastor/rodent/internal/services/docker"
+	"github.com/stratastor/rodent/internal/system/privilege"
 	"github.com/stratastor/rodent/internal/templates"
 	"github.com/stratastor/rodent/pkg/netmage"
 	"github.com/stratastor/rodent/pkg/netmage/types"
@@ -136,6 +145,7 @@ type Client struct {
 	composeFile   string
 	configManager *config.ServiceConfigManager
 	executor      *command.CommandExecutor
+	fileOps       privilege.FileOperations
 	domainClient  *domain.Client
 }
 
@@ -202,6 +212,7 @@ func NewClient(logger logger.Logger) (*Client, error) {
 		composeFile:   defaultAdDcComposePath,
 		configManager: configManager,
 		executor:      executor,
+		fileOps:       privilege.NewSudoFileOperations(logger, executor, privilege.AllowedPaths()),
 		domainClient:  domainClient,
 	}
 
@@ -993,17 +1004,19 @@ func (c *Client) configureHostDNS(ctx context.Context, cfg *AdDcConfig) error {
 	// Add entry to /etc/hosts
 	hostsEntry := fmt.Sprintf("%s %s %s", containerIP, dcFQDN, dcHostname)
 
-	// Check if entry already exists using grep (executor has sudo enabled)
-	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "grep", containerIP, "/etc/hosts")
+	// Check if entry already exists; /etc/hosts is world-readable
+	hosts, err := os.ReadFile("/etc/hosts")
 	if err != nil {
-		// Entry doesn't exist, add it using sed (which can append in-place with sudo)
+		c.logger.Warn("Failed to read /etc/hosts", "error", err)
+	} else if !hostsHasAddress(string(hosts), containerIP) {
+		// Entry doesn't exist, append it through the privilege helper
 		c.logger.Info("Adding AD DC entry to /etc/hosts", "entry", hostsEntry)
 
-		// Use sed to append: sed -i '$a\<entry>' /etc/hosts
-		// $a means append after last line
-		sedCmd := fmt.Sprintf("$a\\%s", hostsEntry)
-		_, err = c.executor.ExecuteWithCombinedOutput(ctx, "sed", "-i", sedCmd, "/etc/hosts")
-		if err != nil {
+		entry := hostsEntry + "\n"
+		if len(hosts) > 0 && !strings.HasSuffix(string(hosts), "\n") {
+			entry = "\n" + entry
+		}
+		if err := c.fileOps.AppendFile(ctx, "/etc/hosts", []byte(entry)); err != nil {
 			c.logger.Warn("Failed to add entry to /etc/hosts", "error", err)
 		} else {
 			c.logger.Info("Added AD DC entry to /etc/hosts successfully")
@@ -1040,6 +1053,17 @@ func (c *Client) configureHostDNS(ctx context.Context, cfg *AdDcConfig) error {
 	return nil
 }
 
+// hostsHasAddress reports whether a hosts file has an entry for address
+func hostsHasAddress(hosts, address string) bool {
+	for _, line := range strings.Split(hosts, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 && fields[0] == address {
+			return true
+		}
+	}
+	return false
+}
+
 // configurePasswordPolicies sets password policies in the AD DC
 func (c *Client) configurePasswordPolicies(ctx context.Context, cfg *AdDcConfig) error {
 	c.logger.Info("Setting password policies in AD DC", "container", cfg.ContainerName)
@@ -0,0 +1,397 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package addc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	rodentCfg "github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/events"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// Health checks, in the order they run
+const (
+	HealthCheckContainer    = "container"
+	HealthCheckReplication  = "replication"
+	HealthCheckFSMO         = "fsmo"
+	HealthCheckSysvol       = "sysvol"
+	HealthCheckDNSForwarder = "dns-forwarder"
+	HealthCheckDatabase     = "database"
+)
+
+const (
+	// defaultHealthCheckInterval is used when ad.dc.healthCheck.interval is unset or invalid
+	defaultHealthCheckInterval = 15 * time.Minute
+
+	// dbCheckInterval spaces out 'samba-tool dbcheck', which reads every
+	// object in the directory; its last result is reused in between
+	dbCheckInterval = 24 * time.Hour
+	dbCheckTimeout  = 5 * time.Minute
+)
+
+// fsmoRoles are the roles 'samba-tool fsmo show' reports an owner for
+var fsmoRoles = []string{
+	"SchemaMasterRole",
+	"InfrastructureMasterRole",
+	"RidAllocationMasterRole",
+	"PdcEmulationMasterRole",
+	"DomainNamingMasterRole",
+	"DomainDnsZonesMasterRole",
+	"ForestDnsZonesMasterRole",
+}
+
+// HealthCheck is the outcome of one AD DC health check
+type HealthCheck struct {
+	Name      string    `json:"name"`
+	OK        bool      `json:"ok"`
+	Message   string    `json:"message,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport is the result of an AD DC health check run
+type HealthReport struct {
+	Healthy   bool          `json:"healthy"`
+	Checks    []HealthCheck `json:"checks"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// failed returns the names of the failed checks
+func (r *HealthReport) failed() []string {
+	var names []string
+	for _, check := range r.Checks {
+		if !check.OK {
+			names = append(names, check.Name)
+		}
+	}
+	return names
+}
+
+// sambaTool runs samba-tool inside the DC container
+func (c *Client) sambaTool(ctx context.Context, args ...string) (string, error) {
+	cfg := rodentCfg.GetConfig()
+	cmdArgs := append([]string{"exec", cfg.AD.DC.ContainerName, "samba-tool"}, args...)
+	output, err := c.executor.ExecuteWithCombinedOutput(ctx, "docker", cmdArgs...)
+	return strings.TrimSpace(string(output)), err
+}
+
+// CheckHealth checks the DC container, replication, FSMO role owners, sysvol
+// ACLs and the DNS forwarder. The database is checked only when checkDB is
+// set, since dbcheck walks the whole directory.
+func (c *Client) CheckHealth(ctx context.Context, checkDB bool) *HealthReport {
+	report := &HealthReport{CheckedAt: time.Now()}
+	record := func(name string, err error, message string) {
+		check := HealthCheck{Name: name, OK: err == nil, Message: message, CheckedAt: time.Now()}
+		if err != nil {
+			check.Message = err.Error()
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	// Nothing else can be checked while the container is down
+	if err := c.checkContainer(ctx); err != nil {
+		record(HealthCheckContainer, err, "")
+		report.Healthy = false
+		return report
+	}
+	record(HealthCheckContainer, nil, "running")
+
+	record(HealthCheckReplication, c.checkReplication(ctx), "all good")
+	record(HealthCheckFSMO, c.checkFSMO(ctx), "all roles owned")
+	record(HealthCheckSysvol, c.checkSysvol(ctx), "ACLs consistent")
+
+	forwarder := rodentCfg.GetConfig().AD.DC.DnsForwarder
+	if forwarder != "" {
+		record(HealthCheckDNSForwarder, checkDNSForwarder(ctx, forwarder), forwarder+" reachable")
+	}
+
+	if checkDB {
+		record(HealthCheckDatabase, c.checkDatabase(ctx), "no errors")
+	}
+
+	report.Healthy = len(report.failed()) == 0
+	return report
+}
+
+// checkContainer verifies the DC container is running and not marked
+// unhealthy by its Docker health check
+func (c *Client) checkContainer(ctx context.Context) error {
+	statuses, err := c.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		return fmt.Errorf("AD DC container not found")
+	}
+
+	for _, status := range statuses {
+		if status.InstanceState() != "running" {
+			return fmt.Errorf("AD DC container %s is %s", status.InstanceName(), status.InstanceState())
+		}
+		if status.InstanceHealth() == "unhealthy" {
+			return fmt.Errorf("AD DC container %s is unhealthy", status.InstanceName())
+		}
+	}
+	return nil
+}
+
+// checkReplication verifies inbound and outbound replication with
+// 'samba-tool drs showrepl --summary'
+func (c *Client) checkReplication(ctx context.Context) error {
+	output, err := c.sambaTool(ctx, "drs", "showrepl", "--summary")
+	summary := parseShowreplSummary(output)
+	switch {
+	case len(summary.Failures) > 0:
+		// samba-tool exits non-zero when links fail; the links say more
+		return fmt.Errorf("replication failures: %s", strings.Join(summary.Failures, "; "))
+	case err != nil:
+		return fmt.Errorf("replication check failed: %w", err)
+	case !summary.AllGood:
+		return fmt.Errorf("replication failures: %s", output)
+	}
+	return nil
+}
+
+// replicationSummary is the parsed output of 'samba-tool drs showrepl --summary'
+type replicationSummary struct {
+	AllGood  bool     `json:"all_good"`
+	Failures []string `json:"failures,omitempty"`
+}
+
+// parseShowreplSummary reads '[ALL GOOD]' or the failing links listed under
+// "Failing outbound connections:" and "Failing inbound connection:". Each
+// failure names the direction, naming context, peer and last result.
+func parseShowreplSummary(output string) replicationSummary {
+	var summary replicationSummary
+	var direction, nc string
+	for _, line := range strings.Split(output, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "[ALL GOOD]":
+			summary.AllGood = true
+		case strings.HasPrefix(trimmed, "Failing outbound"):
+			direction = "outbound"
+		case strings.HasPrefix(trimmed, "Failing inbound"):
+			direction = "inbound"
+		case direction == "" || trimmed == "":
+			// Outside the failing lists, or between links
+		case !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, " "):
+			nc = trimmed
+		case strings.HasSuffix(trimmed, " via RPC"):
+			peer := strings.TrimSuffix(trimmed, " via RPC")
+			summary.Failures = append(summary.Failures, fmt.Sprintf("%s %s with %s", direction, nc, peer))
+		case len(summary.Failures) > 0 && strings.Contains(trimmed, "failed, result "):
+			_, result, _ := strings.Cut(trimmed, "failed, ")
+			summary.Failures[len(summary.Failures)-1] += ": " + result
+		}
+	}
+	return summary
+}
+
+// checkFSMO verifies every FSMO role has an owner that has not been deleted
+func (c *Client) checkFSMO(ctx context.Context) error {
+	output, err := c.sambaTool(ctx, "fsmo", "show")
+	if err != nil {
+		return fmt.Errorf("failed to show FSMO roles: %w", err)
+	}
+	return fsmoOwnerError(parseFSMOOwners(output))
+}
+
+// parseFSMOOwners maps each role in 'samba-tool fsmo show' output to the DN
+// of its owner's NTDS settings
+func parseFSMOOwners(output string) map[string]string {
+	owners := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		role, owner, ok := strings.Cut(line, " owner: ")
+		if ok {
+			owners[strings.TrimSpace(role)] = strings.TrimSpace(owner)
+		}
+	}
+	return owners
+}
+
+// fsmoOwnerError reports the first role without an owner or owned by a
+// deleted DC, whose DN carries the "\0ADEL:" mangling
+func fsmoOwnerError(owners map[string]string) error {
+	for _, role := range fsmoRoles {
+		owner := owners[role]
+		switch {
+		case owner == "":
+			return fmt.Errorf("FSMO role %s has no owner", role)
+		case strings.Contains(owner, "0ADEL"):
+			return fmt.Errorf("FSMO role %s is owned by a deleted DC", role)
+		}
+	}
+	return nil
+}
+
+// checkSysvol verifies the sysvol ACLs match the GPOs
+func (c *Client) checkSysvol(ctx context.Context) error {
+	if _, err := c.sambaTool(ctx, "ntacl", "sysvolcheck"); err != nil {
+		return fmt.Errorf("sysvol ACLs are inconsistent, run 'samba-tool ntacl sysvolreset': %w", err)
+	}
+	return nil
+}
+
+// checkDatabase runs 'samba-tool dbcheck' across all naming contexts
+func (c *Client) checkDatabase(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, dbCheckTimeout)
+	defer cancel()
+
+	output, err := c.sambaTool(ctx, "dbcheck", "--cross-ncs")
+	if err != nil {
+		return fmt.Errorf("database errors found, run 'samba-tool dbcheck --cross-ncs --fix': %w", err)
+	}
+	if !strings.Contains(output, "(0 errors)") {
+		return fmt.Errorf("database errors found: %s", output)
+	}
+	return nil
+}
+
+// checkDNSForwarder resolves the root name servers through the forwarder
+func checkDNSForwarder(ctx context.Context, forwarder string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, common.JoinHostPort(forwarder, 53))
+		},
+	}
+	if _, err := resolver.LookupNS(ctx, "."); err != nil {
+		return fmt.Errorf("DNS forwarder %s is not answering: %w", forwarder, err)
+	}
+	return nil
+}
+
+// HealthMonitor periodically checks the AD DC and keeps the latest report for
+// the health endpoint. An event is emitted when the DC becomes unhealthy,
+// when the set of failing checks changes and when it recovers.
+type HealthMonitor struct {
+	client   *Client
+	interval time.Duration
+
+	mu          sync.RWMutex
+	report      *HealthReport
+	lastDBCheck time.Time
+	dbCheck     *HealthCheck
+}
+
+// NewHealthMonitor creates an AD DC health monitor. An interval of zero or
+// less uses the default of 15 minutes.
+func NewHealthMonitor(client *Client, interval time.Duration) *HealthMonitor {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	return &HealthMonitor{client: client, interval: interval}
+}
+
+// Report returns the latest health report, or nil before the first check
+func (m *HealthMonitor) Report() *HealthReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.report
+}
+
+// Run checks the DC immediately and then every interval until ctx is cancelled
+func (m *HealthMonitor) Run(ctx context.Context) {
+	m.client.logger.Info("Starting AD DC health monitor", "interval", m.interval)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs one round of checks, reusing the last database check result
+// until dbCheckInterval has passed
+func (m *HealthMonitor) check(ctx context.Context) {
+	m.mu.RLock()
+	checkDB := time.Since(m.lastDBCheck) >= dbCheckInterval
+	m.mu.RUnlock()
+
+	report := m.client.CheckHealth(ctx, checkDB)
+
+	m.mu.Lock()
+	if checkDB {
+		for i := range report.Checks {
+			if report.Checks[i].Name == HealthCheckDatabase {
+				dbCheck := report.Checks[i]
+				m.dbCheck = &dbCheck
+				m.lastDBCheck = report.CheckedAt
+			}
+		}
+	} else if m.dbCheck != nil && len(report.Checks) > 1 {
+		report.Checks = append(report.Checks, *m.dbCheck)
+		report.Healthy = report.Healthy && m.dbCheck.OK
+	}
+	previous := m.report
+	m.report = report
+	m.mu.Unlock()
+
+	m.recordTransition(previous, report)
+}
+
+// recordTransition logs and emits an event when the health state changes
+func (m *HealthMonitor) recordTransition(previous, report *HealthReport) {
+	failed := strings.Join(report.failed(), ",")
+	wasHealthy, previouslyFailed := true, ""
+	if previous != nil {
+		wasHealthy = previous.Healthy
+		previouslyFailed = strings.Join(previous.failed(), ",")
+	}
+
+	switch {
+	case !report.Healthy && (wasHealthy || failed != previouslyFailed):
+		m.client.logger.Warn("AD DC is unhealthy", "failed_checks", failed)
+		m.emitHealthEvent(report, eventspb.EventLevel_EVENT_LEVEL_ERROR, "unhealthy")
+	case report.Healthy && !wasHealthy:
+		m.client.logger.Info("AD DC is healthy again")
+		m.emitHealthEvent(report, eventspb.EventLevel_EVENT_LEVEL_INFO, "recovered")
+	}
+}
+
+// emitHealthEvent publishes an AD DC health change on the service event stream
+func (m *HealthMonitor) emitHealthEvent(report *HealthReport, level eventspb.EventLevel, action string) {
+	status := "healthy"
+	if !report.Healthy {
+		status = "unhealthy"
+	}
+
+	payload := &eventspb.ServiceStatusPayload{
+		ServiceName: m.client.Name(),
+		Status:      status,
+		Operation:   eventspb.ServiceStatusPayload_SERVICE_STATUS_OPERATION_UNSPECIFIED,
+	}
+
+	metadata := map[string]string{
+		"component":     "addc-health",
+		"action":        action,
+		"failed_checks": strings.Join(report.failed(), ","),
+	}
+	for _, check := range report.Checks {
+		if !check.OK {
+			metadata["error_"+check.Name] = check.Message
+		}
+	}
+
+	events.EmitServiceStatus(level, payload, metadata)
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package addc
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files from the current parsers:
+// go test ./internal/services/addc/ -update
+var update = flag.Bool("update", false, "update golden files")
+
+// golden parses every input in testdata/<dir> and compares the result,
+// as JSON, with the input's .golden file
+func golden(t *testing.T, dir string, parse func(input string) any) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*.txt"))
+	require.NoError(t, err)
+	require.NotEmpty(t, inputs)
+
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			got, err := json.MarshalIndent(parse(strings.TrimSpace(string(data))), "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			path := strings.TrimSuffix(input, filepath.Ext(input)) + ".golden"
+			if *update {
+				require.NoError(t, os.WriteFile(path, got, 0644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "run with -update to create the golden file")
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestParseShowreplSummaryGolden(t *testing.T) {
+	golden(t, "showrepl", func(input string) any {
+		return parseShowreplSummary(input)
+	})
+}
+
+func TestParseFSMOOwnersGolden(t *testing.T) {
+	golden(t, "fsmo", func(input string) any {
+		owners := parseFSMOOwners(input)
+		result := struct {
+			Owners map[string]string `json:"owners"`
+			Error  string            `json:"error,omitempty"`
+		}{Owners: owners}
+		if err := fsmoOwnerError(owners); err != nil {
+			result.Error = err.Error()
+		}
+		return result
+	})
+}
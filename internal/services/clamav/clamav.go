@@ -0,0 +1,150 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/services"
+	"github.com/stratastor/rodent/internal/services/systemd"
+)
+
+const (
+	// DaemonServiceName is the clamd unit of the Debian/Ubuntu packages
+	DaemonServiceName = "clamav-daemon"
+
+	// DefaultSocketPath is the clamd socket vfs_virusfilter connects to by default
+	DefaultSocketPath = "/var/run/clamav/clamd.ctl"
+
+	pingTimeout = 5 * time.Second
+)
+
+// Client handles interactions with the ClamAV scanner daemon used for
+// on-access scanning of SMB shares
+type Client struct {
+	logger        logger.Logger
+	systemdClient *systemd.Client
+	socketPath    string
+}
+
+// Verify that Client implements both Service and StartupService interfaces
+var (
+	_ services.Service        = (*Client)(nil)
+	_ services.StartupService = (*Client)(nil)
+)
+
+// NewClient creates a new ClamAV service client
+func NewClient(logger logger.Logger) (*Client, error) {
+	if logger == nil {
+		return nil, fmt.Errorf("logger cannot be nil")
+	}
+
+	if _, err := exec.LookPath("clamd"); err != nil {
+		return nil, fmt.Errorf("clamav is not available or not in PATH: %w", err)
+	}
+
+	systemdClient, err := systemd.NewClient(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create systemd client: %w", err)
+	}
+
+	return &Client{
+		logger:        logger,
+		systemdClient: systemdClient,
+		socketPath:    DefaultSocketPath,
+	}, nil
+}
+
+// Name returns the name of the service
+func (c *Client) Name() string {
+	return "clamav"
+}
+
+// Status returns the status of the scanner daemon. A running daemon that does
+// not answer a PING is reported as unhealthy, since smbd cannot scan with it.
+func (c *Client) Status(ctx context.Context) ([]services.ServiceStatus, error) {
+	status, err := c.systemdClient.GetServiceStatus(ctx, DaemonServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ClamAV daemon status: %w", err)
+	}
+
+	if status.State == "running" {
+		if err := Ping(ctx, c.socketPath); err != nil {
+			c.logger.Warn("ClamAV daemon is running but not answering", "socket", c.socketPath, "err", err)
+			status.Health = "unhealthy"
+		}
+	}
+
+	return []services.ServiceStatus{status}, nil
+}
+
+// Start starts the scanner daemon
+func (c *Client) Start(ctx context.Context) error {
+	return c.systemdClient.StartService(ctx, DaemonServiceName)
+}
+
+// Stop stops the scanner daemon. Shares with block_access_on_error set deny
+// access to files until it is started again.
+func (c *Client) Stop(ctx context.Context) error {
+	return c.systemdClient.StopService(ctx, DaemonServiceName)
+}
+
+// Restart restarts the scanner daemon
+func (c *Client) Restart(ctx context.Context) error {
+	return c.systemdClient.RestartService(ctx, DaemonServiceName)
+}
+
+// EnableAtStartup enables the scanner daemon to start at system boot
+func (c *Client) EnableAtStartup(ctx context.Context) error {
+	return c.systemdClient.EnableService(ctx, DaemonServiceName)
+}
+
+// DisableAtStartup disables the scanner daemon from starting at system boot
+func (c *Client) DisableAtStartup(ctx context.Context) error {
+	return c.systemdClient.DisableService(ctx, DaemonServiceName)
+}
+
+// IsEnabledAtStartup checks if the scanner daemon starts at system boot
+func (c *Client) IsEnabledAtStartup(ctx context.Context) (bool, error) {
+	return c.systemdClient.IsServiceEnabled(ctx, DaemonServiceName)
+}
+
+// Ping checks that clamd answers on its socket
+func Ping(ctx context.Context, socketPath string) error {
+	ctx, cancel := context.WithTimeout(ctx, pingTimeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The z prefix selects null-terminated commands and replies
+	if _, err := conn.Write([]byte("zPING\x00")); err != nil {
+		return fmt.Errorf("failed to send PING to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil {
+		return fmt.Errorf("failed to read PING reply from clamd: %w", err)
+	}
+	if strings.TrimSuffix(reply, "\x00") != "PONG" {
+		return fmt.Errorf("unexpected PING reply from clamd: %q", reply)
+	}
+	return nil
+}
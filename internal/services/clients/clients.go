@@ -7,6 +7,7 @@ package clients
 import (
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/internal/services/addc"
+	"github.com/stratastor/rodent/internal/services/clamav"
 	"github.com/stratastor/rodent/internal/services/docker"
 	"github.com/stratastor/rodent/internal/services/samba"
 	"github.com/stratastor/rodent/internal/services/systemd"
@@ -33,6 +34,11 @@ func NewSambaClient(logger logger.Logger) (*samba.Client, error) {
 	return samba.NewClient(logger)
 }
 
+// NewClamAVClient creates a new ClamAV scanner service client
+func NewClamAVClient(logger logger.Logger) (*clamav.Client, error) {
+	return clamav.NewClient(logger)
+}
+
 // NewSystemdClient creates a new systemd service client
 func NewSystemdClient(logger logger.Logger) (*systemd.Client, error) {
 	return systemd.NewClient(logger)
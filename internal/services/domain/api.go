@@ -0,0 +1,196 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// JoinRequest joins the host to a domain. Without a realm the configured
+// domain is joined and the credentials come from the configuration.
+type JoinRequest struct {
+	Realm         string   `json:"realm,omitempty"`
+	DCServers     []string `json:"dc_servers,omitempty"`
+	AdminUser     string   `json:"admin_user,omitempty"` // Defaults to Administrator with a realm
+	AdminPassword string   `json:"admin_password,omitempty"`
+	Backend       string   `json:"backend,omitempty"`      // winbind or sssd; defaults to config
+	WaitSeconds   int      `json:"wait_seconds,omitempty"` // Readiness check of each DC
+	Preview       bool     `json:"preview,omitempty"`      // Only show the Kerberos changes
+}
+
+// Config returns the domain configuration the request joins with
+func (r JoinRequest) Config() *DomainConfig {
+	var cfg *DomainConfig
+	if r.Realm != "" {
+		cfg = &DomainConfig{
+			Realm:         r.Realm,
+			DCServers:     r.DCServers,
+			AdminUser:     r.AdminUser,
+			AdminPassword: r.AdminPassword,
+		}
+		if cfg.AdminUser == "" {
+			cfg.AdminUser = "Administrator"
+		}
+	} else {
+		cfg = GetConfigFromGlobal()
+	}
+	if r.Backend != "" {
+		cfg.MembershipBackend = r.Backend
+	}
+	if r.WaitSeconds > 0 {
+		cfg.DCWaitTimeout = time.Duration(r.WaitSeconds) * time.Second
+	}
+	return cfg
+}
+
+// LeaveRequest removes the host from the configured domain. Empty fields
+// take their value from the configuration.
+type LeaveRequest struct {
+	AdminUser     string `json:"admin_user,omitempty"`
+	AdminPassword string `json:"admin_password,omitempty"`
+	Backend       string `json:"backend,omitempty"`
+}
+
+// Config returns the domain configuration the request leaves with
+func (r LeaveRequest) Config() *DomainConfig {
+	cfg := GetConfigFromGlobal()
+	if r.AdminUser != "" {
+		cfg.AdminUser = r.AdminUser
+	}
+	if r.AdminPassword != "" {
+		cfg.AdminPassword = r.AdminPassword
+	}
+	if r.Backend != "" {
+		cfg.MembershipBackend = r.Backend
+	}
+	return cfg
+}
+
+// CheckRequest checks the configured domain membership
+type CheckRequest struct {
+	Repair  bool   `json:"repair,omitempty"`
+	Backend string `json:"backend,omitempty"`
+}
+
+// Config returns the domain configuration the request checks
+func (r CheckRequest) Config() (*DomainConfig, error) {
+	cfg := GetConfigFromGlobal()
+	if r.Backend != "" {
+		cfg.MembershipBackend = r.Backend
+	}
+	if cfg.Realm == "" {
+		return nil, errors.New(errors.ConfigValidationFailed, "no realm configured")
+	}
+	return cfg, nil
+}
+
+// StatusResponse is the domain membership of the host
+type StatusResponse struct {
+	Joined bool   `json:"joined"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// APIHandler serves domain membership to the CLI and other clients, so they
+// act through the daemon's client rather than one of their own
+type APIHandler struct {
+	client *Client
+}
+
+// NewAPIHandler creates a domain membership API handler
+func NewAPIHandler(client *Client) *APIHandler {
+	return &APIHandler{client: client}
+}
+
+// RegisterRoutes registers the domain membership routes
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/status", h.status)
+	router.POST("/join", h.join)
+	router.POST("/leave", h.leave)
+	router.POST("/check", h.check)
+	router.GET("/trusts", h.trusts)
+}
+
+// status reports whether the host is joined
+func (h *APIHandler) status(c *gin.Context) {
+	joined, domain, err := h.client.Status(c.Request.Context())
+	if err != nil {
+		common.APIError(c, errors.Wrap(err, errors.ADMembershipFailed))
+		return
+	}
+	c.JSON(http.StatusOK, StatusResponse{Joined: joined, Domain: domain})
+}
+
+// join joins the domain, or previews the Kerberos changes
+func (h *APIHandler) join(c *gin.Context) {
+	var req JoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+	cfg := req.Config()
+
+	if req.Preview {
+		diff, err := h.client.PreviewKerberos(c.Request.Context(), cfg)
+		if err != nil {
+			common.APIError(c, errors.Wrap(err, errors.ADMembershipFailed))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"realm": cfg.Realm, "diff": diff})
+		return
+	}
+
+	if err := h.client.Join(c.Request.Context(), cfg); err != nil {
+		common.APIError(c, errors.Wrap(err, errors.ADMembershipFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"realm": cfg.Realm})
+}
+
+// leave removes the host from the domain
+func (h *APIHandler) leave(c *gin.Context) {
+	var req LeaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.client.Leave(c.Request.Context(), req.Config()); err != nil {
+		common.APIError(c, errors.Wrap(err, errors.ADMembershipFailed))
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// check runs the membership health check, repairing the trust if asked to.
+// An unhealthy membership is a successful response with healthy false.
+func (h *APIHandler) check(c *gin.Context) {
+	var req CheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+	cfg, err := req.Config()
+	if err != nil {
+		common.APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, h.client.CheckHealth(c.Request.Context(), cfg, req.Repair))
+}
+
+// trusts lists the joined domain and its trusted domains
+func (h *APIHandler) trusts(c *gin.Context) {
+	domains, err := h.client.TrustedDomains(c.Request.Context())
+	if err != nil {
+		common.APIError(c, errors.Wrap(err, errors.ADMembershipFailed))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"trusts": domains})
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// How the admin credentials reach net ads join and leave
+const (
+	// AdminAuthKerberos obtains a ticket with kinit, which reads the
+	// password from stdin, and runs net with -k
+	AdminAuthKerberos = "kerberos"
+	// AdminAuthFile passes the credentials in a samba authentication file
+	AdminAuthFile = "file"
+)
+
+// adminAuth returns the configured admin authentication, defaulting to
+// kerberos
+func (cfg *DomainConfig) adminAuth() string {
+	if cfg.AdminAuth == "" {
+		return AdminAuthKerberos
+	}
+	return strings.ToLower(cfg.AdminAuth)
+}
+
+// adminPrincipal returns the Kerberos principal of the admin user. A user
+// given as user@realm is kept, otherwise the realm is appended.
+func (cfg *DomainConfig) adminPrincipal() string {
+	if strings.Contains(cfg.AdminUser, "@") {
+		return cfg.AdminUser
+	}
+	return cfg.AdminUser + "@" + strings.ToUpper(cfg.Realm)
+}
+
+// netAsAdmin runs a net command as the domain admin. With kerberos the
+// password only travels over kinit's stdin; where kinit is not installed, or
+// with ad.adminAuth set to "file", it is written to an authentication file.
+// Either way it never appears in the process arguments.
+func (c *Client) netAsAdmin(ctx context.Context, cfg *DomainConfig, args ...string) error {
+	if strings.ContainsAny(cfg.AdminUser+cfg.AdminPassword, "\r\n") {
+		return fmt.Errorf("admin credentials must not contain line breaks")
+	}
+
+	if cfg.adminAuth() == AdminAuthKerberos {
+		if _, err := exec.LookPath("kinit"); err == nil {
+			return c.withAdminTicket(ctx, cfg, func(ccache string) error {
+				// net takes the ccache itself (Samba 4.15+), so sudo need
+				// not run it through env
+				_, err := c.executor.ExecuteWithCombinedOutput(ctx, "net",
+					append(args, "--use-krb5-ccache=FILE:"+ccache)...)
+				return err
+			})
+		}
+		c.logger.Warn("kinit not found, passing admin credentials in an authentication file")
+	}
+
+	return withAuthFile(cfg, func(authFile string) error {
+		_, err := c.executor.ExecuteWithCombinedOutput(ctx, "net",
+			append(args, "--authentication-file="+authFile)...)
+		return err
+	})
+}
+
+// withAdminTicket obtains a ticket for the admin user in a credentials cache
+// private to this call, and destroys it once fn returns
+func (c *Client) withAdminTicket(ctx context.Context, cfg *DomainConfig, fn func(ccache string) error) error {
+	// MkdirTemp creates the directory with mode 0700
+	dir, err := os.MkdirTemp("", "rodent-krb5-*")
+	if err != nil {
+		return fmt.Errorf("failed to create credentials cache directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	ccache := filepath.Join(dir, "krb5cc")
+
+	principal := cfg.adminPrincipal()
+	if _, err := c.executor.ExecuteWithInput(ctx, cfg.AdminPassword+"\n",
+		"kinit", "-c", ccache, principal); err != nil {
+		return fmt.Errorf("kinit failed for %s: %w", principal, err)
+	}
+	defer func() {
+		_, _ = c.executor.ExecuteWithCombinedOutput(ctx, "kdestroy", "-c", ccache)
+	}()
+
+	return fn(ccache)
+}
+
+// withAuthFile writes the admin credentials to a samba authentication file
+// readable only by Rodent (and root, which runs net) for the duration of fn,
+// so the password never appears in the process arguments
+func withAuthFile(cfg *DomainConfig, fn func(authFile string) error) error {
+	// CreateTemp creates the file with mode 0600
+	f, err := os.CreateTemp("", "rodent-auth-*")
+	if err != nil {
+		return fmt.Errorf("failed to create authentication file: %w", err)
+	}
+	authFile := f.Name()
+	defer os.Remove(authFile)
+
+	_, err = fmt.Fprintf(f, "username = %s\npassword = %s\n", cfg.AdminUser, cfg.AdminPassword)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write authentication file: %w", err)
+	}
+
+	return fn(authFile)
+}
@@ -15,12 +15,13 @@
 // The domain join process involves several steps to integrate the Linux host with AD:
 //
 //  1. Kerberos Configuration (/etc/krb5.conf):
-//     - Configures realm, KDC servers, and domain mappings
+//     - Merges realm, KDC servers, and domain mappings into the existing file
+//     - Preserves other realms and settings used by other services
 //     - Enables dns_lookup_kdc for automatic DC discovery
 //     - Required for 'net ads join' to authenticate with the DC
 //
 //  2. NSS Configuration (/etc/nsswitch.conf):
-//     - Adds winbind to passwd and group resolution
+//     - Adds winbind (or sss) to passwd and group resolution
 //     - Allows Linux to resolve AD users/groups (e.g., 'id paul0')
 //     - Format: "passwd: files systemd winbind"
 //
@@ -39,6 +40,15 @@
 //     - Provides user/group enumeration from AD
 //     - Required for SMB shares with AD authentication
 //
+// # Membership Backends
+//
+// DomainConfig.MembershipBackend (config.AD.MembershipBackend) selects the
+// identity stack. "winbind" is the default and follows the steps above. With
+// "sssd", /etc/sssd/sssd.conf is generated (id_provider = ad, mode 0600),
+// nsswitch.conf uses "sss", the join and leave run through adcli with the
+// password on stdin, and sssd is restarted instead of winbind. Status()
+// reports membership the same way for either backend.
+//
 // # Self-Hosted vs External AD
 //
 // Self-Hosted Mode (config.AD.Mode = "self-hosted"):
@@ -54,8 +64,21 @@
 //
 // # DC Failover
 //
-// When multiple domain controllers are configured, the Join() method tries each DC
-// in order until one succeeds. This provides resilience against individual DC failures.
+// Join() builds a candidate list from the configured DCs, in order, followed by
+// the DCs advertised in DNS (_ldap._tcp.dc._msdcs.<realm>) ordered by SRV
+// priority and weight. Each candidate's LDAPS port is checked first and
+// unreachable DCs are skipped; the join is then attempted against each
+// reachable DC with 'net ads join -S <dc>' (or 'adcli join
+// --domain-controller=<dc>') until one succeeds. When all fail,
+// the returned *JoinError lists the reason for every DC tried.
+//
+// # Health Checks
+//
+// CheckHealth verifies membership without admin credentials: testjoin, a kinit
+// as the machine account from the keytab, and wbinfo -t / --ping-dc (sssctl
+// domain-status for SSSD). A broken winbind trust can be repaired with
+// 'net ads join -k' while the keytab is still accepted. HealthMonitor runs the
+// check periodically and emits a service event when the state changes.
 //
 // # Manual Operations
 //
@@ -64,6 +87,7 @@
 //	rodent domain join --realm AD.CORP.COM --dc dc1.corp.com --user Administrator
 //	rodent domain leave
 //	rodent domain status
+//	rodent domain check --repair
 //
 // # File Backups
 //
@@ -79,30 +103,73 @@ package domain
 import (
 	"context"
 	"fmt"
-	"net"
+	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/stratastor/logger"
 	rodentCfg "github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/system/privilege"
 )
 
+// krb5ConfPath is the system Kerberos configuration
+const krb5ConfPath = "/etc/krb5.conf"
+
 // DomainConfig contains configuration for domain join operations
 type DomainConfig struct {
 	Realm         string   // AD realm (e.g., "AD.STRATA.INTERNAL")
 	DCServers     []string // List of domain controller IPs/hostnames
 	AdminUser     string   // Admin username for domain join
-	AdminPassword string   // Admin password
+	AdminPassword string   `json:"-"` // Admin password, never serialized
 	IPAddress     string   // DC IP address (for DNS configuration)
 	HostInterface string   // Host interface for DNS configuration
+
+	// DCWaitTimeout bounds the reachability check of each DC during join;
+	// zero uses a default of 10 seconds
+	DCWaitTimeout time.Duration
+
+	// MembershipBackend selects the join and identity stack: "winbind"
+	// (default, net ads join) or "sssd" (adcli join)
+	MembershipBackend string
+
+	// AdminAuth selects how net ads join and leave get the admin
+	// credentials: "kerberos" (default, a kinit ticket) or "file" (a samba
+	// authentication file). Kerberos falls back to the file without kinit.
+	AdminAuth string
+}
+
+// LogValue logs the configuration without the admin password
+func (cfg DomainConfig) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("realm", cfg.Realm),
+		slog.Any("dc_servers", cfg.DCServers),
+		slog.String("admin_user", cfg.AdminUser),
+		slog.String("membership_backend", cfg.membershipBackend()),
+		slog.String("admin_auth", cfg.adminAuth()),
+	)
 }
 
 // Client handles domain membership operations
 type Client struct {
 	logger   logger.Logger
-	executor *command.CommandExecutor
+	executor membershipExecutor
+	fileOps  privilege.FileOperations
+
+	// membership serializes joins, leaves and health checks, which share
+	// the Kerberos and backend configuration and the machine ccache
+	membership sync.Mutex
+}
+
+// membershipExecutor runs the membership tools with sudo; *command.CommandExecutor in use
+type membershipExecutor interface {
+	ExecuteWithCombinedOutput(ctx context.Context, cmd string, args ...string) ([]byte, error)
+	ExecuteWithInput(ctx context.Context, input string, cmd string, args ...string) ([]byte, error)
 }
 
 // NewClient creates a new domain client
@@ -115,13 +182,17 @@ func NewClient(logger logger.Logger) (*Client, error) {
 	executor := command.NewCommandExecutor(true)
 
 	return &Client{
-		logger:   logger,
+		logger:   common.WithRedaction(logger),
 		executor: executor,
+		fileOps:  privilege.NewSudoFileOperations(logger, executor, privilege.AllowedPaths()),
 	}, nil
 }
 
 // Join joins the host to an AD domain
 func (c *Client) Join(ctx context.Context, cfg *DomainConfig) error {
+	c.membership.Lock()
+	defer c.membership.Unlock()
+
 	c.logger.Info("Starting domain join process", "realm", cfg.Realm, "admin_user", cfg.AdminUser)
 
 	// Validate configuration
@@ -129,9 +200,11 @@ func (c *Client) Join(ctx context.Context, cfg *DomainConfig) error {
 		return fmt.Errorf("invalid domain configuration: %w", err)
 	}
 
+	backend := cfg.membershipBackend()
+
 	// Check if already joined
-	c.logger.Info("Checking if host is already joined to AD domain", "realm", cfg.Realm)
-	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "testjoin")
+	c.logger.Info("Checking if host is already joined to AD domain", "realm", cfg.Realm, "backend", backend)
+	_, err := c.testJoin(ctx, backend)
 	if err == nil {
 		c.logger.Info("Host is already joined to AD domain", "realm", cfg.Realm)
 		return nil
@@ -139,13 +212,24 @@ func (c *Client) Join(ctx context.Context, cfg *DomainConfig) error {
 
 	c.logger.Info("Host not joined to AD domain, proceeding with join", "realm", cfg.Realm)
 
+	candidates := c.candidateDCs(ctx, cfg)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no domain controllers configured or found in DNS for %s", cfg.Realm)
+	}
+
 	// Configure Kerberos
-	if err := c.configureKerberos(ctx, cfg); err != nil {
+	if err := c.configureKerberos(ctx, cfg, candidates); err != nil {
 		return fmt.Errorf("failed to configure Kerberos: %w", err)
 	}
 
-	// Configure NSS for winbind
-	if err := c.configureNSS(ctx); err != nil {
+	if backend == MembershipBackendSSSD {
+		if err := c.configureSSSD(ctx, cfg, candidates); err != nil {
+			return fmt.Errorf("failed to configure SSSD: %w", err)
+		}
+	}
+
+	// Configure NSS for the membership backend
+	if err := c.configureNSS(ctx, nssSource(backend)); err != nil {
 		return fmt.Errorf("failed to configure NSS: %w", err)
 	}
 
@@ -157,25 +241,21 @@ func (c *Client) Join(ctx context.Context, cfg *DomainConfig) error {
 		}
 	}
 
-	// Join the domain using net ads join
-	c.logger.Info("Joining AD domain", "realm", cfg.Realm, "user", cfg.AdminUser)
-
-	// Use --password flag for non-interactive join
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "join",
-		"-U", cfg.AdminUser,
-		"--password="+cfg.AdminPassword)
+	// Join the domain, failing over between domain controllers
+	c.logger.Info("Joining AD domain", "realm", cfg.Realm, "user", cfg.AdminUser, "candidates", candidates)
+	dc, err := c.joinWithFailover(ctx, cfg, candidates)
 	if err != nil {
-		return fmt.Errorf("failed to join AD domain: %w", err)
+		return err
 	}
 
-	c.logger.Info("Successfully joined AD domain", "realm", cfg.Realm)
+	c.logger.Info("Successfully joined AD domain", "realm", cfg.Realm, "dc", dc, "backend", backend)
 
-	// Restart winbind service to apply domain membership
-	c.logger.Info("Restarting winbind service")
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", "winbind")
+	// Restart the backend service to apply domain membership
+	c.logger.Info("Restarting membership service", "service", backend)
+	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", backend)
 	if err != nil {
-		c.logger.Warn("Failed to restart winbind, continuing", "error", err)
-		// Don't fail completely - winbind might not be installed yet
+		c.logger.Warn("Failed to restart membership service, continuing", "service", backend, "error", err)
+		// Don't fail completely - the service might not be installed yet
 	}
 
 	return nil
@@ -183,50 +263,53 @@ func (c *Client) Join(ctx context.Context, cfg *DomainConfig) error {
 
 // Leave removes the host from the AD domain
 func (c *Client) Leave(ctx context.Context, cfg *DomainConfig) error {
+	c.membership.Lock()
+	defer c.membership.Unlock()
+
 	c.logger.Info("Leaving AD domain", "realm", cfg.Realm)
 
+	backend := cfg.membershipBackend()
+
 	// Check if we're actually joined
-	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "testjoin")
-	if err != nil {
+	if _, err := c.testJoin(ctx, backend); err != nil {
 		c.logger.Info("Host is not joined to any domain")
 		return nil
 	}
 
 	// Leave the domain
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "leave",
-		"-U", cfg.AdminUser,
-		"--password="+cfg.AdminPassword)
-	if err != nil {
+	if err := c.leaveCommand(ctx, cfg); err != nil {
 		return fmt.Errorf("failed to leave AD domain: %w", err)
 	}
 
-	c.logger.Info("Successfully left AD domain")
+	c.logger.Info("Successfully left AD domain", "backend", backend)
 
-	// Restart winbind
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", "winbind")
+	// Restart the backend service
+	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", backend)
 	if err != nil {
-		c.logger.Warn("Failed to restart winbind", "error", err)
+		c.logger.Warn("Failed to restart membership service", "service", backend, "error", err)
 	}
 
 	return nil
 }
 
-// Status checks if the host is joined to a domain
+// Status checks if the host is joined to a domain through either backend
 func (c *Client) Status(ctx context.Context) (bool, string, error) {
-	output, err := c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "testjoin")
-	if err != nil {
-		return false, "", nil // Not joined
+	for _, backend := range []string{MembershipBackendWinbind, MembershipBackendSSSD} {
+		domain, err := c.testJoin(ctx, backend)
+		if err == nil {
+			return true, domain, nil
+		}
 	}
 
-	// Parse output to extract domain name
-	domain := strings.TrimSpace(string(output))
-
-	return true, domain, nil
+	return false, "", nil // Not joined
 }
 
-// WaitForDC waits for a domain controller to be ready
+// WaitForDC waits for a domain controller to be ready. Each attempt tries
+// every address of the DC, IPv6 and IPv4, so a dual-stack DC is reachable
+// through either family.
 func (c *Client) WaitForDC(ctx context.Context, dcServer string, timeout time.Duration) error {
-	ldapsPort := "636"
+	const ldapsPort = 636
+	host := common.UnbracketHost(dcServer)
 
 	deadline := time.Now().Add(timeout)
 	attempt := 0
@@ -235,11 +318,11 @@ func (c *Client) WaitForDC(ctx context.Context, dcServer string, timeout time.Du
 		attempt++
 
 		// Try to connect to LDAPS port
-		conn, err := net.DialTimeout("tcp", dcServer+":"+ldapsPort, 2*time.Second)
+		addr, err := dialAnyAddr(ctx, host, ldapsPort, 2*time.Second)
 		if err == nil {
-			conn.Close()
 			c.logger.Info("Domain controller LDAPS port is reachable",
 				"dc", dcServer,
+				"address", addr,
 				"attempts", attempt)
 			return nil
 		}
@@ -267,127 +350,127 @@ func (c *Client) validateConfig(cfg *DomainConfig) error {
 	if cfg.Realm == "" {
 		return fmt.Errorf("realm is required")
 	}
-	if len(cfg.DCServers) == 0 {
-		return fmt.Errorf("at least one domain controller is required")
-	}
 	if cfg.AdminUser == "" {
 		return fmt.Errorf("admin user is required")
 	}
 	if cfg.AdminPassword == "" {
 		return fmt.Errorf("admin password is required")
 	}
+	switch cfg.membershipBackend() {
+	case MembershipBackendWinbind, MembershipBackendSSSD:
+	default:
+		return fmt.Errorf("unsupported membership backend %q", cfg.MembershipBackend)
+	}
+	switch cfg.adminAuth() {
+	case AdminAuthKerberos, AdminAuthFile:
+	default:
+		return fmt.Errorf("unsupported admin authentication %q", cfg.AdminAuth)
+	}
 	return nil
 }
 
-// configureKerberos writes a minimal Kerberos configuration for AD
-func (c *Client) configureKerberos(ctx context.Context, cfg *DomainConfig) error {
-	realm := strings.ToUpper(cfg.Realm)
-	domainLower := strings.ToLower(cfg.Realm)
+// PreviewKerberos returns a diff of the changes Join would make to
+// /etc/krb5.conf, without applying them
+func (c *Client) PreviewKerberos(ctx context.Context, cfg *DomainConfig) (string, error) {
+	candidates := c.candidateDCs(ctx, cfg)
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no domain controllers configured or found in DNS for %s", cfg.Realm)
+	}
 
-	c.logger.Info("Configuring Kerberos", "realm", realm)
+	current, err := c.readKerberosConfig(ctx)
+	if err != nil {
+		return "", err
+	}
+	return diffLines(current, mergeKerberosConfig(current, cfg.Realm, candidates)), nil
+}
 
-	// Backup existing krb5.conf if it exists
-	krb5Path := "/etc/krb5.conf"
-	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "test", "-f", krb5Path)
+// readKerberosConfig returns the current krb5.conf, or "" if there is none
+func (c *Client) readKerberosConfig(ctx context.Context) (string, error) {
+	data, err := os.ReadFile(krb5ConfPath)
 	if err == nil {
-		// File exists, create backup with datetime
-		backupPath := fmt.Sprintf("%s.backup.%s", krb5Path, time.Now().Format("20060102-150405"))
-		c.logger.Info("Backing up existing Kerberos config", "backup", backupPath)
-		_, err = c.executor.ExecuteWithCombinedOutput(ctx, "cp", krb5Path, backupPath)
-		if err != nil {
-			c.logger.Warn("Failed to backup krb5.conf", "error", err)
-		}
+		return string(data), nil
 	}
-
-	// Build KDC list from DC servers
-	kdcList := ""
-	for _, dc := range cfg.DCServers {
-		kdcList += fmt.Sprintf("        kdc = %s\n", dc)
+	if os.IsNotExist(err) {
+		return "", nil
 	}
 
-	krb5Conf := fmt.Sprintf(`[libdefaults]
-    default_realm = %s
-    dns_lookup_realm = false
-    dns_lookup_kdc = true
-    ticket_lifetime = 30d
-    renew_lifetime = 365d
-    forwardable = true
+	// Fall back to sudo when the file is not world-readable
+	data, err = c.fileOps.ReadFile(ctx, krb5ConfPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", krb5ConfPath, err)
+	}
+	return string(data), nil
+}
 
-[realms]
-    %s = {
-%s        admin_server = %s
-        default_domain = %s
-    }
+// configureKerberos merges the realm into the existing Kerberos configuration,
+// listing the candidate DCs as KDCs in join order. Realms and settings used by
+// other services are preserved.
+func (c *Client) configureKerberos(ctx context.Context, cfg *DomainConfig, dcServers []string) error {
+	realm := strings.ToUpper(cfg.Realm)
 
-`, realm, realm, kdcList, cfg.DCServers[0], domainLower)
+	c.logger.Info("Configuring Kerberos", "realm", realm)
 
-	// Write Kerberos config
-	// Create temp file
-	tmpFile, err := os.CreateTemp("", "rodent-krb5-*")
+	current, err := c.readKerberosConfig(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file for krb5.conf: %w", err)
+		return err
 	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
 
-	if _, err := tmpFile.WriteString(krb5Conf); err != nil {
-		tmpFile.Close()
-		return fmt.Errorf("failed to write krb5.conf: %w", err)
+	krb5Conf := mergeKerberosConfig(current, realm, dcServers)
+	if krb5Conf == current {
+		c.logger.Info("Kerberos configuration already up to date")
+		return nil
 	}
-	tmpFile.Close()
+	c.logger.Info("Kerberos configuration changes", "diff", diffLines(current, krb5Conf))
 
-	// Copy to /etc/krb5.conf using sudo
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "cp", tmpPath, krb5Path)
-	if err != nil {
-		return fmt.Errorf("failed to copy krb5.conf: %w", err)
+	// Backup existing krb5.conf if it exists
+	if current != "" {
+		backupPath := fmt.Sprintf("%s.backup.%s", krb5ConfPath, time.Now().Format("20060102-150405"))
+		c.logger.Info("Backing up existing Kerberos config", "backup", backupPath)
+		if err := c.fileOps.CopyFile(ctx, krb5ConfPath, backupPath); err != nil {
+			c.logger.Warn("Failed to backup krb5.conf", "error", err)
+		}
+	}
+
+	// Write Kerberos config
+	if err := c.fileOps.WriteFile(ctx, krb5ConfPath, []byte(krb5Conf), 0o644); err != nil {
+		return fmt.Errorf("failed to write krb5.conf: %w", err)
 	}
 
 	c.logger.Info("Kerberos configuration written successfully")
 	return nil
 }
 
-// configureNSS updates /etc/nsswitch.conf to use winbind for user/group resolution
-func (c *Client) configureNSS(ctx context.Context) error {
-	c.logger.Info("Configuring NSS for winbind")
+// configureNSS updates /etc/nsswitch.conf to use the given source (winbind
+// or sss) for user/group resolution
+func (c *Client) configureNSS(ctx context.Context, source string) error {
+	c.logger.Info("Configuring NSS", "source", source)
 
-	// Check if winbind is already in nsswitch.conf
-	output, err := c.executor.ExecuteWithCombinedOutput(
-		ctx,
-		"grep",
-		"winbind",
-		"/etc/nsswitch.conf",
-	)
-	if err == nil && len(output) > 0 {
-		c.logger.Debug("NSS already configured for winbind")
+	nssPath := "/etc/nsswitch.conf"
+	data, err := c.fileOps.ReadFile(ctx, nssPath)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", nssPath, err)
+	}
+
+	// Check if the source is already in nsswitch.conf
+	current := string(data)
+	updated := setNSSSource(current, source)
+	if updated == current {
+		c.logger.Debug("NSS already configured", "source", source)
 		return nil
 	}
 
 	// Backup existing nsswitch.conf
-	nssPath := "/etc/nsswitch.conf"
 	backupPath := fmt.Sprintf("%s.backup.%s", nssPath, time.Now().Format("20060102-150405"))
 	c.logger.Info("Backing up existing NSS config", "backup", backupPath)
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "cp", nssPath, backupPath)
-	if err != nil {
+	if err := c.fileOps.CopyFile(ctx, nssPath, backupPath); err != nil {
 		c.logger.Warn("Failed to backup nsswitch.conf", "error", err)
 	}
 
-	// Update passwd and group lines to add winbind
-	// passwd: files systemd winbind
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "sed", "-i",
-		"s/^passwd:.*/passwd:         files systemd winbind/",
-		nssPath)
-	if err != nil {
-		c.logger.Warn("Failed to update passwd line in nsswitch.conf", "error", err)
+	if err := c.fileOps.WriteFile(ctx, nssPath, []byte(updated), 0); err != nil {
+		return fmt.Errorf("failed to update %s: %w", nssPath, err)
 	}
 
-	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "sed", "-i",
-		"s/^group:.*/group:          files systemd winbind/",
-		nssPath)
-	if err != nil {
-		c.logger.Warn("Failed to update group line in nsswitch.conf", "error", err)
-	}
-
-	c.logger.Info("NSS configured for winbind")
+	c.logger.Info("NSS configured", "source", source)
 	return nil
 }
 
@@ -399,15 +482,25 @@ func (c *Client) configureDNS(ctx context.Context, cfg *DomainConfig) error {
 
 	realm := strings.ToLower(cfg.Realm)
 
+	// resolvectl takes a bare IPv4 or IPv6 address, while the configured
+	// one may carry a prefix length or brackets
+	dnsIP, err := common.HostIP(cfg.IPAddress)
+	if err != nil {
+		return err
+	}
+	if !common.ValidInterfaceName(cfg.HostInterface) {
+		return fmt.Errorf("invalid interface name %q", cfg.HostInterface)
+	}
+
 	// Set DNS server for the interface
-	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "resolvectl", "dns",
-		cfg.HostInterface, cfg.IPAddress)
+	_, err = c.executor.ExecuteWithCombinedOutput(ctx, "resolvectl", "dns",
+		cfg.HostInterface, dnsIP.String())
 	if err != nil {
 		c.logger.Warn("Failed to set DNS server via resolvectl", "error", err)
 	} else {
 		c.logger.Info("Configured DNS server via resolvectl",
 			"interface", cfg.HostInterface,
-			"dns", cfg.IPAddress)
+			"dns", dnsIP.String())
 	}
 
 	// Set DNS domain for the interface
@@ -429,8 +522,10 @@ func GetConfigFromGlobal() *DomainConfig {
 	cfg := rodentCfg.GetConfig()
 
 	domainCfg := &DomainConfig{
-		Realm:         cfg.AD.Realm,
-		AdminPassword: cfg.AD.AdminPassword,
+		Realm:             cfg.AD.Realm,
+		AdminPassword:     cfg.AD.AdminPassword,
+		MembershipBackend: cfg.AD.MembershipBackend,
+		AdminAuth:         cfg.AD.AdminAuth,
 	}
 
 	// Populate based on mode
@@ -457,3 +552,28 @@ func GetConfigFromGlobal() *DomainConfig {
 
 	return domainCfg
 }
+
+// setNSSSource returns nsswitch.conf with the passwd and group lines set to
+// "files systemd <source>". It is returned unchanged when source already
+// appears in it as a word.
+func setNSSSource(conf, source string) string {
+	lines := strings.Split(conf, "\n")
+	for _, line := range lines {
+		words := strings.FieldsFunc(line, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		if slices.Contains(words, source) {
+			return conf
+		}
+	}
+
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "passwd:"):
+			lines[i] = "passwd:         files systemd " + source
+		case strings.HasPrefix(line, "group:"):
+			lines[i] = "group:          files systemd " + source
+		}
+	}
+	return strings.Join(lines, "\n")
+}
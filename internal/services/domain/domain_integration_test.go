@@ -0,0 +1,59 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/services/domain"
+	"github.com/stratastor/rodent/internal/testutil/samba"
+)
+
+// TestDomainJoinLeave joins this host to a disposable AD DC and leaves it.
+// Run with RODENT_TEST_SAMBA_DC=1 as root on a disposable host, since the
+// join rewrites its Kerberos and Samba configuration.
+func TestDomainJoinLeave(t *testing.T) {
+	dc := samba.StartDC(t, samba.DCConfig{})
+
+	l, err := logger.NewTag(logger.Config{LogLevel: "debug"}, "domain-test")
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	client, err := domain.NewClient(l)
+	if err != nil {
+		t.Fatalf("Failed to create domain client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cfg := dc.DomainConfig()
+	if err := client.Join(ctx, cfg); err != nil {
+		t.Fatalf("Failed to join %s: %v", dc.Realm, err)
+	}
+	t.Cleanup(func() {
+		// Leave again when an assertion below failed
+		_ = client.Leave(context.Background(), cfg)
+	})
+
+	joined, name, err := client.Status(ctx)
+	if err != nil {
+		t.Fatalf("Failed to get domain status: %v", err)
+	}
+	if !joined || !strings.EqualFold(name, dc.Domain) && !strings.EqualFold(name, dc.Realm) {
+		t.Fatalf("Status = (%v, %q), want joined to %s", joined, name, dc.Realm)
+	}
+
+	if err := client.Leave(ctx, cfg); err != nil {
+		t.Fatalf("Failed to leave %s: %v", dc.Realm, err)
+	}
+	if joined, _, _ := client.Status(ctx); joined {
+		t.Error("Host is still joined after leaving")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+)
+
+// defaultDCWaitTimeout bounds the reachability check of each DC during join
+const defaultDCWaitTimeout = 10 * time.Second
+
+// DCAttempt records why joining through a domain controller failed
+type DCAttempt struct {
+	Server string
+	Stage  string // "precheck" or "join"
+	Err    error
+}
+
+// JoinError aggregates the failure of every domain controller tried by Join
+type JoinError struct {
+	Realm    string
+	Attempts []DCAttempt
+}
+
+func (e *JoinError) Error() string {
+	reasons := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		reasons = append(reasons, fmt.Sprintf("%s (%s): %v", a.Server, a.Stage, a.Err))
+	}
+	return fmt.Sprintf("failed to join AD domain %s through any domain controller: %s",
+		e.Realm, strings.Join(reasons, "; "))
+}
+
+// candidateDCs returns the domain controllers to try, in order. Configured
+// servers come first in the order given; DCs advertised in DNS follow, ordered
+// by SRV priority and weight so the nearest DC is preferred.
+func (c *Client) candidateDCs(ctx context.Context, cfg *DomainConfig) []string {
+	candidates := make([]string, 0, len(cfg.DCServers))
+	seen := make(map[string]bool)
+	add := func(server string) {
+		server = common.UnbracketHost(strings.TrimSuffix(strings.TrimSpace(server), "."))
+		key := strings.ToLower(server)
+		if server == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		candidates = append(candidates, server)
+	}
+
+	for _, dc := range cfg.DCServers {
+		add(dc)
+	}
+
+	srvDCs, err := lookupSRVDCs(ctx, cfg.Realm)
+	if err != nil {
+		c.logger.Debug("No domain controllers found in DNS", "realm", cfg.Realm, "error", err)
+	}
+	for _, dc := range srvDCs {
+		add(dc)
+	}
+
+	return candidates
+}
+
+// dialAnyAddr connects to a port of a host through each of its addresses in
+// turn and returns the first address that accepts the connection
+func dialAnyAddr(ctx context.Context, host string, port int, timeout time.Duration) (string, error) {
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return "", err
+	}
+
+	dialer := net.Dialer{Timeout: timeout}
+	var errs []error
+	for _, ip := range ips {
+		addr := common.JoinHostPort(ip.String(), port)
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			conn.Close()
+			return addr, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", errors.Join(errs...)
+}
+
+// lookupSRV resolves SRV records; tests replace it to advertise fixed DCs
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// lookupSRVDCs returns the DCs advertised for a realm. The resolver sorts
+// records by priority and shuffles each priority by weight (RFC 2782).
+func lookupSRVDCs(ctx context.Context, realm string) ([]string, error) {
+	_, records, err := lookupSRV(ctx, "ldap", "tcp",
+		"dc._msdcs."+strings.ToLower(realm))
+	if err != nil {
+		return nil, err
+	}
+
+	servers := make([]string, 0, len(records))
+	for _, r := range records {
+		servers = append(servers, r.Target)
+	}
+	return servers, nil
+}
+
+// joinWithFailover tries each candidate DC until a join succeeds. DCs that do
+// not answer the reachability check are skipped without attempting a join.
+func (c *Client) joinWithFailover(ctx context.Context, cfg *DomainConfig, candidates []string) (string, error) {
+	waitTimeout := cfg.DCWaitTimeout
+	if waitTimeout <= 0 {
+		waitTimeout = defaultDCWaitTimeout
+	}
+
+	joinErr := &JoinError{Realm: cfg.Realm}
+	for i, dc := range candidates {
+		if ctx.Err() != nil {
+			joinErr.Attempts = append(joinErr.Attempts, DCAttempt{Server: dc, Stage: "precheck", Err: ctx.Err()})
+			break
+		}
+
+		if err := c.WaitForDC(ctx, dc, waitTimeout); err != nil {
+			c.logger.Warn("Skipping unreachable domain controller", "dc", dc, "error", err)
+			joinErr.Attempts = append(joinErr.Attempts, DCAttempt{Server: dc, Stage: "precheck", Err: err})
+			continue
+		}
+
+		c.logger.Info("Joining AD domain through domain controller",
+			"realm", cfg.Realm,
+			"dc", dc,
+			"candidate", i+1,
+			"of", len(candidates))
+
+		err := c.joinCommand(ctx, cfg, dc)
+		if err == nil {
+			return dc, nil
+		}
+
+		c.logger.Warn("Domain join failed through domain controller", "dc", dc, "error", err)
+		joinErr.Attempts = append(joinErr.Attempts, DCAttempt{Server: dc, Stage: "join", Err: err})
+	}
+
+	return "", joinErr
+}
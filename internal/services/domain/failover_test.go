@@ -0,0 +1,138 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stratastor/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withSRV makes lookupSRV advertise targets, or fail with err
+func withSRV(t *testing.T, targets []string, err error) *string {
+	t.Helper()
+	var queried string
+	orig := lookupSRV
+	lookupSRV = func(_ context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		queried = fmt.Sprintf("_%s._%s.%s", service, proto, name)
+		if err != nil {
+			return "", nil, err
+		}
+		records := make([]*net.SRV, 0, len(targets))
+		for _, target := range targets {
+			records = append(records, &net.SRV{Target: target, Port: 389})
+		}
+		return queried, records, nil
+	}
+	t.Cleanup(func() { lookupSRV = orig })
+	return &queried
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test.domain")
+	require.NoError(t, err)
+	return &Client{logger: l}
+}
+
+func TestCandidateDCs(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured []string
+		srv        []string
+		srvErr     error
+		want       []string
+	}{
+		{
+			name:       "configured before DNS",
+			configured: []string{"dc2.ad.example.com", "dc1.ad.example.com"},
+			srv:        []string{"dc3.ad.example.com.", "dc4.ad.example.com."},
+			want:       []string{"dc2.ad.example.com", "dc1.ad.example.com", "dc3.ad.example.com", "dc4.ad.example.com"},
+		},
+		{
+			name: "DNS order kept",
+			srv:  []string{"dc9.ad.example.com.", "dc1.ad.example.com.", "dc5.ad.example.com."},
+			want: []string{"dc9.ad.example.com", "dc1.ad.example.com", "dc5.ad.example.com"},
+		},
+		{
+			name:       "duplicates removed case-insensitively",
+			configured: []string{"DC1.AD.Example.com", " dc1.ad.example.com. ", "dc2.ad.example.com"},
+			srv:        []string{"dc2.ad.example.com.", "dc1.ad.example.com.", "dc3.ad.example.com."},
+			want:       []string{"DC1.AD.Example.com", "dc2.ad.example.com", "dc3.ad.example.com"},
+		},
+		{
+			name:       "bracketed IPv6 literal",
+			configured: []string{"[fd00::10]", "fd00::10", "192.0.2.10"},
+			want:       []string{"fd00::10", "192.0.2.10"},
+		},
+		{
+			name:       "empty entries skipped",
+			configured: []string{"", "  ", "."},
+			srv:        []string{"dc1.ad.example.com."},
+			want:       []string{"dc1.ad.example.com"},
+		},
+		{
+			name:       "DNS lookup failure",
+			configured: []string{"dc1.ad.example.com"},
+			srvErr:     &net.DNSError{Err: "no such host", IsNotFound: true},
+			want:       []string{"dc1.ad.example.com"},
+		},
+		{
+			name:   "nothing found",
+			srvErr: &net.DNSError{Err: "no such host", IsNotFound: true},
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queried := withSRV(t, tt.srv, tt.srvErr)
+			c := newTestClient(t)
+
+			got := c.candidateDCs(context.Background(), &DomainConfig{
+				Realm:     "AD.EXAMPLE.COM",
+				DCServers: tt.configured,
+			})
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "_ldap._tcp.dc._msdcs.ad.example.com", *queried)
+		})
+	}
+}
+
+func TestJoinError(t *testing.T) {
+	err := &JoinError{
+		Realm: "AD.EXAMPLE.COM",
+		Attempts: []DCAttempt{
+			{Server: "dc1.ad.example.com", Stage: "precheck", Err: fmt.Errorf("timeout")},
+			{Server: "dc2.ad.example.com", Stage: "join", Err: fmt.Errorf("access denied")},
+		},
+	}
+
+	assert.Equal(t, "failed to join AD domain AD.EXAMPLE.COM through any domain controller: "+
+		"dc1.ad.example.com (precheck): timeout; dc2.ad.example.com (join): access denied", err.Error())
+}
+
+func TestJoinWithFailoverCancelled(t *testing.T) {
+	c := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dc, err := c.joinWithFailover(ctx, &DomainConfig{Realm: "AD.EXAMPLE.COM"},
+		[]string{"dc1.ad.example.com", "dc2.ad.example.com"})
+	assert.Empty(t, dc)
+
+	var joinErr *JoinError
+	require.ErrorAs(t, err, &joinErr)
+	assert.Equal(t, "AD.EXAMPLE.COM", joinErr.Realm)
+	require.Len(t, joinErr.Attempts, 1, "remaining DCs are not tried once the context is done")
+	assert.Equal(t, "dc1.ad.example.com", joinErr.Attempts[0].Server)
+	assert.Equal(t, "precheck", joinErr.Attempts[0].Stage)
+	assert.ErrorIs(t, joinErr.Attempts[0].Err, context.Canceled)
+}
@@ -0,0 +1,320 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/events"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// Health check steps, in the order they run
+const (
+	HealthStepTestJoin     = "testjoin"
+	HealthStepMachineKinit = "machine-kinit"
+	HealthStepTrustSecret  = "wbinfo-trust"
+	HealthStepPingDC       = "wbinfo-ping-dc"
+	HealthStepSSSDOnline   = "sssd-online"
+)
+
+// defaultHealthCheckInterval is used when ad.healthCheck.interval is unset or invalid
+const defaultHealthCheckInterval = time.Hour
+
+// machineCCachePath holds the machine account ticket obtained during a check.
+// It is separate from the default cache so admin tickets are never touched.
+var machineCCachePath = filepath.Join(os.TempDir(), "rodent-machine-krb5cc")
+
+// HealthStep is the outcome of one domain health check step
+type HealthStep struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthReport is the result of a domain health check
+type HealthReport struct {
+	Realm       string       `json:"realm"`
+	Backend     string       `json:"backend"`
+	Healthy     bool         `json:"healthy"`
+	FailedStep  string       `json:"failed_step,omitempty"`
+	Steps       []HealthStep `json:"steps"`
+	Repaired    bool         `json:"repaired"`
+	RepairError string       `json:"repair_error,omitempty"`
+	Action      string       `json:"action,omitempty"` // What an operator should do next
+	CheckedAt   time.Time    `json:"checked_at"`
+}
+
+// step returns the named step, or nil if it did not run
+func (r *HealthReport) step(name string) *HealthStep {
+	for i := range r.Steps {
+		if r.Steps[i].Name == name {
+			return &r.Steps[i]
+		}
+	}
+	return nil
+}
+
+// CheckHealth verifies domain membership without admin credentials: the
+// machine account is tested, a ticket is obtained with the machine keytab and
+// the backend's connection to the DC is checked. With repair set, a broken
+// winbind trust is rejoined with 'net ads join -k' using the machine ticket.
+func (c *Client) CheckHealth(ctx context.Context, cfg *DomainConfig, repair bool) *HealthReport {
+	c.membership.Lock()
+	defer c.membership.Unlock()
+
+	backend := cfg.membershipBackend()
+	report := &HealthReport{
+		Realm:     strings.ToUpper(cfg.Realm),
+		Backend:   backend,
+		CheckedAt: time.Now(),
+	}
+
+	defer func() {
+		_, _ = c.executor.ExecuteWithCombinedOutput(ctx, "kdestroy", "-c", machineCCachePath)
+	}()
+
+	c.runHealthSteps(ctx, cfg, report)
+	if report.Healthy {
+		return report
+	}
+
+	c.logger.Warn("Domain health check failed",
+		"realm", report.Realm,
+		"backend", backend,
+		"failed_step", report.FailedStep)
+
+	if !repair {
+		report.Action = healthAction(report)
+		return report
+	}
+
+	if err := c.repairTrust(ctx, report); err != nil {
+		report.RepairError = err.Error()
+		report.Action = healthAction(report)
+		c.logger.Warn("Domain trust repair failed", "realm", report.Realm, "error", err)
+		return report
+	}
+
+	// Verify the repair with a fresh run of every step
+	repaired := &HealthReport{Realm: report.Realm, Backend: backend, CheckedAt: time.Now()}
+	c.runHealthSteps(ctx, cfg, repaired)
+	repaired.Repaired = true
+	if !repaired.Healthy {
+		repaired.RepairError = "trust still broken after rejoin"
+		repaired.Action = healthAction(repaired)
+	}
+	return repaired
+}
+
+// runHealthSteps runs every check for the backend and records the first failure
+func (c *Client) runHealthSteps(ctx context.Context, cfg *DomainConfig, report *HealthReport) {
+	record := func(name string, output []byte, err error) {
+		step := HealthStep{Name: name, OK: err == nil, Output: strings.TrimSpace(string(output))}
+		if err != nil {
+			step.Error = err.Error()
+			if report.FailedStep == "" {
+				report.FailedStep = name
+			}
+		}
+		report.Steps = append(report.Steps, step)
+	}
+
+	output, err := c.testJoin(ctx, report.Backend)
+	record(HealthStepTestJoin, []byte(output), err)
+
+	principal, err := machinePrincipal(report.Realm)
+	if err != nil {
+		record(HealthStepMachineKinit, nil, err)
+	} else {
+		out, err := c.executor.ExecuteWithCombinedOutput(ctx, "kinit", "-k",
+			"-c", machineCCachePath, principal)
+		record(HealthStepMachineKinit, out, err)
+	}
+
+	if report.Backend == MembershipBackendSSSD {
+		out, err := c.executor.ExecuteWithCombinedOutput(ctx, "sssctl", "domain-status",
+			"-o", strings.ToLower(cfg.Realm))
+		if err == nil && !strings.Contains(string(out), "Online status: Online") {
+			err = fmt.Errorf("sssd is offline for %s", strings.ToLower(cfg.Realm))
+		}
+		record(HealthStepSSSDOnline, out, err)
+	} else {
+		out, err := c.executor.ExecuteWithCombinedOutput(ctx, "wbinfo", "-t")
+		record(HealthStepTrustSecret, out, err)
+
+		out, err = c.executor.ExecuteWithCombinedOutput(ctx, "wbinfo", "--ping-dc")
+		record(HealthStepPingDC, out, err)
+	}
+
+	report.Healthy = report.FailedStep == ""
+}
+
+// repairTrust rejoins the domain with the machine account ticket. Only the
+// winbind backend can rejoin this way, and only while the keytab is accepted.
+func (c *Client) repairTrust(ctx context.Context, report *HealthReport) error {
+	if report.Backend != MembershipBackendWinbind {
+		return fmt.Errorf("automatic repair is not supported for the %s backend", report.Backend)
+	}
+	if kinit := report.step(HealthStepMachineKinit); kinit == nil || !kinit.OK {
+		return fmt.Errorf("machine keytab was rejected, admin credentials are required to rejoin")
+	}
+
+	c.logger.Info("Repairing domain trust with the machine keytab", "realm", report.Realm)
+	_, err := c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "join",
+		"--use-krb5-ccache=FILE:"+machineCCachePath)
+	if err != nil {
+		return fmt.Errorf("net ads join -k failed: %w", err)
+	}
+
+	if _, err := c.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", MembershipBackendWinbind); err != nil {
+		c.logger.Warn("Failed to restart winbind after repair", "error", err)
+	}
+
+	c.logger.Info("Domain trust repaired", "realm", report.Realm)
+	return nil
+}
+
+// healthAction suggests the next step for an operator based on the failed check
+func healthAction(report *HealthReport) string {
+	switch report.FailedStep {
+	case HealthStepTestJoin, HealthStepTrustSecret:
+		if kinit := report.step(HealthStepMachineKinit); kinit != nil && kinit.OK &&
+			report.Backend == MembershipBackendWinbind && report.RepairError == "" {
+			return "Machine trust is broken but the keytab is valid; run 'rodent domain check --repair'"
+		}
+		return "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials"
+	case HealthStepMachineKinit:
+		return "Machine keytab was rejected by the KDC; check time sync with the DC and /etc/krb5.keytab, or rejoin with 'rodent domain join'"
+	case HealthStepPingDC, HealthStepSSSDOnline:
+		return "Domain controller is unreachable; check DNS and network connectivity to the DC"
+	}
+	return ""
+}
+
+// machinePrincipal returns the computer account principal of this host, the
+// upper-case NetBIOS name (at most 15 characters) followed by '$'
+func machinePrincipal(realm string) (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", fmt.Errorf("failed to get hostname: %w", err)
+	}
+
+	name := strings.ToUpper(strings.SplitN(hostname, ".", 2)[0])
+	if len(name) > 15 {
+		name = name[:15]
+	}
+	return name + "$@" + strings.ToUpper(realm), nil
+}
+
+// HealthMonitor periodically checks domain membership, optionally repairing
+// a broken trust, and emits an event whenever the health state changes
+type HealthMonitor struct {
+	client     *Client
+	logger     logger.Logger
+	interval   time.Duration
+	autoRepair bool
+
+	healthy    bool
+	failedStep string
+}
+
+// NewHealthMonitor creates a domain health monitor. An interval of zero or
+// less uses the default of one hour.
+func NewHealthMonitor(client *Client, interval time.Duration, autoRepair bool) *HealthMonitor {
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	return &HealthMonitor{
+		client:     client,
+		logger:     client.logger,
+		interval:   interval,
+		autoRepair: autoRepair,
+		healthy:    true,
+	}
+}
+
+// Run checks domain health every interval until ctx is cancelled. The global
+// configuration is read on each check so realm and backend changes apply
+// without a restart.
+func (m *HealthMonitor) Run(ctx context.Context) {
+	m.logger.Info("Starting domain health monitor",
+		"interval", m.interval,
+		"auto_repair", m.autoRepair)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cfg := GetConfigFromGlobal()
+			if cfg.Realm == "" {
+				continue
+			}
+			m.record(m.client.CheckHealth(ctx, cfg, m.autoRepair))
+		}
+	}
+}
+
+// record emits an event when the host becomes unhealthy, is repaired, or
+// recovers, and when the failing step changes while unhealthy
+func (m *HealthMonitor) record(report *HealthReport) {
+	wasHealthy, lastFailedStep := m.healthy, m.failedStep
+	m.healthy, m.failedStep = report.Healthy, report.FailedStep
+
+	switch {
+	case report.Healthy && report.Repaired:
+		m.emitHealthEvent(report, eventspb.EventLevel_EVENT_LEVEL_WARN, "trust_repaired")
+	case report.Healthy && !wasHealthy:
+		m.logger.Info("Domain membership healthy again", "realm", report.Realm)
+		m.emitHealthEvent(report, eventspb.EventLevel_EVENT_LEVEL_INFO, "recovered")
+	case !report.Healthy && (wasHealthy || report.FailedStep != lastFailedStep):
+		m.emitHealthEvent(report, eventspb.EventLevel_EVENT_LEVEL_ERROR, "unhealthy")
+	}
+}
+
+// emitHealthEvent publishes a domain health change on the service event stream
+func (m *HealthMonitor) emitHealthEvent(report *HealthReport, level eventspb.EventLevel, action string) {
+	status := "healthy"
+	if !report.Healthy {
+		status = "unhealthy"
+	}
+
+	payload := &eventspb.ServiceStatusPayload{
+		ServiceName: report.Backend,
+		Status:      status,
+		Operation:   eventspb.ServiceStatusPayload_SERVICE_STATUS_OPERATION_UNSPECIFIED,
+	}
+
+	metadata := map[string]string{
+		"component":   "domain-health",
+		"action":      action,
+		"realm":       report.Realm,
+		"backend":     report.Backend,
+		"failed_step": report.FailedStep,
+		"repaired":    fmt.Sprintf("%t", report.Repaired),
+	}
+	if step := report.step(report.FailedStep); step != nil {
+		metadata["error"] = step.Error
+	}
+	if report.RepairError != "" {
+		metadata["repair_error"] = report.RepairError
+	}
+	if report.Action != "" {
+		metadata["suggested_action"] = report.Action
+	}
+
+	events.EmitServiceStatus(level, payload, metadata)
+}
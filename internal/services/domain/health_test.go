@@ -0,0 +1,315 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExecutor answers commands by the prefix of their command line. Commands
+// in fail return an error; running repairedBy clears every failure.
+type fakeExecutor struct {
+	output     map[string]string
+	fail       map[string]bool
+	repairedBy string
+	calls      []string
+}
+
+func (f *fakeExecutor) ExecuteWithCombinedOutput(ctx context.Context, cmd string, args ...string) ([]byte, error) {
+	line := strings.Join(append([]string{cmd}, args...), " ")
+	f.calls = append(f.calls, line)
+
+	if f.repairedBy != "" && strings.HasPrefix(line, f.repairedBy) {
+		f.fail = nil
+	}
+
+	var out string
+	for prefix, o := range f.output {
+		if strings.HasPrefix(line, prefix) {
+			out = o
+		}
+	}
+	for prefix := range f.fail {
+		if strings.HasPrefix(line, prefix) {
+			return []byte(out), fmt.Errorf("command failed: exit status 1")
+		}
+	}
+	return []byte(out), nil
+}
+
+func (f *fakeExecutor) ExecuteWithInput(ctx context.Context, input string, cmd string, args ...string) ([]byte, error) {
+	return f.ExecuteWithCombinedOutput(ctx, cmd, args...)
+}
+
+// ran reports whether a command starting with prefix was run
+func (f *fakeExecutor) ran(prefix string) bool {
+	for _, call := range f.calls {
+		if strings.HasPrefix(call, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newHealthClient(t *testing.T, exec *fakeExecutor) *Client {
+	t.Helper()
+	c := newTestClient(t)
+	c.executor = exec
+	return c
+}
+
+func stepNames(report *HealthReport) []string {
+	names := make([]string, 0, len(report.Steps))
+	for _, s := range report.Steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestRunHealthSteps(t *testing.T) {
+	sssdOnline := map[string]string{"sssctl": "Online status: Online\n\nActive servers:\nAD Global Catalog: dc1\n"}
+
+	tests := []struct {
+		name      string
+		backend   string
+		output    map[string]string
+		fail      []string
+		wantSteps []string
+		wantFail  string
+	}{
+		{
+			name:      "winbind healthy",
+			backend:   MembershipBackendWinbind,
+			output:    map[string]string{"net ads testjoin": "Join is OK\n"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepTrustSecret, HealthStepPingDC},
+		},
+		{
+			name:      "winbind trust broken",
+			backend:   MembershipBackendWinbind,
+			fail:      []string{"net ads testjoin", "wbinfo -t"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepTrustSecret, HealthStepPingDC},
+			wantFail:  HealthStepTestJoin,
+		},
+		{
+			name:      "keytab rejected",
+			backend:   MembershipBackendWinbind,
+			fail:      []string{"kinit", "wbinfo --ping-dc"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepTrustSecret, HealthStepPingDC},
+			wantFail:  HealthStepMachineKinit,
+		},
+		{
+			name:      "DC unreachable",
+			backend:   MembershipBackendWinbind,
+			fail:      []string{"wbinfo --ping-dc"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepTrustSecret, HealthStepPingDC},
+			wantFail:  HealthStepPingDC,
+		},
+		{
+			name:      "sssd healthy",
+			backend:   MembershipBackendSSSD,
+			output:    sssdOnline,
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepSSSDOnline},
+		},
+		{
+			name:      "sssd offline",
+			backend:   MembershipBackendSSSD,
+			output:    map[string]string{"sssctl": "Online status: Offline\n"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepSSSDOnline},
+			wantFail:  HealthStepSSSDOnline,
+		},
+		{
+			name:      "sssd status failed",
+			backend:   MembershipBackendSSSD,
+			output:    sssdOnline,
+			fail:      []string{"sssctl"},
+			wantSteps: []string{HealthStepTestJoin, HealthStepMachineKinit, HealthStepSSSDOnline},
+			wantFail:  HealthStepSSSDOnline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeExecutor{output: tt.output, fail: map[string]bool{}}
+			for _, f := range tt.fail {
+				exec.fail[f] = true
+			}
+			c := newHealthClient(t, exec)
+
+			report := &HealthReport{Realm: "AD.EXAMPLE.COM", Backend: tt.backend}
+			c.runHealthSteps(context.Background(),
+				&DomainConfig{Realm: "ad.example.com", MembershipBackend: tt.backend}, report)
+
+			assert.Equal(t, tt.wantSteps, stepNames(report))
+			assert.Equal(t, tt.wantFail, report.FailedStep)
+			assert.Equal(t, tt.wantFail == "", report.Healthy)
+			for _, step := range report.Steps {
+				assert.Equal(t, step.OK, step.Error == "", step.Name)
+			}
+		})
+	}
+}
+
+func TestCheckHealthRepair(t *testing.T) {
+	const rejoin = "net ads join --use-krb5-ccache=FILE:"
+
+	tests := []struct {
+		name         string
+		backend      string
+		fail         []string
+		repair       bool
+		repairedBy   string
+		wantHealthy  bool
+		wantRepaired bool
+		wantRejoin   bool
+		wantError    string
+		wantAction   string
+	}{
+		{
+			name:        "healthy needs no repair",
+			backend:     MembershipBackendWinbind,
+			repair:      true,
+			wantHealthy: true,
+		},
+		{
+			name:       "repair not requested",
+			backend:    MembershipBackendWinbind,
+			fail:       []string{"wbinfo -t"},
+			wantAction: "Machine trust is broken but the keytab is valid; run 'rodent domain check --repair'",
+		},
+		{
+			name:         "rejoin with keytab",
+			backend:      MembershipBackendWinbind,
+			fail:         []string{"net ads testjoin", "wbinfo -t"},
+			repair:       true,
+			repairedBy:   rejoin,
+			wantHealthy:  true,
+			wantRepaired: true,
+			wantRejoin:   true,
+		},
+		{
+			name:         "still broken after rejoin",
+			backend:      MembershipBackendWinbind,
+			fail:         []string{"wbinfo -t"},
+			repair:       true,
+			wantRepaired: true,
+			wantRejoin:   true,
+			wantError:    "trust still broken after rejoin",
+			wantAction:   "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+		{
+			name:       "rejoin failed",
+			backend:    MembershipBackendWinbind,
+			fail:       []string{"wbinfo -t", rejoin},
+			repair:     true,
+			wantRejoin: true,
+			wantError:  "net ads join -k failed: command failed: exit status 1",
+			wantAction: "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+		{
+			name:       "keytab rejected",
+			backend:    MembershipBackendWinbind,
+			fail:       []string{"kinit", "wbinfo -t"},
+			repair:     true,
+			wantError:  "machine keytab was rejected, admin credentials are required to rejoin",
+			wantAction: "Machine keytab was rejected by the KDC; check time sync with the DC and /etc/krb5.keytab, or rejoin with 'rodent domain join'",
+		},
+		{
+			name:       "sssd cannot rejoin",
+			backend:    MembershipBackendSSSD,
+			fail:       []string{"adcli testjoin"},
+			repair:     true,
+			wantError:  "automatic repair is not supported for the sssd backend",
+			wantAction: "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeExecutor{
+				output:     map[string]string{"sssctl": "Online status: Online\n"},
+				fail:       map[string]bool{},
+				repairedBy: tt.repairedBy,
+			}
+			for _, f := range tt.fail {
+				exec.fail[f] = true
+			}
+			c := newHealthClient(t, exec)
+
+			report := c.CheckHealth(context.Background(),
+				&DomainConfig{Realm: "ad.example.com", MembershipBackend: tt.backend}, tt.repair)
+			require.NotNil(t, report)
+
+			assert.Equal(t, "AD.EXAMPLE.COM", report.Realm)
+			assert.Equal(t, tt.wantHealthy, report.Healthy)
+			assert.Equal(t, tt.wantRepaired, report.Repaired)
+			assert.Equal(t, tt.wantRejoin, exec.ran(rejoin))
+			assert.Equal(t, tt.wantError, report.RepairError)
+			assert.Equal(t, tt.wantAction, report.Action)
+			assert.Equal(t, tt.wantRepaired, exec.ran("systemctl restart winbind"))
+			assert.True(t, exec.ran("kdestroy -c "+machineCCachePath), "machine ticket is always destroyed")
+		})
+	}
+}
+
+func TestHealthAction(t *testing.T) {
+	kinitOK := []HealthStep{{Name: HealthStepMachineKinit, OK: true}}
+	kinitFailed := []HealthStep{{Name: HealthStepMachineKinit}}
+
+	tests := []struct {
+		name   string
+		report HealthReport
+		want   string
+	}{
+		{name: "healthy", report: HealthReport{Backend: MembershipBackendWinbind}},
+		{
+			name:   "trust broken, keytab valid",
+			report: HealthReport{Backend: MembershipBackendWinbind, FailedStep: HealthStepTrustSecret, Steps: kinitOK},
+			want:   "Machine trust is broken but the keytab is valid; run 'rodent domain check --repair'",
+		},
+		{
+			name:   "trust broken, keytab rejected",
+			report: HealthReport{Backend: MembershipBackendWinbind, FailedStep: HealthStepTestJoin, Steps: kinitFailed},
+			want:   "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+		{
+			name:   "trust broken on sssd",
+			report: HealthReport{Backend: MembershipBackendSSSD, FailedStep: HealthStepTestJoin, Steps: kinitOK},
+			want:   "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+		{
+			name: "repair already failed",
+			report: HealthReport{Backend: MembershipBackendWinbind, FailedStep: HealthStepTestJoin,
+				Steps: kinitOK, RepairError: "net ads join -k failed"},
+			want: "Machine trust is broken; rejoin with 'rodent domain join' using admin credentials",
+		},
+		{
+			name:   "keytab rejected",
+			report: HealthReport{FailedStep: HealthStepMachineKinit},
+			want:   "Machine keytab was rejected by the KDC; check time sync with the DC and /etc/krb5.keytab, or rejoin with 'rodent domain join'",
+		},
+		{
+			name:   "winbind DC unreachable",
+			report: HealthReport{FailedStep: HealthStepPingDC},
+			want:   "Domain controller is unreachable; check DNS and network connectivity to the DC",
+		},
+		{
+			name:   "sssd offline",
+			report: HealthReport{FailedStep: HealthStepSSSDOnline},
+			want:   "Domain controller is unreachable; check DNS and network connectivity to the DC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, healthAction(&tt.report))
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	krb5SectionRegex  = regexp.MustCompile(`^\s*\[([^\]]+)\]\s*$`)
+	krb5RelationRegex = regexp.MustCompile(`^\s*([^=\s]+)\s*=\s*(.*?)\s*$`)
+)
+
+// krb5Section is a [section] of krb5.conf with its lines kept verbatim
+type krb5Section struct {
+	name  string
+	lines []string
+}
+
+// krb5Config is a krb5.conf split into sections. Lines before the first
+// section (comments, include and includedir directives) are kept as the
+// preamble.
+type krb5Config struct {
+	preamble []string
+	sections []*krb5Section
+}
+
+// parseKrb5Config splits krb5.conf content into sections
+func parseKrb5Config(content string) *krb5Config {
+	cfg := &krb5Config{}
+	var current *krb5Section
+
+	// Only the final newline is dropped, so trailing blank lines round-trip
+	lines := strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	if content == "" {
+		lines = nil
+	}
+	for _, line := range lines {
+		if m := krb5SectionRegex.FindStringSubmatch(line); m != nil {
+			current = &krb5Section{name: strings.TrimSpace(m[1])}
+			cfg.sections = append(cfg.sections, current)
+			continue
+		}
+		if current == nil {
+			cfg.preamble = append(cfg.preamble, line)
+		} else {
+			current.lines = append(current.lines, line)
+		}
+	}
+	return cfg
+}
+
+// String renders the config back to krb5.conf syntax
+func (k *krb5Config) String() string {
+	var b strings.Builder
+	for _, line := range k.preamble {
+		b.WriteString(line + "\n")
+	}
+	for _, s := range k.sections {
+		b.WriteString("[" + s.name + "]\n")
+		for _, line := range s.lines {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
+
+// section returns the named section, appending an empty one if missing
+func (k *krb5Config) section(name string) *krb5Section {
+	for _, s := range k.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &krb5Section{name: name}
+	k.sections = append(k.sections, s)
+	return s
+}
+
+// setRelation sets a top-level tag = value in the section, replacing an
+// existing value. Relations nested in { } blocks are not touched.
+func (s *krb5Section) setRelation(tag, value string, caseInsensitive bool) {
+	depth := 0
+	for i, line := range s.lines {
+		if depth == 0 {
+			if m := krb5RelationRegex.FindStringSubmatch(line); m != nil && m[2] != "{" &&
+				(m[1] == tag || caseInsensitive && strings.EqualFold(m[1], tag)) {
+				s.lines[i] = fmt.Sprintf("    %s = %s", tag, value)
+				return
+			}
+		}
+		depth += braceDelta(line)
+	}
+	s.insertLine(fmt.Sprintf("    %s = %s", tag, value))
+}
+
+// setDefault sets tag = value only when the section does not already set it
+func (s *krb5Section) setDefault(tag, value string) {
+	depth := 0
+	for _, line := range s.lines {
+		if depth == 0 {
+			if m := krb5RelationRegex.FindStringSubmatch(line); m != nil && m[1] == tag {
+				return
+			}
+		}
+		depth += braceDelta(line)
+	}
+	s.insertLine(fmt.Sprintf("    %s = %s", tag, value))
+}
+
+// setBlock replaces the tag = { ... } block in the section with body, or
+// appends it. The realm tag is matched case-insensitively.
+func (s *krb5Section) setBlock(tag string, body []string) {
+	block := append([]string{fmt.Sprintf("    %s = {", tag)}, body...)
+	block = append(block, "    }")
+
+	depth := 0
+	for i, line := range s.lines {
+		if depth == 0 {
+			if m := krb5RelationRegex.FindStringSubmatch(line); m != nil && m[2] == "{" &&
+				strings.EqualFold(m[1], tag) {
+				end := blockEnd(s.lines, i)
+				rest := append([]string{}, s.lines[end+1:]...)
+				s.lines = append(append(s.lines[:i], block...), rest...)
+				return
+			}
+		}
+		depth += braceDelta(line)
+	}
+	s.insertLine(block...)
+}
+
+// insertLine adds lines after the last non-blank line of the section, so the
+// blank line separating it from the next section stays at the end
+func (s *krb5Section) insertLine(lines ...string) {
+	at := len(s.lines)
+	for at > 0 && strings.TrimSpace(s.lines[at-1]) == "" {
+		at--
+	}
+	rest := append([]string{}, s.lines[at:]...)
+	s.lines = append(append(s.lines[:at], lines...), rest...)
+	if len(rest) == 0 {
+		s.lines = append(s.lines, "")
+	}
+}
+
+// blockEnd returns the index of the line closing the block opened at start
+func blockEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += braceDelta(lines[i])
+		if depth <= 0 {
+			return i
+		}
+	}
+	return len(lines) - 1
+}
+
+// braceDelta returns the change in { } nesting on a line, ignoring comments
+func braceDelta(line string) int {
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return 0
+	}
+	return strings.Count(line, "{") - strings.Count(line, "}")
+}
+
+// mergeKerberosConfig merges the realm settings for a domain join into an
+// existing krb5.conf. Other realms, domain mappings and settings are kept;
+// only the join realm's block and the libdefaults the join relies on are set.
+func mergeKerberosConfig(existing, realm string, dcServers []string) string {
+	realm = strings.ToUpper(realm)
+	domainLower := strings.ToLower(realm)
+
+	cfg := parseKrb5Config(existing)
+
+	libdefaults := cfg.section("libdefaults")
+	libdefaults.setRelation("default_realm", realm, false)
+	libdefaults.setRelation("dns_lookup_kdc", "true", false)
+	libdefaults.setDefault("dns_lookup_realm", "false")
+	libdefaults.setDefault("ticket_lifetime", "30d")
+	libdefaults.setDefault("renew_lifetime", "365d")
+	libdefaults.setDefault("forwardable", "true")
+
+	body := make([]string, 0, len(dcServers)+2)
+	for _, dc := range dcServers {
+		body = append(body, fmt.Sprintf("        kdc = %s", dc))
+	}
+	body = append(body,
+		fmt.Sprintf("        admin_server = %s", dcServers[0]),
+		fmt.Sprintf("        default_domain = %s", domainLower),
+	)
+	cfg.section("realms").setBlock(realm, body)
+
+	domainRealm := cfg.section("domain_realm")
+	domainRealm.setRelation("."+domainLower, realm, true)
+	domainRealm.setRelation(domainLower, realm, true)
+
+	return cfg.String()
+}
+
+// diffLines returns a unified-style line diff of two texts, with "-" for
+// removed lines, "+" for added lines and two spaces for unchanged ones
+func diffLines(before, after string) string {
+	a := strings.Split(strings.TrimRight(before, "\n"), "\n")
+	b := strings.Split(strings.TrimRight(after, "\n"), "\n")
+	if before == "" {
+		a = nil
+	}
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			out.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			out.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			out.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return out.String()
+}
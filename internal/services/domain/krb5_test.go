@@ -0,0 +1,228 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKrb5Config(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		preamble []string
+		sections []string
+	}{
+		{name: "empty", content: ""},
+		{
+			name:     "preamble only",
+			content:  "# managed elsewhere\nincludedir /etc/krb5.conf.d/\n",
+			preamble: []string{"# managed elsewhere", "includedir /etc/krb5.conf.d/"},
+		},
+		{
+			name:     "trailing blank lines",
+			content:  "[libdefaults]\n    rdns = false\n\n\n",
+			sections: []string{"libdefaults"},
+		},
+		{
+			name: "sections",
+			content: "includedir /etc/krb5.conf.d/\n\n[libdefaults]\n    default_realm = A.COM\n\n" +
+				" [realms] \n    A.COM = {\n        kdc = dc1\n    }\n",
+			preamble: []string{"includedir /etc/krb5.conf.d/", ""},
+			sections: []string{"libdefaults", "realms"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := parseKrb5Config(tt.content)
+			assert.Equal(t, tt.preamble, cfg.preamble)
+
+			var names []string
+			for _, s := range cfg.sections {
+				names = append(names, s.name)
+			}
+			assert.Equal(t, tt.sections, names)
+
+			// Content round-trips apart from whitespace around section names
+			if tt.name != "sections" {
+				assert.Equal(t, tt.content, cfg.String())
+			}
+		})
+	}
+}
+
+func TestSetBlock(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		tag   string
+		want  []string
+	}{
+		{
+			name:  "appended to empty section",
+			lines: nil,
+			tag:   "A.COM",
+			want:  []string{"    A.COM = {", "        kdc = dc1", "    }", ""},
+		},
+		{
+			name: "replaces existing block",
+			lines: []string{"    A.COM = {", "        kdc = old1", "        kdc = old2",
+				"        auth_to_local = {", "        }", "    }", ""},
+			tag:  "A.COM",
+			want: []string{"    A.COM = {", "        kdc = dc1", "    }", ""},
+		},
+		{
+			name:  "matches the realm case-insensitively",
+			lines: []string{"    a.com = {", "        kdc = old", "    }", ""},
+			tag:   "A.COM",
+			want:  []string{"    A.COM = {", "        kdc = dc1", "    }", ""},
+		},
+		{
+			name: "keeps other realms",
+			lines: []string{"    B.COM = {", "        kdc = b-dc", "    }", "    A.COM = {",
+				"        kdc = old", "    }", "    C.COM = {", "        kdc = c-dc", "    }", ""},
+			tag: "A.COM",
+			want: []string{"    B.COM = {", "        kdc = b-dc", "    }", "    A.COM = {",
+				"        kdc = dc1", "    }", "    C.COM = {", "        kdc = c-dc", "    }", ""},
+		},
+		{
+			name:  "ignores nested tags of the same name",
+			lines: []string{"    B.COM = {", "        A.COM = {", "        }", "    }", ""},
+			tag:   "A.COM",
+			want: []string{"    B.COM = {", "        A.COM = {", "        }", "    }",
+				"    A.COM = {", "        kdc = dc1", "    }", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &krb5Section{name: "realms", lines: tt.lines}
+			s.setBlock(tt.tag, []string{"        kdc = dc1"})
+			assert.Equal(t, tt.want, s.lines)
+		})
+	}
+}
+
+func TestMergeKerberosConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		realm    string
+		dcs      []string
+		want     string
+	}{
+		{
+			name:  "new file",
+			realm: "ad.example.com",
+			dcs:   []string{"dc1.ad.example.com", "dc2.ad.example.com"},
+			want: `[libdefaults]
+    default_realm = AD.EXAMPLE.COM
+    dns_lookup_kdc = true
+    dns_lookup_realm = false
+    ticket_lifetime = 30d
+    renew_lifetime = 365d
+    forwardable = true
+
+[realms]
+    AD.EXAMPLE.COM = {
+        kdc = dc1.ad.example.com
+        kdc = dc2.ad.example.com
+        admin_server = dc1.ad.example.com
+        default_domain = ad.example.com
+    }
+
+[domain_realm]
+    .ad.example.com = AD.EXAMPLE.COM
+    ad.example.com = AD.EXAMPLE.COM
+
+`,
+		},
+		{
+			name: "keeps includedir, other realms and settings",
+			existing: `includedir /etc/krb5.conf.d/
+
+[libdefaults]
+    default_realm = OTHER.ORG
+    dns_lookup_realm = true
+    rdns = false
+
+[realms]
+    OTHER.ORG = {
+        kdc = kdc.other.org
+    }
+    ad.example.com = {
+        kdc = stale.ad.example.com
+    }
+
+[domain_realm]
+    .other.org = OTHER.ORG
+    .AD.EXAMPLE.COM = STALE
+`,
+			realm: "AD.EXAMPLE.COM",
+			dcs:   []string{"dc1.ad.example.com"},
+			want: `includedir /etc/krb5.conf.d/
+
+[libdefaults]
+    default_realm = AD.EXAMPLE.COM
+    dns_lookup_realm = true
+    rdns = false
+    dns_lookup_kdc = true
+    ticket_lifetime = 30d
+    renew_lifetime = 365d
+    forwardable = true
+
+[realms]
+    OTHER.ORG = {
+        kdc = kdc.other.org
+    }
+    AD.EXAMPLE.COM = {
+        kdc = dc1.ad.example.com
+        admin_server = dc1.ad.example.com
+        default_domain = ad.example.com
+    }
+
+[domain_realm]
+    .other.org = OTHER.ORG
+    .ad.example.com = AD.EXAMPLE.COM
+    ad.example.com = AD.EXAMPLE.COM
+
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeKerberosConfig(tt.existing, tt.realm, tt.dcs)
+			assert.Equal(t, tt.want, got)
+
+			// Merging again changes nothing
+			assert.Equal(t, got, mergeKerberosConfig(got, tt.realm, tt.dcs))
+		})
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{name: "unchanged", before: "a\nb\n", after: "a\nb\n", want: "  a\n  b\n"},
+		{name: "from nothing", before: "", after: "a\nb\n", want: "+ a\n+ b\n"},
+		{name: "line changed", before: "a\nb\nc\n", after: "a\nB\nc\n", want: "  a\n- b\n+ B\n  c\n"},
+		{name: "line added", before: "a\nc\n", after: "a\nb\nc\n", want: "  a\n+ b\n  c\n"},
+		{name: "line removed", before: "a\nb\nc", after: "a\nc", want: "  a\n- b\n  c\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, diffLines(tt.before, tt.after))
+		})
+	}
+}
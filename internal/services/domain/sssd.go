@@ -0,0 +1,143 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Domain membership backends
+const (
+	MembershipBackendWinbind = "winbind"
+	MembershipBackendSSSD    = "sssd"
+)
+
+// sssdConfPath is the SSSD configuration written on join
+const sssdConfPath = "/etc/sssd/sssd.conf"
+
+// membershipBackend returns the configured backend, defaulting to winbind
+func (cfg *DomainConfig) membershipBackend() string {
+	if cfg.MembershipBackend == "" {
+		return MembershipBackendWinbind
+	}
+	return strings.ToLower(cfg.MembershipBackend)
+}
+
+// nssSource returns the nsswitch.conf source name of the backend
+func nssSource(backend string) string {
+	if backend == MembershipBackendSSSD {
+		return "sss"
+	}
+	return "winbind"
+}
+
+// generateSSSDConfig renders sssd.conf for the realm. The DCs are listed in
+// join order with _srv_ last, so SSSD falls back to DNS discovery.
+func generateSSSDConfig(realm string, dcServers []string) string {
+	realm = strings.ToUpper(realm)
+	domainLower := strings.ToLower(realm)
+
+	servers := append(append([]string{}, dcServers...), "_srv_")
+
+	return fmt.Sprintf(`# Generated by rodent - changes will be overwritten on domain join
+[sssd]
+domains = %[1]s
+config_file_version = 2
+services = nss, pam
+
+[domain/%[1]s]
+id_provider = ad
+access_provider = ad
+ad_domain = %[1]s
+krb5_realm = %[2]s
+ad_server = %[3]s
+cache_credentials = True
+krb5_store_password_if_offline = True
+ldap_id_mapping = True
+use_fully_qualified_names = False
+fallback_homedir = /home/%%u@%%d
+default_shell = /bin/bash
+`, domainLower, realm, strings.Join(servers, ", "))
+}
+
+// configureSSSD writes sssd.conf for the realm. SSSD refuses to start unless
+// the file is owned by root and not readable by others.
+func (c *Client) configureSSSD(ctx context.Context, cfg *DomainConfig, dcServers []string) error {
+	c.logger.Info("Configuring SSSD", "realm", cfg.Realm)
+
+	sssdConf := generateSSSDConfig(cfg.Realm, dcServers)
+
+	// Backup existing sssd.conf if it exists; the copy keeps its mode
+	if exists, err := c.fileOps.Exists(ctx, sssdConfPath); err == nil && exists {
+		backupPath := fmt.Sprintf("%s.backup.%s", sssdConfPath, time.Now().Format("20060102-150405"))
+		c.logger.Info("Backing up existing SSSD config", "backup", backupPath)
+		if err := c.fileOps.CopyFile(ctx, sssdConfPath, backupPath); err != nil {
+			c.logger.Warn("Failed to backup sssd.conf", "error", err)
+		}
+	}
+
+	if _, err := c.executor.ExecuteWithCombinedOutput(ctx, "mkdir", "-p", filepath.Dir(sssdConfPath)); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(sssdConfPath), err)
+	}
+
+	// The helper writes the file as root, with the mode set before the contents
+	if err := c.fileOps.WriteFile(ctx, sssdConfPath, []byte(sssdConf), 0o600); err != nil {
+		return fmt.Errorf("failed to write sssd.conf: %w", err)
+	}
+
+	c.logger.Info("SSSD configuration written successfully")
+	return nil
+}
+
+// joinCommand runs the join through a DC for the configured backend
+func (c *Client) joinCommand(ctx context.Context, cfg *DomainConfig, dc string) error {
+	if cfg.membershipBackend() == MembershipBackendSSSD {
+		// adcli reads the password from stdin so it never shows up in ps
+		_, err := c.executor.ExecuteWithInput(ctx, cfg.AdminPassword, "adcli", "join",
+			"--domain="+strings.ToLower(cfg.Realm),
+			"--domain-realm="+strings.ToUpper(cfg.Realm),
+			"--domain-controller="+dc,
+			"--login-user="+cfg.AdminUser,
+			"--stdin-password")
+		return err
+	}
+
+	return c.netAsAdmin(ctx, cfg, "ads", "join", "-S", dc)
+}
+
+// leaveCommand removes the computer account for the configured backend
+func (c *Client) leaveCommand(ctx context.Context, cfg *DomainConfig) error {
+	if cfg.membershipBackend() == MembershipBackendSSSD {
+		_, err := c.executor.ExecuteWithInput(ctx, cfg.AdminPassword, "adcli", "delete-computer",
+			"--domain="+strings.ToLower(cfg.Realm),
+			"--login-user="+cfg.AdminUser,
+			"--stdin-password")
+		return err
+	}
+
+	return c.netAsAdmin(ctx, cfg, "ads", "leave")
+}
+
+// testJoin checks the machine account through the backend. Winbind output is
+// returned as-is; adcli's is reduced to the same "Join is OK" form so Status
+// reports both backends alike.
+func (c *Client) testJoin(ctx context.Context, backend string) (string, error) {
+	if backend == MembershipBackendSSSD {
+		if _, err := c.executor.ExecuteWithCombinedOutput(ctx, "adcli", "testjoin"); err != nil {
+			return "", err
+		}
+		return "Join is OK", nil
+	}
+
+	output, err := c.executor.ExecuteWithCombinedOutput(ctx, "net", "ads", "testjoin")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package domain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TrustedDomain describes a domain known to winbind: the joined domain and
+// every domain it trusts
+type TrustedDomain struct {
+	Name       string `json:"name"`                 // NetBIOS name
+	DNSName    string `json:"dns_name,omitempty"`   // DNS name
+	TrustType  string `json:"trust_type,omitempty"` // e.g. "Forest", "External", "Routed"
+	Transitive bool   `json:"transitive"`
+	Inbound    bool   `json:"inbound"`
+	Outbound   bool   `json:"outbound"`
+	Online     bool   `json:"online"`
+}
+
+// TrustedDomains lists the joined domain and its trusted domains with their
+// trust attributes and whether winbind has an active connection to each.
+// Only the winbind backend exposes trusts.
+func (c *Client) TrustedDomains(ctx context.Context) ([]TrustedDomain, error) {
+	output, err := c.executor.ExecuteWithCombinedOutput(ctx, "wbinfo", "-m", "--verbose")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trusted domains: %w", err)
+	}
+	domains := parseTrustedDomains(string(output))
+
+	// Online status is best effort, a missing entry is reported as offline
+	statusOut, err := c.executor.ExecuteWithCombinedOutput(ctx, "wbinfo", "--online-status")
+	if err != nil {
+		c.logger.Warn("Failed to get domain online status", "error", err)
+		return domains, nil
+	}
+	online := parseOnlineStatus(string(statusOut))
+	for i := range domains {
+		domains[i].Online = online[strings.ToUpper(domains[i].Name)]
+	}
+
+	return domains, nil
+}
+
+// parseTrustedDomains parses 'wbinfo -m --verbose' output:
+//
+//	Domain Name   DNS Domain            Trust Type  Transitive  In   Out
+//	BUILTIN                             Local
+//	AD            ad.strata.internal    Routed
+//	PARTNER       partner.example.com   Forest      Yes         Yes  Yes
+//
+// BUILTIN and the local SAM are skipped.
+func parseTrustedDomains(output string) []TrustedDomain {
+	var domains []TrustedDomain
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] == "Domain" {
+			continue
+		}
+
+		d := TrustedDomain{Name: fields[0]}
+		rest := fields[1:]
+		// The DNS name is absent for BUILTIN and the local SAM
+		if strings.Contains(rest[0], ".") {
+			d.DNSName = rest[0]
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			d.TrustType = rest[0]
+		}
+		if d.TrustType == "Local" {
+			continue
+		}
+		if len(rest) > 1 {
+			d.Transitive = rest[1] == "Yes"
+		}
+		if len(rest) > 2 {
+			d.Inbound = rest[2] == "Yes"
+		}
+		if len(rest) > 3 {
+			d.Outbound = rest[3] == "Yes"
+		}
+		domains = append(domains, d)
+	}
+	return domains
+}
+
+// parseOnlineStatus parses 'wbinfo --online-status' output of the form
+// "PARTNER : active connection" into a map keyed by upper-case domain name
+func parseOnlineStatus(output string) map[string]bool {
+	online := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		name, status, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		online[strings.ToUpper(strings.TrimSpace(name))] =
+			strings.TrimSpace(status) == "active connection"
+	}
+	return online
+}
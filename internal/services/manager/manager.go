@@ -78,6 +78,15 @@ func (m *ServiceManager) initializeServices() error {
 		m.registerService(sambaClient)
 	}
 
+	// Create ClamAV client for shares with on-access scanning
+	clamavClient, err := clients.NewClamAVClient(m.logger)
+	if err != nil {
+		m.logger.Debug("ClamAV is not available", "err", err)
+		// Continue without ClamAV client
+	} else {
+		m.registerService(clamavClient)
+	}
+
 	// TODO: Add other services (NFS, etc.)
 
 	return nil
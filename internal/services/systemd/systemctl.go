@@ -64,6 +64,7 @@ func (s ServiceStatus) InstanceState() string {
 // Client provides a systemd service management client
 type Client struct {
 	logger       logger.Logger
+	executor     *command.CommandExecutor
 	systemctlBin string
 }
 
@@ -81,6 +82,7 @@ func NewClient(logger logger.Logger) (*Client, error) {
 
 	return &Client{
 		logger:       logger,
+		executor:     command.NewCommandExecutor(true),
 		systemctlBin: systemctlBin,
 	}, nil
 }
@@ -176,11 +178,9 @@ func (c *Client) StartService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to start the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as start requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl start requires root privileges
 		c.systemctlBin,
 		"start",
 		serviceUnit,
@@ -200,11 +200,9 @@ func (c *Client) StopService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to stop the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as stop requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl stop requires root privileges
 		c.systemctlBin,
 		"stop",
 		serviceUnit,
@@ -224,11 +222,9 @@ func (c *Client) RestartService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to restart the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as restart requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl restart requires root privileges
 		c.systemctlBin,
 		"restart",
 		serviceUnit,
@@ -248,11 +244,9 @@ func (c *Client) ReloadService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to reload the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as reload requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl reload requires root privileges
 		c.systemctlBin,
 		"reload",
 		serviceUnit,
@@ -278,11 +272,9 @@ func (c *Client) EnableService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to enable the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as enable requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl enable requires root privileges
 		c.systemctlBin,
 		"enable",
 		serviceUnit,
@@ -302,11 +294,9 @@ func (c *Client) DisableService(ctx context.Context, serviceName string) error {
 		serviceUnit = serviceName + ".service"
 	}
 
-	// Execute systemctl to disable the service
-	_, err := command.ExecCommand(
+	// Execute systemctl with sudo, as disable requires root privileges
+	_, err := c.executor.ExecuteWithCombinedOutput(
 		ctx,
-		c.logger,
-		"sudo", // Using sudo as systemctl disable requires root privileges
 		c.systemctlBin,
 		"disable",
 		serviceUnit,
@@ -0,0 +1,348 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// minRegularID is the lowest user and group ID of a regular account; lower
+// IDs belong to root and system accounts, which Rodent never manages
+const minRegularID = 1000
+
+// privilegedGroups are groups whose members can become root or read secrets,
+// which no account Rodent manages may join
+var privilegedGroups = []string{
+	"root", "sudo", "wheel", "admin", "adm", "shadow", "disk", "docker", "lxd",
+	"libvirt", "systemd-journal",
+}
+
+// homeDirs are where the home directory of an account Rodent manages may be,
+// besides user data on ZFS datasets
+var homeDirs = []string{"/home"}
+
+// accountCommand lists the options an account command accepts and what
+// their values name. Any other option is denied.
+type accountCommand struct {
+	// flags take no value
+	flags []string
+
+	// valueOptions take a value, either in the same argument or the next
+	valueOptions []string
+
+	// idOptions give a user or group ID, which must be a regular one
+	idOptions []string
+
+	// groupOptions give comma-separated groups, none of them privileged
+	groupOptions []string
+
+	// homeOptions give a home directory
+	homeOptions []string
+
+	// userOptions give an existing account, which must be a regular one
+	userOptions []string
+
+	// operand is what the single operand names: "user" for a regular
+	// account, "group" for a group that is not privileged, "regular-group"
+	// for a group that is neither privileged nor a system group, "any" for
+	// an account or group that is not checked, or "" for no operand
+	operand string
+
+	// required are options that must be given, one of them at least
+	required []string
+}
+
+// accountCommands are the account commands whose accounts and groups are
+// checked, so Rodent cannot change root or system accounts, or grant an
+// account root through a privileged group or a duplicate ID
+var accountCommands = map[string]accountCommand{
+	"useradd": {
+		flags: []string{
+			"-m", "--create-home", "-M", "--no-create-home", "-r", "--system",
+			"-N", "--no-user-group", "-U", "--user-group", "-l", "--no-log-init",
+		},
+		valueOptions: []string{
+			"-u", "--uid", "-g", "--gid", "-G", "--groups", "-d", "--home-dir",
+			"-s", "--shell", "-c", "--comment", "-p", "--password",
+			"-e", "--expiredate", "-f", "--inactive",
+		},
+		idOptions:    []string{"-u", "--uid"},
+		groupOptions: []string{"-g", "--gid", "-G", "--groups"},
+		homeOptions:  []string{"-d", "--home-dir"},
+		operand:      "any",
+	},
+	"usermod": {
+		flags: []string{"-a", "--append", "-L", "--lock", "-U", "--unlock", "-m", "--move-home"},
+		valueOptions: []string{
+			"-u", "--uid", "-g", "--gid", "-G", "--groups", "-d", "--home",
+			"-s", "--shell", "-c", "--comment", "-p", "--password", "-l", "--login",
+			"-e", "--expiredate", "-f", "--inactive",
+		},
+		idOptions:    []string{"-u", "--uid"},
+		groupOptions: []string{"-g", "--gid", "-G", "--groups"},
+		homeOptions:  []string{"-d", "--home"},
+		operand:      "user",
+	},
+	"userdel": {
+		flags:   []string{"-r", "--remove", "-f", "--force"},
+		operand: "user",
+	},
+	"groupadd": {
+		flags:        []string{"-r", "--system", "-f", "--force"},
+		valueOptions: []string{"-g", "--gid"},
+		idOptions:    []string{"-g", "--gid"},
+		operand:      "any",
+	},
+	"groupdel": {
+		flags:   []string{"-f", "--force"},
+		operand: "regular-group",
+	},
+	"gpasswd": {
+		valueOptions: []string{"-a", "--add", "-d", "--delete"},
+		userOptions:  []string{"-a", "--add", "-d", "--delete"},
+		operand:      "group",
+		required:     []string{"-a", "--add", "-d", "--delete"},
+	},
+	"passwd": {
+		flags:    []string{"-S", "--status"},
+		operand:  "any",
+		required: []string{"-S", "--status"},
+	},
+	"pdbedit": {
+		flags: []string{
+			"-a", "--create", "-x", "--delete", "-t", "--password-from-stdin",
+			"-L", "--list", "-v", "--verbose", "-w", "--smbpasswd-style",
+		},
+		valueOptions: []string{"-u", "--user"},
+		userOptions:  []string{"-u", "--user"},
+	},
+}
+
+// accountLookup looks up users and groups by name or ID
+type accountLookup interface {
+	LookupUser(name string) (*user.User, error)
+	LookupGroup(name string) (*user.Group, error)
+	LookupGroupID(gid string) (*user.Group, error)
+}
+
+// systemAccounts looks up accounts through the system's name services
+type systemAccounts struct{}
+
+func (systemAccounts) LookupUser(name string) (*user.User, error)    { return user.Lookup(name) }
+func (systemAccounts) LookupGroup(name string) (*user.Group, error)  { return user.LookupGroup(name) }
+func (systemAccounts) LookupGroupID(gid string) (*user.Group, error) { return user.LookupGroupId(gid) }
+
+// checkAccountCommand checks that an account command only uses the options
+// it is allowed, and only touches regular accounts and unprivileged groups
+func (b *Broker) checkAccountCommand(name string, spec accountCommand, args []string) error {
+	denied := func(reason string, key, value string) error {
+		return errors.New(errors.PermissionDenied, reason).
+			WithMetadata("command", name).
+			WithMetadata(key, value)
+	}
+
+	options, operands, bad := parseAccountArgs(spec, args)
+	if bad != "" {
+		return denied("Option not allowed for privileged execution", "option", bad)
+	}
+
+	if len(spec.required) > 0 && !slices.ContainsFunc(spec.required, func(option string) bool {
+		_, ok := options[option]
+		return ok
+	}) {
+		return denied("Required option missing for privileged execution", "options", strings.Join(spec.required, " "))
+	}
+
+	for option, values := range options {
+		for _, value := range values {
+			switch {
+			case slices.Contains(spec.idOptions, option):
+				if id, err := strconv.Atoi(value); err != nil || id < minRegularID {
+					return denied("Only regular IDs allowed for privileged execution", "id", value)
+				}
+			case slices.Contains(spec.groupOptions, option):
+				for _, group := range strings.Split(value, ",") {
+					if b.privilegedGroup(group) {
+						return denied("Privileged group not allowed for privileged execution", "group", group)
+					}
+				}
+			case slices.Contains(spec.homeOptions, option):
+				if !b.homeAllowed(value) {
+					return denied("Home directory not allowed for privileged execution", "path", value)
+				}
+			case slices.Contains(spec.userOptions, option):
+				if b.protectedUser(value) {
+					return denied("Account not allowed for privileged execution", "user", value)
+				}
+			}
+		}
+	}
+
+	if spec.operand == "" {
+		if len(operands) > 0 {
+			return denied("Unexpected operand for privileged execution", "operand", operands[0])
+		}
+		return nil
+	}
+	if len(operands) != 1 {
+		return denied("Exactly one account expected for privileged execution", "args", strings.Join(operands, " "))
+	}
+
+	switch account := operands[0]; spec.operand {
+	case "user":
+		if b.protectedUser(account) {
+			return denied("Account not allowed for privileged execution", "user", account)
+		}
+	case "group":
+		if b.privilegedGroup(account) {
+			return denied("Privileged group not allowed for privileged execution", "group", account)
+		}
+	case "regular-group":
+		if b.protectedGroup(account) {
+			return denied("Group not allowed for privileged execution", "group", account)
+		}
+	}
+	return nil
+}
+
+// parseAccountArgs splits args into the options of spec with their values,
+// keyed by option, and operands. Short options may be grouped, and long
+// options abbreviated to an unambiguous prefix. The first option spec does
+// not list, or that lacks its value, is returned as bad.
+func parseAccountArgs(spec accountCommand, args []string) (options map[string][]string, operands []string, bad string) {
+	known := append(slices.Clone(spec.flags), spec.valueOptions...)
+	options = make(map[string][]string)
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return options, append(operands, args[i+1:]...), ""
+
+		case strings.HasPrefix(arg, "--"):
+			given, value, inline := strings.Cut(arg, "=")
+			var matches []string
+			for _, option := range known {
+				if option == given {
+					matches = []string{option}
+					break
+				}
+				if strings.HasPrefix(option, "--") && strings.HasPrefix(option, given) {
+					matches = append(matches, option)
+				}
+			}
+			if len(matches) != 1 {
+				return nil, nil, given
+			}
+			option := matches[0]
+			if !slices.Contains(spec.valueOptions, option) {
+				if inline {
+					return nil, nil, given
+				}
+				options[option] = append(options[option], "")
+				continue
+			}
+			if !inline {
+				if i+1 == len(args) {
+					return nil, nil, given
+				}
+				i++
+				value = args[i]
+			}
+			options[option] = append(options[option], value)
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			for j := 1; j < len(arg); j++ {
+				option := "-" + arg[j:j+1]
+				if !slices.Contains(known, option) {
+					return nil, nil, option
+				}
+				if !slices.Contains(spec.valueOptions, option) {
+					options[option] = append(options[option], "")
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" {
+					if i+1 == len(args) {
+						return nil, nil, option
+					}
+					i++
+					value = args[i]
+				}
+				options[option] = append(options[option], value)
+				break
+			}
+
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return options, operands, ""
+}
+
+// protectedUser reports whether an existing account is root or a system
+// account. Unknown accounts are left to the command to refuse.
+func (b *Broker) protectedUser(name string) bool {
+	u, err := b.accounts.LookupUser(name)
+	if err != nil {
+		return name == "root"
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	return err != nil || uid < minRegularID
+}
+
+// privilegedGroup reports whether a group, by name or ID, is root's group or
+// one of privilegedGroups
+func (b *Broker) privilegedGroup(group string) bool {
+	if slices.Contains(privilegedGroups, group) {
+		return true
+	}
+	lookup := b.accounts.LookupGroup
+	if _, err := strconv.Atoi(group); err == nil {
+		lookup = b.accounts.LookupGroupID
+	}
+	g, err := lookup(group)
+	if err != nil {
+		return false
+	}
+	return g.Gid == "0" || slices.Contains(privilegedGroups, g.Name)
+}
+
+// protectedGroup reports whether an existing group is privileged or a system
+// group, which Rodent must not delete or change the members of
+func (b *Broker) protectedGroup(group string) bool {
+	if b.privilegedGroup(group) {
+		return true
+	}
+	g, err := b.accounts.LookupGroup(group)
+	if err != nil {
+		return false
+	}
+	gid, err := strconv.Atoi(g.Gid)
+	return err != nil || gid < minRegularID
+}
+
+// homeAllowed reports whether a home directory is below one of homeDirs or
+// user data on a ZFS dataset, so a home created or moved as root cannot take
+// over a system directory
+func (b *Broker) homeAllowed(path string) bool {
+	clean := filepath.Clean(path)
+	if !filepath.IsAbs(clean) {
+		return false
+	}
+	resolved, ok := resolveSymlinks(clean)
+	for _, dir := range homeDirs {
+		if ok && clean != dir && within(dir, clean) && resolved != dir && within(dir, resolved) {
+			return true
+		}
+	}
+	return b.DataPath(clean)
+}
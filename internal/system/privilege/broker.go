@@ -0,0 +1,681 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/parsers"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// fileCommand describes where the paths are in the arguments of a file
+// command, whose paths must be in AllowedPaths
+type fileCommand struct {
+	// valueOptions take a value, either in the same argument or the next
+	valueOptions []string
+
+	// sourceOptions are value options whose value is a path read from
+	sourceOptions []string
+
+	// deniedOptions are long options that are never allowed, because they
+	// name files the command changes or copies from outside its operands
+	deniedOptions []string
+
+	// leading is set when the first operand is not a path but a mode
+	// (chmod) or an owner (chown)
+	leading bool
+
+	// dataPaths is set when the command may also change user data on ZFS
+	// datasets, such as the ownership and ACLs of a share
+	dataPaths bool
+}
+
+// fileCommands are the file commands whose paths are checked. Files are read
+// and written by the root helper itself rather than by cat, tee or sed.
+var fileCommands = map[string]fileCommand{
+	"mkdir": {valueOptions: []string{"-m", "--mode"}},
+	"chmod": {
+		deniedOptions: []string{"--reference"},
+		leading:       true,
+		dataPaths:     true,
+	},
+	"chown": {
+		deniedOptions: []string{"--reference"},
+		leading:       true,
+		dataPaths:     true,
+	},
+	"setfacl": {
+		valueOptions: []string{
+			"-m", "--modify", "-x", "--remove", "--set",
+			"-M", "--modify-file", "-X", "--remove-file", "--set-file",
+		},
+		sourceOptions: []string{"-M", "--modify-file", "-X", "--remove-file", "--set-file"},
+		// A restore file names the paths it changes
+		deniedOptions: []string{"--restore"},
+		dataPaths:     true,
+	},
+}
+
+// globalValueOptions are the options taking a value that may come before
+// the subcommand of a command in AllowedSubcommands
+var globalValueOptions = map[string][]string{
+	"systemctl": {
+		"-H", "--host", "-M", "--machine", "-p", "--property", "-t", "--type",
+		"-s", "--signal", "-n", "--lines", "-o", "--output", "--state", "--root",
+		"--kill-whom", "--job-mode", "--what",
+	},
+	"net": {
+		"-U", "--user", "-W", "--workgroup", "-S", "--server", "-I", "--ipaddress",
+		"-p", "--port", "-s", "--configfile", "-d", "--debuglevel", "-n", "--netbiosname",
+		"--realm",
+	},
+	"docker": {
+		"-H", "--host", "-c", "--context", "--config", "-l", "--log-level",
+		"--tlscacert", "--tlscert", "--tlskey",
+	},
+}
+
+// systemDirs are directories a ZFS dataset mounted at or below is not user
+// data, so a root file system on ZFS does not open them up
+var systemDirs = []string{
+	"/bin", "/boot", "/dev", "/etc", "/lib", "/lib32", "/lib64", "/opt", "/proc",
+	"/root", "/run", "/sbin", "/snap", "/sys", "/usr", "/var",
+}
+
+// denialEventInterval limits denial events to one per operation and target
+// in this interval; every denial is still logged
+const denialEventInterval = time.Minute
+
+// systemBinDirs are the directories a command given by absolute path may live in
+var systemBinDirs = []string{
+	"/bin", "/sbin", "/usr/bin", "/usr/sbin", "/usr/local/bin", "/usr/local/sbin", "/snap/bin",
+}
+
+// Broker is the single point through which Rodent performs privileged
+// operations. It checks every sudo command and file operation against the
+// policy in Config and writes an audit record for each one. Outside of
+// enforce mode, operations that fall outside the policy are logged but still
+// allowed.
+type Broker struct {
+	logger   logger.Logger
+	config   *Config
+	commands map[string]bool
+	paths    []string
+
+	// helperPath is the Rodent executable run as the root helper, or empty
+	// when it cannot be found
+	helperPath string
+
+	// readMounts reads /proc/self/mounts; tests replace it
+	readMounts func() ([]byte, error)
+
+	// accounts looks up users and groups; tests replace it
+	accounts accountLookup
+
+	mu          sync.Mutex
+	lastDenials map[string]time.Time
+}
+
+var (
+	brokerMu      sync.RWMutex
+	defaultBroker *Broker
+)
+
+// NewBroker creates a broker for the given policy
+func NewBroker(logger logger.Logger, config *Config) *Broker {
+	if config == nil {
+		config = DefaultConfig()
+	}
+
+	commands := make(map[string]bool, len(config.AllowedCommands))
+	for _, name := range config.AllowedCommands {
+		commands[name] = true
+	}
+
+	return &Broker{
+		logger:      logger,
+		config:      config,
+		commands:    commands,
+		paths:       cleanPathPatterns(config.AllowedPaths),
+		helperPath:  helperExecutable(),
+		lastDenials: make(map[string]time.Time),
+		readMounts: func() ([]byte, error) {
+			return os.ReadFile("/proc/self/mounts")
+		},
+		accounts: systemAccounts{},
+	}
+}
+
+// Install makes b the process-wide broker: every CommandExecutor using sudo
+// and every SudoFileOperations instance goes through it from now on
+func (b *Broker) Install() {
+	brokerMu.Lock()
+	defaultBroker = b
+	brokerMu.Unlock()
+
+	command.SetSudoGuard(b)
+	b.logger.Info("Privilege broker installed",
+		"enforce", b.config.Enforce,
+		"commands", len(b.config.AllowedCommands),
+		"paths", len(b.config.AllowedPaths))
+}
+
+// AllowedPaths returns the effective allow-list of the installed broker. It is
+// shared by every manager that performs privileged file operations.
+func AllowedPaths() []string {
+	return slices.Clone(currentBroker().config.AllowedPaths)
+}
+
+// PathAllowed reports whether the installed broker's allow-list covers path
+func PathAllowed(path string) bool {
+	return currentBroker().PathAllowed(path)
+}
+
+// Policy returns a copy of the effective policy of the installed broker
+func Policy() Config {
+	config := *currentBroker().config
+	config.AllowedPaths = slices.Clone(config.AllowedPaths)
+	config.AllowedCommands = slices.Clone(config.AllowedCommands)
+	return config
+}
+
+// currentBroker returns the installed broker. Before one is installed, as in
+// CLI commands, a broker is created from the Rodent configuration, falling
+// back to the default policy if the configuration is invalid.
+func currentBroker() *Broker {
+	brokerMu.RLock()
+	b := defaultBroker
+	brokerMu.RUnlock()
+	if b != nil {
+		return b
+	}
+
+	brokerMu.Lock()
+	defer brokerMu.Unlock()
+	if defaultBroker == nil {
+		config, err := LoadConfig()
+		if err != nil {
+			common.Log.Warn("Invalid privilege policy, using defaults", "error", err)
+			config = DefaultConfig()
+		}
+		defaultBroker = NewBroker(common.Log, config)
+	}
+	return defaultBroker
+}
+
+// Authorize implements command.SudoGuard
+func (b *Broker) Authorize(cmd string, args []string) error {
+	return b.enforce("execute_command", cmd, args, b.CheckCommand(cmd, args))
+}
+
+// Audit implements command.SudoGuard
+func (b *Broker) Audit(cmd string, args []string, elapsed time.Duration, err error) {
+	b.audit("execute_command", cmd, args, elapsed, err)
+}
+
+// CheckCommand returns an error if the policy does not allow running cmd with
+// args as root. It does not consider enforce mode.
+func (b *Broker) CheckCommand(cmd string, args []string) error {
+	name := cmd
+	if strings.Contains(cmd, "/") {
+		if !slices.Contains(systemBinDirs, filepath.Dir(cmd)) {
+			return errors.New(errors.PermissionDenied, "Command path not allowed for privileged execution").
+				WithMetadata("command", cmd)
+		}
+		name = filepath.Base(cmd)
+	}
+
+	if !b.commands[name] {
+		return errors.New(errors.PermissionDenied, "Command not allowed for privileged execution").
+			WithMetadata("command", name)
+	}
+
+	if subcommands, ok := b.config.AllowedSubcommands[name]; ok {
+		operands, _ := parseArgs(args, globalValueOptions[name])
+		if len(operands) == 0 || !slices.Contains(subcommands, operands[0]) {
+			return errors.New(errors.PermissionDenied, "Subcommand not allowed for privileged execution").
+				WithMetadata("command", name).
+				WithMetadata("args", strings.Join(command.RedactArgs(args), " "))
+		}
+	}
+
+	if name == "ip" {
+		if err := checkIPCommand(args); err != nil {
+			return err
+		}
+	}
+
+	if spec, ok := accountCommands[name]; ok {
+		return b.checkAccountCommand(name, spec, args)
+	}
+
+	if spec, ok := fileCommands[name]; ok {
+		return b.checkFileCommand(name, spec, args)
+	}
+
+	return nil
+}
+
+// checkFileCommand checks that a file command only writes paths the policy
+// allows, and only reads paths it allows or Rodent's own temporary files
+func (b *Broker) checkFileCommand(name string, spec fileCommand, args []string) error {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		// Long options may be abbreviated to any unambiguous prefix
+		option, _, _ := strings.Cut(arg, "=")
+		if len(option) > 2 && strings.HasPrefix(option, "--") &&
+			slices.ContainsFunc(spec.deniedOptions, func(denied string) bool {
+				return strings.HasPrefix(denied, option)
+			}) {
+			return errors.New(errors.PermissionDenied, "Option not allowed for privileged execution").
+				WithMetadata("command", name).
+				WithMetadata("option", option)
+		}
+	}
+
+	operands, values := parseArgs(args, spec.valueOptions)
+
+	var sources []string
+	for _, option := range spec.sourceOptions {
+		sources = append(sources, values[option]...)
+	}
+
+	var mode string
+	if spec.leading && len(operands) > 0 {
+		mode, operands = operands[0], operands[1:]
+	}
+	targets := operands
+
+	if len(targets) == 0 {
+		return errors.New(errors.PermissionDenied, "File command without a path").
+			WithMetadata("command", name)
+	}
+	for _, path := range targets {
+		if !b.PathAllowed(path) && !(spec.dataPaths && b.DataPath(path)) {
+			return errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
+				WithMetadata("command", name).
+				WithMetadata("path", path)
+		}
+	}
+	for _, path := range sources {
+		if !b.PathAllowed(path) && !(spec.dataPaths && b.DataPath(path)) && !ownTempFile(path) {
+			return errors.New(errors.PermissionDenied, "Source path not allowed for privileged access").
+				WithMetadata("command", name).
+				WithMetadata("path", path)
+		}
+	}
+
+	if name == "chmod" {
+		return checkMode(mode, targets)
+	}
+	return nil
+}
+
+// checkMode denies a chmod mode that sets the set-user-ID bit, or the
+// set-group-ID bit on anything but a directory, where it only passes the
+// group on to new files. Either would let a file in a share run with the
+// privileges of its owner or group.
+func checkMode(mode string, targets []string) error {
+	setuid, setgid := setIDBits(mode)
+	if setuid {
+		return errors.New(errors.PermissionDenied, "Set-user-ID mode not allowed for privileged execution").
+			WithMetadata("mode", mode)
+	}
+	if !setgid {
+		return nil
+	}
+	for _, path := range targets {
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			return errors.New(errors.PermissionDenied, "Set-group-ID mode only allowed on directories").
+				WithMetadata("mode", mode).
+				WithMetadata("path", path)
+		}
+	}
+	return nil
+}
+
+// setIDBits reports whether a numeric or symbolic chmod mode sets the
+// set-user-ID or set-group-ID bit
+func setIDBits(mode string) (setuid, setgid bool) {
+	if bits, err := strconv.ParseUint(mode, 8, 32); err == nil {
+		return bits&0o4000 != 0, bits&0o2000 != 0
+	}
+
+	for _, clause := range strings.Split(mode, ",") {
+		perms := strings.TrimLeft(clause, "ugoa")
+		who := clause[:len(clause)-len(perms)]
+		op := '-'
+		for _, c := range perms {
+			switch {
+			case c == '+' || c == '-' || c == '=':
+				op = c
+			case c == 's' && op != '-':
+				all := who == "" || strings.Contains(who, "a")
+				setuid = setuid || all || strings.Contains(who, "u")
+				setgid = setgid || all || strings.Contains(who, "g")
+			}
+		}
+	}
+	return setuid, setgid
+}
+
+// ipOptions are the options ip may be given before its object. Others, such
+// as -batch, run commands from a file.
+var ipOptions = []string{
+	"-4", "-6", "-o", "-oneline", "-j", "-json", "-p", "-pretty",
+	"-br", "-brief", "-d", "-details", "-s", "-stats",
+}
+
+// ipDeniedOperands load BPF programs from a file
+var ipDeniedOperands = []string{"obj", "object", "pinned", "xdp", "xdpgeneric", "xdpdrv", "xdpoffload"}
+
+// checkIPCommand checks the options and operands of ip; its object is
+// checked as a subcommand
+func checkIPCommand(args []string) error {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			if slices.ContainsFunc(args[i:], func(arg string) bool {
+				return slices.Contains(ipDeniedOperands, arg)
+			}) {
+				return errors.New(errors.PermissionDenied, "ip operand not allowed for privileged execution").
+					WithMetadata("args", strings.Join(args, " "))
+			}
+			return nil
+		}
+		// ip accepts options with one or two dashes
+		if !slices.Contains(ipOptions, "-"+strings.TrimLeft(arg, "-")) {
+			return errors.New(errors.PermissionDenied, "ip option not allowed for privileged execution").
+				WithMetadata("option", arg)
+		}
+	}
+	return nil
+}
+
+// PathAllowed reports whether path is covered by the policy's AllowedPaths
+func (b *Broker) PathAllowed(path string) bool {
+	return matchesPath(b.paths, path)
+}
+
+// DataPath reports whether path is user data: it lies on a mounted ZFS
+// dataset other than the root file system and system directories, both as
+// given and with its symlinks resolved, so a link cannot lead out of it
+func (b *Broker) DataPath(path string) bool {
+	data, err := b.readMounts()
+	if err != nil {
+		return false
+	}
+	mounts := parsers.ParseMounts(data)
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	resolved, ok := resolveSymlinks(absPath)
+	return ok && onDataset(mounts, absPath) && onDataset(mounts, resolved)
+}
+
+// onDataset reports whether the mount holding path is a ZFS dataset outside
+// the system directories
+func onDataset(mounts []parsers.Mount, path string) bool {
+	var holder *parsers.Mount
+	for i, mount := range mounts {
+		if !within(mount.Mountpoint, path) {
+			continue
+		}
+		if holder == nil || len(mount.Mountpoint) >= len(holder.Mountpoint) {
+			holder = &mounts[i]
+		}
+	}
+	if holder == nil || holder.FSType != "zfs" || holder.Mountpoint == "/" {
+		return false
+	}
+	return !slices.ContainsFunc(systemDirs, func(dir string) bool {
+		return within(dir, holder.Mountpoint)
+	})
+}
+
+// resolveSymlinks resolves the symlinks of an absolute path. Only a missing
+// path is resolved through its parent, such as a file about to be created;
+// any other error fails.
+func resolveSymlinks(path string) (string, bool) {
+	return resolveThrough(path, os.IsNotExist)
+}
+
+// resolveAllowedPath resolves the symlinks of an absolute path like
+// resolveSymlinks, but also resolves a path in a directory Rodent's user
+// cannot search through its parent. Allowed paths live in root-owned
+// directories such as /etc/sssd, where only root can place a link.
+func resolveAllowedPath(path string) (string, bool) {
+	return resolveThrough(path, func(err error) bool {
+		return os.IsNotExist(err) || os.IsPermission(err)
+	})
+}
+
+// resolveThrough resolves the symlinks of an absolute path, resolving it
+// through its parent when the error is one skip accepts
+func resolveThrough(path string, skip func(error) bool) (string, bool) {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		return resolved, true
+	}
+	parent := filepath.Dir(path)
+	if !skip(err) || parent == path {
+		return "", false
+	}
+	resolvedParent, ok := resolveThrough(parent, skip)
+	if !ok {
+		return "", false
+	}
+	return filepath.Join(resolvedParent, filepath.Base(path)), true
+}
+
+// within reports whether path is dir or below it
+func within(dir, path string) bool {
+	return dir == "/" || path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+// ownTempFile reports whether path is a regular file directly in the
+// temporary directory owned by Rodent's user, such as the ACL entries a
+// setfacl reads. Under the root helper that is the user that ran sudo.
+func ownTempFile(path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil || filepath.Dir(absPath) != filepath.Clean(os.TempDir()) {
+		return false
+	}
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(stat.Uid) == invokingUID()
+}
+
+// invokingUID returns the user ID Rodent runs as: the user that ran sudo when
+// running as root, which sudo records and the user cannot change
+func invokingUID() int {
+	if os.Geteuid() == 0 {
+		if uid, err := strconv.Atoi(os.Getenv("SUDO_UID")); err == nil {
+			return uid
+		}
+	}
+	return os.Getuid()
+}
+
+// enforce logs a policy violation and returns it in enforce mode
+func (b *Broker) enforce(operation, target string, args []string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	fields := []any{"operation", operation, "target", target, "reason", err}
+	if len(args) > 0 {
+		fields = append(fields, "args", strings.Join(command.RedactArgs(args), " "))
+	}
+
+	if b.config.Enforce {
+		b.denied(operation, target, args, err)
+		return err
+	}
+
+	b.logger.Warn("Privileged operation outside policy allowed in audit mode", fields...)
+	return nil
+}
+
+// denied logs an operation that was blocked and emits a security event for it
+func (b *Broker) denied(operation, target string, args []string, err error) {
+	fields := []any{"operation", operation, "target", target, "reason", err}
+	if len(args) > 0 {
+		fields = append(fields, "args", strings.Join(command.RedactArgs(args), " "))
+	}
+	b.logger.Warn("Privileged operation denied", fields...)
+
+	key := operation + " " + target
+	b.mu.Lock()
+	last, seen := b.lastDenials[key]
+	if seen && time.Since(last) < denialEventInterval {
+		b.mu.Unlock()
+		return
+	}
+	b.lastDenials[key] = time.Now()
+	b.mu.Unlock()
+
+	username := ""
+	if u, err := user.Current(); err == nil {
+		username = u.Username
+	}
+
+	metadata := map[string]string{
+		"component": "privilege-broker",
+		"action":    "denied",
+		"operation": operation,
+		"target":    target,
+		"reason":    err.Error(),
+	}
+	if len(args) > 0 {
+		metadata["args"] = strings.Join(command.RedactArgs(args), " ")
+	}
+
+	events.EmitSecurityAuth(eventspb.EventLevel_EVENT_LEVEL_WARN, &eventspb.SecurityAuthPayload{
+		Username:  username,
+		Method:    "sudo",
+		Operation: eventspb.SecurityAuthPayload_SECURITY_AUTH_OPERATION_UNSPECIFIED,
+	}, metadata)
+}
+
+// audit writes the audit record for a privileged operation
+func (b *Broker) audit(operation, target string, args []string, elapsed time.Duration, err error) {
+	fields := []any{"operation", operation, "target", target, "duration", elapsed}
+	if len(args) > 0 {
+		fields = append(fields, "args", strings.Join(command.RedactArgs(args), " "))
+	}
+
+	if err != nil {
+		b.logger.Warn("Privileged operation failed", append(fields, "error", err)...)
+		return
+	}
+	b.logger.Info("Privileged operation", fields...)
+}
+
+// parseArgs splits args into operands and the values of valueOptions, keyed
+// by option. A value is taken from the same argument ("-tDIR", "--opt=value")
+// or from the next one. Everything after "--" is an operand.
+func parseArgs(args []string, valueOptions []string) ([]string, map[string][]string) {
+	operands := make([]string, 0, len(args))
+	values := make(map[string][]string)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(operands, args[i+1:]...), values
+
+		case strings.HasPrefix(arg, "--"):
+			option, value, inline := strings.Cut(arg, "=")
+			if inline {
+				values[option] = append(values[option], value)
+			} else if slices.Contains(valueOptions, option) && i+1 < len(args) {
+				i++
+				values[option] = append(values[option], args[i])
+			}
+
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// Short options may be grouped; the first one taking a value
+			// takes the rest of the argument, or the next one
+			for j := 1; j < len(arg); j++ {
+				option := "-" + arg[j:j+1]
+				if !slices.Contains(valueOptions, option) {
+					continue
+				}
+				value := arg[j+1:]
+				if value == "" && i+1 < len(args) {
+					i++
+					value = args[i]
+				}
+				values[option] = append(values[option], value)
+				break
+			}
+
+		case arg != "":
+			operands = append(operands, arg)
+		}
+	}
+	return operands, values
+}
+
+// cleanPathPatterns returns the allowed path patterns in clean form
+func cleanPathPatterns(paths []string) []string {
+	patterns := make([]string, 0, len(paths))
+	for _, path := range paths {
+		patterns = append(patterns, filepath.Clean(path))
+	}
+	return patterns
+}
+
+// matchesPath reports whether the absolute, cleaned form of path is covered
+// by one of the patterns, both as given and with its symlinks resolved, so a
+// link under an allowed directory cannot lead out of it
+func matchesPath(patterns []string, path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	resolved, ok := resolveAllowedPath(absPath)
+	return ok && matchesPattern(patterns, absPath) && matchesPattern(patterns, resolved)
+}
+
+// matchesPattern reports whether an absolute, clean path is covered by one of
+// the patterns: it or one of its parent directories matches a pattern, or it
+// is a suffixed backup ("<pattern>.<suffix>") of a match
+func matchesPattern(patterns []string, absPath string) bool {
+	for _, pattern := range patterns {
+		for dir := absPath; ; dir = filepath.Dir(dir) {
+			if ok, _ := filepath.Match(pattern, dir); ok {
+				return true
+			}
+			if dir == "/" {
+				break
+			}
+		}
+		if ok, _ := filepath.Match(pattern+".*", absPath); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrokerCheckCommand(t *testing.T) {
+	b := NewBroker(common.Log, DefaultConfig())
+	b.readMounts = func() ([]byte, error) {
+		return []byte("rpool/ROOT/ubuntu / zfs rw 0 0\n" +
+			"rpool/var/lib /var/lib zfs rw 0 0\n" +
+			"tank/share /tank/share zfs rw 0 0\n" +
+			"/dev/sdb1 /mnt/usb ext4 rw 0 0\n"), nil
+	}
+
+	tmp, err := os.CreateTemp("", "rodent-test-*")
+	require.NoError(t, err)
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	own := tmp.Name()
+
+	tests := []struct {
+		name    string
+		cmd     string
+		args    []string
+		allowed bool
+	}{
+		{"allowed command", "zfs", []string{"list", "-H"}, true},
+		{"absolute path in system dir", "/usr/sbin/zpool", []string{"status"}, true},
+		{"absolute path outside system dirs", "/tmp/zfs", []string{"list"}, false},
+		{"unknown command", "bash", []string{"-c", "id"}, false},
+		{"allowed subcommand", "systemctl", []string{"restart", "smbd"}, true},
+		{"subcommand after options", "systemctl", []string{"--no-pager", "status", "smbd"}, true},
+		{"disallowed subcommand", "systemctl", []string{"edit", "smbd"}, false},
+		{"net ads", "net", []string{"ads", "testjoin"}, true},
+		{"net rpc", "net", []string{"rpc", "shell"}, false},
+		{"mkdir allowed path", "mkdir", []string{"-p", "/etc/sssd"}, true},
+		{"mkdir disallowed path", "mkdir", []string{"-p", "/etc/cron.d"}, false},
+		{"traversal out of allowed path", "mkdir", []string{"-p", "/etc/samba/conf.d/../../cron.d"}, false},
+		{"file command without path", "mkdir", []string{"-p"}, false},
+		{"removed file command", "sed", []string{"-i", "s/a/b/", "/etc/hosts"}, false},
+		{"chmod allowed path", "chmod", []string{"600", "/etc/netplan/01.yaml"}, true},
+		{"chmod disallowed path", "chmod", []string{"777", "/etc/shadow"}, false},
+		{"chmod setuid", "chmod", []string{"4755", "/tank/share/sh"}, false},
+		{"chmod symbolic setuid", "chmod", []string{"u+s", "/tank/share/sh"}, false},
+		{"chmod setgid on missing file", "chmod", []string{"g+s", "/tank/share/sh"}, false},
+		{"chmod reference", "chmod", []string{"--reference=/tank/share/sh", "/tank/share/docs"}, false},
+		{"chown reference", "chown", []string{"--ref=/etc/shadow", "/tank/share/a", "/tank/share/b"}, false},
+		{"chown data path", "chown", []string{"nobody:", "/tank/share/docs"}, true},
+		{"chown system dataset", "chown", []string{"nobody:", "/var/lib/samba"}, false},
+		{"chown root dataset", "chown", []string{"nobody:", "/home/user"}, false},
+		{"chown other file system", "chown", []string{"nobody:", "/mnt/usb/x"}, false},
+		{"setfacl data path", "setfacl", []string{"-R", "--set-file=" + own, "/tank/share"}, true},
+		{"setfacl modify data path", "setfacl", []string{"-m", "u:bob:rwx", "/tank/share"}, true},
+		{"setfacl system path", "setfacl", []string{"-m", "u:bob:rwx", "/etc/shadow"}, false},
+		{"setfacl from disallowed file", "setfacl", []string{"-M", "/root/acl", "/tank/share"}, false},
+		{"setfacl restore", "setfacl", []string{"--restore=" + own}, false},
+		{"ip show", "ip", []string{"-o", "-4", "addr", "show", "dev", "eth0"}, true},
+		{"ip link add", "ip", []string{"link", "add", "shim", "link", "eth0", "type", "macvlan", "mode", "bridge"}, true},
+		{"ip netns exec", "ip", []string{"netns", "exec", "x", "sh"}, false},
+		{"ip batch", "ip", []string{"-batch", "/tmp/cmds", "addr"}, false},
+		{"ip xdp program", "ip", []string{"link", "set", "eth0", "xdp", "obj", "/tmp/prog.o"}, false},
+		{"cp is restored by the helper itself", "cp", []string{"-a", "-T", "--remove-destination",
+			"/tank/share/.zfs/snapshot/daily/docs", "/tank/share/docs"}, false},
+		{"option value is not a subcommand", "systemctl", []string{"-H", "status", "edit", "smbd"}, false},
+		{"inline option value before subcommand", "systemctl", []string{"--property=Id", "show", "smbd"}, true},
+		{"docker exec", "docker", []string{"exec", "dc", "samba-tool", "drs", "showrepl"}, true},
+		{"docker exec with stdin", "docker", []string{"exec", "-i", "dc", "samba-tool", "user", "setpassword", "bob"}, true},
+		{"docker run", "docker", []string{"run", "--privileged", "-v", "/:/host", "alpine"}, false},
+		{"docker host option", "docker", []string{"-H", "exec", "run", "alpine"}, false},
+		{"env", "env", []string{"KRB5CCNAME=FILE:/tmp/cc", "net", "ads", "join", "-k"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.CheckCommand(tt.cmd, tt.args)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestBrokerAuthorizeAuditMode(t *testing.T) {
+	config := DefaultConfig()
+	b := NewBroker(common.Log, config)
+	assert.NoError(t, b.Authorize("bash", []string{"-c", "id"}))
+
+	config.Enforce = true
+	b = NewBroker(common.Log, config)
+	assert.Error(t, b.Authorize("bash", []string{"-c", "id"}))
+}
+
+// fakeAccounts looks up users and groups in maps of name to ID
+type fakeAccounts struct {
+	users  map[string]string
+	groups map[string]string
+}
+
+func (f fakeAccounts) LookupUser(name string) (*user.User, error) {
+	if uid, ok := f.users[name]; ok {
+		return &user.User{Username: name, Uid: uid}, nil
+	}
+	return nil, user.UnknownUserError(name)
+}
+
+func (f fakeAccounts) LookupGroup(name string) (*user.Group, error) {
+	if gid, ok := f.groups[name]; ok {
+		return &user.Group{Name: name, Gid: gid}, nil
+	}
+	return nil, user.UnknownGroupError(name)
+}
+
+func (f fakeAccounts) LookupGroupID(gid string) (*user.Group, error) {
+	for name, id := range f.groups {
+		if id == gid {
+			return &user.Group{Name: name, Gid: id}, nil
+		}
+	}
+	return nil, user.UnknownGroupIdError(gid)
+}
+
+func TestBrokerCheckAccountCommand(t *testing.T) {
+	b := NewBroker(common.Log, DefaultConfig())
+	b.accounts = fakeAccounts{
+		users:  map[string]string{"root": "0", "daemon": "1", "alice": "1001"},
+		groups: map[string]string{"root": "0", "sudo": "27", "users": "100", "staff": "1002", "wheel": "10"},
+	}
+	b.readMounts = func() ([]byte, error) {
+		return []byte("tank/home /tank/home zfs rw 0 0\n"), nil
+	}
+
+	tests := []struct {
+		name    string
+		cmd     string
+		args    []string
+		allowed bool
+	}{
+		{"useradd", "useradd", []string{"--comment", "Bob", "--home-dir", "/home/bob", "--create-home", "bob"}, true},
+		{"useradd with uid", "useradd", []string{"--uid", "2000", "bob"}, true},
+		{"useradd system account", "useradd", []string{"--system", "--no-create-home", "svc"}, true},
+		{"useradd home on dataset", "useradd", []string{"-d", "/tank/home/bob", "-m", "bob"}, true},
+		{"useradd root uid", "useradd", []string{"-o", "-u", "0", "bob"}, false},
+		{"useradd low uid", "useradd", []string{"--uid=0", "bob"}, false},
+		{"useradd abbreviated non-unique", "useradd", []string{"--non", "bob"}, false},
+		{"useradd privileged group", "useradd", []string{"-G", "users,sudo", "bob"}, false},
+		{"useradd privileged group id", "useradd", []string{"-g", "0", "bob"}, false},
+		{"useradd home in system dir", "useradd", []string{"-d", "/etc/cron.d", "-m", "bob"}, false},
+		{"useradd skeleton", "useradd", []string{"-k", "/root", "-m", "bob"}, false},
+		{"useradd two accounts", "useradd", []string{"bob", "carol"}, false},
+		{"usermod append group", "usermod", []string{"-a", "-G", "staff", "alice"}, true},
+		{"usermod grouped options", "usermod", []string{"-aG", "staff", "alice"}, true},
+		{"usermod lock", "usermod", []string{"--lock", "alice"}, true},
+		{"usermod sudo group", "usermod", []string{"-aG", "sudo", "alice"}, false},
+		{"usermod wheel group", "usermod", []string{"-a", "-G", "wheel", "alice"}, false},
+		{"usermod root", "usermod", []string{"--password", "x", "root"}, false},
+		{"usermod system account", "usermod", []string{"--shell", "/bin/bash", "daemon"}, false},
+		{"usermod subordinate ids", "usermod", []string{"--add-subuids", "0-0", "alice"}, false},
+		{"usermod chroot", "usermod", []string{"-R", "/tmp/x", "alice"}, false},
+		{"userdel", "userdel", []string{"-r", "alice"}, true},
+		{"userdel root", "userdel", []string{"root"}, false},
+		{"groupadd", "groupadd", []string{"eng"}, true},
+		{"groupadd low gid", "groupadd", []string{"-g", "27", "eng"}, false},
+		{"groupdel", "groupdel", []string{"staff"}, true},
+		{"groupdel system group", "groupdel", []string{"users"}, false},
+		{"gpasswd remove member", "gpasswd", []string{"-d", "alice", "staff"}, true},
+		{"gpasswd add to sudo", "gpasswd", []string{"-a", "alice", "sudo"}, false},
+		{"gpasswd set members", "gpasswd", []string{"-M", "alice", "staff"}, false},
+		{"passwd status", "passwd", []string{"-S", "alice"}, true},
+		{"passwd change", "passwd", []string{"alice"}, false},
+		{"pdbedit add", "pdbedit", []string{"-a", "-u", "alice", "-t"}, true},
+		{"pdbedit delete", "pdbedit", []string{"-x", "-u", "alice"}, true},
+		{"pdbedit root", "pdbedit", []string{"-a", "-u", "root", "-t"}, false},
+		{"pdbedit backend", "pdbedit", []string{"-b", "tdbsam:/etc/shadow", "-a", "-u", "alice"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := b.CheckCommand(tt.cmd, tt.args)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckMode(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o644))
+
+	assert.NoError(t, checkMode("0755", []string{file}))
+	assert.NoError(t, checkMode("u+rwx,go-w", []string{file}))
+	assert.NoError(t, checkMode("2775", []string{dir}))
+	assert.NoError(t, checkMode("g+s", []string{dir}))
+	assert.NoError(t, checkMode("u-s", []string{file}))
+	assert.Error(t, checkMode("4755", []string{file}))
+	assert.Error(t, checkMode("a+s", []string{dir}))
+	assert.Error(t, checkMode("+s", []string{dir}))
+	assert.Error(t, checkMode("2775", []string{file}))
+	assert.Error(t, checkMode("g=rxs", []string{dir, file}))
+}
+
+func TestSudoers(t *testing.T) {
+	config := &Config{
+		AllowedPaths:       []string{"/etc/hosts"},
+		AllowedCommands:    []string{"zfs", "systemctl", "chown", "usermod", "missing"},
+		AllowedSubcommands: map[string][]string{"systemctl": {"restart"}},
+	}
+	lookPath := func(name string) (string, error) {
+		if name == "missing" {
+			return "", assert.AnError
+		}
+		return "/usr/bin/" + name, nil
+	}
+
+	out := config.Sudoers("rodent", "/usr/local/bin/rodent", lookPath)
+
+	assert.Contains(t, out, "    /usr/bin/zfs, \\\n")
+	assert.Contains(t, out, "/usr/bin/systemctl restart *")
+	assert.Contains(t, out, "/usr/local/bin/rodent privilege helper *")
+	assert.Contains(t, out, "rodent ALL=(ALL) NOPASSWD: RODENT_COMMANDS, RODENT_HELPER")
+	assert.NotContains(t, out, "/usr/bin/chown")
+	assert.NotContains(t, out, "/usr/bin/usermod")
+	assert.NotContains(t, out, "missing")
+
+	out = config.Sudoers("rodent", "", lookPath)
+	assert.NotContains(t, out, "RODENT_HELPER")
+}
+
+func TestBrokerSudoCommand(t *testing.T) {
+	b := NewBroker(common.Log, DefaultConfig())
+	b.helperPath = "/usr/local/bin/rodent"
+
+	cmd, args := b.SudoCommand("chown", []string{"bob:", "/tank/share"})
+	assert.Equal(t, "/usr/local/bin/rodent", cmd)
+	assert.Equal(t, []string{"privilege", "helper", "run", "chown", "bob:", "/tank/share"}, args)
+
+	cmd, args = b.SudoCommand("/usr/bin/setfacl", []string{"-b", "/tank/share"})
+	assert.Equal(t, "/usr/local/bin/rodent", cmd)
+	assert.Equal(t, []string{"privilege", "helper", "run", "/usr/bin/setfacl", "-b", "/tank/share"}, args)
+
+	cmd, args = b.SudoCommand("zfs", []string{"list"})
+	assert.Equal(t, "zfs", cmd)
+	assert.Equal(t, []string{"list"}, args)
+}
+
+func TestMatchesPath(t *testing.T) {
+	patterns := cleanPathPatterns([]string{"/etc/krb5.conf", "/etc/samba/conf.d", "/srv/*/exports"})
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{"/etc/krb5.conf", true},
+		{"/etc/krb5.conf.backup.20250101", true},
+		{"/etc/samba/conf.d/share.conf", true},
+		{"/etc/samba/smb.conf", false},
+		{"/srv/tank/exports", true},
+		{"/srv/tank/exports/share", true},
+		{"/srv/tank/nested/exports", false},
+		{"/srv/exports", false},
+		{"/etc/samba/conf.d/../../shadow", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, matchesPath(patterns, tt.path), tt.path)
+	}
+}
+
+func TestPathAllowedResolvesSymlinks(t *testing.T) {
+	root, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	allowed := filepath.Join(root, "conf.d")
+	outside := filepath.Join(root, "secret")
+	require.NoError(t, os.Mkdir(allowed, 0755))
+	require.NoError(t, os.Mkdir(outside, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(allowed, "share.conf"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(outside, "shadow"), nil, 0600))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "shadow"), filepath.Join(allowed, "escape")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(allowed, "escape.d")))
+	require.NoError(t, os.Symlink(filepath.Join(allowed, "share.conf"), filepath.Join(allowed, "alias")))
+
+	b := NewBroker(common.Log, &Config{AllowedPaths: []string{allowed}})
+
+	tests := []struct {
+		path    string
+		allowed bool
+	}{
+		{filepath.Join(allowed, "share.conf"), true},
+		{filepath.Join(allowed, "new.conf"), true},
+		{filepath.Join(allowed, "alias"), true},
+		{filepath.Join(allowed, "escape"), false},
+		{filepath.Join(allowed, "escape.d", "new.conf"), false},
+		{filepath.Join(outside, "shadow"), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, b.PathAllowed(tt.path), tt.path)
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	valid := []string{"/etc/krb5.conf", "/etc/exports.d", "/srv/*/exports", "/etc/samba/[a-z]*.conf"}
+	for _, pattern := range valid {
+		assert.NoError(t, ValidatePathPattern(pattern), pattern)
+	}
+
+	invalid := []string{
+		"etc/krb5.conf",   // relative
+		"/etc/../root/x",  // not clean
+		"/etc/samba/",     // trailing slash
+		"/etc",            // too broad
+		"/",               // too broad
+		"/*/exports",      // glob in first element
+		"/etc/samba/[a-z", // bad pattern
+	}
+	for _, pattern := range invalid {
+		assert.Error(t, ValidatePathPattern(pattern), pattern)
+	}
+
+	assert.NoError(t, DefaultConfig().Validate())
+}
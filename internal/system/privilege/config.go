@@ -4,29 +4,181 @@
 
 package privilege
 
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	rodentCfg "github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
 // Config contains configuration for the privilege operations module
 type Config struct {
-	// AllowedPaths defines paths that can be accessed with sudo
+	// AllowedPaths defines paths that can be accessed with sudo. Each entry
+	// covers itself, everything below it and suffixed backups of it, and may
+	// contain glob patterns as understood by filepath.Match.
 	AllowedPaths []string `yaml:"allowed_paths" json:"allowed_paths"`
-	
+
 	// AllowedCommands defines commands that can be executed with sudo
 	AllowedCommands []string `yaml:"allowed_commands" json:"allowed_commands"`
+
+	// AllowedSubcommands limits the first argument of the listed commands.
+	// Commands not listed here accept any arguments.
+	AllowedSubcommands map[string][]string `yaml:"allowed_subcommands" json:"allowed_subcommands"`
+
+	// Enforce rejects operations outside the policy. It is on by default;
+	// when false they are allowed and only audited, which helps to find an
+	// operation a custom setup needs. The root helper always enforces.
+	Enforce bool `yaml:"enforce" json:"enforce"`
 }
 
-// DefaultConfig returns a default configuration
+// DefaultConfig returns a default configuration covering the privileged
+// operations Rodent performs
 func DefaultConfig() *Config {
 	return &Config{
 		AllowedPaths: []string{
 			"/etc/samba/smb.conf",
 			"/etc/samba/conf.d",
+			"/var/spool/samba",
+			"/var/lib/samba/printers",
 			"/etc/hosts",
 			"/etc/resolv.conf",
 			"/etc/krb5.conf",
+			"/etc/nsswitch.conf",
+			"/etc/sssd",
+			"/etc/netplan",
+			"/etc/systemd/network",
+			"/etc/systemd/resolved.conf.d",
+			"/etc/modprobe.d/zfs-arc.conf",
+			"/etc/dremio/tls",
+			"/run/systemd/network",
+			"/run/systemd/resolve",
 		},
 		AllowedCommands: []string{
-			"smbcontrol",
-			"smbstatus",
-			"systemctl",
+			// Storage
+			"zfs", "zpool", "lsblk", "smartctl", "lsscsi", "sg_ses", "udevadm",
+			// SMB and domain membership
+			"smbcontrol", "smbstatus", "testparm", "pdbedit",
+			"net", "adcli", "wbinfo", "kinit", "kdestroy", "sssctl",
+			// Services and containers
+			"systemctl", "journalctl", "docker",
+			// Networking
+			"ip", "netplan", "networkctl", "resolvectl",
+			// System
+			"hostnamectl", "timedatectl", "localectl", "shutdown",
+			"dmidecode", "lscpu", "systemd-detect-virt", "uname", "uptime", "last",
+			"useradd", "userdel", "usermod", "groupadd", "groupdel",
+			"gpasswd", "groups", "passwd", "which", "getfacl",
+			// File access, limited to AllowedPaths; chown, chmod and
+			// setfacl may also be user data on ZFS datasets. Files are
+			// read, written and restored from snapshots by the root
+			// helper itself.
+			"mkdir", "chown", "chmod", "setfacl",
 		},
+		AllowedSubcommands: map[string][]string{
+			"systemctl": {
+				"start", "stop", "restart", "reload", "enable", "disable",
+				"status", "show", "is-enabled", "is-active", "is-failed",
+				"is-system-running", "daemon-reload", "list-units", "list-unit-files",
+			},
+			"net":     {"ads"},
+			"udevadm": {"info", "settle", "trigger", "monitor"},
+			"docker":  {"exec", "inspect", "ps", "logs", "kill"},
+			"ip":      {"addr", "address", "link", "route"},
+		},
+	}
+}
+
+// LoadConfig returns the default policy extended with the paths and enforce
+// mode from the Rodent configuration. The result is validated.
+func LoadConfig() (*Config, error) {
+	cfg := rodentCfg.GetConfig()
+
+	config := DefaultConfig()
+	config.Enforce = cfg.Privilege.Enforce
+	for _, path := range cfg.Privilege.AllowedPaths {
+		if !slices.Contains(config.AllowedPaths, path) {
+			config.AllowedPaths = append(config.AllowedPaths, path)
+		}
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// HelperConfig returns the policy the root helper enforces: the default
+// policy, always enforced, extended with the allowed paths of a Rodent
+// configuration file only root can change. The Rodent user may be able to
+// edit its own configuration, which must not widen what it can do as root.
+func HelperConfig() *Config {
+	config := DefaultConfig()
+	config.Enforce = true
+
+	info, err := os.Stat(rodentCfg.GetLoadedConfigPath())
+	if err != nil {
+		return config
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Uid != 0 || info.Mode().Perm()&0o022 != 0 {
+		return config
+	}
+
+	for _, path := range rodentCfg.GetConfig().Privilege.AllowedPaths {
+		if ValidatePathPattern(path) == nil && !slices.Contains(config.AllowedPaths, path) {
+			config.AllowedPaths = append(config.AllowedPaths, path)
+		}
+	}
+	return config
+}
+
+// Validate checks that every allowed path is a valid pattern and every
+// allowed command is a bare command name
+func (c *Config) Validate() error {
+	for _, path := range c.AllowedPaths {
+		if err := ValidatePathPattern(path); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range c.AllowedCommands {
+		if name == "" || strings.ContainsAny(name, "/ ") {
+			return errors.New(errors.ConfigInvalid, "Allowed command must be a bare command name").
+				WithMetadata("command", name)
+		}
+	}
+	return nil
+}
+
+// ValidatePathPattern checks an allowed path entry. It must be an absolute,
+// clean path at least two levels deep, must not use a glob in its first
+// element, and must be a valid filepath.Match pattern.
+func ValidatePathPattern(pattern string) error {
+	invalid := func(reason string) error {
+		return errors.New(errors.ConfigInvalid, reason).WithMetadata("path", pattern)
 	}
-}
\ No newline at end of file
+
+	if !filepath.IsAbs(pattern) {
+		return invalid("Allowed path must be absolute")
+	}
+	if filepath.Clean(pattern) != pattern {
+		return invalid("Allowed path must be clean, without '..', '.' or trailing slashes")
+	}
+
+	elements := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	if len(elements) < 2 {
+		return invalid("Allowed path is too broad; it must be at least two levels deep")
+	}
+	if strings.ContainsAny(elements[0], "*?[") {
+		return invalid("Allowed path must not use a glob in its first element")
+	}
+
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return invalid("Allowed path is not a valid glob pattern")
+	}
+	return nil
+}
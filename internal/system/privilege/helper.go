@@ -0,0 +1,327 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// helperCommands run through the root helper instead of directly with sudo.
+// sudoers cannot check their arguments: the paths they change, the accounts
+// and groups they touch, or the options that make ip run a batch file.
+var helperCommands = []string{
+	"mkdir", "chown", "chmod", "setfacl",
+	"useradd", "userdel", "usermod", "groupadd", "groupdel", "gpasswd", "passwd", "pdbedit",
+	"ip",
+}
+
+// helperArgs are the arguments that run the root helper
+var helperArgs = []string{"privilege", "helper"}
+
+// ErrHelperNotExist is returned by the helper's exists operation for a path
+// that does not exist
+var ErrHelperNotExist = stderrors.New("path does not exist")
+
+// Exit codes of the helper, besides those of the commands it runs
+const (
+	// HelperExitNotExist is the exit code for ErrHelperNotExist, like test -e
+	HelperExitNotExist = 1
+
+	// HelperExitFailed is the exit code for a denied or failed operation
+	HelperExitFailed = 126
+)
+
+// helperExecutable returns the path of the running Rodent executable, which
+// is also the root helper, or "" if it cannot be found
+func helperExecutable() string {
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	return path
+}
+
+// SudoCommand implements command.SudoRouter: helper commands run as
+// 'rodent privilege helper run <command> <args>', others directly
+func (b *Broker) SudoCommand(cmd string, args []string) (string, []string) {
+	if b.helperPath == "" || !slices.Contains(helperCommands, filepath.Base(cmd)) {
+		return cmd, args
+	}
+	routed := append(slices.Clone(helperArgs), "run", cmd)
+	return b.helperPath, append(routed, args...)
+}
+
+// helperCommand returns the sudo arguments that run a helper operation
+func (b *Broker) helperCommand(op string, args ...string) ([]string, error) {
+	if b.helperPath == "" {
+		return nil, errors.New(errors.OperationFailed, "Rodent executable not found for the root helper")
+	}
+	sudoArgs := append([]string{b.helperPath}, helperArgs...)
+	return append(append(sudoArgs, op), args...), nil
+}
+
+// Helper performs privileged operations as root on Rodent's behalf. It is run
+// through sudo as 'rodent privilege helper <op> ...', and the generated
+// sudoers policy allows it rather than the commands whose arguments sudoers
+// cannot check. It checks every operation against its own policy, always
+// enforced, and reads and writes files itself so that a symlink cannot send
+// a write elsewhere.
+type Helper struct {
+	broker *Broker
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// NewHelper creates a helper enforcing config, with file contents read from
+// stdin and written to stdout
+func NewHelper(logger logger.Logger, config *Config, stdin io.Reader, stdout io.Writer) *Helper {
+	config.Enforce = true
+	return &Helper{
+		broker: NewBroker(logger, config),
+		stdin:  stdin,
+		stdout: stdout,
+	}
+}
+
+// Run performs a helper operation:
+//
+//	run <command> [args...]   run a helper command, replacing the helper
+//	read <path>               write the file to stdout
+//	write <path> [mode]       replace the file with stdin, setting mode (octal)
+//	append <path>             append stdin to the file
+//	remove <path>             remove the file if it exists
+//	copy <src> <dst>          copy a file, keeping its mode and owner
+//	exists <path>             return ErrHelperNotExist if there is no file
+//	restore <snapshot-root> <share-root> <path> [overwrite]
+//	                          copy path from a snapshot directory onto a share
+func (h *Helper) Run(op string, args []string) error {
+	if os.Geteuid() != 0 {
+		return errors.New(errors.PermissionDenied, "The privilege helper must run as root")
+	}
+
+	want := map[string]int{"read": 1, "append": 1, "remove": 1, "exists": 1, "copy": 2}
+	if n, ok := want[op]; ok && len(args) != n {
+		return fmt.Errorf("%s takes %d path(s)", op, n)
+	}
+
+	switch op {
+	case "run":
+		if len(args) == 0 {
+			return fmt.Errorf("run takes a command")
+		}
+		return h.exec(args[0], args[1:])
+	case "read":
+		return h.read(args[0])
+	case "write":
+		if len(args) != 1 && len(args) != 2 {
+			return fmt.Errorf("write takes a path and an optional mode")
+		}
+		mode := ""
+		if len(args) == 2 {
+			mode = args[1]
+		}
+		return h.write(args[0], mode)
+	case "append":
+		return h.append(args[0])
+	case "remove":
+		return h.remove(args[0])
+	case "copy":
+		return h.copy(args[0], args[1])
+	case "exists":
+		return h.exists(args[0])
+	case "restore":
+		if len(args) != 3 && (len(args) != 4 || args[3] != "overwrite") {
+			return fmt.Errorf("restore takes a snapshot root, a share root, a path and an optional overwrite")
+		}
+		return h.restore(args[0], args[1], args[2], len(args) == 4)
+	}
+	return fmt.Errorf("unknown helper operation %q", op)
+}
+
+// exec replaces the helper with a helper command the policy allows, found
+// only in the system binary directories
+func (h *Helper) exec(cmd string, args []string) error {
+	name := filepath.Base(cmd)
+	if !slices.Contains(helperCommands, name) {
+		return errors.New(errors.PermissionDenied, "Command not run by the privilege helper").
+			WithMetadata("command", cmd)
+	}
+	if err := h.broker.CheckCommand(cmd, args); err != nil {
+		h.broker.denied("helper_run", cmd, args, err)
+		return err
+	}
+
+	path := cmd
+	if !strings.Contains(cmd, "/") {
+		path = ""
+		for _, dir := range systemBinDirs {
+			candidate := filepath.Join(dir, name)
+			if info, err := os.Stat(candidate); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0 {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return fmt.Errorf("%s not found in %s", name, strings.Join(systemBinDirs, ":"))
+		}
+	}
+
+	env := []string{"PATH=" + strings.Join(systemBinDirs, ":")}
+	return syscall.Exec(path, append([]string{name}, args...), env)
+}
+
+// checkPath denies a path outside AllowedPaths
+func (h *Helper) checkPath(operation, path string) error {
+	if h.broker.PathAllowed(path) {
+		return nil
+	}
+	err := errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
+		WithMetadata("path", path)
+	h.broker.denied(operation, path, nil, err)
+	return err
+}
+
+// open opens an allowed path without following a symlink in its last
+// element. Allowed paths live in root-owned directories, where only root can
+// place a link in any other element.
+func (h *Helper) open(operation, path string, flag int, perm os.FileMode) (*os.File, error) {
+	if err := h.checkPath(operation, path); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(path, flag|syscall.O_NOFOLLOW, perm)
+}
+
+func (h *Helper) read(path string) error {
+	f, err := h.open("read_file", path, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h.stdout, f)
+	return err
+}
+
+func (h *Helper) write(path, mode string) error {
+	var perm os.FileMode
+	if mode != "" {
+		bits, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || bits&^0o1777 != 0 {
+			return errors.New(errors.PermissionDenied, "File mode not allowed for privileged access").
+				WithMetadata("mode", mode)
+		}
+		perm = os.FileMode(bits & 0o777)
+		if bits&0o1000 != 0 {
+			perm |= os.ModeSticky
+		}
+	}
+
+	f, err := h.open("write_file", path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if mode != "" {
+		// Set the mode before the contents, so a secret is never readable
+		if err := f.Chmod(perm); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if _, err := io.Copy(f, h.stdin); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *Helper) append(path string) error {
+	f, err := h.open("append_file", path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, h.stdin); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (h *Helper) remove(path string) error {
+	if err := h.checkPath("delete_file", path); err != nil {
+		return err
+	}
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return os.Remove(path)
+}
+
+func (h *Helper) copy(src, dst string) error {
+	in, err := h.open("copy_file", src, os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", src)
+	}
+
+	out, err := h.open("copy_file", dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	// Keep the source's owner and mode, so a backup of a secret stays one
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		if err := out.Chown(int(stat.Uid), int(stat.Gid)); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := out.Chmod(info.Mode().Perm()); err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (h *Helper) exists(path string) error {
+	if err := h.checkPath("check_exists", path); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrHelperNotExist
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
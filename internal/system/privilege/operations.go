@@ -29,6 +29,10 @@ type FileOperations interface {
 	
 	// Exists checks if a privileged file exists
 	Exists(ctx context.Context, path string) (bool, error)
+
+	// RestorePath copies a path from a snapshot directory onto a share,
+	// replacing what is there when overwrite is set
+	RestorePath(ctx context.Context, snapshotRoot, shareRoot, path string, overwrite bool) error
 	
 	// ExecuteCommand runs a command with elevated privileges
 	ExecuteCommand(ctx context.Context, command string, args ...string) ([]byte, error)
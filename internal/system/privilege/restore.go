@@ -0,0 +1,288 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// snapshotDir is the path element under which ZFS exposes snapshots
+const snapshotDir = "/.zfs/snapshot/"
+
+// ErrRestoreExists is returned by the helper's restore operation when the
+// path exists in the share and is not to be overwritten
+var ErrRestoreExists = stderrors.New("path exists in the share")
+
+// checkRestore checks the paths of a restore: the share root is user data,
+// the snapshot root is a snapshot directory in it or another dataset, and the
+// path is relative and stays below both
+func (h *Helper) checkRestore(snapshotRoot, shareRoot, rel string) error {
+	var err error
+	switch {
+	case !filepath.IsAbs(shareRoot) || filepath.Clean(shareRoot) != shareRoot ||
+		!h.broker.DataPath(shareRoot):
+		err = errors.New(errors.PermissionDenied, "Share is not on a ZFS dataset").
+			WithMetadata("path", shareRoot)
+	case !filepath.IsAbs(snapshotRoot) || filepath.Clean(snapshotRoot) != snapshotRoot ||
+		!strings.Contains(snapshotRoot+"/", snapshotDir) || !h.broker.DataPath(snapshotRoot):
+		err = errors.New(errors.PermissionDenied, "Source is not a ZFS snapshot directory").
+			WithMetadata("path", snapshotRoot)
+	case rel == "" || filepath.IsAbs(rel) || filepath.Clean(rel) != rel ||
+		rel == "." || rel == ".." || strings.HasPrefix(rel, "../"):
+		err = errors.New(errors.PermissionDenied, "Restore path must stay within the share").
+			WithMetadata("path", rel)
+	}
+	if err != nil {
+		h.broker.denied("restore_path", shareRoot, []string{snapshotRoot, rel}, err)
+	}
+	return err
+}
+
+// restore copies rel from a snapshot directory onto the share, like
+// cp -a -T --remove-destination: owners, modes, extended attributes (and so
+// ACLs) and times are kept, and a file replaces the one in the share rather
+// than being written through it. Both trees are walked with openat and
+// O_NOFOLLOW from their roots, so a symlink placed in the share while the
+// restore runs cannot lead it elsewhere.
+func (h *Helper) restore(snapshotRoot, shareRoot, rel string, overwrite bool) error {
+	if err := h.checkRestore(snapshotRoot, shareRoot, rel); err != nil {
+		return err
+	}
+
+	srcDir, err := openDirBelow(snapshotRoot, filepath.Dir(rel))
+	if err != nil {
+		return err
+	}
+	defer unix.Close(srcDir)
+	dstDir, err := openDirBelow(shareRoot, filepath.Dir(rel))
+	if err != nil {
+		return err
+	}
+	defer unix.Close(dstDir)
+
+	name := filepath.Base(rel)
+	if !overwrite {
+		var st unix.Stat_t
+		if err := unix.Fstatat(dstDir, name, &st, unix.AT_SYMLINK_NOFOLLOW); err == nil {
+			return ErrRestoreExists
+		} else if err != unix.ENOENT {
+			return &os.PathError{Op: "stat", Path: rel, Err: err}
+		}
+	}
+	return copyAt(srcDir, dstDir, name)
+}
+
+// openDirBelow opens the directory rel below root, refusing symlinks in root
+// as its last element and anywhere in rel
+func openDirBelow(root, rel string) (int, error) {
+	const flags = unix.O_RDONLY | unix.O_DIRECTORY | unix.O_NOFOLLOW | unix.O_CLOEXEC
+
+	fd, err := unix.Open(root, flags, 0)
+	if err != nil {
+		return -1, &os.PathError{Op: "open", Path: root, Err: err}
+	}
+	if rel == "." {
+		return fd, nil
+	}
+	for _, name := range strings.Split(rel, "/") {
+		next, err := unix.Openat(fd, name, flags, 0)
+		unix.Close(fd)
+		if err != nil {
+			return -1, &os.PathError{Op: "open", Path: filepath.Join(root, rel), Err: err}
+		}
+		fd = next
+	}
+	return fd, nil
+}
+
+// copyAt copies the entry name of srcDir to dstDir
+func copyAt(srcDir, dstDir int, name string) error {
+	var st unix.Stat_t
+	if err := unix.Fstatat(srcDir, name, &st, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFREG:
+		return copyFileAt(srcDir, dstDir, name, &st)
+	case unix.S_IFDIR:
+		return copyDirAt(srcDir, dstDir, name, &st)
+	case unix.S_IFLNK:
+		return copyLinkAt(srcDir, dstDir, name, &st)
+	}
+	return fmt.Errorf("%s is not a file, directory or symlink", name)
+}
+
+// clearAt removes the entry name of dir so another can take its place. A
+// directory is kept when keepDir is set, and is otherwise an error, as cp
+// does not replace a directory with a file.
+func clearAt(dir int, name string, keepDir bool) (dirExists bool, err error) {
+	var st unix.Stat_t
+	if err := unix.Fstatat(dir, name, &st, unix.AT_SYMLINK_NOFOLLOW); err == unix.ENOENT {
+		return false, nil
+	} else if err != nil {
+		return false, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+	if st.Mode&unix.S_IFMT == unix.S_IFDIR {
+		if keepDir {
+			return true, nil
+		}
+		return false, fmt.Errorf("cannot replace directory %s with a non-directory", name)
+	}
+	if err := unix.Unlinkat(dir, name, 0); err != nil {
+		return false, &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return false, nil
+}
+
+func copyFileAt(srcDir, dstDir int, name string, st *unix.Stat_t) error {
+	in, err := unix.Openat(srcDir, name, unix.O_RDONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	src := os.NewFile(uintptr(in), name)
+	defer src.Close()
+
+	if _, err := clearAt(dstDir, name, false); err != nil {
+		return err
+	}
+	out, err := unix.Openat(dstDir, name,
+		unix.O_WRONLY|unix.O_CREAT|unix.O_EXCL|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return &os.PathError{Op: "create", Path: name, Err: err}
+	}
+	dst := os.NewFile(uintptr(out), name)
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	if err := copyMetadata(in, out, st); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return dst.Close()
+}
+
+func copyDirAt(srcDir, dstDir int, name string, st *unix.Stat_t) error {
+	const flags = unix.O_RDONLY | unix.O_DIRECTORY | unix.O_NOFOLLOW | unix.O_CLOEXEC
+
+	in, err := unix.Openat(srcDir, name, flags, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	src := os.NewFile(uintptr(in), name)
+	defer src.Close()
+
+	exists, err := clearAt(dstDir, name, true)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := unix.Mkdirat(dstDir, name, 0o700); err != nil {
+			return &os.PathError{Op: "mkdir", Path: name, Err: err}
+		}
+	}
+	out, err := unix.Openat(dstDir, name, flags, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	dst := os.NewFile(uintptr(out), name)
+	defer dst.Close()
+
+	entries, err := src.Readdirnames(-1)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := copyAt(in, out, entry); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	// Last, so the copies made in it do not change its times
+	if err := copyMetadata(in, out, st); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+func copyLinkAt(srcDir, dstDir int, name string, st *unix.Stat_t) error {
+	buf := make([]byte, unix.PathMax)
+	n, err := unix.Readlinkat(srcDir, name, buf)
+	if err != nil {
+		return &os.PathError{Op: "readlink", Path: name, Err: err}
+	}
+
+	if _, err := clearAt(dstDir, name, false); err != nil {
+		return err
+	}
+	if err := unix.Symlinkat(string(buf[:n]), dstDir, name); err != nil {
+		return &os.PathError{Op: "symlink", Path: name, Err: err}
+	}
+	if err := unix.Fchownat(dstDir, name, int(st.Uid), int(st.Gid), unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "chown", Path: name, Err: err}
+	}
+	times := []unix.Timespec{st.Atim, st.Mtim}
+	if err := unix.UtimesNanoAt(dstDir, name, times, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return &os.PathError{Op: "utimes", Path: name, Err: err}
+	}
+	return nil
+}
+
+// copyMetadata gives out the owner, mode, extended attributes and times of
+// in. The owner is set first, as chown clears setuid and setgid bits.
+func copyMetadata(in, out int, st *unix.Stat_t) error {
+	if err := unix.Fchown(out, int(st.Uid), int(st.Gid)); err != nil {
+		return err
+	}
+	if err := unix.Fchmod(out, st.Mode&0o7777); err != nil {
+		return err
+	}
+	if err := copyXattrs(in, out); err != nil {
+		return err
+	}
+	return unix.Futimes(out, []unix.Timeval{
+		unix.NsecToTimeval(unix.TimespecToNsec(st.Atim)),
+		unix.NsecToTimeval(unix.TimespecToNsec(st.Mtim)),
+	})
+}
+
+// copyXattrs copies the extended attributes of in, including POSIX ACLs, to
+// out. File systems without extended attributes are skipped.
+func copyXattrs(in, out int) error {
+	size, err := unix.Flistxattr(in, nil)
+	if err == unix.ENOTSUP || size == 0 {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	list := make([]byte, size)
+	if size, err = unix.Flistxattr(in, list); err != nil {
+		return err
+	}
+
+	for _, attr := range strings.Split(strings.TrimRight(string(list[:size]), "\x00"), "\x00") {
+		n, err := unix.Fgetxattr(in, attr, nil)
+		if err != nil {
+			return err
+		}
+		value := make([]byte, n)
+		if n, err = unix.Fgetxattr(in, attr, value); err != nil {
+			return err
+		}
+		if err := unix.Fsetxattr(out, attr, value[:n], 0); err != nil && err != unix.ENOTSUP {
+			return fmt.Errorf("set %s: %w", attr, err)
+		}
+	}
+	return nil
+}
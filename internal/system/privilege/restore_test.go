@@ -0,0 +1,126 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restoreFixture lays out a share on a fake dataset with a snapshot of it
+// and a directory outside it
+func restoreFixture(t *testing.T) (h *Helper, snapshot, share, outside string) {
+	t.Helper()
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	share = filepath.Join(dir, "share")
+	snapshot = filepath.Join(share, ".zfs", "snapshot", "daily")
+	outside = filepath.Join(dir, "outside")
+
+	b := NewBroker(common.Log, DefaultConfig())
+	b.readMounts = func() ([]byte, error) {
+		return []byte("tank/share " + share + " zfs rw 0 0\n"), nil
+	}
+
+	write := func(path, data string, mode os.FileMode) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), mode))
+		require.NoError(t, os.Chmod(path, mode))
+	}
+	write(filepath.Join(snapshot, "docs", "a.txt"), "snapshot version", 0o640)
+	write(filepath.Join(snapshot, "docs", "sub", "b.txt"), "nested", 0o600)
+	require.NoError(t, os.Symlink("a.txt", filepath.Join(snapshot, "docs", "link")))
+
+	write(filepath.Join(share, "docs", "a.txt"), "current version", 0o644)
+	require.NoError(t, os.MkdirAll(outside, 0o755))
+	// A directory in the share swapped for a symlink leading out of it
+	require.NoError(t, os.Symlink(outside, filepath.Join(share, "docs", "sub")))
+
+	return &Helper{broker: b}, snapshot, share, outside
+}
+
+func TestHelperRestore(t *testing.T) {
+	h, snapshot, share, outside := restoreFixture(t)
+
+	require.NoError(t, h.restore(snapshot, share, "docs", true))
+
+	data, err := os.ReadFile(filepath.Join(share, "docs", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "snapshot version", string(data))
+	info, err := os.Stat(filepath.Join(share, "docs", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o640), info.Mode().Perm())
+
+	info, err = os.Lstat(filepath.Join(share, "docs", "sub"))
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "the symlink is replaced by the directory")
+	data, err = os.ReadFile(filepath.Join(share, "docs", "sub", "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "nested", string(data))
+
+	link, err := os.Readlink(filepath.Join(share, "docs", "link"))
+	require.NoError(t, err)
+	assert.Equal(t, "a.txt", link)
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "nothing is written through the symlink")
+}
+
+func TestHelperRestoreRefusesSymlinkedParent(t *testing.T) {
+	h, snapshot, share, outside := restoreFixture(t)
+
+	assert.Error(t, h.restore(snapshot, share, "docs/sub/b.txt", true))
+
+	entries, err := os.ReadDir(outside)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHelperRestoreKeepsExisting(t *testing.T) {
+	h, snapshot, share, _ := restoreFixture(t)
+
+	assert.ErrorIs(t, h.restore(snapshot, share, "docs/a.txt", false), ErrRestoreExists)
+	data, err := os.ReadFile(filepath.Join(share, "docs", "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "current version", string(data))
+}
+
+func TestHelperCheckRestore(t *testing.T) {
+	h, snapshot, share, outside := restoreFixture(t)
+
+	tests := []struct {
+		name     string
+		snapshot string
+		share    string
+		path     string
+		allowed  bool
+	}{
+		{"file in share", snapshot, share, "docs/a.txt", true},
+		{"share not on a dataset", snapshot, outside, "docs/a.txt", false},
+		{"share not clean", snapshot, share + "/docs/..", "docs/a.txt", false},
+		{"source not a snapshot", share, share, "docs/a.txt", false},
+		{"source outside datasets", filepath.Join(outside, ".zfs", "snapshot", "x"), share, "docs/a.txt", false},
+		{"parent traversal", snapshot, share, "../outside", false},
+		{"absolute path", snapshot, share, "/etc/shadow", false},
+		{"unclean path", snapshot, share, "docs/../../x", false},
+		{"share root itself", snapshot, share, ".", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := h.checkRestore(tt.snapshot, tt.share, tt.path)
+			if tt.allowed {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
@@ -5,26 +5,28 @@
 package privilege
 
 import (
+	"bytes"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io/fs"
-	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/pkg/errors"
 )
 
-// SudoFileOperations implements FileOperations using sudo
+// SudoFileOperations implements FileOperations using sudo. Every operation is
+// also checked against the installed Broker's policy and audited by it.
 type SudoFileOperations struct {
-	logger        logger.Logger
-	executor      *command.CommandExecutor
-	allowedPaths  []string // Paths that are allowed to be accessed
-	allowedRegexp []*regexp.Regexp // Regexp patterns for allowed paths
+	logger          logger.Logger
+	executor        *command.CommandExecutor
+	allowedPaths    []string // Paths that are allowed to be accessed
+	allowedPatterns []string // Cleaned patterns for allowed paths
 }
 
 // NewSudoFileOperations creates a new SudoFileOperations instance
@@ -33,238 +35,288 @@ func NewSudoFileOperations(
 	executor *command.CommandExecutor,
 	allowedPaths []string,
 ) *SudoFileOperations {
-	// Compile regexp patterns for path validation
-	allowedRegexp := make([]*regexp.Regexp, 0, len(allowedPaths))
-	for _, path := range allowedPaths {
-		// Allow exact path, subdirectories, and backup files with extensions/timestamps
-		re := regexp.MustCompile("^" + regexp.QuoteMeta(path) + "($|/.*|\\..*)")
-		allowedRegexp = append(allowedRegexp, re)
-	}
-	
 	return &SudoFileOperations{
-		logger:        logger,
-		executor:      executor,
-		allowedPaths:  allowedPaths,
-		allowedRegexp: allowedRegexp,
+		logger:       logger,
+		executor:     executor,
+		allowedPaths: allowedPaths,
+		// Allow exact path, subdirectories, and backup files with extensions/timestamps
+		allowedPatterns: cleanPathPatterns(allowedPaths),
 	}
 }
 
 // isPathAllowed checks if a path is allowed to be accessed with sudo
 func (s *SudoFileOperations) isPathAllowed(path string) bool {
-	// Always check with absolute path
-	absPath, err := filepath.Abs(path)
-	if err != nil {
+	return matchesPath(s.allowedPatterns, path)
+}
+
+// authorizePath checks path against this instance's allowed paths, which are
+// always enforced, and then against the broker policy
+func (s *SudoFileOperations) authorizePath(operation, path string) error {
+	b := currentBroker()
+
+	if !s.isPathAllowed(path) {
+		err := errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
+			WithMetadata("path", path)
+		b.denied(operation, path, nil, err)
+		return err
+	}
+
+	if b.PathAllowed(path) {
+		return nil
+	}
+	return b.enforce(operation, path, nil,
+		errors.New(errors.PermissionDenied, "Path not allowed by privilege policy").
+			WithMetadata("path", path))
+}
+
+// audit records a completed file operation with the broker. It is deferred
+// with a pointer to the operation's named error result.
+func (s *SudoFileOperations) audit(operation, path string, start time.Time, err *error) {
+	currentBroker().audit(operation, path, nil, time.Since(start), *err)
+}
+
+// skipInDryRun reports whether a file change must be skipped because dry-run
+// mode is enabled, logging the change that would have been made
+func (s *SudoFileOperations) skipInDryRun(operation, path string, fields ...any) bool {
+	if !command.DryRun() {
 		return false
 	}
-	
-	for _, re := range s.allowedRegexp {
-		if re.MatchString(absPath) {
-			return true
+	s.logger.Info("Dry run: would modify file",
+		append([]any{"operation", operation, "path", path}, fields...)...)
+	return true
+}
+
+// runHelper runs a root helper operation through sudo, with input on its
+// stdin, and returns its output
+func (s *SudoFileOperations) runHelper(
+	ctx context.Context,
+	input []byte,
+	op string,
+	args ...string,
+) ([]byte, error) {
+	sudoArgs, err := currentBroker().helperCommand(op, args...)
+	if err != nil {
+		return nil, err
+	}
+	if err := command.CheckSudo(ctx, sudoArgs[0], sudoArgs[1:len(helperArgs)+2]...); err != nil {
+		return nil, err
+	}
+
+	cmd, release := command.GroupCommand(ctx, "sudo", sudoArgs...)
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	release()
+	if err != nil {
+		if sudoErr := command.SudoFailure(sudoArgs[0], stderr.String()); sudoErr != nil {
+			return nil, sudoErr
 		}
+		return stderr.Bytes(), err
 	}
-	return false
+	return stdout.Bytes(), nil
+}
+
+// helperError wraps the failure of a helper operation
+func helperError(err error, operation, path string, output []byte) *errors.RodentError {
+	return errors.Wrap(err, errors.OperationFailed).
+		WithMetadata("operation", operation).
+		WithMetadata("path", path).
+		WithMetadata("output", string(output))
 }
 
 // ReadFile implements FileOperations.ReadFile
-func (s *SudoFileOperations) ReadFile(ctx context.Context, path string) ([]byte, error) {
+func (s *SudoFileOperations) ReadFile(ctx context.Context, path string) (output []byte, err error) {
 	// Validate path
-	if !s.isPathAllowed(path) {
-		return nil, errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
-			WithMetadata("path", path)
+	if err := s.authorizePath("read_file", path); err != nil {
+		return nil, err
 	}
-	
-	// Use cat with sudo to read the file
-	cmd := exec.CommandContext(ctx, "sudo", "cat", path)
-	output, err := cmd.Output()
+	defer s.audit("read_file", path, time.Now(), &err)
+
+	output, err = s.runHelper(ctx, nil, "read", path)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return nil, errors.Wrap(err, errors.OperationFailed).
-				WithMetadata("operation", "read_file").
-				WithMetadata("path", path).
-				WithMetadata("stderr", string(exitErr.Stderr))
-		}
-		return nil, errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "read_file").
-			WithMetadata("path", path)
+		return nil, helperError(err, "read_file", path, output)
 	}
-	
 	return output, nil
 }
 
-// WriteFile implements FileOperations.WriteFile
-func (s *SudoFileOperations) WriteFile(ctx context.Context, path string, data []byte, perm fs.FileMode) error {
+// WriteFile implements FileOperations.WriteFile. The file keeps its mode
+// when perm is zero.
+func (s *SudoFileOperations) WriteFile(
+	ctx context.Context,
+	path string,
+	data []byte,
+	perm fs.FileMode,
+) (err error) {
 	// Validate path
-	if !s.isPathAllowed(path) {
-		return errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
-			WithMetadata("path", path)
+	if err := s.authorizePath("write_file", path); err != nil {
+		return err
 	}
-	
-	// Create temporary file
-	tmpFile, err := os.CreateTemp("", "rodent-sudo-*")
-	if err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "create_temp_file")
-	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
-	
-	// Write data to temporary file
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "write_temp_file")
-	}
-	tmpFile.Close()
-	
-	// Use sudo with cp to write to the destination
-	cmd := exec.CommandContext(ctx, "sudo", "cp", tmpPath, path)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "write_file").
-			WithMetadata("path", path).
-			WithMetadata("output", string(output))
+	defer s.audit("write_file", path, time.Now(), &err)
+
+	if s.skipInDryRun("write_file", path, "bytes", len(data), "mode", fmt.Sprintf("%o", perm)) {
+		return nil
 	}
-	
-	// Set permissions if specified
+
+	args := []string{path}
 	if perm != 0 {
-		permStr := fmt.Sprintf("%o", perm)
-		chmodCmd := exec.CommandContext(ctx, "sudo", "chmod", permStr, path)
-		if output, err := chmodCmd.CombinedOutput(); err != nil {
-			return errors.Wrap(err, errors.OperationFailed).
-				WithMetadata("operation", "chmod").
-				WithMetadata("path", path).
-				WithMetadata("permissions", permStr).
-				WithMetadata("output", string(output))
-		}
+		args = append(args, fmt.Sprintf("%o", perm))
+	}
+	if output, err := s.runHelper(ctx, data, "write", args...); err != nil {
+		return helperError(err, "write_file", path, output)
 	}
-	
 	return nil
 }
 
 // AppendFile implements FileOperations.AppendFile
-func (s *SudoFileOperations) AppendFile(ctx context.Context, path string, data []byte) error {
+func (s *SudoFileOperations) AppendFile(ctx context.Context, path string, data []byte) (err error) {
 	// Validate path
-	if !s.isPathAllowed(path) {
-		return errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
-			WithMetadata("path", path)
+	if err := s.authorizePath("append_file", path); err != nil {
+		return err
 	}
-	
-	// Create temporary file with the data to append
-	tmpFile, err := os.CreateTemp("", "rodent-sudo-append-*")
-	if err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "create_temp_file")
-	}
-	tmpPath := tmpFile.Name()
-	defer os.Remove(tmpPath)
-	
-	// Write append data to temporary file
-	if _, err := tmpFile.Write(data); err != nil {
-		tmpFile.Close()
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "write_temp_file")
-	}
-	tmpFile.Close()
-	
-	// Use tee with append flag to append to the destination via sudo
-	cmd := exec.CommandContext(ctx, "sudo", "tee", "-a", path)
-	cmd.Stdin, err = os.Open(tmpPath)
-	if err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "open_temp_file").
-			WithMetadata("path", tmpPath)
-	}
-	
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "append_file").
-			WithMetadata("path", path).
-			WithMetadata("output", string(output))
+	defer s.audit("append_file", path, time.Now(), &err)
+
+	if s.skipInDryRun("append_file", path, "bytes", len(data)) {
+		return nil
+	}
+
+	if output, err := s.runHelper(ctx, data, "append", path); err != nil {
+		return helperError(err, "append_file", path, output)
 	}
-	
 	return nil
 }
 
 // DeleteFile implements FileOperations.DeleteFile
-func (s *SudoFileOperations) DeleteFile(ctx context.Context, path string) error {
+func (s *SudoFileOperations) DeleteFile(ctx context.Context, path string) (err error) {
 	// Validate path
-	if !s.isPathAllowed(path) {
-		return errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
-			WithMetadata("path", path)
+	if err := s.authorizePath("delete_file", path); err != nil {
+		return err
 	}
-	
-	// Use sudo with rm to delete the file
-	cmd := exec.CommandContext(ctx, "sudo", "rm", "-f", path)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "delete_file").
-			WithMetadata("path", path).
-			WithMetadata("output", string(output))
+	defer s.audit("delete_file", path, time.Now(), &err)
+
+	if s.skipInDryRun("delete_file", path) {
+		return nil
+	}
+
+	if output, err := s.runHelper(ctx, nil, "remove", path); err != nil {
+		return helperError(err, "delete_file", path, output)
 	}
-	
 	return nil
 }
 
-// CopyFile implements FileOperations.CopyFile
-func (s *SudoFileOperations) CopyFile(ctx context.Context, src, dst string) error {
-	// Validate destination path (source could be a regular file)
-	if !s.isPathAllowed(dst) {
-		return errors.New(errors.PermissionDenied, "Destination path not allowed for privileged access").
-			WithMetadata("path", dst)
-	}
-	
-	// Use sudo with cp to copy the file
-	cmd := exec.CommandContext(ctx, "sudo", "cp", src, dst)
-	if output, err := cmd.CombinedOutput(); err != nil {
-		return errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "copy_file").
-			WithMetadata("src", src).
-			WithMetadata("dst", dst).
-			WithMetadata("output", string(output))
+// CopyFile implements FileOperations.CopyFile. The copy keeps the owner and
+// mode of src, which must be allowed as well.
+func (s *SudoFileOperations) CopyFile(ctx context.Context, src, dst string) (err error) {
+	// Validate both paths
+	if err := s.authorizePath("copy_file", src); err != nil {
+		return err
+	}
+	if err := s.authorizePath("copy_file", dst); err != nil {
+		return err
+	}
+	defer s.audit("copy_file", dst, time.Now(), &err)
+
+	if s.skipInDryRun("copy_file", dst, "src", src) {
+		return nil
+	}
+
+	if output, err := s.runHelper(ctx, nil, "copy", src, dst); err != nil {
+		return helperError(err, "copy_file", dst, output).WithMetadata("src", src)
 	}
-	
 	return nil
 }
 
 // Exists implements FileOperations.Exists
 func (s *SudoFileOperations) Exists(ctx context.Context, path string) (bool, error) {
 	// Validate path
-	if !s.isPathAllowed(path) {
-		return false, errors.New(errors.PermissionDenied, "Path not allowed for privileged access").
-			WithMetadata("path", path)
+	if err := s.authorizePath("check_exists", path); err != nil {
+		return false, err
 	}
-	
-	// Use sudo with test to check if file exists
-	cmd := exec.CommandContext(ctx, "sudo", "test", "-e", path)
-	if err := cmd.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
-			// File does not exist (exit code 1)
+
+	output, err := s.runHelper(ctx, nil, "exists", path)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if stderrors.As(err, &exitErr) && exitErr.ExitCode() == HelperExitNotExist {
 			return false, nil
 		}
-		// Some other error occurred
-		return false, errors.Wrap(err, errors.OperationFailed).
-			WithMetadata("operation", "check_exists").
-			WithMetadata("path", path)
+		return false, helperError(err, "check_exists", path, output)
 	}
-	
 	return true, nil
 }
 
-// ExecuteCommand implements FileOperations.ExecuteCommand
-func (s *SudoFileOperations) ExecuteCommand(ctx context.Context, command string, args ...string) ([]byte, error) {
-	// Prepend sudo to the command
-	sudoArgs := append([]string{command}, args...)
-	cmd := exec.CommandContext(ctx, "sudo", sudoArgs...)
-	
+// RestorePath implements FileOperations.RestorePath. The root helper walks
+// both trees itself without following symlinks, so both roots must be on
+// ZFS datasets rather than in the allow-list.
+func (s *SudoFileOperations) RestorePath(
+	ctx context.Context,
+	snapshotRoot, shareRoot, path string,
+	overwrite bool,
+) (err error) {
+	b := currentBroker()
+	for _, root := range []string{snapshotRoot, shareRoot} {
+		if !b.DataPath(root) {
+			if err := b.enforce("restore_path", root, nil,
+				errors.New(errors.PermissionDenied, "Path is not on a ZFS dataset").
+					WithMetadata("path", root)); err != nil {
+				return err
+			}
+		}
+	}
+	target := filepath.Join(shareRoot, path)
+	defer s.audit("restore_path", target, time.Now(), &err)
+
+	if s.skipInDryRun("restore_path", target, "src", filepath.Join(snapshotRoot, path)) {
+		return nil
+	}
+
+	args := []string{snapshotRoot, shareRoot, path}
+	if overwrite {
+		args = append(args, "overwrite")
+	}
+	if output, err := s.runHelper(ctx, nil, "restore", args...); err != nil {
+		return helperError(err, "restore_path", target, output).
+			WithMetadata("src", filepath.Join(snapshotRoot, path))
+	}
+	return nil
+}
+
+// ExecuteCommand implements FileOperations.ExecuteCommand. The command must be
+// allowed by the broker policy, and runs through the root helper when it is
+// one of the helper commands.
+func (s *SudoFileOperations) ExecuteCommand(
+	ctx context.Context,
+	name string,
+	args ...string,
+) ([]byte, error) {
+	b := currentBroker()
+	if err := b.Authorize(name, args); err != nil {
+		return nil, err
+	}
+	if command.SkipInDryRun(s.logger, name, args) {
+		return nil, nil
+	}
+
+	sudoName, sudoArgs := b.SudoCommand(name, args)
+	if err := command.CheckSudo(ctx, sudoName, sudoArgs[:len(sudoArgs)-len(args)]...); err != nil {
+		return nil, err
+	}
+	cmd, release := command.GroupCommand(ctx, "sudo", append([]string{sudoName}, sudoArgs...)...)
+
 	// Execute the command
+	start := time.Now()
 	output, err := cmd.CombinedOutput()
+	release()
+	b.Audit(name, args, time.Since(start), err)
 	if err != nil {
 		return output, errors.Wrap(err, errors.OperationFailed).
 			WithMetadata("operation", "execute_command").
-			WithMetadata("command", command).
-			WithMetadata("args", strings.Join(args, " ")).
+			WithMetadata("command", name).
+			WithMetadata("args", strings.Join(command.RedactArgs(args), " ")).
 			WithMetadata("output", string(output))
 	}
-	
+
 	return output, nil
-}
\ No newline at end of file
+}
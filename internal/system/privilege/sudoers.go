@@ -0,0 +1,91 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package privilege
+
+import (
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Sudoers renders a sudoers policy that lets user run the commands in the
+// config and nothing else. Commands are resolved with lookPath (normally
+// exec.LookPath); commands that are not installed are left out.
+//
+// Commands whose arguments sudoers cannot check, such as file, ownership,
+// account and ip commands, are not allowed directly. Rodent runs them through
+// the root helper, 'helper privilege helper', which checks them against the
+// policy itself; helper is the path of the Rodent executable. Other commands
+// are limited to their allowed subcommands, if any.
+func (c *Config) Sudoers(user, helper string, lookPath func(string) (string, error)) string {
+	if lookPath == nil {
+		lookPath = exec.LookPath
+	}
+
+	var commands []string
+	for _, name := range c.AllowedCommands {
+		if slices.Contains(helperCommands, name) {
+			continue
+		}
+		path, err := lookPath(name)
+		if err != nil {
+			continue
+		}
+
+		if subcommands, ok := c.AllowedSubcommands[name]; ok {
+			for _, sub := range subcommands {
+				commands = append(commands, path+" "+sub, path+" "+sub+" *")
+			}
+			continue
+		}
+
+		// A command without arguments in sudoers allows any arguments
+		commands = append(commands, path)
+	}
+
+	var helpers []string
+	if helper != "" {
+		helpers = append(helpers, helper+" "+strings.Join(helperArgs, " ")+" *")
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by 'rodent privilege sudoers'; install as /etc/sudoers.d/rodent\n")
+	b.WriteString("# and check with 'visudo -c -f <file>' before use.\n\n")
+	writeCmndAlias(&b, "RODENT_COMMANDS", commands)
+	writeCmndAlias(&b, "RODENT_HELPER", helpers)
+
+	var aliases []string
+	if len(commands) > 0 {
+		aliases = append(aliases, "RODENT_COMMANDS")
+	}
+	if len(helpers) > 0 {
+		aliases = append(aliases, "RODENT_HELPER")
+	}
+	if len(aliases) > 0 {
+		fmt.Fprintf(&b, "%s ALL=(ALL) NOPASSWD: %s\n\n", user, strings.Join(aliases, ", "))
+	}
+
+	fmt.Fprintf(&b, "Defaults:%s !requiretty\n", user)
+	return b.String()
+}
+
+// writeCmndAlias writes a Cmnd_Alias with one command per line
+func writeCmndAlias(b *strings.Builder, name string, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Fprintf(b, "Cmnd_Alias %s = \\\n", name)
+	for i, entry := range entries {
+		// Characters special to sudoers must be escaped in arguments
+		entry = strings.NewReplacer(",", "\\,", ":", "\\:", "=", "\\=").Replace(entry)
+		if i < len(entries)-1 {
+			fmt.Fprintf(b, "    %s, \\\n", entry)
+		} else {
+			fmt.Fprintf(b, "    %s\n\n", entry)
+		}
+	}
+}
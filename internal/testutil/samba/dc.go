@@ -0,0 +1,176 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package samba provides disposable Samba environments for integration
+// tests: an AD domain controller in a container and an smbd instance with
+// its own configuration, state and port, so domain and SMB share flows can
+// be tested without a production-like host.
+package samba
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/services/domain"
+)
+
+const (
+	// DCEnv enables the domain controller harness. Joining a domain rewrites
+	// the host's Kerberos and NSS configuration, so it is opt-in.
+	DCEnv = "RODENT_TEST_SAMBA_DC"
+
+	// DCImageEnv overrides DefaultDCImage
+	DCImageEnv = "RODENT_TEST_SAMBA_DC_IMAGE"
+
+	// DefaultDCImage is the image of the self-hosted AD DC
+	DefaultDCImage = "diegogslomp/samba-ad-dc"
+
+	// dcReadyTimeout bounds the provisioning of a new domain
+	dcReadyTimeout = 3 * time.Minute
+)
+
+// DCConfig describes the domain a DC provisions; empty fields take test
+// defaults
+type DCConfig struct {
+	Realm         string // TEST.RODENT.INTERNAL by default
+	Domain        string // NetBIOS name, the first label of the realm by default
+	AdminPassword string
+	Image         string // DCImageEnv or DefaultDCImage by default
+}
+
+// DC is a Samba AD domain controller in a disposable container
+type DC struct {
+	Container     string
+	Address       string
+	Realm         string
+	Domain        string
+	AdminUser     string
+	AdminPassword string
+
+	t *testing.T
+}
+
+// StartDC starts a domain controller and waits until it serves LDAPS. The
+// container is removed when the test ends. The test is skipped unless
+// DCEnv is set and docker is available.
+func StartDC(t *testing.T, cfg DCConfig) *DC {
+	t.Helper()
+	if os.Getenv(DCEnv) == "" {
+		t.Skipf("set %s=1 to run tests against a disposable AD DC", DCEnv)
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		t.Skipf("docker is not available: %v", err)
+	}
+
+	if cfg.Realm == "" {
+		cfg.Realm = "TEST.RODENT.INTERNAL"
+	}
+	if cfg.Domain == "" {
+		cfg.Domain, _, _ = strings.Cut(cfg.Realm, ".")
+	}
+	if cfg.AdminPassword == "" {
+		cfg.AdminPassword = "Rodent-Test-1"
+	}
+	if cfg.Image == "" {
+		cfg.Image = os.Getenv(DCImageEnv)
+	}
+	if cfg.Image == "" {
+		cfg.Image = DefaultDCImage
+	}
+
+	dc := &DC{
+		Container:     fmt.Sprintf("rodent-test-dc-%d", time.Now().UnixNano()),
+		Realm:         strings.ToUpper(cfg.Realm),
+		Domain:        strings.ToUpper(cfg.Domain),
+		AdminUser:     "Administrator",
+		AdminPassword: cfg.AdminPassword,
+		t:             t,
+	}
+
+	out, err := exec.Command("docker", "run", "-d", "--privileged",
+		"--name", dc.Container,
+		"--hostname", "dc1",
+		"-e", "REALM="+dc.Realm,
+		"-e", "DOMAIN="+dc.Domain,
+		"-e", "ADMIN_PASS="+dc.AdminPassword,
+		"-e", "DNS_FORWARDER=127.0.0.11",
+		"-e", "INTERFACE=eth0",
+		cfg.Image).CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to start DC container: %v: %s", err, out)
+	}
+	t.Cleanup(dc.remove)
+
+	out, err = exec.Command("docker", "inspect", "-f",
+		"{{range .NetworkSettings.Networks}}{{.IPAddress}}{{end}}", dc.Container).Output()
+	if err != nil {
+		t.Fatalf("failed to inspect DC container: %v", err)
+	}
+	dc.Address = strings.TrimSpace(string(out))
+	if dc.Address == "" {
+		t.Fatalf("DC container %s has no address", dc.Container)
+	}
+
+	dc.waitReady()
+	t.Logf("Started AD DC %s for %s at %s", dc.Container, dc.Realm, dc.Address)
+	return dc
+}
+
+// waitReady waits for the DC's LDAPS port, which opens once the domain is
+// provisioned
+func (dc *DC) waitReady() {
+	dc.t.Helper()
+	addr := net.JoinHostPort(dc.Address, "636")
+	deadline := time.Now().Add(dcReadyTimeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, 2*time.Second)
+		if err == nil {
+			conn.Close()
+			return
+		}
+		time.Sleep(2 * time.Second)
+	}
+	logs, _ := exec.Command("docker", "logs", "--tail", "50", dc.Container).CombinedOutput()
+	dc.t.Fatalf("DC did not serve LDAPS within %s:\n%s", dcReadyTimeout, logs)
+}
+
+// Exec runs a command in the DC container and returns its combined output
+func (dc *DC) Exec(args ...string) ([]byte, error) {
+	out, err := exec.Command("docker", append([]string{"exec", dc.Container}, args...)...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// CreateUser creates a domain user, removed with the DC
+func (dc *DC) CreateUser(name, password string) {
+	dc.t.Helper()
+	if _, err := dc.Exec("samba-tool", "user", "create", name, password); err != nil {
+		dc.t.Fatalf("failed to create domain user: %v", err)
+	}
+}
+
+// DomainConfig returns the configuration that joins this host to the DC
+func (dc *DC) DomainConfig() *domain.DomainConfig {
+	return &domain.DomainConfig{
+		Realm:         dc.Realm,
+		DCServers:     []string{dc.Address},
+		AdminUser:     dc.AdminUser,
+		AdminPassword: dc.AdminPassword,
+		DCWaitTimeout: 30 * time.Second,
+	}
+}
+
+// remove removes the container and its volumes
+func (dc *DC) remove() {
+	if out, err := exec.Command("docker", "rm", "-f", "-v", dc.Container).CombinedOutput(); err != nil {
+		dc.t.Logf("Warning: failed to remove DC container %s: %v: %s", dc.Container, err, out)
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package samba
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+// smbdReadyTimeout bounds the start of an smbd instance
+const smbdReadyTimeout = 30 * time.Second
+
+// SMBD is an smbd instance with its own smb.conf, state and port, so share
+// flows can run without touching the host's Samba configuration
+type SMBD struct {
+	Dir       string // Holds the configuration and state of the instance
+	ConfPath  string // smb.conf of the instance
+	SharesDir string // Rodent's shares configuration directory
+	Port      int
+
+	t   *testing.T
+	cmd *exec.Cmd
+}
+
+// StartSMBD starts an smbd instance listening on a free loopback port and
+// stops it when the test ends. The test is skipped unless smbd is installed
+// and can run as root: smbd needs root, and the manager reloads it through
+// sudo smbcontrol.
+func StartSMBD(t *testing.T) *SMBD {
+	t.Helper()
+	smbd, err := exec.LookPath("smbd")
+	if err != nil {
+		t.Skip("smbd is not installed")
+	}
+	if os.Geteuid() != 0 {
+		t.Skip("smbd tests need root")
+	}
+	if err := exec.Command("sudo", "-n", "true").Run(); err != nil {
+		t.Skipf("smbcontrol reloads need sudo: %v", err)
+	}
+
+	s := &SMBD{Dir: t.TempDir(), t: t}
+	s.ConfPath = filepath.Join(s.Dir, "smb.conf")
+	s.SharesDir = filepath.Join(s.Dir, "shares")
+	for _, dir := range []string{"pid", "lock", "state", "cache", "private", "ncalrpc", s.SharesDir} {
+		if err := os.MkdirAll(filepath.Join(s.Dir, dir), 0755); err != nil {
+			t.Fatalf("failed to create smbd directory: %v", err)
+		}
+	}
+	s.Port = freePort(t)
+
+	var conf strings.Builder
+	conf.WriteString("[global]\n")
+	for key, value := range s.Parameters() {
+		fmt.Fprintf(&conf, "    %s = %s\n", key, value)
+	}
+	if err := os.WriteFile(s.ConfPath, []byte(conf.String()), 0644); err != nil {
+		t.Fatalf("failed to write smb.conf: %v", err)
+	}
+
+	s.cmd = exec.Command(smbd, "--foreground", "--no-process-group", "--configfile="+s.ConfPath)
+	s.cmd.Stdout, s.cmd.Stderr = io.Discard, io.Discard
+	if err := s.cmd.Start(); err != nil {
+		t.Fatalf("failed to start smbd: %v", err)
+	}
+	t.Cleanup(s.stop)
+
+	s.waitReady()
+	t.Logf("Started smbd on port %d with %s", s.Port, s.ConfPath)
+	return s
+}
+
+// Parameters returns the global parameters that keep the instance apart
+// from the host's smbd. Global configurations applied to the instance must
+// carry them.
+func (s *SMBD) Parameters() map[string]string {
+	return map[string]string{
+		"pid directory":        filepath.Join(s.Dir, "pid"),
+		"lock directory":       filepath.Join(s.Dir, "lock"),
+		"state directory":      filepath.Join(s.Dir, "state"),
+		"cache directory":      filepath.Join(s.Dir, "cache"),
+		"private dir":          filepath.Join(s.Dir, "private"),
+		"ncalrpc dir":          filepath.Join(s.Dir, "ncalrpc"),
+		"log file":             filepath.Join(s.Dir, "log.smbd"),
+		"smb ports":            strconv.Itoa(s.Port),
+		"interfaces":           "lo",
+		"bind interfaces only": "yes",
+		"disable netbios":      "yes",
+		"load printers":        "no",
+	}
+}
+
+// Manager returns an SMB manager writing the instance's smb.conf and shares
+// configuration, with a global configuration applied that keeps the
+// instance's parameters
+func (s *SMBD) Manager(t *testing.T) *smb.Manager {
+	t.Helper()
+	l, err := logger.NewTag(logger.Config{LogLevel: "debug"}, "smb-test")
+	if err != nil {
+		t.Fatalf("failed to create logger: %v", err)
+	}
+
+	manager, err := smb.NewManager(l, command.NewCommandExecutor(true), localFileOps{})
+	if err != nil {
+		t.Fatalf("failed to create SMB manager: %v", err)
+	}
+	manager.UseConfigPaths(s.ConfPath, s.SharesDir)
+
+	global := smb.NewSMBGlobalConfig()
+	for key, value := range s.Parameters() {
+		global.CustomParameters[key] = value
+	}
+	if _, err := manager.UpdateGlobalConfig(context.Background(), global); err != nil {
+		t.Fatalf("failed to apply global configuration: %v", err)
+	}
+	return manager
+}
+
+// Running reports whether smbd is still serving its port
+func (s *SMBD) Running() bool {
+	conn, err := net.DialTimeout("tcp", s.address(), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// Testparm returns the instance's configuration as smbd reads it, or the
+// section of one share
+func (s *SMBD) Testparm(share string) ([]byte, error) {
+	args := []string{"-s", s.ConfPath}
+	if share != "" {
+		args = append(args, "--section-name="+share)
+	}
+	out, err := exec.Command("testparm", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("testparm: %v", err)
+	}
+	return out, nil
+}
+
+func (s *SMBD) address() string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(s.Port))
+}
+
+func (s *SMBD) waitReady() {
+	s.t.Helper()
+	deadline := time.Now().Add(smbdReadyTimeout)
+	for time.Now().Before(deadline) {
+		if s.Running() {
+			return
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	logs, _ := os.ReadFile(filepath.Join(s.Dir, "log.smbd"))
+	s.t.Fatalf("smbd did not listen on port %d within %s:\n%s", s.Port, smbdReadyTimeout, logs)
+}
+
+func (s *SMBD) stop() {
+	if s.cmd.Process == nil {
+		return
+	}
+	_ = s.cmd.Process.Kill()
+	_ = s.cmd.Wait()
+}
+
+// freePort returns a loopback port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// localFileOps implements privilege.FileOperations without sudo, since the
+// instance's files are in the test's temporary directory
+type localFileOps struct{}
+
+func (localFileOps) ReadFile(_ context.Context, path string) ([]byte, error) {
+	return os.ReadFile(path)
+}
+
+func (localFileOps) WriteFile(_ context.Context, path string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(path, data, perm)
+}
+
+func (localFileOps) AppendFile(_ context.Context, path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(data)
+	return err
+}
+
+func (localFileOps) DeleteFile(_ context.Context, path string) error {
+	return os.Remove(path)
+}
+
+func (localFileOps) CopyFile(_ context.Context, src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
+
+func (localFileOps) Exists(_ context.Context, path string) (bool, error) {
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (localFileOps) RestorePath(ctx context.Context, snapshotRoot, shareRoot, path string, _ bool) error {
+	src, dst := filepath.Join(snapshotRoot, path), filepath.Join(shareRoot, path)
+	return exec.CommandContext(ctx, "cp", "-a", "-T", "--remove-destination", src, dst).Run()
+}
+
+func (localFileOps) ExecuteCommand(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput()
+}
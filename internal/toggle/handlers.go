@@ -61,8 +61,9 @@ func RegisterAllHandlers() {
 
 	genexec := generalCmd.NewCommandExecutor(true)
 	// Create and register AD handler for gRPC using the shared client
-	adHandler, err := adHandlers.NewADHandler()
-	if err != nil {
+	if !cfg.Features.Domain {
+		l.Info("Domain feature is disabled, skipping AD gRPC handlers")
+	} else if adHandler, err := adHandlers.NewADHandler(); err != nil {
 		l.Error("Failed to create AD handler", "error", err)
 	} else {
 		adHandlers.RegisterADGRPCHandlers(adHandler)
@@ -84,8 +85,9 @@ func RegisterAllHandlers() {
 	l.Info("Registered FACL gRPC handlers")
 
 	// Create SMB managers and register SMB shares handler for gRPC
-	smbManager, err := smb.NewManager(sl, genexec, nil)
-	if err != nil {
+	if !cfg.Features.SMB {
+		l.Info("SMB feature is disabled, skipping SMB shares gRPC handlers")
+	} else if smbManager, err := smb.NewManager(sl, genexec, nil); err != nil {
 		l.Error("Failed to create SMB manager", "error", err)
 	} else {
 		smbService := smb.NewServiceManager(sl)
@@ -0,0 +1,243 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package trash keeps deleted configuration, such as share configs and
+// snapshot policies, for a retention window so a deletion can be undone.
+// Each deleted item is one JSON file in the bin's directory.
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	entryExt = ".json"
+
+	// DefaultRetention is how long deleted items are kept when the config
+	// does not say
+	DefaultRetention = 7 * 24 * time.Hour
+)
+
+// Entry is a deleted item
+type Entry struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"` // Name the item had, such as a share name or policy ID
+	DeletedAt time.Time       `json:"deleted_at"`
+	DeletedBy common.Actor    `json:"deleted_by"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Item      json.RawMessage `json:"item"`
+}
+
+// Bin holds deleted items of one kind
+type Bin struct {
+	dir       string
+	retention time.Duration
+	mu        sync.Mutex
+}
+
+// Retention returns the configured trash retention. An invalid value falls
+// back to the default; zero turns the trash off.
+func Retention() time.Duration {
+	value := config.GetConfig().Trash.Retention
+	if value == "" {
+		return DefaultRetention
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		common.Log.Warn("Invalid trash.retention, using default",
+			"value", value,
+			"default", DefaultRetention)
+		return DefaultRetention
+	}
+	return d
+}
+
+// New creates a bin keeping items in dir for retention. A bin with zero
+// retention keeps nothing.
+func New(dir string, retention time.Duration) *Bin {
+	return &Bin{dir: dir, retention: retention}
+}
+
+// Enabled reports whether the bin keeps deleted items
+func (b *Bin) Enabled() bool {
+	return b != nil && b.retention > 0
+}
+
+// Put keeps a deleted item, attributed to the actor in ctx. It does nothing
+// when the bin is disabled.
+func (b *Bin) Put(ctx context.Context, name string, item any) (*Entry, error) {
+	if !b.Enabled() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.RodentMisc).
+			WithMetadata("name", name)
+	}
+
+	now := time.Now()
+	entry := Entry{
+		ID:        common.UUID7(),
+		Name:      name,
+		DeletedAt: now,
+		DeletedBy: common.ActorFromContext(ctx),
+		ExpiresAt: now.Add(b.retention),
+		Item:      data,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.write(entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// List returns the items in the bin, newest first. Expired items are
+// purged first.
+func (b *Bin) List() ([]Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.list()
+}
+
+// Latest returns the most recently deleted item with the given name
+func (b *Bin) Latest(name string) (*Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries, err := b.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.Name == name {
+			return &e, nil
+		}
+	}
+	return nil, errors.New(errors.NotFoundError, "No deleted item with this name in the trash").
+		WithMetadata("name", name)
+}
+
+// Get returns an item by its trash ID
+func (b *Bin) Get(id string) (*Entry, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries, err := b.list()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.ID == id {
+			return &e, nil
+		}
+	}
+	return nil, errors.New(errors.NotFoundError, "Trash entry not found").
+		WithMetadata("id", id)
+}
+
+// Remove drops an item from the bin, once it has been restored
+func (b *Bin) Remove(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := os.Remove(b.path(id)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, errors.FSError).WithMetadata("id", id)
+	}
+	return nil
+}
+
+// Purge deletes the expired items and returns how many were deleted
+func (b *Bin) Purge() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	_, purged, err := b.read()
+	return purged, err
+}
+
+// list reads the items, newest first. The caller must hold mu.
+func (b *Bin) list() ([]Entry, error) {
+	entries, _, err := b.read()
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(entries, func(a, c Entry) int { return c.DeletedAt.Compare(a.DeletedAt) })
+	return entries, nil
+}
+
+// read reads the items, deleting the expired ones. Unreadable files are
+// left alone. The caller must hold mu.
+func (b *Bin) read() ([]Entry, int, error) {
+	files, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, 0, nil
+		}
+		return nil, 0, errors.Wrap(err, errors.FSError).WithMetadata("path", b.dir)
+	}
+
+	now := time.Now()
+	entries := make([]Entry, 0, len(files))
+	purged := 0
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), entryExt) {
+			continue
+		}
+		path := filepath.Join(b.dir, f.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			continue
+		}
+		if now.After(e.ExpiresAt) {
+			if err := os.Remove(path); err == nil {
+				purged++
+			}
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, purged, nil
+}
+
+// write saves an item. The caller must hold mu.
+func (b *Bin) write(e Entry) error {
+	if err := common.EnsureDir(b.dir, 0755); err != nil {
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", b.dir)
+	}
+
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.RodentMisc).WithMetadata("name", e.Name)
+	}
+
+	if err := os.WriteFile(b.path(e.ID), data, 0644); err != nil {
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", b.path(e.ID))
+	}
+	return nil
+}
+
+// path returns the file of an item
+func (b *Bin) path(id string) string {
+	return filepath.Join(b.dir, filepath.Base(id)+entryExt)
+}
@@ -0,0 +1,99 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package trash
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Path string `json:"path"`
+}
+
+func TestBin(t *testing.T) {
+	b := New(t.TempDir(), time.Hour)
+	ctx := common.WithActor(context.Background(), common.Actor{Source: "rest", User: "alice"})
+
+	first, err := b.Put(ctx, "eng", item{Path: "/tank/eng"})
+	require.NoError(t, err)
+	second, err := b.Put(ctx, "eng", item{Path: "/tank/eng2"})
+	require.NoError(t, err)
+	_, err = b.Put(ctx, "ops", item{Path: "/tank/ops"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice", first.DeletedBy.User)
+
+	entries, err := b.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "ops", entries[0].Name, "newest first")
+
+	latest, err := b.Latest("eng")
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, latest.ID)
+	var got item
+	require.NoError(t, json.Unmarshal(latest.Item, &got))
+	assert.Equal(t, "/tank/eng2", got.Path)
+
+	byID, err := b.Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "eng", byID.Name)
+
+	require.NoError(t, b.Remove(second.ID))
+	latest, err = b.Latest("eng")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, latest.ID)
+
+	_, err = b.Latest("missing")
+	assert.Error(t, err)
+	_, err = b.Get("missing")
+	assert.Error(t, err)
+}
+
+func TestBinPurgesExpired(t *testing.T) {
+	dir := t.TempDir()
+	b := New(dir, time.Hour)
+
+	e, err := b.Put(context.Background(), "old", item{})
+	require.NoError(t, err)
+	_, err = b.Put(context.Background(), "new", item{})
+	require.NoError(t, err)
+
+	// Age the first entry past its expiry
+	e.ExpiresAt = time.Now().Add(-time.Minute)
+	require.NoError(t, b.write(*e))
+
+	purged, err := b.Purge()
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	_, err = os.Stat(b.path(e.ID))
+	assert.True(t, os.IsNotExist(err))
+
+	entries, err := b.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "new", entries[0].Name)
+}
+
+func TestBinDisabled(t *testing.T) {
+	dir := t.TempDir()
+	b := New(dir, 0)
+	assert.False(t, b.Enabled())
+
+	e, err := b.Put(context.Background(), "eng", item{})
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	entries, err := b.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
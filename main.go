@@ -18,15 +18,11 @@
 package main
 
 import (
-	"fmt"
+	"os"
 
 	"github.com/stratastor/rodent/cmd"
 )
 
 func main() {
-	rootCmd := cmd.NewRootCmd()
-
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-	}
+	os.Exit(cmd.Execute())
 }
@@ -0,0 +1,224 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/ad"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// ADDCHandler provides HTTP endpoints for provisioning users and groups in
+// the self-hosted AD DC through samba-tool
+type ADDCHandler struct {
+	tool *ad.SambaTool
+}
+
+// NewADDCHandler creates a handler for the configured self-hosted DC
+func NewADDCHandler() (*ADDCHandler, error) {
+	tool, err := ad.NewSambaTool()
+	if err != nil {
+		return nil, err
+	}
+	return &ADDCHandler{tool: tool}, nil
+}
+
+// PasswordResetRequest is used for binding password reset requests
+type PasswordResetRequest struct {
+	Password           string `json:"password"             binding:"required"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
+// DCGroupRequest is used for binding group creation requests
+type DCGroupRequest struct {
+	Name        string `json:"name"        binding:"required"`
+	Description string `json:"description"`
+}
+
+// DCGroupMembersRequest is used for binding group membership changes
+type DCGroupMembersRequest struct {
+	Members []string `json:"members" binding:"required"`
+}
+
+// ListDCUsers returns the usernames of all users
+func (h *ADDCHandler) ListDCUsers(c *gin.Context) {
+	users, err := h.tool.ListUsers(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"users": users})
+}
+
+// CreateDCUser creates a user, enforcing the domain password policy
+func (h *ADDCHandler) CreateDCUser(c *gin.Context) {
+	var req ad.DCUserSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.tool.CreateUser(c.Request.Context(), &req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// GetDCUser returns a user's account details
+func (h *ADDCHandler) GetDCUser(c *gin.Context) {
+	user, err := h.tool.GetUser(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteDCUser removes a user
+func (h *ADDCHandler) DeleteDCUser(c *gin.Context) {
+	if err := h.tool.DeleteUser(c.Request.Context(), c.Param("username")); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ResetDCUserPassword sets a new password, enforcing the domain password policy
+func (h *ADDCHandler) ResetDCUserPassword(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	err := h.tool.ResetPassword(c.Request.Context(), c.Param("username"),
+		req.Password, req.MustChangePassword)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// EnableDCUser enables a user account
+func (h *ADDCHandler) EnableDCUser(c *gin.Context) {
+	if err := h.tool.SetUserEnabled(c.Request.Context(), c.Param("username"), true); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DisableDCUser disables a user account
+func (h *ADDCHandler) DisableDCUser(c *gin.Context) {
+	if err := h.tool.SetUserEnabled(c.Request.Context(), c.Param("username"), false); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// ListDCGroups returns the names of all groups
+func (h *ADDCHandler) ListDCGroups(c *gin.Context) {
+	groups, err := h.tool.ListGroups(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"groups": groups})
+}
+
+// CreateDCGroup creates a security group
+func (h *ADDCHandler) CreateDCGroup(c *gin.Context) {
+	var req DCGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.tool.CreateGroup(c.Request.Context(), req.Name, req.Description); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// DeleteDCGroup removes a group
+func (h *ADDCHandler) DeleteDCGroup(c *gin.Context) {
+	if err := h.tool.DeleteGroup(c.Request.Context(), c.Param("groupname")); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDCGroupMembers returns the members of a group
+func (h *ADDCHandler) GetDCGroupMembers(c *gin.Context) {
+	members, err := h.tool.ListGroupMembers(c.Request.Context(), c.Param("groupname"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"members": members})
+}
+
+// AddDCGroupMembers adds users or groups to a group
+func (h *ADDCHandler) AddDCGroupMembers(c *gin.Context) {
+	var req DCGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	err := h.tool.AddGroupMembers(c.Request.Context(), c.Param("groupname"), req.Members)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// RemoveDCGroupMembers removes users or groups from a group
+func (h *ADDCHandler) RemoveDCGroupMembers(c *gin.Context) {
+	var req DCGroupMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	err := h.tool.RemoveGroupMembers(c.Request.Context(), c.Param("groupname"), req.Members)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetPasswordPolicy returns the domain password policy
+func (h *ADDCHandler) GetPasswordPolicy(c *gin.Context) {
+	policy, err := h.tool.GetPasswordPolicy(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
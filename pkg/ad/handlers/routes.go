@@ -44,3 +44,30 @@ func (h *ADHandler) RegisterRoutes(router *gin.RouterGroup) {
 		computers.DELETE("/:computername", h.DeleteComputer)
 	}
 }
+
+// RegisterRoutes registers the self-hosted DC provisioning routes with the
+// given router group
+func (h *ADDCHandler) RegisterRoutes(router *gin.RouterGroup) {
+	users := router.Group("/users")
+	{
+		users.GET("", h.ListDCUsers)
+		users.POST("", h.CreateDCUser)
+		users.GET("/:username", h.GetDCUser)
+		users.DELETE("/:username", h.DeleteDCUser)
+		users.POST("/:username/password", h.ResetDCUserPassword)
+		users.POST("/:username/enable", h.EnableDCUser)
+		users.POST("/:username/disable", h.DisableDCUser)
+	}
+
+	groups := router.Group("/groups")
+	{
+		groups.GET("", h.ListDCGroups)
+		groups.POST("", h.CreateDCGroup)
+		groups.DELETE("/:groupname", h.DeleteDCGroup)
+		groups.GET("/:groupname/members", h.GetDCGroupMembers)
+		groups.POST("/:groupname/members", h.AddDCGroupMembers)
+		groups.DELETE("/:groupname/members", h.RemoveDCGroupMembers)
+	}
+
+	router.GET("/password-policy", h.GetPasswordPolicy)
+}
@@ -82,7 +82,6 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"os/exec"
 	"strings"
 	"sync"
 	"time"
@@ -1008,23 +1007,11 @@ func (c *ADClient) setPasswordViaSambaTool(username, newPassword string) error {
 	}
 
 	// Execute samba-tool user setpassword inside the container
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "docker", "exec", containerName,
-		"samba-tool", "user", "setpassword", username,
-		fmt.Sprintf("--newpassword=%s", newPassword))
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return errors.Wrap(err, errors.ADSetPasswordFailed).
-			WithMetadata("username", username).
-			WithMetadata("method", "samba-tool").
-			WithMetadata("container", containerName).
-			WithMetadata("output", string(output))
+	err := newContainerSambaTool(containerName).setPassword(context.Background(), username, newPassword, false)
+	if rerr, ok := err.(*errors.RodentError); ok {
+		return rerr.WithMetadata("container", containerName)
 	}
-
-	return nil
+	return err
 }
 
 // setUserPassword is the internal helper that modifies password via LDAP.
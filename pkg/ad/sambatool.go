@@ -0,0 +1,509 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ad
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// sambaToolTimeout bounds each samba-tool invocation in the DC container
+const sambaToolTimeout = 30 * time.Second
+
+// userAccountDisable is the ACCOUNTDISABLE flag of userAccountControl
+const userAccountDisable = 0x2
+
+var (
+	// sAMAccountName is limited to 20 characters for users
+	dcUsernameRegex  = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,19}$`)
+	dcGroupNameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 ._-]{0,63}$`)
+)
+
+// PasswordPolicy is the domain password policy of the self-hosted DC
+type PasswordPolicy struct {
+	Complexity     bool `json:"complexity"`
+	MinLength      int  `json:"min_length"`
+	HistoryLength  int  `json:"history_length"`
+	MinAgeDays     int  `json:"min_age_days"`
+	MaxAgeDays     int  `json:"max_age_days"`
+	LockoutAttempt int  `json:"lockout_threshold"`
+}
+
+// DCUser is a user account in the self-hosted DC
+type DCUser struct {
+	Username    string `json:"username"`
+	GivenName   string `json:"given_name,omitempty"`
+	Surname     string `json:"surname,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	Mail        string `json:"mail,omitempty"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// DCUserSpec describes a user to create in the self-hosted DC
+type DCUserSpec struct {
+	Username           string `json:"username"             binding:"required"`
+	Password           string `json:"password"             binding:"required"`
+	GivenName          string `json:"given_name"`
+	Surname            string `json:"surname"`
+	Mail               string `json:"mail"`
+	Description        string `json:"description"`
+	MustChangePassword bool   `json:"must_change_password"`
+}
+
+// SambaTool provisions users and groups in the self-hosted Samba AD DC by
+// running samba-tool inside the DC container. Unlike LDAP, samba-tool can set
+// passwords on Samba and applies the domain password policy itself.
+type SambaTool struct {
+	container string
+	userOU    string
+	groupOU   string
+	executor  sambaToolExecutor
+}
+
+// sambaToolExecutor runs docker with sudo; *command.CommandExecutor in use
+type sambaToolExecutor interface {
+	ExecuteWithInput(ctx context.Context, input string, cmd string, args ...string) ([]byte, error)
+}
+
+// newContainerSambaTool returns a SambaTool for the DC container
+func newContainerSambaTool(container string) *SambaTool {
+	return &SambaTool{container: container, executor: command.NewCommandExecutor(true)}
+}
+
+// NewSambaTool returns a SambaTool for the configured DC container. It fails
+// unless the self-hosted AD DC is enabled.
+func NewSambaTool() (*SambaTool, error) {
+	cfg := config.GetConfig()
+	if cfg.AD.Mode != "self-hosted" || !cfg.AD.DC.Enabled {
+		return nil, errors.New(errors.ConfigInvalid,
+			"User provisioning through samba-tool requires the self-hosted AD DC")
+	}
+	if cfg.AD.DC.ContainerName == "" {
+		return nil, errors.New(errors.ConfigInvalid, "AD DC container name not configured")
+	}
+
+	s := newContainerSambaTool(cfg.AD.DC.ContainerName)
+	s.userOU, s.groupOU = cfg.AD.UserOU, cfg.AD.GroupOU
+	return s, nil
+}
+
+// run executes samba-tool in the DC container and returns its combined output
+func (s *SambaTool) run(ctx context.Context, args ...string) (string, error) {
+	return s.runWithInput(ctx, "", args...)
+}
+
+// runWithInput executes samba-tool with input on its stdin, which is how
+// passwords are passed so they never appear in process arguments. samba-tool
+// prompts for a password it is not given and, without a terminal, reads the
+// answers from stdin.
+func (s *SambaTool) runWithInput(ctx context.Context, input string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, sambaToolTimeout)
+	defer cancel()
+
+	cmdArgs := []string{"exec"}
+	if input != "" {
+		cmdArgs = append(cmdArgs, "-i")
+	}
+	cmdArgs = append(cmdArgs, s.container, "samba-tool")
+	output, err := s.executor.ExecuteWithInput(ctx, input, "docker", append(cmdArgs, args...)...)
+	return string(output), err
+}
+
+// passwordInput answers samba-tool's new password and retype prompts
+func passwordInput(password, username string) (string, error) {
+	if strings.ContainsAny(password, "\r\n") {
+		return "", errors.New(errors.ADInvalidPassword, "password must not contain line breaks").
+			WithMetadata("username", username)
+	}
+	return password + "\n" + password + "\n", nil
+}
+
+// wrapSambaToolError converts a samba-tool failure into a structured error,
+// mapping "not found" output to the matching AD error code
+func wrapSambaToolError(err error, output string, code errors.ErrorCode) *errors.RodentError {
+	switch {
+	case strings.Contains(output, "Unable to find user"):
+		code = errors.ADUserNotFound
+	case strings.Contains(output, "Unable to find group"):
+		code = errors.ADGroupNotFound
+	}
+	return errors.Wrap(err, code).
+		WithMetadata("method", "samba-tool").
+		WithMetadata("output", strings.TrimSpace(output))
+}
+
+// GetPasswordPolicy reads the domain password settings from the DC
+func (s *SambaTool) GetPasswordPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	output, err := s.run(ctx, "domain", "passwordsettings", "show")
+	if err != nil {
+		return nil, wrapSambaToolError(err, output, errors.ADSearchFailed)
+	}
+	return parsePasswordSettings(output), nil
+}
+
+// parsePasswordSettings parses 'samba-tool domain passwordsettings show'
+func parsePasswordSettings(output string) *PasswordPolicy {
+	policy := &PasswordPolicy{}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		n, _ := strconv.Atoi(value)
+
+		switch strings.TrimSpace(key) {
+		case "Password complexity":
+			policy.Complexity = value == "on"
+		case "Minimum password length":
+			policy.MinLength = n
+		case "Password history length":
+			policy.HistoryLength = n
+		case "Minimum password age (days)":
+			policy.MinAgeDays = n
+		case "Maximum password age (days)":
+			policy.MaxAgeDays = n
+		case "Account lockout threshold (attempts)":
+			policy.LockoutAttempt = n
+		}
+	}
+	return policy
+}
+
+// validatePassword checks a password against the domain policy before it is
+// sent to the DC, so a violation is reported with a reason rather than the
+// generic samba-tool constraint error
+func (s *SambaTool) validatePassword(ctx context.Context, password, username, fullName string) error {
+	policy, err := s.GetPasswordPolicy(ctx)
+	if err != nil {
+		// Fall back to the default AD policy
+		policy = &PasswordPolicy{Complexity: true, MinLength: 7}
+	}
+
+	if len(password) < policy.MinLength {
+		return errors.New(errors.ADInvalidPassword,
+			fmt.Sprintf("password must be at least %d characters long", policy.MinLength)).
+			WithMetadata("username", username)
+	}
+	if policy.Complexity {
+		if err := ValidatePassword(password, username, fullName); err != nil {
+			return errors.Wrap(err, errors.ADInvalidPassword).
+				WithMetadata("username", username)
+		}
+	}
+	return nil
+}
+
+// ensureOU creates an OU relative to the domain DN, ignoring one that exists
+func (s *SambaTool) ensureOU(ctx context.Context, ou string) error {
+	output, err := s.run(ctx, "ou", "create", ou)
+	if err != nil && !strings.Contains(output, "already exists") {
+		return wrapSambaToolError(err, output, errors.ADCreateOUFailed).
+			WithMetadata("ou", ou)
+	}
+	return nil
+}
+
+// ListUsers returns the sAMAccountNames of all users
+func (s *SambaTool) ListUsers(ctx context.Context) ([]string, error) {
+	output, err := s.run(ctx, "user", "list")
+	if err != nil {
+		return nil, wrapSambaToolError(err, output, errors.ADSearchFailed)
+	}
+	return splitLines(output), nil
+}
+
+// GetUser returns a user's account details
+func (s *SambaTool) GetUser(ctx context.Context, username string) (*DCUser, error) {
+	if err := validateDCUsername(username); err != nil {
+		return nil, err
+	}
+
+	output, err := s.run(ctx, "user", "show", username,
+		"--attributes=sAMAccountName,givenName,sn,displayName,mail,description,userAccountControl")
+	if err != nil {
+		return nil, wrapSambaToolError(err, output, errors.ADSearchFailed).
+			WithMetadata("username", username)
+	}
+	return parseUserShow(output), nil
+}
+
+// parseUserShow parses the LDIF printed by 'samba-tool user show'
+func parseUserShow(output string) *DCUser {
+	user := &DCUser{Enabled: true}
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "sAMAccountName":
+			user.Username = value
+		case "givenName":
+			user.GivenName = value
+		case "sn":
+			user.Surname = value
+		case "displayName":
+			user.DisplayName = value
+		case "mail":
+			user.Mail = value
+		case "description":
+			user.Description = value
+		case "userAccountControl":
+			if uac, err := strconv.Atoi(value); err == nil {
+				user.Enabled = uac&userAccountDisable == 0
+			}
+		}
+	}
+	return user
+}
+
+// CreateUser creates a user after checking the password against the domain
+// policy. Users are created in the configured user OU.
+func (s *SambaTool) CreateUser(ctx context.Context, spec *DCUserSpec) error {
+	if err := validateDCUsername(spec.Username); err != nil {
+		return err
+	}
+
+	fullName := strings.TrimSpace(spec.GivenName + " " + spec.Surname)
+	if err := s.validatePassword(ctx, spec.Password, spec.Username, fullName); err != nil {
+		return err
+	}
+
+	args := []string{"user", "create"}
+	if s.userOU != "" {
+		if err := s.ensureOU(ctx, s.userOU); err != nil {
+			return err
+		}
+		args = append(args, "--userou="+s.userOU)
+	}
+	if spec.GivenName != "" {
+		args = append(args, "--given-name="+spec.GivenName)
+	}
+	if spec.Surname != "" {
+		args = append(args, "--surname="+spec.Surname)
+	}
+	if spec.Mail != "" {
+		args = append(args, "--mail-address="+spec.Mail)
+	}
+	if spec.Description != "" {
+		args = append(args, "--description="+spec.Description)
+	}
+	if spec.MustChangePassword {
+		args = append(args, "--must-change-at-next-login")
+	}
+	input, err := passwordInput(spec.Password, spec.Username)
+	if err != nil {
+		return err
+	}
+	// The password is prompted for; "--" keeps a username starting with '-'
+	// from being read as an option
+	args = append(args, "--", spec.Username)
+
+	output, err := s.runWithInput(ctx, input, args...)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADCreateUserFailed).
+			WithMetadata("username", spec.Username)
+	}
+	return nil
+}
+
+// DeleteUser removes a user
+func (s *SambaTool) DeleteUser(ctx context.Context, username string) error {
+	if err := validateDCUsername(username); err != nil {
+		return err
+	}
+
+	output, err := s.run(ctx, "user", "delete", username)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADDeleteUserFailed).
+			WithMetadata("username", username)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password after checking it against the domain policy
+func (s *SambaTool) ResetPassword(ctx context.Context, username, password string, mustChange bool) error {
+	if err := validateDCUsername(username); err != nil {
+		return err
+	}
+	if err := s.validatePassword(ctx, password, username, ""); err != nil {
+		return err
+	}
+	return s.setPassword(ctx, username, password, mustChange)
+}
+
+// setPassword sets a user's password without checking it first
+func (s *SambaTool) setPassword(ctx context.Context, username, password string, mustChange bool) error {
+	input, err := passwordInput(password, username)
+	if err != nil {
+		return err
+	}
+	args := []string{"user", "setpassword", username}
+	if mustChange {
+		args = append(args, "--must-change-at-next-login")
+	}
+
+	output, err := s.runWithInput(ctx, input, args...)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADSetPasswordFailed).
+			WithMetadata("username", username)
+	}
+	return nil
+}
+
+// SetUserEnabled enables or disables a user account
+func (s *SambaTool) SetUserEnabled(ctx context.Context, username string, enabled bool) error {
+	if err := validateDCUsername(username); err != nil {
+		return err
+	}
+
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	output, err := s.run(ctx, "user", action, username)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADEnableAccountFailed).
+			WithMetadata("username", username).
+			WithMetadata("action", action)
+	}
+	return nil
+}
+
+// ListGroups returns the names of all groups
+func (s *SambaTool) ListGroups(ctx context.Context) ([]string, error) {
+	output, err := s.run(ctx, "group", "list")
+	if err != nil {
+		return nil, wrapSambaToolError(err, output, errors.ADSearchFailed)
+	}
+	return splitLines(output), nil
+}
+
+// CreateGroup creates a security group in the configured group OU
+func (s *SambaTool) CreateGroup(ctx context.Context, name, description string) error {
+	if err := validateDCGroupName(name); err != nil {
+		return err
+	}
+
+	args := []string{"group", "add", name}
+	if s.groupOU != "" {
+		if err := s.ensureOU(ctx, s.groupOU); err != nil {
+			return err
+		}
+		args = append(args, "--groupou="+s.groupOU)
+	}
+	if description != "" {
+		args = append(args, "--description="+description)
+	}
+
+	output, err := s.run(ctx, args...)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADCreateGroupFailed).
+			WithMetadata("group", name)
+	}
+	return nil
+}
+
+// DeleteGroup removes a group
+func (s *SambaTool) DeleteGroup(ctx context.Context, name string) error {
+	if err := validateDCGroupName(name); err != nil {
+		return err
+	}
+
+	output, err := s.run(ctx, "group", "delete", name)
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADDeleteGroupFailed).
+			WithMetadata("group", name)
+	}
+	return nil
+}
+
+// ListGroupMembers returns the sAMAccountNames of a group's members
+func (s *SambaTool) ListGroupMembers(ctx context.Context, name string) ([]string, error) {
+	if err := validateDCGroupName(name); err != nil {
+		return nil, err
+	}
+
+	output, err := s.run(ctx, "group", "listmembers", name)
+	if err != nil {
+		return nil, wrapSambaToolError(err, output, errors.ADSearchFailed).
+			WithMetadata("group", name)
+	}
+	return splitLines(output), nil
+}
+
+// AddGroupMembers adds users or groups to a group
+func (s *SambaTool) AddGroupMembers(ctx context.Context, name string, members []string) error {
+	return s.updateGroupMembers(ctx, "addmembers", name, members)
+}
+
+// RemoveGroupMembers removes users or groups from a group
+func (s *SambaTool) RemoveGroupMembers(ctx context.Context, name string, members []string) error {
+	return s.updateGroupMembers(ctx, "removemembers", name, members)
+}
+
+func (s *SambaTool) updateGroupMembers(ctx context.Context, action, name string, members []string) error {
+	if err := validateDCGroupName(name); err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		return errors.New(errors.ADInvalidGroup, "at least one member is required").
+			WithMetadata("group", name)
+	}
+	for _, member := range members {
+		if err := validateDCGroupName(member); err != nil {
+			return err
+		}
+	}
+
+	output, err := s.run(ctx, "group", action, name, strings.Join(members, ","))
+	if err != nil {
+		return wrapSambaToolError(err, output, errors.ADUpdateGroupFailed).
+			WithMetadata("group", name).
+			WithMetadata("action", action)
+	}
+	return nil
+}
+
+func validateDCUsername(username string) error {
+	if !dcUsernameRegex.MatchString(username) {
+		return errors.New(errors.ADInvalidUser, "Invalid username").
+			WithMetadata("username", username)
+	}
+	return nil
+}
+
+// validateDCGroupName accepts group names and, for membership changes, user
+// names; spaces are allowed as in "Domain Admins"
+func validateDCGroupName(name string) error {
+	if !dcGroupNameRegex.MatchString(name) {
+		return errors.New(errors.ADInvalidGroup, "Invalid group or member name").
+			WithMetadata("name", name)
+	}
+	return nil
+}
+
+// splitLines returns the non-empty trimmed lines of samba-tool list output
+func splitLines(output string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
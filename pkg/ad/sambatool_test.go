@@ -0,0 +1,150 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ad
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const passwordSettingsOutput = `Password information for domain 'DC=example,DC=com'
+
+Password complexity: on
+Store plaintext passwords: off
+Password history length: 24
+Minimum password length: 10
+Minimum password age (days): 1
+Maximum password age (days): 42
+Account lockout duration (mins): 30
+Account lockout threshold (attempts): 5
+Reset account lockout after (mins): 30
+`
+
+// fakeExecutor records samba-tool runs and answers them from a table
+// keyed by the samba-tool subcommand
+type fakeExecutor struct {
+	outputs map[string]string
+	errs    map[string]error
+	inputs  []string
+	args    [][]string
+}
+
+func (f *fakeExecutor) ExecuteWithInput(ctx context.Context, input string, cmd string, args ...string) ([]byte, error) {
+	f.inputs = append(f.inputs, input)
+	f.args = append(f.args, args)
+
+	// Skip "exec [-i] <container> samba-tool"
+	i := 0
+	for i < len(args) && args[i] != "samba-tool" {
+		i++
+	}
+	key := strings.Join(args[i+1:min(i+3, len(args))], " ")
+	return []byte(f.outputs[key]), f.errs[key]
+}
+
+func TestParsePasswordSettings(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   PasswordPolicy
+	}{
+		{
+			name:   "samba-tool output",
+			output: passwordSettingsOutput,
+			want: PasswordPolicy{Complexity: true, MinLength: 10, HistoryLength: 24,
+				MinAgeDays: 1, MaxAgeDays: 42, LockoutAttempt: 5},
+		},
+		{
+			name:   "complexity off",
+			output: "Password complexity: off\nMinimum password length: 0\n",
+			want:   PasswordPolicy{},
+		},
+		{
+			name:   "unparsable values",
+			output: "Minimum password length: seven\nnot a setting\n",
+			want:   PasswordPolicy{},
+		},
+		{name: "empty", output: "", want: PasswordPolicy{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, *parsePasswordSettings(tt.output))
+		})
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  string
+		policyErr error
+		password  string
+		username  string
+		wantErr   bool
+	}{
+		{name: "meets policy", settings: passwordSettingsOutput, password: "Corr3ct-Horse"},
+		{name: "shorter than policy", settings: passwordSettingsOutput, password: "Sh0rt!x", wantErr: true},
+		{name: "not complex", settings: passwordSettingsOutput, password: "alllowercaseletters", wantErr: true},
+		{name: "contains username", settings: passwordSettingsOutput, password: "Alice-Passw0rd",
+			username: "alice", wantErr: true},
+		{name: "complexity off", settings: "Password complexity: off\nMinimum password length: 4\n",
+			password: "simple"},
+		{name: "default policy when unreadable", policyErr: fmt.Errorf("exit status 1"),
+			password: "short", wantErr: true},
+		{name: "default policy accepts", policyErr: fmt.Errorf("exit status 1"), password: "Passw0rd!"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exec := &fakeExecutor{
+				outputs: map[string]string{"domain passwordsettings": tt.settings},
+				errs:    map[string]error{"domain passwordsettings": tt.policyErr},
+			}
+			s := &SambaTool{container: "dc", executor: exec}
+
+			username := tt.username
+			if username == "" {
+				username = "bob"
+			}
+			err := s.validatePassword(context.Background(), tt.password, username, "")
+			if tt.wantErr {
+				require.Error(t, err)
+				code, _ := errors.GetCode(err)
+				assert.Equal(t, errors.ErrorCode(errors.ADInvalidPassword), code)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPasswordsPassedOnStdin(t *testing.T) {
+	exec := &fakeExecutor{outputs: map[string]string{"domain passwordsettings": passwordSettingsOutput}}
+	s := &SambaTool{container: "dc", executor: exec}
+	ctx := context.Background()
+
+	require.NoError(t, s.CreateUser(ctx, &DCUserSpec{Username: "bob", Password: "Corr3ct-Horse"}))
+	require.NoError(t, s.ResetPassword(ctx, "bob", "N3w-Battery-Staple", true))
+
+	for i, args := range exec.args {
+		assert.NotContains(t, strings.Join(args, " "), "Corr3ct-Horse")
+		assert.NotContains(t, strings.Join(args, " "), "N3w-Battery-Staple")
+		if exec.inputs[i] != "" {
+			assert.Equal(t, []string{"exec", "-i", "dc", "samba-tool"}, args[:4])
+		}
+	}
+	assert.Contains(t, exec.inputs, "Corr3ct-Horse\nCorr3ct-Horse\n")
+	assert.Contains(t, exec.inputs, "N3w-Battery-Staple\nN3w-Battery-Staple\n")
+
+	err := s.ResetPassword(ctx, "bob", "Corr3ct-Horse\nextra", false)
+	assert.Error(t, err, "a line break would answer the retype prompt")
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package consistency
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler serves consistency reports and repairs
+type APIHandler struct {
+	checker *Checker
+}
+
+// NewAPIHandler creates a consistency API handler
+func NewAPIHandler(c *Checker) *APIHandler {
+	return &APIHandler{checker: c}
+}
+
+// RegisterRoutes registers the consistency routes
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.report)
+	router.POST("/check", h.check)
+	router.POST("/repair", h.repair)
+}
+
+// report returns the last report, checking first if there is none
+func (h *APIHandler) report(c *gin.Context) {
+	report := h.checker.LastReport()
+	if report == nil {
+		report = h.checker.Check(c.Request.Context())
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// check checks now and returns the report
+func (h *APIHandler) check(c *gin.Context) {
+	c.JSON(http.StatusOK, h.checker.Check(c.Request.Context()))
+}
+
+// repair repairs the requested issues, or every repairable issue
+func (h *APIHandler) repair(c *gin.Context) {
+	var req RepairRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+			return
+		}
+	}
+
+	result, err := h.checker.Repair(c.Request.Context(), req)
+	if err != nil {
+		common.APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
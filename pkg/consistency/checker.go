@@ -0,0 +1,372 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package consistency finds references between policies, shares and disks
+// that point at objects which no longer exist: transfer policies of removed
+// snapshot policies, snapshot policies listing removed transfer policies,
+// shares on removed paths and disks discovery no longer finds. Each check
+// produces a repair plan; repairs are only made on request.
+package consistency
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/disk"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+)
+
+// SharePaths is a share subsystem, listing the path of each share by name
+type SharePaths interface {
+	SharePaths(ctx context.Context) (map[string]string, error)
+}
+
+// Checker checks the subsystems set on it for dangling references, every
+// interval and on request
+type Checker struct {
+	logger   logger.Logger
+	interval time.Duration
+
+	snapshots        atomic.Pointer[autosnapshots.Manager]
+	transferPolicies atomic.Pointer[autotransfers.Manager]
+	disks            atomic.Pointer[disk.Manager]
+	shares           atomic.Pointer[SharePaths]
+
+	mu   sync.Mutex // Serializes checks and repairs
+	last atomic.Pointer[Report]
+}
+
+// NewChecker creates a checker. An interval of zero or less only checks on
+// request.
+func NewChecker(l logger.Logger, interval time.Duration) *Checker {
+	return &Checker{logger: l, interval: interval}
+}
+
+// UseSnapshotManager checks snapshot policies and their transfer policy
+// associations
+func (c *Checker) UseSnapshotManager(m *autosnapshots.Manager) {
+	c.snapshots.Store(m)
+}
+
+// UseTransferPolicyManager checks transfer policies against their snapshot
+// policies
+func (c *Checker) UseTransferPolicyManager(m *autotransfers.Manager) {
+	c.transferPolicies.Store(m)
+}
+
+// UseDiskManager checks the disks the disk manager holds against discovery
+func (c *Checker) UseDiskManager(m *disk.Manager) {
+	c.disks.Store(m)
+}
+
+// UseShares checks that share paths exist
+func (c *Checker) UseShares(s SharePaths) {
+	c.shares.Store(&s)
+}
+
+// Run checks every interval until ctx is cancelled
+func (c *Checker) Run(ctx context.Context) {
+	if c.interval <= 0 {
+		return
+	}
+	c.logger.Info("Starting consistency checks", "interval", c.interval)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := c.Check(ctx)
+			if len(report.Issues) > 0 {
+				c.logger.Warn("Consistency check found dangling references",
+					"issues", len(report.Issues),
+					"repairable", report.Repairable)
+			}
+		}
+	}
+}
+
+// LastReport returns the report of the last check, or nil before the first
+func (c *Checker) LastReport() *Report {
+	return c.last.Load()
+}
+
+// Check checks the subsystems now and returns the report. Subsystems that
+// cannot be checked are listed in the report's errors.
+func (c *Checker) Check(ctx context.Context) *Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.check(ctx)
+}
+
+// check runs a check; the caller must hold mu
+func (c *Checker) check(ctx context.Context) *Report {
+	start := time.Now()
+	report := &Report{CheckedAt: start, Issues: []Issue{}, Errors: map[string]string{}}
+	fail := func(subsystem string, err error) {
+		err = errors.Wrap(err, errors.ConsistencyCheckFailed).WithMetadata("subsystem", subsystem)
+		report.Errors[subsystem] = err.Error()
+		c.logger.Warn("Consistency check failed", "subsystem", subsystem, "error", err)
+	}
+
+	snapshotMgr, transferMgr := c.snapshots.Load(), c.transferPolicies.Load()
+	if snapshotMgr != nil && transferMgr != nil {
+		snapshots, err := snapshotMgr.ListPolicies()
+		if err != nil {
+			fail("snapshot_policies", err)
+		}
+		transfers, terr := transferMgr.ListPolicies()
+		if terr != nil {
+			fail("transfer_policies", terr)
+		}
+		if err == nil && terr == nil {
+			report.Issues = append(report.Issues, policyIssues(snapshots, transfers)...)
+		}
+	}
+
+	if ptr := c.shares.Load(); ptr != nil {
+		paths, err := (*ptr).SharePaths(ctx)
+		if err != nil {
+			fail("shares", err)
+		} else {
+			report.Issues = append(report.Issues, shareIssues(paths, pathExists)...)
+		}
+	}
+
+	if diskMgr := c.disks.Load(); diskMgr != nil {
+		devices, err := diskMgr.StaleDevices(ctx)
+		if err != nil {
+			fail("disks", err)
+		} else {
+			report.Issues = append(report.Issues, diskIssues(devices)...)
+		}
+	}
+
+	for _, issue := range report.Issues {
+		if issue.Repair != "" {
+			report.Repairable++
+		}
+	}
+	report.Duration = time.Since(start)
+	c.last.Store(report)
+	return report
+}
+
+// Repair checks again and repairs the issues named in the request, or
+// every repairable issue when none are named. Issues that are gone or have
+// no repair are refused before any repair is made.
+func (c *Checker) Repair(ctx context.Context, req RepairRequest) (*RepairReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := c.check(ctx)
+	issues := make(map[string]Issue, len(report.Issues))
+	for _, issue := range report.Issues {
+		issues[issue.ID] = issue
+	}
+
+	var selected []Issue
+	if len(req.Issues) == 0 {
+		for _, issue := range report.Issues {
+			if issue.Repair != "" {
+				selected = append(selected, issue)
+			}
+		}
+	}
+	for _, id := range req.Issues {
+		issue, ok := issues[id]
+		if !ok {
+			return nil, errors.New(errors.ConsistencyIssueUnknown, "issue not found; it may be resolved").
+				WithMetadata("issue_id", id)
+		}
+		if issue.Repair == "" {
+			return nil, errors.New(errors.ServerRequestValidation, "issue has no repair: "+issue.Action).
+				WithMetadata("issue_id", id)
+		}
+		selected = append(selected, issue)
+	}
+
+	results := make([]RepairResult, 0, len(selected))
+	for _, issue := range selected {
+		result := RepairResult{IssueID: issue.ID, Repair: issue.Repair}
+		if err := c.repair(ctx, issue); err != nil {
+			err = errors.Wrap(err, errors.ConsistencyRepairFailed).WithMetadata("issue_id", issue.ID)
+			c.logger.Warn("Failed to repair consistency issue",
+				"issue_id", issue.ID,
+				"repair", issue.Repair,
+				"error", err)
+			result.Error = err.Error()
+		} else {
+			c.logger.Info("Repaired consistency issue",
+				"issue_id", issue.ID,
+				"repair", issue.Repair)
+		}
+		results = append(results, result)
+	}
+
+	return &RepairReport{Results: results, Report: c.check(ctx)}, nil
+}
+
+// repair makes the repair of an issue
+func (c *Checker) repair(ctx context.Context, issue Issue) error {
+	switch issue.Repair {
+	case RepairDisableTransferPolicy:
+		transferMgr := c.transferPolicies.Load()
+		if transferMgr == nil {
+			return errors.New(errors.ServerInternalError, "transfer policy manager not available")
+		}
+		return transferMgr.DisablePolicy(ctx, issue.TransferPolicyID)
+
+	case RepairRemoveTransferReference, RepairAddTransferReference:
+		snapshotMgr := c.snapshots.Load()
+		if snapshotMgr == nil {
+			return errors.New(errors.ServerInternalError, "snapshot policy manager not available")
+		}
+		if issue.Repair == RepairRemoveTransferReference {
+			return snapshotMgr.UpdateTransferPolicyAssociation(issue.SnapshotPolicyID, "", issue.TransferPolicyID)
+		}
+		return snapshotMgr.UpdateTransferPolicyAssociation("", issue.SnapshotPolicyID, issue.TransferPolicyID)
+
+	case RepairForgetDisk:
+		diskMgr := c.disks.Load()
+		if diskMgr == nil {
+			return errors.New(errors.ServerInternalError, "disk manager not available")
+		}
+		return diskMgr.ForgetDevice(issue.DeviceID)
+	}
+	return errors.New(errors.ServerInternalError, fmt.Sprintf("unknown repair %q", issue.Repair))
+}
+
+// policyIssues finds transfer policies of removed snapshot policies and
+// associations that only one side of records
+func policyIssues(
+	snapshots []autosnapshots.SnapshotPolicy,
+	transfers []autotransfers.TransferPolicy,
+) []Issue {
+	snapshotPolicies := make(map[string]autosnapshots.SnapshotPolicy, len(snapshots))
+	for _, sp := range snapshots {
+		snapshotPolicies[sp.ID] = sp
+	}
+	transferPolicies := make(map[string]autotransfers.TransferPolicy, len(transfers))
+	for _, tp := range transfers {
+		transferPolicies[tp.ID] = tp
+	}
+
+	var issues []Issue
+	for _, tp := range transfers {
+		sp, ok := snapshotPolicies[tp.SnapshotPolicyID]
+		if !ok {
+			issue := Issue{
+				ID:               string(IssueMissingSnapshotPolicy) + ":" + tp.ID,
+				Kind:             IssueMissingSnapshotPolicy,
+				Detail:           fmt.Sprintf("transfer policy %q uses snapshot policy %s, which does not exist", tp.Name, tp.SnapshotPolicyID),
+				SnapshotPolicyID: tp.SnapshotPolicyID,
+				TransferPolicyID: tp.ID,
+				Action:           "Point the transfer policy at an existing snapshot policy, or remove it",
+			}
+			if tp.Enabled {
+				issue.Repair = RepairDisableTransferPolicy
+				issue.Action = "Disable the transfer policy, whose runs fail; then point it at an existing snapshot policy, or remove it"
+			}
+			issues = append(issues, issue)
+			continue
+		}
+		if !slices.Contains(sp.TransferPolicyIDs, tp.ID) {
+			issues = append(issues, Issue{
+				ID:               string(IssueMissingTransferReference) + ":" + sp.ID + ":" + tp.ID,
+				Kind:             IssueMissingTransferReference,
+				Detail:           fmt.Sprintf("snapshot policy %q does not list transfer policy %q, which uses it", sp.Name, tp.Name),
+				SnapshotPolicyID: sp.ID,
+				TransferPolicyID: tp.ID,
+				Repair:           RepairAddTransferReference,
+				Action:           "Add the transfer policy to the snapshot policy's transfer policies, so the snapshot policy cannot be removed under it",
+			})
+		}
+	}
+
+	for _, sp := range snapshots {
+		for _, id := range sp.TransferPolicyIDs {
+			if _, ok := transferPolicies[id]; ok {
+				continue
+			}
+			issues = append(issues, Issue{
+				ID:               string(IssueGhostTransferReference) + ":" + sp.ID + ":" + id,
+				Kind:             IssueGhostTransferReference,
+				Detail:           fmt.Sprintf("snapshot policy %q lists transfer policy %s, which does not exist", sp.Name, id),
+				SnapshotPolicyID: sp.ID,
+				TransferPolicyID: id,
+				Repair:           RepairRemoveTransferReference,
+				Action:           "Remove the transfer policy from the snapshot policy's transfer policies, which keep it from being removed",
+			})
+		}
+	}
+
+	sortIssues(issues)
+	return issues
+}
+
+// shareIssues finds shares whose paths do not exist
+func shareIssues(paths map[string]string, exists func(string) bool) []Issue {
+	var issues []Issue
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		if exists(paths[name]) {
+			continue
+		}
+		issues = append(issues, Issue{
+			ID:     string(IssueMissingSharePath) + ":" + name,
+			Kind:   IssueMissingSharePath,
+			Detail: fmt.Sprintf("share %q exports %s, which does not exist", name, paths[name]),
+			Share:  name,
+			Action: "Mount or restore the dataset holding the path, point the share at the path's new location, or delete the share",
+		})
+	}
+	return issues
+}
+
+// diskIssues lists the disks discovery no longer finds
+func diskIssues(devices []disk.StaleDevice) []Issue {
+	issues := make([]Issue, 0, len(devices))
+	for _, d := range devices {
+		detail := fmt.Sprintf("disk %s is %s in the disk inventory but discovery does not find it", d.DeviceID, d.State)
+		if d.PoolName != "" {
+			detail += fmt.Sprintf("; it was a member of pool %s", d.PoolName)
+		}
+		issues = append(issues, Issue{
+			ID:       string(IssueStaleDisk) + ":" + d.DeviceID,
+			Kind:     IssueStaleDisk,
+			Detail:   detail,
+			DeviceID: d.DeviceID,
+			Repair:   RepairForgetDisk,
+			Action:   "Drop the disk from the device cache and mark it offline, as a missed removal event would have",
+		})
+	}
+	return issues
+}
+
+// sortIssues orders issues by ID, so reports of the same state compare equal
+func sortIssues(issues []Issue) {
+	slices.SortFunc(issues, func(a, b Issue) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+}
+
+// pathExists reports whether a path exists; paths that cannot be checked
+// are assumed to
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
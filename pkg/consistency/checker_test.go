@@ -0,0 +1,81 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package consistency
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/disk"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyIssues(t *testing.T) {
+	snapshots := []autosnapshots.SnapshotPolicy{
+		{ID: "sp1", Name: "hourly", TransferPolicyIDs: []string{"tp1", "tp-gone"}},
+		{ID: "sp2", Name: "daily"},
+	}
+	transfers := []autotransfers.TransferPolicy{
+		{ID: "tp1", Name: "offsite", SnapshotPolicyID: "sp1", Enabled: true},
+		{ID: "tp2", Name: "unlisted", SnapshotPolicyID: "sp2"},
+		{ID: "tp3", Name: "orphan", SnapshotPolicyID: "sp-gone", Enabled: true},
+		{ID: "tp4", Name: "disabled orphan", SnapshotPolicyID: "sp-gone"},
+	}
+
+	issues := policyIssues(snapshots, transfers)
+	require.Len(t, issues, 4)
+
+	byID := map[string]Issue{}
+	for _, issue := range issues {
+		byID[issue.ID] = issue
+	}
+
+	ghost := byID["ghost_transfer_policy_reference:sp1:tp-gone"]
+	assert.Equal(t, IssueGhostTransferReference, ghost.Kind)
+	assert.Equal(t, RepairRemoveTransferReference, ghost.Repair)
+
+	unlisted := byID["missing_transfer_policy_reference:sp2:tp2"]
+	assert.Equal(t, IssueMissingTransferReference, unlisted.Kind)
+	assert.Equal(t, RepairAddTransferReference, unlisted.Repair)
+
+	orphan := byID["transfer_policy_missing_snapshot_policy:tp3"]
+	assert.Equal(t, RepairDisableTransferPolicy, orphan.Repair)
+
+	// A disabled orphan has nothing left to repair automatically
+	disabled := byID["transfer_policy_missing_snapshot_policy:tp4"]
+	assert.Empty(t, disabled.Repair)
+	assert.NotEmpty(t, disabled.Action)
+
+	// Associations both sides record are consistent
+	consistent := []autosnapshots.SnapshotPolicy{{ID: "sp1", TransferPolicyIDs: []string{"tp1"}}}
+	assert.Empty(t, policyIssues(consistent, transfers[:1]))
+}
+
+func TestShareIssues(t *testing.T) {
+	paths := map[string]string{
+		"media": "/tank/media",
+		"gone":  "/tank/gone",
+	}
+	issues := shareIssues(paths, func(path string) bool { return path != "/tank/gone" })
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "share_path_missing:gone", issues[0].ID)
+	assert.Equal(t, "gone", issues[0].Share)
+	assert.Empty(t, issues[0].Repair)
+}
+
+func TestDiskIssues(t *testing.T) {
+	issues := diskIssues([]disk.StaleDevice{
+		{DeviceID: "wwn-1", State: types.DiskStateOnline, PoolName: "tank"},
+	})
+
+	require.Len(t, issues, 1)
+	assert.Equal(t, "disk_missing_from_discovery:wwn-1", issues[0].ID)
+	assert.Equal(t, RepairForgetDisk, issues[0].Repair)
+	assert.Contains(t, issues[0].Detail, "pool tank")
+}
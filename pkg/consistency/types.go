@@ -0,0 +1,87 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package consistency
+
+import "time"
+
+// IssueKind is the kind of a dangling reference
+type IssueKind string
+
+const (
+	// IssueMissingSnapshotPolicy is a transfer policy whose snapshot policy
+	// was removed
+	IssueMissingSnapshotPolicy IssueKind = "transfer_policy_missing_snapshot_policy"
+
+	// IssueGhostTransferReference is a snapshot policy listing a transfer
+	// policy that was removed, which keeps the snapshot policy from being
+	// deleted
+	IssueGhostTransferReference IssueKind = "ghost_transfer_policy_reference"
+
+	// IssueMissingTransferReference is a transfer policy its snapshot
+	// policy does not list, so the snapshot policy can be deleted under it
+	IssueMissingTransferReference IssueKind = "missing_transfer_policy_reference"
+
+	// IssueMissingSharePath is a share whose path no longer exists
+	IssueMissingSharePath IssueKind = "share_path_missing"
+
+	// IssueStaleDisk is a disk the disk manager holds that discovery no
+	// longer finds
+	IssueStaleDisk IssueKind = "disk_missing_from_discovery"
+)
+
+// RepairAction is what a repair does to resolve an issue
+type RepairAction string
+
+const (
+	RepairDisableTransferPolicy   RepairAction = "disable_transfer_policy"
+	RepairRemoveTransferReference RepairAction = "remove_transfer_policy_reference"
+	RepairAddTransferReference    RepairAction = "add_transfer_policy_reference"
+	RepairForgetDisk              RepairAction = "forget_disk"
+)
+
+// Issue is a reference to an object that no longer exists, with its repair
+type Issue struct {
+	ID     string    `json:"id"` // Stable across checks while the issue lasts
+	Kind   IssueKind `json:"kind"`
+	Detail string    `json:"detail"`
+
+	SnapshotPolicyID string `json:"snapshot_policy_id,omitempty"`
+	TransferPolicyID string `json:"transfer_policy_id,omitempty"`
+	Share            string `json:"share,omitempty"`
+	DeviceID         string `json:"device_id,omitempty"`
+
+	// Repair is empty when the issue needs an operator; Action says what a
+	// repair does, or what to do by hand
+	Repair RepairAction `json:"repair,omitempty"`
+	Action string       `json:"action"`
+}
+
+// Report is the outcome of a check, and the repair plan for its issues
+type Report struct {
+	CheckedAt  time.Time         `json:"checked_at"`
+	Duration   time.Duration     `json:"duration"`
+	Issues     []Issue           `json:"issues"`
+	Repairable int               `json:"repairable"`       // Issues with a repair
+	Errors     map[string]string `json:"errors,omitempty"` // Subsystems that could not be checked
+}
+
+// RepairRequest names the issues to repair; none repairs every repairable
+// issue
+type RepairRequest struct {
+	Issues []string `json:"issues"`
+}
+
+// RepairResult is the outcome of repairing one issue
+type RepairResult struct {
+	IssueID string       `json:"issue_id"`
+	Repair  RepairAction `json:"repair"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// RepairReport lists the repairs made and the check run after them
+type RepairReport struct {
+	Results []RepairResult `json:"results"`
+	Report  *Report        `json:"report"`
+}
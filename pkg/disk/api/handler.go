@@ -155,7 +155,20 @@ func (h *DiskHandler) GetDisk(c *gin.Context) {
 		return
 	}
 
-	h.sendSuccess(c, http.StatusOK, disk)
+	// A pool disk shows what its failure puts at risk
+	impact, err := h.manager.GetDiskImpact(c.Request.Context(), deviceID)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, diskDetail{PhysicalDisk: disk, Impact: impact})
+}
+
+// diskDetail is a disk with the impact of its failure
+type diskDetail struct {
+	*types.PhysicalDisk
+	Impact *disk.DiskImpact `json:"impact,omitempty"`
 }
 
 func (h *DiskHandler) TriggerDiscovery(c *gin.Context) {
@@ -770,3 +783,88 @@ func (h *DiskHandler) GetAvailableDisks(c *gin.Context) {
 		"count": len(disks),
 	})
 }
+
+// GetTemperatures returns the current disk and enclosure temperatures,
+// with their history when ?history=true
+func (h *DiskHandler) GetTemperatures(c *gin.Context) {
+	sensors := h.manager.GetTemperatures(c.Query("history") == "true")
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"sensors": sensors,
+		"count":   len(sensors),
+	})
+}
+
+// GetDiskTemperature returns the temperature history of a disk
+func (h *DiskHandler) GetDiskTemperature(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" {
+		h.sendError(c, errors.New(errors.ServerRequestValidation, "device_id is required"))
+		return
+	}
+
+	sensor, err := h.manager.GetDiskTemperature(deviceID)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, sensor)
+}
+
+// GetIgnoredDisks returns the devices excluded from discovery
+func (h *DiskHandler) GetIgnoredDisks(c *gin.Context) {
+	disks := h.manager.GetIgnoredDisks()
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"disks": disks,
+		"count": len(disks),
+	})
+}
+
+// GetReplacementSuggestions returns the disks worth replacing before they
+// fail, most urgent first
+func (h *DiskHandler) GetReplacementSuggestions(c *gin.Context) {
+	suggestions := h.manager.GetReplacementSuggestions(c.Request.Context())
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"suggestions": suggestions,
+		"count":       len(suggestions),
+	})
+}
+
+// PlanPool previews the pool a request would create from discovered disks,
+// with the issues that keep it from being created
+func (h *DiskHandler) PlanPool(c *gin.Context) {
+	var request disk.PoolPlanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.sendError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	plan, err := h.manager.PlanPool(request)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, plan)
+}
+
+// CreatePool creates a pool from discovered disks when its plan has no
+// issues
+func (h *DiskHandler) CreatePool(c *gin.Context) {
+	var request disk.PoolPlanRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		h.sendError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	plan, err := h.manager.CreatePool(c.Request.Context(), request)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusCreated, plan)
+}
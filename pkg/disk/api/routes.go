@@ -9,9 +9,13 @@ func (h *DiskHandler) RegisterRoutes(router *gin.RouterGroup) {
 	// Disk collection and resource routes
 	router.GET("/", h.GetInventory)
 	router.GET("/available", h.GetAvailableDisks)
+	router.GET("/replacements", h.GetReplacementSuggestions)
+	router.GET("/ignored", h.GetIgnoredDisks)
+	router.GET("/temperatures", h.GetTemperatures)
 	router.GET("/:device_id", h.GetDisk)
 	router.GET("/:device_id/health", h.GetDiskHealth)
 	router.GET("/:device_id/smart", h.GetSMARTData)
+	router.GET("/:device_id/temperature", h.GetDiskTemperature)
 	router.GET("/:device_id/state", h.GetDeviceState)
 	router.PUT("/:device_id/state", h.SetDeviceState)
 	router.PUT("/:device_id/tags", h.SetDiskTags)
@@ -22,6 +26,10 @@ func (h *DiskHandler) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/:device_id/quarantine", h.QuarantineDisk)
 	router.GET("/:device_id/probes/history", h.GetProbeHistory)
 
+	// Pool creation routes
+	router.POST("/pools/plan", h.PlanPool)
+	router.POST("/pools", h.CreatePool)
+
 	// Discovery routes
 	router.POST("/discovery/trigger", h.TriggerDiscovery)
 
@@ -24,6 +24,7 @@ func (m *Manager) UpdateConfig(config *types.DiskManagerConfig) error {
 		return err
 	}
 
+	m.discoverer.SetExclusions(m.configManager.Get().Discovery.Exclude)
 	m.logger.Info("configuration updated")
 
 	return nil
@@ -35,6 +36,7 @@ func (m *Manager) ReloadConfig() error {
 		return err
 	}
 
+	m.discoverer.SetExclusions(m.configManager.Get().Discovery.Exclude)
 	m.logger.Info("configuration reloaded from disk")
 
 	return nil
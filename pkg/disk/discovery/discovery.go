@@ -29,7 +29,9 @@ type Discoverer struct {
 	envDetector *system.EnvironmentDetector
 	mu          sync.RWMutex
 	lastScan    time.Time
-	deviceCache map[string]*types.PhysicalDisk // Keyed by device path
+	deviceCache map[string]*types.PhysicalDisk // Keyed by device ID
+	exclusions  types.ExclusionConfig
+	ignored     map[string]*types.IgnoredDisk // Excluded devices, keyed by device ID
 }
 
 // NewDiscoverer creates a new disk discoverer
@@ -75,6 +77,14 @@ func (d *Discoverer) DiscoverAll(ctx context.Context) ([]*types.PhysicalDisk, er
 		d.logger.Warn("udevadm not available, skipping udev enrichment")
 	}
 
+	// Collapse the paths of multipath disks into one disk each; this needs
+	// the device IDs udev gives the paths
+	devices = d.collapseMultipath(ctx, devices, blockDeviceMap)
+
+	// Leave out the devices matching the exclusion rules, such as the OS
+	// disk and USB sticks
+	devices = d.excludeDisks(devices, blockDeviceMap)
+
 	// Enrich with system usage (check for mounted partitions)
 	// This must run BEFORE pool enrichment to set SYSTEM state for boot/system disks
 	d.enrichWithSystemUsage(devices, blockDeviceMap)
@@ -98,7 +108,7 @@ func (d *Discoverer) DiscoverAll(ctx context.Context) ([]*types.PhysicalDisk, er
 	d.mu.Lock()
 	d.deviceCache = make(map[string]*types.PhysicalDisk)
 	for _, dev := range devices {
-		d.deviceCache[dev.DevicePath] = dev
+		d.deviceCache[dev.DeviceID] = dev
 	}
 	d.lastScan = time.Now()
 	d.mu.Unlock()
@@ -332,7 +342,7 @@ func (d *Discoverer) enrichWithSMART(ctx context.Context, disks []*types.Physica
 		}
 
 		// 3. Try to get SMART info
-		output, err := d.smartctl.GetInfo(ctx, disk.DevicePath)
+		output, err := d.smartctl.GetInfo(ctx, disk.SMARTDevicePath())
 		if err != nil {
 			d.logger.Debug("failed to get SMART info (may not support SMART)",
 				"device", disk.DevicePath,
@@ -366,7 +376,7 @@ func (d *Discoverer) enrichWithSMART(ctx context.Context, disks []*types.Physica
 
 		// 4. Check if self-tests are actually supported
 		if disk.SMARTAvailable {
-			canRunTests, err := d.smartctl.CanRunSelfTests(ctx, disk.DevicePath)
+			canRunTests, err := d.smartctl.CanRunSelfTests(ctx, disk.SMARTDevicePath())
 			if err != nil || !canRunTests {
 				d.logger.Debug("SMART info available but self-tests not supported",
 					"device", disk.DevicePath)
@@ -564,7 +574,7 @@ func parseUdevProperties(output string) map[string]string {
 	return props
 }
 
-// GetCachedDevices returns cached devices from last scan
+// GetCachedDevices returns cached devices from last scan, keyed by device ID
 func (d *Discoverer) GetCachedDevices() map[string]*types.PhysicalDisk {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -622,7 +632,7 @@ func (d *Discoverer) RefreshDevice(ctx context.Context, devicePath string) (*typ
 
 	// Update cache
 	d.mu.Lock()
-	d.deviceCache[devicePath] = disk
+	d.deviceCache[disk.DeviceID] = disk
 	d.mu.Unlock()
 
 	return disk, nil
@@ -0,0 +1,151 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/parsers"
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+// systemMountpoints mark the disk holding the OS
+var systemMountpoints = []string{"/", "/boot", "/boot/efi", "/efi", "[SWAP]"}
+
+// SetExclusions sets the rules for the devices discovery ignores, applied
+// from the next discovery
+func (d *Discoverer) SetExclusions(cfg types.ExclusionConfig) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.exclusions = cfg
+}
+
+// GetIgnored returns the devices the last discovery excluded, by path
+func (d *Discoverer) GetIgnored() []*types.IgnoredDisk {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	ignored := make([]*types.IgnoredDisk, 0, len(d.ignored))
+	for _, disk := range d.ignored {
+		ignored = append(ignored, disk)
+	}
+	slices.SortFunc(ignored, func(a, b *types.IgnoredDisk) int {
+		return strings.Compare(a.DevicePath, b.DevicePath)
+	})
+	return ignored
+}
+
+// excludeDisks removes the devices matching the exclusion rules from the
+// discovered disks and records them as ignored
+func (d *Discoverer) excludeDisks(
+	disks []*types.PhysicalDisk,
+	blockDeviceMap map[string]*parsers.BlockDevice,
+) []*types.PhysicalDisk {
+	d.mu.RLock()
+	cfg := d.exclusions
+	previous := d.ignored
+	d.mu.RUnlock()
+
+	kept := make([]*types.PhysicalDisk, 0, len(disks))
+	ignored := make(map[string]*types.IgnoredDisk)
+	for _, disk := range disks {
+		reason, rule, ok := matchExclusion(cfg, disk, blockDeviceMap[disk.DevicePath])
+		if !ok {
+			kept = append(kept, disk)
+			continue
+		}
+
+		entry := &types.IgnoredDisk{PhysicalDisk: disk, Reason: reason, Rule: rule, IgnoredAt: time.Now()}
+		if prev, ok := previous[disk.DeviceID]; ok {
+			entry.IgnoredAt = prev.IgnoredAt
+		} else {
+			d.logger.Info("ignoring excluded device",
+				"device", disk.DevicePath,
+				"device_id", disk.DeviceID,
+				"reason", reason,
+				"rule", rule)
+		}
+		ignored[disk.DeviceID] = entry
+	}
+
+	d.mu.Lock()
+	d.ignored = ignored
+	d.mu.Unlock()
+	return kept
+}
+
+// matchExclusion returns the first exclusion rule a disk matches; bd may be
+// nil when lsblk did not report the disk
+func matchExclusion(
+	cfg types.ExclusionConfig,
+	disk *types.PhysicalDisk,
+	bd *parsers.BlockDevice,
+) (types.ExclusionReason, string, bool) {
+	if cfg.SystemDisks && bd != nil {
+		if mountpoint := systemMountpoint(bd); mountpoint != "" {
+			return types.ExcludedSystemDisk, mountpoint, true
+		}
+	}
+
+	if cfg.USB && isUSB(disk) {
+		return types.ExcludedUSB, "", true
+	}
+
+	if wwn := normalizeWWN(disk.WWN); wwn != "" {
+		for _, excluded := range cfg.WWNs {
+			if normalizeWWN(excluded) == wwn {
+				return types.ExcludedWWN, excluded, true
+			}
+		}
+	}
+
+	names := append([]string{disk.DevicePath, disk.DeviceID, disk.Model}, disk.DevLinks...)
+	for _, pattern := range cfg.Patterns {
+		for _, name := range names {
+			if matched, _ := path.Match(pattern, name); matched && name != "" {
+				return types.ExcludedPattern, pattern, true
+			}
+		}
+	}
+
+	return "", "", false
+}
+
+// systemMountpoint returns the OS mountpoint on a disk's partitions and the
+// volumes built on them, or ""
+func systemMountpoint(bd *parsers.BlockDevice) string {
+	for i := range bd.Children {
+		child := &bd.Children[i]
+		if child.Mountpoint != nil && slices.Contains(systemMountpoints, *child.Mountpoint) {
+			return *child.Mountpoint
+		}
+		if mountpoint := systemMountpoint(child); mountpoint != "" {
+			return mountpoint
+		}
+	}
+	return ""
+}
+
+// isUSB reports whether a disk is attached over USB
+func isUSB(disk *types.PhysicalDisk) bool {
+	if disk.Interface == types.InterfaceUSB {
+		return true
+	}
+	for _, link := range disk.DevLinks {
+		if strings.HasPrefix(link, "/dev/disk/by-id/usb-") || strings.Contains(link, "-usb-") {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeWWN lowercases a WWN and drops its 0x prefix
+func normalizeWWN(wwn string) string {
+	wwn = strings.ToLower(strings.TrimSpace(wwn))
+	return strings.TrimPrefix(wwn, "0x")
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/disk/parsers"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mounted is a block device whose children are mounted at the mountpoints
+func mounted(path string, mountpoints ...string) *parsers.BlockDevice {
+	bd := &parsers.BlockDevice{Path: path, Type: "disk"}
+	for _, mp := range mountpoints {
+		mp := mp
+		bd.Children = append(bd.Children, parsers.BlockDevice{Type: "part", Mountpoint: &mp})
+	}
+	return bd
+}
+
+func TestMatchExclusion(t *testing.T) {
+	lvmRoot := "/"
+	rootOnLVM := &parsers.BlockDevice{Path: "/dev/sda", Type: "disk", Children: []parsers.BlockDevice{{
+		Type:     "part",
+		Children: []parsers.BlockDevice{{Type: "lvm", Mountpoint: &lvmRoot}},
+	}}}
+
+	sata := &types.PhysicalDisk{
+		DeviceID:   "WD-WCC4N1234567",
+		DevicePath: "/dev/sdb",
+		Model:      "WDC WD40EFRX-68N32N0",
+		WWN:        "0x50014ee2b5c7a1d2",
+		Interface:  types.InterfaceSATA,
+		DevLinks:   []string{"/dev/disk/by-id/ata-WDC_WD40EFRX-68N32N0_WD-WCC4N1234567"},
+	}
+	usbByInterface := &types.PhysicalDisk{DeviceID: "usb1", DevicePath: "/dev/sdc", Interface: types.InterfaceUSB}
+	usbByLink := &types.PhysicalDisk{DeviceID: "usb2", DevicePath: "/dev/sdd",
+		DevLinks: []string{"/dev/disk/by-id/usb-SanDisk_Ultra_4C530001-0:0"}}
+	usbByPath := &types.PhysicalDisk{DeviceID: "usb3", DevicePath: "/dev/sde",
+		DevLinks: []string{"/dev/disk/by-path/pci-0000:00:14.0-usb-0:2:1.0-scsi-0:0:0:0"}}
+
+	tests := []struct {
+		name       string
+		cfg        types.ExclusionConfig
+		disk       *types.PhysicalDisk
+		bd         *parsers.BlockDevice
+		wantReason types.ExclusionReason
+		wantRule   string
+	}{
+		{name: "no rules", disk: sata, bd: mounted("/dev/sdb", "/")},
+
+		// System disks
+		{name: "root partition", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: mounted("/dev/sdb", "/"), wantReason: types.ExcludedSystemDisk, wantRule: "/"},
+		{name: "boot partition", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: mounted("/dev/sdb", "/data", "/boot"), wantReason: types.ExcludedSystemDisk, wantRule: "/boot"},
+		{name: "EFI partition", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: mounted("/dev/sdb", "/boot/efi"), wantReason: types.ExcludedSystemDisk, wantRule: "/boot/efi"},
+		{name: "swap", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: mounted("/dev/sdb", "[SWAP]"), wantReason: types.ExcludedSystemDisk, wantRule: "[SWAP]"},
+		{name: "root on LVM", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: rootOnLVM, wantReason: types.ExcludedSystemDisk, wantRule: "/"},
+		{name: "data mounts only", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata,
+			bd: mounted("/dev/sdb", "/data", "/boot2")},
+		{name: "system rule off", cfg: types.ExclusionConfig{}, disk: sata, bd: mounted("/dev/sdb", "/")},
+		{name: "not reported by lsblk", cfg: types.ExclusionConfig{SystemDisks: true}, disk: sata},
+
+		// USB
+		{name: "USB interface", cfg: types.ExclusionConfig{USB: true}, disk: usbByInterface,
+			wantReason: types.ExcludedUSB},
+		{name: "USB by-id link", cfg: types.ExclusionConfig{USB: true}, disk: usbByLink,
+			wantReason: types.ExcludedUSB},
+		{name: "USB by-path link", cfg: types.ExclusionConfig{USB: true}, disk: usbByPath,
+			wantReason: types.ExcludedUSB},
+		{name: "SATA disk", cfg: types.ExclusionConfig{USB: true}, disk: sata},
+		{name: "USB rule off", disk: usbByInterface},
+
+		// WWNs
+		{name: "WWN with prefix", cfg: types.ExclusionConfig{WWNs: []string{"0x50014ee2b5c7a1d2"}},
+			disk: sata, wantReason: types.ExcludedWWN, wantRule: "0x50014ee2b5c7a1d2"},
+		{name: "WWN without prefix, upper case", cfg: types.ExclusionConfig{WWNs: []string{" 50014EE2B5C7A1D2"}},
+			disk: sata, wantReason: types.ExcludedWWN, wantRule: " 50014EE2B5C7A1D2"},
+		{name: "other WWN", cfg: types.ExclusionConfig{WWNs: []string{"0x50014ee2b5c7a1d3"}}, disk: sata},
+		{name: "disk without WWN", cfg: types.ExclusionConfig{WWNs: []string{""}}, disk: usbByInterface},
+
+		// Patterns
+		{name: "device path pattern", cfg: types.ExclusionConfig{Patterns: []string{"/dev/sd[b-c]"}},
+			disk: sata, wantReason: types.ExcludedPattern, wantRule: "/dev/sd[b-c]"},
+		{name: "model pattern", cfg: types.ExclusionConfig{Patterns: []string{"WDC WD40*"}},
+			disk: sata, wantReason: types.ExcludedPattern, wantRule: "WDC WD40*"},
+		{name: "device link pattern", cfg: types.ExclusionConfig{Patterns: []string{"/dev/disk/by-id/ata-*"}},
+			disk: sata, wantReason: types.ExcludedPattern, wantRule: "/dev/disk/by-id/ata-*"},
+		{name: "device ID pattern", cfg: types.ExclusionConfig{Patterns: []string{"WD-*"}},
+			disk: sata, wantReason: types.ExcludedPattern, wantRule: "WD-*"},
+		{name: "no pattern match", cfg: types.ExclusionConfig{Patterns: []string{"/dev/nvme*"}}, disk: sata},
+		{name: "empty model not matched", cfg: types.ExclusionConfig{Patterns: []string{"*"}},
+			disk: &types.PhysicalDisk{}},
+
+		// Order
+		{name: "system disk before pattern", cfg: types.ExclusionConfig{SystemDisks: true, Patterns: []string{"*"}},
+			disk: sata, bd: mounted("/dev/sdb", "/"), wantReason: types.ExcludedSystemDisk, wantRule: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, rule, ok := matchExclusion(tt.cfg, tt.disk, tt.bd)
+			assert.Equal(t, tt.wantReason != "", ok)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Equal(t, tt.wantRule, rule)
+		})
+	}
+}
+
+func TestExcludeDisks(t *testing.T) {
+	d := newTestDiscoverer(t)
+	d.SetExclusions(types.ExclusionConfig{SystemDisks: true, Patterns: []string{"/dev/sdc"}})
+
+	disks := []*types.PhysicalDisk{
+		{DeviceID: "os", DevicePath: "/dev/sda"},
+		{DeviceID: "data1", DevicePath: "/dev/sdb"},
+		{DeviceID: "data2", DevicePath: "/dev/sdc"},
+	}
+	blockDevices := map[string]*parsers.BlockDevice{
+		"/dev/sda": mounted("/dev/sda", "/boot", "/"),
+		"/dev/sdb": mounted("/dev/sdb"),
+	}
+
+	kept := d.excludeDisks(disks, blockDevices)
+	require.Len(t, kept, 1)
+	assert.Equal(t, "data1", kept[0].DeviceID)
+
+	ignored := d.GetIgnored()
+	require.Len(t, ignored, 2)
+	assert.Equal(t, "/dev/sda", ignored[0].DevicePath)
+	assert.Equal(t, types.ExcludedSystemDisk, ignored[0].Reason)
+	assert.Equal(t, "/boot", ignored[0].Rule)
+	assert.Equal(t, "/dev/sdc", ignored[1].DevicePath)
+	assert.Equal(t, types.ExcludedPattern, ignored[1].Reason)
+
+	// A device still ignored keeps the time it was first ignored
+	first := ignored[0].IgnoredAt
+	d.excludeDisks(disks, blockDevices)
+	assert.Equal(t, first, d.GetIgnored()[0].IgnoredAt)
+
+	// Devices no longer excluded are dropped from the ignored list
+	d.SetExclusions(types.ExclusionConfig{})
+	assert.Len(t, d.excludeDisks(disks, blockDevices), 3)
+	assert.Empty(t, d.GetIgnored())
+}
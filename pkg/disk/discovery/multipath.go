@@ -0,0 +1,203 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/disk/parsers"
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+// multipathDir holds the bindings and wwids files of multipathd
+var multipathDir = "/etc/multipath"
+
+// multipathConfig is what multipathd records about the disks it manages
+type multipathConfig struct {
+	bindings map[string]string // Map name -> WWID
+	wwids    map[string]bool   // WWIDs of multipath disks
+}
+
+// readMultipathConfig reads /etc/multipath/bindings and wwids; missing files
+// leave the config empty
+func readMultipathConfig() multipathConfig {
+	cfg := multipathConfig{bindings: map[string]string{}, wwids: map[string]bool{}}
+	readLines(filepath.Join(multipathDir, "bindings"), func(fields []string) {
+		if len(fields) >= 2 {
+			cfg.bindings[fields[0]] = fields[1]
+		}
+	})
+	readLines(filepath.Join(multipathDir, "wwids"), func(fields []string) {
+		if wwid := strings.Trim(fields[0], "/"); wwid != "" {
+			cfg.wwids[wwid] = true
+		}
+	})
+	return cfg
+}
+
+// readLines passes the fields of each line of a file, skipping blank lines
+// and comments
+func readLines(path string, fn func(fields []string)) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fn(strings.Fields(line))
+	}
+}
+
+// wwid returns the multipath WWID of a disk: the one bound to its map name,
+// or its NAA WWN in the form multipathd records when listed in wwids
+func (c multipathConfig) wwid(name string, disk *types.PhysicalDisk) string {
+	if wwid, ok := c.bindings[name]; ok {
+		return wwid
+	}
+	if naa, ok := strings.CutPrefix(disk.WWN, "0x"); ok && c.wwids["3"+naa] {
+		return "3" + naa
+	}
+	return ""
+}
+
+// collapseMultipath merges the paths of each multipath disk into one logical
+// disk. Paths are grouped by the multipath device lsblk shows on them, or,
+// for disks without a map, such as when multipathd is not running, by their
+// device ID. The logical disk takes the multipath device as its path, keeps
+// the device links of every path and lists the paths with their states.
+// blockDeviceMap gains the multipath devices, whose children are the
+// partitions of the disk.
+func (d *Discoverer) collapseMultipath(
+	ctx context.Context,
+	disks []*types.PhysicalDisk,
+	blockDeviceMap map[string]*parsers.BlockDevice,
+) []*types.PhysicalDisk {
+	groups := make(map[string][]*types.PhysicalDisk)
+	var order []string
+	for _, disk := range disks {
+		key := "id:" + disk.DeviceID
+		if bd, ok := blockDeviceMap[disk.DevicePath]; ok {
+			if mpath := bd.MultipathChild(); mpath != nil {
+				key = "mpath:" + mpath.Path
+			}
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], disk)
+	}
+
+	var cfg *multipathConfig
+	collapsed := make([]*types.PhysicalDisk, 0, len(groups))
+	for _, key := range order {
+		paths := groups[key]
+		dmPath, isMap := strings.CutPrefix(key, "mpath:")
+		if !isMap {
+			if len(paths) == 1 {
+				collapsed = append(collapsed, paths[0])
+				continue
+			}
+			dmPath = ""
+		}
+		if cfg == nil {
+			c := readMultipathConfig()
+			cfg = &c
+		}
+		collapsed = append(collapsed, d.mergePaths(ctx, paths, dmPath, *cfg, blockDeviceMap))
+	}
+	return collapsed
+}
+
+// mergePaths makes one logical disk of the paths to a disk; dmPath is its
+// multipath device, empty when the paths have no map
+func (d *Discoverer) mergePaths(
+	ctx context.Context,
+	paths []*types.PhysicalDisk,
+	dmPath string,
+	cfg multipathConfig,
+	blockDeviceMap map[string]*parsers.BlockDevice,
+) *types.PhysicalDisk {
+	info := &types.MultipathInfo{DMPath: dmPath}
+	var primary *types.PhysicalDisk
+	var links []string
+	for _, p := range paths {
+		path := types.MultipathPath{Device: p.DevicePath}
+		if bd, ok := blockDeviceMap[p.DevicePath]; ok {
+			path.HCTL = bd.GetHCTLString()
+			path.State = bd.GetStateString()
+		}
+		info.Paths = append(info.Paths, path)
+		if primary == nil || (path.Active() && !activePath(info, primary.DevicePath)) {
+			primary = p
+		}
+		links = append(links, p.DevLinks...)
+	}
+
+	disk := *primary
+	disk.Multipath = info
+	if dmPath != "" {
+		info.Name = filepath.Base(dmPath)
+		disk.DevicePath = dmPath
+		links = append(links, dmPath)
+		links = append(links, d.deviceLinks(ctx, dmPath)...)
+		if bd, ok := blockDeviceMap[paths[0].DevicePath]; ok {
+			blockDeviceMap[dmPath] = bd.MultipathChild()
+		}
+	} else {
+		d.logger.Warn("disk seen over several paths without a multipath map",
+			"device_id", disk.DeviceID,
+			"paths", len(paths))
+	}
+	info.WWID = cfg.wwid(info.Name, &disk)
+
+	slices.Sort(links)
+	disk.DevLinks = slices.Compact(links)
+	if disk.DeviceIDSource == "path" {
+		disk.DeviceID = disk.DevicePath
+	}
+
+	d.logger.Debug("collapsed multipath disk",
+		"device_id", disk.DeviceID,
+		"device", disk.DevicePath,
+		"wwid", info.WWID,
+		"paths", len(info.Paths),
+		"active_paths", info.ActivePaths())
+	return &disk
+}
+
+// activePath reports whether the path device of a multipath disk is active
+func activePath(info *types.MultipathInfo, device string) bool {
+	for _, p := range info.Paths {
+		if p.Device == device {
+			return p.Active()
+		}
+	}
+	return false
+}
+
+// deviceLinks returns the udev device links of a device, so pools built on
+// multipath devices match their disk
+func (d *Discoverer) deviceLinks(ctx context.Context, device string) []string {
+	if d.udevadm == nil || !d.toolChecker.IsAvailable("udevadm") {
+		return nil
+	}
+	output, err := d.udevadm.Info(ctx, device)
+	if err != nil {
+		d.logger.Debug("failed to get udev info of multipath device", "device", device, "error", err)
+		return nil
+	}
+	return strings.Fields(parseUdevProperties(string(output))["DEVLINKS"])
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package discovery
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/disk/parsers"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withMultipathDir points multipathDir at a directory holding the given
+// bindings and wwids files
+func withMultipathDir(t *testing.T, bindings, wwids string) {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bindings"), []byte(bindings), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "wwids"), []byte(wwids), 0644))
+	saved := multipathDir
+	multipathDir = dir
+	t.Cleanup(func() { multipathDir = saved })
+}
+
+func newTestDiscoverer(t *testing.T) *Discoverer {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test.disk.discovery")
+	require.NoError(t, err)
+	return &Discoverer{logger: l}
+}
+
+// pathDevice is an lsblk path device, with the multipath map built on it
+// when mpath is set
+func pathDevice(path, hctl, state, mpath string) *parsers.BlockDevice {
+	bd := &parsers.BlockDevice{Path: path, Type: "disk", HCTL: &hctl, State: &state}
+	if mpath != "" {
+		bd.Children = []parsers.BlockDevice{{Path: mpath, Type: "mpath"}}
+	}
+	return bd
+}
+
+func pathDisk(id, path, wwn string) *types.PhysicalDisk {
+	disk := types.NewPhysicalDisk(id, path)
+	disk.DeviceIDSource = "serial"
+	disk.WWN = wwn
+	disk.DevLinks = []string{"/dev/disk/by-path/" + filepath.Base(path)}
+	return disk
+}
+
+func TestReadMultipathConfig(t *testing.T) {
+	withMultipathDir(t,
+		"# Multipath bindings, Version : 1.0\nmpatha 35000c500a1b2c3d4\n\nmpathb 35000c500deadbeef\n",
+		"# Valid WWIDs:\n/35000c500a1b2c3d4/\n/35000c500cafef00d/\n")
+
+	cfg := readMultipathConfig()
+	assert.Equal(t, map[string]string{
+		"mpatha": "35000c500a1b2c3d4",
+		"mpathb": "35000c500deadbeef",
+	}, cfg.bindings)
+	assert.Equal(t, map[string]bool{
+		"35000c500a1b2c3d4": true,
+		"35000c500cafef00d": true,
+	}, cfg.wwids)
+
+	tests := []struct {
+		name  string
+		mpath string
+		wwn   string
+		want  string
+	}{
+		{name: "bound map name", mpath: "mpathb", wwn: "0x5000c500a1b2c3d4", want: "35000c500deadbeef"},
+		{name: "NAA WWN in wwids", wwn: "0x5000c500cafef00d", want: "35000c500cafef00d"},
+		{name: "WWN not in wwids", wwn: "0x5000c50000000000", want: ""},
+		{name: "no WWN", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cfg.wwid(tt.mpath, &types.PhysicalDisk{WWN: tt.wwn}))
+		})
+	}
+
+	// Missing files leave the config empty
+	multipathDir = t.TempDir()
+	cfg = readMultipathConfig()
+	assert.Empty(t, cfg.bindings)
+	assert.Empty(t, cfg.wwids)
+}
+
+func TestCollapseMultipath(t *testing.T) {
+	withMultipathDir(t, "mpatha 35000c500a1b2c3d4\n", "/35000c500cafef00d/\n")
+	d := newTestDiscoverer(t)
+
+	disks := []*types.PhysicalDisk{
+		pathDisk("A1", "/dev/sdb", "0x5000c500a1b2c3d4"),
+		pathDisk("single", "/dev/sda", ""),
+		pathDisk("A2", "/dev/sdc", "0x5000c500a1b2c3d4"),
+		pathDisk("B", "/dev/sdd", "0x5000c500cafef00d"),
+		pathDisk("B", "/dev/sde", "0x5000c500cafef00d"),
+	}
+	blockDevices := map[string]*parsers.BlockDevice{
+		"/dev/sda": pathDevice("/dev/sda", "0:0:0:0", "running", ""),
+		"/dev/sdb": pathDevice("/dev/sdb", "1:0:0:0", "offline", "/dev/mapper/mpatha"),
+		"/dev/sdc": pathDevice("/dev/sdc", "2:0:0:0", "running", "/dev/mapper/mpatha"),
+		"/dev/sdd": pathDevice("/dev/sdd", "1:0:1:0", "running", ""),
+		"/dev/sde": pathDevice("/dev/sde", "2:0:1:0", "running", ""),
+	}
+
+	collapsed := d.collapseMultipath(context.Background(), disks, blockDevices)
+	require.Len(t, collapsed, 3)
+
+	// Paths are grouped by their map, whatever their device IDs, in the
+	// order they were found
+	mapped := collapsed[0]
+	assert.Equal(t, "/dev/mapper/mpatha", mapped.DevicePath)
+	assert.Equal(t, "A2", mapped.DeviceID, "the active path is the primary")
+	require.NotNil(t, mapped.Multipath)
+	assert.Equal(t, "mpatha", mapped.Multipath.Name)
+	assert.Equal(t, "35000c500a1b2c3d4", mapped.Multipath.WWID)
+	assert.Equal(t, []types.MultipathPath{
+		{Device: "/dev/sdb", HCTL: "1:0:0:0", State: "offline"},
+		{Device: "/dev/sdc", HCTL: "2:0:0:0", State: "running"},
+	}, mapped.Multipath.Paths)
+	assert.Equal(t, 1, mapped.Multipath.ActivePaths())
+	assert.Equal(t, []string{"/dev/disk/by-path/sdb", "/dev/disk/by-path/sdc", "/dev/mapper/mpatha"},
+		mapped.DevLinks)
+	assert.Contains(t, blockDevices, "/dev/mapper/mpatha")
+
+	// A disk on one path is left as it is
+	assert.Same(t, disks[1], collapsed[1])
+	assert.Nil(t, collapsed[1].Multipath)
+
+	// Without a map, paths are grouped by device ID and the WWID comes from
+	// the wwids file
+	unmapped := collapsed[2]
+	assert.Equal(t, "B", unmapped.DeviceID)
+	assert.Equal(t, "/dev/sdd", unmapped.DevicePath)
+	require.NotNil(t, unmapped.Multipath)
+	assert.Empty(t, unmapped.Multipath.Name)
+	assert.Empty(t, unmapped.Multipath.DMPath)
+	assert.Equal(t, "35000c500cafef00d", unmapped.Multipath.WWID)
+	assert.Equal(t, 2, unmapped.Multipath.ActivePaths())
+}
+
+func TestMergePathsPrimary(t *testing.T) {
+	d := newTestDiscoverer(t)
+
+	tests := []struct {
+		name        string
+		states      []string
+		wantPrimary string
+		wantActive  int
+	}{
+		{name: "all running", states: []string{"running", "running"}, wantPrimary: "A1", wantActive: 2},
+		{name: "first offline", states: []string{"offline", "running"}, wantPrimary: "A2", wantActive: 1},
+		{name: "all offline", states: []string{"offline", "blocked"}, wantPrimary: "A1", wantActive: 0},
+		{name: "state unknown counts as active", states: []string{"offline", ""}, wantPrimary: "A2", wantActive: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths := []*types.PhysicalDisk{
+				pathDisk("A1", "/dev/sdb", ""),
+				pathDisk("A2", "/dev/sdc", ""),
+			}
+			blockDevices := map[string]*parsers.BlockDevice{
+				"/dev/sdb": pathDevice("/dev/sdb", "1:0:0:0", tt.states[0], "/dev/mapper/mpatha"),
+				"/dev/sdc": pathDevice("/dev/sdc", "2:0:0:0", tt.states[1], "/dev/mapper/mpatha"),
+			}
+
+			disk := d.mergePaths(context.Background(), paths, "/dev/mapper/mpatha", multipathConfig{}, blockDevices)
+			assert.Equal(t, tt.wantActive, disk.Multipath.ActivePaths())
+			assert.Equal(t, tt.wantPrimary, disk.DeviceID, "the first active path is the primary")
+		})
+	}
+}
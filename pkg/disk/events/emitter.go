@@ -5,6 +5,8 @@
 package events
 
 import (
+	"maps"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -60,8 +62,13 @@ func (e *Emitter) EmitDiskDiscovered(disk *types.PhysicalDisk) {
 	})
 }
 
-// EmitDiskHealthChanged emits a disk health change event
-func (e *Emitter) EmitDiskHealthChanged(disk *types.PhysicalDisk, oldHealth, newHealth types.HealthStatus) {
+// EmitDiskHealthChanged emits a disk health change event. impact, when set,
+// adds what the failing disk puts at risk to the event metadata.
+func (e *Emitter) EmitDiskHealthChanged(
+	disk *types.PhysicalDisk,
+	oldHealth, newHealth types.HealthStatus,
+	impact map[string]string,
+) {
 	level := eventspb.EventLevel_EVENT_LEVEL_INFO
 	switch newHealth {
 	case types.HealthCritical:
@@ -89,15 +96,20 @@ func (e *Emitter) EmitDiskHealthChanged(disk *types.PhysicalDisk, oldHealth, new
 		Operation:     eventspb.StorageDiskPayload_STORAGE_DISK_OPERATION_HEALTH_CHANGED,
 	}
 
-	e.emitDiskEvent(level, payload, map[string]string{
+	e.emitDiskEvent(level, payload, withImpact(map[string]string{
 		"device_id":  disk.DeviceID,
 		"old_health": string(oldHealth),
 		"new_health": string(newHealth),
-	})
+	}, impact))
 }
 
-// EmitDiskStateChanged emits a disk state change event
-func (e *Emitter) EmitDiskStateChanged(disk *types.PhysicalDisk, oldState, newState types.DiskState) {
+// EmitDiskStateChanged emits a disk state change event. impact, when set,
+// adds what the failing disk puts at risk to the event metadata.
+func (e *Emitter) EmitDiskStateChanged(
+	disk *types.PhysicalDisk,
+	oldState, newState types.DiskState,
+	impact map[string]string,
+) {
 	level := eventspb.EventLevel_EVENT_LEVEL_INFO
 	switch newState {
 	case types.DiskStateFaulted:
@@ -116,15 +128,16 @@ func (e *Emitter) EmitDiskStateChanged(disk *types.PhysicalDisk, oldState, newSt
 		Operation:  eventspb.StorageDiskPayload_STORAGE_DISK_OPERATION_STATE_CHANGED,
 	}
 
-	e.emitDiskEvent(level, payload, map[string]string{
+	e.emitDiskEvent(level, payload, withImpact(map[string]string{
 		"device_id": disk.DeviceID,
 		"old_state": string(oldState),
 		"new_state": string(newState),
-	})
+	}, impact))
 }
 
-// EmitDiskRemoved emits a disk removal event
-func (e *Emitter) EmitDiskRemoved(disk *types.PhysicalDisk) {
+// EmitDiskRemoved emits a disk removal event. impact, when set, adds what
+// the removed pool disk puts at risk to the event metadata.
+func (e *Emitter) EmitDiskRemoved(disk *types.PhysicalDisk, impact map[string]string) {
 	payload := &eventspb.StorageDiskPayload{
 		DeviceId:   disk.DeviceID,
 		DevicePath: disk.DevicePath,
@@ -134,9 +147,82 @@ func (e *Emitter) EmitDiskRemoved(disk *types.PhysicalDisk) {
 		Operation:  eventspb.StorageDiskPayload_STORAGE_DISK_OPERATION_REMOVED,
 	}
 
-	e.emitDiskEvent(eventspb.EventLevel_EVENT_LEVEL_WARN, payload, map[string]string{
+	level := eventspb.EventLevel_EVENT_LEVEL_WARN
+	if impact != nil {
+		level = eventspb.EventLevel_EVENT_LEVEL_ERROR
+	}
+	e.emitDiskEvent(level, payload, withImpact(map[string]string{
 		"device_id":   disk.DeviceID,
 		"device_path": disk.DevicePath,
+	}, impact))
+}
+
+// EmitMultipathPathChanged emits an event when a path to a multipath disk
+// is lost or restored. The disk stays present while any path is active.
+func (e *Emitter) EmitMultipathPathChanged(disk *types.PhysicalDisk, path, oldState, newState string) {
+	activePaths := disk.Multipath.ActivePaths()
+	level := eventspb.EventLevel_EVENT_LEVEL_INFO
+	switch {
+	case activePaths == 0:
+		level = eventspb.EventLevel_EVENT_LEVEL_ERROR
+	case newState != types.PathStateRunning:
+		level = eventspb.EventLevel_EVENT_LEVEL_WARN
+	}
+
+	payload := &eventspb.StorageDiskPayload{
+		DeviceId:   disk.DeviceID,
+		DevicePath: disk.DevicePath,
+		Serial:     disk.Serial,
+		Model:      disk.Model,
+		State:      string(disk.State),
+		Health:     string(disk.Health),
+		Operation:  eventspb.StorageDiskPayload_STORAGE_DISK_OPERATION_STATE_CHANGED,
+	}
+
+	e.emitDiskEvent(level, payload, map[string]string{
+		"device_id":      disk.DeviceID,
+		"multipath_path": path,
+		"old_path_state": oldState,
+		"new_path_state": newState,
+		"active_paths":   strconv.Itoa(activePaths),
+		"total_paths":    strconv.Itoa(len(disk.Multipath.Paths)),
+	})
+}
+
+// EmitTemperatureAlert emits an event when a disk or enclosure sensor
+// crosses a temperature threshold or starts rising fast. disk is nil for
+// enclosure sensors.
+func (e *Emitter) EmitTemperatureAlert(alert *types.TemperatureAlert, disk *types.PhysicalDisk) {
+	sensor := alert.Sensor
+	level := eventspb.EventLevel_EVENT_LEVEL_INFO
+	switch {
+	case sensor.Level == types.TempCritical:
+		level = eventspb.EventLevel_EVENT_LEVEL_CRITICAL
+	case sensor.Level == types.TempWarning || sensor.Rising:
+		level = eventspb.EventLevel_EVENT_LEVEL_WARN
+	}
+
+	payload := &eventspb.StorageDiskPayload{
+		DeviceId:    sensor.ID,
+		DevicePath:  sensor.Name,
+		Temperature: int32(sensor.Celsius),
+		Operation:   eventspb.StorageDiskPayload_STORAGE_DISK_OPERATION_HEALTH_CHANGED,
+	}
+	if disk != nil {
+		payload.Serial = disk.Serial
+		payload.Model = disk.Model
+		payload.State = string(disk.State)
+		payload.Health = string(disk.Health)
+	}
+
+	e.emitDiskEvent(level, payload, map[string]string{
+		"sensor_id":         sensor.ID,
+		"sensor_kind":       string(sensor.Kind),
+		"temperature":       strconv.FormatFloat(sensor.Celsius, 'f', -1, 64),
+		"temperature_level": string(sensor.Level),
+		"old_level":         string(alert.OldLevel),
+		"rising":            strconv.FormatBool(sensor.Rising),
+		"reason":            alert.Reason,
 	})
 }
 
@@ -274,6 +360,12 @@ func (e *Emitter) emitDiskEvent(
 		"level", level.String())
 }
 
+// withImpact adds the impact of a failing disk to event metadata
+func withImpact(metadata, impact map[string]string) map[string]string {
+	maps.Copy(metadata, impact)
+	return metadata
+}
+
 // emitProbeEvent emits a probe event
 func (e *Emitter) emitProbeEvent(
 	level eventspb.EventLevel,
@@ -64,7 +64,7 @@ func (m *Monitor) CheckHealth(ctx context.Context, disk *types.PhysicalDisk) (*H
 	}
 
 	// Get SMART data
-	output, err := m.smartctl.GetAll(ctx, disk.DevicePath)
+	output, err := m.smartctl.GetAll(ctx, disk.SMARTDevicePath())
 	if err != nil {
 		return nil, errors.Wrap(err, errors.DiskHealthCheckFailed).
 			WithMetadata("device_id", disk.DeviceID).
@@ -141,7 +141,7 @@ func (m *Monitor) RefreshSMART(ctx context.Context, disk *types.PhysicalDisk) (*
 	}
 
 	// Get SMART data
-	output, err := m.smartctl.GetAll(ctx, disk.DevicePath)
+	output, err := m.smartctl.GetAll(ctx, disk.SMARTDevicePath())
 	if err != nil {
 		return nil, errors.Wrap(err, errors.DiskSMARTRefreshFailed).
 			WithMetadata("device_id", disk.DeviceID).
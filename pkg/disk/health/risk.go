@@ -0,0 +1,195 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+const (
+	// riskSampleInterval is how often a SMART sample is kept when the
+	// counters have not changed
+	riskSampleInterval = 6 * time.Hour
+
+	// maxRiskSamples bounds the SMART history of a disk, about 30 days at
+	// riskSampleInterval
+	maxRiskSamples = 120
+
+	// recentErrorWindow is how recent SMART error log entries must be to
+	// add to the score
+	recentErrorWindow = 7 * 24 * time.Hour
+)
+
+// NewSMARTSample takes the failure counters of a disk from its SMART data
+// and ZFS error counters, either of which may be nil
+func NewSMARTSample(info *types.SMARTInfo, zfsErrors *types.ZFSErrors, at time.Time) types.SMARTSample {
+	sample := types.SMARTSample{At: at}
+	if info != nil {
+		sample.Reallocated = attributeRaw(info, 5)
+		sample.Pending = attributeRaw(info, 197)
+		sample.Uncorrectable = attributeRaw(info, 198) + attributeRaw(info, 187)
+		sample.ErrorLogEntries = uint64(info.ErrorLogCount)
+		if nvme := info.NVMeHealth; nvme != nil {
+			sample.MediaErrors = nvme.MediaErrors
+			sample.PercentUsed = nvme.PercentUsed
+			sample.ErrorLogEntries = nvme.ErrorLogEntries
+		}
+	}
+	if zfsErrors != nil {
+		sample.ZFSErrors = zfsErrors.Read + zfsErrors.Write + zfsErrors.Checksum
+	}
+	return sample
+}
+
+// AddSample appends a sample to a SMART history when the counters changed
+// or the last sample is older than the sample interval, dropping the oldest
+// samples beyond the limit
+func AddSample(history []types.SMARTSample, sample types.SMARTSample) []types.SMARTSample {
+	if n := len(history); n > 0 {
+		last := history[n-1]
+		last.At = sample.At
+		if last == sample && sample.At.Sub(history[n-1].At) < riskSampleInterval {
+			return history
+		}
+	}
+	history = append(history, sample)
+	if len(history) > maxRiskSamples {
+		history = history[len(history)-maxRiskSamples:]
+	}
+	return history
+}
+
+// ScoreRisk scores how likely a disk is to fail from its current SMART data,
+// the growth of its counters over the SMART history and its ZFS error
+// counters. The history ends with the current sample.
+func ScoreRisk(
+	info *types.SMARTInfo,
+	history []types.SMARTSample,
+	zfsErrors *types.ZFSErrors,
+	thresholds *types.SMARTThresholds,
+) *types.DiskRisk {
+	if thresholds == nil {
+		thresholds = types.DefaultSMARTThresholds()
+	}
+
+	risk := &types.DiskRisk{ScoredAt: time.Now()}
+	add := func(name string, points int, format string, args ...any) {
+		risk.Factors = append(risk.Factors, types.RiskFactor{
+			Name:   name,
+			Points: points,
+			Detail: fmt.Sprintf(format, args...),
+		})
+		risk.Score += points
+	}
+
+	var first, current types.SMARTSample
+	if len(history) > 0 {
+		first, current = history[0], history[len(history)-1]
+		if len(history) > 1 {
+			risk.TrendSince = &first.At
+		}
+	}
+
+	if info != nil && info.Available {
+		if info.OverallStatus == "FAILED" {
+			add("smart_failed", 60, "SMART overall status is FAILED")
+		}
+		// In ID order, so the attribute named is the same on every scoring
+		for _, id := range slices.Sorted(maps.Keys(info.Attributes)) {
+			if attr := info.Attributes[id]; attr != nil && attr.FailureNear {
+				add("smart_threshold", 40, "SMART attribute %s is at its failure threshold", attr.Name)
+				break
+			}
+		}
+
+		switch {
+		case current.Reallocated >= uint64(thresholds.ReallocatedSectorsWarning) && current.Reallocated > 0:
+			add("reallocated_sectors", 20, "%d reallocated sectors", current.Reallocated)
+		case current.Reallocated > 0:
+			add("reallocated_sectors", 10, "%d reallocated sectors", current.Reallocated)
+		}
+		if growth := grown(first.Reallocated, current.Reallocated); growth >= 10 {
+			add("reallocation_growth", 30, "%d sectors reallocated since %s", growth, dateOf(first.At))
+		} else if growth > 0 {
+			add("reallocation_growth", 20, "%d sectors reallocated since %s", growth, dateOf(first.At))
+		}
+		if current.Pending > 0 {
+			add("pending_sectors", 20, "%d sectors pending reallocation", current.Pending)
+		}
+		if current.Uncorrectable > 0 {
+			add("uncorrectable_errors", 15, "%d uncorrectable errors", current.Uncorrectable)
+		}
+
+		if nvme := info.NVMeHealth; nvme != nil {
+			if nvme.CriticalWarning != 0 {
+				add("nvme_critical_warning", 40, "NVMe critical warning 0x%02x", nvme.CriticalWarning)
+			}
+			if nvme.AvailableSpareThresh > 0 && nvme.AvailableSpare <= nvme.AvailableSpareThresh {
+				add("nvme_spare", 30, "%d%% spare left, threshold %d%%", nvme.AvailableSpare, nvme.AvailableSpareThresh)
+			}
+			switch {
+			case nvme.PercentUsed >= 100:
+				add("nvme_endurance", 25, "%d%% of rated endurance used", nvme.PercentUsed)
+			case nvme.PercentUsed >= 90:
+				add("nvme_endurance", 15, "%d%% of rated endurance used", nvme.PercentUsed)
+			}
+			if current.MediaErrors > 0 {
+				add("media_errors", 15, "%d media errors", current.MediaErrors)
+			}
+			if growth := grown(first.MediaErrors, current.MediaErrors); growth > 0 {
+				add("media_error_growth", 20, "%d media errors since %s", growth, dateOf(first.At))
+			}
+		}
+
+		if info.HasRecentErrors(recentErrorWindow) {
+			add("smart_error_log", 10, "SMART error log entries in the last 7 days")
+		}
+		if info.TemperatureValid && info.Temperature >= thresholds.TempWarning {
+			add("temperature", 5, "%d°C", info.Temperature)
+		}
+		if thresholds.PowerOnHoursWarning > 0 && info.PowerOnHours >= thresholds.PowerOnHoursWarning {
+			add("age", 5, "%d power-on hours", info.PowerOnHours)
+		}
+	}
+
+	if zfsErrors != nil {
+		if io := zfsErrors.Read + zfsErrors.Write; io > 0 {
+			add("zfs_io_errors", 20, "ZFS counted %d read and %d write errors", zfsErrors.Read, zfsErrors.Write)
+		}
+		if zfsErrors.Checksum > 0 {
+			add("zfs_checksum_errors", 10, "ZFS counted %d checksum errors", zfsErrors.Checksum)
+		}
+	}
+
+	risk.Score = min(risk.Score, 100)
+	risk.Level = types.RiskLevelForScore(risk.Score)
+	return risk
+}
+
+// attributeRaw returns the raw value of a SMART attribute, or 0
+func attributeRaw(info *types.SMARTInfo, id int) uint64 {
+	if attr, ok := info.Attributes[id]; ok && attr != nil {
+		return attr.RawValue
+	}
+	return 0
+}
+
+// grown returns how much a counter grew, ignoring resets
+func grown(from, to uint64) uint64 {
+	if to > from {
+		return to - from
+	}
+	return 0
+}
+
+// dateOf formats the day a trend starts
+func dateOf(t time.Time) string {
+	return t.Format(time.DateOnly)
+}
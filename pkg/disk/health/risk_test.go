@@ -0,0 +1,151 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// factor returns the named factor of a risk, or nil
+func factor(risk *types.DiskRisk, name string) *types.RiskFactor {
+	for i := range risk.Factors {
+		if risk.Factors[i].Name == name {
+			return &risk.Factors[i]
+		}
+	}
+	return nil
+}
+
+func TestScoreRiskTrend(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	info := &types.SMARTInfo{Available: true, OverallStatus: "PASSED"}
+
+	tests := []struct {
+		name       string
+		history    []types.SMARTSample
+		wantPoints int // Of reallocation_growth, 0 when absent
+		wantTrend  bool
+	}{
+		{name: "no history"},
+		{
+			name:    "single sample",
+			history: []types.SMARTSample{{At: start, Reallocated: 4}},
+		},
+		{
+			name: "no growth",
+			history: []types.SMARTSample{
+				{At: start, Reallocated: 4},
+				{At: start.Add(24 * time.Hour), Reallocated: 4},
+			},
+			wantTrend: true,
+		},
+		{
+			name: "some growth",
+			history: []types.SMARTSample{
+				{At: start, Reallocated: 4},
+				{At: start.Add(24 * time.Hour), Reallocated: 6},
+				{At: start.Add(48 * time.Hour), Reallocated: 9},
+			},
+			wantPoints: 20,
+			wantTrend:  true,
+		},
+		{
+			name: "fast growth",
+			history: []types.SMARTSample{
+				{At: start, Reallocated: 0},
+				{At: start.Add(24 * time.Hour), Reallocated: 10},
+			},
+			wantPoints: 30,
+			wantTrend:  true,
+		},
+		{
+			name: "counter reset",
+			history: []types.SMARTSample{
+				{At: start, Reallocated: 50},
+				{At: start.Add(24 * time.Hour), Reallocated: 3},
+			},
+			wantTrend: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			risk := ScoreRisk(info, tt.history, nil, nil)
+
+			f := factor(risk, "reallocation_growth")
+			if tt.wantPoints == 0 {
+				assert.Nil(t, f)
+			} else {
+				require.NotNil(t, f)
+				assert.Equal(t, tt.wantPoints, f.Points)
+				assert.Contains(t, f.Detail, "since 2025-03-01")
+			}
+			if tt.wantTrend {
+				require.NotNil(t, risk.TrendSince)
+				assert.Equal(t, start, *risk.TrendSince)
+			} else {
+				assert.Nil(t, risk.TrendSince)
+			}
+		})
+	}
+}
+
+func TestScoreRiskCapped(t *testing.T) {
+	now := time.Now()
+	info := &types.SMARTInfo{
+		Available:     true,
+		OverallStatus: "FAILED",
+		Attributes: map[int]*types.SMARTAttribute{
+			5: {ID: 5, Name: "Reallocated_Sector_Ct", RawValue: 500, FailureNear: true},
+		},
+		ErrorLogs:        []*types.ErrorLogEntry{{OccurredAt: now}},
+		TemperatureValid: true,
+		Temperature:      80,
+	}
+	history := []types.SMARTSample{
+		{At: now.Add(-48 * time.Hour)},
+		{At: now, Reallocated: 500, Pending: 8, Uncorrectable: 3},
+	}
+	zfsErrors := &types.ZFSErrors{Read: 1, Checksum: 2}
+
+	risk := ScoreRisk(info, history, zfsErrors, nil)
+
+	sum := 0
+	for _, f := range risk.Factors {
+		sum += f.Points
+	}
+	assert.Greater(t, sum, 100, "the factors add up past the cap")
+	assert.Equal(t, 100, risk.Score)
+	assert.Equal(t, types.RiskCritical, risk.Level)
+}
+
+func TestScoreRiskAttributes(t *testing.T) {
+	info := &types.SMARTInfo{
+		Available: true,
+		Attributes: map[int]*types.SMARTAttribute{
+			197: {ID: 197, Name: "Current_Pending_Sector", FailureNear: true},
+			3:   nil,
+			5:   {ID: 5, Name: "Reallocated_Sector_Ct", FailureNear: true},
+			9:   {ID: 9, Name: "Power_On_Hours"},
+		},
+	}
+
+	// Nil attributes are skipped and the lowest failing ID is named every time
+	for range 20 {
+		risk := ScoreRisk(info, nil, nil, nil)
+		f := factor(risk, "smart_threshold")
+		require.NotNil(t, f)
+		assert.Equal(t, 40, f.Points)
+		assert.Contains(t, f.Detail, "Reallocated_Sector_Ct")
+	}
+
+	info.Attributes = map[int]*types.SMARTAttribute{5: nil}
+	assert.Nil(t, factor(ScoreRisk(info, nil, nil, nil), "smart_threshold"))
+}
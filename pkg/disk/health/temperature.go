@@ -0,0 +1,235 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/metrics"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// temperatureHistoryFile keeps the temperature history across restarts
+	temperatureHistoryFile = "disk-temperatures.json"
+
+	// tempSampleSpacing is the least time between kept samples of a sensor
+	tempSampleSpacing = 10 * time.Minute
+)
+
+// TemperatureTracker keeps the temperature history of disks and enclosure
+// sensors and grades their readings
+type TemperatureTracker struct {
+	logger  logger.Logger
+	path    string
+	mu      sync.RWMutex
+	sensors map[string]*types.TemperatureSensor // Keyed by sensor ID, with history
+}
+
+// NewTemperatureTracker creates a tracker keeping its history in dir
+func NewTemperatureTracker(l logger.Logger, dir string) *TemperatureTracker {
+	t := &TemperatureTracker{
+		logger:  l,
+		path:    filepath.Join(dir, temperatureHistoryFile),
+		sensors: make(map[string]*types.TemperatureSensor),
+	}
+	if err := t.load(); err != nil {
+		l.Warn("failed to load temperature history, starting a new one", "error", err)
+	}
+	return t
+}
+
+// Record adds a reading of a sensor and returns an alert when its level
+// changed or it started or stopped rising fast
+func (t *TemperatureTracker) Record(
+	kind types.SensorKind,
+	id, name string,
+	celsius, warning, critical float64,
+	at time.Time,
+	cfg types.TemperatureConfig,
+) *types.TemperatureAlert {
+	cfg = cfg.WithDefaults()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	sensor, known := t.sensors[id]
+	if !known {
+		sensor = &types.TemperatureSensor{ID: id, Kind: kind, Level: types.TempNormal}
+		t.sensors[id] = sensor
+	}
+	oldLevel, wasRising := sensor.Level, sensor.Rising
+
+	sensor.Name = name
+	sensor.Celsius = celsius
+	sensor.UpdatedAt = at
+	sensor.Level = types.TempLevelFor(celsius, warning, critical)
+	sensor.Rising = risen(sensor.History, celsius, at, cfg.RiseWindow) >= cfg.RiseLimit
+
+	history := sensor.History
+	if n := len(history); n == 0 || at.Sub(history[n-1].At) >= tempSampleSpacing {
+		history = append(history, types.TemperatureSample{At: at, Celsius: celsius})
+	}
+	cutoff := at.Add(-cfg.Retention)
+	for len(history) > 0 && history[0].At.Before(cutoff) {
+		history = history[1:]
+	}
+	sensor.History = history
+
+	var reasons []string
+	if sensor.Level != oldLevel && (known || sensor.Level != types.TempNormal) {
+		reasons = append(reasons, fmt.Sprintf("%.0f°C is %s, thresholds %.0f°C and %.0f°C",
+			celsius, strings.ToLower(string(sensor.Level)), warning, critical))
+	}
+	if sensor.Rising && !wasRising {
+		reasons = append(reasons, fmt.Sprintf("rose %.0f°C within %s",
+			risen(sensor.History, celsius, at, cfg.RiseWindow), cfg.RiseWindow))
+	}
+	if !sensor.Rising && wasRising {
+		reasons = append(reasons, "no longer rising fast")
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	current := *sensor
+	current.History = nil
+	return &types.TemperatureAlert{Sensor: current, OldLevel: oldLevel, Reason: strings.Join(reasons, "; ")}
+}
+
+// risen returns how far a reading is above the lowest kept sample within
+// the window before it
+func risen(history []types.TemperatureSample, celsius float64, at time.Time, window time.Duration) float64 {
+	lowest := celsius
+	for _, s := range history {
+		if !s.At.Before(at.Add(-window)) && s.Celsius < lowest {
+			lowest = s.Celsius
+		}
+	}
+	return celsius - lowest
+}
+
+// Sensors returns the sensors by kind and ID, with their history when
+// asked for
+func (t *TemperatureTracker) Sensors(withHistory bool) []types.TemperatureSensor {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	sensors := make([]types.TemperatureSensor, 0, len(t.sensors))
+	for _, s := range t.sensors {
+		sensor := *s
+		sensor.History = nil
+		if withHistory {
+			sensor.History = slices.Clone(s.History)
+		}
+		sensors = append(sensors, sensor)
+	}
+	slices.SortFunc(sensors, func(a, b types.TemperatureSensor) int {
+		if c := cmp.Compare(a.Kind, b.Kind); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return sensors
+}
+
+// Sensor returns a sensor with its history
+func (t *TemperatureTracker) Sensor(id string) (*types.TemperatureSensor, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	s, ok := t.sensors[id]
+	if !ok {
+		return nil, false
+	}
+	sensor := *s
+	sensor.History = slices.Clone(s.History)
+	return &sensor, true
+}
+
+// Forget drops the sensors not in keep of a kind, such as disks no longer
+// present
+func (t *TemperatureTracker) Forget(kind types.SensorKind, keep map[string]bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for id, s := range t.sensors {
+		if s.Kind == kind && !keep[id] {
+			delete(t.sensors, id)
+		}
+	}
+}
+
+// WritePrometheus writes the current temperatures in the Prometheus text
+// format
+func (t *TemperatureTracker) WritePrometheus(w io.Writer) error {
+	sensors := t.Sensors(false)
+
+	var b strings.Builder
+	metrics.WriteHeader(&b, "rodent_disk_temperature_celsius", "gauge", "Drive temperature from SMART")
+	for _, s := range sensors {
+		if s.Kind == types.SensorDisk {
+			metrics.WriteSample(&b, "rodent_disk_temperature_celsius", s.Celsius, "device_id", s.ID, "device", s.Name)
+		}
+	}
+	metrics.WriteHeader(&b, "rodent_enclosure_temperature_celsius", "gauge", "Enclosure sensor temperature")
+	for _, s := range sensors {
+		if s.Kind == types.SensorEnclosure {
+			metrics.WriteSample(&b, "rodent_enclosure_temperature_celsius", s.Celsius, "sensor", s.ID, "location", s.Name)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Save writes the temperature history
+func (t *TemperatureTracker) Save() error {
+	t.mu.RLock()
+	data, err := json.Marshal(t.sensors)
+	t.mu.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, errors.DiskStateSaveFailed).WithMetadata("path", t.path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return errors.Wrap(err, errors.DiskStateSaveFailed).WithMetadata("path", t.path)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return errors.Wrap(err, errors.DiskStateSaveFailed).WithMetadata("path", t.path)
+	}
+	return nil
+}
+
+// load reads the temperature history
+func (t *TemperatureTracker) load() error {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, errors.DiskStateLoadFailed).WithMetadata("path", t.path)
+	}
+
+	sensors := make(map[string]*types.TemperatureSensor)
+	if err := json.Unmarshal(data, &sensors); err != nil {
+		return errors.Wrap(err, errors.DiskStateLoadFailed).WithMetadata("path", t.path)
+	}
+
+	t.mu.Lock()
+	t.sensors = sensors
+	t.mu.Unlock()
+	return nil
+}
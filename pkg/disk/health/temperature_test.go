@@ -0,0 +1,173 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package health
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(t *testing.T) *TemperatureTracker {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test.disk.temperature")
+	require.NoError(t, err)
+	return NewTemperatureTracker(l, t.TempDir())
+}
+
+func TestRecordThresholds(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Readings an hour apart, so none counts as a fast rise
+	tests := []struct {
+		name     string
+		readings []float64
+		levels   []types.TempLevel
+		alerts   []bool
+	}{
+		{
+			name:     "normal",
+			readings: []float64{30, 35, 39.9},
+			levels:   []types.TempLevel{types.TempNormal, types.TempNormal, types.TempNormal},
+			alerts:   []bool{false, false, false},
+		},
+		{
+			name:     "first reading above warning",
+			readings: []float64{45},
+			levels:   []types.TempLevel{types.TempWarning},
+			alerts:   []bool{true},
+		},
+		{
+			name:     "up to critical and back",
+			readings: []float64{38, 40, 50, 50, 45, 30},
+			levels: []types.TempLevel{types.TempNormal, types.TempWarning, types.TempCritical,
+				types.TempCritical, types.TempWarning, types.TempNormal},
+			alerts: []bool{false, true, true, false, true, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTestTracker(t)
+			for i, celsius := range tt.readings {
+				at := start.Add(time.Duration(i) * time.Hour)
+				alert := tracker.Record(types.SensorEnclosure, "enc0:1", "sensor 1",
+					celsius, 40, 50, at, types.TemperatureConfig{})
+
+				sensor, ok := tracker.Sensor("enc0:1")
+				require.True(t, ok)
+				assert.Equal(t, tt.levels[i], sensor.Level, "reading %d", i)
+				assert.False(t, sensor.Rising, "reading %d", i)
+				if tt.alerts[i] {
+					require.NotNil(t, alert, "reading %d", i)
+					assert.Equal(t, tt.levels[i], alert.Sensor.Level)
+					assert.Nil(t, alert.Sensor.History)
+				} else {
+					assert.Nil(t, alert, "reading %d", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordRiseRate(t *testing.T) {
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := types.TemperatureConfig{RiseLimit: 8, RiseWindow: 30 * time.Minute}
+
+	tests := []struct {
+		name     string
+		offsets  []time.Duration
+		readings []float64
+		rising   []bool
+	}{
+		{
+			name:     "slow rise",
+			offsets:  []time.Duration{0, 20 * time.Minute, 40 * time.Minute, 60 * time.Minute},
+			readings: []float64{30, 33, 36, 39},
+			rising:   []bool{false, false, false, false},
+		},
+		{
+			name:     "fast rise then steady",
+			offsets:  []time.Duration{0, 10 * time.Minute, 20 * time.Minute, 60 * time.Minute},
+			readings: []float64{30, 34, 38, 38},
+			rising:   []bool{false, false, true, false},
+		},
+		{
+			name:     "rise outside the window",
+			offsets:  []time.Duration{0, 40 * time.Minute},
+			readings: []float64{30, 38},
+			rising:   []bool{false, false},
+		},
+		{
+			name:     "falling",
+			offsets:  []time.Duration{0, 10 * time.Minute, 20 * time.Minute},
+			readings: []float64{45, 40, 35},
+			rising:   []bool{false, false, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTestTracker(t)
+			for i, celsius := range tt.readings {
+				alert := tracker.Record(types.SensorDisk, "disk0", "/dev/sda",
+					celsius, 60, 70, start.Add(tt.offsets[i]), cfg)
+
+				sensor, _ := tracker.Sensor("disk0")
+				assert.Equal(t, tt.rising[i], sensor.Rising, "reading %d", i)
+
+				started := tt.rising[i] && (i == 0 || !tt.rising[i-1])
+				stopped := !tt.rising[i] && i > 0 && tt.rising[i-1]
+				if started || stopped {
+					require.NotNil(t, alert, "reading %d", i)
+				} else {
+					assert.Nil(t, alert, "reading %d", i)
+				}
+				if started {
+					assert.Contains(t, alert.Reason, "rose 8°C within 30m0s")
+				}
+				if stopped {
+					assert.Equal(t, "no longer rising fast", alert.Reason)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordHistory(t *testing.T) {
+	tracker := newTestTracker(t)
+	start := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := types.TemperatureConfig{Retention: time.Hour}
+
+	// Samples closer than the spacing are not kept, and samples older than
+	// the retention are dropped
+	for _, offset := range []time.Duration{0, 5 * time.Minute, 10 * time.Minute, 30 * time.Minute, 80 * time.Minute} {
+		tracker.Record(types.SensorDisk, "disk0", "/dev/sda", 35, 60, 70, start.Add(offset), cfg)
+	}
+
+	sensor, ok := tracker.Sensor("disk0")
+	require.True(t, ok)
+	var kept []time.Duration
+	for _, s := range sensor.History {
+		kept = append(kept, s.At.Sub(start))
+	}
+	assert.Equal(t, []time.Duration{30 * time.Minute, 80 * time.Minute}, kept)
+}
+
+func TestTemperaturePrometheus(t *testing.T) {
+	tracker := newTestTracker(t)
+	at := time.Now()
+	tracker.Record(types.SensorDisk, "disk0", "/dev/sda", 35, 60, 70, at, types.TemperatureConfig{})
+	tracker.Record(types.SensorEnclosure, "enc0:1", "sensor 1", 28, 40, 50, at, types.TemperatureConfig{})
+
+	var b strings.Builder
+	require.NoError(t, tracker.WritePrometheus(&b))
+	assert.Contains(t, b.String(), `rodent_disk_temperature_celsius{device_id="disk0",device="/dev/sda"} 35`)
+	assert.Contains(t, b.String(), `rodent_enclosure_temperature_celsius{sensor="enc0:1",location="sensor 1"} 28`)
+}
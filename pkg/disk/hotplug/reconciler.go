@@ -201,7 +201,13 @@ func (r *Reconciler) hasChanged(cached, discovered *types.PhysicalDisk) bool {
 		return true
 	}
 
-	if cached.DevicePath != discovered.DevicePath {
+	// Device paths are not compared: the kernel may enumerate disks in
+	// another order after a reboot, and the device ID, not the path, is
+	// what identifies a disk
+
+	// A multipath disk that lost or regained a path is the same disk; the
+	// change is reported as a path change rather than a removal
+	if pathSummary(cached.Multipath) != pathSummary(discovered.Multipath) {
 		return true
 	}
 
@@ -214,6 +220,14 @@ func (r *Reconciler) hasChanged(cached, discovered *types.PhysicalDisk) bool {
 	return false
 }
 
+// pathSummary counts the paths and active paths of a multipath disk
+func pathSummary(info *types.MultipathInfo) [2]int {
+	if info == nil {
+		return [2]int{}
+	}
+	return [2]int{len(info.Paths), info.ActivePaths()}
+}
+
 // TriggerNow triggers an immediate reconciliation pass
 func (r *Reconciler) TriggerNow() {
 	go r.reconcile()
@@ -0,0 +1,102 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package hotplug
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReconciler(
+	t *testing.T,
+	cache map[string]*types.PhysicalDisk,
+	discovered []*types.PhysicalDisk,
+) *Reconciler {
+	l, err := logger.NewTag(logger.Config{LogLevel: "debug"}, "test.disk.reconciler")
+	require.NoError(t, err)
+
+	return NewReconciler(l, time.Minute,
+		func(ctx context.Context) ([]*types.PhysicalDisk, error) { return discovered, nil },
+		func() map[string]*types.PhysicalDisk { return cache },
+	)
+}
+
+func reconcileOnce(t *testing.T, r *Reconciler) *ReconciliationResult {
+	r.reconcile()
+	select {
+	case result := <-r.Events():
+		return result
+	default:
+		t.Fatal("reconciliation sent no result")
+		return nil
+	}
+}
+
+func disk(id, source, path string) *types.PhysicalDisk {
+	return &types.PhysicalDisk{
+		DeviceID:       id,
+		DeviceIDSource: source,
+		DevicePath:     path,
+		Serial:         id,
+		Model:          "WDC WD80EFZZ",
+		SizeBytes:      8001563222016,
+	}
+}
+
+func TestReconcileRebootReorder(t *testing.T) {
+	// The kernel named the disks in another order after a reboot
+	cache := map[string]*types.PhysicalDisk{
+		"SER1": disk("SER1", "serial", "/dev/sda"),
+		"SER2": disk("SER2", "serial", "/dev/sdb"),
+		"SER3": disk("SER3", "serial", "/dev/sdc"),
+	}
+	discovered := []*types.PhysicalDisk{
+		disk("SER1", "serial", "/dev/sdc"),
+		disk("SER2", "serial", "/dev/sda"),
+		disk("SER3", "serial", "/dev/sdb"),
+	}
+
+	result := reconcileOnce(t, newTestReconciler(t, cache, discovered))
+	assert.Empty(t, result.AddedDevices)
+	assert.Empty(t, result.RemovedDevices)
+	assert.Empty(t, result.ChangedDevices)
+}
+
+func TestReconcileReorderWithRemoval(t *testing.T) {
+	// SER2 was pulled; SER3 took its kernel name
+	cache := map[string]*types.PhysicalDisk{
+		"SER1": disk("SER1", "serial", "/dev/sda"),
+		"SER2": disk("SER2", "serial", "/dev/sdb"),
+		"SER3": disk("SER3", "serial", "/dev/sdc"),
+	}
+	discovered := []*types.PhysicalDisk{
+		disk("SER1", "serial", "/dev/sda"),
+		disk("SER3", "serial", "/dev/sdb"),
+	}
+
+	result := reconcileOnce(t, newTestReconciler(t, cache, discovered))
+	assert.Empty(t, result.AddedDevices)
+	assert.Equal(t, []string{"SER2"}, result.RemovedDevices)
+	assert.Empty(t, result.ChangedDevices)
+}
+
+func TestReconcileDetectsChanges(t *testing.T) {
+	replaced := disk("SER1", "serial", "/dev/sdb")
+	replaced.SizeBytes = 12000138625024
+
+	cache := map[string]*types.PhysicalDisk{"SER1": disk("SER1", "serial", "/dev/sda")}
+	discovered := []*types.PhysicalDisk{replaced, disk("SER4", "serial", "/dev/sdc")}
+
+	result := reconcileOnce(t, newTestReconciler(t, cache, discovered))
+	assert.Equal(t, []string{"SER4"}, result.AddedDevices)
+	assert.Empty(t, result.RemovedDevices)
+	assert.Equal(t, []string{"SER1"}, result.ChangedDevices)
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+// Device IDs from a disk's serial or WWN stay the same when the kernel
+// enumerates disks in another order; device paths are only metadata.
+
+// keyMigration is a saved device moved from an earlier device ID to its
+// stable one
+type keyMigration struct {
+	from string
+	to   string
+}
+
+// pathMove is a disk found under another device path than last seen
+type pathMove struct {
+	deviceID string
+	oldPath  string
+	newPath  string
+}
+
+// stableID reports whether a disk's device ID comes from its serial or
+// WWN rather than from a path
+func stableID(disk *types.PhysicalDisk) bool {
+	return disk.DeviceIDSource == "serial" || disk.DeviceIDSource == "wwn"
+}
+
+// legacyKeys returns the earlier device IDs a disk may be saved under: its
+// by-id links, and its WWN or serial when the other is its ID now. Kernel
+// names such as /dev/sdb are left out, since after a reorder they may name
+// another disk.
+func legacyKeys(disk *types.PhysicalDisk) []string {
+	var keys []string
+	add := func(key string) {
+		if key != "" && key != disk.DeviceID {
+			keys = append(keys, key)
+		}
+	}
+
+	add(disk.Serial)
+	add(disk.WWN)
+	add(disk.ByIDPath)
+	for _, link := range disk.DevLinks {
+		if strings.HasPrefix(link, "/dev/disk/by-id/") && link != disk.ByIDPath && !strings.Contains(link, "-part") {
+			add(link)
+		}
+	}
+	return keys
+}
+
+// migrateDeviceKeys moves saved devices and their probe history from
+// earlier device IDs to the stable IDs of the discovered disks. A device
+// already saved under its stable ID is left as is. The caller must hold
+// the state lock.
+func migrateDeviceKeys(s *types.DiskManagerState, disks []*types.PhysicalDisk) []keyMigration {
+	var migrations []keyMigration
+	for _, disk := range disks {
+		if !stableID(disk) {
+			continue
+		}
+		if _, ok := s.Devices[disk.DeviceID]; ok {
+			continue
+		}
+
+		for _, key := range legacyKeys(disk) {
+			device, ok := s.Devices[key]
+			if !ok {
+				continue
+			}
+
+			delete(s.Devices, key)
+			device.DeviceID = disk.DeviceID
+			s.Devices[disk.DeviceID] = device
+
+			if history, ok := s.ProbeHistory[key]; ok {
+				delete(s.ProbeHistory, key)
+				history.DeviceID = disk.DeviceID
+				s.ProbeHistory[disk.DeviceID] = history
+			}
+			for _, execution := range s.ProbeExecutions {
+				if execution.DeviceID == key {
+					execution.DeviceID = disk.DeviceID
+				}
+			}
+
+			migrations = append(migrations, keyMigration{from: key, to: disk.DeviceID})
+			break
+		}
+	}
+	return migrations
+}
+
+// recordDevicePath updates the last seen path of a saved device, returning
+// the move when the disk is under another path than last seen
+func recordDevicePath(device *types.DeviceState, disk *types.PhysicalDisk) (pathMove, bool) {
+	oldPath := device.DevicePath
+	device.DevicePath = disk.DevicePath
+	if oldPath == "" || oldPath == disk.DevicePath {
+		return pathMove{}, false
+	}
+	return pathMove{deviceID: disk.DeviceID, oldPath: oldPath, newPath: disk.DevicePath}, true
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serialDisk(serial, path string) *types.PhysicalDisk {
+	return &types.PhysicalDisk{
+		DeviceID:       serial,
+		DeviceIDSource: "serial",
+		DevicePath:     path,
+		Serial:         serial,
+		ByIDPath:       "/dev/disk/by-id/ata-WDC_" + serial,
+		DevLinks: []string{
+			"/dev/disk/by-id/ata-WDC_" + serial,
+			"/dev/disk/by-id/ata-WDC_" + serial + "-part1",
+		},
+	}
+}
+
+func TestMigrateDeviceKeys(t *testing.T) {
+	execution := &types.ProbeExecution{ID: "p1", DeviceID: "/dev/disk/by-id/ata-WDC_SER1"}
+	s := &types.DiskManagerState{
+		Devices: map[string]*types.DeviceState{
+			"/dev/disk/by-id/ata-WDC_SER1": {DeviceID: "/dev/disk/by-id/ata-WDC_SER1", State: types.DiskStateOnline},
+			"/dev/sdc":                     {DeviceID: "/dev/sdc", State: types.DiskStateAvailable},
+			"SER3":                         {DeviceID: "SER3", State: types.DiskStateOnline},
+			"/dev/disk/by-id/ata-WDC_SER3": {DeviceID: "/dev/disk/by-id/ata-WDC_SER3"},
+		},
+		ProbeExecutions: map[string]*types.ProbeExecution{"p1": execution},
+		ProbeHistory: map[string]*types.ProbeHistory{
+			"/dev/disk/by-id/ata-WDC_SER1": {
+				DeviceID:   "/dev/disk/by-id/ata-WDC_SER1",
+				Executions: []*types.ProbeExecution{execution},
+			},
+		},
+	}
+	disks := []*types.PhysicalDisk{
+		serialDisk("SER1", "/dev/sda"),
+		serialDisk("SER2", "/dev/sdc"),
+		serialDisk("SER3", "/dev/sdb"),
+	}
+
+	migrations := migrateDeviceKeys(s, disks)
+	assert.Equal(t, []keyMigration{{from: "/dev/disk/by-id/ata-WDC_SER1", to: "SER1"}}, migrations)
+
+	// The by-id entry moved with its probe history
+	require.Contains(t, s.Devices, "SER1")
+	assert.Equal(t, "SER1", s.Devices["SER1"].DeviceID)
+	assert.Equal(t, types.DiskStateOnline, s.Devices["SER1"].State)
+	assert.NotContains(t, s.Devices, "/dev/disk/by-id/ata-WDC_SER1")
+	require.Contains(t, s.ProbeHistory, "SER1")
+	assert.Equal(t, "SER1", s.ProbeHistory["SER1"].DeviceID)
+	assert.Equal(t, "SER1", execution.DeviceID)
+
+	// A kernel name may belong to another disk after a reorder
+	assert.Contains(t, s.Devices, "/dev/sdc")
+	assert.NotContains(t, s.Devices, "SER2")
+
+	// A device already under its stable ID is left alone
+	assert.Contains(t, s.Devices, "/dev/disk/by-id/ata-WDC_SER3")
+}
+
+func TestMigrateDeviceKeysWWNToSerial(t *testing.T) {
+	s := &types.DiskManagerState{
+		Devices: map[string]*types.DeviceState{
+			"0x5000c500a1b2c3d4": {DeviceID: "0x5000c500a1b2c3d4", PoolName: "tank"},
+		},
+	}
+	disk := serialDisk("SER1", "/dev/sda")
+	disk.WWN = "0x5000c500a1b2c3d4"
+
+	migrations := migrateDeviceKeys(s, []*types.PhysicalDisk{disk})
+	assert.Len(t, migrations, 1)
+	require.Contains(t, s.Devices, "SER1")
+	assert.Equal(t, "tank", s.Devices["SER1"].PoolName)
+}
+
+func TestMigrateDeviceKeysSkipsPathIDs(t *testing.T) {
+	s := &types.DiskManagerState{
+		Devices: map[string]*types.DeviceState{
+			"/dev/disk/by-id/ata-QEMU": {DeviceID: "/dev/disk/by-id/ata-QEMU"},
+		},
+	}
+	disk := &types.PhysicalDisk{
+		DeviceID:       "/dev/sda",
+		DeviceIDSource: "path",
+		DevicePath:     "/dev/sda",
+		ByIDPath:       "/dev/disk/by-id/ata-QEMU",
+	}
+
+	assert.Empty(t, migrateDeviceKeys(s, []*types.PhysicalDisk{disk}))
+	assert.Contains(t, s.Devices, "/dev/disk/by-id/ata-QEMU")
+}
+
+func TestRecordDevicePathAfterReorder(t *testing.T) {
+	// Before the reboot SER1 was sda and SER2 sdb; afterwards they swap
+	s := &types.DiskManagerState{
+		Devices: map[string]*types.DeviceState{
+			"SER1": {DeviceID: "SER1", DevicePath: "/dev/sda"},
+			"SER2": {DeviceID: "SER2", DevicePath: "/dev/sdb"},
+		},
+	}
+	disks := []*types.PhysicalDisk{
+		serialDisk("SER1", "/dev/sdb"),
+		serialDisk("SER2", "/dev/sda"),
+	}
+
+	assert.Empty(t, migrateDeviceKeys(s, disks))
+
+	var moves []pathMove
+	for _, disk := range disks {
+		device, ok := s.Devices[disk.DeviceID]
+		require.True(t, ok, disk.DeviceID)
+		if move, moved := recordDevicePath(device, disk); moved {
+			moves = append(moves, move)
+		}
+	}
+
+	assert.Equal(t, []pathMove{
+		{deviceID: "SER1", oldPath: "/dev/sda", newPath: "/dev/sdb"},
+		{deviceID: "SER2", oldPath: "/dev/sdb", newPath: "/dev/sda"},
+	}, moves)
+	assert.Equal(t, "/dev/sdb", s.Devices["SER1"].DevicePath)
+	assert.Equal(t, "/dev/sda", s.Devices["SER2"].DevicePath)
+
+	// A device saved before paths were recorded has no move to report
+	device := &types.DeviceState{DeviceID: "SER3"}
+	_, moved := recordDevicePath(device, serialDisk("SER3", "/dev/sdc"))
+	assert.False(t, moved)
+	assert.Equal(t, "/dev/sdc", device.DevicePath)
+}
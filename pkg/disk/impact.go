@@ -0,0 +1,326 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/tools"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
+)
+
+// impactTimeout bounds finding what a failing disk puts at risk
+const impactTimeout = 30 * time.Second
+
+// PoolResolver lists what a pool stores
+type PoolResolver interface {
+	PoolContents(ctx context.Context, pool string) (*resolver.PoolContents, error)
+}
+
+// DiskImpact is what a failing disk puts at risk: the pool and vdev it
+// belongs to, the redundancy the vdev has left, and the datasets and shares
+// stored on the pool. Every dataset of a pool is at risk, since pools stripe
+// data across their vdevs.
+type DiskImpact struct {
+	DeviceID  string      `json:"device_id"`
+	Serial    string      `json:"serial,omitempty"`
+	Pool      string      `json:"pool"`
+	PoolState string      `json:"pool_state,omitempty"`
+	Vdev      *VdevImpact `json:"vdev,omitempty"` // Nil when the disk is not in the pool's status
+
+	// Redundancy is how many more disks the vdev can lose without losing
+	// data once this disk has failed; negative when the vdev cannot serve
+	// its data. Nil when the vdev is unknown.
+	Redundancy *int `json:"redundancy,omitempty"`
+
+	Datasets []string            `json:"datasets"`
+	Shares   []resolver.ShareRef `json:"shares"`
+	Errors   []string            `json:"errors,omitempty"` // Parts of the impact that could not be found
+	FoundAt  time.Time           `json:"found_at"`
+}
+
+// VdevImpact is the vdev holding a failing disk. Top-level disks with no
+// redundancy are their own vdev, of type "disk".
+type VdevImpact struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	State       string `json:"state"`
+	Parity      int    `json:"parity"` // Disks the vdev can lose
+	Disks       int    `json:"disks"`
+	FailedDisks int    `json:"failed_disks"` // Including this disk
+}
+
+// DataLoss reports whether the vdev can no longer serve its data
+func (i *DiskImpact) DataLoss() bool {
+	return i.Redundancy != nil && *i.Redundancy < 0
+}
+
+// Summary describes the impact in one line for notifications
+func (i *DiskImpact) Summary() string {
+	parts := []string{"pool " + i.Pool}
+	if i.PoolState != "" {
+		parts[0] += " (" + i.PoolState + ")"
+	}
+	if i.Vdev != nil {
+		parts = append(parts, fmt.Sprintf("vdev %s (%s, %d of %d disks failed)",
+			i.Vdev.Name, i.Vdev.Type, i.Vdev.FailedDisks, i.Vdev.Disks))
+	}
+	if i.Redundancy != nil {
+		switch r := *i.Redundancy; {
+		case r < 0:
+			parts = append(parts, "data unavailable")
+		case r == 0:
+			parts = append(parts, "no redundancy left")
+		default:
+			parts = append(parts, fmt.Sprintf("survives %d more disk failures", r))
+		}
+	}
+	parts = append(parts, fmt.Sprintf("%d datasets and %d shares at risk", len(i.Datasets), len(i.Shares)))
+	return strings.Join(parts, ", ")
+}
+
+// Metadata returns the impact as event metadata, or nil without one
+func (i *DiskImpact) Metadata() map[string]string {
+	if i == nil {
+		return nil
+	}
+	md := map[string]string{
+		"pool":           i.Pool,
+		"impact":         i.Summary(),
+		"datasets_count": strconv.Itoa(len(i.Datasets)),
+		"shares_count":   strconv.Itoa(len(i.Shares)),
+	}
+	if i.Vdev != nil {
+		md["vdev"] = i.Vdev.Name
+		md["vdev_type"] = i.Vdev.Type
+		md["vdev_state"] = i.Vdev.State
+	}
+	if i.Redundancy != nil {
+		md["redundancy"] = strconv.Itoa(*i.Redundancy)
+	}
+	if len(i.Shares) > 0 {
+		names := make([]string, len(i.Shares))
+		for n, share := range i.Shares {
+			names[n] = share.Name
+		}
+		md["shares"] = strings.Join(names, ",")
+	}
+	return md
+}
+
+// UsePoolResolver lists the datasets and shares at risk in the impact of
+// failing disks
+func (m *Manager) UsePoolResolver(r PoolResolver) {
+	m.pools.Store(&r)
+}
+
+// GetDiskImpact returns what a failure of the disk puts at risk, or nil when
+// the disk is in no pool
+func (m *Manager) GetDiskImpact(ctx context.Context, deviceID string) (*DiskImpact, error) {
+	disk, err := m.GetDisk(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	return m.diskImpact(ctx, disk), nil
+}
+
+// diskImpact finds what a failure of the disk puts at risk. Parts that
+// cannot be found are listed in the impact's errors.
+func (m *Manager) diskImpact(ctx context.Context, disk *types.PhysicalDisk) *DiskImpact {
+	if disk.PoolName == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, impactTimeout)
+	defer cancel()
+
+	impact := &DiskImpact{
+		DeviceID: disk.DeviceID,
+		Serial:   disk.Serial,
+		Pool:     disk.PoolName,
+		Datasets: []string{},
+		Shares:   []resolver.ShareRef{},
+		FoundAt:  time.Now(),
+	}
+
+	if m.zpool == nil {
+		impact.Errors = append(impact.Errors, "zpool is not available")
+	} else if status, err := m.zpool.GetPoolStatus(ctx); err != nil {
+		impact.Errors = append(impact.Errors, "pool status: "+err.Error())
+	} else if pool, ok := status.Pools[disk.PoolName]; ok {
+		impact.PoolState = pool.State
+		if vdev, redundancy, ok := vdevImpact(pool, disk.VdevGUID); ok {
+			impact.Vdev = vdev
+			impact.Redundancy = &redundancy
+		}
+	}
+
+	if ptr := m.pools.Load(); ptr != nil {
+		contents, err := (*ptr).PoolContents(ctx, disk.PoolName)
+		if err != nil {
+			impact.Errors = append(impact.Errors, "pool contents: "+err.Error())
+		} else {
+			impact.Datasets = contents.Datasets
+			impact.Shares = contents.Shares
+		}
+	}
+
+	return impact
+}
+
+// reportHealthChanged emits the health change of a disk and notifies the
+// listeners, with the impact of the failure when the disk is failing
+func (m *Manager) reportHealthChanged(ctx context.Context, disk types.PhysicalDisk, oldHealth types.HealthStatus) {
+	var impact *DiskImpact
+	failing := disk.Health == types.HealthCritical || disk.Health == types.HealthFailed
+	if failing {
+		impact = m.diskImpact(ctx, &disk)
+	}
+
+	m.eventEmitter.EmitDiskHealthChanged(&disk, oldHealth, disk.Health, impact.Metadata())
+	m.notifyHealthChanged(disk, oldHealth)
+	if failing {
+		m.diskFailed(disk, "health "+string(disk.Health), impact)
+	}
+}
+
+// reportStateChanged emits a pool state change of a disk found by
+// discovery, with the impact of the failure when ZFS faulted the disk or
+// cannot open it
+func (m *Manager) reportStateChanged(ctx context.Context, disk types.PhysicalDisk, oldState types.DiskState) {
+	var impact *DiskImpact
+	failing := disk.State == types.DiskStateFaulted || disk.State == types.DiskStateUnavail
+	if failing {
+		impact = m.diskImpact(ctx, &disk)
+	}
+
+	m.eventEmitter.EmitDiskStateChanged(&disk, oldState, disk.State, impact.Metadata())
+	if failing {
+		m.diskFailed(disk, "state "+string(disk.State), impact)
+	}
+}
+
+// diskFailed logs a failing disk with what it puts at risk and notifies the
+// failure listeners
+func (m *Manager) diskFailed(disk types.PhysicalDisk, reason string, impact *DiskImpact) {
+	if impact != nil {
+		m.logger.Error("disk failure puts pool at risk",
+			"device_id", disk.DeviceID,
+			"serial", disk.Serial,
+			"reason", reason,
+			"impact", impact.Summary())
+	} else {
+		m.logger.Warn("disk failing", "device_id", disk.DeviceID, "serial", disk.Serial, "reason", reason)
+	}
+
+	m.notifyDiskFailed(DiskFailure{
+		Disk:       disk,
+		Reason:     reason,
+		Impact:     impact,
+		DetectedAt: time.Now(),
+	})
+}
+
+// vdevImpact finds the vdev holding the leaf with the GUID and how many more
+// of its disks it can lose once that leaf has failed
+func vdevImpact(pool tools.Pool, guid string) (*VdevImpact, int, bool) {
+	if guid == "" {
+		return nil, 0, false
+	}
+	path, ok := findVdev(pool.VDevs, guid, nil)
+	if !ok {
+		return nil, 0, false
+	}
+
+	// The vdev giving redundancy is the nearest ancestor that is not a
+	// spare or replacement in progress; child is its member on the way to
+	// the leaf. Without one, the leaf is a top-level disk of its own.
+	leaf := path[len(path)-1]
+	vdev, child := leaf, leaf
+	for n := len(path) - 2; n >= 0; n-- {
+		if path[n].Type == "spare" || path[n].Type == "replacing" {
+			continue
+		}
+		if path[n].Type != "root" {
+			vdev, child = path[n], path[n+1]
+		}
+		break
+	}
+
+	impact := &VdevImpact{
+		Name:  vdev.Name,
+		Type:  vdev.Type,
+		State: vdev.State,
+	}
+	if vdev == leaf {
+		impact.Type = "disk"
+		impact.Disks = 1
+		impact.FailedDisks = 1
+		return impact, -1, true
+	}
+
+	for _, member := range vdev.VDevs {
+		impact.Disks++
+		if member == child || vdevFailed(member.State) {
+			impact.FailedDisks++
+		}
+	}
+	impact.Parity = vdevParity(vdev, impact.Disks)
+	return impact, impact.Parity - impact.FailedDisks, true
+}
+
+// findVdev returns the vdevs from the top of the tree down to the one with
+// the GUID
+func findVdev(vdevs map[string]*tools.VDev, guid string, path []*tools.VDev) ([]*tools.VDev, bool) {
+	for _, vdev := range vdevs {
+		if vdev == nil {
+			continue
+		}
+		current := append(path[:len(path):len(path)], vdev)
+		if vdev.GUID == guid {
+			return current, true
+		}
+		if found, ok := findVdev(vdev.VDevs, guid, current); ok {
+			return found, true
+		}
+	}
+	return nil, false
+}
+
+// parityPattern matches the parity in raidz and draid vdev names, such as
+// raidz2-0 or draid1:4d:8c:1s-0
+var parityPattern = regexp.MustCompile(`^(?:raidz|draid)(\d)`)
+
+// vdevParity returns how many disks a vdev of the given number of disks
+// can lose
+func vdevParity(vdev *tools.VDev, disks int) int {
+	switch {
+	case vdev.Type == "mirror":
+		return disks - 1
+	case vdev.Type == "raidz" || vdev.Type == "draid":
+		if m := parityPattern.FindStringSubmatch(vdev.Name); m != nil {
+			parity, _ := strconv.Atoi(m[1])
+			return parity
+		}
+		return 1
+	default:
+		return 0
+	}
+}
+
+// vdevFailed reports whether a vdev in the state no longer serves data
+func vdevFailed(state string) bool {
+	switch strings.ToUpper(state) {
+	case "FAULTED", "UNAVAIL", "OFFLINE", "REMOVED":
+		return true
+	}
+	return false
+}
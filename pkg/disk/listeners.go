@@ -0,0 +1,64 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+// HealthListener is called after the health of a disk changes; the disk
+// carries its new health
+type HealthListener func(disk types.PhysicalDisk, oldHealth types.HealthStatus)
+
+// DiskFailure is a disk found failing: its health turned critical or failed,
+// ZFS faulted it or it was removed while in a pool
+type DiskFailure struct {
+	Disk       types.PhysicalDisk `json:"disk"`
+	Reason     string             `json:"reason"`           // Such as "health CRITICAL" or "state FAULTED"
+	Impact     *DiskImpact        `json:"impact,omitempty"` // Nil when the disk is in no pool
+	DetectedAt time.Time          `json:"detected_at"`
+}
+
+// FailureListener is called after a disk is found failing
+type FailureListener func(failure DiskFailure)
+
+// OnHealthChanged registers a listener for disk health changes found by
+// health checks. Listeners run in their own goroutine.
+func (m *Manager) OnHealthChanged(fn HealthListener) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// OnDiskFailed registers a listener for failing disks, found by health
+// checks, discovery or removal. Listeners run in their own goroutine.
+func (m *Manager) OnDiskFailed(fn FailureListener) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.failureListeners = append(m.failureListeners, fn)
+}
+
+// notifyHealthChanged passes a disk whose health changed to the registered
+// listeners
+func (m *Manager) notifyHealthChanged(disk types.PhysicalDisk, oldHealth types.HealthStatus) {
+	m.listenersMu.RLock()
+	defer m.listenersMu.RUnlock()
+
+	for _, fn := range m.listeners {
+		go fn(disk, oldHealth)
+	}
+}
+
+// notifyDiskFailed passes a failing disk to the registered listeners
+func (m *Manager) notifyDiskFailed(failure DiskFailure) {
+	m.listenersMu.RLock()
+	defer m.listenersMu.RUnlock()
+
+	for _, fn := range m.failureListeners {
+		go fn(failure)
+	}
+}
@@ -7,10 +7,12 @@ package disk
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
 	"github.com/stratastor/logger"
+	rodentconfig "github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/internal/events"
 	"github.com/stratastor/rodent/pkg/disk/config"
@@ -43,6 +45,14 @@ type Manager struct {
 	probeScheduler *probing.ProbeScheduler
 	eventEmitter   *diskevents.Emitter
 	hotplugHandler *hotplug.EventHandler
+	zpool          *tools.ZpoolExecutor // Nil when zpool is not installed
+	temperatures   *health.TemperatureTracker
+
+	// Lists the datasets and shares at risk when a disk fails
+	pools atomic.Pointer[PoolResolver]
+
+	// Creates the pools planned from discovered disks
+	creator atomic.Pointer[PoolCreator]
 
 	// Background tasks
 	scheduler gocron.Scheduler
@@ -52,6 +62,11 @@ type Manager struct {
 	deviceCache map[string]*types.PhysicalDisk // DeviceID (serial) -> PhysicalDisk
 	pathToID    map[string]string               // DevicePath -> DeviceID mapping
 	cacheMu     sync.RWMutex
+
+	// Health change and failure listeners
+	listeners        []HealthListener
+	failureListeners []FailureListener
+	listenersMu      sync.RWMutex
 }
 
 // NewManager creates a new disk manager
@@ -129,6 +144,7 @@ func NewManager(
 
 	// Initialize discoverer (with zpool executor for pool membership detection)
 	discoverer := discovery.NewDiscoverer(l, lsblk, smartctl, udevadm, zpool, toolChecker, envDetector)
+	discoverer.SetExclusions(cfg.Discovery.Exclude)
 
 	// Initialize topology mapper
 	topoMapper := topology.NewMapper(l, lsscsi, sgses, toolChecker)
@@ -176,6 +192,8 @@ func NewManager(
 		healthMonitor:  healthMonitor,
 		probeScheduler: probeScheduler,
 		eventEmitter:   eventEmitter,
+		zpool:          zpool,
+		temperatures:   health.NewTemperatureTracker(l, rodentconfig.GetDiskDir()),
 		scheduler:      scheduler,
 		deviceCache:    make(map[string]*types.PhysicalDisk),
 		pathToID:       make(map[string]string),
@@ -353,16 +371,26 @@ func (m *Manager) runDiscovery(ctx context.Context) error {
 
 	// Update device cache
 	m.cacheMu.Lock()
+	pathChanges := multipathChanges(m.deviceCache, disks)
 	m.deviceCache = make(map[string]*types.PhysicalDisk)
 	m.pathToID = make(map[string]string)
 	for _, disk := range disks {
 		m.deviceCache[disk.DeviceID] = disk
 		m.pathToID[disk.DevicePath] = disk.DeviceID
+		if disk.Multipath != nil {
+			// Hotplug events name the path devices of multipath disks
+			for _, p := range disk.Multipath.Paths {
+				m.pathToID[p.Device] = disk.DeviceID
+			}
+		}
 	}
 	m.cacheMu.Unlock()
 
-	// Track new devices discovered
+	// Track new devices discovered and pool state changes
 	newDevices := 0
+	var stateChanges []stateChange
+	var migrations []keyMigration
+	var moves []pathMove
 
 	// Update state with device info
 	m.stateManager.WithLock(func(s *types.DiskManagerState) {
@@ -370,9 +398,18 @@ func (m *Manager) runDiscovery(ctx context.Context) error {
 			s.Devices = make(map[string]*types.DeviceState)
 		}
 
+		// Devices saved under earlier, path-like IDs move to their stable IDs
+		migrations = migrateDeviceKeys(s, disks)
+
 		// Update or add devices
 		for _, disk := range disks {
 			if existing, ok := s.Devices[disk.DeviceID]; ok {
+				if disk.PoolName != "" && existing.State != disk.State {
+					stateChanges = append(stateChanges, stateChange{disk: *disk, oldState: existing.State})
+				}
+				if move, moved := recordDevicePath(existing, disk); moved {
+					moves = append(moves, move)
+				}
 				// Update existing device
 				existing.State = disk.State         // Update state from discovery (pool state or AVAILABLE)
 				existing.Health = disk.Health
@@ -382,6 +419,7 @@ func (m *Manager) runDiscovery(ctx context.Context) error {
 				// New device discovered
 				newDevices++
 				deviceState := types.NewDeviceState(disk.DeviceID)
+				deviceState.DevicePath = disk.DevicePath
 				deviceState.State = disk.State      // Set initial state from discovery
 				deviceState.Health = disk.Health
 				deviceState.PoolName = disk.PoolName // Store pool membership
@@ -395,9 +433,32 @@ func (m *Manager) runDiscovery(ctx context.Context) error {
 	// Record discovery completion with real-time counter update
 	m.stateManager.RecordDiscoveryCompleted(newDevices)
 
+	for _, migration := range migrations {
+		m.logger.Info("moved saved disk to its stable device ID",
+			"old_device_id", migration.from,
+			"device_id", migration.to)
+	}
+	for _, move := range moves {
+		m.logger.Info("disk found under a new device path",
+			"device_id", move.deviceID,
+			"old_path", move.oldPath,
+			"new_path", move.newPath)
+	}
+
+	for _, change := range stateChanges {
+		m.reportStateChanged(ctx, change.disk, change.oldState)
+	}
+	m.reportPathChanges(pathChanges)
+
 	return nil
 }
 
+// stateChange is a pool disk whose state discovery changed
+type stateChange struct {
+	disk     types.PhysicalDisk
+	oldState types.DiskState
+}
+
 // runHealthCheck performs health check on all disks
 func (m *Manager) runHealthCheck(ctx context.Context) error {
 	m.logger.Debug("running health check")
@@ -421,19 +482,24 @@ func (m *Manager) runHealthCheck(ctx context.Context) error {
 		return errors.Wrap(err, errors.DiskHealthCheckFailed)
 	}
 
+	// ZFS error counters of pool disks add to their failure risk
+	zfsErrors := m.poolDiskErrors(ctx)
+
 	// Update state and cache
+	var changed []healthChange
 	for _, status := range healthStatuses {
 		// Update cache
+		var vdevGUID string
 		m.cacheMu.Lock()
 		if disk, ok := m.deviceCache[status.DeviceID]; ok {
+			vdevGUID = disk.VdevGUID
 			oldHealth := disk.Health
 			disk.Health = status.Health
 			disk.HealthReason = status.HealthReason
 			disk.SMARTInfo = status.SMARTInfo
 
-			// Emit health change event if changed
 			if oldHealth != status.Health {
-				m.eventEmitter.EmitDiskHealthChanged(disk, oldHealth, status.Health)
+				changed = append(changed, healthChange{disk: *disk, oldHealth: oldHealth})
 			}
 		}
 		m.cacheMu.Unlock()
@@ -446,15 +512,30 @@ func (m *Manager) runHealthCheck(ctx context.Context) error {
 					deviceState.HealthChanges++
 				}
 				deviceState.HealthReason = status.HealthReason
+				m.updateRisk(deviceState, status, zfsErrors[vdevGUID])
 			}
 		})
 	}
 
 	m.stateManager.SaveDebounced()
 
+	m.recordTemperatures(ctx, healthStatuses)
+
+	// Emit health change events outside the cache lock: the impact of a
+	// failing disk takes pool and dataset listings to find
+	for _, change := range changed {
+		m.reportHealthChanged(ctx, change.disk, change.oldHealth)
+	}
+
 	return nil
 }
 
+// healthChange is a disk whose health a health check changed
+type healthChange struct {
+	disk      types.PhysicalDisk
+	oldHealth types.HealthStatus
+}
+
 // GetInventory returns the current disk inventory, enriched with managed state
 func (m *Manager) GetInventory(filter *types.DiskFilter) []*types.PhysicalDisk {
 	m.cacheMu.RLock()
@@ -481,6 +562,7 @@ func (m *Manager) GetInventory(filter *types.DiskFilter) []*types.PhysicalDisk {
 			enrichedDisk.DiscoveredAt = deviceState.FirstSeenAt
 			enrichedDisk.LastSeenAt = deviceState.LastSeenAt
 			enrichedDisk.PoolName = deviceState.PoolName // Enrich from persistent state
+			enrichedDisk.Risk = deviceState.Risk
 		}
 
 		enrichedDisks = append(enrichedDisks, &enrichedDisk)
@@ -489,6 +571,12 @@ func (m *Manager) GetInventory(filter *types.DiskFilter) []*types.PhysicalDisk {
 	return enrichedDisks
 }
 
+// GetIgnoredDisks returns the devices the exclusion rules keep out of the
+// inventory, with the rule that matched each
+func (m *Manager) GetIgnoredDisks() []*types.IgnoredDisk {
+	return m.discoverer.GetIgnored()
+}
+
 // GetDisk returns a specific disk by ID, enriched with managed state
 func (m *Manager) GetDisk(deviceID string) (*types.PhysicalDisk, error) {
 	m.cacheMu.RLock()
@@ -512,6 +600,7 @@ func (m *Manager) GetDisk(deviceID string) (*types.PhysicalDisk, error) {
 		enrichedDisk.DiscoveredAt = deviceState.FirstSeenAt
 		enrichedDisk.LastSeenAt = deviceState.LastSeenAt
 		enrichedDisk.PoolName = deviceState.PoolName // Enrich from persistent state
+		enrichedDisk.Risk = deviceState.Risk
 	} else {
 		// If no managed state exists, use defaults
 		m.logger.Debug("no device state found, using defaults",
@@ -542,7 +631,7 @@ func (m *Manager) ResolveDevices(filter *types.DiskFilter) (map[string]string, e
 	result := make(map[string]string)
 	for _, disk := range m.deviceCache {
 		if filter == nil || disk.MatchesFilter(filter) {
-			result[disk.DeviceID] = disk.DevicePath
+			result[disk.DeviceID] = disk.SMARTDevicePath()
 		}
 	}
 
@@ -576,6 +665,12 @@ func (m *Manager) getDeviceCache() map[string]*types.PhysicalDisk {
 func (m *Manager) handleDeviceAdded(ctx context.Context, deviceID string) error {
 	m.logger.Info("processing device addition", "lookup_key", deviceID)
 
+	// A path coming back to a multipath disk is not a new disk
+	m.cacheMu.RLock()
+	known, _, existed := m.findDiskInCache(deviceID)
+	m.cacheMu.RUnlock()
+	pathRestored := existed && known.Multipath != nil
+
 	// Trigger discovery to pick up the new device
 	// This ensures we use the same discovery logic (lsblk + udevadm) for consistency
 	if err := m.runDiscovery(ctx); err != nil {
@@ -596,7 +691,14 @@ func (m *Manager) handleDeviceAdded(ctx context.Context, deviceID string) error
 			"actual_device_id", actualDeviceID,
 			"device_path", disk.DevicePath,
 			"id_source", disk.DeviceIDSource)
-		m.eventEmitter.EmitDiskDiscovered(disk)
+		if !pathRestored {
+			m.eventEmitter.EmitDiskDiscovered(disk)
+		}
+	} else if ignored := m.findIgnored(deviceID); ignored != nil {
+		m.logger.Debug("added device is excluded from discovery",
+			"lookup_key", deviceID,
+			"device_path", ignored.DevicePath,
+			"reason", ignored.Reason)
 	} else {
 		m.logger.Warn("device not found in cache after discovery",
 			"lookup_key", deviceID)
@@ -605,6 +707,21 @@ func (m *Manager) handleDeviceAdded(ctx context.Context, deviceID string) error
 	return nil
 }
 
+// findIgnored returns the excluded device a udev lookup key names, if any
+func (m *Manager) findIgnored(lookupKey string) *types.IgnoredDisk {
+	devicePath := lookupKey
+	if len(devicePath) > 0 && devicePath[0] != '/' {
+		devicePath = "/dev/" + devicePath
+	}
+	for _, disk := range m.discoverer.GetIgnored() {
+		if disk.DeviceID == lookupKey || disk.Serial == lookupKey || disk.WWN == lookupKey ||
+			disk.DevicePath == devicePath {
+			return disk
+		}
+	}
+	return nil
+}
+
 // findDiskInCache performs intelligent multi-key lookup to find a disk in cache
 // This handles cases where the lookup key might not match the cached DeviceID
 // Returns the disk and its actual DeviceID if found
@@ -665,6 +782,25 @@ func (m *Manager) findDiskInCache(lookupKey string) (*types.PhysicalDisk, string
 func (m *Manager) handleDeviceRemoved(deviceID string) error {
 	m.logger.Info("processing device removal", "device_id", deviceID)
 
+	// Losing a path to a multipath disk leaves the disk present; discovery
+	// reports the lost path
+	m.cacheMu.RLock()
+	known, _, existed := m.findDiskInCache(deviceID)
+	m.cacheMu.RUnlock()
+	if existed && known.Multipath != nil {
+		if err := m.runDiscovery(m.ctx); err != nil {
+			m.logger.Warn("discovery failed after multipath path removal",
+				"lookup_key", deviceID,
+				"error", err)
+		}
+		m.cacheMu.RLock()
+		_, stillPresent := m.deviceCache[known.DeviceID]
+		m.cacheMu.RUnlock()
+		if stillPresent {
+			return nil
+		}
+	}
+
 	// Use smart multi-key lookup to find the disk
 	m.cacheMu.Lock()
 	disk, actualDeviceID, exists := m.findDiskInCache(deviceID)
@@ -682,6 +818,12 @@ func (m *Manager) handleDeviceRemoved(deviceID string) error {
 	m.cacheMu.Unlock()
 
 	if !exists {
+		if ignored := m.findIgnored(deviceID); ignored != nil {
+			m.logger.Debug("removed device is excluded from discovery",
+				"lookup_key", deviceID,
+				"device_path", ignored.DevicePath)
+			return nil
+		}
 		m.logger.Warn("device not found in cache during removal",
 			"lookup_key", deviceID)
 		return nil
@@ -690,8 +832,12 @@ func (m *Manager) handleDeviceRemoved(deviceID string) error {
 	// Update state
 	m.stateManager.UpdateDeviceState(actualDeviceID, types.DiskStateOffline, types.HealthUnknown)
 
-	// Emit event
-	m.eventEmitter.EmitDiskRemoved(disk)
+	// A disk removed from a pool fails it; report what that puts at risk
+	impact := m.diskImpact(m.ctx, disk)
+	m.eventEmitter.EmitDiskRemoved(disk, impact.Metadata())
+	if impact != nil {
+		m.diskFailed(*disk, "removed", impact)
+	}
 
 	return nil
 }
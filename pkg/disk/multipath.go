@@ -0,0 +1,97 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import "github.com/stratastor/rodent/pkg/disk/types"
+
+// pathStateGone is the state reported for a path that disappeared
+const pathStateGone = "gone"
+
+// pathChange is a path to a multipath disk whose state discovery changed
+type pathChange struct {
+	disk     types.PhysicalDisk
+	path     string
+	oldState string
+	newState string
+}
+
+// multipathChanges compares the paths of multipath disks with those in the
+// previous cache, so a lost path is reported as such rather than as the
+// disk being removed and added again
+func multipathChanges(old map[string]*types.PhysicalDisk, disks []*types.PhysicalDisk) []pathChange {
+	var changes []pathChange
+	for _, disk := range disks {
+		prev, ok := old[disk.DeviceID]
+		if !ok || prev.Multipath == nil {
+			continue
+		}
+
+		reported := *disk
+		if reported.Multipath == nil {
+			// A disk without a multipath map is one disk again once a
+			// single path is left
+			reported.Multipath = &types.MultipathInfo{
+				Paths: []types.MultipathPath{{Device: disk.DevicePath, State: types.PathStateRunning}},
+			}
+		}
+
+		previous, current := pathStates(prev.Multipath), pathStates(reported.Multipath)
+		for device, oldState := range previous {
+			newState, ok := current[device]
+			if !ok {
+				newState = pathStateGone
+			}
+			if newState != oldState {
+				changes = append(changes, pathChange{disk: reported, path: device, oldState: oldState, newState: newState})
+			}
+		}
+		for device, newState := range current {
+			if _, ok := previous[device]; !ok {
+				changes = append(changes, pathChange{disk: reported, path: device, oldState: pathStateGone, newState: newState})
+			}
+		}
+	}
+	return changes
+}
+
+// pathStates maps the path devices of a multipath disk to their states
+func pathStates(info *types.MultipathInfo) map[string]string {
+	states := make(map[string]string, len(info.Paths))
+	for _, p := range info.Paths {
+		state := p.State
+		if p.Active() {
+			state = types.PathStateRunning
+		}
+		states[p.Device] = state
+	}
+	return states
+}
+
+// reportPathChanges logs and emits the path changes of multipath disks
+func (m *Manager) reportPathChanges(changes []pathChange) {
+	for _, change := range changes {
+		disk := change.disk
+		active := disk.Multipath.ActivePaths()
+		switch {
+		case active == 0:
+			m.logger.Error("multipath disk lost its last path",
+				"device_id", disk.DeviceID,
+				"path", change.path,
+				"path_state", change.newState)
+		case change.newState != types.PathStateRunning:
+			m.logger.Warn("multipath disk lost a path",
+				"device_id", disk.DeviceID,
+				"path", change.path,
+				"path_state", change.newState,
+				"active_paths", active)
+		default:
+			m.logger.Info("multipath disk path restored",
+				"device_id", disk.DeviceID,
+				"path", change.path,
+				"active_paths", active)
+		}
+		m.eventEmitter.EmitMultipathPathChanged(&disk, change.path, change.oldState, change.newState)
+	}
+}
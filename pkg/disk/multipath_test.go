@@ -0,0 +1,118 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// multipathDisk is a disk with the given path devices and states
+func multipathDisk(id string, paths ...string) *types.PhysicalDisk {
+	info := &types.MultipathInfo{Name: "mpatha", DMPath: "/dev/mapper/mpatha"}
+	for i := 0; i+1 < len(paths); i += 2 {
+		info.Paths = append(info.Paths, types.MultipathPath{Device: paths[i], State: paths[i+1]})
+	}
+	return &types.PhysicalDisk{DeviceID: id, DevicePath: "/dev/mapper/mpatha", Multipath: info}
+}
+
+func TestMultipathChanges(t *testing.T) {
+	tests := []struct {
+		name string
+		old  *types.PhysicalDisk
+		now  *types.PhysicalDisk
+		want []string // path:old->new
+	}{
+		{
+			name: "unchanged",
+			old:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+		},
+		{
+			name: "path goes offline",
+			old:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "offline", "/dev/sdc", "running"),
+			want: []string{"/dev/sdb:running->offline"},
+		},
+		{
+			name: "path restored",
+			old:  multipathDisk("A", "/dev/sdb", "offline", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			want: []string{"/dev/sdb:offline->running"},
+		},
+		{
+			name: "unknown state is running",
+			old:  multipathDisk("A", "/dev/sdb", "", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", ""),
+		},
+		{
+			name: "path disappears",
+			old:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdc", "running"),
+			want: []string{"/dev/sdb:running->gone"},
+		},
+		{
+			name: "path appears",
+			old:  multipathDisk("A", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			want: []string{"/dev/sdb:gone->running"},
+		},
+		{
+			name: "last path lost",
+			old:  multipathDisk("A", "/dev/sdb", "offline", "/dev/sdc", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "offline", "/dev/sdc", "transport-offline"),
+			want: []string{"/dev/sdc:running->transport-offline"},
+		},
+		{
+			name: "one path left without a map",
+			old:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+			now:  &types.PhysicalDisk{DeviceID: "A", DevicePath: "/dev/sdc"},
+			want: []string{"/dev/sdb:running->gone"},
+		},
+		{
+			name: "not multipath before",
+			old:  &types.PhysicalDisk{DeviceID: "A", DevicePath: "/dev/sdb"},
+			now:  multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+		},
+		{
+			name: "new disk",
+			old:  multipathDisk("B", "/dev/sdd", "running"),
+			now:  multipathDisk("A", "/dev/sdb", "offline"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old := map[string]*types.PhysicalDisk{tt.old.DeviceID: tt.old}
+			changes := multipathChanges(old, []*types.PhysicalDisk{tt.now})
+
+			var got []string
+			for _, c := range changes {
+				got = append(got, c.path+":"+c.oldState+"->"+c.newState)
+				assert.NotNil(t, c.disk.Multipath, "changes are reported with the disk's paths")
+			}
+			slices.Sort(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMultipathChangesActivePaths(t *testing.T) {
+	old := map[string]*types.PhysicalDisk{
+		"A": multipathDisk("A", "/dev/sdb", "running", "/dev/sdc", "running"),
+	}
+	changes := multipathChanges(old, []*types.PhysicalDisk{
+		multipathDisk("A", "/dev/sdb", "offline", "/dev/sdc", "blocked"),
+	})
+
+	assert.Len(t, changes, 2)
+	for _, c := range changes {
+		assert.Equal(t, 0, c.disk.Multipath.ActivePaths())
+		assert.NotEqual(t, types.PathStateRunning, c.newState)
+	}
+}
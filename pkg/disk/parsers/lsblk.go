@@ -71,6 +71,24 @@ func (bd *BlockDevice) IsLoop() bool {
 	return bd.Type == "loop"
 }
 
+// MultipathChild returns the multipath device built on this path, if any
+func (bd *BlockDevice) MultipathChild() *BlockDevice {
+	for i := range bd.Children {
+		if bd.Children[i].Type == "mpath" {
+			return &bd.Children[i]
+		}
+	}
+	return nil
+}
+
+// GetHCTLString returns HCTL as string (handles nil)
+func (bd *BlockDevice) GetHCTLString() string {
+	if bd.HCTL != nil {
+		return *bd.HCTL
+	}
+	return ""
+}
+
 // IsZFSVolumeDevice returns true if this is a ZFS zvol device
 func (bd *BlockDevice) IsZFSVolumeDevice() bool {
 	// ZFS zvols appear as /dev/zd*
@@ -177,6 +195,8 @@ func (bd *BlockDevice) ToPhysicalDisk() *types.PhysicalDisk {
 	disk.Vendor = bd.GetVendorString()
 	disk.WWN = bd.GetWWNString()
 	disk.SizeBytes = bd.Size
+	disk.PhysicalSectorSize = bd.PhySec
+	disk.LogicalSectorSize = bd.LogSec
 	disk.Type = bd.DetermineDeviceType()
 	disk.Interface = bd.DetermineInterfaceType()
 
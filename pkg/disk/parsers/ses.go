@@ -0,0 +1,86 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+var (
+	sesElementType = regexp.MustCompile(`Element type:\s*([^,\[]+)`)
+	sesElement     = regexp.MustCompile(`Element (\d+) descriptor:`)
+	sesTemperature = regexp.MustCompile(`Temperature=\s*(-?\d+)\s*C`)
+	sesStatus      = regexp.MustCompile(`status:\s*([A-Za-z -]+?)\s*$`)
+)
+
+// ParseSESTemperatures parses the temperature sensor elements of an sg_ses
+// enclosure status page (--page=es). Sensors without a reading, such as
+// those not installed, are skipped.
+func ParseSESTemperatures(output string) []*types.TempSensorElement {
+	var sensors []*types.TempSensorElement
+	var current *types.TempSensorElement
+	hasReading := false
+	inTemperature := false
+
+	flush := func() {
+		if current != nil && hasReading {
+			sensors = append(sensors, current)
+		}
+		current, hasReading = nil, false
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if m := sesElementType.FindStringSubmatch(line); m != nil {
+			flush()
+			inTemperature = strings.EqualFold(strings.TrimSpace(m[1]), "Temperature sensor")
+			continue
+		}
+		if !inTemperature {
+			continue
+		}
+		if strings.Contains(line, "Overall descriptor:") {
+			flush()
+			continue
+		}
+		if m := sesElement.FindStringSubmatch(line); m != nil {
+			flush()
+			index, _ := strconv.Atoi(m[1])
+			current = &types.TempSensorElement{
+				Index:    index,
+				Location: "sensor " + m[1],
+				Status:   "OK",
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		if m := sesStatus.FindStringSubmatch(line); m != nil {
+			switch strings.ToLower(m[1]) {
+			case "critical":
+				current.Status = "Critical"
+			case "noncritical", "non-critical":
+				current.Status = "Warning"
+			}
+		}
+		if strings.Contains(line, "OT failure=1") {
+			current.Status = "Critical"
+		} else if strings.Contains(line, "OT warning=1") && current.Status != "Critical" {
+			current.Status = "Warning"
+		}
+		if m := sesTemperature.FindStringSubmatch(line); m != nil {
+			current.Temperature, _ = strconv.ParseFloat(m[1], 64)
+			hasReading = true
+		}
+	}
+	flush()
+
+	return sensors
+}
@@ -0,0 +1,453 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/common"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+)
+
+const (
+	// sizeTolerance is how much larger than the smallest disk of a vdev
+	// another may be and still count as the same size. Drives sold as the
+	// same capacity differ by a few megabytes between vendors.
+	sizeTolerance = 0.01
+
+	// draidDataDisks is the default data disks of a dRAID redundancy group
+	draidDataDisks = 8
+
+	// wideRaidz is the raidz width beyond which resilvers become slow
+	wideRaidz = 12
+)
+
+// PoolCreator creates ZFS pools
+type PoolCreator interface {
+	Create(ctx context.Context, cfg pool.CreateConfig) error
+}
+
+// PoolLayout is the redundancy of a planned pool
+type PoolLayout string
+
+const (
+	LayoutMirror PoolLayout = "mirror"
+	LayoutRaidz1 PoolLayout = "raidz1"
+	LayoutRaidz2 PoolLayout = "raidz2"
+	LayoutRaidz3 PoolLayout = "raidz3"
+	LayoutDraid1 PoolLayout = "draid1"
+	LayoutDraid2 PoolLayout = "draid2"
+	LayoutDraid3 PoolLayout = "draid3"
+)
+
+// PoolPlanRequest asks for a pool of a layout built from discovered disks.
+// Disks are grouped into vdevs in the order given; hot spares are taken
+// from the end.
+type PoolPlanRequest struct {
+	Name       string            `json:"name" binding:"required"`
+	Layout     PoolLayout        `json:"layout" binding:"required"`
+	DeviceIDs  []string          `json:"device_ids" binding:"required"`
+	Width      int               `json:"width,omitempty"`      // Disks per vdev; mirror pairs and a single raidz vdev by default
+	DataDisks  int               `json:"data_disks,omitempty"` // Data disks per dRAID group; up to 8 by default
+	Spares     int               `json:"spares,omitempty"`     // Hot spares, or distributed spares for dRAID
+	Properties map[string]string `json:"properties,omitempty"` // Pool properties (-o)
+	MountPoint string            `json:"mount_point,omitempty"`
+	Force      bool              `json:"force,omitempty"` // Accept disks of mixed sizes or media
+}
+
+// PlannedDisk is a disk of a planned pool
+type PlannedDisk struct {
+	DeviceID           string           `json:"device_id"`
+	Path               string           `json:"path"` // Path the pool is created with, by-id when there is one
+	DevicePath         string           `json:"device_path"`
+	Type               types.DeviceType `json:"type"`
+	SizeBytes          uint64           `json:"size_bytes"`
+	PhysicalSectorSize int              `json:"physical_sector_size,omitempty"`
+}
+
+// PlannedVdev is a top-level vdev of a planned pool
+type PlannedVdev struct {
+	Type      string        `json:"type"`             // mirror, raidz2, draid2:8d:12c:1s, ...
+	Parity    int           `json:"parity"`           // Disks the vdev can lose
+	DataDisks int           `json:"data_disks"`       // Disks of data per stripe or dRAID group
+	Spares    int           `json:"spares,omitempty"` // Distributed dRAID spares
+	Disks     []PlannedDisk `json:"disks"`
+}
+
+// PoolPlan previews the pool a request would create: its vdevs, the
+// problems that keep it from being created, warnings and the zpool create
+// command that would run
+type PoolPlan struct {
+	Name        string            `json:"name"`
+	Layout      PoolLayout        `json:"layout"`
+	Vdevs       []PlannedVdev     `json:"vdevs"`
+	Spares      []PlannedDisk     `json:"spares,omitempty"` // Hot spares; dRAID spares are distributed
+	UsableBytes uint64            `json:"usable_bytes"`     // Estimate before ZFS metadata and slop space
+	Valid       bool              `json:"valid"`
+	Issues      []ValidationIssue `json:"issues,omitempty"`
+	Warnings    []string          `json:"warnings,omitempty"`
+	Command     string            `json:"command"`
+
+	config pool.CreateConfig
+}
+
+// UsePoolCreator lets planned pools be created
+func (m *Manager) UsePoolCreator(c PoolCreator) {
+	m.creator.Store(&c)
+}
+
+// PlanPool checks a pool request against the discovered disks and previews
+// the pool. Requests that cannot describe a pool, such as disks that do not
+// split into vdevs of the width, are errors; disks that are in use, failing
+// or mixed are issues of the plan.
+func (m *Manager) PlanPool(req PoolPlanRequest) (*PoolPlan, error) {
+	if err := common.PoolNameCheck(req.Name); err != nil {
+		return nil, err
+	}
+	kind, parity, ok := parseLayout(req.Layout)
+	if !ok {
+		return nil, errors.New(errors.PoolPlanInvalid, "layout must be mirror, raidz1-3 or draid1-3").
+			WithMetadata("layout", string(req.Layout))
+	}
+	if req.Spares < 0 || req.Width < 0 || req.DataDisks < 0 {
+		return nil, errors.New(errors.PoolPlanInvalid, "width, data_disks and spares cannot be negative")
+	}
+
+	plan := &PoolPlan{Name: req.Name, Layout: req.Layout}
+
+	disks := make([]PlannedDisk, 0, len(req.DeviceIDs))
+	seen := make(map[string]bool, len(req.DeviceIDs))
+	for _, id := range req.DeviceIDs {
+		if seen[id] {
+			return nil, errors.New(errors.PoolPlanInvalid, "disk is listed more than once").
+				WithMetadata("device_id", id)
+		}
+		seen[id] = true
+
+		disk, err := m.GetDisk(id)
+		if err != nil {
+			return nil, err
+		}
+		disks = append(disks, m.checkPoolDisk(plan, disk))
+	}
+
+	data := disks
+	if kind != "draid" && req.Spares > 0 {
+		if req.Spares >= len(disks) {
+			return nil, errors.New(errors.PoolPlanInvalid, "spares leave no disks for data").
+				WithMetadata("disks", strconv.Itoa(len(disks))).
+				WithMetadata("spares", strconv.Itoa(req.Spares))
+		}
+		data, plan.Spares = disks[:len(disks)-req.Spares], disks[len(disks)-req.Spares:]
+	}
+
+	vdevs, err := groupVdevs(kind, parity, data, req)
+	if err != nil {
+		return nil, err
+	}
+	plan.Vdevs = vdevs
+	if kind == "raidz" {
+		if width := len(vdevs[0].Disks); width > wideRaidz {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"raidz vdevs of %d disks resilver slowly; consider vdevs of %d or fewer", width, wideRaidz))
+		}
+		if vdevs[0].DataDisks < 2 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"%s vdevs of %d disks store one disk of data; mirrors give the same redundancy and are faster",
+				req.Layout, len(vdevs[0].Disks)))
+		}
+	}
+
+	checkHomogeneity(plan, disks, req.Force)
+
+	// zpool picks the ashift of a vdev from its disks, so a vdev of 512-byte
+	// disks could never take a 4K replacement
+	properties := maps.Clone(req.Properties)
+	if sectorSizes(disks) > 1 {
+		warning := "disks mix 512-byte and 4K physical sectors"
+		if properties["ashift"] == "" {
+			if properties == nil {
+				properties = make(map[string]string)
+			}
+			properties["ashift"] = "12"
+			warning += "; ashift=12 is set so every vdev uses 4K sectors"
+		}
+		plan.Warnings = append(plan.Warnings, warning)
+	}
+
+	for _, vdev := range plan.Vdevs {
+		plan.UsableBytes += usableBytes(vdev)
+	}
+
+	plan.config = pool.CreateConfig{
+		Name:       req.Name,
+		Properties: properties,
+		MountPoint: req.MountPoint,
+	}
+	for _, vdev := range plan.Vdevs {
+		plan.config.VDevSpec = append(plan.config.VDevSpec, pool.VDevSpec{Type: vdev.Type, Devices: diskPaths(vdev.Disks)})
+	}
+	if len(plan.Spares) > 0 {
+		plan.config.VDevSpec = append(plan.config.VDevSpec, pool.VDevSpec{Type: "spare", Devices: diskPaths(plan.Spares)})
+	}
+	plan.Command = pool.CreateCommand(plan.config)
+
+	plan.Valid = !slices.ContainsFunc(plan.Issues, func(i ValidationIssue) bool { return i.Severity == "error" })
+	return plan, nil
+}
+
+// CreatePool plans a pool and creates it when the plan has no issues
+func (m *Manager) CreatePool(ctx context.Context, req PoolPlanRequest) (*PoolPlan, error) {
+	plan, err := m.PlanPool(req)
+	if err != nil {
+		return nil, err
+	}
+	if !plan.Valid {
+		messages := make([]string, 0, len(plan.Issues))
+		for _, issue := range plan.Issues {
+			if issue.Severity == "error" {
+				messages = append(messages, issue.Message)
+			}
+		}
+		return plan, errors.New(errors.PoolPlanRejected, strings.Join(messages, "; ")).
+			WithMetadata("pool", req.Name)
+	}
+
+	creator := m.creator.Load()
+	if creator == nil {
+		return plan, errors.New(errors.PoolPlanCreateUnavailable, "no pool manager is set up")
+	}
+
+	m.logger.Info("creating pool", "pool", req.Name, "command", plan.Command)
+	if err := (*creator).Create(ctx, plan.config); err != nil {
+		return plan, err
+	}
+
+	// Show the disks as members of the pool without waiting for the next scan
+	if err := m.TriggerDiscovery(ctx); err != nil {
+		m.logger.Warn("failed to rediscover disks after creating pool", "pool", req.Name, "error", err)
+	}
+	return plan, nil
+}
+
+// parseLayout returns the vdev kind and parity of a layout
+func parseLayout(layout PoolLayout) (kind string, parity int, ok bool) {
+	switch layout {
+	case LayoutMirror:
+		return "mirror", 1, true
+	case LayoutRaidz1, LayoutRaidz2, LayoutRaidz3:
+		return "raidz", int(layout[5] - '0'), true
+	case LayoutDraid1, LayoutDraid2, LayoutDraid3:
+		return "draid", int(layout[5] - '0'), true
+	}
+	return "", 0, false
+}
+
+// checkPoolDisk adds the issues keeping a disk out of a new pool to the
+// plan and returns the disk as planned
+func (m *Manager) checkPoolDisk(plan *PoolPlan, disk *types.PhysicalDisk) PlannedDisk {
+	planned := PlannedDisk{
+		DeviceID:           disk.DeviceID,
+		Path:               disk.GetPreferredPath(types.NamingByID),
+		DevicePath:         disk.DevicePath,
+		Type:               disk.Type,
+		SizeBytes:          disk.SizeBytes,
+		PhysicalSectorSize: disk.PhysicalSectorSize,
+	}
+
+	// Quarantined disks are reported by ValidateDisk
+	if disk.PoolName != "" || (disk.State != types.DiskStateAvailable && disk.State != types.DiskStateQuarantined) {
+		message := fmt.Sprintf("%s is %s", disk.DeviceID, disk.State)
+		if disk.PoolName != "" {
+			message = fmt.Sprintf("%s is in pool %s", disk.DeviceID, disk.PoolName)
+		}
+		plan.Issues = append(plan.Issues, ValidationIssue{
+			Code:     "DISK_NOT_AVAILABLE",
+			Severity: "error",
+			Message:  message,
+		})
+	}
+
+	if result, err := m.ValidateDisk(disk.DeviceID); err == nil {
+		for _, issue := range result.Issues {
+			issue.Message = disk.DeviceID + ": " + issue.Message
+			plan.Issues = append(plan.Issues, issue)
+		}
+		for _, warning := range result.Warnings {
+			plan.Warnings = append(plan.Warnings, disk.DeviceID+": "+warning)
+		}
+	}
+
+	if planned.Path == disk.DevicePath {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+			"%s has no /dev/disk/by-id link; the pool uses %s, which can change across reboots",
+			disk.DeviceID, disk.DevicePath))
+	}
+
+	return planned
+}
+
+// groupVdevs splits the data disks into the vdevs of a layout
+func groupVdevs(kind string, parity int, disks []PlannedDisk, req PoolPlanRequest) ([]PlannedVdev, error) {
+	if len(disks) == 0 {
+		return nil, errors.New(errors.PoolPlanInvalid, "no disks given")
+	}
+
+	if kind == "draid" {
+		children := len(disks)
+		dataDisks := req.DataDisks
+		if dataDisks == 0 {
+			dataDisks = min(draidDataDisks, children-parity-req.Spares)
+		}
+		if dataDisks < 1 || children < parity+dataDisks+req.Spares {
+			return nil, errors.New(errors.PoolPlanInvalid, fmt.Sprintf(
+				"%s needs at least %d disks for %d data disks and %d spares",
+				req.Layout, parity+max(dataDisks, 1)+req.Spares, max(dataDisks, 1), req.Spares)).
+				WithMetadata("disks", strconv.Itoa(children))
+		}
+		return []PlannedVdev{{
+			Type:      fmt.Sprintf("draid%d:%dd:%dc:%ds", parity, dataDisks, children, req.Spares),
+			Parity:    parity,
+			DataDisks: dataDisks,
+			Spares:    req.Spares,
+			Disks:     disks,
+		}}, nil
+	}
+
+	width := req.Width
+	minWidth := parity + 1
+	if width == 0 {
+		width = len(disks)
+		if kind == "mirror" {
+			width = 2
+		}
+	}
+	if width < minWidth || (kind == "mirror" && width < 2) {
+		return nil, errors.New(errors.PoolPlanInvalid, fmt.Sprintf(
+			"%s vdevs need at least %d disks", req.Layout, max(minWidth, 2))).
+			WithMetadata("width", strconv.Itoa(width))
+	}
+	if len(disks)%width != 0 {
+		return nil, errors.New(errors.PoolPlanInvalid, fmt.Sprintf(
+			"%d disks do not split into %s vdevs of %d", len(disks), req.Layout, width)).
+			WithMetadata("disks", strconv.Itoa(len(disks))).
+			WithMetadata("width", strconv.Itoa(width))
+	}
+
+	if kind == "mirror" {
+		parity = width - 1
+	}
+	var vdevs []PlannedVdev
+	for chunk := range slices.Chunk(disks, width) {
+		vdevs = append(vdevs, PlannedVdev{
+			Type:      string(req.Layout),
+			Parity:    parity,
+			DataDisks: width - parity,
+			Disks:     chunk,
+		})
+	}
+	return vdevs, nil
+}
+
+// checkHomogeneity adds issues for vdevs of mixed sizes, pools of mixed
+// media and spares too small to replace a disk. With force they are
+// warnings instead.
+func checkHomogeneity(plan *PoolPlan, disks []PlannedDisk, force bool) {
+	report := func(code, message string) {
+		if force {
+			plan.Warnings = append(plan.Warnings, message)
+			return
+		}
+		plan.Issues = append(plan.Issues, ValidationIssue{Code: code, Severity: "error", Message: message})
+	}
+
+	rotational := slices.ContainsFunc(disks, func(d PlannedDisk) bool { return d.Type == types.DeviceTypeHDD })
+	solid := slices.ContainsFunc(disks, func(d PlannedDisk) bool { return d.Type != types.DeviceTypeHDD })
+	if rotational && solid {
+		report("MIXED_MEDIA", "pool mixes rotational and solid-state disks; it runs at the speed of the slowest")
+	}
+
+	var largest uint64
+	for i, vdev := range plan.Vdevs {
+		smallest, biggest := sizeRange(vdev.Disks)
+		largest = max(largest, biggest)
+		if float64(biggest) > float64(smallest)*(1+sizeTolerance) {
+			report("MIXED_SIZES", fmt.Sprintf(
+				"vdev %d mixes disk sizes from %s to %s; each disk only uses %s",
+				i, formatSize(smallest), formatSize(biggest), formatSize(smallest)))
+		}
+	}
+	for _, spare := range plan.Spares {
+		if spare.SizeBytes < largest {
+			report("SPARE_TOO_SMALL", fmt.Sprintf(
+				"spare %s (%s) is smaller than the largest data disk (%s) and cannot replace it",
+				spare.DeviceID, formatSize(spare.SizeBytes), formatSize(largest)))
+		}
+	}
+}
+
+// sizeRange returns the smallest and largest disk size
+func sizeRange(disks []PlannedDisk) (smallest, largest uint64) {
+	for i, d := range disks {
+		if i == 0 || d.SizeBytes < smallest {
+			smallest = d.SizeBytes
+		}
+		largest = max(largest, d.SizeBytes)
+	}
+	return smallest, largest
+}
+
+// sectorSizes returns how many physical sector sizes the disks report
+func sectorSizes(disks []PlannedDisk) int {
+	sizes := make(map[int]bool)
+	for _, d := range disks {
+		if d.PhysicalSectorSize > 0 {
+			sizes[d.PhysicalSectorSize] = true
+		}
+	}
+	return len(sizes)
+}
+
+// usableBytes estimates the space a vdev stores, sized by its smallest disk.
+// dRAID stores data on the data disks of each group across the disks left
+// after its distributed spares.
+func usableBytes(vdev PlannedVdev) uint64 {
+	smallest, _ := sizeRange(vdev.Disks)
+	if vdev.Spares > 0 || strings.HasPrefix(vdev.Type, "draid") {
+		groups := uint64(len(vdev.Disks) - vdev.Spares)
+		return smallest * groups * uint64(vdev.DataDisks) / uint64(vdev.DataDisks+vdev.Parity)
+	}
+	return smallest * uint64(vdev.DataDisks)
+}
+
+// diskPaths returns the paths the pool is created with
+func diskPaths(disks []PlannedDisk) []string {
+	paths := make([]string, len(disks))
+	for i, d := range disks {
+		paths[i] = d.Path
+	}
+	return paths
+}
+
+// formatSize returns a size in decimal units, as drives are sold
+func formatSize(bytes uint64) string {
+	const unit = 1000
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := uint64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "kMGTPE"[exp])
+}
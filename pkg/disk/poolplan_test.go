@@ -0,0 +1,101 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tb8 = 8001563222016
+
+func plannedDisks(n int, size uint64) []PlannedDisk {
+	disks := make([]PlannedDisk, n)
+	for i := range disks {
+		disks[i] = PlannedDisk{
+			DeviceID:           fmt.Sprintf("SER%d", i),
+			Path:               fmt.Sprintf("/dev/disk/by-id/ata-WDC_SER%d", i),
+			Type:               types.DeviceTypeHDD,
+			SizeBytes:          size,
+			PhysicalSectorSize: 4096,
+		}
+	}
+	return disks
+}
+
+func TestGroupVdevs(t *testing.T) {
+	mirrors, err := groupVdevs("mirror", 1, plannedDisks(4, tb8), PoolPlanRequest{Layout: LayoutMirror})
+	require.NoError(t, err)
+	require.Len(t, mirrors, 2)
+	assert.Equal(t, "mirror", mirrors[0].Type)
+	assert.Equal(t, 1, mirrors[0].Parity)
+	assert.Equal(t, uint64(2*tb8), usableBytes(mirrors[0])+usableBytes(mirrors[1]))
+
+	raidz, err := groupVdevs("raidz", 2, plannedDisks(12, tb8), PoolPlanRequest{Layout: LayoutRaidz2, Width: 6})
+	require.NoError(t, err)
+	require.Len(t, raidz, 2)
+	assert.Equal(t, 4, raidz[0].DataDisks)
+	assert.Equal(t, uint64(4*tb8), usableBytes(raidz[0]))
+
+	draid, err := groupVdevs("draid", 2, plannedDisks(12, tb8), PoolPlanRequest{Layout: LayoutDraid2, Spares: 1})
+	require.NoError(t, err)
+	require.Len(t, draid, 1)
+	assert.Equal(t, "draid2:8d:12c:1s", draid[0].Type)
+	assert.Equal(t, uint64(11*tb8*8/10), usableBytes(draid[0]))
+
+	_, err = groupVdevs("raidz", 2, plannedDisks(7, tb8), PoolPlanRequest{Layout: LayoutRaidz2, Width: 6})
+	assert.Error(t, err, "7 disks do not split into vdevs of 6")
+
+	_, err = groupVdevs("raidz", 3, plannedDisks(3, tb8), PoolPlanRequest{Layout: LayoutRaidz3})
+	assert.Error(t, err, "raidz3 needs 4 disks")
+
+	_, err = groupVdevs("draid", 2, plannedDisks(3, tb8), PoolPlanRequest{Layout: LayoutDraid2, Spares: 1})
+	assert.Error(t, err, "no disks left for data")
+}
+
+func TestCheckHomogeneity(t *testing.T) {
+	disks := plannedDisks(4, tb8)
+	disks[1].SizeBytes = tb8 + 4*1024*1024 // Another vendor's 8 TB
+	disks[3].SizeBytes = 12000138625024
+	disks[2].Type = types.DeviceTypeSSD
+
+	plan := &PoolPlan{}
+	plan.Vdevs, _ = groupVdevs("mirror", 1, disks, PoolPlanRequest{Layout: LayoutMirror})
+	checkHomogeneity(plan, disks, false)
+
+	codes := make([]string, 0, len(plan.Issues))
+	for _, issue := range plan.Issues {
+		codes = append(codes, issue.Code)
+	}
+	assert.Equal(t, []string{"MIXED_MEDIA", "MIXED_SIZES"}, codes)
+	assert.Contains(t, plan.Issues[1].Message, "vdev 1")
+
+	forced := &PoolPlan{Vdevs: plan.Vdevs}
+	checkHomogeneity(forced, disks, true)
+	assert.Empty(t, forced.Issues)
+	assert.Len(t, forced.Warnings, 2)
+}
+
+func TestPoolCreateCommand(t *testing.T) {
+	disks := plannedDisks(3, tb8)
+	cfg := pool.CreateConfig{
+		Name:       "tank",
+		Properties: map[string]string{"ashift": "12", "autotrim": "on"},
+		VDevSpec: []pool.VDevSpec{
+			{Type: "raidz1", Devices: diskPaths(disks[:2])},
+			{Type: "spare", Devices: diskPaths(disks[2:])},
+		},
+	}
+
+	assert.Equal(t,
+		"zpool create -f -o ashift=12 -o autotrim=on tank raidz1 "+
+			"/dev/disk/by-id/ata-WDC_SER0 /dev/disk/by-id/ata-WDC_SER1 spare /dev/disk/by-id/ata-WDC_SER2",
+		pool.CreateCommand(cfg))
+}
@@ -40,7 +40,7 @@ func (m *Manager) TriggerProbe(
 	}
 
 	// Trigger probe via scheduler
-	probeID, err := m.probeScheduler.TriggerProbe(ctx, deviceID, disk.DevicePath, probeType)
+	probeID, err := m.probeScheduler.TriggerProbe(ctx, deviceID, disk.SMARTDevicePath(), probeType)
 	if err != nil {
 		return "", errors.Wrap(err, errors.DiskProbeStartFailed).
 			WithMetadata("device_id", deviceID).
@@ -0,0 +1,148 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/disk/health"
+	"github.com/stratastor/rodent/pkg/disk/tools"
+	"github.com/stratastor/rodent/pkg/disk/types"
+)
+
+// ReplacementSuggestion is a disk worth replacing before it fails, with
+// what its failure would put at risk
+type ReplacementSuggestion struct {
+	DeviceID   string         `json:"device_id"`
+	DevicePath string         `json:"device_path"`
+	Serial     string         `json:"serial"`
+	Model      string         `json:"model"`
+	Pool       string         `json:"pool,omitempty"`
+	Risk       types.DiskRisk `json:"risk"`
+	Reason     string         `json:"reason"`           // The factors adding most to the score
+	Impact     *DiskImpact    `json:"impact,omitempty"` // Nil when the disk is in no pool
+}
+
+// updateRisk records a SMART sample of a checked disk and rescores its
+// failure risk. The caller must hold the state lock.
+func (m *Manager) updateRisk(deviceState *types.DeviceState, status *health.HealthStatus, zfsErrors *types.ZFSErrors) {
+	sample := health.NewSMARTSample(status.SMARTInfo, zfsErrors, status.CheckedAt)
+	deviceState.SMARTHistory = health.AddSample(deviceState.SMARTHistory, sample)
+
+	risk := health.ScoreRisk(status.SMARTInfo, deviceState.SMARTHistory, zfsErrors, m.healthMonitor.GetThresholds())
+	if old := deviceState.Risk; risk.Score >= 50 && (old == nil || old.Level != risk.Level) {
+		m.logger.Warn("disk failure risk rose",
+			"device_id", deviceState.DeviceID,
+			"score", risk.Score,
+			"level", risk.Level,
+			"reason", riskReason(risk))
+	}
+	deviceState.Risk = risk
+}
+
+// GetReplacementSuggestions lists the disks at elevated failure risk or
+// above, most urgent first: by risk score, then by the redundancy their
+// vdev has left
+func (m *Manager) GetReplacementSuggestions(ctx context.Context) []ReplacementSuggestion {
+	suggestions := []ReplacementSuggestion{}
+	for _, disk := range m.GetInventory(nil) {
+		if disk.Risk == nil || disk.Risk.Level == types.RiskLow {
+			continue
+		}
+		suggestions = append(suggestions, ReplacementSuggestion{
+			DeviceID:   disk.DeviceID,
+			DevicePath: disk.DevicePath,
+			Serial:     disk.Serial,
+			Model:      disk.Model,
+			Pool:       disk.PoolName,
+			Risk:       *disk.Risk,
+			Reason:     riskReason(disk.Risk),
+			Impact:     m.diskImpact(ctx, disk),
+		})
+	}
+
+	slices.SortFunc(suggestions, func(a, b ReplacementSuggestion) int {
+		if c := cmp.Compare(b.Risk.Score, a.Risk.Score); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(redundancyOf(a.Impact), redundancyOf(b.Impact)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.DeviceID, b.DeviceID)
+	})
+	return suggestions
+}
+
+// redundancyOf returns the redundancy left in a disk's vdev, ordering disks
+// outside pools or of unknown vdevs last
+func redundancyOf(impact *DiskImpact) int {
+	if impact == nil || impact.Redundancy == nil {
+		return int(^uint(0) >> 1)
+	}
+	return *impact.Redundancy
+}
+
+// riskReason describes the factors adding most to a risk score
+func riskReason(risk *types.DiskRisk) string {
+	factors := slices.Clone(risk.Factors)
+	slices.SortStableFunc(factors, func(a, b types.RiskFactor) int {
+		return cmp.Compare(b.Points, a.Points)
+	})
+
+	details := make([]string, 0, 3)
+	for _, f := range factors[:min(len(factors), 3)] {
+		details = append(details, f.Detail)
+	}
+	return strings.Join(details, "; ")
+}
+
+// poolDiskErrors returns the ZFS error counters of pool disks by vdev GUID,
+// or nil when pool status cannot be read
+func (m *Manager) poolDiskErrors(ctx context.Context) map[string]*types.ZFSErrors {
+	if m.zpool == nil {
+		return nil
+	}
+	status, err := m.zpool.GetPoolStatus(ctx)
+	if err != nil {
+		m.logger.Warn("failed to read ZFS error counters for risk scoring", "error", err)
+		return nil
+	}
+
+	errs := make(map[string]*types.ZFSErrors)
+	for _, pool := range status.Pools {
+		leafErrors(pool.VDevs, errs)
+	}
+	return errs
+}
+
+// leafErrors adds the error counters of the leaf vdevs in a vdev tree
+func leafErrors(vdevs map[string]*tools.VDev, errs map[string]*types.ZFSErrors) {
+	for _, vdev := range vdevs {
+		if vdev == nil {
+			continue
+		}
+		if len(vdev.VDevs) > 0 {
+			leafErrors(vdev.VDevs, errs)
+			continue
+		}
+		if vdev.GUID != "" {
+			errs[vdev.GUID] = &types.ZFSErrors{
+				Read:     parseCount(vdev.ReadErrors),
+				Write:    parseCount(vdev.WriteErrors),
+				Checksum: parseCount(vdev.ChecksumErrors),
+			}
+		}
+	}
+}
+
+// parseCount parses a zpool status error counter, 0 when absent
+func parseCount(s string) uint64 {
+	n, _ := strconv.ParseUint(s, 10, 64)
+	return n
+}
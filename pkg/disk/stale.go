@@ -0,0 +1,103 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// StaleDevice is a device the disk manager still holds, in its device cache
+// or saved state, that discovery no longer finds
+type StaleDevice struct {
+	DeviceID   string          `json:"device_id"`
+	DevicePath string          `json:"device_path,omitempty"`
+	State      types.DiskState `json:"state"`
+	PoolName   string          `json:"pool_name,omitempty"`
+	LastSeenAt time.Time       `json:"last_seen_at"`
+	Cached     bool            `json:"cached"` // In the device cache, not only the saved state
+}
+
+// goneStates already record that a device is missing
+var goneStates = []types.DiskState{types.DiskStateOffline, types.DiskStateRetired}
+
+// StaleDevices runs a discovery and returns the devices the manager holds
+// that it did not find, other than saved devices already offline or
+// retired. Missed udev remove events leave such devices behind.
+func (m *Manager) StaleDevices(ctx context.Context) ([]StaleDevice, error) {
+	disks, err := m.discoverer.DiscoverAll(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.DiskDiscoveryFailed)
+	}
+	found := make(map[string]bool, len(disks))
+	for _, disk := range disks {
+		found[disk.DeviceID] = true
+	}
+	// Excluded devices are present, only left out of the inventory
+	for _, disk := range m.discoverer.GetIgnored() {
+		found[disk.DeviceID] = true
+	}
+
+	stale := make(map[string]*StaleDevice)
+	m.stateManager.WithRLock(func(s *types.DiskManagerState) {
+		for id, device := range s.Devices {
+			if found[id] || slices.Contains(goneStates, device.State) {
+				continue
+			}
+			stale[id] = &StaleDevice{
+				DeviceID:   id,
+				State:      device.State,
+				PoolName:   device.PoolName,
+				LastSeenAt: device.LastSeenAt,
+			}
+		}
+	})
+
+	m.cacheMu.RLock()
+	for id, disk := range m.deviceCache {
+		if found[id] {
+			continue
+		}
+		device, ok := stale[id]
+		if !ok {
+			device = &StaleDevice{DeviceID: id, State: disk.State, PoolName: disk.PoolName}
+			stale[id] = device
+		}
+		device.DevicePath = disk.DevicePath
+		device.Cached = true
+	}
+	m.cacheMu.RUnlock()
+
+	devices := make([]StaleDevice, 0, len(stale))
+	for _, device := range stale {
+		devices = append(devices, *device)
+	}
+	slices.SortFunc(devices, func(a, b StaleDevice) int {
+		return strings.Compare(a.DeviceID, b.DeviceID)
+	})
+	return devices, nil
+}
+
+// ForgetDevice drops a device discovery no longer finds from the device
+// cache and marks it offline, as a udev remove event would have
+func (m *Manager) ForgetDevice(deviceID string) error {
+	m.cacheMu.RLock()
+	_, cached := m.deviceCache[deviceID]
+	m.cacheMu.RUnlock()
+	if cached {
+		return m.handleDeviceRemoved(deviceID)
+	}
+
+	if _, err := m.stateManager.GetDeviceState(deviceID); err != nil {
+		return err
+	}
+	m.stateManager.UpdateDeviceState(deviceID, types.DiskStateOffline, types.HealthUnknown)
+	return nil
+}
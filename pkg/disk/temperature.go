@@ -0,0 +1,129 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package disk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/health"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// recordTemperatures adds the drive temperatures of a health check and the
+// enclosure sensor readings to the temperature history, and alerts on
+// sensors crossing a threshold or rising fast
+func (m *Manager) recordTemperatures(ctx context.Context, statuses []*health.HealthStatus) {
+	cfg := m.configManager.Get().Monitoring.Temperature.WithDefaults()
+	thresholds := m.healthMonitor.GetThresholds()
+	if thresholds == nil {
+		thresholds = types.DefaultSMARTThresholds()
+	}
+
+	type diskAlert struct {
+		alert *types.TemperatureAlert
+		disk  *types.PhysicalDisk
+	}
+	var alerts []diskAlert
+
+	for _, status := range statuses {
+		info := status.SMARTInfo
+		if info == nil || !info.TemperatureValid {
+			continue
+		}
+
+		m.cacheMu.RLock()
+		var disk *types.PhysicalDisk
+		name := status.DeviceID
+		if cached, ok := m.deviceCache[status.DeviceID]; ok {
+			diskCopy := *cached
+			disk = &diskCopy
+			name = cached.DevicePath
+		}
+		m.cacheMu.RUnlock()
+
+		m.stateManager.WithLock(func(s *types.DiskManagerState) {
+			if deviceState, ok := s.Devices[status.DeviceID]; ok {
+				deviceState.LastTemperature = info.Temperature
+			}
+		})
+
+		alert := m.temperatures.Record(types.SensorDisk, status.DeviceID, name,
+			float64(info.Temperature), float64(thresholds.TempWarning), float64(thresholds.TempCritical),
+			status.CheckedAt, cfg)
+		if alert != nil {
+			alerts = append(alerts, diskAlert{alert: alert, disk: disk})
+		}
+	}
+
+	// Drop the history of disks no longer present
+	m.cacheMu.RLock()
+	present := make(map[string]bool, len(m.deviceCache))
+	for id := range m.deviceCache {
+		present[id] = true
+	}
+	m.cacheMu.RUnlock()
+	m.temperatures.Forget(types.SensorDisk, present)
+
+	// Enclosure sensors, read alongside the drives so a failing fan shows
+	// in both
+	now := time.Now()
+	for enclosureID, sensors := range m.topoMapper.ReadEnclosureTemperatures(ctx) {
+		for _, sensor := range sensors {
+			id := fmt.Sprintf("%s:%d", enclosureID, sensor.Index)
+			alert := m.temperatures.Record(types.SensorEnclosure, id, enclosureID+" "+sensor.Location,
+				sensor.Temperature, cfg.EnclosureWarning, cfg.EnclosureCritical, now, cfg)
+			if alert != nil {
+				alerts = append(alerts, diskAlert{alert: alert})
+			}
+		}
+	}
+
+	for _, a := range alerts {
+		sensor := a.alert.Sensor
+		attrs := []any{
+			"sensor", sensor.ID,
+			"kind", sensor.Kind,
+			"name", sensor.Name,
+			"celsius", sensor.Celsius,
+			"level", sensor.Level,
+			"reason", a.alert.Reason,
+		}
+		if sensor.Level == types.TempNormal && !sensor.Rising {
+			m.logger.Info("temperature back to normal", attrs...)
+		} else {
+			m.logger.Warn("temperature alert", attrs...)
+		}
+		m.eventEmitter.EmitTemperatureAlert(a.alert, a.disk)
+	}
+
+	if err := m.temperatures.Save(); err != nil {
+		m.logger.Warn("failed to save temperature history", "error", err)
+	}
+}
+
+// GetTemperatures returns the current temperatures of the disks and
+// enclosure sensors, with their history when asked for
+func (m *Manager) GetTemperatures(withHistory bool) []types.TemperatureSensor {
+	return m.temperatures.Sensors(withHistory)
+}
+
+// GetDiskTemperature returns the temperature history of a disk
+func (m *Manager) GetDiskTemperature(deviceID string) (*types.TemperatureSensor, error) {
+	sensor, ok := m.temperatures.Sensor(deviceID)
+	if !ok || sensor.Kind != types.SensorDisk {
+		return nil, errors.New(errors.DiskNotFound, "no temperature readings for disk").
+			WithMetadata("device_id", deviceID)
+	}
+	return sensor, nil
+}
+
+// TemperatureMetrics returns the collector of disk and enclosure
+// temperatures for the metrics endpoint
+func (m *Manager) TemperatureMetrics() *health.TemperatureTracker {
+	return m.temperatures
+}
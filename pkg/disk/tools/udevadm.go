@@ -76,7 +76,7 @@ func (u *UdevadmExecutor) Monitor(ctx context.Context) ([]byte, error) {
 	u.logger.Debug("monitoring udev events")
 	// Override timeout for monitoring
 	oldTimeout := u.executor.Timeout
-	u.executor.Timeout = 0 // No timeout, use context
+	u.executor.Timeout = command.NoTimeout // Use context
 	defer func() { u.executor.Timeout = oldTimeout }()
 
 	return u.executor.ExecuteWithCombinedOutput(ctx, u.path,
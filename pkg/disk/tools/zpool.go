@@ -41,6 +41,7 @@ type PoolStatus struct {
 // Pool represents a ZFS pool from status output
 type Pool struct {
 	Name      string            `json:"name"`
+	State     string            `json:"state"` // ONLINE, DEGRADED, FAULTED, UNAVAIL, ...
 	VDevs     map[string]*VDev `json:"vdevs"`
 	ScanStats *ScanStats        `json:"scan_stats,omitempty"`
 }
@@ -54,10 +55,16 @@ type ScanStats struct {
 // VDev represents a virtual device in the pool
 type VDev struct {
 	Name  string            `json:"name"`
+	Type  string            `json:"vdev_type,omitempty"` // root, mirror, raidz, draid, disk, file, spare, replacing, ...
 	GUID  string            `json:"guid,omitempty"` // ZFS vdev GUID
 	State string            `json:"state"`          // ONLINE, DEGRADED, FAULTED, UNAVAIL, OFFLINE
 	Path  string            `json:"path,omitempty"`
 	VDevs map[string]*VDev `json:"vdevs,omitempty"` // Nested vdevs
+
+	// Error counters since import or the last zpool clear
+	ReadErrors     string `json:"read_errors,omitempty"`
+	WriteErrors    string `json:"write_errors,omitempty"`
+	ChecksumErrors string `json:"checksum_errors,omitempty"`
 }
 
 // Status returns the status of all pools in JSON format
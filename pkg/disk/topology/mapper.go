@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/disk/parsers"
 	"github.com/stratastor/rodent/pkg/disk/tools"
 	"github.com/stratastor/rodent/pkg/disk/types"
 	"github.com/stratastor/rodent/pkg/errors"
@@ -244,6 +245,57 @@ func (m *Mapper) GetTopology() *types.PhysicalTopology {
 	return topo
 }
 
+// ReadEnclosureTemperatures reads the temperature sensors of the known
+// enclosures from their SES status page, keyed by enclosure ID, and keeps
+// them in the topology
+func (m *Mapper) ReadEnclosureTemperatures(ctx context.Context) map[string][]*types.TempSensorElement {
+	if m.sgses == nil || !m.toolChecker.IsAvailable("sg_ses") {
+		return nil
+	}
+
+	m.mu.RLock()
+	ids := make([]string, 0, len(m.topology.Enclosures))
+	for id := range m.topology.Enclosures {
+		ids = append(ids, id)
+	}
+	m.mu.RUnlock()
+
+	readings := make(map[string][]*types.TempSensorElement)
+	for _, id := range ids {
+		// Enclosures are identified by their sg device
+		output, err := m.sgses.GetEnclosureStatus(ctx, id)
+		if err != nil {
+			m.logger.Warn("failed to read enclosure status",
+				"enclosure", id,
+				"error", err)
+			continue
+		}
+		if sensors := parsers.ParseSESTemperatures(string(output)); len(sensors) > 0 {
+			readings[id] = sensors
+		}
+	}
+
+	m.mu.Lock()
+	for id, sensors := range readings {
+		enc, ok := m.topology.Enclosures[id]
+		if !ok {
+			continue
+		}
+		if enc.Elements == nil {
+			enc.Elements = &types.EnclosureElements{}
+		}
+		enc.Elements.TempSensors = sensors
+		enc.Status.Temperature = sensors[0].Temperature
+		for _, sensor := range sensors[1:] {
+			enc.Status.Temperature = max(enc.Status.Temperature, sensor.Temperature)
+		}
+		enc.Status.UpdatedAt = time.Now()
+	}
+	m.mu.Unlock()
+
+	return readings
+}
+
 // SCSIInfo represents parsed lsscsi information
 type SCSIInfo struct {
 	Host       int
@@ -46,6 +46,7 @@ type DiscoveryConfig struct {
 	ReconcileInterval time.Duration `yaml:"reconcile_interval" json:"reconcile_interval"` // Reconciliation interval
 	UdevMonitor       bool          `yaml:"udev_monitor" json:"udev_monitor"`             // Enable udev monitoring
 	AutoValidate      bool          `yaml:"auto_validate" json:"auto_validate"`           // Auto-validate new disks
+	Exclude           ExclusionConfig `yaml:"exclude" json:"exclude"`                     // Devices to ignore
 }
 
 // MonitoringConfig configures SMART monitoring
@@ -56,6 +57,7 @@ type MonitoringConfig struct {
 	MetricRetention  time.Duration      `yaml:"metric_retention" json:"metric_retention"`   // Metric retention period
 	AlertOnWarning   bool               `yaml:"alert_on_warning" json:"alert_on_warning"`   // Send alerts on warnings
 	AlertOnCritical  bool               `yaml:"alert_on_critical" json:"alert_on_critical"` // Send alerts on critical
+	Temperature      TemperatureConfig  `yaml:"temperature" json:"temperature"`             // Temperature history and alerts
 }
 
 // ProbingConfig configures SMART probe scheduling
@@ -133,6 +135,10 @@ func DefaultDiskManagerConfig() *DiskManagerConfig {
 			ReconcileInterval: DefaultReconcileInterval,
 			UdevMonitor:       DefaultUdevMonitorEnabled,
 			AutoValidate:      true,
+			Exclude: ExclusionConfig{
+				SystemDisks: true,
+				USB:         true,
+			},
 		},
 		Monitoring: MonitoringConfig{
 			Enabled:         true,
@@ -141,6 +147,13 @@ func DefaultDiskManagerConfig() *DiskManagerConfig {
 			MetricRetention: DefaultMetricRetention,
 			AlertOnWarning:  true,
 			AlertOnCritical: true,
+			Temperature: TemperatureConfig{
+				EnclosureWarning:  DefaultEnclosureTempWarning,
+				EnclosureCritical: DefaultEnclosureTempCritical,
+				RiseLimit:         DefaultTempRiseLimit,
+				RiseWindow:        DefaultTempRiseWindow,
+				Retention:         DefaultTempRetention,
+			},
 		},
 		Probing: ProbingConfig{
 			Enabled:                true,
@@ -207,12 +220,18 @@ func (c *DiskManagerConfig) Validate() error {
 			return ErrInvalidConfig("discovery timeout must be positive")
 		}
 	}
+	if err := c.Discovery.Exclude.Validate(); err != nil {
+		return err
+	}
 
 	// Validate monitoring config
 	if c.Monitoring.Enabled {
 		if c.Monitoring.Interval <= 0 {
 			return ErrInvalidConfig("monitoring interval must be positive")
 		}
+		if t := c.Monitoring.Temperature.WithDefaults(); t.EnclosureWarning >= t.EnclosureCritical {
+			return ErrInvalidConfig("enclosure temperature warning must be below critical")
+		}
 	}
 
 	// Validate probing config
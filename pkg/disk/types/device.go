@@ -19,9 +19,11 @@ type PhysicalDisk struct {
 	Firmware       string `json:"firmware"`         // Firmware version
 
 	// Device properties
-	Type      DeviceType    `json:"type"`       // HDD, SSD, NVMe, etc.
-	Interface InterfaceType `json:"interface"`  // SATA, SAS, NVMe, etc.
-	SizeBytes uint64        `json:"size_bytes"` // Total device size in bytes
+	Type               DeviceType    `json:"type"`                           // HDD, SSD, NVMe, etc.
+	Interface          InterfaceType `json:"interface"`                      // SATA, SAS, NVMe, etc.
+	SizeBytes          uint64        `json:"size_bytes"`                     // Total device size in bytes
+	PhysicalSectorSize int           `json:"physical_sector_size,omitempty"` // Physical sector size in bytes
+	LogicalSectorSize  int           `json:"logical_sector_size,omitempty"`  // Logical sector size in bytes
 
 	// Alternative device paths
 	ByIDPath   string   `json:"by_id_path"`   // /dev/disk/by-id path (first one found)
@@ -32,6 +34,9 @@ type PhysicalDisk struct {
 	// Physical topology (optional, filled by topology discovery)
 	Topology *DiskTopology `json:"topology,omitempty"`
 
+	// Paths of a multipath disk (nil for single-path disks)
+	Multipath *MultipathInfo `json:"multipath,omitempty"`
+
 	// SMART capability
 	SMARTAvailable      bool       `json:"smart_available"`       // Whether SMART is supported
 	SMARTEnabled        bool       `json:"smart_enabled"`         // Whether SMART is enabled
@@ -39,9 +44,10 @@ type PhysicalDisk struct {
 	SMARTInfo           *SMARTInfo `json:"smart_info,omitempty"`  // Latest SMART data
 
 	// Health and state
-	State        DiskState    `json:"state"`         // Current lifecycle state
-	Health       HealthStatus `json:"health"`        // Overall health status
-	HealthReason string       `json:"health_reason"` // Explanation for health status
+	State        DiskState    `json:"state"`          // Current lifecycle state
+	Health       HealthStatus `json:"health"`         // Overall health status
+	HealthReason string       `json:"health_reason"`  // Explanation for health status
+	Risk         *DiskRisk    `json:"risk,omitempty"` // Predictive failure score, set by health checks
 
 	// ZFS integration
 	PoolName string `json:"pool_name,omitempty"` // Pool name if disk is in use
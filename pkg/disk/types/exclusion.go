@@ -0,0 +1,49 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import (
+	"fmt"
+	"path"
+	"time"
+)
+
+// ExclusionConfig lists the devices discovery ignores. Ignored devices are
+// left out of the inventory, health checks, probes and reconciliation, and
+// are listed with the rule that matched them.
+type ExclusionConfig struct {
+	SystemDisks bool     `yaml:"system_disks" json:"system_disks"` // Disks holding /, /boot, the EFI partition or swap
+	USB         bool     `yaml:"usb" json:"usb"`                   // Disks attached over USB
+	WWNs        []string `yaml:"wwns" json:"wwns"`                 // WWNs, with or without the 0x prefix
+	Patterns    []string `yaml:"patterns" json:"patterns"`         // Globs on device path, device ID, device links or model
+}
+
+// Validate checks that the exclusion patterns are valid globs
+func (c *ExclusionConfig) Validate() error {
+	for _, pattern := range c.Patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return ErrInvalidConfig(fmt.Sprintf("invalid exclusion pattern %q: %v", pattern, err))
+		}
+	}
+	return nil
+}
+
+// ExclusionReason is the kind of rule that excluded a device
+type ExclusionReason string
+
+const (
+	ExcludedSystemDisk ExclusionReason = "system_disk" // Holds the OS
+	ExcludedUSB        ExclusionReason = "usb"         // Attached over USB
+	ExcludedWWN        ExclusionReason = "wwn"         // WWN listed in the config
+	ExcludedPattern    ExclusionReason = "pattern"     // Matched a configured pattern
+)
+
+// IgnoredDisk is a device discovery found and excluded
+type IgnoredDisk struct {
+	*PhysicalDisk
+	Reason    ExclusionReason `json:"ignore_reason"`
+	Rule      string          `json:"ignore_rule,omitempty"` // The mountpoint, WWN or pattern that matched
+	IgnoredAt time.Time       `json:"ignored_at"`
+}
@@ -0,0 +1,55 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+// MultipathInfo describes a disk reached over several paths, such as the
+// two ports of a dual-ported SAS disk. Discovery collapses the paths into
+// one logical disk whose DevicePath is the multipath device.
+type MultipathInfo struct {
+	Name   string          `json:"name,omitempty"`    // Map name, such as mpatha; empty without a map
+	DMPath string          `json:"dm_path,omitempty"` // Multipath device, such as /dev/mapper/mpatha
+	WWID   string          `json:"wwid,omitempty"`    // WWID from /etc/multipath
+	Paths  []MultipathPath `json:"paths"`
+}
+
+// MultipathPath is one path to a multipath disk
+type MultipathPath struct {
+	Device string `json:"device"`         // Path device, such as /dev/sdb
+	HCTL   string `json:"hctl,omitempty"` // SCSI Host:Channel:Target:LUN
+	State  string `json:"state"`          // SCSI device state, such as running or offline
+}
+
+// PathStateRunning is the state of a usable SCSI path
+const PathStateRunning = "running"
+
+// Active reports whether the path can carry I/O
+func (p MultipathPath) Active() bool {
+	return p.State == "" || p.State == PathStateRunning
+}
+
+// ActivePaths counts the paths that can carry I/O
+func (m *MultipathInfo) ActivePaths() int {
+	n := 0
+	for _, p := range m.Paths {
+		if p.Active() {
+			n++
+		}
+	}
+	return n
+}
+
+// SMARTDevicePath returns the device to query SMART on: an active path of a
+// multipath disk, since the multipath device does not pass SMART commands
+// through, or the device path
+func (d *PhysicalDisk) SMARTDevicePath() string {
+	if d.Multipath != nil {
+		for _, p := range d.Multipath.Paths {
+			if p.Active() {
+				return p.Device
+			}
+		}
+	}
+	return d.DevicePath
+}
@@ -0,0 +1,69 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "time"
+
+// RiskLevel groups failure risk scores
+type RiskLevel string
+
+const (
+	RiskLow      RiskLevel = "LOW"      // Score below 25: no signs of failure
+	RiskElevated RiskLevel = "ELEVATED" // Score 25-49: worth watching
+	RiskHigh     RiskLevel = "HIGH"     // Score 50-74: plan a replacement
+	RiskCritical RiskLevel = "CRITICAL" // Score 75 and above: replace now
+)
+
+// RiskLevelForScore returns the level of a risk score
+func RiskLevelForScore(score int) RiskLevel {
+	switch {
+	case score >= 75:
+		return RiskCritical
+	case score >= 50:
+		return RiskHigh
+	case score >= 25:
+		return RiskElevated
+	default:
+		return RiskLow
+	}
+}
+
+// DiskRisk is a predictive failure score of a disk, from 0 (no signs of
+// failure) to 100, with the factors adding to it
+type DiskRisk struct {
+	Score      int          `json:"score"`
+	Level      RiskLevel    `json:"level"`
+	Factors    []RiskFactor `json:"factors,omitempty"`
+	ScoredAt   time.Time    `json:"scored_at"`
+	TrendSince *time.Time   `json:"trend_since,omitempty"` // Oldest SMART sample trends are measured from
+}
+
+// RiskFactor is one sign of failure and the points it adds to a risk score
+type RiskFactor struct {
+	Name   string `json:"name"` // Such as "reallocation_growth" or "zfs_checksum_errors"
+	Points int    `json:"points"`
+	Detail string `json:"detail"`
+}
+
+// SMARTSample holds the failure counters of a disk at one health check, so
+// risk scoring can follow their growth
+type SMARTSample struct {
+	At              time.Time `json:"at"`
+	Reallocated     uint64    `json:"reallocated"`                 // Reallocated sectors (ID 5)
+	Pending         uint64    `json:"pending"`                     // Current pending sectors (ID 197)
+	Uncorrectable   uint64    `json:"uncorrectable"`               // Offline (ID 198) and reported (ID 187) uncorrectable
+	MediaErrors     uint64    `json:"media_errors,omitempty"`      // NVMe media errors
+	PercentUsed     int       `json:"percent_used,omitempty"`      // NVMe endurance used
+	ZFSErrors       uint64    `json:"zfs_errors,omitempty"`        // ZFS read, write and checksum errors
+	ErrorLogEntries uint64    `json:"error_log_entries,omitempty"` // SMART or NVMe error log entries
+}
+
+// ZFSErrors are the error counters ZFS keeps for a pool disk since the pool
+// was imported or the errors were cleared
+type ZFSErrors struct {
+	Read     uint64 `json:"read"`
+	Write    uint64 `json:"write"`
+	Checksum uint64 `json:"checksum"`
+}
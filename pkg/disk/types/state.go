@@ -30,6 +30,7 @@ type DiskManagerState struct {
 // DeviceState represents the persistent state of a single device
 type DeviceState struct {
 	DeviceID     string       `json:"device_id"`
+	DevicePath   string       `json:"device_path,omitempty"` // Last seen device path; changes across reboots
 	State        DiskState    `json:"state"`
 	Health       HealthStatus `json:"health"`
 	HealthReason string       `json:"health_reason,omitempty"` // Explanation for health status
@@ -52,6 +53,10 @@ type DeviceState struct {
 	// Pool membership (populated during discovery)
 	PoolName string `json:"pool_name,omitempty"` // ZFS pool name (if member of a pool)
 
+	// Failure prediction (populated during health checks)
+	Risk         *DiskRisk     `json:"risk,omitempty"`          // Latest failure risk score
+	SMARTHistory []SMARTSample `json:"smart_history,omitempty"` // Failure counters over time, oldest first
+
 	// Metadata
 	Metadata map[string]string `json:"metadata,omitempty"`
 }
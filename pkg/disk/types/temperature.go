@@ -0,0 +1,101 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package types
+
+import "time"
+
+const (
+	DefaultEnclosureTempWarning  = 40.0             // Enclosure sensor warning (C)
+	DefaultEnclosureTempCritical = 50.0             // Enclosure sensor critical (C)
+	DefaultTempRiseLimit         = 8.0              // Rise that alerts within the rise window (C)
+	DefaultTempRiseWindow        = 30 * time.Minute // Window a rise is measured over
+	DefaultTempRetention         = 7 * 24 * time.Hour
+)
+
+// TemperatureConfig configures temperature history and alerts. Disk
+// thresholds are the SMART thresholds; zero values take the defaults.
+type TemperatureConfig struct {
+	EnclosureWarning  float64       `yaml:"enclosure_warning" json:"enclosure_warning"`   // Enclosure sensor warning (C)
+	EnclosureCritical float64       `yaml:"enclosure_critical" json:"enclosure_critical"` // Enclosure sensor critical (C)
+	RiseLimit         float64       `yaml:"rise_limit" json:"rise_limit"`                 // Rise within the window that alerts (C)
+	RiseWindow        time.Duration `yaml:"rise_window" json:"rise_window"`               // Window a rise is measured over
+	Retention         time.Duration `yaml:"retention" json:"retention"`                   // Temperature history kept
+}
+
+// WithDefaults returns the config with zero values set to the defaults
+func (c TemperatureConfig) WithDefaults() TemperatureConfig {
+	if c.EnclosureWarning <= 0 {
+		c.EnclosureWarning = DefaultEnclosureTempWarning
+	}
+	if c.EnclosureCritical <= 0 {
+		c.EnclosureCritical = DefaultEnclosureTempCritical
+	}
+	if c.RiseLimit <= 0 {
+		c.RiseLimit = DefaultTempRiseLimit
+	}
+	if c.RiseWindow <= 0 {
+		c.RiseWindow = DefaultTempRiseWindow
+	}
+	if c.Retention <= 0 {
+		c.Retention = DefaultTempRetention
+	}
+	return c
+}
+
+// TempLevel grades a temperature against its thresholds
+type TempLevel string
+
+const (
+	TempNormal   TempLevel = "NORMAL"
+	TempWarning  TempLevel = "WARNING"
+	TempCritical TempLevel = "CRITICAL"
+)
+
+// TempLevelFor returns the level of a temperature
+func TempLevelFor(celsius, warning, critical float64) TempLevel {
+	switch {
+	case celsius >= critical:
+		return TempCritical
+	case celsius >= warning:
+		return TempWarning
+	default:
+		return TempNormal
+	}
+}
+
+// SensorKind is what a temperature sensor measures
+type SensorKind string
+
+const (
+	SensorDisk      SensorKind = "disk"      // Drive temperature from SMART
+	SensorEnclosure SensorKind = "enclosure" // SES temperature sensor of an enclosure
+)
+
+// TemperatureSample is one temperature reading
+type TemperatureSample struct {
+	At      time.Time `json:"at"`
+	Celsius float64   `json:"celsius"`
+}
+
+// TemperatureSensor is the current temperature of a disk or enclosure
+// sensor, with its history when requested
+type TemperatureSensor struct {
+	ID        string              `json:"id"` // Device ID, or enclosure ID and sensor index
+	Kind      SensorKind          `json:"kind"`
+	Name      string              `json:"name"` // Device path or sensor location
+	Celsius   float64             `json:"celsius"`
+	Level     TempLevel           `json:"level"`
+	Rising    bool                `json:"rising"` // Rose by the rise limit within the rise window
+	UpdatedAt time.Time           `json:"updated_at"`
+	History   []TemperatureSample `json:"history,omitempty"` // Oldest first
+}
+
+// TemperatureAlert is a sensor whose level changed or that started or
+// stopped rising fast
+type TemperatureAlert struct {
+	Sensor   TemperatureSensor `json:"sensor"`
+	OldLevel TempLevel         `json:"old_level"`
+	Reason   string            `json:"reason"`
+}
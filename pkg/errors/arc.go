@@ -0,0 +1,43 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+// ZFS ARC error codes (2540-2549)
+const (
+	ARCStatsUnavailable  = 2540 + iota // ARC statistics could not be read
+	ARCInvalidConfig                   // Invalid ARC configuration
+	ARCConfigWriteFailed               // ARC configuration could not be written
+)
+
+func init() {
+	arcErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		ARCStatsUnavailable: {
+			"ARC statistics unavailable",
+			DomainZFS,
+			http.StatusServiceUnavailable,
+		},
+		ARCInvalidConfig: {
+			"Invalid ARC configuration",
+			DomainZFS,
+			http.StatusBadRequest,
+		},
+		ARCConfigWriteFailed: {
+			"Failed to write ARC configuration",
+			DomainZFS,
+			http.StatusInternalServerError,
+		},
+	}
+
+	maps.Copy(errorDefinitions, arcErrorDefinitions)
+}
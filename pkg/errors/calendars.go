@@ -0,0 +1,55 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+// Schedule calendar error codes (2520-2529)
+const (
+	CalendarNotFound      = 2520 + iota // Calendar not found
+	CalendarInvalidConfig               // Invalid calendar definition
+	CalendarAlreadyExists               // A calendar with the name exists
+	CalendarInUse                       // Calendar is referenced by a schedule
+	CalendarParseFailed                 // iCalendar data could not be parsed
+)
+
+func init() {
+	calendarErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		CalendarNotFound: {
+			"Calendar not found",
+			DomainZFS,
+			http.StatusNotFound,
+		},
+		CalendarInvalidConfig: {
+			"Invalid calendar configuration",
+			DomainZFS,
+			http.StatusBadRequest,
+		},
+		CalendarAlreadyExists: {
+			"Calendar already exists",
+			DomainZFS,
+			http.StatusConflict,
+		},
+		CalendarInUse: {
+			"Calendar is used by schedules",
+			DomainZFS,
+			http.StatusConflict,
+		},
+		CalendarParseFailed: {
+			"Failed to parse iCalendar data",
+			DomainZFS,
+			http.StatusBadRequest,
+		},
+	}
+
+	maps.Copy(errorDefinitions, calendarErrorDefinitions)
+}
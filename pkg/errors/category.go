@@ -0,0 +1,148 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Category says what kind of failure an error is, so callers can decide
+// whether to retry it, how loudly to report it and which status to answer
+// with. Errors of unknown kind have no category.
+type Category string
+
+const (
+	CategoryNetwork    Category = "network"    // Host unreachable, connection dropped
+	CategoryTimeout    Category = "timeout"    // Operation took too long
+	CategoryBusy       Category = "busy"       // Resource in use by another operation
+	CategoryPermission Category = "permission" // Not allowed to do it
+	CategoryNoSpace    Category = "no_space"   // Out of space or over quota
+	CategoryNotFound   Category = "not_found"  // Object does not exist
+	CategoryInvalid    Category = "invalid"    // Request is wrong
+)
+
+// Retryable reports whether an operation that failed this way may succeed
+// when tried again unchanged
+func (c Category) Retryable() bool {
+	switch c {
+	case CategoryNetwork, CategoryTimeout, CategoryBusy:
+		return true
+	}
+	return false
+}
+
+// Permanent reports whether retrying is known to be pointless. Errors
+// without a category are neither retryable nor permanent.
+func (c Category) Permanent() bool {
+	return c != "" && !c.Retryable()
+}
+
+// categoryHTTPStatus answers for errors whose code only has a generic status
+var categoryHTTPStatus = map[Category]int{
+	CategoryNetwork:    http.StatusBadGateway,
+	CategoryTimeout:    http.StatusGatewayTimeout,
+	CategoryBusy:       http.StatusConflict,
+	CategoryPermission: http.StatusForbidden,
+	CategoryNoSpace:    http.StatusInsufficientStorage,
+	CategoryNotFound:   http.StatusNotFound,
+}
+
+// codeCategories holds the category implied by an error code
+var codeCategories = map[ErrorCode]Category{
+	CommandTimeout:             CategoryTimeout,
+	CommandPermission:          CategoryPermission,
+	CommandInvalidInput:        CategoryInvalid,
+	CommandNotFound:            CategoryNotFound,
+	ZFSPermissionDenied:        CategoryPermission,
+	ZFSQuotaExceeded:           CategoryNoSpace,
+	ZFSRequestValidationError:  CategoryInvalid,
+	PermissionDenied:           CategoryPermission,
+	ServerUnauthorized:         CategoryPermission,
+	NotFoundError:              CategoryNotFound,
+	ServerRequestValidation:    CategoryInvalid,
+	ServerDaemonUnreachable:    CategoryNetwork,
+	ConfigPermissionDenied:     CategoryPermission,
+	SSHKeyPairPermissionDenied: CategoryPermission,
+}
+
+// outputPatterns map lowercase fragments of command output to categories.
+// They are tried in order, so more specific fragments come first.
+var outputPatterns = []struct {
+	fragment string
+	category Category
+}{
+	{"out of space", CategoryNoSpace},
+	{"no space left on device", CategoryNoSpace},
+	{"disk quota exceeded", CategoryNoSpace},
+	{"quota exceeded", CategoryNoSpace},
+	{"dataset is busy", CategoryBusy},
+	{"pool is busy", CategoryBusy},
+	{"resource busy", CategoryBusy},
+	{"currently being received", CategoryBusy},
+	{"another operation in progress", CategoryBusy},
+	{"permission denied", CategoryPermission},
+	{"operation not permitted", CategoryPermission},
+	{"a password is required", CategoryPermission},
+	{"is not in the sudoers file", CategoryPermission},
+	{"host key verification failed", CategoryPermission},
+	{"connection timed out", CategoryNetwork},
+	{"connection refused", CategoryNetwork},
+	{"connection reset", CategoryNetwork},
+	{"connection closed", CategoryNetwork},
+	{"no route to host", CategoryNetwork},
+	{"network is unreachable", CategoryNetwork},
+	{"could not resolve hostname", CategoryNetwork},
+	{"broken pipe", CategoryNetwork},
+	{"kex_exchange_identification", CategoryNetwork},
+	{"timed out", CategoryTimeout},
+	{"does not exist", CategoryNotFound},
+	{"no such file or directory", CategoryNotFound},
+	{"no such pool", CategoryNotFound},
+	{"no such tag", CategoryNotFound},
+}
+
+// Classify returns the category of a failure from the output of the command
+// that failed, or "" when the output does not say
+func Classify(output string) Category {
+	output = strings.ToLower(output)
+	for _, p := range outputPatterns {
+		if strings.Contains(output, p.fragment) {
+			return p.category
+		}
+	}
+	return ""
+}
+
+// WithCategory sets the error's category. Errors whose code only carries a
+// generic 400 or 500 status take the category's status instead.
+func (e *RodentError) WithCategory(c Category) *RodentError {
+	e.Category = c
+	if status, ok := categoryHTTPStatus[c]; ok &&
+		(e.HTTPStatus == http.StatusBadRequest || e.HTTPStatus == http.StatusInternalServerError) {
+		e.HTTPStatus = status
+	}
+	return e
+}
+
+// CategoryOf returns the category of an error. Errors other than
+// RodentErrors are classified by their message.
+func CategoryOf(err error) Category {
+	if err == nil {
+		return ""
+	}
+	var re *RodentError
+	if errors.As(err, &re) {
+		return re.Category
+	}
+	return Classify(err.Error())
+}
+
+// IsRetryable reports whether the operation that failed with err may
+// succeed when tried again
+func IsRetryable(err error) bool {
+	return CategoryOf(err).Retryable()
+}
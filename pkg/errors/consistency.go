@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainConsistency Domain = "CONSISTENCY"
+)
+
+// Consistency check error codes (2560-2569)
+const (
+	ConsistencyCheckFailed  = 2560 + iota // A subsystem could not be checked
+	ConsistencyIssueUnknown               // No issue of the ID was found by the last check
+	ConsistencyRepairFailed               // An issue could not be repaired
+)
+
+func init() {
+	consistencyErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		ConsistencyCheckFailed: {
+			"Consistency check failed",
+			DomainConsistency,
+			http.StatusInternalServerError,
+		},
+		ConsistencyIssueUnknown: {
+			"Consistency issue not found",
+			DomainConsistency,
+			http.StatusNotFound,
+		},
+		ConsistencyRepairFailed: {
+			"Consistency repair failed",
+			DomainConsistency,
+			http.StatusInternalServerError,
+		},
+	}
+
+	maps.Copy(errorDefinitions, consistencyErrorDefinitions)
+}
@@ -108,6 +108,7 @@ func New(code ErrorCode, details string) *RodentError {
 		Message:    def.message,
 		Details:    details,
 		HTTPStatus: def.httpStatus,
+		Category:   codeCategories[code],
 	}
 }
 
@@ -149,9 +150,18 @@ func Wrap(err error, code ErrorCode) *RodentError {
 		newErr.WithMetadata("wrapped_code", fmt.Sprintf("%d", re.Code))
 		newErr.WithMetadata("wrapped_domain", string(re.Domain))
 		newErr.WithMetadata("wrapped_message", re.Message)
+		if newErr.Category == "" && re.Category != "" {
+			newErr.WithCategory(re.Category)
+		}
 		return newErr
 	}
-	return New(code, err.Error())
+	newErr := New(code, err.Error())
+	if newErr.Category == "" {
+		if c := Classify(err.Error()); c != "" {
+			newErr.WithCategory(c)
+		}
+	}
+	return newErr
 }
 
 // Unwrap implements the interface for errors.Unwrap
@@ -180,6 +190,7 @@ type CommandError struct {
 
 func NewCommandError(cmd string, exitCode int, stderr string) *RodentError {
 	return New(CommandExecution, "Command execution failed").
+		WithCategory(Classify(stderr)).
 		WithMetadata("command", cmd).
 		WithMetadata("exit_code", fmt.Sprintf("%d", exitCode)).
 		WithMetadata("stderr", stderr)
@@ -248,6 +259,10 @@ func (e *RodentError) ToProto() *proto.RodentError {
 			protoErr.Metadata[k] = v
 		}
 	}
+	// The proto has no category field; it travels in the metadata
+	if e.Category != "" {
+		protoErr.Metadata["category"] = string(e.Category)
+	}
 
 	return protoErr
 }
@@ -273,6 +288,12 @@ func FromProto(protoErr *proto.RodentError) *RodentError {
 			rodentErr.Metadata[k] = v
 		}
 	}
+	if c, ok := rodentErr.Metadata["category"]; ok {
+		rodentErr.Category = Category(c)
+		delete(rodentErr.Metadata, "category")
+	} else {
+		rodentErr.Category = codeCategories[rodentErr.Code]
+	}
 
 	return rodentErr
 }
@@ -0,0 +1,65 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainJobs Domain = "JOBS"
+)
+
+// Job queue error codes (2500-2519)
+const (
+	JobNotFound        = 2500 + iota // Job not found
+	JobTypeUnknown                   // No handler registered for the job type
+	JobInvalidPayload                // Job payload could not be encoded
+	JobStateLoadFailed               // Failed to load persisted jobs
+	JobStateSaveFailed               // Failed to persist jobs
+	JobNotCancellable                // Job already finished
+)
+
+func init() {
+	jobErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		JobNotFound: {
+			"Job not found",
+			DomainJobs,
+			http.StatusNotFound,
+		},
+		JobTypeUnknown: {
+			"Unknown job type",
+			DomainJobs,
+			http.StatusBadRequest,
+		},
+		JobInvalidPayload: {
+			"Invalid job payload",
+			DomainJobs,
+			http.StatusBadRequest,
+		},
+		JobStateLoadFailed: {
+			"Failed to load persisted jobs",
+			DomainJobs,
+			http.StatusInternalServerError,
+		},
+		JobStateSaveFailed: {
+			"Failed to persist jobs",
+			DomainJobs,
+			http.StatusInternalServerError,
+		},
+		JobNotCancellable: {
+			"Job has already finished",
+			DomainJobs,
+			http.StatusConflict,
+		},
+	}
+
+	maps.Copy(errorDefinitions, jobErrorDefinitions)
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainMaintenance Domain = "MAINTENANCE"
+)
+
+// Maintenance error codes (2600-2609)
+const (
+	MaintenanceActive      = 2600 + iota // Refused while the node is in maintenance mode
+	MaintenanceNotActive                 // The node is not in maintenance mode
+	MaintenanceStateFailed               // Maintenance state could not be saved or applied
+)
+
+func init() {
+	maintenanceErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		MaintenanceActive: {
+			"Node is in maintenance mode",
+			DomainMaintenance,
+			http.StatusServiceUnavailable,
+		},
+		MaintenanceNotActive: {
+			"Node is not in maintenance mode",
+			DomainMaintenance,
+			http.StatusConflict,
+		},
+		MaintenanceStateFailed: {
+			"Failed to apply maintenance state",
+			DomainMaintenance,
+			http.StatusInternalServerError,
+		},
+	}
+
+	maps.Copy(errorDefinitions, maintenanceErrorDefinitions)
+}
@@ -0,0 +1,43 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+// Pool planning error codes (2590-2599)
+const (
+	PoolPlanInvalid           = 2590 + iota // The requested pool layout is invalid
+	PoolPlanRejected                        // The disks do not fit the layout
+	PoolPlanCreateUnavailable               // Pools cannot be created by this process
+)
+
+func init() {
+	poolPlanErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		PoolPlanInvalid: {
+			"Invalid pool layout",
+			DomainZFS,
+			http.StatusBadRequest,
+		},
+		PoolPlanRejected: {
+			"Disks do not fit the pool layout",
+			DomainZFS,
+			http.StatusConflict,
+		},
+		PoolPlanCreateUnavailable: {
+			"Pool creation is not available",
+			DomainZFS,
+			http.StatusServiceUnavailable,
+		},
+	}
+
+	maps.Copy(errorDefinitions, poolPlanErrorDefinitions)
+}
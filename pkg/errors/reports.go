@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainReports Domain = "REPORTS"
+)
+
+// Report error codes (2550-2559)
+const (
+	ReportNotFound       = 2550 + iota // No digest with the name is configured
+	ReportInvalidConfig                // Digest or mail server configuration is invalid
+	ReportDeliveryFailed               // Digest could not be sent
+)
+
+func init() {
+	reportErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		ReportNotFound: {
+			"Digest report not found",
+			DomainReports,
+			http.StatusNotFound,
+		},
+		ReportInvalidConfig: {
+			"Invalid digest report configuration",
+			DomainReports,
+			http.StatusBadRequest,
+		},
+		ReportDeliveryFailed: {
+			"Digest report delivery failed",
+			DomainReports,
+			http.StatusBadGateway,
+		},
+	}
+
+	maps.Copy(errorDefinitions, reportErrorDefinitions)
+}
@@ -0,0 +1,59 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainStream Domain = "STREAM"
+)
+
+// Stream session error codes (2570-2579)
+const (
+	StreamNotFound         = 2570 + iota // No stream session of the ID
+	StreamOffsetMismatch                 // An upload chunk does not start at the session's offset
+	StreamRangeUnsatisfied               // A download range starts past the end of the stream
+	StreamClosed                         // The session's zfs process has exited
+	StreamFailed                         // The session's zfs process failed
+)
+
+func init() {
+	streamErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		StreamNotFound: {
+			"Stream session not found",
+			DomainStream,
+			http.StatusNotFound,
+		},
+		StreamOffsetMismatch: {
+			"Chunk does not continue the stream",
+			DomainStream,
+			http.StatusConflict,
+		},
+		StreamRangeUnsatisfied: {
+			"Range starts past the end of the stream",
+			DomainStream,
+			http.StatusRequestedRangeNotSatisfiable,
+		},
+		StreamClosed: {
+			"Stream session is closed",
+			DomainStream,
+			http.StatusConflict,
+		},
+		StreamFailed: {
+			"Stream failed",
+			DomainStream,
+			http.StatusInternalServerError,
+		},
+	}
+
+	maps.Copy(errorDefinitions, streamErrorDefinitions)
+}
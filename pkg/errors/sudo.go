@@ -0,0 +1,43 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainSudo Domain = "SUDO"
+)
+
+// Sudo error codes (2580-2589)
+const (
+	SudoPasswordRequired = 2580 + iota // sudo asks for a password for a command Rodent runs
+	SudoNotPermitted                   // sudo does not allow a command Rodent runs
+)
+
+func init() {
+	sudoErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		SudoPasswordRequired: {
+			"Passwordless sudo is not configured",
+			DomainSudo,
+			http.StatusServiceUnavailable,
+		},
+		SudoNotPermitted: {
+			"Command is not allowed by sudo",
+			DomainSudo,
+			http.StatusServiceUnavailable,
+		},
+	}
+
+	maps.Copy(errorDefinitions, sudoErrorDefinitions)
+	codeCategories[SudoPasswordRequired] = CategoryPermission
+	codeCategories[SudoNotPermitted] = CategoryPermission
+}
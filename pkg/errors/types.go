@@ -47,6 +47,8 @@ type RodentError struct {
 	Details string    `json:"details,omitempty"`
 	// Context    string           `json:"context,omitempty"`
 	HTTPStatus int `json:"-"`
+	// Category says what kind of failure this is, as far as it is known
+	Category Category `json:"category,omitempty"`
 
 	// The Metadata field is designed for additional contextual information
 	// that doesn't fit into the standard error fields but is valuable for
@@ -100,7 +102,12 @@ const (
 	ServerContextCancelled                // Context cancelled
 	ServerTLSError                        // TLS configuration error
 	ServerInternalError
-	ServerBadRequest // Bad request error
+	ServerBadRequest           // Bad request error
+	ServerFeatureDisabled      // Subsystem disabled by a feature flag
+	ServerDaemonUnreachable    // CLI could not reach the running daemon
+	ServerGuardDenied          // Operation blocked by a guard rule
+	ServerGuardConfirmRequired // Operation needs confirmation under a guard rule
+	ServerUnauthorized         // Request lacks a valid API key
 )
 
 const (
@@ -131,6 +138,7 @@ const (
 	ADSetPasswordFailed    // Failed to set password
 	ADEnableAccountFailed  // Failed to enable account
 	ADCreateOUFailed       // Failed to create OU
+	ADMembershipFailed     // Domain join, leave or membership query failed
 )
 
 const (
@@ -240,6 +248,22 @@ const (
 	ZFSPoolDeviceOperation
 	ZFSPoolTooManyDevices
 	ZFSPoolRestrictedDevice
+
+	TransferInsufficientSpace
+
+	// Auto-attach rule errors
+	AttachRuleNotFound
+	AttachRuleInvalidConfig
+
+	// Retention hold errors
+	ZFSHoldError
+	RetentionNotFound
+	RetentionInvalidConfig
+	RetentionShortenDenied
+
+	// Raised instead of a command failure when the installed OpenZFS is
+	// too old for an operation
+	ZFSFeatureUnsupported
 )
 
 const (
@@ -339,6 +363,8 @@ const (
 	SSHKnownHostRemoveFailed                     // Failed to remove from known hosts
 	SSHKnownHostEntryNotFound                    // Known host entry not found
 	SSHKnownHostEntryAlreadyExists               // Known host entry already exists
+	SSHKeyPolicyViolation                        // SSH key or host does not meet the security policy
+	SSHHostKeyNotPinned                          // Remote host has no pinned host key
 )
 
 const (
@@ -443,6 +469,16 @@ var errorDefinitions = map[ErrorCode]struct {
 		DomainSSH,
 		http.StatusConflict,
 	},
+	SSHKeyPolicyViolation: {
+		"SSH key does not meet the security policy",
+		DomainSSH,
+		http.StatusBadRequest,
+	},
+	SSHHostKeyNotPinned: {
+		"Remote host has no pinned host key",
+		DomainSSH,
+		http.StatusPreconditionFailed,
+	},
 	// Configuration errors
 	ConfigNotFound: {"Configuration file not found", DomainConfig, http.StatusNotFound},
 	ConfigInvalid:  {"Invalid configuration format", DomainConfig, http.StatusBadRequest},
@@ -550,6 +586,31 @@ var errorDefinitions = map[ErrorCode]struct {
 		DomainServer,
 		http.StatusBadRequest,
 	},
+	ServerFeatureDisabled: {
+		"Feature is disabled",
+		DomainServer,
+		http.StatusNotFound,
+	},
+	ServerDaemonUnreachable: {
+		"Rodent daemon is not reachable",
+		DomainServer,
+		http.StatusServiceUnavailable,
+	},
+	ServerGuardDenied: {
+		"Operation blocked by a guard rule",
+		DomainServer,
+		http.StatusForbidden,
+	},
+	ServerGuardConfirmRequired: {
+		"Operation requires confirmation",
+		DomainServer,
+		http.StatusPreconditionRequired,
+	},
+	ServerUnauthorized: {
+		"Invalid or missing API key",
+		DomainServer,
+		http.StatusUnauthorized,
+	},
 
 	// Active Directory errors
 	ADConnectFailed: {
@@ -677,6 +738,11 @@ var errorDefinitions = map[ErrorCode]struct {
 		DomainAD,
 		http.StatusInternalServerError,
 	},
+	ADMembershipFailed: {
+		"Domain membership operation failed",
+		DomainAD,
+		http.StatusInternalServerError,
+	},
 
 	// ZFS errors
 	ZFSCommandFailed: {
@@ -845,6 +911,27 @@ var errorDefinitions = map[ErrorCode]struct {
 	ZFSPoolRestrictedDevice: {"ZFS device not allowed", DomainZFS, http.StatusForbidden},
 	ZFSPoolTooManyDevices:   {"ZFS too many devices", DomainZFS, http.StatusForbidden},
 
+	TransferInsufficientSpace: {
+		"Insufficient space on transfer target",
+		DomainZFS,
+		http.StatusInsufficientStorage,
+	},
+
+	// Auto-attach rule error definitions
+	AttachRuleNotFound:      {"Auto-attach rule not found", DomainZFS, http.StatusNotFound},
+	AttachRuleInvalidConfig: {"Invalid auto-attach rule configuration", DomainZFS, http.StatusBadRequest},
+
+	// Retention hold error definitions
+	ZFSHoldError:           {"ZFS snapshot hold operation failed", DomainZFS, http.StatusInternalServerError},
+	RetentionNotFound:      {"Retention record not found", DomainZFS, http.StatusNotFound},
+	RetentionInvalidConfig: {"Invalid retention configuration", DomainZFS, http.StatusBadRequest},
+	RetentionShortenDenied: {"Retention period cannot be shortened", DomainZFS, http.StatusConflict},
+	ZFSFeatureUnsupported: {
+		"Operation not supported by the installed OpenZFS version",
+		DomainZFS,
+		http.StatusNotImplemented,
+	},
+
 	// Command execution errors
 	CommandNotFound:  {"Command not found", DomainCommand, http.StatusNotFound},
 	CommandExecution: {"Command execution failed", DomainCommand, http.StatusBadRequest},
@@ -0,0 +1,47 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package errors
+
+import (
+	"maps"
+	"net/http"
+)
+
+const (
+	DomainWebhooks Domain = "WEBHOOKS"
+)
+
+// Webhook error codes (2530-2539)
+const (
+	WebhookNotFound       = 2530 + iota // No endpoint with the name is configured
+	WebhookInvalidConfig                // Endpoint configuration is invalid
+	WebhookDeliveryFailed               // Endpoint did not accept a delivery
+)
+
+func init() {
+	webhookErrorDefinitions := map[ErrorCode]struct {
+		message    string
+		domain     Domain
+		httpStatus int
+	}{
+		WebhookNotFound: {
+			"Webhook endpoint not found",
+			DomainWebhooks,
+			http.StatusNotFound,
+		},
+		WebhookInvalidConfig: {
+			"Invalid webhook endpoint configuration",
+			DomainWebhooks,
+			http.StatusBadRequest,
+		},
+		WebhookDeliveryFailed: {
+			"Webhook delivery failed",
+			DomainWebhooks,
+			http.StatusBadGateway,
+		},
+	}
+
+	maps.Copy(errorDefinitions, webhookErrorDefinitions)
+}
@@ -0,0 +1,61 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package guard
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler serves the guard rules and issues override tokens
+type APIHandler struct {
+	guard *Guard
+}
+
+// NewAPIHandler creates a guard API handler
+func NewAPIHandler(g *Guard) *APIHandler {
+	return &APIHandler{guard: g}
+}
+
+// OverrideRequest asks for an override token
+type OverrideRequest struct {
+	Operation Operation `json:"operation" binding:"required"`
+	Target    string    `json:"target"    binding:"required"`
+	Reason    string    `json:"reason"    binding:"required"`
+}
+
+// RegisterRoutes registers the guard routes. issue guards the route that
+// issues override tokens, which should be limited to clients on the host.
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup, issue ...gin.HandlerFunc) {
+	router.GET("", h.list)
+	router.POST("/overrides", append(issue, h.issueOverride)...)
+}
+
+// list returns the rules and the outstanding override tokens
+func (h *APIHandler) list(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"rules":     h.guard.Rules(),
+		"overrides": h.guard.Overrides(),
+	})
+}
+
+// issueOverride issues a one-time override token
+func (h *APIHandler) issueOverride(c *gin.Context) {
+	var req OverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	o, err := h.guard.IssueOverride(c.Request.Context(), req.Operation, req.Target, req.Reason)
+	if err != nil {
+		common.APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, o)
+}
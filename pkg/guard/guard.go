@@ -0,0 +1,374 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package guard checks destructive operations against configured rules
+// before they run. A rule denies an operation, or asks for it to be
+// confirmed; a one-time override token lets an operator break glass.
+package guard
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Operation names a guarded operation
+type Operation string
+
+// Guarded operations
+const (
+	OpPoolDestroy          Operation = "pool.destroy"
+	OpDatasetDestroy       Operation = "dataset.destroy"
+	OpShareDelete          Operation = "share.delete"
+	OpSnapshotPolicyRemove Operation = "snapshot_policy.remove"
+	OpTransferStart        Operation = "transfer.start"
+	OpComplianceRemove     Operation = "compliance.remove" // Always needs an override
+)
+
+// Operations lists the guarded operations
+var Operations = []Operation{
+	OpPoolDestroy,
+	OpDatasetDestroy,
+	OpShareDelete,
+	OpSnapshotPolicyRemove,
+	OpTransferStart,
+	OpComplianceRemove,
+}
+
+// Action is what a rule does to the operations it matches
+type Action string
+
+// Rule actions
+const (
+	ActionDeny    Action = "deny"    // Blocked unless overridden
+	ActionConfirm Action = "confirm" // Blocked unless confirmed or overridden
+)
+
+// defaultOverrideTTL is how long override tokens are valid when the config
+// does not say
+const defaultOverrideTTL = 15 * time.Minute
+
+// Rule is a validated guard rule
+type Rule struct {
+	Name           string      `json:"name"`
+	Operations     []Operation `json:"operations,omitempty"`
+	Targets        []string    `json:"targets,omitempty"`
+	MinFreePercent float64     `json:"min_free_percent,omitempty"`
+	Action         Action      `json:"action"`
+	Message        string      `json:"message,omitempty"`
+}
+
+// ParseRule validates a rule from the config file
+func ParseRule(cfg config.GuardRule) (Rule, error) {
+	rule := Rule{
+		Name:           cfg.Name,
+		Targets:        cfg.Targets,
+		MinFreePercent: cfg.MinFreePercent,
+		Action:         Action(cfg.Action),
+		Message:        cfg.Message,
+	}
+	if rule.Name == "" {
+		return Rule{}, errors.New(errors.ConfigValidationFailed, "guard rule name is required")
+	}
+	if rule.Action == "" {
+		rule.Action = ActionDeny
+	}
+	if rule.Action != ActionDeny && rule.Action != ActionConfirm {
+		return Rule{}, errors.New(errors.ConfigValidationFailed,
+			fmt.Sprintf("guard rule action must be %q or %q", ActionDeny, ActionConfirm)).
+			WithMetadata("rule", rule.Name)
+	}
+	for _, op := range cfg.Operations {
+		if !slices.Contains(Operations, Operation(op)) {
+			return Rule{}, errors.New(errors.ConfigValidationFailed, "unknown guarded operation").
+				WithMetadata("rule", rule.Name).
+				WithMetadata("operation", op)
+		}
+		rule.Operations = append(rule.Operations, Operation(op))
+	}
+	for _, pattern := range rule.Targets {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Rule{}, errors.Wrap(err, errors.ConfigValidationFailed).
+				WithMetadata("rule", rule.Name).
+				WithMetadata("target", pattern)
+		}
+	}
+	if rule.MinFreePercent < 0 || rule.MinFreePercent > 100 {
+		return Rule{}, errors.New(errors.ConfigValidationFailed,
+			"guard rule minFreePercent must be between 0 and 100").
+			WithMetadata("rule", rule.Name)
+	}
+	return rule, nil
+}
+
+// Request describes an operation about to run
+type Request struct {
+	Operation Operation
+
+	// Targets are the names the operation acts on, such as a dataset or a
+	// share name. A rule matches when any of them matches.
+	Targets []string
+
+	// FreePercent reports the free space of the pool a transfer writes to.
+	// It is only called for rules with MinFreePercent.
+	FreePercent func() (float64, error)
+}
+
+// matches reports whether the rule covers the request, not counting free
+// space
+func (r Rule) matches(req Request) bool {
+	if len(r.Operations) > 0 && !slices.Contains(r.Operations, req.Operation) {
+		return false
+	}
+	if len(r.Targets) == 0 {
+		return true
+	}
+	for _, target := range req.Targets {
+		for _, pattern := range r.Targets {
+			if matchTarget(pattern, target) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchTarget matches a target, or any dataset it is under, against a glob
+// pattern, so tank/prod/* also covers tank/prod/db/logs and its snapshots
+func matchTarget(pattern, target string) bool {
+	for name := target; name != ""; {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		i := strings.LastIndexAny(name, "/@#")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return false
+}
+
+// Guard checks operations against its rules
+type Guard struct {
+	logger      logger.Logger
+	rules       []Rule
+	overrideTTL time.Duration
+
+	mu        sync.Mutex
+	overrides map[string]Override // Keyed by the hash of the token
+}
+
+// Singleton instance
+var (
+	globalGuard *Guard
+	initMutex   sync.Mutex
+)
+
+// GetGuard returns the singleton guard for the rules in the config file.
+// Invalid rules are logged and left out.
+func GetGuard(logCfg logger.Config) (*Guard, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalGuard != nil {
+		return globalGuard, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "guard")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	cfg := config.GetConfig().Guards
+	var rules []Rule
+	seen := make(map[string]bool)
+	for _, rc := range cfg.Rules {
+		rule, err := ParseRule(rc)
+		if err != nil {
+			l.Error("Ignoring invalid guard rule", "rule", rc.Name, "error", err)
+			continue
+		}
+		if seen[rule.Name] {
+			l.Error("Ignoring guard rule with duplicate name", "rule", rule.Name)
+			continue
+		}
+		seen[rule.Name] = true
+		rules = append(rules, rule)
+	}
+
+	ttl := defaultOverrideTTL
+	if cfg.OverrideTTL != "" {
+		if d, err := time.ParseDuration(cfg.OverrideTTL); err == nil && d > 0 {
+			ttl = d
+		} else {
+			l.Warn("Invalid guards.overrideTTL, using default",
+				"value", cfg.OverrideTTL,
+				"default", ttl)
+		}
+	}
+
+	globalGuard = NewGuard(l, rules, ttl)
+	return globalGuard, nil
+}
+
+// NewGuard creates a guard for validated rules. Most callers should use
+// GetGuard.
+func NewGuard(l logger.Logger, rules []Rule, overrideTTL time.Duration) *Guard {
+	if overrideTTL <= 0 {
+		overrideTTL = defaultOverrideTTL
+	}
+	return &Guard{
+		logger:      l,
+		rules:       rules,
+		overrideTTL: overrideTTL,
+		overrides:   make(map[string]Override),
+	}
+}
+
+// Rules returns the guard's rules
+func (g *Guard) Rules() []Rule {
+	return append([]Rule(nil), g.rules...)
+}
+
+// Check returns an error if a rule blocks the operation. A confirm rule is
+// satisfied by a confirmation carried by ctx; any rule is satisfied by an
+// override token for the operation and target, which is then spent. A
+// request without an operation is allowed. It is safe to call on a nil guard.
+func (g *Guard) Check(ctx context.Context, req Request) error {
+	if g == nil || req.Operation == "" {
+		return nil
+	}
+
+	approval := ApprovalFromContext(ctx)
+	actor := common.ActorFromContext(ctx)
+	overridden := false
+
+	for _, rule := range g.rules {
+		if !rule.matches(req) {
+			continue
+		}
+		if rule.MinFreePercent > 0 {
+			if req.FreePercent == nil {
+				continue
+			}
+			free, err := req.FreePercent()
+			if err != nil {
+				g.logger.Warn("Could not determine free space for guard rule, skipping it",
+					"rule", rule.Name,
+					"operation", req.Operation,
+					"error", err)
+				continue
+			}
+			if free >= rule.MinFreePercent {
+				continue
+			}
+		}
+
+		if rule.Action == ActionConfirm && approval.Confirm {
+			g.logger.Info("Guarded operation confirmed",
+				"rule", rule.Name,
+				"operation", req.Operation,
+				"targets", req.Targets,
+				"actor_source", actor.Source,
+				"actor_user", actor.User)
+			continue
+		}
+		if !overridden && approval.OverrideToken != "" {
+			overridden = g.redeem(approval.OverrideToken, req)
+		}
+		if overridden {
+			g.logger.Warn("Guard rule overridden",
+				"rule", rule.Name,
+				"operation", req.Operation,
+				"targets", req.Targets,
+				"actor_source", actor.Source,
+				"actor_user", actor.User,
+				"actor_address", actor.Address)
+			continue
+		}
+
+		return rule.violation(req)
+	}
+	return nil
+}
+
+// RequireOverride returns an error unless ctx carries an override token for
+// the operation and target, which is then spent. It guards operations no rule
+// or confirmation may allow, and fails on a nil guard.
+func (g *Guard) RequireOverride(ctx context.Context, req Request) error {
+	approval := ApprovalFromContext(ctx)
+	if g == nil || approval.OverrideToken == "" || !g.redeem(approval.OverrideToken, req) {
+		return errors.New(errors.ServerGuardDenied, "Operation always requires an override token").
+			WithMetadata("operation", string(req.Operation)).
+			WithMetadata("targets", strings.Join(req.Targets, ","))
+	}
+
+	actor := common.ActorFromContext(ctx)
+	g.logger.Warn("Override token used",
+		"operation", req.Operation,
+		"targets", req.Targets,
+		"actor_source", actor.Source,
+		"actor_user", actor.User,
+		"actor_address", actor.Address)
+	return nil
+}
+
+// violation is the error returned for an operation the rule blocks
+func (r Rule) violation(req Request) error {
+	var code errors.ErrorCode = errors.ServerGuardDenied
+	message := r.Message
+	if r.Action == ActionConfirm {
+		code = errors.ServerGuardConfirmRequired
+		if message == "" {
+			message = "Operation requires confirmation; repeat it with confirmation or an override token"
+		}
+	} else if message == "" {
+		message = "Operation is blocked by a guard rule; an override token is required"
+	}
+
+	return errors.New(code, message).
+		WithMetadata("rule", r.Name).
+		WithMetadata("operation", string(req.Operation)).
+		WithMetadata("targets", strings.Join(req.Targets, ","))
+}
+
+var (
+	defaultMu    sync.RWMutex
+	defaultGuard *Guard
+)
+
+// SetDefault installs the guard consulted by Check. Passing nil removes it.
+func SetDefault(g *Guard) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultGuard = g
+}
+
+// Check checks an operation against the installed guard. Without one every
+// operation is allowed.
+func Check(ctx context.Context, req Request) error {
+	defaultMu.RLock()
+	g := defaultGuard
+	defaultMu.RUnlock()
+	return g.Check(ctx, req)
+}
+
+// RequireOverride checks an override token against the installed guard.
+// Without one the operation is refused.
+func RequireOverride(ctx context.Context, req Request) error {
+	defaultMu.RLock()
+	g := defaultGuard
+	defaultMu.RUnlock()
+	return g.RequireOverride(ctx, req)
+}
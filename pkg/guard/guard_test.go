@@ -0,0 +1,152 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package guard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule(config.GuardRule{
+		Name:       "prod",
+		Operations: []string{"dataset.destroy"},
+		Targets:    []string{"tank/prod/*"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, ActionDeny, rule.Action)
+
+	for name, cfg := range map[string]config.GuardRule{
+		"no name":           {Operations: []string{"dataset.destroy"}},
+		"unknown operation": {Name: "x", Operations: []string{"dataset.rename"}},
+		"unknown action":    {Name: "x", Action: "warn"},
+		"bad pattern":       {Name: "x", Targets: []string{"tank/[prod"}},
+		"bad free percent":  {Name: "x", MinFreePercent: 120},
+	} {
+		_, err := ParseRule(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestMatchTarget(t *testing.T) {
+	assert.True(t, matchTarget("tank/prod/*", "tank/prod/db"))
+	assert.True(t, matchTarget("tank/prod/*", "tank/prod/db/logs"))
+	assert.True(t, matchTarget("tank/prod/*", "tank/prod/db@daily"))
+	assert.True(t, matchTarget("tank/prod", "tank/prod/db"))
+	assert.True(t, matchTarget("backup-host:*", "backup-host:tank/replica"))
+	assert.False(t, matchTarget("tank/prod/*", "tank/prod"))
+	assert.False(t, matchTarget("tank/prod/*", "tank/production"))
+	assert.False(t, matchTarget("tank/prod/*", "tank/dev/db"))
+}
+
+func TestGuardCheck(t *testing.T) {
+	g := NewGuard(common.Log, []Rule{
+		{
+			Name:       "prod",
+			Operations: []Operation{OpDatasetDestroy},
+			Targets:    []string{"tank/prod/*"},
+			Action:     ActionDeny,
+		},
+		{
+			Name:           "low-space",
+			Operations:     []Operation{OpTransferStart},
+			MinFreePercent: 10,
+			Action:         ActionConfirm,
+		},
+	}, time.Minute)
+	ctx := context.Background()
+
+	destroy := Request{Operation: OpDatasetDestroy, Targets: []string{"tank/prod/db"}}
+	err := g.Check(ctx, destroy)
+	var re *errors.RodentError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.ServerGuardDenied), re.Code)
+	assert.Equal(t, "prod", re.Metadata["rule"])
+	assert.Equal(t, http.StatusForbidden, re.HTTPStatus)
+
+	assert.NoError(t, g.Check(ctx, Request{Operation: OpDatasetDestroy, Targets: []string{"tank/dev/db"}}))
+	assert.Error(t, g.Check(WithApproval(ctx, Approval{Confirm: true}), destroy),
+		"deny rules are not confirmed")
+
+	// Override tokens are spent on the first use, and only fit their target
+	o, err := g.IssueOverride(ctx, OpDatasetDestroy, "tank/prod/db", "decommission")
+	require.NoError(t, err)
+	require.NotEmpty(t, o.Token)
+	overridden := WithApproval(ctx, Approval{OverrideToken: o.Token})
+	assert.Error(t, g.Check(overridden, Request{Operation: OpDatasetDestroy, Targets: []string{"tank/prod/web"}}))
+	assert.NoError(t, g.Check(overridden, destroy))
+	assert.Error(t, g.Check(overridden, destroy))
+	assert.Empty(t, g.Overrides())
+
+	// Free space rules apply below the threshold
+	transfer := func(free float64) Request {
+		return Request{
+			Operation:   OpTransferStart,
+			Targets:     []string{"backup/replica"},
+			FreePercent: func() (float64, error) { return free, nil },
+		}
+	}
+	assert.NoError(t, g.Check(ctx, transfer(40)))
+	err = g.Check(ctx, transfer(5))
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.ServerGuardConfirmRequired), re.Code)
+	assert.NoError(t, g.Check(WithApproval(ctx, Approval{Confirm: true}), transfer(5)))
+
+	// Requests without an operation are not checked
+	assert.NoError(t, g.Check(ctx, Request{}))
+}
+
+func TestRequireOverride(t *testing.T) {
+	g := NewGuard(common.Log, nil, time.Minute)
+	ctx := context.Background()
+	remove := Request{Operation: OpComplianceRemove, Targets: []string{"tank/audit"}}
+
+	err := g.RequireOverride(WithApproval(ctx, Approval{Confirm: true}), remove)
+	var re *errors.RodentError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.ServerGuardDenied), re.Code)
+	assert.Equal(t, "tank/audit", re.Metadata["targets"])
+
+	o, err := g.IssueOverride(ctx, OpComplianceRemove, "tank/audit", "retention period changed")
+	require.NoError(t, err)
+	overridden := WithApproval(ctx, Approval{OverrideToken: o.Token})
+	assert.Error(t, g.RequireOverride(overridden,
+		Request{Operation: OpComplianceRemove, Targets: []string{"tank/other"}}))
+	assert.NoError(t, g.RequireOverride(overridden, remove))
+	assert.Error(t, g.RequireOverride(overridden, remove), "tokens are spent on first use")
+
+	var none *Guard
+	assert.Error(t, none.RequireOverride(overridden, remove))
+}
+
+func TestApproval(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware())
+	var got Approval
+	engine.DELETE("/x", func(c *gin.Context) {
+		got = ApprovalFromContext(c.Request.Context())
+	})
+
+	req := httptest.NewRequest(http.MethodDelete, "/x", nil)
+	req.Header.Set(ConfirmHeader, "true")
+	req.Header.Set(OverrideHeader, "abc")
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, Approval{Confirm: true, OverrideToken: "abc"}, got)
+
+	assert.Equal(t, Approval{Confirm: true},
+		ApprovalFromPayload([]byte(`{"name":"data","guard":{"confirm":true}}`)))
+	assert.Equal(t, Approval{}, ApprovalFromPayload([]byte(`{"name":"data"}`)))
+}
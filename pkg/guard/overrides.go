@@ -0,0 +1,176 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package guard
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Headers REST clients use to get past guard rules
+const (
+	ConfirmHeader  = "X-Rodent-Confirm"  // "true" confirms the operation
+	OverrideHeader = "X-Rodent-Override" // An override token
+)
+
+// Approval is what a caller offers to get past guard rules
+type Approval struct {
+	Confirm       bool   `json:"confirm,omitempty"`
+	OverrideToken string `json:"override_token,omitempty"`
+}
+
+type approvalKey struct{}
+
+// WithApproval returns a context carrying the approval
+func WithApproval(ctx context.Context, a Approval) context.Context {
+	return context.WithValue(ctx, approvalKey{}, a)
+}
+
+// ApprovalFromContext returns the approval carried by ctx, if any
+func ApprovalFromContext(ctx context.Context) Approval {
+	if ctx == nil {
+		return Approval{}
+	}
+	a, _ := ctx.Value(approvalKey{}).(Approval)
+	return a
+}
+
+// Middleware reads the confirm and override headers into the request context
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		confirm, _ := strconv.ParseBool(c.GetHeader(ConfirmHeader))
+		a := Approval{
+			Confirm:       confirm,
+			OverrideToken: c.GetHeader(OverrideHeader),
+		}
+		if a != (Approval{}) {
+			c.Request = c.Request.WithContext(WithApproval(c.Request.Context(), a))
+		}
+		c.Next()
+	}
+}
+
+// ApprovalFromPayload reads the approval of a Toggle command from the
+// "guard" field of its JSON payload. Payloads without one approve nothing.
+func ApprovalFromPayload(payload []byte) Approval {
+	var envelope struct {
+		Guard Approval `json:"guard"`
+	}
+	_ = json.Unmarshal(payload, &envelope)
+	return envelope.Guard
+}
+
+// Override lets one operation on one target past the guard rules
+type Override struct {
+	Token     string    `json:"token,omitempty"` // Only returned when issued
+	Operation Operation `json:"operation"`
+	Target    string    `json:"target"`
+	Reason    string    `json:"reason"`
+	IssuedBy  string    `json:"issued_by,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IssueOverride creates a one-time token letting the operation on target
+// past every rule that would block it. Tokens expire after the configured
+// TTL and are only kept in memory.
+func (g *Guard) IssueOverride(ctx context.Context, op Operation, target, reason string) (Override, error) {
+	if !slices.Contains(Operations, op) {
+		return Override{}, errors.New(errors.ServerRequestValidation, "unknown guarded operation").
+			WithMetadata("operation", string(op))
+	}
+	if target == "" || reason == "" {
+		return Override{}, errors.New(errors.ServerRequestValidation,
+			"an override needs a target and a reason")
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return Override{}, errors.Wrap(err, errors.ServerInternalError)
+	}
+	token := hex.EncodeToString(buf)
+
+	actor := common.ActorFromContext(ctx)
+	o := Override{
+		Operation: op,
+		Target:    target,
+		Reason:    reason,
+		IssuedBy:  actor.User,
+		ExpiresAt: time.Now().Add(g.overrideTTL),
+	}
+
+	g.mu.Lock()
+	g.pruneOverrides()
+	g.overrides[tokenHash(token)] = o
+	g.mu.Unlock()
+
+	g.logger.Warn("Guard override issued",
+		"operation", op,
+		"target", target,
+		"reason", reason,
+		"actor_source", actor.Source,
+		"actor_user", actor.User,
+		"actor_address", actor.Address,
+		"expires_at", o.ExpiresAt)
+
+	o.Token = token
+	return o, nil
+}
+
+// Overrides lists the override tokens not yet spent or expired, without
+// the tokens themselves
+func (g *Guard) Overrides() []Override {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pruneOverrides()
+	overrides := make([]Override, 0, len(g.overrides))
+	for _, o := range g.overrides {
+		overrides = append(overrides, o)
+	}
+	slices.SortFunc(overrides, func(a, b Override) int { return a.ExpiresAt.Compare(b.ExpiresAt) })
+	return overrides
+}
+
+// redeem spends an override token if it was issued for the request
+func (g *Guard) redeem(token string, req Request) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.pruneOverrides()
+	key := tokenHash(token)
+	o, ok := g.overrides[key]
+	if !ok || o.Operation != req.Operation || !slices.Contains(req.Targets, o.Target) {
+		return false
+	}
+	delete(g.overrides, key)
+	return true
+}
+
+// pruneOverrides drops expired tokens. The caller must hold mu.
+func (g *Guard) pruneOverrides() {
+	now := time.Now()
+	for key, o := range g.overrides {
+		if now.After(o.ExpiresAt) {
+			delete(g.overrides, key)
+		}
+	}
+}
+
+// tokenHash is the key an override token is kept under, so tokens are not
+// held in memory in the clear
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
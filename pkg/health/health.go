@@ -23,6 +23,7 @@ import (
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/daemon"
 	"github.com/stratastor/rodent/pkg/httpclient"
 )
 
@@ -37,12 +38,10 @@ func NewHealthChecker(cfg *config.Config) *HealthChecker {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create logger: %v", err))
 	}
-	baseURL := fmt.Sprintf("http://localhost:%d", cfg.Server.Port)
-	clientConfig := httpclient.NewClientConfig()
+	clientConfig := daemon.ClientConfig(cfg)
 	clientConfig.Timeout = 5 * time.Second
 	clientConfig.RetryCount = 3
 	clientConfig.RetryWaitTime = 2 * time.Second
-	clientConfig.BaseURL = baseURL
 	if cfg.Server.LogLevel == "debug" &&
 		(cfg.Environment == "dev" || cfg.Environment == "development") {
 		clientConfig.Debug = true
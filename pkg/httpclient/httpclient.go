@@ -20,6 +20,7 @@ package httpclient
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"net/http"
 	"time"
 
@@ -75,6 +76,7 @@ type ClientConfig struct {
 	IdleConnTimeout     time.Duration
 	DisableCompression  bool
 	DisableKeepAlives   bool
+	UnixSocket          string // Dial this socket instead of the BaseURL host
 
 	// Authentication
 	BasicAuth struct {
@@ -199,6 +201,14 @@ func (c *Client) applyConfig() {
 		DisableKeepAlives:   c.config.DisableKeepAlives,
 	}
 
+	if c.config.UnixSocket != "" {
+		socket := c.config.UnixSocket
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+	}
+
 	// Configure TLS
 	if c.config.TLSConfig != nil {
 		transport.TLSClientConfig = c.config.TLSConfig
@@ -302,6 +302,10 @@ func (c *Collector) collectDiskSummary(
 			summary.FailedCount++
 		}
 
+		if disk.Risk != nil && (disk.Risk.Level == diskTypes.RiskHigh || disk.Risk.Level == diskTypes.RiskCritical) {
+			summary.AtRiskCount++
+		}
+
 		// Count available disks
 		if disk.IsAvailable() {
 			summary.AvailableCount++
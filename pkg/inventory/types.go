@@ -111,6 +111,7 @@ type DiskSummary struct {
 	HealthyCount   int                                `json:"healthy_count"`
 	WarningCount   int                                `json:"warning_count"`
 	FailedCount    int                                `json:"failed_count"`
+	AtRiskCount    int                                `json:"at_risk_count"` // High or critical failure risk
 	TotalCapacity  uint64                             `json:"total_capacity_bytes"`
 	UsedCapacity   uint64                             `json:"used_capacity_bytes"`
 	Devices        map[string]*diskTypes.PhysicalDisk `json:"devices,omitempty"` // Included in basic/full detail level
@@ -0,0 +1,118 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler handles HTTP requests for inspecting the job queue
+type APIHandler struct {
+	queue *Queue
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewAPIHandler creates a new job queue API handler
+func NewAPIHandler(queue *Queue) *APIHandler {
+	return &APIHandler{
+		queue: queue,
+	}
+}
+
+// RegisterRoutes registers HTTP routes for the job queue
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.listJobs)
+	router.GET("/:id", h.getJob)
+	router.POST("/:id/cancel", h.cancelJob)
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *APIHandler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *APIHandler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  string(errors.DomainJobs),
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// listJobs lists jobs, optionally filtered by the type and status query
+// parameters
+func (h *APIHandler) listJobs(c *gin.Context) {
+	jobs := h.queue.List(ListFilter{
+		Type:   c.Query("type"),
+		Status: Status(c.Query("status")),
+	})
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"jobs":  jobs,
+		"count": len(jobs),
+	})
+}
+
+// getJob returns a single job
+func (h *APIHandler) getJob(c *gin.Context) {
+	job, err := h.queue.Get(c.Param("id"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, job)
+}
+
+// cancelJob cancels a job that has not finished
+func (h *APIHandler) cancelJob(c *gin.Context) {
+	job, err := h.queue.Cancel(c.Param("id"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, job)
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// OperationTypePrefix starts the type of every job that runs a mutating API
+// request in the background. Such jobs are exposed as operations.
+const OperationTypePrefix = "operation."
+
+// OperationRetryPolicy runs an operation once. A failed operation is
+// reported to the client rather than retried, as the request may have been
+// rejected on its merits.
+var OperationRetryPolicy = RetryPolicy{
+	MaxAttempts: 1,
+	Timeout:     10 * time.Minute,
+}
+
+// Operation is the client's view of a request accepted with 202 Accepted
+type Operation struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Status     Status          `json:"status"`
+	Done       bool            `json:"done"`
+	Error      string          `json:"error,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// IsOperation reports whether a job runs an API request
+func IsOperation(job Job) bool {
+	return strings.HasPrefix(job.Type, OperationTypePrefix)
+}
+
+// NewOperation returns the operation view of a job
+func NewOperation(job Job) Operation {
+	return Operation{
+		ID:         job.ID,
+		Type:       strings.TrimPrefix(job.Type, OperationTypePrefix),
+		Status:     job.Status,
+		Done:       job.Status.Finished(),
+		Error:      job.LastError,
+		Result:     job.Result,
+		CreatedAt:  job.CreatedAt,
+		UpdatedAt:  job.UpdatedAt,
+		FinishedAt: job.FinishedAt,
+	}
+}
+
+// OperationPath is the API path clients poll for an operation
+func OperationPath(id string) string {
+	return constants.APIOperations + "/" + id
+}
+
+// WantsAsync reports whether the client asked for a mutating request to run
+// in the background, with ?async=true or a "Prefer: respond-async" header
+func WantsAsync(c *gin.Context) bool {
+	if c.Query("async") == "true" {
+		return true
+	}
+	for _, pref := range strings.Split(c.GetHeader("Prefer"), ",") {
+		if strings.EqualFold(strings.TrimSpace(pref), "respond-async") {
+			return true
+		}
+	}
+	return false
+}
+
+// Accepted enqueues an operation and answers the request with 202 Accepted,
+// pointing the client at the operation. The operation type is given without
+// OperationTypePrefix.
+func Accepted(c *gin.Context, q *Queue, opType string, payload any) error {
+	id, err := q.Enqueue(OperationTypePrefix+opType, payload)
+	if err != nil {
+		return err
+	}
+
+	c.Header("Location", OperationPath(id))
+	c.JSON(http.StatusAccepted, gin.H{
+		"operation_id": id,
+		"status":       StatusPending,
+		"location":     OperationPath(id),
+	})
+	return nil
+}
+
+// RegisterOperationRoutes registers the routes clients poll for operations
+func (h *APIHandler) RegisterOperationRoutes(router *gin.RouterGroup) {
+	router.GET("", h.listOperations)
+	router.GET("/:id", h.getOperation)
+	router.POST("/:id/cancel", h.cancelOperation)
+}
+
+// listOperations lists operations, optionally filtered by the status query
+// parameter
+func (h *APIHandler) listOperations(c *gin.Context) {
+	ops := []Operation{}
+	for _, job := range h.queue.List(ListFilter{Status: Status(c.Query("status"))}) {
+		if IsOperation(job) {
+			ops = append(ops, NewOperation(job))
+		}
+	}
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"operations": ops,
+		"count":      len(ops),
+	})
+}
+
+// getOperation returns a single operation
+func (h *APIHandler) getOperation(c *gin.Context) {
+	job, err := h.operationJob(c.Param("id"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, NewOperation(*job))
+}
+
+// cancelOperation cancels an operation that has not finished
+func (h *APIHandler) cancelOperation(c *gin.Context) {
+	if _, err := h.operationJob(c.Param("id")); err != nil {
+		h.sendError(c, err)
+		return
+	}
+	job, err := h.queue.Cancel(c.Param("id"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, NewOperation(*job))
+}
+
+// operationJob returns the job of an operation. Jobs that are not operations
+// are reported as not found.
+func (h *APIHandler) operationJob(id string) (*Job, error) {
+	job, err := h.queue.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if !IsOperation(*job) {
+		return nil, errors.New(errors.JobNotFound, "Operation not found").WithMetadata("id", id)
+	}
+	return job, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationLifecycle(t *testing.T) {
+	q := NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 1)
+	q.Start()
+	defer q.Stop()
+
+	done := make(chan Job, 4)
+	q.OnFinished(func(job Job) { done <- job })
+
+	q.Register(OperationTypePrefix+"test.create", func(ctx context.Context, payload json.RawMessage) error {
+		var p struct{ Name string }
+		require.NoError(t, json.Unmarshal(payload, &p))
+		return SetResult(ctx, map[string]string{"name": p.Name})
+	}, OperationRetryPolicy)
+	q.Register(OperationTypePrefix+"test.fail", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("share exists")
+	}, OperationRetryPolicy)
+	q.Register("internal", func(ctx context.Context, payload json.RawMessage) error {
+		return nil
+	}, fastRetries)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/create", func(c *gin.Context) {
+		require.True(t, WantsAsync(c))
+		require.NoError(t, Accepted(c, q, "test.create", map[string]string{"name": "media"}))
+	})
+	NewAPIHandler(q).RegisterOperationRoutes(router.Group("/operations"))
+
+	req := httptest.NewRequest(http.MethodPost, "/create", nil)
+	req.Header.Set("Prefer", "wait=5, respond-async")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var accepted struct {
+		OperationID string `json:"operation_id"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &accepted))
+	assert.Equal(t, OperationPath(accepted.OperationID), w.Header().Get("Location"))
+
+	select {
+	case job := <-done:
+		assert.Equal(t, accepted.OperationID, job.ID)
+		assert.Equal(t, StatusSucceeded, job.Status)
+	case <-time.After(5 * time.Second):
+		t.Fatal("operation did not finish")
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/operations/"+accepted.OperationID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Result Operation `json:"result"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "test.create", resp.Result.Type)
+	assert.True(t, resp.Result.Done)
+	assert.JSONEq(t, `{"name":"media"}`, string(resp.Result.Result))
+
+	failed, err := q.Enqueue(OperationTypePrefix+"test.fail", nil)
+	require.NoError(t, err)
+	job := waitForStatus(t, q, failed, StatusFailed)
+	assert.Equal(t, 1, job.Attempts, "operations are not retried")
+	assert.Equal(t, "share exists", NewOperation(*job).Error)
+
+	// Jobs that are not operations are not exposed as operations
+	internal, err := q.Enqueue("internal", nil)
+	require.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/operations/"+internal, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestWantsAsync(t *testing.T) {
+	for target, want := range map[string]bool{
+		"/x?async=true":  true,
+		"/x?async=false": false,
+		"/x":             false,
+	} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, target, nil)
+		assert.Equal(t, want, WantsAsync(c), target)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/x", nil)
+	c.Request.Header.Set("Prefer", "return=minimal")
+	assert.False(t, WantsAsync(c))
+}
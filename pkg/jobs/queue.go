@@ -0,0 +1,563 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// DefaultWorkers is the number of jobs run concurrently
+	DefaultWorkers = 4
+
+	// maxFinishedJobs and finishedJobTTL bound the finished jobs kept for
+	// inspection
+	maxFinishedJobs = 500
+	finishedJobTTL  = 7 * 24 * time.Hour
+)
+
+// registration is a job type's handler and retry policy
+type registration struct {
+	handler Handler
+	policy  RetryPolicy
+}
+
+// Queue runs jobs on a bounded pool of workers and persists them to a state
+// file after every change
+type Queue struct {
+	logger    logger.Logger
+	statePath string
+	workers   int
+
+	mu       sync.Mutex
+	handlers map[string]registration
+	jobs     map[string]*Job
+	timers   map[string]*time.Timer
+	cancels  map[string]context.CancelCauseFunc // Cancel the attempts being run, by job ID
+	finished []func(Job)                        // Called with a copy of every job that finishes
+	ready    chan string
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	started  bool
+}
+
+// Singleton instance
+var (
+	globalQueue *Queue
+	initMutex   sync.Mutex
+)
+
+// GetQueue returns the singleton job queue, persisted under the Rodent
+// config directory
+func GetQueue(logCfg logger.Config) (*Queue, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalQueue != nil {
+		return globalQueue, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "jobs")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	jobsDir := filepath.Join(config.GetConfigDir(), "jobs")
+	if err := os.MkdirAll(jobsDir, 0755); err != nil {
+		return nil, errors.New(
+			errors.ConfigWriteError,
+			fmt.Sprintf("failed to create jobs directory: %v", err),
+		)
+	}
+
+	globalQueue = NewQueue(l, filepath.Join(jobsDir, "jobs.rodent.json"), DefaultWorkers)
+	return globalQueue, nil
+}
+
+// NewQueue creates a queue that persists its jobs to statePath and runs up to
+// workers jobs at a time. Jobs left in the state file by a previous run are
+// loaded and resumed once the queue is started.
+func NewQueue(l logger.Logger, statePath string, workers int) *Queue {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	q := &Queue{
+		logger:    l,
+		statePath: statePath,
+		workers:   workers,
+		handlers:  make(map[string]registration),
+		jobs:      make(map[string]*Job),
+		timers:    make(map[string]*time.Timer),
+		cancels:   make(map[string]context.CancelCauseFunc),
+		ready:     make(chan string, 256),
+	}
+
+	if err := q.load(); err != nil {
+		l.Warn("Failed to load persisted jobs, starting with an empty queue", "error", err)
+	}
+	return q
+}
+
+// Register sets the handler and retry policy for a job type. Zero fields of
+// the policy take their value from DefaultRetryPolicy. Persisted jobs of the
+// type are resumed if the queue is already running.
+func (q *Queue) Register(jobType string, handler Handler, policy RetryPolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobType] = registration{handler: handler, policy: policy.withDefaults()}
+
+	if q.started {
+		for _, job := range q.jobs {
+			if job.Type == jobType && !job.Status.Finished() {
+				q.schedule(job)
+			}
+		}
+	}
+}
+
+// Enqueue adds a job of a registered type and returns its ID. The payload is
+// stored as JSON and handed to the handler on every attempt.
+func (q *Queue) Enqueue(jobType string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", errors.Wrap(err, errors.JobInvalidPayload).WithMetadata("type", jobType)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	reg, ok := q.handlers[jobType]
+	if !ok {
+		return "", errors.New(errors.JobTypeUnknown, "No handler registered for job type").
+			WithMetadata("type", jobType)
+	}
+
+	now := time.Now()
+	job := &Job{
+		ID:          common.UUID7(),
+		Type:        jobType,
+		Payload:     data,
+		Status:      StatusPending,
+		MaxAttempts: reg.policy.MaxAttempts,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	q.jobs[job.ID] = job
+	q.save()
+
+	if q.started {
+		q.schedule(job)
+	}
+
+	q.logger.Debug("Job enqueued", "id", job.ID, "type", jobType)
+	return job.ID, nil
+}
+
+// Start starts the workers and resumes persisted jobs. A job that was running
+// when Rodent stopped is run again without counting the interrupted attempt.
+func (q *Queue) Start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.started {
+		return
+	}
+
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	q.started = true
+
+	for range q.workers {
+		q.wg.Add(1)
+		crash.Go("jobs", q.work)
+	}
+
+	resumed := 0
+	for _, job := range q.jobs {
+		if job.Status == StatusRunning {
+			job.Status = StatusPending
+			job.Attempts = max(job.Attempts-1, 0)
+		}
+		if !job.Status.Finished() {
+			if _, ok := q.handlers[job.Type]; ok {
+				q.schedule(job)
+				resumed++
+			}
+		}
+	}
+
+	q.logger.Info("Job queue started", "workers", q.workers, "resumed_jobs", resumed)
+}
+
+// Stop stops the workers, cancelling running jobs, and waits for them to
+// return. Unfinished jobs are resumed by the next Start.
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	if !q.started {
+		q.mu.Unlock()
+		return
+	}
+	q.started = false
+	q.cancel()
+	for id, timer := range q.timers {
+		timer.Stop()
+		delete(q.timers, id)
+	}
+	q.mu.Unlock()
+
+	q.wg.Wait()
+
+	// Jobs already handed to the workers are scheduled again by Start
+	for len(q.ready) > 0 {
+		<-q.ready
+	}
+
+	q.mu.Lock()
+	q.save()
+	q.mu.Unlock()
+}
+
+// OnFinished registers fn to be called with a copy of every job that
+// succeeds, fails or is cancelled. It is called on its own goroutine, so it
+// may use the queue.
+func (q *Queue) OnFinished(fn func(Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.finished = append(q.finished, fn)
+}
+
+// notifyFinished hands a copy of a finished job to the OnFinished callbacks.
+// Caller must hold q.mu.
+func (q *Queue) notifyFinished(job *Job) {
+	jobCopy := *job
+	for _, fn := range q.finished {
+		crash.Go("jobs", func() { fn(jobCopy) })
+	}
+}
+
+// Get returns a copy of the job with the given ID
+func (q *Queue) Get(id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, errors.New(errors.JobNotFound, "Job not found").WithMetadata("id", id)
+	}
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// List returns copies of the jobs selected by filter, newest first
+func (q *Queue) List(filter ListFilter) []Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	jobs := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		if filter.matches(job) {
+			jobs = append(jobs, *job)
+		}
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// Running returns copies of the jobs being run by a worker
+func (q *Queue) Running() []Job {
+	return q.List(ListFilter{Status: StatusRunning})
+}
+
+// Interrupt marks running jobs as pending and saves the state file, so the
+// next start runs them again with the interrupted attempt not counted. It is
+// run before Rodent exits after a fatal panic; the workers are not stopped.
+func (q *Queue) Interrupt(reason string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	for _, job := range q.jobs {
+		if job.Status != StatusRunning {
+			continue
+		}
+		job.Status = StatusPending
+		job.Attempts = max(job.Attempts-1, 0)
+		job.LastError = reason
+		job.UpdatedAt = now
+		q.logger.Warn("Job interrupted, resumed on next start", "id", job.ID, "type", job.Type)
+	}
+	q.save()
+}
+
+// Cancel stops a job. A pending or retrying job is not run again. A running
+// job's context is cancelled, and it is marked cancelled once its handler
+// returns.
+func (q *Queue) Cancel(id string) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return nil, errors.New(errors.JobNotFound, "Job not found").WithMetadata("id", id)
+	}
+	if job.Status.Finished() {
+		return nil, errors.New(errors.JobNotCancellable, "Job has already finished").
+			WithMetadata("id", id).
+			WithMetadata("status", string(job.Status))
+	}
+
+	if cancelRun, running := q.cancels[id]; running {
+		cancelRun(errJobCancelled)
+		q.logger.Info("Cancelling running job", "id", id, "type", job.Type)
+	} else {
+		if timer, ok := q.timers[id]; ok {
+			timer.Stop()
+			delete(q.timers, id)
+		}
+		now := time.Now()
+		job.Status = StatusCancelled
+		job.LastError = errJobCancelled.Error()
+		job.NextRunAt = nil
+		job.FinishedAt = &now
+		job.UpdatedAt = now
+		q.save()
+		q.notifyFinished(job)
+		q.logger.Info("Job cancelled", "id", id, "type", job.Type)
+	}
+
+	jobCopy := *job
+	return &jobCopy, nil
+}
+
+// schedule hands the job to a worker at its NextRunAt, or now if unset.
+// Caller must hold q.mu.
+func (q *Queue) schedule(job *Job) {
+	if timer, ok := q.timers[job.ID]; ok {
+		timer.Stop()
+	}
+
+	var delay time.Duration
+	if job.NextRunAt != nil {
+		delay = time.Until(*job.NextRunAt)
+	}
+
+	id, ctx := job.ID, q.ctx
+	q.timers[id] = time.AfterFunc(delay, func() {
+		select {
+		case q.ready <- id:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// work runs ready jobs until the queue is stopped
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case id := <-q.ready:
+			q.run(id)
+		}
+	}
+}
+
+// run makes one attempt at a job and records the outcome
+func (q *Queue) run(id string) {
+	q.mu.Lock()
+	delete(q.timers, id)
+	job, ok := q.jobs[id]
+	if !ok || job.Status.Finished() || job.Status == StatusRunning {
+		q.mu.Unlock()
+		return
+	}
+	reg, ok := q.handlers[job.Type]
+	if !ok {
+		q.mu.Unlock()
+		return
+	}
+
+	job.Status = StatusRunning
+	job.Attempts++
+	job.NextRunAt = nil
+	job.UpdatedAt = time.Now()
+	payload, jobType := job.Payload, job.Type
+	runCtx, cancelRun := context.WithCancelCause(q.ctx)
+	q.cancels[id] = cancelRun
+	q.save()
+	q.mu.Unlock()
+
+	result := &resultSlot{}
+	ctx, cancel := context.WithTimeout(context.WithValue(runCtx, resultKey{}, result), reg.policy.Timeout)
+	err := callHandler(ctx, reg.handler, payload, id, jobType)
+	cancel()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.cancels, id)
+	cancelled := stderrors.Is(context.Cause(runCtx), errJobCancelled)
+	cancelRun(nil)
+
+	now := time.Now()
+	job.UpdatedAt = now
+
+	switch {
+	case cancelled:
+		job.Status = StatusCancelled
+		job.LastError = errJobCancelled.Error()
+		job.FinishedAt = &now
+		q.logger.Info("Job cancelled", "id", job.ID, "type", job.Type, "attempts", job.Attempts)
+	case q.ctx.Err() != nil:
+		// Interrupted by Stop; the attempt does not count
+		job.Status = StatusPending
+		job.Attempts--
+	case err == nil:
+		job.Status = StatusSucceeded
+		job.LastError = ""
+		job.Result = result.get()
+		job.FinishedAt = &now
+		q.logger.Debug("Job succeeded", "id", job.ID, "type", job.Type, "attempts", job.Attempts)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		job.Status = StatusFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		q.logger.Warn("Job failed",
+			"id", job.ID,
+			"type", job.Type,
+			"attempts", job.Attempts,
+			"error", err)
+	default:
+		next := now.Add(reg.policy.backoff(job.Attempts))
+		job.Status = StatusRetrying
+		job.LastError = err.Error()
+		job.NextRunAt = &next
+		q.schedule(job)
+		q.logger.Info("Job attempt failed, retrying",
+			"id", job.ID,
+			"type", job.Type,
+			"attempt", job.Attempts,
+			"next_run_at", next,
+			"error", err)
+	}
+
+	if job.Status.Finished() {
+		q.notifyFinished(job)
+	}
+	q.prune(now)
+	q.save()
+}
+
+// callHandler runs a handler, turning a panic into a crash report and a
+// permanent failure
+func callHandler(
+	ctx context.Context,
+	handler Handler,
+	payload json.RawMessage,
+	id, jobType string,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			report := crash.Handle(crash.SourceJob, "jobs/"+jobType, id, r, debug.Stack())
+			err = Permanent(fmt.Errorf("job handler panicked: %v (crash report %s)", r, report.ID))
+		}
+	}()
+	return handler(ctx, payload)
+}
+
+// prune drops finished jobs older than finishedJobTTL and the oldest
+// finished jobs beyond maxFinishedJobs. Caller must hold q.mu.
+func (q *Queue) prune(now time.Time) {
+	var finished []*Job
+	for id, job := range q.jobs {
+		if !job.Status.Finished() {
+			continue
+		}
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > finishedJobTTL {
+			delete(q.jobs, id)
+			continue
+		}
+		finished = append(finished, job)
+	}
+
+	if len(finished) <= maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].UpdatedAt.Before(finished[j].UpdatedAt)
+	})
+	for _, job := range finished[:len(finished)-maxFinishedJobs] {
+		delete(q.jobs, job.ID)
+	}
+}
+
+// load reads persisted jobs from the state file
+func (q *Queue) load() error {
+	data, err := os.ReadFile(q.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, errors.JobStateLoadFailed).WithMetadata("path", q.statePath)
+	}
+
+	var jobs []*Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return errors.Wrap(err, errors.JobStateLoadFailed).WithMetadata("path", q.statePath)
+	}
+	for _, job := range jobs {
+		q.jobs[job.ID] = job
+	}
+	return nil
+}
+
+// save writes all jobs to the state file. Failures are logged; the queue
+// keeps running from memory. Caller must hold q.mu.
+func (q *Queue) save() {
+	jobs := make([]*Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.Before(jobs[j].CreatedAt)
+	})
+
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		q.logger.Warn("Failed to encode jobs", "error", err)
+		return
+	}
+
+	tmpPath := q.statePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		q.logger.Warn("Failed to persist jobs", "path", q.statePath, "error", err)
+		return
+	}
+	if err := os.Rename(tmpPath, q.statePath); err != nil {
+		q.logger.Warn("Failed to persist jobs", "path", q.statePath, "error", err)
+	}
+}
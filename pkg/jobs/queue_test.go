@@ -0,0 +1,206 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetries = RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: 10 * time.Millisecond,
+	MaxBackoff:     20 * time.Millisecond,
+	Timeout:        time.Second,
+}
+
+// waitForStatus waits until the job reaches a finished state
+func waitForStatus(t *testing.T, q *Queue, id string, want Status) *Job {
+	t.Helper()
+
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = q.Get(id)
+		require.NoError(t, err)
+		return job.Status == want
+	}, 5*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestQueueRetriesUntilSuccess(t *testing.T) {
+	q := NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 2)
+	q.Start()
+	defer q.Stop()
+
+	var calls atomic.Int32
+	q.Register("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		var p struct{ Name string }
+		require.NoError(t, json.Unmarshal(payload, &p))
+		assert.Equal(t, "tank", p.Name)
+
+		if calls.Add(1) < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}, fastRetries)
+
+	id, err := q.Enqueue("flaky", map[string]string{"name": "tank"})
+	require.NoError(t, err)
+
+	job := waitForStatus(t, q, id, StatusSucceeded)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Empty(t, job.LastError)
+	assert.NotNil(t, job.FinishedAt)
+}
+
+func TestQueueGivesUp(t *testing.T) {
+	q := NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 1)
+	q.Start()
+	defer q.Stop()
+
+	q.Register("broken", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("always fails")
+	}, fastRetries)
+	q.Register("permanent", func(ctx context.Context, payload json.RawMessage) error {
+		return Permanent(errors.New("bad input"))
+	}, fastRetries)
+	q.Register("denied", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("cannot open 'tank/data': permission denied")
+	}, fastRetries)
+
+	id, err := q.Enqueue("broken", nil)
+	require.NoError(t, err)
+	job := waitForStatus(t, q, id, StatusFailed)
+	assert.Equal(t, 3, job.Attempts)
+	assert.Equal(t, "always fails", job.LastError)
+
+	id, err = q.Enqueue("permanent", nil)
+	require.NoError(t, err)
+	job = waitForStatus(t, q, id, StatusFailed)
+	assert.Equal(t, 1, job.Attempts)
+
+	// Failures retrying cannot fix are not retried either
+	id, err = q.Enqueue("denied", nil)
+	require.NoError(t, err)
+	job = waitForStatus(t, q, id, StatusFailed)
+	assert.Equal(t, 1, job.Attempts)
+
+	assert.Len(t, q.List(ListFilter{Status: StatusFailed}), 3)
+	assert.Len(t, q.List(ListFilter{Type: "permanent"}), 1)
+}
+
+func TestQueueUnknownType(t *testing.T) {
+	q := NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 1)
+
+	_, err := q.Enqueue("missing", nil)
+	assert.Error(t, err)
+
+	_, err = q.Get("missing")
+	assert.Error(t, err)
+}
+
+func TestQueueResumesPersistedJobs(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "jobs.json")
+	handler := func(ctx context.Context, payload json.RawMessage) error { return nil }
+
+	// Enqueued while stopped, so the job is only persisted
+	q := NewQueue(common.Log, statePath, 1)
+	q.Register("resume", handler, fastRetries)
+	id, err := q.Enqueue("resume", nil)
+	require.NoError(t, err)
+
+	q = NewQueue(common.Log, statePath, 1)
+	job, err := q.Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, job.Status)
+
+	q.Start()
+	defer q.Stop()
+	q.Register("resume", handler, fastRetries)
+
+	waitForStatus(t, q, id, StatusSucceeded)
+}
+
+func TestQueueInterrupt(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "jobs.json")
+	q := NewQueue(common.Log, statePath, 1)
+	q.Start()
+	defer q.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	q.Register("slow", func(ctx context.Context, payload json.RawMessage) error {
+		<-release
+		return nil
+	}, fastRetries)
+
+	id, err := q.Enqueue("slow", nil)
+	require.NoError(t, err)
+	waitForStatus(t, q, id, StatusRunning)
+	assert.Len(t, q.Running(), 1)
+
+	// What the next start finds after a crash
+	q.Interrupt("interrupted by a crash")
+	job, err := NewQueue(common.Log, statePath, 1).Get(id)
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, job.Status)
+	assert.Equal(t, 0, job.Attempts)
+	assert.Equal(t, "interrupted by a crash", job.LastError)
+}
+
+func TestQueueCancel(t *testing.T) {
+	q := NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 1)
+	q.Start()
+	defer q.Stop()
+
+	started := make(chan struct{})
+	q.Register("slow", func(ctx context.Context, payload json.RawMessage) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, fastRetries)
+
+	running, err := q.Enqueue("slow", nil)
+	require.NoError(t, err)
+	<-started
+
+	// The only worker is busy, so this job waits
+	pending, err := q.Enqueue("slow", nil)
+	require.NoError(t, err)
+
+	job, err := q.Cancel(pending)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, job.Status)
+
+	_, err = q.Cancel(running)
+	require.NoError(t, err)
+	job = waitForStatus(t, q, running, StatusCancelled)
+	assert.Equal(t, 1, job.Attempts)
+	assert.NotNil(t, job.FinishedAt)
+
+	_, err = q.Cancel(running)
+	assert.Error(t, err, "finished jobs cannot be cancelled")
+	_, err = q.Cancel("missing")
+	assert.Error(t, err)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	assert.Equal(t, time.Second, p.backoff(1))
+	assert.Equal(t, 2*time.Second, p.backoff(2))
+	assert.Equal(t, 4*time.Second, p.backoff(3))
+	assert.Equal(t, 5*time.Second, p.backoff(4))
+}
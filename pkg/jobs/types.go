@@ -0,0 +1,173 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package jobs runs one-off background operations, such as applying a
+// retention policy after a transfer, on a small pool of workers. Jobs are
+// retried with exponential backoff, persisted so they survive a restart, and
+// can be inspected through the API.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Status is the state of a job
+type Status string
+
+const (
+	StatusPending   Status = "pending"   // Waiting for a worker
+	StatusRunning   Status = "running"   // Being run by a worker
+	StatusRetrying  Status = "retrying"  // Failed, waiting for the next attempt
+	StatusSucceeded Status = "succeeded" // Finished successfully
+	StatusFailed    Status = "failed"    // Gave up after the last attempt
+	StatusCancelled Status = "cancelled" // Cancelled through the API
+)
+
+// Finished reports whether a job in this state will not run again
+func (s Status) Finished() bool {
+	return s == StatusSucceeded || s == StatusFailed || s == StatusCancelled
+}
+
+// RetryPolicy controls how often and how long a job type is attempted
+type RetryPolicy struct {
+	MaxAttempts    int           `json:"max_attempts"`
+	InitialBackoff time.Duration `json:"initial_backoff"`
+	MaxBackoff     time.Duration `json:"max_backoff"`
+	Timeout        time.Duration `json:"timeout"` // Per attempt
+}
+
+// DefaultRetryPolicy is used for zero fields of a registered policy
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 30 * time.Second,
+	MaxBackoff:     30 * time.Minute,
+	Timeout:        10 * time.Minute,
+}
+
+// withDefaults fills zero fields from DefaultRetryPolicy
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultRetryPolicy.InitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultRetryPolicy.MaxBackoff
+	}
+	if p.Timeout <= 0 {
+		p.Timeout = DefaultRetryPolicy.Timeout
+	}
+	return p
+}
+
+// backoff returns the delay before the attempt following the given one. It
+// doubles with every attempt up to MaxBackoff.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxBackoff)
+}
+
+// Handler runs one attempt of a job. The payload is the JSON encoding of the
+// value passed to Enqueue. Returning an error schedules a retry unless it is
+// wrapped with Permanent or the job is out of attempts. The context is
+// cancelled when the attempt times out, the job is cancelled or the queue
+// stops, and handlers pass it on to the commands they run.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// errJobCancelled is the cancellation cause of an attempt stopped by Cancel
+var errJobCancelled = stderrors.New("job cancelled")
+
+// permanentError marks an error that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the job fails without further attempts
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was wrapped with Permanent, or is of a
+// category that retrying does not fix, such as permission denied
+func isPermanent(err error) bool {
+	var perm *permanentError
+	return stderrors.As(err, &perm) || errors.CategoryOf(err).Permanent()
+}
+
+// resultKey is the context key of the attempt's resultSlot
+type resultKey struct{}
+
+// resultSlot holds the result recorded by a handler during an attempt
+type resultSlot struct {
+	mu   sync.Mutex
+	data json.RawMessage
+}
+
+func (r *resultSlot) get() json.RawMessage {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.data
+}
+
+// SetResult records v, encoded as JSON, as the result of the job being run
+// with ctx. It is kept on the job if the attempt succeeds. Outside a job
+// handler it does nothing.
+func SetResult(ctx context.Context, v any) error {
+	slot, ok := ctx.Value(resultKey{}).(*resultSlot)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	slot.mu.Lock()
+	slot.data = data
+	slot.mu.Unlock()
+	return nil
+}
+
+// Job is a queued operation and the outcome of its attempts
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      Status          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"` // Set by the handler through SetResult
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	NextRunAt   *time.Time      `json:"next_run_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+}
+
+// ListFilter selects jobs by type and status. Empty fields match any job.
+type ListFilter struct {
+	Type   string
+	Status Status
+}
+
+// matches reports whether job is selected by the filter
+func (f ListFilter) matches(job *Job) bool {
+	return (f.Type == "" || job.Type == f.Type) &&
+		(f.Status == "" || job.Status == f.Status)
+}
@@ -61,6 +61,10 @@ func (h *SSHKeyHandler) RegisterRoutes(router *gin.RouterGroup) {
 	keyGroup.GET("/hostkey", h.getHostKey)
 	keyGroup.POST("/knownhost", h.addKnownHost)
 	keyGroup.DELETE("/knownhost/:peering_id", h.removeKnownHost)
+
+	// Security policy and the posture of each peering
+	keyGroup.GET("/security", h.getSecurityPosture)
+	keyGroup.GET("/security/:peering_id", h.getPeerPosture)
 }
 
 // generateKeyPair handles requests to generate a new SSH key pair
@@ -258,8 +262,13 @@ func (h *SSHKeyHandler) addKnownHost(c *gin.Context) {
 		return
 	}
 
-	// Add the remote host's key to known_hosts
-	if err := h.manager.AddRemoteHostKey(
+	// Add the remote host's key to known_hosts, or pin it in place of the
+	// keys known before
+	addKey := h.manager.AddRemoteHostKey
+	if req.Replace {
+		addKey = h.manager.PinHostKey
+	}
+	if err := addKey(
 		c.Request.Context(),
 		req.Hostname,
 		req.HostKey,
@@ -306,3 +315,29 @@ func (h *SSHKeyHandler) removeKnownHost(c *gin.Context) {
 		"peering_id": peeringID,
 	})
 }
+
+// getSecurityPosture handles requests for the SSH security policy and the
+// posture of every peering
+func (h *SSHKeyHandler) getSecurityPosture(c *gin.Context) {
+	posture, err := h.manager.GetSecurityPosture(c.Request.Context())
+	if err != nil {
+		h.logger.Error("Failed to get security posture", "error", err)
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, posture)
+}
+
+// getPeerPosture handles requests for the security posture of a peering
+func (h *SSHKeyHandler) getPeerPosture(c *gin.Context) {
+	peeringID := c.Param("peering_id")
+	posture, err := h.manager.GetPeerPosture(c.Request.Context(), peeringID)
+	if err != nil {
+		h.logger.Error("Failed to get peer security posture", "error", err, "peering_id", peeringID)
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, posture)
+}
@@ -271,8 +271,13 @@ func handleAddKnownHost(handler *SSHKeyHandler) client.CommandHandler {
 			return nil, errors.New(errors.SSHKeyPairInvalidPublicKey, "Host key is required")
 		}
 
-		// Add the remote host's key to known_hosts
-		if err := handler.manager.AddRemoteHostKey(
+		// Add the remote host's key to known_hosts, or pin it in place of the
+		// keys known before
+		addKey := handler.manager.AddRemoteHostKey
+		if payload.Replace {
+			addKey = handler.manager.PinHostKey
+		}
+		if err := addKey(
 			context.Background(),
 			payload.Hostname,
 			payload.HostKey,
@@ -14,6 +14,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/stratastor/logger"
@@ -144,6 +145,11 @@ func (m *SSHKeyManager) GenerateKeyPair(
 		keyType = m.algorithm
 	}
 
+	policy := CurrentSecurityPolicy()
+	if err := policy.checkKeyType(keyType); err != nil {
+		return nil, err
+	}
+
 	// Generate key pair
 	var privateBytes, publicBytes []byte
 	var err error
@@ -152,7 +158,7 @@ func (m *SSHKeyManager) GenerateKeyPair(
 	case KeyPairTypeED25519:
 		privateBytes, publicBytes, err = generateED25519KeyPair()
 	case KeyPairTypeRSA:
-		privateBytes, publicBytes, err = generateRSAKeyPair()
+		privateBytes, publicBytes, err = generateRSAKeyPair(policy.rsaBits())
 	default:
 		return nil, errors.New(errors.SSHKeyPairInvalidType,
 			fmt.Sprintf("Unsupported key algorithm: %s", keyType))
@@ -303,8 +309,7 @@ func (m *SSHKeyManager) RemoveKnownHost(
 	// Write back the file
 	var newContent strings.Builder
 	for _, entry := range newEntries {
-		newContent.WriteString(fmt.Sprintf("%s %s %s\n",
-			entry.Hostname, entry.PublicKey, entry.PeeringID))
+		newContent.WriteString(entry.line())
 	}
 
 	if err := os.WriteFile(m.knownHosts, []byte(newContent.String()), m.permissions); err != nil {
@@ -480,6 +485,54 @@ func (m *SSHKeyManager) AddRemoteHostKey(
 	return m.AddKnownHost(ctx, hostname, hostKey, peeringID)
 }
 
+// PinHostKey makes hostKey the only trusted key of a host for a peering,
+// replacing the keys pinned before, such as after the remote host key was
+// rotated, and keys learned on first use. Keys of other peerings for the
+// same host are kept.
+func (m *SSHKeyManager) PinHostKey(
+	ctx context.Context,
+	hostname string,
+	hostKey string,
+	peeringID string,
+) error {
+	if err := validateHostname(hostname); err != nil {
+		return err
+	}
+	if err := validatePeeringID(peeringID); err != nil {
+		return err
+	}
+	if !isValidSSHPublicKey(hostKey) {
+		return errors.New(errors.SSHKeyPairInvalidPublicKey, "Invalid host key format")
+	}
+
+	knownHosts, err := os.ReadFile(m.knownHosts)
+	if err != nil {
+		return errors.Wrap(err, errors.SSHKeyPairReadFailed).
+			WithMetadata("path", m.knownHosts)
+	}
+
+	var content strings.Builder
+	for _, entry := range parseKnownHosts(string(knownHosts)) {
+		if entry.Hostname == hostname && (entry.PeeringID == peeringID || entry.PeeringID == "") {
+			continue
+		}
+		content.WriteString(entry.line())
+	}
+	content.WriteString(KnownHostEntry{
+		Hostname:  hostname,
+		PublicKey: strings.TrimSpace(strings.ReplaceAll(hostKey, "\n", " ")),
+		PeeringID: peeringID,
+	}.line())
+
+	if err := os.WriteFile(m.knownHosts, []byte(content.String()), m.permissions); err != nil {
+		return errors.Wrap(err, errors.SSHKnownHostAddFailed).
+			WithMetadata("path", m.knownHosts)
+	}
+
+	m.logger.Info("Pinned host key", "hostname", hostname, "peering_id", peeringID)
+	return nil
+}
+
 // GetKeyPair gets a key pair for a peering ID
 func (m *SSHKeyManager) GetKeyPair(peeringID string) (*KeyPair, error) {
 	// Validate peering ID
@@ -724,7 +777,16 @@ func processKnownHostEntry(line string, entries *[]KnownHostEntry) {
 	// Now split the rest by space, counting from the end to get the peering ID (last field)
 	restParts := strings.Fields(rest)
 	if len(restParts) < 2 {
-		return // Need at least a public key and peering ID
+		return // Need at least a key type and key
+	}
+
+	// Keys learned by ssh on first use have no peering ID comment
+	if len(restParts) == 2 {
+		*entries = append(*entries, KnownHostEntry{
+			Hostname:  hostname,
+			PublicKey: strings.Join(restParts, " "),
+		})
+		return
 	}
 
 	// Last field is the peering ID
@@ -795,8 +857,8 @@ func opensshPrivateKeyED25519(privateKey ed25519.PrivateKey) ([]byte, error) {
 	return privateKeyBytes, nil
 }
 
-// generateRSAKeyPair generates an RSA SSH key pair
-func generateRSAKeyPair() ([]byte, []byte, error) {
+// generateRSAKeyPair generates an RSA SSH key pair of the given size
+func generateRSAKeyPair(bits int) ([]byte, []byte, error) {
 	// For RSA key generation, we'll use the ssh-keygen command directly
 	// This ensures proper OpenSSH format
 
@@ -815,7 +877,7 @@ func generateRSAKeyPair() ([]byte, []byte, error) {
 	// Pass nil logger, ExecCommand will use common.Log
 	_, err = command.ExecCommand(context.Background(), nil, "ssh-keygen",
 		"-t", "rsa",
-		"-b", "4096", // 4096 bits for better security
+		"-b", strconv.Itoa(bits),
 		"-f", tmpPrivateKeyPath,
 		"-N", "", // Empty passphrase
 		"-q") // Quiet mode
@@ -861,9 +923,11 @@ func validateHostname(hostname string) error {
 	}
 
 	// Simple hostname/IP validation
-	// Allow hostnames, IPv4, IPv6, and wildcards
+	// Allow hostnames, IPv4, IPv6, and wildcards,
+	// and the [host]:port form ssh uses for ports other than 22
 	validHost := regexp.MustCompile(
-		`^([a-zA-Z0-9_-]+\.)*[a-zA-Z0-9_-]+$|^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+$|^\[[0-9a-fA-F:]+\]$|^\*\.[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$`,
+		`^([a-zA-Z0-9_-]+\.)*[a-zA-Z0-9_-]+$|^[0-9]+\.[0-9]+\.[0-9]+\.[0-9]+$|^\[[0-9a-fA-F:]+\]$|^\*\.[a-zA-Z0-9_-]+(\.[a-zA-Z0-9_-]+)*$` +
+			`|^[0-9a-fA-F]*:[0-9a-fA-F:]+$|^\[(([a-zA-Z0-9_-]+\.)*[a-zA-Z0-9_-]+|[0-9a-fA-F:.]+)\]:[0-9]{1,5}$`,
 	)
 	if !validHost.MatchString(hostname) {
 		return errors.New(errors.SSHKeyPairInvalidHostname,
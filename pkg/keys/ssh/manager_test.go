@@ -234,6 +234,46 @@ func TestListKeyPairsAndKnownHosts(t *testing.T) {
 	assert.Len(t, knownHosts, 2)
 }
 
+func TestPinHostKey(t *testing.T) {
+	manager, _, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	hostname := "[backup.example.com]:2222"
+	oldKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKw3fGn15qQeXMUG/fMCGwvJ/QzZ9tsAEXkJD4x2V2JH"
+	newKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMuR0W7tRTZ9Vd6cHrDWcYkVbJ1pX7aPN7R0QyUx4m9e"
+	peeringID := generateRandomID()
+	otherPeer := generateRandomID()
+
+	require.NoError(t, manager.AddKnownHost(ctx, hostname, oldKey, peeringID))
+	require.NoError(t, manager.AddKnownHost(ctx, hostname, oldKey, otherPeer))
+
+	// A key learned by ssh on first use has no peering ID
+	f, err := os.OpenFile(manager.knownHosts, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(hostname + " " + oldKey + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err := manager.FindKnownHostsByHostname(hostname)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "", entries[2].PeeringID)
+	assert.Equal(t, oldKey, entries[2].PublicKey)
+
+	// Adding again fails, pinning replaces the peer's and learned keys
+	assert.Error(t, manager.AddKnownHost(ctx, hostname, newKey, peeringID))
+	require.NoError(t, manager.PinHostKey(ctx, hostname, newKey, peeringID))
+
+	entries, err = manager.FindKnownHostsByHostname(hostname)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, otherPeer, entries[0].PeeringID)
+	assert.Equal(t, oldKey, entries[0].PublicKey)
+	assert.Equal(t, peeringID, entries[1].PeeringID)
+	assert.Equal(t, newKey, entries[1].PublicKey)
+}
+
 func TestValidateInputs(t *testing.T) {
 	manager, _, cleanup := setupTestManager(t)
 	defer cleanup()
@@ -0,0 +1,352 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ssh
+
+import (
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultMinRSABits is the smallest RSA key accepted when the policy does
+// not set one
+const defaultMinRSABits = 3072
+
+// generatedRSABits is the size of generated RSA keys unless the policy asks
+// for larger ones
+const generatedRSABits = 4096
+
+// SecurityPolicy is the policy for transfers over SSH: pinned host keys,
+// the keys generated for peerings, and the refusal of password logins.
+// Password and keyboard-interactive logins are always refused; transfers
+// authenticate with keys only.
+type SecurityPolicy struct {
+	// RequirePinnedHostKeys refuses remote hosts, and their jump hosts,
+	// without a host key pinned for a peering
+	RequirePinnedHostKeys bool `json:"require_pinned_host_keys"`
+	// KeyTypes are the key types allowed for generated key pairs
+	KeyTypes []KeyPairType `json:"key_types"`
+	// MinRSABits is the smallest RSA key generated or trusted
+	MinRSABits int `json:"min_rsa_bits"`
+}
+
+// CurrentSecurityPolicy returns the SSH security policy from the config
+func CurrentSecurityPolicy() SecurityPolicy {
+	security := config.GetConfig().Keys.SSH.Security
+	policy := SecurityPolicy{
+		RequirePinnedHostKeys: security.RequirePinnedHostKeys,
+		MinRSABits:            security.MinRSABits,
+	}
+	for _, keyType := range security.KeyTypes {
+		policy.KeyTypes = append(policy.KeyTypes, KeyPairType(strings.ToLower(keyType)))
+	}
+	if len(policy.KeyTypes) == 0 {
+		policy.KeyTypes = []KeyPairType{KeyPairTypeED25519, KeyPairTypeRSA}
+	}
+	if policy.MinRSABits <= 0 {
+		policy.MinRSABits = defaultMinRSABits
+	}
+	return policy
+}
+
+// checkKeyType refuses generating key pairs of types the policy does not allow
+func (p SecurityPolicy) checkKeyType(keyType KeyPairType) error {
+	if !slices.Contains(p.KeyTypes, keyType) {
+		return errors.New(errors.SSHKeyPolicyViolation,
+			fmt.Sprintf("Key type %s is not allowed by the SSH security policy", keyType)).
+			WithMetadata("allowed_types", fmt.Sprint(p.KeyTypes))
+	}
+	return nil
+}
+
+// rsaBits returns the size of generated RSA keys
+func (p SecurityPolicy) rsaBits() int {
+	return max(generatedRSABits, p.MinRSABits)
+}
+
+// keyIssues lists where a key falls short of the policy. DSA keys are
+// always refused; RSA keys must be at least MinRSABits.
+func (p SecurityPolicy) keyIssues(strength KeyStrength) []string {
+	switch {
+	case strength.Type == ssh.KeyAlgoDSA:
+		return []string{"DSA keys are not accepted"}
+	case strength.Type == ssh.KeyAlgoRSA && strength.Bits < p.MinRSABits:
+		return []string{fmt.Sprintf("RSA key of %d bits is below the minimum of %d", strength.Bits, p.MinRSABits)}
+	}
+	return nil
+}
+
+// KeyStrength describes a public key
+type KeyStrength struct {
+	// Type is the SSH key algorithm, such as ssh-ed25519
+	Type string `json:"type"`
+	// Bits is the key size
+	Bits int `json:"bits"`
+	// Fingerprint is the SHA256 fingerprint, as printed by ssh-keygen -l
+	Fingerprint string `json:"fingerprint"`
+}
+
+// ParseKeyStrength parses a public key in authorized_keys or known_hosts
+// key form
+func ParseKeyStrength(publicKey string) (KeyStrength, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(publicKey))
+	if err != nil {
+		return KeyStrength{}, errors.Wrap(err, errors.SSHKeyPairInvalidPublicKey)
+	}
+
+	strength := KeyStrength{
+		Type:        key.Type(),
+		Fingerprint: ssh.FingerprintSHA256(key),
+	}
+	switch key.Type() {
+	case ssh.KeyAlgoED25519, ssh.KeyAlgoSKED25519:
+		strength.Bits = 256
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoSKECDSA256:
+		strength.Bits = 256
+	case ssh.KeyAlgoECDSA384:
+		strength.Bits = 384
+	case ssh.KeyAlgoECDSA521:
+		strength.Bits = 521
+	case ssh.KeyAlgoDSA:
+		strength.Bits = 1024
+	case ssh.KeyAlgoRSA:
+		if cryptoKey, ok := key.(ssh.CryptoPublicKey); ok {
+			if rsaKey, ok := cryptoKey.CryptoPublicKey().(*rsa.PublicKey); ok {
+				strength.Bits = rsaKey.N.BitLen()
+			}
+		}
+	}
+	return strength, nil
+}
+
+// KnownHostName returns the name ssh looks a host up by in known_hosts,
+// [host]:port for ports other than 22
+func KnownHostName(host string, port int) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if port == 0 || port == 22 {
+		return host
+	}
+	return "[" + host + "]:" + strconv.Itoa(port)
+}
+
+// HostKeyPinned reports whether the Rodent-managed known_hosts file pins a
+// key for hostname to a peering. Keys learned on first use do not count.
+func HostKeyPinned(hostname string) (bool, error) {
+	knownHosts, err := os.ReadFile(config.GetKnownHostsFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, errors.SSHKeyPairReadFailed).
+			WithMetadata("path", config.GetKnownHostsFilePath())
+	}
+	return hostKeyPinned(parseKnownHosts(string(knownHosts)), hostname), nil
+}
+
+// hostKeyPinned reports whether entries pin a key for hostname to a peering
+func hostKeyPinned(entries []KnownHostEntry, hostname string) bool {
+	for _, entry := range entries {
+		if entry.PeeringID == "" {
+			continue
+		}
+		for name := range strings.SplitSeq(entry.Hostname, ",") {
+			if name == hostname {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// PinnedHostKey is a host key pinned for a peering
+type PinnedHostKey struct {
+	Hostname string `json:"hostname"`
+	KeyStrength
+}
+
+// PeerPosture summarizes the SSH security of a peering: the key pair used
+// to connect to the peer, the host keys pinned for it, and the key it is
+// authorized with here
+type PeerPosture struct {
+	PeeringID string `json:"peering_id"`
+	// KeyPair is the key this node connects to the peer with
+	KeyPair *KeyStrength `json:"key_pair,omitempty"`
+	// PinnedHostKeys are the peer's host keys in known_hosts
+	PinnedHostKeys []PinnedHostKey `json:"pinned_host_keys,omitempty"`
+	// AuthorizedKey is the key the peer connects to this node with
+	AuthorizedKey *KeyStrength `json:"authorized_key,omitempty"`
+	// Issues lists where the peering falls short of the policy
+	Issues []string `json:"issues,omitempty"`
+	// Compliant is true when there are no issues
+	Compliant bool `json:"compliant"`
+}
+
+// SecurityPosture is the SSH security policy and the posture of every peering
+type SecurityPosture struct {
+	Policy SecurityPolicy `json:"policy"`
+	Peers  []PeerPosture  `json:"peers"`
+}
+
+// GetSecurityPosture returns the posture of every peering with a key pair,
+// a pinned host key or an authorized key
+func (m *SSHKeyManager) GetSecurityPosture(ctx context.Context) (*SecurityPosture, error) {
+	peeringIDs, err := m.peeringIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	posture := &SecurityPosture{Policy: CurrentSecurityPolicy(), Peers: []PeerPosture{}}
+	for _, peeringID := range peeringIDs {
+		peer, err := m.peerPosture(ctx, peeringID, posture.Policy)
+		if err != nil {
+			return nil, err
+		}
+		posture.Peers = append(posture.Peers, *peer)
+	}
+	return posture, nil
+}
+
+// GetPeerPosture returns the posture of a peering
+func (m *SSHKeyManager) GetPeerPosture(ctx context.Context, peeringID string) (*PeerPosture, error) {
+	if err := validatePeeringID(peeringID); err != nil {
+		return nil, err
+	}
+	peeringIDs, err := m.peeringIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(peeringIDs, peeringID) {
+		return nil, errors.New(errors.SSHKeyPairNotFound,
+			"No key pair, host key or authorized key for the peering").
+			WithMetadata("peering_id", peeringID)
+	}
+	return m.peerPosture(ctx, peeringID, CurrentSecurityPolicy())
+}
+
+// peeringIDs returns the sorted peering IDs with a key pair, a pinned host
+// key or an authorized key
+func (m *SSHKeyManager) peeringIDs(ctx context.Context) ([]string, error) {
+	keyPairs, err := m.ListKeyPairs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	knownHosts, err := m.ListKnownHosts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := m.GetAuthorizedPeers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SSHKeyPairReadFailed)
+	}
+
+	var ids []string
+	for _, keyPair := range keyPairs {
+		ids = append(ids, keyPair.PeeringID)
+	}
+	for _, entry := range knownHosts {
+		if entry.PeeringID != "" {
+			ids = append(ids, entry.PeeringID)
+		}
+	}
+	for _, peer := range peers {
+		ids = append(ids, peer.PeeringID)
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids), nil
+}
+
+// peerPosture checks a peering's keys against the policy
+func (m *SSHKeyManager) peerPosture(
+	ctx context.Context,
+	peeringID string,
+	policy SecurityPolicy,
+) (*PeerPosture, error) {
+	posture := &PeerPosture{PeeringID: peeringID}
+
+	hasKeyPair, err := m.HasKeyPair(peeringID)
+	if err != nil {
+		return nil, err
+	}
+	if hasKeyPair {
+		keyPair, err := m.GetKeyPair(peeringID)
+		if err != nil {
+			return nil, err
+		}
+		strength, err := ParseKeyStrength(keyPair.PublicKey)
+		if err != nil {
+			posture.Issues = append(posture.Issues, "key pair public key cannot be parsed")
+		} else {
+			posture.KeyPair = &strength
+			posture.Issues = append(posture.Issues, prefixIssues("key pair", policy.keyIssues(strength))...)
+			if policy.checkKeyType(keyPair.Type) != nil {
+				posture.Issues = append(posture.Issues,
+					fmt.Sprintf("key pair type %s is not allowed", keyPair.Type))
+			}
+		}
+	}
+
+	entries, err := m.FindKnownHostsByPeeringID(peeringID)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		strength, err := ParseKeyStrength(entry.PublicKey)
+		if err != nil {
+			posture.Issues = append(posture.Issues,
+				fmt.Sprintf("host key of %s cannot be parsed", entry.Hostname))
+			continue
+		}
+		posture.PinnedHostKeys = append(posture.PinnedHostKeys, PinnedHostKey{
+			Hostname:    entry.Hostname,
+			KeyStrength: strength,
+		})
+		posture.Issues = append(posture.Issues,
+			prefixIssues("host key of "+entry.Hostname, policy.keyIssues(strength))...)
+	}
+	if hasKeyPair && len(entries) == 0 {
+		if policy.RequirePinnedHostKeys {
+			posture.Issues = append(posture.Issues, "no host key is pinned; transfers to the peer are refused")
+		} else {
+			posture.Issues = append(posture.Issues, "no host key is pinned")
+		}
+	}
+
+	peers, err := m.GetAuthorizedPeers(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SSHKeyPairReadFailed)
+	}
+	for _, peer := range peers {
+		if peer.PeeringID != peeringID {
+			continue
+		}
+		strength, err := ParseKeyStrength(peer.PublicKey)
+		if err != nil {
+			posture.Issues = append(posture.Issues, "authorized key cannot be parsed")
+			break
+		}
+		posture.AuthorizedKey = &strength
+		posture.Issues = append(posture.Issues, prefixIssues("authorized key", policy.keyIssues(strength))...)
+		break
+	}
+
+	posture.Compliant = len(posture.Issues) == 0
+	return posture, nil
+}
+
+// prefixIssues names the key each issue is about
+func prefixIssues(key string, issues []string) []string {
+	for i, issue := range issues {
+		issues[i] = key + ": " + issue
+	}
+	return issues
+}
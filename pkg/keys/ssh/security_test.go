@@ -0,0 +1,148 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func rsaPublicKey(t *testing.T, bits int) string {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	require.NoError(t, err)
+	publicKey, err := ssh.NewPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey)))
+}
+
+func TestParseKeyStrength(t *testing.T) {
+	_, publicBytes, err := generateED25519KeyPair()
+	require.NoError(t, err)
+
+	strength, err := ParseKeyStrength(string(publicBytes))
+	require.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoED25519, strength.Type)
+	assert.Equal(t, 256, strength.Bits)
+	assert.True(t, strings.HasPrefix(strength.Fingerprint, "SHA256:"))
+
+	strength, err = ParseKeyStrength(rsaPublicKey(t, 2048))
+	require.NoError(t, err)
+	assert.Equal(t, ssh.KeyAlgoRSA, strength.Type)
+	assert.Equal(t, 2048, strength.Bits)
+
+	_, err = ParseKeyStrength("ssh-ed25519 not-a-key")
+	assert.Error(t, err)
+}
+
+func TestSecurityPolicyKeys(t *testing.T) {
+	policy := SecurityPolicy{KeyTypes: []KeyPairType{KeyPairTypeED25519}, MinRSABits: 3072}
+
+	assert.NoError(t, policy.checkKeyType(KeyPairTypeED25519))
+	assert.Error(t, policy.checkKeyType(KeyPairTypeRSA))
+	assert.Equal(t, 4096, policy.rsaBits())
+	assert.Equal(t, 8192, SecurityPolicy{MinRSABits: 8192}.rsaBits())
+
+	assert.Empty(t, policy.keyIssues(KeyStrength{Type: ssh.KeyAlgoED25519, Bits: 256}))
+	assert.Empty(t, policy.keyIssues(KeyStrength{Type: ssh.KeyAlgoRSA, Bits: 4096}))
+	assert.Len(t, policy.keyIssues(KeyStrength{Type: ssh.KeyAlgoRSA, Bits: 2048}), 1)
+	assert.Len(t, policy.keyIssues(KeyStrength{Type: ssh.KeyAlgoDSA, Bits: 1024}), 1)
+}
+
+func TestHostKeyPinned(t *testing.T) {
+	assert.Equal(t, "backup.example.com", KnownHostName("backup.example.com", 22))
+	assert.Equal(t, "[backup.example.com]:2222", KnownHostName("backup.example.com", 2222))
+	assert.Equal(t, "[2001:db8::10]:2222", KnownHostName("[2001:db8::10]", 2222))
+
+	entries := parseKnownHosts(
+		"backup.example.com,10.0.0.5 ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIKw3 peer-1\n" +
+			"learned.example.com ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMuR\n")
+	assert.True(t, hostKeyPinned(entries, "backup.example.com"))
+	assert.True(t, hostKeyPinned(entries, "10.0.0.5"))
+	assert.False(t, hostKeyPinned(entries, "learned.example.com"), "keys learned on first use are not pinned")
+	assert.False(t, hostKeyPinned(entries, "other.example.com"))
+}
+
+func TestPeerPosture(t *testing.T) {
+	manager, _, cleanup := setupTestManager(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	policy := SecurityPolicy{
+		KeyTypes:   []KeyPairType{KeyPairTypeED25519, KeyPairTypeRSA},
+		MinRSABits: 3072,
+	}
+
+	peeringID := generateRandomID()
+	_, err := manager.GenerateKeyPair(ctx, peeringID, KeyPairTypeED25519)
+	require.NoError(t, err)
+
+	posture, err := manager.peerPosture(ctx, peeringID, policy)
+	require.NoError(t, err)
+	require.NotNil(t, posture.KeyPair)
+	assert.Equal(t, ssh.KeyAlgoED25519, posture.KeyPair.Type)
+	assert.False(t, posture.Compliant, "a peer without a pinned host key is not compliant")
+
+	_, hostKey, err := generateED25519KeyPair()
+	require.NoError(t, err)
+	require.NoError(t, manager.PinHostKey(ctx, "backup.example.com", string(hostKey), peeringID))
+	require.NoError(t, manager.AuthorizePeer(ctx, PeerInfo{
+		PeeringID: peeringID,
+		PublicKey: rsaPublicKey(t, 2048),
+	}))
+
+	posture, err = manager.peerPosture(ctx, peeringID, policy)
+	require.NoError(t, err)
+	require.Len(t, posture.PinnedHostKeys, 1)
+	assert.Equal(t, "backup.example.com", posture.PinnedHostKeys[0].Hostname)
+	require.NotNil(t, posture.AuthorizedKey)
+	assert.Equal(t, 2048, posture.AuthorizedKey.Bits)
+	require.Len(t, posture.Issues, 1)
+	assert.Contains(t, posture.Issues[0], "authorized key")
+	assert.False(t, posture.Compliant)
+
+	ids, err := manager.peeringIDs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{peeringID}, ids)
+}
+
+func TestParseKeyscan(t *testing.T) {
+	_, ed25519Key, err := generateED25519KeyPair()
+	require.NoError(t, err)
+	edFields := strings.Fields(string(ed25519Key))
+	rsaFields := strings.Fields(rsaPublicKey(t, 2048))
+
+	output := "# nas.example:22 SSH-2.0-OpenSSH_9.6\n" +
+		"nas.example " + rsaFields[0] + " " + rsaFields[1] + "\n" +
+		"# nas.example:22 SSH-2.0-OpenSSH_9.6\n" +
+		"nas.example " + edFields[0] + " " + edFields[1] + "\n"
+
+	keys := parseKeyscan(output, SecurityPolicy{MinRSABits: 3072})
+	require.Len(t, keys, 2)
+	assert.Equal(t, ssh.KeyAlgoED25519, keys[0].Type, "ed25519 keys come first")
+	assert.Empty(t, keys[0].Issues)
+	assert.Equal(t, ssh.KeyAlgoRSA, keys[1].Type)
+	assert.Len(t, keys[1].Issues, 1)
+
+	key, err := SelectHostKey(keys, "")
+	require.NoError(t, err)
+	assert.Equal(t, keys[0].Fingerprint, key.Fingerprint)
+
+	key, err = SelectHostKey(keys, strings.TrimPrefix(keys[0].Fingerprint, "SHA256:"))
+	require.NoError(t, err)
+	assert.Equal(t, keys[0].PublicKey, key.PublicKey)
+
+	_, err = SelectHostKey(keys, keys[1].Fingerprint)
+	assert.Error(t, err, "a key below the policy is not pinned even when its fingerprint matches")
+	_, err = SelectHostKey(keys, "SHA256:unknown")
+	assert.Error(t, err)
+}
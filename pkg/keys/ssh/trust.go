@@ -0,0 +1,141 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package ssh
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// keyscanTimeout is how long ssh-keyscan waits for a host, in seconds
+const keyscanTimeout = 10
+
+// hostKeyPreference orders host key types, most preferred first
+var hostKeyPreference = []string{
+	ssh.KeyAlgoED25519,
+	ssh.KeyAlgoECDSA521,
+	ssh.KeyAlgoECDSA384,
+	ssh.KeyAlgoECDSA256,
+	ssh.KeyAlgoRSA,
+}
+
+// ScannedHostKey is a host key offered by a remote SSH server
+type ScannedHostKey struct {
+	// PublicKey is the key in known_hosts key form, "<type> <base64>"
+	PublicKey string `json:"public_key"`
+	KeyStrength
+	// Issues lists where the key falls short of the security policy
+	Issues []string `json:"issues,omitempty"`
+}
+
+// ScanHostKeys asks the SSH server of a host for its host keys with
+// ssh-keyscan. The keys are not trusted by scanning; compare a fingerprint
+// with the one the host's administrator reports before pinning one.
+func ScanHostKeys(ctx context.Context, host string, port int) ([]ScannedHostKey, error) {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if err := validateHostname(host); err != nil {
+		return nil, err
+	}
+	if port == 0 {
+		port = 22
+	}
+	if port < 1 || port > 65535 {
+		return nil, errors.New(errors.SSHKeyPairInvalidHostname, "Invalid SSH port").
+			WithMetadata("port", strconv.Itoa(port))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, (keyscanTimeout+5)*time.Second)
+	defer cancel()
+	output, err := command.ExecCommand(ctx, nil, "ssh-keyscan",
+		"-p", strconv.Itoa(port),
+		"-T", strconv.Itoa(keyscanTimeout),
+		host)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SSHKeyPairReadFailed).
+			WithMetadata("host", host)
+	}
+
+	keys := parseKeyscan(string(output), CurrentSecurityPolicy())
+	if len(keys) == 0 {
+		return nil, errors.New(errors.SSHKeyPairNotFound, "The host offered no SSH host keys").
+			WithMetadata("host", host)
+	}
+	return keys, nil
+}
+
+// parseKeyscan parses the output of ssh-keyscan, "<host> <type> <base64>"
+// lines and comments, into keys ordered by preference
+func parseKeyscan(output string, policy SecurityPolicy) []ScannedHostKey {
+	var keys []ScannedHostKey
+	for line := range strings.SplitSeq(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		publicKey := fields[1] + " " + fields[2]
+		strength, err := ParseKeyStrength(publicKey)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, ScannedHostKey{
+			PublicKey:   publicKey,
+			KeyStrength: strength,
+			Issues:      policy.keyIssues(strength),
+		})
+	}
+
+	slices.SortStableFunc(keys, func(a, b ScannedHostKey) int {
+		return hostKeyRank(a.Type) - hostKeyRank(b.Type)
+	})
+	return keys
+}
+
+// hostKeyRank returns the place of a key type in hostKeyPreference, with
+// types not listed last
+func hostKeyRank(keyType string) int {
+	if i := slices.Index(hostKeyPreference, keyType); i >= 0 {
+		return i
+	}
+	return len(hostKeyPreference)
+}
+
+// SelectHostKey picks the key to pin from scanned keys: the one with the
+// given fingerprint, or without one, the most preferred key that meets the
+// security policy
+func SelectHostKey(keys []ScannedHostKey, fingerprint string) (*ScannedHostKey, error) {
+	if fingerprint != "" {
+		want := fingerprint
+		if !strings.HasPrefix(want, "SHA256:") {
+			want = "SHA256:" + want
+		}
+		for i := range keys {
+			if keys[i].Fingerprint == want {
+				if len(keys[i].Issues) > 0 {
+					return nil, errors.New(errors.SSHKeyPolicyViolation, keys[i].Issues[0]).
+						WithMetadata("fingerprint", want)
+				}
+				return &keys[i], nil
+			}
+		}
+		return nil, errors.New(errors.SSHKeyPolicyViolation,
+			"No host key offered by the host has the expected fingerprint").
+			WithMetadata("fingerprint", want)
+	}
+
+	for i := range keys {
+		if len(keys[i].Issues) == 0 {
+			return &keys[i], nil
+		}
+	}
+	return nil, errors.New(errors.SSHKeyPolicyViolation,
+		"No host key offered by the host meets the SSH security policy")
+}
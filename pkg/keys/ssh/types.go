@@ -4,6 +4,8 @@
 
 package ssh
 
+import "fmt"
+
 // KeyPairType represents the type of SSH key algorithm
 type KeyPairType string
 
@@ -34,10 +36,19 @@ type KnownHostEntry struct {
 	Hostname string `json:"hostname"`
 	// PublicKey is the public key in known_hosts format
 	PublicKey string `json:"public_key"`
-	// PeeringID identifies which Rodent peer this key belongs to
+	// PeeringID identifies which Rodent peer this key belongs to. Keys
+	// learned on first use have none.
 	PeeringID string `json:"peering_id"`
 }
 
+// line formats the entry as a known_hosts line
+func (e KnownHostEntry) line() string {
+	if e.PeeringID == "" {
+		return fmt.Sprintf("%s %s\n", e.Hostname, e.PublicKey)
+	}
+	return fmt.Sprintf("%s %s %s\n", e.Hostname, e.PublicKey, e.PeeringID)
+}
+
 // GenerateKeyPairRequest represents a request to generate a new key pair
 type GenerateKeyPairRequest struct {
 	// PeeringID is a unique identifier for the peer
@@ -110,6 +121,9 @@ type AddKnownHostRequest struct {
 	Hostname string `json:"hostname" binding:"required"`
 	// HostKey is the remote host's SSH server public key
 	HostKey string `json:"host_key" binding:"required"`
+	// Replace pins HostKey as the host's only key for the peering, replacing
+	// keys added before instead of failing when an entry exists
+	Replace bool `json:"replace,omitempty"`
 }
 
 // RemoveKnownHostRequest represents a request to remove a host entry from known_hosts
@@ -0,0 +1,61 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler serves the maintenance state and opens and closes windows
+type APIHandler struct {
+	manager *Manager
+}
+
+// NewAPIHandler creates a maintenance API handler
+func NewAPIHandler(m *Manager) *APIHandler {
+	return &APIHandler{manager: m}
+}
+
+// RegisterRoutes registers the maintenance routes
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.status)
+	router.POST("/on", h.on)
+	router.POST("/off", h.off)
+}
+
+// status returns the open window, the schedulers and past windows
+func (h *APIHandler) status(c *gin.Context) {
+	c.JSON(http.StatusOK, h.manager.Status())
+}
+
+// on opens a maintenance window
+func (h *APIHandler) on(c *gin.Context) {
+	var req OnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	w, err := h.manager.On(c.Request.Context(), req)
+	if err != nil {
+		common.APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+// off closes the open maintenance window
+func (h *APIHandler) off(c *gin.Context) {
+	w, err := h.manager.Off(c.Request.Context())
+	if err != nil {
+		common.APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
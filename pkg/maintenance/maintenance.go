@@ -0,0 +1,398 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package maintenance puts the whole node in maintenance mode, such as for a
+// disk swap. While a window is open every scheduler is paused, new transfers
+// are refused, notifications other than critical ones are muted and shares
+// are optionally made read only or unavailable. Closing the window undoes
+// all of it and runs the scheduled tasks that were missed. Windows are kept,
+// with who opened and closed them, as an audit trail.
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/internal/schedwatch"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/reports"
+	"github.com/stratastor/rodent/pkg/webhooks"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// stateFile keeps the open window and past windows in the config directory
+const stateFile = "maintenance.json"
+
+// historyLimit is how many past windows are kept
+const historyLimit = 100
+
+// Share access during a window. The values are those of the SMB manager's
+// access overrides.
+const (
+	SharesUnchanged    = ""             // Shares stay as configured
+	SharesReadOnly     = "read-only"    // Shares are made read only
+	SharesDisconnected = "disconnected" // Shares are made unavailable
+)
+
+// ShareAccess is a share subsystem whose shares can be made read only or
+// unavailable
+type ShareAccess interface {
+	SetShareAccess(ctx context.Context, mode string) error
+}
+
+// OnRequest opens a maintenance window
+type OnRequest struct {
+	Reason string `json:"reason"           binding:"required"`
+	Shares string `json:"shares,omitempty"` // read-only or disconnected; shares are left alone when empty
+}
+
+// Window is a period the node spent in maintenance
+type Window struct {
+	ID        string        `json:"id"`
+	Reason    string        `json:"reason"`
+	Shares    string        `json:"shares,omitempty"`
+	StartedAt time.Time     `json:"started_at"`
+	StartedBy common.Actor  `json:"started_by"`
+	EndedAt   *time.Time    `json:"ended_at,omitempty"`
+	EndedBy   *common.Actor `json:"ended_by,omitempty"`
+	// CaughtUp is how many missed scheduled runs were started when it ended
+	CaughtUp int `json:"caught_up,omitempty"`
+	// Errors are the parts of the node that could not be paused or resumed
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Status is the maintenance state of the node
+type Status struct {
+	Active     bool                `json:"active"`
+	Window     *Window             `json:"window,omitempty"`
+	Schedulers []schedwatch.Status `json:"schedulers"`
+	History    []Window            `json:"history"` // Past windows, latest first
+}
+
+// state is what is saved to stateFile
+type state struct {
+	Current *Window  `json:"current,omitempty"`
+	History []Window `json:"history"`
+}
+
+// Manager opens and closes maintenance windows over the subsystems set on it
+type Manager struct {
+	logger logger.Logger
+	path   string
+
+	transfers atomic.Pointer[dataset.TransferManager]
+	webhooks  atomic.Pointer[webhooks.Dispatcher]
+	reports   atomic.Pointer[reports.Service]
+	shares    atomic.Pointer[ShareAccess]
+
+	mu    sync.Mutex // Serializes windows opening and closing
+	state state
+}
+
+// Singleton instance
+var (
+	globalManager *Manager
+	initMutex     sync.Mutex
+)
+
+// GetManager returns the singleton manager, keeping its windows in the
+// config directory
+func GetManager(logCfg logger.Config) (*Manager, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalManager != nil {
+		return globalManager, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "maintenance")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	globalManager = NewManager(l, config.GetConfigDir())
+	return globalManager, nil
+}
+
+// NewManager creates a manager keeping its windows in configDir. Most
+// callers should use GetManager.
+func NewManager(l logger.Logger, configDir string) *Manager {
+	m := &Manager{
+		logger: l,
+		path:   filepath.Join(configDir, stateFile),
+	}
+	if err := m.load(); err != nil {
+		l.Warn("Failed to load maintenance state, starting without it", "error", err)
+	}
+	return m
+}
+
+// UseTransferManager refuses new transfers while in maintenance
+func (m *Manager) UseTransferManager(tm *dataset.TransferManager) {
+	m.transfers.Store(tm)
+}
+
+// UseWebhooks mutes webhook events other than critical ones while in
+// maintenance
+func (m *Manager) UseWebhooks(d *webhooks.Dispatcher) {
+	m.webhooks.Store(d)
+}
+
+// UseReports skips scheduled digests while in maintenance
+func (m *Manager) UseReports(s *reports.Service) {
+	m.reports.Store(s)
+}
+
+// UseShares makes shares read only or unavailable in windows that ask for
+// it
+func (m *Manager) UseShares(s ShareAccess) {
+	m.shares.Store(&s)
+}
+
+// Status returns the maintenance state of the node
+func (m *Manager) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{
+		Active:     m.state.Current != nil,
+		Schedulers: schedwatch.Statuses(),
+		History:    slices.Clone(m.state.History),
+	}
+	if status.History == nil {
+		status.History = []Window{}
+	}
+	slices.Reverse(status.History)
+	if m.state.Current != nil {
+		w := *m.state.Current
+		status.Window = &w
+	}
+	return status
+}
+
+// Restore pauses the node again when it was restarted during a window, so
+// a restart does not end maintenance. It must be called once the
+// subsystems are set.
+func (m *Manager) Restore(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.state.Current
+	if w == nil {
+		return
+	}
+	w.Errors = append(w.Errors, m.pause(ctx, w)...)
+	m.save()
+	m.logger.Warn("Node is in maintenance, resumed the window open before the restart",
+		"window_id", w.ID,
+		"reason", w.Reason,
+		"started_at", w.StartedAt)
+}
+
+// On opens a maintenance window. Parts of the node that cannot be paused are
+// listed in the window's errors; the window is open regardless.
+func (m *Manager) On(ctx context.Context, req OnRequest) (*Window, error) {
+	if req.Reason == "" {
+		return nil, errors.New(errors.ServerRequestValidation, "A reason for maintenance is required")
+	}
+	if !slices.Contains([]string{SharesUnchanged, SharesReadOnly, SharesDisconnected}, req.Shares) {
+		return nil, errors.New(errors.ServerRequestValidation,
+			"Shares must be read-only or disconnected during maintenance").
+			WithMetadata("shares", req.Shares)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if w := m.state.Current; w != nil {
+		return nil, errors.New(errors.MaintenanceActive, "A maintenance window is already open").
+			WithMetadata("window_id", w.ID).
+			WithMetadata("reason", w.Reason)
+	}
+
+	actor := common.ActorFromContext(ctx)
+	w := &Window{
+		ID:        common.UUID7(),
+		Reason:    req.Reason,
+		Shares:    req.Shares,
+		StartedAt: time.Now(),
+		StartedBy: actor,
+	}
+
+	w.Errors = m.pause(ctx, w)
+	m.state.Current = w
+	m.save()
+	emitWindowEvent(w, "on")
+
+	m.logger.Warn("Node entered maintenance",
+		"window_id", w.ID,
+		"reason", w.Reason,
+		"shares", w.Shares,
+		"actor_source", actor.Source,
+		"actor_user", actor.User,
+		"actor_address", actor.Address,
+		"errors", len(w.Errors))
+
+	result := *w
+	return &result, nil
+}
+
+// Off closes the open maintenance window, resuming the node and running
+// the scheduled tasks missed during it
+func (m *Manager) Off(ctx context.Context) (*Window, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.state.Current
+	if w == nil {
+		return nil, errors.New(errors.MaintenanceNotActive, "No maintenance window is open")
+	}
+
+	actor := common.ActorFromContext(ctx)
+	now := time.Now()
+	w.EndedAt = &now
+	w.EndedBy = &actor
+	w.Errors = append(w.Errors, m.resume(ctx, w)...)
+	w.CaughtUp = schedwatch.Resume()
+
+	m.state.History = append(m.state.History, *w)
+	if len(m.state.History) > historyLimit {
+		m.state.History = slices.Clone(m.state.History[len(m.state.History)-historyLimit:])
+	}
+	m.state.Current = nil
+	m.save()
+	emitWindowEvent(w, "off")
+
+	m.logger.Warn("Node left maintenance",
+		"window_id", w.ID,
+		"reason", w.Reason,
+		"duration", now.Sub(w.StartedAt).Round(time.Second),
+		"caught_up", w.CaughtUp,
+		"actor_source", actor.Source,
+		"actor_user", actor.User,
+		"actor_address", actor.Address)
+
+	result := *w
+	return &result, nil
+}
+
+// pause applies a window to the subsystems, returning what failed (must be
+// called with lock held)
+func (m *Manager) pause(ctx context.Context, w *Window) []string {
+	reason := "maintenance: " + w.Reason
+
+	schedwatch.Pause(w.Reason)
+	if tm := m.transfers.Load(); tm != nil {
+		tm.BlockTransfers(reason)
+	}
+	if d := m.webhooks.Load(); d != nil {
+		d.Mute(reason)
+	}
+	if s := m.reports.Load(); s != nil {
+		s.Mute(reason)
+	}
+
+	var failed []string
+	if w.Shares != SharesUnchanged {
+		if s := m.shares.Load(); s == nil {
+			failed = append(failed, "shares: no share manager is running")
+		} else if err := (*s).SetShareAccess(ctx, w.Shares); err != nil {
+			m.logger.Error("Failed to change share access for maintenance",
+				"shares", w.Shares,
+				"error", err)
+			failed = append(failed, "shares: "+err.Error())
+		}
+	}
+	return failed
+}
+
+// resume undoes a window, returning what failed; the held scheduled runs
+// are left to the caller (must be called with lock held)
+func (m *Manager) resume(ctx context.Context, w *Window) []string {
+	var failed []string
+	if w.Shares != SharesUnchanged {
+		if s := m.shares.Load(); s != nil {
+			if err := (*s).SetShareAccess(ctx, SharesUnchanged); err != nil {
+				m.logger.Error("Failed to restore share access after maintenance", "error", err)
+				failed = append(failed, "shares: "+err.Error())
+			}
+		}
+	}
+
+	if s := m.reports.Load(); s != nil {
+		s.Unmute()
+	}
+	if d := m.webhooks.Load(); d != nil {
+		d.Unmute()
+	}
+	if tm := m.transfers.Load(); tm != nil {
+		tm.UnblockTransfers()
+	}
+	return failed
+}
+
+// emitWindowEvent announces a window opening or closing to the control
+// plane
+func emitWindowEvent(w *Window, operation string) {
+	metadata := map[string]string{
+		"component": "maintenance",
+		"action":    operation,
+		"window_id": w.ID,
+		"reason":    w.Reason,
+		"shares":    w.Shares,
+		"actor":     w.StartedBy.User,
+	}
+	if w.EndedBy != nil {
+		metadata["actor"] = w.EndedBy.User
+		metadata["caught_up"] = strconv.Itoa(w.CaughtUp)
+	}
+	events.EmitSystemConfigChange(&eventspb.SystemConfigChangePayload{
+		ConfigSection: "maintenance",
+		ChangedKeys:   []string{"active"},
+		Operation:     eventspb.SystemConfigChangePayload_SYSTEM_CONFIG_OPERATION_UPDATED,
+	}, metadata)
+}
+
+// load reads the saved windows; a missing file is no window
+func (m *Manager) load() error {
+	data, err := os.ReadFile(m.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &m.state)
+}
+
+// save writes the windows, logging failures, as the node is already paused
+// or resumed by then (must be called with lock held)
+func (m *Manager) save() {
+	data, err := json.MarshalIndent(m.state, "", "  ")
+	if err != nil {
+		m.logger.Warn("Failed to encode maintenance state", "error", err)
+		return
+	}
+
+	tmpPath := m.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		m.logger.Warn("Failed to save maintenance state", "path", m.path, "error", err)
+		return
+	}
+	if err := os.Rename(tmpPath, m.path); err != nil {
+		m.logger.Warn("Failed to save maintenance state", "path", m.path, "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/schedwatch"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeShares records the share access it is set to
+type fakeShares struct {
+	modes []string
+}
+
+func (f *fakeShares) SetShareAccess(_ context.Context, mode string) error {
+	f.modes = append(f.modes, mode)
+	return nil
+}
+
+func TestWindow(t *testing.T) {
+	dir := t.TempDir()
+	m := NewManager(common.Log, dir)
+	shares := &fakeShares{}
+	m.UseShares(shares)
+	t.Cleanup(func() { schedwatch.Resume() })
+
+	ctx := common.WithActor(context.Background(), common.Actor{Source: common.ActorSourceAPI, User: "ops"})
+
+	_, err := m.On(ctx, OnRequest{Reason: "disk swap", Shares: "read-write"})
+	assert.Error(t, err)
+	_, err = m.Off(ctx)
+	code, _ := errors.GetCode(err)
+	assert.Equal(t, errors.ErrorCode(errors.MaintenanceNotActive), code)
+
+	w, err := m.On(ctx, OnRequest{Reason: "disk swap", Shares: SharesReadOnly})
+	require.NoError(t, err)
+	assert.Equal(t, "ops", w.StartedBy.User)
+	assert.Empty(t, w.Errors)
+	assert.Equal(t, []string{SharesReadOnly}, shares.modes)
+	paused, reason := schedwatch.Paused()
+	assert.True(t, paused)
+	assert.Equal(t, "disk swap", reason)
+
+	_, err = m.On(ctx, OnRequest{Reason: "again"})
+	code, _ = errors.GetCode(err)
+	assert.Equal(t, errors.ErrorCode(errors.MaintenanceActive), code)
+
+	// A restart keeps the window open
+	restarted := NewManager(common.Log, dir)
+	status := restarted.Status()
+	require.True(t, status.Active)
+	assert.Equal(t, w.ID, status.Window.ID)
+
+	ended, err := m.Off(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, ended.EndedAt)
+	assert.Equal(t, "ops", ended.EndedBy.User)
+	assert.Equal(t, []string{SharesReadOnly, SharesUnchanged}, shares.modes)
+	paused, _ = schedwatch.Paused()
+	assert.False(t, paused)
+
+	status = NewManager(common.Log, dir).Status()
+	assert.False(t, status.Active)
+	require.Len(t, status.History, 1)
+	assert.Equal(t, "disk swap", status.History[0].Reason)
+}
@@ -46,11 +46,10 @@ func NewManager(
 	}
 
 	// Create sudo file operations for privileged file access
-	allowedPaths := []string{"/etc/netplan", "/etc/systemd/resolved.conf.d"}
 	sudoOps := privilege.NewSudoFileOperations(
 		logger,
 		command.NewCommandExecutor(true),
-		allowedPaths,
+		privilege.AllowedPaths(),
 	)
 
 	// Initialize netplan command wrapper
@@ -0,0 +1,170 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// update rewrites the golden files from the current parsers:
+// go test ./pkg/parsers/ -update
+var update = flag.Bool("update", false, "update golden files")
+
+// golden parses every input in testdata/<dir> and compares the result,
+// as JSON, with the input's .golden file
+func golden(t *testing.T, dir string, parse func(t *testing.T, input []byte) any) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", dir, "*"))
+	require.NoError(t, err)
+
+	for _, input := range inputs {
+		if strings.HasSuffix(input, ".golden") {
+			continue
+		}
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			data, err := os.ReadFile(input)
+			require.NoError(t, err)
+
+			got, err := json.MarshalIndent(parse(t, data), "", "  ")
+			require.NoError(t, err)
+			got = append(got, '\n')
+
+			path := strings.TrimSuffix(input, filepath.Ext(input)) + ".golden"
+			if *update {
+				require.NoError(t, os.WriteFile(path, got, 0644))
+				return
+			}
+			want, err := os.ReadFile(path)
+			require.NoError(t, err, "run with -update to create the golden file")
+			assert.Equal(t, string(want), string(got))
+		})
+	}
+}
+
+func TestParseSMBStatusGolden(t *testing.T) {
+	golden(t, "smbstatus", func(t *testing.T, input []byte) any {
+		status, err := ParseSMBStatus(input)
+		require.NoError(t, err)
+		return status
+	})
+}
+
+func TestParseSMBStatusTextGolden(t *testing.T) {
+	golden(t, "smbstatus-text", func(t *testing.T, input []byte) any {
+		status, err := ParseSMBStatusText(input)
+		require.NoError(t, err)
+		return status
+	})
+}
+
+func TestSMBStatusProfileFor(t *testing.T) {
+	tests := []struct {
+		version string
+		want    SMBStatusFormat
+	}{
+		{"Version 4.13.17", SMBStatusText},
+		{"Version 4.15.13-Ubuntu", SMBStatusText},
+		{"Version 4.16.0", SMBStatusJSON},
+		{"Version 4.19.5-Ubuntu", SMBStatusJSON},
+		{"", SMBStatusJSON},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, SMBStatusProfileFor(ParseVersion(tt.version)).Format, tt.version)
+	}
+}
+
+func TestParseSMBStatusInvalid(t *testing.T) {
+	for _, input := range []string{"", "smbd is not running", `{"sessions": {`} {
+		_, err := ParseSMBStatus([]byte(input))
+		assert.Error(t, err, "input %q", input)
+	}
+	_, err := ParseSMBStatusText([]byte("smbstatus: unknown option -j"))
+	assert.Error(t, err)
+}
+
+func TestParseZFSListGolden(t *testing.T) {
+	golden(t, "zfslist", func(t *testing.T, input []byte) any {
+		return ParseZFSList(string(input), "name", "guid", "creation", "rodent:pinned")
+	})
+}
+
+func TestZFSListRow(t *testing.T) {
+	rows := ParseZFSList("tank@a\t-\t1700000000\n", "name", "used", "creation", "guid")
+	require.Len(t, rows, 1)
+	row := rows[0]
+
+	_, ok := row.Get("used")
+	assert.False(t, ok, "unset values are not ok")
+	_, ok = row.Uint("guid")
+	assert.False(t, ok, "missing columns are not ok")
+	created, ok := row.Time("creation")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1700000000), created.Unix())
+}
+
+func TestParseProcStatGolden(t *testing.T) {
+	golden(t, "proc", func(t *testing.T, input []byte) any {
+		stat, err := ParseProcStat(input)
+		require.NoError(t, err)
+		return stat
+	})
+}
+
+func TestProcStatStartedAt(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	stat := &ProcStat{StartTime: 250}
+	assert.Equal(t, now.Add(-time.Hour).Add(2500*time.Millisecond), stat.StartedAt(time.Hour, now))
+}
+
+func TestParseProcFiles(t *testing.T) {
+	uptime, err := ParseUptime([]byte("3600.50 7100.20\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 3600500*time.Millisecond, uptime)
+	_, err = ParseUptime(nil)
+	assert.Error(t, err)
+
+	meminfo := ParseMeminfo([]byte("MemTotal:       16303452 kB\nHugePages_Total:       4\nbroken line\nMemFree: x kB\n"))
+	assert.Equal(t, map[string]uint64{"MemTotal": 16303452 * 1024, "HugePages_Total": 4}, meminfo)
+
+	load, err := ParseLoadavg([]byte("0.52 0.58 0.59 1/467 12345\n"))
+	require.NoError(t, err)
+	assert.Equal(t, &LoadAverage{Load1: 0.52, Load5: 0.58, Load15: 0.59}, load)
+	_, err = ParseLoadavg([]byte("0.52"))
+	assert.Error(t, err)
+
+	mounts := ParseMounts([]byte("rpool/ROOT/ubuntu / zfs rw,relatime 0 0\n" +
+		"tank/My\\040Share /tank/My\\040Share zfs rw 0 0\nshort line\n"))
+	assert.Equal(t, []Mount{
+		{Source: "rpool/ROOT/ubuntu", Mountpoint: "/", FSType: "zfs"},
+		{Source: "tank/My Share", Mountpoint: "/tank/My Share", FSType: "zfs"},
+	}, mounts)
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+	}{
+		{"Version 4.19.5-Ubuntu", Version{4, 19, 5, "Version 4.19.5-Ubuntu"}},
+		{"zfs-2.2.2-0ubuntu9", Version{2, 2, 2, "zfs-2.2.2-0ubuntu9"}},
+		{"4.20", Version{4, 20, 0, "4.20"}},
+		{"unknown", Version{Raw: "unknown"}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ParseVersion(tt.in), tt.in)
+	}
+
+	assert.True(t, ParseVersion("4.16.0").AtLeast(4, 16))
+	assert.False(t, ParseVersion("4.15.13").AtLeast(4, 16))
+	assert.False(t, ParseVersion("").Known())
+}
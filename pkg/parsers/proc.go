@@ -0,0 +1,159 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// ClockTicks is the USER_HZ of /proc times, 100 on every Linux
+// architecture rodent runs on
+const ClockTicks = 100
+
+// ProcStat is the part of /proc/<pid>/stat that rodent reads
+type ProcStat struct {
+	PID       int    `json:"pid"`
+	Comm      string `json:"comm"`
+	State     string `json:"state"`      // R, S, D, Z, T, I, ...
+	PPID      int    `json:"ppid"`       // Zero when the line is truncated
+	StartTime uint64 `json:"start_time"` // Clock ticks after boot; zero when truncated
+}
+
+// ParseProcStat parses a /proc/<pid>/stat line. The command name is taken
+// up to the last ')', since it may contain spaces and parentheses that
+// would shift fields split on whitespace. Fields missing from older kernels
+// are left zero.
+func ParseProcStat(data []byte) (*ProcStat, error) {
+	line := strings.TrimSpace(string(data))
+	start, end := strings.IndexByte(line, '('), strings.LastIndexByte(line, ')')
+	if start < 0 || end < start {
+		return nil, errors.New(errors.CommandOutputParse, "stat line has no command name").
+			WithMetadata("line", truncate(line))
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(line[:start]))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CommandOutputParse).
+			WithMetadata("line", truncate(line))
+	}
+	stat := &ProcStat{PID: pid, Comm: line[start+1 : end]}
+
+	// Fields after the command name, starting with the state (field 3)
+	fields := strings.Fields(line[end+1:])
+	if len(fields) > 0 {
+		stat.State = fields[0]
+	}
+	if len(fields) > 1 {
+		stat.PPID, _ = strconv.Atoi(fields[1])
+	}
+	if len(fields) > 19 {
+		stat.StartTime, _ = strconv.ParseUint(fields[19], 10, 64)
+	}
+	return stat, nil
+}
+
+// StartedAt returns when the process started, given the system uptime
+func (s *ProcStat) StartedAt(uptime time.Duration, now time.Time) time.Time {
+	return now.Add(-uptime).Add(time.Duration(s.StartTime) * time.Second / ClockTicks)
+}
+
+// ParseUptime parses /proc/uptime
+func ParseUptime(data []byte) (time.Duration, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, errors.New(errors.CommandOutputParse, "uptime is empty")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.CommandOutputParse).
+			WithMetadata("uptime", truncate(fields[0]))
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// ParseMeminfo parses /proc/meminfo into bytes by key. Values in kB are
+// converted to bytes; unitless values, such as HugePages_Total, are counts
+// and kept as is. Malformed lines are skipped.
+func ParseMeminfo(data []byte) map[string]uint64 {
+	info := make(map[string]uint64)
+	for line := range strings.SplitSeq(string(data), "\n") {
+		key, rest, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 1 && strings.EqualFold(fields[1], "kB") {
+			value *= 1024
+		}
+		info[strings.TrimSpace(key)] = value
+	}
+	return info
+}
+
+// LoadAverage is the system load of /proc/loadavg
+type LoadAverage struct {
+	Load1  float64 `json:"load1"`
+	Load5  float64 `json:"load5"`
+	Load15 float64 `json:"load15"`
+}
+
+// ParseLoadavg parses /proc/loadavg
+func ParseLoadavg(data []byte) (*LoadAverage, error) {
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, errors.New(errors.CommandOutputParse, "loadavg has fewer than 3 fields").
+			WithMetadata("loadavg", truncate(string(data)))
+	}
+	var load [3]float64
+	for i := range load {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.CommandOutputParse).
+				WithMetadata("loadavg", truncate(string(data)))
+		}
+		load[i] = v
+	}
+	return &LoadAverage{Load1: load[0], Load5: load[1], Load15: load[2]}, nil
+}
+
+// Mount is a line of /proc/self/mounts
+type Mount struct {
+	Source     string `json:"source"`
+	Mountpoint string `json:"mountpoint"`
+	FSType     string `json:"fstype"`
+}
+
+// mountEscapes undoes the octal escapes the kernel writes for spaces, tabs,
+// newlines and backslashes in mount fields
+var mountEscapes = strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`)
+
+// ParseMounts parses /proc/self/mounts. Lines with fewer than three fields
+// are skipped.
+func ParseMounts(data []byte) []Mount {
+	var mounts []Mount
+	for line := range strings.SplitSeq(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		mounts = append(mounts, Mount{
+			Source:     mountEscapes.Replace(fields[0]),
+			Mountpoint: mountEscapes.Replace(fields[1]),
+			FSType:     fields[2],
+		})
+	}
+	return mounts
+}
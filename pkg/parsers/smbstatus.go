@@ -0,0 +1,271 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// SMBStatus is the state smbstatus -j reports: sessions, the tree
+// connections of shares and open files. Maps are never nil.
+type SMBStatus struct {
+	Version      Version                   `json:"version"`
+	Timestamp    time.Time                 `json:"timestamp"`
+	Sessions     map[string]SMBSession     `json:"sessions"`      // By session ID
+	TreeConnects map[string]SMBTreeConnect `json:"tree_connects"` // By tree connection ID
+	OpenFiles    map[string]SMBOpenFile    `json:"open_files"`    // By path
+}
+
+// SMBSession is an authenticated client session
+type SMBSession struct {
+	SessionID        string `json:"session_id"`
+	Username         string `json:"username"`
+	GroupName        string `json:"group_name"`
+	UID              int    `json:"uid"`
+	GID              int    `json:"gid"`
+	RemoteMachine    string `json:"remote_machine"`
+	Hostname         string `json:"hostname"`
+	Dialect          string `json:"dialect"`
+	Encryption       string `json:"encryption"` // Degree, such as none, partial or full
+	EncryptionCipher string `json:"encryption_cipher"`
+	Signing          string `json:"signing"`
+	SigningCipher    string `json:"signing_cipher"`
+}
+
+// SMBTreeConnect is a session's connection to a share
+type SMBTreeConnect struct {
+	TconID      string    `json:"tcon_id"`
+	SessionID   string    `json:"session_id"`
+	Service     string    `json:"service"` // Share name
+	Machine     string    `json:"machine"`
+	ConnectedAt time.Time `json:"connected_at"`
+	Encryption  string    `json:"encryption"`
+	Signing     string    `json:"signing"`
+}
+
+// SMBOpenFile is a file opened through a share
+type SMBOpenFile struct {
+	ServicePath string             `json:"service_path"`
+	Filename    string             `json:"filename"`
+	Opens       map[string]SMBOpen `json:"opens"` // By open ID
+}
+
+// SMBOpen is one open of a file
+type SMBOpen struct {
+	UID        int       `json:"uid"`
+	OpenedAt   time.Time `json:"opened_at"`
+	ShareMode  string    `json:"share_mode"`  // Such as RW or RWD
+	AccessMask string    `json:"access_mask"` // Such as RW
+}
+
+// SMBStatusFormat is how smbstatus reports its state
+type SMBStatusFormat string
+
+const (
+	// SMBStatusJSON is the output of smbstatus -j, since Samba 4.16
+	SMBStatusJSON SMBStatusFormat = "json"
+	// SMBStatusText is the tabular output of older versions
+	SMBStatusText SMBStatusFormat = "text"
+)
+
+// SMBStatusProfile is how to run and parse smbstatus for a Samba version
+type SMBStatusProfile struct {
+	Format SMBStatusFormat `json:"format"`
+	Args   []string        `json:"args"`
+}
+
+var (
+	smbStatusJSONProfile = SMBStatusProfile{Format: SMBStatusJSON, Args: []string{"-j"}}
+	smbStatusTextProfile = SMBStatusProfile{Format: SMBStatusText}
+)
+
+// SMBStatusProfileFor returns the smbstatus profile of a Samba version.
+// Unknown versions get the JSON profile of current releases.
+func SMBStatusProfileFor(v Version) SMBStatusProfile {
+	if v.Known() && !v.AtLeast(4, 16) {
+		return smbStatusTextProfile
+	}
+	return smbStatusJSONProfile
+}
+
+// Parse parses smbstatus output run with the profile's arguments
+func (p SMBStatusProfile) Parse(data []byte) (*SMBStatus, error) {
+	if p.Format == SMBStatusText {
+		return ParseSMBStatusText(data)
+	}
+	return ParseSMBStatus(data)
+}
+
+// ParseSMBStatus parses smbstatus -j output, available since Samba 4.16.
+// Warnings smbstatus prints before the JSON are skipped. Fields are read
+// leniently: numbers may be strings, encryption and signing may be objects
+// or plain degrees, and missing sections are empty.
+func ParseSMBStatus(data []byte) (*SMBStatus, error) {
+	start := bytes.IndexByte(data, '{')
+	if start < 0 {
+		return nil, errors.New(errors.CommandOutputParse, "smbstatus output has no JSON object").
+			WithMetadata("output", truncate(string(data)))
+	}
+
+	var raw map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data[start:]))
+	dec.UseNumber()
+	if err := dec.Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, errors.CommandOutputParse).
+			WithMetadata("command", "smbstatus")
+	}
+
+	status := &SMBStatus{
+		Version:      ParseVersion(str(raw, "version")),
+		Timestamp:    smbTime(str(raw, "timestamp")),
+		Sessions:     make(map[string]SMBSession),
+		TreeConnects: make(map[string]SMBTreeConnect),
+		OpenFiles:    make(map[string]SMBOpenFile),
+	}
+
+	for id, v := range object(raw, "sessions") {
+		s, _ := v.(map[string]any)
+		session := SMBSession{
+			SessionID:     firstNonEmpty(str(s, "session_id"), id),
+			Username:      str(s, "username"),
+			GroupName:     str(s, "groupname", "group_name"),
+			UID:           integer(s, "uid"),
+			GID:           integer(s, "gid"),
+			RemoteMachine: str(s, "remote_machine"),
+			Hostname:      str(s, "hostname"),
+			Dialect:       str(s, "session_dialect", "dialect"),
+		}
+		session.Encryption, session.EncryptionCipher = protection(s, "encryption")
+		session.Signing, session.SigningCipher = protection(s, "signing")
+		status.Sessions[session.SessionID] = session
+	}
+
+	for id, v := range object(raw, "tcons") {
+		t, _ := v.(map[string]any)
+		tcon := SMBTreeConnect{
+			TconID:      firstNonEmpty(str(t, "tcon_id"), id),
+			SessionID:   str(t, "session_id"),
+			Service:     str(t, "service"),
+			Machine:     str(t, "machine"),
+			ConnectedAt: smbTime(str(t, "connected_at")),
+		}
+		tcon.Encryption, _ = protection(t, "encryption")
+		tcon.Signing, _ = protection(t, "signing")
+		status.TreeConnects[id] = tcon
+	}
+
+	for path, v := range object(raw, "open_files") {
+		f, _ := v.(map[string]any)
+		file := SMBOpenFile{
+			ServicePath: str(f, "service_path"),
+			Filename:    str(f, "filename"),
+			Opens:       make(map[string]SMBOpen),
+		}
+		for id, v := range object(f, "opens") {
+			o, _ := v.(map[string]any)
+			file.Opens[id] = SMBOpen{
+				UID:        integer(o, "uid"),
+				OpenedAt:   smbTime(str(o, "opened_at")),
+				ShareMode:  text(o, "sharemode"),
+				AccessMask: text(o, "access_mask"),
+			}
+		}
+		status.OpenFiles[path] = file
+	}
+
+	return status, nil
+}
+
+// smbTimeLayouts are the timestamp formats of smbstatus across versions
+var smbTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999-0700",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05.999999999 -0700",
+	time.ANSIC,
+}
+
+// smbTime parses an smbstatus timestamp, or returns the zero time
+func smbTime(s string) time.Time {
+	for _, layout := range smbTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// protection returns the degree and cipher of an encryption or signing
+// field, which is an object since Samba 4.16 and a plain degree in some
+// builds
+func protection(m map[string]any, key string) (degree, cipher string) {
+	if obj, ok := m[key].(map[string]any); ok {
+		return str(obj, "degree"), str(obj, "cipher")
+	}
+	return str(m, key), ""
+}
+
+// text returns a flag set's text, such as the sharemode and access_mask
+// objects of an open, or the field itself when it is a string
+func text(m map[string]any, key string) string {
+	if obj, ok := m[key].(map[string]any); ok {
+		return str(obj, "text")
+	}
+	return str(m, key)
+}
+
+// object returns the object at key, or nil when it is missing or another
+// type
+func object(m map[string]any, key string) map[string]any {
+	obj, _ := m[key].(map[string]any)
+	return obj
+}
+
+// str returns the first of keys present in m as a string, formatting
+// numbers and booleans
+func str(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case string:
+			return v
+		case json.Number:
+			return v.String()
+		case bool:
+			return strconv.FormatBool(v)
+		}
+	}
+	return ""
+}
+
+// integer returns the first of keys present in m as an int, parsing
+// strings, or 0 when it is missing or not a number
+func integer(m map[string]any, keys ...string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(str(m, keys...)))
+	return n
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// truncate shortens output kept in error metadata
+func truncate(s string) string {
+	const limit = 256
+	if len(s) > limit {
+		return s[:limit] + "..."
+	}
+	return s
+}
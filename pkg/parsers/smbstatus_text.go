@@ -0,0 +1,252 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+var (
+	// textColumn matches a column title, whose words are one space apart
+	textColumn = regexp.MustCompile(`\S+(?: \S+)*`)
+	// textGap separates the path, name and time of a locked file
+	textGap = regexp.MustCompile(`\s{2,}`)
+	// textProtection matches a degree with its cipher, as in partial(AES-128-CMAC)
+	textProtection = regexp.MustCompile(`^([^(]+)\(([^)]*)\)$`)
+)
+
+// smbTextTimeLayouts are the times of the tabular output, which follow the
+// locale
+var smbTextTimeLayouts = []string{
+	"Mon Jan _2 03:04:05 PM 2006 MST",
+	"Mon Jan _2 15:04:05 2006 MST",
+	"Mon Jan _2 15:04:05 2006",
+	time.ANSIC,
+}
+
+// ParseSMBStatusText parses the tabular output of smbstatus before Samba
+// 4.16, which has sections of sessions, connected services and locked
+// files. It has no session IDs, so sessions are keyed by the PID of their
+// smbd, as are the tree connections and opens of the session. Columns are
+// cut at the offsets of their titles, adjusted for values wider than their
+// column.
+func ParseSMBStatusText(data []byte) (*SMBStatus, error) {
+	status := &SMBStatus{
+		Sessions:     make(map[string]SMBSession),
+		TreeConnects: make(map[string]SMBTreeConnect),
+		OpenFiles:    make(map[string]SMBOpenFile),
+	}
+
+	var section string
+	var header []textHeader
+	sections := 0
+	for line := range strings.SplitSeq(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			continue
+		case strings.HasPrefix(trimmed, "Samba version"):
+			status.Version = ParseVersion(trimmed)
+			continue
+		case strings.HasPrefix(trimmed, "---"):
+			continue
+		case strings.HasPrefix(trimmed, "PID") && strings.Contains(trimmed, "Username"):
+			section, header = "sessions", textHeaders(line)
+			sections++
+			continue
+		case strings.HasPrefix(trimmed, "Service") && strings.Contains(trimmed, "Machine"):
+			section, header = "services", textHeaders(line)
+			sections++
+			continue
+		case strings.HasPrefix(trimmed, "Locked files"):
+			section = "locks"
+			sections++
+			continue
+		case strings.HasPrefix(trimmed, "Pid") && strings.Contains(trimmed, "DenyMode"):
+			continue
+		case strings.HasPrefix(trimmed, "No locked files"):
+			continue
+		}
+
+		switch section {
+		case "sessions":
+			row := textRow(line, header)
+			pid := row["PID"]
+			machine, hostname, _ := strings.Cut(row["Machine"], " ")
+			session := SMBSession{
+				SessionID:     pid,
+				Username:      row["Username"],
+				GroupName:     row["Group"],
+				RemoteMachine: machine,
+				Hostname:      strings.Trim(hostname, "() "),
+				Dialect:       row["Protocol Version"],
+			}
+			session.Encryption, session.EncryptionCipher = textProtectionOf(row["Encryption"])
+			session.Signing, session.SigningCipher = textProtectionOf(row["Signing"])
+			status.Sessions[pid] = session
+		case "services":
+			row := textRow(line, header)
+			tcon := SMBTreeConnect{
+				TconID:      row["pid"] + "/" + row["Service"],
+				SessionID:   row["pid"],
+				Service:     row["Service"],
+				Machine:     row["Machine"],
+				ConnectedAt: textTime(row["Connected at"]),
+			}
+			tcon.Encryption, _ = textProtectionOf(row["Encryption"])
+			tcon.Signing, _ = textProtectionOf(row["Signing"])
+			status.TreeConnects[tcon.TconID] = tcon
+		case "locks":
+			parseTextLock(status, trimmed)
+		}
+	}
+
+	if sections == 0 {
+		return nil, errors.New(errors.CommandOutputParse, "smbstatus output has no known sections").
+			WithMetadata("output", truncate(string(data)))
+	}
+	return status, nil
+}
+
+// parseTextLock adds a locked file line: pid, uid, deny mode, access, R/W
+// and oplock, then the share path, name and time, which may contain single
+// spaces and are two or more spaces apart
+func parseTextLock(status *SMBStatus, line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 7 {
+		return
+	}
+	rest := line
+	for range 6 {
+		rest = strings.TrimLeft(rest, " \t")
+		rest = rest[strings.IndexAny(rest+" ", " \t"):]
+	}
+	tail := textGap.Split(strings.TrimSpace(rest), -1)
+	servicePath, filename, opened := tail[0], "", ""
+	if len(tail) > 1 {
+		filename = tail[1]
+	}
+	if len(tail) > 2 {
+		opened = tail[2]
+	}
+
+	path := strings.TrimSuffix(servicePath, "/") + "/" + filename
+	file, ok := status.OpenFiles[path]
+	if !ok {
+		file = SMBOpenFile{ServicePath: servicePath, Filename: filename, Opens: make(map[string]SMBOpen)}
+	}
+	uid, _ := strconv.Atoi(fields[1])
+	file.Opens[fields[0]+"/"+strconv.Itoa(len(file.Opens))] = SMBOpen{
+		UID:        uid,
+		OpenedAt:   textTime(opened),
+		ShareMode:  textOr(textShareModes, fields[2]),
+		AccessMask: textOr(textAccess, fields[4]),
+	}
+	status.OpenFiles[path] = file
+}
+
+// textShareModes and textAccess map the deny modes and R/W of locked files
+// to the sharing and access flags of smbstatus -j
+var (
+	textShareModes = map[string]string{
+		"DENY_NONE":  "RWD",
+		"DENY_READ":  "W",
+		"DENY_WRITE": "R",
+		"DENY_ALL":   "",
+	}
+	textAccess = map[string]string{
+		"RDONLY": "R",
+		"WRONLY": "W",
+		"RDWR":   "RW",
+	}
+)
+
+// textOr returns the mapping of s, or s itself when it has none
+func textOr(mapping map[string]string, s string) string {
+	if v, ok := mapping[s]; ok {
+		return v
+	}
+	return s
+}
+
+type textHeader struct {
+	title string
+	start int
+}
+
+// textHeaders returns the titles of a header line and where they start
+func textHeaders(line string) []textHeader {
+	var headers []textHeader
+	for _, loc := range textColumn.FindAllStringIndex(line, -1) {
+		headers = append(headers, textHeader{title: line[loc[0]:loc[1]], start: loc[0]})
+	}
+	return headers
+}
+
+// textRow cuts a line at the offsets of the header's titles. A value wider
+// than its column pushes the following ones right, so a cut that would
+// split a word is moved past it, and pushed values end at a gap of two
+// spaces.
+func textRow(line string, headers []textHeader) map[string]string {
+	row := make(map[string]string, len(headers))
+	pos := 0
+	for i, h := range headers {
+		// A value starts where the previous one ended, which is the
+		// column's offset unless a value was pushed
+		for pos < len(line) && line[pos] == ' ' {
+			pos++
+		}
+		if pos >= len(line) {
+			break
+		}
+		end := len(line)
+		if i+1 < len(headers) {
+			if headers[i+1].start < end {
+				end = max(headers[i+1].start, pos+1)
+				for end < len(line) && line[end-1] != ' ' && line[end] != ' ' {
+					end++
+				}
+			}
+			// Offsets of a pushed row are off; its values end at a gap of
+			// two spaces instead
+			if pos != h.start {
+				if gap := strings.Index(line[pos:end], "  "); gap > 0 {
+					end = pos + gap
+				}
+			}
+		}
+		row[h.title] = strings.TrimSpace(line[pos:end])
+		pos = end
+	}
+	return row
+}
+
+// textProtectionOf splits partial(AES-128-CMAC) into its degree and
+// cipher; "-" is no protection
+func textProtectionOf(s string) (degree, cipher string) {
+	if s == "" || s == "-" {
+		return "none", ""
+	}
+	if m := textProtection.FindStringSubmatch(s); m != nil {
+		return m[1], m[2]
+	}
+	return s, ""
+}
+
+func textTime(s string) time.Time {
+	s = strings.Join(strings.Fields(s), " ")
+	for _, layout := range smbTextTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
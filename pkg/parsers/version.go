@@ -0,0 +1,59 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package parsers parses the output of external tools and kernel interfaces
+// whose formats drift across versions: smbstatus JSON, zfs list and /proc
+// files. Parsers are defensive: fields that are missing, renamed or typed
+// differently by a version degrade to zero values instead of failing the
+// whole parse, and only output that cannot be read at all is an error.
+package parsers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// versionPattern matches the first dotted version in tool output, such as
+// "Version 4.19.5-Ubuntu" or "zfs-2.2.2-0ubuntu9"
+var versionPattern = regexp.MustCompile(`(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// Version is the version of a tool whose output is parsed
+type Version struct {
+	Major int    `json:"major"`
+	Minor int    `json:"minor"`
+	Patch int    `json:"patch"`
+	Raw   string `json:"raw,omitempty"` // As reported by the tool
+}
+
+// ParseVersion returns the first dotted version in s. Unknown versions are
+// the zero Version, which is older than any other.
+func ParseVersion(s string) Version {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{Raw: s}
+	}
+	v := Version{Raw: s}
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v
+}
+
+// Known reports whether a version was found
+func (v Version) Known() bool {
+	return v.Major != 0 || v.Minor != 0 || v.Patch != 0
+}
+
+// AtLeast reports whether v is major.minor or later
+func (v Version) AtLeast(major, minor int) bool {
+	return v.Major > major || v.Major == major && v.Minor >= minor
+}
+
+// String returns the version as major.minor.patch
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
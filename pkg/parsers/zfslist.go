@@ -0,0 +1,73 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package parsers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ZFSListRow is a line of zfs list -H (or zfs get -H) output, by the
+// columns requested with -o
+type ZFSListRow map[string]string
+
+// ParseZFSList parses zfs list -H output for the columns given to -o, in
+// order. Lines are split on tabs only, since values such as user properties
+// may contain spaces. Blank lines are skipped. Lines with fewer fields than
+// columns, as from a version that does not know a property, keep the
+// fields they have and leave the others missing.
+func ParseZFSList(output string, columns ...string) []ZFSListRow {
+	var rows []ZFSListRow
+	for line := range strings.SplitSeq(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		row := make(ZFSListRow, len(columns))
+		for i, column := range columns {
+			if i < len(fields) {
+				row[column] = fields[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// Get returns the value of a column. Missing columns and unset values,
+// which zfs prints as "-", are not ok.
+func (r ZFSListRow) Get(column string) (string, bool) {
+	v, ok := r[column]
+	if !ok || v == "-" || v == "" {
+		return "", false
+	}
+	return v, true
+}
+
+// Uint returns the value of a column listed with -p as a number
+func (r ZFSListRow) Uint(column string) (uint64, bool) {
+	v, ok := r.Get(column)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	return n, err == nil
+}
+
+// Time returns the value of a timestamp column listed with -p, such as
+// creation, as a time
+func (r ZFSListRow) Time(column string) (time.Time, bool) {
+	v, ok := r.Get(column)
+	if !ok {
+		return time.Time{}, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(n, 0), true
+}
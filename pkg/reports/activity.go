@@ -0,0 +1,140 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	activityFile = "report-activity.json"
+
+	// activityRetention covers a weekly digest, with a day to spare for
+	// digests sent late
+	activityRetention = 8 * 24 * time.Hour
+)
+
+// activity is what happened between digests
+type activity struct {
+	Snapshots   []SnapshotRun      `json:"snapshots"`
+	Transfers   []TransferRun      `json:"transfers"`
+	Scans       []ScanOutcome      `json:"scans"`
+	DiskChanges []DiskHealthChange `json:"disk_changes"`
+}
+
+// record is an entry of the activity log
+type record interface {
+	when() time.Time
+}
+
+// recorder keeps the activity log, in the config directory so a restart does
+// not lose the day
+type recorder struct {
+	logger logger.Logger
+	path   string
+
+	mu   sync.Mutex
+	data activity
+}
+
+// newRecorder creates a recorder keeping its log in configDir
+func newRecorder(l logger.Logger, configDir string) *recorder {
+	r := &recorder{
+		logger: l,
+		path:   filepath.Join(configDir, activityFile),
+	}
+	if err := r.load(); err != nil {
+		l.Warn("Failed to load report activity, starting a new log", "error", err)
+	}
+	return r
+}
+
+// add changes the log, drops entries past the retention and saves it
+func (r *recorder) add(fn func(a *activity)) {
+	r.mu.Lock()
+	fn(&r.data)
+	cutoff := time.Now().Add(-activityRetention)
+	r.data.Snapshots = since(r.data.Snapshots, cutoff)
+	r.data.Transfers = since(r.data.Transfers, cutoff)
+	r.data.Scans = since(r.data.Scans, cutoff)
+	r.data.DiskChanges = since(r.data.DiskChanges, cutoff)
+	data, err := json.Marshal(r.data)
+	r.mu.Unlock()
+
+	if err != nil {
+		r.logger.Warn("Failed to encode report activity", "error", err)
+		return
+	}
+	if r.path == "" {
+		return
+	}
+	if err := os.WriteFile(r.path, data, 0644); err != nil {
+		r.logger.Warn("Failed to save report activity", "path", r.path, "error", err)
+	}
+}
+
+// between returns the activity from from, inclusive, to to, exclusive
+func (r *recorder) between(from, to time.Time) activity {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return activity{
+		Snapshots:   within(r.data.Snapshots, from, to),
+		Transfers:   within(r.data.Transfers, from, to),
+		Scans:       within(r.data.Scans, from, to),
+		DiskChanges: within(r.data.DiskChanges, from, to),
+	}
+}
+
+// load reads the kept log
+func (r *recorder) load() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, errors.ConfigLoadFailed).WithMetadata("path", r.path)
+	}
+
+	var a activity
+	if err := json.Unmarshal(data, &a); err != nil {
+		return errors.Wrap(err, errors.ConfigUnmarshalFailed).WithMetadata("path", r.path)
+	}
+
+	r.mu.Lock()
+	r.data = a
+	r.mu.Unlock()
+	return nil
+}
+
+// since drops the records older than cutoff
+func since[T record](records []T, cutoff time.Time) []T {
+	return slices.DeleteFunc(records, func(rec T) bool {
+		return rec.when().Before(cutoff)
+	})
+}
+
+// within returns a copy of the records from from, inclusive, to to, exclusive
+func within[T record](records []T, from, to time.Time) []T {
+	var out []T
+	for _, rec := range records {
+		at := rec.when()
+		if !at.Before(from) && at.Before(to) {
+			out = append(out, rec)
+		}
+	}
+	return out
+}
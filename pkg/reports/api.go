@@ -0,0 +1,147 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	stderrors "errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler handles HTTP requests for previewing and sending digests
+type APIHandler struct {
+	service *Service
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewAPIHandler creates a new reports API handler
+func NewAPIHandler(service *Service) *APIHandler {
+	return &APIHandler{
+		service: service,
+	}
+}
+
+// RegisterRoutes registers HTTP routes for digests
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	digests := router.Group("/digests")
+	{
+		digests.GET("", h.listDigests)
+		digests.GET("/:name/preview", h.previewDigest)
+		digests.POST("/:name/send", h.sendDigest)
+	}
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *APIHandler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *APIHandler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	var rodentErr *errors.RodentError
+	if stderrors.As(err, &rodentErr) {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  string(errors.DomainReports),
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// listDigests lists the configured digests
+func (h *APIHandler) listDigests(c *gin.Context) {
+	schedules := h.service.Schedules()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"digests": schedules,
+		"count":   len(schedules),
+	})
+}
+
+// previewDigest builds a digest of the period ending now without sending
+// it. ?format=html or ?format=text return the email body instead of JSON.
+func (h *APIHandler) previewDigest(c *gin.Context) {
+	digest, err := h.service.Build(c.Request.Context(), c.Param("name"), time.Now())
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	switch Format(c.Query("format")) {
+	case FormatHTML:
+		body, err := RenderHTML(digest)
+		if err != nil {
+			h.sendError(c, errors.Wrap(err, errors.ServerInternalError))
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(body))
+	case FormatText:
+		body, err := RenderText(digest)
+		if err != nil {
+			h.sendError(c, errors.Wrap(err, errors.ServerInternalError))
+			return
+		}
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(body))
+	default:
+		h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+			"subject": Subject(digest),
+			"digest":  digest,
+		})
+	}
+}
+
+// sendDigest sends a digest of the period ending now to its recipients
+func (h *APIHandler) sendDigest(c *gin.Context) {
+	digest, err := h.service.Send(c.Request.Context(), c.Param("name"), time.Now())
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"digest":  c.Param("name"),
+		"subject": Subject(digest),
+		"issues":  len(digest.Issues),
+		"sent":    true,
+	})
+}
@@ -0,0 +1,253 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+)
+
+// maxTransferFailures bounds the failed transfers listed in a digest; all
+// are counted
+const maxTransferFailures = 20
+
+// build returns a digest of the period ending at to. Sections whose source
+// is not available, such as lag without transfer policies, are left empty.
+func (s *Service) build(ctx context.Context, sched Schedule, to time.Time) *Digest {
+	from := to.Add(-sched.Period.Duration())
+	d := &Digest{
+		Name:   sched.Name,
+		Host:   s.host,
+		Period: sched.Period,
+		From:   from,
+		To:     to,
+	}
+
+	a := s.recorder.between(from, to)
+	d.Snapshots = summarizeSnapshots(a.Snapshots)
+	d.Transfers = summarizeTransfers(a.Transfers)
+	d.Scans = a.Scans
+	d.DiskChanges = a.DiskChanges
+
+	s.addSnapshotPolicies(d)
+	s.addLag(d)
+	s.addCapacity(ctx, d)
+
+	d.Issues = issues(d)
+	return d
+}
+
+// summarizeSnapshots counts created and pruned snapshots, in total and by
+// policy
+func summarizeSnapshots(runs []SnapshotRun) SnapshotSummary {
+	var sum SnapshotSummary
+	byPolicy := make(map[string]*PolicyRuns)
+	for _, run := range runs {
+		sum.Created++
+		sum.Pruned += run.Pruned
+
+		p, ok := byPolicy[run.PolicyID]
+		if !ok {
+			p = &PolicyRuns{PolicyID: run.PolicyID, Dataset: run.Dataset}
+			byPolicy[run.PolicyID] = p
+		}
+		p.Created++
+		p.Pruned += run.Pruned
+	}
+
+	for _, p := range byPolicy {
+		sum.Policies = append(sum.Policies, *p)
+	}
+	slices.SortFunc(sum.Policies, func(a, b PolicyRuns) int {
+		return cmp.Compare(a.Dataset, b.Dataset)
+	})
+	return sum
+}
+
+// summarizeTransfers counts finished transfers by outcome and lists the
+// latest failures
+func summarizeTransfers(runs []TransferRun) TransferSummary {
+	var sum TransferSummary
+	for _, run := range runs {
+		switch dataset.TransferStatus(run.Status) {
+		case dataset.TransferStatusCompleted:
+			sum.Completed++
+			sum.Bytes += run.Bytes
+		case dataset.TransferStatusFailed:
+			sum.Failed++
+			sum.Failures = append(sum.Failures, run)
+		case dataset.TransferStatusCancelled:
+			sum.Cancelled++
+		}
+	}
+	if len(sum.Failures) > maxTransferFailures {
+		sum.Failures = sum.Failures[len(sum.Failures)-maxTransferFailures:]
+	}
+	return sum
+}
+
+// addSnapshotPolicies names the policies in the snapshot summary and adds
+// those whose last run in the period failed
+func (s *Service) addSnapshotPolicies(d *Digest) {
+	m := s.snapshots.Load()
+	if m == nil {
+		return
+	}
+	policies, err := m.ListPolicies()
+	if err != nil {
+		s.logger.Warn("Failed to list snapshot policies for digest", "error", err)
+		return
+	}
+
+	names := make(map[string]string, len(policies))
+	for _, p := range policies {
+		names[p.ID] = p.Name
+	}
+	for i := range d.Snapshots.Policies {
+		d.Snapshots.Policies[i].Name = names[d.Snapshots.Policies[i].PolicyID]
+	}
+
+	for id, monitor := range m.Monitors() {
+		if monitor.LastError == "" || monitor.LastRunAt.Before(d.From) || !monitor.LastRunAt.Before(d.To) {
+			continue
+		}
+		d.Snapshots.Failing = append(d.Snapshots.Failing, PolicyFailure{
+			PolicyID: id,
+			Name:     names[id],
+			At:       monitor.LastRunAt,
+			Error:    monitor.LastError,
+		})
+	}
+	slices.SortFunc(d.Snapshots.Failing, func(a, b PolicyFailure) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// addLag reports the replication lag of enabled transfer policies
+func (s *Service) addLag(d *Digest) {
+	m := s.transferPolicies.Load()
+	if m == nil {
+		return
+	}
+	policies, err := m.ListPolicies()
+	if err != nil {
+		s.logger.Warn("Failed to list transfer policies for digest", "error", err)
+		return
+	}
+
+	for _, p := range policies {
+		if !p.Enabled || p.MonitorStatus == nil {
+			continue
+		}
+		mon := p.MonitorStatus
+		if mon.LagCheckedAt == nil && mon.LastError == "" {
+			continue
+		}
+		d.Lag = append(d.Lag, PolicyLag{
+			PolicyID:           p.ID,
+			Name:               p.Name,
+			Lag:                mon.ReplicationLag,
+			RPOTarget:          p.RPOTarget,
+			RPOBreached:        mon.RPOBreached,
+			LastCommonSnapshot: mon.LastCommonSnapshot,
+			LastError:          mon.LastError,
+		})
+	}
+	slices.SortFunc(d.Lag, func(a, b PolicyLag) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+}
+
+// addCapacity reports the usage and trends of every pool
+func (s *Service) addCapacity(ctx context.Context, d *Digest) {
+	cm := s.capacity.Load()
+	if cm == nil {
+		return
+	}
+	analytics, err := cm.AllAnalytics(ctx)
+	if err != nil {
+		s.logger.Warn("Failed to read pool capacity for digest", "error", err)
+		return
+	}
+
+	for _, a := range analytics {
+		d.Capacity = append(d.Capacity, PoolCapacity{
+			Pool:                a.Pool,
+			Capacity:            a.Current.Capacity,
+			Level:               a.Level,
+			GrowthBytesPerDay:   a.GrowthBytesPerDay,
+			DaysUntilFull:       a.DaysUntilFull,
+			Fragmentation:       a.Current.Fragmentation,
+			FragmentationRising: a.FragmentationRising,
+		})
+	}
+}
+
+// issues lists what in the digest needs attention
+func issues(d *Digest) []string {
+	var out []string
+	for _, f := range d.Snapshots.Failing {
+		out = append(out, fmt.Sprintf("Snapshot policy %s is failing: %s", nameOr(f.Name, f.PolicyID), f.Error))
+	}
+	if d.Transfers.Failed > 0 {
+		out = append(out, fmt.Sprintf("%d transfers failed", d.Transfers.Failed))
+	}
+	for _, l := range d.Lag {
+		if l.RPOBreached {
+			out = append(out, fmt.Sprintf("Transfer policy %s is %s behind, past its RPO of %s",
+				l.Name, formatDuration(l.Lag), formatDuration(l.RPOTarget)))
+		}
+	}
+	for _, scan := range d.Scans {
+		if scan.Errors > 0 {
+			out = append(out, fmt.Sprintf("Pool %s %s found %d errors", scan.Pool, scan.Function, scan.Errors))
+		} else if scan.State == pool.ScanStateCanceled {
+			out = append(out, fmt.Sprintf("Pool %s %s was canceled", scan.Pool, scan.Function))
+		}
+	}
+	for _, c := range d.DiskChanges {
+		if healthRank(c.To) > healthRank(c.From) {
+			out = append(out, fmt.Sprintf("Disk %s went from %s to %s", nameOr(c.Path, c.DeviceID), c.From, c.To))
+		}
+	}
+	for _, c := range d.Capacity {
+		if c.Level != pool.CapacityLevelOK {
+			out = append(out, fmt.Sprintf("Pool %s is %d%% full", c.Pool, c.Capacity))
+		}
+		if c.DaysUntilFull != nil && *c.DaysUntilFull < 30 {
+			out = append(out, fmt.Sprintf("Pool %s fills in %.0f days at its current growth", c.Pool, *c.DaysUntilFull))
+		}
+	}
+	return out
+}
+
+// healthRank orders disk health from best to worst
+func healthRank(health string) int {
+	switch types.HealthStatus(health) {
+	case types.HealthHealthy:
+		return 1
+	case types.HealthWarning:
+		return 2
+	case types.HealthCritical:
+		return 3
+	case types.HealthFailed:
+		return 4
+	}
+	return 0
+}
+
+func nameOr(name, fallback string) string {
+	if name != "" {
+		return name
+	}
+	return fallback
+}
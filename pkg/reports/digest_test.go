@@ -0,0 +1,192 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	s, err := ParseSchedule(config.ReportDigest{
+		Name:       "weekly",
+		Period:     "Weekly",
+		Weekday:    "fri",
+		Recipients: []string{"Storage Team <storage@example.com>"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, PeriodWeekly, s.Period)
+	assert.Equal(t, "friday", s.Weekday)
+	assert.Equal(t, time.Friday, s.weekday)
+	assert.Equal(t, defaultAt, s.At)
+	assert.Equal(t, FormatHTML, s.Format)
+	assert.Equal(t, []string{"storage@example.com"}, s.Recipients)
+
+	for name, cfg := range map[string]config.ReportDigest{
+		"no name":       {Webhook: true},
+		"bad period":    {Name: "d", Period: "hourly", Webhook: true},
+		"bad time":      {Name: "d", At: "7am", Webhook: true},
+		"bad weekday":   {Name: "d", Period: "weekly", Weekday: "someday", Webhook: true},
+		"bad format":    {Name: "d", Format: "pdf", Webhook: true},
+		"bad recipient": {Name: "d", Recipients: []string{"not an address"}},
+		"no delivery":   {Name: "d"},
+	} {
+		_, err := ParseSchedule(cfg)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRecorderBetween(t *testing.T) {
+	dir := t.TempDir()
+	r := newRecorder(common.Log, dir)
+
+	now := time.Now()
+	r.add(func(a *activity) {
+		a.Snapshots = append(a.Snapshots,
+			SnapshotRun{At: now.Add(-2 * time.Hour), PolicyID: "p1"},
+			SnapshotRun{At: now.Add(-30 * time.Hour), PolicyID: "p1"},
+			SnapshotRun{At: now.Add(-10 * 24 * time.Hour), PolicyID: "p1"}, // Past retention
+		)
+	})
+
+	got := r.between(now.Add(-24*time.Hour), now)
+	assert.Len(t, got.Snapshots, 1)
+
+	// The log survives a restart
+	reloaded := newRecorder(common.Log, dir)
+	assert.Len(t, reloaded.between(now.Add(-PeriodWeekly.Duration()), now).Snapshots, 2)
+}
+
+func newTestService(t *testing.T, schedules ...Schedule) *Service {
+	t.Helper()
+	return NewService(common.Log, config.ReportSMTP{
+		Host: "mail.example.com",
+		From: "Rodent <rodent@example.com>",
+	}, schedules, t.TempDir())
+}
+
+func TestBuildDigest(t *testing.T) {
+	sched, err := ParseSchedule(config.ReportDigest{Name: "nightly", Webhook: true})
+	require.NoError(t, err)
+	s := newTestService(t, sched)
+
+	now := time.Now()
+	s.recorder.add(func(a *activity) {
+		a.Snapshots = append(a.Snapshots,
+			SnapshotRun{At: now.Add(-time.Hour), PolicyID: "p1", Dataset: "tank/a", Pruned: 2},
+			SnapshotRun{At: now.Add(-2 * time.Hour), PolicyID: "p1", Dataset: "tank/a", Pruned: 1},
+			SnapshotRun{At: now.Add(-3 * time.Hour), PolicyID: "p2", Dataset: "tank/b"},
+		)
+		a.Transfers = append(a.Transfers,
+			TransferRun{At: now.Add(-time.Hour), Status: "completed", Bytes: 4096},
+			TransferRun{At: now.Add(-time.Hour), Status: "failed", Snapshot: "tank/a@s1",
+				Error: "connection refused", ErrorCategory: "network"},
+		)
+		a.Scans = append(a.Scans, ScanOutcome{
+			At: now.Add(-time.Hour), Pool: "tank", Function: "scrub",
+			State: pool.ScanStateFinished, Errors: 3,
+		})
+		a.DiskChanges = append(a.DiskChanges,
+			DiskHealthChange{At: now.Add(-time.Hour), DeviceID: "d1", From: "HEALTHY", To: "WARNING"},
+			DiskHealthChange{At: now.Add(-time.Hour), DeviceID: "d2", From: "WARNING", To: "HEALTHY"},
+		)
+	})
+
+	d, err := s.Build(context.Background(), "nightly", now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, d.Snapshots.Created)
+	assert.Equal(t, 3, d.Snapshots.Pruned)
+	require.Len(t, d.Snapshots.Policies, 2)
+	assert.Equal(t, "tank/a", d.Snapshots.Policies[0].Dataset)
+	assert.Equal(t, 2, d.Snapshots.Policies[0].Created)
+	assert.Equal(t, 1, d.Transfers.Completed)
+	assert.Equal(t, int64(4096), d.Transfers.Bytes)
+	assert.Equal(t, 1, d.Transfers.Failed)
+	require.Len(t, d.Transfers.Failures, 1)
+
+	// A failed transfer, a scrub with errors and a worsening disk; the
+	// recovering disk is not an issue
+	assert.Len(t, d.Issues, 3)
+
+	_, err = s.Build(context.Background(), "missing", now)
+	var rerr *errors.RodentError
+	require.ErrorAs(t, err, &rerr)
+	assert.Equal(t, errors.ErrorCode(errors.ReportNotFound), rerr.Code)
+}
+
+func TestRenderDigest(t *testing.T) {
+	days := 12.0
+	d := &Digest{
+		Name:      "nightly",
+		Host:      "nas1",
+		Period:    PeriodDaily,
+		From:      time.Date(2025, 3, 1, 7, 0, 0, 0, time.UTC),
+		To:        time.Date(2025, 3, 2, 7, 0, 0, 0, time.UTC),
+		Issues:    []string{"Pool tank fills in 12 days at its current growth"},
+		Transfers: TransferSummary{Completed: 2, Bytes: 3 << 30},
+		Lag:       []PolicyLag{{Name: "offsite", Lag: 26 * time.Hour, RPOTarget: 24 * time.Hour, RPOBreached: true}},
+		Capacity:  []PoolCapacity{{Pool: "tank", Capacity: 71, Level: "ok", DaysUntilFull: &days}},
+	}
+
+	assert.Equal(t, "[nas1] daily digest for 2025-03-02: 1 issue", Subject(d))
+
+	text, err := RenderText(d)
+	require.NoError(t, err)
+	assert.Contains(t, text, "NEEDS ATTENTION")
+	assert.Contains(t, text, "2 completed (3.0G)")
+	assert.Contains(t, text, "offsite: 1d2h behind (RPO 1d, BREACHED)")
+	assert.Contains(t, text, "full in 12 days")
+
+	html, err := RenderHTML(d)
+	require.NoError(t, err)
+	assert.Contains(t, html, "<h3 class=\"bad\">Needs attention</h3>")
+	assert.Contains(t, html, "<td>offsite</td>")
+}
+
+func TestComposeMessage(t *testing.T) {
+	d := &Digest{Host: "nas1", Period: PeriodDaily, To: time.Now()}
+	to := []string{"a@example.com", "b@example.com"}
+
+	msg, err := composeMessage("rodent@example.com", to, d, FormatText)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "To: a@example.com, b@example.com\r\n")
+	assert.Contains(t, string(msg), "Content-Type: text/plain; charset=utf-8\r\n")
+	assert.Contains(t, string(msg), "Nothing needs attention.")
+
+	msg, err = composeMessage("rodent@example.com", to, d, FormatHTML)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "Content-Type: multipart/alternative; boundary=")
+	assert.Equal(t, 2, strings.Count(string(msg), "Content-Type: text/"))
+}
+
+func TestSendDigest(t *testing.T) {
+	sched, err := ParseSchedule(config.ReportDigest{
+		Name:       "nightly",
+		Recipients: []string{"ops@example.com"},
+	})
+	require.NoError(t, err)
+	s := newTestService(t, sched)
+
+	var sentTo []string
+	orig := sendMail
+	sendMail = func(_ context.Context, _ config.ReportSMTP, to []string, _ []byte) error {
+		sentTo = to
+		return nil
+	}
+	defer func() { sendMail = orig }()
+
+	_, err = s.Send(context.Background(), "nightly", time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops@example.com"}, sentTo)
+}
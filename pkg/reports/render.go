@@ -0,0 +1,195 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+)
+
+// Subject returns the email subject of a digest
+func Subject(d *Digest) string {
+	status := "all OK"
+	if n := len(d.Issues); n == 1 {
+		status = "1 issue"
+	} else if n > 1 {
+		status = fmt.Sprintf("%d issues", n)
+	}
+	return fmt.Sprintf("[%s] %s digest for %s: %s",
+		d.Host, d.Period, d.To.Format("2006-01-02"), status)
+}
+
+// RenderText renders a digest as plain text
+func RenderText(d *Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := textTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderHTML renders a digest as an HTML document
+func RenderHTML(d *Digest) (string, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, d); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+var templateFuncs = map[string]any{
+	"bytes":    formatBytes,
+	"duration": formatDuration,
+	"time":     func(t time.Time) string { return t.Local().Format("2006-01-02 15:04") },
+	"days": func(d *float64) string {
+		if d == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.0f", *d)
+	},
+	"upper": strings.ToUpper,
+}
+
+var textTemplate = template.Must(template.New("digest").Funcs(templateFuncs).Parse(
+	`Rodent {{.Period}} digest for {{.Host}}
+{{time .From}} to {{time .To}}
+
+{{if .Issues}}NEEDS ATTENTION
+{{range .Issues}}  - {{.}}
+{{end}}{{else}}Nothing needs attention.
+{{end}}
+SNAPSHOTS
+  {{.Snapshots.Created}} created, {{.Snapshots.Pruned}} pruned
+{{range .Snapshots.Policies}}  {{.Dataset}}{{if .Name}} ({{.Name}}){{end}}: {{.Created}} created, {{.Pruned}} pruned
+{{end}}{{range .Snapshots.Failing}}  FAILING {{if .Name}}{{.Name}}{{else}}{{.PolicyID}}{{end}} at {{time .At}}: {{.Error}}
+{{end}}
+TRANSFERS
+  {{.Transfers.Completed}} completed ({{bytes .Transfers.Bytes}}), {{.Transfers.Failed}} failed, {{.Transfers.Cancelled}} cancelled
+{{range .Transfers.Failures}}  FAILED {{.Snapshot}} to {{if .RemoteHost}}{{.RemoteHost}}:{{end}}{{.Target}} at {{time .At}}{{if .ErrorCategory}} [{{.ErrorCategory}}]{{end}}: {{.Error}}
+{{end}}{{if .Lag}}
+REPLICATION LAG
+{{range .Lag}}  {{.Name}}: {{duration .Lag}} behind{{if .RPOTarget}} (RPO {{duration .RPOTarget}}{{if .RPOBreached}}, BREACHED{{end}}){{end}}{{if .LastError}}, last run failed: {{.LastError}}{{end}}
+{{end}}{{end}}{{if .Scans}}
+SCRUBS AND RESILVERS
+{{range .Scans}}  {{.Pool}} {{.Function}} {{.State}} at {{time .At}}: {{.Errors}} errors, {{bytes .RepairedBytes}} repaired
+{{end}}{{end}}{{if .DiskChanges}}
+DISK HEALTH
+{{range .DiskChanges}}  {{if .Path}}{{.Path}}{{else}}{{.DeviceID}}{{end}}{{if .Model}} ({{.Model}}){{end}}: {{.From}} to {{.To}} at {{time .At}}{{if .Reason}}: {{.Reason}}{{end}}
+{{end}}{{end}}{{if .Capacity}}
+CAPACITY
+{{range .Capacity}}  {{.Pool}}: {{.Capacity}}% used ({{.Level}}), {{bytes .GrowthBytesPerDay}}/day, full in {{days .DaysUntilFull}} days, {{.Fragmentation}}% fragmented{{if .FragmentationRising}} and rising{{end}}
+{{end}}{{end}}`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Rodent {{.Period}} digest for {{.Host}}</title>
+<style>
+body { font-family: sans-serif; font-size: 14px; color: #222; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; text-align: left; }
+th { background: #f4f4f4; }
+.bad { color: #b00020; }
+.ok { color: #2e7d32; }
+</style>
+</head>
+<body>
+<h2>Rodent {{.Period}} digest for {{.Host}}</h2>
+<p>{{time .From}} to {{time .To}}</p>
+
+{{if .Issues}}<h3 class="bad">Needs attention</h3>
+<ul>{{range .Issues}}<li>{{.}}</li>{{end}}</ul>
+{{else}}<p class="ok">Nothing needs attention.</p>
+{{end}}
+<h3>Snapshots</h3>
+<p>{{.Snapshots.Created}} created, {{.Snapshots.Pruned}} pruned</p>
+{{if .Snapshots.Policies}}<table>
+<tr><th>Dataset</th><th>Policy</th><th>Created</th><th>Pruned</th></tr>
+{{range .Snapshots.Policies}}<tr><td>{{.Dataset}}</td><td>{{.Name}}</td><td>{{.Created}}</td><td>{{.Pruned}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Snapshots.Failing}}<table>
+<tr><th>Failing policy</th><th>Last run</th><th>Error</th></tr>
+{{range .Snapshots.Failing}}<tr class="bad"><td>{{if .Name}}{{.Name}}{{else}}{{.PolicyID}}{{end}}</td><td>{{time .At}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+
+<h3>Transfers</h3>
+<p>{{.Transfers.Completed}} completed ({{bytes .Transfers.Bytes}}), {{.Transfers.Failed}} failed, {{.Transfers.Cancelled}} cancelled</p>
+{{if .Transfers.Failures}}<table>
+<tr><th>Snapshot</th><th>Target</th><th>At</th><th>Category</th><th>Error</th></tr>
+{{range .Transfers.Failures}}<tr class="bad"><td>{{.Snapshot}}</td><td>{{if .RemoteHost}}{{.RemoteHost}}:{{end}}{{.Target}}</td><td>{{time .At}}</td><td>{{.ErrorCategory}}</td><td>{{.Error}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Lag}}<h3>Replication lag</h3>
+<table>
+<tr><th>Policy</th><th>Lag</th><th>RPO</th><th>Last common snapshot</th><th>Last error</th></tr>
+{{range .Lag}}<tr{{if .RPOBreached}} class="bad"{{end}}><td>{{.Name}}</td><td>{{duration .Lag}}</td><td>{{if .RPOTarget}}{{duration .RPOTarget}}{{end}}</td><td>{{.LastCommonSnapshot}}</td><td>{{.LastError}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Scans}}<h3>Scrubs and resilvers</h3>
+<table>
+<tr><th>Pool</th><th>Scan</th><th>State</th><th>Ended</th><th>Errors</th><th>Repaired</th></tr>
+{{range .Scans}}<tr{{if .Errors}} class="bad"{{end}}><td>{{.Pool}}</td><td>{{.Function}}</td><td>{{.State}}</td><td>{{time .At}}</td><td>{{.Errors}}</td><td>{{bytes .RepairedBytes}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .DiskChanges}}<h3>Disk health</h3>
+<table>
+<tr><th>Disk</th><th>Model</th><th>From</th><th>To</th><th>At</th><th>Reason</th></tr>
+{{range .DiskChanges}}<tr><td>{{if .Path}}{{.Path}}{{else}}{{.DeviceID}}{{end}}</td><td>{{.Model}}</td><td>{{.From}}</td><td>{{.To}}</td><td>{{time .At}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>{{end}}
+
+{{if .Capacity}}<h3>Capacity</h3>
+<table>
+<tr><th>Pool</th><th>Used</th><th>Level</th><th>Growth per day</th><th>Days until full</th><th>Fragmentation</th></tr>
+{{range .Capacity}}<tr><td>{{.Pool}}</td><td>{{.Capacity}}%</td><td>{{upper .Level}}</td><td>{{bytes .GrowthBytesPerDay}}</td><td>{{days .DaysUntilFull}}</td><td>{{.Fragmentation}}%{{if .FragmentationRising}} (rising){{end}}</td></tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`))
+
+// formatBytes formats the int64 and uint64 byte counts of a report
+func formatBytes(n any) string {
+	switch n := n.(type) {
+	case int64:
+		return common.FormatBytes(n)
+	case uint64:
+		return common.FormatBytes(n)
+	case int:
+		return common.FormatBytes(n)
+	}
+	return fmt.Sprint(n)
+}
+
+// formatDuration formats a duration to the minute, as lag is not measured
+// more finely
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "0m"
+	}
+	days := d / (24 * time.Hour)
+	d -= days * 24 * time.Hour
+	hours := d / time.Hour
+	d -= hours * time.Hour
+	minutes := d / time.Minute
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%dd", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%dh", hours))
+	}
+	if minutes > 0 {
+		parts = append(parts, fmt.Sprintf("%dm", minutes))
+	}
+	return strings.Join(parts, "")
+}
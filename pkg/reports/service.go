@@ -0,0 +1,389 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/disk"
+	"github.com/stratastor/rodent/pkg/disk/types"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/webhooks"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/pool"
+)
+
+// JobTypeDigest is the job type of a scheduled digest delivery
+const JobTypeDigest = "report-digest"
+
+// digestRetryPolicy retries deliveries through the day, as mail servers
+// come and go
+var digestRetryPolicy = jobs.RetryPolicy{
+	MaxAttempts:    6,
+	InitialBackoff: time.Minute,
+	MaxBackoff:     time.Hour,
+	Timeout:        5 * time.Minute,
+}
+
+// digestJobPayload names the digest to send and the end of its period
+type digestJobPayload struct {
+	Name string    `json:"name"`
+	To   time.Time `json:"to"`
+}
+
+// Service records activity, and builds and sends the configured digests
+type Service struct {
+	logger    logger.Logger
+	host      string
+	smtp      config.ReportSMTP
+	schedules []Schedule
+	recorder  *recorder
+	scheduler gocron.Scheduler
+
+	jobQueue         atomic.Pointer[jobs.Queue]
+	webhooks         atomic.Pointer[webhooks.Dispatcher]
+	snapshots        atomic.Pointer[autosnapshots.Manager]
+	transferPolicies atomic.Pointer[autotransfers.Manager]
+	capacity         atomic.Pointer[pool.CapacityMonitor]
+
+	// muted is why scheduled digests are skipped; nil while they are sent
+	muted atomic.Pointer[string]
+}
+
+// Singleton instance
+var (
+	globalService *Service
+	initMutex     sync.Mutex
+)
+
+// GetService returns the singleton service for the digests in the config
+// file. Invalid digests are logged and left out.
+func GetService(logCfg logger.Config) (*Service, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalService != nil {
+		return globalService, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "reports")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	cfg := config.GetConfig().Reports
+	var schedules []Schedule
+	seen := make(map[string]bool)
+	for _, dc := range cfg.Digests {
+		s, err := ParseSchedule(dc)
+		if err != nil {
+			l.Error("Ignoring invalid digest", "digest", dc.Name, "error", err)
+			continue
+		}
+		if seen[s.Name] {
+			l.Error("Ignoring digest with duplicate name", "digest", s.Name)
+			continue
+		}
+		if len(s.Recipients) > 0 && (cfg.SMTP.Host == "" || cfg.SMTP.From == "") {
+			l.Error("Ignoring digest with recipients, as reports.smtp has no host or sender",
+				"digest", s.Name)
+			continue
+		}
+		seen[s.Name] = true
+		schedules = append(schedules, s)
+	}
+
+	sched, err := gocron.NewScheduler()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ReportInvalidConfig)
+	}
+
+	globalService = NewService(l, cfg.SMTP, schedules, config.GetConfigDir())
+	globalService.scheduler = sched
+	return globalService, nil
+}
+
+// NewService creates a service for validated digests, keeping its activity
+// log in configDir. Most callers should use GetService.
+func NewService(l logger.Logger, smtpCfg config.ReportSMTP, schedules []Schedule, configDir string) *Service {
+	host, _ := os.Hostname()
+	return &Service{
+		logger:    l,
+		host:      host,
+		smtp:      smtpCfg,
+		schedules: schedules,
+		recorder:  newRecorder(l, configDir),
+	}
+}
+
+// UseJobQueue sends scheduled digests through the job queue, so failed
+// deliveries are retried. Without a queue each digest is attempted once.
+func (s *Service) UseJobQueue(q *jobs.Queue) {
+	q.Register(JobTypeDigest, s.runDigestJob, digestRetryPolicy)
+	s.jobQueue.Store(q)
+}
+
+// UseWebhooks publishes digests of schedules with webhook set
+func (s *Service) UseWebhooks(d *webhooks.Dispatcher) {
+	s.webhooks.Store(d)
+}
+
+// WatchSnapshots records the snapshots policies create and prune, and
+// reports policies whose runs fail
+func (s *Service) WatchSnapshots(m *autosnapshots.Manager) {
+	s.snapshots.Store(m)
+	m.OnSnapshotCreated(func(result autosnapshots.CreateSnapshotResult) {
+		if result.DryRun {
+			return
+		}
+		s.recorder.add(func(a *activity) {
+			a.Snapshots = append(a.Snapshots, SnapshotRun{
+				At:       result.CreatedAt,
+				PolicyID: result.PolicyID,
+				Dataset:  result.DatasetName,
+				Snapshot: result.DatasetName + "@" + result.SnapshotName,
+				Pruned:   len(result.PrunedSnapshots),
+			})
+		})
+	})
+}
+
+// WatchTransfers records transfers as they finish
+func (s *Service) WatchTransfers(tm *dataset.TransferManager) {
+	tm.OnTransferFinished(func(info dataset.TransferInfo) {
+		at := time.Now()
+		if info.CompletedAt != nil {
+			at = *info.CompletedAt
+		}
+		s.recorder.add(func(a *activity) {
+			a.Transfers = append(a.Transfers, TransferRun{
+				At:            at,
+				TransferID:    info.ID,
+				PolicyID:      info.PolicyID,
+				Status:        string(info.Status),
+				Snapshot:      info.Config.SendConfig.Snapshot,
+				Target:        info.Config.ReceiveConfig.Target,
+				RemoteHost:    info.Config.ReceiveConfig.RemoteConfig.Host,
+				Bytes:         info.Progress.BytesTransferred,
+				Error:         info.ErrorMessage,
+				ErrorCategory: string(info.ErrorCategory),
+			})
+		})
+	})
+}
+
+// UseTransferPolicies reports the replication lag of transfer policies
+func (s *Service) UseTransferPolicies(m *autotransfers.Manager) {
+	s.transferPolicies.Store(m)
+}
+
+// WatchScans records scrubs and resilvers as they end
+func (s *Service) WatchScans(sm *pool.ScanMonitor) {
+	sm.OnScanFinished(func(scan pool.ScanProgress) {
+		at := time.Now()
+		if scan.EndedAt != nil {
+			at = *scan.EndedAt
+		}
+		s.recorder.add(func(a *activity) {
+			a.Scans = append(a.Scans, ScanOutcome{
+				At:            at,
+				Pool:          scan.Pool,
+				Function:      scan.Function,
+				State:         scan.State,
+				Errors:        scan.Errors,
+				RepairedBytes: scan.RepairedBytes,
+			})
+		})
+	})
+}
+
+// UseCapacity reports the usage and trends of pools
+func (s *Service) UseCapacity(cm *pool.CapacityMonitor) {
+	s.capacity.Store(cm)
+}
+
+// WatchDisks records disk health changes
+func (s *Service) WatchDisks(m *disk.Manager) {
+	m.OnHealthChanged(func(d types.PhysicalDisk, oldHealth types.HealthStatus) {
+		s.recorder.add(func(a *activity) {
+			a.DiskChanges = append(a.DiskChanges, DiskHealthChange{
+				At:       time.Now(),
+				DeviceID: d.DeviceID,
+				Path:     d.DevicePath,
+				Model:    d.Model,
+				From:     string(oldHealth),
+				To:       string(d.Health),
+				Reason:   d.HealthReason,
+			})
+		})
+	})
+}
+
+// Schedules returns the configured digests
+func (s *Service) Schedules() []Schedule {
+	return append([]Schedule(nil), s.schedules...)
+}
+
+// Start schedules the digests
+func (s *Service) Start() error {
+	if s.scheduler == nil || len(s.schedules) == 0 {
+		return nil
+	}
+
+	for _, sched := range s.schedules {
+		var def gocron.JobDefinition
+		at := gocron.NewAtTimes(gocron.NewAtTime(uint(sched.hour), uint(sched.minute), 0))
+		if sched.Period == PeriodWeekly {
+			def = gocron.WeeklyJob(1, gocron.NewWeekdays(sched.weekday), at)
+		} else {
+			def = gocron.DailyJob(1, at)
+		}
+
+		name := sched.Name
+		if _, err := s.scheduler.NewJob(def, gocron.NewTask(func() { s.scheduled(name) }),
+			gocron.WithName("digest:"+name)); err != nil {
+			return errors.Wrap(err, errors.ReportInvalidConfig).WithMetadata("digest", name)
+		}
+	}
+
+	s.scheduler.Start()
+	s.logger.Info("Digest reports scheduled", "count", len(s.schedules))
+	return nil
+}
+
+// Mute skips scheduled digests until Unmute is called, such as while the
+// node is in maintenance. Activity is still recorded, and digests can still
+// be sent on request.
+func (s *Service) Mute(reason string) {
+	s.muted.Store(&reason)
+}
+
+// Unmute sends scheduled digests again
+func (s *Service) Unmute() {
+	s.muted.Store(nil)
+}
+
+// Stop ends the schedules; queued deliveries are left to the job queue
+func (s *Service) Stop() {
+	if s.scheduler != nil {
+		if err := s.scheduler.Shutdown(); err != nil {
+			s.logger.Warn("Failed to stop digest scheduler", "error", err)
+		}
+	}
+}
+
+// scheduled sends a digest for the period ending now, through the job queue
+// when there is one
+func (s *Service) scheduled(name string) {
+	if reason := s.muted.Load(); reason != nil {
+		s.logger.Info("Skipping muted digest", "digest", name, "reason", *reason)
+		return
+	}
+
+	payload := digestJobPayload{Name: name, To: time.Now()}
+
+	if q := s.jobQueue.Load(); q != nil {
+		_, err := q.Enqueue(JobTypeDigest, payload)
+		if err == nil {
+			return
+		}
+		s.logger.Warn("Failed to enqueue digest, sending once", "digest", name, "error", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), digestRetryPolicy.Timeout)
+	defer cancel()
+	if _, err := s.Send(ctx, name, payload.To); err != nil {
+		s.logger.Warn("Digest delivery failed", "digest", name, "error", err)
+	}
+}
+
+// runDigestJob makes one delivery attempt of a scheduled digest. Digests
+// removed from the config are dropped.
+func (s *Service) runDigestJob(ctx context.Context, payload json.RawMessage) error {
+	var p digestJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+
+	if _, ok := s.schedule(p.Name); !ok {
+		s.logger.Debug("Dropping removed digest", "digest", p.Name)
+		return nil
+	}
+
+	digest, err := s.Send(ctx, p.Name, p.To)
+	if err != nil {
+		return err
+	}
+	jobs.SetResult(ctx, map[string]any{
+		"digest": digest.Name,
+		"from":   digest.From,
+		"to":     digest.To,
+		"issues": len(digest.Issues),
+	})
+	return nil
+}
+
+// schedule returns the digest with the name
+func (s *Service) schedule(name string) (Schedule, bool) {
+	for _, sched := range s.schedules {
+		if sched.Name == name {
+			return sched, true
+		}
+	}
+	return Schedule{}, false
+}
+
+// Build returns the named digest for the period ending at to
+func (s *Service) Build(ctx context.Context, name string, to time.Time) (*Digest, error) {
+	sched, ok := s.schedule(name)
+	if !ok {
+		return nil, errors.New(errors.ReportNotFound, "digest not found").
+			WithMetadata("digest", name)
+	}
+	return s.build(ctx, sched, to), nil
+}
+
+// Send builds the named digest for the period ending at to and sends it to
+// its recipients and, if set, the webhook endpoints
+func (s *Service) Send(ctx context.Context, name string, to time.Time) (*Digest, error) {
+	sched, ok := s.schedule(name)
+	if !ok {
+		return nil, errors.New(errors.ReportNotFound, "digest not found").
+			WithMetadata("digest", name)
+	}
+
+	digest := s.build(ctx, sched, to)
+	if len(sched.Recipients) > 0 {
+		msg, err := composeMessage(s.smtp.From, sched.Recipients, digest, sched.Format)
+		if err != nil {
+			return nil, jobs.Permanent(errors.Wrap(err, errors.ReportInvalidConfig).
+				WithMetadata("digest", name))
+		}
+		if err := sendMail(ctx, s.smtp, sched.Recipients, msg); err != nil {
+			return nil, err
+		}
+	}
+	if sched.Webhook {
+		s.webhooks.Load().Publish(webhooks.EventReportDigest, digest)
+	}
+
+	s.logger.Info("Digest sent",
+		"digest", name,
+		"recipients", len(sched.Recipients),
+		"webhook", sched.Webhook,
+		"issues", len(digest.Issues))
+	return digest, nil
+}
@@ -0,0 +1,269 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package reports builds digests of what the scheduled jobs did, such as
+// snapshot runs and pruning, transfers and replication lag, scrubs, disk
+// health changes and pool capacity trends, and sends them daily or weekly
+// by email or as webhook events.
+package reports
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Period is how much activity a digest covers, and how often it is sent
+type Period string
+
+const (
+	PeriodDaily  Period = "daily"
+	PeriodWeekly Period = "weekly"
+)
+
+// Duration returns the length of the period
+func (p Period) Duration() time.Duration {
+	if p == PeriodWeekly {
+		return 7 * 24 * time.Hour
+	}
+	return 24 * time.Hour
+}
+
+// Format is the body of a digest email
+type Format string
+
+const (
+	FormatHTML Format = "html" // HTML with a plain text alternative
+	FormatText Format = "text" // Plain text only
+)
+
+const (
+	defaultAt      = "07:00"
+	defaultWeekday = time.Monday
+)
+
+// Schedule is a validated digest configuration
+type Schedule struct {
+	Name       string   `json:"name"`
+	Period     Period   `json:"period"`
+	At         string   `json:"at"`                // Local time, HH:MM
+	Weekday    string   `json:"weekday,omitempty"` // Weekly digests only
+	Format     Format   `json:"format"`
+	Recipients []string `json:"recipients,omitempty"`
+	Webhook    bool     `json:"webhook"`
+
+	hour, minute int
+	weekday      time.Weekday
+}
+
+// ParseSchedule validates a digest from the config file and fills in defaults
+func ParseSchedule(cfg config.ReportDigest) (Schedule, error) {
+	if cfg.Name == "" {
+		return Schedule{}, errors.New(errors.ReportInvalidConfig, "digest name is required")
+	}
+
+	s := Schedule{
+		Name:    cfg.Name,
+		Period:  Period(strings.ToLower(cfg.Period)),
+		At:      cfg.At,
+		Format:  Format(strings.ToLower(cfg.Format)),
+		Webhook: cfg.Webhook,
+		weekday: defaultWeekday,
+	}
+	if s.Period == "" {
+		s.Period = PeriodDaily
+	}
+	if s.Period != PeriodDaily && s.Period != PeriodWeekly {
+		return Schedule{}, errors.New(errors.ReportInvalidConfig, "period must be daily or weekly").
+			WithMetadata("digest", cfg.Name)
+	}
+
+	if s.At == "" {
+		s.At = defaultAt
+	}
+	at, err := time.Parse("15:04", s.At)
+	if err != nil {
+		return Schedule{}, errors.New(errors.ReportInvalidConfig, "at must be a time of day as HH:MM").
+			WithMetadata("digest", cfg.Name)
+	}
+	s.hour, s.minute = at.Hour(), at.Minute()
+
+	if s.Period == PeriodWeekly {
+		if cfg.Weekday != "" {
+			wd, ok := parseWeekday(cfg.Weekday)
+			if !ok {
+				return Schedule{}, errors.New(errors.ReportInvalidConfig,
+					fmt.Sprintf("unknown weekday %q", cfg.Weekday)).
+					WithMetadata("digest", cfg.Name)
+			}
+			s.weekday = wd
+		}
+		s.Weekday = strings.ToLower(s.weekday.String())
+	}
+
+	if s.Format == "" {
+		s.Format = FormatHTML
+	}
+	if s.Format != FormatHTML && s.Format != FormatText {
+		return Schedule{}, errors.New(errors.ReportInvalidConfig, "format must be html or text").
+			WithMetadata("digest", cfg.Name)
+	}
+
+	for _, r := range cfg.Recipients {
+		addr, err := mail.ParseAddress(r)
+		if err != nil {
+			return Schedule{}, errors.New(errors.ReportInvalidConfig,
+				fmt.Sprintf("invalid recipient %q", r)).
+				WithMetadata("digest", cfg.Name)
+		}
+		s.Recipients = append(s.Recipients, addr.Address)
+	}
+	if len(s.Recipients) == 0 && !s.Webhook {
+		return Schedule{}, errors.New(errors.ReportInvalidConfig,
+			"digest needs recipients, the webhook, or both").
+			WithMetadata("digest", cfg.Name)
+	}
+
+	return s, nil
+}
+
+// parseWeekday parses a weekday name such as "monday" or "mon"
+func parseWeekday(name string) (time.Weekday, bool) {
+	name = strings.ToLower(name)
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		full := strings.ToLower(wd.String())
+		if name == full || name == full[:3] {
+			return wd, true
+		}
+	}
+	return 0, false
+}
+
+// Digest summarises the activity of one period
+type Digest struct {
+	Name   string    `json:"name"`
+	Host   string    `json:"host"`
+	Period Period    `json:"period"`
+	From   time.Time `json:"from"`
+	To     time.Time `json:"to"`
+
+	// Issues lists what needs attention, one line each; empty when all is well
+	Issues []string `json:"issues"`
+
+	Snapshots   SnapshotSummary    `json:"snapshots"`
+	Transfers   TransferSummary    `json:"transfers"`
+	Lag         []PolicyLag        `json:"lag,omitempty"`
+	Scans       []ScanOutcome      `json:"scans,omitempty"`
+	DiskChanges []DiskHealthChange `json:"disk_changes,omitempty"`
+	Capacity    []PoolCapacity     `json:"capacity,omitempty"`
+}
+
+// SnapshotSummary counts the snapshots policies created and pruned
+type SnapshotSummary struct {
+	Created  int             `json:"created"`
+	Pruned   int             `json:"pruned"`
+	Policies []PolicyRuns    `json:"policies,omitempty"`
+	Failing  []PolicyFailure `json:"failing,omitempty"` // Policies whose last run in the period failed
+}
+
+// PolicyRuns counts the snapshots of one snapshot policy
+type PolicyRuns struct {
+	PolicyID string `json:"policy_id"`
+	Name     string `json:"name,omitempty"`
+	Dataset  string `json:"dataset"`
+	Created  int    `json:"created"`
+	Pruned   int    `json:"pruned"`
+}
+
+// PolicyFailure is a policy whose last run failed
+type PolicyFailure struct {
+	PolicyID string    `json:"policy_id"`
+	Name     string    `json:"name,omitempty"`
+	At       time.Time `json:"at"`
+	Error    string    `json:"error"`
+}
+
+// TransferSummary counts finished transfers
+type TransferSummary struct {
+	Completed int           `json:"completed"`
+	Failed    int           `json:"failed"`
+	Cancelled int           `json:"cancelled"`
+	Bytes     int64         `json:"bytes"` // Sent by completed transfers
+	Failures  []TransferRun `json:"failures,omitempty"`
+}
+
+// PolicyLag is how far a transfer policy's target is behind its source
+type PolicyLag struct {
+	PolicyID           string        `json:"policy_id"`
+	Name               string        `json:"name"`
+	Lag                time.Duration `json:"lag"`
+	RPOTarget          time.Duration `json:"rpo_target,omitempty"`
+	RPOBreached        bool          `json:"rpo_breached"`
+	LastCommonSnapshot string        `json:"last_common_snapshot,omitempty"`
+	LastError          string        `json:"last_error,omitempty"`
+}
+
+// PoolCapacity is a pool's usage and trend when the digest was built
+type PoolCapacity struct {
+	Pool                string   `json:"pool"`
+	Capacity            int      `json:"capacity"` // Percent used
+	Level               string   `json:"level"`
+	GrowthBytesPerDay   int64    `json:"growth_bytes_per_day"`
+	DaysUntilFull       *float64 `json:"days_until_full,omitempty"`
+	Fragmentation       int      `json:"fragmentation"`
+	FragmentationRising bool     `json:"fragmentation_rising"`
+}
+
+// SnapshotRun is a snapshot a policy created
+type SnapshotRun struct {
+	At       time.Time `json:"at"`
+	PolicyID string    `json:"policy_id"`
+	Dataset  string    `json:"dataset"`
+	Snapshot string    `json:"snapshot"`
+	Pruned   int       `json:"pruned"`
+}
+
+// TransferRun is a transfer that finished
+type TransferRun struct {
+	At            time.Time `json:"at"`
+	TransferID    string    `json:"transfer_id"`
+	PolicyID      string    `json:"policy_id,omitempty"`
+	Status        string    `json:"status"`
+	Snapshot      string    `json:"snapshot"`
+	Target        string    `json:"target"`
+	RemoteHost    string    `json:"remote_host,omitempty"`
+	Bytes         int64     `json:"bytes"`
+	Error         string    `json:"error,omitempty"`
+	ErrorCategory string    `json:"error_category,omitempty"`
+}
+
+// ScanOutcome is a scrub or resilver that ended
+type ScanOutcome struct {
+	At            time.Time `json:"at"`
+	Pool          string    `json:"pool"`
+	Function      string    `json:"function"` // "scrub" or "resilver"
+	State         string    `json:"state"`    // "finished" or "canceled"
+	Errors        uint64    `json:"errors"`
+	RepairedBytes uint64    `json:"repaired_bytes"`
+}
+
+// DiskHealthChange is a change in a disk's health
+type DiskHealthChange struct {
+	At       time.Time `json:"at"`
+	DeviceID string    `json:"device_id"`
+	Path     string    `json:"path,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Reason   string    `json:"reason,omitempty"`
+}
+
+func (r SnapshotRun) when() time.Time      { return r.At }
+func (r TransferRun) when() time.Time      { return r.At }
+func (r ScanOutcome) when() time.Time      { return r.At }
+func (r DiskHealthChange) when() time.Time { return r.At }
@@ -0,0 +1,103 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/lockwatch"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// mutexProfileFraction samples one in this many mutex contention events
+	mutexProfileFraction = 5
+
+	// blockProfileRate samples on average one blocking event per this many
+	// nanoseconds spent blocked
+	blockProfileRate = int(time.Millisecond)
+)
+
+// sharedLockWatcher warns about contended manager locks
+// Stopped by the shutdown handler
+var sharedLockWatcher *lockwatch.Watcher
+
+// registerPprofRoutes serves the runtime profiles under /debug/pprof and
+// turns on mutex and block profiling. The REST API has no authentication of
+// its own, so only loopback clients are served: a shell on the host, or an
+// authenticated Toggle user through a tunnel service allowing /debug/pprof/.
+func registerPprofRoutes(engine *gin.Engine) {
+	runtime.SetMutexProfileFraction(mutexProfileFraction)
+	runtime.SetBlockProfileRate(blockProfileRate)
+
+	debug := engine.Group("/debug/pprof", LoopbackOnly())
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+		// goroutine, heap, mutex, block, allocs and threadcreate
+		debug.GET("/:profile", func(c *gin.Context) {
+			pprof.Handler(c.Param("profile")).ServeHTTP(c.Writer, c.Request)
+		})
+	}
+}
+
+// LoopbackOnly rejects requests that do not come from the host itself: over
+// the API socket or from a loopback address. The peer address is used, not
+// forwarding headers.
+func LoopbackOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := PeerCredFromContext(c.Request.Context()); ok {
+			c.Next()
+			return
+		}
+		if ip := net.ParseIP(c.RemoteIP()); ip == nil || !ip.IsLoopback() {
+			common.APIError(c, errors.New(errors.PermissionDenied,
+				"Only available to clients on the host").
+				WithMetadata("path", c.Request.URL.Path))
+			return
+		}
+		c.Next()
+	}
+}
+
+// startLockWatcher warns with a goroutine dump when the snapshot, transfer
+// and transfer policy manager locks are waited on or held too long
+func startLockWatcher(l logger.Logger) {
+	cfg := config.GetConfig().Debug.LockWatch
+	if !cfg.Enabled {
+		return
+	}
+
+	interval := parseDurationOrDefault(l, "debug.lockWatch.interval", cfg.Interval)
+	threshold := parseDurationOrDefault(l, "debug.lockWatch.threshold", cfg.Threshold)
+
+	sharedLockWatcher = lockwatch.NewWatcher(l, interval, threshold)
+	sharedLockWatcher.Start()
+}
+
+// parseDurationOrDefault parses a duration setting, logging an invalid one
+// and returning 0 for the default
+func parseDurationOrDefault(l logger.Logger, key, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.Warn("Invalid duration, using default", "setting", key, "value", value, "error", err)
+		return 0
+	}
+	return d
+}
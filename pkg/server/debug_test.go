@@ -0,0 +1,39 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPprofRoutesLoopbackOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	registerPprofRoutes(engine)
+
+	tests := []struct {
+		remoteAddr string
+		path       string
+		want       int
+	}{
+		{"127.0.0.1:51000", "/debug/pprof/", http.StatusOK},
+		{"[::1]:51000", "/debug/pprof/goroutine?debug=1", http.StatusOK},
+		{"127.0.0.1:51000", "/debug/pprof/cmdline", http.StatusOK},
+		{"192.0.2.10:51000", "/debug/pprof/heap", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("X-Forwarded-For", "127.0.0.1")
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		assert.Equal(t, tt.want, rec.Code, "%s from %s", tt.path, tt.remoteAddr)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Feature names reported in feature-disabled errors; they match the keys of
+// the features config section
+const (
+	featureSMB           = "smb"
+	featureNFS           = "nfs"
+	featureAutoSnapshots = "zfs.autosnapshots"
+	featureAutoTransfers = "zfs.autotransfers"
+	featureDisks         = "disks"
+	featureDomain        = "domain"
+	featureADDC          = "addc"
+	featureAPI           = "api"
+)
+
+// featureDisabled responds with a feature-disabled error
+func featureDisabled(c *gin.Context, feature string) {
+	err := errors.New(errors.ServerFeatureDisabled, "Feature is disabled in the configuration").
+		WithMetadata("feature", feature)
+	c.AbortWithStatusJSON(err.HTTPStatus, err)
+}
+
+// registerDisabledFeature answers every request below basePath with a
+// feature-disabled error, so clients can tell a disabled subsystem from a
+// mistyped path
+func registerDisabledFeature(router gin.IRoutes, basePath, feature string) {
+	router.Any(basePath+"/*path", func(c *gin.Context) {
+		featureDisabled(c, feature)
+	})
+}
+
+// APIFeatureGate rejects REST API requests when the api feature is disabled.
+// Health checks stay available, and subsystems still initialize so that
+// Toggle commands keep working.
+func APIFeatureGate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.GetConfig().Features.API &&
+			strings.HasPrefix(c.Request.URL.Path, constants.APIBase+"/") {
+			featureDisabled(c, featureAPI)
+			return
+		}
+		c.Next()
+	}
+}
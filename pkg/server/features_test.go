@@ -0,0 +1,42 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterDisabledFeature(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+
+	shares := engine.Group(constants.APIShares)
+	shares.GET("/smb", func(c *gin.Context) { c.Status(http.StatusOK) })
+	registerDisabledFeature(shares, "/nfs", featureNFS)
+
+	for _, path := range []string{"/nfs/", "/nfs/exports", "/nfs/exports/data"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, constants.APIShares+path, nil))
+		assert.Equal(t, http.StatusNotFound, w.Code, path)
+
+		var body errors.RodentError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, errors.ErrorCode(errors.ServerFeatureDisabled), body.Code)
+		assert.Equal(t, featureNFS, body.Metadata["feature"])
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, constants.APIShares+"/smb", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,113 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+)
+
+// apiListeners opens the API's TCP listeners, unless disabled, and its Unix
+// socket, if configured. It returns the addresses for logging, with the
+// socket as "unix:<path>".
+func apiListeners(l logger.Logger, cfg *config.Config) ([]string, []net.Listener, error) {
+	var addrs []string
+	var listeners []net.Listener
+
+	if !cfg.Server.DisableTCP {
+		tcpAddrs, err := listenAddrs(cfg.Server.BindAddress, cfg.Server.BindInterface, cfg.Server.Port)
+		if err != nil {
+			return nil, nil, err
+		}
+		if listeners, err = listen(tcpAddrs); err != nil {
+			return nil, nil, err
+		}
+		addrs = tcpAddrs
+	}
+
+	if cfg.Server.Socket != "" {
+		ln, err := listenSocket(l, cfg.Server.Socket, cfg.Server.SocketGroup)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, nil, err
+		}
+		listeners = append(listeners, ln)
+		addrs = append(addrs, "unix:"+cfg.Server.Socket)
+	}
+
+	if len(listeners) == 0 {
+		return nil, nil, fmt.Errorf("server.disableTCP requires server.socket")
+	}
+	return addrs, listeners, nil
+}
+
+// listenAddrs returns the addresses the API listens on. Without a bind
+// address or interface it listens on every address of both families. A bound
+// API also listens on the IPv4 loopback, so the health checker and the
+// loopback-only debug endpoints keep working.
+func listenAddrs(bindAddress, bindInterface string, port int) ([]string, error) {
+	var ips []netip.Addr
+	switch {
+	case bindAddress != "" && bindInterface != "":
+		return nil, fmt.Errorf("server.bindAddress and server.bindInterface are exclusive")
+	case bindAddress != "":
+		ip, err := common.HostIP(bindAddress)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server.bindAddress: %w", err)
+		}
+		ips = []netip.Addr{ip}
+	case bindInterface != "":
+		if !common.ValidInterfaceName(bindInterface) {
+			return nil, fmt.Errorf("invalid server.bindInterface %q", bindInterface)
+		}
+		addrs, err := common.InterfaceAddrs(bindInterface)
+		if err != nil {
+			return nil, err
+		}
+		if len(addrs) == 0 {
+			return nil, fmt.Errorf("interface %s has no addresses", bindInterface)
+		}
+		ips = addrs
+	default:
+		return []string{fmt.Sprintf(":%d", port)}, nil
+	}
+
+	loopback := netip.MustParseAddr("127.0.0.1")
+	needLoopback := true
+	addrs := make([]string, 0, len(ips)+1)
+	for _, ip := range ips {
+		if ip.IsUnspecified() || ip == loopback {
+			needLoopback = false
+		}
+		addrs = append(addrs, common.JoinHostPort(ip.String(), port))
+	}
+	if needLoopback {
+		addrs = append(addrs, common.JoinHostPort(loopback.String(), port))
+	}
+	return addrs, nil
+}
+
+// listen opens a listener on each address, closing the opened ones on failure
+func listen(addrs []string) ([]net.Listener, error) {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, ln)
+	}
+	return listeners, nil
+}
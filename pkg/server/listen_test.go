@@ -0,0 +1,48 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAddrs(t *testing.T) {
+	addrs, err := listenAddrs("", "", 8042)
+	require.NoError(t, err)
+	assert.Equal(t, []string{":8042"}, addrs)
+
+	addrs, err = listenAddrs("[2001:db8::5]", "", 8042)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"[2001:db8::5]:8042", "127.0.0.1:8042"}, addrs)
+
+	addrs, err = listenAddrs("0.0.0.0", "", 8042)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0.0.0.0:8042"}, addrs, "unspecified addresses cover the loopback")
+
+	addrs, err = listenAddrs("", "lo", 8042)
+	require.NoError(t, err)
+	assert.Contains(t, addrs, "127.0.0.1:8042")
+	assert.Equal(t, 1, countOf(addrs, "127.0.0.1:8042"), "the loopback is not listed twice")
+
+	_, err = listenAddrs("nas.example", "", 8042)
+	assert.Error(t, err)
+	_, err = listenAddrs("192.168.1.5", "eth0", 8042)
+	assert.Error(t, err)
+	_, err = listenAddrs("", "eth0;reboot", 8042)
+	assert.Error(t, err)
+}
+
+func countOf(items []string, item string) int {
+	n := 0
+	for _, i := range items {
+		if i == item {
+			n++
+		}
+	}
+	return n
+}
@@ -19,14 +19,18 @@ package server
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"runtime/debug"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/crash"
 	"github.com/stratastor/rodent/pkg/errors"
 )
 
@@ -123,6 +127,60 @@ func LoggerMiddleware(l logger.Logger) gin.HandlerFunc {
 	}
 }
 
+// RecoveryMiddleware turns a panic in a handler into a 500 response and a
+// crash report correlated with the request ID
+func RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+			// Deliberate aborts are left to net/http
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+
+			requestID := c.GetString("request_id")
+			report := crash.Handle(crash.SourceHTTP, c.Request.Method+" "+c.FullPath(),
+				requestID, r, debug.Stack())
+
+			err := errors.New(errors.ServerInternalError, "Request handler panicked").
+				WithMetadata("crash_id", report.ID)
+			if requestID != "" {
+				err = err.WithMetadata("request_id", requestID)
+			}
+			c.Error(err)
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			common.APIError(c, err)
+		}()
+		c.Next()
+	}
+}
+
+// ActorMiddleware records who made a REST request in the request context, so
+// managers can attribute the changes it makes. Requests over the API socket
+// are attributed to the local user the kernel reports.
+func ActorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := common.Actor{
+			Source:    common.ActorSourceAPI,
+			User:      c.GetHeader(common.ActorUserHeader),
+			RequestID: c.GetString("request_id"),
+			Address:   c.ClientIP(),
+		}
+		if cred, ok := PeerCredFromContext(c.Request.Context()); ok {
+			actor.User = cred.User()
+			actor.Address = fmt.Sprintf("unix:pid=%d", cred.PID)
+		}
+		c.Request = c.Request.WithContext(common.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
 // Helper to convert slog.Attr slice to interface slice
 func logAttrs(attrs []slog.Attr) []interface{} {
 	args := make([]interface{}, len(attrs)*2)
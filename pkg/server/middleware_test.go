@@ -0,0 +1,84 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+		c.Next()
+	})
+	engine.Use(ActorMiddleware())
+
+	var actor common.Actor
+	engine.PUT("/change", func(c *gin.Context) {
+		actor = common.ActorFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/change", nil)
+	req.Header.Set(common.ActorUserHeader, "alice")
+	req.RemoteAddr = "192.0.2.10:51000"
+	engine.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, common.Actor{
+		Source:    common.ActorSourceAPI,
+		User:      "alice",
+		RequestID: "req-1",
+		Address:   "192.0.2.10",
+	}, actor)
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	reportDir := t.TempDir()
+	crash.Setup(common.Log, reportDir)
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Set("request_id", "req-1")
+		c.Next()
+	})
+	engine.Use(RecoveryMiddleware())
+	engine.GET("/boom", func(c *gin.Context) {
+		var m map[string]int
+		m["x"] = 1
+	})
+
+	before := crash.Counts()[crash.SourceHTTP]
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, before+1, crash.Counts()[crash.SourceHTTP])
+
+	var resp struct {
+		Error struct {
+			Metadata map[string]string `json:"metadata"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, "req-1", resp.Error.Metadata["request_id"])
+	require.NotEmpty(t, resp.Error.Metadata["crash_id"])
+
+	reports, err := os.ReadDir(reportDir)
+	require.NoError(t, err)
+	assert.Len(t, reports, 1)
+}
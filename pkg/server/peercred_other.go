@@ -0,0 +1,18 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build !linux
+
+package server
+
+import (
+	"fmt"
+	"net"
+)
+
+// peerCredentials is only implemented on Linux; elsewhere every socket
+// connection is refused
+func peerCredentials(conn net.Conn) (PeerCred, error) {
+	return PeerCred{}, fmt.Errorf("peer credentials are not supported on this platform")
+}
@@ -7,38 +7,52 @@ package server
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
 	generalCmd "github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/crash"
 	"github.com/stratastor/rodent/internal/events"
 	"github.com/stratastor/rodent/internal/managers"
+	"github.com/stratastor/rodent/internal/metrics"
+	"github.com/stratastor/rodent/internal/selftest"
 	svcAPI "github.com/stratastor/rodent/internal/services/api"
+	"github.com/stratastor/rodent/internal/services/domain"
 	svcManager "github.com/stratastor/rodent/internal/services/manager"
 	"github.com/stratastor/rodent/pkg/ad"
 	"github.com/stratastor/rodent/pkg/ad/handlers"
+	"github.com/stratastor/rodent/pkg/consistency"
 	"github.com/stratastor/rodent/pkg/disk"
 	diskAPI "github.com/stratastor/rodent/pkg/disk/api"
 	"github.com/stratastor/rodent/pkg/facl"
 	aclAPI "github.com/stratastor/rodent/pkg/facl/api"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/inventory"
+	"github.com/stratastor/rodent/pkg/jobs"
 	sshAPI "github.com/stratastor/rodent/pkg/keys/ssh/api"
+	"github.com/stratastor/rodent/pkg/maintenance"
 	"github.com/stratastor/rodent/pkg/netmage"
 	netmageAPI "github.com/stratastor/rodent/pkg/netmage/api"
 	"github.com/stratastor/rodent/pkg/netmage/types"
+	"github.com/stratastor/rodent/pkg/reports"
 	"github.com/stratastor/rodent/pkg/shares"
 	sharesAPI "github.com/stratastor/rodent/pkg/shares/api"
 	"github.com/stratastor/rodent/pkg/shares/smb"
 	"github.com/stratastor/rodent/pkg/system"
 	systemAPI "github.com/stratastor/rodent/pkg/system/api"
+	"github.com/stratastor/rodent/pkg/webhooks"
 	"github.com/stratastor/rodent/pkg/zfs/api"
+	"github.com/stratastor/rodent/pkg/zfs/arc"
 	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
 	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 	"github.com/stratastor/rodent/pkg/zfs/pool"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
 )
 
 // Shared manager instances for stateful subsystems
@@ -62,19 +76,254 @@ var (
 	// sharedTransferManager holds the transfer manager instance
 	// Used for shutdown to gracefully terminate active transfers
 	sharedTransferManager *dataset.TransferManager
+
+	// sharedScanMonitor polls scrub and resilver progress of all pools
+	// Stopped by the shutdown handler
+	sharedScanMonitor *pool.ScanMonitor
+
+	// sharedCapacityMonitor tracks pool usage and fragmentation over time
+	// Stopped by the shutdown handler
+	sharedCapacityMonitor *pool.CapacityMonitor
+
+	// sharedIOStatSampler samples pool and vdev IO for the API and /metrics
+	// Stopped by the shutdown handler
+	sharedIOStatSampler *pool.IOStatSampler
+
+	// sharedARCMonitor samples the ARC statistics for the API and /metrics
+	// Stopped by the shutdown handler
+	sharedARCMonitor *arc.Monitor
+
+	// sharedJobQueue holds the background job queue
+	// Used by the ZFS managers to run retention and verification jobs, and stopped on shutdown
+	sharedJobQueue *jobs.Queue
+
+	// sharedDomainClient joins, leaves and checks domain membership
+	// Shared by the domain API and the domain health monitor, which it serializes
+	sharedDomainClient *domain.Client
+
+	// sharedWebhooks publishes events to the configured webhook endpoints
+	// Used by the ZFS managers to announce snapshots and finished transfers
+	sharedWebhooks *webhooks.Dispatcher
+
+	// sharedReports builds and sends the scheduled digests
+	// Stopped on shutdown
+	sharedReports *reports.Service
 )
 
+// registerSelfTestRoutes publishes the startup self-test report
+func registerSelfTestRoutes(engine *gin.Engine) {
+	v1 := engine.Group(constants.APISelfTest)
+	{
+		selftest.NewAPIHandler(selfTestReport).RegisterRoutes(v1)
+	}
+}
+
+// registerJobRoutes starts the background job queue and registers its
+// inspection routes. It must run before the managers that enqueue jobs are
+// created.
+func registerJobRoutes(engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	queue, err := jobs.GetQueue(logger.Config{LogLevel: cfg.Server.LogLevel})
+	if err != nil {
+		return err
+	}
+	queue.Start()
+	sharedJobQueue = queue
+
+	// Crash reports list the jobs in flight, which are resumed on the next start
+	crash.AddState("running_jobs", func() any { return queue.Running() })
+	crash.OnFatal(func() { queue.Interrupt("interrupted by a crash") })
+
+	v1 := engine.Group(constants.APIJobs)
+	{
+		jobs.NewAPIHandler(queue).RegisterRoutes(v1)
+	}
+	ops := engine.Group(constants.APIOperations)
+	{
+		jobs.NewAPIHandler(queue).RegisterOperationRoutes(ops)
+	}
+	return nil
+}
+
+// registerWebhookRoutes sets up delivery to the configured webhook endpoints
+// and registers their inspection routes. It must run after the job queue is
+// started and before the ZFS managers that publish events are created.
+func registerWebhookRoutes(engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	dispatcher, err := webhooks.GetDispatcher(logger.Config{LogLevel: cfg.Server.LogLevel})
+	if err != nil {
+		return err
+	}
+	if sharedJobQueue != nil {
+		dispatcher.UseJobQueue(sharedJobQueue)
+		dispatcher.WatchOperations(sharedJobQueue)
+	}
+	sharedWebhooks = dispatcher
+
+	v1 := engine.Group(constants.APIWebhooks)
+	{
+		webhooks.NewAPIHandler(dispatcher).RegisterRoutes(v1)
+	}
+	return nil
+}
+
+// registerReportRoutes sets up the scheduled digests and registers their
+// routes. It must run after the managers whose activity digests summarise
+// are created.
+func registerReportRoutes(engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	service, err := reports.GetService(logger.Config{LogLevel: cfg.Server.LogLevel})
+	if err != nil {
+		return err
+	}
+	if sharedJobQueue != nil {
+		service.UseJobQueue(sharedJobQueue)
+	}
+	if sharedWebhooks != nil {
+		service.UseWebhooks(sharedWebhooks)
+	}
+	if sharedSnapshotHandler != nil {
+		service.WatchSnapshots(sharedSnapshotHandler.Manager())
+	}
+	if sharedTransferManager != nil {
+		service.WatchTransfers(sharedTransferManager)
+	}
+	if sharedTransferPolicyHandler != nil {
+		service.UseTransferPolicies(sharedTransferPolicyHandler.Manager())
+	}
+	if sharedScanMonitor != nil {
+		service.WatchScans(sharedScanMonitor)
+	}
+	if sharedCapacityMonitor != nil {
+		service.UseCapacity(sharedCapacityMonitor)
+	}
+	if sharedDiskManager != nil {
+		service.WatchDisks(sharedDiskManager)
+	}
+	if err := service.Start(); err != nil {
+		return err
+	}
+	sharedReports = service
+
+	v1 := engine.Group(constants.APIReports)
+	{
+		reports.NewAPIHandler(service).RegisterRoutes(v1)
+	}
+	return nil
+}
+
+// registerConsistencyRoutes sets up the consistency checker over the
+// managers created so far, registers its routes and runs it until ctx is
+// cancelled
+func registerConsistencyRoutes(ctx context.Context, engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	l, err := logger.NewTag(config.NewLoggerConfig(cfg), "consistency")
+	if err != nil {
+		return err
+	}
+
+	interval, err := time.ParseDuration(cfg.Consistency.Interval)
+	if err != nil {
+		l.Warn("Invalid consistency check interval, only checking on request",
+			"interval", cfg.Consistency.Interval,
+			"error", err)
+		interval = 0
+	}
+
+	checker := consistency.NewChecker(l, interval)
+	if sharedSnapshotHandler != nil {
+		checker.UseSnapshotManager(sharedSnapshotHandler.Manager())
+	}
+	if sharedTransferPolicyHandler != nil {
+		checker.UseTransferPolicyManager(sharedTransferPolicyHandler.Manager())
+	}
+	if sharedDiskManager != nil {
+		checker.UseDiskManager(sharedDiskManager)
+	}
+	if paths, ok := sharedSharesManager.(consistency.SharePaths); ok {
+		checker.UseShares(paths)
+	}
+
+	v1 := engine.Group(constants.APIConsistency)
+	{
+		consistency.NewAPIHandler(checker).RegisterRoutes(v1)
+	}
+	crash.Go("consistency", func() { checker.Run(ctx) })
+	return nil
+}
+
+// registerMaintenanceRoutes sets up maintenance mode over the subsystems
+// registered so far and registers its routes. A window open before a restart
+// is applied again.
+func registerMaintenanceRoutes(ctx context.Context, engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	m, err := maintenance.GetManager(logger.Config{LogLevel: cfg.Server.LogLevel})
+	if err != nil {
+		return err
+	}
+	if sharedTransferManager != nil {
+		m.UseTransferManager(sharedTransferManager)
+	}
+	if sharedWebhooks != nil {
+		m.UseWebhooks(sharedWebhooks)
+	}
+	if sharedReports != nil {
+		m.UseReports(sharedReports)
+	}
+	if access, ok := sharedSharesManager.(maintenance.ShareAccess); ok {
+		m.UseShares(access)
+	}
+	m.Restore(ctx)
+
+	v1 := engine.Group(constants.APIMaintenance)
+	{
+		maintenance.NewAPIHandler(m).RegisterRoutes(v1)
+	}
+	return nil
+}
+
+// registerGuardRoutes installs the configured guard rules and registers
+// their routes. Override tokens are only issued to clients on the host.
+func registerGuardRoutes(engine *gin.Engine) error {
+	cfg := config.GetConfig()
+	g, err := guard.GetGuard(logger.Config{LogLevel: cfg.Server.LogLevel})
+	if err != nil {
+		return err
+	}
+	guard.SetDefault(g)
+
+	v1 := engine.Group(constants.APIGuards)
+	{
+		guard.NewAPIHandler(g).RegisterRoutes(v1, LoopbackOnly())
+	}
+	return nil
+}
+
 func registerZFSRoutes(engine *gin.Engine) (error error) {
 	// Add error handler middleware
 	engine.Use(ErrorHandler())
 
 	cfg := config.GetConfig()
+	l, err := logger.NewTag(logger.Config{LogLevel: cfg.Server.LogLevel}, "routes")
+	if err != nil {
+		return err
+	}
+
 	// Create command executor with sudo support
 	executor := command.NewCommandExecutor(true, logger.Config{LogLevel: cfg.Server.LogLevel})
 
+	// Gate version-dependent features before any manager runs commands
+	versionCtx, cancelVersion := context.WithTimeout(context.Background(), command.DefaultTimeout)
+	if _, err := executor.DetectVersion(versionCtx); err != nil {
+		l.Warn("Failed to detect OpenZFS version, assuming all features are supported", "error", err)
+	}
+	cancelVersion()
+
 	// Initialize managers
 	datasetManager := dataset.NewManager(executor)
 	managers.SetDatasetManager(datasetManager)
+	pathResolver := resolver.New(datasetManager)
+	managers.SetPathResolver(pathResolver)
 
 	var datasetHandler *api.DatasetHandler
 	transferManager, err := dataset.NewTransferManager(logger.Config{LogLevel: cfg.Server.LogLevel})
@@ -84,6 +333,14 @@ func registerZFSRoutes(engine *gin.Engine) (error error) {
 	} else {
 		// Store shared instance for use by shutdown handler and gRPC handlers
 		sharedTransferManager = transferManager
+		crash.AddState("active_transfers", func() any { return transferManager.ListTransfers() })
+		transferManager.UseDelegation(datasetManager.Delegation())
+		if sharedJobQueue != nil {
+			transferManager.UseJobQueue(sharedJobQueue)
+		}
+		if sharedWebhooks != nil {
+			sharedWebhooks.WatchTransfers(transferManager)
+		}
 		managers.SetTransferManager(transferManager)
 
 		// Create dataset handler with transfer manager
@@ -95,6 +352,20 @@ func registerZFSRoutes(engine *gin.Engine) (error error) {
 
 	poolManager := pool.NewManager(executor)
 	poolHandler := api.NewPoolHandler(poolManager)
+	if l, lerr := logger.NewTag(logger.Config{LogLevel: cfg.Server.LogLevel}, "pool-monitor"); lerr == nil {
+		sharedScanMonitor = pool.NewScanMonitor(poolManager, l)
+		sharedScanMonitor.Start()
+		poolHandler.UseScanMonitor(sharedScanMonitor)
+
+		sharedCapacityMonitor = pool.NewCapacityMonitor(poolManager, l, config.GetConfigDir())
+		sharedCapacityMonitor.Start()
+		poolHandler.UseCapacityMonitor(sharedCapacityMonitor)
+
+		sharedIOStatSampler = pool.NewIOStatSampler(poolManager, l)
+		sharedIOStatSampler.Start()
+		poolHandler.UseIOStatSampler(sharedIOStatSampler)
+		metrics.Register(sharedIOStatSampler)
+	}
 
 	// API group with version
 	v1 := engine.Group(constants.APIZFS)
@@ -102,19 +373,64 @@ func registerZFSRoutes(engine *gin.Engine) (error error) {
 		// Register ZFS routes
 		datasetHandler.RegisterRoutes(v1)
 		poolHandler.RegisterRoutes(v1)
+		api.RegisterVersionRoutes(v1, executor)
+
+		if arcHandler, err := api.RegisterARCRoutes(v1); err != nil {
+			l.Warn("Failed to register ARC routes", "error", err)
+		} else {
+			sharedARCMonitor = arcHandler.Monitor()
+			metrics.Register(sharedARCMonitor)
+		}
 
 		schedulers := v1.Group("/schedulers")
 		{
+			// Register calendar routes; schedules of both policy kinds may reference calendars
+			var calendarHandler *calendars.Handler
+			if cfg.Features.ZFS.AutoSnapshots {
+				calendarHandler, err = api.RegisterCalendarRoutes(schedulers)
+				if err != nil {
+					l.Warn("Failed to register calendar routes", "error", err)
+				}
+			}
+
 			// Register auto-snapshot routes and store handler for use by other subsystems (e.g., inventory)
-			snapshotHandler, err := api.RegisterAutoSnapshotRoutes(schedulers, datasetManager)
-			if err == nil {
-				sharedSnapshotHandler = snapshotHandler
-				managers.SetSnapshotManager(snapshotHandler.Manager())
+			var snapshotHandler *autosnapshots.Handler
+			if cfg.Features.ZFS.AutoSnapshots {
+				snapshotHandler, err = api.RegisterAutoSnapshotRoutes(schedulers, datasetManager)
+				if err == nil {
+					sharedSnapshotHandler = snapshotHandler
+					snapshotHandler.RegisterTriggerRoutes(v1)
+					if sharedJobQueue != nil {
+						snapshotHandler.UseJobQueue(sharedJobQueue)
+					}
+					crash.AddState("snapshot_scheduler", func() any {
+						return snapshotHandler.Manager().Monitors()
+					})
+					managers.SetSnapshotManager(snapshotHandler.Manager())
+					pathResolver.UseSnapshotManager(snapshotHandler.Manager())
+					if calendarHandler != nil {
+						snapshotHandler.Manager().UseCalendars(calendarHandler.Manager())
+					}
+					if sharedWebhooks != nil {
+						sharedWebhooks.WatchSnapshots(snapshotHandler.Manager())
+					}
+				}
+				// If err != nil, sharedSnapshotHandler remains nil and inventory won't include snapshot policies
+			} else {
+				// Auto-attach rules, transfer policies and calendars are built on snapshot policies
+				registerDisabledFeature(schedulers, "/autosnapshot", featureAutoSnapshots)
+				registerDisabledFeature(schedulers, "/attach-rules", featureAutoSnapshots)
+				registerDisabledFeature(schedulers, "/transfers", featureAutoSnapshots)
+				registerDisabledFeature(schedulers, "/calendars", featureAutoSnapshots)
+				registerDisabledFeature(v1, "/snapshot-policies", featureAutoSnapshots)
 			}
-			// If err != nil, sharedSnapshotHandler remains nil and inventory won't include snapshot policies
 
 			// Register transfer policy routes
-			if snapshotHandler != nil && transferManager != nil {
+			if !cfg.Features.ZFS.AutoTransfers {
+				if cfg.Features.ZFS.AutoSnapshots {
+					registerDisabledFeature(schedulers, "/transfers", featureAutoTransfers)
+				}
+			} else if snapshotHandler != nil && transferManager != nil {
 				transferPolicyHandler, err := api.RegisterTransferPolicyRoutes(
 					schedulers,
 					transferManager,
@@ -122,17 +438,48 @@ func registerZFSRoutes(engine *gin.Engine) (error error) {
 				)
 				if err != nil {
 					// Log the error but don't fail startup
-					cfg := config.GetConfig()
-					if l, lerr := logger.NewTag(logger.Config{LogLevel: cfg.Server.LogLevel}, "routes"); lerr == nil {
-						l.Warn("Failed to register transfer policy routes", "error", err)
-					}
+					l.Warn("Failed to register transfer policy routes", "error", err)
 				} else {
 					sharedTransferPolicyHandler = transferPolicyHandler
 					managers.SetTransferPolicyManager(transferPolicyHandler.Manager())
+					metrics.Register(transferPolicyHandler.Manager())
+					pathResolver.UseTransferPolicyManager(transferPolicyHandler.Manager())
+					if sharedJobQueue != nil {
+						transferPolicyHandler.Manager().UseJobQueue(sharedJobQueue)
+					}
+					if calendarHandler != nil {
+						transferPolicyHandler.Manager().UseCalendars(calendarHandler.Manager())
+					}
+				}
+			}
+
+			// Register auto-attach rule routes; transfer templates need the transfer policy handler
+			if snapshotHandler != nil {
+				if _, err := api.RegisterAttachRuleRoutes(
+					schedulers,
+					datasetManager,
+					snapshotHandler,
+					sharedTransferPolicyHandler,
+				); err != nil {
+					l.Warn("Failed to register auto-attach rule routes", "error", err)
 				}
 			}
 		}
 
+		// Register snapshot retention (WORM compliance) routes
+		if _, err := api.RegisterRetentionRoutes(v1, datasetManager); err != nil {
+			l.Warn("Failed to register retention routes", "error", err)
+		}
+
+		// Replication topology routes depend on the transfer policy manager
+		if sharedTransferPolicyHandler != nil {
+			sharedTransferPolicyHandler.RegisterReplicationRoutes(v1)
+		} else if !cfg.Features.ZFS.AutoSnapshots {
+			registerDisabledFeature(v1, "/replication", featureAutoSnapshots)
+		} else if !cfg.Features.ZFS.AutoTransfers {
+			registerDisabledFeature(v1, "/replication", featureAutoTransfers)
+		}
+
 		// Health check routes
 		// v1.GET("/health", healthCheck)
 	}
@@ -160,6 +507,42 @@ func registerADRoutes(engine *gin.Engine) (adHandler *handlers.ADHandler, err er
 	return adHandler, nil
 }
 
+func registerADDCRoutes(engine *gin.Engine) error {
+	// Add error handler middleware
+	engine.Use(ErrorHandler())
+
+	addcHandler, err := handlers.NewADDCHandler()
+	if err != nil {
+		return err
+	}
+
+	v1 := engine.Group(constants.APIADDC)
+	{
+		addcHandler.RegisterRoutes(v1)
+	}
+	return nil
+}
+
+// registerDomainRoutes registers the domain membership routes the CLI uses
+// to join, leave and check the domain through the daemon
+func registerDomainRoutes(engine *gin.Engine) error {
+	l, err := logger.NewTag(config.NewLoggerConfig(config.GetConfig()), "domain")
+	if err != nil {
+		return err
+	}
+	client, err := domain.NewClient(l)
+	if err != nil {
+		return err
+	}
+	sharedDomainClient = client
+
+	v1 := engine.Group(constants.APIDomain)
+	{
+		domain.NewAPIHandler(client).RegisterRoutes(v1)
+	}
+	return nil
+}
+
 func registerServiceRoutes(engine *gin.Engine) (serviceHandler *svcAPI.ServiceHandler, err error) {
 	// Add error handler middleware
 	engine.Use(ErrorHandler())
@@ -228,7 +611,7 @@ func registerSharesRoutes(engine *gin.Engine) error {
 	// Create the SMB manager
 	executor := generalCmd.NewCommandExecutor(true)
 
-	// Create SMB manager (passing nil for fileOps to use default paths)
+	// Create SMB manager (passing nil for fileOps to use the shared privileged path allow-list)
 	smbManager, err := smb.NewManager(l, executor, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create SMB manager: %w", err)
@@ -244,14 +627,27 @@ func registerSharesRoutes(engine *gin.Engine) error {
 		}
 	}()
 
+	// Pick up infected file reports from vfs_virusfilter
+	go smbManager.WatchVirusEvents(context.Background())
+
 	// Create SMB service manager
 	smbService := smb.NewServiceManager(l)
 
+	// Show the dataset backing each share, and move shares with managed
+	// dataset renames; ZFS routes are registered first
+	if pathResolver := managers.GetPathResolver(); pathResolver != nil {
+		smbManager.UseResolver(pathResolver)
+		pathResolver.UseShares(smbManager)
+	}
+
 	// Store shared instance for use by other subsystems (e.g., inventory)
 	sharedSharesManager = smbManager
 
 	// Create the shares handler
 	sharesHandler := sharesAPI.NewSharesHandler(l, smbManager, smbService)
+	if sharedJobQueue != nil {
+		sharesHandler.UseJobQueue(sharedJobQueue)
+	}
 
 	// Register routes
 	v1 := engine.Group(constants.APIShares)
@@ -382,6 +778,20 @@ func registerDiskRoutes(engine *gin.Engine) (*diskAPI.DiskHandler, error) {
 		return nil, fmt.Errorf("failed to create disk manager: %w", err)
 	}
 
+	// Failing disks report the datasets and shares of their pool and are
+	// published to webhooks, including those found by the first checks
+	if pathResolver := managers.GetPathResolver(); pathResolver != nil {
+		diskManager.UsePoolResolver(pathResolver)
+	}
+	if sharedWebhooks != nil {
+		sharedWebhooks.WatchDisks(diskManager)
+	}
+
+	// Pools planned from discovered disks are created with a pool manager
+	// of their own
+	diskManager.UsePoolCreator(pool.NewManager(
+		command.NewCommandExecutor(true, logger.Config{LogLevel: config.GetConfig().Server.LogLevel})))
+
 	// Start disk manager
 	ctx := context.Background()
 	if err := diskManager.Start(ctx); err != nil {
@@ -390,6 +800,7 @@ func registerDiskRoutes(engine *gin.Engine) (*diskAPI.DiskHandler, error) {
 
 	// Store shared instance for use by other subsystems (e.g., inventory)
 	sharedDiskManager = diskManager
+	metrics.Register(diskManager.TemperatureMetrics())
 
 	// Create disk handler
 	diskHandler := diskAPI.NewDiskHandler(diskManager, l)
@@ -0,0 +1,107 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/scope"
+)
+
+// scopedRoutes are the routes whose handlers check the objects they touch
+// against the scope of a delegated key. Delegated keys are refused
+// everywhere else, so new routes stay closed to them until they check too.
+var scopedRoutes = map[string]bool{}
+
+func init() {
+	smb := constants.APIShares + "/smb"
+	ds := constants.APIDataset
+	policies := constants.APIZFS + "/schedulers/autosnapshot/policies"
+
+	for _, route := range []string{
+		http.MethodGet + " " + smb,
+		http.MethodGet + " " + smb + "/:name",
+		http.MethodPut + " " + smb + "/:name",
+		http.MethodDelete + " " + smb + "/:name",
+		http.MethodGet + " " + smb + "/:name/stats",
+		http.MethodGet + " " + smb + "/:name/revisions",
+		http.MethodPost + " " + smb + "/:name/rollback",
+		http.MethodPost + " " + smb + "/:name/restore",
+		http.MethodGet + " " + smb + "/:name/snapshots",
+		http.MethodPost + " " + smb + "/:name/snapshots/restore",
+		http.MethodGet + " " + smb + "/:name/snapshots/settings",
+
+		http.MethodPost + " " + ds + "/list",
+		http.MethodPost + " " + ds + "/filesystems/list",
+		http.MethodPost + " " + ds + "/volumes/list",
+		http.MethodPost + " " + ds + "/snapshots/list",
+		http.MethodPost + " " + ds + "/delete",
+		http.MethodPost + " " + ds + "/properties/list",
+		http.MethodPost + " " + ds + "/property/fetch",
+		http.MethodPost + " " + ds + "/property",
+		http.MethodPost + " " + ds + "/property/inherit",
+		http.MethodPost + " " + ds + "/filesystem/mount",
+		http.MethodPost + " " + ds + "/filesystem/unmount",
+		http.MethodPost + " " + ds + "/filesystem/snapdir/fetch",
+		http.MethodPost + " " + ds + "/filesystem/snapdir",
+		http.MethodPost + " " + ds + "/volume/info",
+		http.MethodPost + " " + ds + "/snapshot",
+		http.MethodPost + " " + ds + "/snapshot/rollback",
+		http.MethodPost + " " + ds + "/snapshot/pin",
+		http.MethodDelete + " " + ds + "/snapshot/pin",
+
+		http.MethodGet + " " + policies,
+		http.MethodGet + " " + policies + "/:id",
+		http.MethodPut + " " + policies + "/:id",
+		http.MethodDelete + " " + policies + "/:id",
+		http.MethodPost + " " + policies + "/:id/run",
+	} {
+		scopedRoutes[route] = true
+	}
+}
+
+// ScopeMiddleware limits requests carrying a delegated key to the objects
+// the key names. The key's name is recorded as the actor. Requests without
+// a delegated key, including those with trigger keys, pass unchanged.
+func ScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := c.GetHeader("X-API-Key")
+		if presented == "" {
+			if s, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok &&
+				strings.EqualFold(s, "Bearer") {
+				presented = strings.TrimSpace(token)
+			}
+		}
+		if presented == "" {
+			c.Next()
+			return
+		}
+
+		key, ok := scope.MatchKey(config.GetConfig().Delegation.Keys, presented)
+		if !ok {
+			c.Next()
+			return
+		}
+		sc := scope.FromKey(key)
+		if !scopedRoutes[c.Request.Method+" "+c.FullPath()] {
+			common.APIError(c, sc.Denied(scope.KindEndpoint, c.Request.URL.Path).
+				WithMetadata("method", c.Request.Method))
+			return
+		}
+
+		ctx := c.Request.Context()
+		actor := common.ActorFromContext(ctx)
+		actor.Source = common.ActorSourceScoped
+		actor.User = key.Name
+		ctx = common.WithActor(ctx, actor)
+		c.Request = c.Request.WithContext(scope.WithScope(ctx, sc))
+		c.Next()
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/scope"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.GetConfig()
+	saved := cfg.Delegation.Keys
+	cfg.Delegation.Keys = []config.DelegatedKey{
+		{Name: "eng-lead", Key: "eng-secret", Shares: []string{"eng"}},
+	}
+	t.Cleanup(func() { cfg.Delegation.Keys = saved })
+
+	var (
+		actor common.Actor
+		sc    *scope.Scope
+	)
+	record := func(c *gin.Context) {
+		actor = common.ActorFromContext(c.Request.Context())
+		sc = scope.FromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	}
+	engine := gin.New()
+	engine.Use(ActorMiddleware(), ScopeMiddleware())
+	engine.GET(constants.APIShares+"/smb/:name", record)
+	engine.GET(constants.APIShares+"/smb/global", record)
+
+	tests := []struct {
+		name       string
+		path       string
+		header     string
+		value      string
+		wantStatus int
+		wantScope  bool
+	}{
+		{"no key", "/smb/eng", "", "", http.StatusOK, false},
+		{"unknown key passes through", "/smb/eng", "X-API-Key", "other", http.StatusOK, false},
+		{"delegated key on scoped route", "/smb/eng", "X-API-Key", "eng-secret", http.StatusOK, true},
+		{"bearer token", "/smb/eng", "Authorization", "Bearer eng-secret", http.StatusOK, true},
+		{"delegated key on other route", "/smb/global", "X-API-Key", "eng-secret", http.StatusForbidden, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor, sc = common.Actor{}, nil
+			req := httptest.NewRequest(http.MethodGet, constants.APIShares+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantScope {
+				if assert.NotNil(t, sc) {
+					assert.Equal(t, []string{"eng"}, sc.Shares)
+				}
+				assert.Equal(t, common.ActorSourceScoped, actor.Source)
+				assert.Equal(t, "eng-lead", actor.User)
+			} else {
+				assert.Nil(t, sc)
+			}
+		})
+	}
+}
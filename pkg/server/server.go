@@ -7,8 +7,8 @@
 // We're using Gin's Engine (gin.New()) which provides:
 // - A router with middleware support
 // - HTTP handler implementation (ServeHTTP)
-// - Recovery middleware for handling panics
-// And then we add custom middlewares for logging, Sentry, etc.
+// And then we add custom middlewares for logging, panic recovery with crash
+// reports, Sentry, etc.
 //
 // When assigned to http.Server.Handler, we're using Gin's ServeHTTP method
 // since gin.Engine implements http.Handler interface
@@ -30,6 +30,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"time"
@@ -37,14 +38,33 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/internal/crash"
 	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/internal/metrics"
+	"github.com/stratastor/rodent/internal/schedwatch"
+	"github.com/stratastor/rodent/internal/selftest"
+	"github.com/stratastor/rodent/internal/services/addc"
+	"github.com/stratastor/rodent/internal/services/domain"
+	"github.com/stratastor/rodent/internal/services/manager"
+	"github.com/stratastor/rodent/internal/system/privilege"
 	"github.com/stratastor/rodent/internal/toggle"
+	"github.com/stratastor/rodent/pkg/guard"
 	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
 )
 
 // TODO: Review this logic
 var srv *http.Server
 
+// addcHealth holds the AD DC health monitor when the DC is enabled; its latest
+// report is included in the /health response
+var addcHealth *addc.HealthMonitor
+
+// selfTestReport holds the startup dependency probe; nil when the self-test is
+// disabled, which enables every subsystem
+var selfTestReport *selftest.Report
+
 const transfersShutdownTimeout = 60 * time.Second
 
 func Start(ctx context.Context, port int) error {
@@ -55,6 +75,28 @@ func Start(ctx context.Context, port int) error {
 	}
 	cfg := config.GetConfig()
 
+	// In dry-run mode state-changing commands and file writes are only logged
+	generalCmd.SetDryRun(cfg.Server.DryRun)
+	if cfg.Server.DryRun {
+		l.Warn("Dry-run mode enabled: changes to the system are logged, not applied")
+	}
+
+	// Route sudo commands and privileged file operations through the broker
+	if err := installPrivilegeBroker(l); err != nil {
+		return err
+	}
+
+	// Bound commands so a stuck one cannot hold up a manager
+	applyCommandTimeouts(l)
+
+	// Probe dependencies before any subsystem is started
+	if cfg.SelfTest.Enabled {
+		selfTestReport = selftest.Run(ctx, l, selftest.Options{
+			Enforce:     cfg.SelfTest.Enforce,
+			MinVersions: cfg.SelfTest.MinVersions,
+		})
+	}
+
 	toggle.StartRegistrationProcess(ctx, l)
 
 	// Switch to debug mode for non-production environments
@@ -68,17 +110,82 @@ func Start(ctx context.Context, port int) error {
 	// Create engine without middleware
 	engine := gin.New()
 
-	engine.Use(gin.Recovery())
+	// Panics are reported to the crash directory with the state registered
+	// by the subsystems below
+	crash.Setup(l, crash.DefaultDir())
+	crash.AddState("recent_events", func() any { return events.Recent() })
 
 	// Logging middleware
 	engine.Use(LoggerMiddleware(l))
 
+	// Turn handler panics into a 500 and a crash report; after the logger so
+	// the request ID is set and the failed request is logged
+	engine.Use(RecoveryMiddleware())
+
+	// Attribute changes to the client that asked for them
+	engine.Use(ActorMiddleware())
+
+	// Limit delegated keys to the shares, datasets and policies they name
+	engine.Use(ScopeMiddleware())
+
+	// Carry guard rule confirmations and override tokens to the handlers
+	engine.Use(guard.Middleware())
+
+	// Reject REST API requests when the api feature is disabled
+	engine.Use(APIFeatureGate())
+
 	// Register routes
 	engine.GET("/health", func(c *gin.Context) {
 		// TODO: Add sphisticated health check for Rodent
-		c.JSON(http.StatusOK, gin.H{"status": "healthy"})
+		resp := gin.H{"status": "healthy"}
+		if addcHealth != nil {
+			if report := addcHealth.Report(); report != nil {
+				resp["addc"] = report
+				if !report.Healthy {
+					resp["status"] = "degraded"
+				}
+			}
+		}
+		// Binaries probed for passwordless sudo, with remediation for
+		// those sudo refuses
+		if sudo := generalCmd.SudoStatuses(); len(sudo) > 0 {
+			resp["sudo"] = sudo
+			for _, s := range sudo {
+				if !s.OK {
+					resp["status"] = "degraded"
+				}
+			}
+		}
+		// Policy schedulers, with the panics of their tasks and restarts
+		if schedulers := schedwatch.Statuses(); len(schedulers) > 0 {
+			resp["schedulers"] = schedulers
+			for _, s := range schedulers {
+				if !s.Healthy() {
+					resp["status"] = "degraded"
+				}
+			}
+		}
+		c.JSON(http.StatusOK, resp)
 	})
 
+	// Metrics for Prometheus from the collectors subsystems register as
+	// they start
+	engine.GET("/metrics", func(c *gin.Context) {
+		c.Header("Content-Type", metrics.ContentType)
+		c.Status(http.StatusOK)
+		if err := metrics.WriteAll(c.Writer); err != nil {
+			l.Debug("Failed to write metrics", "error", err)
+		}
+	})
+
+	if cfg.Debug.Pprof {
+		registerPprofRoutes(engine)
+		l.Warn("Profiling enabled at /debug/pprof for clients on this host")
+	}
+	startLockWatcher(l)
+
+	registerSelfTestRoutes(engine)
+
 	// Register service routes
 	serviceHandler, err := registerServiceRoutes(engine)
 	if err != nil {
@@ -86,9 +193,26 @@ func Start(ctx context.Context, port int) error {
 	}
 	defer serviceHandler.Close()
 
-	err = registerZFSRoutes(engine)
-	if err != nil {
-		l.Error("Failed to register ZFS routes, continuing without ZFS functionality", "error", err)
+	// The job queue must exist before the ZFS managers that enqueue jobs
+	if err := registerJobRoutes(engine); err != nil {
+		l.Error("Failed to start job queue, background jobs run without retries", "error", err)
+	}
+
+	// Webhooks must be set up before the ZFS managers that publish events
+	if err := registerWebhookRoutes(engine); err != nil {
+		l.Error("Failed to set up webhooks, events will not be delivered", "error", err)
+	}
+
+	// Guard rules must be installed before the handlers that check them
+	if err := registerGuardRoutes(engine); err != nil {
+		l.Error("Failed to set up guard rules, destructive operations are not guarded", "error", err)
+	}
+
+	if selfTestReport.Enabled(selftest.SubsystemZFS) {
+		err = registerZFSRoutes(engine)
+		if err != nil {
+			l.Error("Failed to register ZFS routes, continuing without ZFS functionality", "error", err)
+		}
 	}
 
 	// Register ACL routes with graceful error handling
@@ -100,13 +224,20 @@ func Start(ctx context.Context, port int) error {
 	}
 
 	// Register shares routes with graceful error handling
-	err = registerSharesRoutes(engine)
-	if err != nil {
-		l.Error(
-			"Failed to register shares routes, continuing without shares functionality",
-			"error",
-			err,
-		)
+	if !cfg.Features.NFS {
+		registerDisabledFeature(engine, constants.APIShares+"/nfs", featureNFS)
+	}
+	if !cfg.Features.SMB {
+		registerDisabledFeature(engine, constants.APIShares+"/smb", featureSMB)
+	} else if selfTestReport.Enabled(selftest.SubsystemShares) {
+		err = registerSharesRoutes(engine)
+		if err != nil {
+			l.Error(
+				"Failed to register shares routes, continuing without shares functionality",
+				"error",
+				err,
+			)
+		}
 	}
 
 	// Register SSH key routes with graceful error handling
@@ -146,15 +277,19 @@ func Start(ctx context.Context, port int) error {
 	}
 
 	// Register disk management routes with graceful error handling
-	diskHandler, err := registerDiskRoutes(engine)
-	if err != nil {
-		l.Error(
-			"Failed to register disk routes, continuing without disk management functionality",
-			"error",
-			err,
-		)
-	} else {
-		_ = diskHandler // Handler doesn't implement Close() method yet
+	if !cfg.Features.Disks {
+		registerDisabledFeature(engine, constants.APIDisk, featureDisks)
+	} else if selfTestReport.Enabled(selftest.SubsystemDisk) {
+		diskHandler, err := registerDiskRoutes(engine)
+		if err != nil {
+			l.Error(
+				"Failed to register disk routes, continuing without disk management functionality",
+				"error",
+				err,
+			)
+		} else {
+			_ = diskHandler // Handler doesn't implement Close() method yet
+		}
 	}
 
 	// Register inventory routes
@@ -171,8 +306,25 @@ func Start(ctx context.Context, port int) error {
 		_ = inventoryHandler // Handler doesn't implement Close() method
 	}
 
+	// Register digest reports; they summarise the subsystems registered above
+	if err := registerReportRoutes(engine); err != nil {
+		l.Warn("Failed to register report routes, continuing without digests", "error", err)
+	}
+
+	// Register maintenance mode; it pauses the subsystems registered above
+	if err := registerMaintenanceRoutes(ctx, engine); err != nil {
+		l.Warn("Failed to register maintenance routes, continuing without maintenance mode", "error", err)
+	}
+
+	// Register consistency checks across the policy, share and disk managers
+	if err := registerConsistencyRoutes(ctx, engine); err != nil {
+		l.Warn("Failed to register consistency routes, continuing without consistency checks", "error", err)
+	}
+
 	// Start AD DC service if enabled in config
-	if cfg.AD.DC.Enabled {
+	if cfg.AD.DC.Enabled && !cfg.Features.ADDC {
+		l.Info("AD DC feature is disabled, the AD DC service will not be started")
+	} else if cfg.AD.DC.Enabled {
 		l.Info("AD DC service is enabled, starting the service...")
 
 		// Get the service manager
@@ -186,12 +338,20 @@ func Start(ctx context.Context, port int) error {
 			} else {
 				l.Info("AD DC service started successfully")
 			}
+
+			if cfg.AD.DC.HealthCheck.Enabled {
+				startADDCHealthMonitor(ctx, l, svcManager)
+			}
 		}
 
 		// Wait a moment for AD DC to initialize if it was just started
 		l.Info("Waiting for AD DC service to initialize before registering AD routes...")
 		// We don't need to sleep here as the AD client will retry connection if needed
+	}
 
+	if !cfg.Features.Domain {
+		registerDisabledFeature(engine, constants.APIAD, featureDomain)
+	} else if cfg.AD.DC.Enabled {
 		// Register AD routes with graceful error handling
 		adHandler, err := registerADRoutes(engine)
 		if err != nil {
@@ -203,28 +363,63 @@ func Start(ctx context.Context, port int) error {
 		} else {
 			defer adHandler.Close()
 		}
+	}
 
+	// Domain membership, used by the CLI
+	if !cfg.Features.Domain {
+		registerDisabledFeature(engine, constants.APIDomain, featureDomain)
+	} else if err := registerDomainRoutes(engine); err != nil {
+		l.Error("Failed to register domain routes, the CLI must use --local", "error", err)
+	}
+
+	// Register samba-tool provisioning routes for the self-hosted DC
+	if !cfg.Features.ADDC {
+		registerDisabledFeature(engine, constants.APIADDC, featureADDC)
+	} else if cfg.AD.DC.Enabled && cfg.AD.Mode == "self-hosted" {
+		if err := registerADDCRoutes(engine); err != nil {
+			l.Error(
+				"Failed to register AD DC routes, continuing without AD DC provisioning",
+				"error",
+				err,
+			)
+		}
+	}
+
+	// Periodically verify domain membership when the host is expected to be joined
+	if cfg.Features.Domain && cfg.AD.HealthCheck.Enabled &&
+		(cfg.AD.DC.Enabled || cfg.AD.Mode == "external") &&
+		selfTestReport.Enabled(selftest.SubsystemDomain) {
+		startDomainHealthMonitor(ctx, l)
+	}
+
+	addrs, listeners, err := apiListeners(l, cfg)
+	if err != nil {
+		return fmt.Errorf("server startup failed: %w", err)
 	}
+	l.Info("API listening", "addresses", addrs)
 
 	srv = &http.Server{
-		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
-		Handler: engine,
+		Addr:        addrs[0],
+		Handler:     engine,
+		ConnContext: socketConnContext,
 	}
 
 	// Channel to catch server startup errors
-	errChan := make(chan error, 1)
+	errChan := make(chan error, len(listeners))
 
 	// While gin.Run() would be simpler, it:
 	// - Doesn't support graceful shutdown
 	// - Blocks until the server exits
 	// - Doesn't integrate with our context-based lifecycle management from lifecycle package
-	go func() {
-		if err := srv.ListenAndServe(); err != nil {
-			if err != http.ErrServerClosed {
-				errChan <- err
+	for _, ln := range listeners {
+		go func(ln net.Listener) {
+			if err := srv.Serve(ln); err != nil {
+				if err != http.ErrServerClosed {
+					errChan <- err
+				}
 			}
-		}
-	}()
+		}(ln)
+	}
 
 	// Wait for either server error or context cancellation
 	select {
@@ -235,6 +430,106 @@ func Start(ctx context.Context, port int) error {
 	}
 }
 
+// installPrivilegeBroker validates the privilege policy and installs the
+// broker that checks and audits every privileged operation against it
+func installPrivilegeBroker(l logger.Logger) error {
+	cfg := config.GetConfig()
+
+	policy, err := privilege.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("invalid privilege policy: %w", err)
+	}
+
+	pl, err := logger.NewTag(config.NewLoggerConfig(cfg), "privilege")
+	if err != nil {
+		l.Warn("Failed to create privilege audit logger, using server logger", "error", err)
+		pl = l
+	}
+
+	privilege.NewBroker(pl, policy).Install()
+	return nil
+}
+
+// applyCommandTimeouts installs the configured command timeouts and kill
+// grace; invalid durations are logged and their defaults kept
+func applyCommandTimeouts(l logger.Logger) {
+	cfg := config.GetConfig()
+
+	timeouts := make(map[string]time.Duration, len(cfg.Commands.Timeouts))
+	for class, value := range cfg.Commands.Timeouts {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			l.Warn("Invalid command timeout, using default", "command", class, "timeout", value)
+			continue
+		}
+		timeouts[class] = timeout
+	}
+
+	grace, err := time.ParseDuration(cfg.Commands.KillGrace)
+	if err != nil || grace <= 0 {
+		l.Warn("Invalid command kill grace, using default",
+			"kill_grace", cfg.Commands.KillGrace,
+			"default", generalCmd.DefaultKillGrace)
+		grace = generalCmd.DefaultKillGrace
+	}
+
+	generalCmd.SetCommandTimeouts(timeouts, grace)
+}
+
+// startDomainHealthMonitor runs the domain health check in the background
+// until ctx is cancelled
+func startDomainHealthMonitor(ctx context.Context, l logger.Logger) {
+	cfg := config.GetConfig()
+
+	interval, err := time.ParseDuration(cfg.AD.HealthCheck.Interval)
+	if err != nil {
+		l.Warn("Invalid domain health check interval, using default",
+			"interval", cfg.AD.HealthCheck.Interval,
+			"error", err)
+		interval = 0
+	}
+
+	domainClient := sharedDomainClient
+	if domainClient == nil {
+		var err error
+		if domainClient, err = domain.NewClient(l); err != nil {
+			l.Warn("Failed to create domain client, domain health checks disabled", "error", err)
+			return
+		}
+	}
+
+	monitor := domain.NewHealthMonitor(domainClient, interval, cfg.AD.HealthCheck.AutoRepair)
+	crash.Go("domain-health", func() { monitor.Run(ctx) })
+}
+
+// startADDCHealthMonitor runs the AD DC health check in the background until
+// ctx is cancelled
+func startADDCHealthMonitor(ctx context.Context, l logger.Logger, svcManager *manager.ServiceManager) {
+	cfg := config.GetConfig()
+
+	svc, ok := svcManager.GetService("addc")
+	if !ok {
+		l.Warn("AD DC service not registered, AD DC health checks disabled")
+		return
+	}
+	addcClient, ok := svc.(*addc.Client)
+	if !ok {
+		l.Warn("Unexpected AD DC service type, AD DC health checks disabled")
+		return
+	}
+
+	interval, err := time.ParseDuration(cfg.AD.DC.HealthCheck.Interval)
+	if err != nil {
+		l.Warn("Invalid AD DC health check interval, using default",
+			"interval", cfg.AD.DC.HealthCheck.Interval,
+			"error", err)
+		interval = 0
+	}
+
+	addcHealth = addc.NewHealthMonitor(addcClient, interval)
+	crash.Go("addc-health", func() { addcHealth.Run(ctx) })
+}
+
 func Shutdown(ctx context.Context) error {
 	if srv == nil {
 		return nil
@@ -260,6 +555,30 @@ func Shutdown(ctx context.Context) error {
 		serviceMeta,
 	)
 
+	if sharedReports != nil {
+		sharedReports.Stop()
+	}
+	if sharedScanMonitor != nil {
+		sharedScanMonitor.Stop()
+	}
+	if sharedCapacityMonitor != nil {
+		sharedCapacityMonitor.Stop()
+	}
+	if sharedIOStatSampler != nil {
+		sharedIOStatSampler.Stop()
+	}
+	if sharedARCMonitor != nil {
+		sharedARCMonitor.Stop()
+	}
+	if sharedLockWatcher != nil {
+		sharedLockWatcher.Stop()
+	}
+
+	// Stop background jobs; unfinished jobs resume on the next start
+	if sharedJobQueue != nil {
+		sharedJobQueue.Stop()
+	}
+
 	// Shutdown active transfers before shutting down HTTP server
 	// This prevents orphaned transfer processes when Rodent exits
 	if sharedTransferManager != nil {
@@ -0,0 +1,207 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/stratastor/logger"
+)
+
+// PeerCred is the process on the other end of a Unix socket connection, as
+// reported by the kernel
+type PeerCred struct {
+	PID int
+	UID int
+	GID int
+}
+
+// User returns the peer's user name, or its UID if it has none
+func (p PeerCred) User() string {
+	if u, err := user.LookupId(strconv.Itoa(p.UID)); err == nil {
+		return u.Username
+	}
+	return strconv.Itoa(p.UID)
+}
+
+type peerCredKey struct{}
+
+// PeerCredFromContext returns the credentials of a request that came in over
+// the API socket
+func PeerCredFromContext(ctx context.Context) (PeerCred, bool) {
+	cred, ok := ctx.Value(peerCredKey{}).(PeerCred)
+	return cred, ok
+}
+
+// errPeerRejected is returned by connections from peers outside the socket
+// group
+var errPeerRejected = errors.New("API socket peer is not allowed")
+
+// peerConn is an accepted socket connection and its peer's credentials
+type peerConn struct {
+	net.Conn
+	cred PeerCred
+
+	// admit is the check of the peer left to its first read or write, or nil
+	admit func(PeerCred) bool
+	once  sync.Once
+	err   error
+}
+
+// admitted runs the peer check once, closing the connection if it fails
+func (c *peerConn) admitted() error {
+	c.once.Do(func() {
+		if c.admit != nil && !c.admit(c.cred) {
+			c.err = errPeerRejected
+			c.Conn.Close()
+		}
+	})
+	return c.err
+}
+
+func (c *peerConn) Read(b []byte) (int, error) {
+	if err := c.admitted(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *peerConn) Write(b []byte) (int, error) {
+	if err := c.admitted(); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+// socketConnContext carries the peer credentials of socket connections into
+// their requests; it is the http.Server ConnContext
+func socketConnContext(ctx context.Context, c net.Conn) context.Context {
+	if pc, ok := c.(*peerConn); ok {
+		return context.WithValue(ctx, peerCredKey{}, pc.cred)
+	}
+	return ctx
+}
+
+// socketListener accepts connections on the API socket from root, the user
+// Rodent runs as, and members of the socket group. Other peers are closed
+// without a response.
+type socketListener struct {
+	net.Listener
+	logger logger.Logger
+	gid    int // Socket group, or -1
+}
+
+// Accept returns the next connection from an allowed peer
+func (l *socketListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		cred, err := peerCredentials(conn)
+		if err != nil {
+			l.logger.Warn("Closing API socket connection without peer credentials", "error", err)
+			conn.Close()
+			continue
+		}
+		if l.trusted(cred) {
+			return &peerConn{Conn: conn, cred: cred}, nil
+		}
+		if l.gid < 0 {
+			l.logger.Warn("Rejected API socket connection", "pid", cred.PID, "uid", cred.UID)
+			conn.Close()
+			continue
+		}
+		// Supplementary groups are looked up in the connection's own
+		// goroutine, so a slow user database cannot hold up the accept loop
+		return &peerConn{Conn: conn, cred: cred, admit: func(cred PeerCred) bool {
+			if l.inGroup(cred) {
+				return true
+			}
+			l.logger.Warn("Rejected API socket connection", "pid", cred.PID, "uid", cred.UID)
+			return false
+		}}, nil
+	}
+}
+
+// allowed reports whether a peer may use the API socket
+func (l *socketListener) allowed(cred PeerCred) bool {
+	return l.trusted(cred) || (l.gid >= 0 && l.inGroup(cred))
+}
+
+// trusted reports whether a peer is allowed without looking up its
+// supplementary groups: root, the user Rodent runs as, and peers whose
+// primary group is the socket group
+func (l *socketListener) trusted(cred PeerCred) bool {
+	return cred.UID == 0 || cred.UID == os.Getuid() || (l.gid >= 0 && cred.GID == l.gid)
+}
+
+// inGroup reports whether the peer's user is a member of the socket group
+func (l *socketListener) inGroup(cred PeerCred) bool {
+	u, err := user.LookupId(strconv.Itoa(cred.UID))
+	if err != nil {
+		return false
+	}
+	groups, err := u.GroupIds()
+	return err == nil && slices.Contains(groups, strconv.Itoa(l.gid))
+}
+
+// listenSocket listens on a Unix socket at path. The socket is readable and
+// writable by its owner and, if group is set, by that group; peers are also
+// checked by their credentials. A stale socket left by a previous run is
+// replaced.
+func listenSocket(l logger.Logger, path, group string) (net.Listener, error) {
+	gid := -1
+	if group != "" {
+		g, err := user.LookupGroup(group)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server.socketGroup: %w", err)
+		}
+		if gid, err = strconv.Atoi(g.Gid); err != nil {
+			return nil, fmt.Errorf("invalid server.socketGroup: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	mode := os.FileMode(0600)
+	if gid >= 0 {
+		mode = 0660
+		if err := os.Chown(path, -1, gid); err != nil {
+			ln.Close()
+			return nil, fmt.Errorf("failed to set socket group: %w", err)
+		}
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+
+	return &socketListener{Listener: ln, logger: l, gid: gid}, nil
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build linux
+
+package server
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocketListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "rodent.sock")
+
+	// A stale socket from a previous run is replaced
+	stale, err := listenSocket(common.Log, path, "")
+	require.NoError(t, err)
+	stale.(*socketListener).Listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listenSocket(common.Log, path, "")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(ActorMiddleware())
+	engine.GET("/whoami", LoopbackOnly(), func(c *gin.Context) {
+		actor := common.ActorFromContext(c.Request.Context())
+		c.String(http.StatusOK, actor.User)
+	})
+	srv := &http.Server{Handler: engine, ConnContext: socketConnContext}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	cfg := httpclient.NewClientConfig()
+	cfg.BaseURL = "http://rodent"
+	cfg.UnixSocket = path
+	cfg.RetryCount = 0
+	resp, err := httpclient.NewClient(cfg).R().
+		SetHeader(common.ActorUserHeader, "someone-else").
+		Get("/whoami")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode(), "socket clients count as local")
+
+	me := PeerCred{UID: os.Getuid()}.User()
+	assert.Equal(t, me, resp.String(), "socket requests are attributed to the peer")
+
+	// Regular files are not replaced
+	file := filepath.Join(t.TempDir(), "not-a-socket")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	_, err = listenSocket(common.Log, file, "")
+	assert.Error(t, err)
+}
+
+func TestSocketListenerAllowed(t *testing.T) {
+	l := &socketListener{gid: -1}
+	assert.True(t, l.allowed(PeerCred{UID: 0}))
+	assert.True(t, l.allowed(PeerCred{UID: os.Getuid()}))
+	assert.False(t, l.allowed(PeerCred{UID: 65000, GID: 65000}))
+
+	l.gid = 65000
+	assert.True(t, l.allowed(PeerCred{UID: 65001, GID: 65000}))
+}
+
+func TestPeerConnAdmit(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+
+	checks := 0
+	pc := &peerConn{Conn: server, cred: PeerCred{UID: 65001}, admit: func(PeerCred) bool {
+		checks++
+		return false
+	}}
+
+	_, err := pc.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, errPeerRejected)
+	_, err = pc.Write([]byte("x"))
+	assert.ErrorIs(t, err, errPeerRejected)
+	assert.Equal(t, 1, checks, "the peer is checked once")
+
+	// The rejected connection is closed
+	_, err = client.Read(make([]byte, 1))
+	assert.Error(t, err)
+
+	client, server = net.Pipe()
+	defer client.Close()
+	pc = &peerConn{Conn: server, admit: func(PeerCred) bool { return true }}
+	go client.Write([]byte("ok"))
+	buf := make([]byte, 2)
+	n, err := pc.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(buf[:n]))
+}
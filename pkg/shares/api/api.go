@@ -7,12 +7,17 @@ package api
 import (
 	"net/http"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/scope"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stratastor/rodent/pkg/jobs"
 	"github.com/stratastor/rodent/pkg/shares"
 	"github.com/stratastor/rodent/pkg/shares/smb"
 )
@@ -22,6 +27,7 @@ type SharesHandler struct {
 	logger     logger.Logger
 	smbManager *smb.Manager
 	smbService *smb.ServiceManager
+	jobQueue   *jobs.Queue // Runs requests in the background, if set
 }
 
 // NewSharesHandler creates a new shares handler
@@ -50,10 +56,25 @@ func (h *SharesHandler) RegisterRoutes(router *gin.RouterGroup) {
 			smb.PUT("/:name", ValidateShareName(), ValidateSMBShareConfig(), h.updateSMBShare)
 			smb.DELETE("/:name", ValidateShareName(), h.deleteSMBShare)
 			smb.GET("/:name/stats", ValidateShareName(), h.getSMBStats)
+			smb.GET("/:name/revisions", ValidateShareName(), h.listSMBShareRevisions)
+			smb.POST("/:name/rollback", ValidateShareName(), h.rollbackSMBShare)
+			smb.POST("/:name/restore", ValidateShareName(), h.restoreSMBShare)
+			smb.GET("/:name/snapshots", ValidateShareName(), h.listSMBShareSnapshots)
+			smb.POST("/:name/snapshots/restore", ValidateShareName(), h.restoreSMBShareFile)
+			smb.GET("/:name/snapshots/settings", ValidateShareName(), h.getSMBShareSnapshotSettings)
+
+			// Deleted shares that can still be restored
+			smb.GET("/trash", h.listSMBShareTrash)
 
 			// Global SMB config
 			smb.GET("/global", h.getSMBGlobalConfig)
 			smb.PUT("/global", ValidateSMBGlobalConfig(), h.updateSMBGlobalConfig)
+			smb.GET("/global/versions", h.listSMBGlobalConfigVersions)
+			smb.POST("/global/versions/:version/revert", h.revertSMBGlobalConfig)
+
+			// Printing, when the smbPrinting feature is enabled
+			smb.GET("/printing", h.getSMBPrintingConfig)
+			smb.PUT("/printing", h.updateSMBPrintingConfig)
 
 			// Bulk operations
 			smb.PUT("/bulk-update", ValidateSMBBulkUpdateConfig(), h.bulkUpdateSMBShares)
@@ -69,6 +90,9 @@ func (h *SharesHandler) RegisterRoutes(router *gin.RouterGroup) {
 			smb.POST("/regenerate-config", h.RegenerateSMBConfig)
 		}
 
+		// Protocols sharing a directory, across SMB and NFS
+		sharesAPI.GET("/protocols", h.getSharePathProtocols)
+
 		// NFS and iSCSI can be added similarly when implementing them
 	}
 }
@@ -91,6 +115,12 @@ func ValidateShareName() gin.HandlerFunc {
 			return
 		}
 
+		// Delegated keys manage only the shares they name
+		if sc := scope.FromContext(c.Request.Context()); !sc.AllowsShare(name) {
+			APIError(c, sc.Denied(scope.KindShare, name))
+			return
+		}
+
 		c.Next()
 	}
 }
@@ -204,6 +234,20 @@ func ValidateSMBBulkUpdateConfig() gin.HandlerFunc {
 func (h *SharesHandler) deleteSMBShare(c *gin.Context) {
 	name := c.Param("name")
 
+	// Checked before a background delete is queued, while the request's
+	// confirmation or override token is at hand
+	if err := guard.Check(c.Request.Context(), guard.Request{
+		Operation: guard.OpShareDelete,
+		Targets:   []string{name},
+	}); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	if h.startOperation(c, OpDeleteSMBShare, shareNamePayload{Name: name}) {
+		return
+	}
+
 	if err := h.smbManager.DeleteShare(c.Request.Context(), name); err != nil {
 		APIError(c, err)
 		return
@@ -220,17 +264,23 @@ func (h *SharesHandler) listSMBShares(c *gin.Context) {
 		return
 	}
 
+	if sc := scope.FromContext(c.Request.Context()); sc != nil {
+		result = slices.DeleteFunc(result, func(s shares.ShareConfig) bool {
+			return !sc.AllowsShare(s.Name)
+		})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"shares": result,
 		"count":  len(result),
 	})
 }
 
-// getSMBShare gets an SMB share by name
+// getSMBShare gets an SMB share by name, with the dataset backing its path
 func (h *SharesHandler) getSMBShare(c *gin.Context) {
 	name := c.Param("name")
 
-	share, err := h.smbManager.GetSMBShare(c.Request.Context(), name)
+	share, err := h.smbManager.GetSMBShareDetail(c.Request.Context(), name)
 	if err != nil {
 		APIError(c, err)
 		return
@@ -292,15 +342,26 @@ func (h *SharesHandler) createSMBShare(c *gin.Context) {
 		defaultConfig.Browsable = rawConfig.Browsable
 		defaultConfig.GuestOk = rawConfig.GuestOk
 		defaultConfig.Public = rawConfig.Public
+		defaultConfig.GuestAccess = rawConfig.GuestAccess
 		defaultConfig.InheritACLs = rawConfig.InheritACLs
 		defaultConfig.MapACLInherit = rawConfig.MapACLInherit
 		defaultConfig.FollowSymlinks = rawConfig.FollowSymlinks
+		defaultConfig.AccessBasedEnumeration = rawConfig.AccessBasedEnumeration
+		defaultConfig.HideUnreadable = rawConfig.HideUnreadable
+		defaultConfig.Worm = rawConfig.Worm
+		defaultConfig.VirusFilter = rawConfig.VirusFilter
+		defaultConfig.MaxConnections = rawConfig.MaxConnections
+		defaultConfig.RateLimit = rawConfig.RateLimit
 
 		smbConfig = *defaultConfig
 	} else {
 		smbConfig = config.(smb.SMBShareConfig)
 	}
 
+	if h.startOperation(c, OpCreateSMBShare, smbConfig) {
+		return
+	}
+
 	if err := h.smbManager.CreateShare(c.Request.Context(), &smbConfig); err != nil {
 		APIError(c, err)
 		return
@@ -338,6 +399,27 @@ func (h *SharesHandler) updateSMBShare(c *gin.Context) {
 		return
 	}
 
+	// A delegated key may not point its share at another path, which could
+	// lie outside the datasets it manages
+	if scope.FromContext(c.Request.Context()) != nil {
+		current, err := h.smbManager.GetShare(c.Request.Context(), name)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		if filepath.Clean(current.Path) != filepath.Clean(smbConfig.Path) {
+			APIError(c, errors.New(errors.PermissionDenied,
+				"API key may not change the path of a share").
+				WithMetadata("name", name).
+				WithMetadata("path", smbConfig.Path))
+			return
+		}
+	}
+
+	if h.startOperation(c, OpUpdateSMBShare, smbConfig) {
+		return
+	}
+
 	if err := h.smbManager.UpdateShare(c.Request.Context(), name, &smbConfig); err != nil {
 		APIError(c, err)
 		return
@@ -376,6 +458,137 @@ func (h *SharesHandler) getSMBStats(c *gin.Context) {
 	c.JSON(http.StatusOK, stats)
 }
 
+// listSMBShareRevisions lists the saved revisions of an SMB share
+func (h *SharesHandler) listSMBShareRevisions(c *gin.Context) {
+	name := c.Param("name")
+
+	revisions, err := h.smbManager.ListShareRevisions(c.Request.Context(), name)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":      name,
+		"revisions": revisions,
+		"count":     len(revisions),
+	})
+}
+
+// rollbackSMBShare restores the share revision given by the rev query
+// parameter
+func (h *SharesHandler) rollbackSMBShare(c *gin.Context) {
+	name := c.Param("name")
+
+	rev, err := strconv.Atoi(c.Query("rev"))
+	if err != nil || rev < 1 {
+		APIError(c, errors.New(errors.SharesInvalidInput, "rev must be a positive integer").
+			WithMetadata("rev", c.Query("rev")))
+		return
+	}
+
+	revision, err := h.smbManager.RollbackShare(c.Request.Context(), name, rev)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Share rolled back successfully",
+		"name":        name,
+		"revision":    revision.Revision,
+		"rollback_of": revision.RollbackOf,
+	})
+}
+
+// listSMBShareTrash lists the deleted shares that can still be restored
+func (h *SharesHandler) listSMBShareTrash(c *gin.Context) {
+	trashed, err := h.smbManager.ListTrashedShares(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"shares": trashed,
+		"count":  len(trashed),
+	})
+}
+
+// restoreSMBShare recreates a deleted share from the trash. The id query
+// parameter picks a trash entry when the name was deleted more than once.
+func (h *SharesHandler) restoreSMBShare(c *gin.Context) {
+	name := c.Param("name")
+
+	config, err := h.smbManager.RestoreShare(c.Request.Context(), name, c.Query("id"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Share restored successfully",
+		"name":    name,
+		"config":  config,
+	})
+}
+
+// listSMBShareSnapshots lists the snapshots of the dataset behind a share
+func (h *SharesHandler) listSMBShareSnapshots(c *gin.Context) {
+	name := c.Param("name")
+
+	snapshots, err := h.smbManager.ListShareSnapshots(c.Request.Context(), name)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":      name,
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+	})
+}
+
+// getSMBShareSnapshotSettings reports the snapdir and Previous Versions
+// settings of a share, with warnings where they disagree
+func (h *SharesHandler) getSMBShareSnapshotSettings(c *gin.Context) {
+	settings, err := h.smbManager.ShareSnapshotSettings(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// restoreSMBShareFile restores a path within a share from one of its
+// snapshots
+func (h *SharesHandler) restoreSMBShareFile(c *gin.Context) {
+	var req smb.RestoreShareFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(
+			c,
+			errors.New(
+				errors.ServerRequestValidation,
+				"Invalid restore request: "+err.Error(),
+			),
+		)
+		return
+	}
+
+	restored, err := h.smbManager.RestoreShareFile(c.Request.Context(), c.Param("name"), &req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "File restored successfully",
+		"restored": restored,
+	})
+}
+
 // getSMBGlobalConfig gets the global SMB configuration
 func (h *SharesHandler) getSMBGlobalConfig(c *gin.Context) {
 	config, err := h.smbManager.GetGlobalConfig(c.Request.Context())
@@ -400,13 +613,133 @@ func (h *SharesHandler) updateSMBGlobalConfig(c *gin.Context) {
 
 	smbGlobalConfig := config.(smb.SMBGlobalConfig)
 
-	if err := h.smbManager.UpdateGlobalConfig(c.Request.Context(), &smbGlobalConfig); err != nil {
+	// Changes are only previewed unless the caller asks for them to be applied
+	if c.Query("apply") != "true" {
+		preview, err := h.smbManager.PreviewGlobalConfig(c.Request.Context(), &smbGlobalConfig)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"applied": false,
+			"changed": preview.Changed,
+			"diff":    preview.Diff,
+		})
+		return
+	}
+
+	if h.startOperation(c, OpUpdateSMBGlobalConfig, smbGlobalConfig) {
+		return
+	}
+
+	version, err := h.smbManager.UpdateGlobalConfig(c.Request.Context(), &smbGlobalConfig)
+	if err != nil {
 		APIError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Global SMB configuration updated successfully",
+		"applied": true,
+		"version": version.Version,
+	})
+}
+
+// listSMBGlobalConfigVersions lists the applied global SMB configurations
+func (h *SharesHandler) listSMBGlobalConfigVersions(c *gin.Context) {
+	versions, err := h.smbManager.ListGlobalConfigVersions(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"versions": versions,
+		"count":    len(versions),
+	})
+}
+
+// revertSMBGlobalConfig applies an earlier global SMB configuration again
+func (h *SharesHandler) revertSMBGlobalConfig(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		APIError(c, errors.New(errors.SharesInvalidInput, "Version must be a positive integer").
+			WithMetadata("version", c.Param("version")))
+		return
+	}
+
+	applied, err := h.smbManager.RevertGlobalConfig(c.Request.Context(), version)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Global SMB configuration reverted successfully",
+		"version":   applied.Version,
+		"revert_of": applied.RevertOf,
+	})
+}
+
+// getSharePathProtocols shows the protocols sharing the directory given by
+// the path query parameter, or every SMB share path also exported over NFS
+func (h *SharesHandler) getSharePathProtocols(c *gin.Context) {
+	if path := c.Query("path"); path != "" {
+		view, err := h.smbManager.GetPathProtocols(c.Request.Context(), path)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, view)
+		return
+	}
+
+	views, err := h.smbManager.ListMultiProtocolPaths(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"paths": views,
+		"count": len(views),
+	})
+}
+
+// getSMBPrintingConfig gets the SMB printing configuration
+func (h *SharesHandler) getSMBPrintingConfig(c *gin.Context) {
+	config, err := h.smbManager.GetPrintingConfig(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, config)
+}
+
+// updateSMBPrintingConfig updates the [printers] and print$ sections
+func (h *SharesHandler) updateSMBPrintingConfig(c *gin.Context) {
+	var config smb.SMBPrintingConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		APIError(
+			c,
+			errors.New(
+				errors.ServerRequestValidation,
+				"Invalid SMB printing configuration: "+err.Error(),
+			),
+		)
+		return
+	}
+
+	if err := h.smbManager.UpdatePrintingConfig(c.Request.Context(), &config); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "SMB printing configuration updated successfully",
+		"printing": config,
 	})
 }
 
@@ -426,6 +759,10 @@ func (h *SharesHandler) bulkUpdateSMBShares(c *gin.Context) {
 
 	bulkConfig := config.(smb.SMBBulkUpdateConfig)
 
+	if h.startOperation(c, OpBulkUpdateSMBShares, bulkConfig) {
+		return
+	}
+
 	// Process the bulk update
 	results, err := h.smbManager.BulkUpdateShares(c.Request.Context(), bulkConfig)
 	if err != nil {
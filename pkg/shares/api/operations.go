@@ -0,0 +1,118 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+// Operation types of the SMB requests that can run in the background
+const (
+	OpCreateSMBShare        = "smb.create_share"
+	OpUpdateSMBShare        = "smb.update_share"
+	OpDeleteSMBShare        = "smb.delete_share"
+	OpBulkUpdateSMBShares   = "smb.bulk_update"
+	OpUpdateSMBGlobalConfig = "smb.update_global"
+)
+
+// shareNamePayload is the payload of operations on a share by name
+type shareNamePayload struct {
+	Name string `json:"name"`
+}
+
+// UseJobQueue lets share and global config changes run in the background.
+// Clients opt in per request with ?async=true or "Prefer: respond-async",
+// and get 202 Accepted with an operation to poll. Without a queue every
+// request runs synchronously.
+func (h *SharesHandler) UseJobQueue(q *jobs.Queue) {
+	q.Register(jobs.OperationTypePrefix+OpCreateSMBShare, h.runCreateShare, jobs.OperationRetryPolicy)
+	q.Register(jobs.OperationTypePrefix+OpUpdateSMBShare, h.runUpdateShare, jobs.OperationRetryPolicy)
+	q.Register(jobs.OperationTypePrefix+OpDeleteSMBShare, h.runDeleteShare, jobs.OperationRetryPolicy)
+	q.Register(jobs.OperationTypePrefix+OpBulkUpdateSMBShares, h.runBulkUpdate, jobs.OperationRetryPolicy)
+	q.Register(jobs.OperationTypePrefix+OpUpdateSMBGlobalConfig, h.runUpdateGlobalConfig, jobs.OperationRetryPolicy)
+	h.jobQueue = q
+}
+
+// startOperation answers the request with 202 Accepted and runs it in the
+// background if the client asked for it and a job queue is in use. It
+// reports whether the request was handed off.
+func (h *SharesHandler) startOperation(c *gin.Context, opType string, payload any) bool {
+	if h.jobQueue == nil || !jobs.WantsAsync(c) {
+		return false
+	}
+	if err := jobs.Accepted(c, h.jobQueue, opType, payload); err != nil {
+		APIError(c, err)
+	}
+	return true
+}
+
+// runCreateShare creates a share from an accepted request
+func (h *SharesHandler) runCreateShare(ctx context.Context, payload json.RawMessage) error {
+	var config smb.SMBShareConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := h.smbManager.CreateShare(ctx, &config); err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, shareNamePayload{Name: config.Name})
+}
+
+// runUpdateShare updates a share from an accepted request
+func (h *SharesHandler) runUpdateShare(ctx context.Context, payload json.RawMessage) error {
+	var config smb.SMBShareConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := h.smbManager.UpdateShare(ctx, config.Name, &config); err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, shareNamePayload{Name: config.Name})
+}
+
+// runDeleteShare deletes a share from an accepted request
+func (h *SharesHandler) runDeleteShare(ctx context.Context, payload json.RawMessage) error {
+	var p shareNamePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := h.smbManager.DeleteShare(ctx, p.Name); err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, p)
+}
+
+// runBulkUpdate applies a bulk update from an accepted request. The
+// per-share results are the operation's result.
+func (h *SharesHandler) runBulkUpdate(ctx context.Context, payload json.RawMessage) error {
+	var config smb.SMBBulkUpdateConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return jobs.Permanent(err)
+	}
+	results, err := h.smbManager.BulkUpdateShares(ctx, config)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, gin.H{"results": results})
+}
+
+// runUpdateGlobalConfig applies a global config from an accepted request.
+// The applied version is the operation's result.
+func (h *SharesHandler) runUpdateGlobalConfig(ctx context.Context, payload json.RawMessage) error {
+	var config smb.SMBGlobalConfig
+	if err := json.Unmarshal(payload, &config); err != nil {
+		return jobs.Permanent(err)
+	}
+	version, err := h.smbManager.UpdateGlobalConfig(ctx, &config)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, gin.H{"version": version.Version})
+}
@@ -8,8 +8,10 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/shares/smb"
 	"github.com/stratastor/toggle-rodent-proto/proto"
 )
@@ -127,8 +129,8 @@ func handleSMBGet(h *SharesHandler) client.CommandHandler {
 		// Create a context
 		ctx := context.Background()
 
-		// Call the manager's GetSMBShare method
-		share, err := h.smbManager.GetSMBShare(ctx, payload.Name)
+		// Include the dataset backing the share's path
+		share, err := h.smbManager.GetSMBShareDetail(ctx, payload.Name)
 		if err != nil {
 			return nil, err
 		}
@@ -137,6 +139,14 @@ func handleSMBGet(h *SharesHandler) client.CommandHandler {
 	}
 }
 
+// actorContext returns a context attributing changes to the Toggle request
+func actorContext(req *proto.ToggleRequest) context.Context {
+	return common.WithActor(context.Background(), common.Actor{
+		Source:    common.ActorSourceToggle,
+		RequestID: req.RequestId,
+	})
+}
+
 // handleSMBCreate returns a handler for creating an SMB share
 func handleSMBCreate(h *SharesHandler) client.CommandHandler {
 	return func(req *proto.ToggleRequest, cmd *proto.CommandRequest) (*proto.CommandResponse, error) {
@@ -155,7 +165,7 @@ func handleSMBCreate(h *SharesHandler) client.CommandHandler {
 		}
 
 		// Create a context
-		ctx := context.Background()
+		ctx := actorContext(req)
 
 		// Create a new config with defaults if necessary
 		defaultConfig := smb.NewSMBShareConfig(config.Name, config.Path)
@@ -198,9 +208,16 @@ func handleSMBCreate(h *SharesHandler) client.CommandHandler {
 		defaultConfig.Browsable = config.Browsable
 		defaultConfig.GuestOk = config.GuestOk
 		defaultConfig.Public = config.Public
+		defaultConfig.GuestAccess = config.GuestAccess
 		defaultConfig.InheritACLs = config.InheritACLs
 		defaultConfig.MapACLInherit = config.MapACLInherit
 		defaultConfig.FollowSymlinks = config.FollowSymlinks
+		defaultConfig.AccessBasedEnumeration = config.AccessBasedEnumeration
+		defaultConfig.HideUnreadable = config.HideUnreadable
+		defaultConfig.Worm = config.Worm
+		defaultConfig.VirusFilter = config.VirusFilter
+		defaultConfig.MaxConnections = config.MaxConnections
+		defaultConfig.RateLimit = config.RateLimit
 
 		// Call the manager's CreateShare method
 		if err := h.smbManager.CreateShare(ctx, defaultConfig); err != nil {
@@ -234,7 +251,7 @@ func handleSMBUpdate(h *SharesHandler) client.CommandHandler {
 		}
 
 		// Create a context
-		ctx := context.Background()
+		ctx := actorContext(req)
 
 		// Call the manager's UpdateShare method
 		if err := h.smbManager.UpdateShare(ctx, config.Name, &config); err != nil {
@@ -265,8 +282,13 @@ func handleSMBDelete(h *SharesHandler) client.CommandHandler {
 			return nil, errors.New(errors.SharesInvalidInput, "Share name cannot be empty")
 		}
 
-		// Create a context
-		ctx := context.Background()
+		ctx := guard.WithApproval(actorContext(req), guard.ApprovalFromPayload(cmd.Payload))
+		if err := guard.Check(ctx, guard.Request{
+			Operation: guard.OpShareDelete,
+			Targets:   []string{payload.Name},
+		}); err != nil {
+			return nil, err
+		}
 
 		// Call the manager's DeleteShare method
 		if err := h.smbManager.DeleteShare(ctx, payload.Name); err != nil {
@@ -356,13 +378,15 @@ func handleSMBGlobalUpdate(h *SharesHandler) client.CommandHandler {
 		ctx := context.Background()
 
 		// Call the manager's UpdateGlobalConfig method
-		if err := h.smbManager.UpdateGlobalConfig(ctx, &config); err != nil {
+		version, err := h.smbManager.UpdateGlobalConfig(ctx, &config)
+		if err != nil {
 			return nil, err
 		}
 
 		// Return success response
 		response := map[string]interface{}{
 			"message": "Global SMB configuration updated successfully",
+			"version": version.Version,
 		}
 		return successResponse(
 			req.RequestId,
@@ -389,7 +413,7 @@ func handleSMBBulkUpdate(h *SharesHandler) client.CommandHandler {
 		}
 
 		// Create a context
-		ctx := context.Background()
+		ctx := actorContext(req)
 
 		// Process the bulk update
 		results, err := h.smbManager.BulkUpdateShares(ctx, config)
@@ -785,7 +785,7 @@ func TestSMBGlobalConfig(t *testing.T) {
 
 	// Register deferred restoration
 	defer func() {
-		if err := smbManager.UpdateGlobalConfig(context.Background(), &finalConfig); err != nil {
+		if _, err := smbManager.UpdateGlobalConfig(context.Background(), &finalConfig); err != nil {
 			t.Logf("Failed to restore original global config: %v", err)
 		}
 	}()
@@ -803,7 +803,7 @@ func TestSMBGlobalConfig(t *testing.T) {
 		updatedConfig.CustomParameters["client min protocol"] = "SMB2"
 		updatedConfig.CustomParameters["client max protocol"] = "SMB3"
 
-		err := smbManager.UpdateGlobalConfig(ctx, &updatedConfig)
+		_, err := smbManager.UpdateGlobalConfig(ctx, &updatedConfig)
 		if err != nil {
 			t.Fatalf("Failed to update global config: %v", err)
 		}
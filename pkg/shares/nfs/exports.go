@@ -0,0 +1,153 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package nfs reads the NFS exports of the host. Rodent does not manage NFS
+// shares yet, but exports made by hand or through the ZFS sharenfs property
+// are read so other protocols sharing the same paths can account for them.
+package nfs
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+var (
+	// exportsFile and exportsDir are where exportfs reads exports from. ZFS
+	// writes the sharenfs exports to zfs.exports in exportsDir.
+	exportsFile = "/etc/exports"
+	exportsDir  = "/etc/exports.d"
+)
+
+// Export is a directory exported over NFS
+type Export struct {
+	Path    string   `json:"path"`
+	Clients []Client `json:"clients"`
+	Source  string   `json:"source"` // File the export was read from
+}
+
+// Client is a host, network or netgroup an export is shared with, and its
+// options
+type Client struct {
+	Host    string   `json:"host"`
+	Options []string `json:"options,omitempty"`
+}
+
+// HasOption reports whether the client's options include opt
+func (c Client) HasOption(opt string) bool {
+	return slices.Contains(c.Options, opt)
+}
+
+// Writable reports whether the export is writable by any client. Exports
+// are read-only unless rw is given.
+func (e Export) Writable() bool {
+	return slices.ContainsFunc(e.Clients, func(c Client) bool { return c.HasOption("rw") })
+}
+
+// Async reports whether any client is served with async, which acknowledges
+// writes before they are stable
+func (e Export) Async() bool {
+	return slices.ContainsFunc(e.Clients, func(c Client) bool { return c.HasOption("async") })
+}
+
+// ReadExports returns the exports of /etc/exports and /etc/exports.d. Missing
+// files are not an error.
+func ReadExports() ([]Export, error) {
+	files := []string{exportsFile}
+	extra, err := filepath.Glob(filepath.Join(exportsDir, "*.exports"))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "find_nfs_exports")
+	}
+	slices.Sort(extra)
+	files = append(files, extra...)
+
+	var exports []Export
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errors.Wrap(err, errors.SharesOperationFailed).
+				WithMetadata("operation", "read_nfs_exports").
+				WithMetadata("file", file)
+		}
+		exports = append(exports, ParseExports(string(data), file)...)
+	}
+	return exports, nil
+}
+
+// ParseExports parses exports in the exports(5) format. Lines that cannot be
+// parsed are skipped.
+func ParseExports(content, source string) []Export {
+	// Join continued lines
+	content = strings.ReplaceAll(content, "\\\n", " ")
+
+	var exports []Export
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		path, rest := splitPath(line)
+		if !strings.HasPrefix(path, "/") {
+			continue
+		}
+		export := Export{Path: filepath.Clean(path), Source: source}
+
+		// A leading -options applies to every client listed after it
+		var defaults []string
+		for _, field := range strings.Fields(rest) {
+			if strings.HasPrefix(field, "-") {
+				defaults = splitOptions(strings.TrimPrefix(field, "-"))
+				continue
+			}
+			host, opts, _ := strings.Cut(field, "(")
+			options := slices.Clone(defaults)
+			if opts != "" {
+				options = append(options, splitOptions(strings.TrimSuffix(opts, ")"))...)
+			}
+			export.Clients = append(export.Clients, Client{Host: host, Options: options})
+		}
+		// An export without clients is shared with everyone
+		if len(export.Clients) == 0 {
+			export.Clients = []Client{{Host: "*", Options: defaults}}
+		}
+		exports = append(exports, export)
+	}
+	return exports
+}
+
+// splitPath splits the possibly quoted export path from the client list
+func splitPath(line string) (string, string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			return line[1 : end+1], line[end+2:]
+		}
+	}
+	i := strings.IndexAny(line, " \t")
+	if i < 0 {
+		return line, ""
+	}
+	return line[:i], line[i:]
+}
+
+// splitOptions splits a comma separated option list
+func splitOptions(opts string) []string {
+	var options []string
+	for _, opt := range strings.Split(opts, ",") {
+		if opt = strings.TrimSpace(opt); opt != "" {
+			options = append(options, opt)
+		}
+	}
+	return options
+}
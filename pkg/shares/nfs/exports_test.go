@@ -0,0 +1,67 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package nfs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExports(t *testing.T) {
+	content := `# exports
+/tank/data  10.0.0.0/24(rw,sync,no_subtree_check) host1(ro)
+"/tank/with space" *(rw,async)
+/tank/defaults -rw,sync \
+    hostA hostB(no_root_squash)
+/tank/open
+not-a-path host(rw)
+`
+	exports := ParseExports(content, "/etc/exports")
+	require.Len(t, exports, 4)
+
+	assert.Equal(t, "/tank/data", exports[0].Path)
+	require.Len(t, exports[0].Clients, 2)
+	assert.Equal(t, "10.0.0.0/24", exports[0].Clients[0].Host)
+	assert.True(t, exports[0].Writable())
+	assert.False(t, exports[0].Async())
+
+	assert.Equal(t, "/tank/with space", exports[1].Path)
+	assert.True(t, exports[1].Async())
+
+	assert.Equal(t, "/tank/defaults", exports[2].Path)
+	require.Len(t, exports[2].Clients, 2)
+	assert.Equal(t, []string{"rw", "sync"}, exports[2].Clients[0].Options)
+	assert.Equal(t, []string{"rw", "sync", "no_root_squash"}, exports[2].Clients[1].Options)
+
+	assert.Equal(t, []Client{{Host: "*"}}, exports[3].Clients)
+	assert.False(t, exports[3].Writable())
+}
+
+func TestReadExports(t *testing.T) {
+	dir := t.TempDir()
+	savedFile, savedDir := exportsFile, exportsDir
+	exportsFile = filepath.Join(dir, "exports")
+	exportsDir = filepath.Join(dir, "exports.d")
+	t.Cleanup(func() { exportsFile, exportsDir = savedFile, savedDir })
+
+	exports, err := ReadExports()
+	require.NoError(t, err)
+	assert.Empty(t, exports)
+
+	require.NoError(t, os.MkdirAll(exportsDir, 0755))
+	require.NoError(t, os.WriteFile(exportsFile, []byte("/srv/a *(ro)\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(exportsDir, "zfs.exports"),
+		[]byte("/tank/b *(sec=sys,rw,no_subtree_check)\n"), 0644))
+
+	exports, err = ReadExports()
+	require.NoError(t, err)
+	require.Len(t, exports, 2)
+	assert.Equal(t, "/tank/b", exports[1].Path)
+	assert.Equal(t, filepath.Join(exportsDir, "zfs.exports"), exports[1].Source)
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Supported idmap backends for trusted domains
+const (
+	IDMapBackendRID     = "rid"
+	IDMapBackendAD      = "ad"
+	IDMapBackendAutoRID = "autorid"
+	IDMapBackendTDB     = "tdb"
+)
+
+var idmapBackends = []string{IDMapBackendRID, IDMapBackendAD, IDMapBackendAutoRID, IDMapBackendTDB}
+
+// winbind enumeration parameters, managed through WinbindEnumUsers and
+// WinbindEnumGroups when set
+const (
+	winbindEnumUsersParam  = "winbind enum users"
+	winbindEnumGroupsParam = "winbind enum groups"
+)
+
+// SMBTrustedDomain is a domain trusted by the joined domain whose users and
+// groups may be named in share ACLs. Each domain gets its own idmap range so
+// IDs stay stable and never collide across domains.
+type SMBTrustedDomain struct {
+	// Name is the NetBIOS name of the domain (e.g., "PARTNER")
+	Name string `json:"name"`
+	// Realm is the DNS name of the domain, accepted in user@realm principals
+	Realm string `json:"realm,omitempty"`
+	// IDMapBackend is rid (default), ad, autorid or tdb
+	IDMapBackend   string `json:"idmap_backend,omitempty"`
+	IDMapRangeLow  uint32 `json:"idmap_range_low"`
+	IDMapRangeHigh uint32 `json:"idmap_range_high"`
+	// IDMapSchemaMode is used by the ad backend (rfc2307 by default)
+	IDMapSchemaMode string `json:"idmap_schema_mode,omitempty"`
+}
+
+// idmapBackend returns the configured backend, defaulting to rid
+func (d *SMBTrustedDomain) idmapBackend() string {
+	if d.IDMapBackend == "" {
+		return IDMapBackendRID
+	}
+	return strings.ToLower(d.IDMapBackend)
+}
+
+// idmapRange is an inclusive range of IDs owned by one idmap config
+type idmapRange struct {
+	domain    string
+	low, high uint64
+}
+
+// validateTrustedDomains checks the trusted domain list and that no two idmap
+// ranges, configured directly or per trusted domain, overlap
+func validateTrustedDomains(config *SMBGlobalConfig) error {
+	seen := map[string]bool{strings.ToUpper(config.WorkGroup): true}
+	var ranges []idmapRange
+
+	for _, d := range config.TrustedDomains {
+		name := strings.ToUpper(strings.TrimSpace(d.Name))
+		if name == "" || strings.ContainsAny(name, `\/@ `) {
+			return errors.New(errors.SharesInvalidInput, "Invalid trusted domain name").
+				WithMetadata("domain", d.Name)
+		}
+		if seen[name] {
+			return errors.New(errors.SharesInvalidInput,
+				"Trusted domain is listed twice or is the primary workgroup").
+				WithMetadata("domain", d.Name)
+		}
+		seen[name] = true
+
+		if !slices.Contains(idmapBackends, d.idmapBackend()) {
+			return errors.New(errors.SharesInvalidInput, "Unsupported idmap backend").
+				WithMetadata("domain", d.Name).
+				WithMetadata("backend", d.IDMapBackend)
+		}
+		if d.IDMapRangeLow == 0 || d.IDMapRangeHigh <= d.IDMapRangeLow {
+			return errors.New(errors.SharesInvalidInput, "Invalid idmap range").
+				WithMetadata("domain", d.Name).
+				WithMetadata("range", fmt.Sprintf("%d-%d", d.IDMapRangeLow, d.IDMapRangeHigh))
+		}
+		ranges = append(ranges, idmapRange{
+			domain: name,
+			low:    uint64(d.IDMapRangeLow),
+			high:   uint64(d.IDMapRangeHigh),
+		})
+	}
+
+	for key, value := range config.IDMapConfig {
+		domain, ok := strings.CutSuffix(strings.TrimPrefix(key, "idmap config "), ":range")
+		if !ok {
+			continue
+		}
+		r, err := parseIDMapRange(domain, value)
+		if err != nil {
+			return errors.New(errors.SharesInvalidInput, "Invalid idmap range").
+				WithMetadata("parameter", key).
+				WithMetadata("range", value)
+		}
+		if seen[strings.ToUpper(domain)] && !strings.EqualFold(domain, config.WorkGroup) {
+			return errors.New(errors.SharesInvalidInput,
+				"Trusted domain idmap is set in both trusted_domains and idmap_config").
+				WithMetadata("domain", domain)
+		}
+		ranges = append(ranges, r)
+	}
+
+	for i := range ranges {
+		for j := i + 1; j < len(ranges); j++ {
+			if ranges[i].low <= ranges[j].high && ranges[j].low <= ranges[i].high {
+				return errors.New(errors.SharesInvalidInput, "idmap ranges overlap").
+					WithMetadata("domain", ranges[i].domain).
+					WithMetadata("other_domain", ranges[j].domain)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseIDMapRange parses an idmap range value such as "200000-999999"
+func parseIDMapRange(domain, value string) (idmapRange, error) {
+	lowStr, highStr, ok := strings.Cut(value, "-")
+	if !ok {
+		return idmapRange{}, fmt.Errorf("range %q is not low-high", value)
+	}
+	low, err := strconv.ParseUint(strings.TrimSpace(lowStr), 10, 32)
+	if err != nil {
+		return idmapRange{}, err
+	}
+	high, err := strconv.ParseUint(strings.TrimSpace(highStr), 10, 32)
+	if err != nil {
+		return idmapRange{}, err
+	}
+	if high <= low {
+		return idmapRange{}, fmt.Errorf("range %q is empty", value)
+	}
+	return idmapRange{domain: domain, low: low, high: high}, nil
+}
+
+// renderTrustedDomains adds the idmap config of each trusted domain and the
+// managed winbind settings to a global config being rendered. The stored
+// config is left untouched.
+func renderTrustedDomains(rendered *SMBGlobalConfig) {
+	if len(rendered.TrustedDomains) > 0 {
+		idmap := maps.Clone(rendered.IDMapConfig)
+		if idmap == nil {
+			idmap = make(map[string]string)
+		}
+		for _, d := range rendered.TrustedDomains {
+			prefix := "idmap config " + strings.ToUpper(d.Name)
+			idmap[prefix+":backend"] = d.idmapBackend()
+			idmap[prefix+":range"] = fmt.Sprintf("%d-%d", d.IDMapRangeLow, d.IDMapRangeHigh)
+			if d.idmapBackend() == IDMapBackendAD {
+				schemaMode := d.IDMapSchemaMode
+				if schemaMode == "" {
+					schemaMode = "rfc2307"
+				}
+				idmap[prefix+":schema_mode"] = schemaMode
+			}
+		}
+		rendered.IDMapConfig = idmap
+	}
+
+	params := maps.Clone(rendered.CustomParameters)
+	if params == nil {
+		params = make(map[string]string)
+	}
+	if rendered.AllowTrustedDomains != nil {
+		params["allow trusted domains"] = yesNo(*rendered.AllowTrustedDomains)
+	}
+	if rendered.WinbindEnumUsers != nil {
+		params[winbindEnumUsersParam] = yesNo(*rendered.WinbindEnumUsers)
+	}
+	if rendered.WinbindEnumGroups != nil {
+		params[winbindEnumGroupsParam] = yesNo(*rendered.WinbindEnumGroups)
+	}
+	rendered.CustomParameters = params
+}
+
+// sharePrincipalLists returns the user and group lists of a share by field name
+func sharePrincipalLists(config *SMBShareConfig) map[string][]string {
+	return map[string][]string{
+		"valid_users":   config.ValidUsers,
+		"invalid_users": config.InvalidUsers,
+		"read_list":     config.ReadList,
+		"write_list":    config.WriteList,
+		"admin_users":   config.AdminUsers,
+	}
+}
+
+// principalDomain returns the domain of a domain-qualified principal, as
+// written in DOMAIN\name or name@realm form, or "" for an unqualified one.
+// Group prefixes (@, + and &) and quotes are ignored.
+func principalDomain(principal string) string {
+	p := strings.Trim(strings.TrimSpace(principal), `"`)
+	p = strings.TrimLeft(p, "@+&")
+	if domain, _, ok := strings.Cut(p, `\`); ok {
+		return domain
+	}
+	if i := strings.LastIndex(p, "@"); i > 0 {
+		return p[i+1:]
+	}
+	return ""
+}
+
+// validatePrincipals checks that domain-qualified users and groups in the
+// share's access lists belong to the joined domain or an allowed trusted
+// domain. Callers hold the manager lock.
+func (m *Manager) validatePrincipals(config *SMBShareConfig) error {
+	adMode := m.isADMode()
+
+	var allowed map[string]bool
+	for field, principals := range sharePrincipalLists(config) {
+		for _, principal := range principals {
+			domain := principalDomain(principal)
+			if domain == "" {
+				continue
+			}
+			if !adMode {
+				return errors.New(errors.SharesInvalidInput,
+					"Domain-qualified principals require Active Directory mode").
+					WithMetadata("field", field).
+					WithMetadata("principal", principal)
+			}
+
+			if allowed == nil {
+				allowed = m.allowedDomains()
+			}
+			if !allowed[strings.ToUpper(domain)] {
+				return errors.New(errors.SharesInvalidInput,
+					"Principal belongs to a domain that is not trusted for share access").
+					WithMetadata("field", field).
+					WithMetadata("principal", principal).
+					WithMetadata("domain", domain)
+			}
+		}
+	}
+	return nil
+}
+
+// allowedDomains returns the upper-case NetBIOS and DNS names of the joined
+// domain and of every trusted domain, from the global config. Trusted domains
+// are only allowed while "allow trusted domains" is not turned off. Callers
+// hold the manager lock.
+func (m *Manager) allowedDomains() map[string]bool {
+	allowed := make(map[string]bool)
+	globalConfig, err := m.GetGlobalConfig(context.Background(), true)
+	if err != nil {
+		return allowed
+	}
+
+	add := func(names ...string) {
+		for _, name := range names {
+			if name != "" {
+				allowed[strings.ToUpper(name)] = true
+			}
+		}
+	}
+	add(globalConfig.WorkGroup, globalConfig.Realm)
+
+	if globalConfig.AllowTrustedDomains != nil && !*globalConfig.AllowTrustedDomains {
+		return allowed
+	}
+	for _, d := range globalConfig.TrustedDomains {
+		add(d.Name, d.Realm)
+	}
+	return allowed
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// globalVersionsFile holds the applied global configurations, oldest first
+	globalVersionsFile = "global.versions"
+
+	// maxGlobalConfigVersions bounds the applied global configurations kept
+	maxGlobalConfigVersions = 10
+)
+
+// GlobalConfigVersion is an applied global configuration that can be
+// reverted to
+type GlobalConfigVersion struct {
+	Version   int             `json:"version"`
+	AppliedAt time.Time       `json:"applied_at"`
+	RevertOf  int             `json:"revert_of,omitempty"` // Version this one restored, for reverts
+	Config    SMBGlobalConfig `json:"config"`
+}
+
+// GlobalConfigPreview describes the smb.conf changes a global configuration
+// would make
+type GlobalConfigPreview struct {
+	Changed bool   `json:"changed"`
+	Diff    string `json:"diff,omitempty"` // Unified diff of smb.conf
+}
+
+// PreviewGlobalConfig renders the smb.conf a global configuration would
+// produce and diffs it against the current smb.conf. Nothing is written.
+func (m *Manager) PreviewGlobalConfig(
+	ctx context.Context,
+	config *SMBGlobalConfig,
+) (_ *GlobalConfigPreview, err error) {
+	defer m.observe("preview_global_config", "")(&err)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if err := validateGlobalConfig(config); err != nil {
+		return nil, err
+	}
+
+	globalData, err := m.renderGlobalConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	proposed, err := m.renderMainConfig(globalData)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := m.fileOps.ReadFile(ctx, m.smbConfPath)
+	if err != nil {
+		m.logger.Debug("Failed to read current SMB config, diffing against an empty file",
+			"path", m.smbConfPath,
+			"error", err)
+		current = nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(current)),
+		B:        difflib.SplitLines(proposed),
+		FromFile: m.smbConfPath + " (current)",
+		ToFile:   m.smbConfPath + " (proposed)",
+		Context:  3,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "diff_config")
+	}
+
+	return &GlobalConfigPreview{
+		Changed: diff != "",
+		Diff:    diff,
+	}, nil
+}
+
+// ListGlobalConfigVersions returns the applied global configurations, oldest
+// first
+func (m *Manager) ListGlobalConfigVersions(ctx context.Context) (_ []GlobalConfigVersion, err error) {
+	defer m.observe("list_global_config_versions", "")(&err)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.loadGlobalVersions()
+}
+
+// RevertGlobalConfig applies an earlier global configuration again. The
+// revert is itself recorded as a new version.
+func (m *Manager) RevertGlobalConfig(ctx context.Context, version int) (_ *GlobalConfigVersion, err error) {
+	defer m.observe("revert_global_config", "")(&err)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	versions, err := m.loadGlobalVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range versions {
+		if v.Version == version {
+			config := v.Config
+			return m.applyGlobalConfig(ctx, &config, version)
+		}
+	}
+
+	return nil, errors.New(errors.SharesNotFound, "Global configuration version not found").
+		WithMetadata("version", strconv.Itoa(version))
+}
+
+// applyGlobalConfig writes a global configuration and records it as the
+// newest version. The first apply also records the configuration it replaces,
+// so it can be reverted to. Must be called with lock held.
+func (m *Manager) applyGlobalConfig(
+	ctx context.Context,
+	config *SMBGlobalConfig,
+	revertOf int,
+) (*GlobalConfigVersion, error) {
+	versions, err := m.loadGlobalVersions()
+	if err != nil {
+		m.logger.Warn("Failed to load global config versions, starting a new history", "error", err)
+		versions = nil
+	}
+
+	globalPath := filepath.Join(m.configDir, globalJSONConf)
+	if _, err := os.Stat(globalPath); err == nil && len(versions) == 0 {
+		if previous, err := m.GetGlobalConfig(ctx, true); err == nil {
+			versions = append(versions, GlobalConfigVersion{
+				Version:   1,
+				AppliedAt: getFileModificationTime(globalPath),
+				Config:    *previous,
+			})
+		}
+	}
+
+	if err := m.writeGlobalConfig(ctx, config); err != nil {
+		return nil, err
+	}
+
+	applied := GlobalConfigVersion{
+		Version:   1,
+		AppliedAt: time.Now(),
+		RevertOf:  revertOf,
+		Config:    *config,
+	}
+	if len(versions) > 0 {
+		applied.Version = versions[len(versions)-1].Version + 1
+	}
+
+	versions = append(versions, applied)
+	if len(versions) > maxGlobalConfigVersions {
+		versions = versions[len(versions)-maxGlobalConfigVersions:]
+	}
+
+	// The configuration is applied either way; only the history is lost
+	if err := m.saveGlobalVersions(versions); err != nil {
+		m.logger.Warn("Failed to save global config versions", "error", err)
+	}
+
+	m.logger.Info("Global SMB configuration applied",
+		"version", applied.Version,
+		"revert_of", revertOf)
+	return &applied, nil
+}
+
+// loadGlobalVersions reads the applied global configurations (must be called
+// with lock held)
+func (m *Manager) loadGlobalVersions() ([]GlobalConfigVersion, error) {
+	data, err := os.ReadFile(filepath.Join(m.configDir, globalVersionsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []GlobalConfigVersion{}, nil
+		}
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "read_global_versions")
+	}
+
+	var versions []GlobalConfigVersion
+	if err := json.Unmarshal(data, &versions); err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "parse_global_versions")
+	}
+
+	return versions, nil
+}
+
+// saveGlobalVersions writes the applied global configurations (must be called
+// with lock held)
+func (m *Manager) saveGlobalVersions(versions []GlobalConfigVersion) error {
+	data, err := json.MarshalIndent(versions, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "marshal_global_versions")
+	}
+
+	if err := os.WriteFile(filepath.Join(m.configDir, globalVersionsFile), data, 0644); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "save_global_versions")
+	}
+
+	return nil
+}
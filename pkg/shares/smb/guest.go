@@ -0,0 +1,165 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// defaultGuestAccount is Samba's default "guest account"
+	defaultGuestAccount = "nobody"
+
+	guestDirMode         = "2775"
+	guestReadOnlyDirMode = "0755"
+)
+
+// guestForbiddenPrefixes are system trees that must never be exposed to guests
+var guestForbiddenPrefixes = []string{
+	"/etc", "/root", "/boot", "/usr", "/var", "/proc", "/sys", "/dev", "/bin", "/sbin", "/lib",
+}
+
+// guestForbiddenPaths may have guest-accessible children but are not guest
+// shares themselves
+var guestForbiddenPaths = []string{"/", "/home", "/mnt", "/srv"}
+
+// validateGuestAccess enforces the safety checks for guest shares and keeps the
+// legacy guest_ok/public flags consistent with the guest access settings
+func (m *Manager) validateGuestAccess(config *SMBShareConfig) error {
+	config.GuestOk = config.GuestEnabled()
+	config.Public = config.GuestEnabled()
+
+	if !config.GuestEnabled() {
+		return nil
+	}
+
+	if !config.GuestAccess.AcknowledgeInsecure {
+		return errors.New(
+			errors.SharesInvalidInput,
+			"Guest access lets anyone on the network use the share; set i_understand_guest_is_insecure to enable it",
+		).WithMetadata("name", config.Name)
+	}
+
+	if len(config.ValidUsers) > 0 {
+		return errors.New(
+			errors.SharesInvalidInput,
+			"valid_users cannot be combined with guest access",
+		).WithMetadata("name", config.Name)
+	}
+
+	cleanPath := filepath.Clean(config.Path)
+	for _, p := range guestForbiddenPaths {
+		if cleanPath == p {
+			return errors.New(errors.SharesInvalidInput, "Path cannot be shared with guest access").
+				WithMetadata("path", config.Path)
+		}
+	}
+	for _, prefix := range guestForbiddenPrefixes {
+		if cleanPath == prefix || strings.HasPrefix(cleanPath, prefix+"/") {
+			return errors.New(errors.SharesInvalidInput, "Path cannot be shared with guest access").
+				WithMetadata("path", config.Path)
+		}
+	}
+
+	account := m.guestAccount()
+	if _, err := user.Lookup(account); err != nil {
+		return errors.New(errors.SharesInvalidInput, "Guest account does not exist").
+			WithMetadata("guest_account", account)
+	}
+
+	return nil
+}
+
+// guestAccount returns the account guests act as, from the global config.
+// Callers hold the manager lock.
+func (m *Manager) guestAccount() string {
+	globalConfig, err := m.GetGlobalConfig(context.Background(), true)
+	if err == nil {
+		if account := globalConfig.CustomParameters["guest account"]; account != "" {
+			return account
+		}
+	}
+	return defaultGuestAccount
+}
+
+// fixGuestPermissions gives the guest account ownership of the share path so
+// guests can use it. Only the share root is changed, not its contents.
+func (m *Manager) fixGuestPermissions(ctx context.Context, config *SMBShareConfig) error {
+	if !config.GuestEnabled() || !config.GuestAccess.FixPermissions {
+		return nil
+	}
+
+	account := m.guestAccount()
+	if out, err := m.executor.Execute(ctx, "chown", account+":", config.Path); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "guest_chown").
+			WithMetadata("path", config.Path).
+			WithMetadata("output", string(out))
+	}
+
+	mode := guestDirMode
+	if config.ReadOnly {
+		mode = guestReadOnlyDirMode
+	}
+	if out, err := m.executor.Execute(ctx, "chmod", mode, config.Path); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "guest_chmod").
+			WithMetadata("path", config.Path).
+			WithMetadata("output", string(out))
+	}
+
+	m.logger.Info("Adjusted share path for guest access",
+		"name", config.Name,
+		"path", config.Path,
+		"owner", account,
+		"mode", mode)
+	return nil
+}
+
+// hasGuestShares reports whether any enabled share allows guest access
+func (m *Manager) hasGuestShares() bool {
+	configs, err := m.getAllShareConfigs()
+	if err != nil {
+		return false
+	}
+	for _, c := range configs {
+		if c.Enabled && c.GuestEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// regenerateGlobalForGuests re-renders the global section after a guest share
+// is added or removed, since encryption and guest mapping are server-wide.
+// Callers hold the manager lock.
+func (m *Manager) regenerateGlobalForGuests(ctx context.Context) error {
+	globalConfig, err := m.GetGlobalConfig(ctx, true)
+	if err != nil {
+		return err
+	}
+	return m.generateGlobalConfig(globalConfig)
+}
+
+// isGuestShare reports whether the stored config of a share allows guest access
+func (m *Manager) isGuestShare(name string) bool {
+	data, err := os.ReadFile(filepath.Join(m.configDir, name+configFileExt))
+	if err != nil {
+		return false
+	}
+
+	var config SMBShareConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return false
+	}
+	return config.GuestEnabled()
+}
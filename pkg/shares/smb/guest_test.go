@@ -0,0 +1,111 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withGuestAccount writes a global config whose guest account is account
+func withGuestAccount(t *testing.T, m *Manager, account string) {
+	t.Helper()
+	global := &SMBGlobalConfig{CustomParameters: map[string]string{"guest account": account}}
+	data, err := json.Marshal(global)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(m.configDir, globalJSONConf), data, 0644))
+}
+
+func TestValidateGuestAccess(t *testing.T) {
+	guest := func() *SMBGuestAccess {
+		return &SMBGuestAccess{Enabled: true, AcknowledgeInsecure: true}
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		access  *SMBGuestAccess
+		users   []string
+		account string
+		wantErr bool
+	}{
+		{name: "no guest access", path: "/etc", access: nil},
+		{name: "guest access disabled", path: "/etc", access: &SMBGuestAccess{}},
+		{name: "guest share", path: "/tank/public", access: guest()},
+		{name: "not acknowledged", path: "/tank/public",
+			access: &SMBGuestAccess{Enabled: true}, wantErr: true},
+		{name: "with valid users", path: "/tank/public", access: guest(),
+			users: []string{"alice"}, wantErr: true},
+		{name: "root", path: "/", access: guest(), wantErr: true},
+		{name: "home", path: "/home/", access: guest(), wantErr: true},
+		{name: "under home", path: "/home/public", access: guest()},
+		{name: "system tree", path: "/etc", access: guest(), wantErr: true},
+		{name: "under system tree", path: "/var/lib/../lib/samba", access: guest(), wantErr: true},
+		{name: "system tree prefix only", path: "/etcetera", access: guest()},
+		{name: "missing guest account", path: "/tank/public", access: guest(),
+			account: "rodent-no-such-user", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{logger: common.Log, configDir: t.TempDir()}
+			account := tt.account
+			if account == "" {
+				account = "root"
+			}
+			withGuestAccount(t, m, account)
+
+			config := NewSMBShareConfig("public", tt.path)
+			config.GuestAccess = tt.access
+			config.ValidUsers = tt.users
+
+			err := m.validateGuestAccess(config)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, config.GuestEnabled(), config.GuestOk)
+			assert.Equal(t, config.GuestEnabled(), config.Public)
+		})
+	}
+}
+
+func TestRenderGuestEncryption(t *testing.T) {
+	tmpl, err := template.New(defaultTemplate).
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(DefaultTemplateContent())
+	require.NoError(t, err)
+
+	render := func(config *SMBShareConfig) string {
+		var buf bytes.Buffer
+		require.NoError(t, tmpl.Execute(&buf, renderShareConfig(config)))
+		return buf.String()
+	}
+
+	// Authenticated shares inherit the global setting, so a custom value is
+	// the only one rendered
+	config := NewSMBShareConfig("media", "/tank/media")
+	config.CustomParameters["server smb encrypt"] = "off"
+	out := render(config)
+	assert.Equal(t, 1, strings.Count(out, "server smb encrypt"))
+	assert.Contains(t, out, "server smb encrypt = off")
+
+	config = NewSMBShareConfig("public", "/tank/public")
+	config.GuestAccess = &SMBGuestAccess{Enabled: true, GuestOnly: true}
+	out = render(config)
+	assert.Contains(t, out, "guest ok = yes")
+	assert.Contains(t, out, "guest only = yes")
+	assert.Contains(t, out, "server smb encrypt = desired")
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	maxConnectionsParam = "max connections"
+
+	rateLimitVFSObject   = "aio_ratelimit"
+	rateLimitParamPrefix = "aio_ratelimit:"
+)
+
+// rateLimitModulePaths are where the distribution packages install the
+// vfs_aio_ratelimit module, added in Samba 4.23
+var rateLimitModulePaths = []string{
+	"/usr/lib/*/samba/vfs/aio_ratelimit.so",
+	"/usr/lib64/samba/vfs/aio_ratelimit.so",
+	"/usr/lib/samba/vfs/aio_ratelimit.so",
+}
+
+// rateLimitSupported reports whether the installed Samba can enforce rate
+// limits. Replaced by tests.
+var rateLimitSupported = func() bool {
+	for _, pattern := range rateLimitModulePaths {
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RateLimitEnabled reports whether any transfer rate limit is set for the share
+func (c *SMBShareConfig) RateLimitEnabled() bool {
+	rl := c.RateLimit
+	return rl != nil && (rl.ReadBytesPerSecond > 0 || rl.WriteBytesPerSecond > 0 ||
+		rl.ReadIOPS > 0 || rl.WriteIOPS > 0)
+}
+
+// RateLimitParameters returns the vfs_aio_ratelimit parameters of the share
+func (c *SMBShareConfig) RateLimitParameters() map[string]string {
+	if !c.RateLimitEnabled() {
+		return nil
+	}
+	rl := c.RateLimit
+
+	params := make(map[string]string)
+	set := func(name string, value int64) {
+		if value > 0 {
+			params[rateLimitParamPrefix+name] = strconv.FormatInt(value, 10)
+		}
+	}
+	set("read_bw_limit", rl.ReadBytesPerSecond)
+	set("write_bw_limit", rl.WriteBytesPerSecond)
+	set("read_iops_limit", rl.ReadIOPS)
+	set("write_iops_limit", rl.WriteIOPS)
+	return params
+}
+
+// validateLimits checks the connection and rate limits and rejects limits
+// set outside of them
+func validateLimits(config *SMBShareConfig) error {
+	if _, ok := config.CustomParameters[maxConnectionsParam]; ok {
+		return errors.New(errors.SharesInvalidInput, "Use max_connections to limit connections").
+			WithMetadata("parameter", maxConnectionsParam)
+	}
+	for param := range config.CustomParameters {
+		if strings.HasPrefix(param, rateLimitParamPrefix) {
+			return errors.New(errors.SharesInvalidInput, "Use rate_limit to limit transfer rates").
+				WithMetadata("parameter", param)
+		}
+	}
+	if hasVFSObject(config.CustomParameters["vfs objects"], rateLimitVFSObject) {
+		return errors.New(errors.SharesInvalidInput, "Use rate_limit to enable vfs_aio_ratelimit").
+			WithMetadata("parameter", "vfs objects")
+	}
+
+	if config.MaxConnections < 0 {
+		return errors.New(errors.SharesInvalidInput, "max_connections cannot be negative").
+			WithMetadata("name", config.Name)
+	}
+
+	if rl := config.RateLimit; rl != nil {
+		if rl.ReadBytesPerSecond < 0 || rl.WriteBytesPerSecond < 0 || rl.ReadIOPS < 0 || rl.WriteIOPS < 0 {
+			return errors.New(errors.SharesInvalidInput, "Rate limits cannot be negative").
+				WithMetadata("name", config.Name)
+		}
+	}
+	// An unknown vfs object makes smbd refuse connections to the share
+	if config.RateLimitEnabled() && !rateLimitSupported() {
+		return errors.New(errors.SharesInvalidInput,
+			"Rate limits need the vfs_aio_ratelimit module of Samba 4.23 or later").
+			WithMetadata("name", config.Name)
+	}
+
+	return nil
+}
+
+// rateLimitFromParameters moves imported vfs_aio_ratelimit parameters into a
+// rate limit config
+func rateLimitFromParameters(params map[string]string) *SMBRateLimitConfig {
+	rl := &SMBRateLimitConfig{}
+	for param, value := range params {
+		if !strings.HasPrefix(param, rateLimitParamPrefix) {
+			continue
+		}
+		n, _ := strconv.ParseInt(value, 10, 64)
+		switch strings.TrimPrefix(param, rateLimitParamPrefix) {
+		case "read_bw_limit":
+			rl.ReadBytesPerSecond = n
+		case "write_bw_limit":
+			rl.WriteBytesPerSecond = n
+		case "read_iops_limit":
+			rl.ReadIOPS = n
+		case "write_iops_limit":
+			rl.WriteIOPS = n
+		}
+		delete(params, param)
+	}
+	return rl
+}
+
+// shareLimitStatus reports the limits configured for a share and whether
+// they are being enforced, given its current tree connections. It returns
+// nil for shares without limits.
+func (m *Manager) shareLimitStatus(name string, connections int) *SMBShareLimitStatus {
+	data, err := os.ReadFile(filepath.Join(m.configDir, name+configFileExt))
+	if err != nil {
+		return nil
+	}
+	var config SMBShareConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil
+	}
+	if config.MaxConnections == 0 && !config.RateLimitEnabled() {
+		return nil
+	}
+
+	status := &SMBShareLimitStatus{
+		MaxConnections:         config.MaxConnections,
+		Connections:            connections,
+		ConnectionLimitReached: config.MaxConnections > 0 && connections >= config.MaxConnections,
+	}
+	if config.RateLimitEnabled() {
+		status.RateLimit = config.RateLimit
+		status.RateLimitEnforced = rateLimitSupported()
+	}
+	return status
+}
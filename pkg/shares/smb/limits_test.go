@@ -0,0 +1,94 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRateLimitSupport makes vfs_aio_ratelimit appear installed or not
+func withRateLimitSupport(t *testing.T, supported bool) {
+	t.Helper()
+	saved := rateLimitSupported
+	rateLimitSupported = func() bool { return supported }
+	t.Cleanup(func() { rateLimitSupported = saved })
+}
+
+func TestValidateLimits(t *testing.T) {
+	withRateLimitSupport(t, false)
+
+	config := NewSMBShareConfig("media", "/tank/media")
+	config.MaxConnections = 10
+	assert.NoError(t, validateLimits(config))
+
+	config.RateLimit = &SMBRateLimitConfig{ReadBytesPerSecond: 100 << 20}
+	assert.Error(t, validateLimits(config), "rate limits need vfs_aio_ratelimit")
+
+	withRateLimitSupport(t, true)
+	assert.NoError(t, validateLimits(config))
+
+	config.MaxConnections = -1
+	assert.Error(t, validateLimits(config))
+	config.MaxConnections = 0
+
+	config.CustomParameters["max connections"] = "5"
+	assert.Error(t, validateLimits(config))
+	delete(config.CustomParameters, "max connections")
+
+	config.CustomParameters["aio_ratelimit:read_bw_limit"] = "1"
+	assert.Error(t, validateLimits(config))
+}
+
+func TestRenderLimits(t *testing.T) {
+	tmpl, err := template.New(defaultTemplate).
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(DefaultTemplateContent())
+	require.NoError(t, err)
+
+	config := NewSMBShareConfig("media", "/tank/media")
+	config.MaxConnections = 10
+	config.RateLimit = &SMBRateLimitConfig{ReadBytesPerSecond: 1048576, WriteIOPS: 200}
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, renderShareConfig(config)))
+	out := buf.String()
+
+	assert.Contains(t, out, "max connections = 10")
+	assert.Contains(t, out, "aio_ratelimit:read_bw_limit = 1048576")
+	assert.Contains(t, out, "aio_ratelimit:write_iops_limit = 200")
+	assert.NotContains(t, out, "write_bw_limit")
+	assert.Contains(t, out, "vfs objects = acl_xattr aio_ratelimit")
+}
+
+func TestShareLimitStatus(t *testing.T) {
+	withRateLimitSupport(t, false)
+
+	m := &Manager{logger: common.Log, configDir: t.TempDir()}
+	config := NewSMBShareConfig("media", "/tank/media")
+	config.MaxConnections = 2
+	config.RateLimit = &SMBRateLimitConfig{WriteBytesPerSecond: 1 << 20}
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(m.configDir, "media"+configFileExt), data, 0644))
+
+	status := m.shareLimitStatus("media", 2)
+	require.NotNil(t, status)
+	assert.True(t, status.ConnectionLimitReached)
+	assert.Equal(t, 2, status.Connections)
+	assert.False(t, status.RateLimitEnforced)
+	assert.Equal(t, int64(1<<20), status.RateLimit.WriteBytesPerSecond)
+
+	assert.Nil(t, m.shareLimitStatus("missing", 0))
+}
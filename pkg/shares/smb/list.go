@@ -0,0 +1,115 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/shares"
+)
+
+// listWorkers bounds the share configurations ListShares reads at once
+const listWorkers = 8
+
+// activeShares returns the names of the shares with tree connections, from
+// a single smbstatus run
+func activeShares(ctx context.Context) (map[string]bool, error) {
+	smbStatus, err := statusCache.get(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "check_status")
+	}
+
+	active := make(map[string]bool, len(smbStatus.TreeConnects))
+	for _, tcon := range smbStatus.TreeConnects {
+		active[tcon.Service] = true
+	}
+	return active, nil
+}
+
+// readShareConfigs reads share configuration files with a bounded pool of
+// workers. Results keep the order of files; files that cannot be read or
+// parsed are logged and left out.
+func (m *Manager) readShareConfigs(ctx context.Context, files []string) []shares.ShareConfig {
+	results := make([]*shares.ShareConfig, len(files))
+	next := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(listWorkers, len(files)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = m.readShareConfig(files[i])
+			}
+		}()
+	}
+
+feed:
+	for i := range files {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	configs := make([]shares.ShareConfig, 0, len(files))
+	for _, c := range results {
+		if c != nil {
+			configs = append(configs, *c)
+		}
+	}
+	return configs
+}
+
+// readShareConfig reads one share configuration file
+func (m *Manager) readShareConfig(file string) *shares.ShareConfig {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		m.logger.Warn("Failed to read share config file", "file", file, "error", err)
+		return nil
+	}
+
+	var smbConfig SMBShareConfig
+	if err := json.Unmarshal(data, &smbConfig); err != nil {
+		m.logger.Warn("Failed to parse share config file", "file", file, "error", err)
+		return nil
+	}
+
+	return &shares.ShareConfig{
+		Name:        smbConfig.Name,
+		Description: smbConfig.Description,
+		Path:        smbConfig.Path,
+		Type:        shares.ShareTypeSMB,
+		Enabled:     smbConfig.Enabled,
+		Tags:        smbConfig.Tags,
+		Created:     getFileCreationTime(file),
+		Modified:    getFileModificationTime(file),
+	}
+}
+
+// shareFiles returns the share configuration files, without smb.conf
+func (m *Manager) shareFiles() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(m.configDir, "*"+configFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	shareFiles := files[:0]
+	for _, file := range files {
+		if filepath.Base(file) != "smb.conf" {
+			shareFiles = append(shareFiles, file)
+		}
+	}
+	return shareFiles, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/shares"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSharesRunsSMBStatusOnce(t *testing.T) {
+	dir := t.TempDir()
+	const count = 50
+	for i := 0; i < count; i++ {
+		data, err := json.Marshal(SMBShareConfig{
+			Name: fmt.Sprintf("share%02d", i),
+			Path: fmt.Sprintf("/tank/share%02d", i),
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, fmt.Sprintf("share%02d%s", i, configFileExt)), data, 0600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken"+configFileExt), []byte("{"), 0600))
+
+	var calls atomic.Int32
+	fakeSMBStatus(t, "Version 4.19.5", func([]string) ([]byte, error) {
+		calls.Add(1)
+		return []byte(`{"tcons": {"1": {"service": "share07"}, "2": {"service": "share42"}}}`), nil
+	})
+	t.Cleanup(statusCache.invalidate)
+	statusCache.invalidate()
+
+	m := &Manager{logger: common.Log, configDir: dir}
+	list, err := m.ListShares(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	require.Len(t, list, count, "unreadable configurations are left out")
+	for i, share := range list {
+		assert.Equal(t, fmt.Sprintf("share%02d", i), share.Name, "shares keep their order")
+		want := shares.ShareStatusInactive
+		if i == 7 || i == 42 {
+			want = shares.ShareStatusActive
+		}
+		assert.Equal(t, want, share.Status, share.Name)
+	}
+}
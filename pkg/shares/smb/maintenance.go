@@ -0,0 +1,119 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Access overrides applied to every share while the node is in maintenance.
+// Shares are rendered with the override; their stored configs are left
+// untouched, so clearing it restores them as they were.
+const (
+	ShareAccessNormal       = ""             // Shares as configured
+	ShareAccessReadOnly     = "read-only"    // Every share is read only
+	ShareAccessDisconnected = "disconnected" // Every share is unavailable
+)
+
+// ShareAccessModes are the access overrides that can be set
+var ShareAccessModes = []string{ShareAccessNormal, ShareAccessReadOnly, ShareAccessDisconnected}
+
+// ShareAccess returns the access override of the shares
+func (m *Manager) ShareAccess() string {
+	if mode := m.accessOverride.Load(); mode != nil {
+		return *mode
+	}
+	return ShareAccessNormal
+}
+
+// SetShareAccess renders every share with an access override and reloads
+// Samba. Clients connected to the shares are disconnected, so they come
+// back read only or not at all; ShareAccessNormal restores the shares.
+func (m *Manager) SetShareAccess(ctx context.Context, mode string) (err error) {
+	defer m.observe("set_share_access", "")(&err)
+	if !slices.Contains(ShareAccessModes, mode) {
+		return errors.New(errors.SharesInvalidInput, "Share access must be read-only, disconnected or empty").
+			WithMetadata("access", mode)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if mode == ShareAccessNormal {
+		m.accessOverride.Store(nil)
+	} else {
+		m.accessOverride.Store(&mode)
+	}
+
+	configs, err := m.getAllShareConfigs()
+	if err != nil {
+		return err
+	}
+	for _, config := range configs {
+		if err := m.generateShareConfig(config); err != nil {
+			return err
+		}
+	}
+	if err := m.ReloadConfig(ctx); err != nil {
+		return err
+	}
+
+	if mode != ShareAccessNormal {
+		for _, config := range configs {
+			if err := m.closeShare(ctx, config.Name); err != nil {
+				m.logger.Warn("Failed to disconnect share clients",
+					"name", config.Name,
+					"error", err)
+			}
+		}
+	}
+
+	m.logger.Info("Share access changed", "access", mode, "shares", len(configs))
+	return nil
+}
+
+// withAccessOverride returns the config to render under the access
+// override, leaving config untouched
+func (m *Manager) withAccessOverride(config *SMBShareConfig) *SMBShareConfig {
+	mode := m.ShareAccess()
+	if mode == ShareAccessNormal {
+		return config
+	}
+
+	rendered := *config
+	rendered.ReadOnly = true
+	if mode == ShareAccessDisconnected {
+		rendered.CustomParameters = maps.Clone(config.CustomParameters)
+		if rendered.CustomParameters == nil {
+			rendered.CustomParameters = make(map[string]string)
+		}
+		rendered.CustomParameters["available"] = "no"
+	}
+	return &rendered
+}
+
+// closeShare disconnects the clients of a share
+func (m *Manager) closeShare(ctx context.Context, name string) error {
+	args := []string{"smbd", "close-share", name}
+	if m.smbConfPath != defaultSMBConfigPath {
+		args = append([]string{"--configfile=" + m.smbConfPath}, args...)
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	output, err := m.executor.ExecuteWithCombinedOutput(timeoutCtx, "smbcontrol", args...)
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "close_share").
+			WithMetadata("name", name).
+			WithMetadata("output", string(output))
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithAccessOverride(t *testing.T) {
+	m := &Manager{}
+	config := NewSMBShareConfig("projects", "/tank/projects")
+	config.CustomParameters["hide files"] = "/desktop.ini/"
+
+	assert.Same(t, config, m.withAccessOverride(config))
+
+	mode := ShareAccessReadOnly
+	m.accessOverride.Store(&mode)
+	rendered := m.withAccessOverride(config)
+	assert.True(t, rendered.ReadOnly)
+	assert.NotContains(t, rendered.CustomParameters, "available")
+
+	mode = ShareAccessDisconnected
+	m.accessOverride.Store(&mode)
+	rendered = m.withAccessOverride(config)
+	assert.True(t, rendered.ReadOnly)
+	assert.Equal(t, "no", rendered.CustomParameters["available"])
+	assert.Equal(t, "/desktop.ini/", rendered.CustomParameters["hide files"])
+
+	assert.False(t, config.ReadOnly, "stored config is untouched")
+	assert.NotContains(t, config.CustomParameters, "available")
+}
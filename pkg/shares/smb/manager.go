@@ -9,13 +9,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
@@ -24,27 +27,23 @@ import (
 	"github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/internal/system/privilege"
+	"github.com/stratastor/rodent/internal/trash"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/parsers"
 	"github.com/stratastor/rodent/pkg/shares"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
 )
 
 var (
 	defaultSMBConfigPath = "/etc/samba/smb.conf"
 	sharesConfigDir      = "~/.rodent/shares/smb"
 	templateDir          = "~/.rodent/templates/smb"
-	// Default allowed paths for privileged file operations
-	DefaultAllowedPaths = []string{
-		"/etc/samba/smb.conf",
-		"/etc/samba/conf.d",
-		"/etc/hosts",
-		"/etc/resolv.conf",
-		"/etc/krb5.conf",
-	}
 )
 
 const (
 	defaultTemplate  = "share.tmpl"
 	globalTemplate   = "global.tmpl"
+	printingTemplate = "printing.tmpl"
 	configFileExt    = ".json"
 	smbConfigFileExt = ".smb.conf"
 	globalJSONConf   = "global.conf"
@@ -66,20 +65,37 @@ func init() {
 }
 
 var (
+	// guestParameters are managed through SMBShareConfig.GuestAccess and may not
+	// be set as custom parameters, which would bypass the guest safety checks
+	guestParameters = []string{"guest ok", "public", "guest only"}
+
 	// Ensure safe share names
 	shareNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][-a-zA-Z0-9_.]{0,62}$`)
 	pathRegex      = regexp.MustCompile(`^/[a-zA-Z0-9/._-]+$`)
 )
 
-// TODO: Add main smb conf path variable to facilitate testing
 // Manager implements SMB share management
 type Manager struct {
 	logger    logger.Logger
 	executor  *command.CommandExecutor
 	configDir string
+
+	// smbConfPath is the smb.conf assembled from the global and share
+	// configurations
+	smbConfPath string
+
 	templates map[string]*template.Template
 	mutex     sync.RWMutex
 	fileOps   privilege.FileOperations
+	resolver  atomic.Pointer[resolver.Resolver]
+
+	// accessOverride is the maintenance access every share is rendered
+	// with; nil while shares are rendered as configured
+	accessOverride atomic.Pointer[string]
+
+	// trashRetention is how long deleted shares are kept for restoring;
+	// zero deletes them outright
+	trashRetention time.Duration
 }
 
 // NewManager creates a new SMB shares manager
@@ -116,9 +132,18 @@ func NewManager(
 	}
 	templates[globalTemplate] = globalTemp
 
-	// If no file operations are provided, create default one (for backward compatibility)
+	printingTemp, err := template.New(printingTemplate).
+		Funcs(funcMap).
+		Parse(PrintingTemplateContent())
+	if err != nil {
+		return nil, errors.Wrap(err, errors.RodentMisc).
+			WithMetadata("template", printingTemplate)
+	}
+	templates[printingTemplate] = printingTemp
+
+	// If no file operations are provided, use the shared privileged path allow-list
 	if fileOps == nil {
-		fileOps = privilege.NewSudoFileOperations(logger, executor, DefaultAllowedPaths)
+		fileOps = privilege.NewSudoFileOperations(logger, executor, privilege.AllowedPaths())
 	}
 
 	manager := &Manager{
@@ -127,11 +152,31 @@ func NewManager(
 		configDir: sharesConfigDir,
 		templates: templates,
 		fileOps:   fileOps,
+
+		smbConfPath: defaultSMBConfigPath,
+
+		trashRetention: trash.Retention(),
 	}
+	registerMetrics()
+
+	// Pick the smbstatus profile of the installed Samba
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	statusCache.negotiate(ctx, logger, executor)
 
 	return manager, nil
 }
 
+// UseConfigPaths points the manager at another smb.conf and shares
+// configuration directory, such as those of a separate smbd instance in
+// tests. Reloads reach that instance through the pid directory of its
+// smb.conf.
+func (m *Manager) UseConfigPaths(smbConfPath, configDir string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.smbConfPath, m.configDir = smbConfPath, configDir
+}
+
 func (m *Manager) validateShareConfig(config *SMBShareConfig) error {
 	// Validate share name
 	if config.Name == "" {
@@ -143,6 +188,11 @@ func (m *Manager) validateShareConfig(config *SMBShareConfig) error {
 			WithMetadata("name", config.Name)
 	}
 
+	if strings.EqualFold(config.Name, printersSection) && m.managedPrinting() != nil {
+		return errors.New(errors.SharesInvalidInput, "Share name is reserved for printing").
+			WithMetadata("name", config.Name)
+	}
+
 	// Validate path
 	if config.Path == "" {
 		return errors.New(errors.SharesInvalidInput, "Share path cannot be empty")
@@ -168,67 +218,59 @@ func (m *Manager) validateShareConfig(config *SMBShareConfig) error {
 		config.CustomParameters = make(map[string]string)
 	}
 
-	return nil
+	for _, param := range guestParameters {
+		if _, ok := config.CustomParameters[param]; ok {
+			return errors.New(errors.SharesInvalidInput, "Use guest_access to configure guest access").
+				WithMetadata("parameter", param)
+		}
+	}
+
+	if err := validateWorm(config); err != nil {
+		return err
+	}
+	applyVirusFilterDefaults(config)
+	if err := validateVirusFilter(config); err != nil {
+		return err
+	}
+	if err := validateLimits(config); err != nil {
+		return err
+	}
+	if err := validateSnapshotSettings(config); err != nil {
+		return err
+	}
+	if err := m.validatePrincipals(config); err != nil {
+		return err
+	}
+
+	return m.validateGuestAccess(config)
 }
 
 // ListShares returns a list of all configured SMB shares
-func (m *Manager) ListShares(ctx context.Context) ([]shares.ShareConfig, error) {
+func (m *Manager) ListShares(ctx context.Context) (_ []shares.ShareConfig, err error) {
+	defer m.observe("list_shares", "")(&err)
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	// Get all share config files
-	files, err := filepath.Glob(filepath.Join(m.configDir, "*"+configFileExt))
+	files, err := m.shareFiles()
 	if err != nil {
 		return nil, errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "list")
 	}
 
-	var result []shares.ShareConfig
-
-	// Read each share config file
-	for _, file := range files {
-		// Skip smb.conf
-		if filepath.Base(file) == "smb.conf" {
-			continue
-		}
-
-		// Read share config
-		data, err := os.ReadFile(file)
-		if err != nil {
-			m.logger.Warn("Failed to read share config file", "file", file, "error", err)
-			continue
-		}
-
-		var smbConfig SMBShareConfig
-		if err := json.Unmarshal(data, &smbConfig); err != nil {
-			m.logger.Warn("Failed to parse share config file", "file", file, "error", err)
-			continue
-		}
-
-		// Create ShareConfig from SMBShareConfig
-		shareConfig := shares.ShareConfig{
-			Name:        smbConfig.Name,
-			Description: smbConfig.Description,
-			Path:        smbConfig.Path,
-			Type:        shares.ShareTypeSMB,
-			Enabled:     smbConfig.Enabled,
-			Tags:        smbConfig.Tags,
-			Created:     getFileCreationTime(file),
-			Modified:    getFileModificationTime(file),
-		}
+	// One smbstatus run gives the status of every share
+	active, err := activeShares(ctx)
+	if err != nil {
+		m.logger.Warn("Failed to get share status", "error", err)
+	}
 
-		// Get share status
-		status, err := m.getShareStatus(ctx, smbConfig.Name)
-		if err != nil {
-			m.logger.Warn("Failed to get share status", "share", smbConfig.Name, "error", err)
-			shareConfig.Status = shares.ShareStatusInactive
-		} else if status {
-			shareConfig.Status = shares.ShareStatusActive
+	result := m.readShareConfigs(ctx, files)
+	for i := range result {
+		if active[result[i].Name] {
+			result[i].Status = shares.ShareStatusActive
 		} else {
-			shareConfig.Status = shares.ShareStatusInactive
+			result[i].Status = shares.ShareStatusInactive
 		}
-
-		result = append(result, shareConfig)
 	}
 
 	return result, nil
@@ -238,7 +280,8 @@ func (m *Manager) ListShares(ctx context.Context) ([]shares.ShareConfig, error)
 func (m *Manager) ListSharesByType(
 	ctx context.Context,
 	shareType shares.ShareType,
-) ([]shares.ShareConfig, error) {
+) (_ []shares.ShareConfig, err error) {
+	defer m.observe("list_shares_by_type", "")(&err)
 	if shareType != shares.ShareTypeSMB {
 		return nil, errors.New(errors.SharesInvalidInput, "Unsupported share type").
 			WithMetadata("type", string(shareType))
@@ -248,7 +291,8 @@ func (m *Manager) ListSharesByType(
 }
 
 // GetShare returns the configuration for a specific SMB share
-func (m *Manager) GetShare(ctx context.Context, name string) (*shares.ShareConfig, error) {
+func (m *Manager) GetShare(ctx context.Context, name string) (_ *shares.ShareConfig, err error) {
+	defer m.observe("get_share", name)(&err)
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -305,7 +349,8 @@ func (m *Manager) GetShare(ctx context.Context, name string) (*shares.ShareConfi
 }
 
 // GetSMBShare returns the SMB specific configuration for a share
-func (m *Manager) GetSMBShare(ctx context.Context, name string) (*SMBShareConfig, error) {
+func (m *Manager) GetSMBShare(ctx context.Context, name string) (_ *SMBShareConfig, err error) {
+	defer m.observe("get_smb_share", name)(&err)
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -339,7 +384,8 @@ func (m *Manager) GetSMBShare(ctx context.Context, name string) (*SMBShareConfig
 }
 
 // CreateShare creates a new SMB share
-func (m *Manager) CreateShare(ctx context.Context, config interface{}) error {
+func (m *Manager) CreateShare(ctx context.Context, config interface{}) (err error) {
+	defer m.observe("create_share", configShareName(config))(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -349,6 +395,17 @@ func (m *Manager) CreateShare(ctx context.Context, config interface{}) error {
 		return errors.New(errors.SharesInvalidInput, "Invalid share configuration type")
 	}
 
+	if err := m.createShare(ctx, smbConfig); err != nil {
+		return err
+	}
+
+	m.recordShareRevision(ctx, smbConfig, ShareRevisionCreate, 0)
+	return nil
+}
+
+// createShare validates and writes a new share's configuration, generates
+// it and reloads Samba (must be called with lock held)
+func (m *Manager) createShare(ctx context.Context, smbConfig *SMBShareConfig) error {
 	// Validate share configuration
 	if err := m.validateShareConfig(smbConfig); err != nil {
 		return err
@@ -361,6 +418,10 @@ func (m *Manager) CreateShare(ctx context.Context, config interface{}) error {
 			WithMetadata("name", smbConfig.Name)
 	}
 
+	if err := m.applySnapshotSettings(ctx, smbConfig); err != nil {
+		return err
+	}
+
 	// Save share configuration
 	data, err := json.MarshalIndent(smbConfig, "", "  ")
 	if err != nil {
@@ -380,6 +441,15 @@ func (m *Manager) CreateShare(ctx context.Context, config interface{}) error {
 		return err
 	}
 
+	if smbConfig.GuestEnabled() {
+		if err := m.fixGuestPermissions(ctx, smbConfig); err != nil {
+			return err
+		}
+		if err := m.regenerateGlobalForGuests(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Reload SMB configuration
 	if err := m.ReloadConfig(ctx); err != nil {
 		return errors.Wrap(err, errors.SharesOperationFailed).
@@ -391,7 +461,8 @@ func (m *Manager) CreateShare(ctx context.Context, config interface{}) error {
 }
 
 // UpdateShare updates an existing SMB share
-func (m *Manager) UpdateShare(ctx context.Context, name string, config interface{}) error {
+func (m *Manager) UpdateShare(ctx context.Context, name string, config interface{}) (err error) {
+	defer m.observe("update_share", name)(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -401,6 +472,17 @@ func (m *Manager) UpdateShare(ctx context.Context, name string, config interface
 		return errors.New(errors.SharesInvalidInput, "Invalid share configuration type")
 	}
 
+	if err := m.updateShare(ctx, name, smbConfig); err != nil {
+		return err
+	}
+
+	m.recordShareRevision(ctx, smbConfig, ShareRevisionUpdate, 0)
+	return nil
+}
+
+// updateShare validates and writes an existing share's configuration,
+// regenerates it and reloads Samba (must be called with lock held)
+func (m *Manager) updateShare(ctx context.Context, name string, smbConfig *SMBShareConfig) error {
 	// Validate share configuration
 	if err := m.validateShareConfig(smbConfig); err != nil {
 		return err
@@ -419,6 +501,12 @@ func (m *Manager) UpdateShare(ctx context.Context, name string, config interface
 		return errors.New(errors.SharesNotFound, "Share not found").
 			WithMetadata("name", name)
 	}
+	wasGuest := m.isGuestShare(name)
+	m.ensureShareBaseline(name)
+
+	if err := m.applySnapshotSettings(ctx, smbConfig); err != nil {
+		return err
+	}
 
 	// Save share configuration
 	data, err := json.MarshalIndent(smbConfig, "", "  ")
@@ -439,6 +527,15 @@ func (m *Manager) UpdateShare(ctx context.Context, name string, config interface
 		return err
 	}
 
+	if err := m.fixGuestPermissions(ctx, smbConfig); err != nil {
+		return err
+	}
+	if wasGuest || smbConfig.GuestEnabled() {
+		if err := m.regenerateGlobalForGuests(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Reload SMB configuration
 	if err := m.ReloadConfig(ctx); err != nil {
 		return errors.Wrap(err, errors.SharesOperationFailed).
@@ -450,7 +547,8 @@ func (m *Manager) UpdateShare(ctx context.Context, name string, config interface
 }
 
 // DeleteShare deletes an SMB share
-func (m *Manager) DeleteShare(ctx context.Context, name string) error {
+func (m *Manager) DeleteShare(ctx context.Context, name string) (err error) {
+	defer m.observe("delete_share", name)(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -466,6 +564,12 @@ func (m *Manager) DeleteShare(ctx context.Context, name string) error {
 		return errors.New(errors.SharesNotFound, "Share not found").
 			WithMetadata("name", name)
 	}
+	wasGuest := m.isGuestShare(name)
+
+	// Keep the share in the trash so the deletion can be undone
+	if err := m.trashShare(ctx, name); err != nil {
+		return err
+	}
 
 	// Remove share configuration file
 	if err := os.Remove(filePath); err != nil {
@@ -475,12 +579,19 @@ func (m *Manager) DeleteShare(ctx context.Context, name string) error {
 	}
 
 	// Remove generated SMB configuration
-	smbConfPath := filepath.Join(sharesConfigDir, name+smbConfigFileExt)
+	smbConfPath := filepath.Join(m.configDir, name+smbConfigFileExt)
 	if err := os.Remove(smbConfPath); err != nil && !os.IsNotExist(err) {
 		m.logger.Warn("Failed to remove SMB configuration file",
 			"file", smbConfPath,
 			"error", err)
 	}
+	m.removeShareRevisions(name)
+
+	if wasGuest {
+		if err := m.regenerateGlobalForGuests(ctx); err != nil {
+			return err
+		}
+	}
 
 	// Reload SMB configuration
 	if err := m.ReloadConfig(ctx); err != nil {
@@ -493,7 +604,8 @@ func (m *Manager) DeleteShare(ctx context.Context, name string) error {
 }
 
 // GetShareStats returns statistics for an SMB share
-func (m *Manager) GetShareStats(ctx context.Context, name string) (*shares.ShareStats, error) {
+func (m *Manager) GetShareStats(ctx context.Context, name string) (_ *shares.ShareStats, err error) {
+	defer m.observe("get_share_stats", name)(&err)
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -533,7 +645,8 @@ func (m *Manager) GetShareStats(ctx context.Context, name string) (*shares.Share
 }
 
 // GetSMBShareStats returns detailed SMB statistics for a share
-func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareStats, error) {
+func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (_ *SMBShareStats, err error) {
+	defer m.observe("get_smb_share_stats", name)(&err)
 	// Validate share name
 	if !shareNameRegex.MatchString(name) {
 		return nil, errors.New(errors.SharesInvalidInput, "Invalid share name format").
@@ -542,64 +655,14 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 
 	filePath := filepath.Join(m.configDir, name+configFileExt)
 
-	// Run smbstatus to get detailed information
-	out, err := exec.CommandContext(ctx, "sudo", "smbstatus", "-j").Output()
+	// Run smbstatus to get detailed information, or reuse its recent state
+	smbStatus, err := statusCache.get(ctx)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "stats").
 			WithMetadata("name", name)
 	}
 
-	// Parse JSON output
-	var smbStatus struct {
-		Sessions map[string]struct {
-			SessionID     string `json:"session_id"`
-			Username      string `json:"username"`
-			GroupName     string `json:"groupname"`
-			RemoteMachine string `json:"remote_machine"`
-			Encryption    struct {
-				Cipher string `json:"cipher"`
-				Degree string `json:"degree"`
-			} `json:"encryption"`
-			Signing struct {
-				Cipher string `json:"cipher"`
-				Degree string `json:"degree"`
-			} `json:"signing"`
-		} `json:"sessions"`
-		Tcons map[string]struct {
-			Service     string `json:"service"`
-			SessionID   string `json:"session_id"`
-			Machine     string `json:"machine"`
-			ConnectedAt string `json:"connected_at"`
-		} `json:"tcons"`
-		OpenFiles map[string]struct {
-			ServicePath string `json:"service_path"`
-			Filename    string `json:"filename"`
-			Opens       map[string]struct {
-				UID       int    `json:"uid"`
-				OpenedAt  string `json:"opened_at"`
-				ShareMode struct {
-					Read   bool   `json:"READ"`
-					Write  bool   `json:"WRITE"`
-					Delete bool   `json:"DELETE"`
-					Text   string `json:"text"`
-				} `json:"sharemode"`
-				AccessMask struct {
-					ReadData   bool   `json:"READ_DATA"`
-					WriteData  bool   `json:"WRITE_DATA"`
-					AppendData bool   `json:"APPEND_DATA"`
-					Text       string `json:"text"`
-				} `json:"access_mask"`
-			} `json:"opens"`
-		} `json:"open_files"`
-	}
-
-	if err := json.Unmarshal(out, &smbStatus); err != nil {
-		return nil, errors.Wrap(err, errors.SharesOperationFailed).
-			WithMetadata("operation", "parse_stats").
-			WithMetadata("name", name)
-	}
-
 	// Create SMBShareStats
 	stats := &SMBShareStats{
 		Sessions: make([]SMBSession, 0),
@@ -607,22 +670,23 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 		Status:   shares.ShareStatusInactive,
 	}
 
-	// Track session IDs for this share
+	// Track session IDs and tree connections for this share
+	connections := 0
 	shareSessions := make(map[string]bool)
 
 	// Map to store connection times for each session
 	sessionConnectedTimes := make(map[string]time.Time)
 
 	// Gather connection info from tcons and record connection times
-	for _, tcon := range smbStatus.Tcons {
+	for _, tcon := range smbStatus.TreeConnects {
 		if tcon.Service == name {
 			shareSessions[tcon.SessionID] = true
 			stats.Status = shares.ShareStatusActive
+			connections++
 
-			// Parse and store connection time for this session
-			connectedAt, err := time.Parse(time.RFC3339, tcon.ConnectedAt)
-			if err == nil {
-				sessionConnectedTimes[tcon.SessionID] = connectedAt
+			// Store connection time for this session
+			if !tcon.ConnectedAt.IsZero() {
+				sessionConnectedTimes[tcon.SessionID] = tcon.ConnectedAt
 			}
 		}
 	}
@@ -639,8 +703,8 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 				GroupName:     session.GroupName,
 				RemoteMachine: session.RemoteMachine,
 				ConnectedAt:   connectedAt,
-				Encryption:    session.Encryption.Degree,
-				Signing:       session.Signing.Degree,
+				Encryption:    session.Encryption,
+				Signing:       session.Signing,
 			}
 
 			stats.Sessions = append(stats.Sessions, smbSession)
@@ -660,7 +724,7 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 
 		// If not a direct match, check if the service path is used by this share in any tcon
 		if !belongsToShare {
-			for _, tcon := range smbStatus.Tcons {
+			for _, tcon := range smbStatus.TreeConnects {
 				if tcon.Service == name {
 					// This is our share - if the path contains our share's path, include it
 					if strings.Contains(path, fileInfo.ServicePath) {
@@ -673,14 +737,13 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 
 		if belongsToShare {
 			for openID, openInfo := range fileInfo.Opens {
-				openedAt, _ := time.Parse(time.RFC3339, openInfo.OpenedAt)
 
 				// Get username from session if possible
 				var username string
 				var sessionID string
 
 				// Find the session ID for this open file
-				for _, tcon := range smbStatus.Tcons {
+				for _, tcon := range smbStatus.TreeConnects {
 					if tcon.Service == name {
 						sessionID = tcon.SessionID
 						break
@@ -696,9 +759,9 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 					Path:         path,
 					ShareName:    name,
 					Username:     username,
-					OpenedAt:     openedAt,
-					AccessMode:   openInfo.ShareMode.Text,
-					AccessRights: openInfo.AccessMask.Text,
+					OpenedAt:     openInfo.OpenedAt,
+					AccessMode:   openInfo.ShareMode,
+					AccessRights: openInfo.AccessMask,
 					OpenID:       openID,
 				}
 
@@ -711,12 +774,14 @@ func (m *Manager) GetSMBShareStats(ctx context.Context, name string) (*SMBShareS
 	stats.ActiveSessions = len(stats.Sessions)
 	stats.OpenFiles = len(stats.Files)
 	stats.ConfModified = getFileModificationTime(filePath)
+	stats.Limits = m.shareLimitStatus(name, connections)
 
 	return stats, nil
 }
 
 // Exists checks if an SMB share exists
-func (m *Manager) Exists(ctx context.Context, name string) (bool, error) {
+func (m *Manager) Exists(ctx context.Context, name string) (_ bool, err error) {
+	defer m.observe("exists", name)(&err)
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -740,7 +805,10 @@ func (m *Manager) Exists(ctx context.Context, name string) (bool, error) {
 }
 
 // ReloadConfig reloads the SMB configuration
-func (m *Manager) ReloadConfig(ctx context.Context) error {
+func (m *Manager) ReloadConfig(ctx context.Context) (err error) {
+	defer m.observe("reload_config", "")(&err)
+	// Connections may change with the configuration
+	defer statusCache.invalidate()
 	// Update main SMB configuration file
 	if err := m.updateMainConfig(); err != nil {
 		return err
@@ -753,10 +821,14 @@ func (m *Manager) ReloadConfig(ctx context.Context) error {
 	// Reload SMB service configuration
 	m.logger.Debug("Reloading SMB configuration with smbcontrol")
 
-	cmd := exec.CommandContext(timeoutCtx, "sudo", "smbcontrol", "smbd", "reload-config")
+	args := []string{"smbd", "reload-config"}
+	if m.smbConfPath != defaultSMBConfigPath {
+		// Reach the smbd of this configuration through its pid directory
+		args = []string{"--configfile=" + m.smbConfPath, "smbd", "reload-config"}
+	}
 
 	// Capture output in case of errors
-	output, err := cmd.CombinedOutput()
+	output, err := m.executor.ExecuteWithCombinedOutput(timeoutCtx, "smbcontrol", args...)
 	if err != nil {
 		if timeoutCtx.Err() == context.DeadlineExceeded {
 			m.logger.Error("Timeout while reloading SMB configuration")
@@ -776,7 +848,8 @@ func (m *Manager) ReloadConfig(ctx context.Context) error {
 // GenerateConfig imports existing SMB configurations into Rodent-managed shares
 // It backs up the existing smb.conf file, parses it to find shares and global config,
 // and creates individual share config files that Rodent can manage.
-func (m *Manager) GenerateConfig(ctx context.Context) error {
+func (m *Manager) GenerateConfig(ctx context.Context) (err error) {
+	defer m.observe("generate_config", "")(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -800,7 +873,7 @@ func (m *Manager) GenerateConfig(ctx context.Context) error {
 	if !hasExistingShareConfigs {
 		m.logger.Info("Existing Rodent-managed SMB shares not found, backing up original config")
 		// Backup existing SMB config file
-		backupPath, err := BackupConfigFile(defaultSMBConfigPath, m.fileOps)
+		backupPath, err := BackupConfigFile(m.smbConfPath, m.fileOps)
 		if err != nil {
 			return errors.Wrap(err, errors.SharesOperationFailed).
 				WithMetadata("operation", "backup_smb_config")
@@ -811,7 +884,7 @@ func (m *Manager) GenerateConfig(ctx context.Context) error {
 	}
 
 	// Parse existing SMB config file if it exists
-	exists, err := m.fileOps.Exists(ctx, defaultSMBConfigPath)
+	exists, err := m.fileOps.Exists(ctx, m.smbConfPath)
 	if err != nil {
 		m.logger.Warn("Error checking if SMB config exists", "error", err)
 	}
@@ -866,7 +939,7 @@ func (m *Manager) GenerateConfig(ctx context.Context) error {
 	}
 
 	// Parse existing config
-	parser, err := NewSMBConfigParser(defaultSMBConfigPath, m.fileOps)
+	parser, err := NewSMBConfigParser(m.smbConfPath, m.fileOps)
 	if err != nil {
 		return err
 	}
@@ -1035,18 +1108,24 @@ func (m *Manager) GenerateConfig(ctx context.Context) error {
 	return nil
 }
 
-// UpdateGlobalConfig updates the global SMB configuration
-func (m *Manager) UpdateGlobalConfig(ctx context.Context, config *SMBGlobalConfig) error {
+// UpdateGlobalConfig updates the global SMB configuration and records it as
+// an applied version that can be reverted to
+func (m *Manager) UpdateGlobalConfig(
+	ctx context.Context,
+	config *SMBGlobalConfig,
+) (_ *GlobalConfigVersion, err error) {
+	defer m.observe("update_global_config", "")(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// Validate global configuration
-	if config.WorkGroup == "" {
-		return errors.New(errors.SharesInvalidInput, "Workgroup cannot be empty")
-	}
+	return m.applyGlobalConfig(ctx, config, 0)
+}
 
-	if config.SecurityMode == "" {
-		return errors.New(errors.SharesInvalidInput, "Security mode cannot be empty")
+// writeGlobalConfig saves, renders and reloads the global configuration (must
+// be called with lock held)
+func (m *Manager) writeGlobalConfig(ctx context.Context, config *SMBGlobalConfig) error {
+	if err := validateGlobalConfig(config); err != nil {
+		return err
 	}
 
 	// Save global configuration
@@ -1076,9 +1155,24 @@ func (m *Manager) UpdateGlobalConfig(ctx context.Context, config *SMBGlobalConfi
 	return m.ReloadConfig(ctx)
 }
 
+// validateGlobalConfig validates a global configuration before it is applied
+// or previewed
+func validateGlobalConfig(config *SMBGlobalConfig) error {
+	if config.WorkGroup == "" {
+		return errors.New(errors.SharesInvalidInput, "Workgroup cannot be empty")
+	}
+
+	if config.SecurityMode == "" {
+		return errors.New(errors.SharesInvalidInput, "Security mode cannot be empty")
+	}
+
+	return validateTrustedDomains(config)
+}
+
 // GetGlobalConfig returns the global SMB configuration
 // skipLock parameter allows callers that already hold the lock to avoid deadlocks
-func (m *Manager) GetGlobalConfig(ctx context.Context, skipLock ...bool) (*SMBGlobalConfig, error) {
+func (m *Manager) GetGlobalConfig(ctx context.Context, skipLock ...bool) (_ *SMBGlobalConfig, err error) {
+	defer m.observe("get_global_config", "")(&err)
 	acquireLock := true
 	if len(skipLock) > 0 && skipLock[0] {
 		acquireLock = false
@@ -1119,7 +1213,8 @@ func (m *Manager) GetGlobalConfig(ctx context.Context, skipLock ...bool) (*SMBGl
 }
 
 // GetSMBServiceStatus returns the status of the SMB service
-func (m *Manager) GetSMBServiceStatus(ctx context.Context) (*SMBServiceStatus, error) {
+func (m *Manager) GetSMBServiceStatus(ctx context.Context) (_ *SMBServiceStatus, err error) {
+	defer m.observe("get_service_status", "")(&err)
 	// Check if SMB service is running
 	cmd := exec.CommandContext(ctx, "systemctl", "is-active", "smbd")
 	out, err := cmd.Output()
@@ -1154,50 +1249,28 @@ func (m *Manager) GetSMBServiceStatus(ctx context.Context) (*SMBServiceStatus, e
 		}
 
 		// Get config file
-		status.ConfigFile = defaultSMBConfigPath
+		status.ConfigFile = m.smbConfPath
 
 		// Get start time
 		if status.PID > 0 {
-			procFile := fmt.Sprintf("/proc/%d/stat", status.PID)
-			procData, err := os.ReadFile(procFile)
+			procData, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", status.PID))
 			if err == nil {
-				procFields := strings.Fields(string(procData))
-				if len(procFields) > 22 {
-					startTime, err := strconv.ParseInt(procFields[21], 10, 64)
-					if err == nil {
-						// Convert clock ticks to seconds
-						var clockTicks int64 = 100 // Usually 100 Hz on Linux
-						uptime, err := os.ReadFile("/proc/uptime")
-						if err == nil {
-							uptimeFields := strings.Fields(string(uptime))
-							if len(uptimeFields) > 0 {
-								upSec, err := strconv.ParseFloat(uptimeFields[0], 64)
-								if err == nil {
-									bootTime := time.Now().Add(-time.Duration(upSec) * time.Second)
-									status.StartTime = bootTime.Add(
-										time.Duration(startTime/clockTicks) * time.Second,
-									)
-								}
-							}
-						}
+				stat, err := parsers.ParseProcStat(procData)
+				uptimeData, uptimeErr := os.ReadFile("/proc/uptime")
+				if err == nil && uptimeErr == nil && stat.StartTime > 0 {
+					if uptime, err := parsers.ParseUptime(uptimeData); err == nil {
+						status.StartTime = stat.StartedAt(uptime, time.Now())
 					}
 				}
 			}
 		}
 
 		// Get active shares and sessions
-		smbStatus, err := exec.CommandContext(ctx, "sudo", "smbstatus", "-f", "-j").Output()
-		if err == nil {
-			var parsedStatus struct {
-				Sessions map[string]interface{} `json:"sessions"`
-				Tcons    map[string]interface{} `json:"tcons"`
-			}
-
-			if err := json.Unmarshal(smbStatus, &parsedStatus); err == nil {
-				status.ActiveSessions = len(parsedStatus.Sessions)
-				status.ActiveShares = len(parsedStatus.Tcons)
-			}
+		if smbStatus, err := statusCache.get(ctx); err == nil {
+			status.ActiveSessions = len(smbStatus.Sessions)
+			status.ActiveShares = len(smbStatus.TreeConnects)
 		}
+		status.StatusFormat = string(statusCache.format())
 	}
 
 	return status, nil
@@ -1207,28 +1280,52 @@ func (m *Manager) GetSMBServiceStatus(ctx context.Context) (*SMBServiceStatus, e
 
 // updateMainConfig updates the main SMB configuration file
 func (m *Manager) updateMainConfig() error {
+	content, err := m.renderMainConfig(nil)
+	if err != nil {
+		return err
+	}
+
+	// Write updated config using privileged operations
+	if err := m.fileOps.WriteFile(context.Background(), m.smbConfPath, []byte(content), 0644); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "write_config")
+	}
+
+	return nil
+}
+
+// renderMainConfig assembles the main SMB configuration. A non-nil globalData
+// is used as the global section instead of global.smb.conf, so a global
+// configuration can be previewed before it is written.
+func (m *Manager) renderMainConfig(globalData []byte) (string, error) {
 	// Start with global configuration
 	var content strings.Builder
 
 	// Add debugging logs
 	m.logger.Debug("Updating main SMB config",
-		"sharesConfigDir", sharesConfigDir,
+		"sharesConfigDir", m.configDir,
 		"globalSMBConf", globalSMBConf)
 
 	// Read global configuration
-	globalPath := filepath.Join(sharesConfigDir, globalSMBConf)
-	m.logger.Debug("Reading global config", "globalPath", globalPath)
-
-	globalData, err := os.ReadFile(globalPath)
-	if err == nil {
-		m.logger.Debug("Successfully read global config", "size", len(globalData))
-		content.WriteString(string(globalData))
+	globalPath := filepath.Join(m.configDir, globalSMBConf)
+	if globalData != nil {
+		content.Write(globalData)
 		content.WriteString("\n\n")
 	} else {
-		m.logger.Debug("Failed to read global config", "error", err.Error())
-		if !os.IsNotExist(err) {
-			return errors.Wrap(err, errors.SharesOperationFailed).
-				WithMetadata("operation", "read_global_config")
+		m.logger.Debug("Reading global config", "globalPath", globalPath)
+
+		var err error
+		globalData, err = os.ReadFile(globalPath)
+		if err == nil {
+			m.logger.Debug("Successfully read global config", "size", len(globalData))
+			content.WriteString(string(globalData))
+			content.WriteString("\n\n")
+		} else {
+			m.logger.Debug("Failed to read global config", "error", err.Error())
+			if !os.IsNotExist(err) {
+				return "", errors.Wrap(err, errors.SharesOperationFailed).
+					WithMetadata("operation", "read_global_config")
+			}
 		}
 	}
 
@@ -1264,10 +1361,17 @@ func (m *Manager) updateMainConfig() error {
 		}
 	}
 
+	// Printing sections managed by Rodent replace those in smb.conf
+	printing := m.managedPrinting()
+	printingSections, err := m.renderPrintingSections(printing)
+	if err != nil {
+		return "", err
+	}
+
 	// Check if we have existing files in SharesConfigDir
-	shareConfigs, err := filepath.Glob(filepath.Join(sharesConfigDir, "*"+smbConfigFileExt))
+	shareConfigs, err := filepath.Glob(filepath.Join(m.configDir, "*"+smbConfigFileExt))
 	if err != nil {
-		return errors.Wrap(err, errors.SharesOperationFailed).
+		return "", errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "find_share_configs")
 	}
 
@@ -1275,18 +1379,22 @@ func (m *Manager) updateMainConfig() error {
 	// The only change would be to update the global section if we have one
 	if len(shareConfigs) <= 1 && len(globalData) > 0 {
 		// Read existing smb.conf to preserve non-share sections
-		existingConfig, readErr := m.fileOps.ReadFile(context.Background(), defaultSMBConfigPath)
+		existingConfig, readErr := m.fileOps.ReadFile(context.Background(), m.smbConfPath)
 
 		// If we have an existing config and can read it
 		if readErr == nil && len(existingConfig) > 0 {
 			// Parse out non-share sections and preserve them
-			nonShareSections, preservedShares := preserveSpecialSections(string(existingConfig))
+			nonShareSections, preservedShares := preserveSpecialSections(
+				string(existingConfig),
+				printing != nil,
+			)
 
 			// Add special sections after the global section
 			if nonShareSections != "" {
 				content.WriteString(nonShareSections)
 				content.WriteString("\n\n")
 			}
+			content.WriteString(printingSections)
 
 			// Add preserved shares at the end
 			if preservedShares != "" {
@@ -1294,18 +1402,14 @@ func (m *Manager) updateMainConfig() error {
 				content.WriteString(preservedShares)
 			}
 
-			// Write updated config using privileged operations
-			if err := m.fileOps.WriteFile(context.Background(), defaultSMBConfigPath, []byte(content.String()), 0644); err != nil {
-				return errors.Wrap(err, errors.SharesOperationFailed).
-					WithMetadata("operation", "write_config")
-			}
-
-			return nil
+			return content.String(), nil
 		}
 	}
 
 	// Standard path for rodent-managed shares
 
+	content.WriteString(printingSections)
+
 	// Append each share configuration
 	content.WriteString(
 		"# Do not manually edit share definitions - managed by StrataSTOR Rodent service\n",
@@ -1326,18 +1430,13 @@ func (m *Manager) updateMainConfig() error {
 		content.WriteString("\n\n")
 	}
 
-	// Write updated config using privileged operations
-	if err := m.fileOps.WriteFile(context.Background(), defaultSMBConfigPath, []byte(content.String()), 0644); err != nil {
-		return errors.Wrap(err, errors.SharesOperationFailed).
-			WithMetadata("operation", "write_config")
-	}
-
-	return nil
+	return content.String(), nil
 }
 
 // preserveSpecialSections extracts special sections from smb.conf that should be preserved
-// Returns two strings: non-share special sections and non-rodent managed shares
-func preserveSpecialSections(content string) (string, string) {
+// Returns two strings: non-share special sections and non-rodent managed shares.
+// The printing sections are dropped when Rodent manages printing.
+func preserveSpecialSections(content string, managedPrinting bool) (string, string) {
 	var specialSections strings.Builder
 	var preservedShares strings.Builder
 
@@ -1366,7 +1465,8 @@ func preserveSpecialSections(content string) (string, string) {
 					currentSection == "printers" ||
 					currentSection == "print$" {
 					// Special sections but not global (which is handled separately)
-					if currentSection != "global" {
+					if currentSection != "global" &&
+						!(managedPrinting && isPrintingSection(currentSection)) {
 						specialSections.WriteString("[" + currentSection + "]\n")
 						specialSections.WriteString(sectionData)
 						specialSections.WriteString("\n\n")
@@ -1404,7 +1504,8 @@ func preserveSpecialSections(content string) (string, string) {
 			currentSection == "printers" ||
 			currentSection == "print$" {
 			// Special sections but not global
-			if currentSection != "global" {
+			if currentSection != "global" &&
+				!(managedPrinting && isPrintingSection(currentSection)) {
 				specialSections.WriteString("[" + currentSection + "]\n")
 				specialSections.WriteString(sectionData)
 				specialSections.WriteString("\n\n")
@@ -1444,16 +1545,27 @@ func (m *Manager) generateShareConfig(config *SMBShareConfig) error {
 
 	tmpl = tmpl.Funcs(funcMap)
 
+	// Paths also exported over NFS get options safe for both protocols
+	exports := m.nfsExportsFor(config.Path)
+	if len(exports) > 0 {
+		m.logger.Info("Share path is also exported over NFS, rendering multi-protocol options",
+			"name", config.Name,
+			"path", config.Path)
+		for _, warning := range multiProtocolWarnings(config, exports) {
+			m.logger.Warn("Multi-protocol share", "name", config.Name, "warning", warning)
+		}
+	}
+
 	// Render the template
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, config); err != nil {
+	if err := tmpl.Execute(&buf, m.withAccessOverride(withNFSCompatibility(renderShareConfig(config), exports))); err != nil {
 		return errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "render_template").
 			WithMetadata("name", config.Name)
 	}
 
 	// Write the configuration file
-	filePath := filepath.Join(sharesConfigDir, config.Name+smbConfigFileExt)
+	filePath := filepath.Join(m.configDir, config.Name+smbConfigFileExt)
 	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
 		return errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "write_config").
@@ -1463,84 +1575,145 @@ func (m *Manager) generateShareConfig(config *SMBShareConfig) error {
 	return nil
 }
 
+// renderShareConfig returns the config to render, with the VFS modules of
+// enabled features appended to the vfs objects and the .zfs directory added
+// to hidden files when asked. The stored config is left untouched.
+func renderShareConfig(config *SMBShareConfig) *SMBShareConfig {
+	var modules []string
+	if config.VirusFilterEnabled() {
+		modules = append(modules, virusFilterVFSObject)
+	}
+	if config.RateLimitEnabled() {
+		modules = append(modules, rateLimitVFSObject)
+	}
+	if config.PreviousVersionsEnabled() {
+		modules = append(modules, shadowCopyVFSObject)
+	}
+	// worm goes last so it sees the final result of the other modules
+	if config.WormEnabled() {
+		modules = append(modules, wormVFSObject)
+	}
+	if len(modules) == 0 && !config.HideSnapdirEnabled() {
+		return config
+	}
+
+	rendered := *config
+	rendered.CustomParameters = maps.Clone(config.CustomParameters)
+	if rendered.CustomParameters == nil {
+		rendered.CustomParameters = make(map[string]string)
+	}
+	if len(modules) > 0 {
+		vfsObjects := strings.TrimSpace(rendered.CustomParameters["vfs objects"])
+		for _, module := range modules {
+			if !hasVFSObject(vfsObjects, module) {
+				vfsObjects = strings.TrimSpace(vfsObjects + " " + module)
+			}
+		}
+		rendered.CustomParameters["vfs objects"] = vfsObjects
+	}
+	// hide files is one value; the .zfs entry joins any set by hand
+	if config.HideSnapdirEnabled() {
+		hidden := rendered.CustomParameters[hideFilesParam]
+		if !strings.Contains(hidden, snapdirHideEntry) {
+			rendered.CustomParameters[hideFilesParam] = strings.TrimSuffix(hidden, "/") + snapdirHideEntry
+		}
+	}
+	return &rendered
+}
+
+// hasVFSObject reports whether a vfs objects value loads a module
+func hasVFSObject(vfsObjects, module string) bool {
+	return slices.Contains(strings.Fields(vfsObjects), module)
+}
+
+// withoutVFSObject removes a module from a vfs objects value
+func withoutVFSObject(vfsObjects, module string) string {
+	objects := slices.DeleteFunc(strings.Fields(vfsObjects), func(o string) bool {
+		return o == module
+	})
+	return strings.Join(objects, " ")
+}
+
 // generateGlobalConfig generates the global SMB configuration
 func (m *Manager) generateGlobalConfig(config *SMBGlobalConfig) error {
+	data, err := m.renderGlobalConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// Write the configuration file
+	filePath := filepath.Join(m.configDir, globalSMBConf)
+	m.logger.Debug("Writing global config file", "path", filePath)
+
+	if err := os.WriteFile(filePath, data, 0644); err != nil {
+		m.logger.Error("Failed to write global config file", "error", err.Error())
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "write_global_config")
+	}
+
+	m.logger.Debug("Successfully wrote global config file")
+	return nil
+}
+
+// renderGlobalConfig renders the global section of the SMB configuration
+func (m *Manager) renderGlobalConfig(config *SMBGlobalConfig) ([]byte, error) {
 	m.logger.Debug("Generating global SMB config",
 		"workgroup", config.WorkGroup,
 		"security", config.SecurityMode,
-		"sharesConfigDir", sharesConfigDir,
+		"sharesConfigDir", m.configDir,
 		"globalTemplate", globalTemplate)
 
 	// Get the template
 	tmpl, ok := m.templates[globalTemplate]
 	if !ok {
 		m.logger.Error("Global template not found")
-		return errors.New(errors.SharesInternalError, "Global template not found")
+		return nil, errors.New(errors.SharesInternalError, "Global template not found")
 	}
 
 	m.logger.Debug("Found global template")
 
+	// Trusted domains add their idmap config and winbind settings
+	renderConfig := *config
+	renderTrustedDomains(&renderConfig)
+
+	renderConfig.Printing = m.managedPrinting()
+
+	// Guest shares need unknown users mapped to the guest account
+	renderConfig.GuestSharesEnabled = m.hasGuestShares()
+	if renderConfig.GuestSharesEnabled {
+		params := renderConfig.CustomParameters
+		if mapping := params["map to guest"]; mapping == "" || strings.EqualFold(mapping, "never") {
+			params["map to guest"] = "Bad User"
+		}
+	}
+
 	// Render the template
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, config); err != nil {
+	if err := tmpl.Execute(&buf, &renderConfig); err != nil {
 		m.logger.Error("Failed to render global template", "error", err.Error())
-		return errors.Wrap(err, errors.SharesOperationFailed).
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
 			WithMetadata("operation", "render_global_template")
 	}
 
 	m.logger.Debug("Successfully rendered global template", "size", buf.Len())
-
-	// Write the configuration file
-	filePath := filepath.Join(sharesConfigDir, globalSMBConf)
-	m.logger.Debug("Writing global config file", "path", filePath)
-
-	if err := os.WriteFile(filePath, buf.Bytes(), 0644); err != nil {
-		m.logger.Error("Failed to write global config file", "error", err.Error())
-		return errors.Wrap(err, errors.SharesOperationFailed).
-			WithMetadata("operation", "write_global_config")
-	}
-
-	m.logger.Debug("Successfully wrote global config file")
-	return nil
+	return buf.Bytes(), nil
 }
 
 // getShareStatus checks if a share is active
 func (m *Manager) getShareStatus(ctx context.Context, name string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "sudo", "smbstatus", "-f", "-j")
-	out, err := cmd.Output()
+	active, err := activeShares(ctx)
 	if err != nil {
-		return false, errors.Wrap(err, errors.SharesOperationFailed).
-			WithMetadata("operation", "check_status").
-			WithMetadata("name", name)
-	}
-
-	var smbStatus struct {
-		Tcons map[string]struct {
-			Service string `json:"service"`
-		} `json:"tcons"`
-	}
-
-	if err := json.Unmarshal(out, &smbStatus); err != nil {
-		return false, errors.Wrap(err, errors.SharesOperationFailed).
-			WithMetadata("operation", "parse_status").
-			WithMetadata("name", name)
-	}
-
-	// Check if the share is active
-	for _, tcon := range smbStatus.Tcons {
-		if tcon.Service == name {
-			return true, nil
-		}
+		return false, err
 	}
-
-	return false, nil
+	return active[name], nil
 }
 
 // BulkUpdateShares updates multiple SMB shares with the same parameters
 func (m *Manager) BulkUpdateShares(
 	ctx context.Context,
 	config SMBBulkUpdateConfig,
-) ([]SMBBulkUpdateResult, error) {
+) (_ []SMBBulkUpdateResult, err error) {
+	defer m.observe("bulk_update_shares", "")(&err)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -1553,6 +1726,49 @@ func (m *Manager) BulkUpdateShares(
 		return nil, errors.New(errors.SharesInvalidInput, "No shares specified for bulk update")
 	}
 
+	for _, param := range guestParameters {
+		if _, ok := config.Parameters[param]; ok {
+			return nil, errors.New(
+				errors.SharesInvalidInput,
+				"Guest access cannot be changed by bulk update",
+			).WithMetadata("parameter", param)
+		}
+	}
+	if _, ok := config.Parameters[wormGracePeriodParam]; ok || hasWormObject(config.Parameters["vfs objects"]) {
+		return nil, errors.New(
+			errors.SharesInvalidInput,
+			"WORM mode cannot be changed by bulk update",
+		)
+	}
+	if hasVFSObject(config.Parameters["vfs objects"], virusFilterVFSObject) {
+		return nil, errors.New(
+			errors.SharesInvalidInput,
+			"Virus scanning cannot be changed by bulk update",
+		).WithMetadata("parameter", "vfs objects")
+	}
+	for param := range config.Parameters {
+		if strings.HasPrefix(param, virusFilterParamPrefix) {
+			return nil, errors.New(
+				errors.SharesInvalidInput,
+				"Virus scanning cannot be changed by bulk update",
+			).WithMetadata("parameter", param)
+		}
+	}
+	if hasVFSObject(config.Parameters["vfs objects"], rateLimitVFSObject) {
+		return nil, errors.New(
+			errors.SharesInvalidInput,
+			"Rate limits cannot be changed by bulk update",
+		).WithMetadata("parameter", "vfs objects")
+	}
+	for param := range config.Parameters {
+		if param == maxConnectionsParam || strings.HasPrefix(param, rateLimitParamPrefix) {
+			return nil, errors.New(
+				errors.SharesInvalidInput,
+				"Share limits cannot be changed by bulk update",
+			).WithMetadata("parameter", param)
+		}
+	}
+
 	// Get all shares
 	allShares, err := m.getAllShareConfigs()
 	if err != nil {
@@ -1612,11 +1828,14 @@ func (m *Manager) BulkUpdateShares(
 		}
 
 		// Save updated configuration
+		m.ensureShareBaseline(share.Name)
 		err := m.saveShareConfig(share)
 		if err != nil {
 			result.Success = false
 			result.Error = err.Error()
 		} else {
+			m.recordShareRevision(ctx, share, ShareRevisionBulkUpdate, 0)
+
 			// Generate SMB configuration
 			err = m.generateShareConfig(share)
 			if err != nil {
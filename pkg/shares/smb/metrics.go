@@ -0,0 +1,184 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/rodent/internal/metrics"
+)
+
+// Operation results recorded in the metrics and logs
+const (
+	resultSuccess = "success"
+	resultError   = "error"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the operation
+// duration histogram. Reads take milliseconds; writes reload smbd.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// opKey identifies an operation and its result
+type opKey struct {
+	op, result string
+}
+
+// shareKey identifies an operation and its result on one share
+type shareKey struct {
+	share, op, result string
+}
+
+// opHistogram holds the duration distribution of one operation and result
+type opHistogram struct {
+	counts []uint64 // Per bucket, plus one for +Inf
+	sum    float64
+	count  uint64
+}
+
+// operationStats are the SMB manager operation metrics. They are shared by
+// every Manager, as they describe the host's shares rather than one manager.
+type operationStats struct {
+	mu         sync.Mutex
+	histograms map[opKey]*opHistogram
+	shares     map[shareKey]uint64
+}
+
+var (
+	stats = &operationStats{
+		histograms: make(map[opKey]*opHistogram),
+		shares:     make(map[shareKey]uint64),
+	}
+
+	registerOnce sync.Once
+)
+
+// registerMetrics adds the operation metrics to the metrics endpoint
+func registerMetrics() {
+	registerOnce.Do(func() {
+		metrics.Register(stats)
+	})
+}
+
+// record adds one completed operation
+func (s *operationStats) record(op, share, result string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := opKey{op: op, result: result}
+	h, ok := s.histograms[key]
+	if !ok {
+		h = &opHistogram{counts: make([]uint64, len(durationBuckets)+1)}
+		s.histograms[key] = h
+	}
+	seconds := d.Seconds()
+	i, _ := slices.BinarySearch(durationBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+
+	if share != "" {
+		s.shares[shareKey{share: share, op: op, result: result}]++
+	}
+}
+
+// WritePrometheus writes rodent_smb_operation_duration_seconds by operation
+// and result, and rodent_smb_share_operations_total by share
+func (s *operationStats) WritePrometheus(w io.Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+
+	const durationName = "rodent_smb_operation_duration_seconds"
+	metrics.WriteHeader(&b, durationName, "histogram",
+		"Duration of SMB share manager operations, by operation and result")
+	keys := slices.SortedFunc(maps.Keys(s.histograms), func(a, b opKey) int {
+		return strings.Compare(a.op+"\x00"+a.result, b.op+"\x00"+b.result)
+	})
+	for _, key := range keys {
+		h := s.histograms[key]
+		var cumulative uint64
+		for i, bound := range durationBuckets {
+			cumulative += h.counts[i]
+			metrics.WriteSample(&b, durationName+"_bucket", float64(cumulative),
+				"operation", key.op, "result", key.result,
+				"le", strconv.FormatFloat(bound, 'g', -1, 64))
+		}
+		metrics.WriteSample(&b, durationName+"_bucket", float64(h.count),
+			"operation", key.op, "result", key.result, "le", "+Inf")
+		metrics.WriteSample(&b, durationName+"_sum", h.sum,
+			"operation", key.op, "result", key.result)
+		metrics.WriteSample(&b, durationName+"_count", float64(h.count),
+			"operation", key.op, "result", key.result)
+	}
+
+	const sharesName = "rodent_smb_share_operations_total"
+	metrics.WriteHeader(&b, sharesName, "counter",
+		"SMB share manager operations on each share, by operation and result")
+	shareKeys := slices.SortedFunc(maps.Keys(s.shares), func(a, b shareKey) int {
+		return strings.Compare(a.share+"\x00"+a.op+"\x00"+a.result,
+			b.share+"\x00"+b.op+"\x00"+b.result)
+	})
+	for _, key := range shareKeys {
+		metrics.WriteSample(&b, sharesName, float64(s.shares[key]),
+			"share", key.share, "operation", key.op, "result", key.result)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// observe starts timing an operation. The returned function, deferred with
+// a pointer to the operation's error result, records its duration and result
+// and logs it at debug level:
+//
+//	defer m.observe("get_share", name)(&err)
+//
+// share is empty for operations not on a single share.
+func (m *Manager) observe(op, share string) func(*error) {
+	start := time.Now()
+	return func(errp *error) {
+		d := time.Since(start)
+
+		var err error
+		if errp != nil {
+			err = *errp
+		}
+		result := resultSuccess
+		if err != nil {
+			result = resultError
+		}
+
+		stats.record(op, share, result, d)
+
+		args := []any{
+			"operation", op,
+			"result", result,
+			"duration", d,
+		}
+		if share != "" {
+			args = append(args, "share", share)
+		}
+		if err != nil {
+			args = append(args, "error", err)
+		}
+		m.logger.Debug("SMB operation", args...)
+	}
+}
+
+// configShareName returns the name of a share configuration passed to
+// CreateShare, or "" for an invalid one
+func configShareName(config interface{}) string {
+	if c, ok := config.(*SMBShareConfig); ok && c != nil {
+		return c.Name
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveRecordsOperations(t *testing.T) {
+	saved := stats
+	stats = &operationStats{
+		histograms: make(map[opKey]*opHistogram),
+		shares:     make(map[shareKey]uint64),
+	}
+	t.Cleanup(func() { stats = saved })
+
+	m := &Manager{logger: common.Log}
+	getShare := func(name string, fail bool) (err error) {
+		defer m.observe("get_share", name)(&err)
+		if fail {
+			return errors.New(errors.SharesNotFound, "Share not found")
+		}
+		return nil
+	}
+	require.NoError(t, getShare("media", false))
+	require.Error(t, getShare("media", true))
+	stats.record("reload_config", "", resultSuccess, 2*time.Second)
+
+	var b strings.Builder
+	require.NoError(t, stats.WritePrometheus(&b))
+	out := b.String()
+
+	assert.Contains(t, out,
+		`rodent_smb_operation_duration_seconds_count{operation="get_share",result="success"} 1`)
+	assert.Contains(t, out,
+		`rodent_smb_operation_duration_seconds_count{operation="get_share",result="error"} 1`)
+	assert.Contains(t, out,
+		`rodent_smb_operation_duration_seconds_bucket{operation="reload_config",result="success",le="1"} 0`)
+	assert.Contains(t, out,
+		`rodent_smb_operation_duration_seconds_bucket{operation="reload_config",result="success",le="2.5"} 1`)
+	assert.Contains(t, out,
+		`rodent_smb_share_operations_total{share="media",operation="get_share",result="error"} 1`)
+	assert.NotContains(t, out, `share="",`)
+}
@@ -0,0 +1,215 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/shares/nfs"
+)
+
+// nfsCompatParameters are rendered for shares whose path is also exported
+// over NFS, unless set as custom parameters. NFS clients do not see SMB
+// oplocks or leases, so SMB clients must not cache data NFS clients may
+// change, and SMB byte-range locks must be POSIX locks NFS clients honour.
+var nfsCompatParameters = map[string]string{
+	"oplocks":        "no",
+	"level2 oplocks": "no",
+	"posix locking":  "yes",
+}
+
+// PathProtocols lists the protocols sharing a directory. Shares and exports
+// of a parent or child directory are included, as they reach the same files.
+type PathProtocols struct {
+	Path          string         `json:"path"`
+	Protocols     []string       `json:"protocols"`
+	MultiProtocol bool           `json:"multi_protocol"`
+	SMBShares     []PathSMBShare `json:"smb_shares,omitempty"`
+	NFSExports    []nfs.Export   `json:"nfs_exports,omitempty"`
+	Warnings      []string       `json:"warnings,omitempty"`
+}
+
+// PathSMBShare is an SMB share in a PathProtocols view
+type PathSMBShare struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	ReadOnly bool   `json:"read_only"`
+}
+
+// pathsOverlap reports whether two directories are the same or one contains
+// the other
+func pathsOverlap(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	return a == b || strings.HasPrefix(a, strings.TrimSuffix(b, "/")+"/") ||
+		strings.HasPrefix(b, strings.TrimSuffix(a, "/")+"/")
+}
+
+// overlappingExports returns the exports reaching files under path
+func overlappingExports(exports []nfs.Export, path string) []nfs.Export {
+	var overlapping []nfs.Export
+	for _, export := range exports {
+		if pathsOverlap(export.Path, path) {
+			overlapping = append(overlapping, export)
+		}
+	}
+	return overlapping
+}
+
+// nfsExportsFor returns the NFS exports overlapping a share path. Exports
+// that cannot be read are logged and treated as absent.
+func (m *Manager) nfsExportsFor(path string) []nfs.Export {
+	exports, err := nfs.ReadExports()
+	if err != nil {
+		m.logger.Warn("Failed to read NFS exports, skipping multi-protocol checks",
+			"path", path,
+			"error", err)
+		return nil
+	}
+	return overlappingExports(exports, path)
+}
+
+// withNFSCompatibility returns the config to render with the multi-protocol
+// parameters added when the share path is also exported over NFS. Parameters
+// set by the user are kept. The config passed in is left untouched.
+func withNFSCompatibility(config *SMBShareConfig, exports []nfs.Export) *SMBShareConfig {
+	if len(exports) == 0 {
+		return config
+	}
+
+	rendered := *config
+	rendered.CustomParameters = maps.Clone(config.CustomParameters)
+	if rendered.CustomParameters == nil {
+		rendered.CustomParameters = make(map[string]string)
+	}
+	for param, value := range nfsCompatParameters {
+		if _, ok := rendered.CustomParameters[param]; !ok {
+			rendered.CustomParameters[param] = value
+		}
+	}
+	return &rendered
+}
+
+// multiProtocolWarnings describes the pitfalls of sharing a share's files
+// over NFS as well
+func multiProtocolWarnings(config *SMBShareConfig, exports []nfs.Export) []string {
+	if len(exports) == 0 {
+		return nil
+	}
+
+	var warnings []string
+	for _, param := range slices.Sorted(maps.Keys(nfsCompatParameters)) {
+		value, ok := config.CustomParameters[param]
+		if ok && !strings.EqualFold(value, nfsCompatParameters[param]) {
+			warnings = append(warnings, fmt.Sprintf(
+				"SMB share %s sets %s = %s; with NFS clients on the same files %s = %s "+
+					"keeps SMB caching and locking consistent",
+				config.Name, param, value, param, nfsCompatParameters[param]))
+		}
+	}
+	for _, export := range exports {
+		if export.Async() {
+			warnings = append(warnings, fmt.Sprintf(
+				"NFS export %s uses async; writes SMB clients already read can be lost on a crash",
+				export.Path))
+		}
+		if config.ReadOnly && export.Writable() {
+			warnings = append(warnings, fmt.Sprintf(
+				"SMB share %s is read-only but NFS export %s is writable",
+				config.Name, export.Path))
+		}
+	}
+	return warnings
+}
+
+// GetPathProtocols returns the SMB shares and NFS exports reaching a
+// directory, with warnings about sharing it over both
+func (m *Manager) GetPathProtocols(ctx context.Context, path string) (_ *PathProtocols, err error) {
+	defer m.observe("get_path_protocols", "")(&err)
+	if !pathRegex.MatchString(path) {
+		return nil, errors.New(errors.SharesInvalidInput, "Invalid path format").
+			WithMetadata("path", path)
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	configs, err := m.getAllShareConfigs()
+	if err != nil {
+		return nil, err
+	}
+	exports, err := nfs.ReadExports()
+	if err != nil {
+		return nil, err
+	}
+
+	return pathProtocols(filepath.Clean(path), configs, exports), nil
+}
+
+// ListMultiProtocolPaths returns a view of every SMB share path that is also
+// exported over NFS
+func (m *Manager) ListMultiProtocolPaths(ctx context.Context) (_ []PathProtocols, err error) {
+	defer m.observe("list_multi_protocol_paths", "")(&err)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	configs, err := m.getAllShareConfigs()
+	if err != nil {
+		return nil, err
+	}
+	exports, err := nfs.ReadExports()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	views := make([]PathProtocols, 0)
+	for _, config := range configs {
+		path := filepath.Clean(config.Path)
+		if seen[path] || len(overlappingExports(exports, path)) == 0 {
+			continue
+		}
+		seen[path] = true
+		views = append(views, *pathProtocols(path, configs, exports))
+	}
+	slices.SortFunc(views, func(a, b PathProtocols) int { return strings.Compare(a.Path, b.Path) })
+	return views, nil
+}
+
+// pathProtocols builds the view of one directory
+func pathProtocols(path string, configs []*SMBShareConfig, exports []nfs.Export) *PathProtocols {
+	view := &PathProtocols{
+		Path:       path,
+		Protocols:  make([]string, 0, 2),
+		NFSExports: overlappingExports(exports, path),
+	}
+
+	for _, config := range configs {
+		if !pathsOverlap(config.Path, path) {
+			continue
+		}
+		view.SMBShares = append(view.SMBShares, PathSMBShare{
+			Name:     config.Name,
+			Path:     config.Path,
+			ReadOnly: config.ReadOnly,
+		})
+		view.Warnings = append(view.Warnings,
+			multiProtocolWarnings(config, overlappingExports(exports, config.Path))...)
+	}
+
+	if len(view.SMBShares) > 0 {
+		view.Protocols = append(view.Protocols, "smb")
+	}
+	if len(view.NFSExports) > 0 {
+		view.Protocols = append(view.Protocols, "nfs")
+	}
+	view.MultiProtocol = len(view.Protocols) > 1
+	return view
+}
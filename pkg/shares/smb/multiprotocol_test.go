@@ -0,0 +1,62 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/shares/nfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathsOverlap(t *testing.T) {
+	assert.True(t, pathsOverlap("/tank/data", "/tank/data/"))
+	assert.True(t, pathsOverlap("/tank", "/tank/data"))
+	assert.True(t, pathsOverlap("/tank/data/projects", "/tank/data"))
+	assert.False(t, pathsOverlap("/tank/data", "/tank/database"))
+	assert.False(t, pathsOverlap("/tank/a", "/tank/b"))
+}
+
+func TestWithNFSCompatibility(t *testing.T) {
+	config := NewSMBShareConfig("data", "/tank/data")
+	config.CustomParameters["oplocks"] = "yes"
+
+	assert.Same(t, config, withNFSCompatibility(config, nil))
+
+	exports := nfs.ParseExports("/tank *(rw,async)\n", "/etc/exports")
+	rendered := withNFSCompatibility(config, exports)
+	assert.Equal(t, "yes", rendered.CustomParameters["oplocks"], "user settings are kept")
+	assert.Equal(t, "no", rendered.CustomParameters["level2 oplocks"])
+	assert.Equal(t, "yes", rendered.CustomParameters["posix locking"])
+	assert.NotContains(t, config.CustomParameters, "posix locking", "stored config is untouched")
+
+	config.ReadOnly = true
+	warnings := multiProtocolWarnings(config, exports)
+	require.Len(t, warnings, 3)
+	assert.Contains(t, warnings[0], "oplocks = yes")
+	assert.Contains(t, warnings[1], "async")
+	assert.Contains(t, warnings[2], "read-only")
+}
+
+func TestPathProtocols(t *testing.T) {
+	configs := []*SMBShareConfig{
+		NewSMBShareConfig("data", "/tank/data"),
+		NewSMBShareConfig("media", "/tank/media"),
+	}
+	exports := nfs.ParseExports("/tank/data/exported 10.0.0.0/24(rw,sync)\n", "/etc/exports")
+
+	view := pathProtocols("/tank/data", configs, exports)
+	assert.True(t, view.MultiProtocol)
+	assert.Equal(t, []string{"smb", "nfs"}, view.Protocols)
+	require.Len(t, view.SMBShares, 1)
+	assert.Equal(t, "data", view.SMBShares[0].Name)
+	require.Len(t, view.NFSExports, 1)
+	assert.Empty(t, view.Warnings)
+
+	view = pathProtocols("/tank/media", configs, exports)
+	assert.False(t, view.MultiProtocol)
+	assert.Equal(t, []string{"smb"}, view.Protocols)
+}
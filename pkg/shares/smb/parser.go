@@ -11,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -116,6 +117,13 @@ func (p *SMBConfigParser) ParseShares() (map[string]*SMBShareConfig, error) {
 				currentConfig.GuestOk = (value == "yes" || value == "true" || value == "1")
 			case "public":
 				currentConfig.Public = (value == "yes" || value == "true" || value == "1")
+			case "guest only":
+				if value == "yes" || value == "true" || value == "1" {
+					if currentConfig.GuestAccess == nil {
+						currentConfig.GuestAccess = &SMBGuestAccess{}
+					}
+					currentConfig.GuestAccess.GuestOnly = true
+				}
 			case "valid users":
 				currentConfig.ValidUsers = parseList(value)
 			case "invalid users":
@@ -144,6 +152,16 @@ func (p *SMBConfigParser) ParseShares() (map[string]*SMBShareConfig, error) {
 				currentConfig.HideFiles = parseList(value)
 			case "follow symlinks":
 				currentConfig.FollowSymlinks = (value == "yes" || value == "true" || value == "1")
+			case "access based share enum":
+				currentConfig.AccessBasedEnumeration = (value == "yes" || value == "true" || value == "1")
+			case "hide unreadable":
+				currentConfig.HideUnreadable = (value == "yes" || value == "true" || value == "1")
+			case maxConnectionsParam:
+				currentConfig.MaxConnections, _ = strconv.Atoi(value)
+			case wormGracePeriodParam:
+				if seconds, err := strconv.Atoi(value); err == nil {
+					currentConfig.Worm = &SMBWormConfig{GracePeriodSeconds: seconds}
+				}
 			default:
 				// Store as custom parameter
 				currentConfig.CustomParameters[param] = value
@@ -158,6 +176,67 @@ func (p *SMBConfigParser) ParseShares() (map[string]*SMBShareConfig, error) {
 			share.Description = fmt.Sprintf("Imported share %s", share.Name)
 		}
 		share.Enabled = true
+
+		// Guest access configured by hand before import is carried over as
+		// already acknowledged
+		if share.GuestOk || share.Public {
+			if share.GuestAccess == nil {
+				share.GuestAccess = &SMBGuestAccess{}
+			}
+			share.GuestAccess.Enabled = true
+			share.GuestAccess.AcknowledgeInsecure = true
+		} else if share.GuestAccess != nil {
+			share.GuestAccess = nil
+		}
+
+		// vfs_worm is tracked through Worm rather than as a vfs object
+		if vfsObjects, ok := share.CustomParameters["vfs objects"]; ok && hasWormObject(vfsObjects) {
+			if share.Worm == nil {
+				share.Worm = &SMBWormConfig{}
+			}
+			share.Worm.Enabled = true
+			if rest := withoutWormObject(vfsObjects); rest != "" {
+				share.CustomParameters["vfs objects"] = rest
+			} else {
+				delete(share.CustomParameters, "vfs objects")
+			}
+		} else if share.Worm != nil {
+			share.Worm = nil
+		}
+
+		// Likewise vfs_virusfilter and its parameters move into VirusFilter
+		if vfsObjects, ok := share.CustomParameters["vfs objects"]; ok &&
+			hasVFSObject(vfsObjects, virusFilterVFSObject) {
+			share.VirusFilter = virusFilterFromParameters(share.CustomParameters)
+			if rest := withoutVFSObject(vfsObjects, virusFilterVFSObject); rest != "" {
+				share.CustomParameters["vfs objects"] = rest
+			} else {
+				delete(share.CustomParameters, "vfs objects")
+			}
+		}
+
+		// and vfs_aio_ratelimit and its parameters into RateLimit
+		if vfsObjects, ok := share.CustomParameters["vfs objects"]; ok &&
+			hasVFSObject(vfsObjects, rateLimitVFSObject) {
+			share.RateLimit = rateLimitFromParameters(share.CustomParameters)
+			if rest := withoutVFSObject(vfsObjects, rateLimitVFSObject); rest != "" {
+				share.CustomParameters["vfs objects"] = rest
+			} else {
+				delete(share.CustomParameters, "vfs objects")
+			}
+		}
+
+		// and vfs_shadow_copy2 and its parameters into Snapshots
+		if vfsObjects, ok := share.CustomParameters["vfs objects"]; ok &&
+			hasVFSObject(vfsObjects, shadowCopyVFSObject) {
+			share.Snapshots = snapshotSettingsFromParameters(share.CustomParameters)
+			if rest := withoutVFSObject(vfsObjects, shadowCopyVFSObject); rest != "" {
+				share.CustomParameters["vfs objects"] = rest
+			} else {
+				delete(share.CustomParameters, "vfs objects")
+			}
+		}
+
 		share.Tags["imported"] = "true"
 		share.Tags["imported_date"] = time.Now().Format(time.RFC3339)
 
@@ -0,0 +1,290 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// printingJSONConf holds the printing configuration once it is managed
+	printingJSONConf = "printing.conf"
+
+	printersSection = "printers"
+	driversSection  = "print$"
+
+	// featureSMBPrinting is the features config key gating printing
+	featureSMBPrinting = "smbPrinting"
+
+	defaultPrintSpoolPath  = "/var/spool/samba"
+	defaultPrintDriverPath = "/var/lib/samba/printers"
+
+	// The spool directory is shared by all users like /tmp; the driver
+	// directories are writable by the owning group
+	printSpoolMode  = "1777"
+	printDriverMode = "2775"
+)
+
+// printDriverArchitectures are the print$ subdirectories Windows clients
+// upload drivers to and download them from
+var printDriverArchitectures = []string{"W32X86", "x64", "COLOR"}
+
+// SMBPrintingConfig configures the [printers] and print$ sections. Rodent
+// only manages them when the smbPrinting feature is enabled and a
+// configuration has been saved; until then they are preserved from smb.conf.
+type SMBPrintingConfig struct {
+	// Enabled shares the system printers; when false printing is turned off
+	// entirely, including the spoolss service
+	Enabled bool `json:"enabled"`
+	// CUPS sends jobs to the local CUPS server; otherwise the BSD printing
+	// system and /etc/printcap are used
+	CUPS bool `json:"cups"`
+	// CUPSOptions are passed to CUPS with every job, such as "raw" when the
+	// clients render jobs with their own drivers
+	CUPSOptions string   `json:"cups_options,omitempty"`
+	Browsable   bool     `json:"browsable"`
+	SpoolPath   string   `json:"spool_path,omitempty"`
+	ValidUsers  []string `json:"valid_users,omitempty"`
+
+	Drivers SMBPrintDriversConfig `json:"drivers"`
+}
+
+// SMBPrintDriversConfig configures the print$ share Windows clients
+// download printer drivers from
+type SMBPrintDriversConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path,omitempty"`
+	// UploadUsers may upload drivers. Windows also requires them to hold
+	// SePrintOperatorPrivilege, granted with "net rpc rights grant".
+	UploadUsers []string `json:"upload_users,omitempty"`
+}
+
+// NewSMBPrintingConfig returns a printing configuration with printing turned
+// off
+func NewSMBPrintingConfig() *SMBPrintingConfig {
+	return &SMBPrintingConfig{
+		CUPS:      true,
+		Browsable: true,
+		SpoolPath: defaultPrintSpoolPath,
+		Drivers:   SMBPrintDriversConfig{Path: defaultPrintDriverPath},
+	}
+}
+
+// isPrintingSection reports whether an smb.conf section belongs to printing
+func isPrintingSection(name string) bool {
+	return name == printersSection || name == driversSection
+}
+
+// printingFeatureEnabled returns a feature-disabled error unless printing
+// may be managed
+func printingFeatureEnabled() error {
+	if !config.GetConfig().Features.SMBPrinting {
+		return errors.New(errors.ServerFeatureDisabled, "Feature is disabled in the configuration").
+			WithMetadata("feature", featureSMBPrinting)
+	}
+	return nil
+}
+
+// validatePrintingConfig checks a printing configuration and fills in the
+// default paths
+func validatePrintingConfig(config *SMBPrintingConfig) error {
+	if config.SpoolPath == "" {
+		config.SpoolPath = defaultPrintSpoolPath
+	}
+	if config.Drivers.Path == "" {
+		config.Drivers.Path = defaultPrintDriverPath
+	}
+
+	for _, path := range []string{config.SpoolPath, config.Drivers.Path} {
+		if !pathRegex.MatchString(path) {
+			return errors.New(errors.SharesInvalidInput, "Invalid path format").
+				WithMetadata("path", path)
+		}
+	}
+	if strings.ContainsAny(config.CUPSOptions, "\r\n") {
+		return errors.New(errors.SharesInvalidInput, "Invalid CUPS options")
+	}
+
+	for _, users := range [][]string{config.ValidUsers, config.Drivers.UploadUsers} {
+		for _, user := range users {
+			if strings.TrimSpace(user) == "" || strings.ContainsAny(user, ",\r\n") {
+				return errors.New(errors.SharesInvalidInput, "Invalid user or group name").
+					WithMetadata("user", user)
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetPrintingConfig returns the printing configuration, or one with printing
+// turned off if none has been saved
+func (m *Manager) GetPrintingConfig(ctx context.Context) (_ *SMBPrintingConfig, err error) {
+	defer m.observe("get_printing_config", "")(&err)
+	if err := printingFeatureEnabled(); err != nil {
+		return nil, err
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	config, err := m.loadPrintingConfig()
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		config = NewSMBPrintingConfig()
+	}
+	return config, nil
+}
+
+// UpdatePrintingConfig saves the printing configuration, prepares the spool
+// and driver directories and regenerates smb.conf. From then on Rodent
+// manages the printing sections instead of preserving them.
+func (m *Manager) UpdatePrintingConfig(ctx context.Context, config *SMBPrintingConfig) (err error) {
+	defer m.observe("update_printing_config", "")(&err)
+	if err := printingFeatureEnabled(); err != nil {
+		return err
+	}
+	if err := validatePrintingConfig(config); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if config.Enabled {
+		if _, err := os.Stat(filepath.Join(m.configDir, printersSection+configFileExt)); err == nil {
+			return errors.New(errors.SharesAlreadyExists, "A share named printers already exists").
+				WithMetadata("name", printersSection)
+		}
+		if err := m.preparePrintingDirs(ctx, config); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "marshal_printing")
+	}
+	if err := os.WriteFile(filepath.Join(m.configDir, printingJSONConf), data, 0644); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "save_printing")
+	}
+
+	// The global section carries the printing system settings
+	globalConfig, err := m.GetGlobalConfig(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := m.generateGlobalConfig(globalConfig); err != nil {
+		return err
+	}
+	if err := m.updateMainConfig(); err != nil {
+		return err
+	}
+
+	m.logger.Info("Printing configuration updated",
+		"enabled", config.Enabled,
+		"cups", config.CUPS,
+		"drivers", config.Drivers.Enabled)
+	return m.ReloadConfig(ctx)
+}
+
+// preparePrintingDirs creates the spool directory and, when drivers are
+// shared, the print$ architecture directories
+func (m *Manager) preparePrintingDirs(ctx context.Context, config *SMBPrintingConfig) error {
+	type dir struct {
+		path, mode string
+	}
+	dirs := []dir{{config.SpoolPath, printSpoolMode}}
+	if config.Drivers.Enabled {
+		dirs = append(dirs, dir{config.Drivers.Path, printDriverMode})
+		for _, arch := range printDriverArchitectures {
+			dirs = append(dirs, dir{filepath.Join(config.Drivers.Path, arch), printDriverMode})
+		}
+	}
+
+	for _, dir := range dirs {
+		if out, err := m.executor.Execute(ctx, "mkdir", "-p", dir.path); err != nil {
+			return errors.Wrap(err, errors.SharesOperationFailed).
+				WithMetadata("operation", "printing_mkdir").
+				WithMetadata("path", dir.path).
+				WithMetadata("output", string(out))
+		}
+		if out, err := m.executor.Execute(ctx, "chmod", dir.mode, dir.path); err != nil {
+			return errors.Wrap(err, errors.SharesOperationFailed).
+				WithMetadata("operation", "printing_chmod").
+				WithMetadata("path", dir.path).
+				WithMetadata("output", string(out))
+		}
+	}
+	return nil
+}
+
+// loadPrintingConfig returns the saved printing configuration, or nil if
+// there is none (must be called with lock held)
+func (m *Manager) loadPrintingConfig() (*SMBPrintingConfig, error) {
+	data, err := os.ReadFile(filepath.Join(m.configDir, printingJSONConf))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "read_printing")
+	}
+
+	var config SMBPrintingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "parse_printing")
+	}
+	return &config, nil
+}
+
+// managedPrinting returns the printing configuration when Rodent manages
+// printing, or nil when the printing sections are left as they are
+func (m *Manager) managedPrinting() *SMBPrintingConfig {
+	if printingFeatureEnabled() != nil {
+		return nil
+	}
+	config, err := m.loadPrintingConfig()
+	if err != nil {
+		m.logger.Warn("Failed to load printing configuration, leaving printing unmanaged",
+			"error", err)
+		return nil
+	}
+	return config
+}
+
+// renderPrintingSections renders the [printers] and print$ sections, which
+// are empty when printing is not managed or turned off
+func (m *Manager) renderPrintingSections(config *SMBPrintingConfig) (string, error) {
+	if config == nil || !config.Enabled {
+		return "", nil
+	}
+
+	tmpl, ok := m.templates[printingTemplate]
+	if !ok {
+		return "", errors.New(errors.SharesInternalError, "Printing template not found")
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, config); err != nil {
+		return "", errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "render_printing_template")
+	}
+	buf.WriteString("\n")
+	return buf.String(), nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPrintingSections(t *testing.T) {
+	m, err := NewManager(common.Log, nil, nil)
+	require.NoError(t, err)
+
+	config := NewSMBPrintingConfig()
+	out, err := m.renderPrintingSections(config)
+	require.NoError(t, err)
+	assert.Empty(t, out, "printing turned off renders no sections")
+
+	config.Enabled = true
+	config.ValidUsers = []string{"@staff"}
+	out, err = m.renderPrintingSections(config)
+	require.NoError(t, err)
+	assert.Contains(t, out, "[printers]")
+	assert.Contains(t, out, "path = /var/spool/samba")
+	assert.Contains(t, out, "valid users = @staff")
+	assert.NotContains(t, out, "[print$]")
+
+	config.Drivers.Enabled = true
+	config.Drivers.UploadUsers = []string{"admin"}
+	out, err = m.renderPrintingSections(config)
+	require.NoError(t, err)
+	assert.Contains(t, out, "[print$]")
+	assert.Contains(t, out, "path = /var/lib/samba/printers")
+	assert.Contains(t, out, "write list = admin")
+}
+
+func TestRenderGlobalPrinting(t *testing.T) {
+	tmpl, err := template.New(globalTemplate).
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(GlobalTemplateContent())
+	require.NoError(t, err)
+
+	render := func(printing *SMBPrintingConfig) string {
+		config := NewSMBGlobalConfig()
+		config.Printing = printing
+		var buf bytes.Buffer
+		require.NoError(t, tmpl.Execute(&buf, config))
+		return buf.String()
+	}
+
+	assert.NotContains(t, render(nil), "load printers", "unmanaged printing is left to Samba")
+
+	off := NewSMBPrintingConfig()
+	assert.Contains(t, render(off), "disable spoolss = yes")
+
+	on := NewSMBPrintingConfig()
+	on.Enabled = true
+	on.CUPSOptions = "raw"
+	out := render(on)
+	assert.Contains(t, out, "load printers = yes")
+	assert.Contains(t, out, "printing = cups")
+	assert.Contains(t, out, "cups options = raw")
+}
+
+func TestPreserveSpecialSectionsPrinting(t *testing.T) {
+	conf := `[global]
+    workgroup = AD
+[printers]
+    path = /var/tmp
+[print$]
+    path = /srv/drivers
+[homes]
+    browseable = no
+[data]
+    path = /srv/data
+`
+	special, shares := preserveSpecialSections(conf, false)
+	assert.Contains(t, special, "[printers]")
+	assert.Contains(t, special, "[print$]")
+	assert.Contains(t, shares, "[data]")
+
+	special, _ = preserveSpecialSections(conf, true)
+	assert.NotContains(t, special, "[printers]")
+	assert.NotContains(t, special, "[print$]")
+	assert.Contains(t, special, "[homes]")
+}
+
+func TestValidatePrintingConfig(t *testing.T) {
+	config := &SMBPrintingConfig{Enabled: true}
+	require.NoError(t, validatePrintingConfig(config))
+	assert.Equal(t, defaultPrintSpoolPath, config.SpoolPath)
+	assert.Equal(t, defaultPrintDriverPath, config.Drivers.Path)
+
+	assert.Error(t, validatePrintingConfig(&SMBPrintingConfig{SpoolPath: "spool"}))
+	assert.Error(t, validatePrintingConfig(&SMBPrintingConfig{CUPSOptions: "raw\nload printers = yes"}))
+	assert.Error(t, validatePrintingConfig(&SMBPrintingConfig{ValidUsers: []string{"a,b"}}))
+}
@@ -12,33 +12,39 @@ import (
 	"time"
 
 	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/pkg/errors"
 )
 
 // ServiceManager implements SMB service management
 type ServiceManager struct {
-	logger logger.Logger
+	logger   logger.Logger
+	executor *command.CommandExecutor
 }
 
 // NewServiceManager creates a new SMB service manager
 func NewServiceManager(logger logger.Logger) *ServiceManager {
 	return &ServiceManager{
-		logger: logger,
+		logger:   logger,
+		executor: command.NewCommandExecutor(true),
 	}
 }
 
 // Start starts the SMB service
 func (m *ServiceManager) Start(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "sudo", "systemctl", "start", "smbd")
-	if err := cmd.Run(); err != nil {
+	if command.SkipInDryRun(m.logger, "systemctl", []string{"start", "smbd"}) {
+		return nil
+	}
+
+	defer statusCache.invalidate()
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "start", "smbd"); err != nil {
 		return errors.Wrap(err, errors.SharesServiceFailed).
 			WithMetadata("operation", "start").
 			WithMetadata("service", "smbd")
 	}
 
 	// Also start winbind if available
-	winbindCmd := exec.CommandContext(ctx, "sudo", "systemctl", "start", "winbind")
-	if err := winbindCmd.Run(); err != nil {
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "start", "winbind"); err != nil {
 		m.logger.Warn("Failed to start winbind service", "error", err)
 	}
 
@@ -48,8 +54,12 @@ func (m *ServiceManager) Start(ctx context.Context) error {
 
 // Stop stops the SMB service
 func (m *ServiceManager) Stop(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "sudo", "systemctl", "stop", "smbd")
-	if err := cmd.Run(); err != nil {
+	if command.SkipInDryRun(m.logger, "systemctl", []string{"stop", "smbd"}) {
+		return nil
+	}
+
+	defer statusCache.invalidate()
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "stop", "smbd"); err != nil {
 		return errors.Wrap(err, errors.SharesServiceFailed).
 			WithMetadata("operation", "stop").
 			WithMetadata("service", "smbd")
@@ -61,16 +71,19 @@ func (m *ServiceManager) Stop(ctx context.Context) error {
 
 // Restart restarts the SMB service
 func (m *ServiceManager) Restart(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "sudo", "systemctl", "restart", "smbd")
-	if err := cmd.Run(); err != nil {
+	if command.SkipInDryRun(m.logger, "systemctl", []string{"restart", "smbd"}) {
+		return nil
+	}
+
+	defer statusCache.invalidate()
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", "smbd"); err != nil {
 		return errors.Wrap(err, errors.SharesServiceFailed).
 			WithMetadata("operation", "restart").
 			WithMetadata("service", "smbd")
 	}
 
 	// Also restart winbind if available
-	winbindCmd := exec.CommandContext(ctx, "sudo", "systemctl", "restart", "winbind")
-	if err := winbindCmd.Run(); err != nil {
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "systemctl", "restart", "winbind"); err != nil {
 		m.logger.Warn("Failed to restart winbind service", "error", err)
 	}
 
@@ -108,8 +121,12 @@ func (m *ServiceManager) Status(ctx context.Context) (string, error) {
 
 // ReloadConfig reloads the SMB service configuration
 func (m *ServiceManager) ReloadConfig(ctx context.Context) error {
-	cmd := exec.CommandContext(ctx, "sudo", "smbcontrol", "smbd", "reload-config")
-	if err := cmd.Run(); err != nil {
+	if command.SkipInDryRun(m.logger, "smbcontrol", []string{"smbd", "reload-config"}) {
+		return nil
+	}
+
+	defer statusCache.invalidate()
+	if _, err := m.executor.ExecuteWithCombinedOutput(ctx, "smbcontrol", "smbd", "reload-config"); err != nil {
 		return errors.Wrap(err, errors.SharesServiceFailed).
 			WithMetadata("operation", "reload_config").
 			WithMetadata("service", "smbd")
@@ -0,0 +1,347 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
+)
+
+const (
+	shadowCopyVFSObject = "shadow_copy2"
+	shadowParamPrefix   = "shadow:"
+	shadowFormatParam   = "shadow:format"
+
+	hideFilesParam = "hide files"
+	// snapdirHideEntry hides the .zfs directory of the share root in listings
+	snapdirHideEntry = "/.zfs/"
+)
+
+// shadowTimestamp matches the timestamps rodent puts in snapshot names,
+// as in autosnap-daily-2025-01-31-233000
+var shadowTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}-\d{6}`)
+
+// ShareSnapshotSettings reports how the snapshots of the dataset behind a
+// share reach its users, and where the settings disagree
+type ShareSnapshotSettings struct {
+	Share   string                 `json:"share"`
+	Dataset string                 `json:"dataset"`
+	Snapdir *dataset.SnapdirStatus `json:"snapdir"`
+
+	PreviousVersions bool   `json:"previous_versions"`
+	Format           string `json:"format,omitempty"`
+	HideSnapdir      bool   `json:"hide_snapdir"`
+
+	// Snapshots of the dataset, and how many of them Previous Versions lists
+	Snapshots int `json:"snapshots"`
+	Listed    int `json:"listed"`
+
+	Warnings []string `json:"warnings"`
+}
+
+// PreviousVersionsEnabled reports whether the share lists its snapshots as
+// Previous Versions
+func (c *SMBShareConfig) PreviousVersionsEnabled() bool {
+	return c.Snapshots != nil && c.Snapshots.PreviousVersions
+}
+
+// HideSnapdirEnabled reports whether the share hides the .zfs directory of
+// its dataset from listings
+func (c *SMBShareConfig) HideSnapdirEnabled() bool {
+	return c.Snapshots != nil && c.Snapshots.HideSnapdir
+}
+
+// ShadowCopyParameters returns the vfs_shadow_copy2 parameters of the share.
+// smbd finds the dataset's mountpoint itself, as the mount holding the share
+// path, and the snapshots below it. Policies name snapshots in local time.
+func (c *SMBShareConfig) ShadowCopyParameters() map[string]string {
+	if !c.PreviousVersionsEnabled() {
+		return nil
+	}
+	params := map[string]string{
+		"shadow:snapdir":   ".zfs/snapshot",
+		"shadow:sort":      "desc",
+		"shadow:localtime": "yes",
+	}
+	if c.Snapshots.Format != "" {
+		params[shadowFormatParam] = c.Snapshots.Format
+	}
+	return params
+}
+
+// validateSnapshotSettings checks the snapshot settings and rejects
+// vfs_shadow_copy2 configured outside of them
+func validateSnapshotSettings(config *SMBShareConfig) error {
+	for param := range config.CustomParameters {
+		if strings.HasPrefix(param, shadowParamPrefix) {
+			return errors.New(errors.SharesInvalidInput, "Use snapshots to configure Previous Versions").
+				WithMetadata("parameter", param)
+		}
+	}
+	if hasVFSObject(config.CustomParameters["vfs objects"], shadowCopyVFSObject) {
+		return errors.New(errors.SharesInvalidInput, "Use snapshots.previous_versions to enable vfs_shadow_copy2").
+			WithMetadata("parameter", "vfs objects")
+	}
+
+	s := config.Snapshots
+	if s == nil {
+		return nil
+	}
+	if s.Snapdir != "" && !slices.Contains(dataset.SnapdirVisibilities, s.Snapdir) {
+		return errors.New(errors.SharesInvalidInput, "snapdir must be hidden or visible").
+			WithMetadata("name", config.Name).
+			WithMetadata("snapdir", s.Snapdir)
+	}
+	if s.Format != "" && (!strings.Contains(s.Format, "%") || strings.ContainsAny(s.Format, "\r\n")) {
+		return errors.New(errors.SharesInvalidInput, "Snapshot format must be a strptime format").
+			WithMetadata("name", config.Name).
+			WithMetadata("format", s.Format)
+	}
+	return nil
+}
+
+// snapshotSettingsFromParameters moves imported vfs_shadow_copy2 parameters
+// into a snapshot config
+func snapshotSettingsFromParameters(params map[string]string) *SMBSnapshotConfig {
+	s := &SMBSnapshotConfig{PreviousVersions: true}
+	for param, value := range params {
+		if !strings.HasPrefix(param, shadowParamPrefix) {
+			continue
+		}
+		if param == shadowFormatParam {
+			s.Format = value
+		}
+		delete(params, param)
+	}
+	return s
+}
+
+// applySnapshotSettings sets the snapdir of the share's dataset and works
+// out the Previous Versions format, before the share is saved. Settings
+// that disagree are logged; they do not fail the save.
+func (m *Manager) applySnapshotSettings(ctx context.Context, config *SMBShareConfig) error {
+	s := config.Snapshots
+	if s == nil || (s.Snapdir == "" && !s.PreviousVersions) {
+		return nil
+	}
+
+	r := m.resolver.Load()
+	if r == nil {
+		if s.Snapdir != "" {
+			return errors.New(errors.SharesOperationFailed, "Snapshot visibility cannot be set").
+				WithMetadata("name", config.Name)
+		}
+		return nil
+	}
+	mount, err := r.ResolvePath(ctx, config.Path)
+	if err != nil {
+		return err
+	}
+
+	if s.Snapdir != "" {
+		if err := r.SetSnapdir(ctx, mount.Dataset, s.Snapdir); err != nil {
+			return err
+		}
+		m.logger.Info("Set snapshot visibility of share dataset",
+			"name", config.Name,
+			"dataset", mount.Dataset,
+			"snapdir", s.Snapdir)
+	}
+
+	settings, err := m.snapshotSettings(ctx, r, config, mount)
+	if err != nil {
+		m.logger.Warn("Failed to check share snapshot settings", "name", config.Name, "error", err)
+		return nil
+	}
+	if s.PreviousVersions && s.Format == "" {
+		s.Format = settings.Format
+	}
+	for _, warning := range settings.Warnings {
+		m.logger.Warn("Share snapshot settings", "name", config.Name, "warning", warning)
+	}
+	return nil
+}
+
+// ShareSnapshotSettings reports the snapshot settings of a share and the
+// dataset behind it, with warnings where Previous Versions or restores
+// would not work as configured
+func (m *Manager) ShareSnapshotSettings(ctx context.Context, name string) (_ *ShareSnapshotSettings, err error) {
+	defer m.observe("share_snapshot_settings", name)(&err)
+	share, err := m.GetSMBShare(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := m.resolver.Load()
+	if r == nil {
+		return nil, errors.New(errors.SharesOperationFailed, "Share snapshots are not available").
+			WithMetadata("name", name)
+	}
+	mount, err := r.ResolvePath(ctx, share.Path)
+	if err != nil {
+		return nil, err
+	}
+	return m.snapshotSettings(ctx, r, share, mount)
+}
+
+// snapshotSettings reads the snapdir and snapshots of the dataset behind a
+// share and checks them against the share's settings
+func (m *Manager) snapshotSettings(
+	ctx context.Context,
+	r *resolver.Resolver,
+	config *SMBShareConfig,
+	mount resolver.Mount,
+) (*ShareSnapshotSettings, error) {
+	snapdir, err := r.Snapdir(ctx, mount.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := r.Snapshots(ctx, mount.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &ShareSnapshotSettings{
+		Share:            config.Name,
+		Dataset:          mount.Dataset,
+		Snapdir:          snapdir,
+		PreviousVersions: config.PreviousVersionsEnabled(),
+		HideSnapdir:      config.HideSnapdirEnabled(),
+		Snapshots:        len(snapshots),
+	}
+	if config.Snapshots != nil {
+		settings.Format = config.Snapshots.Format
+	}
+	if settings.PreviousVersions && settings.Format == "" {
+		settings.Format = shadowFormatFor(r.SnapshotPolicies(mount.Dataset), snapshots)
+	}
+	if settings.PreviousVersions && settings.Format != "" {
+		for _, s := range snapshots {
+			if shadowFormatMatches(settings.Format, s.Name) {
+				settings.Listed++
+			}
+		}
+	}
+	settings.Warnings = snapshotWarnings(config, settings)
+	return settings, nil
+}
+
+// snapshotWarnings explains where a share's snapshot settings disagree with
+// its dataset: restores and Previous Versions need the .zfs directory, and
+// Previous Versions only lists snapshots whose names match its format
+func snapshotWarnings(config *SMBShareConfig, settings *ShareSnapshotSettings) []string {
+	warnings := []string{}
+	snapdir := settings.Snapdir
+
+	if !snapdir.Restorable() {
+		if settings.PreviousVersions {
+			warnings = append(warnings, fmt.Sprintf(
+				"Previous Versions and restores need the .zfs directory, but snapdir is disabled on %s",
+				settings.Dataset))
+		} else {
+			warnings = append(warnings, fmt.Sprintf(
+				"Restores from snapshots need the .zfs directory, but snapdir is disabled on %s",
+				settings.Dataset))
+		}
+	}
+
+	if s := config.Snapshots; s != nil && s.Snapdir != "" && s.Snapdir != snapdir.Visibility {
+		warnings = append(warnings, fmt.Sprintf(
+			"The share sets snapdir to %s, but it is %s on %s; another share of the dataset or a change outside the share overrides it",
+			s.Snapdir, snapdir.Visibility, settings.Dataset))
+	}
+
+	if snapdir.Browsable() && settings.PreviousVersions && !settings.HideSnapdir {
+		warnings = append(warnings, fmt.Sprintf(
+			"snapdir is visible on %s, so clients see snapshots both as Previous Versions and in the .zfs directory; set hide_snapdir to show them once",
+			settings.Dataset))
+	}
+	if settings.HideSnapdir && !snapdir.Browsable() {
+		warnings = append(warnings, fmt.Sprintf(
+			"hide_snapdir has no effect while snapdir is %s on %s", snapdir.Visibility, settings.Dataset))
+	}
+
+	if !settings.PreviousVersions {
+		return warnings
+	}
+	switch {
+	case settings.Format == "":
+		warnings = append(warnings, fmt.Sprintf(
+			"No snapshot policy or snapshot of %s gives a Previous Versions format; set snapshots.format or save the share again once %s has policy snapshots",
+			settings.Dataset, settings.Dataset))
+	case settings.Snapshots == 0:
+		warnings = append(warnings, fmt.Sprintf(
+			"%s has no snapshots yet, so Previous Versions is empty", settings.Dataset))
+	case settings.Listed == 0:
+		warnings = append(warnings, fmt.Sprintf(
+			"None of the %d snapshots of %s match the Previous Versions format %q",
+			settings.Snapshots, settings.Dataset, settings.Format))
+	case settings.Listed < settings.Snapshots:
+		warnings = append(warnings, fmt.Sprintf(
+			"Previous Versions lists %d of the %d snapshots of %s; the others do not match the format %q",
+			settings.Listed, settings.Snapshots, settings.Dataset, settings.Format))
+	}
+	return warnings
+}
+
+// shadowFormatFor works out the Previous Versions format of a dataset: the
+// name format of the first enabled snapshot policy covering it that has
+// one, or else the format of its newest snapshot named with a timestamp
+func shadowFormatFor(policies []autosnapshots.SnapshotPolicy, snapshots []resolver.Snapshot) string {
+	for _, p := range policies {
+		if p.Enabled {
+			if format := p.NameFormat(); format != "" {
+				return format
+			}
+		}
+	}
+	// Snapshots are newest first
+	for _, s := range snapshots {
+		loc := shadowTimestamp.FindStringIndex(s.Name)
+		if loc == nil {
+			continue
+		}
+		prefix := strings.ReplaceAll(s.Name[:loc[0]], "%", "%%")
+		return prefix + "%Y-%m-%d-%H%M%S"
+	}
+	return ""
+}
+
+// shadowFormatMatches reports whether a snapshot name starts with a time in
+// a strptime format, as shadow_copy2 reads it; text after the time, such as
+// the schedule and policy ID of policy snapshots, is ignored
+func shadowFormatMatches(format, name string) bool {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			pattern.WriteString(regexp.QuoteMeta(format[i : i+1]))
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			pattern.WriteString(`\d{4}`)
+		case 'm', 'd', 'H', 'M', 'S', 'y':
+			pattern.WriteString(`\d{1,2}`)
+		case 's':
+			pattern.WriteString(`\d+`)
+		case '%':
+			pattern.WriteString("%")
+		default:
+			pattern.WriteString(`.+?`)
+		}
+	}
+	re, err := regexp.Compile(pattern.String())
+	return err == nil && re.MatchString(name)
+}
@@ -0,0 +1,133 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSnapshotSettings(t *testing.T) {
+	config := NewSMBShareConfig("projects", "/tank/projects")
+	config.Snapshots = &SMBSnapshotConfig{PreviousVersions: true, Snapdir: "visible"}
+	assert.NoError(t, validateSnapshotSettings(config))
+
+	config.Snapshots.Snapdir = "disabled"
+	assert.Error(t, validateSnapshotSettings(config), "snapdir cannot be disabled from a share")
+	config.Snapshots.Snapdir = ""
+
+	config.Snapshots.Format = "daily"
+	assert.Error(t, validateSnapshotSettings(config))
+	config.Snapshots.Format = "autosnap-daily-%Y-%m-%d-%H%M%S"
+	assert.NoError(t, validateSnapshotSettings(config))
+
+	config.CustomParameters["shadow:format"] = "@GMT-%Y.%m.%d-%H.%M.%S"
+	assert.Error(t, validateSnapshotSettings(config))
+	delete(config.CustomParameters, "shadow:format")
+
+	config.CustomParameters["vfs objects"] = "acl_xattr shadow_copy2"
+	assert.Error(t, validateSnapshotSettings(config))
+}
+
+func TestRenderSnapshotSettings(t *testing.T) {
+	tmpl, err := template.New(defaultTemplate).
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(DefaultTemplateContent())
+	require.NoError(t, err)
+
+	config := NewSMBShareConfig("projects", "/tank/projects")
+	config.CustomParameters["hide files"] = "/desktop.ini/"
+	config.Snapshots = &SMBSnapshotConfig{
+		PreviousVersions: true,
+		Format:           "autosnap-daily-%Y-%m-%d-%H%M%S",
+		HideSnapdir:      true,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, tmpl.Execute(&buf, renderShareConfig(config)))
+	out := buf.String()
+
+	assert.Contains(t, out, "vfs objects = acl_xattr shadow_copy2")
+	assert.Contains(t, out, "shadow:snapdir = .zfs/snapshot")
+	assert.Contains(t, out, "shadow:format = autosnap-daily-%Y-%m-%d-%H%M%S")
+	assert.Contains(t, out, "shadow:localtime = yes")
+	assert.Contains(t, out, "hide files = /desktop.ini/.zfs/")
+	assert.Equal(t, "/desktop.ini/", config.CustomParameters["hide files"], "stored config is untouched")
+}
+
+func TestShadowFormatMatches(t *testing.T) {
+	format := "autosnap-daily-%Y-%m-%d-%H%M%S"
+	assert.True(t, shadowFormatMatches(format, "autosnap-daily-2025-01-31-233000-0-d1f36875b92f"))
+	assert.True(t, shadowFormatMatches(format, "autosnap-daily-2025-01-31-233000-ci.42-0-d1f36875b92f"))
+	assert.False(t, shadowFormatMatches(format, "autosnap-hourly-2025-01-31-233000-0-d1f36875b92f"))
+	assert.False(t, shadowFormatMatches(format, "before-upgrade"))
+	assert.True(t, shadowFormatMatches("100%% %Y", "100% 2025"))
+}
+
+func TestShadowFormatFor(t *testing.T) {
+	snapshots := []resolver.Snapshot{
+		{Name: "before-upgrade"},
+		{Name: "manual-2025-01-31-120000"},
+	}
+	assert.Equal(t, "manual-%Y-%m-%d-%H%M%S", shadowFormatFor(nil, snapshots))
+	assert.Empty(t, shadowFormatFor(nil, snapshots[:1]))
+
+	policies := []autosnapshots.SnapshotPolicy{
+		{Name: "paused", SnapNamePattern: "autosnap-paused-%Y-%m-%d-%H%M%S"},
+		{Name: "daily", Enabled: true, SnapNamePattern: "autosnap-daily-%Y-%m-%d-%H%M%S"},
+	}
+	assert.Equal(t, "autosnap-daily-%Y-%m-%d-%H%M%S", shadowFormatFor(policies, snapshots),
+		"enabled policies come before snapshot names")
+}
+
+func TestSnapshotWarnings(t *testing.T) {
+	config := NewSMBShareConfig("projects", "/tank/projects")
+	config.Snapshots = &SMBSnapshotConfig{PreviousVersions: true, Snapdir: "hidden"}
+	settings := func(visibility string, listed, total int) *ShareSnapshotSettings {
+		return &ShareSnapshotSettings{
+			Dataset:          "tank/projects",
+			Snapdir:          &dataset.SnapdirStatus{Name: "tank/projects", Visibility: visibility},
+			PreviousVersions: true,
+			Format:           "autosnap-daily-%Y-%m-%d-%H%M%S",
+			Snapshots:        total,
+			Listed:           listed,
+		}
+	}
+
+	assert.Empty(t, snapshotWarnings(config, settings("hidden", 3, 3)))
+
+	warnings := snapshotWarnings(config, settings("disabled", 3, 3))
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "snapdir is disabled")
+	assert.Contains(t, warnings[1], "overrides it")
+
+	warnings = snapshotWarnings(config, settings("hidden", 1, 3))
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "lists 1 of the 3 snapshots")
+
+	warnings = snapshotWarnings(config, settings("hidden", 0, 3))
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "None of the 3 snapshots")
+
+	config.Snapshots = &SMBSnapshotConfig{PreviousVersions: true}
+	warnings = snapshotWarnings(config, settings("visible", 3, 3))
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "hide_snapdir")
+
+	config.Snapshots = &SMBSnapshotConfig{HideSnapdir: true}
+	noPreviousVersions := settings("hidden", 0, 0)
+	noPreviousVersions.PreviousVersions, noPreviousVersions.HideSnapdir = false, true
+	warnings = snapshotWarnings(config, noPreviousVersions)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "no effect")
+}
@@ -0,0 +1,244 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// shareHistoryDir holds one revisions file per share, kept out of the
+	// config directory so they are not mistaken for share configs
+	shareHistoryDir = "history"
+	shareHistoryExt = ".revisions"
+
+	// maxShareRevisions bounds the revisions kept per share
+	maxShareRevisions = 10
+)
+
+// ShareRevisionAction is the change that produced a share revision
+type ShareRevisionAction string
+
+const (
+	ShareRevisionBaseline   ShareRevisionAction = "baseline" // Config found before its history was kept
+	ShareRevisionCreate     ShareRevisionAction = "create"
+	ShareRevisionUpdate     ShareRevisionAction = "update"
+	ShareRevisionBulkUpdate ShareRevisionAction = "bulk_update"
+	ShareRevisionRollback   ShareRevisionAction = "rollback"
+	ShareRevisionRestore    ShareRevisionAction = "restore" // Restored from the trash
+)
+
+// ShareRevision is a saved version of a share's configuration
+type ShareRevision struct {
+	Revision   int                 `json:"revision"`
+	SavedAt    time.Time           `json:"saved_at"`
+	Action     ShareRevisionAction `json:"action"`
+	Author     common.Actor        `json:"author"`
+	RollbackOf int                 `json:"rollback_of,omitempty"` // Revision restored, for rollbacks
+	Config     SMBShareConfig      `json:"config"`
+}
+
+// ListShareRevisions returns the saved revisions of a share, oldest first
+func (m *Manager) ListShareRevisions(ctx context.Context, name string) (_ []ShareRevision, err error) {
+	defer m.observe("list_share_revisions", name)(&err)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if err := m.checkShareExists(name); err != nil {
+		return nil, err
+	}
+
+	return m.loadShareRevisions(name)
+}
+
+// RollbackShare restores a saved revision of a share and regenerates its
+// configuration. The rollback is itself saved as a new revision.
+func (m *Manager) RollbackShare(
+	ctx context.Context,
+	name string,
+	revision int,
+) (_ *ShareRevision, err error) {
+	defer m.observe("rollback_share", name)(&err)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.checkShareExists(name); err != nil {
+		return nil, err
+	}
+
+	revisions, err := m.loadShareRevisions(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rev := range revisions {
+		if rev.Revision != revision {
+			continue
+		}
+
+		config := rev.Config
+		if err := m.updateShare(ctx, name, &config); err != nil {
+			return nil, err
+		}
+
+		m.logger.Info("Share rolled back", "name", name, "revision", revision)
+		return m.recordShareRevision(ctx, &config, ShareRevisionRollback, revision), nil
+	}
+
+	return nil, errors.New(errors.SharesNotFound, "Share revision not found").
+		WithMetadata("name", name).
+		WithMetadata("revision", strconv.Itoa(revision))
+}
+
+// checkShareExists returns SharesNotFound for unknown shares
+func (m *Manager) checkShareExists(name string) error {
+	if !shareNameRegex.MatchString(name) {
+		return errors.New(errors.SharesInvalidInput, "Invalid share name format").
+			WithMetadata("name", name)
+	}
+
+	if _, err := os.Stat(filepath.Join(m.configDir, name+configFileExt)); os.IsNotExist(err) {
+		return errors.New(errors.SharesNotFound, "Share not found").
+			WithMetadata("name", name)
+	}
+
+	return nil
+}
+
+// ensureShareBaseline saves a share's current configuration as its first
+// revision when it has none yet, so the first change to a share created
+// before histories were kept can be rolled back. Must be called with lock
+// held, before the configuration is changed.
+func (m *Manager) ensureShareBaseline(name string) {
+	revisions, err := m.loadShareRevisions(name)
+	if err != nil || len(revisions) > 0 {
+		return
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.configDir, name+configFileExt))
+	if err != nil {
+		return
+	}
+
+	var config SMBShareConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return
+	}
+
+	m.recordShareRevision(context.Background(), &config, ShareRevisionBaseline, 0)
+}
+
+// recordShareRevision saves a share's configuration as its newest revision,
+// attributed to the actor in ctx. Failing to save a revision does not fail
+// the change it records. Must be called with lock held.
+func (m *Manager) recordShareRevision(
+	ctx context.Context,
+	config *SMBShareConfig,
+	action ShareRevisionAction,
+	rollbackOf int,
+) *ShareRevision {
+	revisions, err := m.loadShareRevisions(config.Name)
+	if err != nil {
+		m.logger.Warn("Failed to load share revisions, starting a new history",
+			"name", config.Name,
+			"error", err)
+		revisions = nil
+	}
+
+	rev := ShareRevision{
+		Revision:   1,
+		SavedAt:    time.Now(),
+		Action:     action,
+		RollbackOf: rollbackOf,
+		Config:     *config,
+	}
+	if action != ShareRevisionBaseline {
+		rev.Author = common.ActorFromContext(ctx)
+	}
+	if len(revisions) > 0 {
+		rev.Revision = revisions[len(revisions)-1].Revision + 1
+	}
+
+	revisions = append(revisions, rev)
+	if len(revisions) > maxShareRevisions {
+		revisions = revisions[len(revisions)-maxShareRevisions:]
+	}
+
+	if err := m.saveShareRevisions(config.Name, revisions); err != nil {
+		m.logger.Warn("Failed to save share revision",
+			"name", config.Name,
+			"revision", rev.Revision,
+			"error", err)
+	}
+
+	return &rev
+}
+
+// removeShareRevisions drops the history of a deleted share, so a new share
+// with the same name starts its own
+func (m *Manager) removeShareRevisions(name string) {
+	if err := os.Remove(m.shareRevisionsPath(name)); err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("Failed to remove share revisions", "name", name, "error", err)
+	}
+}
+
+// shareRevisionsPath returns the revisions file of a share
+func (m *Manager) shareRevisionsPath(name string) string {
+	return filepath.Join(m.configDir, shareHistoryDir, name+shareHistoryExt)
+}
+
+// loadShareRevisions reads the saved revisions of a share
+func (m *Manager) loadShareRevisions(name string) ([]ShareRevision, error) {
+	data, err := os.ReadFile(m.shareRevisionsPath(name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []ShareRevision{}, nil
+		}
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "read_revisions").
+			WithMetadata("name", name)
+	}
+
+	var revisions []ShareRevision
+	if err := json.Unmarshal(data, &revisions); err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "parse_revisions").
+			WithMetadata("name", name)
+	}
+
+	return revisions, nil
+}
+
+// saveShareRevisions writes the saved revisions of a share
+func (m *Manager) saveShareRevisions(name string, revisions []ShareRevision) error {
+	data, err := json.MarshalIndent(revisions, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "marshal_revisions").
+			WithMetadata("name", name)
+	}
+
+	if err := common.EnsureDir(filepath.Join(m.configDir, shareHistoryDir), 0755); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "create_history_dir").
+			WithMetadata("name", name)
+	}
+
+	if err := os.WriteFile(m.shareRevisionsPath(name), data, 0644); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "save_revisions").
+			WithMetadata("name", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/trash"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// shareTrashDir holds deleted shares until they expire or are restored
+const shareTrashDir = "trash"
+
+// TrashedShare is a deleted share that can still be restored
+type TrashedShare struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	DeletedAt time.Time      `json:"deleted_at"`
+	DeletedBy common.Actor   `json:"deleted_by"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Config    SMBShareConfig `json:"config"`
+}
+
+// trashedShareItem is what the trash keeps of a share: its config and
+// revision history
+type trashedShareItem struct {
+	Config    SMBShareConfig  `json:"config"`
+	Revisions []ShareRevision `json:"revisions,omitempty"`
+}
+
+// ListTrashedShares returns the deleted shares that can be restored,
+// newest first
+func (m *Manager) ListTrashedShares(ctx context.Context) (_ []TrashedShare, err error) {
+	defer m.observe("list_trashed_shares", "")(&err)
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	entries, err := m.shareTrash().List()
+	if err != nil {
+		return nil, err
+	}
+
+	shares := make([]TrashedShare, 0, len(entries))
+	for _, e := range entries {
+		item, err := decodeTrashedShare(e)
+		if err != nil {
+			m.logger.Warn("Skipping unreadable trashed share", "id", e.ID, "error", err)
+			continue
+		}
+		shares = append(shares, TrashedShare{
+			ID:        e.ID,
+			Name:      e.Name,
+			DeletedAt: e.DeletedAt,
+			DeletedBy: e.DeletedBy,
+			ExpiresAt: e.ExpiresAt,
+			Config:    item.Config,
+		})
+	}
+	return shares, nil
+}
+
+// RestoreShare recreates a deleted share from the trash, with its revision
+// history. id picks one of several deletions of the same name; empty
+// restores the latest. The restore is saved as a new revision.
+func (m *Manager) RestoreShare(
+	ctx context.Context,
+	name string,
+	id string,
+) (_ *SMBShareConfig, err error) {
+	defer m.observe("restore_share", name)(&err)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if !shareNameRegex.MatchString(name) {
+		return nil, errors.New(errors.SharesInvalidInput, "Invalid share name format").
+			WithMetadata("name", name)
+	}
+
+	bin := m.shareTrash()
+	var entry *trash.Entry
+	if id == "" {
+		entry, err = bin.Latest(name)
+	} else {
+		entry, err = bin.Get(id)
+		if err == nil && entry.Name != name {
+			err = errors.New(errors.SharesInvalidInput, "Trash entry is for another share").
+				WithMetadata("name", name).
+				WithMetadata("id", id)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(m.configDir, name+configFileExt)); err == nil {
+		return nil, errors.New(errors.SharesAlreadyExists,
+			"A share with this name exists; delete or rename it before restoring").
+			WithMetadata("name", name)
+	}
+
+	item, err := decodeTrashedShare(*entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.createShare(ctx, &item.Config); err != nil {
+		return nil, err
+	}
+
+	if len(item.Revisions) > 0 {
+		if err := m.saveShareRevisions(name, item.Revisions); err != nil {
+			m.logger.Warn("Failed to restore share revisions", "name", name, "error", err)
+		}
+	}
+	m.recordShareRevision(ctx, &item.Config, ShareRevisionRestore, 0)
+
+	if err := bin.Remove(entry.ID); err != nil {
+		m.logger.Warn("Failed to remove restored share from the trash",
+			"name", name,
+			"id", entry.ID,
+			"error", err)
+	}
+
+	m.logger.Info("Share restored from the trash", "name", name, "id", entry.ID)
+	return &item.Config, nil
+}
+
+// trashShare keeps a share's config and history in the trash before it is
+// deleted. Must be called with lock held.
+func (m *Manager) trashShare(ctx context.Context, name string) error {
+	bin := m.shareTrash()
+	if !bin.Enabled() {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(m.configDir, name+configFileExt))
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "trash").
+			WithMetadata("name", name)
+	}
+
+	var item trashedShareItem
+	if err := json.Unmarshal(data, &item.Config); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "trash").
+			WithMetadata("name", name)
+	}
+	if item.Revisions, err = m.loadShareRevisions(name); err != nil {
+		m.logger.Warn("Trashing share without its revisions", "name", name, "error", err)
+	}
+
+	if _, err := bin.Put(ctx, name, item); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "trash").
+			WithMetadata("name", name)
+	}
+	return nil
+}
+
+// shareTrash returns the bin deleted shares are kept in
+func (m *Manager) shareTrash() *trash.Bin {
+	return trash.New(filepath.Join(m.configDir, shareTrashDir), m.trashRetention)
+}
+
+// decodeTrashedShare reads the share kept by a trash entry
+func decodeTrashedShare(e trash.Entry) (trashedShareItem, error) {
+	var item trashedShareItem
+	if err := json.Unmarshal(e.Item, &item); err != nil {
+		return item, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "parse_trash").
+			WithMetadata("id", e.ID)
+	}
+	return item, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrashShare(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{logger: common.Log, configDir: dir, trashRetention: time.Hour}
+	ctx := common.WithActor(context.Background(), common.Actor{Source: "rest", User: "alice"})
+
+	config := SMBShareConfig{Name: "eng", Path: "/tank/eng", Description: "Engineering"}
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "eng"+configFileExt), data, 0600))
+	m.recordShareRevision(ctx, &config, ShareRevisionCreate, 0)
+
+	require.NoError(t, m.trashShare(ctx, "eng"))
+
+	trashed, err := m.ListTrashedShares(ctx)
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, "eng", trashed[0].Name)
+	assert.Equal(t, "Engineering", trashed[0].Config.Description)
+	assert.Equal(t, "alice", trashed[0].DeletedBy.User)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), trashed[0].ExpiresAt, time.Minute)
+
+	entry, err := m.shareTrash().Get(trashed[0].ID)
+	require.NoError(t, err)
+	item, err := decodeTrashedShare(*entry)
+	require.NoError(t, err)
+	require.Len(t, item.Revisions, 1, "the history is kept with the share")
+
+	// A share is not restored over one that exists
+	var re *errors.RodentError
+	_, err = m.RestoreShare(ctx, "eng", "")
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, errors.ErrorCode(errors.SharesAlreadyExists), re.Code)
+
+	// Trash entries are only restored under their own name
+	require.NoError(t, os.Remove(filepath.Join(dir, "eng"+configFileExt)))
+	_, err = m.RestoreShare(ctx, "ops", trashed[0].ID)
+	assert.Error(t, err)
+}
+
+func TestTrashShareDisabled(t *testing.T) {
+	dir := t.TempDir()
+	m := &Manager{logger: common.Log, configDir: dir}
+
+	require.NoError(t, m.trashShare(context.Background(), "eng"), "nothing is read when the trash is off")
+	trashed, err := m.ListTrashedShares(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, trashed)
+}
@@ -0,0 +1,63 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stratastor/rodent/internal/testutil/samba"
+	"github.com/stratastor/rodent/pkg/shares/smb"
+)
+
+// TestShareLifecycleSMBD creates, updates and deletes a share against a
+// separate smbd instance, checking what smbd reads after each reload
+func TestShareLifecycleSMBD(t *testing.T) {
+	smbd := samba.StartSMBD(t)
+	manager := smbd.Manager(t)
+	ctx := context.Background()
+
+	share := smb.NewSMBShareConfig("rodent-test", t.TempDir())
+	if err := manager.CreateShare(ctx, share); err != nil {
+		t.Fatalf("Failed to create share: %v", err)
+	}
+	out, err := smbd.Testparm(share.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), share.Path) {
+		t.Errorf("smb.conf section of %s lacks its path:\n%s", share.Name, out)
+	}
+	if !smbd.Running() {
+		t.Fatal("smbd stopped after reloading the new share")
+	}
+
+	share.ReadOnly = true
+	if err := manager.UpdateShare(ctx, share.Name, share); err != nil {
+		t.Fatalf("Failed to update share: %v", err)
+	}
+	out, err = smbd.Testparm(share.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "read only = Yes") {
+		t.Errorf("smb.conf section of %s is not read only:\n%s", share.Name, out)
+	}
+
+	if err := manager.DeleteShare(ctx, share.Name); err != nil {
+		t.Fatalf("Failed to delete share: %v", err)
+	}
+	if exists, err := manager.Exists(ctx, share.Name); err != nil || exists {
+		t.Errorf("Exists after delete = %v, %v", exists, err)
+	}
+	out, err = smbd.Testparm("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "["+share.Name+"]") {
+		t.Errorf("smb.conf still has %s:\n%s", share.Name, out)
+	}
+}
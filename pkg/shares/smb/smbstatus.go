@@ -0,0 +1,157 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/parsers"
+)
+
+// smbStatusTTL is how long smbstatus output is reused
+const smbStatusTTL = 2 * time.Second
+
+// runSMBStatus runs smbstatus through executor with the arguments of a
+// profile for sessions, tree connections and open files; replaced in tests
+var runSMBStatus = func(ctx context.Context, executor *command.CommandExecutor, args ...string) ([]byte, error) {
+	return executor.Execute(ctx, "smbstatus", args...)
+}
+
+// runSMBVersion returns the version smbd reports; replaced in tests
+var runSMBVersion = func(ctx context.Context) ([]byte, error) {
+	return exec.CommandContext(ctx, "smbd", "--version").Output()
+}
+
+// smbStatusCache shares the parsed state of smbstatus between callers for
+// smbStatusTTL, so listing shares and reading the stats of each runs one
+// privileged smbstatus instead of one per share. Callers arriving while
+// smbstatus runs wait for its output.
+//
+// smbstatus is run and parsed with the profile of the installed Samba, so
+// that versions without JSON output, or with a different schema, do not
+// report empty state.
+type smbStatusCache struct {
+	mu      sync.Mutex
+	status  *parsers.SMBStatus
+	fetched time.Time
+
+	// profile is the negotiated smbstatus profile; nil until the version of
+	// smbd is known
+	profile  *parsers.SMBStatusProfile
+	logger   logger.Logger
+	executor *command.CommandExecutor // Runs smbstatus with sudo
+}
+
+// statusCache is shared by the share and service managers, which invalidate
+// it when smbd reloads or restarts
+var statusCache smbStatusCache
+
+// negotiate selects the smbstatus profile of the installed Samba, logging
+// with l and running smbstatus through executor from then on. Managers
+// negotiate when they are created; until a version is found, get retries
+// and uses the JSON profile of current releases.
+func (c *smbStatusCache) negotiate(ctx context.Context, l logger.Logger, executor *command.CommandExecutor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l != nil {
+		c.logger = l
+	}
+	if executor != nil {
+		c.executor = executor
+	}
+	c.negotiated(ctx)
+}
+
+// negotiated returns the smbstatus profile, negotiating it when smbd's
+// version is not known yet (must be called with the lock held)
+func (c *smbStatusCache) negotiated(ctx context.Context) parsers.SMBStatusProfile {
+	if c.profile != nil {
+		return *c.profile
+	}
+
+	out, err := runSMBVersion(ctx)
+	version := parsers.ParseVersion(string(out))
+	if err != nil || !version.Known() {
+		return parsers.SMBStatusProfileFor(parsers.Version{})
+	}
+
+	profile := parsers.SMBStatusProfileFor(version)
+	c.profile = &profile
+	if c.logger != nil {
+		c.logger.Info("Selected smbstatus profile",
+			"samba_version", version.String(), "format", profile.Format)
+	}
+	return profile
+}
+
+// get returns the state of smbstatus at most smbStatusTTL old. Failures are
+// not cached. When JSON output cannot be had, as from a Samba older than
+// its version claims, the tabular output is tried and kept on success. The
+// state is shared and must not be modified.
+func (c *smbStatusCache) get(ctx context.Context) (*parsers.SMBStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.status != nil && time.Since(c.fetched) < smbStatusTTL {
+		return c.status, nil
+	}
+
+	profile := c.negotiated(ctx)
+	status, err := c.runProfile(ctx, profile)
+	if err != nil && profile.Format == parsers.SMBStatusJSON {
+		text := parsers.SMBStatusProfileFor(parsers.Version{Major: 4, Minor: 15})
+		if textStatus, textErr := c.runProfile(ctx, text); textErr == nil {
+			if c.logger != nil {
+				c.logger.Warn("smbstatus has no usable JSON output, using its tabular output",
+					"error", err)
+			}
+			c.profile = &text
+			status, err = textStatus, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.status, c.fetched = status, time.Now()
+	return status, nil
+}
+
+// runProfile runs smbstatus and parses its output with a profile (must be
+// called with the lock held)
+func (c *smbStatusCache) runProfile(ctx context.Context, profile parsers.SMBStatusProfile) (*parsers.SMBStatus, error) {
+	if c.executor == nil {
+		c.executor = command.NewCommandExecutor(true)
+	}
+	out, err := runSMBStatus(ctx, c.executor, profile.Args...)
+	if err != nil {
+		return nil, err
+	}
+	return profile.Parse(out)
+}
+
+// format returns the format of the negotiated profile, or nothing when it
+// is not negotiated yet
+func (c *smbStatusCache) format() parsers.SMBStatusFormat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.profile == nil {
+		return ""
+	}
+	return c.profile.Format
+}
+
+// invalidate drops the cached state, so the next caller sees the state
+// after a configuration reload or service restart
+func (c *smbStatusCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status = nil
+}
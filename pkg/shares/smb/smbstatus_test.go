@@ -0,0 +1,151 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/pkg/parsers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMBStatus replaces smbd --version and smbstatus for a test
+func fakeSMBStatus(t *testing.T, version string, run func(args []string) ([]byte, error)) {
+	savedStatus, savedVersion := runSMBStatus, runSMBVersion
+	runSMBStatus = func(_ context.Context, _ *command.CommandExecutor, args ...string) ([]byte, error) {
+		return run(args)
+	}
+	runSMBVersion = func(context.Context) ([]byte, error) {
+		if version == "" {
+			return nil, fmt.Errorf("smbd not found")
+		}
+		return []byte(version), nil
+	}
+	t.Cleanup(func() { runSMBStatus, runSMBVersion = savedStatus, savedVersion })
+}
+
+func TestSMBStatusCache(t *testing.T) {
+	calls := 0
+	fail := false
+	fakeSMBStatus(t, "Version 4.19.5", func([]string) ([]byte, error) {
+		calls++
+		if fail {
+			return nil, fmt.Errorf("smbstatus failed")
+		}
+		return []byte(fmt.Sprintf(`{"version": "4.19.%d"}`, calls)), nil
+	})
+
+	var c smbStatusCache
+	ctx := context.Background()
+	run := func() int {
+		status, err := c.get(ctx)
+		require.NoError(t, err)
+		return status.Version.Patch
+	}
+
+	// Concurrent callers share one run
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := c.get(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, status.Version.Patch)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, calls)
+
+	// Invalidation and expiry run smbstatus again
+	c.invalidate()
+	assert.Equal(t, 2, run())
+
+	c.fetched = time.Now().Add(-smbStatusTTL)
+	assert.Equal(t, 3, run())
+
+	// Failures are not cached
+	c.invalidate()
+	fail = true
+	_, err := c.get(ctx)
+	assert.Error(t, err)
+	fail = false
+	assert.Equal(t, 6, run(), "failed JSON runs are retried as text before failing")
+}
+
+const smbStatusText = `
+Samba version 4.15.13
+PID     Username     Group        Machine                                   Protocol Version  Encryption           Signing
+----------------------------------------------------------------------------------------------------------------------------------------
+48211   alice        alice        192.168.10.21 (ipv4:192.168.10.21:50944)  SMB3_11           -                    -
+
+Service      pid     Machine       Connected at                     Encryption   Signing
+---------------------------------------------------------------------------------------------
+projects     48211   192.168.10.21 Wed Sep 14 10:58:03 2022         -            -
+
+No locked files
+`
+
+func TestSMBStatusNegotiation(t *testing.T) {
+	// smbstatus of Samba before 4.16 rejects -j
+	text := func(args []string) ([]byte, error) {
+		if slices.Contains(args, "-j") {
+			return nil, fmt.Errorf("smbstatus: unknown option -j")
+		}
+		return []byte(smbStatusText), nil
+	}
+	ctx := context.Background()
+
+	t.Run("old version", func(t *testing.T) {
+		var args [][]string
+		fakeSMBStatus(t, "Version 4.15.13", func(a []string) ([]byte, error) {
+			args = append(args, a)
+			return text(a)
+		})
+		var c smbStatusCache
+		c.negotiate(ctx, nil, nil)
+		assert.Equal(t, parsers.SMBStatusText, c.format())
+
+		status, err := c.get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "projects", status.TreeConnects["48211/projects"].Service)
+		assert.Equal(t, "alice", status.Sessions["48211"].Username)
+		assert.Equal(t, [][]string{nil}, args, "smbstatus is not run with -j")
+	})
+
+	t.Run("unknown version", func(t *testing.T) {
+		fakeSMBStatus(t, "", text)
+		var c smbStatusCache
+		c.negotiate(ctx, nil, nil)
+		assert.Empty(t, c.format(), "negotiation is retried")
+
+		// JSON is tried first and the tabular output kept when it fails
+		status, err := c.get(ctx)
+		require.NoError(t, err)
+		assert.Len(t, status.TreeConnects, 1)
+		assert.Equal(t, parsers.SMBStatusText, c.format())
+	})
+
+	t.Run("current version", func(t *testing.T) {
+		fakeSMBStatus(t, "Version 4.19.5-Ubuntu", func(a []string) ([]byte, error) {
+			require.Equal(t, []string{"-j"}, a)
+			return []byte(`{"tcons": {"7": {"service": "home", "session_id": "1"}}}`), nil
+		})
+		var c smbStatusCache
+		c.negotiate(ctx, nil, nil)
+		assert.Equal(t, parsers.SMBStatusJSON, c.format())
+
+		status, err := c.get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "home", status.TreeConnects["7"].Service)
+	})
+}
@@ -0,0 +1,246 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
+)
+
+// ShareSnapshot is a snapshot of the dataset behind a share, as shown to the
+// share's users. The dataset is left out; ID names the snapshot in restores.
+type ShareSnapshot struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	CreatedAt  time.Time `json:"created_at"`
+	PolicyName string    `json:"policy_name,omitempty"`
+}
+
+// RestoreShareFileRequest restores a file or directory of a share from one
+// of its snapshots
+type RestoreShareFileRequest struct {
+	Snapshot string `json:"snapshot"`
+	// Path is relative to the share path, and is restored to the same place
+	Path string `json:"path"`
+	// Overwrite replaces the current file, or merges into the current
+	// directory; otherwise restoring over an existing path fails
+	Overwrite bool `json:"overwrite"`
+}
+
+// RestoredShareFile describes a completed restore
+type RestoredShareFile struct {
+	Share     string `json:"share"`
+	Snapshot  string `json:"snapshot"`
+	Path      string `json:"path"`
+	Overwrote bool   `json:"overwrote"`
+}
+
+// shareSnapshotSource is where a share's files are found in its snapshots
+type shareSnapshotSource struct {
+	share     *SMBShareConfig
+	mount     resolver.Mount
+	snapshots []resolver.Snapshot
+}
+
+// ListShareSnapshots lists the snapshots of the dataset behind a share,
+// newest first, labelled with the policies that took them
+func (m *Manager) ListShareSnapshots(ctx context.Context, name string) (_ []ShareSnapshot, err error) {
+	defer m.observe("list_share_snapshots", name)(&err)
+	src, err := m.shareSnapshotSource(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]ShareSnapshot, 0, len(src.snapshots))
+	for _, s := range src.snapshots {
+		snapshots = append(snapshots, ShareSnapshot{
+			ID:         s.Name,
+			Label:      s.Label,
+			CreatedAt:  s.CreatedAt,
+			PolicyName: s.PolicyName,
+		})
+	}
+	return snapshots, nil
+}
+
+// RestoreShareFile copies a file or directory from a snapshot of a share
+// back into the share, keeping its ownership, permissions and ACLs. Only
+// paths within the share can be restored, from snapshots of its dataset.
+func (m *Manager) RestoreShareFile(
+	ctx context.Context,
+	name string,
+	req *RestoreShareFileRequest,
+) (_ *RestoredShareFile, err error) {
+	defer m.observe("restore_share_file", name)(&err)
+	rel, err := shareRelativePath(req.Path)
+	if err != nil {
+		return nil, err
+	}
+	if req.Snapshot == "" || strings.ContainsAny(req.Snapshot, "/@") ||
+		req.Snapshot == "." || req.Snapshot == ".." {
+		return nil, errors.New(errors.SharesInvalidInput, "Invalid snapshot").
+			WithMetadata("snapshot", req.Snapshot)
+	}
+
+	src, err := m.shareSnapshotSource(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(src.snapshots, func(s resolver.Snapshot) bool { return s.Name == req.Snapshot }) {
+		return nil, errors.New(errors.SharesNotFound, "Snapshot not found for share").
+			WithMetadata("name", name).
+			WithMetadata("snapshot", req.Snapshot)
+	}
+
+	sharePath := filepath.Clean(src.share.Path)
+	shareRel, err := filepath.Rel(src.mount.Mountpoint, sharePath)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.SharesPathInvalid).
+			WithMetadata("path", sharePath)
+	}
+	snapshotRoot := filepath.Join(src.mount.Mountpoint, ".zfs", "snapshot", req.Snapshot, shareRel)
+	source := filepath.Join(snapshotRoot, rel)
+	target := filepath.Join(sharePath, rel)
+
+	// Symlinked directories must not lead the copy out of the share
+	if err := parentWithin(snapshotRoot, source); err != nil {
+		return nil, err
+	}
+	if err := parentWithin(sharePath, target); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Lstat(source); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New(errors.SharesNotFound, "Path not found in snapshot").
+				WithMetadata("snapshot", req.Snapshot).
+				WithMetadata("path", rel)
+		}
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "stat_snapshot_path").
+			WithMetadata("path", rel)
+	}
+
+	exists := false
+	if info, err := os.Lstat(target); err == nil {
+		// A symlink in the share is refused rather than replaced
+		if info.Mode()&os.ModeSymlink != 0 {
+			return nil, errors.New(errors.SharesPathInvalid, "Path in the share is a symlink and cannot be restored over").
+				WithMetadata("path", rel)
+		}
+		exists = true
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "stat_share_path").
+			WithMetadata("path", rel)
+	}
+	if exists && !req.Overwrite {
+		return nil, errors.New(errors.SharesAlreadyExists, "Path exists in the share, set overwrite to replace it").
+			WithMetadata("path", rel)
+	}
+
+	// The root helper copies onto the target, replacing files below it, and
+	// walks both trees without following symlinks, so one placed in the
+	// share after the checks above cannot lead the copy out of it
+	if err := m.fileOps.RestorePath(ctx, snapshotRoot, sharePath, rel, req.Overwrite); err != nil {
+		return nil, errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "restore_copy").
+			WithMetadata("path", rel)
+	}
+
+	m.logger.Info("Restored share path from snapshot",
+		"name", name,
+		"snapshot", req.Snapshot,
+		"path", rel,
+		"overwrote", exists)
+	return &RestoredShareFile{
+		Share:     name,
+		Snapshot:  req.Snapshot,
+		Path:      rel,
+		Overwrote: exists,
+	}, nil
+}
+
+// shareSnapshotSource resolves the dataset behind a share and its snapshots
+func (m *Manager) shareSnapshotSource(ctx context.Context, name string) (*shareSnapshotSource, error) {
+	share, err := m.GetSMBShare(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	r := m.resolver.Load()
+	if r == nil {
+		return nil, errors.New(errors.SharesOperationFailed, "Share snapshots are not available").
+			WithMetadata("name", name)
+	}
+	mount, err := r.ResolvePath(ctx, share.Path)
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := r.Snapshots(ctx, mount.Dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shareSnapshotSource{
+		share:     share,
+		mount:     mount,
+		snapshots: snapshots,
+	}, nil
+}
+
+// shareRelativePath validates a path within a share and returns it relative
+// to the share path. The share root itself cannot be restored.
+func shareRelativePath(path string) (string, error) {
+	invalid := errors.New(errors.SharesPathInvalid, "Path must be a file or directory within the share").
+		WithMetadata("path", path)
+
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" || strings.ContainsAny(path, "\x00\r\n") {
+		return "", invalid
+	}
+	for _, elem := range strings.Split(trimmed, "/") {
+		if elem == ".." {
+			return "", invalid
+		}
+	}
+
+	rel := filepath.Clean(trimmed)
+	if rel == "." {
+		return "", invalid
+	}
+	return rel, nil
+}
+
+// parentWithin checks that the directory holding path resolves, following
+// symlinks, to root or a directory below it
+func parentWithin(root, path string) error {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return errors.Wrap(err, errors.SharesPathInvalid).
+			WithMetadata("path", root)
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errors.New(errors.SharesNotFound, "Parent directory not found").
+				WithMetadata("path", filepath.Dir(path))
+		}
+		return errors.Wrap(err, errors.SharesPathInvalid).
+			WithMetadata("path", filepath.Dir(path))
+	}
+	if dir != resolvedRoot && !strings.HasPrefix(dir, strings.TrimSuffix(resolvedRoot, "/")+"/") {
+		return errors.New(errors.SharesPathInvalid, "Path leads outside the share").
+			WithMetadata("path", path)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShareRelativePath(t *testing.T) {
+	valid := map[string]string{
+		"reports/q3.xlsx":    "reports/q3.xlsx",
+		"/reports/q3.xlsx":   "reports/q3.xlsx",
+		"reports//./drafts/": "reports/drafts",
+		"..hidden":           "..hidden",
+	}
+	for path, want := range valid {
+		rel, err := shareRelativePath(path)
+		require.NoError(t, err, path)
+		assert.Equal(t, want, rel, path)
+	}
+
+	for _, path := range []string{"", "/", ".", "../etc/passwd", "reports/../../etc", "a\nb"} {
+		_, err := shareRelativePath(path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestParentWithin(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "docs"), filepath.Join(root, "alias")))
+
+	assert.NoError(t, parentWithin(root, filepath.Join(root, "file.txt")))
+	assert.NoError(t, parentWithin(root, filepath.Join(root, "docs", "file.txt")))
+	assert.NoError(t, parentWithin(root, filepath.Join(root, "alias", "file.txt")),
+		"symlinks within the share are followed")
+	assert.Error(t, parentWithin(root, filepath.Join(root, "escape", "file.txt")))
+	assert.Error(t, parentWithin(root, filepath.Join(root, "missing", "file.txt")))
+}
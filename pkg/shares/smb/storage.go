@@ -0,0 +1,76 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
+)
+
+// SMBShareDetail is a share's configuration with the storage behind its path
+type SMBShareDetail struct {
+	SMBShareConfig
+	Storage *resolver.Storage `json:"storage,omitempty"` // Nil when the path is not on a mounted dataset
+}
+
+// UseResolver lets share details report the dataset backing each share's
+// path, with its quota and policies
+func (m *Manager) UseResolver(r *resolver.Resolver) {
+	m.resolver.Store(r)
+}
+
+// GetSMBShareDetail returns a share with the dataset backing its path. A
+// path that cannot be resolved leaves Storage unset rather than failing.
+func (m *Manager) GetSMBShareDetail(ctx context.Context, name string) (_ *SMBShareDetail, err error) {
+	defer m.observe("get_smb_share_detail", name)(&err)
+	share, err := m.GetSMBShare(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &SMBShareDetail{SMBShareConfig: *share}
+	if r := m.resolver.Load(); r != nil {
+		storage, err := r.Describe(ctx, share.Path)
+		if err != nil {
+			m.logger.Debug("Failed to resolve dataset of share path",
+				"name", name,
+				"path", share.Path,
+				"error", err)
+		} else {
+			detail.Storage = storage
+		}
+	}
+
+	return detail, nil
+}
+
+// SharePaths returns the path of each share, by share name
+func (m *Manager) SharePaths(ctx context.Context) (map[string]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	configs, err := m.getAllShareConfigs()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string, len(configs))
+	for _, config := range configs {
+		paths[config.Name] = config.Path
+	}
+	return paths, nil
+}
+
+// SetSharePath moves a share to a new path, such as the new mountpoint of
+// a renamed dataset, keeping the rest of its configuration
+func (m *Manager) SetSharePath(ctx context.Context, name, path string) error {
+	share, err := m.GetSMBShare(ctx, name)
+	if err != nil {
+		return err
+	}
+	share.Path = path
+	return m.UpdateShare(ctx, name, share)
+}
@@ -9,7 +9,7 @@ import (
 	"embed"
 )
 
-//go:embed share.tmpl global.tmpl
+//go:embed share.tmpl global.tmpl printing.tmpl
 var templateFS embed.FS
 
 // DefaultTemplateContent returns the content for the default share template
@@ -22,7 +22,16 @@ func DefaultTemplateContent() string {
     comment = {{.Description}}
     read only = {{if .ReadOnly}}yes{{else}}no{{end}}
     browsable = {{if .Browsable}}yes{{else}}no{{end}}
+    {{if .GuestEnabled}}guest ok = yes{{end}}
+    {{if .GuestOnly}}guest only = yes{{end}}
+    {{if .GuestEnabled}}server smb encrypt = desired{{end}}
     {{if .ValidUsers}}valid users = {{join .ValidUsers ", "}}{{end}}
+    {{if .AccessBasedEnumeration}}access based share enum = yes{{end}}
+    {{if .HideUnreadable}}hide unreadable = yes{{end}}
+    {{if .WormEnabled}}worm:grace_period = {{.WormGracePeriod}}{{end}}
+    {{range $key, $value := .VirusFilterParameters}}
+    {{$key}} = {{$value}}
+    {{end}}
     {{if .InheritACLs}}inherit acls = yes{{end}}
     {{if .MapACLInherit}}map acl inherit = yes{{end}}
     {{range $key, $value := .CustomParameters}}
@@ -37,3 +46,9 @@ func GlobalTemplateContent() string {
 	content, _ := templateFS.ReadFile("global.tmpl")
 	return string(content)
 }
+
+// PrintingTemplateContent returns the [printers] and print$ template content
+func PrintingTemplateContent() string {
+	content, _ := templateFS.ReadFile("printing.tmpl")
+	return string(content)
+}
@@ -40,9 +40,11 @@
 // File types and their purposes:
 //
 //   global.conf              - JSON source of truth for global Samba settings
+//   global.versions          - JSON list of the last applied global configurations
 //   global.smb.conf          - Generated [global] section for smb.conf
 //   <sharename>.json         - JSON source of truth for individual share
 //   <sharename>.smb.conf     - Generated [sharename] section for smb.conf
+//   history/<sharename>.revisions - JSON list of the share's last saved configurations
 //
 // Templates are stored in:
 //   ~/.rodent/templates/smb/
@@ -81,10 +83,18 @@
 // Share Management:
 //   - CreateShare: Validates → Save JSON → Generate config → Update main → Reload
 //   - UpdateShare: Validate → Save JSON → Regenerate → Update main → Reload
-//   - DeleteShare: Remove JSON and .smb.conf → Update main → Reload
+//   - DeleteShare: Move JSON and revisions to trash/ → Remove .smb.conf → Update main → Reload
+//   - RollbackShare: Restore a saved revision → Regenerate → Update main → Reload
+//   - RestoreShare: Recreate a deleted share from trash/ → Generate config → Update main → Reload
+//
+// Creates, updates, bulk updates, rollbacks and restores save the share's
+// configuration as a revision attributed to the common.Actor in the request
+// context. Deleted shares are kept in the trash for trash.retention.
 //
 // Global Config Management:
-//   - UpdateGlobalConfig: Validate → Save JSON → Regenerate → Update main → Reload
+//   - PreviewGlobalConfig: Validate → Render → Diff against smb.conf (nothing written)
+//   - UpdateGlobalConfig: Validate → Save JSON → Regenerate → Update main → Reload → Record version
+//   - RevertGlobalConfig: Re-apply one of the last applied versions (global.versions)
 //   - GetGlobalConfig: Read and parse global.conf JSON
 //
 // # Security Mode Migration
@@ -110,6 +120,12 @@
 //   - AD users/groups: Validated via LDAP queries (DOMAIN\user format)
 //   - Mixed mode: Both types can be used in the same share
 //
+// Domain-qualified principals (DOMAIN\user, user@realm) in valid_users,
+// invalid_users, read_list, write_list and admin_users must name the joined
+// domain or one of SMBGlobalConfig.TrustedDomains, and are rejected in
+// standalone mode. Each trusted domain is rendered with its own idmap config;
+// idmap ranges may not overlap.
+//
 // # File Lifecycle
 //
 // Creating a share:
@@ -233,10 +249,138 @@ type SMBShareConfig struct {
 	HideFiles          []string `json:"hide_files,omitempty"`
 	FollowSymlinks     bool     `json:"follow_symlinks"`
 
+	// AccessBasedEnumeration hides the share from users who cannot access it
+	// when clients list shares; HideUnreadable hides files and folders the
+	// user cannot read within the share
+	AccessBasedEnumeration bool `json:"access_based_enumeration"`
+	HideUnreadable         bool `json:"hide_unreadable"`
+
+	// Guest (anonymous) access; nil or disabled means authenticated access only
+	GuestAccess *SMBGuestAccess `json:"guest_access,omitempty"`
+
+	// Worm enables write-once-read-many mode through vfs_worm
+	Worm *SMBWormConfig `json:"worm,omitempty"`
+
+	// VirusFilter enables on-access virus scanning through vfs_virusfilter
+	VirusFilter *SMBVirusFilterConfig `json:"virus_filter,omitempty"`
+
+	// MaxConnections limits concurrent connections to the share; 0 is unlimited
+	MaxConnections int `json:"max_connections,omitempty"`
+
+	// RateLimit limits transfer rates through vfs_aio_ratelimit
+	RateLimit *SMBRateLimitConfig `json:"rate_limit,omitempty"`
+
+	// Snapshots configures Previous Versions and how the snapshots of the
+	// share's dataset are shown
+	Snapshots *SMBSnapshotConfig `json:"snapshots,omitempty"`
+
 	// Advanced configuration
 	CustomParameters map[string]string `json:"custom_parameters,omitempty"`
 }
 
+// SMBGuestAccess configures anonymous access to a share. Anyone who can reach
+// the server can then read, and unless the share is read-only write, its
+// contents, so enabling it requires an explicit acknowledgement.
+type SMBGuestAccess struct {
+	Enabled bool `json:"enabled"`
+	// GuestOnly maps every connection to the guest account, even when the
+	// client presents valid credentials
+	GuestOnly bool `json:"guest_only"`
+	// FixPermissions makes the share path owned by the guest account (the
+	// global "guest account" parameter, "nobody" by default) so guests
+	// can actually use it; the mode is 2775, or 0755 for read-only shares
+	FixPermissions bool `json:"fix_permissions"`
+	// AcknowledgeInsecure must be set to enable guest access
+	AcknowledgeInsecure bool `json:"i_understand_guest_is_insecure"`
+}
+
+// GuestEnabled reports whether guest access is enabled for the share
+func (c *SMBShareConfig) GuestEnabled() bool {
+	return c.GuestAccess != nil && c.GuestAccess.Enabled
+}
+
+// GuestOnly reports whether all connections to the share map to the guest account
+func (c *SMBShareConfig) GuestOnly() bool {
+	return c.GuestEnabled() && c.GuestAccess.GuestOnly
+}
+
+// SMBWormConfig configures vfs_worm for a share. Files become read-only once
+// they have not been modified for the grace period, and stay that way for as
+// long as the share is served with the module loaded.
+type SMBWormConfig struct {
+	Enabled bool `json:"enabled"`
+	// GracePeriodSeconds is how long a file stays writable after its last
+	// change; 0 uses the vfs_worm default of one hour
+	GracePeriodSeconds int `json:"grace_period_seconds,omitempty"`
+}
+
+// WormEnabled reports whether the share is in write-once-read-many mode
+func (c *SMBShareConfig) WormEnabled() bool {
+	return c.Worm != nil && c.Worm.Enabled
+}
+
+// WormGracePeriod returns the vfs_worm grace period in seconds
+func (c *SMBShareConfig) WormGracePeriod() int {
+	if !c.WormEnabled() || c.Worm.GracePeriodSeconds <= 0 {
+		return defaultWormGracePeriod
+	}
+	return c.Worm.GracePeriodSeconds
+}
+
+// SMBVirusFilterConfig configures on-access scanning with vfs_virusfilter.
+// Files are scanned when opened, and optionally when closed, by the scanner
+// daemon listening on SocketPath.
+type SMBVirusFilterConfig struct {
+	Enabled bool `json:"enabled"`
+	// Scanner is the vfs_virusfilter backend: clamav (default), sophos or fsav
+	Scanner string `json:"scanner,omitempty"`
+	// SocketPath defaults to the scanner package's daemon socket
+	SocketPath  string `json:"socket_path,omitempty"`
+	ScanOnClose bool   `json:"scan_on_close"`
+	// MaxFileSize skips files larger than this many bytes; 0 uses the module default
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+	// InfectedFileAction is nothing, quarantine (default), rename or delete
+	InfectedFileAction string `json:"infected_file_action,omitempty"`
+	// QuarantineDirectory receives infected files; it must be outside the share
+	QuarantineDirectory string `json:"quarantine_directory,omitempty"`
+	// BlockAccessOnError denies access when the scanner cannot be reached
+	BlockAccessOnError bool `json:"block_access_on_error"`
+}
+
+// SMBRateLimitConfig limits the transfer rates of a share. smbd runs a
+// process per client, so each limit applies to every client connection, and
+// thereby user, separately. 0 leaves a rate unlimited.
+type SMBRateLimitConfig struct {
+	ReadBytesPerSecond  int64 `json:"read_bytes_per_second,omitempty"`
+	WriteBytesPerSecond int64 `json:"write_bytes_per_second,omitempty"`
+	ReadIOPS            int64 `json:"read_iops,omitempty"`
+	WriteIOPS           int64 `json:"write_iops,omitempty"`
+}
+
+// SMBSnapshotConfig configures how users of a share reach the snapshots of
+// its dataset: as Previous Versions through vfs_shadow_copy2, and through
+// the .zfs directory, which restores also read from
+type SMBSnapshotConfig struct {
+	PreviousVersions bool `json:"previous_versions"`
+	// Format is the strptime format snapshot names start with; only
+	// matching snapshots are listed as Previous Versions. Empty takes the
+	// format of the dataset's snapshot policy, or of its newest snapshot,
+	// when the share is saved.
+	Format string `json:"format,omitempty"`
+	// Snapdir sets the snapdir of the share's dataset, hidden or visible,
+	// when the share is saved; empty leaves the dataset as it is. It applies
+	// to every share of the dataset.
+	Snapdir string `json:"snapdir,omitempty"`
+	// HideSnapdir hides the .zfs directory from listings of this share
+	// even where snapdir is visible; it stays reachable by path
+	HideSnapdir bool `json:"hide_snapdir"`
+}
+
+// VirusFilterEnabled reports whether on-access scanning is enabled for the share
+func (c *SMBShareConfig) VirusFilterEnabled() bool {
+	return c.VirusFilter != nil && c.VirusFilter.Enabled
+}
+
 // NewSMBShareConfig creates a new SMB share configuration with default values
 func NewSMBShareConfig(name, path string) *SMBShareConfig {
 	return &SMBShareConfig{
@@ -284,8 +428,28 @@ type SMBGlobalConfig struct {
 	KerberosMethod          string            `json:"kerberos_method,omitempty"`
 	DedicatedKeytabFile     string            `json:"dedicated_keytab_file,omitempty"`
 
+	// TrustedDomains lists the trusted domains whose users and groups may be
+	// named in share ACLs, each with its own idmap range
+	TrustedDomains []SMBTrustedDomain `json:"trusted_domains,omitempty"`
+	// AllowTrustedDomains sets "allow trusted domains"; when false only the
+	// joined domain is accepted. Nil leaves the Samba default (yes).
+	AllowTrustedDomains *bool `json:"allow_trusted_domains,omitempty"`
+	// WinbindEnumUsers and WinbindEnumGroups set winbind enumeration,
+	// overriding custom parameters of the same name. Nil leaves them as is.
+	WinbindEnumUsers  *bool `json:"winbind_enum_users,omitempty"`
+	WinbindEnumGroups *bool `json:"winbind_enum_groups,omitempty"`
+
 	// Advanced configuration
 	CustomParameters map[string]string `json:"custom_parameters,omitempty"`
+
+	// GuestSharesEnabled is derived from share configs while rendering and never
+	// persisted. Guest sessions cannot be encrypted, so when any share allows
+	// guests encryption is only required per share.
+	GuestSharesEnabled bool `json:"-"`
+
+	// Printing is the printing configuration while rendering, set when Rodent
+	// manages printing, and never persisted
+	Printing *SMBPrintingConfig `json:"-"`
 }
 
 // NewSMBGlobalConfig creates a new global SMB configuration with default values
@@ -368,6 +532,22 @@ type SMBShareStats struct {
 	Files          []SMBOpenFile      `json:"files,omitempty"`
 	Status         shares.ShareStatus `json:"status"`
 	ConfModified   time.Time          `json:"conf_modified"`
+	// Limits is set for shares with connection or rate limits
+	Limits *SMBShareLimitStatus `json:"limits,omitempty"`
+}
+
+// SMBShareLimitStatus reports the limits of a share and their enforcement
+type SMBShareLimitStatus struct {
+	MaxConnections int `json:"max_connections,omitempty"`
+	// Connections counts the tree connections to the share, which
+	// max connections applies to
+	Connections            int  `json:"connections"`
+	ConnectionLimitReached bool `json:"connection_limit_reached"`
+
+	RateLimit *SMBRateLimitConfig `json:"rate_limit,omitempty"`
+	// RateLimitEnforced is false when the installed Samba lacks
+	// vfs_aio_ratelimit and the rate limits are not applied
+	RateLimitEnforced bool `json:"rate_limit_enforced"`
 }
 
 // SMBServiceStatus represents the status of the SMB service
@@ -379,6 +559,9 @@ type SMBServiceStatus struct {
 	ConfigFile     string    `json:"config_file,omitempty"`
 	ActiveSessions int       `json:"active_sessions"`
 	ActiveShares   int       `json:"active_shares"`
+	// StatusFormat is the smbstatus output sessions are read from: json,
+	// or text for Samba before 4.16
+	StatusFormat string `json:"status_format,omitempty"`
 }
 
 // SMBBulkUpdateConfig represents a configuration for bulk updating SMB shares
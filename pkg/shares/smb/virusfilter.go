@@ -0,0 +1,375 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/pkg/errors"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+const (
+	virusFilterVFSObject   = "virusfilter"
+	virusFilterParamPrefix = "virusfilter:"
+
+	// VirusEventSpoolDir is where the infected file command drops detection
+	// reports for the daemon to pick up. smbd runs the command, so the
+	// directory is writable by all but not listable (like a mail drop).
+	VirusEventSpoolDir  = "/var/spool/rodent/virusfilter"
+	virusEventSpoolMode = 0o1733
+
+	// virusEventPollInterval is how often the spool directory is drained
+	virusEventPollInterval = 30 * time.Second
+
+	// maxVirusEventSize bounds how much of a spooled detection is read
+	maxVirusEventSize = 64 << 10
+)
+
+// Supported vfs_virusfilter scanner backends
+const (
+	VirusScannerClamAV = "clamav"
+	VirusScannerSophos = "sophos"
+	VirusScannerFSAV   = "fsav"
+)
+
+// Infected file actions
+const (
+	VirusActionNothing    = "nothing"
+	VirusActionQuarantine = "quarantine"
+	VirusActionRename     = "rename"
+	VirusActionDelete     = "delete"
+)
+
+// defaultScannerSockets are the daemon sockets of the distribution packages
+var defaultScannerSockets = map[string]string{
+	VirusScannerClamAV: "/var/run/clamav/clamd.ctl",
+	VirusScannerSophos: "/var/run/savdi/sssp.sock",
+	VirusScannerFSAV:   "/tmp/.fsav-0",
+}
+
+var virusActions = []string{
+	VirusActionNothing, VirusActionQuarantine, VirusActionRename, VirusActionDelete,
+}
+
+// virusReportCommand is run by smbd for every infected file. It is the
+// running binary so reports reach this daemon's spool directory.
+var virusReportCommand = func() string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = "/usr/local/bin/rodent"
+	}
+	return exe + " shares virus-report"
+}()
+
+// applyVirusFilterDefaults fills in the scanner and infected file action
+// left unset in an enabled virus filter
+func applyVirusFilterDefaults(config *SMBShareConfig) {
+	if !config.VirusFilterEnabled() {
+		return
+	}
+	if config.VirusFilter.Scanner == "" {
+		config.VirusFilter.Scanner = VirusScannerClamAV
+	}
+	if config.VirusFilter.InfectedFileAction == "" {
+		config.VirusFilter.InfectedFileAction = VirusActionQuarantine
+	}
+}
+
+// validateVirusFilter checks the scanner settings and rejects vfs_virusfilter
+// settings made outside of the virus filter config. Defaults are applied
+// beforehand by applyVirusFilterDefaults.
+func validateVirusFilter(config *SMBShareConfig) error {
+	for param := range config.CustomParameters {
+		if strings.HasPrefix(param, virusFilterParamPrefix) {
+			return errors.New(errors.SharesInvalidInput, "Use virus_filter to configure virus scanning").
+				WithMetadata("parameter", param)
+		}
+	}
+	if hasVFSObject(config.CustomParameters["vfs objects"], virusFilterVFSObject) {
+		return errors.New(errors.SharesInvalidInput, "Use virus_filter to enable vfs_virusfilter").
+			WithMetadata("parameter", "vfs objects")
+	}
+
+	if !config.VirusFilterEnabled() {
+		return nil
+	}
+	vf := config.VirusFilter
+
+	if _, ok := defaultScannerSockets[vf.Scanner]; !ok {
+		return errors.New(errors.SharesInvalidInput, "Unsupported virus scanner").
+			WithMetadata("scanner", vf.Scanner)
+	}
+	if vf.SocketPath != "" && !filepath.IsAbs(vf.SocketPath) {
+		return errors.New(errors.SharesInvalidInput, "Scanner socket path must be absolute").
+			WithMetadata("socket_path", vf.SocketPath)
+	}
+
+	if !slices.Contains(virusActions, vf.InfectedFileAction) {
+		return errors.New(errors.SharesInvalidInput, "Invalid infected file action").
+			WithMetadata("infected_file_action", vf.InfectedFileAction)
+	}
+
+	if vf.InfectedFileAction == VirusActionQuarantine {
+		if vf.QuarantineDirectory == "" || !pathRegex.MatchString(vf.QuarantineDirectory) {
+			return errors.New(errors.SharesInvalidInput, "A valid quarantine directory is required").
+				WithMetadata("quarantine_directory", vf.QuarantineDirectory)
+		}
+		// Quarantined files must not be reachable through the share itself
+		quarantine := filepath.Clean(vf.QuarantineDirectory)
+		sharePath := filepath.Clean(config.Path)
+		if quarantine == sharePath || strings.HasPrefix(quarantine, sharePath+"/") {
+			return errors.New(errors.SharesInvalidInput, "Quarantine directory cannot be inside the share").
+				WithMetadata("quarantine_directory", vf.QuarantineDirectory)
+		}
+	}
+
+	if vf.MaxFileSize < 0 {
+		return errors.New(errors.SharesInvalidInput, "max_file_size cannot be negative")
+	}
+
+	return nil
+}
+
+// VirusFilterParameters returns the vfs_virusfilter parameters of the share
+func (c *SMBShareConfig) VirusFilterParameters() map[string]string {
+	if !c.VirusFilterEnabled() {
+		return nil
+	}
+	vf := c.VirusFilter
+
+	params := map[string]string{
+		"virusfilter:scanner":               vf.Scanner,
+		"virusfilter:socket path":           vf.SocketPath,
+		"virusfilter:scan on close":         yesNo(vf.ScanOnClose),
+		"virusfilter:infected file action":  vf.InfectedFileAction,
+		"virusfilter:infected file command": virusReportCommand,
+		"virusfilter:block access on error": yesNo(vf.BlockAccessOnError),
+	}
+	if params["virusfilter:socket path"] == "" {
+		params["virusfilter:socket path"] = defaultScannerSockets[vf.Scanner]
+	}
+	if vf.InfectedFileAction == VirusActionQuarantine {
+		params["virusfilter:quarantine directory"] = vf.QuarantineDirectory
+	}
+	if vf.MaxFileSize > 0 {
+		params["virusfilter:max file size"] = strconv.FormatInt(vf.MaxFileSize, 10)
+	}
+	return params
+}
+
+// virusFilterFromParameters moves imported vfs_virusfilter parameters into a
+// virus filter config
+func virusFilterFromParameters(params map[string]string) *SMBVirusFilterConfig {
+	vf := &SMBVirusFilterConfig{Enabled: true}
+	for param, value := range params {
+		if !strings.HasPrefix(param, virusFilterParamPrefix) {
+			continue
+		}
+		switch strings.TrimPrefix(param, virusFilterParamPrefix) {
+		case "scanner":
+			vf.Scanner = value
+		case "socket path":
+			vf.SocketPath = value
+		case "scan on close":
+			vf.ScanOnClose = value == "yes" || value == "true" || value == "1"
+		case "infected file action":
+			vf.InfectedFileAction = value
+		case "quarantine directory":
+			vf.QuarantineDirectory = value
+		case "block access on error":
+			vf.BlockAccessOnError = value == "yes" || value == "true" || value == "1"
+		case "max file size":
+			vf.MaxFileSize, _ = strconv.ParseInt(value, 10, 64)
+		}
+		delete(params, param)
+	}
+	return vf
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// VirusEvent is a detection reported by vfs_virusfilter
+type VirusEvent struct {
+	Share           string    `json:"share"`
+	Path            string    `json:"path"`
+	Report          string    `json:"report"`
+	Action          string    `json:"action"`
+	QuarantinedPath string    `json:"quarantined_path,omitempty"`
+	User            string    `json:"user,omitempty"`
+	Domain          string    `json:"domain,omitempty"`
+	ClientIP        string    `json:"client_ip,omitempty"`
+	ClientName      string    `json:"client_name,omitempty"`
+	DetectedAt      time.Time `json:"detected_at"`
+}
+
+// VirusEventFromEnv builds a detection from the environment vfs_virusfilter
+// sets for the infected file command
+func VirusEventFromEnv(getenv func(string) string) VirusEvent {
+	return VirusEvent{
+		Share:           getenv("VIRUSFILTER_SERVICE_NAME"),
+		Path:            filepath.Join(getenv("VIRUSFILTER_SERVICE_PATH"), getenv("VIRUSFILTER_INFECTED_SERVICE_FILE_PATH")),
+		Report:          getenv("VIRUSFILTER_INFECTED_FILE_REPORT"),
+		Action:          getenv("VIRUSFILTER_INFECTED_FILE_ACTION"),
+		QuarantinedPath: getenv("VIRUSFILTER_QUARANTINED_FILE_PATH"),
+		User:            getenv("VIRUSFILTER_USER_NAME"),
+		Domain:          getenv("VIRUSFILTER_USER_DOMAIN"),
+		ClientIP:        getenv("VIRUSFILTER_CLIENT_IP"),
+		ClientName:      getenv("VIRUSFILTER_CLIENT_NAME"),
+		DetectedAt:      time.Now(),
+	}
+}
+
+// WriteVirusEvent drops a detection into the spool directory
+func WriteVirusEvent(dir string, ev VirusEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "marshal_virus_event")
+	}
+
+	// Write under a temporary name so the daemon never reads a partial report
+	name := uuid.New().String()
+	tmpPath := filepath.Join(dir, "."+name)
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "write_virus_event")
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dir, name+".json")); err != nil {
+		os.Remove(tmpPath)
+		return errors.Wrap(err, errors.SharesOperationFailed).
+			WithMetadata("operation", "write_virus_event")
+	}
+	return nil
+}
+
+// WatchVirusEvents drains the virus event spool directory until ctx is done
+func (m *Manager) WatchVirusEvents(ctx context.Context) {
+	if err := os.MkdirAll(VirusEventSpoolDir, 0755); err != nil {
+		m.logger.Warn("Failed to create virus event spool directory", "error", err)
+		return
+	}
+	if err := os.Chmod(VirusEventSpoolDir, virusEventSpoolMode); err != nil {
+		m.logger.Warn("Failed to set virus event spool directory mode", "error", err)
+	}
+
+	ticker := time.NewTicker(virusEventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		m.processVirusEvents(VirusEventSpoolDir)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// virusEventOwners returns the uids whose spool files are trusted: root,
+// and the smbd account where smbd runs as one
+var virusEventOwners = func() []uint32 {
+	owners := []uint32{0}
+	if u, err := user.Lookup("smbd"); err == nil {
+		if uid, err := strconv.ParseUint(u.Uid, 10, 32); err == nil {
+			owners = append(owners, uint32(uid))
+		}
+	}
+	return owners
+}
+
+// readVirusEvent reads a spooled detection. Anyone can write to the spool
+// directory, so only regular files owned by root or smbd are read, checked
+// on the opened file so it cannot be swapped in between.
+func readVirusEvent(file string, owners []uint32) ([]byte, error) {
+	f, err := os.OpenFile(file, os.O_RDONLY|syscall.O_NOFOLLOW|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !info.Mode().IsRegular() || !ok || st.Nlink != 1 {
+		return nil, fmt.Errorf("not a regular file")
+	}
+	if !slices.Contains(owners, st.Uid) {
+		return nil, fmt.Errorf("owned by uid %d", st.Uid)
+	}
+	return io.ReadAll(io.LimitReader(f, maxVirusEventSize))
+}
+
+// processVirusEvents logs and emits each spooled detection, then removes it
+func (m *Manager) processVirusEvents(dir string) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return
+	}
+
+	owners := virusEventOwners()
+	for _, file := range files {
+		data, err := readVirusEvent(file, owners)
+		os.Remove(file)
+		if err != nil {
+			m.logger.Warn("Discarding untrusted virus event", "file", file, "error", err)
+			continue
+		}
+
+		var ev VirusEvent
+		if err := json.Unmarshal(data, &ev); err != nil {
+			m.logger.Warn("Discarding malformed virus event", "file", file, "error", err)
+			continue
+		}
+
+		m.logger.Warn("Infected file detected on share",
+			"share", ev.Share,
+			"path", ev.Path,
+			"report", ev.Report,
+			"action", ev.Action,
+			"user", ev.User,
+			"client_ip", ev.ClientIP)
+
+		events.EmitSharingFileAccess(
+			eventspb.EventLevel_EVENT_LEVEL_CRITICAL,
+			&eventspb.SharingFileAccessPayload{
+				FilePath:      ev.Path,
+				Username:      ev.User,
+				OperationType: "virus_detected",
+				Operation:     eventspb.SharingFileAccessPayload_SHARING_FILE_ACCESS_OPERATION_DENIED,
+			},
+			map[string]string{
+				"share":            ev.Share,
+				"report":           ev.Report,
+				"action":           ev.Action,
+				"quarantined_path": ev.QuarantinedPath,
+				"client_ip":        ev.ClientIP,
+				"client_name":      ev.ClientName,
+				"detected_at":      ev.DetectedAt.Format(time.RFC3339),
+			},
+		)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyVirusFilterDefaults(t *testing.T) {
+	config := NewSMBShareConfig("docs", "/tank/docs")
+	applyVirusFilterDefaults(config)
+	assert.Nil(t, config.VirusFilter)
+
+	config.VirusFilter = &SMBVirusFilterConfig{Enabled: true}
+	applyVirusFilterDefaults(config)
+	assert.Equal(t, VirusScannerClamAV, config.VirusFilter.Scanner)
+	assert.Equal(t, VirusActionQuarantine, config.VirusFilter.InfectedFileAction)
+
+	config.VirusFilter = &SMBVirusFilterConfig{Enabled: true, Scanner: VirusScannerSophos,
+		InfectedFileAction: VirusActionDelete}
+	applyVirusFilterDefaults(config)
+	assert.Equal(t, VirusScannerSophos, config.VirusFilter.Scanner)
+	assert.Equal(t, VirusActionDelete, config.VirusFilter.InfectedFileAction)
+}
+
+func TestValidateVirusFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		vf      *SMBVirusFilterConfig
+		custom  map[string]string
+		wantErr bool
+	}{
+		{name: "disabled", vf: nil},
+		{name: "disabled with bad settings", vf: &SMBVirusFilterConfig{Scanner: "bogus"}},
+		{name: "quarantine outside share", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionQuarantine,
+			QuarantineDirectory: "/tank/quarantine"}},
+		{name: "delete", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerFSAV, InfectedFileAction: VirusActionDelete}},
+		{name: "scanner unset", vf: &SMBVirusFilterConfig{Enabled: true,
+			InfectedFileAction: VirusActionDelete}, wantErr: true},
+		{name: "action unset", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV}, wantErr: true},
+		{name: "unsupported scanner", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: "bogus", InfectedFileAction: VirusActionDelete}, wantErr: true},
+		{name: "relative socket", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, SocketPath: "clamd.ctl",
+			InfectedFileAction: VirusActionDelete}, wantErr: true},
+		{name: "invalid action", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: "shred"}, wantErr: true},
+		{name: "quarantine without directory", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionQuarantine}, wantErr: true},
+		{name: "quarantine is share", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionQuarantine,
+			QuarantineDirectory: "/tank/docs/"}, wantErr: true},
+		{name: "quarantine inside share", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionQuarantine,
+			QuarantineDirectory: "/tank/docs/.quarantine"}, wantErr: true},
+		{name: "quarantine sibling with share prefix", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionQuarantine,
+			QuarantineDirectory: "/tank/docs-quarantine"}},
+		{name: "negative max file size", vf: &SMBVirusFilterConfig{Enabled: true,
+			Scanner: VirusScannerClamAV, InfectedFileAction: VirusActionDelete,
+			MaxFileSize: -1}, wantErr: true},
+		{name: "custom virusfilter parameter", custom: map[string]string{
+			"virusfilter:scanner": "clamav"}, wantErr: true},
+		{name: "custom vfs object", custom: map[string]string{
+			"vfs objects": "acl_xattr virusfilter"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSMBShareConfig("docs", "/tank/docs")
+			config.VirusFilter = tt.vf
+			for k, v := range tt.custom {
+				config.CustomParameters[k] = v
+			}
+
+			var before SMBVirusFilterConfig
+			if tt.vf != nil {
+				before = *tt.vf
+			}
+			err := validateVirusFilter(config)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.vf != nil {
+				assert.Equal(t, before, *config.VirusFilter, "validation must not change the config")
+			}
+		})
+	}
+}
+
+func TestVirusFilterParameters(t *testing.T) {
+	tests := []struct {
+		name string
+		vf   *SMBVirusFilterConfig
+		want map[string]string
+	}{
+		{name: "disabled", vf: &SMBVirusFilterConfig{Scanner: VirusScannerClamAV}, want: nil},
+		{
+			name: "quarantine with default socket",
+			vf: &SMBVirusFilterConfig{Enabled: true, Scanner: VirusScannerClamAV, ScanOnClose: true,
+				InfectedFileAction: VirusActionQuarantine, QuarantineDirectory: "/tank/quarantine"},
+			want: map[string]string{
+				"virusfilter:scanner":               VirusScannerClamAV,
+				"virusfilter:socket path":           "/var/run/clamav/clamd.ctl",
+				"virusfilter:scan on close":         "yes",
+				"virusfilter:infected file action":  VirusActionQuarantine,
+				"virusfilter:infected file command": virusReportCommand,
+				"virusfilter:block access on error": "no",
+				"virusfilter:quarantine directory":  "/tank/quarantine",
+			},
+		},
+		{
+			name: "delete with socket and size limit",
+			vf: &SMBVirusFilterConfig{Enabled: true, Scanner: VirusScannerSophos,
+				SocketPath: "/run/sssp.sock", InfectedFileAction: VirusActionDelete,
+				QuarantineDirectory: "/tank/ignored", BlockAccessOnError: true, MaxFileSize: 1 << 20},
+			want: map[string]string{
+				"virusfilter:scanner":               VirusScannerSophos,
+				"virusfilter:socket path":           "/run/sssp.sock",
+				"virusfilter:scan on close":         "no",
+				"virusfilter:infected file action":  VirusActionDelete,
+				"virusfilter:infected file command": virusReportCommand,
+				"virusfilter:block access on error": "yes",
+				"virusfilter:max file size":         "1048576",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := NewSMBShareConfig("docs", "/tank/docs")
+			config.VirusFilter = tt.vf
+			assert.Equal(t, tt.want, config.VirusFilterParameters())
+		})
+	}
+}
+
+func TestVirusFilterFromParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		params   map[string]string
+		want     SMBVirusFilterConfig
+		leftover map[string]string
+	}{
+		{
+			name: "all parameters",
+			params: map[string]string{
+				"virusfilter:scanner":               "clamav",
+				"virusfilter:socket path":           "/run/clamd.sock",
+				"virusfilter:scan on close":         "yes",
+				"virusfilter:infected file action":  "quarantine",
+				"virusfilter:quarantine directory":  "/tank/q",
+				"virusfilter:block access on error": "true",
+				"virusfilter:max file size":         "4096",
+				"read only":                         "no",
+			},
+			want: SMBVirusFilterConfig{Enabled: true, Scanner: "clamav", SocketPath: "/run/clamd.sock",
+				ScanOnClose: true, InfectedFileAction: "quarantine", QuarantineDirectory: "/tank/q",
+				BlockAccessOnError: true, MaxFileSize: 4096},
+			leftover: map[string]string{"read only": "no"},
+		},
+		{
+			name: "unknown and malformed parameters",
+			params: map[string]string{
+				"virusfilter:scan on close": "no",
+				"virusfilter:max file size": "big",
+				"virusfilter:cache entry":   "100",
+			},
+			want:     SMBVirusFilterConfig{Enabled: true},
+			leftover: map[string]string{},
+		},
+		{
+			name:     "none",
+			params:   map[string]string{"comment": "docs"},
+			want:     SMBVirusFilterConfig{Enabled: true},
+			leftover: map[string]string{"comment": "docs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, *virusFilterFromParameters(tt.params))
+			assert.Equal(t, tt.leftover, tt.params)
+		})
+	}
+}
+
+func TestReadVirusEvent(t *testing.T) {
+	dir := t.TempDir()
+	uid := uint32(os.Getuid())
+
+	file := filepath.Join(dir, "event.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"share":"docs"}`), 0600))
+
+	data, err := readVirusEvent(file, []uint32{uid})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"share":"docs"}`, string(data))
+
+	_, err = readVirusEvent(file, []uint32{uid + 1})
+	assert.Error(t, err, "files of other owners are untrusted")
+
+	link := filepath.Join(dir, "link.json")
+	require.NoError(t, os.Symlink(file, link))
+	_, err = readVirusEvent(link, []uint32{uid})
+	assert.Error(t, err, "symlinks are not followed")
+
+	hardlink := filepath.Join(dir, "hardlink.json")
+	require.NoError(t, os.Link(file, hardlink))
+	_, err = readVirusEvent(hardlink, []uint32{uid})
+	assert.Error(t, err, "hard links may point at files outside the spool")
+
+	subdir := filepath.Join(dir, "dir.json")
+	require.NoError(t, os.Mkdir(subdir, 0700))
+	_, err = readVirusEvent(subdir, []uint32{uid})
+	assert.Error(t, err, "only regular files are read")
+}
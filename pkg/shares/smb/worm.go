@@ -0,0 +1,44 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package smb
+
+import (
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	wormVFSObject        = "worm"
+	wormGracePeriodParam = "worm:grace_period"
+
+	// defaultWormGracePeriod matches the vfs_worm default
+	defaultWormGracePeriod = 3600
+)
+
+// validateWorm rejects vfs_worm settings made outside of the worm config
+func validateWorm(config *SMBShareConfig) error {
+	if _, ok := config.CustomParameters[wormGracePeriodParam]; ok {
+		return errors.New(errors.SharesInvalidInput, "Use worm to configure the grace period").
+			WithMetadata("parameter", wormGracePeriodParam)
+	}
+	if hasWormObject(config.CustomParameters["vfs objects"]) {
+		return errors.New(errors.SharesInvalidInput, "Use worm to enable vfs_worm").
+			WithMetadata("parameter", "vfs objects")
+	}
+	if config.Worm != nil && config.Worm.GracePeriodSeconds < 0 {
+		return errors.New(errors.SharesInvalidInput, "WORM grace period cannot be negative").
+			WithMetadata("name", config.Name)
+	}
+	return nil
+}
+
+// hasWormObject reports whether a vfs objects value loads vfs_worm
+func hasWormObject(vfsObjects string) bool {
+	return hasVFSObject(vfsObjects, wormVFSObject)
+}
+
+// withoutWormObject removes vfs_worm from a vfs objects value
+func withoutWormObject(vfsObjects string) string {
+	return withoutVFSObject(vfsObjects, wormVFSObject)
+}
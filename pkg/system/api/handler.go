@@ -146,6 +146,14 @@ func (h *SystemHandler) RegisterRoutes(router *gin.RouterGroup) {
 		config.GET("/locale", h.GetLocale)
 		config.PUT("/locale", h.SetLocale)
 	}
+
+	// Privileged operations policy routes
+	privilege := router.Group("/privilege")
+	{
+		privilege.GET("/policy", h.GetPrivilegePolicy)
+		privilege.GET("/allowed-paths", h.GetAllowedPaths)
+		privilege.GET("/allowed-paths/check", h.CheckAllowedPath)
+	}
 }
 
 // System Information Handlers
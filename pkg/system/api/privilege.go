@@ -0,0 +1,46 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/system/privilege"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Privileged Operations Policy Handlers
+
+// GetPrivilegePolicy returns the effective policy for sudo commands and
+// privileged file operations
+func (h *SystemHandler) GetPrivilegePolicy(c *gin.Context) {
+	h.sendSuccess(c, http.StatusOK, privilege.Policy())
+}
+
+// GetAllowedPaths returns the effective allow-list for privileged file operations
+func (h *SystemHandler) GetAllowedPaths(c *gin.Context) {
+	policy := privilege.Policy()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"allowed_paths": policy.AllowedPaths,
+		"enforce":       policy.Enforce,
+	})
+}
+
+// CheckAllowedPath reports whether the allow-list covers the path given in
+// the "path" query parameter
+func (h *SystemHandler) CheckAllowedPath(c *gin.Context) {
+	path := c.Query("path")
+	if path == "" || !filepath.IsAbs(path) {
+		h.sendError(c, errors.New(errors.ServerRequestValidation, "path must be an absolute path"))
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"path":    path,
+		"allowed": privilege.PathAllowed(path),
+	})
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/stratastor/logger"
 	generalCmd "github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/parsers"
 )
 
 const (
@@ -313,48 +314,20 @@ func (ic *InfoCollector) getHardwareInfo(ctx context.Context) (*HardwareInfo, er
 
 // getMemoryInfo parses /proc/meminfo
 func (ic *InfoCollector) getMemoryInfo(_ context.Context) (*MemoryInfo, error) {
-	file, err := os.Open("/proc/meminfo")
+	data, err := os.ReadFile("/proc/meminfo")
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
-
-	info := &MemoryInfo{}
-	scanner := bufio.NewScanner(file)
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-		if len(fields) < 2 {
-			continue
-		}
-
-		key := strings.TrimSuffix(fields[0], ":")
-		valueStr := fields[1]
-
-		// Convert to bytes (values in /proc/meminfo are in kB)
-		value, err := strconv.ParseUint(valueStr, 10, 64)
-		if err != nil {
-			continue
-		}
-		valueBytes := value * 1024
-
-		switch key {
-		case "MemTotal":
-			info.Total = valueBytes
-		case "MemAvailable":
-			info.Available = valueBytes
-		case "MemFree":
-			info.Free = valueBytes
-		case "Cached":
-			info.Cached = valueBytes
-		case "Buffers":
-			info.Buffers = valueBytes
-		case "SwapTotal":
-			info.SwapTotal = valueBytes
-		case "SwapFree":
-			info.SwapFree = valueBytes
-		}
+	meminfo := parsers.ParseMeminfo(data)
+	info := &MemoryInfo{
+		Total:     meminfo["MemTotal"],
+		Available: meminfo["MemAvailable"],
+		Free:      meminfo["MemFree"],
+		Cached:    meminfo["Cached"],
+		Buffers:   meminfo["Buffers"],
+		SwapTotal: meminfo["SwapTotal"],
+		SwapFree:  meminfo["SwapFree"],
 	}
 
 	// Calculate derived values
@@ -368,7 +341,7 @@ func (ic *InfoCollector) getMemoryInfo(_ context.Context) (*MemoryInfo, error) {
 		info.SwapPercent = float64(info.SwapUsed) / float64(info.SwapTotal) * 100
 	}
 
-	return info, scanner.Err()
+	return info, nil
 }
 
 // getSystemHWInfo gets comprehensive system hardware info from DMI or Device Tree
@@ -648,23 +621,15 @@ func (ic *InfoCollector) getLoadAverage() (*LoadAverage, error) {
 		return nil, err
 	}
 
-	fields := strings.Fields(string(data))
-	if len(fields) < 3 {
-		return nil, fmt.Errorf("invalid loadavg format")
-	}
-
-	load1, err1 := strconv.ParseFloat(fields[0], 64)
-	load5, err2 := strconv.ParseFloat(fields[1], 64)
-	load15, err3 := strconv.ParseFloat(fields[2], 64)
-
-	if err1 != nil || err2 != nil || err3 != nil {
-		return nil, fmt.Errorf("failed to parse load averages")
+	load, err := parsers.ParseLoadavg(data)
+	if err != nil {
+		return nil, err
 	}
 
 	return &LoadAverage{
-		Load1:  load1,
-		Load5:  load5,
-		Load15: load15,
+		Load1:  load.Load1,
+		Load5:  load.Load5,
+		Load15: load.Load15,
 	}, nil
 }
 
@@ -752,23 +717,15 @@ func (ic *InfoCollector) getProcessCount() (*ProcessCount, error) {
 				// Read /proc/[pid]/stat to get process state
 				statPath := "/proc/" + entry.Name() + "/stat"
 				if statData, err := os.ReadFile(statPath); err == nil {
-					// Parse the stat file - state is the 3rd field after the comm field
-					// Format: pid (comm) state ...
-					statStr := string(statData)
-
-					// Find the last ')' to handle process names with spaces/parentheses
-					lastParen := strings.LastIndex(statStr, ")")
-					if lastParen != -1 && lastParen+2 < len(statStr) {
-						state := statStr[lastParen+2]
-
-						switch state {
-						case 'R': // Running
+					if stat, err := parsers.ParseProcStat(statData); err == nil {
+						switch stat.State {
+						case "R": // Running
 							count.Running++
-						case 'S', 'D', 'I': // Sleeping (interruptible, uninterruptible, idle)
+						case "S", "D", "I": // Sleeping (interruptible, uninterruptible, idle)
 							count.Sleeping++
-						case 'T': // Stopped (traced or stopped by signal)
+						case "T": // Stopped (traced or stopped by signal)
 							count.Stopped++
-						case 'Z': // Zombie
+						case "Z": // Zombie
 							count.Zombie++
 						}
 					}
@@ -787,17 +744,12 @@ func (ic *InfoCollector) getUptimeAndBootTime() (uint64, time.Time, error) {
 		return 0, time.Time{}, err
 	}
 
-	fields := strings.Fields(string(data))
-	if len(fields) < 1 {
-		return 0, time.Time{}, fmt.Errorf("invalid uptime format")
-	}
-
-	uptimeFloat, err := strconv.ParseFloat(fields[0], 64)
+	uptimeDuration, err := parsers.ParseUptime(data)
 	if err != nil {
 		return 0, time.Time{}, err
 	}
 
-	uptime := uint64(uptimeFloat)
+	uptime := uint64(uptimeDuration / time.Second)
 	bootTime := time.Now().Add(-time.Duration(uptime) * time.Second)
 
 	return uptime, bootTime, nil
@@ -6,11 +6,9 @@ package system
 
 import (
 	"bufio"
-	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
@@ -1057,14 +1055,16 @@ func (um *UserManager) enrichUserInfo(ctx context.Context, user *User) {
 // encryptPassword encrypts a password using openssl for use with useradd -p
 func (um *UserManager) encryptPassword(ctx context.Context, password string) (string, error) {
 	// Use openssl passwd to generate a secure encrypted password
-	// -6 uses SHA-512 which is the modern standard
-	result, err := um.executor.ExecuteCommand(ctx, "openssl", "passwd", "-6", password)
+	// -6 uses SHA-512 which is the modern standard. Hashing needs no root,
+	// and the password is passed on stdin so it never shows up in ps.
+	output, err := generalCmd.NewCommandExecutor(false).
+		ExecuteWithInput(ctx, password+"\n", "openssl", "passwd", "-6", "-stdin")
 	if err != nil {
 		return "", fmt.Errorf("failed to encrypt password: %w", err)
 	}
 	
 	// Return the encrypted password (trim whitespace)
-	encryptedPassword := strings.TrimSpace(result.Stdout)
+	encryptedPassword := strings.TrimSpace(string(output))
 	if encryptedPassword == "" {
 		return "", fmt.Errorf("openssl returned empty encrypted password")
 	}
@@ -1245,20 +1245,14 @@ func (um *UserManager) addUserToSamba(ctx context.Context, username, password st
 	// -a = add user
 	// -u username = specify username
 	// -t = read password from stdin (requires password twice, one per line)
-	cmd := exec.CommandContext(ctx, "sudo", "pdbedit", "-a", "-u", username, "-t")
 	// pdbedit -t expects password twice for confirmation, each on a separate line
-	cmd.Stdin = strings.NewReader(password + "\n" + password + "\n")
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	output, err := generalCmd.NewCommandExecutor(true).
+		ExecuteWithInput(ctx, password+"\n"+password+"\n", "pdbedit", "-a", "-u", username, "-t")
+	if err != nil {
 		return errors.Wrap(err, errors.SystemUserModifyFailed).
 			WithMetadata("username", username).
 			WithMetadata("operation", "samba_add").
-			WithMetadata("stdout", stdout.String()).
-			WithMetadata("stderr", stderr.String())
+			WithMetadata("output", string(output))
 	}
 
 	um.logger.Debug("Added user to Samba database", "username", username)
@@ -1295,26 +1289,19 @@ func (um *UserManager) updateSambaPassword(ctx context.Context, username, passwo
 	// -a = add or update user (when user exists, this updates their password)
 	// -u username = specify username
 	// -t = read password from stdin (requires password twice, one per line)
-	cmd := exec.CommandContext(ctx, "sudo", "pdbedit", "-a", "-u", username, "-t")
 	// pdbedit -t expects password twice for confirmation, each on a separate line
-	cmd.Stdin = strings.NewReader(password + "\n" + password + "\n")
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	output, err := generalCmd.NewCommandExecutor(true).
+		ExecuteWithInput(ctx, password+"\n"+password+"\n", "pdbedit", "-a", "-u", username, "-t")
+	if err != nil {
 		// If user doesn't exist in Samba DB, add them
-		output := stdout.String() + stderr.String()
-		if strings.Contains(output, "not found") {
+		if strings.Contains(string(output), "not found") {
 			um.logger.Debug("User not found in Samba database, adding", "username", username)
 			return um.addUserToSamba(ctx, username, password)
 		}
 		return errors.Wrap(err, errors.SystemUserModifyFailed).
 			WithMetadata("username", username).
 			WithMetadata("operation", "samba_update_password").
-			WithMetadata("stdout", stdout.String()).
-			WithMetadata("stderr", stderr.String())
+			WithMetadata("output", string(output))
 	}
 
 	um.logger.Debug("Updated Samba password", "username", username)
@@ -0,0 +1,109 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// APIHandler handles HTTP requests for inspecting and testing webhook endpoints
+type APIHandler struct {
+	dispatcher *Dispatcher
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewAPIHandler creates a new webhooks API handler
+func NewAPIHandler(dispatcher *Dispatcher) *APIHandler {
+	return &APIHandler{
+		dispatcher: dispatcher,
+	}
+}
+
+// RegisterRoutes registers HTTP routes for webhook endpoints
+func (h *APIHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("", h.listEndpoints)
+	router.POST("/:name/test", h.testEndpoint)
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *APIHandler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *APIHandler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  string(errors.DomainWebhooks),
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// listEndpoints lists the configured endpoints; secrets are never returned
+func (h *APIHandler) listEndpoints(c *gin.Context) {
+	endpoints := h.dispatcher.Endpoints()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"endpoints":   endpoints,
+		"count":       len(endpoints),
+		"event_types": EventTypes,
+	})
+}
+
+// testEndpoint sends a test event to an endpoint and reports whether it was
+// accepted
+func (h *APIHandler) testEndpoint(c *gin.Context) {
+	event, err := h.dispatcher.Test(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"endpoint":  c.Param("name"),
+		"event_id":  event.ID,
+		"delivered": true,
+	})
+}
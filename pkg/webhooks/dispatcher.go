@@ -0,0 +1,327 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+)
+
+// JobTypeDeliveryPrefix prefixes the job type of deliveries to an endpoint.
+// Each endpoint has its own job type so it can have its own retry policy.
+const JobTypeDeliveryPrefix = "webhook-delivery:"
+
+// deliveryJobPayload is an event encoded for one endpoint
+type deliveryJobPayload struct {
+	Endpoint  string          `json:"endpoint"`
+	EventID   string          `json:"event_id"`
+	EventType EventType       `json:"event_type"`
+	Body      json.RawMessage `json:"body"`
+}
+
+// Dispatcher publishes events to the configured endpoints
+type Dispatcher struct {
+	logger    logger.Logger
+	host      string
+	endpoints []Endpoint
+	client    *http.Client
+	jobQueue  atomic.Pointer[jobs.Queue]
+
+	// muted is why only critical events are delivered; nil while every
+	// event is
+	muted atomic.Pointer[string]
+}
+
+// Singleton instance
+var (
+	globalDispatcher *Dispatcher
+	initMutex        sync.Mutex
+)
+
+// GetDispatcher returns the singleton dispatcher for the endpoints in the
+// config file. Invalid endpoints are logged and left out, so one typo does
+// not silence the others.
+func GetDispatcher(logCfg logger.Config) (*Dispatcher, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalDispatcher != nil {
+		return globalDispatcher, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "webhooks")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	var endpoints []Endpoint
+	seen := make(map[string]bool)
+	for _, cfg := range config.GetConfig().Webhooks.Endpoints {
+		ep, err := ParseEndpoint(cfg)
+		if err != nil {
+			l.Error("Ignoring invalid webhook endpoint", "endpoint", cfg.Name, "error", err)
+			continue
+		}
+		if seen[ep.Name] {
+			l.Error("Ignoring webhook endpoint with duplicate name", "endpoint", ep.Name)
+			continue
+		}
+		seen[ep.Name] = true
+		endpoints = append(endpoints, ep)
+	}
+
+	globalDispatcher = NewDispatcher(l, endpoints)
+	return globalDispatcher, nil
+}
+
+// NewDispatcher creates a dispatcher for validated endpoints. Most callers
+// should use GetDispatcher.
+func NewDispatcher(l logger.Logger, endpoints []Endpoint) *Dispatcher {
+	host, _ := os.Hostname()
+	return &Dispatcher{
+		logger:    l,
+		host:      host,
+		endpoints: endpoints,
+		client:    &http.Client{},
+	}
+}
+
+// UseJobQueue delivers events through the job queue, so failed deliveries
+// are retried with backoff and survive a restart. Without a queue each event
+// is attempted once.
+func (d *Dispatcher) UseJobQueue(q *jobs.Queue) {
+	for _, ep := range d.endpoints {
+		q.Register(JobTypeDeliveryPrefix+ep.Name, d.runDeliveryJob, jobs.RetryPolicy{
+			MaxAttempts: ep.MaxAttempts,
+			Timeout:     ep.Timeout,
+		})
+	}
+	d.jobQueue.Store(q)
+}
+
+// Mute holds back every event but the critical ones until Unmute is
+// called, such as while the node is in maintenance. Muted events are
+// dropped, not delivered later.
+func (d *Dispatcher) Mute(reason string) {
+	d.muted.Store(&reason)
+	d.logger.Info("Webhook events muted, except critical ones", "reason", reason)
+}
+
+// Unmute delivers every event again
+func (d *Dispatcher) Unmute() {
+	if d.muted.Swap(nil) != nil {
+		d.logger.Info("Webhook events unmuted")
+	}
+}
+
+// Endpoints returns the configured endpoints
+func (d *Dispatcher) Endpoints() []Endpoint {
+	return append([]Endpoint(nil), d.endpoints...)
+}
+
+// Publish sends an event to every endpoint subscribed to its type. It is
+// safe to call on a nil dispatcher.
+func (d *Dispatcher) Publish(eventType EventType, data any) {
+	if d == nil {
+		return
+	}
+	if reason := d.muted.Load(); reason != nil && !slices.Contains(CriticalEventTypes, eventType) {
+		d.logger.Debug("Dropping muted webhook event", "type", eventType, "reason", *reason)
+		return
+	}
+
+	var targets []Endpoint
+	for _, ep := range d.endpoints {
+		if ep.Wants(eventType) {
+			targets = append(targets, ep)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	event, body, err := d.newEvent(eventType, data)
+	if err != nil {
+		d.logger.Error("Failed to encode webhook event", "type", eventType, "error", err)
+		return
+	}
+
+	q := d.jobQueue.Load()
+	for _, ep := range targets {
+		payload := deliveryJobPayload{
+			Endpoint:  ep.Name,
+			EventID:   event.ID,
+			EventType: eventType,
+			Body:      body,
+		}
+
+		if q != nil {
+			_, err := q.Enqueue(JobTypeDeliveryPrefix+ep.Name, payload)
+			if err == nil {
+				continue
+			}
+			d.logger.Warn("Failed to enqueue webhook delivery, sending once",
+				"endpoint", ep.Name,
+				"event_id", event.ID,
+				"error", err)
+		}
+
+		go func(ep Endpoint, payload deliveryJobPayload) {
+			ctx, cancel := context.WithTimeout(context.Background(), ep.Timeout)
+			defer cancel()
+			if err := d.deliver(ctx, ep, payload); err != nil {
+				d.logger.Warn("Webhook delivery failed",
+					"endpoint", ep.Name,
+					"event_id", payload.EventID,
+					"error", err)
+			}
+		}(ep, payload)
+	}
+}
+
+// Test sends a test event to the named endpoint and waits for the answer.
+// It is not retried.
+func (d *Dispatcher) Test(ctx context.Context, name string) (Event, error) {
+	ep, ok := d.endpoint(name)
+	if !ok {
+		return Event{}, errors.New(errors.WebhookNotFound, "webhook endpoint not found").
+			WithMetadata("endpoint", name)
+	}
+
+	event, body, err := d.newEvent(EventTest, map[string]string{"endpoint": name})
+	if err != nil {
+		return Event{}, errors.Wrap(err, errors.WebhookDeliveryFailed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ep.Timeout)
+	defer cancel()
+
+	err = d.deliver(ctx, ep, deliveryJobPayload{
+		Endpoint:  name,
+		EventID:   event.ID,
+		EventType: EventTest,
+		Body:      body,
+	})
+	return event, err
+}
+
+// newEvent builds an event and its JSON encoding
+func (d *Dispatcher) newEvent(eventType EventType, data any) (Event, []byte, error) {
+	event := Event{
+		ID:        common.UUID7(),
+		Type:      eventType,
+		Host:      d.host,
+		Timestamp: time.Now().UTC(),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	return event, body, err
+}
+
+// endpoint returns the endpoint with the name
+func (d *Dispatcher) endpoint(name string) (Endpoint, bool) {
+	for _, ep := range d.endpoints {
+		if ep.Name == name {
+			return ep, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// runDeliveryJob makes one delivery attempt for a queued event. Deliveries
+// to endpoints removed from the config are dropped.
+func (d *Dispatcher) runDeliveryJob(ctx context.Context, payload json.RawMessage) error {
+	var p deliveryJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+
+	ep, ok := d.endpoint(p.Endpoint)
+	if !ok {
+		d.logger.Debug("Dropping delivery to removed webhook endpoint", "endpoint", p.Endpoint)
+		return nil
+	}
+
+	return d.deliver(ctx, ep, p)
+}
+
+// deliver POSTs an encoded event to an endpoint. Client errors other than
+// timeouts and rate limiting will not succeed on retry and fail permanently.
+func (d *Dispatcher) deliver(ctx context.Context, ep Endpoint, p deliveryJobPayload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(p.Body))
+	if err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.WebhookInvalidConfig).
+			WithMetadata("endpoint", ep.Name))
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rodent-webhooks")
+	req.Header.Set(HeaderEvent, string(p.EventType))
+	req.Header.Set(HeaderDelivery, p.EventID)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if ep.Secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(ep.Secret, timestamp, p.Body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, errors.WebhookDeliveryFailed).
+			WithMetadata("endpoint", ep.Name)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		d.logger.Debug("Webhook delivered",
+			"endpoint", ep.Name,
+			"event_id", p.EventID,
+			"type", p.EventType)
+		return nil
+	}
+
+	err = errors.New(
+		errors.WebhookDeliveryFailed,
+		fmt.Sprintf("endpoint answered %s", resp.Status),
+	).WithMetadata("endpoint", ep.Name).
+		WithMetadata("status", strconv.Itoa(resp.StatusCode))
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout &&
+		resp.StatusCode != http.StatusTooManyRequests {
+		return jobs.Permanent(err)
+	}
+	return err
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret.
+// Receivers recompute it to check that a delivery came from Rodent, and
+// reject old timestamps to prevent replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,195 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// received is a request captured by a test endpoint
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// newTestEndpoint starts a server answering with status and passing the
+// requests it receives to the returned channel
+func newTestEndpoint(t *testing.T, status int) (*httptest.Server, chan received) {
+	t.Helper()
+
+	ch := make(chan received, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ch <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, ch
+}
+
+func TestParseEndpoint(t *testing.T) {
+	ep, err := ParseEndpoint(config.WebhookEndpoint{
+		Name:   "catalog",
+		URL:    "https://catalog.example.com/hooks",
+		Secret: "s3cret",
+		Events: []string{"snapshot.created"},
+	})
+	require.NoError(t, err)
+	assert.True(t, ep.Signed)
+	assert.Equal(t, defaultTimeout, ep.Timeout)
+	assert.Equal(t, defaultMaxAttempts, ep.MaxAttempts)
+	assert.True(t, ep.Wants(EventSnapshotCreated))
+	assert.False(t, ep.Wants(EventTransferFinished))
+	assert.True(t, ep.Wants(EventTest))
+
+	invalid := []config.WebhookEndpoint{
+		{URL: "https://example.com"},
+		{Name: "a", URL: "ftp://example.com"},
+		{Name: "a", URL: "not a url"},
+		{Name: "a", URL: "https://example.com", Events: []string{"snapshot.deleted"}},
+		{Name: "a", URL: "https://example.com", Timeout: "soon"},
+		{Name: "a", URL: "https://example.com", MaxAttempts: -1},
+	}
+	for _, cfg := range invalid {
+		_, err := ParseEndpoint(cfg)
+		assert.Error(t, err, "%+v", cfg)
+	}
+}
+
+func TestPublishSignsDeliveries(t *testing.T) {
+	srv, ch := newTestEndpoint(t, http.StatusNoContent)
+	d := NewDispatcher(common.Log, []Endpoint{
+		{Name: "catalog", URL: srv.URL, Secret: "s3cret", Timeout: time.Second, MaxAttempts: 1},
+		{Name: "transfers-only", URL: srv.URL, Events: []EventType{EventTransferFinished},
+			Timeout: time.Second, MaxAttempts: 1},
+	})
+
+	d.snapshotCreated(autosnapshots.CreateSnapshotResult{
+		PolicyID:     "policy-1",
+		DatasetName:  "tank/data",
+		SnapshotName: "auto-1",
+	})
+
+	var got received
+	select {
+	case got = <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+	}
+
+	assert.Equal(t, string(EventSnapshotCreated), got.header.Get(HeaderEvent))
+	assert.Equal(t,
+		"sha256="+Sign("s3cret", got.header.Get(HeaderTimestamp), got.body),
+		got.header.Get(HeaderSignature))
+
+	var event struct {
+		ID   string              `json:"id"`
+		Type EventType           `json:"type"`
+		Data SnapshotCreatedData `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(got.body, &event))
+	assert.Equal(t, got.header.Get(HeaderDelivery), event.ID)
+	assert.Equal(t, "tank/data@auto-1", event.Data.Snapshot)
+
+	// The transfers-only endpoint is not subscribed and nothing was pruned
+	select {
+	case extra := <-ch:
+		t.Fatalf("unexpected delivery: %s", extra.header.Get(HeaderEvent))
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestMuteKeepsCriticalEvents(t *testing.T) {
+	srv, ch := newTestEndpoint(t, http.StatusNoContent)
+	d := NewDispatcher(common.Log, []Endpoint{
+		{Name: "pager", URL: srv.URL, Timeout: time.Second, MaxAttempts: 1},
+	})
+
+	d.Mute("disk swap")
+	d.Publish(EventSnapshotCreated, map[string]string{"snapshot": "tank/data@auto-1"})
+	d.Publish(EventDiskFailed, map[string]string{"device": "sdb"})
+
+	select {
+	case got := <-ch:
+		assert.Equal(t, string(EventDiskFailed), got.header.Get(HeaderEvent))
+	case <-time.After(5 * time.Second):
+		t.Fatal("critical event not delivered while muted")
+	}
+	select {
+	case extra := <-ch:
+		t.Fatalf("muted event delivered: %s", extra.header.Get(HeaderEvent))
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	d.Unmute()
+	d.Publish(EventSnapshotCreated, map[string]string{"snapshot": "tank/data@auto-2"})
+	select {
+	case got := <-ch:
+		assert.Equal(t, string(EventSnapshotCreated), got.header.Get(HeaderEvent))
+	case <-time.After(5 * time.Second):
+		t.Fatal("event not delivered after unmute")
+	}
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	q := jobs.NewQueue(common.Log, filepath.Join(t.TempDir(), "jobs.json"), 2)
+	q.Start()
+	defer q.Stop()
+
+	rejecting, _ := newTestEndpoint(t, http.StatusBadRequest)
+	failing, _ := newTestEndpoint(t, http.StatusServiceUnavailable)
+	d := NewDispatcher(common.Log, []Endpoint{
+		{Name: "rejecting", URL: rejecting.URL, Timeout: time.Second, MaxAttempts: 3},
+		{Name: "failing", URL: failing.URL, Timeout: time.Second, MaxAttempts: 3},
+	})
+	d.UseJobQueue(q)
+
+	d.Publish(EventTransferFinished, TransferFinishedData{TransferID: "t-1"})
+
+	statusOf := func(name string) (jobs.Status, int) {
+		list := q.List(jobs.ListFilter{Type: JobTypeDeliveryPrefix + name})
+		if len(list) != 1 {
+			return "", 0
+		}
+		return list[0].Status, list[0].Attempts
+	}
+
+	// A client error will not succeed on retry
+	require.Eventually(t, func() bool {
+		status, attempts := statusOf("rejecting")
+		return status == jobs.StatusFailed && attempts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A server error is retried
+	require.Eventually(t, func() bool {
+		status, attempts := statusOf("failing")
+		return status == jobs.StatusRetrying && attempts == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTestUnknownEndpoint(t *testing.T) {
+	d := NewDispatcher(common.Log, nil)
+	_, err := d.Test(context.Background(), "missing")
+	assert.Error(t, err)
+
+	// Publishing with no endpoints, or on a nil dispatcher, is a no-op
+	d.Publish(EventSnapshotCreated, nil)
+	var nilDispatcher *Dispatcher
+	nilDispatcher.Publish(EventSnapshotCreated, nil)
+}
@@ -0,0 +1,96 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package webhooks
+
+import (
+	"github.com/stratastor/rodent/pkg/disk"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// WatchSnapshots publishes the snapshots that snapshot policies create and prune
+func (d *Dispatcher) WatchSnapshots(m *autosnapshots.Manager) {
+	m.OnSnapshotCreated(d.snapshotCreated)
+}
+
+// WatchTransfers publishes transfers as they finish
+func (d *Dispatcher) WatchTransfers(tm *dataset.TransferManager) {
+	tm.OnTransferFinished(d.transferFinished)
+}
+
+// WatchOperations publishes operations as they finish. The data of an
+// operation.done event is the operation as returned by the operations API.
+func (d *Dispatcher) WatchOperations(q *jobs.Queue) {
+	q.OnFinished(func(job jobs.Job) {
+		if jobs.IsOperation(job) {
+			d.Publish(EventOperationDone, jobs.NewOperation(job))
+		}
+	})
+}
+
+// WatchDisks publishes failing disks with what their failure puts at risk
+func (d *Dispatcher) WatchDisks(m *disk.Manager) {
+	m.OnDiskFailed(d.diskFailed)
+}
+
+// snapshotCreated publishes a policy run's new snapshot and, if retention
+// destroyed any, the pruned snapshots
+func (d *Dispatcher) snapshotCreated(result autosnapshots.CreateSnapshotResult) {
+	d.Publish(EventSnapshotCreated, SnapshotCreatedData{
+		PolicyID:      result.PolicyID,
+		ScheduleIndex: result.ScheduleIndex,
+		Dataset:       result.DatasetName,
+		Snapshot:      result.DatasetName + "@" + result.SnapshotName,
+		CreatedAt:     result.CreatedAt,
+	})
+
+	if len(result.PrunedSnapshots) > 0 {
+		d.Publish(EventSnapshotPruned, SnapshotPrunedData{
+			PolicyID:  result.PolicyID,
+			Dataset:   result.DatasetName,
+			Snapshots: result.PrunedSnapshots,
+		})
+	}
+}
+
+// transferFinished publishes the outcome of a transfer
+func (d *Dispatcher) transferFinished(info dataset.TransferInfo) {
+	d.Publish(EventTransferFinished, TransferFinishedData{
+		TransferID:       info.ID,
+		PolicyID:         info.PolicyID,
+		Status:           string(info.Status),
+		Snapshot:         info.Config.SendConfig.Snapshot,
+		FromSnapshot:     info.Config.SendConfig.FromSnapshot,
+		Target:           info.Config.ReceiveConfig.Target,
+		RemoteHost:       info.Config.ReceiveConfig.RemoteConfig.Host,
+		SourceHost:       info.Config.SendConfig.RemoteConfig.Host,
+		BytesTransferred: info.Progress.BytesTransferred,
+		StartedAt:        info.StartedAt,
+		CompletedAt:      info.CompletedAt,
+		Error:            info.ErrorMessage,
+		ErrorCategory:    string(info.ErrorCategory),
+	})
+}
+
+// diskFailed publishes a failing disk
+func (d *Dispatcher) diskFailed(failure disk.DiskFailure) {
+	data := DiskFailedData{
+		DeviceID:   failure.Disk.DeviceID,
+		DevicePath: failure.Disk.DevicePath,
+		Serial:     failure.Disk.Serial,
+		Model:      failure.Disk.Model,
+		Reason:     failure.Reason,
+		Health:     string(failure.Disk.Health),
+		State:      string(failure.Disk.State),
+		Impact:     failure.Impact,
+		DetectedAt: failure.DetectedAt,
+	}
+	if failure.Impact != nil {
+		data.Summary = failure.Impact.Summary()
+		data.DataLoss = failure.Impact.DataLoss()
+	}
+	d.Publish(EventDiskFailed, data)
+}
@@ -0,0 +1,188 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package webhooks delivers snapshot and transfer events to external HTTP
+// endpoints, such as backup catalogs or CMDBs, so they can index snapshots as
+// they are produced. Deliveries are signed with HMAC-SHA256 and retried
+// through the job queue.
+package webhooks
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/disk"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// EventType names an event delivered to endpoints
+type EventType string
+
+const (
+	EventSnapshotCreated  EventType = "snapshot.created"  // A snapshot policy created a snapshot
+	EventSnapshotPruned   EventType = "snapshot.pruned"   // A snapshot policy's retention destroyed snapshots
+	EventTransferFinished EventType = "transfer.finished" // A transfer completed, failed or was cancelled
+	EventOperationDone    EventType = "operation.done"    // An API request run in the background finished
+	EventDiskFailed       EventType = "disk.failed"       // A disk failed health checks, was faulted or was removed from a pool
+	EventReportDigest     EventType = "report.digest"     // A scheduled digest of job results was built
+	EventTest             EventType = "webhook.test"      // Sent on request through the API
+)
+
+// EventTypes lists the event types endpoints can subscribe to
+var EventTypes = []EventType{
+	EventSnapshotCreated,
+	EventSnapshotPruned,
+	EventTransferFinished,
+	EventOperationDone,
+	EventDiskFailed,
+	EventReportDigest,
+}
+
+// CriticalEventTypes are delivered even while events are muted
+var CriticalEventTypes = []EventType{
+	EventDiskFailed,
+}
+
+// Headers set on every delivery
+const (
+	HeaderEvent     = "X-Rodent-Event"     // Event type
+	HeaderDelivery  = "X-Rodent-Delivery"  // Event ID, the same on every attempt
+	HeaderTimestamp = "X-Rodent-Timestamp" // Unix seconds the attempt was signed at
+	HeaderSignature = "X-Rodent-Signature" // "sha256=" + hex HMAC of "<timestamp>.<body>"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultMaxAttempts = 5
+)
+
+// Event is the JSON body POSTed to endpoints
+type Event struct {
+	ID        string    `json:"id"`
+	Type      EventType `json:"type"`
+	Host      string    `json:"host"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      any       `json:"data"`
+}
+
+// SnapshotCreatedData is the data of a snapshot.created event
+type SnapshotCreatedData struct {
+	PolicyID      string    `json:"policy_id"`
+	ScheduleIndex int       `json:"schedule_index"`
+	Dataset       string    `json:"dataset"`
+	Snapshot      string    `json:"snapshot"` // dataset@name
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SnapshotPrunedData is the data of a snapshot.pruned event
+type SnapshotPrunedData struct {
+	PolicyID  string   `json:"policy_id"`
+	Dataset   string   `json:"dataset"`
+	Snapshots []string `json:"snapshots"`
+}
+
+// TransferFinishedData is the data of a transfer.finished event
+type TransferFinishedData struct {
+	TransferID       string     `json:"transfer_id"`
+	PolicyID         string     `json:"policy_id,omitempty"`
+	Status           string     `json:"status"`
+	Snapshot         string     `json:"snapshot"`
+	FromSnapshot     string     `json:"from_snapshot,omitempty"`
+	Target           string     `json:"target"`
+	RemoteHost       string     `json:"remote_host,omitempty"`
+	SourceHost       string     `json:"source_host,omitempty"` // Remote source of a pull transfer
+	BytesTransferred int64      `json:"bytes_transferred"`
+	StartedAt        *time.Time `json:"started_at,omitempty"`
+	CompletedAt      *time.Time `json:"completed_at,omitempty"`
+	Error            string     `json:"error,omitempty"`
+	ErrorCategory    string     `json:"error_category,omitempty"` // Kind of failure, such as network or no_space
+}
+
+// DiskFailedData is the data of a disk.failed event
+type DiskFailedData struct {
+	DeviceID   string           `json:"device_id"`
+	DevicePath string           `json:"device_path"`
+	Serial     string           `json:"serial"`
+	Model      string           `json:"model,omitempty"`
+	Reason     string           `json:"reason"`
+	Health     string           `json:"health"`
+	State      string           `json:"state"`
+	Summary    string           `json:"summary,omitempty"` // One line description of the impact
+	DataLoss   bool             `json:"data_loss"`         // The vdev of the disk can no longer serve its data
+	Impact     *disk.DiskImpact `json:"impact,omitempty"`  // Pool, vdev, datasets and shares at risk; nil outside pools
+	DetectedAt time.Time        `json:"detected_at"`
+}
+
+// Endpoint is a validated webhook endpoint
+type Endpoint struct {
+	Name        string        `json:"name"`
+	URL         string        `json:"url"`
+	Secret      string        `json:"-"`
+	Signed      bool          `json:"signed"`
+	Events      []EventType   `json:"events,omitempty"` // Empty: all event types
+	Timeout     time.Duration `json:"timeout"`
+	MaxAttempts int           `json:"max_attempts"`
+}
+
+// Wants reports whether the endpoint subscribes to the event type. Test
+// events go to every endpoint.
+func (e Endpoint) Wants(t EventType) bool {
+	return t == EventTest || len(e.Events) == 0 || slices.Contains(e.Events, t)
+}
+
+// ParseEndpoint validates an endpoint from the config file and fills in
+// defaults
+func ParseEndpoint(cfg config.WebhookEndpoint) (Endpoint, error) {
+	if cfg.Name == "" {
+		return Endpoint{}, errors.New(errors.WebhookInvalidConfig, "endpoint name is required")
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, errors.New(errors.WebhookInvalidConfig, "url must be an http or https URL").
+			WithMetadata("endpoint", cfg.Name)
+	}
+
+	ep := Endpoint{
+		Name:        cfg.Name,
+		URL:         cfg.URL,
+		Secret:      cfg.Secret,
+		Signed:      cfg.Secret != "",
+		Timeout:     defaultTimeout,
+		MaxAttempts: defaultMaxAttempts,
+	}
+
+	for _, name := range cfg.Events {
+		t := EventType(name)
+		if !slices.Contains(EventTypes, t) {
+			return Endpoint{}, errors.New(
+				errors.WebhookInvalidConfig,
+				fmt.Sprintf("unknown event type %q", name),
+			).WithMetadata("endpoint", cfg.Name)
+		}
+		ep.Events = append(ep.Events, t)
+	}
+
+	if cfg.Timeout != "" {
+		d, err := time.ParseDuration(cfg.Timeout)
+		if err != nil || d <= 0 {
+			return Endpoint{}, errors.New(errors.WebhookInvalidConfig, "timeout must be a positive duration").
+				WithMetadata("endpoint", cfg.Name)
+		}
+		ep.Timeout = d
+	}
+
+	if cfg.MaxAttempts < 0 {
+		return Endpoint{}, errors.New(errors.WebhookInvalidConfig, "maxAttempts cannot be negative").
+			WithMetadata("endpoint", cfg.Name)
+	}
+	if cfg.MaxAttempts > 0 {
+		ep.MaxAttempts = cfg.MaxAttempts
+	}
+
+	return ep, nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/system/privilege"
+	"github.com/stratastor/rodent/pkg/zfs/arc"
+)
+
+// RegisterARCRoutes registers the ARC statistics and configuration routes and
+// starts sampling the ARC. The caller stops the handler's monitor.
+func RegisterARCRoutes(router *gin.RouterGroup) (*arc.Handler, error) {
+	cfg := config.GetConfig()
+	l, err := logger.NewTag(logger.Config{LogLevel: cfg.Server.LogLevel}, "arc")
+	if err != nil {
+		return nil, err
+	}
+
+	fileOps := privilege.NewSudoFileOperations(l, generalCmd.NewCommandExecutor(true),
+		privilege.AllowedPaths())
+
+	monitor := arc.NewMonitor(l)
+	monitor.Start()
+
+	handler := arc.NewHandler(monitor, arc.NewConfigManager(l, fileOps))
+	handler.RegisterRoutes(router)
+
+	return handler, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/zfs/autoattach"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// RegisterAttachRuleRoutes registers the auto-attach rule routes to the scheduler router group.
+// transferPolicyHandler may be nil, in which case rules cannot use transfer policy templates.
+func RegisterAttachRuleRoutes(
+	router *gin.RouterGroup,
+	datasetManager *dataset.Manager,
+	snapshotHandler *autosnapshots.Handler,
+	transferPolicyHandler *autotransfers.Handler,
+) (*autoattach.Handler, error) {
+	var transferPolicyMgr *autotransfers.Manager
+	if transferPolicyHandler != nil {
+		transferPolicyMgr = transferPolicyHandler.Manager()
+	}
+
+	cfg := config.GetConfig()
+	logCfg := logger.Config{LogLevel: cfg.Server.LogLevel}
+	attachManager, err := autoattach.GetManager(
+		datasetManager,
+		snapshotHandler.Manager(),
+		transferPolicyMgr,
+		logCfg,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := autoattach.NewHandlerWithManager(attachManager)
+	if err := handler.StartManager(); err != nil {
+		return nil, err
+	}
+
+	handler.RegisterRoutes(router)
+
+	return handler, nil
+}
@@ -0,0 +1,27 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
+)
+
+// RegisterCalendarRoutes registers the schedule calendar routes to the scheduler router group
+func RegisterCalendarRoutes(router *gin.RouterGroup) (*calendars.Handler, error) {
+	cfg := config.GetConfig()
+	logCfg := logger.Config{LogLevel: cfg.Server.LogLevel}
+	calendarManager, err := calendars.GetManager(logCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := calendars.NewHandlerWithManager(calendarManager)
+	handler.RegisterRoutes(router)
+
+	return handler, nil
+}
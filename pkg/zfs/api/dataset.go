@@ -18,12 +18,18 @@
 package api
 
 import (
+	"maps"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/stratastor/rodent/internal/managers"
+	"github.com/stratastor/rodent/internal/scope"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/resolver"
 )
 
 func NewDatasetHandler(
@@ -49,7 +55,17 @@ func (h *DatasetHandler) listDatasets(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, gin.H{"result": scopedList(c, result)})
+}
+
+// scopedList leaves out the datasets a delegated key may not manage
+func scopedList(c *gin.Context, result dataset.ListResult) dataset.ListResult {
+	if sc := scope.FromContext(c.Request.Context()); sc != nil {
+		maps.DeleteFunc(result.Datasets, func(name string, _ dataset.Dataset) bool {
+			return !sc.AllowsDataset(name)
+		})
+	}
+	return result
 }
 
 func (h *DatasetHandler) createFilesystem(c *gin.Context) {
@@ -83,7 +99,7 @@ func (h *DatasetHandler) listFilesystems(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, gin.H{"result": scopedList(c, result)})
 }
 
 func (h *DatasetHandler) listVolumes(c *gin.Context) {
@@ -101,7 +117,7 @@ func (h *DatasetHandler) listVolumes(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, gin.H{"result": scopedList(c, result)})
 }
 
 func (h *DatasetHandler) createVolume(c *gin.Context) {
@@ -120,6 +136,133 @@ func (h *DatasetHandler) createVolume(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"result": result})
 }
 
+func (h *DatasetHandler) getVolume(c *gin.Context) {
+	var cfg dataset.NameConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	info, err := h.manager.GetVolume(c.Request.Context(), cfg)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	// Report the snapshot policies protecting the volume and the transfer
+	// policies replicating those snapshots
+	type volumePolicy struct {
+		ID                string   `json:"id"`
+		Name              string   `json:"name"`
+		Dataset           string   `json:"dataset"`
+		Enabled           bool     `json:"enabled"`
+		TransferPolicyIDs []string `json:"transfer_policy_ids,omitempty"`
+	}
+	policies := []volumePolicy{}
+	if snapshotMgr := managers.GetSnapshotManager(); snapshotMgr != nil {
+		for _, p := range snapshotMgr.PoliciesForDataset(cfg.Name) {
+			policies = append(policies, volumePolicy{
+				ID:                p.ID,
+				Name:              p.Name,
+				Dataset:           p.Dataset,
+				Enabled:           p.Enabled,
+				TransferPolicyIDs: p.TransferPolicyIDs,
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": gin.H{
+		"volume":            info,
+		"snapshot_policies": policies,
+	}})
+}
+
+// resolveDataset resolves a path to the dataset holding it, with its quota
+// and policies, or a dataset name to its mountpoint
+func (h *DatasetHandler) resolveDataset(c *gin.Context) {
+	var req struct {
+		Path string `json:"path"`
+		Name string `json:"name"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+	if (req.Path == "") == (req.Name == "") {
+		APIError(c, errors.New(errors.ServerRequestValidation, "exactly one of path or name is required"))
+		return
+	}
+
+	pathResolver := managers.GetPathResolver()
+	if pathResolver == nil {
+		APIError(c, errors.New(errors.ServerInternalError, "path resolver not available"))
+		return
+	}
+
+	if req.Path != "" {
+		storage, err := pathResolver.Describe(c.Request.Context(), req.Path)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": storage})
+		return
+	}
+
+	mount, err := pathResolver.ResolveDataset(c.Request.Context(), req.Name)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": mount})
+}
+
+func (h *DatasetHandler) listVolumeConsumers(c *gin.Context) {
+	var cfg dataset.NameConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	consumers, err := h.manager.ListVolumeConsumers(c.Request.Context(), cfg)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": consumers})
+}
+
+func (h *DatasetHandler) resizeVolume(c *gin.Context) {
+	var cfg dataset.VolumeResizeConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.ResizeVolume(c.Request.Context(), cfg); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *DatasetHandler) cloneVolume(c *gin.Context) {
+	var cfg dataset.VolumeCloneConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.CloneVolume(c.Request.Context(), cfg); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
 func (h *DatasetHandler) destroyDataset(c *gin.Context) {
 	var req dataset.DestroyConfig
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -127,6 +270,16 @@ func (h *DatasetHandler) destroyDataset(c *gin.Context) {
 		return
 	}
 
+	if !req.DryRun {
+		if err := guard.Check(c.Request.Context(), guard.Request{
+			Operation: guard.OpDatasetDestroy,
+			Targets:   []string{req.Name},
+		}); err != nil {
+			APIError(c, err)
+			return
+		}
+	}
+
 	result, err := h.manager.Destroy(c.Request.Context(), req)
 	if err != nil {
 		APIError(c, err)
@@ -224,6 +377,13 @@ func (h *DatasetHandler) listSnapshots(c *gin.Context) {
 	}
 
 	req.Type = "snapshot"
+	// Show pins with the default columns, or with the columns asked for
+	if len(req.Properties) == 0 {
+		req.Properties = []string{"name", "used", "available", "referenced", "mountpoint"}
+	}
+	if !slices.Contains(req.Properties, "all") && !slices.Contains(req.Properties, dataset.TagPinned) {
+		req.Properties = append(req.Properties, dataset.TagPinned)
+	}
 
 	result, err := h.manager.List(c.Request.Context(), req)
 	if err != nil {
@@ -231,6 +391,37 @@ func (h *DatasetHandler) listSnapshots(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{"result": scopedList(c, result)})
+}
+
+// listSnapshotsByTags lists the snapshots having every tag query parameter,
+// given as key=value or key alone to match any value. Keys without a
+// namespace are Rodent's, so tag=policy_id=<id> matches rodent:policy_id.
+// The dataset parameter limits the search to a dataset and its children.
+func (h *DatasetHandler) listSnapshotsByTags(c *gin.Context) {
+	q := dataset.TagQuery{
+		Name: c.Query("dataset"),
+		Tags: make(map[string]string),
+	}
+	for _, tag := range c.QueryArray("tag") {
+		key, value, err := dataset.ParseTag(tag)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		q.Tags[key] = value
+	}
+	if len(q.Tags) == 0 {
+		APIError(c, errors.New(errors.ServerRequestValidation, "at least one tag is required"))
+		return
+	}
+
+	result, err := h.manager.ListSnapshotsByTags(c.Request.Context(), q)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"result": result})
 }
 
@@ -250,6 +441,71 @@ func (h *DatasetHandler) rollbackSnapshot(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// pinSnapshot keeps a snapshot from snapshot policy retention
+func (h *DatasetHandler) pinSnapshot(c *gin.Context) {
+	var req dataset.PinConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.Pin(c.Request.Context(), req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// unpinSnapshot leaves a pinned snapshot to retention again
+func (h *DatasetHandler) unpinSnapshot(c *gin.Context) {
+	var req dataset.NameConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.Unpin(c.Request.Context(), req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// getSnapdir returns whether the .zfs directory of a filesystem is listed
+func (h *DatasetHandler) getSnapdir(c *gin.Context) {
+	var req dataset.NameConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	status, err := h.manager.Snapdir(c.Request.Context(), req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": status})
+}
+
+// setSnapdir hides or shows the .zfs directory of a filesystem
+func (h *DatasetHandler) setSnapdir(c *gin.Context) {
+	var req dataset.SnapdirConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.SetSnapdir(c.Request.Context(), req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
 // Clone operations
 func (h *DatasetHandler) createClone(c *gin.Context) {
 	var req dataset.CloneConfig
@@ -283,6 +539,29 @@ func (h *DatasetHandler) renameDataset(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// renameDatasetManaged renames a dataset and points the policies and shares
+// referring to it at the new name, or reports what would change on a dry run
+func (h *DatasetHandler) renameDatasetManaged(c *gin.Context) {
+	var cfg resolver.RenameConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	pathResolver := managers.GetPathResolver()
+	if pathResolver == nil {
+		APIError(c, errors.New(errors.ServerInternalError, "path resolver not available"))
+		return
+	}
+
+	report, err := pathResolver.Rename(c.Request.Context(), cfg)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": report})
+}
+
 func (h *DatasetHandler) sendDataset(c *gin.Context) {
 	var req dataset.TransferConfig
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -382,7 +661,7 @@ func (h *DatasetHandler) listBookmarks(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, gin.H{"result": scopedList(c, result)})
 }
 
 // Promote clone
@@ -470,6 +749,55 @@ func (h *DatasetHandler) listPermissions(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"result": result})
 }
 
+// Delegation status of the service user
+func (h *DatasetHandler) delegationStatus(c *gin.Context) {
+	var req dataset.NameConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	result, err := h.manager.DelegationStatus(c.Request.Context(), req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+// Delegate operations to the service user
+func (h *DatasetHandler) delegate(c *gin.Context) {
+	var req dataset.DelegationConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.Delegate(c.Request.Context(), req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// Revoke operations from the service user
+func (h *DatasetHandler) undelegate(c *gin.Context) {
+	var req dataset.DelegationConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.Undelegate(c.Request.Context(), req); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
 // Share dataset
 func (h *DatasetHandler) shareDataset(c *gin.Context) {
 	var req dataset.ShareConfig
@@ -506,6 +834,27 @@ func (h *DatasetHandler) unshareDataset(c *gin.Context) {
 
 // Transfer management endpoints
 
+// transferGuardRequest describes a transfer to the guard rules. Its targets
+// are the receiving dataset and, for remote targets, host:dataset. Dry runs
+// have no operation, so they are not checked.
+func (h *DatasetHandler) transferGuardRequest(cfg dataset.TransferConfig) guard.Request {
+	if cfg.SendConfig.DryRun || cfg.ReceiveConfig.DryRun {
+		return guard.Request{}
+	}
+
+	targets := []string{cfg.ReceiveConfig.Target}
+	if host := cfg.ReceiveConfig.RemoteConfig.Host; host != "" {
+		targets = append(targets, host+":"+cfg.ReceiveConfig.Target)
+	}
+	return guard.Request{
+		Operation: guard.OpTransferStart,
+		Targets:   targets,
+		FreePercent: func() (float64, error) {
+			return h.transferManager.TargetPoolFreePercent(cfg)
+		},
+	}
+}
+
 func (h *DatasetHandler) startManagedTransfer(c *gin.Context) {
 	var req dataset.TransferConfig
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -513,6 +862,11 @@ func (h *DatasetHandler) startManagedTransfer(c *gin.Context) {
 		return
 	}
 
+	if err := guard.Check(c.Request.Context(), h.transferGuardRequest(req)); err != nil {
+		APIError(c, err)
+		return
+	}
+
 	transferID, err := h.transferManager.StartTransfer(c.Request.Context(), req)
 	if err != nil {
 		APIError(c, err)
@@ -546,7 +900,7 @@ func (h *DatasetHandler) listTransfers(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"result": gin.H{
-			"transfers": transfers,
+			"transfers": dataset.RedactTransfers(transfers),
 			"type":      transferType,
 			"count":     len(transfers),
 		},
@@ -566,7 +920,7 @@ func (h *DatasetHandler) getTransfer(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"transfer": transfer})
+	c.JSON(http.StatusOK, gin.H{"transfer": transfer.Redacted()})
 }
 
 func (h *DatasetHandler) pauseTransfer(c *gin.Context) {
@@ -635,6 +989,23 @@ func (h *DatasetHandler) deleteTransfer(c *gin.Context) {
 
 // Transfer Log Handlers
 
+// getTransferProgress returns the versioned progress document of a transfer
+func (h *DatasetHandler) getTransferProgress(c *gin.Context) {
+	transferID := c.Param("transferId")
+	if transferID == "" {
+		APIError(c, errors.New(errors.ServerBadRequest, "Transfer ID is required"))
+		return
+	}
+
+	progress, err := h.transferManager.GetTransferProgress(transferID)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": progress})
+}
+
 func (h *DatasetHandler) getTransferLog(c *gin.Context) {
 	transferID := c.Param("transferId")
 	if transferID == "" {
@@ -7,6 +7,7 @@ package api
 import (
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 	"github.com/stratastor/toggle-rodent-proto/proto"
 )
@@ -47,7 +48,16 @@ func handleDatasetDelete(h *DatasetHandler) client.CommandHandler {
 		}
 
 		// Create context for the request
-		ctx := createHandlerContext(req)
+		ctx := createGuardedContext(req, cmd)
+
+		if !destroyConfig.DryRun {
+			if err := guard.Check(ctx, guard.Request{
+				Operation: guard.OpDatasetDestroy,
+				Targets:   []string{destroyConfig.Name},
+			}); err != nil {
+				return nil, err
+			}
+		}
 
 		// Call the manager's Destroy method
 		result, err := h.manager.Destroy(ctx, destroyConfig)
@@ -787,7 +797,11 @@ func handleManagedTransferStart(h *DatasetHandler) client.CommandHandler {
 		}
 
 		// Create context for the request
-		ctx := createHandlerContext(req)
+		ctx := createGuardedContext(req, cmd)
+
+		if err := guard.Check(ctx, h.transferGuardRequest(transferConfig)); err != nil {
+			return nil, err
+		}
 
 		// Start the managed transfer
 		transferID, err := h.transferManager.StartTransfer(ctx, transferConfig)
@@ -834,7 +848,7 @@ func handleManagedTransferList(h *DatasetHandler) client.CommandHandler {
 		}
 		
 		result := map[string]interface{}{
-			"transfers": transfers,
+			"transfers": dataset.RedactTransfers(transfers),
 			"type":      transferType,
 			"count":     len(transfers),
 		}
@@ -862,7 +876,7 @@ func handleManagedTransferGet(h *DatasetHandler) client.CommandHandler {
 			return nil, err
 		}
 
-		return successResponse(req.RequestId, "Transfer details retrieved", transfer)
+		return successResponse(req.RequestId, "Transfer details retrieved", transfer.Redacted())
 	}
 }
 
@@ -27,6 +27,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/scope"
 	"github.com/stratastor/rodent/pkg/errors"
 	zfsCommon "github.com/stratastor/rodent/pkg/zfs/common"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
@@ -301,6 +302,16 @@ func ValidateZFSEntityName(dtype zfsCommon.DatasetType) gin.HandlerFunc {
 				}
 			}
 		}
+
+		// Delegated keys manage only the datasets they name and their children
+		if sc := scope.FromContext(c.Request.Context()); sc != nil {
+			for _, name := range names {
+				if !sc.AllowsDataset(name) {
+					APIError(c, sc.Denied(scope.KindDataset, name))
+					return
+				}
+			}
+		}
 		c.Next()
 	}
 }
@@ -18,10 +18,12 @@
 package api
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/zfs/pool"
 )
 
@@ -34,6 +36,23 @@ func NewPoolHandler(manager *pool.Manager) *PoolHandler {
 	return &PoolHandler{manager: manager}
 }
 
+// UseScanMonitor serves scrub and resilver progress from the scan monitor's
+// latest poll
+func (h *PoolHandler) UseScanMonitor(m *pool.ScanMonitor) {
+	h.scans.Store(m)
+}
+
+// UseCapacityMonitor bases capacity analytics on the capacity monitor's
+// history
+func (h *PoolHandler) UseCapacityMonitor(m *pool.CapacityMonitor) {
+	h.capacity.Store(m)
+}
+
+// UseIOStatSampler serves IO history from the IO sampler's kept samples
+func (h *PoolHandler) UseIOStatSampler(s *pool.IOStatSampler) {
+	h.sampler.Store(s)
+}
+
 func (h *PoolHandler) listPools(c *gin.Context) {
 	pools, err := h.manager.List(c.Request.Context())
 	if err != nil {
@@ -67,6 +86,14 @@ func (h *PoolHandler) destroyPool(c *gin.Context) {
 	name := c.Param("name")
 	force := c.Query("force") == "true"
 
+	if err := guard.Check(c.Request.Context(), guard.Request{
+		Operation: guard.OpPoolDestroy,
+		Targets:   []string{name},
+	}); err != nil {
+		APIError(c, err)
+		return
+	}
+
 	if err := h.manager.Destroy(c.Request.Context(), name, force); err != nil {
 		APIError(c, err)
 		return
@@ -107,9 +134,86 @@ func (h *PoolHandler) getPoolStatus(c *gin.Context) {
 		APIError(c, err)
 		return
 	}
+
+	h.addScanProgress(c.Request.Context(), status, name)
 	c.JSON(http.StatusOK, status)
 }
 
+// getScanProgress returns the progress of the pool's current or last scrub
+// or resilver
+func (h *PoolHandler) getScanProgress(c *gin.Context) {
+	name := c.Param("name")
+
+	scan, err := h.scanProgress(c.Request.Context(), name)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	if scan == nil {
+		APIError(c, errors.New(errors.ZFSPoolNotFound, "pool has no scrub or resilver").
+			WithMetadata("pool", name))
+		return
+	}
+	c.JSON(http.StatusOK, scan)
+}
+
+// getAnalytics returns the pool's capacity and fragmentation trends with the
+// recommended actions
+func (h *PoolHandler) getAnalytics(c *gin.Context) {
+	name := c.Param("name")
+
+	if capacity := h.capacity.Load(); capacity != nil {
+		analytics, err := capacity.Analytics(c.Request.Context(), name)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, analytics)
+		return
+	}
+
+	// Without a monitor there is no history to compute trends from
+	samples, err := h.manager.CapacitySamples(c.Request.Context())
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	sample, ok := samples[name]
+	if !ok {
+		APIError(c, errors.New(errors.ZFSPoolNotFound, "pool not found").
+			WithMetadata("pool", name))
+		return
+	}
+	c.JSON(http.StatusOK, pool.AnalyzeCapacity(name, []pool.CapacitySample{sample}))
+}
+
+// addScanProgress adds scrub or resilver progress to a pool's status. It is
+// best effort; the status stands on its own.
+func (h *PoolHandler) addScanProgress(ctx context.Context, status pool.PoolStatus, name string) {
+	p, ok := status.Pools[name]
+	if !ok {
+		return
+	}
+	if scan, err := h.scanProgress(ctx, name); err == nil {
+		p.ScanProgress = scan
+		status.Pools[name] = p
+	}
+}
+
+// scanProgress reads scan progress from the scan monitor when one is set, or
+// from zpool status otherwise
+func (h *PoolHandler) scanProgress(ctx context.Context, name string) (*pool.ScanProgress, error) {
+	if scans := h.scans.Load(); scans != nil {
+		return scans.Progress(ctx, name)
+	}
+
+	progress, err := h.manager.ScanProgress(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return progress[name], nil
+}
+
 func (h *PoolHandler) getProperties(c *gin.Context) {
 	name := c.Param("name")
 
@@ -288,6 +392,81 @@ func (h *PoolHandler) addVDevs(c *gin.Context) {
 	c.Status(http.StatusOK)
 }
 
+// bindAuxVDevs binds a request adding auxiliary vdevs to the pool of the
+// path, checking its device paths as pool creation does
+func bindAuxVDevs(c *gin.Context) (pool.AuxVDevConfig, bool) {
+	var cfg pool.AuxVDevConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return cfg, false
+	}
+	cfg.Name = c.Param("name")
+
+	if len(cfg.Devices) > maxDevicePaths {
+		APIError(c, errors.New(errors.ZFSPoolTooManyDevices, "Too many devices specified"))
+		return cfg, false
+	}
+	for _, device := range cfg.Devices {
+		if !devicePathRegex.MatchString(device) {
+			APIError(c, errors.New(errors.ZFSPoolInvalidDevice, "Invalid device path"))
+			return cfg, false
+		}
+		if restrictedDevices[device] {
+			APIError(c, errors.New(errors.ZFSPoolRestrictedDevice, "Device not allowed"))
+			return cfg, false
+		}
+	}
+	return cfg, true
+}
+
+// validateAuxVDevs checks log, cache, special or dedup vdevs before they are
+// added, with warnings and suggestions for their layout
+func (h *PoolHandler) validateAuxVDevs(c *gin.Context) {
+	cfg, ok := bindAuxVDevs(c)
+	if !ok {
+		return
+	}
+
+	validation, err := h.manager.ValidateAuxVDevs(c.Request.Context(), cfg)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": validation})
+}
+
+// addAuxVDevs adds log, cache, special or dedup vdevs that pass validation
+func (h *PoolHandler) addAuxVDevs(c *gin.Context) {
+	cfg, ok := bindAuxVDevs(c)
+	if !ok {
+		return
+	}
+
+	validation, err := h.manager.AddAuxVDevs(c.Request.Context(), cfg)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": validation})
+}
+
+// removeAuxVDev removes a log, cache, special or dedup vdev
+func (h *PoolHandler) removeAuxVDev(c *gin.Context) {
+	var req struct {
+		VDev string `json:"vdev" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := h.manager.RemoveAuxVDev(c.Request.Context(), c.Param("name"), req.VDev); err != nil {
+		APIError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
 func (h *PoolHandler) clearErrors(c *gin.Context) {
 	poolName := c.Param("name")
 	var cfg pool.ClearConfig
@@ -471,6 +650,24 @@ func (h *PoolHandler) iostat(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"result": gin.H{"iostat": result}})
 }
 
+func (h *PoolHandler) getIOStatHistory(c *gin.Context) {
+	name := c.Param("name")
+
+	sampler := h.sampler.Load()
+	if sampler == nil {
+		APIError(c, errors.New(errors.ServerInternalError, "pool IO sampling is not running"))
+		return
+	}
+
+	history, ok := sampler.History(name)
+	if !ok {
+		APIError(c, errors.New(errors.ZFSPoolNotFound, "no IO samples for pool").
+			WithMetadata("pool", name))
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
 func (h *PoolHandler) wait(c *gin.Context) {
 	poolName := c.Param("name")
 	var cfg pool.WaitConfig
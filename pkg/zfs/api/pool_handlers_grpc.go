@@ -7,6 +7,7 @@ package api
 import (
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/zfs/pool"
 	"github.com/stratastor/toggle-rodent-proto/proto"
 )
@@ -102,6 +103,7 @@ func handlePoolStatus(h *PoolHandler) client.CommandHandler {
 			return nil, errors.Wrap(err, errors.ZFSPoolStatus)
 		}
 
+		h.addScanProgress(ctx, status, nameParam.Name)
 		return successPoolResponse(req.RequestId, "Pool status", status)
 	}
 }
@@ -156,7 +158,14 @@ func handlePoolDestroy(h *PoolHandler) client.CommandHandler {
 		}
 
 		// Create context for the request
-		ctx := createHandlerContext(req)
+		ctx := createGuardedContext(req, cmd)
+
+		if err := guard.Check(ctx, guard.Request{
+			Operation: guard.OpPoolDestroy,
+			Targets:   []string{destroyParam.Name},
+		}); err != nil {
+			return nil, err
+		}
 
 		// Call the manager's Destroy method
 		err := h.manager.Destroy(ctx, destroyParam.Name, destroyParam.Force)
@@ -0,0 +1,35 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stratastor/rodent/pkg/zfs/retention"
+)
+
+// RegisterRetentionRoutes registers the snapshot retention and compliance routes
+func RegisterRetentionRoutes(
+	router *gin.RouterGroup,
+	datasetManager *dataset.Manager,
+) (*retention.Handler, error) {
+	cfg := config.GetConfig()
+	logCfg := logger.Config{LogLevel: cfg.Server.LogLevel}
+	retentionManager, err := retention.GetManager(datasetManager, logCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	handler := retention.NewHandlerWithManager(retentionManager)
+	if err := handler.StartManager(); err != nil {
+		return nil, err
+	}
+
+	handler.RegisterRoutes(router)
+
+	return handler, nil
+}
@@ -14,6 +14,9 @@ import (
 // and may lead to confusion. Hence, we will pass information in the body
 // to keep the URI clean and simple.
 func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
+	// Snapshot queries by tag, e.g. ?tag=policy_id=<id>
+	router.GET("/snapshots", h.listSnapshotsByTags)
+
 	dataset := router.Group("/dataset")
 	{
 		// TODO: Add appropriate validation middlewares
@@ -30,10 +33,18 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 			ValidateZFSEntityName(common.TypeDatasetMask),
 			h.renameDataset)
 
+		// Rename that carries policies and shares along
+		dataset.POST("/rename/managed",
+			ValidateZFSEntityName(common.TypeDatasetMask),
+			h.renameDatasetManaged)
+
 		dataset.POST("/diff",
 			ValidateDiffConfig(),
 			h.diffDataset)
 
+		// Path to dataset resolution
+		dataset.POST("/resolve", h.resolveDataset)
+
 		// Property operations
 		properties := dataset.Group("/properties",
 			ValidateZFSEntityName(common.TypeZFSEntityMask))
@@ -78,6 +89,14 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 				ValidateZFSEntityName(common.TypeFilesystem),
 				h.unmountDataset)
 
+			// Visibility of the .zfs snapshot directory
+			filesystem.POST("/snapdir/fetch",
+				ValidateZFSEntityName(common.TypeFilesystem),
+				h.getSnapdir)
+			filesystem.POST("/snapdir",
+				ValidateZFSEntityName(common.TypeFilesystem),
+				h.setSnapdir)
+
 		}
 
 		// Volume operations
@@ -92,6 +111,22 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 				ValidateBlockSize(),
 				ValidateZFSProperties(),
 				h.createVolume)
+
+			volume.POST("/info",
+				ValidateZFSEntityName(common.TypeVolume),
+				h.getVolume)
+			volume.POST("/consumers",
+				ValidateZFSEntityName(common.TypeVolume),
+				h.listVolumeConsumers)
+			volume.POST("/resize",
+				ValidateZFSEntityName(common.TypeVolume),
+				ValidateVolumeSize(),
+				h.resizeVolume)
+			volume.POST("/clone",
+				ValidateZFSEntityName(common.TypeSnapshot),
+				ValidateCloneConfig(),
+				ValidateZFSProperties(),
+				h.cloneVolume)
 		}
 
 		// Snapshot operations
@@ -109,6 +144,14 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 			snapshot.POST("/rollback",
 				ValidateZFSEntityName(common.TypeSnapshot),
 				h.rollbackSnapshot)
+
+			// Pinned snapshots are kept by retention
+			snapshot.POST("/pin",
+				ValidateZFSEntityName(common.TypeSnapshot),
+				h.pinSnapshot)
+			snapshot.DELETE("/pin",
+				ValidateZFSEntityName(common.TypeSnapshot),
+				h.unpinSnapshot)
 		}
 
 		// Clone operations
@@ -121,7 +164,7 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 				h.createClone)
 
 			clone.POST("/promote",
-				ValidateZFSEntityName(common.TypeFilesystem),
+				ValidateZFSEntityName(common.TypeFilesystem|common.TypeVolume),
 				h.promoteClone)
 		}
 
@@ -148,6 +191,11 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 			permissions.DELETE("",
 				ValidateUnallowConfig(),
 				h.unallowPermissions)
+
+			// Delegation of operations to the service user
+			permissions.POST("/delegation/status", h.delegationStatus)
+			permissions.POST("/delegation", h.delegate)
+			permissions.DELETE("/delegation", h.undelegate)
 		}
 
 		// Share operations
@@ -162,12 +210,29 @@ func (h *DatasetHandler) RegisterRoutes(router *gin.RouterGroup) {
 		{
 			// Managed transfer operations
 			transfer.POST("/start", h.startManagedTransfer)
+
+			// Seeds carry the initial full send on removable media
+			transfer.POST("/seed/export", h.exportSeed)
+			transfer.POST("/seed/import", h.importSeed)
+			transfer.GET("/seed", h.getSeed)
+
+			// Streams carry sends and receives over HTTP for external orchestrators
+			transfer.GET("/streams", h.listStreams)
+			transfer.POST("/streams/send", h.openSendStream)
+			transfer.POST("/streams/receive", h.openReceiveStream)
+			transfer.GET("/streams/:streamId", h.getStream)
+			transfer.GET("/streams/:streamId/data", h.downloadStream)
+			transfer.PUT("/streams/:streamId/data", h.uploadStream)
+			transfer.POST("/streams/:streamId/complete", h.completeReceiveStream)
+			transfer.DELETE("/streams/:streamId", h.closeStream)
+
 			transfer.GET("/list", h.listTransfers)
 			transfer.GET("/:transferId", h.getTransfer)
 			transfer.POST("/:transferId/pause", h.pauseTransfer)
 			transfer.POST("/:transferId/resume", h.resumeTransfer)
 			transfer.POST("/:transferId/stop", h.stopTransfer)
 			transfer.DELETE("/:transferId", h.deleteTransfer)
+			transfer.GET("/:transferId/progress", h.getTransferProgress)
 
 			// Transfer log operations
 			transfer.GET("/:transferId/log", h.getTransferLog)
@@ -209,6 +274,8 @@ func (h *PoolHandler) RegisterRoutes(router *gin.RouterGroup) {
 
 		// Status and properties
 		pools.GET("/:name/status", ValidatePoolName(), h.getPoolStatus)
+		pools.GET("/:name/scan", ValidatePoolName(), h.getScanProgress)
+		pools.GET("/:name/analytics", ValidatePoolName(), h.getAnalytics)
 		pools.GET("/:name/properties",
 			ValidatePoolName(),
 			h.getProperties)
@@ -237,6 +304,7 @@ func (h *PoolHandler) RegisterRoutes(router *gin.RouterGroup) {
 		pools.GET("/:name/history", ValidatePoolName(), h.history)
 		pools.GET("/:name/events", ValidatePoolName(), h.events)
 		pools.GET("/:name/iostat", ValidatePoolName(), h.iostat)
+		pools.GET("/:name/iostat/history", ValidatePoolName(), h.getIOStatHistory)
 
 		// Advanced operations
 		pools.POST("/:name/split", ValidatePoolName(), h.split)
@@ -247,6 +315,11 @@ func (h *PoolHandler) RegisterRoutes(router *gin.RouterGroup) {
 		pools.POST("/:name/initialize", ValidatePoolName(), h.initializeDevices)
 		pools.POST("/:name/trim", ValidatePoolName(), h.trimDevices)
 
+		// Log, cache, special and dedup vdevs
+		pools.POST("/:name/auxiliary/validate", ValidatePoolName(), h.validateAuxVDevs)
+		pools.POST("/:name/auxiliary", ValidatePoolName(), h.addAuxVDevs)
+		pools.POST("/:name/auxiliary/remove", ValidatePoolName(), h.removeAuxVDev)
+
 		// Device operations
 		devices := pools.Group("/:name/devices", ValidatePoolName())
 		{
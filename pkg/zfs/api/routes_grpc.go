@@ -11,6 +11,7 @@ import (
 
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/toggle-rodent-proto/proto"
 )
 
@@ -145,6 +146,12 @@ func createHandlerContext(req *proto.ToggleRequest) context.Context {
 	return ctx
 }
 
+// createGuardedContext is createHandlerContext for commands checked by guard
+// rules, carrying the confirmation or override token of the payload
+func createGuardedContext(req *proto.ToggleRequest, cmd *proto.CommandRequest) context.Context {
+	return guard.WithApproval(createHandlerContext(req), guard.ApprovalFromPayload(cmd.Payload))
+}
+
 // Helper to create a successful response with JSON payload
 func successResponse(
 	requestID string,
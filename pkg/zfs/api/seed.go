@@ -0,0 +1,75 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stratastor/rodent/internal/constants"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// exportSeed starts writing a snapshot's full send stream to removable media
+func (h *DatasetHandler) exportSeed(c *gin.Context) {
+	var req dataset.SeedExportConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	jobID, err := h.transferManager.StartSeedExport(req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	seedAccepted(c, jobID)
+}
+
+// importSeed starts receiving a seed from removable media. It is checked
+// against the guard rules like a transfer into the target.
+func (h *DatasetHandler) importSeed(c *gin.Context) {
+	var req dataset.SeedImportConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if err := guard.Check(c.Request.Context(), guard.Request{
+		Operation: guard.OpTransferStart,
+		Targets:   []string{req.Target},
+	}); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	jobID, err := h.transferManager.StartSeedImport(req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	seedAccepted(c, jobID)
+}
+
+// getSeed returns the manifest of the seed at the path query parameter,
+// including the chunks an export has written so far
+func (h *DatasetHandler) getSeed(c *gin.Context) {
+	manifest, err := h.transferManager.GetSeed(c.Query("path"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": manifest})
+}
+
+// seedAccepted points the client at the job running a seed
+func seedAccepted(c *gin.Context, jobID string) {
+	location := constants.APIJobs + "/" + jobID
+	c.Header("Location", location)
+	c.JSON(http.StatusAccepted, gin.H{"job_id": jobID, "location": location})
+}
@@ -0,0 +1,251 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// listStreams returns the open stream sessions
+func (h *DatasetHandler) listStreams(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"result": h.transferManager.ListStreams()})
+}
+
+// openSendStream opens a session serving a snapshot's send stream
+func (h *DatasetHandler) openSendStream(c *gin.Context) {
+	var req dataset.SendConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	session, err := h.transferManager.OpenSendStream(c.Request.Context(), req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"result": session})
+}
+
+// openReceiveStream opens a session receiving an uploaded stream. It is
+// checked against the guard rules like a transfer into the target.
+func (h *DatasetHandler) openReceiveStream(c *gin.Context) {
+	var req dataset.ReceiveConfig
+	if err := c.ShouldBindJSON(&req); err != nil {
+		APIError(c, errors.New(errors.ServerRequestValidation, err.Error()))
+		return
+	}
+
+	if !req.DryRun {
+		if err := guard.Check(c.Request.Context(), guard.Request{
+			Operation: guard.OpTransferStart,
+			Targets:   []string{req.Target},
+		}); err != nil {
+			APIError(c, err)
+			return
+		}
+	}
+
+	session, err := h.transferManager.OpenReceiveStream(c.Request.Context(), req)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"result": session})
+}
+
+// getStream returns a stream session
+func (h *DatasetHandler) getStream(c *gin.Context) {
+	session, err := h.transferManager.GetStream(c.Param("streamId"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": session})
+}
+
+// downloadStream serves a send stream. Without a Range header the whole
+// stream is sent; with one, a single range of up to MaxStreamChunk bytes.
+// An If-Range that does not match the session's ETag gets the whole stream.
+func (h *DatasetHandler) downloadStream(c *gin.Context) {
+	id := c.Param("streamId")
+	session, err := h.transferManager.GetStream(id)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	if session.Kind != dataset.StreamKindSend {
+		APIError(c, errors.New(errors.ServerRequestValidation, "Only send streams can be downloaded"))
+		return
+	}
+
+	c.Header("Accept-Ranges", "bytes")
+	c.Header("ETag", `"`+session.ETag+`"`)
+
+	rangeHeader := c.GetHeader("Range")
+	if ifRange := c.GetHeader("If-Range"); ifRange != "" && ifRange != `"`+session.ETag+`"` {
+		rangeHeader = ""
+	}
+	if rangeHeader == "" {
+		c.Header("Content-Type", "application/octet-stream")
+		c.Status(http.StatusOK)
+		if _, err := h.transferManager.CopySendStream(id, 0, c.Writer); err != nil && !c.Writer.Written() {
+			APIError(c, err)
+		}
+		return
+	}
+
+	start, end, err := parseByteRange(rangeHeader)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	data, session, err := h.transferManager.ReadSendStream(id, start, rangeLength(start, end))
+	if err != nil {
+		if re, ok := err.(*errors.RodentError); ok && re.Metadata["total_bytes"] != "" {
+			c.Header("Content-Range", "bytes */"+re.Metadata["total_bytes"])
+		}
+		APIError(c, err)
+		return
+	}
+
+	total := "*"
+	if session.TotalBytes != nil {
+		total = strconv.FormatInt(*session.TotalBytes, 10)
+	}
+	c.Header("Content-Range", fmt.Sprintf("bytes %d-%d/%s", start, start+int64(len(data))-1, total))
+	c.Data(http.StatusPartialContent, "application/octet-stream", data)
+}
+
+// uploadStream writes a chunk of an uploaded stream into its receive. The
+// chunk's place is given by Content-Range; a known total finishes the
+// receive once reached. Without Content-Range the body continues the stream
+// at the session's offset.
+func (h *DatasetHandler) uploadStream(c *gin.Context) {
+	id := c.Param("streamId")
+
+	offset, total := int64(-1), int64(-1)
+	if header := c.GetHeader("Content-Range"); header != "" {
+		var err error
+		if offset, total, err = parseContentRange(header); err != nil {
+			APIError(c, err)
+			return
+		}
+	} else {
+		session, err := h.transferManager.GetStream(id)
+		if err != nil {
+			APIError(c, err)
+			return
+		}
+		offset = session.Offset
+	}
+
+	session, err := h.transferManager.WriteReceiveStream(c.Request.Context(), id, offset, total, c.Request.Body)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": session})
+}
+
+// completeReceiveStream ends an uploaded stream and waits for its receive
+func (h *DatasetHandler) completeReceiveStream(c *gin.Context) {
+	session, err := h.transferManager.CompleteReceiveStream(c.Request.Context(), c.Param("streamId"))
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": session})
+}
+
+// closeStream stops a stream session
+func (h *DatasetHandler) closeStream(c *gin.Context) {
+	if err := h.transferManager.CloseStream(c.Param("streamId")); err != nil {
+		APIError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseByteRange parses a Range header of a single range, "bytes=N-M" or
+// "bytes=N-". The end is -1 for an open range.
+func parseByteRange(header string) (start, end int64, err error) {
+	invalid := errors.New(errors.StreamRangeUnsatisfied,
+		"Range must be a single range of bytes from an offset").
+		WithMetadata("range", header)
+
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, invalid
+	}
+	first, last, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, invalid
+	}
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil || start < 0 {
+		return 0, 0, invalid
+	}
+	if last == "" {
+		return start, -1, nil
+	}
+	if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+		return 0, 0, invalid
+	}
+	return start, end, nil
+}
+
+// rangeLength returns the bytes to read for a range parsed by
+// parseByteRange, at most MaxStreamChunk. The span is compared before adding
+// one, so a range ending at the largest offset cannot overflow.
+func rangeLength(start, end int64) int64 {
+	if end < 0 || end-start >= dataset.MaxStreamChunk {
+		return dataset.MaxStreamChunk
+	}
+	return end - start + 1
+}
+
+// parseContentRange parses an upload's Content-Range, "bytes N-M/T" or
+// "bytes N-M/*". The total is -1 when unknown.
+func parseContentRange(header string) (offset, total int64, err error) {
+	invalid := errors.New(errors.ServerRequestValidation,
+		`Content-Range must be "bytes <first>-<last>/<total or *>"`).
+		WithMetadata("content_range", header)
+
+	spec, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, invalid
+	}
+	span, size, ok := strings.Cut(spec, "/")
+	if !ok {
+		return 0, 0, invalid
+	}
+	first, last, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, invalid
+	}
+	offset, err = strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, invalid
+	}
+	if end, err := strconv.ParseInt(last, 10, 64); err != nil || end < offset {
+		return 0, 0, invalid
+	}
+	if size == "*" {
+		return offset, -1, nil
+	}
+	if total, err = strconv.ParseInt(size, 10, 64); err != nil || total <= offset {
+		return 0, 0, invalid
+	}
+	return offset, total, nil
+}
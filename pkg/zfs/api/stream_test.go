@@ -0,0 +1,87 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+func TestParseByteRange(t *testing.T) {
+	tests := []struct {
+		header     string
+		start, end int64
+		wantErr    bool
+	}{
+		{header: "bytes=0-1023", start: 0, end: 1023},
+		{header: "bytes=4096-", start: 4096, end: -1},
+		{header: "bytes=-500", wantErr: true},
+		{header: "bytes=0-10,20-30", wantErr: true},
+		{header: "bytes=10-5", wantErr: true},
+		{header: "items=0-10", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		start, end, err := parseByteRange(tt.header)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseByteRange(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (start != tt.start || end != tt.end) {
+			t.Errorf("parseByteRange(%q) = %d, %d, want %d, %d", tt.header, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestRangeLength(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int64
+	}{
+		{header: "bytes=0-1023", want: 1024},
+		{header: "bytes=5-5", want: 1},
+		{header: "bytes=4096-", want: dataset.MaxStreamChunk},
+		{header: "bytes=0-9223372036854775807", want: dataset.MaxStreamChunk},
+		{header: "bytes=9223372036854775806-9223372036854775807", want: 2},
+	}
+
+	for _, tt := range tests {
+		start, end, err := parseByteRange(tt.header)
+		if err != nil {
+			t.Errorf("parseByteRange(%q) error = %v", tt.header, err)
+			continue
+		}
+		if got := rangeLength(start, end); got != tt.want {
+			t.Errorf("rangeLength(%q) = %d, want %d", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		header        string
+		offset, total int64
+		wantErr       bool
+	}{
+		{header: "bytes 0-1023/4096", offset: 0, total: 4096},
+		{header: "bytes 1024-2047/*", offset: 1024, total: -1},
+		{header: "bytes */4096", wantErr: true},
+		{header: "bytes 10-5/100", wantErr: true},
+		{header: "bytes 100-199/100", wantErr: true},
+		{header: "bytes=0-10/20", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		offset, total, err := parseContentRange(tt.header)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseContentRange(%q) error = %v, wantErr %v", tt.header, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (offset != tt.offset || total != tt.total) {
+			t.Errorf("parseContentRange(%q) = %d, %d, want %d, %d", tt.header, offset, total, tt.offset, tt.total)
+		}
+	}
+}
@@ -18,6 +18,8 @@
 package api
 
 import (
+	"sync/atomic"
+
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 	"github.com/stratastor/rodent/pkg/zfs/pool"
 )
@@ -47,7 +49,10 @@ type DatasetHandler struct {
 //
 // All operations use proper validation and error handling.
 type PoolHandler struct {
-	manager *pool.Manager
+	manager  *pool.Manager
+	scans    atomic.Pointer[pool.ScanMonitor]
+	capacity atomic.Pointer[pool.CapacityMonitor]
+	sampler  atomic.Pointer[pool.IOStatSampler]
 }
 
 // Response types match dataset package types
@@ -0,0 +1,34 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// RegisterVersionRoutes registers the route reporting the installed OpenZFS
+// versions and the features Rodent can use with them
+func RegisterVersionRoutes(router *gin.RouterGroup, executor *command.CommandExecutor) {
+	router.GET("/version", func(c *gin.Context) {
+		info := command.CurrentVersion()
+		if info == nil {
+			// Detection failed at startup; the module may have been loaded since
+			var err error
+			info, err = executor.DetectVersion(c.Request.Context())
+			if err != nil {
+				APIError(c, err)
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"result": gin.H{
+			"version":       info,
+			"compatibility": command.CompatibilityMatrix,
+		}})
+	})
+}
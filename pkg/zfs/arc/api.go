@@ -0,0 +1,143 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package arc
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Handler handles HTTP requests for ARC statistics and configuration
+type Handler struct {
+	monitor *Monitor
+	config  *ConfigManager
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewHandler creates an ARC handler
+func NewHandler(monitor *Monitor, config *ConfigManager) *Handler {
+	return &Handler{monitor: monitor, config: config}
+}
+
+// Monitor returns the ARC monitor for use by other subsystems
+func (h *Handler) Monitor() *Monitor {
+	return h.monitor
+}
+
+// RegisterRoutes registers HTTP routes for ARC statistics and configuration
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	arc := router.Group("/arc")
+	{
+		arc.GET("/stats", h.getStats)
+		arc.GET("/history", h.getHistory)
+		arc.GET("/config", h.getConfig)
+		arc.PUT("/config", h.setConfig)
+	}
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *Handler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *Handler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  string(errors.DomainZFS),
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// getStats returns the current ARC statistics
+func (h *Handler) getStats(c *gin.Context) {
+	stats, err := ReadStats()
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, stats)
+}
+
+// getHistory returns the current ARC statistics with the kept samples
+func (h *Handler) getHistory(c *gin.Context) {
+	history, err := h.monitor.History()
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, history)
+}
+
+// getConfig returns the configured ARC size limit
+func (h *Handler) getConfig(c *gin.Context) {
+	cfg, err := h.config.GetConfig(c.Request.Context())
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cfg)
+}
+
+// setConfig sets the ARC size limit the ZFS module is loaded with
+func (h *Handler) setConfig(c *gin.Context) {
+	var req SetConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.ARCInvalidConfig))
+		return
+	}
+
+	cfg, err := h.config.SetARCMax(c.Request.Context(), *req.ARCMax)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cfg)
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package arc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+func TestParseKstat(t *testing.T) {
+	kstat, err := parseKstat(strings.NewReader(`13 1 0x01 147 39984 4223418471 92347891731654
+name                            type data
+hits                            4    900
+misses                          4    100
+size                            4    2147483648
+c                               4    3221225472
+c_min                           4    268435456
+c_max                           4    4294967296
+mfu_size                        4    1073741824
+mru_size                        4    536870912
+l2_hits                         4    30
+l2_misses                       4    70
+l2_size                         4    10737418240
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := newStats(kstat)
+	if stats.Size != 2147483648 || stats.TargetSize != 3221225472 || stats.MaxSize != 4294967296 {
+		t.Errorf("unexpected sizes: %+v", stats)
+	}
+	if stats.HitRatio != 90 || stats.L2HitRatio != 30 {
+		t.Errorf("hit ratios = %v, %v; want 90, 30", stats.HitRatio, stats.L2HitRatio)
+	}
+}
+
+func TestNewSample(t *testing.T) {
+	at := time.Unix(1_700_000_000, 0)
+	prev := &Stats{Hits: 1000, Misses: 500, L2Hits: 10, L2Misses: 10}
+
+	// Ratios cover only the lookups since the previous read
+	s := newSample(at, prev, &Stats{Size: 42, Hits: 1075, Misses: 525})
+	if s.HitRatio != 75 || s.L2HitRatio != 0 || s.Size != 42 {
+		t.Errorf("unexpected sample: %+v", s)
+	}
+
+	// Counters reset by a module reload count from zero
+	s = newSample(at, prev, &Stats{Hits: 20, Misses: 80})
+	if s.HitRatio != 20 {
+		t.Errorf("hit ratio after reset = %v, want 20", s.HitRatio)
+	}
+}
+
+func TestValidateARCMax(t *testing.T) {
+	const gib = 1 << 30
+	tests := []struct {
+		name    string
+		arcMax  uint64
+		minSize uint64
+		valid   bool
+	}{
+		{"within memory", 4 * gib, 256 << 20, true},
+		{"below ZFS minimum", 32 << 20, 0, false},
+		{"at ARC minimum", gib, gib, false},
+		{"at system memory", 16 * gib, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateARCMax(tt.arcMax, 16*gib, tt.minSize)
+			if tt.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tt.valid {
+				if rerr, ok := err.(*errors.RodentError); !ok || rerr.Code != errors.ARCInvalidConfig {
+					t.Errorf("expected an invalid config error, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseModprobe(t *testing.T) {
+	if got := parseModprobe(renderModprobe(8 << 30)); got != 8<<30 {
+		t.Errorf("round trip = %d, want %d", got, uint64(8<<30))
+	}
+	if got := parseModprobe("options zfs zfs_arc_min=1073741824\n"); got != 0 {
+		t.Errorf("arc_max without the option = %d, want 0", got)
+	}
+	if got := parseModprobe("options zfs zfs_arc_max=1\noptions zfs zfs_arc_max=2147483648\n"); got != 2<<30 {
+		t.Errorf("the last option should win, got %d", got)
+	}
+}
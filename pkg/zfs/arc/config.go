@@ -0,0 +1,184 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package arc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/system/privilege"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// ModprobePath is the modprobe.d file Rodent manages the ZFS module's
+	// ARC size limit in
+	ModprobePath = "/etc/modprobe.d/zfs-arc.conf"
+
+	// runtimeARCMaxPath is the zfs_arc_max of the loaded module
+	runtimeARCMaxPath = "/sys/module/zfs/parameters/zfs_arc_max"
+
+	meminfoPath = "/proc/meminfo"
+
+	// MinARCMax is the smallest limit ZFS accepts; it ignores lower ones
+	MinARCMax = 64 << 20
+)
+
+// ConfigManager manages the ARC size limit the ZFS module is loaded with
+type ConfigManager struct {
+	logger  logger.Logger
+	fileOps privilege.FileOperations
+	mu      sync.Mutex
+}
+
+// NewConfigManager creates a config manager writing the modprobe.d file with
+// fileOps
+func NewConfigManager(l logger.Logger, fileOps privilege.FileOperations) *ConfigManager {
+	return &ConfigManager{logger: l, fileOps: fileOps}
+}
+
+// GetConfig returns the configured ARC size limit and the one the ZFS module
+// runs with
+func (m *ConfigManager) GetConfig(ctx context.Context) (*Config, error) {
+	cfg := &Config{Path: ModprobePath}
+
+	data, err := os.ReadFile(ModprobePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, errors.ConfigLoadFailed).WithMetadata("path", ModprobePath)
+	}
+	cfg.ARCMax = parseModprobe(string(data))
+
+	if data, err := os.ReadFile(runtimeARCMaxPath); err == nil {
+		cfg.RuntimeARCMax, _ = strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	}
+	if stats, err := ReadStats(); err == nil {
+		cfg.EffectiveMax = stats.MaxSize
+	}
+	if memory, err := memoryBytes(); err == nil {
+		cfg.MemoryBytes = memory
+	}
+
+	cfg.RebootRequired = cfg.ARCMax != cfg.RuntimeARCMax
+	return cfg, nil
+}
+
+// SetARCMax sets the ARC size limit the ZFS module is loaded with, or
+// restores the ZFS default when arcMax is 0. The loaded module keeps its
+// limit until it is reloaded, which the returned config signals.
+func (m *ConfigManager) SetARCMax(ctx context.Context, arcMax uint64) (*Config, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if arcMax == 0 {
+		exists, err := m.fileOps.Exists(ctx, ModprobePath)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ARCConfigWriteFailed).
+				WithMetadata("path", ModprobePath)
+		}
+		if exists {
+			if err := m.fileOps.DeleteFile(ctx, ModprobePath); err != nil {
+				return nil, errors.Wrap(err, errors.ARCConfigWriteFailed).
+					WithMetadata("path", ModprobePath)
+			}
+		}
+		m.logger.Info("Removed ARC size limit", "path", ModprobePath)
+		return m.GetConfig(ctx)
+	}
+
+	memory, err := memoryBytes()
+	if err != nil {
+		return nil, err
+	}
+	var minSize uint64
+	if stats, err := ReadStats(); err == nil {
+		minSize = stats.MinSize
+	}
+	if err := validateARCMax(arcMax, memory, minSize); err != nil {
+		return nil, err
+	}
+
+	if err := m.fileOps.WriteFile(ctx, ModprobePath, []byte(renderModprobe(arcMax)), 0644); err != nil {
+		return nil, errors.Wrap(err, errors.ARCConfigWriteFailed).
+			WithMetadata("path", ModprobePath)
+	}
+	m.logger.Info("Set ARC size limit", "arc_max", arcMax, "path", ModprobePath)
+	return m.GetConfig(ctx)
+}
+
+// validateARCMax checks a limit against the ZFS minimum, the ARC's minimum
+// size and the system memory
+func validateARCMax(arcMax, memory, minSize uint64) error {
+	limit := strconv.FormatUint(arcMax, 10)
+	switch {
+	case arcMax < MinARCMax:
+		return errors.New(errors.ARCInvalidConfig,
+			fmt.Sprintf("ARC size limit must be at least %d bytes", MinARCMax)).
+			WithMetadata("arc_max", limit)
+	case minSize > 0 && arcMax <= minSize:
+		return errors.New(errors.ARCInvalidConfig,
+			"ARC size limit must be above the ARC minimum size").
+			WithMetadata("arc_max", limit).
+			WithMetadata("min_size", strconv.FormatUint(minSize, 10))
+	case arcMax >= memory:
+		return errors.New(errors.ARCInvalidConfig,
+			"ARC size limit must be below the system memory").
+			WithMetadata("arc_max", limit).
+			WithMetadata("memory", strconv.FormatUint(memory, 10))
+	}
+	return nil
+}
+
+// renderModprobe returns the managed modprobe.d file setting arcMax
+func renderModprobe(arcMax uint64) string {
+	return "# Managed by Rodent; changes made here are overwritten\n" +
+		fmt.Sprintf("options zfs zfs_arc_max=%d\n", arcMax)
+}
+
+// parseModprobe returns the zfs_arc_max set in a modprobe.d file, 0 when it
+// is not set
+func parseModprobe(data string) uint64 {
+	var arcMax uint64
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "options" || fields[1] != "zfs" {
+			continue
+		}
+		for _, option := range fields[2:] {
+			if value, ok := strings.CutPrefix(option, "zfs_arc_max="); ok {
+				arcMax, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	return arcMax
+}
+
+// memoryBytes returns the system memory from /proc/meminfo
+func memoryBytes() (uint64, error) {
+	f, err := os.Open(meminfoPath)
+	if err != nil {
+		return 0, errors.Wrap(err, errors.ARCStatsUnavailable).WithMetadata("path", meminfoPath)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				break
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, errors.New(errors.CommandOutputParse, "MemTotal not found").
+		WithMetadata("path", meminfoPath)
+}
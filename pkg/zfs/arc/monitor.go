@@ -0,0 +1,182 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package arc
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/metrics"
+)
+
+const (
+	// sampleInterval is how often the ARC statistics are sampled
+	sampleInterval = time.Minute
+
+	// maxSamples bounds the kept samples, a day at the sampling interval
+	maxSamples = 24 * 60
+)
+
+// Monitor samples the ARC statistics at intervals and keeps a day of samples
+// for the API and Prometheus
+type Monitor struct {
+	logger logger.Logger
+
+	// read is ReadStats, replaced in tests
+	read func() (*Stats, error)
+
+	mu      sync.RWMutex
+	last    *Stats
+	samples []Sample
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewMonitor creates an ARC monitor; call Start to begin sampling
+func NewMonitor(l logger.Logger) *Monitor {
+	return &Monitor{
+		logger: l,
+		read:   ReadStats,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Start samples in the background until Stop is called
+func (m *Monitor) Start() {
+	go m.run()
+}
+
+// Stop ends sampling
+func (m *Monitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// History returns fresh ARC statistics with the kept samples
+func (m *Monitor) History() (*History, error) {
+	stats, err := m.read()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return &History{Current: stats, Samples: slices.Clone(m.samples)}, nil
+}
+
+func (m *Monitor) run() {
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+
+	m.sample(time.Now())
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.sample(time.Now())
+		}
+	}
+}
+
+// sample reads the ARC statistics and keeps a sample with the hit ratios
+// since the previous read. The first read only sets the baseline.
+func (m *Monitor) sample(now time.Time) {
+	stats, err := m.read()
+	if err != nil {
+		m.logger.Debug("Failed to read ARC statistics", "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	last := m.last
+	m.last = stats
+	if last == nil {
+		return
+	}
+
+	m.samples = append(m.samples, newSample(now, last, stats))
+	if len(m.samples) > maxSamples {
+		m.samples = m.samples[len(m.samples)-maxSamples:]
+	}
+}
+
+// newSample returns the ARC sizes of cur with the hit ratios since prev. A
+// module reload resets the counters, which then count from zero.
+func newSample(at time.Time, prev, cur *Stats) Sample {
+	delta := func(prev, cur uint64) uint64 {
+		if cur < prev {
+			return cur
+		}
+		return cur - prev
+	}
+	return Sample{
+		At:         at,
+		Size:       cur.Size,
+		TargetSize: cur.TargetSize,
+		MFUSize:    cur.MFUSize,
+		MRUSize:    cur.MRUSize,
+		L2Size:     cur.L2Size,
+		HitRatio:   ratio(delta(prev.Hits, cur.Hits), delta(prev.Misses, cur.Misses)),
+		L2HitRatio: ratio(delta(prev.L2Hits, cur.L2Hits), delta(prev.L2Misses, cur.L2Misses)),
+	}
+}
+
+// WritePrometheus writes the last read ARC statistics in the Prometheus text
+// format. Hit ratios are left to Prometheus to compute from the counters.
+func (m *Monitor) WritePrometheus(w io.Writer) error {
+	m.mu.RLock()
+	stats := m.last
+	m.mu.RUnlock()
+	if stats == nil {
+		return nil
+	}
+
+	var b strings.Builder
+	gauges := []struct {
+		name, help string
+		value      uint64
+	}{
+		{"rodent_arc_size_bytes", "Current ARC size", stats.Size},
+		{"rodent_arc_target_size_bytes", "Size the ARC is adapting to", stats.TargetSize},
+		{"rodent_arc_min_size_bytes", "Minimum ARC size", stats.MinSize},
+		{"rodent_arc_max_size_bytes", "Maximum ARC size", stats.MaxSize},
+		{"rodent_arc_mfu_size_bytes", "Size of the most frequently used list", stats.MFUSize},
+		{"rodent_arc_mru_size_bytes", "Size of the most recently used list", stats.MRUSize},
+		{"rodent_arc_l2_size_bytes", "Data cached in L2ARC", stats.L2Size},
+		{"rodent_arc_l2_allocated_bytes", "L2ARC device space used", stats.L2AllocSize},
+	}
+	for _, g := range gauges {
+		metrics.WriteHeader(&b, g.name, "gauge", g.help)
+		metrics.WriteSample(&b, g.name, float64(g.value))
+	}
+
+	counters := []struct {
+		name, help string
+		value      uint64
+	}{
+		{"rodent_arc_hits_total", "ARC lookups that hit", stats.Hits},
+		{"rodent_arc_misses_total", "ARC lookups that missed", stats.Misses},
+		{"rodent_arc_mfu_hits_total", "ARC hits on the most frequently used list", stats.MFUHits},
+		{"rodent_arc_mru_hits_total", "ARC hits on the most recently used list", stats.MRUHits},
+		{"rodent_arc_l2_hits_total", "L2ARC lookups that hit", stats.L2Hits},
+		{"rodent_arc_l2_misses_total", "L2ARC lookups that missed", stats.L2Misses},
+		{"rodent_arc_l2_read_bytes_total", "Bytes read from L2ARC", stats.L2ReadBytes},
+		{"rodent_arc_l2_write_bytes_total", "Bytes written to L2ARC", stats.L2WriteBytes},
+	}
+	for _, c := range counters {
+		metrics.WriteHeader(&b, c.name, "counter", c.help)
+		metrics.WriteSample(&b, c.name, float64(c.value))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package arc
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// arcstatsPath is the ZFS kstat with the ARC statistics
+const arcstatsPath = "/proc/spl/kstat/zfs/arcstats"
+
+// ReadStats reads the current ARC statistics
+func ReadStats() (*Stats, error) {
+	f, err := os.Open(arcstatsPath)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ARCStatsUnavailable).
+			WithMetadata("path", arcstatsPath)
+	}
+	defer f.Close()
+
+	kstat, err := parseKstat(f)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.CommandOutputParse).
+			WithMetadata("path", arcstatsPath)
+	}
+	return newStats(kstat), nil
+}
+
+// parseKstat parses a named kstat: a kstat header line, a "name type data"
+// title line and a line per statistic
+func parseKstat(r io.Reader) (map[string]uint64, error) {
+	kstat := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] == "name" {
+			continue
+		}
+		value, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			continue
+		}
+		kstat[fields[0]] = value
+	}
+	return kstat, scanner.Err()
+}
+
+// newStats picks the ARC statistics out of the arcstats kstat
+func newStats(kstat map[string]uint64) *Stats {
+	s := &Stats{
+		Size:         kstat["size"],
+		TargetSize:   kstat["c"],
+		MinSize:      kstat["c_min"],
+		MaxSize:      kstat["c_max"],
+		MFUSize:      kstat["mfu_size"],
+		MRUSize:      kstat["mru_size"],
+		Hits:         kstat["hits"],
+		Misses:       kstat["misses"],
+		MFUHits:      kstat["mfu_hits"],
+		MRUHits:      kstat["mru_hits"],
+		MFUGhostHits: kstat["mfu_ghost_hits"],
+		MRUGhostHits: kstat["mru_ghost_hits"],
+		L2Size:       kstat["l2_size"],
+		L2AllocSize:  kstat["l2_asize"],
+		L2Hits:       kstat["l2_hits"],
+		L2Misses:     kstat["l2_misses"],
+		L2ReadBytes:  kstat["l2_read_bytes"],
+		L2WriteBytes: kstat["l2_write_bytes"],
+	}
+	s.HitRatio = ratio(s.Hits, s.Misses)
+	s.L2HitRatio = ratio(s.L2Hits, s.L2Misses)
+	return s
+}
+
+// ratio returns hits as a percentage of lookups, 0 without lookups
+func ratio(hits, misses uint64) float64 {
+	if hits+misses == 0 {
+		return 0
+	}
+	return float64(hits) * 100 / float64(hits+misses)
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package arc reports ZFS ARC and L2ARC statistics and manages the ARC size
+// limit the ZFS module is loaded with.
+package arc
+
+import "time"
+
+// Stats are the ARC and L2ARC statistics since the ZFS module was loaded
+type Stats struct {
+	Size       uint64 `json:"size"`        // Current ARC size in bytes
+	TargetSize uint64 `json:"target_size"` // Size the ARC is adapting to
+	MinSize    uint64 `json:"min_size"`
+	MaxSize    uint64 `json:"max_size"` // Effective zfs_arc_max
+	MFUSize    uint64 `json:"mfu_size"`
+	MRUSize    uint64 `json:"mru_size"`
+
+	Hits         uint64  `json:"hits"`
+	Misses       uint64  `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"` // Percent
+	MFUHits      uint64  `json:"mfu_hits"`
+	MRUHits      uint64  `json:"mru_hits"`
+	MFUGhostHits uint64  `json:"mfu_ghost_hits"`
+	MRUGhostHits uint64  `json:"mru_ghost_hits"`
+
+	L2Size       uint64  `json:"l2_size"`           // Data cached in L2ARC before compression
+	L2AllocSize  uint64  `json:"l2_allocated_size"` // L2ARC device space used
+	L2Hits       uint64  `json:"l2_hits"`
+	L2Misses     uint64  `json:"l2_misses"`
+	L2HitRatio   float64 `json:"l2_hit_ratio"` // Percent
+	L2ReadBytes  uint64  `json:"l2_read_bytes"`
+	L2WriteBytes uint64  `json:"l2_write_bytes"`
+}
+
+// Sample is the ARC size and hit ratios over a sampling interval
+type Sample struct {
+	At         time.Time `json:"at"`
+	Size       uint64    `json:"size"`
+	TargetSize uint64    `json:"target_size"`
+	MFUSize    uint64    `json:"mfu_size"`
+	MRUSize    uint64    `json:"mru_size"`
+	L2Size     uint64    `json:"l2_size"`
+	HitRatio   float64   `json:"hit_ratio"`    // Percent of lookups in the interval
+	L2HitRatio float64   `json:"l2_hit_ratio"` // Percent of L2ARC lookups in the interval
+}
+
+// History is the current ARC statistics with the kept samples
+type History struct {
+	Current *Stats   `json:"current,omitempty"`
+	Samples []Sample `json:"samples"` // Oldest first
+}
+
+// Config is the configured ARC size limit and the one the ZFS module runs
+// with
+type Config struct {
+	Path string `json:"path"` // Managed modprobe.d file
+
+	// ARCMax is the zfs_arc_max set in the managed file, 0 for the ZFS
+	// default
+	ARCMax uint64 `json:"arc_max"`
+
+	// RuntimeARCMax is the zfs_arc_max of the loaded module, 0 for the ZFS
+	// default
+	RuntimeARCMax uint64 `json:"runtime_arc_max"`
+
+	// EffectiveMax is the limit the ARC currently adapts within
+	EffectiveMax uint64 `json:"effective_max"`
+	MemoryBytes  uint64 `json:"memory_bytes"`
+
+	// RebootRequired is set while the configured limit differs from the
+	// loaded module's, until the module is reloaded
+	RebootRequired bool `json:"reboot_required"`
+}
+
+// SetConfigRequest sets the ARC size limit
+type SetConfigRequest struct {
+	// ARCMax is the limit in bytes; 0 removes it, restoring the ZFS default
+	ARCMax *uint64 `json:"arc_max" binding:"required"`
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autoattach
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Handler handles HTTP requests for auto-attach rules
+type Handler struct {
+	manager *Manager
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewHandlerWithManager creates a new auto-attach handler with an existing manager
+func NewHandlerWithManager(manager *Manager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+// Manager returns the auto-attach manager for use by other subsystems
+func (h *Handler) Manager() *Manager {
+	return h.manager
+}
+
+// RegisterRoutes registers HTTP routes for auto-attach rules
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	rules := router.Group("/attach-rules")
+	{
+		rules.GET("", h.listRules)
+		rules.POST("", h.createRule)
+		rules.GET("/attachments", h.listAttachments)
+		rules.POST("/scan", h.scan)
+		rules.GET("/:rule_id", h.getRule)
+		rules.PUT("/:rule_id", h.updateRule)
+		rules.DELETE("/:rule_id", h.deleteRule)
+	}
+}
+
+// StartManager starts watching for new datasets
+func (h *Handler) StartManager() error {
+	return h.manager.Start()
+}
+
+// StopManager stops watching for new datasets
+func (h *Handler) StopManager() error {
+	return h.manager.Stop()
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *Handler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *Handler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  "AUTO_ATTACH",
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// createRule creates a new auto-attach rule
+func (h *Handler) createRule(c *gin.Context) {
+	var params EditAttachRuleParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.AttachRuleInvalidConfig))
+		return
+	}
+	params.ID = ""
+
+	ruleID, err := h.manager.AddRule(params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	rule, err := h.manager.GetRule(ruleID)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusCreated, rule)
+}
+
+// listRules lists all auto-attach rules
+func (h *Handler) listRules(c *gin.Context) {
+	rules := h.manager.ListRules()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"rules": rules,
+		"count": len(rules),
+	})
+}
+
+// getRule gets an auto-attach rule by ID
+func (h *Handler) getRule(c *gin.Context) {
+	rule, err := h.manager.GetRule(c.Param("rule_id"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, rule)
+}
+
+// updateRule updates an auto-attach rule
+func (h *Handler) updateRule(c *gin.Context) {
+	var params EditAttachRuleParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.AttachRuleInvalidConfig))
+		return
+	}
+	params.ID = c.Param("rule_id")
+
+	if err := h.manager.UpdateRule(params); err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	rule, err := h.manager.GetRule(params.ID)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, rule)
+}
+
+// deleteRule deletes an auto-attach rule. Attached policies are left in place.
+func (h *Handler) deleteRule(c *gin.Context) {
+	ruleID := c.Param("rule_id")
+	if err := h.manager.RemoveRule(ruleID); err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"rule_id": ruleID,
+		"deleted": true,
+	})
+}
+
+// listAttachments lists recorded attachments, optionally filtered by rule_id
+func (h *Handler) listAttachments(c *gin.Context) {
+	attachments := h.manager.ListAttachments(c.Query("rule_id"))
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"attachments": attachments,
+		"count":       len(attachments),
+	})
+}
+
+// scan runs a discovery pass immediately
+func (h *Handler) scan(c *gin.Context) {
+	result, err := h.manager.Scan(c.Request.Context())
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, result)
+}
@@ -0,0 +1,614 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autoattach
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// scanInterval is how often rule parents are listed to discover new datasets
+const scanInterval = time.Minute
+
+// maxAttachAttempts bounds how many scans retry a failed attachment. The last
+// failure stays recorded on the attachment for operators to inspect.
+const maxAttachAttempts = 5
+
+// Manager watches for dataset creation and attaches policies according to rules
+type Manager struct {
+	logger                logger.Logger
+	configPath            string
+	config                AttachRuleConfig
+	datasetManager        *dataset.Manager
+	snapshotManager       *autosnapshots.Manager
+	transferPolicyManager *autotransfers.Manager // optional; nil disables transfer templates
+	stop                  chan struct{}
+	mu                    sync.RWMutex
+	scanMu                sync.Mutex // serializes scans
+	started               bool
+}
+
+// Singleton instance
+var (
+	globalManager *Manager
+	initMutex     sync.Mutex
+)
+
+// GetManager returns the singleton auto-attach manager instance
+func GetManager(
+	dsManager *dataset.Manager,
+	snapshotMgr *autosnapshots.Manager,
+	transferPolicyMgr *autotransfers.Manager,
+	logCfg logger.Config,
+) (*Manager, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalManager != nil {
+		return globalManager, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "zfs-auto-attach")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	attachDir := filepath.Join(config.GetPoliciesDir(), "attach")
+	if err := os.MkdirAll(attachDir, 0755); err != nil {
+		return nil, errors.New(
+			errors.ConfigWriteError,
+			fmt.Sprintf("failed to create auto-attach rules directory: %v", err),
+		)
+	}
+
+	m := &Manager{
+		logger:                l,
+		configPath:            filepath.Join(attachDir, "zfs.attach-rules.rodent.yml"),
+		datasetManager:        dsManager,
+		snapshotManager:       snapshotMgr,
+		transferPolicyManager: transferPolicyMgr,
+		config: AttachRuleConfig{
+			Rules:         []AttachRule{},
+			Attachments:   []Attachment{},
+			KnownDatasets: make(map[string][]string),
+		},
+	}
+
+	if err := m.LoadConfig(); err != nil {
+		l.Warn("Failed to load auto-attach rules config, starting with empty config", "error", err)
+	}
+
+	globalManager = m
+	return m, nil
+}
+
+// Start begins watching for new datasets
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return nil
+	}
+
+	m.stop = make(chan struct{})
+	go m.watch(m.stop)
+	m.started = true
+
+	m.logger.Info("Auto-attach manager started", "rule_count", len(m.config.Rules))
+	return nil
+}
+
+// Stop stops watching for new datasets
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.started {
+		return nil
+	}
+
+	close(m.stop)
+	m.started = false
+	return nil
+}
+
+// watch runs a scan every scanInterval until stop is closed, which also
+// cancels a scan in progress
+func (m *Manager) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(scanInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := m.Scan(ctx); err != nil {
+				m.logger.Warn("Auto-attach scan failed", "error", err)
+			}
+		}
+	}
+}
+
+// AddRule creates a new rule
+func (m *Manager) AddRule(params EditAttachRuleParams) (string, error) {
+	rule := NewAttachRule(params)
+	if err := m.validateRule(rule); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.config.Rules {
+		if r.ID == rule.ID {
+			return "", errors.New(
+				errors.AttachRuleInvalidConfig,
+				"rule with the same ID already exists",
+			)
+		}
+	}
+
+	m.config.Rules = append(m.config.Rules, rule)
+	if err := m.SaveConfig(true); err != nil {
+		return "", err
+	}
+
+	m.logger.Info("Added auto-attach rule",
+		"rule_id", rule.ID,
+		"parent_dataset", rule.ParentDataset)
+	return rule.ID, nil
+}
+
+// UpdateRule updates an existing rule. Changing the parent dataset resets the
+// set of known datasets, so the next scan starts from a fresh baseline.
+func (m *Manager) UpdateRule(params EditAttachRuleParams) error {
+	updated := NewAttachRule(params)
+	if err := m.validateRule(updated); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.config.Rules {
+		rule := &m.config.Rules[i]
+		if rule.ID != params.ID {
+			continue
+		}
+
+		updated.CreatedAt = rule.CreatedAt
+		if rule.ParentDataset == updated.ParentDataset {
+			updated.LastScanAt = rule.LastScanAt
+		} else {
+			delete(m.config.KnownDatasets, rule.ID)
+		}
+		*rule = updated
+
+		return m.SaveConfig(true)
+	}
+
+	return errors.New(errors.AttachRuleNotFound, "rule not found").
+		WithMetadata("rule_id", params.ID)
+}
+
+// RemoveRule deletes a rule. Policies already attached by the rule are kept.
+func (m *Manager) RemoveRule(ruleID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, rule := range m.config.Rules {
+		if rule.ID != ruleID {
+			continue
+		}
+
+		m.config.Rules = append(m.config.Rules[:i], m.config.Rules[i+1:]...)
+		delete(m.config.KnownDatasets, ruleID)
+		return m.SaveConfig(true)
+	}
+
+	return errors.New(errors.AttachRuleNotFound, "rule not found").
+		WithMetadata("rule_id", ruleID)
+}
+
+// GetRule returns a rule by ID
+func (m *Manager) GetRule(ruleID string) (AttachRule, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, rule := range m.config.Rules {
+		if rule.ID == ruleID {
+			return rule, nil
+		}
+	}
+
+	return AttachRule{}, errors.New(errors.AttachRuleNotFound, "rule not found").
+		WithMetadata("rule_id", ruleID)
+}
+
+// ListRules returns all rules
+func (m *Manager) ListRules() []AttachRule {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rules := make([]AttachRule, len(m.config.Rules))
+	copy(rules, m.config.Rules)
+	return rules
+}
+
+// ListAttachments returns recorded attachments, optionally filtered by rule
+func (m *Manager) ListAttachments(ruleID string) []Attachment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	attachments := []Attachment{}
+	for _, a := range m.config.Attachments {
+		if ruleID == "" || a.RuleID == ruleID {
+			attachments = append(attachments, a)
+		}
+	}
+	return attachments
+}
+
+// validateRule validates the rule and checks that its templates exist
+func (m *Manager) validateRule(rule AttachRule) error {
+	if err := ValidateAttachRule(rule); err != nil {
+		return err
+	}
+
+	if _, err := m.snapshotManager.GetPolicy(rule.SnapshotPolicyTemplateID); err != nil {
+		return errors.Wrap(err, errors.AttachRuleInvalidConfig).
+			WithMetadata("snapshot_policy_template_id", rule.SnapshotPolicyTemplateID)
+	}
+
+	if rule.TransferPolicyTemplateID != "" {
+		if m.transferPolicyManager == nil {
+			return errors.New(
+				errors.AttachRuleInvalidConfig,
+				"transfer policies are not available on this node",
+			)
+		}
+		if _, err := m.transferPolicyManager.GetPolicy(rule.TransferPolicyTemplateID); err != nil {
+			return errors.Wrap(err, errors.AttachRuleInvalidConfig).
+				WithMetadata("transfer_policy_template_id", rule.TransferPolicyTemplateID)
+		}
+	}
+
+	return nil
+}
+
+// Scan lists the datasets under each enabled rule's parent and attaches
+// policies to those not seen before. The first scan of a rule only records the
+// existing datasets unless the rule applies to existing datasets.
+func (m *Manager) Scan(ctx context.Context) (*ScanResult, error) {
+	m.scanMu.Lock()
+	defer m.scanMu.Unlock()
+
+	result := &ScanResult{
+		ScannedAt:   time.Now(),
+		Attachments: []Attachment{},
+	}
+
+	for _, rule := range m.ListRules() {
+		if !rule.Enabled {
+			continue
+		}
+
+		datasets, err := m.listChildren(ctx, rule)
+		if err != nil {
+			m.logger.Warn("Failed to list datasets for auto-attach rule",
+				"rule_id", rule.ID,
+				"parent_dataset", rule.ParentDataset,
+				"error", err)
+			continue
+		}
+
+		m.mu.RLock()
+		known := make(map[string]bool)
+		for _, name := range m.config.KnownDatasets[rule.ID] {
+			known[name] = true
+		}
+		m.mu.RUnlock()
+
+		baseline := rule.LastScanAt == nil && !rule.ApplyToExisting
+		for _, name := range datasets {
+			if baseline {
+				continue
+			}
+			previous, found := m.lastAttachment(rule.ID, name)
+			if !needsAttach(previous, found, known[name]) {
+				continue
+			}
+			attachment := m.attach(ctx, rule, name, previous)
+			result.Attachments = append(result.Attachments, attachment)
+		}
+
+		m.mu.Lock()
+		for i := range m.config.Rules {
+			if m.config.Rules[i].ID == rule.ID {
+				scannedAt := result.ScannedAt
+				m.config.Rules[i].LastScanAt = &scannedAt
+				m.config.KnownDatasets[rule.ID] = datasets
+				break
+			}
+		}
+		m.mu.Unlock()
+	}
+
+	if err := m.SaveConfig(false); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// listChildren returns the sorted names of datasets under the rule's parent
+// that match the rule
+func (m *Manager) listChildren(ctx context.Context, rule AttachRule) ([]string, error) {
+	listCfg := dataset.ListConfig{
+		Name:      rule.ParentDataset,
+		Type:      "filesystem,volume",
+		Recursive: rule.Recursive,
+	}
+	if !rule.Recursive {
+		listCfg.Depth = "1"
+	}
+
+	list, err := m.datasetManager.List(ctx, listCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for name := range list.Datasets {
+		if rule.Matches(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// lastAttachment returns the attachment recorded for the rule and dataset, if any
+func (m *Manager) lastAttachment(ruleID, name string) (Attachment, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, a := range m.config.Attachments {
+		if a.RuleID == ruleID && a.Dataset == name {
+			return a, true
+		}
+	}
+	return Attachment{}, false
+}
+
+// needsAttach reports whether a scan should attach policies to a dataset.
+// Datasets already seen are skipped unless their last attachment failed and
+// has attempts left.
+func needsAttach(previous Attachment, found, known bool) bool {
+	if !found {
+		return !known
+	}
+	return previous.Error != "" && previous.Attempts < maxAttachAttempts
+}
+
+// recordAttachment stores an attachment, replacing the earlier record for the
+// same rule and dataset so retries don't pile up failed entries
+func recordAttachment(attachments []Attachment, attachment Attachment) []Attachment {
+	for i, a := range attachments {
+		if a.RuleID == attachment.RuleID && a.Dataset == attachment.Dataset {
+			attachments[i] = attachment
+			return attachments
+		}
+	}
+	return append(attachments, attachment)
+}
+
+// attach creates the rule's policies for a dataset and records the attachment.
+// Failures are recorded on the attachment rather than returned, so one bad
+// dataset does not stop the scan; later scans retry it. A snapshot policy
+// created by a previous failed attempt is reused rather than copied again.
+func (m *Manager) attach(
+	ctx context.Context,
+	rule AttachRule,
+	name string,
+	previous Attachment,
+) Attachment {
+	attachment := Attachment{
+		Dataset:    name,
+		RuleID:     rule.ID,
+		AttachedAt: time.Now(),
+		Attempts:   previous.Attempts + 1,
+	}
+
+	snapshotPolicyID := previous.SnapshotPolicyID
+	var err error
+	if snapshotPolicyID == "" {
+		snapshotPolicyID, err = m.attachSnapshotPolicy(rule, name)
+	}
+	if err != nil {
+		attachment.Error = err.Error()
+	} else {
+		attachment.SnapshotPolicyID = snapshotPolicyID
+		if rule.TransferPolicyTemplateID != "" {
+			transferPolicyID, err := m.attachTransferPolicy(ctx, rule, name, snapshotPolicyID)
+			if err != nil {
+				attachment.Error = err.Error()
+			}
+			attachment.TransferPolicyID = transferPolicyID
+		}
+	}
+
+	if attachment.Error != "" {
+		m.logger.Warn("Failed to attach policies to dataset",
+			"rule_id", rule.ID,
+			"dataset", name,
+			"attempt", attachment.Attempts,
+			"max_attempts", maxAttachAttempts,
+			"error", attachment.Error)
+	} else {
+		m.logger.Info("Attached policies to new dataset",
+			"rule_id", rule.ID,
+			"dataset", name,
+			"snapshot_policy_id", attachment.SnapshotPolicyID,
+			"transfer_policy_id", attachment.TransferPolicyID)
+	}
+
+	m.mu.Lock()
+	m.config.Attachments = recordAttachment(m.config.Attachments, attachment)
+	m.mu.Unlock()
+
+	return attachment
+}
+
+// attachSnapshotPolicy copies the rule's snapshot policy template onto a dataset
+func (m *Manager) attachSnapshotPolicy(rule AttachRule, name string) (string, error) {
+	template, err := m.snapshotManager.GetPolicy(rule.SnapshotPolicyTemplateID)
+	if err != nil {
+		return "", err
+	}
+
+	return m.snapshotManager.AddPolicy(autosnapshots.EditPolicyParams{
+		Name:            policyName(template.Name, name),
+		Description:     fmt.Sprintf("Attached by rule %q from template %q", rule.Name, template.Name),
+		Dataset:         name,
+		Schedules:       template.Schedules,
+		Recursive:       template.Recursive,
+		ExcludeDatasets: template.ExcludeDatasets,
+		SnapNamePattern: template.SnapNamePattern,
+		RetentionPolicy: template.RetentionPolicy,
+		Properties:      template.Properties,
+		Enabled:         template.Enabled,
+	})
+}
+
+// attachTransferPolicy copies the rule's transfer policy template, pointing it
+// at the new snapshot policy and at a target below the template's target
+func (m *Manager) attachTransferPolicy(
+	ctx context.Context,
+	rule AttachRule,
+	name string,
+	snapshotPolicyID string,
+) (string, error) {
+	if m.transferPolicyManager == nil {
+		return "", errors.New(
+			errors.AttachRuleInvalidConfig,
+			"transfer policies are not available on this node",
+		)
+	}
+
+	template, err := m.transferPolicyManager.GetPolicy(rule.TransferPolicyTemplateID)
+	if err != nil {
+		return "", err
+	}
+
+	transferCfg := template.TransferConfig
+	transferCfg.ReceiveConfig.Target = strings.TrimSuffix(
+		transferCfg.ReceiveConfig.Target,
+		"/",
+	) + "/" + rule.RelativeName(name)
+
+	return m.transferPolicyManager.AddPolicy(ctx, autotransfers.EditTransferPolicyParams{
+		Name:             policyName(template.Name, name),
+		Description:      fmt.Sprintf("Attached by rule %q from template %q", rule.Name, template.Name),
+		SnapshotPolicyID: snapshotPolicyID,
+		TransferConfig:   transferCfg,
+		Schedules:        template.Schedules,
+		RetentionPolicy:  template.RetentionPolicy,
+		RPOTarget:        template.RPOTarget,
+		Enabled:          template.Enabled,
+	})
+}
+
+// policyName names an attached policy after its template and dataset
+func policyName(templateName, datasetName string) string {
+	return fmt.Sprintf("%s (%s)", templateName, datasetName)
+}
+
+// LoadConfig loads rules and attachments from disk
+func (m *Manager) LoadConfig() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
+		m.logger.Info("Auto-attach config file does not exist, starting with empty config")
+		return nil
+	}
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigReadError)
+	}
+
+	var cfg AttachRuleConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return errors.Wrap(err, errors.ConfigUnmarshalFailed)
+	}
+
+	validRules := []AttachRule{}
+	for _, rule := range cfg.Rules {
+		if err := ValidateAttachRule(rule); err != nil {
+			m.logger.Warn("Invalid auto-attach rule in config, skipping",
+				"rule_id", rule.ID,
+				"error", err)
+			continue
+		}
+		validRules = append(validRules, rule)
+	}
+	cfg.Rules = validRules
+
+	if cfg.Attachments == nil {
+		cfg.Attachments = []Attachment{}
+	}
+	if cfg.KnownDatasets == nil {
+		cfg.KnownDatasets = make(map[string][]string)
+	}
+
+	m.config = cfg
+	m.logger.Info("Auto-attach config loaded", "rule_count", len(cfg.Rules))
+	return nil
+}
+
+// SaveConfig saves rules and attachments to disk
+func (m *Manager) SaveConfig(skipLock bool) error {
+	if !skipLock {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+
+	data, err := yaml.Marshal(&m.config)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigMarshalFailed)
+	}
+
+	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autoattach
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachRuleMatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    AttachRule
+		dataset string
+		want    bool
+	}{
+		{
+			name:    "direct child",
+			rule:    AttachRule{ParentDataset: "tank/projects"},
+			dataset: "tank/projects/alpha",
+			want:    true,
+		},
+		{
+			name:    "parent itself",
+			rule:    AttachRule{ParentDataset: "tank/projects"},
+			dataset: "tank/projects",
+			want:    false,
+		},
+		{
+			name:    "sibling with common prefix",
+			rule:    AttachRule{ParentDataset: "tank/projects"},
+			dataset: "tank/projects-old/alpha",
+			want:    false,
+		},
+		{
+			name:    "grandchild without recursion",
+			rule:    AttachRule{ParentDataset: "tank/projects"},
+			dataset: "tank/projects/alpha/data",
+			want:    false,
+		},
+		{
+			name:    "grandchild with recursion",
+			rule:    AttachRule{ParentDataset: "tank/projects", Recursive: true},
+			dataset: "tank/projects/alpha/data",
+			want:    true,
+		},
+		{
+			name:    "name pattern match",
+			rule:    AttachRule{ParentDataset: "tank/projects", NamePattern: "proj-*"},
+			dataset: "tank/projects/proj-42",
+			want:    true,
+		},
+		{
+			name:    "name pattern mismatch",
+			rule:    AttachRule{ParentDataset: "tank/projects", NamePattern: "proj-*"},
+			dataset: "tank/projects/scratch",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.rule.Matches(tt.dataset))
+		})
+	}
+}
+
+func TestValidateAttachRule(t *testing.T) {
+	valid := AttachRule{
+		Name:                     "projects",
+		ParentDataset:            "tank/projects",
+		SnapshotPolicyTemplateID: "template",
+	}
+	assert.NoError(t, ValidateAttachRule(valid))
+
+	noTemplate := valid
+	noTemplate.SnapshotPolicyTemplateID = ""
+	assert.Error(t, ValidateAttachRule(noTemplate))
+
+	badParent := valid
+	badParent.ParentDataset = "tank/projects@snap"
+	assert.Error(t, ValidateAttachRule(badParent))
+
+	badPattern := valid
+	badPattern.NamePattern = "proj-["
+	assert.Error(t, ValidateAttachRule(badPattern))
+
+	rel := AttachRule{ParentDataset: "tank/projects"}
+	assert.Equal(t, "alpha/data", rel.RelativeName("tank/projects/alpha/data"))
+}
+
+func TestNeedsAttach(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous Attachment
+		found    bool
+		known    bool
+		want     bool
+	}{
+		{name: "new dataset", want: true},
+		{name: "known dataset", known: true, want: false},
+		{
+			name:     "attached",
+			previous: Attachment{SnapshotPolicyID: "p1", Attempts: 1},
+			found:    true,
+			known:    true,
+			want:     false,
+		},
+		{
+			name:     "failed with attempts left",
+			previous: Attachment{Error: "boom", Attempts: 1},
+			found:    true,
+			known:    true,
+			want:     true,
+		},
+		{
+			name:     "failed out of attempts",
+			previous: Attachment{Error: "boom", Attempts: maxAttachAttempts},
+			found:    true,
+			known:    true,
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, needsAttach(tt.previous, tt.found, tt.known))
+		})
+	}
+}
+
+func TestRecordAttachmentReplacesRetries(t *testing.T) {
+	attachments := []Attachment{
+		{RuleID: "r1", Dataset: "tank/projects/alpha", Error: "boom", Attempts: 1},
+		{RuleID: "r2", Dataset: "tank/projects/alpha", SnapshotPolicyID: "p2", Attempts: 1},
+	}
+
+	retried := Attachment{
+		RuleID:           "r1",
+		Dataset:          "tank/projects/alpha",
+		SnapshotPolicyID: "p1",
+		Attempts:         2,
+	}
+	attachments = recordAttachment(attachments, retried)
+	assert.Len(t, attachments, 2)
+	assert.Equal(t, retried, attachments[0])
+
+	attachments = recordAttachment(attachments, Attachment{RuleID: "r1", Dataset: "tank/projects/beta"})
+	assert.Len(t, attachments, 3)
+}
@@ -0,0 +1,170 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autoattach
+
+import (
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// AttachRule attaches copies of template policies to datasets created under a
+// parent dataset. For example, every new dataset under tank/projects can get
+// its own copy of the "hourly-7d" snapshot policy and the "offsite" transfer
+// policy.
+type AttachRule struct {
+	ID            string `json:"id"                     yaml:"id"`
+	Name          string `json:"name"                   yaml:"name"`
+	Description   string `json:"description,omitempty"  yaml:"description,omitempty"`
+	ParentDataset string `json:"parent_dataset"         yaml:"parent_dataset"`
+	// Recursive matches datasets at any depth below the parent instead of only
+	// its direct children
+	Recursive bool `json:"recursive"              yaml:"recursive"`
+	// NamePattern is an optional glob matched against the last component of the
+	// dataset name, e.g. "proj-*"
+	NamePattern string `json:"name_pattern,omitempty" yaml:"name_pattern,omitempty"`
+
+	// SnapshotPolicyTemplateID is the snapshot policy copied for each new dataset
+	SnapshotPolicyTemplateID string `json:"snapshot_policy_template_id"           yaml:"snapshot_policy_template_id"`
+	// TransferPolicyTemplateID is an optional transfer policy copied for each new
+	// dataset. The copy's target is the template target with the dataset's path
+	// relative to the parent appended.
+	TransferPolicyTemplateID string `json:"transfer_policy_template_id,omitempty" yaml:"transfer_policy_template_id,omitempty"`
+
+	// ApplyToExisting attaches policies to datasets that already exist when the
+	// rule is first scanned; otherwise only datasets created afterwards match
+	ApplyToExisting bool       `json:"apply_to_existing"      yaml:"apply_to_existing"`
+	Enabled         bool       `json:"enabled"                yaml:"enabled"`
+	CreatedAt       time.Time  `json:"created_at"             yaml:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"             yaml:"updated_at"`
+	LastScanAt      *time.Time `json:"last_scan_at,omitempty" yaml:"last_scan_at,omitempty"`
+}
+
+// Attachment records the policies created for a dataset by a rule
+type Attachment struct {
+	Dataset          string    `json:"dataset"                      yaml:"dataset"`
+	RuleID           string    `json:"rule_id"                      yaml:"rule_id"`
+	SnapshotPolicyID string    `json:"snapshot_policy_id,omitempty" yaml:"snapshot_policy_id,omitempty"`
+	TransferPolicyID string    `json:"transfer_policy_id,omitempty" yaml:"transfer_policy_id,omitempty"`
+	AttachedAt       time.Time `json:"attached_at"                  yaml:"attached_at"`
+	// Attempts counts the scans that tried to attach policies to the dataset;
+	// failed attachments are retried until it reaches maxAttachAttempts
+	Attempts int    `json:"attempts"                     yaml:"attempts"`
+	Error    string `json:"error,omitempty"              yaml:"error,omitempty"`
+}
+
+// AttachRuleConfig is the persisted state of the auto-attach manager
+type AttachRuleConfig struct {
+	Rules       []AttachRule `yaml:"rules"`
+	Attachments []Attachment `yaml:"attachments"`
+	// KnownDatasets holds the datasets seen by the last scan of each rule, so
+	// only datasets that appear afterwards are treated as new
+	KnownDatasets map[string][]string `yaml:"known_datasets"`
+}
+
+// EditAttachRuleParams are parameters for creating or updating a rule
+type EditAttachRuleParams struct {
+	ID                       string `json:"id,omitempty"`
+	Name                     string `json:"name"`
+	Description              string `json:"description,omitempty"`
+	ParentDataset            string `json:"parent_dataset"`
+	Recursive                bool   `json:"recursive"`
+	NamePattern              string `json:"name_pattern,omitempty"`
+	SnapshotPolicyTemplateID string `json:"snapshot_policy_template_id"`
+	TransferPolicyTemplateID string `json:"transfer_policy_template_id,omitempty"`
+	ApplyToExisting          bool   `json:"apply_to_existing"`
+	Enabled                  bool   `json:"enabled"`
+}
+
+// ScanResult summarizes a discovery pass
+type ScanResult struct {
+	ScannedAt   time.Time    `json:"scanned_at"`
+	Attachments []Attachment `json:"attachments"`
+}
+
+// NewAttachRule creates a rule from the given parameters
+func NewAttachRule(params EditAttachRuleParams) AttachRule {
+	now := time.Now()
+	id := params.ID
+	if id == "" {
+		id = uuid.New().String()
+	}
+
+	return AttachRule{
+		ID:                       id,
+		Name:                     params.Name,
+		Description:              params.Description,
+		ParentDataset:            params.ParentDataset,
+		Recursive:                params.Recursive,
+		NamePattern:              params.NamePattern,
+		SnapshotPolicyTemplateID: params.SnapshotPolicyTemplateID,
+		TransferPolicyTemplateID: params.TransferPolicyTemplateID,
+		ApplyToExisting:          params.ApplyToExisting,
+		Enabled:                  params.Enabled,
+		CreatedAt:                now,
+		UpdatedAt:                now,
+	}
+}
+
+// ValidateAttachRule checks that a rule is well-formed. Template references are
+// resolved by the manager.
+func ValidateAttachRule(rule AttachRule) error {
+	if rule.Name == "" {
+		return errors.New(errors.AttachRuleInvalidConfig, "name is required")
+	}
+
+	if rule.ParentDataset == "" {
+		return errors.New(errors.AttachRuleInvalidConfig, "parent dataset is required")
+	}
+
+	if strings.Contains(rule.ParentDataset, "@") || strings.HasSuffix(rule.ParentDataset, "/") {
+		return errors.New(errors.AttachRuleInvalidConfig, "invalid parent dataset name").
+			WithMetadata("parent_dataset", rule.ParentDataset)
+	}
+
+	if rule.NamePattern != "" {
+		if _, err := path.Match(rule.NamePattern, ""); err != nil {
+			return errors.New(errors.AttachRuleInvalidConfig, "invalid name pattern").
+				WithMetadata("name_pattern", rule.NamePattern)
+		}
+	}
+
+	if rule.SnapshotPolicyTemplateID == "" {
+		return errors.New(errors.AttachRuleInvalidConfig, "snapshot policy template is required")
+	}
+
+	return nil
+}
+
+// Matches reports whether a dataset falls under the rule. The parent dataset
+// itself never matches.
+func (r AttachRule) Matches(name string) bool {
+	prefix := r.ParentDataset + "/"
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+
+	rel := strings.TrimPrefix(name, prefix)
+	if rel == "" || (!r.Recursive && strings.Contains(rel, "/")) {
+		return false
+	}
+
+	if r.NamePattern != "" {
+		matched, err := path.Match(r.NamePattern, path.Base(rel))
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// RelativeName returns the dataset name relative to the rule's parent
+func (r AttachRule) RelativeName(name string) string {
+	return strings.TrimPrefix(name, r.ParentDataset+"/")
+}
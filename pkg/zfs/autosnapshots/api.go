@@ -6,18 +6,26 @@ package autosnapshots
 
 import (
 	"context"
+	stderrors "errors"
+	"io"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/scope"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stratastor/rodent/pkg/jobs"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 )
 
 // Handler handles HTTP requests for auto-snapshot operations
 type Handler struct {
-	manager *Manager
+	manager  *Manager
+	jobQueue *jobs.Queue // Runs policy updates in the background, if set
 }
 
 // NewHandler creates a new snapshot handler
@@ -40,6 +48,9 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 		policies := autosnapshots.Group("/policies")
 		{
 			policies.GET("", h.listPolicies)
+			policies.POST("/reload", h.reloadPolicies)
+			policies.GET("/trash", h.listTrashedPolicies)
+			policies.POST("/tags/backfill", h.backfillTags)
 			policies.POST("",
 				ValidateSnapshotPolicyConfig(),
 				h.createPolicy)
@@ -48,6 +59,7 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 				ValidateSnapshotPolicyConfig(),
 				h.updatePolicy)
 			policies.DELETE("/:id", h.deletePolicy)
+			policies.POST("/:id/restore", h.restorePolicy)
 			policies.POST("/:id/run",
 				ValidateRunPolicyParams(),
 				h.runPolicy)
@@ -55,6 +67,17 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 	}
 }
 
+// RegisterTriggerRoutes registers the route external systems run policies
+// by. It is authenticated with the trigger keys in the config file.
+func (h *Handler) RegisterTriggerRoutes(router *gin.RouterGroup) {
+	policies := router.Group("/snapshot-policies")
+	{
+		policies.POST("/:id/trigger",
+			RequireTriggerKey(),
+			h.triggerPolicy)
+	}
+}
+
 // StartManager starts the snapshot manager scheduler
 func (h *Handler) StartManager() error {
 	return h.manager.Start()
@@ -84,8 +107,8 @@ func (h *Handler) UpdatePolicy(params EditPolicyParams) error {
 }
 
 // RemovePolicy removes a snapshot policy
-func (h *Handler) RemovePolicy(policyID string, removeSnapshots bool) error {
-	return h.manager.RemovePolicy(policyID, removeSnapshots)
+func (h *Handler) RemovePolicy(ctx context.Context, policyID string, removeSnapshots bool) error {
+	return h.manager.RemovePolicy(ctx, policyID, removeSnapshots)
 }
 
 // GetPolicy gets a snapshot policy by ID
@@ -99,8 +122,8 @@ func (h *Handler) ListPolicies() ([]SnapshotPolicy, error) {
 }
 
 // RunPolicy runs a snapshot policy
-func (h *Handler) RunPolicy(params RunPolicyParams) (CreateSnapshotResult, error) {
-	return h.manager.RunPolicy(params)
+func (h *Handler) RunPolicy(ctx context.Context, params RunPolicyParams) (CreateSnapshotResult, error) {
+	return h.manager.RunPolicy(ctx, params)
 }
 
 // Start starts the scheduler
@@ -119,8 +142,8 @@ func (h *Handler) LoadConfig() error {
 }
 
 // SaveConfig saves the configuration
-func (h *Handler) SaveConfig(skipLock bool) error {
-	return h.manager.SaveConfig(skipLock)
+func (h *Handler) SaveConfig() error {
+	return h.manager.SaveConfig()
 }
 
 // createPolicy creates a new snapshot policy
@@ -158,12 +181,37 @@ func (h *Handler) listPolicies(c *gin.Context) {
 		return
 	}
 
+	if sc := scope.FromContext(c.Request.Context()); sc != nil {
+		policies = slices.DeleteFunc(policies, func(p SnapshotPolicy) bool {
+			return !sc.AllowsPolicy(p.ID, p.Dataset)
+		})
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"policies": policies,
 		"count":    len(policies),
 	})
 }
 
+// allowedByScope reports whether the request may manage the policy, and
+// responds with the denial when a delegated key may not
+func (h *Handler) allowedByScope(c *gin.Context, id string) bool {
+	sc := scope.FromContext(c.Request.Context())
+	if sc == nil {
+		return true
+	}
+	dataset := ""
+	if p, err := h.manager.GetPolicy(id); err == nil {
+		dataset = p.Dataset
+	}
+	if !sc.AllowsPolicy(id, dataset) {
+		err := sc.Denied(scope.KindPolicy, id)
+		c.JSON(errors.GetHTTPStatus(err), err)
+		return false
+	}
+	return true
+}
+
 // getPolicy gets a snapshot policy by ID
 func (h *Handler) getPolicy(c *gin.Context) {
 	id := c.Param("id")
@@ -175,6 +223,10 @@ func (h *Handler) getPolicy(c *gin.Context) {
 		return
 	}
 
+	if !h.allowedByScope(c, id) {
+		return
+	}
+
 	policy, err := h.manager.GetPolicy(id)
 	if err != nil {
 		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
@@ -204,6 +256,25 @@ func (h *Handler) updatePolicy(c *gin.Context) {
 	// Set the ID from path parameter
 	params.ID = id
 
+	if !h.allowedByScope(c, id) {
+		return
+	}
+	// A delegated key may not move the policy out of its datasets
+	if sc := scope.FromContext(c.Request.Context()); sc != nil && !sc.AllowsDataset(params.Dataset) {
+		if current, err := h.manager.GetPolicy(id); err != nil || current.Dataset != params.Dataset {
+			err := sc.Denied(scope.KindDataset, params.Dataset)
+			c.JSON(errors.GetHTTPStatus(err), err)
+			return
+		}
+	}
+
+	if h.jobQueue != nil && jobs.WantsAsync(c) {
+		if err := jobs.Accepted(c, h.jobQueue, OpUpdatePolicy, params); err != nil {
+			c.JSON(errors.GetHTTPStatus(err), err)
+		}
+		return
+	}
+
 	err := h.manager.UpdatePolicy(params)
 	if err != nil {
 		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
@@ -220,6 +291,55 @@ func (h *Handler) updatePolicy(c *gin.Context) {
 	c.JSON(http.StatusOK, policy)
 }
 
+// reloadPolicies applies edits made to the config file by hand
+func (h *Handler) reloadPolicies(c *gin.Context) {
+	result, err := h.manager.ReloadConfig()
+	if err != nil {
+		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// listTrashedPolicies lists the removed policies that can be restored
+func (h *Handler) listTrashedPolicies(c *gin.Context) {
+	policies, err := h.manager.ListTrashedPolicies()
+	if err != nil {
+		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policies": policies,
+		"count":    len(policies),
+	})
+}
+
+// restorePolicy restores a removed policy from the trash
+func (h *Handler) restorePolicy(c *gin.Context) {
+	policy, err := h.manager.RestorePolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// policyRemovalRequest describes the removal of a policy to the guard
+// rules. Its targets are the policy's ID, name and dataset.
+func policyRemovalRequest(m *Manager, policyID string) guard.Request {
+	targets := []string{policyID}
+	if p, err := m.GetPolicy(policyID); err == nil {
+		targets = append(targets, p.Name, p.Dataset)
+	}
+	return guard.Request{
+		Operation: guard.OpSnapshotPolicyRemove,
+		Targets:   targets,
+	}
+}
+
 // deletePolicy deletes a snapshot policy
 func (h *Handler) deletePolicy(c *gin.Context) {
 	id := c.Param("id")
@@ -242,7 +362,16 @@ func (h *Handler) deletePolicy(c *gin.Context) {
 		return
 	}
 
-	err = h.manager.RemovePolicy(id, removeSnapshots)
+	if !h.allowedByScope(c, id) {
+		return
+	}
+
+	if err := guard.Check(c.Request.Context(), policyRemovalRequest(h.manager, id)); err != nil {
+		c.JSON(errors.GetHTTPStatus(err), err)
+		return
+	}
+
+	err = h.manager.RemovePolicy(c.Request.Context(), id, removeSnapshots)
 	if err != nil {
 		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
 		return
@@ -276,6 +405,10 @@ func (h *Handler) runPolicy(c *gin.Context) {
 	// Set the ID from path parameter (this takes precedence over any ID in the body)
 	params.ID = id
 
+	if !h.allowedByScope(c, id) {
+		return
+	}
+
 	// Create a context with timeout
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
 	defer cancel()
@@ -283,7 +416,7 @@ func (h *Handler) runPolicy(c *gin.Context) {
 	// Replace the context in the gin context
 	c.Request = c.Request.WithContext(ctx)
 
-	result, err := h.manager.RunPolicy(params)
+	result, err := h.manager.RunPolicy(ctx, params)
 	if err != nil {
 		c.JSON(errors.GetHTTPStatus(err), errors.Wrap(err, errors.ZFSSnapshotPolicyError))
 		return
@@ -296,5 +429,70 @@ func (h *Handler) runPolicy(c *gin.Context) {
 		"created_at":       result.CreatedAt,
 		"pruned_snapshots": result.PrunedSnapshots,
 		"pruned_count":     len(result.PrunedSnapshots),
+		"prune_results":    result.PruneResults,
+		"dry_run":          result.DryRun,
+	})
+}
+
+// triggerPolicy runs a snapshot policy for an external system, such as a
+// backup orchestrator or CI pipeline. The snapshot is named, pruned and
+// replicated like any other run of the policy. The body is optional.
+func (h *Handler) triggerPolicy(c *gin.Context) {
+	var req TriggerPolicyParams
+	if err := c.ShouldBindJSON(&req); err != nil && !stderrors.Is(err, io.EOF) {
+		APIError(c, errors.New(errors.ZFSRequestValidationError, err.Error()))
+		return
+	}
+	if err := ValidateSnapSuffix(req.Suffix); err != nil {
+		APIError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 60*time.Second)
+	defer cancel()
+
+	result, err := h.manager.RunPolicy(ctx, RunPolicyParams{
+		ID:            c.Param("id"),
+		ScheduleIndex: req.ScheduleIndex,
+		Suffix:        req.Suffix,
+	})
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"policy_id":        result.PolicyID,
+		"dataset":          result.DatasetName,
+		"snapshot":         result.SnapshotName,
+		"created_at":       result.CreatedAt,
+		"pruned_snapshots": result.PrunedSnapshots,
+		"pruned_count":     len(result.PrunedSnapshots),
+		"triggered_by":     common.ActorFromContext(c.Request.Context()).User,
 	})
 }
+
+// backfillTags tags the snapshots policies took before snapshots were
+// tagged. Dry-runs answer with the report; otherwise the back-fill runs as
+// an operation when there is a job queue.
+func (h *Handler) backfillTags(c *gin.Context) {
+	var params BackfillTagsParams
+	if err := c.ShouldBindJSON(&params); err != nil && !stderrors.Is(err, io.EOF) {
+		APIError(c, errors.New(errors.ZFSRequestValidationError, err.Error()))
+		return
+	}
+
+	if !params.DryRun && h.jobQueue != nil {
+		if err := jobs.Accepted(c, h.jobQueue, OpBackfillTags, params); err != nil {
+			APIError(c, err)
+		}
+		return
+	}
+
+	report, err := h.manager.BackfillTags(c.Request.Context(), params.DryRun)
+	if err != nil {
+		APIError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,108 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
+)
+
+// UseCalendars lets schedules reference calendars of blocked days, and keeps
+// calendars that snapshot policies reference from being deleted
+func (m *Manager) UseCalendars(cm *calendars.Manager) {
+	cm.RegisterUsage(m.policiesUsingCalendar)
+	m.calendarManager.Store(cm)
+}
+
+// policiesUsingCalendar describes the policies with a schedule referencing
+// the calendar
+func (m *Manager) policiesUsingCalendar(calendar string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []string
+	for _, p := range m.config.Policies {
+		for _, s := range p.Schedules {
+			if s.Calendar == calendar {
+				users = append(users, fmt.Sprintf("snapshot policy %s", p.Name))
+				break
+			}
+		}
+	}
+	return users
+}
+
+// validateCalendarRefs checks that every calendar the schedules reference exists
+func (m *Manager) validateCalendarRefs(schedules []ScheduleSpec) error {
+	cm := m.calendarManager.Load()
+	for i, s := range schedules {
+		if err := cm.ValidateRef(s.Calendar, s.CalendarAction); err != nil {
+			return errors.Wrap(err, errors.ZFSRequestValidationError).
+				WithMetadata("schedule_index", fmt.Sprintf("%d", i))
+		}
+	}
+	return nil
+}
+
+// calendarKey identifies shifted runs of a policy schedule
+func calendarKey(policyID string, scheduleIndex int) string {
+	return fmt.Sprintf("%s%d", calendarKeyPrefix(policyID), scheduleIndex)
+}
+
+// calendarKeyPrefix matches the shifted runs of every schedule of a policy
+func calendarKeyPrefix(policyID string) string {
+	return fmt.Sprintf("snapshot-policy:%s:", policyID)
+}
+
+// gateRun applies the schedule's calendar to a run due now. When the run is
+// blocked, the reason is recorded on the policy monitor and, for shifted
+// runs, run is called on the next open day. Runs due while schedulers are
+// paused for maintenance are held and run when they resume.
+func (m *Manager) gateRun(
+	policyID string,
+	scheduleIndex int,
+	schedule ScheduleSpec,
+	run func(),
+) bool {
+	key := calendarKey(policyID, scheduleIndex)
+	held, reason := m.scheduler.Hold(key, run)
+	ok := !held
+	if ok {
+		ok, reason = m.calendarManager.Load().Gate(
+			schedule.Calendar,
+			schedule.CalendarAction,
+			key,
+			time.Now(),
+			run,
+		)
+	}
+	if ok {
+		return true
+	}
+
+	m.mu.Lock()
+	monitor, exists := m.config.Monitors[policyID]
+	if !exists {
+		monitor = JobMonitor{
+			PolicyID:   policyID,
+			ScheduleID: scheduleIndex,
+		}
+	}
+	monitor.LastSkipReason = reason
+	m.config.Monitors[policyID] = monitor
+	m.mu.Unlock()
+
+	return false
+}
+
+// cancelShiftedRuns drops pending shifted runs of a policy whose jobs are
+// being removed
+func (m *Manager) cancelShiftedRuns(policyID string) {
+	m.calendarManager.Load().CancelShifted(calendarKeyPrefix(policyID))
+	m.scheduler.DropHeld(calendarKeyPrefix(policyID))
+}
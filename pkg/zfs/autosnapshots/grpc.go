@@ -5,10 +5,12 @@
 package autosnapshots
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/stratastor/rodent/internal/toggle/client"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 	"github.com/stratastor/toggle-rodent-proto/proto"
 )
@@ -210,8 +212,13 @@ func handleDeletePolicy(h *GRPCHandler) client.CommandHandler {
 			return nil, errors.New(errors.ZFSRequestValidationError, "policy ID is required")
 		}
 
+		ctx := guard.WithApproval(context.Background(), guard.ApprovalFromPayload(cmd.Payload))
+		if err := guard.Check(ctx, policyRemovalRequest(h.manager, payload.ID)); err != nil {
+			return nil, err
+		}
+
 		// Call the manager's RemovePolicy method
-		if err := h.manager.RemovePolicy(payload.ID, payload.RemoveSnapshots); err != nil {
+		if err := h.manager.RemovePolicy(ctx, payload.ID, payload.RemoveSnapshots); err != nil {
 			return nil, errors.Wrap(err, errors.ZFSSnapshotPolicyError)
 		}
 
@@ -242,7 +249,8 @@ func handleRunPolicy(h *GRPCHandler) client.CommandHandler {
 		}
 
 		// Call the manager's RunPolicy method
-		result, err := h.manager.RunPolicy(params)
+		ctx := context.Background()
+		result, err := h.manager.RunPolicy(ctx, params)
 		if err != nil {
 			return nil, errors.Wrap(err, errors.ZFSSnapshotPolicyError)
 		}
@@ -254,6 +262,8 @@ func handleRunPolicy(h *GRPCHandler) client.CommandHandler {
 			"created_at":       result.CreatedAt,
 			"pruned_snapshots": result.PrunedSnapshots,
 			"pruned_count":     len(result.PrunedSnapshots),
+			"prune_results":    result.PruneResults,
+			"dry_run":          result.DryRun,
 		}
 
 		return successResponse(req.RequestId, "Snapshot policy executed successfully", response)
@@ -0,0 +1,110 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// MaxRunHistory bounds the run records a monitor keeps
+const MaxRunHistory = 20
+
+// RunRecord records the size and duration of one successful run
+type RunRecord struct {
+	At             time.Time     `json:"at"                        yaml:"at"`
+	Duration       time.Duration `json:"duration"                  yaml:"duration"`
+	Bytes          int64         `json:"bytes"                     yaml:"bytes"`                     // Written bytes of a snapshot, or bytes sent by a transfer
+	EstimatedBytes int64         `json:"estimated_bytes,omitempty" yaml:"estimated_bytes,omitempty"` // Stream size predicted by `zfs send -nP`, for transfers
+}
+
+// RunEstimate predicts the next run from the runs before it
+type RunEstimate struct {
+	Duration   time.Duration `json:"duration"`
+	Bytes      int64         `json:"bytes"`
+	Throughput int64         `json:"throughput,omitempty"` // Bytes per second, zero when runs moved no data
+	Samples    int           `json:"samples"`
+}
+
+// AppendRunRecord appends rec to runs, keeping the MaxRunHistory most recent
+func AppendRunRecord(runs []RunRecord, rec RunRecord) []RunRecord {
+	runs = append(runs, rec)
+	if len(runs) > MaxRunHistory {
+		runs = append([]RunRecord(nil), runs[len(runs)-MaxRunHistory:]...)
+	}
+	return runs
+}
+
+// EstimateNextRun averages the sizes and durations of runs. It returns nil
+// when there are no runs to go by.
+func EstimateNextRun(runs []RunRecord) *RunEstimate {
+	if len(runs) == 0 {
+		return nil
+	}
+
+	var duration time.Duration
+	var bytes int64
+	for _, r := range runs {
+		duration += r.Duration
+		bytes += r.Bytes
+	}
+
+	est := &RunEstimate{
+		Duration: duration / time.Duration(len(runs)),
+		Bytes:    bytes / int64(len(runs)),
+		Samples:  len(runs),
+	}
+	if seconds := duration.Seconds(); seconds > 0 {
+		est.Throughput = int64(float64(bytes) / seconds)
+	}
+	return est
+}
+
+// DurationFor predicts how long a run moving bytes takes. Without a size or
+// a known throughput, the average duration is returned.
+func (e *RunEstimate) DurationFor(bytes int64) time.Duration {
+	if e == nil {
+		return 0
+	}
+	if bytes <= 0 || e.Throughput <= 0 {
+		return e.Duration
+	}
+	return time.Duration(float64(bytes) / float64(e.Throughput) * float64(time.Second))
+}
+
+// snapshotWrittenBytes returns the written property of a snapshot: the data
+// written to its dataset since the previous snapshot, which is roughly the
+// size of an incremental stream from that snapshot. Zero is returned when
+// the property cannot be read.
+func (m *Manager) snapshotWrittenBytes(ctx context.Context, snapshot string) int64 {
+	result, err := m.dsManager.GetProperty(ctx, dataset.PropertyConfig{
+		NameConfig: dataset.NameConfig{Name: snapshot},
+		Property:   "written",
+		Parsable:   true,
+	})
+	if err != nil {
+		m.logger.Debug("Failed to read written bytes of snapshot",
+			"snapshot", snapshot,
+			"error", err)
+		return 0
+	}
+
+	prop, ok := result.Datasets[snapshot].Properties["written"]
+	if !ok {
+		return 0
+	}
+	switch v := prop.Value.(type) {
+	case float64:
+		return int64(v)
+	case string:
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+// SnapshotListener is called after a policy creates a snapshot
+type SnapshotListener func(result CreateSnapshotResult)
+
+// OnSnapshotCreated registers a listener for snapshots created by policies,
+// whether by a schedule or a manual run. Listeners run in their own goroutine
+// so a slow listener cannot hold up the scheduler.
+func (m *Manager) OnSnapshotCreated(fn SnapshotListener) {
+	m.listenersMu.Lock()
+	defer m.listenersMu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// notifySnapshotCreated passes a created snapshot to the registered listeners
+func (m *Manager) notifySnapshotCreated(result CreateSnapshotResult) {
+	m.listenersMu.RLock()
+	defer m.listenersMu.RUnlock()
+
+	for _, fn := range m.listeners {
+		go fn(result)
+	}
+}
@@ -6,14 +6,17 @@ package autosnapshots
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"slices"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"maps"
@@ -22,7 +25,11 @@ import (
 	"github.com/google/uuid"
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/lockwatch"
+	"github.com/stratastor/rodent/internal/schedwatch"
+	"github.com/stratastor/rodent/internal/trash"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 	"gopkg.in/yaml.v3"
 )
@@ -40,10 +47,46 @@ type Manager struct {
 	configPath string
 	config     SnapshotConfig
 	dsManager  *dataset.Manager
-	scheduler  gocron.Scheduler
+	scheduler  *schedwatch.Scheduler
 	jobMapping map[string][]string // Maps policyID to list of job IDs
-	mu         sync.RWMutex
-	started    bool // Track if the manager has been started
+	started    bool                // Track if the manager has been started
+
+	// mu guards the in-memory index: config, policyIndex, policyLocks,
+	// jobMapping and started. It is held only to read or update them, never
+	// across ZFS commands, scheduler calls or config writes.
+	mu lockwatch.RWMutex
+
+	// policyIndex maps a policy ID to its position in config.Policies
+	policyIndex map[string]int
+
+	// policyLocks serialize the runs, updates and removal of each policy.
+	// RemovePolicy drops the lock of the policy it removes; callers that were
+	// waiting on it take the lock again, so they cannot race a policy
+	// re-added with the same ID.
+	policyLocks map[string]*sync.Mutex
+
+	// saveMu orders config writes so an older state never overwrites a
+	// newer one
+	saveMu sync.Mutex
+
+	// savedHash is the hash of the config file as last loaded or saved, so
+	// the watcher can tell the manager's own writes from edits. Guarded by
+	// saveMu.
+	savedHash [sha256.Size]byte
+
+	// stopWatch stops the config file watcher; nil when not watching
+	stopWatch chan struct{}
+
+	// policyTrash keeps removed policies so they can be restored
+	policyTrash *trash.Bin
+
+	// calendarManager resolves the calendars schedules reference; nil until
+	// UseCalendars is called
+	calendarManager atomic.Pointer[calendars.Manager]
+
+	// listeners are notified of snapshots created by policies
+	listenersMu sync.RWMutex
+	listeners   []SnapshotListener
 }
 
 // Global instance and mutex for singleton pattern
@@ -110,7 +153,7 @@ func newManager(dsManager *dataset.Manager, cfgDir string) (*Manager, error) {
 
 	// Create the scheduler with default options
 	l.Debug("Creating scheduler")
-	scheduler, err := gocron.NewScheduler()
+	scheduler, err := schedwatch.New("snapshot-policies", l)
 	if err != nil {
 		l.Error("Failed to create scheduler", "error", err)
 		return nil, errors.Wrap(err, errors.SchedulerError)
@@ -127,12 +170,138 @@ func newManager(dsManager *dataset.Manager, cfgDir string) (*Manager, error) {
 			Policies: make([]SnapshotPolicy, 0),
 			Monitors: make(map[string]JobMonitor),
 		},
+		policyIndex: make(map[string]int),
+		policyLocks: make(map[string]*sync.Mutex),
+		policyTrash: trash.New(filepath.Join(configDir, policyTrashDir), trash.Retention()),
 	}
+	lockwatch.Watch("snapshot-manager", &manager.mu)
 
 	l.Info("Snapshot manager initialized successfully")
 	return manager, nil
 }
 
+// lockPolicy takes the lock serializing operations on a policy and returns
+// the function releasing it
+func (m *Manager) lockPolicy(policyID string) func() {
+	for {
+		m.mu.Lock()
+		l, ok := m.policyLocks[policyID]
+		if !ok {
+			l = &sync.Mutex{}
+			m.policyLocks[policyID] = l
+		}
+		m.mu.Unlock()
+
+		l.Lock()
+
+		// The policy was removed while we waited and its lock dropped
+		m.mu.RLock()
+		current := m.policyLocks[policyID] == l
+		m.mu.RUnlock()
+		if current {
+			return l.Unlock
+		}
+		l.Unlock()
+	}
+}
+
+// reindex rebuilds the policy index after policies are added, removed or
+// loaded. The caller must hold mu.
+func (m *Manager) reindex() {
+	m.policyIndex = make(map[string]int, len(m.config.Policies))
+	for i, p := range m.config.Policies {
+		m.policyIndex[p.ID] = i
+	}
+}
+
+// lookupPolicy returns a copy of a policy. The caller must hold mu.
+func (m *Manager) lookupPolicy(policyID string) (SnapshotPolicy, bool) {
+	i, ok := m.policyIndex[policyID]
+	if !ok {
+		return SnapshotPolicy{}, false
+	}
+	return m.config.Policies[i], true
+}
+
+// updatePolicyState applies fn to a policy in place, reporting whether the
+// policy exists
+func (m *Manager) updatePolicyState(policyID string, fn func(p *SnapshotPolicy)) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.policyIndex[policyID]
+	if ok {
+		fn(&m.config.Policies[i])
+	}
+	return ok
+}
+
+// scheduleJobs creates the jobs of a policy's enabled schedules and records
+// them in the job mapping, including those created before a failure so they
+// are removed with the policy
+func (m *Manager) scheduleJobs(policy SnapshotPolicy) ([]string, error) {
+	jobIDs := []string{}
+	var err error
+	for i, schedule := range policy.Schedules {
+		if !schedule.Enabled {
+			continue
+		}
+		m.logger.Debug("Creating job for enabled schedule",
+			"policy_id", policy.ID,
+			"policy_name", policy.Name,
+			"schedule_index", i,
+			"schedule_type", schedule.Type)
+
+		var jobID string
+		if jobID, err = m.createJob(policy, i); err != nil {
+			err = errors.Wrap(err, errors.SchedulerError).
+				WithMetadata("schedule_index", fmt.Sprintf("%d", i))
+			break
+		}
+		if jobID != "" {
+			jobIDs = append(jobIDs, jobID)
+		}
+	}
+
+	m.mu.Lock()
+	m.jobMapping[policy.ID] = jobIDs
+	m.mu.Unlock()
+	return jobIDs, err
+}
+
+// unscheduleJobs removes a policy's jobs and its pending shifted runs
+func (m *Manager) unscheduleJobs(policyID string) {
+	m.cancelShiftedRuns(policyID)
+
+	m.mu.Lock()
+	jobIDs := m.jobMapping[policyID]
+	delete(m.jobMapping, policyID)
+	m.mu.Unlock()
+
+	if len(jobIDs) > 0 {
+		m.logger.Debug("Removing jobs for policy",
+			"policy_id", policyID,
+			"jobs_count", len(jobIDs))
+	}
+
+	for _, jobID := range jobIDs {
+		jobUUID, parseErr := uuid.Parse(jobID)
+		if parseErr != nil {
+			m.logger.Warn("Failed to parse job UUID",
+				"job_id", jobID,
+				"error", parseErr)
+			continue
+		}
+		if err := m.scheduler.RemoveJob(jobUUID); err != nil {
+			m.logger.Warn("Failed to remove job",
+				"job_id", jobID,
+				"error", err)
+		} else {
+			m.logger.Debug("Removed job successfully", "job_id", jobID)
+		}
+	}
+}
+
 // createJob creates a gocron job for the given policy and schedule
 func (m *Manager) createJob(policy SnapshotPolicy, scheduleIndex int) (string, error) {
 	if scheduleIndex >= len(policy.Schedules) {
@@ -180,9 +349,24 @@ func (m *Manager) createJob(policy SnapshotPolicy, scheduleIndex int) (string, e
 	}
 
 	// Create a task function that will run the snapshot
-	taskFn := func(ctx context.Context) (any, error) {
+	var taskFn func(ctx context.Context) (any, error)
+	taskFn = func(ctx context.Context) (any, error) {
+		// Runs due on a calendar's blocked day are skipped or shifted
+		// A shifted run keeps the job's context, so it is cancelled with the
+		// job on shutdown or when the policy changes
+		if !m.gateRun(policy.ID, scheduleIndex, schedule, func() {
+			_, _ = taskFn(ctx)
+		}) {
+			return nil, nil
+		}
+
+		// A panic fails this run of the policy only
 		start := time.Now()
-		result, err := m.createSnapshot(policy.ID, scheduleIndex)
+		var result CreateSnapshotResult
+		err := m.scheduler.Run(policy.ID, func() (err error) {
+			result, err = m.createSnapshot(ctx, policy.ID, scheduleIndex, "")
+			return err
+		})
 		duration := time.Since(start)
 
 		// Update the monitor
@@ -198,6 +382,7 @@ func (m *Manager) createJob(policy SnapshotPolicy, scheduleIndex int) (string, e
 		monitor.LastRunAt = time.Now()
 		monitor.LastDuration = duration
 		monitor.RunCount = monitor.RunCount + 1
+		monitor.LastSkipReason = ""
 
 		if err != nil {
 			monitor.Status = "error"
@@ -205,23 +390,26 @@ func (m *Manager) createJob(policy SnapshotPolicy, scheduleIndex int) (string, e
 		} else {
 			monitor.Status = "success"
 			monitor.LastError = ""
+			monitor.RecentRuns = AppendRunRecord(monitor.RecentRuns, RunRecord{
+				At:       start,
+				Duration: duration,
+				Bytes:    result.WrittenBytes,
+			})
+			monitor.NextRunEstimate = EstimateNextRun(monitor.RecentRuns)
 		}
 
 		m.config.Monitors[policy.ID] = monitor
+		m.mu.Unlock()
 
 		// Update the policy
-		for i, p := range m.config.Policies {
-			if p.ID == policy.ID {
-				m.config.Policies[i].LastRunAt = time.Now()
-				m.config.Policies[i].LastRunStatus = monitor.Status
-				m.config.Policies[i].LastRunError = monitor.LastError
-				break
-			}
-		}
-		m.mu.Unlock()
+		m.updatePolicyState(policy.ID, func(p *SnapshotPolicy) {
+			p.LastRunAt = time.Now()
+			p.LastRunStatus = monitor.Status
+			p.LastRunError = monitor.LastError
+		})
 
 		// Save config with updated monitor status
-		_ = m.SaveConfig(false)
+		_ = m.SaveConfig()
 
 		return result, err
 	}
@@ -442,22 +630,22 @@ func ParseAtTime(atTime string) (hour, min, sec uint) {
 }
 
 // createSnapshot creates a snapshot for the given policy and schedule
-func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnapshotResult, error) {
+func (m *Manager) createSnapshot(
+	ctx context.Context,
+	policyID string,
+	scheduleIndex int,
+	suffix string,
+) (CreateSnapshotResult, error) {
 	m.logger.Debug("Creating snapshot",
 		"policy_id", policyID,
 		"schedule_index", scheduleIndex)
 
-	m.mu.RLock()
-	var policy SnapshotPolicy
-	found := false
+	// Runs of a policy are serialized with each other and with its updates
+	// and removal
+	defer m.lockPolicy(policyID)()
 
-	for _, p := range m.config.Policies {
-		if p.ID == policyID {
-			policy = p
-			found = true
-			break
-		}
-	}
+	m.mu.RLock()
+	policy, found := m.lookupPolicy(policyID)
 	m.mu.RUnlock()
 
 	if !found {
@@ -488,14 +676,16 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 		"dataset", policy.Dataset,
 		"schedule_index", scheduleIndex)
 
+	start := time.Now()
+
 	// Generate snapshot name based on pattern
-	snapName := expandSnapNamePattern(
+	snapName := withSnapSuffix(expandSnapNamePattern(
 		policyID,
 		policy.Name,
 		scheduleIndex,
 		policy.SnapNamePattern,
 		time.Now(),
-	)
+	), suffix)
 
 	// Create snapshot config
 	snapshotCfg := dataset.SnapshotConfig{
@@ -504,11 +694,10 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 		},
 		SnapName:   snapName,
 		Recursive:  policy.Recursive,
-		Properties: policy.Properties,
+		Properties: snapshotProperties(policy, scheduleIndex),
 	}
 
 	// Create the snapshot
-	ctx := context.Background()
 	m.logger.Debug("Calling dataset manager to create snapshot",
 		"policy_id", policyID,
 		"dataset", policy.Dataset,
@@ -537,21 +726,28 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 		"dataset", policy.Dataset,
 		"snap_name", snapName)
 
-	// Update policy status
-	m.mu.Lock()
-	for i, p := range m.config.Policies {
-		if p.ID == policyID {
-			m.config.Policies[i].LastRunAt = time.Now()
-			m.config.Policies[i].LastRunStatus = "success"
-			m.config.Policies[i].LastRunError = ""
-			break
+	// zfs snapshot -r has no exclusion support, so drop the snapshots taken
+	// on excluded children right after the atomic recursive snapshot
+	if policy.Recursive && len(policy.ExcludeDatasets) > 0 {
+		if err := m.destroyExcludedSnapshots(ctx, policy, snapName); err != nil {
+			m.logger.Warn("Failed to remove snapshots of excluded datasets",
+				"policy_id", policyID,
+				"snap_name", snapName,
+				"error", err)
 		}
 	}
-	m.mu.Unlock()
+
+	// Update policy status
+	m.updatePolicyState(policyID, func(p *SnapshotPolicy) {
+		p.LastRunAt = time.Now()
+		p.LastRunStatus = "success"
+		p.LastRunError = ""
+	})
 
 	// Prune old snapshots if retention policy is set
 	prunedSnapshots := []string{}
-	if policy.RetentionPolicy.Count > 0 || policy.RetentionPolicy.OlderThan > 0 {
+	var pruneResults []PruneResult
+	if policy.HasRetention() {
 		m.logger.Debug("Pruning old snapshots based on retention policy",
 			"policy_id", policyID,
 			"policy_name", policy.Name,
@@ -559,7 +755,27 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 			"retention_count", policy.RetentionPolicy.Count,
 			"retention_older_than", policy.RetentionPolicy.OlderThan)
 
-		prunedSnapshots, err = m.pruneSnapshots(policy)
+		pruneResults, err = m.pruneSnapshots(ctx, policy, scheduleIndex, false)
+		skipped := 0
+		for _, pr := range pruneResults {
+			switch pr.Action {
+			case PruneActionDestroyed:
+				prunedSnapshots = append(prunedSnapshots, pr.Snapshot)
+			case PruneActionSkipped:
+				skipped++
+			}
+		}
+
+		m.updatePolicyState(policyID, func(p *SnapshotPolicy) {
+			p.LastPruneResults = pruneResults
+			if err == nil && skipped > 0 {
+				p.LastRunError = fmt.Sprintf(
+					"Snapshot created but %d snapshot(s) could not be pruned",
+					skipped,
+				)
+			}
+		})
+
 		if err != nil {
 			// Log the error but don't fail the snapshot creation
 			m.logger.Error("Snapshot pruning failed",
@@ -569,17 +785,12 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 				"error", err)
 
 			// Update the error string
-			m.mu.Lock()
-			for i, p := range m.config.Policies {
-				if p.ID == policyID {
-					m.config.Policies[i].LastRunError = fmt.Sprintf(
-						"Snapshot created but pruning failed: %s",
-						err.Error(),
-					)
-					break
-				}
-			}
-			m.mu.Unlock()
+			m.updatePolicyState(policyID, func(p *SnapshotPolicy) {
+				p.LastRunError = fmt.Sprintf(
+					"Snapshot created but pruning failed: %s",
+					err.Error(),
+				)
+			})
 		} else if len(prunedSnapshots) > 0 {
 			m.logger.Debug("Successfully pruned snapshots",
 				"policy_id", policyID,
@@ -590,7 +801,7 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 	}
 
 	// Save config after successful snapshot
-	if err := m.SaveConfig(false); err != nil {
+	if err := m.SaveConfig(); err != nil {
 		// Log but don't fail
 		m.logger.Error("Failed to save config after snapshot creation",
 			"policy_id", policyID,
@@ -600,28 +811,78 @@ func (m *Manager) createSnapshot(policyID string, scheduleIndex int) (CreateSnap
 			"error", err)
 	}
 
-	return CreateSnapshotResult{
+	result := CreateSnapshotResult{
 		PolicyID:        policyID,
 		ScheduleIndex:   scheduleIndex,
 		DatasetName:     policy.Dataset,
 		SnapshotName:    snapName,
 		CreatedAt:       time.Now(),
 		PrunedSnapshots: prunedSnapshots,
-	}, nil
+		PruneResults:    pruneResults,
+		WrittenBytes:    m.snapshotWrittenBytes(ctx, policy.Dataset+"@"+snapName),
+		Duration:        time.Since(start),
+	}
+	m.notifySnapshotCreated(result)
+
+	return result, nil
 }
 
-// listPolicySnapshots lists all snapshots associated with a given policy
-func (m *Manager) listPolicySnapshots(policy SnapshotPolicy) ([]struct {
+// previewSnapshot reports the snapshot a policy run would create and the
+// snapshots its retention policy would prune, without changing anything
+func (m *Manager) previewSnapshot(
+	ctx context.Context,
+	policy SnapshotPolicy,
+	scheduleIndex int,
+	suffix string,
+) (CreateSnapshotResult, error) {
+	result := CreateSnapshotResult{
+		PolicyID:      policy.ID,
+		ScheduleIndex: scheduleIndex,
+		DatasetName:   policy.Dataset,
+		SnapshotName: withSnapSuffix(expandSnapNamePattern(
+			policy.ID,
+			policy.Name,
+			scheduleIndex,
+			policy.SnapNamePattern,
+			time.Now(),
+		), suffix),
+		CreatedAt: time.Now(),
+		DryRun:    true,
+	}
+
+	if !policy.HasRetention() {
+		return result, nil
+	}
+
+	pruneResults, err := m.pruneSnapshots(ctx, policy, scheduleIndex, true)
+	if err != nil {
+		return result, err
+	}
+	result.PruneResults = pruneResults
+	for _, pr := range pruneResults {
+		if pr.Action == PruneActionWouldDestroy {
+			result.PrunedSnapshots = append(result.PrunedSnapshots, pr.Snapshot)
+		}
+	}
+
+	return result, nil
+}
+
+// policySnapshot is a snapshot taken by a policy
+type policySnapshot struct {
 	Name      string
 	CreatedAt time.Time
-}, error) {
+	Schedule  int // Index of the schedule that took it; -1 when unknown
+}
+
+// listPolicySnapshots lists all snapshots associated with a given policy
+func (m *Manager) listPolicySnapshots(ctx context.Context, policy SnapshotPolicy) ([]policySnapshot, error) {
 	// Get all snapshots for this dataset
-	ctx := context.Background()
 	listCfg := dataset.ListConfig{
 		Name:       policy.Dataset,
 		Type:       "snapshot",
 		Parsable:   true,
-		Properties: []string{"creation"},
+		Properties: []string{"creation", TagPolicyID, TagSchedule, dataset.TagPinned},
 	}
 
 	suffix := policy.ID
@@ -635,10 +896,8 @@ func (m *Manager) listPolicySnapshots(policy SnapshotPolicy) ([]struct {
 		return nil, errors.Wrap(err, errors.ZFSDatasetList)
 	}
 
-	snapshots := []struct {
-		Name      string
-		CreatedAt time.Time
-	}{}
+	snapshots := []policySnapshot{}
+	nameRegex := policySnapRegex(policy.ID)
 
 	// Extract snapshots and creation times
 	for name, ds := range result.Datasets {
@@ -647,14 +906,21 @@ func (m *Manager) listPolicySnapshots(policy SnapshotPolicy) ([]struct {
 			continue
 		}
 
-		// Skip snapshots that don't belong to this policy ID
+		// Skip snapshots that don't belong to this policy. Snapshots are
+		// matched by their policy tag, and those taken before snapshots were
+		// tagged by the policy ID suffix of their names.
 		snapName := strings.Split(name, "@")[1]
-		if !strings.HasSuffix(snapName, suffix) {
+		if id, ok := dataset.TagValue(ds, TagPolicyID); ok {
+			if id != policy.ID {
+				continue
+			}
+		} else if !strings.HasSuffix(snapName, suffix) {
 			continue
 		}
 
-		// Skip snapshots in the keep list
-		if slices.Contains(policy.RetentionPolicy.KeepNamedSnap, snapName) {
+		// Skip snapshots in the keep list, and pinned ones
+		if slices.Contains(policy.RetentionPolicy.KeepNamedSnap, snapName) ||
+			dataset.IsPinned(ds) {
 			continue
 		}
 
@@ -671,12 +937,21 @@ func (m *Manager) listPolicySnapshots(policy SnapshotPolicy) ([]struct {
 			}
 		}
 
-		snapshots = append(snapshots, struct {
-			Name      string
-			CreatedAt time.Time
-		}{
+		// The schedule comes from its tag, or else the schedule index in
+		// the name
+		schedule := -1
+		if v, ok := dataset.TagValue(ds, TagSchedule); ok {
+			if idx, err := strconv.Atoi(v); err == nil {
+				schedule = idx
+			}
+		} else if match := nameRegex.FindStringSubmatch(snapName); match != nil {
+			schedule, _ = strconv.Atoi(match[1])
+		}
+
+		snapshots = append(snapshots, policySnapshot{
 			Name:      name,
 			CreatedAt: creationTime,
+			Schedule:  schedule,
 		})
 	}
 
@@ -688,57 +963,224 @@ func (m *Manager) listPolicySnapshots(policy SnapshotPolicy) ([]struct {
 	return snapshots, nil
 }
 
-// pruneSnapshots prunes old snapshots based on the retention policy
-func (m *Manager) pruneSnapshots(policy SnapshotPolicy) ([]string, error) {
-	prunedSnapshots := []string{}
+// pruneSnapshots prunes old snapshots based on the retention policy and
+// reports the outcome for every snapshot selected for deletion. Schedules with
+// their own retention are pruned apart from the rest of the policy's
+// snapshots. In dry-run mode nothing is destroyed; `zfs destroy -nv` is used
+// to estimate reclaimed space and to surface snapshots that could not be
+// destroyed.
+func (m *Manager) pruneSnapshots(
+	ctx context.Context,
+	policy SnapshotPolicy,
+	scheduleIndex int,
+	dryRun bool,
+) ([]PruneResult, error) {
+	results := []PruneResult{}
 
 	// Get all snapshots for this policy
-	snapshots, err := m.listPolicySnapshots(policy)
+	snapshots, err := m.listPolicySnapshots(ctx, policy)
 	if err != nil {
-		return prunedSnapshots, err
+		return results, err
+	}
+
+	// Newer snapshots seen so far, by retention class
+	newer := make(map[int]int)
+	// A dry run happens before the new snapshot exists, so leave room for it
+	// in the class of the schedule taking it
+	if dryRun {
+		newer[policy.retentionClass(scheduleIndex)]++
 	}
 
 	// Apply retention policy
-	ctx := context.Background()
-	for i, snap := range snapshots {
+	for _, snap := range snapshots {
+		// Stop between snapshots once the run is cancelled
+		if ctx.Err() != nil {
+			return results, errors.New(errors.CommandContext, "pruning cancelled").
+				WithMetadata("policy_id", policy.ID)
+		}
+
+		class := policy.retentionClass(snap.Schedule)
+		keepCount, olderThan := policy.retentionFor(class)
 		shouldDelete := false
 
 		// Apply count-based retention
-		if policy.RetentionPolicy.Count > 0 && i >= policy.RetentionPolicy.Count {
+		if keepCount > 0 && newer[class] >= keepCount {
 			shouldDelete = true
 		}
+		newer[class]++
 
 		// Apply time-based retention
-		if policy.RetentionPolicy.OlderThan > 0 {
-			if time.Since(snap.CreatedAt) > policy.RetentionPolicy.OlderThan {
+		if olderThan > 0 {
+			if time.Since(snap.CreatedAt) > olderThan {
 				shouldDelete = true
 			}
 		}
 
-		if shouldDelete {
-			// Delete the snapshot
-			destroyCfg := dataset.DestroyConfig{
-				NameConfig: dataset.NameConfig{
-					Name: snap.Name,
-				},
-				Force: policy.RetentionPolicy.ForceDestroy,
-				// TODO: Support DeferDestroy in the SnapshotPolicy
-				DeferDestroy: true,
-				// TODO: Support RecursiveDestroyDependents in the SnapshotPolicy
-				RecursiveDestroyChildren: policy.Recursive,
-			}
+		if !shouldDelete {
+			continue
+		}
 
-			_, err := m.dsManager.Destroy(ctx, destroyCfg)
-			if err != nil {
-				// Continue with other snapshots
-				continue
-			}
+		// Delete the snapshot
+		destroyCfg := dataset.DestroyConfig{
+			NameConfig: dataset.NameConfig{
+				Name: snap.Name,
+			},
+			Force: policy.RetentionPolicy.ForceDestroy,
+			// TODO: Support DeferDestroy in the SnapshotPolicy
+			// Deferred destroy is not meaningful for a dry run: -n with -d
+			// would report held snapshots as destroyable
+			DeferDestroy: !dryRun,
+			// TODO: Support RecursiveDestroyDependents in the SnapshotPolicy
+			RecursiveDestroyChildren: policy.Recursive,
+			DryRun:                   dryRun,
+		}
+
+		var destroyResult dataset.DestroyResult
+		if policy.Recursive && len(policy.ExcludeDatasets) > 0 {
+			destroyResult, err = m.destroyIncludedSnapshots(ctx, policy, destroyCfg)
+		} else {
+			destroyResult, err = m.dsManager.Destroy(ctx, destroyCfg)
+		}
+		if err != nil {
+			reason := pruneSkipReason(err)
+			m.logger.Warn("Snapshot could not be pruned",
+				"policy_id", policy.ID,
+				"snapshot", snap.Name,
+				"reason", reason)
+			results = append(results, PruneResult{
+				Snapshot: snap.Name,
+				Action:   PruneActionSkipped,
+				Reason:   reason,
+			})
+			// Continue with other snapshots
+			continue
+		}
 
-			prunedSnapshots = append(prunedSnapshots, snap.Name)
+		action := PruneActionDestroyed
+		if dryRun {
+			action = PruneActionWouldDestroy
 		}
+		results = append(results, PruneResult{
+			Snapshot:     snap.Name,
+			Action:       action,
+			ReclaimBytes: destroyResult.ReclaimBytes,
+		})
 	}
 
-	return prunedSnapshots, nil
+	return results, nil
+}
+
+// listSnapshotsByName returns every snapshot named snapName in the policy
+// dataset and its descendants
+func (m *Manager) listSnapshotsByName(
+	ctx context.Context,
+	policy SnapshotPolicy,
+	snapName string,
+) ([]string, error) {
+	result, err := m.dsManager.List(ctx, dataset.ListConfig{
+		Name:      policy.Dataset,
+		Type:      "snapshot",
+		Recursive: true,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSDatasetList)
+	}
+
+	names := []string{}
+	for name := range result.Datasets {
+		if strings.HasSuffix(name, "@"+snapName) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// destroyExcludedSnapshots removes a freshly created recursive snapshot from
+// datasets matched by the policy's exclusion patterns
+func (m *Manager) destroyExcludedSnapshots(
+	ctx context.Context,
+	policy SnapshotPolicy,
+	snapName string,
+) error {
+	snapshots, err := m.listSnapshotsByName(ctx, policy, snapName)
+	if err != nil {
+		return err
+	}
+
+	for _, snap := range snapshots {
+		datasetName := strings.SplitN(snap, "@", 2)[0]
+		if !policy.IsDatasetExcluded(datasetName) {
+			continue
+		}
+		if _, err := m.dsManager.Destroy(ctx, dataset.DestroyConfig{
+			NameConfig: dataset.NameConfig{Name: snap},
+		}); err != nil {
+			return err
+		}
+		m.logger.Debug("Removed snapshot of excluded dataset", "snapshot", snap)
+	}
+
+	return nil
+}
+
+// destroyIncludedSnapshots is the exclusion-aware replacement for a recursive
+// snapshot destroy: the snapshot is destroyed individually on the policy
+// dataset and on each descendant that is not excluded, so snapshots on
+// excluded datasets are left alone
+func (m *Manager) destroyIncludedSnapshots(
+	ctx context.Context,
+	policy SnapshotPolicy,
+	destroyCfg dataset.DestroyConfig,
+) (dataset.DestroyResult, error) {
+	combined := dataset.DestroyResult{Destroyed: []string{}}
+
+	snapName := strings.SplitN(destroyCfg.Name, "@", 2)[1]
+	snapshots, err := m.listSnapshotsByName(ctx, policy, snapName)
+	if err != nil {
+		return combined, err
+	}
+
+	// Destroy children before the policy dataset so a failure leaves the
+	// root snapshot, and with it the retention bookkeeping, in place
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return strings.Count(snapshots[i], "/") > strings.Count(snapshots[j], "/")
+	})
+	for _, snap := range snapshots {
+		datasetName := strings.SplitN(snap, "@", 2)[0]
+		if policy.IsDatasetExcluded(datasetName) {
+			continue
+		}
+
+		cfg := destroyCfg
+		cfg.Name = snap
+		cfg.RecursiveDestroyChildren = false
+		result, err := m.dsManager.Destroy(ctx, cfg)
+		if err != nil {
+			return combined, err
+		}
+		combined.Destroyed = append(combined.Destroyed, result.Destroyed...)
+		combined.ReclaimBytes += result.ReclaimBytes
+	}
+
+	return combined, nil
+}
+
+// pruneSkipReason extracts a readable reason from a failed snapshot destroy,
+// preferring the zfs output over the wrapped command error
+func pruneSkipReason(err error) string {
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		if output := strings.TrimSpace(rodentErr.Metadata["output"]); output != "" {
+			switch {
+			case strings.Contains(output, "dependent clones"):
+				return "snapshot has dependent clones: " + output
+			case strings.Contains(output, "dataset is busy"):
+				return "snapshot is busy or has user holds: " + output
+			}
+			return output
+		}
+	}
+	return err.Error()
 }
 
 // expandSnapNamePattern expands a snapshot name pattern with current time
@@ -794,6 +1236,78 @@ func expandSnapNamePattern(
 	return result
 }
 
+// snapNameTimeFormat matches the time conversions of a name pattern
+var snapNameTimeFormat = regexp.MustCompile(`%[YmdHMS]`)
+
+// NameFormat returns the strptime format the names of the policy's
+// snapshots start with, for tools that date snapshots by their names, such
+// as Samba's shadow_copy2. It ends before the first placeholder that is not
+// a time or the policy name, and is empty when the pattern has no time.
+func (p SnapshotPolicy) NameFormat() string {
+	format := strings.NewReplacer(
+		"{timestamp}", "%Y-%m-%d-%H%M%S",
+		"{date}", "%Y-%m-%d",
+		"{time}", "%H%M%S",
+		"{policy_name}", strings.ReplaceAll(p.Name, "%", "%%"),
+	).Replace(p.SnapNamePattern)
+	for _, placeholder := range []string{"{policy_id}", "{sequence}"} {
+		if i := strings.Index(format, placeholder); i >= 0 {
+			format = format[:i]
+		}
+	}
+	if !snapNameTimeFormat.MatchString(format) {
+		return ""
+	}
+	return format
+}
+
+// snapshotProperties returns the properties of a policy run's snapshot: the
+// policy's own properties and the tags identifying the run
+func snapshotProperties(policy SnapshotPolicy, scheduleIndex int) map[string]string {
+	props := maps.Clone(policy.Properties)
+	if props == nil {
+		props = make(map[string]string, 3)
+	}
+	props[TagPolicyID] = policy.ID
+	props[TagSchedule] = strconv.Itoa(scheduleIndex)
+	if scheduleIndex < len(policy.Schedules) {
+		props[TagRetentionClass] = string(policy.Schedules[scheduleIndex].Type)
+	}
+	return props
+}
+
+// snapSuffixRegex matches suffixes a run may add to snapshot names
+var snapSuffixRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:-]{0,63}$`)
+
+// ValidateSnapSuffix checks a suffix given for a single run, such as a CI
+// build number
+func ValidateSnapSuffix(suffix string) error {
+	if suffix == "" || snapSuffixRegex.MatchString(suffix) {
+		return nil
+	}
+	return errors.New(errors.ZFSRequestValidationError,
+		"suffix must be up to 64 letters, digits, '_', '.', ':' or '-', starting with a letter or digit").
+		WithMetadata("suffix", suffix)
+}
+
+// withSnapSuffix adds a suffix to an expanded snapshot name. It goes before
+// the schedule index and policy ID suffix, which retention and transfer
+// policies match snapshots of the policy by.
+func withSnapSuffix(snapName, suffix string) string {
+	if suffix == "" {
+		return snapName
+	}
+	i := strings.LastIndex(snapName, "-")
+	if i <= 0 {
+		return snapName + "-" + suffix
+	}
+	j := strings.LastIndex(snapName[:i], "-")
+	if j < 0 {
+		return snapName + "-" + suffix
+	}
+	return snapName[:j] + "-" + suffix + snapName[j:]
+}
+
 // AddPolicy adds a new policy to the manager
 func (m *Manager) AddPolicy(params EditPolicyParams) (string, error) {
 	m.logger.Info("Adding new snapshot policy",
@@ -813,72 +1327,50 @@ func (m *Manager) AddPolicy(params EditPolicyParams) (string, error) {
 		return "", err
 	}
 
+	if err := m.validateCalendarRefs(policy.Schedules); err != nil {
+		return "", err
+	}
+
 	m.logger.Debug("Policy validation successful",
 		"id", policy.ID,
 		"name", policy.Name)
 
+	defer m.lockPolicy(policy.ID)()
+
 	// Check if policy with the same ID already exists
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	for _, p := range m.config.Policies {
-		if p.ID == policy.ID {
-			m.logger.Error("Policy with same ID already exists",
-				"id", policy.ID,
-				"name", policy.Name)
-			return "", errors.New(
-				errors.ZFSRequestValidationError,
-				"policy with the same ID already exists",
-			)
-		}
+	if _, exists := m.policyIndex[policy.ID]; exists {
+		m.mu.Unlock()
+		m.logger.Error("Policy with same ID already exists",
+			"id", policy.ID,
+			"name", policy.Name)
+		return "", errors.New(
+			errors.ZFSRequestValidationError,
+			"policy with the same ID already exists",
+		)
 	}
 
 	m.logger.Debug("No duplicate policy found", "id", policy.ID)
 
 	// Add policy to the config
 	m.config.Policies = append(m.config.Policies, policy)
+	m.reindex()
+	m.mu.Unlock()
 
 	// Create jobs for each schedule
 	if policy.Enabled {
-		m.jobMapping[policy.ID] = []string{}
-		for i, schedule := range policy.Schedules {
-			if schedule.Enabled {
-				jobID, err := m.createJob(policy, i)
-				if err != nil {
-					return policy.ID, errors.Wrap(err, errors.SchedulerError).
-						WithMetadata("schedule_index", fmt.Sprintf("%d", i))
-				}
-				if jobID != "" {
-					m.jobMapping[policy.ID] = append(m.jobMapping[policy.ID], jobID)
-				}
-			}
+		if _, err := m.scheduleJobs(policy); err != nil {
+			return policy.ID, err
 		}
 	}
 
-	// Save the updated config with a timeout to avoid hangs
 	m.logger.Debug("Adding policy: About to save config", "policy_id", policy.ID)
-
-	saveDone := make(chan error, 1)
-	go func() {
-		saveDone <- m.SaveConfig(true) // Skip lock since we already hold it
-	}()
-
-	// Wait for save with a timeout
-	select {
-	case err := <-saveDone:
-		if err != nil {
-			m.logger.Error("Failed to save config after adding policy",
-				"policy_id", policy.ID,
-				"policy_name", policy.Name,
-				"error", err)
-			return policy.ID, errors.Wrap(err, errors.ConfigWriteError)
-		}
-		m.logger.Debug("Config save completed successfully", "policy_id", policy.ID)
-	case <-time.After(5 * time.Second):
-		m.logger.Error("Timeout while saving config after adding policy",
+	if err := m.SaveConfig(); err != nil {
+		m.logger.Error("Failed to save config after adding policy",
 			"policy_id", policy.ID,
-			"policy_name", policy.Name)
-		return policy.ID, errors.New(errors.ConfigWriteError, "timeout while saving config")
+			"policy_name", policy.Name,
+			"error", err)
+		return policy.ID, errors.Wrap(err, errors.ConfigWriteError)
 	}
 
 	m.logger.Info("Successfully added policy",
@@ -895,117 +1387,76 @@ func (m *Manager) UpdatePolicy(params EditPolicyParams) error {
 		return errors.New(errors.ZFSRequestValidationError, "policy ID is required for updates")
 	}
 
-	// Find the policy
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	defer m.lockPolicy(params.ID)()
 
-	policyIndex := -1
-	for i, p := range m.config.Policies {
-		if p.ID == params.ID {
-			policyIndex = i
-			break
-		}
-	}
+	// Find the policy
+	m.mu.RLock()
+	existing, found := m.lookupPolicy(params.ID)
+	m.mu.RUnlock()
 
-	if policyIndex == -1 {
+	if !found {
 		return errors.New(errors.NotFoundError, "policy not found")
 	}
 
 	// Create an updated policy
 	updatedPolicy := NewSnapshotPolicy(params)
-	updatedPolicy.CreatedAt = m.config.Policies[policyIndex].CreatedAt
-	updatedPolicy.LastRunAt = m.config.Policies[policyIndex].LastRunAt
-	updatedPolicy.LastRunStatus = m.config.Policies[policyIndex].LastRunStatus
-	updatedPolicy.LastRunError = m.config.Policies[policyIndex].LastRunError
+	updatedPolicy.CreatedAt = existing.CreatedAt
+	updatedPolicy.LastRunAt = existing.LastRunAt
+	updatedPolicy.LastRunStatus = existing.LastRunStatus
+	updatedPolicy.LastRunError = existing.LastRunError
+	updatedPolicy.LastPruneResults = existing.LastPruneResults
+	// Associations are managed by the transfer policies, not the request
+	updatedPolicy.TransferPolicyIDs = existing.TransferPolicyIDs
 
 	// Validate the updated policy
 	if err := ValidatePolicy(updatedPolicy); err != nil {
 		return err
 	}
+	if err := m.validateCalendarRefs(updatedPolicy.Schedules); err != nil {
+		return err
+	}
 
 	// Remove existing jobs for this policy
-	if jobIDs, ok := m.jobMapping[updatedPolicy.ID]; ok {
-		for _, jobID := range jobIDs {
-			jobUUID, parseErr := uuid.Parse(jobID)
-			if parseErr != nil {
-				// Log parse error but continue
-				continue
-			}
-			if err := m.scheduler.RemoveJob(jobUUID); err != nil {
-				// Log but continue
-			}
-		}
-		delete(m.jobMapping, updatedPolicy.ID)
-	}
+	m.unscheduleJobs(updatedPolicy.ID)
 
 	// Update the policy in the config
-	m.config.Policies[policyIndex] = updatedPolicy
+	m.updatePolicyState(updatedPolicy.ID, func(p *SnapshotPolicy) {
+		*p = updatedPolicy
+	})
 
 	// Create new jobs if the policy is enabled
 	if updatedPolicy.Enabled {
-		m.jobMapping[updatedPolicy.ID] = []string{}
-		for i, schedule := range updatedPolicy.Schedules {
-			if schedule.Enabled {
-				jobID, err := m.createJob(updatedPolicy, i)
-				if err != nil {
-					return errors.Wrap(err, errors.SchedulerError).
-						WithMetadata("schedule_index", fmt.Sprintf("%d", i))
-				}
-				if jobID != "" {
-					m.jobMapping[updatedPolicy.ID] = append(m.jobMapping[updatedPolicy.ID], jobID)
-				}
-			}
+		if _, err := m.scheduleJobs(updatedPolicy); err != nil {
+			return err
 		}
 	}
 
-	// Save the updated config with a timeout to avoid hangs
 	m.logger.Debug("UpdatePolicy: About to save config", "policy_id", updatedPolicy.ID)
-
-	saveDone := make(chan error, 1)
-	go func() {
-		saveDone <- m.SaveConfig(true) // Skip lock since we already hold it
-	}()
-
-	// Wait for save with a timeout
-	select {
-	case err := <-saveDone:
-		if err != nil {
-			m.logger.Error("Failed to save config after updating policy",
-				"policy_id", updatedPolicy.ID,
-				"policy_name", updatedPolicy.Name,
-				"error", err)
-			return errors.Wrap(err, errors.ConfigWriteError)
-		}
-		m.logger.Debug("Config save completed successfully", "policy_id", updatedPolicy.ID)
-	case <-time.After(5 * time.Second):
-		m.logger.Error("Timeout while saving config after updating policy",
+	if err := m.SaveConfig(); err != nil {
+		m.logger.Error("Failed to save config after updating policy",
 			"policy_id", updatedPolicy.ID,
-			"policy_name", updatedPolicy.Name)
-		return errors.New(errors.ConfigWriteError, "timeout while saving config")
+			"policy_name", updatedPolicy.Name,
+			"error", err)
+		return errors.Wrap(err, errors.ConfigWriteError)
 	}
 
 	return nil
 }
 
 // RemovePolicy removes a policy
-func (m *Manager) RemovePolicy(policyID string, removeSnapshots bool) error {
+func (m *Manager) RemovePolicy(ctx context.Context, policyID string, removeSnapshots bool) error {
 	m.logger.Debug("Removing policy", "policy_id", policyID, "remove_snapshots", removeSnapshots)
 
-	// Find the policy
-	m.mu.Lock()
-	defer m.mu.Unlock()
+	// The policy lock is held throughout, so runs, updates and transfer
+	// policy associations wait until the policy is gone
+	defer m.lockPolicy(policyID)()
 
-	policyIndex := -1
-	var policy SnapshotPolicy
-	for i, p := range m.config.Policies {
-		if p.ID == policyID {
-			policyIndex = i
-			policy = p
-			break
-		}
-	}
+	// Find the policy
+	m.mu.RLock()
+	policy, found := m.lookupPolicy(policyID)
+	m.mu.RUnlock()
 
-	if policyIndex == -1 {
+	if !found {
 		m.logger.Warn("Policy not found for removal", "policy_id", policyID)
 		return errors.New(errors.NotFoundError, "policy not found")
 	}
@@ -1026,8 +1477,7 @@ func (m *Manager) RemovePolicy(policyID string, removeSnapshots bool) error {
 
 	m.logger.Debug("Found policy for removal",
 		"policy_id", policyID,
-		"policy_name", policy.Name,
-		"policy_index", policyIndex)
+		"policy_name", policy.Name)
 
 	// If requested, remove all snapshots associated with this policy
 	var deletedSnapshots []string
@@ -1041,18 +1491,16 @@ func (m *Manager) RemovePolicy(policyID string, removeSnapshots bool) error {
 		deletionPolicy.RetentionPolicy.OlderThan = 0
 		deletionPolicy.RetentionPolicy.KeepNamedSnap = []string{}
 
-		// Need to temporarily release the lock while pruning snapshots
-		m.mu.Unlock()
 		m.logger.Info("Removing all snapshots associated with policy",
 			"policy_id", policyID,
 			"policy_name", policy.Name,
 			"dataset", policy.Dataset)
 
 		// Since pruneSnapshots only deletes snapshots that match retention criteria,
-		// we need to force it to consider all snapshots as candidates for deletion
-		snapshots, err := m.listPolicySnapshots(deletionPolicy)
+		// we need to force it to consider all snapshots as candidates for deletion.
+		// Pinned snapshots are still kept.
+		snapshots, err := m.listPolicySnapshots(ctx, deletionPolicy)
 		if err != nil {
-			m.mu.Lock() // Reacquire lock before error return
 			m.logger.Error("Failed to list snapshots for policy",
 				"policy_id", policyID,
 				"policy_name", policy.Name,
@@ -1060,9 +1508,15 @@ func (m *Manager) RemovePolicy(policyID string, removeSnapshots bool) error {
 			return errors.Wrap(err, errors.ZFSDatasetList)
 		}
 
-		// Delete each snapshot individually
-		ctx := context.Background()
+		// Delete each snapshot individually, stopping if the caller gives up;
+		// the policy is kept so the removal can be retried
 		for _, snap := range snapshots {
+			if ctx.Err() != nil {
+				return errors.New(errors.CommandContext, "snapshot removal cancelled").
+					WithMetadata("policy_id", policyID).
+					WithMetadata("removed_count", fmt.Sprintf("%d", len(deletedSnapshots)))
+			}
+
 			destroyCfg := dataset.DestroyConfig{
 				NameConfig: dataset.NameConfig{
 					Name: snap.Name,
@@ -1087,71 +1541,38 @@ func (m *Manager) RemovePolicy(policyID string, removeSnapshots bool) error {
 			"policy_id", policyID,
 			"policy_name", policy.Name,
 			"removed_count", len(deletedSnapshots))
-
-		// Reacquire lock before continuing
-		m.mu.Lock()
 	}
 
-	// Remove jobs for this policy
-	if jobIDs, ok := m.jobMapping[policyID]; ok {
-		m.logger.Debug("Removing jobs for policy",
+	// Keep the policy in the trash so the removal can be undone
+	if _, err := m.policyTrash.Put(ctx, policyID, policy); err != nil {
+		m.logger.Error("Failed to move policy to the trash",
 			"policy_id", policyID,
-			"jobs_count", len(jobIDs))
-
-		for _, jobID := range jobIDs {
-			jobUUID, parseErr := uuid.Parse(jobID)
-			if parseErr != nil {
-				m.logger.Warn("Failed to parse job UUID",
-					"job_id", jobID,
-					"error", parseErr)
-				continue
-			}
-			if err := m.scheduler.RemoveJob(jobUUID); err != nil {
-				m.logger.Warn("Failed to remove job",
-					"job_id", jobID,
-					"error", err)
-			} else {
-				m.logger.Debug("Removed job successfully", "job_id", jobID)
-			}
-		}
-		delete(m.jobMapping, policyID)
+			"error", err)
+		return errors.Wrap(err, errors.ZFSSnapshotPolicyError).
+			WithMetadata("policy_id", policyID)
 	}
 
-	// Remove the policy from the config
-	m.config.Policies = append(
-		m.config.Policies[:policyIndex],
-		m.config.Policies[policyIndex+1:]...)
-	m.logger.Debug("Removed policy from config", "policy_id", policyID)
+	// Remove jobs for this policy
+	m.unscheduleJobs(policyID)
 
-	// Remove monitors for this policy
+	// Remove the policy and its monitor from the config
+	m.mu.Lock()
+	if i, ok := m.policyIndex[policyID]; ok {
+		m.config.Policies = slices.Delete(m.config.Policies, i, i+1)
+		m.reindex()
+	}
 	delete(m.config.Monitors, policyID)
-	m.logger.Debug("Removed monitors for policy", "policy_id", policyID)
+	delete(m.policyLocks, policyID)
+	m.mu.Unlock()
+	m.logger.Debug("Removed policy and monitors from config", "policy_id", policyID)
 
-	// Save the updated config with a timeout to avoid hangs
 	m.logger.Debug("RemovePolicy: About to save config", "policy_id", policyID)
-
-	saveDone := make(chan error, 1)
-	go func() {
-		saveDone <- m.SaveConfig(true) // Skip lock since we already hold it
-	}()
-
-	// Wait for save with a timeout
-	select {
-	case err := <-saveDone:
-		if err != nil {
-			m.logger.Error("Failed to save config after removing policy",
-				"policy_id", policyID,
-				"policy_name", policy.Name,
-				"error", err)
-			return errors.Wrap(err, errors.ConfigWriteError)
-		}
-		m.logger.Debug("Config save completed successfully after removing policy",
-			"policy_id", policyID)
-	case <-time.After(5 * time.Second):
-		m.logger.Error("Timeout while saving config after removing policy",
+	if err := m.SaveConfig(); err != nil {
+		m.logger.Error("Failed to save config after removing policy",
 			"policy_id", policyID,
-			"policy_name", policy.Name)
-		return errors.New(errors.ConfigWriteError, "timeout while saving config")
+			"policy_name", policy.Name,
+			"error", err)
+		return errors.Wrap(err, errors.ConfigWriteError)
 	}
 
 	m.logger.Info("Successfully removed policy",
@@ -1166,45 +1587,55 @@ func (m *Manager) GetPolicy(policyID string) (SnapshotPolicy, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	for _, p := range m.config.Policies {
-		if p.ID == policyID {
-			// Create a copy of the policy to avoid modifying the original
-			policy := p
-
-			// Add monitor status information if it exists
-			if monitor, exists := m.config.Monitors[policyID]; exists {
-				// Status information is already in the policy (LastRunAt, LastRunStatus, LastRunError)
-				// But we can enrich it with additional details from the monitor
-				policy.MonitorStatus = &monitor
-			} else {
-				// If no monitor exists yet, create a default one
-				policy.MonitorStatus = &JobMonitor{
-					PolicyID: policyID,
-					Status:   "pending",
-				}
-			}
+	// lookupPolicy returns a copy, so the original is not modified
+	policy, found := m.lookupPolicy(policyID)
+	if !found {
+		return SnapshotPolicy{}, errors.New(errors.NotFoundError, "policy not found")
+	}
 
-			return policy, nil
+	// Add monitor status information if it exists
+	if monitor, exists := m.config.Monitors[policyID]; exists {
+		// Status information is already in the policy (LastRunAt, LastRunStatus, LastRunError)
+		// But we can enrich it with additional details from the monitor
+		policy.MonitorStatus = &monitor
+	} else {
+		// If no monitor exists yet, create a default one
+		policy.MonitorStatus = &JobMonitor{
+			PolicyID: policyID,
+			Status:   "pending",
 		}
 	}
 
-	return SnapshotPolicy{}, errors.New(errors.NotFoundError, "policy not found")
+	return policy, nil
 }
 
-// ListPolicies lists all policies with their status information
-func (m *Manager) ListPolicies() ([]SnapshotPolicy, error) {
+// PoliciesForDataset returns the policies whose snapshots include the dataset
+func (m *Manager) PoliciesForDataset(name string) []SnapshotPolicy {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	// Create a deep copy of the policies and add monitor information
-	policies := make([]SnapshotPolicy, len(m.config.Policies))
-	for i, p := range m.config.Policies {
-		policies[i] = p
+	policies := []SnapshotPolicy{}
+	for _, p := range m.config.Policies {
+		if p.Covers(name) {
+			policies = append(policies, p)
+		}
+	}
+	return policies
+}
+
+// ListPolicies lists all policies with their status information. The lock
+// is only held to copy the policies and monitors; the listing is built after.
+func (m *Manager) ListPolicies() ([]SnapshotPolicy, error) {
+	m.mu.RLock()
+	policies := append(make([]SnapshotPolicy, 0, len(m.config.Policies)), m.config.Policies...)
+	monitors := maps.Clone(m.config.Monitors)
+	m.mu.RUnlock()
 
-		// Add monitor status information if it exists
-		if monitor, exists := m.config.Monitors[p.ID]; exists {
-			// Status information is already in the policy (LastRunAt, LastRunStatus, LastRunError)
-			// But we can enrich it with additional details from the monitor
+	// Add monitor information to the copies
+	for i, p := range policies {
+		// Status information is already in the policy (LastRunAt, LastRunStatus, LastRunError)
+		// But we can enrich it with additional details from the monitor
+		if monitor, exists := monitors[p.ID]; exists {
 			policies[i].MonitorStatus = &monitor
 		} else {
 			// If no monitor exists yet, create a default one
@@ -1218,8 +1649,16 @@ func (m *Manager) ListPolicies() ([]SnapshotPolicy, error) {
 	return policies, nil
 }
 
-// RunPolicy runs a policy immediately
-func (m *Manager) RunPolicy(params RunPolicyParams) (CreateSnapshotResult, error) {
+// Monitors returns the scheduler's job monitors by policy ID
+func (m *Manager) Monitors() map[string]JobMonitor {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.config.Monitors)
+}
+
+// RunPolicy runs a policy immediately. Cancelling ctx stops the run between
+// commands.
+func (m *Manager) RunPolicy(ctx context.Context, params RunPolicyParams) (CreateSnapshotResult, error) {
 	// Find the policy
 	policy, err := m.GetPolicy(params.ID)
 	if err != nil {
@@ -1239,8 +1678,19 @@ func (m *Manager) RunPolicy(params RunPolicyParams) (CreateSnapshotResult, error
 			)
 	}
 
+	if err := ValidateSnapSuffix(params.Suffix); err != nil {
+		return CreateSnapshotResult{
+			PolicyID:      params.ID,
+			ScheduleIndex: params.ScheduleIndex,
+		}, err
+	}
+
+	if params.DryRun {
+		return m.previewSnapshot(ctx, policy, params.ScheduleIndex, params.Suffix)
+	}
+
 	// Create snapshot
-	result, err := m.createSnapshot(params.ID, params.ScheduleIndex)
+	result, err := m.createSnapshot(ctx, params.ID, params.ScheduleIndex, params.Suffix)
 	if err != nil {
 		return result, err
 	}
@@ -1270,19 +1720,27 @@ func (m *Manager) UpdateTransferPolicyAssociation(
 		return nil
 	}
 
+	// Hold the policy locks, in ID order, so the snapshot policies are not
+	// removed while the association changes
+	policyIDs := []string{}
+	for _, id := range []string{oldSnapshotPolicyID, newSnapshotPolicyID} {
+		if id != "" {
+			policyIDs = append(policyIDs, id)
+		}
+	}
+	slices.Sort(policyIDs)
+	for _, id := range policyIDs {
+		defer m.lockPolicy(id)()
+	}
+
 	m.mu.Lock()
-	defer m.mu.Unlock()
 
 	// Find old policy index (if removing)
 	oldPolicyIdx := -1
 	if oldSnapshotPolicyID != "" {
-		for i, p := range m.config.Policies {
-			if p.ID == oldSnapshotPolicyID {
-				oldPolicyIdx = i
-				break
-			}
-		}
-		if oldPolicyIdx == -1 {
+		if i, ok := m.policyIndex[oldSnapshotPolicyID]; ok {
+			oldPolicyIdx = i
+		} else {
 			// Old policy not found - this could happen if snapshot policy was deleted
 			// Log warning but continue with adding to new policy
 			m.logger.Warn("Old snapshot policy not found for disassociation",
@@ -1294,18 +1752,15 @@ func (m *Manager) UpdateTransferPolicyAssociation(
 	// Find new policy index (if adding)
 	newPolicyIdx := -1
 	if newSnapshotPolicyID != "" {
-		for i, p := range m.config.Policies {
-			if p.ID == newSnapshotPolicyID {
-				newPolicyIdx = i
-				break
-			}
-		}
-		if newPolicyIdx == -1 {
+		i, ok := m.policyIndex[newSnapshotPolicyID]
+		if !ok {
+			m.mu.Unlock()
 			return errors.New(
 				errors.NotFoundError,
 				fmt.Sprintf("snapshot policy %s not found", newSnapshotPolicyID),
 			)
 		}
+		newPolicyIdx = i
 	}
 
 	// Perform in-memory modifications
@@ -1330,9 +1785,10 @@ func (m *Manager) UpdateTransferPolicyAssociation(
 			)
 		}
 	}
+	m.mu.Unlock()
 
 	// Single atomic save
-	if err := m.SaveConfig(true); err != nil {
+	if err := m.SaveConfig(); err != nil {
 		return err
 	}
 
@@ -1361,19 +1817,18 @@ func (m *Manager) GetTransferPolicyAssociations(snapshotPolicyID string) ([]stri
 	defer m.mu.RUnlock()
 
 	// Find the snapshot policy
-	for _, p := range m.config.Policies {
-		if p.ID == snapshotPolicyID {
-			// Return a copy to prevent external modifications
-			result := make([]string, len(p.TransferPolicyIDs))
-			copy(result, p.TransferPolicyIDs)
-			return result, nil
-		}
+	p, found := m.lookupPolicy(snapshotPolicyID)
+	if !found {
+		return nil, errors.New(
+			errors.NotFoundError,
+			fmt.Sprintf("snapshot policy %s not found", snapshotPolicyID),
+		)
 	}
 
-	return nil, errors.New(
-		errors.NotFoundError,
-		fmt.Sprintf("snapshot policy %s not found", snapshotPolicyID),
-	)
+	// Return a copy to prevent external modifications
+	result := make([]string, len(p.TransferPolicyIDs))
+	copy(result, p.TransferPolicyIDs)
+	return result, nil
 }
 
 // Start starts the scheduler
@@ -1405,56 +1860,53 @@ func (m *Manager) Start() error {
 	enabledScheduleCount := 0
 	createdJobCount := 0
 
-	// Reacquire lock for job creation
-	m.mu.Lock()
+	m.mu.RLock()
+	policies := append(make([]SnapshotPolicy, 0, len(m.config.Policies)), m.config.Policies...)
+	m.mu.RUnlock()
 
 	// Create jobs for all enabled policies
-	for _, policy := range m.config.Policies {
-		if policy.Enabled {
-			enabledPolicyCount++
-			m.logger.Debug("Processing enabled policy",
-				"policy_id", policy.ID,
-				"policy_name", policy.Name)
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		enabledPolicyCount++
+		m.logger.Debug("Processing enabled policy",
+			"policy_id", policy.ID,
+			"policy_name", policy.Name)
 
-			m.jobMapping[policy.ID] = []string{}
-			for j, schedule := range policy.Schedules {
-				if schedule.Enabled {
-					enabledScheduleCount++
-					m.logger.Debug("Creating job for enabled schedule",
-						"policy_id", policy.ID,
-						"policy_name", policy.Name,
-						"schedule_index", j,
-						"schedule_type", schedule.Type)
-
-					jobID, err := m.createJob(policy, j)
-					if err != nil {
-						m.mu.Unlock()
-						m.logger.Error("Failed to create job",
-							"policy_id", policy.ID,
-							"policy_name", policy.Name,
-							"schedule_index", j,
-							"schedule_type", schedule.Type,
-							"error", err)
-						return errors.Wrap(err, errors.SchedulerError).
-							WithMetadata("policy_id", policy.ID).
-							WithMetadata("schedule_index", fmt.Sprintf("%d", j))
-					}
-					if jobID != "" {
-						createdJobCount++
-						m.jobMapping[policy.ID] = append(m.jobMapping[policy.ID], jobID)
-					}
-				}
+		for _, schedule := range policy.Schedules {
+			if schedule.Enabled {
+				enabledScheduleCount++
 			}
 		}
+
+		unlock := m.lockPolicy(policy.ID)
+		jobIDs, err := m.scheduleJobs(policy)
+		unlock()
+		if err != nil {
+			m.logger.Error("Failed to create job",
+				"policy_id", policy.ID,
+				"policy_name", policy.Name,
+				"error", err)
+			return errors.Wrap(err, errors.SchedulerError).
+				WithMetadata("policy_id", policy.ID)
+		}
+		createdJobCount += len(jobIDs)
 	}
 
 	// Start the scheduler and mark as started
 	m.scheduler.Start()
+	m.mu.Lock()
 	m.started = true
-
-	// Release the lock before finishing
 	m.mu.Unlock()
 
+	// Edits to the config file are applied without a restart
+	if err := m.watchConfig(); err != nil {
+		m.logger.Warn("Failed to watch snapshot config file, edits need a reload",
+			"path", m.configPath,
+			"error", err)
+	}
+
 	m.logger.Info("Snapshot scheduler started",
 		"enabled_policies", enabledPolicyCount,
 		"enabled_schedules", enabledScheduleCount,
@@ -1465,9 +1917,6 @@ func (m *Manager) Start() error {
 
 // cleanupExistingJobs removes all existing jobs from the scheduler and clears the job mapping
 func (m *Manager) cleanupExistingJobs() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	// Get all jobs from the scheduler
 	jobs := m.scheduler.Jobs()
 
@@ -1490,7 +1939,9 @@ func (m *Manager) cleanupExistingJobs() {
 	}
 
 	// Clear the job mapping
+	m.mu.Lock()
 	m.jobMapping = make(map[string][]string)
+	m.mu.Unlock()
 }
 
 // Stop stops the scheduler
@@ -1507,6 +1958,8 @@ func (m *Manager) Stop() error {
 	m.logger.Info("Stopping snapshot scheduler")
 	m.mu.Unlock()
 
+	m.stopWatchingConfig()
+
 	// Stop the scheduler
 	err := m.scheduler.Shutdown()
 	if err != nil {
@@ -1516,7 +1969,7 @@ func (m *Manager) Stop() error {
 	m.logger.Debug("Scheduler shut down successfully")
 
 	// Save the config
-	if err := m.SaveConfig(false); err != nil {
+	if err := m.SaveConfig(); err != nil {
 		m.logger.Error("Failed to save config during shutdown", "error", err)
 		return errors.Wrap(err, errors.ConfigWriteError)
 	}
@@ -1545,10 +1998,11 @@ func (m *Manager) LoadConfig() error {
 			Policies: make([]SnapshotPolicy, 0),
 			Monitors: make(map[string]JobMonitor),
 		}
+		m.reindex()
 		m.mu.Unlock()
 
 		// Create initial config file
-		return m.SaveConfig(false)
+		return m.SaveConfig()
 	}
 
 	// Read config file
@@ -1564,6 +2018,10 @@ func (m *Manager) LoadConfig() error {
 		"path", m.configPath,
 		"size", len(data))
 
+	m.saveMu.Lock()
+	m.savedHash = sha256.Sum256(data)
+	m.saveMu.Unlock()
+
 	// Unmarshal config
 	var config SnapshotConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
@@ -1592,10 +2050,11 @@ func (m *Manager) LoadConfig() error {
 			Policies: make([]SnapshotPolicy, 0),
 			Monitors: make(map[string]JobMonitor),
 		}
+		m.reindex()
 		m.mu.Unlock()
 
 		// Create new config file
-		saveErr := m.SaveConfig(false)
+		saveErr := m.SaveConfig()
 		if saveErr != nil {
 			return errors.Wrap(saveErr, errors.ConfigWriteError)
 		}
@@ -1654,12 +2113,13 @@ func (m *Manager) LoadConfig() error {
 		m.logger.Warn("Monitors map was nil in loaded config, initializing it")
 		m.config.Monitors = make(map[string]JobMonitor)
 	}
+	m.reindex()
 	m.mu.Unlock()
 
 	// If any policies were filtered out, save the valid config
 	if len(validPolicies) < len(config.Policies) {
 		m.logger.Info("Saving config with only valid policies")
-		if saveErr := m.SaveConfig(false); saveErr != nil {
+		if saveErr := m.SaveConfig(); saveErr != nil {
 			m.logger.Error("Failed to save config with valid policies", "error", saveErr)
 			return errors.Wrap(saveErr, errors.ConfigWriteError)
 		}
@@ -1667,58 +2127,29 @@ func (m *Manager) LoadConfig() error {
 
 	m.logger.Info("Successfully loaded config",
 		"path", m.configPath,
-		"policies_count", len(m.config.Policies),
-		"monitors_count", len(m.config.Monitors))
+		"policies_count", len(config.Policies),
+		"monitors_count", len(config.Monitors))
 	return nil
 }
 
-// SaveConfig saves the config to file
-// If skipLock is true, the method assumes the caller already holds the lock
-// and will skip acquiring it to avoid deadlocks
-func (m *Manager) SaveConfig(skipLock bool) error {
+// SaveConfig saves the config to file. Saves are serialized, and each copies
+// the config once it is the one writing, so the file always ends up with
+// the latest state. It must not be called with mu held.
+func (m *Manager) SaveConfig() error {
 	m.logger.Debug("SaveConfig: Starting", "path", m.configPath)
 
-	// Check if we should skip lock acquisition
-	if skipLock {
-		m.logger.Debug("SaveConfig: Skipping lock acquisition as requested")
-	}
-
-	// Take a copy of the config under lock (or use directly if lock already held)
-	var configCopy SnapshotConfig
-
-	if !skipLock {
-		// Standard path - acquire lock ourselves
-		m.mu.RLock()
-		m.logger.Debug("SaveConfig: Acquired read lock")
-
-		// Create a deep copy of the config
-		configCopy = SnapshotConfig{
-			Policies: make([]SnapshotPolicy, len(m.config.Policies)),
-			Monitors: make(map[string]JobMonitor, len(m.config.Monitors)),
-		}
-
-		// Copy policies
-		copy(configCopy.Policies, m.config.Policies)
-
-		// Copy monitors
-		maps.Copy(configCopy.Monitors, m.config.Monitors)
-
-		m.mu.RUnlock()
-		m.logger.Debug("SaveConfig: Released read lock")
-	} else {
-		// Lock is already held by caller - use the config directly
-		// Create a deep copy of the config without acquiring lock
-		configCopy = SnapshotConfig{
-			Policies: make([]SnapshotPolicy, len(m.config.Policies)),
-			Monitors: make(map[string]JobMonitor, len(m.config.Monitors)),
-		}
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
 
-		// Copy policies
-		copy(configCopy.Policies, m.config.Policies)
-
-		// Copy monitors
-		maps.Copy(configCopy.Monitors, m.config.Monitors)
+	// Take a copy of the config under the read lock
+	m.mu.RLock()
+	configCopy := SnapshotConfig{
+		Policies: make([]SnapshotPolicy, len(m.config.Policies)),
+		Monitors: make(map[string]JobMonitor, len(m.config.Monitors)),
 	}
+	copy(configCopy.Policies, m.config.Policies)
+	maps.Copy(configCopy.Monitors, m.config.Monitors)
+	m.mu.RUnlock()
 
 	m.logger.Debug("SaveConfig: Config copy taken",
 		"policies_count", len(configCopy.Policies),
@@ -1741,6 +2172,7 @@ func (m *Manager) SaveConfig(skipLock bool) error {
 			"error", err)
 		return errors.Wrap(err, errors.ConfigWriteError)
 	}
+	m.savedHash = sha256.Sum256(data)
 
 	m.logger.Debug("SaveConfig: Successfully saved config file",
 		"path", m.configPath,
@@ -10,15 +10,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/trash"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 	ds "github.com/stratastor/rodent/pkg/zfs/dataset"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestValidateScheduleSpec(t *testing.T) {
@@ -143,6 +146,16 @@ func TestValidateScheduleSpec(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "invalid retention - negative count",
+			spec: ScheduleSpec{
+				Type:      ScheduleTypeHourly,
+				Interval:  1,
+				Enabled:   true,
+				Retention: &ScheduleRetention{Count: -1},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -344,6 +357,78 @@ func TestNewSnapshotPolicy(t *testing.T) {
 	assert.Equal(t, "existing-id", policy.ID)
 }
 
+// TestIsDatasetExcluded tests exclusion glob matching for recursive policies
+func TestIsDatasetExcluded(t *testing.T) {
+	policy := SnapshotPolicy{
+		Dataset:         "tank/data",
+		Recursive:       true,
+		ExcludeDatasets: []string{"scratch", "*/tmp", "tank/data/cache-*"},
+	}
+
+	tests := []struct {
+		dataset  string
+		excluded bool
+	}{
+		{"tank/data", false},
+		{"tank/data/home", false},
+		{"tank/data/scratch", true},
+		{"tank/data/scratch/build", true},
+		{"tank/data/home/tmp", true},
+		{"tank/data/home/tmpfiles", false},
+		{"tank/data/cache-web", true},
+		{"tank/other/scratch", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataset, func(t *testing.T) {
+			assert.Equal(t, tt.excluded, policy.IsDatasetExcluded(tt.dataset))
+		})
+	}
+
+	// Exclusions are only valid on recursive policies
+	policy.Name = "test-policy"
+	policy.Schedules = []ScheduleSpec{{Type: ScheduleTypeHourly, Interval: 1, Enabled: true}}
+	assert.NoError(t, ValidatePolicy(policy))
+	policy.Recursive = false
+	assert.Error(t, ValidatePolicy(policy))
+	policy.Recursive = true
+	policy.ExcludeDatasets = []string{"[bad"}
+	assert.Error(t, ValidatePolicy(policy))
+}
+
+func TestScheduleRetention(t *testing.T) {
+	policy := SnapshotPolicy{
+		Schedules: []ScheduleSpec{
+			{Type: ScheduleTypeHourly, Interval: 1, Retention: &ScheduleRetention{Count: 24}},
+			{Type: ScheduleTypeDaily, Interval: 1, Retention: &ScheduleRetention{Count: 30}},
+			{Type: ScheduleTypeWeekly, Interval: 1},
+		},
+		RetentionPolicy: RetentionPolicy{OlderThan: 90 * 24 * time.Hour},
+	}
+	assert.True(t, policy.HasRetention())
+
+	assert.Equal(t, 0, policy.retentionClass(0))
+	assert.Equal(t, 1, policy.retentionClass(1))
+	// Schedules without their own retention, and unknown ones, fall under
+	// the policy's
+	assert.Equal(t, -1, policy.retentionClass(2))
+	assert.Equal(t, -1, policy.retentionClass(-1))
+	assert.Equal(t, -1, policy.retentionClass(7))
+
+	count, olderThan := policy.retentionFor(1)
+	assert.Equal(t, 30, count)
+	assert.Zero(t, olderThan)
+	count, olderThan = policy.retentionFor(-1)
+	assert.Zero(t, count)
+	assert.Equal(t, 90*24*time.Hour, olderThan)
+
+	policy.RetentionPolicy = RetentionPolicy{}
+	assert.True(t, policy.HasRetention())
+	policy.Schedules[0].Retention = &ScheduleRetention{}
+	policy.Schedules[1].Retention = nil
+	assert.False(t, policy.HasRetention())
+}
+
 // TestExpandSnapNamePattern tests the pattern expansion for snapshot names
 func TestExpandSnapNamePattern(t *testing.T) {
 	// Mock fixed time for testing
@@ -390,8 +475,233 @@ func TestExpandSnapNamePattern(t *testing.T) {
 	}
 }
 
+func TestWithSnapSuffix(t *testing.T) {
+	fixedTime := time.Date(2025, 5, 15, 14, 30, 45, 0, time.UTC)
+	id := "0196d3a4-7b2c-7e1f-9a3b-d1f36875b92f"
+	name := expandSnapNamePattern(id, "test-policy", 0, "auto-%Y-%m-%d", fixedTime)
+
+	assert.Equal(t, name, withSnapSuffix(name, ""))
+	suffixed := withSnapSuffix(name, "build-42")
+	assert.Equal(t, "auto-2025-05-15-build-42-0-d1f36875b92f", suffixed)
+	// Retention still finds the snapshot by the policy ID suffix
+	assert.True(t, strings.HasSuffix(suffixed, "d1f36875b92f"))
+
+	assert.NoError(t, ValidateSnapSuffix(""))
+	assert.NoError(t, ValidateSnapSuffix("ci.1234:main"))
+	assert.Error(t, ValidateSnapSuffix("-leading"))
+	assert.Error(t, ValidateSnapSuffix("has space"))
+	assert.Error(t, ValidateSnapSuffix("a@b"))
+	assert.Error(t, ValidateSnapSuffix(strings.Repeat("a", 65)))
+}
+
+func TestSnapshotPolicyNameFormat(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"autosnap-daily-%Y-%m-%d-%H%M%S", "autosnap-daily-%Y-%m-%d-%H%M%S"},
+		{"{policy_name}-{timestamp}", "daily-%Y-%m-%d-%H%M%S"},
+		{"{date}-{sequence}-{time}", "%Y-%m-%d-"},
+		{"{policy_id}-{timestamp}", ""},
+		{"snapshot", ""},
+	}
+	for _, tt := range tests {
+		policy := SnapshotPolicy{Name: "daily", SnapNamePattern: tt.pattern}
+		assert.Equal(t, tt.want, policy.NameFormat(), tt.pattern)
+	}
+}
+
+func TestSnapshotProperties(t *testing.T) {
+	policy := SnapshotPolicy{
+		ID: "p1",
+		Schedules: []ScheduleSpec{
+			{Type: ScheduleTypeHourly},
+			{Type: ScheduleTypeDaily},
+		},
+		Properties: map[string]string{"com.example:owner": "ops"},
+	}
+
+	props := snapshotProperties(policy, 1)
+	assert.Equal(t, map[string]string{
+		"com.example:owner": "ops",
+		TagPolicyID:         "p1",
+		TagSchedule:         "1",
+		TagRetentionClass:   "daily",
+	}, props)
+	// The policy's own properties are left alone
+	assert.Len(t, policy.Properties, 1)
+}
+
 // Basic integration test that requires a real ZFS dataset
 // This test will be skipped if no test filesystem is provided
+func TestEstimateNextRun(t *testing.T) {
+	assert.Nil(t, EstimateNextRun(nil))
+
+	var runs []RunRecord
+	for i := 1; i <= MaxRunHistory+5; i++ {
+		runs = AppendRunRecord(runs, RunRecord{
+			Duration: time.Duration(i) * time.Second,
+			Bytes:    int64(i) * 1000,
+		})
+	}
+	require.Len(t, runs, MaxRunHistory)
+	assert.Equal(t, 6*time.Second, runs[0].Duration, "oldest runs are dropped")
+
+	est := EstimateNextRun(runs[len(runs)-2:])
+	require.NotNil(t, est)
+	assert.Equal(t, 2, est.Samples)
+	assert.Equal(t, 24500*time.Millisecond, est.Duration)
+	assert.Equal(t, int64(24500), est.Bytes)
+	assert.Equal(t, int64(1000), est.Throughput)
+
+	// Durations scale with the expected size at the observed throughput
+	assert.Equal(t, 5*time.Second, est.DurationFor(5000))
+	assert.Equal(t, est.Duration, est.DurationFor(0))
+
+	// Runs that moved no data fall back to the average duration
+	idle := EstimateNextRun([]RunRecord{{Duration: time.Second}, {Duration: 3 * time.Second}})
+	assert.Zero(t, idle.Throughput)
+	assert.Equal(t, 2*time.Second, idle.DurationFor(5000))
+
+	var none *RunEstimate
+	assert.Zero(t, none.DurationFor(5000))
+}
+
+func TestManagerConcurrentPolicyOperations(t *testing.T) {
+	m, err := newManager(nil, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.scheduler.Shutdown() })
+
+	params := func(name string) EditPolicyParams {
+		return EditPolicyParams{
+			Name:    name,
+			Dataset: "tank/data",
+			Schedules: []ScheduleSpec{
+				{
+					Type:     ScheduleTypeHourly,
+					Interval: 1,
+					Enabled:  true,
+				},
+			},
+			Enabled: true,
+		}
+	}
+
+	kept, err := m.AddPolicy(params("kept"))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			id, err := m.AddPolicy(params(fmt.Sprintf("policy-%d", i)))
+			assert.NoError(t, err)
+
+			update := params(fmt.Sprintf("policy-%d-renamed", i))
+			update.ID = id
+			assert.NoError(t, m.UpdatePolicy(update))
+			assert.NoError(t, m.UpdateTransferPolicyAssociation("", kept, fmt.Sprintf("transfer-%d", i)))
+
+			_, err = m.ListPolicies()
+			assert.NoError(t, err)
+			assert.NoError(t, m.SaveConfig())
+			assert.NoError(t, m.RemovePolicy(context.Background(), id, false))
+		}()
+	}
+	wg.Wait()
+
+	policies, err := m.ListPolicies()
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	assert.Len(t, policies[0].TransferPolicyIDs, 8)
+
+	// The saved config holds the final state
+	require.NoError(t, m.LoadConfig())
+	saved, err := m.GetPolicy(kept)
+	require.NoError(t, err)
+	assert.Len(t, saved.TransferPolicyIDs, 8)
+	assert.Len(t, m.jobMapping, 1)
+}
+
+func TestManagerPolicyTrash(t *testing.T) {
+	dir := t.TempDir()
+	m, err := newManager(nil, dir)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.scheduler.Shutdown() })
+	m.policyTrash = trash.New(filepath.Join(dir, policyTrashDir), time.Hour)
+
+	id, err := m.AddPolicy(EditPolicyParams{
+		Name:    "hourly",
+		Dataset: "tank/data",
+		Schedules: []ScheduleSpec{
+			{Type: ScheduleTypeHourly, Interval: 1, Enabled: true},
+		},
+		Enabled: true,
+	})
+	require.NoError(t, err)
+
+	ctx := common.WithActor(context.Background(), common.Actor{Source: "rest", User: "alice"})
+	require.NoError(t, m.RemovePolicy(ctx, id, false))
+	_, err = m.GetPolicy(id)
+	require.Error(t, err)
+	assert.NotContains(t, m.policyLocks, id, "the removed policy's lock is dropped")
+
+	trashed, err := m.ListTrashedPolicies()
+	require.NoError(t, err)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, id, trashed[0].Policy.ID)
+	assert.Equal(t, "alice", trashed[0].DeletedBy.User)
+
+	restored, err := m.RestorePolicy(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "hourly", restored.Name)
+	assert.Len(t, m.jobMapping[id], 1, "the restored policy is scheduled")
+
+	_, err = m.RestorePolicy(ctx, id)
+	assert.Error(t, err, "a policy that exists is not restored")
+
+	trashed, err = m.ListTrashedPolicies()
+	require.NoError(t, err)
+	assert.Empty(t, trashed)
+
+	// The restored policy is saved
+	require.NoError(t, m.LoadConfig())
+	_, err = m.GetPolicy(id)
+	assert.NoError(t, err)
+}
+
+func TestLockPolicyAfterRemoval(t *testing.T) {
+	m := &Manager{policyLocks: make(map[string]*sync.Mutex)}
+
+	unlock := m.lockPolicy("p1")
+	old := m.policyLocks["p1"]
+
+	acquired := make(chan func())
+	go func() { acquired <- m.lockPolicy("p1") }()
+
+	// Removal drops the lock while holding it, as RemovePolicy does
+	m.mu.Lock()
+	delete(m.policyLocks, "p1")
+	m.mu.Unlock()
+	unlock()
+
+	var waiterUnlock func()
+	select {
+	case waiterUnlock = <-acquired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter did not take the policy lock")
+	}
+	current := m.policyLocks["p1"]
+	require.NotNil(t, current)
+	assert.NotSame(t, old, current, "the waiter holds a fresh lock")
+	assert.False(t, current.TryLock(), "the fresh lock is held by the waiter")
+	assert.True(t, old.TryLock(), "the dropped lock is released")
+	waiterUnlock()
+	assert.True(t, current.TryLock())
+}
+
 func TestManager_Integration(t *testing.T) {
 	// Get test filesystem from environment
 	testFS := os.Getenv("RODENT_TEST_FS_NAME")
@@ -699,7 +1009,7 @@ func TestManager_Integration(t *testing.T) {
 
 	// Test removing the policy
 	t.Log("Removing policy")
-	err = manager.RemovePolicy(policyID, false)
+	err = manager.RemovePolicy(context.Background(), policyID, false)
 	require.NoError(t, err)
 
 	// Test that the policy was removed
@@ -718,3 +1028,114 @@ func TestManager_Integration(t *testing.T) {
 
 	t.Log("Integration test completed successfully")
 }
+
+func TestManagerReloadConfig(t *testing.T) {
+	m, err := newManager(nil, t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = m.scheduler.Shutdown() })
+	require.NoError(t, m.LoadConfig())
+
+	params := func(name string) EditPolicyParams {
+		return EditPolicyParams{
+			Name:    name,
+			Dataset: "tank/data",
+			Schedules: []ScheduleSpec{
+				{
+					Type:     ScheduleTypeHourly,
+					Interval: 1,
+					Enabled:  true,
+				},
+			},
+			Enabled: true,
+		}
+	}
+	kept, err := m.AddPolicy(params("kept"))
+	require.NoError(t, err)
+	edited, err := m.AddPolicy(params("edited"))
+	require.NoError(t, err)
+	removed, err := m.AddPolicy(params("removed"))
+	require.NoError(t, err)
+	m.updatePolicyState(edited, func(p *SnapshotPolicy) { p.LastRunStatus = "success" })
+
+	// Nothing to apply while the file holds the manager's own save
+	result, err := m.ReloadConfig()
+	require.NoError(t, err)
+	assert.False(t, result.Changed())
+
+	// Edit the file by hand
+	var cfg SnapshotConfig
+	data, err := os.ReadFile(m.configPath)
+	require.NoError(t, err)
+	require.NoError(t, yaml.Unmarshal(data, &cfg))
+	var policies []SnapshotPolicy
+	for _, p := range cfg.Policies {
+		switch p.ID {
+		case edited:
+			p.Enabled = false
+			p.Description = "paused by hand"
+		case removed:
+			continue
+		}
+		policies = append(policies, p)
+	}
+	added := NewSnapshotPolicy(params("added"))
+	cfg.Policies = append(policies, added)
+	data, err = yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(m.configPath, data, 0644))
+
+	result, err = m.ReloadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, []string{added.ID}, result.Added)
+	assert.Equal(t, []string{edited}, result.Updated)
+	assert.Equal(t, []string{removed}, result.Removed)
+
+	p, err := m.GetPolicy(edited)
+	require.NoError(t, err)
+	assert.False(t, p.Enabled)
+	assert.Equal(t, "paused by hand", p.Description)
+	assert.Equal(t, "success", p.LastRunStatus, "run state is kept")
+
+	_, err = m.GetPolicy(removed)
+	assert.Error(t, err)
+	assert.Len(t, m.jobMapping[kept], 1)
+	assert.Len(t, m.jobMapping[added.ID], 1)
+	assert.Empty(t, m.jobMapping[edited])
+	assert.NotContains(t, m.jobMapping, removed)
+
+	// An invalid file is not applied
+	require.NoError(t, os.WriteFile(m.configPath, []byte("policies:\n  - id: x\n"), 0644))
+	_, err = m.ReloadConfig()
+	assert.Error(t, err)
+	policiesNow, err := m.ListPolicies()
+	require.NoError(t, err)
+	assert.Len(t, policiesNow, 3)
+}
+
+func TestManagerWatchesConfig(t *testing.T) {
+	m, err := newManager(nil, t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, m.Start())
+	t.Cleanup(func() { _ = m.Stop() })
+
+	policy := NewSnapshotPolicy(EditPolicyParams{
+		Name:    "by-hand",
+		Dataset: "tank/data",
+		Schedules: []ScheduleSpec{
+			{
+				Type:     ScheduleTypeHourly,
+				Interval: 1,
+				Enabled:  true,
+			},
+		},
+		Enabled: true,
+	})
+	data, err := yaml.Marshal(SnapshotConfig{Policies: []SnapshotPolicy{policy}})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(m.configPath, data, 0644))
+
+	require.Eventually(t, func() bool {
+		_, err := m.GetPolicy(policy.ID)
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+}
@@ -5,10 +5,21 @@
 package autosnapshots
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"slices"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/pkg/errors"
 )
 
+// TriggerKeyHeader carries the API key of trigger requests. A bearer token in
+// the Authorization header is accepted as well.
+const TriggerKeyHeader = "X-API-Key"
+
 // ValidateScheduleConfig validates schedule configuration parameters
 func ValidateScheduleConfig() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -88,3 +99,61 @@ func ValidateRunPolicyParams() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireTriggerKey admits requests carrying a configured trigger key that
+// may run the policy in the path. The key's name is recorded as the actor,
+// so triggered runs can be told apart in histories and events.
+func RequireTriggerKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		presented := c.GetHeader(TriggerKeyHeader)
+		if presented == "" {
+			if scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " "); ok &&
+				strings.EqualFold(scheme, "Bearer") {
+				presented = strings.TrimSpace(token)
+			}
+		}
+		if presented == "" {
+			APIError(c, errors.New(errors.ServerUnauthorized, "API key is required"))
+			return
+		}
+
+		key, ok := matchTriggerKey(config.GetConfig().Triggers.Keys, presented)
+		if !ok {
+			APIError(c, errors.New(errors.ServerUnauthorized, "API key is not valid"))
+			return
+		}
+		policyID := c.Param("id")
+		if len(key.Policies) > 0 && !slices.Contains(key.Policies, policyID) {
+			APIError(c, errors.New(errors.PermissionDenied,
+				"API key may not trigger this policy").
+				WithMetadata("key", key.Name).
+				WithMetadata("policy_id", policyID))
+			return
+		}
+
+		actor := common.ActorFromContext(c.Request.Context())
+		actor.Source = common.ActorSourceTrigger
+		actor.User = key.Name
+		c.Request = c.Request.WithContext(common.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+// matchTriggerKey returns the configured key equal to presented. Keys are
+// compared as hashes in constant time, so neither their contents nor their
+// lengths leak through response times.
+func matchTriggerKey(keys []config.TriggerKey, presented string) (config.TriggerKey, bool) {
+	want := sha256.Sum256([]byte(presented))
+	var found config.TriggerKey
+	ok := false
+	for _, k := range keys {
+		if k.Key == "" {
+			continue
+		}
+		have := sha256.Sum256([]byte(k.Key))
+		if subtle.ConstantTimeCompare(want[:], have[:]) == 1 && !ok {
+			found, ok = k, true
+		}
+	}
+	return found, ok
+}
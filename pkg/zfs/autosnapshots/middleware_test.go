@@ -0,0 +1,68 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireTriggerKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.GetConfig()
+	saved := cfg.Triggers.Keys
+	cfg.Triggers.Keys = []config.TriggerKey{
+		{Name: "ci", Key: "ci-secret", Policies: []string{"p1"}},
+		{Name: "orchestrator", Key: "orch-secret"},
+	}
+	t.Cleanup(func() { cfg.Triggers.Keys = saved })
+
+	var actor common.Actor
+	engine := gin.New()
+	engine.POST("/snapshot-policies/:id/trigger", RequireTriggerKey(), func(c *gin.Context) {
+		actor = common.ActorFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name   string
+		policy string
+		header string
+		value  string
+		status int
+		user   string
+	}{
+		{"no key", "p1", "", "", http.StatusUnauthorized, ""},
+		{"wrong key", "p1", TriggerKeyHeader, "nope", http.StatusUnauthorized, ""},
+		{"header key", "p1", TriggerKeyHeader, "ci-secret", http.StatusOK, "ci"},
+		{"bearer key", "p2", "Authorization", "Bearer orch-secret", http.StatusOK, "orchestrator"},
+		{"policy not allowed", "p2", TriggerKeyHeader, "ci-secret", http.StatusForbidden, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor = common.Actor{}
+			req := httptest.NewRequest(http.MethodPost, "/snapshot-policies/"+tt.policy+"/trigger", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.user, actor.User)
+			if tt.user != "" {
+				assert.Equal(t, common.ActorSourceTrigger, actor.Source)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/jobs"
+)
+
+const (
+	// OpUpdatePolicy is the operation type of a policy update run in the background
+	OpUpdatePolicy = "autosnapshot.update_policy"
+
+	// OpBackfillTags is the operation type of a snapshot tag back-fill
+	OpBackfillTags = "autosnapshot.backfill_tags"
+)
+
+// backfillRetryPolicy runs a back-fill once, allowing for installs with
+// many snapshots
+var backfillRetryPolicy = jobs.RetryPolicy{
+	MaxAttempts: 1,
+	Timeout:     time.Hour,
+}
+
+// BackfillTagsParams are the parameters of a snapshot tag back-fill
+type BackfillTagsParams struct {
+	DryRun bool `json:"dry_run"` // Report the tags that would be set without setting them
+}
+
+// UseJobQueue lets policy updates run in the background when the client
+// asks for it with ?async=true or "Prefer: respond-async", and runs tag
+// back-fills as operations
+func (h *Handler) UseJobQueue(q *jobs.Queue) {
+	q.Register(jobs.OperationTypePrefix+OpUpdatePolicy, h.runUpdatePolicy, jobs.OperationRetryPolicy)
+	q.Register(jobs.OperationTypePrefix+OpBackfillTags, h.runBackfillTags, backfillRetryPolicy)
+	h.jobQueue = q
+}
+
+// runUpdatePolicy updates a policy from an accepted request. The updated
+// policy is the operation's result.
+func (h *Handler) runUpdatePolicy(ctx context.Context, payload json.RawMessage) error {
+	var params EditPolicyParams
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return jobs.Permanent(err)
+	}
+	if err := h.manager.UpdatePolicy(params); err != nil {
+		return err
+	}
+	policy, err := h.manager.GetPolicy(params.ID)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, policy)
+}
+
+// runBackfillTags back-fills snapshot tags from an accepted request. The
+// report is the operation's result.
+func (h *Handler) runBackfillTags(ctx context.Context, payload json.RawMessage) error {
+	var params BackfillTagsParams
+	if err := json.Unmarshal(payload, &params); err != nil {
+		return jobs.Permanent(err)
+	}
+	report, err := h.manager.BackfillTags(ctx, params.DryRun)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, report)
+}
@@ -0,0 +1,354 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stratastor/rodent/internal/crash"
+	"github.com/stratastor/rodent/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// reloadDebounce is how long the watcher waits after the last change to the
+// config file before reloading it, so an editor's write is read whole
+const reloadDebounce = 500 * time.Millisecond
+
+// ReloadResult lists the policies a reload added, updated and removed
+type ReloadResult struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+// Changed reports whether the reload changed any policy
+func (r ReloadResult) Changed() bool {
+	return len(r.Added)+len(r.Updated)+len(r.Removed) > 0
+}
+
+// ReloadConfig applies the policies of the config file to the running
+// manager, for edits made to the file by hand. Policies whose definition
+// changed are rescheduled and keep their run state; removed policies are
+// unscheduled and moved to the trash, and their snapshots are kept. A file that does not parse or
+// holds an invalid policy is not applied at all.
+func (m *Manager) ReloadConfig() (ReloadResult, error) {
+	var result ReloadResult
+
+	onDisk, unchanged, err := m.readEditedConfig()
+	if err != nil || unchanged {
+		return result, err
+	}
+
+	m.mu.RLock()
+	current := append([]SnapshotPolicy(nil), m.config.Policies...)
+	m.mu.RUnlock()
+
+	// Work out the changes before applying any, so a policy that cannot be
+	// removed leaves the manager as it was
+	onDiskIndex := make(map[string]int, len(onDisk))
+	for i, p := range onDisk {
+		onDiskIndex[p.ID] = i
+	}
+	for _, p := range current {
+		i, ok := onDiskIndex[p.ID]
+		if !ok {
+			if len(p.TransferPolicyIDs) > 0 {
+				return ReloadResult{}, errors.New(errors.ZFSSnapshotPolicyError,
+					fmt.Sprintf("cannot remove policy: referenced by %d transfer policies",
+						len(p.TransferPolicyIDs))).
+					WithMetadata("policy_id", p.ID)
+			}
+			result.Removed = append(result.Removed, p.ID)
+		} else if !sameDefinition(p, onDisk[i]) {
+			result.Updated = append(result.Updated, p.ID)
+		}
+	}
+	for _, p := range onDisk {
+		if !slices.ContainsFunc(current, func(c SnapshotPolicy) bool { return c.ID == p.ID }) {
+			result.Added = append(result.Added, p.ID)
+		}
+	}
+
+	if !result.Changed() {
+		m.logger.Debug("Snapshot config file has no policy changes", "path", m.configPath)
+		return result, nil
+	}
+
+	var scheduleErr error
+	for _, id := range result.Removed {
+		m.reloadRemove(id)
+	}
+	for _, id := range result.Updated {
+		if err := m.reloadUpdate(onDisk[onDiskIndex[id]]); err != nil && scheduleErr == nil {
+			scheduleErr = err
+		}
+	}
+	for _, id := range result.Added {
+		if err := m.reloadAdd(onDisk[onDiskIndex[id]]); err != nil && scheduleErr == nil {
+			scheduleErr = err
+		}
+	}
+
+	// Write back the merged run state, which the edited file may lack
+	if err := m.SaveConfig(); err != nil {
+		return result, errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	m.logger.Info("Reloaded snapshot policies from config file",
+		"path", m.configPath,
+		"added", len(result.Added),
+		"updated", len(result.Updated),
+		"removed", len(result.Removed))
+	return result, scheduleErr
+}
+
+// readEditedConfig reads and validates the policies of the config file. It
+// reports the file as unchanged when it still holds the manager's last save.
+func (m *Manager) readEditedConfig() ([]SnapshotPolicy, bool, error) {
+	// Hold saveMu so a save in progress is not read half written
+	m.saveMu.Lock()
+	data, err := os.ReadFile(m.configPath)
+	unchanged := err == nil && sha256.Sum256(data) == m.savedHash
+	m.saveMu.Unlock()
+
+	if err != nil {
+		return nil, false, errors.Wrap(err, errors.ConfigReadError).
+			WithMetadata("path", m.configPath)
+	}
+	if unchanged {
+		return nil, true, nil
+	}
+
+	var config SnapshotConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, false, errors.Wrap(err, errors.ConfigParseError).
+			WithMetadata("path", m.configPath)
+	}
+
+	seen := make(map[string]bool, len(config.Policies))
+	for i, p := range config.Policies {
+		if p.ID == "" {
+			return nil, false, errors.New(errors.ConfigValidationFailed, "policy ID is required").
+				WithMetadata("policy_index", fmt.Sprintf("%d", i))
+		}
+		if seen[p.ID] {
+			return nil, false, errors.New(errors.ConfigValidationFailed, "duplicate policy ID").
+				WithMetadata("policy_id", p.ID)
+		}
+		seen[p.ID] = true
+
+		if err := ValidatePolicy(p); err != nil {
+			return nil, false, errors.Wrap(err, errors.ConfigValidationFailed).
+				WithMetadata("policy_id", p.ID)
+		}
+		if err := m.validateCalendarRefs(p.Schedules); err != nil {
+			return nil, false, errors.Wrap(err, errors.ConfigValidationFailed).
+				WithMetadata("policy_id", p.ID)
+		}
+	}
+	return config.Policies, false, nil
+}
+
+// reloadRemove unschedules and drops a policy removed from the file,
+// keeping it in the trash
+func (m *Manager) reloadRemove(policyID string) {
+	defer m.lockPolicy(policyID)()
+
+	m.unscheduleJobs(policyID)
+
+	m.mu.Lock()
+	if i, ok := m.policyIndex[policyID]; ok {
+		if _, err := m.policyTrash.Put(context.Background(), policyID, m.config.Policies[i]); err != nil {
+			m.logger.Warn("Failed to move policy edited out of config file to the trash",
+				"policy_id", policyID,
+				"error", err)
+		}
+		m.config.Policies = slices.Delete(m.config.Policies, i, i+1)
+		m.reindex()
+	}
+	delete(m.config.Monitors, policyID)
+	m.mu.Unlock()
+
+	m.logger.Info("Removed snapshot policy edited out of config file", "policy_id", policyID)
+}
+
+// reloadUpdate applies the edited definition of a policy, keeping its run
+// state and transfer policy associations
+func (m *Manager) reloadUpdate(edited SnapshotPolicy) error {
+	defer m.lockPolicy(edited.ID)()
+
+	m.mu.RLock()
+	existing, found := m.lookupPolicy(edited.ID)
+	m.mu.RUnlock()
+	if !found {
+		return nil
+	}
+
+	updated := existing
+	updated.Name = edited.Name
+	updated.Description = edited.Description
+	updated.Dataset = edited.Dataset
+	updated.Schedules = edited.Schedules
+	updated.Recursive = edited.Recursive
+	updated.ExcludeDatasets = edited.ExcludeDatasets
+	updated.SnapNamePattern = edited.SnapNamePattern
+	updated.RetentionPolicy = edited.RetentionPolicy
+	updated.Properties = edited.Properties
+	updated.Enabled = edited.Enabled
+	updated.UpdatedAt = time.Now()
+
+	m.unscheduleJobs(updated.ID)
+	m.updatePolicyState(updated.ID, func(p *SnapshotPolicy) {
+		*p = updated
+	})
+
+	m.logger.Info("Updated snapshot policy from config file",
+		"policy_id", updated.ID,
+		"policy_name", updated.Name,
+		"enabled", updated.Enabled)
+
+	if updated.Enabled {
+		if _, err := m.scheduleJobs(updated); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reloadAdd adds and schedules a policy added to the file
+func (m *Manager) reloadAdd(added SnapshotPolicy) error {
+	defer m.lockPolicy(added.ID)()
+
+	now := time.Now()
+	if added.CreatedAt.IsZero() {
+		added.CreatedAt = now
+	}
+	added.UpdatedAt = now
+	// Associations are made by transfer policies, not by hand
+	added.TransferPolicyIDs = nil
+
+	m.mu.Lock()
+	if _, exists := m.policyIndex[added.ID]; exists {
+		m.mu.Unlock()
+		return nil
+	}
+	m.config.Policies = append(m.config.Policies, added)
+	m.reindex()
+	m.mu.Unlock()
+
+	m.logger.Info("Added snapshot policy from config file",
+		"policy_id", added.ID,
+		"policy_name", added.Name,
+		"enabled", added.Enabled)
+
+	if added.Enabled {
+		if _, err := m.scheduleJobs(added); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameDefinition reports whether two policies differ only in run state.
+// They are compared in YAML so that empty and missing fields are equal.
+func sameDefinition(a, b SnapshotPolicy) bool {
+	definition := func(p SnapshotPolicy) []byte {
+		data, err := yaml.Marshal(EditPolicyParams{
+			ID:              p.ID,
+			Name:            p.Name,
+			Description:     p.Description,
+			Dataset:         p.Dataset,
+			Schedules:       p.Schedules,
+			Recursive:       p.Recursive,
+			ExcludeDatasets: p.ExcludeDatasets,
+			SnapNamePattern: p.SnapNamePattern,
+			RetentionPolicy: p.RetentionPolicy,
+			Properties:      p.Properties,
+			Enabled:         p.Enabled,
+		})
+		if err != nil {
+			return nil
+		}
+		return data
+	}
+	da, db := definition(a), definition(b)
+	return da != nil && bytes.Equal(da, db)
+}
+
+// watchConfig reloads the config file when it is edited while the manager
+// runs. The directory is watched rather than the file, as editors often
+// replace the file instead of writing to it.
+func (m *Manager) watchConfig() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, errors.FSError)
+	}
+	if err := watcher.Add(filepath.Dir(m.configPath)); err != nil {
+		watcher.Close()
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", m.configPath)
+	}
+
+	stop := make(chan struct{})
+	m.mu.Lock()
+	m.stopWatch = stop
+	m.mu.Unlock()
+
+	crash.Go("snapshot-config-watch", func() {
+		defer watcher.Close()
+
+		debounce := time.NewTimer(reloadDebounce)
+		debounce.Stop()
+		defer debounce.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == m.configPath &&
+					event.Has(fsnotify.Write|fsnotify.Create) {
+					debounce.Reset(reloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				m.logger.Warn("Snapshot config watcher error", "error", err)
+			case <-debounce.C:
+				if _, err := m.ReloadConfig(); err != nil {
+					m.logger.Error("Edited snapshot config not applied",
+						"path", m.configPath,
+						"error", err)
+				}
+			}
+		}
+	})
+
+	m.logger.Debug("Watching snapshot config file for edits", "path", m.configPath)
+	return nil
+}
+
+// stopWatchingConfig stops the config file watcher, if running
+func (m *Manager) stopWatchingConfig() {
+	m.mu.Lock()
+	stop := m.stopWatch
+	m.stopWatch = nil
+	m.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"slices"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// SetPolicyDataset points a policy at a renamed dataset, along with its
+// exclude patterns. Snapshots already taken keep their names and stay under
+// the policy's retention.
+func (m *Manager) SetPolicyDataset(policyID, name string, excludeDatasets []string) error {
+	defer m.lockPolicy(policyID)()
+
+	m.mu.RLock()
+	existing, found := m.lookupPolicy(policyID)
+	m.mu.RUnlock()
+
+	if !found {
+		return errors.New(errors.NotFoundError, "policy not found")
+	}
+
+	updated := existing
+	updated.Dataset = name
+	updated.ExcludeDatasets = slices.Clone(excludeDatasets)
+	updated.UpdatedAt = time.Now()
+	if err := ValidatePolicy(updated); err != nil {
+		return err
+	}
+
+	// Jobs are tagged with the dataset name
+	m.unscheduleJobs(policyID)
+
+	m.updatePolicyState(policyID, func(p *SnapshotPolicy) {
+		p.Dataset = updated.Dataset
+		p.ExcludeDatasets = updated.ExcludeDatasets
+		p.UpdatedAt = updated.UpdatedAt
+	})
+
+	if updated.Enabled {
+		if _, err := m.scheduleJobs(updated); err != nil {
+			return err
+		}
+	}
+
+	if err := m.SaveConfig(); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	m.logger.Info("Snapshot policy dataset changed",
+		"policy_id", policyID,
+		"old_dataset", existing.Dataset,
+		"dataset", name)
+	return nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// TagBackfillAction is what a back-fill did, or would do, with a snapshot
+type TagBackfillAction string
+
+const (
+	TagBackfillTagged   TagBackfillAction = "tagged"
+	TagBackfillWouldTag TagBackfillAction = "would_tag" // dry-run
+	TagBackfillSkipped  TagBackfillAction = "skipped"
+	TagBackfillFailed   TagBackfillAction = "failed"
+)
+
+// TagBackfillResult is the outcome of back-filling the tags of one snapshot
+type TagBackfillResult struct {
+	Snapshot string            `json:"snapshot"`
+	PolicyID string            `json:"policy_id,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Action   TagBackfillAction `json:"action"`
+	Reason   string            `json:"reason,omitempty"`
+}
+
+// TagBackfillReport is the outcome of back-filling tags onto the snapshots
+// policies took before snapshots were tagged
+type TagBackfillReport struct {
+	DryRun        bool                `json:"dry_run"`
+	Policies      int                 `json:"policies"`
+	AlreadyTagged int                 `json:"already_tagged"`
+	Tagged        int                 `json:"tagged"` // Tagged, or that would be in a dry-run
+	Skipped       int                 `json:"skipped"`
+	Failed        int                 `json:"failed"`
+	Results       []TagBackfillResult `json:"results,omitempty"`
+}
+
+// policySnapRegex matches the schedule index and policy ID suffix that
+// expandSnapNamePattern ends every snapshot name with
+func policySnapRegex(policyID string) *regexp.Regexp {
+	suffix := policyID
+	if parts := strings.Split(policyID, "-"); len(parts) > 0 {
+		suffix = parts[len(parts)-1]
+	}
+	return regexp.MustCompile(`-(\d+)-` + regexp.QuoteMeta(suffix) + `$`)
+}
+
+// planTagBackfill decides the tags of the untagged snapshots, by dataset
+// name, that policies took. Snapshots whose names match several policies
+// are skipped, as retention could then not tell them apart.
+func planTagBackfill(policies []SnapshotPolicy, snapshots map[string]dataset.Dataset) (planned []TagBackfillResult, alreadyTagged int) {
+	regexes := make([]*regexp.Regexp, len(policies))
+	for i, p := range policies {
+		regexes[i] = policySnapRegex(p.ID)
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(snapshots)) {
+		if _, ok := dataset.TagValue(snapshots[name], TagPolicyID); ok {
+			alreadyTagged++
+			continue
+		}
+		datasetName, snapName, ok := strings.Cut(name, "@")
+		if !ok {
+			continue
+		}
+
+		var matched []TagBackfillResult
+		for i, p := range policies {
+			if !policyCovers(p, datasetName) {
+				continue
+			}
+			m := regexes[i].FindStringSubmatch(snapName)
+			if m == nil {
+				continue
+			}
+			idx, _ := strconv.Atoi(m[1])
+			tags := map[string]string{
+				TagPolicyID: p.ID,
+				TagSchedule: m[1],
+			}
+			if idx < len(p.Schedules) {
+				tags[TagRetentionClass] = string(p.Schedules[idx].Type)
+			}
+			matched = append(matched, TagBackfillResult{
+				Snapshot: name,
+				PolicyID: p.ID,
+				Tags:     tags,
+			})
+		}
+
+		switch len(matched) {
+		case 0:
+		case 1:
+			planned = append(planned, matched[0])
+		default:
+			planned = append(planned, TagBackfillResult{
+				Snapshot: name,
+				Action:   TagBackfillSkipped,
+				Reason:   "name matches more than one policy",
+			})
+		}
+	}
+
+	return planned, alreadyTagged
+}
+
+// policyCovers reports whether a policy snapshots the dataset
+func policyCovers(p SnapshotPolicy, name string) bool {
+	if name == p.Dataset {
+		return true
+	}
+	return p.Recursive && strings.HasPrefix(name, p.Dataset+"/") && !p.IsDatasetExcluded(name)
+}
+
+// BackfillTags stamps the policy tags onto the snapshots policies took
+// before snapshots were tagged, matching them by the ending of their names
+// as retention did. With dryRun nothing is changed and the report lists the
+// tags that would be set.
+func (m *Manager) BackfillTags(ctx context.Context, dryRun bool) (TagBackfillReport, error) {
+	report := TagBackfillReport{DryRun: dryRun}
+
+	policies, err := m.ListPolicies()
+	if err != nil {
+		return report, err
+	}
+	report.Policies = len(policies)
+	if len(policies) == 0 {
+		return report, nil
+	}
+
+	// List the snapshots of every policy dataset once, so overlapping
+	// policies see the same snapshots
+	snapshots := make(map[string]dataset.Dataset)
+	listed := make(map[string]bool)
+	for _, p := range policies {
+		if listed[p.Dataset] {
+			continue
+		}
+		listed[p.Dataset] = true
+		result, err := m.dsManager.List(ctx, dataset.ListConfig{
+			Name:       p.Dataset,
+			Type:       "snapshot",
+			Recursive:  true,
+			Properties: []string{"name", TagPolicyID},
+			Parsable:   true,
+		})
+		if err != nil {
+			// A policy whose dataset is gone has no snapshots to tag; the
+			// others are still back-filled
+			m.logger.Warn("Failed to list snapshots for tag back-fill",
+				"dataset", p.Dataset, "error", err)
+			report.Failed++
+			report.Results = append(report.Results, TagBackfillResult{
+				Snapshot: p.Dataset,
+				PolicyID: p.ID,
+				Action:   TagBackfillFailed,
+				Reason:   err.Error(),
+			})
+			continue
+		}
+		for name, ds := range result.Datasets {
+			snapshots[name] = ds
+		}
+	}
+
+	planned, alreadyTagged := planTagBackfill(policies, snapshots)
+	report.AlreadyTagged = alreadyTagged
+
+	for _, r := range planned {
+		switch {
+		case r.Action == TagBackfillSkipped:
+		case dryRun:
+			r.Action = TagBackfillWouldTag
+		default:
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			// Tag under the policy lock, so retention does not prune the
+			// snapshot meanwhile
+			unlock := m.lockPolicy(r.PolicyID)
+			err := m.dsManager.SetTags(ctx, r.Snapshot, r.Tags)
+			unlock()
+			if err != nil {
+				r.Action = TagBackfillFailed
+				r.Reason = err.Error()
+			} else {
+				r.Action = TagBackfillTagged
+			}
+		}
+
+		switch r.Action {
+		case TagBackfillTagged, TagBackfillWouldTag:
+			report.Tagged++
+		case TagBackfillSkipped:
+			report.Skipped++
+		case TagBackfillFailed:
+			report.Failed++
+		}
+		report.Results = append(report.Results, r)
+	}
+
+	slices.SortStableFunc(report.Results, func(a, b TagBackfillResult) int {
+		return cmp.Compare(a.PolicyID, b.PolicyID)
+	})
+
+	m.logger.Info("Back-filled snapshot tags",
+		"dry_run", dryRun,
+		"already_tagged", report.AlreadyTagged,
+		"tagged", report.Tagged,
+		"skipped", report.Skipped,
+		"failed", report.Failed)
+	return report, nil
+}
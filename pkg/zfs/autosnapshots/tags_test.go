@@ -0,0 +1,73 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"testing"
+
+	ds "github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanTagBackfill(t *testing.T) {
+	daily := SnapshotPolicy{
+		ID:              "0196d3a4-7b2c-7e1f-9a3b-d1f36875b92f",
+		Dataset:         "tank/data",
+		Recursive:       true,
+		ExcludeDatasets: []string{"tank/data/scratch"},
+		Schedules:       []ScheduleSpec{{Type: ScheduleTypeHourly}, {Type: ScheduleTypeDaily}},
+	}
+	other := SnapshotPolicy{
+		ID:        "0196d3a4-7b2c-7e1f-9a3b-00000000abcd",
+		Dataset:   "tank/data",
+		Schedules: []ScheduleSpec{{Type: ScheduleTypeWeekly}},
+	}
+
+	snap := func(tags map[string]string) ds.Dataset {
+		props := map[string]ds.Property{TagPolicyID: {Value: "-"}}
+		for k, v := range tags {
+			props[k] = ds.Property{Value: v}
+		}
+		return ds.Dataset{Properties: props}
+	}
+	snapshots := map[string]ds.Dataset{
+		"tank/data@auto-2025-05-15-1-d1f36875b92f":         snap(nil),
+		"tank/data/child@auto-2025-05-15-1-d1f36875b92f":   snap(nil),
+		"tank/data/scratch@auto-2025-05-15-1-d1f36875b92f": snap(nil), // Excluded
+		"tank/data@auto-2025-05-15-7-d1f36875b92f":         snap(nil), // Schedule since removed
+		"tank/data@weekly-0-00000000abcd":                  snap(nil),
+		"tank/data@manual":                                 snap(nil),
+		"tank/data@auto-2025-05-14-0-d1f36875b92f":         snap(map[string]string{TagPolicyID: daily.ID}),
+	}
+
+	planned, alreadyTagged := planTagBackfill([]SnapshotPolicy{daily, other}, snapshots)
+	assert.Equal(t, 1, alreadyTagged)
+
+	byName := make(map[string]TagBackfillResult)
+	for _, r := range planned {
+		byName[r.Snapshot] = r
+	}
+	require.Len(t, byName, 4)
+
+	assert.Equal(t, map[string]string{
+		TagPolicyID:       daily.ID,
+		TagSchedule:       "1",
+		TagRetentionClass: "daily",
+	}, byName["tank/data@auto-2025-05-15-1-d1f36875b92f"].Tags)
+	assert.Equal(t, daily.ID, byName["tank/data/child@auto-2025-05-15-1-d1f36875b92f"].PolicyID)
+	assert.NotContains(t, byName, "tank/data/scratch@auto-2025-05-15-1-d1f36875b92f")
+	assert.NotContains(t, byName["tank/data@auto-2025-05-15-7-d1f36875b92f"].Tags, TagRetentionClass)
+	assert.Equal(t, other.ID, byName["tank/data@weekly-0-00000000abcd"].PolicyID)
+
+	// Policies whose ID suffixes collide cannot be told apart
+	twin := other
+	twin.ID = "0196d3a4-7b2c-7e1f-aaaa-00000000abcd"
+	planned, _ = planTagBackfill([]SnapshotPolicy{other, twin}, map[string]ds.Dataset{
+		"tank/data@weekly-0-00000000abcd": snap(nil),
+	})
+	require.Len(t, planned, 1)
+	assert.Equal(t, TagBackfillSkipped, planned[0].Action)
+}
@@ -0,0 +1,124 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autosnapshots
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// policyTrashDir holds removed policies, relative to the config directory
+const policyTrashDir = "trash/snapshot-policies"
+
+// TrashedPolicy is a removed policy that can still be restored
+type TrashedPolicy struct {
+	TrashID   string         `json:"trash_id"`
+	DeletedAt time.Time      `json:"deleted_at"`
+	DeletedBy common.Actor   `json:"deleted_by"`
+	ExpiresAt time.Time      `json:"expires_at"`
+	Policy    SnapshotPolicy `json:"policy"`
+}
+
+// ListTrashedPolicies returns the removed policies that can be restored,
+// newest first
+func (m *Manager) ListTrashedPolicies() ([]TrashedPolicy, error) {
+	entries, err := m.policyTrash.List()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := make([]TrashedPolicy, 0, len(entries))
+	for _, e := range entries {
+		var policy SnapshotPolicy
+		if err := json.Unmarshal(e.Item, &policy); err != nil {
+			m.logger.Warn("Skipping unreadable trashed policy", "trash_id", e.ID, "error", err)
+			continue
+		}
+		policies = append(policies, TrashedPolicy{
+			TrashID:   e.ID,
+			DeletedAt: e.DeletedAt,
+			DeletedBy: e.DeletedBy,
+			ExpiresAt: e.ExpiresAt,
+			Policy:    policy,
+		})
+	}
+	return policies, nil
+}
+
+// RestorePolicy adds the most recently removed policy with the given ID
+// back and schedules it. Snapshots removed along with the policy are not
+// restored.
+func (m *Manager) RestorePolicy(ctx context.Context, policyID string) (SnapshotPolicy, error) {
+	defer m.lockPolicy(policyID)()
+
+	m.mu.RLock()
+	_, exists := m.policyIndex[policyID]
+	m.mu.RUnlock()
+	if exists {
+		return SnapshotPolicy{}, errors.New(errors.ZFSRequestValidationError,
+			"policy with the same ID already exists").
+			WithMetadata("policy_id", policyID)
+	}
+
+	entry, err := m.policyTrash.Latest(policyID)
+	if err != nil {
+		return SnapshotPolicy{}, err
+	}
+
+	var policy SnapshotPolicy
+	if err := json.Unmarshal(entry.Item, &policy); err != nil {
+		return SnapshotPolicy{}, errors.Wrap(err, errors.ZFSSnapshotPolicyError).
+			WithMetadata("trash_id", entry.ID)
+	}
+
+	// The policy is checked again, as calendars it used may be gone
+	if err := ValidatePolicy(policy); err != nil {
+		return SnapshotPolicy{}, err
+	}
+	if err := m.validateCalendarRefs(policy.Schedules); err != nil {
+		return SnapshotPolicy{}, err
+	}
+
+	// Transfer policies dropped their associations before the removal
+	policy.TransferPolicyIDs = nil
+	policy.UpdatedAt = time.Now()
+
+	m.mu.Lock()
+	m.config.Policies = append(m.config.Policies, policy)
+	m.reindex()
+	m.mu.Unlock()
+
+	if policy.Enabled {
+		if _, err := m.scheduleJobs(policy); err != nil {
+			return policy, err
+		}
+	}
+
+	if err := m.SaveConfig(); err != nil {
+		m.logger.Error("Failed to save config after restoring policy",
+			"policy_id", policyID,
+			"error", err)
+		return policy, errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	if err := m.policyTrash.Remove(entry.ID); err != nil {
+		m.logger.Warn("Failed to remove restored policy from the trash",
+			"policy_id", policyID,
+			"trash_id", entry.ID,
+			"error", err)
+	}
+
+	actor := common.ActorFromContext(ctx)
+	m.logger.Info("Restored snapshot policy from the trash",
+		"policy_id", policyID,
+		"policy_name", policy.Name,
+		"actor_source", actor.Source,
+		"actor_user", actor.User)
+	return policy, nil
+}
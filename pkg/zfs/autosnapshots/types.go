@@ -5,7 +5,9 @@
 package autosnapshots
 
 import (
+	"context"
 	"fmt"
+	"path"
 	"regexp"
 	"strconv"
 	"strings"
@@ -13,12 +15,22 @@ import (
 
 	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
 )
 
 var APIError = common.APIError
 var ReadResetBody = common.ReadResetBody
 var ResetBody = common.ResetBody
 
+// User properties stamped on the snapshots policies create. Retention and
+// policy removal find a policy's snapshots by them.
+const (
+	TagPolicyID       = dataset.TagPrefix + "policy_id"
+	TagSchedule       = dataset.TagPrefix + "schedule"        // Index of the schedule that ran
+	TagRetentionClass = dataset.TagPrefix + "retention_class" // Schedule type, e.g. "daily"
+)
+
 // ScheduleType represents the type of schedule
 type ScheduleType string
 
@@ -43,6 +55,14 @@ type RetentionPolicy struct {
 	KeepNamedSnap []string      `json:"keep_named_snap" yaml:"keep_named_snap"` // List of specific snapshot names to keep
 }
 
+// ScheduleRetention overrides the policy retention for the snapshots of one
+// schedule, so a policy can keep, say, 24 hourly and 30 daily snapshots.
+// The policy's keep_named_snap and force_destroy still apply.
+type ScheduleRetention struct {
+	Count     int           `json:"count"      yaml:"count"`      // Number of the schedule's snapshots to keep
+	OlderThan time.Duration `json:"older_than" yaml:"older_than"` // Prune the schedule's snapshots older than this duration
+}
+
 // ScheduleSpec defines a specific schedule configuration
 type ScheduleSpec struct {
 	Type        ScheduleType  `json:"type"         yaml:"type"`         // Type of schedule
@@ -58,6 +78,11 @@ type ScheduleSpec struct {
 	EndTime     time.Time     `json:"end_time"     yaml:"end_time"`     // End time for the schedule
 	LimitedRuns int           `json:"limited_runs" yaml:"limited_runs"` // Number of runs to limit to (0 = unlimited)
 	Enabled     bool          `json:"enabled"      yaml:"enabled"`      // Whether this schedule is enabled
+
+	Calendar       string           `json:"calendar,omitempty"        yaml:"calendar,omitempty"`        // Name of a calendar of blocked days
+	CalendarAction calendars.Action `json:"calendar_action,omitempty" yaml:"calendar_action,omitempty"` // skip (default) or shift runs due on blocked days
+
+	Retention *ScheduleRetention `json:"retention,omitempty" yaml:"retention,omitempty"` // Retention of this schedule's snapshots (nil: the policy's)
 }
 
 // SnapshotPolicy represents a complete auto-snapshot policy
@@ -68,6 +93,7 @@ type SnapshotPolicy struct {
 	Dataset           string            `json:"dataset"             yaml:"dataset"`                       // ZFS dataset to snapshot
 	Schedules         []ScheduleSpec    `json:"schedules"           yaml:"schedules"`                     // List of schedules for this policy (max 5)
 	Recursive         bool              `json:"recursive"           yaml:"recursive"`                     // Whether to snapshot recursively
+	ExcludeDatasets   []string          `json:"exclude_datasets"    yaml:"exclude_datasets,omitempty"`    // Glob patterns of child datasets to leave out of recursive snapshots
 	SnapNamePattern   string            `json:"snap_name_pattern"   yaml:"snap_name_pattern"`             // Pattern for snapshot names
 	RetentionPolicy   RetentionPolicy   `json:"retention_policy"    yaml:"retention_policy"`              // Retention/pruning policy
 	Properties        map[string]string `json:"properties"          yaml:"properties"`                    // ZFS properties to set on snapshots
@@ -78,6 +104,7 @@ type SnapshotPolicy struct {
 	LastRunStatus     string            `json:"last_run_status"     yaml:"last_run_status"`               // Status of the last run
 	LastRunError      string            `json:"last_run_error"      yaml:"last_run_error"`                // Error from the last run, if any
 	TransferPolicyIDs []string          `json:"transfer_policy_ids" yaml:"transfer_policy_ids,omitempty"` // IDs of transfer policies using this snapshot policy
+	LastPruneResults  []PruneResult     `json:"last_prune_results"  yaml:"last_prune_results,omitempty"`  // Per-snapshot outcome of the last pruning pass
 	MonitorStatus     *JobMonitor       `json:"monitor_status"      yaml:"-"`                             // Detailed job monitor status (not stored in YAML)
 }
 
@@ -91,6 +118,11 @@ type JobMonitor struct {
 	RunCount     int           `json:"run_count"     yaml:"run_count"`
 	LastDuration time.Duration `json:"last_duration" yaml:"last_duration"`
 	LastError    string        `json:"last_error"    yaml:"last_error"`
+
+	LastSkipReason string `json:"last_skip_reason,omitempty" yaml:"last_skip_reason,omitempty"` // Why the last due run was skipped or shifted by a calendar
+
+	RecentRuns      []RunRecord  `json:"recent_runs,omitempty"       yaml:"recent_runs,omitempty"`       // Size and duration of the latest successful runs, oldest first
+	NextRunEstimate *RunEstimate `json:"next_run_estimate,omitempty" yaml:"next_run_estimate,omitempty"` // Average of RecentRuns
 }
 
 // SnapshotConfig wraps the collection of snapshot policies and job monitors
@@ -107,6 +139,7 @@ type EditPolicyParams struct {
 	Dataset         string            `json:"dataset"`   // Required
 	Schedules       []ScheduleSpec    `json:"schedules"` // Required, max 5
 	Recursive       bool              `json:"recursive"`
+	ExcludeDatasets []string          `json:"exclude_datasets,omitempty"`
 	SnapNamePattern string            `json:"snap_name_pattern,omitempty"`
 	RetentionPolicy RetentionPolicy   `json:"retention_policy,omitempty"`
 	Properties      map[string]string `json:"properties,omitempty"`
@@ -118,31 +151,60 @@ type RunPolicyParams struct {
 	ID            string `json:"id"`                // Policy ID
 	ScheduleIndex int    `json:"schedule_index"`    // Index of schedule to run
 	DryRun        bool   `json:"dry_run,omitempty"` // Just simulate, don't create
+	Suffix        string `json:"suffix,omitempty"`  // Added to the snapshot name, e.g. a build number
+}
+
+// TriggerPolicyParams are the optional parameters of a run triggered by an
+// external system
+type TriggerPolicyParams struct {
+	ScheduleIndex int    `json:"schedule_index"`   // Index of schedule to run
+	Suffix        string `json:"suffix,omitempty"` // Added to the snapshot name, e.g. a build number
 }
 
 // CreateSnapshotResult is the result of creating a snapshot
 type CreateSnapshotResult struct {
-	PolicyID        string    `json:"policy_id"`
-	ScheduleIndex   int       `json:"schedule_index"`
-	DatasetName     string    `json:"dataset_name"`
-	SnapshotName    string    `json:"snapshot_name"`
-	CreatedAt       time.Time `json:"created_at"`
-	Error           error     `json:"error,omitempty"`
-	PrunedSnapshots []string  `json:"pruned_snapshots,omitempty"`
+	PolicyID        string        `json:"policy_id"`
+	ScheduleIndex   int           `json:"schedule_index"`
+	DatasetName     string        `json:"dataset_name"`
+	SnapshotName    string        `json:"snapshot_name"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Error           error         `json:"error,omitempty"`
+	PrunedSnapshots []string      `json:"pruned_snapshots,omitempty"`
+	PruneResults    []PruneResult `json:"prune_results,omitempty"`
+	DryRun          bool          `json:"dry_run,omitempty"`
+	WrittenBytes    int64         `json:"written_bytes,omitempty"` // Data written to the dataset since the previous snapshot
+	Duration        time.Duration `json:"duration,omitempty"`
+}
+
+// PruneAction describes what pruning did, or would do, with a snapshot
+type PruneAction string
+
+const (
+	PruneActionDestroyed    PruneAction = "destroyed"
+	PruneActionWouldDestroy PruneAction = "would_destroy" // dry-run
+	PruneActionSkipped      PruneAction = "skipped"
+)
+
+// PruneResult is the outcome of pruning a single snapshot
+type PruneResult struct {
+	Snapshot     string      `json:"snapshot"                yaml:"snapshot"`
+	Action       PruneAction `json:"action"                  yaml:"action"`
+	Reason       string      `json:"reason,omitempty"        yaml:"reason,omitempty"`        // Why the snapshot was skipped, e.g. dependent clones
+	ReclaimBytes int64       `json:"reclaim_bytes,omitempty" yaml:"reclaim_bytes,omitempty"` // Estimated space freed, from zfs destroy -nv
 }
 
 // SchedulerInterface defines the interface for the scheduler
 type SchedulerInterface interface {
 	AddPolicy(params EditPolicyParams) (string, error)
 	UpdatePolicy(params EditPolicyParams) error
-	RemovePolicy(policyID string, removeSnapshots bool) error
+	RemovePolicy(ctx context.Context, policyID string, removeSnapshots bool) error
 	GetPolicy(policyID string) (SnapshotPolicy, error)
 	ListPolicies() ([]SnapshotPolicy, error)
-	RunPolicy(params RunPolicyParams) (CreateSnapshotResult, error)
+	RunPolicy(ctx context.Context, params RunPolicyParams) (CreateSnapshotResult, error)
 	Start() error
 	Stop() error
 	LoadConfig() error
-	SaveConfig(skipLock bool) error
+	SaveConfig() error
 }
 
 // NewSnapshotPolicy creates a new snapshot policy with default values
@@ -161,6 +223,7 @@ func NewSnapshotPolicy(params EditPolicyParams) SnapshotPolicy {
 		Dataset:         params.Dataset,
 		Schedules:       params.Schedules,
 		Recursive:       params.Recursive,
+		ExcludeDatasets: params.ExcludeDatasets,
 		SnapNamePattern: params.SnapNamePattern,
 		RetentionPolicy: params.RetentionPolicy,
 		Properties:      params.Properties,
@@ -178,6 +241,19 @@ func NewSnapshotPolicy(params EditPolicyParams) SnapshotPolicy {
 
 // ValidateScheduleSpec validates a schedule specification
 func ValidateScheduleSpec(spec ScheduleSpec) error {
+	if r := spec.Retention; r != nil && (r.Count < 0 || r.OlderThan < 0) {
+		return errors.New(errors.ZFSRequestValidationError,
+			"schedule retention count and older_than cannot be negative")
+	}
+	if err := calendars.ValidateAction(spec.Calendar, spec.CalendarAction); err != nil {
+		return err
+	}
+	if spec.Calendar != "" {
+		if err := calendars.ValidateName(spec.Calendar); err != nil {
+			return err
+		}
+	}
+
 	switch spec.Type {
 	case ScheduleTypeSecondly, ScheduleTypeMinutely, ScheduleTypeHourly:
 		if spec.Interval <= 0 {
@@ -349,5 +425,91 @@ func ValidatePolicy(policy SnapshotPolicy) error {
 		}
 	}
 
+	if len(policy.ExcludeDatasets) > 0 && !policy.Recursive {
+		return errors.New(
+			errors.ZFSRequestValidationError,
+			"exclude_datasets requires a recursive policy",
+		)
+	}
+
+	for _, pattern := range policy.ExcludeDatasets {
+		if pattern == "" {
+			return errors.New(errors.ZFSRequestValidationError, "exclude pattern cannot be empty")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.New(errors.ZFSRequestValidationError, "invalid exclude pattern").
+				WithMetadata("pattern", pattern)
+		}
+	}
+
 	return nil
 }
+
+// HasRetention reports whether any of the policy's snapshots are pruned
+func (p SnapshotPolicy) HasRetention() bool {
+	if p.RetentionPolicy.Count > 0 || p.RetentionPolicy.OlderThan > 0 {
+		return true
+	}
+	for _, s := range p.Schedules {
+		if s.Retention != nil && (s.Retention.Count > 0 || s.Retention.OlderThan > 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// retentionClass returns the schedule whose own retention applies to the
+// snapshots of a schedule, or -1 when the policy retention does. Snapshots
+// of unknown or removed schedules fall under the policy retention.
+func (p SnapshotPolicy) retentionClass(scheduleIndex int) int {
+	if scheduleIndex >= 0 && scheduleIndex < len(p.Schedules) &&
+		p.Schedules[scheduleIndex].Retention != nil {
+		return scheduleIndex
+	}
+	return -1
+}
+
+// retentionFor returns the count and age limits of a retention class
+func (p SnapshotPolicy) retentionFor(class int) (count int, olderThan time.Duration) {
+	if class >= 0 {
+		r := p.Schedules[class].Retention
+		return r.Count, r.OlderThan
+	}
+	return p.RetentionPolicy.Count, p.RetentionPolicy.OlderThan
+}
+
+// IsDatasetExcluded reports whether a descendant of the policy dataset is
+// excluded. Patterns are matched against both the full dataset name and the
+// name relative to the policy dataset; excluding a dataset also excludes its
+// children. The policy dataset itself is never excluded.
+func (p SnapshotPolicy) IsDatasetExcluded(name string) bool {
+	if len(p.ExcludeDatasets) == 0 || !strings.HasPrefix(name, p.Dataset+"/") {
+		return false
+	}
+
+	relative := strings.TrimPrefix(name, p.Dataset+"/")
+	components := strings.Split(relative, "/")
+	for i := range components {
+		relPrefix := strings.Join(components[:i+1], "/")
+		fullPrefix := p.Dataset + "/" + relPrefix
+		for _, pattern := range p.ExcludeDatasets {
+			if ok, _ := path.Match(pattern, relPrefix); ok {
+				return true
+			}
+			if ok, _ := path.Match(pattern, fullPrefix); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Covers reports whether snapshots taken by the policy include the dataset,
+// either directly or through a recursive snapshot of a parent
+func (p SnapshotPolicy) Covers(name string) bool {
+	if name == p.Dataset {
+		return true
+	}
+	return p.Recursive && strings.HasPrefix(name, p.Dataset+"/") && !p.IsDatasetExcluded(name)
+}
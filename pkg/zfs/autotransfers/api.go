@@ -72,10 +72,23 @@ func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
 			policies.POST("/:policy_id/disable",
 				ValidateEnableDisableParams(),
 				h.disablePolicy)
+			policies.POST("/:policy_id/seed", h.seedPolicy)
+			policies.DELETE("/:policy_id/seed", h.cancelPolicySeed)
+			policies.POST("/:policy_id/import", h.importHistory)
+			policies.POST("/import", h.importAllHistory)
 		}
 	}
 }
 
+// RegisterReplicationRoutes registers read-only replication overview routes.
+// These live directly under the ZFS API group rather than the schedulers group.
+func (h *Handler) RegisterReplicationRoutes(router *gin.RouterGroup) {
+	replication := router.Group("/replication")
+	{
+		replication.GET("/graph", h.getReplicationGraph)
+	}
+}
+
 // StartManager starts the transfer policy manager scheduler
 func (h *Handler) StartManager() error {
 	return h.manager.Start()
@@ -155,7 +168,7 @@ func (h *Handler) createPolicy(c *gin.Context) {
 		return
 	}
 
-	h.sendSuccess(c, http.StatusCreated, policy)
+	h.sendSuccess(c, http.StatusCreated, policy.Redacted())
 }
 
 // listPolicies lists all transfer policies
@@ -167,7 +180,7 @@ func (h *Handler) listPolicies(c *gin.Context) {
 	}
 
 	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
-		"policies": policies,
+		"policies": RedactPolicies(policies),
 		"count":    len(policies),
 	})
 }
@@ -186,7 +199,7 @@ func (h *Handler) getPolicy(c *gin.Context) {
 		return
 	}
 
-	h.sendSuccess(c, http.StatusOK, policy)
+	h.sendSuccess(c, http.StatusOK, policy.Redacted())
 }
 
 // updatePolicy updates a transfer policy
@@ -220,7 +233,7 @@ func (h *Handler) updatePolicy(c *gin.Context) {
 		return
 	}
 
-	h.sendSuccess(c, http.StatusOK, policy)
+	h.sendSuccess(c, http.StatusOK, policy.Redacted())
 }
 
 // deletePolicy deletes a transfer policy
@@ -338,3 +351,86 @@ func (h *Handler) disablePolicy(c *gin.Context) {
 		"policy_id": policyID,
 	})
 }
+
+// seedPolicy exports a policy's initial send to removable media
+func (h *Handler) seedPolicy(c *gin.Context) {
+	var params SeedPolicyParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.New(errors.TransferPolicyInvalidConfig, err.Error()))
+		return
+	}
+
+	seed, err := h.manager.SeedPolicy(c.Request.Context(), c.Param("policy_id"), params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusAccepted, seed)
+}
+
+// cancelPolicySeed stops a policy from waiting for its seed
+func (h *Handler) cancelPolicySeed(c *gin.Context) {
+	policyID := c.Param("policy_id")
+	if err := h.manager.CancelSeed(c.Request.Context(), policyID); err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"message":   "Policy no longer waits for a seed",
+		"policy_id": policyID,
+	})
+}
+
+// importHistory imports replication done before the policy was managed
+func (h *Handler) importHistory(c *gin.Context) {
+	var params ImportHistoryParams
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			h.sendError(c, errors.New(errors.TransferPolicyInvalidConfig, err.Error()))
+			return
+		}
+	}
+
+	result, err := h.manager.ImportHistory(c.Request.Context(), c.Param("policy_id"), params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, result)
+}
+
+// importAllHistory imports replication for every policy that has not run
+func (h *Handler) importAllHistory(c *gin.Context) {
+	var params ImportHistoryParams
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&params); err != nil {
+			h.sendError(c, errors.New(errors.TransferPolicyInvalidConfig, err.Error()))
+			return
+		}
+	}
+
+	results, err := h.manager.ImportAllHistory(c.Request.Context(), params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
+// getReplicationGraph returns the replication topology for visualization
+func (h *Handler) getReplicationGraph(c *gin.Context) {
+	graph, err := h.manager.BuildReplicationGraph()
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, graph)
+}
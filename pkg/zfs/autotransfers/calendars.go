@@ -0,0 +1,94 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
+)
+
+// UseCalendars lets schedules reference calendars of blocked days, such as
+// maintenance windows, and keeps calendars that transfer policies reference
+// from being deleted
+func (m *Manager) UseCalendars(cm *calendars.Manager) {
+	cm.RegisterUsage(m.policiesUsingCalendar)
+	m.calendarManager.Store(cm)
+}
+
+// policiesUsingCalendar describes the policies with a schedule referencing
+// the calendar
+func (m *Manager) policiesUsingCalendar(calendar string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var users []string
+	for _, p := range m.config.Policies {
+		for _, s := range p.Schedules {
+			if s.Calendar == calendar {
+				users = append(users, fmt.Sprintf("transfer policy %s", p.Name))
+				break
+			}
+		}
+	}
+	return users
+}
+
+// validateCalendarRefs checks that every calendar the schedules reference exists
+func (m *Manager) validateCalendarRefs(schedules []autosnapshots.ScheduleSpec) error {
+	cm := m.calendarManager.Load()
+	for i, s := range schedules {
+		if err := cm.ValidateRef(s.Calendar, s.CalendarAction); err != nil {
+			return errors.Wrap(err, errors.TransferPolicyInvalidConfig).
+				WithMetadata("schedule_index", fmt.Sprintf("%d", i))
+		}
+	}
+	return nil
+}
+
+// calendarKeyPrefix matches the shifted runs of every schedule of a policy
+func calendarKeyPrefix(policyID string) string {
+	return fmt.Sprintf("transfer-policy:%s:", policyID)
+}
+
+// gateRun applies the schedule's calendar to a run due now. A blocked run is
+// recorded as skipped on the policy monitor and, for shifted runs, run is
+// called on the next open day. Runs due while schedulers are paused for
+// maintenance are held and run when they resume.
+func (m *Manager) gateRun(
+	policyID string,
+	scheduleIdx int,
+	schedule autosnapshots.ScheduleSpec,
+	run func(),
+) bool {
+	key := fmt.Sprintf("%s%d", calendarKeyPrefix(policyID), scheduleIdx)
+	held, reason := m.scheduler.Hold(key, run)
+	ok := !held
+	if ok {
+		ok, reason = m.calendarManager.Load().Gate(
+			schedule.Calendar,
+			schedule.CalendarAction,
+			key,
+			time.Now(),
+			run,
+		)
+	}
+	if ok {
+		return true
+	}
+
+	m.mu.Lock()
+	if monitor, exists := m.config.Monitors[policyID]; exists {
+		monitor.LastSkipped = true
+		monitor.LastSkipReason = reason
+		monitor.SkipCount++
+	}
+	m.mu.Unlock()
+
+	return false
+}
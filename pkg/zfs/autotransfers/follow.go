@@ -0,0 +1,101 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+)
+
+// handleSnapshotCreated schedules a run of every enabled policy that follows
+// the snapshot policy which created the snapshot
+func (m *Manager) handleSnapshotCreated(result autosnapshots.CreateSnapshotResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.started {
+		return
+	}
+
+	for _, policy := range m.config.Policies {
+		if policy.Enabled && policy.FollowSnapshotPolicy &&
+			policy.SnapshotPolicyID == result.PolicyID {
+			m.scheduleFollowRun(policy.ID, policy.FollowDelay, result.SnapshotName)
+		}
+	}
+}
+
+// scheduleFollowRun runs a follow-mode policy after delay. While a run is
+// pending, further snapshots are picked up by it, since the run transfers
+// the latest snapshot of the snapshot policy (must be called with lock held).
+func (m *Manager) scheduleFollowRun(policyID string, delay time.Duration, snapName string) {
+	if _, pending := m.followRuns[policyID]; pending {
+		m.logger.Debug("Follow-mode transfer already pending",
+			"policy_id", policyID,
+			"snapshot", snapName)
+		return
+	}
+
+	runAt := time.Now().Add(delay)
+	if monitor, exists := m.config.Monitors[policyID]; exists {
+		monitor.NextRunAt = &runAt
+	}
+
+	m.followRuns[policyID] = time.AfterFunc(delay, func() {
+		m.runFollow(policyID)
+	})
+
+	m.logger.Debug("Scheduled follow-mode transfer",
+		"policy_id", policyID,
+		"snapshot", snapName,
+		"run_at", runAt)
+}
+
+// runFollow executes a pending follow-mode run, unless the policy was
+// disabled or changed since it was scheduled
+func (m *Manager) runFollow(policyID string) {
+	m.mu.Lock()
+	delete(m.followRuns, policyID)
+
+	var policy *TransferPolicy
+	for i := range m.config.Policies {
+		p := m.config.Policies[i]
+		if p.ID == policyID && p.Enabled && p.FollowSnapshotPolicy {
+			policy = &p
+			break
+		}
+	}
+	if monitor, exists := m.config.Monitors[policyID]; exists {
+		monitor.NextRunAt = nil
+	}
+	started := m.started
+	m.mu.Unlock()
+
+	if policy == nil || !started {
+		return
+	}
+
+	// Held while schedulers are paused for maintenance, and run on resume
+	if held, _ := m.scheduler.Hold(calendarKeyPrefix(policyID)+"follow", func() {
+		m.runFollow(policyID)
+	}); held {
+		return
+	}
+
+	if _, err := m.runPolicyJob(context.Background(), policy, 0); err != nil {
+		m.logger.Warn("Follow-mode transfer failed", "policy_id", policyID, "error", err)
+	}
+}
+
+// cancelFollowRun drops a pending follow-mode run of a policy (must be
+// called with lock held)
+func (m *Manager) cancelFollowRun(policyID string) {
+	if timer, pending := m.followRuns[policyID]; pending {
+		timer.Stop()
+		delete(m.followRuns, policyID)
+	}
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// Replication graph node types
+const (
+	GraphNodeDataset        = "dataset"
+	GraphNodeSnapshotPolicy = "snapshot_policy"
+	GraphNodeTransferPolicy = "transfer_policy"
+	GraphNodeTransferTarget = "target"
+)
+
+// Replication graph edge types
+const (
+	GraphEdgeSnapshots    = "snapshots"     // dataset -> snapshot policy
+	GraphEdgeFeeds        = "feeds"         // snapshot policy -> transfer policy
+	GraphEdgeReplicatesTo = "replicates_to" // transfer policy -> target
+)
+
+// localTargetHost labels targets received on this host
+const localTargetHost = "local"
+
+// graphNodeID builds a node ID that is unique across node types
+func graphNodeID(nodeType string, parts ...string) string {
+	return nodeType + ":" + strings.Join(parts, ":")
+}
+
+// ReplicationGraph describes the replication topology: source datasets, the
+// snapshot policies that snapshot them, the transfer policies fed by those
+// snapshot policies, and the targets they replicate to
+type ReplicationGraph struct {
+	Nodes       []ReplicationGraphNode `json:"nodes"`
+	Edges       []ReplicationGraphEdge `json:"edges"`
+	GeneratedAt time.Time              `json:"generated_at"`
+}
+
+// ReplicationGraphNode is a vertex in the replication graph
+type ReplicationGraphNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Label   string `json:"label"`
+	Enabled *bool  `json:"enabled,omitempty"`
+
+	// Target details
+	Host    string `json:"host,omitempty"`
+	Dataset string `json:"dataset,omitempty"`
+}
+
+// ReplicationGraphEdge is a directed link between two nodes. Edges from a
+// transfer policy to its target carry replication state.
+type ReplicationGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Type string `json:"type"`
+
+	LastSuccessAt  *time.Time `json:"last_success_at,omitempty"`
+	LastTransferID string     `json:"last_transfer_id,omitempty"`
+	LastRunStatus  string     `json:"last_run_status,omitempty"`
+	LagSeconds     *int64     `json:"lag_seconds,omitempty"`
+
+	// RPO target of the transfer policy; Stale is set when lag exceeds it
+	RPOTargetSeconds *int64 `json:"rpo_target_seconds,omitempty"`
+	Stale            bool   `json:"stale,omitempty"`
+}
+
+// BuildReplicationGraph assembles the replication topology from the current
+// snapshot policies, transfer policies and transfer history
+func (m *Manager) BuildReplicationGraph() (*ReplicationGraph, error) {
+	snapshotPolicies, err := m.snapshotManager.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	transferPolicies, err := m.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	return buildReplicationGraph(
+		snapshotPolicies,
+		transferPolicies,
+		m.lastSuccessfulTransfers(),
+		time.Now(),
+	), nil
+}
+
+// buildReplicationGraph assembles the graph from the policies and the last
+// successful transfer of each transfer policy, measuring lag up to now
+func buildReplicationGraph(
+	snapshotPolicies []autosnapshots.SnapshotPolicy,
+	transferPolicies []TransferPolicy,
+	lastSuccess map[string]*dataset.TransferInfo,
+	now time.Time,
+) *ReplicationGraph {
+	graph := &ReplicationGraph{
+		Nodes:       []ReplicationGraphNode{},
+		Edges:       []ReplicationGraphEdge{},
+		GeneratedAt: now,
+	}
+	seen := make(map[string]bool)
+	addNode := func(node ReplicationGraphNode) {
+		if seen[node.ID] {
+			return
+		}
+		seen[node.ID] = true
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	sourceDatasets := make(map[string]string, len(snapshotPolicies))
+	for _, sp := range snapshotPolicies {
+		sourceDatasets[sp.ID] = sp.Dataset
+		enabled := sp.Enabled
+		datasetID := graphNodeID(GraphNodeDataset, sp.Dataset)
+		policyID := graphNodeID(GraphNodeSnapshotPolicy, sp.ID)
+
+		addNode(ReplicationGraphNode{
+			ID:      datasetID,
+			Type:    GraphNodeDataset,
+			Label:   sp.Dataset,
+			Dataset: sp.Dataset,
+		})
+		addNode(ReplicationGraphNode{
+			ID:      policyID,
+			Type:    GraphNodeSnapshotPolicy,
+			Label:   sp.Name,
+			Enabled: &enabled,
+		})
+		graph.Edges = append(graph.Edges, ReplicationGraphEdge{
+			From: datasetID,
+			To:   policyID,
+			Type: GraphEdgeSnapshots,
+		})
+	}
+
+	for _, tp := range transferPolicies {
+		enabled := tp.Enabled
+		policyID := graphNodeID(GraphNodeTransferPolicy, tp.ID)
+		addNode(ReplicationGraphNode{
+			ID:      policyID,
+			Type:    GraphNodeTransferPolicy,
+			Label:   tp.Name,
+			Enabled: &enabled,
+		})
+
+		snapshotPolicyNodeID := graphNodeID(GraphNodeSnapshotPolicy, tp.SnapshotPolicyID)
+		if seen[snapshotPolicyNodeID] {
+			graph.Edges = append(graph.Edges, ReplicationGraphEdge{
+				From: snapshotPolicyNodeID,
+				To:   policyID,
+				Type: GraphEdgeFeeds,
+			})
+		}
+
+		recvCfg := tp.TransferConfig.ReceiveConfig
+		if source, ok := sourceDatasets[tp.SnapshotPolicyID]; ok {
+			if target, err := ExpandTarget(recvCfg.Target, source); err == nil {
+				recvCfg.Target = target
+			}
+		}
+		host := recvCfg.RemoteConfig.Host
+		if host == "" {
+			host = localTargetHost
+		}
+		targetID := graphNodeID(GraphNodeTransferTarget, host, recvCfg.Target)
+		addNode(ReplicationGraphNode{
+			ID:      targetID,
+			Type:    GraphNodeTransferTarget,
+			Label:   fmt.Sprintf("%s:%s", host, recvCfg.Target),
+			Host:    host,
+			Dataset: recvCfg.Target,
+		})
+
+		edge := ReplicationGraphEdge{
+			From:          policyID,
+			To:            targetID,
+			Type:          GraphEdgeReplicatesTo,
+			LastRunStatus: tp.LastRunStatus,
+		}
+		if transfer, ok := lastSuccess[tp.ID]; ok && transfer.CompletedAt != nil {
+			completedAt := *transfer.CompletedAt
+			lag := int64(now.Sub(completedAt).Seconds())
+			edge.LastSuccessAt = &completedAt
+			edge.LastTransferID = transfer.ID
+			edge.LagSeconds = &lag
+		}
+		if tp.RPOTarget > 0 {
+			rpo := int64(tp.RPOTarget.Seconds())
+			edge.RPOTargetSeconds = &rpo
+			// Prefer the monitor's snapshot-based lag; fall back to time since
+			// the last successful transfer
+			if tp.MonitorStatus != nil && tp.MonitorStatus.LagCheckedAt != nil {
+				edge.Stale = tp.MonitorStatus.RPOBreached
+			} else {
+				edge.Stale = edge.LagSeconds == nil || *edge.LagSeconds > rpo
+			}
+		}
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	return graph
+}
+
+// lastSuccessfulTransfers returns the most recently completed transfer for
+// each transfer policy. Skipped transfers count as successful since the target
+// was already in sync.
+func (m *Manager) lastSuccessfulTransfers() map[string]*dataset.TransferInfo {
+	result := make(map[string]*dataset.TransferInfo)
+	if m.transferManager == nil {
+		return result
+	}
+
+	for _, transfer := range m.transferManager.ListTransfersByType(dataset.TransferTypeAll) {
+		if transfer.PolicyID == "" || transfer.CompletedAt == nil {
+			continue
+		}
+		if transfer.Status != dataset.TransferStatusCompleted &&
+			transfer.Status != dataset.TransferStatusSkipped {
+			continue
+		}
+		if current, ok := result[transfer.PolicyID]; !ok ||
+			transfer.CompletedAt.After(*current.CompletedAt) {
+			result[transfer.PolicyID] = transfer
+		}
+	}
+
+	return result
+}
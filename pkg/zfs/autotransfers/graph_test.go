@@ -0,0 +1,253 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// graphEdgeKey identifies an edge without its replication state
+type graphEdgeKey struct {
+	From, To, Type string
+}
+
+func graphEdgeKeys(graph *ReplicationGraph) []graphEdgeKey {
+	keys := make([]graphEdgeKey, 0, len(graph.Edges))
+	for _, e := range graph.Edges {
+		keys = append(keys, graphEdgeKey{e.From, e.To, e.Type})
+	}
+	return keys
+}
+
+func graphNodeIDs(graph *ReplicationGraph) []string {
+	ids := make([]string, 0, len(graph.Nodes))
+	for _, n := range graph.Nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestBuildReplicationGraphTopology(t *testing.T) {
+	remote := dataset.RemoteConfig{Host: "backup.example"}
+	transferPolicy := func(id, snapshotPolicyID, target string, remote dataset.RemoteConfig) TransferPolicy {
+		return TransferPolicy{
+			ID:               id,
+			Name:             id,
+			SnapshotPolicyID: snapshotPolicyID,
+			Enabled:          true,
+			TransferConfig: dataset.TransferConfig{
+				ReceiveConfig: dataset.ReceiveConfig{Target: target, RemoteConfig: remote},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		snapshotPolicies []autosnapshots.SnapshotPolicy
+		transferPolicies []TransferPolicy
+		wantNodes        []string
+		wantEdges        []graphEdgeKey
+	}{
+		{
+			name:      "empty",
+			wantNodes: []string{},
+			wantEdges: []graphEdgeKey{},
+		},
+		{
+			name: "snapshot policies on a shared dataset",
+			snapshotPolicies: []autosnapshots.SnapshotPolicy{
+				{ID: "hourly", Name: "Hourly", Dataset: "tank/db"},
+				{ID: "daily", Name: "Daily", Dataset: "tank/db"},
+			},
+			wantNodes: []string{
+				"dataset:tank/db",
+				"snapshot_policy:hourly",
+				"snapshot_policy:daily",
+			},
+			wantEdges: []graphEdgeKey{
+				{"dataset:tank/db", "snapshot_policy:hourly", GraphEdgeSnapshots},
+				{"dataset:tank/db", "snapshot_policy:daily", GraphEdgeSnapshots},
+			},
+		},
+		{
+			name: "local target expanded for the source dataset",
+			snapshotPolicies: []autosnapshots.SnapshotPolicy{
+				{ID: "hourly", Name: "Hourly", Dataset: "tank/db"},
+			},
+			transferPolicies: []TransferPolicy{
+				transferPolicy("local-copy", "hourly", "backup/{source_name}", dataset.RemoteConfig{}),
+			},
+			wantNodes: []string{
+				"dataset:tank/db",
+				"snapshot_policy:hourly",
+				"transfer_policy:local-copy",
+				"target:local:backup/db",
+			},
+			wantEdges: []graphEdgeKey{
+				{"dataset:tank/db", "snapshot_policy:hourly", GraphEdgeSnapshots},
+				{"snapshot_policy:hourly", "transfer_policy:local-copy", GraphEdgeFeeds},
+				{"transfer_policy:local-copy", "target:local:backup/db", GraphEdgeReplicatesTo},
+			},
+		},
+		{
+			name: "transfer policies sharing a remote target",
+			snapshotPolicies: []autosnapshots.SnapshotPolicy{
+				{ID: "hourly", Name: "Hourly", Dataset: "tank/db"},
+			},
+			transferPolicies: []TransferPolicy{
+				transferPolicy("offsite", "hourly", "backup/db", remote),
+				transferPolicy("offsite-again", "hourly", "backup/db", remote),
+			},
+			wantNodes: []string{
+				"dataset:tank/db",
+				"snapshot_policy:hourly",
+				"transfer_policy:offsite",
+				"target:backup.example:backup/db",
+				"transfer_policy:offsite-again",
+			},
+			wantEdges: []graphEdgeKey{
+				{"dataset:tank/db", "snapshot_policy:hourly", GraphEdgeSnapshots},
+				{"snapshot_policy:hourly", "transfer_policy:offsite", GraphEdgeFeeds},
+				{"transfer_policy:offsite", "target:backup.example:backup/db", GraphEdgeReplicatesTo},
+				{"snapshot_policy:hourly", "transfer_policy:offsite-again", GraphEdgeFeeds},
+				{"transfer_policy:offsite-again", "target:backup.example:backup/db", GraphEdgeReplicatesTo},
+			},
+		},
+		{
+			name: "transfer policy of a missing snapshot policy",
+			transferPolicies: []TransferPolicy{
+				transferPolicy("orphan", "gone", "backup/{source_name}", remote),
+			},
+			wantNodes: []string{
+				"transfer_policy:orphan",
+				"target:backup.example:backup/{source_name}",
+			},
+			wantEdges: []graphEdgeKey{
+				{"transfer_policy:orphan", "target:backup.example:backup/{source_name}", GraphEdgeReplicatesTo},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph := buildReplicationGraph(tt.snapshotPolicies, tt.transferPolicies, nil, time.Now())
+			assert.Equal(t, tt.wantNodes, graphNodeIDs(graph))
+			assert.Equal(t, tt.wantEdges, graphEdgeKeys(graph))
+		})
+	}
+}
+
+func TestBuildReplicationGraphLag(t *testing.T) {
+	now := time.Date(2025, 5, 15, 12, 0, 0, 0, time.UTC)
+	twoHoursAgo := now.Add(-2 * time.Hour)
+	checkedAt := now.Add(-time.Minute)
+
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name        string
+		rpo         time.Duration
+		monitor     *TransferPolicyMonitor
+		lastSuccess *dataset.TransferInfo
+		wantLag     *int64
+		wantRPO     *int64
+		wantStale   bool
+	}{
+		{
+			name:        "no RPO target is never stale",
+			lastSuccess: &dataset.TransferInfo{ID: "t1", CompletedAt: &twoHoursAgo},
+			wantLag:     int64Ptr(7200),
+		},
+		{
+			name: "no RPO target and no transfer",
+		},
+		{
+			name:        "lag within the RPO target",
+			rpo:         3 * time.Hour,
+			lastSuccess: &dataset.TransferInfo{ID: "t1", CompletedAt: &twoHoursAgo},
+			wantLag:     int64Ptr(7200),
+			wantRPO:     int64Ptr(10800),
+		},
+		{
+			name:        "lag beyond the RPO target",
+			rpo:         time.Hour,
+			lastSuccess: &dataset.TransferInfo{ID: "t1", CompletedAt: &twoHoursAgo},
+			wantLag:     int64Ptr(7200),
+			wantRPO:     int64Ptr(3600),
+			wantStale:   true,
+		},
+		{
+			name:      "never transferred with an RPO target",
+			rpo:       time.Hour,
+			wantRPO:   int64Ptr(3600),
+			wantStale: true,
+		},
+		{
+			name:        "monitor lag overrides time since the last transfer",
+			rpo:         time.Hour,
+			monitor:     &TransferPolicyMonitor{LagCheckedAt: &checkedAt, RPOBreached: false},
+			lastSuccess: &dataset.TransferInfo{ID: "t1", CompletedAt: &twoHoursAgo},
+			wantLag:     int64Ptr(7200),
+			wantRPO:     int64Ptr(3600),
+		},
+		{
+			name:      "monitor reports a breach",
+			rpo:       3 * time.Hour,
+			monitor:   &TransferPolicyMonitor{LagCheckedAt: &checkedAt, RPOBreached: true},
+			wantRPO:   int64Ptr(10800),
+			wantStale: true,
+		},
+		{
+			name:        "unchecked monitor falls back to the last transfer",
+			rpo:         time.Hour,
+			monitor:     &TransferPolicyMonitor{RPOBreached: false},
+			lastSuccess: &dataset.TransferInfo{ID: "t1", CompletedAt: &twoHoursAgo},
+			wantLag:     int64Ptr(7200),
+			wantRPO:     int64Ptr(3600),
+			wantStale:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := TransferPolicy{
+				ID:               "offsite",
+				SnapshotPolicyID: "hourly",
+				RPOTarget:        tt.rpo,
+				MonitorStatus:    tt.monitor,
+				LastRunStatus:    "completed",
+				TransferConfig: dataset.TransferConfig{
+					ReceiveConfig: dataset.ReceiveConfig{Target: "backup/db"},
+				},
+			}
+			lastSuccess := map[string]*dataset.TransferInfo{}
+			if tt.lastSuccess != nil {
+				lastSuccess[policy.ID] = tt.lastSuccess
+			}
+
+			graph := buildReplicationGraph(nil, []TransferPolicy{policy}, lastSuccess, now)
+			require.Len(t, graph.Edges, 1)
+			edge := graph.Edges[0]
+
+			assert.Equal(t, GraphEdgeReplicatesTo, edge.Type)
+			assert.Equal(t, "completed", edge.LastRunStatus)
+			assert.Equal(t, tt.wantLag, edge.LagSeconds)
+			assert.Equal(t, tt.wantRPO, edge.RPOTargetSeconds)
+			assert.Equal(t, tt.wantStale, edge.Stale)
+			if tt.lastSuccess != nil {
+				assert.Equal(t, "t1", edge.LastTransferID)
+				assert.Equal(t, twoHoursAgo, *edge.LastSuccessAt)
+			} else {
+				assert.Nil(t, edge.LastSuccessAt)
+			}
+		})
+	}
+}
@@ -96,7 +96,7 @@ func handleListPolicies(h *GRPCHandler) client.CommandHandler {
 
 		// Return success response with policies list
 		response := map[string]interface{}{
-			"policies": policies,
+			"policies": RedactPolicies(policies),
 			"count":    len(policies),
 		}
 		return successResponse(req.RequestId, "Transfer policies list", response)
@@ -127,7 +127,7 @@ func handleGetPolicy(h *GRPCHandler) client.CommandHandler {
 			return errorResponse(req.RequestId, err)
 		}
 
-		return successResponse(req.RequestId, "Transfer policy details", policy)
+		return successResponse(req.RequestId, "Transfer policy details", policy.Redacted())
 	}
 }
 
@@ -161,7 +161,7 @@ func handleCreatePolicy(h *GRPCHandler) client.CommandHandler {
 			return errorResponse(req.RequestId, err)
 		}
 
-		return successResponse(req.RequestId, "Transfer policy created successfully", createdPolicy)
+		return successResponse(req.RequestId, "Transfer policy created successfully", createdPolicy.Redacted())
 	}
 }
 
@@ -198,7 +198,7 @@ func handleUpdatePolicy(h *GRPCHandler) client.CommandHandler {
 			return errorResponse(req.RequestId, err)
 		}
 
-		return successResponse(req.RequestId, "Transfer policy updated successfully", updatedPolicy)
+		return successResponse(req.RequestId, "Transfer policy updated successfully", updatedPolicy.Redacted())
 	}
 }
 
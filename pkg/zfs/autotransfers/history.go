@@ -0,0 +1,66 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// handleTransferFinished adds a completed policy transfer to the run history
+// of its policy monitor, and retries a failed one when the failure is of a
+// kind retrying may fix
+func (m *Manager) handleTransferFinished(info dataset.TransferInfo) {
+	if info.PolicyID == "" {
+		return
+	}
+
+	m.mu.Lock()
+	monitor, exists := m.config.Monitors[info.PolicyID]
+	if !exists {
+		m.mu.Unlock()
+		return
+	}
+	switch info.Status {
+	case dataset.TransferStatusCompleted:
+		m.resetRetries(monitor)
+		recordTransferRun(monitor, info)
+	case dataset.TransferStatusFailed:
+		m.scheduleRetry(monitor, info.ErrorCategory)
+	default:
+		m.mu.Unlock()
+		return
+	}
+	m.mu.Unlock()
+
+	if err := m.SaveConfig(false); err != nil {
+		m.logger.Warn("Failed to save config after recording transfer run",
+			"policy_id", info.PolicyID,
+			"transfer_id", info.ID,
+			"error", err)
+	}
+}
+
+// recordTransferRun appends a completed transfer to the monitor's run
+// history and updates its estimate for the next run. It reports false for
+// transfers without start and completion times.
+func recordTransferRun(monitor *TransferPolicyMonitor, info dataset.TransferInfo) bool {
+	if info.StartedAt == nil || info.CompletedAt == nil {
+		return false
+	}
+
+	rec := autosnapshots.RunRecord{
+		At:       *info.StartedAt,
+		Duration: info.CompletedAt.Sub(*info.StartedAt),
+		Bytes:    info.Progress.BytesTransferred,
+	}
+	if info.SizeInfo != nil {
+		rec.EstimatedBytes = info.SizeInfo.CalculatedTransferSize
+	}
+
+	monitor.RecentRuns = autosnapshots.AppendRunRecord(monitor.RecentRuns, rec)
+	monitor.NextRunEstimate = autosnapshots.EstimateNextRun(monitor.RecentRuns)
+	return true
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// ImportHistoryParams asks for existing replication to be imported
+type ImportHistoryParams struct {
+	DryRun bool `json:"dry_run,omitempty"` // Report what would be imported without recording it
+}
+
+// ImportResult is the replication found for a policy, and the transfer
+// recorded for it
+type ImportResult struct {
+	PolicyID         string        `json:"policy_id"`
+	PolicyName       string        `json:"policy_name"`
+	SourceDataset    string        `json:"source_dataset,omitempty"`
+	TargetDataset    string        `json:"target_dataset,omitempty"`
+	CommonSnapshot   string        `json:"common_snapshot,omitempty"`
+	CommonSnapshotAt *time.Time    `json:"common_snapshot_at,omitempty"`
+	ReplicationLag   time.Duration `json:"replication_lag,omitempty"`
+	TransferID       string        `json:"transfer_id,omitempty"` // Empty on a dry run
+	DryRun           bool          `json:"dry_run,omitempty"`
+	Error            string        `json:"error,omitempty"` // Set when importing every policy
+}
+
+// ImportHistory looks for replication of a policy's dataset to its target
+// done before Rodent managed it, such as by cron scripts, and records the
+// newest snapshot common to source and target as the policy's last
+// completed transfer. The policy's first run then sends incrementally from
+// that snapshot and its history, lag and status start from it rather than
+// from nothing. Policies that have run already are refused.
+func (m *Manager) ImportHistory(
+	ctx context.Context,
+	policyID string,
+	params ImportHistoryParams,
+) (*ImportResult, error) {
+	policy, err := m.GetPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.LastTransferID != "" {
+		return nil, errors.New(errors.TransferPolicyInvalidState,
+			fmt.Sprintf("policy %s already has transfer history", policy.Name)).
+			WithMetadata("last_transfer_id", policy.LastTransferID)
+	}
+
+	snapshotPolicy, err := m.snapshotManager.GetPolicy(policy.SnapshotPolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{
+		PolicyID:      policy.ID,
+		PolicyName:    policy.Name,
+		SourceDataset: snapshotPolicy.Dataset,
+		DryRun:        params.DryRun,
+	}
+
+	transferCfg := policy.TransferConfig
+	if transferCfg.ReceiveConfig.Target, err = m.resolveTarget(policy, snapshotPolicy.Dataset); err != nil {
+		return nil, err
+	}
+	result.TargetDataset = transferCfg.ReceiveConfig.Target
+
+	commonSnapshot, err := m.findMostRecentCommonSnapshot(
+		ctx,
+		snapshotPolicy.Dataset,
+		transferCfg.ReceiveConfig.Target,
+		transferCfg.ReceiveConfig,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if commonSnapshot == "" {
+		return nil, errors.New(errors.TransferPolicyInvalidState,
+			"target holds no snapshot of the source; the first run sends in full").
+			WithMetadata("target", transferCfg.ReceiveConfig.Target)
+	}
+
+	createdAt, err := m.snapshotCreationTime(ctx, commonSnapshot)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSSnapshotList)
+	}
+	result.CommonSnapshot = commonSnapshot
+	result.CommonSnapshotAt = &createdAt
+	result.ReplicationLag = time.Since(createdAt)
+	if params.DryRun {
+		return result, nil
+	}
+
+	transferCfg.SendConfig.Snapshot = commonSnapshot
+	transferID, err := m.transferManager.CreateImportedTransfer(transferCfg, policy.ID, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	result.TransferID = transferID
+
+	if err := m.recordImport(result); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Imported existing replication into transfer policy",
+		"policy_id", policy.ID,
+		"common_snapshot", commonSnapshot,
+		"target_dataset", result.TargetDataset,
+		"transfer_id", transferID)
+	return result, nil
+}
+
+// ImportAllHistory imports existing replication for every policy that has
+// not run yet. Policies whose import fails are listed with the error.
+func (m *Manager) ImportAllHistory(ctx context.Context, params ImportHistoryParams) ([]ImportResult, error) {
+	policies, err := m.ListPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ImportResult, 0, len(policies))
+	for _, policy := range policies {
+		if policy.LastTransferID != "" {
+			continue
+		}
+		result, err := m.ImportHistory(ctx, policy.ID, params)
+		if err != nil {
+			result = &ImportResult{
+				PolicyID:   policy.ID,
+				PolicyName: policy.Name,
+				DryRun:     params.DryRun,
+				Error:      err.Error(),
+			}
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// recordImport sets an imported transfer as the policy's last run and
+// saves the config
+func (m *Manager) recordImport(result *ImportResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.config.Policies {
+		policy := &m.config.Policies[i]
+		if policy.ID != result.PolicyID {
+			continue
+		}
+		policy.LastRunAt = result.CommonSnapshotAt
+		policy.LastRunStatus = "success"
+		policy.LastRunError = ""
+		policy.LastTransferID = result.TransferID
+
+		if monitor, exists := m.config.Monitors[policy.ID]; exists {
+			checkedAt := time.Now()
+			monitor.LastRunAt = result.CommonSnapshotAt
+			monitor.CurrentTransferID = result.TransferID
+			monitor.LastCommonSnapshot = result.CommonSnapshot
+			monitor.ReplicationLag = result.ReplicationLag
+			monitor.LagCheckedAt = &checkedAt
+		}
+		return m.saveConfigWithTimeout()
+	}
+	return errors.New(errors.TransferPolicyNotFound,
+		fmt.Sprintf("policy %s not found", result.PolicyID))
+}
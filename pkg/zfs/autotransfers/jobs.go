@@ -0,0 +1,73 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+)
+
+// JobTypeRetention applies a transfer policy's retention rules after one of
+// its transfers has started
+const JobTypeRetention = "transfer-policy-retention"
+
+// retentionJobPayload identifies the policy whose retention is applied
+type retentionJobPayload struct {
+	PolicyID string `json:"policy_id"`
+}
+
+// UseJobQueue runs retention through the job queue, so transfers that fail
+// to be deleted are retried and the runs can be inspected. Without a queue
+// retention runs in a plain goroutine.
+func (m *Manager) UseJobQueue(q *jobs.Queue) {
+	q.Register(JobTypeRetention, m.runRetentionJob, jobs.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Minute,
+		Timeout:        5 * time.Minute,
+	})
+	m.jobQueue.Store(q)
+}
+
+// scheduleRetention applies the policy's retention rules in the background
+func (m *Manager) scheduleRetention(policy *TransferPolicy) {
+	retention := policy.RetentionPolicy
+	if !retention.hasRecordRules() && !retention.hasTargetRules() {
+		return
+	}
+
+	q := m.jobQueue.Load()
+	if q == nil {
+		go m.applyRetentionPolicy(context.Background(), policy)
+		return
+	}
+
+	if _, err := q.Enqueue(JobTypeRetention, retentionJobPayload{PolicyID: policy.ID}); err != nil {
+		m.logger.Warn("Failed to enqueue retention job, applying retention directly",
+			"policy_id", policy.ID,
+			"error", err)
+		go m.applyRetentionPolicy(context.Background(), policy)
+	}
+}
+
+// runRetentionJob applies the retention rules of the policy in the payload.
+// A policy removed since the job was queued has nothing left to clean up.
+func (m *Manager) runRetentionJob(ctx context.Context, payload json.RawMessage) error {
+	var p retentionJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+
+	policy, err := m.GetPolicy(p.PolicyID)
+	if err != nil {
+		m.logger.Debug("Skipping retention for removed policy", "policy_id", p.PolicyID)
+		return nil
+	}
+
+	return m.applyRetentionPolicy(ctx, policy)
+}
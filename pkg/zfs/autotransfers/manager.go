@@ -15,6 +15,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
@@ -25,8 +26,14 @@ import (
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/lockwatch"
+	"github.com/stratastor/rodent/internal/schedwatch"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/parsers"
 	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/calendars"
+	"github.com/stratastor/rodent/pkg/zfs/command"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
 )
 
@@ -37,9 +44,15 @@ type Manager struct {
 	config          TransferPolicyConfig
 	snapshotManager *autosnapshots.Manager
 	transferManager *dataset.TransferManager
-	scheduler       gocron.Scheduler
+	executor        *command.CommandExecutor
+	scheduler       *schedwatch.Scheduler
 	jobMapping      map[string][]uuid.UUID // policyID -> []jobIDs
-	mu              sync.RWMutex
+	followRuns      map[string]*time.Timer // policyID -> pending follow-mode run
+	retryRuns       map[string]*time.Timer // policyID -> pending retry of a failed run
+	rpoStop         chan struct{}          // closes to stop the RPO monitor loop
+	jobQueue        atomic.Pointer[jobs.Queue]
+	calendarManager atomic.Pointer[calendars.Manager]
+	mu              lockwatch.RWMutex
 	started         bool
 }
 
@@ -81,7 +94,7 @@ func GetManager(
 	configPath := filepath.Join(transferPoliciesDir, "zfs.transfer-policies.rodent.yml")
 
 	// Create scheduler
-	sched, err := gocron.NewScheduler()
+	sched, err := schedwatch.New("transfer-policies", l)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.TransferPolicySchedulerError)
 	}
@@ -91,19 +104,33 @@ func GetManager(
 		configPath:      configPath,
 		snapshotManager: snapshotMgr,
 		transferManager: transferMgr,
+		executor:        command.NewCommandExecutor(true, logCfg),
 		scheduler:       sched,
 		jobMapping:      make(map[string][]uuid.UUID),
+		followRuns:      make(map[string]*time.Timer),
+		retryRuns:       make(map[string]*time.Timer),
 		config: TransferPolicyConfig{
 			Policies: []TransferPolicy{},
 			Monitors: make(map[string]*TransferPolicyMonitor),
 		},
 	}
+	lockwatch.Watch("transfer-policy-manager", &m.mu)
 
 	// Load existing policies
 	if err := m.LoadConfig(); err != nil {
 		l.Warn("Failed to load transfer policies config, starting with empty config", "error", err)
 	}
 
+	// Policies in follow mode run after each snapshot of their snapshot policy
+	if snapshotMgr != nil {
+		snapshotMgr.OnSnapshotCreated(m.handleSnapshotCreated)
+	}
+
+	// Completed transfers feed the run history used to predict the next run
+	if transferMgr != nil {
+		transferMgr.OnTransferFinished(m.handleTransferFinished)
+	}
+
 	globalManager = m
 	return m, nil
 }
@@ -138,6 +165,11 @@ func (m *Manager) Start() error {
 
 	// Start scheduler
 	m.scheduler.Start()
+
+	// Start RPO monitor
+	m.rpoStop = make(chan struct{})
+	go m.runRPOMonitor(m.rpoStop)
+
 	m.started = true
 	m.logger.Info("Transfer policy manager started")
 	return nil
@@ -152,6 +184,18 @@ func (m *Manager) Stop() error {
 		return errors.New(errors.TransferPolicyInvalidState, "transfer policy manager not started")
 	}
 
+	if m.rpoStop != nil {
+		close(m.rpoStop)
+		m.rpoStop = nil
+	}
+
+	for policyID := range m.followRuns {
+		m.cancelFollowRun(policyID)
+	}
+	for policyID := range m.retryRuns {
+		m.cancelRetry(policyID)
+	}
+
 	// Stop scheduler (gracefully waits for running jobs)
 	if err := m.scheduler.Shutdown(); err != nil {
 		return errors.Wrap(err, errors.TransferPolicySchedulerError)
@@ -167,6 +211,9 @@ func (m *Manager) AddPolicy(ctx context.Context, params EditTransferPolicyParams
 	if err := ValidateEditTransferPolicyParams(&params); err != nil {
 		return "", err
 	}
+	if err := m.validateCalendarRefs(params.Schedules); err != nil {
+		return "", err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -189,14 +236,21 @@ func (m *Manager) AddPolicy(ctx context.Context, params EditTransferPolicyParams
 		TransferConfig:   params.TransferConfig,
 		Schedules:        params.Schedules,
 		RetentionPolicy:  params.RetentionPolicy,
+		RPOTarget:        params.RPOTarget,
 		Enabled:          params.Enabled,
 		CreatedAt:        now,
 		UpdatedAt:        now,
+
+		FollowSnapshotPolicy: params.FollowSnapshotPolicy,
+		FollowDelay:          params.FollowDelay,
 	}
 
 	if err := ValidateTransferPolicy(&policy); err != nil {
 		return "", err
 	}
+	if err := m.validateTarget(&policy); err != nil {
+		return "", err
+	}
 
 	// Associate with snapshot policy FIRST, before modifying our config
 	// This ensures the snapshot policy accepts the association before we commit
@@ -246,6 +300,9 @@ func (m *Manager) UpdatePolicy(ctx context.Context, params EditTransferPolicyPar
 	if err := ValidateEditTransferPolicyParams(&params); err != nil {
 		return err
 	}
+	if err := m.validateCalendarRefs(params.Schedules); err != nil {
+		return err
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -292,6 +349,11 @@ func (m *Manager) UpdatePolicy(ctx context.Context, params EditTransferPolicyPar
 		params.TransferConfig.ReceiveConfig.RemoteConfig.PrivateKey =
 			oldPolicy.TransferConfig.ReceiveConfig.RemoteConfig.PrivateKey
 	}
+	if jump, oldJump := params.TransferConfig.ReceiveConfig.RemoteConfig.Jump,
+		oldPolicy.TransferConfig.ReceiveConfig.RemoteConfig.Jump; jump != nil && oldJump != nil &&
+		jump.PrivateKey == "" && jump.Host == oldJump.Host {
+		jump.PrivateKey = oldJump.PrivateKey
+	}
 
 	// Update policy fields (preserve CreatedAt and runtime fields)
 	m.config.Policies[policyIdx] = TransferPolicy{
@@ -302,6 +364,7 @@ func (m *Manager) UpdatePolicy(ctx context.Context, params EditTransferPolicyPar
 		TransferConfig:   params.TransferConfig,
 		Schedules:        params.Schedules,
 		RetentionPolicy:  params.RetentionPolicy,
+		RPOTarget:        params.RPOTarget,
 		Enabled:          params.Enabled,
 		CreatedAt:        oldPolicy.CreatedAt,
 		UpdatedAt:        time.Now(),
@@ -309,6 +372,10 @@ func (m *Manager) UpdatePolicy(ctx context.Context, params EditTransferPolicyPar
 		LastRunStatus:    oldPolicy.LastRunStatus,
 		LastRunError:     oldPolicy.LastRunError,
 		LastTransferID:   oldPolicy.LastTransferID,
+		Seed:             oldPolicy.Seed,
+
+		FollowSnapshotPolicy: params.FollowSnapshotPolicy,
+		FollowDelay:          params.FollowDelay,
 	}
 
 	// Validate updated policy
@@ -317,6 +384,10 @@ func (m *Manager) UpdatePolicy(ctx context.Context, params EditTransferPolicyPar
 		m.config.Policies[policyIdx] = oldPolicy
 		return err
 	}
+	if err := m.validateTarget(&m.config.Policies[policyIdx]); err != nil {
+		m.config.Policies[policyIdx] = oldPolicy
+		return err
+	}
 
 	// Create new jobs if enabled and scheduler is running
 	if m.config.Policies[policyIdx].Enabled && m.started {
@@ -423,17 +494,20 @@ func (m *Manager) GetPolicy(policyID string) (*TransferPolicy, error) {
 	)
 }
 
-// ListPolicies returns all transfer policies with enriched monitor status
+// ListPolicies returns all transfer policies with enriched monitor status.
+// The lock is only held to copy the policies and monitors.
 func (m *Manager) ListPolicies() ([]TransferPolicy, error) {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
+	policies := append(make([]TransferPolicy, 0, len(m.config.Policies)), m.config.Policies...)
+	monitors := make(map[string]TransferPolicyMonitor, len(m.config.Monitors))
+	for id, monitor := range m.config.Monitors {
+		monitors[id] = *monitor
+	}
+	m.mu.RUnlock()
 
-	policies := make([]TransferPolicy, len(m.config.Policies))
-	for i, policy := range m.config.Policies {
-		policies[i] = policy
-		if monitor, exists := m.config.Monitors[policy.ID]; exists {
-			monitorCopy := *monitor
-			policies[i].MonitorStatus = &monitorCopy
+	for i, policy := range policies {
+		if monitor, exists := monitors[policy.ID]; exists {
+			policies[i].MonitorStatus = &monitor
 		}
 	}
 
@@ -597,7 +671,7 @@ func (m *Manager) RunPolicy(
 		monitor.LastError = ""
 		monitor.CurrentTransferID = result.TransferID
 		monitor.LastSkipped = true
-		monitor.LastSkipReason = fmt.Sprintf("target already has snapshot: %s", result.SourceSnapshot)
+		monitor.LastSkipReason = result.SkipReason
 		monitor.SkipCount++
 	} else {
 		monitor.Status = string(TransferPolicyStatusIdle)
@@ -605,6 +679,7 @@ func (m *Manager) RunPolicy(
 		monitor.CurrentTransferID = result.TransferID
 		monitor.LastSkipped = false
 		monitor.LastSkipReason = ""
+		result.EstimatedDuration = monitor.NextRunEstimate.DurationFor(result.EstimatedBytes)
 	}
 
 	// Update policy fields
@@ -674,86 +749,18 @@ func (m *Manager) createJob(
 	schedule autosnapshots.ScheduleSpec,
 ) (gocron.Job, error) {
 	// Define the task function that will execute the transfer
-	taskFn := func(ctx context.Context) (any, error) {
-		start := time.Now()
-
-		// Get or initialize monitor
-		m.mu.Lock()
-		monitor, exists := m.config.Monitors[policy.ID]
-		if !exists {
-			monitor = &TransferPolicyMonitor{
-				PolicyID:      policy.ID,
-				ScheduleIndex: scheduleIdx,
-			}
-			m.config.Monitors[policy.ID] = monitor
-		}
-		monitor.Status = string(TransferPolicyStatusRunning)
-		m.mu.Unlock()
-
-		// Execute transfer
-		result, err := m.executeTransferForPolicy(ctx, policy, "")
-
-		// Update monitor
-		m.mu.Lock()
-		duration := time.Since(start)
-		monitor.LastRunAt = &start
-		monitor.RunCount++
-		monitor.LastDuration = duration
-
-		if err != nil {
-			monitor.Status = string(TransferPolicyStatusError)
-			monitor.LastError = err.Error()
-			monitor.LastSkipped = false
-			monitor.LastSkipReason = ""
-			m.logger.Error("Transfer policy execution failed",
-				"policy_id", policy.ID,
-				"error", err)
-		} else if result.Status == dataset.TransferStatusSkipped {
-			// Track skipped transfer
-			monitor.Status = string(TransferPolicyStatusIdle)
-			monitor.LastError = ""
-			monitor.CurrentTransferID = result.TransferID
-			monitor.LastSkipped = true
-			monitor.LastSkipReason = fmt.Sprintf("target already has snapshot: %s", result.SourceSnapshot)
-			monitor.SkipCount++
-		} else {
-			monitor.Status = string(TransferPolicyStatusIdle)
-			monitor.LastError = ""
-			monitor.CurrentTransferID = result.TransferID
-			monitor.LastSkipped = false
-			monitor.LastSkipReason = ""
+	var taskFn func(ctx context.Context) (any, error)
+	taskFn = func(ctx context.Context) (any, error) {
+		// Runs due on a calendar's blocked day are skipped or shifted
+		// A shifted run keeps the job's context, so it is cancelled with the
+		// job on shutdown or when the policy changes
+		if !m.gateRun(policy.ID, scheduleIdx, schedule, func() {
+			_, _ = taskFn(ctx)
+		}) {
+			return nil, nil
 		}
 
-		// Update policy fields
-		for i := range m.config.Policies {
-			if m.config.Policies[i].ID == policy.ID {
-				m.config.Policies[i].LastRunAt = monitor.LastRunAt
-				if err != nil {
-					m.config.Policies[i].LastRunStatus = "error"
-					m.config.Policies[i].LastRunError = err.Error()
-				} else if result.Status == dataset.TransferStatusSkipped {
-					m.config.Policies[i].LastRunStatus = "skipped"
-					m.config.Policies[i].LastRunError = ""
-					m.config.Policies[i].LastTransferID = result.TransferID
-				} else {
-					m.config.Policies[i].LastRunStatus = "success"
-					m.config.Policies[i].LastRunError = ""
-					m.config.Policies[i].LastTransferID = result.TransferID
-				}
-				break
-			}
-		}
-
-		// Save config asynchronously
-		go func() {
-			if saveErr := m.SaveConfig(false); saveErr != nil {
-				m.logger.Warn("Failed to save config after policy execution", "error", saveErr)
-			}
-		}()
-
-		m.mu.Unlock()
-
-		return result, err
+		return m.runPolicyJob(ctx, policy, scheduleIdx)
 	}
 
 	// Build job definition based on schedule type
@@ -804,7 +811,7 @@ func (m *Manager) createJob(
 	// Create the job with task and options
 	job, err := m.scheduler.NewJob(
 		jobDef,
-		gocron.NewTask(taskFn, context.Background()),
+		gocron.NewTask(taskFn), // gocron passes the job's context, cancelled on shutdown
 		gocron.WithSingletonMode(gocron.LimitModeWait), // Wait if previous execution still running
 		gocron.WithEventListeners(
 			gocron.BeforeJobRuns(func(jobID uuid.UUID, jobName string) {
@@ -833,8 +840,108 @@ func (m *Manager) createJob(
 	return job, nil
 }
 
+// runPolicyJob executes a scheduled or follow-mode transfer for a policy and
+// records the outcome on its monitor
+func (m *Manager) runPolicyJob(
+	ctx context.Context,
+	policy *TransferPolicy,
+	scheduleIdx int,
+) (*CreateTransferResult, error) {
+	start := time.Now()
+
+	// Get or initialize monitor
+	m.mu.Lock()
+	monitor, exists := m.config.Monitors[policy.ID]
+	if !exists {
+		monitor = &TransferPolicyMonitor{
+			PolicyID:      policy.ID,
+			ScheduleIndex: scheduleIdx,
+		}
+		m.config.Monitors[policy.ID] = monitor
+	}
+	monitor.Status = string(TransferPolicyStatusRunning)
+	m.mu.Unlock()
+
+	// Execute transfer; a panic fails this run of the policy only
+	var result *CreateTransferResult
+	err := m.scheduler.Run(policy.ID, func() (err error) {
+		result, err = m.executeTransferForPolicy(ctx, policy, "")
+		return err
+	})
+
+	// Update monitor
+	m.mu.Lock()
+	duration := time.Since(start)
+	monitor.LastRunAt = &start
+	monitor.RunCount++
+	monitor.LastDuration = duration
+
+	if err != nil {
+		monitor.Status = string(TransferPolicyStatusError)
+		monitor.LastError = err.Error()
+		monitor.LastSkipped = false
+		monitor.LastSkipReason = ""
+		m.logger.Error("Transfer policy execution failed",
+			"policy_id", policy.ID,
+			"error", err)
+		m.scheduleRetry(monitor, errors.CategoryOf(err))
+	} else if result.Status == dataset.TransferStatusSkipped {
+		// Track skipped transfer
+		m.resetRetries(monitor)
+		monitor.Status = string(TransferPolicyStatusIdle)
+		monitor.LastError = ""
+		monitor.CurrentTransferID = result.TransferID
+		monitor.LastSkipped = true
+		monitor.LastSkipReason = result.SkipReason
+		monitor.SkipCount++
+	} else {
+		monitor.Status = string(TransferPolicyStatusIdle)
+		monitor.LastError = ""
+		monitor.CurrentTransferID = result.TransferID
+		monitor.LastSkipped = false
+		monitor.LastSkipReason = ""
+		result.EstimatedDuration = monitor.NextRunEstimate.DurationFor(result.EstimatedBytes)
+	}
+
+	// Update policy fields
+	for i := range m.config.Policies {
+		if m.config.Policies[i].ID == policy.ID {
+			m.config.Policies[i].LastRunAt = monitor.LastRunAt
+			if err != nil {
+				m.config.Policies[i].LastRunStatus = "error"
+				m.config.Policies[i].LastRunError = err.Error()
+			} else if result.Status == dataset.TransferStatusSkipped {
+				m.config.Policies[i].LastRunStatus = "skipped"
+				m.config.Policies[i].LastRunError = ""
+				m.config.Policies[i].LastTransferID = result.TransferID
+			} else {
+				m.config.Policies[i].LastRunStatus = "success"
+				m.config.Policies[i].LastRunError = ""
+				m.config.Policies[i].LastTransferID = result.TransferID
+			}
+			break
+		}
+	}
+
+	// Save config asynchronously
+	go func() {
+		if saveErr := m.SaveConfig(false); saveErr != nil {
+			m.logger.Warn("Failed to save config after policy execution", "error", saveErr)
+		}
+	}()
+
+	m.mu.Unlock()
+
+	return result, err
+}
+
 // removeJobsForPolicy removes all scheduler jobs for a policy
 func (m *Manager) removeJobsForPolicy(policyID string) {
+	m.calendarManager.Load().CancelShifted(calendarKeyPrefix(policyID))
+	m.scheduler.DropHeld(calendarKeyPrefix(policyID))
+	m.cancelFollowRun(policyID)
+	m.cancelRetry(policyID)
+
 	jobIDs, exists := m.jobMapping[policyID]
 	if !exists {
 		return
@@ -896,7 +1003,7 @@ func (m *Manager) executeTransferForPolicy(
 		sourceSnapshot = snapshotOverride
 	} else {
 		// Get latest snapshot from associated snapshot policy
-		snapshot, err := m.getLatestSnapshotFromPolicy(policy.SnapshotPolicyID)
+		snapshot, err := m.getLatestSnapshotFromPolicy(ctx, policy.SnapshotPolicyID)
 		if err != nil {
 			return nil, err
 		}
@@ -915,10 +1022,41 @@ func (m *Manager) executeTransferForPolicy(
 	transferCfg := policy.TransferConfig
 	transferCfg.SendConfig.Snapshot = sourceSnapshot
 
+	// Expand a templated target for this source, and create the datasets it
+	// is received under
+	if IsTargetTemplate(transferCfg.ReceiveConfig.Target) {
+		target, err := m.resolveTarget(policy, sourceDataset)
+		if err != nil {
+			return nil, err
+		}
+		m.mu.RLock()
+		err = m.checkTargetCollision(policy, target)
+		m.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		transferCfg.ReceiveConfig.Target = target
+		if !transferCfg.ReceiveConfig.DryRun {
+			if err := m.ensureTargetParent(ctx, transferCfg.ReceiveConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// Find the most recent common snapshot between source and target for incremental transfer
 	// This uses ZFS GUIDs to reliably identify common snapshots
 	targetDataset := transferCfg.ReceiveConfig.Target
-	commonSnapshot, err := m.findMostRecentCommonSnapshot(sourceDataset, targetDataset, transferCfg.ReceiveConfig)
+	commonSnapshot, err := m.findMostRecentCommonSnapshot(ctx, sourceDataset, targetDataset, transferCfg.ReceiveConfig)
+	if m.seedPending(policy, commonSnapshot, err) {
+		// The initial send is on its way on removable media; sending it over
+		// the network is what seeding avoids
+		skipReason := fmt.Sprintf("waiting for seed %s to be imported on the target", policy.Seed.Snapshot)
+		m.logger.Info("Target not seeded yet, skipping transfer",
+			"policy_id", policy.ID,
+			"seed_snapshot", policy.Seed.Snapshot,
+			"target_dataset", targetDataset)
+		return m.skipTransfer(policy, transferCfg, sourceSnapshot, skipReason)
+	}
 	if err != nil {
 		// If we can't find common snapshot, log warning and attempt full send
 		m.logger.Warn("Failed to find common snapshot, will attempt full send",
@@ -934,22 +1072,7 @@ func (m *Manager) executeTransferForPolicy(
 				"source_dataset", sourceDataset,
 				"target_dataset", targetDataset)
 
-			// Create a skipped transfer record
-			transferID, err := m.transferManager.CreateSkippedTransfer(transferCfg, policy.ID, skipReason)
-			if err != nil {
-				return nil, errors.Wrap(err, errors.ZFSDatasetSend)
-			}
-
-			result := &CreateTransferResult{
-				PolicyID:       policy.ID,
-				TransferID:     transferID,
-				SourceSnapshot: sourceSnapshot,
-				TargetDataset:  transferCfg.ReceiveConfig.Target,
-				CreatedAt:      time.Now(),
-				Status:         dataset.TransferStatusSkipped,
-			}
-
-			return result, nil
+			return m.skipTransfer(policy, transferCfg, sourceSnapshot, skipReason)
 		}
 
 		// Use the full snapshot path for incremental transfer
@@ -965,7 +1088,7 @@ func (m *Manager) executeTransferForPolicy(
 		// Get the oldest snapshot to use as FromSnapshot, transfer_manager will:
 		// 1. Send the oldest snapshot as a full send (via performInitialSend)
 		// 2. Then send incremental with -I from oldest to latest
-		oldestSnapshot, err := m.getOldestSnapshotFromPolicy(policy.SnapshotPolicyID)
+		oldestSnapshot, err := m.getOldestSnapshotFromPolicy(ctx, policy.SnapshotPolicyID)
 		if err != nil {
 			m.logger.Warn("Failed to get oldest snapshot for intermediary transfer, will send only latest",
 				"error", err,
@@ -1000,32 +1123,62 @@ func (m *Manager) executeTransferForPolicy(
 		CreatedAt:      time.Now(),
 		Status:         dataset.TransferStatusStarting,
 	}
+	if info, err := m.transferManager.GetTransfer(transferID); err == nil && info.SizeInfo != nil {
+		result.EstimatedBytes = info.SizeInfo.CalculatedTransferSize
+	}
 
 	m.logger.Info("Transfer initiated by policy",
 		"policy_id", policy.ID,
 		"transfer_id", transferID,
 		"snapshot", sourceSnapshot)
 
-	// Apply retention policy asynchronously
-	go m.applyRetentionPolicy(policy)
+	// Apply retention policy in the background
+	m.scheduleRetention(policy)
 
 	return result, nil
 }
 
+// skipTransfer records a run of a policy that transferred nothing
+func (m *Manager) skipTransfer(
+	policy *TransferPolicy,
+	transferCfg dataset.TransferConfig,
+	sourceSnapshot string,
+	skipReason string,
+) (*CreateTransferResult, error) {
+	transferID, err := m.transferManager.CreateSkippedTransfer(transferCfg, policy.ID, skipReason)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSDatasetSend)
+	}
+
+	return &CreateTransferResult{
+		PolicyID:       policy.ID,
+		TransferID:     transferID,
+		SourceSnapshot: sourceSnapshot,
+		TargetDataset:  transferCfg.ReceiveConfig.Target,
+		CreatedAt:      time.Now(),
+		Status:         dataset.TransferStatusSkipped,
+		SkipReason:     skipReason,
+	}, nil
+}
+
 // getOldestSnapshotFromPolicy retrieves the oldest snapshot from the associated snapshot policy
 // This is used for initial transfers with intermediary snapshots enabled
-func (m *Manager) getOldestSnapshotFromPolicy(snapshotPolicyID string) (string, error) {
-	return m.getSnapshotFromPolicy(snapshotPolicyID, true)
+func (m *Manager) getOldestSnapshotFromPolicy(ctx context.Context, snapshotPolicyID string) (string, error) {
+	return m.getSnapshotFromPolicy(ctx, snapshotPolicyID, true)
 }
 
 // getLatestSnapshotFromPolicy retrieves the latest snapshot from the associated snapshot policy
-func (m *Manager) getLatestSnapshotFromPolicy(snapshotPolicyID string) (string, error) {
-	return m.getSnapshotFromPolicy(snapshotPolicyID, false)
+func (m *Manager) getLatestSnapshotFromPolicy(ctx context.Context, snapshotPolicyID string) (string, error) {
+	return m.getSnapshotFromPolicy(ctx, snapshotPolicyID, false)
 }
 
 // getSnapshotFromPolicy retrieves a snapshot from the associated snapshot policy
 // If oldest is true, returns the oldest matching snapshot; otherwise returns the latest
-func (m *Manager) getSnapshotFromPolicy(snapshotPolicyID string, oldest bool) (string, error) {
+func (m *Manager) getSnapshotFromPolicy(
+	ctx context.Context,
+	snapshotPolicyID string,
+	oldest bool,
+) (string, error) {
 	// Get the snapshot policy
 	snapPolicy, err := m.snapshotManager.GetPolicy(snapshotPolicyID)
 	if err != nil {
@@ -1038,33 +1191,11 @@ func (m *Manager) getSnapshotFromPolicy(snapshotPolicyID string, oldest bool) (s
 	if oldest {
 		sortFlag = "-s"
 	}
-	cmd := exec.Command(
-		"sudo",
-		"zfs",
-		"list",
-		"-o",
-		"name",
-		"-H",
-		"-t",
-		"snap",
-		sortFlag,
-		"creation",
-		snapPolicy.Dataset,
-	)
-	output, err := cmd.Output()
+	output, err := m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+		"zfs list", "-o", "name", "-t", "snap", sortFlag, "creation", snapPolicy.Dataset)
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			return "", errors.New(
-				errors.ZFSSnapshotList,
-				fmt.Sprintf(
-					"failed to list snapshots for dataset %s: %s",
-					snapPolicy.Dataset,
-					string(exitErr.Stderr),
-				),
-			)
-		}
-		return "", errors.New(errors.ZFSSnapshotList,
-			fmt.Sprintf("failed to list snapshots for dataset %s: %v", snapPolicy.Dataset, err))
+		return "", errors.Wrap(err, errors.ZFSSnapshotList).
+			WithMetadata("dataset", snapPolicy.Dataset)
 	}
 
 	// Parse output and filter by snapshot name pattern
@@ -1149,7 +1280,8 @@ func (m *Manager) buildSnapshotPatternRegex(pattern string) (*regexp.Regexp, err
 	// The snapshot manager appends: -{schedule_index}-{policy_id_suffix}
 	// Schedule index is a digit (0-4, max 5 schedules), policy ID suffix is last part of UUID
 	// Example: autosnap-policy-%Y-%m-%d-%H%M%S becomes autosnap-policy-2025-11-25-081138-0-d1f36875b92f
-	regexPattern = regexPattern + `-\d+-[a-f0-9]+`
+	// Triggered runs may add a suffix before them, e.g. ...-081138-build-42-0-d1f36875b92f
+	regexPattern = regexPattern + `(-[A-Za-z0-9][A-Za-z0-9_.:-]*)?-\d+-[a-f0-9]+`
 
 	// Anchor the pattern to match the full snapshot name
 	regexPattern = "^" + regexPattern + "$"
@@ -1161,6 +1293,7 @@ func (m *Manager) buildSnapshotPatternRegex(pattern string) (*regexp.Regexp, err
 // using ZFS GUIDs for reliable matching. Returns the common snapshot name on the source dataset,
 // or an empty string if no common snapshot is found or target doesn't exist.
 func (m *Manager) findMostRecentCommonSnapshot(
+	ctx context.Context,
 	sourceDataset, targetDataset string,
 	recvCfg dataset.ReceiveConfig,
 ) (string, error) {
@@ -1178,17 +1311,18 @@ func (m *Manager) findMostRecentCommonSnapshot(
 	}
 
 	// Check if target dataset exists
-	var checkCmd *exec.Cmd
+	var checkErr error
 	if isRemote {
 		cmdStr := fmt.Sprintf("%s sudo zfs list -H -o name %s",
 			shellquote.Join(sshPrefix...), shellquote.Join(targetDataset))
-		checkCmd = exec.Command("bash", "-c", cmdStr)
 		m.logger.Debug("Checking remote target dataset existence", "command", cmdStr)
+		checkErr = exec.CommandContext(ctx, "bash", "-c", cmdStr).Run()
 	} else {
-		checkCmd = exec.Command("sudo", "zfs", "list", "-H", "-o", "name", targetDataset)
+		_, checkErr = m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+			"zfs list", "-o", "name", targetDataset)
 	}
 
-	if err := checkCmd.Run(); err != nil {
+	if checkErr != nil {
 		// Target doesn't exist - this will be a full send
 		m.logger.Debug("Target dataset does not exist, will perform full send",
 			"target", targetDataset,
@@ -1197,46 +1331,24 @@ func (m *Manager) findMostRecentCommonSnapshot(
 	}
 
 	// List source snapshots with GUIDs (sorted by creation, newest first)
-	sourceCmd := exec.Command(
-		"sudo",
-		"zfs",
-		"list",
-		"-H",
-		"-o",
-		"name,guid",
-		"-t",
-		"snap",
-		"-S",
-		"creation",
-		sourceDataset,
-	)
-	sourceOutput, err := sourceCmd.Output()
+	sourceOutput, err := m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+		"zfs list", "-o", "name,guid", "-t", "snap", "-S", "creation", sourceDataset)
 	if err != nil {
 		return "", errors.New(errors.ZFSSnapshotList,
 			fmt.Sprintf("failed to list source snapshots for %s: %v", sourceDataset, err))
 	}
 
 	// List target snapshots with GUIDs
-	var targetCmd *exec.Cmd
+	var targetOutput []byte
 	if isRemote {
 		cmdStr := fmt.Sprintf("%s sudo zfs list -H -o name,guid -t snap %s",
 			shellquote.Join(sshPrefix...), shellquote.Join(targetDataset))
-		targetCmd = exec.Command("bash", "-c", cmdStr)
 		m.logger.Debug("Listing remote target snapshots", "command", cmdStr)
+		targetOutput, err = exec.CommandContext(ctx, "bash", "-c", cmdStr).Output()
 	} else {
-		targetCmd = exec.Command(
-			"sudo",
-			"zfs",
-			"list",
-			"-H",
-			"-o",
-			"name,guid",
-			"-t",
-			"snap",
-			targetDataset,
-		)
+		targetOutput, err = m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+			"zfs list", "-o", "name,guid", "-t", "snap", targetDataset)
 	}
-	targetOutput, err := targetCmd.Output()
 	if err != nil {
 		return "", errors.New(errors.ZFSSnapshotList,
 			fmt.Sprintf("failed to list target snapshots for %s: %v", targetDataset, err))
@@ -1340,14 +1452,31 @@ func (m *Manager) deletePolicyTransfers(policyID string) error {
 	return nil
 }
 
-// applyRetentionPolicy applies retention rules to clean up old transfers
-func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
+// applyRetentionPolicy applies the retention rules of a policy to its
+// transfer records and to the snapshots on its target
+func (m *Manager) applyRetentionPolicy(ctx context.Context, policy *TransferPolicy) error {
+	err := m.pruneTransferRecords(ctx, policy)
+	if policy.RetentionPolicy.hasTargetRules() {
+		if targetErr := m.pruneTargetSnapshots(ctx, policy); targetErr != nil {
+			m.logger.Warn("Failed to prune target snapshots",
+				"policy_id", policy.ID,
+				"error", targetErr)
+			err = targetErr
+		}
+	}
+	return err
+}
+
+// pruneTransferRecords applies retention rules to clean up old transfers. It
+// returns the last error from deleting a transfer, if any, and stops between
+// transfers once ctx is cancelled.
+func (m *Manager) pruneTransferRecords(ctx context.Context, policy *TransferPolicy) error {
 	retention := policy.RetentionPolicy
 
 	// Skip if no retention policy is configured
-	if retention.KeepCount == 0 && retention.OlderThan == 0 {
+	if !retention.hasRecordRules() {
 		m.logger.Debug("No retention policy configured", "policy_id", policy.ID)
-		return
+		return nil
 	}
 
 	// List all transfers and filter by policy ID
@@ -1362,7 +1491,7 @@ func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
 
 	if len(policyTransfers) == 0 {
 		m.logger.Debug("No transfers found for policy", "policy_id", policy.ID)
-		return
+		return nil
 	}
 
 	// Sort transfers by creation time (most recent first)
@@ -1370,10 +1499,24 @@ func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
 		return policyTransfers[i].CreatedAt.After(policyTransfers[j].CreatedAt)
 	})
 
+	// Records of transfers that sent pinned snapshots are kept
+	pinned, err := m.pinnedSnapshots(ctx, policyTransfers)
+	if err != nil {
+		return errors.Wrap(err, errors.ZFSSnapshotList).
+			WithMetadata("policy_id", policy.ID)
+	}
+
 	deletedCount := 0
+	var deleteErr error
 	now := time.Now()
 
 	for idx, transfer := range policyTransfers {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, errors.CommandContext).
+				WithMetadata("policy_id", policy.ID).
+				WithMetadata("deleted_count", fmt.Sprintf("%d", deletedCount))
+		}
+
 		// Check if transfer is in the keep list
 		if slices.Contains(retention.KeepTransferIDs, transfer.ID) {
 			m.logger.Debug("Keeping transfer (in keep list)",
@@ -1381,6 +1524,13 @@ func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
 			continue
 		}
 
+		if pinned[transfer.Config.SendConfig.Snapshot] {
+			m.logger.Debug("Keeping transfer (snapshot pinned)",
+				"transfer_id", transfer.ID,
+				"snapshot", transfer.Config.SendConfig.Snapshot)
+			continue
+		}
+
 		// Don't delete running or paused transfers
 		if transfer.Status == dataset.TransferStatusRunning ||
 			transfer.Status == dataset.TransferStatusPaused ||
@@ -1428,6 +1578,7 @@ func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
 				m.logger.Warn("Failed to delete transfer during retention",
 					"transfer_id", transfer.ID,
 					"error", err)
+				deleteErr = err
 			} else {
 				deletedCount++
 				m.logger.Debug("Deleted transfer by retention policy",
@@ -1443,6 +1594,44 @@ func (m *Manager) applyRetentionPolicy(policy *TransferPolicy) {
 			"deleted_count", deletedCount,
 			"total_transfers", len(policyTransfers))
 	}
+
+	return deleteErr
+}
+
+// pinnedSnapshots returns the pinned snapshots among those the transfers
+// sent. Pulled snapshots live on the remote source, so only pushed ones are
+// looked up.
+func (m *Manager) pinnedSnapshots(ctx context.Context, transfers []*dataset.TransferInfo) (map[string]bool, error) {
+	pinned := make(map[string]bool)
+	listed := make(map[string]bool)
+	for _, transfer := range transfers {
+		if transfer.Config.IsPull() {
+			continue
+		}
+		ds, _, ok := strings.Cut(transfer.Config.SendConfig.Snapshot, "@")
+		if !ok || listed[ds] {
+			continue
+		}
+		listed[ds] = true
+
+		output, err := m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+			"zfs list", "-t", "snap", "-d", "1", "-o", "name,"+dataset.TagPinned, ds)
+		if err != nil {
+			// A destroyed dataset has no snapshots left to pin
+			if errors.CategoryOf(err) == errors.CategoryNotFound {
+				continue
+			}
+			return nil, fmt.Errorf("failed to list snapshots of %s: %w", ds, err)
+		}
+
+		for _, row := range parsers.ParseZFSList(string(output), "name", dataset.TagPinned) {
+			name, ok := row.Get("name")
+			if _, set := row.Get(dataset.TagPinned); ok && set {
+				pinned[name] = true
+			}
+		}
+	}
+	return pinned, nil
 }
 
 // LoadConfig loads the transfer policy configuration from disk
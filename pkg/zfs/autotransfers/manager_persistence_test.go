@@ -71,7 +71,7 @@ func TestTransferStatusPersistence_Integration(t *testing.T) {
 
 	// Cleanup snapshot policy after test
 	defer func() {
-		_ = snapshotMgr.RemovePolicy(snapPolicyID, true)
+		_ = snapshotMgr.RemovePolicy(context.Background(), snapPolicyID, true)
 	}()
 
 	// Create a transfer policy with a NEW target filesystem
@@ -6,14 +6,17 @@ package autotransfers
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"os/exec"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
 	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 	"github.com/stratastor/rodent/pkg/zfs/dataset"
@@ -210,6 +213,87 @@ func TestValidateTransferPolicy(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative RPO target",
+			policy: &TransferPolicy{
+				Name:             "test-policy",
+				SnapshotPolicyID: "snap-policy-id",
+				TransferConfig: dataset.TransferConfig{
+					ReceiveConfig: dataset.ReceiveConfig{
+						Target: "tank/backup",
+					},
+				},
+				Schedules: []autosnapshots.ScheduleSpec{
+					{
+						Type:     autosnapshots.ScheduleTypeDaily,
+						Interval: 1,
+						AtTime:   "02:00",
+						Enabled:  true,
+					},
+				},
+				RPOTarget: -time.Hour,
+			},
+			wantErr: true,
+		},
+		{
+			name: "follow mode without schedules",
+			policy: &TransferPolicy{
+				Name:             "test-policy",
+				SnapshotPolicyID: "snap-policy-id",
+				TransferConfig: dataset.TransferConfig{
+					ReceiveConfig: dataset.ReceiveConfig{
+						Target: "tank/backup",
+					},
+				},
+				FollowSnapshotPolicy: true,
+				FollowDelay:          5 * time.Minute,
+			},
+			wantErr: false,
+		},
+		{
+			name: "follow mode with schedules",
+			policy: &TransferPolicy{
+				Name:             "test-policy",
+				SnapshotPolicyID: "snap-policy-id",
+				TransferConfig: dataset.TransferConfig{
+					ReceiveConfig: dataset.ReceiveConfig{
+						Target: "tank/backup",
+					},
+				},
+				Schedules: []autosnapshots.ScheduleSpec{
+					{
+						Type:     autosnapshots.ScheduleTypeDaily,
+						Interval: 1,
+						AtTime:   "02:00",
+						Enabled:  true,
+					},
+				},
+				FollowSnapshotPolicy: true,
+			},
+			wantErr: true,
+		},
+		{
+			name: "follow delay without follow mode",
+			policy: &TransferPolicy{
+				Name:             "test-policy",
+				SnapshotPolicyID: "snap-policy-id",
+				TransferConfig: dataset.TransferConfig{
+					ReceiveConfig: dataset.ReceiveConfig{
+						Target: "tank/backup",
+					},
+				},
+				Schedules: []autosnapshots.ScheduleSpec{
+					{
+						Type:     autosnapshots.ScheduleTypeDaily,
+						Interval: 1,
+						AtTime:   "02:00",
+						Enabled:  true,
+					},
+				},
+				FollowDelay: time.Minute,
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -286,7 +370,315 @@ func TestValidateEditTransferPolicyParams(t *testing.T) {
 	}
 }
 
+// TestFollowRunScheduling tests that snapshots of a snapshot policy schedule
+// one pending run of each enabled policy following it
+func TestFollowRunScheduling(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-follow")
+	require.NoError(t, err)
+
+	m := &Manager{
+		logger:     l,
+		jobMapping: make(map[string][]uuid.UUID),
+		followRuns: make(map[string]*time.Timer),
+		config: TransferPolicyConfig{
+			Policies: []TransferPolicy{
+				{ID: "follower", SnapshotPolicyID: "snap", Enabled: true,
+					FollowSnapshotPolicy: true, FollowDelay: time.Hour},
+				{ID: "disabled", SnapshotPolicyID: "snap",
+					FollowSnapshotPolicy: true, FollowDelay: time.Hour},
+				{ID: "scheduled", SnapshotPolicyID: "snap", Enabled: true},
+				{ID: "other", SnapshotPolicyID: "other-snap", Enabled: true,
+					FollowSnapshotPolicy: true, FollowDelay: time.Hour},
+			},
+			Monitors: map[string]*TransferPolicyMonitor{
+				"follower": {PolicyID: "follower"},
+			},
+		},
+	}
+
+	// Nothing is scheduled before the manager starts
+	m.handleSnapshotCreated(autosnapshots.CreateSnapshotResult{PolicyID: "snap", SnapshotName: "s1"})
+	assert.Empty(t, m.followRuns)
+
+	m.started = true
+	m.handleSnapshotCreated(autosnapshots.CreateSnapshotResult{PolicyID: "snap", SnapshotName: "s1"})
+	m.handleSnapshotCreated(autosnapshots.CreateSnapshotResult{PolicyID: "snap", SnapshotName: "s2"})
+
+	m.mu.Lock()
+	assert.Len(t, m.followRuns, 1)
+	assert.Contains(t, m.followRuns, "follower")
+	require.NotNil(t, m.config.Monitors["follower"].NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), *m.config.Monitors["follower"].NextRunAt, time.Minute)
+
+	m.removeJobsForPolicy("follower")
+	assert.Empty(t, m.followRuns)
+	m.mu.Unlock()
+}
+
+// TestScheduleRetry tests that failed runs are retried with backoff only
+// when the failure is of a kind retrying may fix
+func TestScheduleRetry(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-retry")
+	require.NoError(t, err)
+
+	m := &Manager{
+		logger:    l,
+		retryRuns: make(map[string]*time.Timer),
+		started:   true,
+	}
+	monitor := &TransferPolicyMonitor{PolicyID: "p"}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	assert.False(t, m.scheduleRetry(monitor, errors.CategoryPermission))
+	assert.False(t, m.scheduleRetry(monitor, ""))
+	assert.Empty(t, m.retryRuns)
+
+	assert.True(t, m.scheduleRetry(monitor, errors.CategoryNetwork))
+	assert.Equal(t, 1, monitor.RetryCount)
+	assert.Equal(t, errors.CategoryNetwork, monitor.LastErrorCategory)
+	require.NotNil(t, monitor.NextRunAt)
+	assert.WithinDuration(t, time.Now().Add(policyRetryBaseDelay), *monitor.NextRunAt, time.Second)
+
+	// A pending retry covers further failures
+	assert.True(t, m.scheduleRetry(monitor, errors.CategoryBusy))
+	assert.Equal(t, 1, monitor.RetryCount)
+
+	for i := 2; i <= maxPolicyRetries; i++ {
+		m.cancelRetry("p")
+		assert.True(t, m.scheduleRetry(monitor, errors.CategoryTimeout))
+		assert.Equal(t, i, monitor.RetryCount)
+	}
+	assert.Equal(t, 4*time.Minute, retryDelay(maxPolicyRetries))
+
+	m.cancelRetry("p")
+	assert.False(t, m.scheduleRetry(monitor, errors.CategoryNetwork), "retries are used up")
+
+	m.resetRetries(monitor)
+	assert.Zero(t, monitor.RetryCount)
+	assert.Empty(t, monitor.LastErrorCategory)
+	assert.Empty(t, m.retryRuns)
+}
+
+func TestRecordTransferRun(t *testing.T) {
+	monitor := &TransferPolicyMonitor{PolicyID: "p"}
+	started := time.Now().Add(-time.Minute)
+	completed := started.Add(40 * time.Second)
+
+	assert.False(t, recordTransferRun(monitor, dataset.TransferInfo{PolicyID: "p"}))
+	assert.Empty(t, monitor.RecentRuns)
+
+	ok := recordTransferRun(monitor, dataset.TransferInfo{
+		PolicyID:    "p",
+		Status:      dataset.TransferStatusCompleted,
+		StartedAt:   &started,
+		CompletedAt: &completed,
+		Progress:    dataset.TransferProgress{BytesTransferred: 4000},
+		SizeInfo:    &dataset.TransferSizeInfo{CalculatedTransferSize: 3900},
+	})
+	require.True(t, ok)
+	require.Len(t, monitor.RecentRuns, 1)
+	assert.Equal(t, 40*time.Second, monitor.RecentRuns[0].Duration)
+	assert.Equal(t, int64(4000), monitor.RecentRuns[0].Bytes)
+	assert.Equal(t, int64(3900), monitor.RecentRuns[0].EstimatedBytes)
+
+	require.NotNil(t, monitor.NextRunEstimate)
+	assert.Equal(t, int64(100), monitor.NextRunEstimate.Throughput)
+	assert.Equal(t, 80*time.Second, monitor.NextRunEstimate.DurationFor(8000))
+}
+
+func TestExpandTarget(t *testing.T) {
+	saved := targetHostname
+	targetHostname = func() (string, error) { return "nas1.example.com", nil }
+	t.Cleanup(func() { targetHostname = saved })
+
+	for _, tc := range []struct{ target, source, want string }{
+		{"backup/{source_pool}/{source_dataset}/{hostname}", "tank/projects/eng", "backup/tank/projects/eng/nas1"},
+		{"backup/{hostname}/{source_name}", "tank/projects/eng", "backup/nas1/eng"},
+		{"backup/{source_pool}/{source_dataset}", "tank", "backup/tank"},
+		{"backup/replica", "tank/projects", "backup/replica"},
+	} {
+		got, err := ExpandTarget(tc.target, tc.source)
+		require.NoError(t, err, tc.target)
+		assert.Equal(t, tc.want, got, tc.target)
+	}
+
+	_, err := ExpandTarget("{source_dataset}", "tank/eng")
+	assert.Error(t, err, "a target must name a dataset below a pool")
+
+	assert.NoError(t, ValidateTargetTemplate("backup/{hostname}/{source_dataset}"))
+	assert.Error(t, ValidateTargetTemplate("backup/{host}"))
+	assert.Error(t, ValidateTargetTemplate("backup/{hostname"))
+}
+
+func TestCheckTargetCollision(t *testing.T) {
+	m := &Manager{config: TransferPolicyConfig{Policies: []TransferPolicy{
+		{
+			ID:   "existing",
+			Name: "existing",
+			TransferConfig: dataset.TransferConfig{
+				ReceiveConfig: dataset.ReceiveConfig{Target: "backup/tank/eng"},
+			},
+		},
+	}}}
+
+	policy := &TransferPolicy{
+		ID: "new",
+		TransferConfig: dataset.TransferConfig{
+			ReceiveConfig: dataset.ReceiveConfig{Target: "backup/{source_pool}/{source_dataset}"},
+		},
+	}
+	assert.Error(t, m.checkTargetCollision(policy, "backup/tank/eng"))
+	assert.NoError(t, m.checkTargetCollision(policy, "backup/tank/ops"))
+
+	policy.TransferConfig.ReceiveConfig.RemoteConfig.Host = "backup-host"
+	assert.NoError(t, m.checkTargetCollision(policy, "backup/tank/eng"), "targets on other hosts do not collide")
+}
+
+func TestSeedPending(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-seed")
+	require.NoError(t, err)
+	m := &Manager{
+		logger:     l,
+		configPath: t.TempDir() + "/transfer-policies.yml",
+		config: TransferPolicyConfig{Policies: []TransferPolicy{
+			{ID: "seeded", Seed: &PolicySeed{Snapshot: "tank/eng@initial", Path: "/mnt/seed"}},
+			{ID: "plain"},
+		}},
+	}
+	seeded := &m.config.Policies[0]
+
+	assert.False(t, m.seedPending(&m.config.Policies[1], "", nil))
+	assert.True(t, m.seedPending(seeded, "", nil), "no common snapshot yet")
+	assert.True(t, m.seedPending(seeded, "", os.ErrNotExist), "target not received yet")
+	require.NotNil(t, seeded.Seed)
+
+	assert.False(t, m.seedPending(seeded, "tank/eng@initial", nil))
+	assert.Nil(t, seeded.Seed, "seed is cleared once it has landed")
+	data, err := os.ReadFile(m.configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "/mnt/seed")
+}
+
+func TestRecordImport(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-import")
+	require.NoError(t, err)
+	m := &Manager{
+		logger:     l,
+		configPath: t.TempDir() + "/transfer-policies.yml",
+		config: TransferPolicyConfig{
+			Policies: []TransferPolicy{{ID: "cron"}},
+			Monitors: map[string]*TransferPolicyMonitor{"cron": {PolicyID: "cron"}},
+		},
+	}
+
+	at := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, m.recordImport(&ImportResult{
+		PolicyID:         "cron",
+		CommonSnapshot:   "tank/eng@cron-nightly",
+		CommonSnapshotAt: &at,
+		ReplicationLag:   2 * time.Hour,
+		TransferID:       "imported",
+	}))
+
+	policy := m.config.Policies[0]
+	assert.Equal(t, "imported", policy.LastTransferID)
+	assert.Equal(t, "success", policy.LastRunStatus)
+	assert.Equal(t, at, *policy.LastRunAt)
+	monitor := m.config.Monitors["cron"]
+	assert.Equal(t, "tank/eng@cron-nightly", monitor.LastCommonSnapshot)
+	assert.Equal(t, 2*time.Hour, monitor.ReplicationLag)
+	assert.Empty(t, monitor.RecentRuns, "imports are not runs to estimate from")
+
+	assert.Error(t, m.recordImport(&ImportResult{PolicyID: "missing"}))
+}
+
+// TestWritePrometheus tests that RPO metrics are exported for measured
+// policies only
+func TestWritePrometheus(t *testing.T) {
+	checked := time.Now()
+	m := &Manager{
+		config: TransferPolicyConfig{
+			Policies: []TransferPolicy{
+				{ID: "measured", Name: "nightly", RPOTarget: time.Hour},
+				{ID: "unmeasured", Name: "weekly", RPOTarget: time.Hour},
+				{ID: "untargeted", Name: "adhoc"},
+			},
+			Monitors: map[string]*TransferPolicyMonitor{
+				"measured": {PolicyID: "measured", ReplicationLag: 2 * time.Hour,
+					LagCheckedAt: &checked, RPOBreached: true},
+				"unmeasured": {PolicyID: "unmeasured"},
+				"untargeted": {PolicyID: "untargeted", LagCheckedAt: &checked},
+			},
+		},
+	}
+
+	var b strings.Builder
+	require.NoError(t, m.WritePrometheus(&b))
+	out := b.String()
+
+	assert.Contains(t, out, `rodent_transfer_replication_lag_seconds{policy_id="measured",policy_name="nightly"} 7200`)
+	assert.Contains(t, out, `rodent_transfer_rpo_target_seconds{policy_id="measured",policy_name="nightly"} 3600`)
+	assert.Contains(t, out, `rodent_transfer_rpo_breached{policy_id="measured",policy_name="nightly"} 1`)
+	assert.NotContains(t, out, "unmeasured")
+	assert.NotContains(t, out, "untargeted")
+}
+
 // TestNewTransferPolicy tests policy creation from params
+func TestBuildSnapshotPatternRegex(t *testing.T) {
+	m := &Manager{}
+	re, err := m.buildSnapshotPatternRegex("autosnap-%Y-%m-%d-%H%M%S")
+	require.NoError(t, err)
+
+	assert.True(t, re.MatchString("autosnap-2025-11-25-081138-0-d1f36875b92f"))
+	// Triggered runs add a suffix before the schedule index
+	assert.True(t, re.MatchString("autosnap-2025-11-25-081138-build-42-0-d1f36875b92f"))
+	assert.False(t, re.MatchString("manual-2025-11-25-081138-0-d1f36875b92f"))
+	assert.False(t, re.MatchString("autosnap-2025-11-25-081138"))
+}
+
+func TestPlanTargetPrune(t *testing.T) {
+	m := &Manager{}
+	pattern, err := m.buildSnapshotPatternRegex("autosnap-%Y-%m-%d-%H%M%S")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 11, 30, 12, 0, 0, 0, time.UTC)
+	snap := func(day int, guid string) targetSnapshot {
+		return targetSnapshot{
+			Name:      fmt.Sprintf("backup/data@autosnap-2025-11-%02d-000000-0-d1f36875b92f", day),
+			GUID:      guid,
+			CreatedAt: time.Date(2025, 11, day, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	// Newest first; the source has pruned everything but days 29 and 28
+	snaps := []targetSnapshot{
+		snap(30, "g30"), // Received from a source that has since diverged
+		snap(29, "g29"),
+		snap(28, "g28"),
+		{Name: "backup/data@manual", GUID: "gm", CreatedAt: time.Date(2025, 11, 27, 0, 0, 0, 0, time.UTC)},
+		snap(26, "g26"),
+		snap(25, "g25"),
+		snap(24, "g24"),
+	}
+	snaps[5].Pinned = true
+	source := map[string]bool{"g29": true, "g28": true}
+
+	prune, common := planTargetPrune(snaps, source, pattern, TargetSnapshotRetention{KeepCount: 3}, now)
+	assert.Equal(t, snaps[1].Name, common)
+	// Manual and pinned snapshots are never pruned
+	assert.Equal(t, []string{snaps[4].Name, snaps[6].Name}, prune)
+
+	// Age alone never prunes the newest common snapshot
+	prune, _ = planTargetPrune(snaps, source, pattern, TargetSnapshotRetention{OlderThan: time.Hour}, now)
+	assert.Equal(t, []string{snaps[2].Name, snaps[4].Name, snaps[6].Name}, prune)
+
+	// Without a common snapshot nothing is pruned
+	prune, common = planTargetPrune(snaps, map[string]bool{"gx": true}, pattern, TargetSnapshotRetention{KeepCount: 1}, now)
+	assert.Empty(t, prune)
+	assert.Empty(t, common)
+}
+
 func TestNewTransferPolicy(t *testing.T) {
 	params := EditTransferPolicyParams{
 		ID:               "test-id",
@@ -427,7 +819,7 @@ func TestPolicyCRUD_Integration(t *testing.T) {
 
 	// Cleanup snapshot policy after test (with snapshots)
 	defer func() {
-		_ = snapshotMgr.RemovePolicy(snapPolicyID, true)
+		_ = snapshotMgr.RemovePolicy(context.Background(), snapPolicyID, true)
 	}()
 
 	// Variable to track transfer policy ID for cleanup
@@ -618,7 +1010,7 @@ func TestPolicyExecution_Integration(t *testing.T) {
 	t.Logf("Created snapshot policy: %s", snapPolicyID)
 
 	defer func() {
-		_ = snapshotMgr.RemovePolicy(snapPolicyID, true)
+		_ = snapshotMgr.RemovePolicy(context.Background(), snapPolicyID, true)
 	}()
 
 	// Create a transfer policy
@@ -706,7 +1098,7 @@ func TestSnapshotPolicyProtection_Integration(t *testing.T) {
 	t.Logf("Created snapshot policy: %s", snapPolicyID)
 
 	defer func() {
-		_ = snapshotMgr.RemovePolicy(snapPolicyID, true)
+		_ = snapshotMgr.RemovePolicy(context.Background(), snapPolicyID, true)
 	}()
 
 	// Create a transfer policy that references the snapshot policy
@@ -788,7 +1180,7 @@ func TestScheduledExecution_Integration(t *testing.T) {
 	t.Logf("Created snapshot policy: %s (runs every 1 minute)", snapPolicyID)
 
 	defer func() {
-		_ = snapshotMgr.RemovePolicy(snapPolicyID, true) // Remove snapshots on cleanup
+		_ = snapshotMgr.RemovePolicy(context.Background(), snapPolicyID, true) // Remove snapshots on cleanup
 	}()
 
 	// Create a transfer policy that runs every 2 minutes with incremental support
@@ -0,0 +1,116 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Policy runs that fail in a way retrying may fix, such as a dropped
+// connection or a busy dataset, are run again after a backoff instead of
+// waiting for the next schedule
+const (
+	maxPolicyRetries     = 3
+	policyRetryBaseDelay = time.Minute // Doubles with every retry
+)
+
+// retryDelay returns the backoff before the given retry, counting from 1
+func retryDelay(retry int) time.Duration {
+	return policyRetryBaseDelay << (retry - 1)
+}
+
+// scheduleRetry runs a policy again after a failure of the given category,
+// unless the failure is permanent, its retries are used up or a retry is
+// already pending. It reports whether a retry was scheduled (must be called
+// with lock held).
+func (m *Manager) scheduleRetry(monitor *TransferPolicyMonitor, category errors.Category) bool {
+	monitor.LastErrorCategory = category
+	if !category.Retryable() || !m.started {
+		return false
+	}
+	if _, pending := m.retryRuns[monitor.PolicyID]; pending {
+		return true
+	}
+	if monitor.RetryCount >= maxPolicyRetries {
+		m.logger.Warn("Transfer policy retries exhausted, waiting for next schedule",
+			"policy_id", monitor.PolicyID,
+			"retries", monitor.RetryCount,
+			"category", category)
+		return false
+	}
+
+	monitor.RetryCount++
+	delay := retryDelay(monitor.RetryCount)
+	runAt := time.Now().Add(delay)
+	monitor.NextRunAt = &runAt
+
+	policyID := monitor.PolicyID
+	m.retryRuns[policyID] = time.AfterFunc(delay, func() {
+		m.runRetry(policyID)
+	})
+
+	m.logger.Info("Scheduled transfer policy retry",
+		"policy_id", policyID,
+		"retry", monitor.RetryCount,
+		"category", category,
+		"run_at", runAt)
+	return true
+}
+
+// runRetry executes a pending retry, unless the policy was disabled or
+// removed since it was scheduled
+func (m *Manager) runRetry(policyID string) {
+	m.mu.Lock()
+	delete(m.retryRuns, policyID)
+
+	var policy *TransferPolicy
+	for i := range m.config.Policies {
+		p := m.config.Policies[i]
+		if p.ID == policyID && p.Enabled {
+			policy = &p
+			break
+		}
+	}
+	if monitor, exists := m.config.Monitors[policyID]; exists {
+		monitor.NextRunAt = nil
+	}
+	started := m.started
+	m.mu.Unlock()
+
+	if policy == nil || !started {
+		return
+	}
+
+	// Held while schedulers are paused for maintenance, and run on resume
+	if held, _ := m.scheduler.Hold(calendarKeyPrefix(policyID)+"retry", func() {
+		m.runRetry(policyID)
+	}); held {
+		return
+	}
+
+	if _, err := m.runPolicyJob(context.Background(), policy, 0); err != nil {
+		m.logger.Warn("Transfer policy retry failed", "policy_id", policyID, "error", err)
+	}
+}
+
+// cancelRetry drops a pending retry of a policy (must be called with lock
+// held)
+func (m *Manager) cancelRetry(policyID string) {
+	if timer, pending := m.retryRuns[policyID]; pending {
+		timer.Stop()
+		delete(m.retryRuns, policyID)
+	}
+}
+
+// resetRetries clears the retry state of a policy that ran successfully
+// (must be called with lock held)
+func (m *Manager) resetRetries(monitor *TransferPolicyMonitor) {
+	monitor.RetryCount = 0
+	monitor.LastErrorCategory = ""
+	m.cancelRetry(monitor.PolicyID)
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/internal/metrics"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+// rpoCheckInterval is how often replication lag is evaluated against the
+// RPO target of each enabled policy
+const rpoCheckInterval = 5 * time.Minute
+
+// RPOStatus is the replication lag of a policy measured against its RPO target
+type RPOStatus struct {
+	PolicyID           string        `json:"policy_id"`
+	PolicyName         string        `json:"policy_name"`
+	RPOTarget          time.Duration `json:"rpo_target"`
+	ReplicationLag     time.Duration `json:"replication_lag"`
+	LastCommonSnapshot string        `json:"last_common_snapshot,omitempty"`
+	Breached           bool          `json:"breached"`
+	CheckedAt          time.Time     `json:"checked_at"`
+}
+
+// runRPOMonitor periodically checks replication lag until stop is closed.
+// Lag is tracked independently of individual runs, so a policy whose runs are
+// skipped or blocked still raises an alert once the target falls behind.
+func (m *Manager) runRPOMonitor(stop <-chan struct{}) {
+	ticker := time.NewTicker(rpoCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			m.checkRPO()
+		}
+	}
+}
+
+// checkRPO evaluates every enabled policy that has an RPO target
+func (m *Manager) checkRPO() {
+	m.mu.RLock()
+	policies := make([]TransferPolicy, 0)
+	for _, policy := range m.config.Policies {
+		if policy.Enabled && policy.RPOTarget > 0 {
+			policies = append(policies, policy)
+		}
+	}
+	m.mu.RUnlock()
+
+	for i := range policies {
+		status, err := m.measureReplicationLag(context.Background(), &policies[i])
+		if err != nil {
+			m.logger.Warn("Failed to measure replication lag",
+				"policy_id", policies[i].ID,
+				"error", err)
+			continue
+		}
+		m.recordRPOStatus(status)
+	}
+}
+
+// measureReplicationLag computes the age of the newest snapshot that exists on
+// both source and target. With no common snapshot, lag is counted from the
+// policy's creation.
+func (m *Manager) measureReplicationLag(ctx context.Context, policy *TransferPolicy) (*RPOStatus, error) {
+	snapshotPolicy, err := m.snapshotManager.GetPolicy(policy.SnapshotPolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	status := &RPOStatus{
+		PolicyID:   policy.ID,
+		PolicyName: policy.Name,
+		RPOTarget:  policy.RPOTarget,
+		CheckedAt:  now,
+	}
+
+	recvCfg := policy.TransferConfig.ReceiveConfig
+	if recvCfg.Target, err = m.resolveTarget(policy, snapshotPolicy.Dataset); err != nil {
+		return nil, err
+	}
+	commonSnapshot, err := m.findMostRecentCommonSnapshot(
+		ctx,
+		snapshotPolicy.Dataset,
+		recvCfg.Target,
+		recvCfg,
+	)
+	if err != nil || commonSnapshot == "" {
+		status.ReplicationLag = now.Sub(policy.CreatedAt)
+	} else {
+		createdAt, err := m.snapshotCreationTime(ctx, commonSnapshot)
+		if err != nil {
+			return nil, err
+		}
+		status.LastCommonSnapshot = commonSnapshot
+		status.ReplicationLag = now.Sub(createdAt)
+	}
+
+	status.Breached = status.ReplicationLag > policy.RPOTarget
+	return status, nil
+}
+
+// recordRPOStatus stores the lag in the policy monitor and emits an event when
+// the policy enters or leaves breach of its RPO target
+func (m *Manager) recordRPOStatus(status *RPOStatus) {
+	m.mu.Lock()
+	monitor, exists := m.config.Monitors[status.PolicyID]
+	if !exists {
+		m.mu.Unlock()
+		return
+	}
+
+	wasBreached := monitor.RPOBreached
+	checkedAt := status.CheckedAt
+	monitor.ReplicationLag = status.ReplicationLag
+	monitor.LastCommonSnapshot = status.LastCommonSnapshot
+	monitor.LagCheckedAt = &checkedAt
+	monitor.RPOBreached = status.Breached
+	if status.Breached && !wasBreached {
+		monitor.RPOBreachedAt = &checkedAt
+	} else if !status.Breached {
+		monitor.RPOBreachedAt = nil
+	}
+	m.mu.Unlock()
+
+	switch {
+	case status.Breached && !wasBreached:
+		m.logger.Warn("Replication lag exceeds RPO target",
+			"policy_id", status.PolicyID,
+			"lag", status.ReplicationLag,
+			"rpo_target", status.RPOTarget)
+		m.emitRPOEvent(status, eventspb.EventLevel_EVENT_LEVEL_WARN, "rpo_breached")
+	case !status.Breached && wasBreached:
+		m.logger.Info("Replication lag back within RPO target",
+			"policy_id", status.PolicyID,
+			"lag", status.ReplicationLag,
+			"rpo_target", status.RPOTarget)
+		m.emitRPOEvent(status, eventspb.EventLevel_EVENT_LEVEL_INFO, "rpo_recovered")
+	}
+}
+
+// emitRPOEvent publishes an RPO state change on the data transfer event stream
+func (m *Manager) emitRPOEvent(status *RPOStatus, level eventspb.EventLevel, action string) {
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		m.logger.Warn("Failed to marshal RPO status", "error", err)
+		return
+	}
+
+	payload := &eventspb.DataTransferTransferPayload{
+		Operation:        eventspb.DataTransferTransferPayload_DATA_TRANSFER_OPERATION_UNSPECIFIED,
+		TransferInfoJson: string(statusJSON),
+	}
+
+	events.EmitDataTransfer(level, payload, map[string]string{
+		"component":            "zfs-transfer-policy",
+		"action":               action,
+		"policy_id":            status.PolicyID,
+		"lag_seconds":          fmt.Sprintf("%.0f", status.ReplicationLag.Seconds()),
+		"rpo_target_seconds":   fmt.Sprintf("%.0f", status.RPOTarget.Seconds()),
+		"last_common_snapshot": status.LastCommonSnapshot,
+	})
+}
+
+// snapshotCreationTime returns the creation time of a local snapshot
+func (m *Manager) snapshotCreationTime(ctx context.Context, snapshot string) (time.Time, error) {
+	output, err := m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders | command.FlagParsable},
+		"zfs get", "-o", "value", "creation", snapshot)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get creation time of %s: %w", snapshot, err)
+	}
+
+	seconds, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse creation time of %s: %w", snapshot, err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// WritePrometheus writes the replication lag of the policies with an RPO
+// target, as last measured, in the Prometheus text format
+func (m *Manager) WritePrometheus(w io.Writer) error {
+	type sample struct {
+		id, name string
+		monitor  TransferPolicyMonitor
+		target   time.Duration
+	}
+
+	m.mu.RLock()
+	var samples []sample
+	for _, policy := range m.config.Policies {
+		monitor, ok := m.config.Monitors[policy.ID]
+		if !ok || policy.RPOTarget == 0 || monitor.LagCheckedAt == nil {
+			continue
+		}
+		samples = append(samples, sample{policy.ID, policy.Name, *monitor, policy.RPOTarget})
+	}
+	m.mu.RUnlock()
+
+	var b strings.Builder
+	metrics.WriteHeader(&b, "rodent_transfer_replication_lag_seconds", "gauge",
+		"Age of the newest snapshot common to source and target of a transfer policy")
+	for _, s := range samples {
+		metrics.WriteSample(&b, "rodent_transfer_replication_lag_seconds", s.monitor.ReplicationLag.Seconds(),
+			"policy_id", s.id, "policy_name", s.name)
+	}
+	metrics.WriteHeader(&b, "rodent_transfer_rpo_target_seconds", "gauge",
+		"Replication lag a transfer policy is allowed")
+	for _, s := range samples {
+		metrics.WriteSample(&b, "rodent_transfer_rpo_target_seconds", s.target.Seconds(),
+			"policy_id", s.id, "policy_name", s.name)
+	}
+	metrics.WriteHeader(&b, "rodent_transfer_rpo_breached", "gauge",
+		"Whether the replication lag of a transfer policy exceeds its RPO target")
+	for _, s := range samples {
+		breached := 0.0
+		if s.monitor.RPOBreached {
+			breached = 1
+		}
+		metrics.WriteSample(&b, "rodent_transfer_rpo_breached", breached,
+			"policy_id", s.id, "policy_name", s.name)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// PolicySeed records that a policy's initial full send travels on removable
+// media instead of the network. While it is set, runs are skipped until the
+// target holds a snapshot in common with the source, after which the policy
+// continues with incremental transfers.
+type PolicySeed struct {
+	Snapshot  string    `json:"snapshot"          yaml:"snapshot"`
+	Path      string    `json:"path"              yaml:"path"`
+	JobID     string    `json:"job_id,omitempty"  yaml:"job_id,omitempty"` // Export job
+	StartedAt time.Time `json:"started_at"        yaml:"started_at"`
+}
+
+// SeedPolicyParams asks for a policy's initial send to be exported to
+// removable media
+type SeedPolicyParams struct {
+	Path      string `json:"path"                 binding:"required"`
+	ChunkSize int64  `json:"chunk_size,omitempty"`
+}
+
+// SeedPolicy exports the latest snapshot of a policy's snapshot policy to
+// removable media, with the policy's send options, and holds the policy's
+// transfers until the seed has been imported on the target
+func (m *Manager) SeedPolicy(
+	ctx context.Context,
+	policyID string,
+	params SeedPolicyParams,
+) (*PolicySeed, error) {
+	policy, err := m.GetPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if policy.Seed != nil {
+		return nil, errors.New(errors.TransferPolicyInvalidState,
+			fmt.Sprintf("policy %s is already waiting for seed %s", policy.Name, policy.Seed.Snapshot)).
+			WithMetadata("path", policy.Seed.Path)
+	}
+
+	snapshot, err := m.getLatestSnapshotFromPolicy(ctx, policy.SnapshotPolicyID)
+	if err != nil {
+		return nil, err
+	}
+
+	sendCfg := policy.TransferConfig.SendConfig
+	jobID, err := m.transferManager.StartSeedExport(dataset.SeedExportConfig{
+		Snapshot:    snapshot,
+		Path:        params.Path,
+		ChunkSize:   params.ChunkSize,
+		Replicate:   sendCfg.Replicate,
+		Properties:  sendCfg.Properties,
+		Raw:         sendCfg.Raw,
+		LargeBlocks: sendCfg.LargeBlocks,
+		EmbedData:   sendCfg.EmbedData,
+		Compressed:  sendCfg.Compressed,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seed := &PolicySeed{
+		Snapshot:  snapshot,
+		Path:      params.Path,
+		JobID:     jobID,
+		StartedAt: time.Now(),
+	}
+	if err := m.setPolicySeed(policyID, seed); err != nil {
+		return nil, err
+	}
+
+	m.logger.Info("Seeding transfer policy",
+		"policy_id", policyID,
+		"snapshot", snapshot,
+		"path", params.Path,
+		"job_id", jobID)
+	return seed, nil
+}
+
+// CancelSeed stops holding a policy's transfers for a seed; the next run
+// sends in full over the network unless the target already has a common
+// snapshot. An export still running is not stopped.
+func (m *Manager) CancelSeed(ctx context.Context, policyID string) error {
+	policy, err := m.GetPolicy(policyID)
+	if err != nil {
+		return err
+	}
+	if policy.Seed == nil {
+		return errors.New(errors.TransferPolicyInvalidState,
+			fmt.Sprintf("policy %s is not waiting for a seed", policy.Name))
+	}
+	return m.setPolicySeed(policyID, nil)
+}
+
+// setPolicySeed sets or clears a policy's seed and saves the config
+func (m *Manager) setPolicySeed(policyID string, seed *PolicySeed) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.config.Policies {
+		if m.config.Policies[i].ID == policyID {
+			m.config.Policies[i].Seed = seed
+			return m.saveConfigWithTimeout()
+		}
+	}
+	return errors.New(errors.TransferPolicyNotFound,
+		fmt.Sprintf("policy %s not found", policyID))
+}
+
+// seedPending reports whether a run of a policy waiting for a seed must be
+// skipped. Once the target shares a snapshot with the source the seed has
+// landed; it is cleared and the run goes on incrementally.
+func (m *Manager) seedPending(policy *TransferPolicy, commonSnapshot string, findErr error) bool {
+	if policy.Seed == nil {
+		return false
+	}
+	if findErr != nil || commonSnapshot == "" {
+		return true
+	}
+
+	m.logger.Info("Seed imported on target, continuing with incremental transfers",
+		"policy_id", policy.ID,
+		"seed_snapshot", policy.Seed.Snapshot,
+		"common_snapshot", commonSnapshot)
+	if err := m.setPolicySeed(policy.ID, nil); err != nil {
+		m.logger.Warn("Failed to clear imported seed", "policy_id", policy.ID, "error", err)
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// Placeholders a receive target may hold, expanded on every policy run, as
+// in backup/{hostname}/{source_pool}/{source_dataset}
+const (
+	TargetVarSourcePool    = "source_pool"    // Pool of the source dataset
+	TargetVarSourceDataset = "source_dataset" // Source dataset without its pool
+	TargetVarSourceName    = "source_name"    // Last component of the source dataset
+	TargetVarHostname      = "hostname"       // Short host name of this system
+)
+
+var (
+	targetVars        = []string{TargetVarSourcePool, TargetVarSourceDataset, TargetVarSourceName, TargetVarHostname}
+	targetPlaceholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+	// targetHostname is replaced in tests
+	targetHostname = os.Hostname
+)
+
+// IsTargetTemplate reports whether a receive target holds placeholders
+func IsTargetTemplate(target string) bool {
+	return strings.ContainsAny(target, "{}")
+}
+
+// ValidateTargetTemplate checks the placeholders of a receive target
+func ValidateTargetTemplate(target string) error {
+	if !IsTargetTemplate(target) {
+		return nil
+	}
+
+	for _, match := range targetPlaceholder.FindAllStringSubmatch(target, -1) {
+		if !slices.Contains(targetVars, match[1]) {
+			return errors.New(errors.TransferPolicyInvalidConfig,
+				fmt.Sprintf("unknown receive target placeholder {%s}; use one of {%s}",
+					match[1], strings.Join(targetVars, "}, {"))).
+				WithMetadata("target", target)
+		}
+	}
+	if rest := targetPlaceholder.ReplaceAllString(target, ""); strings.ContainsAny(rest, "{}") {
+		return errors.New(errors.TransferPolicyInvalidConfig, "unbalanced braces in receive target").
+			WithMetadata("target", target)
+	}
+	return nil
+}
+
+// ExpandTarget expands the placeholders of a receive target for a source
+// dataset. Components left empty, such as {source_dataset} for a pool's
+// root dataset, are dropped. Targets without placeholders are returned as
+// they are.
+func ExpandTarget(target, sourceDataset string) (string, error) {
+	if !IsTargetTemplate(target) {
+		return target, nil
+	}
+	if err := ValidateTargetTemplate(target); err != nil {
+		return "", err
+	}
+
+	pool, rest, _ := strings.Cut(sourceDataset, "/")
+	name := sourceDataset[strings.LastIndex(sourceDataset, "/")+1:]
+	hostname, err := targetHostname()
+	if err != nil && strings.Contains(target, "{"+TargetVarHostname+"}") {
+		return "", errors.Wrap(err, errors.TransferPolicyInvalidConfig).
+			WithMetadata("target", target)
+	}
+	hostname, _, _ = strings.Cut(hostname, ".")
+
+	values := map[string]string{
+		TargetVarSourcePool:    pool,
+		TargetVarSourceDataset: rest,
+		TargetVarSourceName:    name,
+		TargetVarHostname:      hostname,
+	}
+	expanded := targetPlaceholder.ReplaceAllStringFunc(target, func(p string) string {
+		return values[p[1:len(p)-1]]
+	})
+
+	var parts []string
+	for part := range strings.SplitSeq(expanded, "/") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) < 2 {
+		return "", errors.New(errors.TransferPolicyInvalidConfig,
+			"receive target expands to a pool; it must name a dataset in one").
+			WithMetadata("target", target).
+			WithMetadata("source", sourceDataset)
+	}
+	return strings.Join(parts, "/"), nil
+}
+
+// resolveTarget returns the receive target of a policy for a source
+// dataset, looking the source up from the snapshot policy when not given
+func (m *Manager) resolveTarget(policy *TransferPolicy, sourceDataset string) (string, error) {
+	target := policy.TransferConfig.ReceiveConfig.Target
+	if !IsTargetTemplate(target) {
+		return target, nil
+	}
+	if sourceDataset == "" {
+		snapshotPolicy, err := m.snapshotManager.GetPolicy(policy.SnapshotPolicyID)
+		if err != nil {
+			return "", errors.New(errors.TransferPolicySnapshotPolicyNotFound,
+				fmt.Sprintf("snapshot policy %s not found", policy.SnapshotPolicyID))
+		}
+		sourceDataset = snapshotPolicy.Dataset
+	}
+	return ExpandTarget(target, sourceDataset)
+}
+
+// checkTargetCollision returns an error when a policy with a templated
+// target would receive into the same dataset, on the same host, as another
+// policy. Literal targets are not checked against each other. The caller
+// must hold mu.
+func (m *Manager) checkTargetCollision(policy *TransferPolicy, target string) error {
+	if !IsTargetTemplate(policy.TransferConfig.ReceiveConfig.Target) {
+		return nil
+	}
+
+	host := policy.TransferConfig.ReceiveConfig.RemoteConfig.Host
+	for i := range m.config.Policies {
+		other := &m.config.Policies[i]
+		if other.ID == policy.ID || other.TransferConfig.ReceiveConfig.RemoteConfig.Host != host {
+			continue
+		}
+		otherTarget, err := m.resolveTarget(other, "")
+		if err != nil || otherTarget != target {
+			continue
+		}
+		return errors.New(errors.TransferPolicyInvalidConfig,
+			fmt.Sprintf("receive target %s is already used by policy %s", target, other.Name)).
+			WithMetadata("target", target).
+			WithMetadata("policy_id", other.ID)
+	}
+	return nil
+}
+
+// validateTarget resolves a policy's target and checks it for collisions.
+// The caller must hold mu.
+func (m *Manager) validateTarget(policy *TransferPolicy) error {
+	if !IsTargetTemplate(policy.TransferConfig.ReceiveConfig.Target) {
+		return nil
+	}
+	target, err := m.resolveTarget(policy, "")
+	if err != nil {
+		return err
+	}
+	return m.checkTargetCollision(policy, target)
+}
+
+// ensureTargetParent creates the datasets a templated target is received
+// under, on the receiving side, since zfs receive only creates the last
+// component. With -d the target itself must exist and is created.
+func (m *Manager) ensureTargetParent(ctx context.Context, recvCfg dataset.ReceiveConfig) error {
+	parent := recvCfg.Target
+	if !recvCfg.UseParent {
+		idx := strings.LastIndex(parent, "/")
+		if idx < 0 {
+			return nil
+		}
+		parent = parent[:idx]
+	}
+	if !strings.Contains(parent, "/") {
+		// A pool is not created
+		return nil
+	}
+
+	if _, err := m.transferManager.TargetZFS(ctx, recvCfg.RemoteConfig, "create", "-p", parent); err != nil {
+		return errors.Wrap(err, errors.ZFSDatasetCreate).
+			WithMetadata("dataset", parent).
+			WithMetadata("remote_host", recvCfg.RemoteConfig.Host)
+	}
+
+	m.logger.Debug("Ensured receive target parent exists",
+		"dataset", parent,
+		"remote_host", recvCfg.RemoteConfig.Host)
+	return nil
+}
+
+// SetPolicyTarget replaces the receive target of a policy, such as when the
+// target dataset was renamed or a templated target is fixed to what it
+// expanded to
+func (m *Manager) SetPolicyTarget(policyID, target string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	policyIdx := slices.IndexFunc(m.config.Policies, func(p TransferPolicy) bool {
+		return p.ID == policyID
+	})
+	if policyIdx == -1 {
+		return errors.New(errors.TransferPolicyNotFound,
+			fmt.Sprintf("policy %s not found", policyID))
+	}
+
+	policy := &m.config.Policies[policyIdx]
+	oldTarget := policy.TransferConfig.ReceiveConfig.Target
+	if err := ValidateTargetTemplate(target); err != nil {
+		return err
+	}
+
+	m.removeJobsForPolicy(policyID)
+	policy.TransferConfig.ReceiveConfig.Target = target
+	policy.UpdatedAt = time.Now()
+
+	if policy.Enabled && m.started {
+		if err := m.createJobsForPolicy(policy); err != nil {
+			m.logger.Error("Failed to create jobs for policy with new target",
+				"policy_id", policyID,
+				"error", err)
+		}
+	}
+
+	if err := m.saveConfigWithTimeout(); err != nil {
+		return err
+	}
+
+	m.logger.Info("Transfer policy target changed",
+		"policy_id", policyID,
+		"old_target", oldTarget,
+		"target", target)
+	return nil
+}
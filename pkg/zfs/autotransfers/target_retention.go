@@ -0,0 +1,185 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package autotransfers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/parsers"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// targetSnapshot is a snapshot on the target of a policy
+type targetSnapshot struct {
+	Name      string
+	GUID      string
+	CreatedAt time.Time
+	Pinned    bool
+}
+
+// planTargetPrune picks the target snapshots, newest first, that the rule
+// destroys. Only snapshots named as the snapshot policy names them are
+// considered. The newest snapshot common to source and target, by GUID, is
+// kept, as are the snapshots after it and pinned ones; without a common
+// snapshot nothing is destroyed. It also returns the common snapshot.
+func planTargetPrune(
+	snaps []targetSnapshot,
+	sourceGUIDs map[string]bool,
+	pattern *regexp.Regexp,
+	rule TargetSnapshotRetention,
+	now time.Time,
+) (prune []string, common string) {
+	commonIdx := -1
+	for i, snap := range snaps {
+		if sourceGUIDs[snap.GUID] {
+			commonIdx = i
+			break
+		}
+	}
+	if commonIdx < 0 {
+		return nil, ""
+	}
+
+	position := 0
+	for i, snap := range snaps {
+		_, snapName, _ := strings.Cut(snap.Name, "@")
+		if !pattern.MatchString(snapName) {
+			continue
+		}
+		position++
+		if i <= commonIdx || snap.Pinned {
+			continue
+		}
+
+		if (rule.KeepCount > 0 && position > rule.KeepCount) ||
+			(rule.OlderThan > 0 && now.Sub(snap.CreatedAt) > rule.OlderThan) {
+			prune = append(prune, snap.Name)
+		}
+	}
+	return prune, snaps[commonIdx].Name
+}
+
+// pruneTargetSnapshots destroys the policy's snapshots on its target that
+// the target snapshot retention no longer keeps
+func (m *Manager) pruneTargetSnapshots(ctx context.Context, policy *TransferPolicy) error {
+	rule := *policy.RetentionPolicy.TargetSnapshots
+
+	snapPolicy, err := m.snapshotManager.GetPolicy(policy.SnapshotPolicyID)
+	if err != nil {
+		return errors.Wrap(err, errors.TransferPolicySnapshotPolicyNotFound)
+	}
+	pattern, err := m.buildSnapshotPatternRegex(snapPolicy.SnapNamePattern)
+	if err != nil {
+		return errors.New(errors.TransferPolicyInvalidConfig,
+			fmt.Sprintf("invalid snapshot pattern: %v", err))
+	}
+
+	recvCfg := policy.TransferConfig.ReceiveConfig
+	target, err := m.resolveTarget(policy, snapPolicy.Dataset)
+	if err != nil {
+		return err
+	}
+
+	sourceOutput, err := m.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+		"zfs list", "-o", "guid", "-t", "snap", "-d", "1", snapPolicy.Dataset)
+	if err != nil {
+		return errors.Wrap(err, errors.ZFSSnapshotList).
+			WithMetadata("dataset", snapPolicy.Dataset)
+	}
+	sourceGUIDs := make(map[string]bool)
+	for guid := range strings.SplitSeq(strings.TrimSpace(string(sourceOutput)), "\n") {
+		if guid != "" {
+			sourceGUIDs[guid] = true
+		}
+	}
+
+	targetOutput, err := m.transferManager.TargetZFS(ctx, recvCfg.RemoteConfig, "list", "-H", "-p",
+		"-o", "name,guid,creation,"+dataset.TagPinned, "-t", "snap", "-d", "1", target)
+	if err != nil {
+		// Nothing was received yet
+		if errors.CategoryOf(err) == errors.CategoryNotFound {
+			return nil
+		}
+		return errors.Wrap(err, errors.ZFSSnapshotList).
+			WithMetadata("dataset", target).
+			WithMetadata("remote_host", recvCfg.RemoteConfig.Host)
+	}
+
+	var snaps []targetSnapshot
+	for _, row := range parsers.ParseZFSList(string(targetOutput),
+		"name", "guid", "creation", dataset.TagPinned) {
+		name, hasName := row.Get("name")
+		guid, hasGUID := row.Get("guid")
+		createdAt, hasCreation := row.Time("creation")
+		// Snapshots that cannot be matched or aged are never pruned
+		if !hasName || !hasGUID || !hasCreation {
+			continue
+		}
+		_, pinned := row.Get(dataset.TagPinned)
+		snaps = append(snaps, targetSnapshot{
+			Name:      name,
+			GUID:      guid,
+			CreatedAt: createdAt,
+			Pinned:    pinned,
+		})
+	}
+	sort.SliceStable(snaps, func(i, j int) bool {
+		return snaps[i].CreatedAt.After(snaps[j].CreatedAt)
+	})
+
+	prune, common := planTargetPrune(snaps, sourceGUIDs, pattern, rule, time.Now())
+	if common == "" {
+		if len(snaps) > 0 {
+			m.logger.Warn("No snapshot common to source and target, not pruning the target",
+				"policy_id", policy.ID,
+				"target", target)
+		}
+		return nil
+	}
+
+	destroyed := 0
+	var destroyErr error
+	for _, name := range prune {
+		if err := ctx.Err(); err != nil {
+			return errors.Wrap(err, errors.CommandContext).
+				WithMetadata("policy_id", policy.ID).
+				WithMetadata("destroyed_count", strconv.Itoa(destroyed))
+		}
+
+		var args []string
+		if policy.TransferConfig.SendConfig.Replicate {
+			// Replicated children carry snapshots of the same name
+			args = append(args, "-r")
+		}
+		if _, err := m.transferManager.TargetZFS(ctx, recvCfg.RemoteConfig, "destroy", append(args, name)...); err != nil {
+			m.logger.Warn("Failed to destroy target snapshot",
+				"snapshot", name,
+				"remote_host", recvCfg.RemoteConfig.Host,
+				"error", err)
+			destroyErr = errors.Wrap(err, errors.ZFSSnapshotDestroy).
+				WithMetadata("snapshot", name)
+			continue
+		}
+		destroyed++
+	}
+
+	if destroyed > 0 {
+		m.logger.Info("Pruned target snapshots",
+			"policy_id", policy.ID,
+			"target", target,
+			"remote_host", recvCfg.RemoteConfig.Host,
+			"destroyed_count", destroyed,
+			"common_snapshot", common)
+	}
+	return destroyErr
+}
@@ -40,10 +40,22 @@ type TransferPolicy struct {
 	// Scheduling - supports multiple schedules per policy
 	Schedules []autosnapshots.ScheduleSpec `json:"schedules" yaml:"schedules"`
 
+	// Follow mode: instead of its own schedules, the policy runs after every
+	// snapshot its snapshot policy creates, FollowDelay later
+	FollowSnapshotPolicy bool          `json:"follow_snapshot_policy,omitempty" yaml:"follow_snapshot_policy,omitempty"`
+	FollowDelay          time.Duration `json:"follow_delay,omitempty"           yaml:"follow_delay,omitempty"`
+
 	// Retention policy for transfer entries (not snapshots)
 	// Controls automatic cleanup of completed/failed transfer records
 	RetentionPolicy TransferRetentionPolicy `json:"retention_policy" yaml:"retention_policy"`
 
+	// Recovery point objective: the maximum acceptable replication lag, measured
+	// as the age of the newest snapshot common to source and target (0 = not tracked)
+	RPOTarget time.Duration `json:"rpo_target,omitempty" yaml:"rpo_target,omitempty"`
+
+	// Seed is set while the initial full send travels on removable media
+	Seed *PolicySeed `json:"seed,omitempty" yaml:"seed,omitempty"`
+
 	// Policy state
 	Enabled        bool       `json:"enabled"                    yaml:"enabled"`
 	LastRunAt      *time.Time `json:"last_run_at,omitempty"      yaml:"last_run_at,omitempty"`
@@ -59,7 +71,24 @@ type TransferPolicy struct {
 	MonitorStatus *TransferPolicyMonitor `json:"monitor_status,omitempty" yaml:"-"`
 }
 
-// TransferRetentionPolicy defines retention rules for transfer records
+// Redacted returns a copy of the policy for API responses, with its transfer
+// configuration redacted
+func (p TransferPolicy) Redacted() TransferPolicy {
+	p.TransferConfig = p.TransferConfig.Redacted()
+	return p
+}
+
+// RedactPolicies returns redacted copies of the policies for API responses
+func RedactPolicies(policies []TransferPolicy) []TransferPolicy {
+	redacted := make([]TransferPolicy, len(policies))
+	for i, p := range policies {
+		redacted[i] = p.Redacted()
+	}
+	return redacted
+}
+
+// TransferRetentionPolicy defines retention rules for transfer records, and
+// optionally for the snapshots received on the target
 type TransferRetentionPolicy struct {
 	// Keep only the N most recent transfers (0 = unlimited)
 	KeepCount int `json:"keep_count" yaml:"keep_count"`
@@ -75,6 +104,31 @@ type TransferRetentionPolicy struct {
 
 	// Specific transfer IDs to never delete
 	KeepTransferIDs []string `json:"keep_transfer_ids,omitempty" yaml:"keep_transfer_ids,omitempty"`
+
+	// Prune the policy's snapshots on the target dataset (nil = never)
+	TargetSnapshots *TargetSnapshotRetention `json:"target_snapshots,omitempty" yaml:"target_snapshots,omitempty"`
+}
+
+// TargetSnapshotRetention defines retention rules for the snapshots a policy
+// received on its target. The newest snapshot common to source and target is
+// always kept, so incremental sends can continue.
+type TargetSnapshotRetention struct {
+	// Keep only the N most recent snapshots on the target (0 = unlimited)
+	KeepCount int `json:"keep_count" yaml:"keep_count"`
+
+	// Destroy target snapshots older than this duration (0 = no age limit)
+	OlderThan time.Duration `json:"older_than" yaml:"older_than"`
+}
+
+// hasRecordRules reports whether transfer records are pruned
+func (r TransferRetentionPolicy) hasRecordRules() bool {
+	return r.KeepCount > 0 || r.OlderThan > 0
+}
+
+// hasTargetRules reports whether target snapshots are pruned
+func (r TransferRetentionPolicy) hasTargetRules() bool {
+	return r.TargetSnapshots != nil &&
+		(r.TargetSnapshots.KeepCount > 0 || r.TargetSnapshots.OlderThan > 0)
 }
 
 // TransferPolicyMonitor tracks runtime execution status of a policy
@@ -97,6 +151,21 @@ type TransferPolicyMonitor struct {
 	LastSkipped    bool   `json:"last_skipped,omitempty"     yaml:"last_skipped,omitempty"`
 	LastSkipReason string `json:"last_skip_reason,omitempty" yaml:"last_skip_reason,omitempty"`
 	SkipCount      int    `json:"skip_count,omitempty"       yaml:"skip_count,omitempty"`
+
+	// Retries of runs that failed in a way retrying may fix
+	RetryCount        int             `json:"retry_count,omitempty"         yaml:"retry_count,omitempty"`
+	LastErrorCategory errors.Category `json:"last_error_category,omitempty" yaml:"last_error_category,omitempty"`
+
+	// RPO tracking (only populated when the policy has an RPO target)
+	ReplicationLag     time.Duration `json:"replication_lag,omitempty"      yaml:"replication_lag,omitempty"`
+	LastCommonSnapshot string        `json:"last_common_snapshot,omitempty" yaml:"last_common_snapshot,omitempty"`
+	LagCheckedAt       *time.Time    `json:"lag_checked_at,omitempty"       yaml:"lag_checked_at,omitempty"`
+	RPOBreached        bool          `json:"rpo_breached,omitempty"         yaml:"rpo_breached,omitempty"`
+	RPOBreachedAt      *time.Time    `json:"rpo_breached_at,omitempty"      yaml:"rpo_breached_at,omitempty"`
+
+	// Size and duration of the latest completed transfers, oldest first
+	RecentRuns      []autosnapshots.RunRecord  `json:"recent_runs,omitempty"       yaml:"recent_runs,omitempty"`
+	NextRunEstimate *autosnapshots.RunEstimate `json:"next_run_estimate,omitempty" yaml:"next_run_estimate,omitempty"` // Average of RecentRuns
 }
 
 // TransferPolicyConfig is the overall configuration structure
@@ -126,7 +195,11 @@ type EditTransferPolicyParams struct {
 	TransferConfig   dataset.TransferConfig       `json:"transfer_config"`
 	Schedules        []autosnapshots.ScheduleSpec `json:"schedules"`
 	RetentionPolicy  TransferRetentionPolicy      `json:"retention_policy"`
+	RPOTarget        time.Duration                `json:"rpo_target,omitempty"`
 	Enabled          bool                         `json:"enabled"`
+
+	FollowSnapshotPolicy bool          `json:"follow_snapshot_policy,omitempty"`
+	FollowDelay          time.Duration `json:"follow_delay,omitempty"`
 }
 
 // RunTransferPolicyParams defines parameters for manually running a transfer policy
@@ -144,6 +217,11 @@ type CreateTransferResult struct {
 	TargetDataset  string                 `json:"target_dataset"`
 	CreatedAt      time.Time              `json:"created_at"`
 	Status         dataset.TransferStatus `json:"status"`
+
+	EstimatedBytes    int64         `json:"estimated_bytes,omitempty"`    // Stream size predicted by `zfs send -nP`
+	EstimatedDuration time.Duration `json:"estimated_duration,omitempty"` // Predicted from the policy's recent transfers
+
+	SkipReason string `json:"skip_reason,omitempty"` // Why a skipped run transferred nothing
 }
 
 // TransferPolicyListResult contains the list of policies with count
@@ -163,7 +241,11 @@ func NewTransferPolicy(params EditTransferPolicyParams) *TransferPolicy {
 		TransferConfig:   params.TransferConfig,
 		Schedules:        params.Schedules,
 		RetentionPolicy:  params.RetentionPolicy,
+		RPOTarget:        params.RPOTarget,
 		Enabled:          params.Enabled,
+
+		FollowSnapshotPolicy: params.FollowSnapshotPolicy,
+		FollowDelay:          params.FollowDelay,
 	}
 }
 
@@ -182,25 +264,12 @@ func ValidateTransferPolicy(policy *TransferPolicy) error {
 		return errors.New(errors.TransferPolicyInvalidConfig, "snapshot policy ID is required")
 	}
 
-	if len(policy.Schedules) == 0 {
-		return errors.New(errors.TransferPolicyInvalidConfig, "at least one schedule is required")
-	}
-
-	if len(policy.Schedules) > 5 {
-		return errors.New(
-			errors.TransferPolicyInvalidConfig,
-			"maximum 5 schedules allowed per policy",
-		)
-	}
-
-	// Validate each schedule
-	for i, schedule := range policy.Schedules {
-		if err := autosnapshots.ValidateScheduleSpec(schedule); err != nil {
-			return errors.New(
-				errors.TransferPolicyInvalidConfig,
-				fmt.Sprintf("schedule %d invalid: %v", i, err),
-			)
-		}
+	if err := validateSchedules(
+		policy.Schedules,
+		policy.FollowSnapshotPolicy,
+		policy.FollowDelay,
+	); err != nil {
+		return err
 	}
 
 	// Validate transfer config
@@ -214,6 +283,13 @@ func ValidateTransferPolicy(policy *TransferPolicy) error {
 	if policy.TransferConfig.ReceiveConfig.Target == "" {
 		return errors.New(errors.TransferPolicyInvalidConfig, "receive target is required")
 	}
+	if err := ValidateTargetTemplate(policy.TransferConfig.ReceiveConfig.Target); err != nil {
+		return err
+	}
+	if policy.TransferConfig.IsPull() {
+		return errors.New(errors.TransferPolicyInvalidConfig,
+			"transfer policies send snapshots of a local snapshot policy and cannot pull")
+	}
 
 	// Retention policy validation
 	if policy.RetentionPolicy.KeepCount < 0 {
@@ -230,6 +306,17 @@ func ValidateTransferPolicy(policy *TransferPolicy) error {
 		)
 	}
 
+	if t := policy.RetentionPolicy.TargetSnapshots; t != nil && (t.KeepCount < 0 || t.OlderThan < 0) {
+		return errors.New(
+			errors.TransferPolicyInvalidConfig,
+			"target snapshot retention keep_count and older_than cannot be negative",
+		)
+	}
+
+	if policy.RPOTarget < 0 {
+		return errors.New(errors.TransferPolicyInvalidConfig, "rpo_target cannot be negative")
+	}
+
 	return nil
 }
 
@@ -243,11 +330,65 @@ func ValidateEditTransferPolicyParams(params *EditTransferPolicyParams) error {
 		return errors.New(errors.TransferPolicyInvalidConfig, "snapshot policy ID is required")
 	}
 
-	if len(params.Schedules) == 0 {
+	if err := validateSchedules(
+		params.Schedules,
+		params.FollowSnapshotPolicy,
+		params.FollowDelay,
+	); err != nil {
+		return err
+	}
+
+	if params.TransferConfig.ReceiveConfig.Target == "" {
+		return errors.New(errors.TransferPolicyInvalidConfig, "receive target is required")
+	}
+	if err := ValidateTargetTemplate(params.TransferConfig.ReceiveConfig.Target); err != nil {
+		return err
+	}
+	if params.TransferConfig.IsPull() {
+		return errors.New(errors.TransferPolicyInvalidConfig,
+			"transfer policies send snapshots of a local snapshot policy and cannot pull")
+	}
+
+	if params.RPOTarget < 0 {
+		return errors.New(errors.TransferPolicyInvalidConfig, "rpo_target cannot be negative")
+	}
+
+	return nil
+}
+
+// validateSchedules validates a policy's own schedules, or their absence for
+// a policy that follows its snapshot policy
+func validateSchedules(
+	schedules []autosnapshots.ScheduleSpec,
+	follow bool,
+	followDelay time.Duration,
+) error {
+	if followDelay < 0 {
+		return errors.New(errors.TransferPolicyInvalidConfig, "follow_delay cannot be negative")
+	}
+
+	if follow {
+		if len(schedules) > 0 {
+			return errors.New(
+				errors.TransferPolicyInvalidConfig,
+				"a policy following its snapshot policy cannot have schedules",
+			)
+		}
+		return nil
+	}
+
+	if followDelay > 0 {
+		return errors.New(
+			errors.TransferPolicyInvalidConfig,
+			"follow_delay requires follow_snapshot_policy",
+		)
+	}
+
+	if len(schedules) == 0 {
 		return errors.New(errors.TransferPolicyInvalidConfig, "at least one schedule is required")
 	}
 
-	if len(params.Schedules) > 5 {
+	if len(schedules) > 5 {
 		return errors.New(
 			errors.TransferPolicyInvalidConfig,
 			"maximum 5 schedules allowed per policy",
@@ -255,7 +396,7 @@ func ValidateEditTransferPolicyParams(params *EditTransferPolicyParams) error {
 	}
 
 	// Validate each schedule
-	for i, schedule := range params.Schedules {
+	for i, schedule := range schedules {
 		if err := autosnapshots.ValidateScheduleSpec(schedule); err != nil {
 			return errors.New(
 				errors.TransferPolicyInvalidConfig,
@@ -264,9 +405,5 @@ func ValidateEditTransferPolicyParams(params *EditTransferPolicyParams) error {
 		}
 	}
 
-	if params.TransferConfig.ReceiveConfig.Target == "" {
-		return errors.New(errors.TransferPolicyInvalidConfig, "receive target is required")
-	}
-
 	return nil
 }
@@ -0,0 +1,189 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package calendars
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Handler handles HTTP requests for schedule calendars
+type Handler struct {
+	manager *Manager
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewHandlerWithManager creates a new calendar handler with an existing manager
+func NewHandlerWithManager(manager *Manager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+// Manager returns the calendar manager for use by other subsystems
+func (h *Handler) Manager() *Manager {
+	return h.manager
+}
+
+// RegisterRoutes registers HTTP routes for schedule calendars
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	calendars := router.Group("/calendars")
+	{
+		calendars.GET("", h.listCalendars)
+		calendars.POST("", h.createCalendar)
+		calendars.GET("/:name", h.getCalendar)
+		calendars.PUT("/:name", h.updateCalendar)
+		calendars.DELETE("/:name", h.deleteCalendar)
+		calendars.GET("/:name/check", h.checkCalendar)
+	}
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *Handler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *Handler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  string(errors.DomainZFS),
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// listCalendars lists all calendars
+func (h *Handler) listCalendars(c *gin.Context) {
+	calendars := h.manager.ListCalendars()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"calendars": calendars,
+		"count":     len(calendars),
+	})
+}
+
+// createCalendar creates a calendar from a list of dates or iCalendar data
+func (h *Handler) createCalendar(c *gin.Context) {
+	var params EditCalendarParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.CalendarInvalidConfig))
+		return
+	}
+
+	cal, err := h.manager.CreateCalendar(params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusCreated, cal)
+}
+
+// getCalendar gets a calendar by name
+func (h *Handler) getCalendar(c *gin.Context) {
+	cal, err := h.manager.GetCalendar(c.Param("name"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cal)
+}
+
+// updateCalendar replaces the calendar named in the path
+func (h *Handler) updateCalendar(c *gin.Context) {
+	var params EditCalendarParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.CalendarInvalidConfig))
+		return
+	}
+	params.Name = c.Param("name")
+
+	cal, err := h.manager.UpdateCalendar(params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cal)
+}
+
+// deleteCalendar deletes a calendar no schedule references
+func (h *Handler) deleteCalendar(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.manager.DeleteCalendar(name); err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"calendar": name,
+		"deleted":  true,
+	})
+}
+
+// checkCalendar reports whether the calendar blocks the time in the at query
+// parameter (RFC 3339, default now)
+func (h *Handler) checkCalendar(c *gin.Context) {
+	at := time.Now()
+	if v := c.Query("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			h.sendError(c, errors.New(errors.CalendarInvalidConfig, "at must be an RFC 3339 time").
+				WithMetadata("at", v))
+			return
+		}
+		at = t
+	}
+
+	result, err := h.manager.Check(c.Param("name"), at)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, result)
+}
@@ -0,0 +1,207 @@
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package calendars
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-calendars")
+	require.NoError(t, err)
+	return NewManager(l, filepath.Join(t.TempDir(), "calendars.rodent.yml"))
+}
+
+func hasCode(err error, code errors.ErrorCode) bool {
+	got, ok := errors.GetCode(err)
+	return ok && got == code
+}
+
+func TestParseICS(t *testing.T) {
+	tests := []struct {
+		name    string
+		ics     string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "all-day event",
+			ics: "BEGIN:VCALENDAR\r\nBEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20251225\r\n" +
+				"DTEND;VALUE=DATE:20251226\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+			want: []string{"2025-12-25"},
+		},
+		{
+			name: "multi-day event",
+			ics: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20251230\nDTEND;VALUE=DATE:20260102\n" +
+				"END:VEVENT\n",
+			want: []string{"2025-12-30", "2025-12-31", "2026-01-01"},
+		},
+		{
+			name: "timed event spanning midnight",
+			ics:  "BEGIN:VEVENT\nDTSTART:20250301T220000Z\nDTEND:20250302T020000Z\nEND:VEVENT\n",
+			want: []string{"2025-03-01", "2025-03-02"},
+		},
+		{
+			name: "yearly rule with count and exdate",
+			ics: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\nRRULE:FREQ=YEARLY;COUNT=3\n" +
+				"EXDATE;VALUE=DATE:20260101\nEND:VEVENT\n",
+			want: []string{"2025-01-01", "2027-01-01"},
+		},
+		{
+			name: "cancelled event and folded line",
+			ics: "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250501\nSTATUS:CANCELLED\nEND:VEVENT\n" +
+				"BEGIN:VEVENT\nDTSTART;VALUE=DATE:2025\n 0704\nEND:VEVENT\n",
+			want: []string{"2025-07-04"},
+		},
+		{
+			name:    "unsupported rule",
+			ics:     "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\nRRULE:FREQ=WEEKLY\nEND:VEVENT\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated event",
+			ics:     "BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseICS(tt.ics)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.True(t, hasCode(err, errors.CalendarParseFailed))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBlocksAndNextOpen(t *testing.T) {
+	cal := Calendar{
+		Name:     "holidays",
+		Timezone: "UTC",
+		Dates:    []string{"2025-12-24", "2025-12-25", "2025-12-26"},
+	}
+
+	at := time.Date(2025, 12, 24, 2, 30, 0, 0, time.UTC)
+	assert.True(t, cal.Blocks(at))
+	assert.False(t, cal.Blocks(at.AddDate(0, 0, -1)))
+
+	next, err := cal.NextOpen(at)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 12, 27, 2, 30, 0, 0, time.UTC), next.UTC())
+
+	open := time.Date(2025, 12, 20, 2, 30, 0, 0, time.UTC)
+	next, err = cal.NextOpen(open)
+	require.NoError(t, err)
+	assert.True(t, next.Equal(open))
+}
+
+func TestNewCalendarValidation(t *testing.T) {
+	_, err := newCalendar(EditCalendarParams{Name: "bad name", Dates: []string{"2025-01-01"}})
+	assert.True(t, hasCode(err, errors.CalendarInvalidConfig))
+
+	_, err = newCalendar(EditCalendarParams{Name: "ok"})
+	assert.True(t, hasCode(err, errors.CalendarInvalidConfig))
+
+	_, err = newCalendar(EditCalendarParams{Name: "ok", Dates: []string{"01/02/2025"}})
+	assert.True(t, hasCode(err, errors.CalendarInvalidConfig))
+
+	_, err = newCalendar(EditCalendarParams{Name: "ok", Timezone: "Nowhere/City", Dates: []string{"2025-01-01"}})
+	assert.True(t, hasCode(err, errors.CalendarInvalidConfig))
+
+	cal, err := newCalendar(EditCalendarParams{
+		Name:  "ok",
+		Dates: []string{"2025-03-01", "2025-01-01", "2025-03-01"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-01-01", "2025-03-01"}, cal.Dates)
+	assert.Equal(t, SourceDates, cal.Source)
+
+	assert.Error(t, ValidateAction("", ActionShift))
+	assert.Error(t, ValidateAction("holidays", Action("defer")))
+	assert.NoError(t, ValidateAction("holidays", ""))
+}
+
+func TestManagerPersistenceAndInUse(t *testing.T) {
+	m := newTestManager(t)
+
+	_, err := m.CreateCalendar(EditCalendarParams{Name: "maint", Dates: []string{"2025-06-01"}})
+	require.NoError(t, err)
+
+	_, err = m.CreateCalendar(EditCalendarParams{Name: "maint", Dates: []string{"2025-06-02"}})
+	assert.True(t, hasCode(err, errors.CalendarAlreadyExists))
+
+	reloaded := NewManager(m.logger, m.configPath)
+	require.NoError(t, reloaded.LoadConfig())
+	cal, err := reloaded.GetCalendar("maint")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2025-06-01"}, cal.Dates)
+
+	users := []string{"snapshot policy nightly"}
+	m.RegisterUsage(func(name string) []string {
+		if name == "maint" {
+			return users
+		}
+		return nil
+	})
+
+	err = m.DeleteCalendar("maint")
+	assert.True(t, hasCode(err, errors.CalendarInUse))
+
+	users = nil
+	require.NoError(t, m.DeleteCalendar("maint"))
+	assert.True(t, hasCode(m.DeleteCalendar("maint"), errors.CalendarNotFound))
+}
+
+func TestGate(t *testing.T) {
+	m := newTestManager(t)
+
+	now := time.Now().UTC()
+	_, err := m.CreateCalendar(EditCalendarParams{
+		Name:     "today",
+		Timezone: "UTC",
+		Dates:    []string{now.Format(DateLayout)},
+	})
+	require.NoError(t, err)
+
+	ok, _ := m.Gate("", "", "k", now, func() {})
+	assert.True(t, ok, "no calendar never blocks")
+
+	ok, _ = m.Gate("missing", ActionSkip, "k", now, func() {})
+	assert.True(t, ok, "a missing calendar does not block")
+
+	ok, _ = m.Gate("today", ActionSkip, "k", now.AddDate(0, 0, 1), func() {})
+	assert.True(t, ok, "open day runs")
+
+	ok, reason := m.Gate("today", ActionSkip, "k", now, func() {})
+	assert.False(t, ok)
+	assert.Contains(t, reason, "skipped")
+
+	ran := false
+	ok, reason = m.Gate("today", ActionShift, "policy:1:0", now, func() { ran = true })
+	assert.False(t, ok)
+	assert.Contains(t, reason, "shifted")
+
+	ok, reason = m.Gate("today", ActionShift, "policy:1:0", now, func() { ran = true })
+	assert.False(t, ok)
+	assert.Contains(t, reason, "already pending")
+
+	m.CancelShifted("policy:1:")
+	m.shiftMu.Lock()
+	assert.Empty(t, m.shifted)
+	m.shiftMu.Unlock()
+	assert.False(t, ran)
+}
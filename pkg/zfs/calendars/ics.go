@@ -0,0 +1,279 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package calendars
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	icsDateLayout = "20060102"
+
+	// maxEventDays bounds the days a single event may cover
+	maxEventDays = 366
+
+	// yearlyHorizon is how many years past the current one an open-ended
+	// yearly rule is expanded
+	yearlyHorizon = 5
+
+	// maxDates bounds the dates one calendar may hold
+	maxDates = 10000
+)
+
+// icsEvent holds the VEVENT properties relevant to blocked days
+type icsEvent struct {
+	start     time.Time
+	end       time.Time // Exclusive; zero when the event has no DTEND
+	allDay    bool
+	rrule     string
+	exdates   map[string]bool
+	cancelled bool
+}
+
+// ParseICS returns the sorted dates covered by the events in iCalendar data.
+// All-day and timed events are supported; a timed event blocks every date it
+// touches. Recurrence is limited to yearly rules, which covers fixed-date
+// holidays; events with other rules are rejected.
+func ParseICS(data string) ([]string, error) {
+	var (
+		events []icsEvent
+		ev     *icsEvent
+	)
+
+	for n, line := range unfoldICS(data) {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			ev = &icsEvent{exdates: make(map[string]bool)}
+		case name == "END" && value == "VEVENT":
+			if ev == nil {
+				return nil, icsError(n, "END:VEVENT without BEGIN:VEVENT")
+			}
+			if ev.start.IsZero() {
+				return nil, icsError(n, "event without DTSTART")
+			}
+			if !ev.cancelled {
+				events = append(events, *ev)
+			}
+			ev = nil
+		case ev == nil:
+			// Calendar-level properties and other components are ignored
+		case name == "DTSTART":
+			t, allDay, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, icsError(n, err.Error())
+			}
+			ev.start, ev.allDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseICSTime(params, value)
+			if err != nil {
+				return nil, icsError(n, err.Error())
+			}
+			ev.end = t
+		case name == "RRULE":
+			ev.rrule = value
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				t, _, err := parseICSTime(params, v)
+				if err != nil {
+					return nil, icsError(n, err.Error())
+				}
+				ev.exdates[t.Format(DateLayout)] = true
+			}
+		case name == "STATUS":
+			ev.cancelled = strings.EqualFold(value, "CANCELLED")
+		}
+	}
+	if ev != nil {
+		return nil, errors.New(errors.CalendarParseFailed, "unterminated VEVENT")
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range events {
+		starts, err := e.occurrences()
+		if err != nil {
+			return nil, err
+		}
+		for _, start := range starts {
+			for _, d := range e.days(start) {
+				if !e.exdates[d] {
+					seen[d] = true
+				}
+			}
+		}
+		if len(seen) > maxDates {
+			return nil, errors.New(
+				errors.CalendarParseFailed,
+				fmt.Sprintf("calendar covers more than %d dates", maxDates),
+			)
+		}
+	}
+
+	dates := make([]string, 0, len(seen))
+	for d := range seen {
+		dates = append(dates, d)
+	}
+	return dedupeSorted(dates), nil
+}
+
+// occurrences returns the start of every occurrence of the event
+func (e icsEvent) occurrences() ([]time.Time, error) {
+	if e.rrule == "" {
+		return []time.Time{e.start}, nil
+	}
+
+	rule := make(map[string]string)
+	for _, part := range strings.Split(e.rrule, ";") {
+		if k, v, ok := strings.Cut(part, "="); ok {
+			rule[strings.ToUpper(k)] = v
+		}
+	}
+
+	if rule["FREQ"] != "YEARLY" {
+		return nil, errors.New(errors.CalendarParseFailed, "only yearly recurrence rules are supported").
+			WithMetadata("rrule", e.rrule)
+	}
+	for k := range rule {
+		switch k {
+		case "FREQ", "COUNT", "UNTIL", "INTERVAL":
+		default:
+			return nil, errors.New(
+				errors.CalendarParseFailed,
+				"yearly rules may only use COUNT, UNTIL and INTERVAL",
+			).WithMetadata("rrule", e.rrule)
+		}
+	}
+
+	interval := 1
+	if v, ok := rule["INTERVAL"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New(errors.CalendarParseFailed, "invalid INTERVAL").
+				WithMetadata("rrule", e.rrule)
+		}
+		interval = n
+	}
+
+	count := -1
+	if v, ok := rule["COUNT"]; ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New(errors.CalendarParseFailed, "invalid COUNT").
+				WithMetadata("rrule", e.rrule)
+		}
+		count = n
+	}
+
+	until := time.Date(max(e.start.Year(), time.Now().Year())+yearlyHorizon, 12, 31, 0, 0, 0, 0, time.UTC)
+	if v, ok := rule["UNTIL"]; ok {
+		t, _, err := parseICSTime("", v)
+		if err != nil {
+			return nil, errors.New(errors.CalendarParseFailed, "invalid UNTIL").
+				WithMetadata("rrule", e.rrule)
+		}
+		until = t
+	}
+
+	var starts []time.Time
+	for i := 0; count < 0 || i < count; i++ {
+		start := e.start.AddDate(i*interval, 0, 0)
+		// A 29 February start only recurs in leap years
+		if start.Day() != e.start.Day() {
+			continue
+		}
+		if start.After(until) || len(starts) > maxDates {
+			break
+		}
+		starts = append(starts, start)
+	}
+	return starts, nil
+}
+
+// days returns the dates covered by an occurrence starting at start
+func (e icsEvent) days(start time.Time) []string {
+	end := start.AddDate(0, 0, 1)
+	if !e.end.IsZero() {
+		end = start.Add(e.end.Sub(e.start))
+		if midnight := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC); !end.Equal(midnight) {
+			// A timed event also blocks the day it ends on
+			end = midnight.AddDate(0, 0, 1)
+		}
+	}
+
+	var days []string
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	for i := 0; i < maxEventDays && (i == 0 || day.Before(end)); i++ {
+		days = append(days, day.Format(DateLayout))
+		day = day.AddDate(0, 0, 1)
+	}
+	return days
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. Times keep the date as
+// written; a UTC "Z" suffix or TZID parameter does not move the event to
+// another day.
+func parseICSTime(params, value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	allDay := strings.Contains(strings.ToUpper(params), "VALUE=DATE") &&
+		!strings.Contains(strings.ToUpper(params), "VALUE=DATE-TIME")
+
+	if len(value) == len(icsDateLayout) {
+		allDay = true
+	}
+	if len(value) < len(icsDateLayout) {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+	}
+
+	t, err := time.Parse(icsDateLayout, value[:len(icsDateLayout)])
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", value)
+	}
+	if allDay {
+		return t, true, nil
+	}
+
+	clock := strings.TrimSuffix(value[len(icsDateLayout):], "Z")
+	ct, err := time.Parse("T150405", clock)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid time %q", value)
+	}
+	return t.Add(time.Duration(ct.Hour())*time.Hour +
+		time.Duration(ct.Minute())*time.Minute +
+		time.Duration(ct.Second())*time.Second), false, nil
+}
+
+// unfoldICS splits iCalendar data into logical lines, joining folded
+// continuation lines
+func unfoldICS(data string) []string {
+	var lines []string
+	for _, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(raw, " ") || strings.HasPrefix(raw, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += raw[1:]
+			continue
+		}
+		if strings.TrimSpace(raw) != "" {
+			lines = append(lines, strings.TrimRight(raw, "\r"))
+		}
+	}
+	return lines
+}
+
+// splitICSLine splits "NAME;PARAMS:VALUE" into its parts
+func splitICSLine(line string) (name, params, value string) {
+	head, value, _ := strings.Cut(line, ":")
+	name, params, _ = strings.Cut(head, ";")
+	return strings.ToUpper(name), params, value
+}
+
+// icsError reports a parse error at a logical line
+func icsError(line int, msg string) error {
+	return errors.New(errors.CalendarParseFailed, msg).
+		WithMetadata("line", strconv.Itoa(line+1))
+}
@@ -0,0 +1,380 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package calendars
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// UsageFunc returns descriptions of the schedules that reference a calendar,
+// e.g. "snapshot policy daily-tank"
+type UsageFunc func(calendar string) []string
+
+// Manager stores calendars and decides whether scheduled runs go ahead
+type Manager struct {
+	logger     logger.Logger
+	configPath string
+	config     CalendarConfig
+	mu         sync.RWMutex
+
+	usageMu sync.RWMutex
+	usage   []UsageFunc
+
+	shiftMu sync.Mutex
+	shifted map[string]*time.Timer // Pending shifted runs by key
+}
+
+// Singleton instance
+var (
+	globalManager *Manager
+	initMutex     sync.Mutex
+)
+
+// GetManager returns the singleton calendar manager instance
+func GetManager(logCfg logger.Config) (*Manager, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalManager != nil {
+		return globalManager, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "zfs-calendars")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	calendarDir := filepath.Join(config.GetPoliciesDir(), "calendars")
+	if err := os.MkdirAll(calendarDir, 0755); err != nil {
+		return nil, errors.New(
+			errors.ConfigWriteError,
+			fmt.Sprintf("failed to create calendars directory: %v", err),
+		)
+	}
+
+	m := NewManager(l, filepath.Join(calendarDir, "calendars.rodent.yml"))
+	if err := m.LoadConfig(); err != nil {
+		l.Warn("Failed to load calendars, starting with no calendars", "error", err)
+	}
+
+	globalManager = m
+	return m, nil
+}
+
+// NewManager creates a calendar manager persisting to configPath. Most
+// callers should use GetManager.
+func NewManager(l logger.Logger, configPath string) *Manager {
+	return &Manager{
+		logger:     l,
+		configPath: configPath,
+		config:     CalendarConfig{Calendars: []Calendar{}},
+		shifted:    make(map[string]*time.Timer),
+	}
+}
+
+// RegisterUsage adds a function consulted before a calendar is deleted
+func (m *Manager) RegisterUsage(fn UsageFunc) {
+	m.usageMu.Lock()
+	defer m.usageMu.Unlock()
+	m.usage = append(m.usage, fn)
+}
+
+// ListCalendars returns all calendars sorted by name
+func (m *Manager) ListCalendars() []Calendar {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := append([]Calendar(nil), m.config.Calendars...)
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// GetCalendar returns a calendar by name
+func (m *Manager) GetCalendar(name string) (Calendar, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if i := m.indexOf(name); i >= 0 {
+		return m.config.Calendars[i], nil
+	}
+	return Calendar{}, errors.New(errors.CalendarNotFound, "calendar not found").
+		WithMetadata("calendar", name)
+}
+
+// CreateCalendar adds a new calendar
+func (m *Manager) CreateCalendar(params EditCalendarParams) (Calendar, error) {
+	cal, err := newCalendar(params)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.indexOf(cal.Name) >= 0 {
+		return Calendar{}, errors.New(errors.CalendarAlreadyExists, "calendar already exists").
+			WithMetadata("calendar", cal.Name)
+	}
+
+	cal.CreatedAt = time.Now()
+	cal.UpdatedAt = cal.CreatedAt
+	m.config.Calendars = append(m.config.Calendars, cal)
+	if err := m.SaveConfig(true); err != nil {
+		m.config.Calendars = m.config.Calendars[:len(m.config.Calendars)-1]
+		return Calendar{}, err
+	}
+
+	m.logger.Info("Calendar created", "calendar", cal.Name, "source", cal.Source, "dates", len(cal.Dates))
+	return cal, nil
+}
+
+// UpdateCalendar replaces the dates and settings of an existing calendar.
+// Schedules referencing it use the new dates from their next run on.
+func (m *Manager) UpdateCalendar(params EditCalendarParams) (Calendar, error) {
+	cal, err := newCalendar(params)
+	if err != nil {
+		return Calendar{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(cal.Name)
+	if i < 0 {
+		return Calendar{}, errors.New(errors.CalendarNotFound, "calendar not found").
+			WithMetadata("calendar", cal.Name)
+	}
+
+	previous := m.config.Calendars[i]
+	cal.CreatedAt = previous.CreatedAt
+	cal.UpdatedAt = time.Now()
+	m.config.Calendars[i] = cal
+	if err := m.SaveConfig(true); err != nil {
+		m.config.Calendars[i] = previous
+		return Calendar{}, err
+	}
+
+	m.logger.Info("Calendar updated", "calendar", cal.Name, "source", cal.Source, "dates", len(cal.Dates))
+	return cal, nil
+}
+
+// DeleteCalendar removes a calendar that no schedule references
+func (m *Manager) DeleteCalendar(name string) error {
+	// Usage functions take the policy managers' locks, so they run before ours
+	var users []string
+	m.usageMu.RLock()
+	for _, fn := range m.usage {
+		users = append(users, fn(name)...)
+	}
+	m.usageMu.RUnlock()
+	if len(users) > 0 {
+		return errors.New(errors.CalendarInUse, "calendar is referenced by schedules").
+			WithMetadata("calendar", name).
+			WithMetadata("used_by", strings.Join(users, ", "))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexOf(name)
+	if i < 0 {
+		return errors.New(errors.CalendarNotFound, "calendar not found").
+			WithMetadata("calendar", name)
+	}
+
+	previous := m.config.Calendars
+	m.config.Calendars = append(append([]Calendar{}, previous[:i]...), previous[i+1:]...)
+	if err := m.SaveConfig(true); err != nil {
+		m.config.Calendars = previous
+		return err
+	}
+
+	m.logger.Info("Calendar deleted", "calendar", name)
+	return nil
+}
+
+// Check reports whether the calendar blocks t and when the next open day is
+func (m *Manager) Check(name string, t time.Time) (CheckResult, error) {
+	cal, err := m.GetCalendar(name)
+	if err != nil {
+		return CheckResult{}, err
+	}
+
+	next, err := cal.NextOpen(t)
+	if err != nil {
+		return CheckResult{}, err
+	}
+	return CheckResult{
+		Calendar: name,
+		At:       t,
+		Blocked:  cal.Blocks(t),
+		NextOpen: next,
+	}, nil
+}
+
+// ValidateRef checks that a schedule's calendar reference can be honoured
+func (m *Manager) ValidateRef(name string, action Action) error {
+	if err := ValidateAction(name, action); err != nil {
+		return err
+	}
+	if name == "" {
+		return nil
+	}
+	if m == nil {
+		return errors.New(errors.CalendarNotFound, "calendars are not available").
+			WithMetadata("calendar", name)
+	}
+	_, err := m.GetCalendar(name)
+	return err
+}
+
+// Gate applies a schedule's calendar to a run due at t and reports whether
+// the run goes ahead now. A blocked run is dropped, or for ActionShift, run
+// is scheduled for the same time on the next open day under key; while a
+// shifted run is pending, further blocked runs with the same key are dropped.
+// The returned reason explains a blocked run. A missing calendar does not
+// block runs, so deleting one by hand cannot stop backups.
+func (m *Manager) Gate(
+	name string,
+	action Action,
+	key string,
+	t time.Time,
+	run func(),
+) (bool, string) {
+	if m == nil || name == "" {
+		return true, ""
+	}
+
+	cal, err := m.GetCalendar(name)
+	if err != nil {
+		m.logger.Warn("Schedule references a missing calendar, running anyway",
+			"calendar", name, "key", key)
+		return true, ""
+	}
+	if !cal.Blocks(t) {
+		return true, ""
+	}
+
+	day := t.In(cal.location()).Format(DateLayout)
+	if action != ActionShift {
+		m.logger.Info("Skipping scheduled run on calendar day", "calendar", name, "day", day, "key", key)
+		return false, fmt.Sprintf("skipped: %s is blocked by calendar %s", day, name)
+	}
+
+	next, err := cal.NextOpen(t)
+	if err != nil {
+		m.logger.Warn("No open day to shift run to, skipping", "calendar", name, "key", key, "error", err)
+		return false, fmt.Sprintf("skipped: %v", err)
+	}
+
+	m.shiftMu.Lock()
+	defer m.shiftMu.Unlock()
+
+	if _, pending := m.shifted[key]; pending {
+		return false, fmt.Sprintf("shifted: %s is blocked by calendar %s, run already pending", day, name)
+	}
+	m.shifted[key] = time.AfterFunc(time.Until(next), func() {
+		m.shiftMu.Lock()
+		delete(m.shifted, key)
+		m.shiftMu.Unlock()
+		run()
+	})
+
+	m.logger.Info("Shifting scheduled run past calendar day",
+		"calendar", name, "day", day, "key", key, "run_at", next)
+	return false, fmt.Sprintf("shifted to %s: %s is blocked by calendar %s",
+		next.Format(time.RFC3339), day, name)
+}
+
+// CancelShifted cancels pending shifted runs whose key starts with prefix
+func (m *Manager) CancelShifted(prefix string) {
+	if m == nil {
+		return
+	}
+
+	m.shiftMu.Lock()
+	defer m.shiftMu.Unlock()
+
+	for key, timer := range m.shifted {
+		if strings.HasPrefix(key, prefix) {
+			timer.Stop()
+			delete(m.shifted, key)
+		}
+	}
+}
+
+// indexOf returns the index of the named calendar, or -1 (must be called
+// with lock held)
+func (m *Manager) indexOf(name string) int {
+	for i := range m.config.Calendars {
+		if m.config.Calendars[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// LoadConfig loads calendars from disk
+func (m *Manager) LoadConfig() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
+		m.logger.Info("Calendar config file does not exist, starting with no calendars")
+		return nil
+	}
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigReadError)
+	}
+
+	var cfg CalendarConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return errors.Wrap(err, errors.ConfigUnmarshalFailed)
+	}
+
+	if cfg.Calendars == nil {
+		cfg.Calendars = []Calendar{}
+	}
+	for i := range cfg.Calendars {
+		cfg.Calendars[i].Dates = dedupeSorted(cfg.Calendars[i].Dates)
+	}
+
+	m.config = cfg
+	m.logger.Info("Calendars loaded", "calendars", len(cfg.Calendars))
+	return nil
+}
+
+// SaveConfig saves calendars to disk
+func (m *Manager) SaveConfig(skipLock bool) error {
+	if !skipLock {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+
+	data, err := yaml.Marshal(&m.config)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigMarshalFailed)
+	}
+
+	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package calendars manages named calendars of blocked days, such as
+// maintenance days and regional holidays. Snapshot and transfer schedules can
+// reference a calendar to skip runs that fall on a blocked day, or to shift
+// them to the next open day.
+package calendars
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// DateLayout is the format of calendar dates
+const DateLayout = "2006-01-02"
+
+// maxShiftDays bounds the search for the next open day
+const maxShiftDays = 366
+
+var nameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,63}$`)
+
+// Action is what a schedule does with a run due on a blocked day
+type Action string
+
+const (
+	ActionSkip  Action = "skip"  // Drop the run
+	ActionShift Action = "shift" // Run at the same time on the next open day
+)
+
+// Source records how a calendar's dates were provided
+type Source string
+
+const (
+	SourceDates Source = "dates"
+	SourceICS   Source = "ics"
+)
+
+// Calendar is a named set of blocked days
+type Calendar struct {
+	Name        string    `json:"name"                  yaml:"name"`
+	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"    yaml:"timezone,omitempty"` // IANA zone the dates are in (default: local)
+	Source      Source    `json:"source"                yaml:"source"`
+	Dates       []string  `json:"dates"                 yaml:"dates"` // Sorted, YYYY-MM-DD
+	CreatedAt   time.Time `json:"created_at"            yaml:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"            yaml:"updated_at"`
+}
+
+// CalendarConfig is the persisted set of calendars
+type CalendarConfig struct {
+	Calendars []Calendar `json:"calendars" yaml:"calendars"`
+}
+
+// EditCalendarParams are parameters for creating or replacing a calendar.
+// Exactly one of Dates and ICS must be set.
+type EditCalendarParams struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Timezone    string   `json:"timezone,omitempty"`
+	Dates       []string `json:"dates,omitempty"` // YYYY-MM-DD
+	ICS         string   `json:"ics,omitempty"`   // iCalendar data; every day an event covers is blocked
+}
+
+// CheckResult reports how a calendar treats a point in time
+type CheckResult struct {
+	Calendar string    `json:"calendar"`
+	At       time.Time `json:"at"`
+	Blocked  bool      `json:"blocked"`
+	NextOpen time.Time `json:"next_open"` // Same time of day on the next open day; At when not blocked
+}
+
+// ValidateName validates a calendar name
+func ValidateName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return errors.New(
+			errors.CalendarInvalidConfig,
+			"name must be 1-64 letters, digits, '.', '_' or '-' and start with a letter or digit",
+		).WithMetadata("name", name)
+	}
+	return nil
+}
+
+// ValidateAction validates the calendar action of a schedule. An action
+// requires a calendar; a calendar without an action defaults to skip.
+func ValidateAction(calendar string, action Action) error {
+	switch action {
+	case "", ActionSkip, ActionShift:
+	default:
+		return errors.New(errors.CalendarInvalidConfig, "calendar_action must be skip or shift").
+			WithMetadata("calendar_action", string(action))
+	}
+	if action != "" && calendar == "" {
+		return errors.New(errors.CalendarInvalidConfig, "calendar_action requires a calendar")
+	}
+	return nil
+}
+
+// newCalendar builds a calendar from params, parsing ICS data if given
+func newCalendar(params EditCalendarParams) (Calendar, error) {
+	if err := ValidateName(params.Name); err != nil {
+		return Calendar{}, err
+	}
+	if params.Timezone != "" {
+		if _, err := time.LoadLocation(params.Timezone); err != nil {
+			return Calendar{}, errors.New(errors.CalendarInvalidConfig, "unknown timezone").
+				WithMetadata("timezone", params.Timezone)
+		}
+	}
+
+	if (len(params.Dates) == 0) == (params.ICS == "") {
+		return Calendar{}, errors.New(
+			errors.CalendarInvalidConfig,
+			"exactly one of dates and ics must be given",
+		)
+	}
+
+	cal := Calendar{
+		Name:        params.Name,
+		Description: params.Description,
+		Timezone:    params.Timezone,
+		Source:      SourceDates,
+		Dates:       params.Dates,
+	}
+
+	if params.ICS != "" {
+		dates, err := ParseICS(params.ICS)
+		if err != nil {
+			return Calendar{}, err
+		}
+		cal.Source = SourceICS
+		cal.Dates = dates
+	}
+
+	for _, d := range cal.Dates {
+		if _, err := time.Parse(DateLayout, d); err != nil {
+			return Calendar{}, errors.New(errors.CalendarInvalidConfig, "dates must be YYYY-MM-DD").
+				WithMetadata("date", d)
+		}
+	}
+	cal.Dates = dedupeSorted(cal.Dates)
+
+	return cal, nil
+}
+
+// dedupeSorted returns the dates sorted with duplicates removed
+func dedupeSorted(dates []string) []string {
+	sorted := append([]string(nil), dates...)
+	sort.Strings(sorted)
+
+	out := sorted[:0]
+	for i, d := range sorted {
+		if i == 0 || d != sorted[i-1] {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+// location returns the zone the calendar's dates are in
+func (c Calendar) location() *time.Location {
+	if c.Timezone != "" {
+		if loc, err := time.LoadLocation(c.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.Local
+}
+
+// Blocks reports whether t falls on one of the calendar's dates
+func (c Calendar) Blocks(t time.Time) bool {
+	day := t.In(c.location()).Format(DateLayout)
+	i := sort.SearchStrings(c.Dates, day)
+	return i < len(c.Dates) && c.Dates[i] == day
+}
+
+// NextOpen returns the same time of day on the first day from t on that the
+// calendar does not block
+func (c Calendar) NextOpen(t time.Time) (time.Time, error) {
+	local := t.In(c.location())
+	for i := 0; i <= maxShiftDays; i++ {
+		next := local.AddDate(0, 0, i)
+		if !c.Blocks(next) {
+			return next, nil
+		}
+	}
+	return time.Time{}, errors.New(
+		errors.CalendarInvalidConfig,
+		fmt.Sprintf("no open day within %d days", maxShiftDays),
+	).WithMetadata("calendar", c.Name)
+}
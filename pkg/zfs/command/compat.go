@@ -0,0 +1,276 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Features Rodent uses that depend on the OpenZFS version
+const (
+	FeatureJSONOutput   = "json_output"   // -j on zfs and zpool list, get, status and version
+	FeatureRawSend      = "raw_send"      // zfs send -w
+	FeatureResumeTokens = "resume_tokens" // zfs receive -s and zfs send -t
+	FeatureZstd         = "zstd"          // compression=zstd
+)
+
+// kernelVersionPath is the version of the loaded ZFS kernel module
+const kernelVersionPath = "/sys/module/zfs/version"
+
+// Version is an OpenZFS release version
+type Version struct {
+	Major int `json:"major"`
+	Minor int `json:"minor"`
+	Patch int `json:"patch"`
+}
+
+// FeatureRequirement is the oldest OpenZFS release supporting a feature
+type FeatureRequirement struct {
+	Feature     string  `json:"feature"`
+	Description string  `json:"description"`
+	MinVersion  Version `json:"min_version"`
+
+	// UserlandOnly features depend on the zfs and zpool commands alone;
+	// others also need the kernel module
+	UserlandOnly bool `json:"userland_only,omitempty"`
+}
+
+// CompatibilityMatrix lists the version-dependent features Rodent uses
+var CompatibilityMatrix = []FeatureRequirement{
+	{
+		Feature:      FeatureJSONOutput,
+		Description:  "JSON output of zfs and zpool list, get, status and version",
+		MinVersion:   Version{2, 3, 0},
+		UserlandOnly: true,
+	},
+	{
+		Feature:     FeatureRawSend,
+		Description: "Raw sends of encrypted datasets",
+		MinVersion:  Version{0, 8, 0},
+	},
+	{
+		Feature:     FeatureResumeTokens,
+		Description: "Resumable receives and sends from resume tokens",
+		MinVersion:  Version{0, 7, 0},
+	},
+	{
+		Feature:     FeatureZstd,
+		Description: "zstd compression",
+		MinVersion:  Version{2, 0, 0},
+	},
+}
+
+// VersionInfo is the installed OpenZFS and the features it supports
+type VersionInfo struct {
+	Userland        string          `json:"userland"` // e.g. "zfs-2.2.2-0ubuntu9"
+	Kernel          string          `json:"kernel"`   // e.g. "zfs-kmod-2.2.2-0ubuntu9"
+	UserlandVersion Version         `json:"userland_version"`
+	KernelVersion   Version         `json:"kernel_version"`
+	Mismatch        bool            `json:"mismatch"` // Userland and kernel module releases differ
+	Features        map[string]bool `json:"features"`
+}
+
+// detectedVersion is shared by every executor, as they all run the same
+// binaries against the same kernel module
+var detectedVersion atomic.Pointer[VersionInfo]
+
+// ParseVersion parses the release of an OpenZFS version string such as
+// "zfs-2.2.2-0ubuntu9", "zfs-kmod-2.1.5-1" or "2.3.0-1"
+func ParseVersion(s string) (Version, bool) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(s, "zfs-kmod-")
+	s = strings.TrimPrefix(s, "zfs-")
+
+	release, _, _ := strings.Cut(s, "-")
+	parts := strings.SplitN(release, ".", 3)
+	if len(parts) < 2 {
+		return Version{}, false
+	}
+
+	var v Version
+	for i, part := range parts {
+		// Drop suffixes such as "rc1" from the last part
+		end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' })
+		if end == 0 {
+			return Version{}, false
+		}
+		if end > 0 {
+			part = part[:end]
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Version{}, false
+		}
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	return v, true
+}
+
+// AtLeast reports whether v is the same release as o or a later one
+func (v Version) AtLeast(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major > o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor > o.Minor
+	}
+	return v.Patch >= o.Patch
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// DetectVersion reads the OpenZFS userland and kernel module versions and
+// records the features they support for Supports and RequireFeature
+func (e *CommandExecutor) DetectVersion(ctx context.Context) (*VersionInfo, error) {
+	// zfs version has printed both versions since 0.8, without -j before 2.3
+	out, err := e.Execute(ctx, CommandOptions{}, "zfs version")
+	if err != nil {
+		return nil, err
+	}
+
+	info := newVersionInfo(string(out))
+	if info.Kernel == "" {
+		// Older releases lack zfs version; the module reports its own
+		if data, err := os.ReadFile(kernelVersionPath); err == nil {
+			info.Kernel = "zfs-kmod-" + strings.TrimSpace(string(data))
+			info.KernelVersion, _ = ParseVersion(info.Kernel)
+		}
+	}
+	if info.Userland == "" && info.Kernel == "" {
+		return nil, errors.New(errors.CommandOutputParse, "no OpenZFS version found").
+			WithMetadata("output", string(out))
+	}
+	info.resolve()
+
+	detectedVersion.Store(info)
+	e.logger.Info("Detected OpenZFS version",
+		"userland", info.Userland,
+		"kernel", info.Kernel,
+		"features", info.Features)
+	if info.Mismatch {
+		e.logger.Warn("OpenZFS userland and kernel module versions differ; reload the module or reboot",
+			"userland", info.Userland,
+			"kernel", info.Kernel)
+	}
+	return info, nil
+}
+
+// newVersionInfo parses the output of zfs version
+func newVersionInfo(output string) *VersionInfo {
+	info := &VersionInfo{}
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "zfs-kmod-"):
+			info.Kernel = line
+			info.KernelVersion, _ = ParseVersion(line)
+		case strings.HasPrefix(line, "zfs-"):
+			info.Userland = line
+			info.UserlandVersion, _ = ParseVersion(line)
+		}
+	}
+	return info
+}
+
+// resolve fills in a missing side with the other and derives the supported
+// features from the compatibility matrix
+func (info *VersionInfo) resolve() {
+	if info.Userland == "" {
+		info.UserlandVersion = info.KernelVersion
+	}
+	if info.Kernel == "" {
+		info.KernelVersion = info.UserlandVersion
+	}
+	info.Mismatch = info.UserlandVersion != info.KernelVersion
+
+	info.Features = make(map[string]bool, len(CompatibilityMatrix))
+	for _, req := range CompatibilityMatrix {
+		supported := info.UserlandVersion.AtLeast(req.MinVersion)
+		if !req.UserlandOnly {
+			supported = supported && info.KernelVersion.AtLeast(req.MinVersion)
+		}
+		info.Features[req.Feature] = supported
+	}
+}
+
+// CurrentVersion returns the detected OpenZFS version, nil before
+// DetectVersion succeeds
+func CurrentVersion() *VersionInfo {
+	return detectedVersion.Load()
+}
+
+// Supports reports whether the installed OpenZFS supports a feature. Until
+// the version is detected every feature is assumed supported, leaving the
+// commands themselves to fail.
+func Supports(feature string) bool {
+	info := detectedVersion.Load()
+	if info == nil {
+		return true
+	}
+	supported, ok := info.Features[feature]
+	return !ok || supported
+}
+
+// RequireFeature returns an error naming the feature and the release it
+// needs when the installed OpenZFS does not support it
+func RequireFeature(feature string) error {
+	if Supports(feature) {
+		return nil
+	}
+
+	info := detectedVersion.Load()
+	required, running := "", info.KernelVersion
+	for _, req := range CompatibilityMatrix {
+		if req.Feature != feature {
+			continue
+		}
+		required = req.MinVersion.String()
+		if req.UserlandOnly || info.UserlandVersion.AtLeast(req.MinVersion) {
+			running = info.UserlandVersion
+		}
+	}
+
+	return errors.New(errors.ZFSFeatureUnsupported,
+		fmt.Sprintf("OpenZFS %s does not support %s", running, feature)).
+		WithMetadata("feature", feature).
+		WithMetadata("required", required).
+		WithMetadata("userland", info.Userland).
+		WithMetadata("kernel", info.Kernel)
+}
+
+// CheckProperty returns an error when setting a property to the value needs
+// a feature the installed OpenZFS lacks
+func CheckProperty(name, value string) error {
+	if name == "compression" && strings.HasPrefix(value, "zstd") {
+		return RequireFeature(FeatureZstd)
+	}
+	return nil
+}
+
+// CheckProperties checks each property with CheckProperty
+func CheckProperties(props map[string]string) error {
+	for name, value := range props {
+		if err := CheckProperty(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package command
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Version
+		ok   bool
+	}{
+		{"zfs-2.2.2-0ubuntu9.1", Version{2, 2, 2}, true},
+		{"zfs-kmod-2.1.5-1ubuntu6~22.04.4", Version{2, 1, 5}, true},
+		{"2.3.0-1", Version{2, 3, 0}, true},
+		{"zfs-2.3.0-rc1", Version{2, 3, 0}, true},
+		{"zfs-0.8", Version{0, 8, 0}, true},
+		{"zfs-unknown", Version{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseVersion(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseVersion(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestVersionInfoFeatures(t *testing.T) {
+	// Userland upgraded while the old module is still loaded
+	info := newVersionInfo("zfs-2.3.1-1\nzfs-kmod-0.8.6-1\n")
+	info.resolve()
+
+	if !info.Mismatch {
+		t.Error("differing userland and kernel releases should be a mismatch")
+	}
+	want := map[string]bool{
+		FeatureJSONOutput:   true, // Userland only
+		FeatureRawSend:      true,
+		FeatureResumeTokens: true,
+		FeatureZstd:         false, // Needs the 2.0 module
+	}
+	for feature, supported := range want {
+		if info.Features[feature] != supported {
+			t.Errorf("%s supported = %v, want %v", feature, info.Features[feature], supported)
+		}
+	}
+}
+
+func TestRequireFeature(t *testing.T) {
+	defer detectedVersion.Store(detectedVersion.Load())
+
+	// Everything is assumed supported until the version is known
+	detectedVersion.Store(nil)
+	if err := RequireFeature(FeatureZstd); err != nil {
+		t.Errorf("undetected version should not gate features: %v", err)
+	}
+
+	info := newVersionInfo("zfs-2.1.5-1\nzfs-kmod-2.1.5-1\n")
+	info.resolve()
+	detectedVersion.Store(info)
+
+	if err := CheckProperty("compression", "zstd-3"); err != nil {
+		t.Errorf("zstd should be supported on 2.1.5: %v", err)
+	}
+	err := RequireFeature(FeatureJSONOutput)
+	rerr, ok := err.(*errors.RodentError)
+	if !ok || rerr.Code != errors.ZFSFeatureUnsupported || rerr.Metadata["required"] != "2.3.0" {
+		t.Fatalf("expected an unsupported feature error requiring 2.3.0, got %v", err)
+	}
+
+	// JSON output is refused before the command runs
+	executor := NewCommandExecutor(false, logger.Config{LogLevel: "error"})
+	_, err = executor.Execute(context.Background(), CommandOptions{Flags: FlagJSON}, "zfs list")
+	if rerr, ok := err.(*errors.RodentError); !ok || rerr.Code != errors.ZFSFeatureUnsupported {
+		t.Errorf("expected JSON output to be refused, got %v", err)
+	}
+}
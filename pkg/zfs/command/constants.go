@@ -77,6 +77,9 @@ var SudoRequiredCommands = map[string]bool{
 	"zfs share":          true,
 	"zfs unshare":        true,
 	"zfs inherit":        true,
+	"zfs hold":           true,
+	"zfs release":        true,
+	"zfs receive":        true,
 	"zpool create":       true,
 	"zpool destroy":      true,
 	"zpool import":       true,
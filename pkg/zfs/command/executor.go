@@ -20,6 +20,7 @@ package command
 import (
 	"bytes"
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"os/exec"
@@ -28,15 +29,14 @@ import (
 	"time"
 
 	"github.com/stratastor/logger"
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/pkg/errors"
 )
 
 // CommandExecutor provides safe execution of ZFS commands
 type CommandExecutor struct {
-	mu           sync.RWMutex
-	zfsVersion   string
-	zpoolVersion string
-	features     map[string]bool // Supported ZFS features
+	mu sync.RWMutex
 
 	useSudo bool          // Whether to use sudo for privileged commands
 	timeout time.Duration // Default command timeout
@@ -58,7 +58,10 @@ const (
 // CommandOptions configures command execution
 type CommandOptions struct {
 	Flags   CommandFlags  // Command flags to apply
-	Timeout time.Duration // Command-specific timeout
+	Timeout time.Duration // Command-specific timeout; the caller's deadline, if any, replaces the default
+	// Unprivileged runs the command without sudo, for operations delegated
+	// to the service user with zfs allow
+	Unprivileged bool
 
 	// TODO: Implement these Capture* options? Not actively used in the code; everything is captured.
 	CaptureOutput bool // Whether to capture command output
@@ -72,12 +75,9 @@ func NewCommandExecutor(useSudo bool, logConfig logger.Config) *CommandExecutor
 	}
 
 	return &CommandExecutor{
-		features:     make(map[string]bool),
-		useSudo:      useSudo,
-		logger:       l,
-		timeout:      DefaultTimeout,
-		zfsVersion:   "N/A", // Placeholder for ZFS version
-		zpoolVersion: "N/A", // Placeholder for Zpool version
+		useSudo: useSudo,
+		logger:  common.WithRedaction(l),
+		timeout: DefaultTimeout,
 	}
 }
 
@@ -101,6 +101,13 @@ func (e *CommandExecutor) Execute(
 		return nil, err
 	}
 
+	// Fail clearly rather than with an unknown option on older releases
+	if opts.Flags&FlagJSON != 0 && JSONSupportedCommands[cmd] {
+		if err := RequireFeature(FeatureJSONOutput); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build command with security checks
 	cmdArgs := e.buildCommandArgs(cmd, opts, args...)
 
@@ -109,18 +116,45 @@ func (e *CommandExecutor) Execute(
 		return nil, err
 	}
 
-	// Set timeout
+	// Don't start commands for callers that have given up
+	if err := ctx.Err(); err != nil {
+		return nil, contextError(err, cmdArgs)
+	}
+
+	// In dry-run mode only list, get and other read-only commands run
+	useSudo := cmdArgs[0] == "sudo"
+	name, nameArgs := cmdArgs[0], cmdArgs[1:]
+	if useSudo {
+		name, nameArgs = cmdArgs[1], cmdArgs[2:]
+	}
+	run, audit, err := generalCmd.PrepareRun(ctx, e.logger, useSudo, name, nameArgs)
+	if !run {
+		return nil, err
+	}
+
+	// Set timeout. A caller with a deadline of its own, such as a job
+	// attempt, is trusted to bound the command instead of the default of
+	// its class, such as a quick list or a long receive.
 	if opts.Timeout == 0 {
-		opts.Timeout = DefaultTimeout
+		if _, ok := ctx.Deadline(); !ok {
+			opts.Timeout = generalCmd.CommandTimeout(parts[0], parts[1:])
+		}
+	}
+	cancel := context.CancelFunc(func() {})
+	if opts.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
 	}
-	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
 	defer cancel()
+	limit := opts.Timeout
+	if deadline, ok := ctx.Deadline(); ok {
+		limit = time.Until(deadline)
+	}
 
 	// Debug logging
-	e.logger.Debug("Executing command", "cmd", strings.Join(cmdArgs, " "))
+	e.logger.Debug("Executing command", "cmd", commandString(cmdArgs))
 
 	// Create command
-	execCmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	execCmd, release := generalCmd.GroupCommand(ctx, cmdArgs[0], cmdArgs[1:]...)
 
 	// Prevent shell expansion
 	execCmd.Env = []string{}
@@ -136,9 +170,12 @@ func (e *CommandExecutor) Execute(
 	}
 
 	// Start command execution
+	start := time.Now()
 	if err := execCmd.Start(); err != nil {
+		release()
+		audit(time.Since(start), err)
 		return nil, errors.NewCommandError(
-			strings.Join(cmdArgs, " "),
+			commandString(cmdArgs),
 			-1,
 			fmt.Sprintf("failed to start command: %v", err),
 		)
@@ -169,28 +206,45 @@ func (e *CommandExecutor) Execute(
 	// 3. Timeout
 	select {
 	case <-ctx.Done():
-		// Kill process on timeout/cancellation
-		if err := execCmd.Process.Kill(); err != nil {
-			return nil, errors.Wrap(err, errors.CommandTimeout)
-		}
-		return nil, errors.New(errors.CommandTimeout, "command execution timed out")
+		// The context stops the process group, SIGTERM then SIGKILL; reap
+		// it once its output is drained without holding up the caller
+		go func() {
+			<-done
+			_ = execCmd.Wait()
+			release()
+		}()
+		audit(time.Since(start), ctx.Err())
+		return nil, contextError(ctx.Err(), cmdArgs).
+			WithMetadata("elapsed", time.Since(start).Round(time.Millisecond).String()).
+			WithMetadata("timeout", limit.Round(time.Millisecond).String())
 
 	case <-done:
 		if outErr != nil {
+			_ = execCmd.Wait()
+			release()
+			audit(time.Since(start), outErr)
 			return nil, outErr
 		}
 
 		// Wait for command completion and check exit status
-		if err := execCmd.Wait(); err != nil {
+		err := execCmd.Wait()
+		release()
+		audit(time.Since(start), err)
+		if err != nil {
+			if cmdArgs[0] == "sudo" {
+				if sudoErr := generalCmd.SudoFailure(cmdArgs[1], stderrBuf.String()); sudoErr != nil {
+					return nil, sudoErr
+				}
+			}
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				return nil, errors.NewCommandError(
-					strings.Join(cmdArgs, " "),
+					commandString(cmdArgs),
 					exitErr.ExitCode(),
 					stderrBuf.String(),
 				)
 			}
 			return nil, errors.Wrap(err, errors.CommandExecution).
-				WithMetadata("command", strings.Join(cmdArgs, " ")).
+				WithMetadata("command", commandString(cmdArgs)).
 				WithMetadata("stderr", stderrBuf.String())
 		}
 
@@ -206,7 +260,7 @@ func (e *CommandExecutor) buildCommandArgs(
 	var cmdArgs []string
 
 	// Add sudo if required
-	if e.useSudo && SudoRequiredCommands[cmd] {
+	if e.useSudo && SudoRequiredCommands[cmd] && !opts.Unprivileged {
 		cmdArgs = append(cmdArgs, "sudo")
 	}
 
@@ -311,3 +365,21 @@ func (e *CommandExecutor) validateBuiltCommand(args []string) error {
 
 	return nil
 }
+
+// commandString joins a built command for logs and errors, with its secrets
+// hidden
+func commandString(cmdArgs []string) string {
+	return strings.Join(generalCmd.RedactArgs(cmdArgs), " ")
+}
+
+// contextError reports a command stopped by its context: a timeout for a
+// passed deadline, or a cancellation, such as a shutdown or a cancelled job
+func contextError(err error, cmdArgs []string) *errors.RodentError {
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return errors.New(errors.CommandTimeout, "command execution timed out").
+			WithMetadata("command", commandString(cmdArgs)).
+			WithMetadata("kill_grace", generalCmd.KillGrace().String())
+	}
+	return errors.New(errors.CommandContext, "command execution cancelled").
+		WithMetadata("command", commandString(cmdArgs))
+}
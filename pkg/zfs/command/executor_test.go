@@ -20,6 +20,7 @@ package command
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/pkg/errors"
@@ -161,3 +162,34 @@ func TestDeviceSecurity(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteStoppedContext(t *testing.T) {
+	executor := NewCommandExecutor(false, logger.Config{LogLevel: "debug"})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		wantCode errors.ErrorCode
+	}{
+		{"cancelled", cancelled, errors.CommandContext},
+		{"deadline_passed", expired, errors.CommandTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := executor.Execute(tt.ctx, CommandOptions{}, "zfs list", "-H")
+			re, ok := err.(*errors.RodentError)
+			if !ok {
+				t.Fatalf("expected RodentError, got %T (%v)", err, err)
+			}
+			if re.Code != tt.wantCode {
+				t.Errorf("Execute() error code = %d, want %d", re.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -18,7 +18,10 @@ import (
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
 	"github.com/stratastor/rodent/pkg/errors"
+	sshkeys "github.com/stratastor/rodent/pkg/keys/ssh"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 )
 
@@ -47,9 +50,56 @@ type TransferLogConfig struct {
 }
 
 type TransferConfig struct {
+	// Direction is push (the default), where this host sends to a remote
+	// receiver, or pull, where this host receives a stream it starts on the
+	// remote source with ssh. Pulling lets a backup host replicate from
+	// production hosts that hold no credentials to it.
+	Direction TransferDirection `json:"direction,omitempty" yaml:"direction,omitempty"`
+
 	SendConfig    SendConfig         `json:"send"                 binding:"required"`
 	ReceiveConfig ReceiveConfig      `json:"receive"              binding:"required"`
 	LogConfig     *TransferLogConfig `json:"log_config,omitempty"                    yaml:"log_config,omitempty"`
+
+	// ResourceLimits throttles the local side of the send/receive pipeline so
+	// replication does not starve latency-sensitive workloads on the host
+	ResourceLimits *ResourceLimits `json:"resource_limits,omitempty" yaml:"resource_limits,omitempty"`
+
+	// SkipSpaceCheck disables the target free-space preflight
+	SkipSpaceCheck bool `json:"skip_space_check,omitempty" yaml:"skip_space_check,omitempty"`
+}
+
+// TransferDirection says which side of a transfer starts it
+type TransferDirection string
+
+const (
+	TransferDirectionPush TransferDirection = "push"
+	TransferDirectionPull TransferDirection = "pull"
+)
+
+// IsPull reports whether the transfer pulls from a remote source
+func (c TransferConfig) IsPull() bool {
+	return c.Direction == TransferDirectionPull
+}
+
+// ResourceLimits defines the scheduling priority applied to the local zfs
+// send/receive processes. Nice and IOClass map to nice(1) and ionice(1);
+// SystemdScope launches the process in a transient systemd scope with the
+// given CPU and IO weights (cgroup v2).
+type ResourceLimits struct {
+	Nice       int    `json:"nice,omitempty"        yaml:"nice,omitempty"`        // -20 (highest) to 19 (lowest)
+	IOClass    string `json:"io_class,omitempty"    yaml:"io_class,omitempty"`    // idle, best-effort, realtime
+	IOPriority int    `json:"io_priority,omitempty" yaml:"io_priority,omitempty"` // 0 (highest) to 7 (lowest), best-effort/realtime only
+
+	SystemdScope bool `json:"systemd_scope,omitempty" yaml:"systemd_scope,omitempty"`
+	CPUWeight    int  `json:"cpu_weight,omitempty"    yaml:"cpu_weight,omitempty"` // 1-10000, default 100
+	IOWeight     int  `json:"io_weight,omitempty"     yaml:"io_weight,omitempty"`  // 1-10000, default 100
+}
+
+// ionice scheduling classes accepted by ResourceLimits.IOClass
+var ioniceClasses = map[string]string{
+	"realtime":    "1",
+	"best-effort": "2",
+	"idle":        "3",
 }
 
 type SendConfig struct {
@@ -86,6 +136,10 @@ type SendConfig struct {
 
 	// Logging
 	LogLevel string `json:"log_level"` // Log level for send operation, not related to zfs verbose output
+
+	// RemoteConfig is the source host of a pull transfer, where zfs send
+	// runs. Push transfers send from this host and leave it empty.
+	RemoteConfig RemoteConfig `json:"remote_host,omitempty"`
 }
 
 type ReceiveConfig struct {
@@ -100,16 +154,69 @@ type ReceiveConfig struct {
 	DryRun       bool              `json:"dry_run"`       // -n: Dry run
 	Verbose      bool              `json:"verbose"`       // -v: Print verbose info
 	RemoteConfig RemoteConfig      `json:"remote_host,omitempty"`
+
+	// AbortPartialOnFailure runs `zfs receive -A` on the target after a
+	// transfer fails so that leftover partial state does not block later
+	// full sends. Partial state is kept when the failure was a lost SSH
+	// connection on a resumable receive, since the token can still be resumed.
+	AbortPartialOnFailure bool `json:"abort_partial_on_failure,omitempty"`
 }
 
 // RemoteConfig defines SSH connection parameters
 type RemoteConfig struct {
-	Host             string `json:"host"`                          // Remote hostname/IP
+	Host             string `json:"host"`                          // Remote hostname/IP, IPv6 with or without brackets
 	Port             int    `json:"port"`                          // SSH port (default: 22)
 	User             string `json:"user"`                          // SSH user
 	PrivateKey       string `json:"private_key,omitempty"`         // Path to private key
 	SSHOptions       string `json:"options,omitempty"`             // Additional SSH options
 	SkipHostKeyCheck bool   `json:"skip_host_key_check,omitempty"` // Skip SSH host key check
+
+	// Source selection on multi-homed hosts: the local address (ssh -b) or
+	// interface (ssh -B) the connection leaves from
+	BindAddress   string `json:"bind_address,omitempty"`
+	BindInterface string `json:"bind_interface,omitempty"`
+
+	// Jump is a bastion the connection goes through, for targets that are
+	// only reachable from it
+	Jump *JumpHost `json:"jump,omitempty"`
+
+	// HostKeyPolicy checks remote host keys against Rodent's known_hosts:
+	// "strict", the default, accepts pinned keys only, "accept-new" also
+	// trusts and records the key of a host seen for the first time.
+	HostKeyPolicy string `json:"host_key_policy,omitempty"`
+}
+
+// JumpHost is an SSH bastion. The remote host's key policy applies to it too.
+type JumpHost struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port,omitempty"` // default: 22
+	User       string `json:"user"`
+	PrivateKey string `json:"private_key,omitempty"` // Path to private key
+}
+
+// Host key policies
+const (
+	HostKeyPolicyStrict    = "strict"
+	HostKeyPolicyAcceptNew = "accept-new"
+)
+
+// Redacted returns a copy of the configuration for API responses, without
+// the path of the SSH private key. Policy updates without a key keep the
+// saved one.
+func (c TransferConfig) Redacted() TransferConfig {
+	c.SendConfig.RemoteConfig = c.SendConfig.RemoteConfig.redacted()
+	c.ReceiveConfig.RemoteConfig = c.ReceiveConfig.RemoteConfig.redacted()
+	return c
+}
+
+func (r RemoteConfig) redacted() RemoteConfig {
+	r.PrivateKey = ""
+	if r.Jump != nil {
+		redactedJump := *r.Jump
+		redactedJump.PrivateKey = ""
+		r.Jump = &redactedJump
+	}
+	return r
 }
 
 // Allowed SSH options to prevent abuse
@@ -217,6 +324,7 @@ func (m *Manager) SendReceive(
 	if err := validateReceiveConfig(recvCfg); err != nil {
 		return err
 	}
+	adaptReceiveConfig(&recvCfg)
 	if recvCfg.RemoteConfig.Host != "" {
 		if err := validateSSHConfig(recvCfg.RemoteConfig); err != nil {
 			return err
@@ -346,8 +454,13 @@ func (m *Manager) SendReceive(
 	if err != nil {
 		return errors.Wrap(err, errors.RodentMisc)
 	}
+	l = common.WithRedaction(l)
 	l.Debug("Executing command",
-		"cmd", fullCmd)
+		"cmd", generalCmd.RedactCommandLine(fullCmd))
+
+	if generalCmd.SkipInDryRun(l, "bash", []string{"-c", fullCmd}) {
+		return nil
+	}
 
 	// Execute with retries
 	var lastErr error
@@ -380,7 +493,7 @@ func (m *Manager) SendReceive(
 				return errors.Wrap(err, errors.ZFSDatasetReceive).
 					WithMetadata("exit_code", fmt.Sprintf("%d", exitErr.ExitCode())).
 					WithMetadata("output", outputStr).
-					WithMetadata("command", fullCmd)
+					WithMetadata("command", generalCmd.RedactCommandLine(fullCmd))
 			}
 		}
 
@@ -395,11 +508,14 @@ func (m *Manager) SendReceive(
 
 	return errors.Wrap(lastErr, errors.ZFSDatasetReceive).
 		WithMetadata("output", "Max retries exceeded").
-		WithMetadata("command", fullCmd)
+		WithMetadata("command", generalCmd.RedactCommandLine(fullCmd))
 }
 
 func validateSendConfig(cfg SendConfig) error {
 	if cfg.ResumeToken != "" {
+		if err := command.RequireFeature(command.FeatureResumeTokens); err != nil {
+			return err
+		}
 		// Resume tokens are opaque but should be printable ASCII
 		if !utf8.ValidString(cfg.ResumeToken) {
 			return errors.New(errors.CommandInvalidInput, "Invalid resume token")
@@ -407,6 +523,12 @@ func validateSendConfig(cfg SendConfig) error {
 		return nil
 	}
 
+	if cfg.Raw {
+		if err := command.RequireFeature(command.FeatureRawSend); err != nil {
+			return err
+		}
+	}
+
 	// Validate snapshot name
 	if !snapshotNameRegex.MatchString(cfg.Snapshot) {
 		return errors.New(errors.CommandInvalidInput, "Invalid snapshot name")
@@ -433,9 +555,114 @@ func validateReceiveConfig(cfg ReceiveConfig) error {
 		}
 	}
 
+	// Remote targets run their own OpenZFS, which is not known here
+	if cfg.RemoteConfig.Host == "" {
+		if err := command.CheckProperties(cfg.Properties); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// validateDirection checks that the remote host sits on the side the
+// direction expects: the receiver for push, the source for pull
+func validateDirection(cfg TransferConfig) error {
+	switch cfg.Direction {
+	case "", TransferDirectionPush:
+		if cfg.SendConfig.RemoteConfig.Host != "" {
+			return errors.New(errors.CommandInvalidInput,
+				"Push transfers send from this host; set direction to pull to send from a remote host")
+		}
+	case TransferDirectionPull:
+		if cfg.SendConfig.RemoteConfig.Host == "" {
+			return errors.New(errors.CommandInvalidInput,
+				"Pull transfers need the source host in send.remote_host")
+		}
+		if cfg.ReceiveConfig.RemoteConfig.Host != "" {
+			return errors.New(errors.CommandInvalidInput,
+				"Pull transfers receive on this host; receive.remote_host must be empty")
+		}
+		return validateSSHConfig(cfg.SendConfig.RemoteConfig)
+	default:
+		return errors.New(errors.CommandInvalidInput, "Invalid transfer direction").
+			WithMetadata("direction", string(cfg.Direction))
+	}
+	return nil
+}
+
+// adaptReceiveConfig drops receive options a local target's OpenZFS lacks
+// and the transfer can do without, reporting whether it changed cfg
+func adaptReceiveConfig(cfg *ReceiveConfig) bool {
+	if cfg.RemoteConfig.Host == "" && cfg.Resumable &&
+		!command.Supports(command.FeatureResumeTokens) {
+		cfg.Resumable = false
+		return true
+	}
+	return false
+}
+
+// validateResourceLimits validates process scheduling limits for a transfer
+func validateResourceLimits(limits *ResourceLimits) error {
+	if limits == nil {
+		return nil
+	}
+	if limits.Nice < -20 || limits.Nice > 19 {
+		return errors.New(errors.CommandInvalidInput, "Nice value must be between -20 and 19")
+	}
+	if limits.IOClass != "" {
+		if _, ok := ioniceClasses[limits.IOClass]; !ok {
+			return errors.New(errors.CommandInvalidInput, "Invalid IO scheduling class").
+				WithMetadata("io_class", limits.IOClass)
+		}
+	}
+	if limits.IOPriority < 0 || limits.IOPriority > 7 {
+		return errors.New(errors.CommandInvalidInput, "IO priority must be between 0 and 7")
+	}
+	if limits.CPUWeight < 0 || limits.CPUWeight > 10000 {
+		return errors.New(errors.CommandInvalidInput, "CPU weight must be between 1 and 10000")
+	}
+	if limits.IOWeight < 0 || limits.IOWeight > 10000 {
+		return errors.New(errors.CommandInvalidInput, "IO weight must be between 1 and 10000")
+	}
+	if !limits.SystemdScope && (limits.CPUWeight != 0 || limits.IOWeight != 0) {
+		return errors.New(errors.CommandInvalidInput,
+			"CPU and IO weights require systemd_scope to be enabled")
+	}
+	return nil
+}
+
+// buildResourceLimitPrefix returns the command prefix that launches a process
+// under the given resource limits. The prefix is meant to run under sudo,
+// directly in front of the zfs binary.
+func buildResourceLimitPrefix(limits *ResourceLimits) []string {
+	if limits == nil {
+		return nil
+	}
+
+	var prefix []string
+	if limits.SystemdScope {
+		prefix = append(prefix, "systemd-run", "--scope", "--quiet", "--collect")
+		if limits.CPUWeight > 0 {
+			prefix = append(prefix, "-p", fmt.Sprintf("CPUWeight=%d", limits.CPUWeight))
+		}
+		if limits.IOWeight > 0 {
+			prefix = append(prefix, "-p", fmt.Sprintf("IOWeight=%d", limits.IOWeight))
+		}
+	}
+	if limits.Nice != 0 {
+		prefix = append(prefix, "nice", "-n", fmt.Sprintf("%d", limits.Nice))
+	}
+	if limits.IOClass != "" {
+		prefix = append(prefix, "ionice", "-c", ioniceClasses[limits.IOClass])
+		if limits.IOClass != "idle" {
+			prefix = append(prefix, "-n", fmt.Sprintf("%d", limits.IOPriority))
+		}
+	}
+
+	return prefix
+}
+
 // ValidateSSHConfig validates SSH connection parameters
 func validateSSHConfig(cfg RemoteConfig) error {
 	if cfg.Host == "" {
@@ -447,11 +674,178 @@ func validateSSHConfig(cfg RemoteConfig) error {
 	if cfg.Port < 0 || cfg.Port > 65535 {
 		return errors.New(errors.CommandInvalidInput, "Invalid SSH port")
 	}
+	return validateSSHRemote(cfg)
+}
+
+// validateSSHRemote validates the host as an IPv6 literal when it looks like
+// one, the source address and interface to bind, the host key policy and the
+// jump host
+func validateSSHRemote(cfg RemoteConfig) error {
+	if host := common.UnbracketHost(cfg.Host); strings.Contains(host, ":") {
+		if _, err := common.HostIP(host); err != nil {
+			return errors.New(errors.CommandInvalidInput, "Invalid SSH host").
+				WithMetadata("host", cfg.Host)
+		}
+	}
+	if cfg.BindAddress != "" {
+		if _, err := common.HostIP(cfg.BindAddress); err != nil {
+			return errors.New(errors.CommandInvalidInput, "Invalid SSH bind address").
+				WithMetadata("bind_address", cfg.BindAddress)
+		}
+	}
+	if cfg.BindInterface != "" && !common.ValidInterfaceName(cfg.BindInterface) {
+		return errors.New(errors.CommandInvalidInput, "Invalid SSH bind interface").
+			WithMetadata("bind_interface", cfg.BindInterface)
+	}
+
+	switch cfg.HostKeyPolicy {
+	case "", HostKeyPolicyStrict, HostKeyPolicyAcceptNew:
+	default:
+		return errors.New(errors.CommandInvalidInput, "Invalid SSH host key policy").
+			WithMetadata("host_key_policy", cfg.HostKeyPolicy)
+	}
+	if cfg.HostKeyPolicy != "" && cfg.SkipHostKeyCheck {
+		return errors.New(errors.CommandInvalidInput,
+			"SSH host key policy cannot be combined with skipping the host key check")
+	}
+	if sshkeys.CurrentSecurityPolicy().RequirePinnedHostKeys &&
+		(cfg.SkipHostKeyCheck || cfg.HostKeyPolicy == HostKeyPolicyAcceptNew) {
+		return errors.New(errors.SSHKeyPolicyViolation,
+			"The SSH security policy requires pinned host keys").
+			WithMetadata("host_key_policy", cfg.HostKeyPolicy)
+	}
+
+	if jump := cfg.Jump; jump != nil {
+		// ssh expands % tokens in the proxy command
+		if jump.Host == "" || jump.User == "" ||
+			strings.ContainsAny(jump.Host+jump.User+jump.PrivateKey, "&|;<>()$`\\\"' %") {
+			return errors.New(errors.CommandInvalidInput, "Invalid SSH jump host").
+				WithMetadata("host", jump.Host)
+		}
+		if jump.Port < 0 || jump.Port > 65535 {
+			return errors.New(errors.CommandInvalidInput, "Invalid SSH jump host port")
+		}
+		if host := common.UnbracketHost(jump.Host); strings.Contains(host, ":") {
+			if _, err := common.HostIP(host); err != nil {
+				return errors.New(errors.CommandInvalidInput, "Invalid SSH jump host").
+					WithMetadata("host", jump.Host)
+			}
+		}
+	}
 	return nil
 }
 
+// checkPinnedHostKeys refuses a remote host, or its jump host, without a
+// host key pinned for a peering when the SSH security policy requires it
+func checkPinnedHostKeys(cfg RemoteConfig) error {
+	if !sshkeys.CurrentSecurityPolicy().RequirePinnedHostKeys {
+		return nil
+	}
+	hosts := []string{sshkeys.KnownHostName(cfg.Host, cfg.Port)}
+	if cfg.Jump != nil {
+		hosts = append(hosts, sshkeys.KnownHostName(cfg.Jump.Host, cfg.Jump.Port))
+	}
+	for _, host := range hosts {
+		pinned, err := sshkeys.HostKeyPinned(host)
+		if err != nil {
+			return err
+		}
+		if !pinned {
+			return errors.New(errors.SSHHostKeyNotPinned,
+				"Pin the host key for the peering before transferring to this host").
+				WithMetadata("host", host)
+		}
+	}
+	return nil
+}
+
+// hostKeyOptions returns the ssh options checking host keys, shared by the
+// connection to the remote host and to its jump host. Keys are checked
+// against Rodent's known_hosts only, never ~/.ssh/known_hosts or the system
+// one, and strictly unless the policy is accept-new.
+func hostKeyOptions(cfg RemoteConfig) []string {
+	if cfg.SkipHostKeyCheck {
+		return []string{"-o", "StrictHostKeyChecking=no"}
+	}
+	opts := []string{
+		"-o", fmt.Sprintf("UserKnownHostsFile=%s", config.GetKnownHostsFilePath()),
+		"-o", "GlobalKnownHostsFile=/dev/null",
+	}
+	if cfg.HostKeyPolicy == HostKeyPolicyAcceptNew {
+		// Learned keys are written in plain form so they can be listed and
+		// pinned through the SSH key API
+		return append(opts, "-o", "StrictHostKeyChecking=accept-new", "-o", "HashKnownHosts=no")
+	}
+	return append(opts, "-o", "StrictHostKeyChecking=yes")
+}
+
+// hostKeyFailure returns a message for an ssh failure to verify a host key
+// found in a command's output, or "" when the output shows none. ssh runs in
+// batch mode, so an unknown or changed key fails the command instead of
+// prompting.
+func hostKeyFailure(output string) string {
+	switch {
+	case strings.Contains(output, "REMOTE HOST IDENTIFICATION HAS CHANGED"):
+		return "the remote host key does not match the key pinned in Rodent's known_hosts; " +
+			"if the host key was rotated, pin the new one with 'rodent peer trust'"
+	case strings.Contains(output, "Host key verification failed"):
+		return "the remote host key is not pinned in Rodent's known_hosts; " +
+			"pin it with 'rodent peer trust' or the SSH key API"
+	}
+	return ""
+}
+
+// jumpProxyCommand returns the ssh ProxyCommand connecting to the remote host
+// through its jump host. ProxyJump cannot take a key for the jump host, so
+// the jump is made with ssh -W. The source binding applies to this hop.
+func jumpProxyCommand(cfg RemoteConfig) string {
+	jump := cfg.Jump
+	proxy := []string{"ssh"}
+	if jump.Port != 0 && jump.Port != 22 {
+		proxy = append(proxy, "-p", fmt.Sprintf("%d", jump.Port))
+	}
+	if cfg.BindAddress != "" {
+		bindIP, _ := common.HostIP(cfg.BindAddress)
+		proxy = append(proxy, "-b", bindIP.String())
+	}
+	if cfg.BindInterface != "" {
+		proxy = append(proxy, "-B", cfg.BindInterface)
+	}
+	if jump.PrivateKey != "" {
+		proxy = append(proxy, "-i", jump.PrivateKey)
+	}
+	proxy = append(proxy, hostKeyOptions(cfg)...)
+
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	proxy = append(proxy, noPasswordOptions...)
+	proxy = append(proxy,
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		"-W", common.JoinHostPort(cfg.Host, port),
+		fmt.Sprintf("%s@%s", jump.User, common.UnbracketHost(jump.Host)),
+	)
+	return shellquote.Join(proxy...)
+}
+
+// noPasswordOptions refuse falling back to password or keyboard-interactive
+// logins; transfers authenticate with keys only. ssh keeps the first value
+// given for an option, so custom options cannot turn them back on.
+var noPasswordOptions = []string{
+	"-o", "PasswordAuthentication=no",
+	"-o", "KbdInteractiveAuthentication=no",
+}
+
 // BuildSSHCommand constructs SSH command with proper options
 func BuildSSHCommand(cfg RemoteConfig) ([]string, error) {
+	if err := validateSSHRemote(cfg); err != nil {
+		return nil, err
+	}
+	if err := checkPinnedHostKeys(cfg); err != nil {
+		return nil, err
+	}
 	sshCmd := []string{"ssh"}
 
 	// Core SSH options
@@ -465,19 +859,22 @@ func BuildSSHCommand(cfg RemoteConfig) ([]string, error) {
 				"Invalid private key path")
 		}
 		sshCmd = append(sshCmd, "-i", cfg.PrivateKey)
-
-		// Use Rodent-managed known_hosts file (respects config overrides)
-		sshCmd = append(
-			sshCmd,
-			"-o",
-			fmt.Sprintf("UserKnownHostsFile=%s", config.GetKnownHostsFilePath()),
-		)
+	}
+	if cfg.Jump != nil {
+		sshCmd = append(sshCmd, "-o", "ProxyCommand="+jumpProxyCommand(cfg))
+	} else {
+		if cfg.BindAddress != "" {
+			bindIP, _ := common.HostIP(cfg.BindAddress)
+			sshCmd = append(sshCmd, "-b", bindIP.String())
+		}
+		if cfg.BindInterface != "" {
+			sshCmd = append(sshCmd, "-B", cfg.BindInterface)
+		}
 	}
 
 	// Security options
-	if cfg.SkipHostKeyCheck {
-		sshCmd = append(sshCmd, "-o", "StrictHostKeyChecking=no")
-	}
+	sshCmd = append(sshCmd, hostKeyOptions(cfg)...)
+	sshCmd = append(sshCmd, noPasswordOptions...)
 
 	// Connection options
 	sshCmd = append(sshCmd,
@@ -503,7 +900,8 @@ func BuildSSHCommand(cfg RemoteConfig) ([]string, error) {
 	if strings.ContainsAny(cfg.Host, "&|;<>()$`\\\"'") {
 		return nil, errors.New(errors.CommandInvalidInput, "Invalid SSH host")
 	}
-	sshCmd = append(sshCmd, fmt.Sprintf("%s@%s", cfg.User, cfg.Host))
+	// ssh takes IPv6 destinations without brackets
+	sshCmd = append(sshCmd, fmt.Sprintf("%s@%s", cfg.User, common.UnbracketHost(cfg.Host)))
 
 	return sshCmd, nil
 }
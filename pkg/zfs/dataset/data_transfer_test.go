@@ -18,12 +18,16 @@
 package dataset
 
 import (
+	"bytes"
 	"context"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/pkg/errors"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 	"github.com/stratastor/rodent/pkg/zfs/pool"
@@ -430,3 +434,346 @@ func TestDataTransferOperations(t *testing.T) {
 	srcPoolDestroyed = true
 	dstPoolDestroyed = true
 }
+
+func TestResourceLimits(t *testing.T) {
+	t.Run("Validate", func(t *testing.T) {
+		valid := []*ResourceLimits{
+			nil,
+			{Nice: 10},
+			{IOClass: "idle"},
+			{Nice: 19, IOClass: "best-effort", IOPriority: 7},
+			{SystemdScope: true, CPUWeight: 50, IOWeight: 25},
+		}
+		for _, limits := range valid {
+			if err := validateResourceLimits(limits); err != nil {
+				t.Errorf("expected %+v to be valid, got %v", limits, err)
+			}
+		}
+
+		invalid := []*ResourceLimits{
+			{Nice: 20},
+			{Nice: -21},
+			{IOClass: "fastest"},
+			{IOClass: "best-effort", IOPriority: 8},
+			{CPUWeight: 100},
+			{SystemdScope: true, IOWeight: 10001},
+		}
+		for _, limits := range invalid {
+			if err := validateResourceLimits(limits); err == nil {
+				t.Errorf("expected %+v to be rejected", limits)
+			}
+		}
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		if prefix := buildResourceLimitPrefix(nil); len(prefix) != 0 {
+			t.Errorf("expected empty prefix for nil limits, got %v", prefix)
+		}
+
+		prefix := strings.Join(buildResourceLimitPrefix(&ResourceLimits{
+			SystemdScope: true,
+			CPUWeight:    50,
+			IOWeight:     20,
+			Nice:         10,
+			IOClass:      "idle",
+		}), " ")
+		expected := "systemd-run --scope --quiet --collect -p CPUWeight=50 -p IOWeight=20 nice -n 10 ionice -c 3"
+		if prefix != expected {
+			t.Errorf("unexpected prefix:\n got: %s\nwant: %s", prefix, expected)
+		}
+
+		prefix = strings.Join(buildResourceLimitPrefix(&ResourceLimits{
+			IOClass:    "best-effort",
+			IOPriority: 6,
+		}), " ")
+		if prefix != "ionice -c 2 -n 6" {
+			t.Errorf("unexpected prefix: %s", prefix)
+		}
+	})
+}
+
+func TestBuildSSHCommand(t *testing.T) {
+	t.Run("IPv6", func(t *testing.T) {
+		for _, host := range []string{"2001:db8::10", "[2001:db8::10]"} {
+			cmd, err := BuildSSHCommand(RemoteConfig{Host: host, User: "backup", Port: 2222})
+			if err != nil {
+				t.Fatalf("unexpected error for %s: %v", host, err)
+			}
+			if dest := cmd[len(cmd)-1]; dest != "backup@2001:db8::10" {
+				t.Errorf("expected unbracketed destination for %s, got %s", host, dest)
+			}
+		}
+
+		if _, err := BuildSSHCommand(RemoteConfig{Host: "2001:db8::zz", User: "backup"}); err == nil {
+			t.Error("expected an invalid IPv6 literal to be rejected")
+		}
+	})
+
+	t.Run("NoPasswordFallback", func(t *testing.T) {
+		cmd, err := BuildSSHCommand(RemoteConfig{
+			Host:       "nas.example",
+			User:       "backup",
+			SSHOptions: "-oPreferredAuthentications=password",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		line := strings.Join(cmd, " ")
+		password := strings.Index(line, "PasswordAuthentication=no")
+		if password < 0 || !strings.Contains(line, "KbdInteractiveAuthentication=no") {
+			t.Fatalf("expected password logins to be refused, got %s", line)
+		}
+		// ssh keeps the first value given for an option
+		if custom := strings.Index(line, "PreferredAuthentications"); custom < password {
+			t.Errorf("expected custom options after the password refusal, got %s", line)
+		}
+	})
+
+	t.Run("Bind", func(t *testing.T) {
+		cmd, err := BuildSSHCommand(RemoteConfig{
+			Host:          "nas.example",
+			User:          "backup",
+			BindAddress:   "[2001:db8::2]",
+			BindInterface: "bond0.20",
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		line := strings.Join(cmd, " ")
+		if !strings.Contains(line, "-b 2001:db8::2") || !strings.Contains(line, "-B bond0.20") {
+			t.Errorf("expected bind options, got %s", line)
+		}
+
+		invalid := []RemoteConfig{
+			{Host: "nas.example", User: "backup", BindAddress: "nas.example"},
+			{Host: "nas.example", User: "backup", BindInterface: "eth0;reboot"},
+		}
+		for _, cfg := range invalid {
+			if _, err := BuildSSHCommand(cfg); err == nil {
+				t.Errorf("expected %+v to be rejected", cfg)
+			}
+		}
+	})
+}
+
+func TestHostKeyOptions(t *testing.T) {
+	tests := []struct {
+		cfg  RemoteConfig
+		want string
+	}{
+		{RemoteConfig{}, "StrictHostKeyChecking=yes"},
+		{RemoteConfig{HostKeyPolicy: HostKeyPolicyStrict}, "StrictHostKeyChecking=yes"},
+		{RemoteConfig{HostKeyPolicy: HostKeyPolicyAcceptNew}, "StrictHostKeyChecking=accept-new"},
+	}
+	for _, tt := range tests {
+		line := strings.Join(hostKeyOptions(tt.cfg), " ")
+		if !strings.Contains(line, tt.want) ||
+			!strings.Contains(line, "UserKnownHostsFile="+config.GetKnownHostsFilePath()) ||
+			!strings.Contains(line, "GlobalKnownHostsFile=/dev/null") {
+			t.Errorf("hostKeyOptions(%q) = %s, want %s against Rodent's known_hosts only",
+				tt.cfg.HostKeyPolicy, line, tt.want)
+		}
+	}
+
+	if line := strings.Join(hostKeyOptions(RemoteConfig{SkipHostKeyCheck: true}), " "); line != "-o StrictHostKeyChecking=no" {
+		t.Errorf("expected skipping the check to disable it, got %s", line)
+	}
+}
+
+func TestHostKeyFailure(t *testing.T) {
+	changed := "@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@@\n" +
+		"@    WARNING: REMOTE HOST IDENTIFICATION HAS CHANGED!     @\n" +
+		"Host key verification failed.\n"
+	if msg := hostKeyFailure(changed); !strings.Contains(msg, "does not match") {
+		t.Errorf("expected a changed key to be reported, got %q", msg)
+	}
+	unknown := "No ED25519 host key is known for nas.example and you have requested strict checking.\n" +
+		"Host key verification failed.\n"
+	if msg := hostKeyFailure(unknown); !strings.Contains(msg, "not pinned") {
+		t.Errorf("expected an unknown key to be reported, got %q", msg)
+	}
+	if msg := hostKeyFailure("cannot receive: dataset is busy"); msg != "" {
+		t.Errorf("expected no host key failure, got %q", msg)
+	}
+}
+
+func TestBuildSSHCommandJumpHost(t *testing.T) {
+	cmd, err := BuildSSHCommand(RemoteConfig{
+		Host:          "10.20.0.5",
+		User:          "backup",
+		PrivateKey:    "/home/rodent/.rodent/ssh/peer1/id_ed25519",
+		BindInterface: "eth1",
+		HostKeyPolicy: HostKeyPolicyStrict,
+		Jump: &JumpHost{
+			Host:       "bastion.example",
+			Port:       2222,
+			User:       "jump",
+			PrivateKey: "/home/rodent/.rodent/ssh/bastion/id_ed25519",
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var proxy string
+	for i, arg := range cmd {
+		if strings.HasPrefix(arg, "ProxyCommand=") && cmd[i-1] == "-o" {
+			proxy = strings.TrimPrefix(arg, "ProxyCommand=")
+		}
+	}
+	for _, want := range []string{
+		"ssh -p 2222 -B eth1 -i /home/rodent/.rodent/ssh/bastion/id_ed25519",
+		"StrictHostKeyChecking=yes",
+		"-W 10.20.0.5:22 jump@bastion.example",
+	} {
+		if !strings.Contains(proxy, want) {
+			t.Errorf("expected proxy command to contain %q, got %q", want, proxy)
+		}
+	}
+
+	line := strings.Join(cmd, " ")
+	if strings.Contains(strings.ReplaceAll(line, proxy, ""), "-B eth1") {
+		t.Errorf("expected the source binding on the jump only, got %s", line)
+	}
+	if !strings.Contains(line, "StrictHostKeyChecking=yes") || cmd[len(cmd)-1] != "backup@10.20.0.5" {
+		t.Errorf("unexpected command %s", line)
+	}
+
+	invalid := []RemoteConfig{
+		{Host: "nas", User: "backup", HostKeyPolicy: "ask"},
+		{Host: "nas", User: "backup", HostKeyPolicy: HostKeyPolicyAcceptNew, SkipHostKeyCheck: true},
+		{Host: "nas", User: "backup", Jump: &JumpHost{Host: "bastion", User: "jump", PrivateKey: "/keys/%h"}},
+		{Host: "nas", User: "backup", Jump: &JumpHost{Host: "bastion"}},
+	}
+	for _, cfg := range invalid {
+		if _, err := BuildSSHCommand(cfg); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestTransferDirection(t *testing.T) {
+	source := RemoteConfig{Host: "prod.example", User: "backup"}
+
+	t.Run("Validate", func(t *testing.T) {
+		valid := []TransferConfig{
+			{},
+			{Direction: TransferDirectionPush, ReceiveConfig: ReceiveConfig{RemoteConfig: source}},
+			{Direction: TransferDirectionPull, SendConfig: SendConfig{RemoteConfig: source}},
+		}
+		for _, cfg := range valid {
+			if err := validateDirection(cfg); err != nil {
+				t.Errorf("expected %+v to be valid, got %v", cfg, err)
+			}
+		}
+
+		invalid := []TransferConfig{
+			{Direction: "sideways"},
+			{SendConfig: SendConfig{RemoteConfig: source}},
+			{Direction: TransferDirectionPull},
+			{
+				Direction:     TransferDirectionPull,
+				SendConfig:    SendConfig{RemoteConfig: source},
+				ReceiveConfig: ReceiveConfig{RemoteConfig: source},
+			},
+			{Direction: TransferDirectionPull, SendConfig: SendConfig{RemoteConfig: RemoteConfig{Host: "prod.example"}}},
+		}
+		for _, cfg := range invalid {
+			if err := validateDirection(cfg); err == nil {
+				t.Errorf("expected %+v to be rejected", cfg)
+			}
+		}
+	})
+
+	t.Run("Command", func(t *testing.T) {
+		l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test")
+		if err != nil {
+			t.Fatalf("failed to create logger: %v", err)
+		}
+		tm := &TransferManager{logger: l}
+
+		cmd, err := tm.buildTransferCommand(&TransferInfo{Config: TransferConfig{
+			Direction:      TransferDirectionPull,
+			SendConfig:     SendConfig{Snapshot: "tank/db@daily", RemoteConfig: source},
+			ReceiveConfig:  ReceiveConfig{Target: "backup/db", Resumable: true},
+			ResourceLimits: &ResourceLimits{Nice: 10},
+		}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		line := cmd.Args[len(cmd.Args)-1]
+		send, recv, ok := strings.Cut(line, " | ")
+		if !ok {
+			t.Fatalf("expected a pipeline, got %s", line)
+		}
+		if !strings.HasPrefix(send, "ssh ") || !strings.HasSuffix(send, "backup@prod.example sudo zfs send -V tank/db@daily") {
+			t.Errorf("expected the send to run on the source, got %s", send)
+		}
+		if recv != "sudo nice -n 10 zfs receive -s backup/db" {
+			t.Errorf("expected a limited local receive, got %s", recv)
+		}
+	})
+}
+
+func TestSeedChunks(t *testing.T) {
+	stream := []byte("0123456789")
+	dir := t.TempDir()
+	manifest := &SeedManifest{Export: SeedExportConfig{ChunkSize: 4}}
+
+	if err := writeSeedChunks(bytes.NewReader(stream), dir, manifest); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(manifest.Chunks) != 3 || manifest.TotalBytes != 10 {
+		t.Fatalf("expected 3 chunks of 10 bytes, got %+v", manifest)
+	}
+
+	readSeed := func(m *SeedManifest) ([]byte, error) {
+		return io.ReadAll(newSeedReader(dir, m))
+	}
+	if data, err := readSeed(manifest); err != nil || !bytes.Equal(data, stream) {
+		t.Fatalf("expected the stream back, got %q, %v", data, err)
+	}
+
+	// An interrupted export continues after the chunks it wrote
+	saved, err := readSeedManifest(dir)
+	if err != nil || saved == nil {
+		t.Fatalf("expected a saved manifest, got %v", err)
+	}
+	saved.Chunks = saved.Chunks[:1]
+	saved.TotalBytes = saved.Chunks[0].Size
+	if err := writeSeedChunks(bytes.NewReader(stream), dir, saved); err != nil {
+		t.Fatalf("unexpected error continuing export: %v", err)
+	}
+	if len(saved.Chunks) != 3 || saved.TotalBytes != 10 {
+		t.Errorf("expected the export to be completed, got %+v", saved)
+	}
+
+	saved.Chunks = saved.Chunks[:1]
+	if err := writeSeedChunks(bytes.NewReader([]byte("abcdefghij")), dir, saved); err == nil {
+		t.Error("expected a different stream to be rejected")
+	}
+
+	// Damaged chunks stop the import
+	if err := os.WriteFile(filepath.Join(dir, manifest.Chunks[1].Name), []byte("4567"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSeed(manifest); err != nil {
+		t.Fatalf("unexpected error for an intact chunk: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifest.Chunks[1].Name), []byte("4568"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSeed(manifest); err == nil {
+		t.Error("expected a damaged chunk to be rejected")
+	}
+
+	// A stream of whole chunks leaves no empty chunk
+	exact := &SeedManifest{Export: SeedExportConfig{ChunkSize: 5}}
+	if err := writeSeedChunks(bytes.NewReader(stream), t.TempDir(), exact); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(exact.Chunks) != 2 {
+		t.Errorf("expected 2 chunks, got %d", len(exact.Chunks))
+	}
+}
@@ -33,11 +33,17 @@ import (
 
 // Manager handles ZFS dataset operations
 type Manager struct {
-	executor *command.CommandExecutor
+	executor   *command.CommandExecutor
+	delegation *Delegation
 }
 
 func NewManager(executor *command.CommandExecutor) *Manager {
-	return &Manager{executor: executor}
+	return &Manager{executor: executor, delegation: NewDelegation(executor)}
+}
+
+// Delegation returns the zfs allow delegation of the service user
+func (m *Manager) Delegation() *Delegation {
+	return m.delegation
 }
 
 // List returns a list of datasets
@@ -155,6 +161,16 @@ func (m *Manager) Destroy(ctx context.Context, dc DestroyConfig) (DestroyResult,
 		if line == "" {
 			continue
 		}
+		// Parsable output reports reclaimable space as "reclaim\t<bytes>"
+		if strings.HasPrefix(line, "reclaim") {
+			parts := strings.Fields(line)
+			if len(parts) == 2 {
+				if bytes, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+					result.ReclaimBytes = bytes
+				}
+			}
+			continue
+		}
 		// Extract dataset name from "destroy dataset" line
 		// Using Contains() instead of HasPrefix() and handling multiple spaces
 		if strings.Contains(line, "destroy") {
@@ -267,6 +283,10 @@ func (m *Manager) InheritProperty(ctx context.Context, cfg InheritConfig) error
 func (m *Manager) SetProperty(ctx context.Context, cfg SetPropertyConfig) error {
 	// TODO: Accommmodate multiple property values
 	// TODO: Accommodate multiple dataset names
+	if err := command.CheckProperty(cfg.Property, cfg.Value); err != nil {
+		return err
+	}
+
 	args := []string{
 		"set",
 		fmt.Sprintf("%s=%s", cfg.Property, shellquote.Join(cfg.Value)),
@@ -322,6 +342,10 @@ func (m *Manager) CreateFilesystem(
 	ctx context.Context,
 	cfg FilesystemConfig,
 ) (CreateResult, error) {
+	if err := command.CheckProperties(cfg.Properties); err != nil {
+		return CreateResult{}, err
+	}
+
 	args := []string{"create", "-P", "-v"}
 
 	if cfg.Parents {
@@ -366,6 +390,10 @@ func (m *Manager) CreateFilesystem(
 
 // CreateVolume creates a new ZFS volume
 func (m *Manager) CreateVolume(ctx context.Context, cfg VolumeConfig) (CreateResult, error) {
+	if err := command.CheckProperties(cfg.Properties); err != nil {
+		return CreateResult{}, err
+	}
+
 	args := []string{"create", "-v"}
 
 	if cfg.Size != "" {
@@ -438,7 +466,9 @@ func (m *Manager) CreateSnapshot(ctx context.Context, cfg SnapshotConfig) error
 	snapStr := fmt.Sprintf("%s@%s", cfg.Name, cfg.SnapName)
 	args = append(args, snapStr)
 
-	opts := command.CommandOptions{}
+	opts := command.CommandOptions{
+		Unprivileged: m.unprivileged(ctx, cfg.Name, "snapshot", cfg.Recursive),
+	}
 	out, err := m.executor.Execute(ctx, opts, "zfs snapshot", args...)
 	if err != nil {
 		if len(out) > 0 {
@@ -481,6 +511,10 @@ func (m *Manager) Exists(ctx context.Context, name string) (bool, error) {
 
 // Clone creates a clone from a snapshot
 func (m *Manager) Clone(ctx context.Context, cfg CloneConfig) error {
+	if err := command.CheckProperties(cfg.Properties); err != nil {
+		return err
+	}
+
 	args := []string{"clone"}
 
 	if cfg.Parents {
@@ -557,6 +591,17 @@ func (m *Manager) Rename(ctx context.Context, cfg RenameConfig) error {
 	return nil
 }
 
+// RenamedName returns the name a dataset, or a descendant, snapshot or
+// bookmark of it, has once oldName is renamed to newName. Other names are
+// returned as they are, with false.
+func RenamedName(name, oldName, newName string) (string, bool) {
+	rest, ok := strings.CutPrefix(name, oldName)
+	if !ok || (rest != "" && !strings.ContainsRune("/@#", rune(rest[0]))) {
+		return name, false
+	}
+	return newName + rest, true
+}
+
 // Rollback rolls back a dataset to a snapshot
 func (m *Manager) Rollback(ctx context.Context, cfg RollbackConfig) error {
 	args := []string{"rollback"}
@@ -797,6 +842,7 @@ func (m *Manager) Allow(ctx context.Context, cfg AllowConfig) error {
 		}
 		return errors.Wrap(err, errors.ZFSDatasetOperation)
 	}
+	m.delegation.Invalidate()
 
 	// TODO: Check with upstream OpenZFS
 	// A workaround for ZFS bug where it returns success even when it fails
@@ -884,6 +930,7 @@ func (m *Manager) Unallow(ctx context.Context, cfg UnallowConfig) error {
 		}
 		return errors.Wrap(err, errors.ZFSDatasetOperation)
 	}
+	m.delegation.Invalidate()
 	return nil
 }
 
@@ -0,0 +1,349 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os/user"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// DelegatedOperations are the operations the service user runs without sudo
+// once zfs allow grants it the listed permissions on the dataset
+var DelegatedOperations = map[string][]string{
+	"snapshot": {"snapshot", "mount"},
+	"send":     {"send"},
+	"hold":     {"hold"},
+	"release":  {"release"},
+}
+
+// delegationTTL is how long permissions read from zfs allow are trusted, so
+// that grants and revocations made outside rodent are picked up
+const delegationTTL = time.Minute
+
+// DelegationConfig delegates operations on a dataset to the service user
+type DelegationConfig struct {
+	NameConfig
+	Operations []string `json:"operations" binding:"required"` // Keys of DelegatedOperations
+	LocalOnly  bool     `json:"local_only,omitempty"`          // Exclude descendant datasets
+}
+
+// DelegationStatus lists which operations on a dataset the service user can
+// run without sudo, and the permissions the others lack
+type DelegationStatus struct {
+	Name       string              `json:"name"`
+	User       string              `json:"user"`
+	Privileged bool                `json:"privileged"` // The service runs as root and needs no delegation
+	Operations map[string]bool     `json:"operations"`
+	Missing    map[string][]string `json:"missing,omitempty"`
+}
+
+// Delegation resolves the zfs allow permissions of the service user, so that
+// delegated operations can skip sudo
+type Delegation struct {
+	executor *command.CommandExecutor
+	user     string
+	groups   []string
+	root     bool
+
+	mu    sync.Mutex
+	cache map[string]delegationEntry
+}
+
+type delegationEntry struct {
+	permissions map[string]bool
+	readAt      time.Time
+}
+
+// NewDelegation returns the delegation of the user the service runs as
+func NewDelegation(executor *command.CommandExecutor) *Delegation {
+	d := &Delegation{executor: executor, cache: make(map[string]delegationEntry)}
+	u, err := user.Current()
+	if err != nil {
+		// Without a user nothing is delegated and sudo is always used
+		return d
+	}
+	d.user, d.root = u.Username, u.Uid == "0"
+	if gids, err := u.GroupIds(); err == nil {
+		for _, gid := range gids {
+			if g, err := user.LookupGroupId(gid); err == nil {
+				d.groups = append(d.groups, g.Name)
+			}
+		}
+	}
+	return d
+}
+
+// User returns the name of the service user
+func (d *Delegation) User() string {
+	return d.user
+}
+
+// Covers reports whether the service user may run op on the dataset, or the
+// dataset of a snapshot, without sudo. Errors reading permissions count as
+// not delegated, so the operation falls back to sudo.
+func (d *Delegation) Covers(ctx context.Context, name, op string) bool {
+	if d == nil || d.root || d.user == "" {
+		return false
+	}
+	required, ok := DelegatedOperations[op]
+	if !ok {
+		return false
+	}
+	permissions, err := d.permissions(ctx, datasetOf(name))
+	if err != nil {
+		return false
+	}
+	for _, p := range required {
+		if !permissions[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// Invalidate forgets the permissions read so far
+func (d *Delegation) Invalidate() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	clear(d.cache)
+}
+
+// permissions returns the permissions of the service user on a dataset,
+// reading them from zfs allow once per delegationTTL
+func (d *Delegation) permissions(ctx context.Context, name string) (map[string]bool, error) {
+	d.mu.Lock()
+	entry, ok := d.cache[name]
+	d.mu.Unlock()
+	if ok && time.Since(entry.readAt) < delegationTTL {
+		return entry.permissions, nil
+	}
+
+	// Reading permissions needs no privileges
+	out, err := d.executor.Execute(ctx, command.CommandOptions{Unprivileged: true}, "zfs allow", "allow", name)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSPermissionError).WithMetadata("dataset", name)
+	}
+	permissions := effectivePermissions(string(out), name, d.user, d.groups)
+
+	d.mu.Lock()
+	d.cache[name] = delegationEntry{permissions: permissions, readAt: time.Now()}
+	d.mu.Unlock()
+	return permissions, nil
+}
+
+// effectivePermissions returns the permissions zfs allow output grants a user
+// on a dataset: local grants on the dataset itself, descendent grants on its
+// ancestors and local+descendent grants on both, to the user, its groups or
+// everyone, with permission sets expanded
+func effectivePermissions(output, name, username string, groups []string) map[string]bool {
+	sets := make(map[string][]string)
+	var granted []string
+
+	var on, section string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "---- Permissions on "):
+			on = strings.Fields(strings.TrimPrefix(line, "---- Permissions on "))[0]
+			section = ""
+			continue
+		case strings.HasSuffix(line, ":"):
+			section = strings.TrimSuffix(line, ":")
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if section == "Permission sets" {
+			if len(fields) == 2 {
+				sets[fields[0]] = append(sets[fields[0]], strings.Split(fields[1], ",")...)
+			}
+			continue
+		}
+
+		applies := section == "Local+Descendent permissions" ||
+			(section == "Local permissions" && on == name) ||
+			(section == "Descendent permissions" && on != name)
+		if !applies {
+			continue
+		}
+		switch {
+		case len(fields) == 2 && fields[0] == "everyone":
+			granted = append(granted, strings.Split(fields[1], ",")...)
+		case len(fields) == 3 && fields[0] == "user" && fields[1] == username,
+			len(fields) == 3 && fields[0] == "group" && slices.Contains(groups, fields[1]):
+			granted = append(granted, strings.Split(fields[2], ",")...)
+		}
+	}
+
+	permissions := make(map[string]bool)
+	var expand func(perms []string, depth int)
+	expand = func(perms []string, depth int) {
+		for _, p := range perms {
+			if strings.HasPrefix(p, "@") {
+				// Sets may include sets; stop at a cycle
+				if depth < 8 {
+					expand(sets[p], depth+1)
+				}
+				continue
+			}
+			permissions[p] = true
+		}
+	}
+	expand(granted, 0)
+	return permissions
+}
+
+// datasetOf returns the dataset of a snapshot or bookmark name
+func datasetOf(name string) string {
+	if i := strings.IndexAny(name, "@#"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+// delegationPermissions returns the permissions operations need, sorted
+func delegationPermissions(ops []string) ([]string, error) {
+	permissions := make(map[string]bool)
+	for _, op := range ops {
+		required, ok := DelegatedOperations[op]
+		if !ok {
+			return nil, errors.New(errors.CommandInvalidInput, fmt.Sprintf(
+				"operation %q cannot be delegated; one of %s", op,
+				strings.Join(slices.Sorted(maps.Keys(DelegatedOperations)), ", ")))
+		}
+		for _, p := range required {
+			permissions[p] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(permissions)), nil
+}
+
+// unprivileged reports whether op on name runs without sudo. Recursive
+// operations keep sudo, since descendants may lack the delegation.
+func (m *Manager) unprivileged(ctx context.Context, name, op string, recursive bool) bool {
+	return !recursive && m.delegation.Covers(ctx, name, op)
+}
+
+// DelegationStatus returns which operations on a dataset the service user
+// can run without sudo
+func (m *Manager) DelegationStatus(ctx context.Context, cfg NameConfig) (*DelegationStatus, error) {
+	status := &DelegationStatus{
+		Name:       cfg.Name,
+		User:       m.delegation.User(),
+		Privileged: m.delegation.root,
+		Operations: make(map[string]bool),
+	}
+	if status.Privileged {
+		for op := range DelegatedOperations {
+			status.Operations[op] = true
+		}
+		return status, nil
+	}
+
+	permissions, err := m.delegation.permissions(ctx, datasetOf(cfg.Name))
+	if err != nil {
+		return nil, err
+	}
+	for op, required := range DelegatedOperations {
+		var missing []string
+		for _, p := range required {
+			if !permissions[p] {
+				missing = append(missing, p)
+			}
+		}
+		status.Operations[op] = len(missing) == 0
+		if len(missing) > 0 {
+			if status.Missing == nil {
+				status.Missing = make(map[string][]string)
+			}
+			status.Missing[op] = missing
+		}
+	}
+	return status, nil
+}
+
+// Delegate grants the service user the permissions of operations on a
+// dataset, and on its descendants unless LocalOnly is set
+func (m *Manager) Delegate(ctx context.Context, cfg DelegationConfig) error {
+	if m.delegation.User() == "" {
+		return errors.New(errors.ZFSPermissionError, "service user is unknown")
+	}
+	permissions, err := delegationPermissions(cfg.Operations)
+	if err != nil {
+		return err
+	}
+
+	return m.Allow(ctx, AllowConfig{
+		NameConfig:  cfg.NameConfig,
+		Permissions: permissions,
+		Users:       []string{m.delegation.User()},
+		Local:       cfg.LocalOnly,
+	})
+}
+
+// Undelegate revokes the permissions of operations on a dataset from the
+// service user, keeping those other delegated operations still need
+func (m *Manager) Undelegate(ctx context.Context, cfg DelegationConfig) error {
+	if m.delegation.User() == "" {
+		return errors.New(errors.ZFSPermissionError, "service user is unknown")
+	}
+	permissions, err := delegationPermissions(cfg.Operations)
+	if err != nil {
+		return err
+	}
+	status, err := m.DelegationStatus(ctx, cfg.NameConfig)
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool)
+	for op, delegated := range status.Operations {
+		if delegated && !slices.Contains(cfg.Operations, op) {
+			for _, p := range DelegatedOperations[op] {
+				keep[p] = true
+			}
+		}
+	}
+	permissions = slices.DeleteFunc(permissions, func(p string) bool { return keep[p] })
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	return m.Unallow(ctx, UnallowConfig{
+		NameConfig:  cfg.NameConfig,
+		Permissions: permissions,
+		Users:       []string{m.delegation.User()},
+		Local:       cfg.LocalOnly,
+	})
+}
+
+// UseDelegation runs local sends of delegated datasets without sudo
+func (tm *TransferManager) UseDelegation(d *Delegation) {
+	tm.delegation.Store(d)
+}
+
+// sendSudo returns the sudo prefix of a local send, or nothing when send is
+// delegated on the snapshot's dataset. Replication streams and sends under
+// resource limits keep sudo.
+func (tm *TransferManager) sendSudo(cfg SendConfig, limited bool) string {
+	if !cfg.Replicate && !limited && tm.delegation.Load().Covers(context.Background(), cfg.Snapshot, "send") {
+		return ""
+	}
+	return "sudo "
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+// allowOutput is zfs allow output for tank/data, with grants on the dataset
+// and on its parent
+const allowOutput = `---- Permissions on tank/data ------------------------------------------
+Local permissions:
+	user rodent hold
+	user other destroy
+---- Permissions on tank -----------------------------------------------
+Permission sets:
+	@backup mount,send
+Local permissions:
+	user rodent release
+Descendent permissions:
+	group backup @backup
+Local+Descendent permissions:
+	everyone userprop
+	user rodent snapshot
+`
+
+func TestEffectivePermissions(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string
+		want   []string
+	}{
+		{
+			name: "tank/data",
+			want: []string{"hold", "snapshot", "userprop"},
+		},
+		{
+			name:   "tank/data",
+			groups: []string{"backup"},
+			want:   []string{"hold", "mount", "send", "snapshot", "userprop"},
+		},
+		{
+			// Descendent grants of tank are not its own
+			name:   "tank",
+			groups: []string{"backup"},
+			want:   []string{"release", "snapshot", "userprop"},
+		},
+	}
+
+	for _, tt := range tests {
+		got := slices.Sorted(maps.Keys(effectivePermissions(allowOutput, tt.name, "rodent", tt.groups)))
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("effectivePermissions(%s, %v) = %v, want %v", tt.name, tt.groups, got, tt.want)
+		}
+	}
+}
+
+func TestDelegationPermissions(t *testing.T) {
+	got, err := delegationPermissions([]string{"snapshot", "send"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"mount", "send", "snapshot"}; !slices.Equal(got, want) {
+		t.Errorf("delegationPermissions = %v, want %v", got, want)
+	}
+
+	if _, err := delegationPermissions([]string{"destroy"}); err == nil {
+		t.Error("destroy should not be delegable")
+	}
+}
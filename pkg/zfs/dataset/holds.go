@@ -0,0 +1,117 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// Hold places a user hold on a snapshot. A held snapshot cannot be destroyed
+// until every hold on it is released.
+func (m *Manager) Hold(ctx context.Context, cfg HoldConfig) error {
+	if err := validateHoldConfig(cfg); err != nil {
+		return err
+	}
+
+	args := []string{"hold"}
+	if cfg.Recursive {
+		args = append(args, "-r")
+	}
+	args = append(args, cfg.Tag, cfg.Name)
+
+	opts := command.CommandOptions{
+		Unprivileged: m.unprivileged(ctx, cfg.Name, "hold", cfg.Recursive),
+	}
+	out, err := m.executor.Execute(ctx, opts, "zfs hold", args...)
+	if err != nil {
+		return errors.Wrap(err, errors.ZFSHoldError).
+			WithMetadata("operation", "hold").
+			WithMetadata("snapshot", cfg.Name).
+			WithMetadata("output", string(out))
+	}
+	return nil
+}
+
+// Release removes a user hold from a snapshot
+func (m *Manager) Release(ctx context.Context, cfg HoldConfig) error {
+	if err := validateHoldConfig(cfg); err != nil {
+		return err
+	}
+
+	args := []string{"release"}
+	if cfg.Recursive {
+		args = append(args, "-r")
+	}
+	args = append(args, cfg.Tag, cfg.Name)
+
+	opts := command.CommandOptions{
+		Unprivileged: m.unprivileged(ctx, cfg.Name, "release", cfg.Recursive),
+	}
+	out, err := m.executor.Execute(ctx, opts, "zfs release", args...)
+	if err != nil {
+		return errors.Wrap(err, errors.ZFSHoldError).
+			WithMetadata("operation", "release").
+			WithMetadata("snapshot", cfg.Name).
+			WithMetadata("output", string(out))
+	}
+	return nil
+}
+
+// ListHolds returns the user holds on a snapshot
+func (m *Manager) ListHolds(ctx context.Context, cfg NameConfig) ([]SnapshotHold, error) {
+	if !strings.Contains(cfg.Name, "@") {
+		return nil, errors.New(errors.ZFSSnapshotInvalidName, "holds can only be listed for snapshots").
+			WithMetadata("name", cfg.Name)
+	}
+
+	out, err := m.executor.Execute(
+		ctx,
+		command.CommandOptions{Flags: command.FlagNoHeaders},
+		"zfs holds",
+		"holds",
+		cfg.Name,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSHoldError).
+			WithMetadata("operation", "holds").
+			WithMetadata("snapshot", cfg.Name)
+	}
+
+	return parseHolds(string(out)), nil
+}
+
+// parseHolds parses `zfs holds -H` output: snapshot, tag and timestamp
+// separated by tabs
+func parseHolds(out string) []SnapshotHold {
+	holds := []SnapshotHold{}
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		holds = append(holds, SnapshotHold{
+			Snapshot:  fields[0],
+			Tag:       fields[1],
+			Timestamp: strings.TrimSpace(fields[2]),
+		})
+	}
+	return holds
+}
+
+func validateHoldConfig(cfg HoldConfig) error {
+	if !strings.Contains(cfg.Name, "@") {
+		return errors.New(errors.ZFSSnapshotInvalidName, "holds can only be placed on snapshots").
+			WithMetadata("name", cfg.Name)
+	}
+	if cfg.Tag == "" || strings.ContainsAny(cfg.Tag, " \t\n") {
+		return errors.New(errors.ZFSRequestValidationError, "invalid hold tag").
+			WithMetadata("tag", cfg.Tag)
+	}
+	return nil
+}
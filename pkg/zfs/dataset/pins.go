@@ -0,0 +1,56 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// TagPinned is the user property marking a snapshot as pinned. Pinned
+// snapshots are never pruned by snapshot policy retention, and the records
+// of transfers that sent them outlive transfer policy retention.
+const TagPinned = TagPrefix + "pinned"
+
+// PinConfig pins a snapshot, optionally noting why
+type PinConfig struct {
+	NameConfig
+	Reason string `json:"reason,omitempty"`
+}
+
+// Pin marks a snapshot as kept. The reason, if any, is the value of the
+// pin property.
+func (m *Manager) Pin(ctx context.Context, cfg PinConfig) error {
+	if !strings.Contains(cfg.Name, "@") {
+		return errors.New(errors.ZFSRequestValidationError,
+			"only snapshots can be pinned").WithMetadata("name", cfg.Name)
+	}
+
+	value := strings.TrimSpace(cfg.Reason)
+	if value == "" {
+		value = "true"
+	}
+	return m.SetTags(ctx, cfg.Name, map[string]string{TagPinned: value})
+}
+
+// Unpin clears the pin of a snapshot, leaving it to retention again
+func (m *Manager) Unpin(ctx context.Context, cfg NameConfig) error {
+	if !strings.Contains(cfg.Name, "@") {
+		return errors.New(errors.ZFSRequestValidationError,
+			"only snapshots can be pinned").WithMetadata("name", cfg.Name)
+	}
+	return m.InheritProperty(ctx, InheritConfig{
+		NameConfig: cfg,
+		Property:   TagPinned,
+	})
+}
+
+// IsPinned reports whether a snapshot listed with the pin property is pinned
+func IsPinned(ds Dataset) bool {
+	_, ok := TagValue(ds, TagPinned)
+	return ok
+}
@@ -0,0 +1,569 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// A seed is the full send stream of a snapshot written to removable media,
+// for initial syncs too large for the network. It is exported on the source,
+// carried to the target and imported there; the replication then continues
+// with incremental sends from the seeded snapshot.
+
+const (
+	// JobTypeSeedExport writes a seed to removable media
+	JobTypeSeedExport = "seed-export"
+	// JobTypeSeedImport receives a seed on the target
+	JobTypeSeedImport = "seed-import"
+
+	// DefaultSeedChunkSize keeps chunks below the 4 GiB file size limit of
+	// FAT32-formatted disks
+	DefaultSeedChunkSize int64 = 4<<30 - 1<<20
+	minSeedChunkSize     int64 = 1 << 20
+
+	seedManifestFile = "manifest.json"
+)
+
+// seedRetryPolicy retries seeds that fail midway; exports continue after
+// the chunks already written
+var seedRetryPolicy = jobs.RetryPolicy{
+	MaxAttempts:    3,
+	InitialBackoff: time.Minute,
+	MaxBackoff:     15 * time.Minute,
+	Timeout:        7 * 24 * time.Hour,
+}
+
+// SeedExportConfig describes a seed to write
+type SeedExportConfig struct {
+	Snapshot  string `json:"snapshot"             binding:"required"`
+	Path      string `json:"path"                 binding:"required"` // Directory on the removable disk
+	ChunkSize int64  `json:"chunk_size,omitempty"`                    // Bytes per chunk file, default DefaultSeedChunkSize
+
+	// Send options, which later incremental sends should match
+	Replicate   bool `json:"replicate,omitempty"`    // -R
+	Properties  bool `json:"properties,omitempty"`   // -p
+	Raw         bool `json:"raw,omitempty"`          // -w
+	LargeBlocks bool `json:"large_blocks,omitempty"` // -L
+	EmbedData   bool `json:"embed_data,omitempty"`   // -e
+	Compressed  bool `json:"compressed,omitempty"`   // -c
+}
+
+// SeedImportConfig describes a seed to receive
+type SeedImportConfig struct {
+	Path      string `json:"path"      binding:"required"` // Directory holding the seed's manifest
+	Target    string `json:"target"    binding:"required"` // Dataset to receive into
+	Force     bool   `json:"force"`                        // -F
+	Unmounted bool   `json:"unmounted"`                    // -u
+}
+
+// SeedChunk is one file of a seed stream
+type SeedChunk struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// SeedManifest describes a seed on disk. It is rewritten after every chunk,
+// so an interrupted export continues where it stopped.
+type SeedManifest struct {
+	Export      SeedExportConfig `json:"export"`
+	GUID        string           `json:"guid"` // GUID of the exported snapshot
+	Chunks      []SeedChunk      `json:"chunks"`
+	TotalBytes  int64            `json:"total_bytes"`
+	Complete    bool             `json:"complete"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
+
+// registerSeedJobs registers the seed job handlers on the queue
+func (tm *TransferManager) registerSeedJobs(q *jobs.Queue) {
+	q.Register(JobTypeSeedExport, tm.runSeedExportJob, seedRetryPolicy)
+	q.Register(JobTypeSeedImport, tm.runSeedImportJob, seedRetryPolicy)
+}
+
+// StartSeedExport validates a seed export and queues it, returning the job ID
+func (tm *TransferManager) StartSeedExport(cfg SeedExportConfig) (string, error) {
+	if err := validateSeedExport(&cfg); err != nil {
+		return "", err
+	}
+	return tm.enqueueSeedJob(JobTypeSeedExport, cfg)
+}
+
+// StartSeedImport validates a seed import and queues it, returning the job ID
+func (tm *TransferManager) StartSeedImport(cfg SeedImportConfig) (string, error) {
+	if err := validateSeedImport(cfg); err != nil {
+		return "", err
+	}
+	return tm.enqueueSeedJob(JobTypeSeedImport, cfg)
+}
+
+// GetSeed reads the manifest of the seed in dir
+func (tm *TransferManager) GetSeed(dir string) (*SeedManifest, error) {
+	if err := validateSeedPath(dir); err != nil {
+		return nil, err
+	}
+	manifest, err := readSeedManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil {
+		return nil, errors.New(errors.NotFoundError, "No seed found at path").
+			WithMetadata("path", dir)
+	}
+	return manifest, nil
+}
+
+// enqueueSeedJob queues a seed job. Seeds run for hours and are only run
+// through the job queue.
+func (tm *TransferManager) enqueueSeedJob(jobType string, payload any) (string, error) {
+	if err := tm.checkBlocked(); err != nil {
+		return "", err
+	}
+	q := tm.jobQueue.Load()
+	if q == nil {
+		return "", errors.New(errors.ServerFeatureDisabled, "Seeds need the job queue, which is not running")
+	}
+	return q.Enqueue(jobType, payload)
+}
+
+// runSeedExportJob exports the seed in the payload
+func (tm *TransferManager) runSeedExportJob(ctx context.Context, payload json.RawMessage) error {
+	var cfg SeedExportConfig
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+	manifest, err := tm.ExportSeed(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, manifest)
+}
+
+// runSeedImportJob imports the seed in the payload
+func (tm *TransferManager) runSeedImportJob(ctx context.Context, payload json.RawMessage) error {
+	var cfg SeedImportConfig
+	if err := json.Unmarshal(payload, &cfg); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+	manifest, err := tm.ImportSeed(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	return jobs.SetResult(ctx, manifest)
+}
+
+// ExportSeed writes the full send stream of a snapshot to cfg.Path in
+// checksummed chunks. An export interrupted earlier continues after its
+// last complete chunk; the stream is sent again from the start, and the
+// chunks already on disk are checked against it instead of being rewritten.
+func (tm *TransferManager) ExportSeed(ctx context.Context, cfg SeedExportConfig) (*SeedManifest, error) {
+	if err := validateSeedExport(&cfg); err != nil {
+		return nil, jobs.Permanent(err)
+	}
+
+	guid, err := tm.localSnapshotGUID(ctx, cfg.Snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := common.EnsureDir(cfg.Path, 0755); err != nil {
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", cfg.Path)
+	}
+
+	manifest, err := readSeedManifest(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if manifest != nil {
+		if manifest.GUID != guid || manifest.Export != cfg {
+			return nil, jobs.Permanent(errors.New(errors.CommandInvalidInput,
+				"Path holds another seed; use an empty directory").
+				WithMetadata("path", cfg.Path).
+				WithMetadata("snapshot", manifest.Export.Snapshot))
+		}
+		if manifest.Complete {
+			return manifest, nil
+		}
+		tm.logger.Info("Continuing seed export",
+			"snapshot", cfg.Snapshot,
+			"path", cfg.Path,
+			"chunks", len(manifest.Chunks))
+	} else {
+		manifest = &SeedManifest{Export: cfg, GUID: guid, Chunks: []SeedChunk{}, CreatedAt: time.Now()}
+	}
+
+	sendArgs := seedSendArgs(cfg)
+	audit, err := prepareStream(ctx, true, sendArgs)
+	if err != nil {
+		return nil, err
+	}
+	cmd := exec.CommandContext(ctx, "sudo", append([]string{command.BinZFS}, sendArgs...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ZFSDatasetSend)
+	}
+
+	tm.logger.Info("Exporting seed", "snapshot", cfg.Snapshot, "path", cfg.Path)
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		audit(time.Since(start), err)
+		return nil, errors.Wrap(err, errors.ZFSDatasetSend).
+			WithMetadata("snapshot", cfg.Snapshot)
+	}
+
+	writeErr := writeSeedChunks(stdout, cfg.Path, manifest)
+	if writeErr != nil {
+		// Stop the send instead of waiting for it to fill the pipe
+		cmd.Process.Kill()
+	}
+	waitErr := cmd.Wait()
+	audit(time.Since(start), waitErr)
+	if writeErr != nil {
+		return nil, writeErr
+	}
+	if waitErr != nil {
+		return nil, errors.Wrap(waitErr, errors.ZFSDatasetSend).
+			WithMetadata("snapshot", cfg.Snapshot).
+			WithMetadata("output", strings.TrimSpace(stderr.String()))
+	}
+
+	now := time.Now()
+	manifest.Complete = true
+	manifest.CompletedAt = &now
+	if err := writeSeedManifest(cfg.Path, manifest); err != nil {
+		return nil, err
+	}
+
+	tm.logger.Info("Seed exported",
+		"snapshot", cfg.Snapshot,
+		"path", cfg.Path,
+		"chunks", len(manifest.Chunks),
+		"bytes", manifest.TotalBytes)
+	return manifest, nil
+}
+
+// ImportSeed receives the seed in cfg.Path into cfg.Target. Chunks are
+// checked against their checksums as they are read; a damaged chunk stops
+// the receive. A seed whose snapshot is already on the target is not
+// received again.
+func (tm *TransferManager) ImportSeed(ctx context.Context, cfg SeedImportConfig) (*SeedManifest, error) {
+	if err := validateSeedImport(cfg); err != nil {
+		return nil, jobs.Permanent(err)
+	}
+
+	manifest, err := readSeedManifest(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if manifest == nil || !manifest.Complete {
+		return nil, jobs.Permanent(errors.New(errors.CommandInvalidInput,
+			"No complete seed found at path").
+			WithMetadata("path", cfg.Path))
+	}
+
+	_, snapName, _ := strings.Cut(manifest.Export.Snapshot, "@")
+	targetSnapshot := cfg.Target + "@" + snapName
+	if guid, err := tm.localSnapshotGUID(ctx, targetSnapshot); err == nil && guid == manifest.GUID {
+		tm.logger.Info("Seed already imported", "snapshot", targetSnapshot)
+		return manifest, nil
+	}
+
+	recvArgs := []string{"receive"}
+	if cfg.Force {
+		recvArgs = append(recvArgs, "-F")
+	}
+	if cfg.Unmounted {
+		recvArgs = append(recvArgs, "-u")
+	}
+	recvArgs = append(recvArgs, cfg.Target)
+
+	audit, err := prepareStream(ctx, true, recvArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newSeedReader(cfg.Path, manifest)
+	cmd := exec.CommandContext(ctx, "sudo", append([]string{command.BinZFS}, recvArgs...)...)
+	cmd.Stdin = reader
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	tm.logger.Info("Importing seed", "path", cfg.Path, "target", cfg.Target)
+	start := time.Now()
+	err = cmd.Run()
+	audit(time.Since(start), err)
+	if err != nil {
+		if reader.err != nil {
+			return nil, jobs.Permanent(reader.err)
+		}
+		return nil, errors.Wrap(err, errors.ZFSDatasetReceive).
+			WithMetadata("target", cfg.Target).
+			WithMetadata("output", strings.TrimSpace(output.String()))
+	}
+
+	tm.logger.Info("Seed imported", "path", cfg.Path, "snapshot", targetSnapshot)
+	return manifest, nil
+}
+
+// seedSendArgs returns the zfs send arguments of a seed export
+func seedSendArgs(cfg SeedExportConfig) []string {
+	args := []string{"send"}
+	for _, opt := range []struct {
+		set  bool
+		flag string
+	}{
+		{cfg.Replicate, "-R"},
+		{cfg.Properties, "-p"},
+		{cfg.Raw, "-w"},
+		{cfg.LargeBlocks, "-L"},
+		{cfg.EmbedData, "-e"},
+		{cfg.Compressed, "-c"},
+	} {
+		if opt.set {
+			args = append(args, opt.flag)
+		}
+	}
+	return append(args, cfg.Snapshot)
+}
+
+// writeSeedChunks splits a send stream into the chunk files of a seed,
+// saving the manifest after each. The chunks the manifest already lists
+// are read from the stream and checked rather than written.
+func writeSeedChunks(r io.Reader, dir string, manifest *SeedManifest) error {
+	chunkSize := manifest.Export.ChunkSize
+	for i := 0; ; i++ {
+		if i < len(manifest.Chunks) {
+			chunk := manifest.Chunks[i]
+			h := sha256.New()
+			n, err := io.CopyN(h, r, chunk.Size)
+			if err != nil && err != io.EOF {
+				return errors.Wrap(err, errors.ZFSDatasetSend)
+			}
+			if n != chunk.Size || hex.EncodeToString(h.Sum(nil)) != chunk.SHA256 {
+				return jobs.Permanent(errors.New(errors.ZFSDatasetSend,
+					"Send stream differs from the chunks already exported; start over in an empty directory").
+					WithMetadata("chunk", chunk.Name))
+			}
+			continue
+		}
+
+		chunk, err := writeSeedChunk(r, dir, i, chunkSize)
+		if err != nil {
+			return err
+		}
+		if chunk == nil {
+			return nil
+		}
+		manifest.Chunks = append(manifest.Chunks, *chunk)
+		manifest.TotalBytes += chunk.Size
+		if err := writeSeedManifest(dir, manifest); err != nil {
+			return err
+		}
+		if chunk.Size < chunkSize {
+			return nil
+		}
+	}
+}
+
+// writeSeedChunk writes up to size bytes of r to chunk file i. It returns
+// nil at the end of the stream.
+func writeSeedChunk(r io.Reader, dir string, i int, size int64) (*SeedChunk, error) {
+	name := fmt.Sprintf("chunk-%06d.zstream", i)
+	path := filepath.Join(dir, name)
+	tmp := path + ".part"
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", tmp)
+	}
+	h := sha256.New()
+	n, copyErr := io.CopyN(io.MultiWriter(f, h), r, size)
+	fileErr := f.Sync()
+	if err := f.Close(); fileErr == nil {
+		fileErr = err
+	}
+	if copyErr != nil && copyErr != io.EOF {
+		os.Remove(tmp)
+		return nil, errors.Wrap(copyErr, errors.ZFSDatasetSend)
+	}
+	if err := fileErr; err != nil {
+		os.Remove(tmp)
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", tmp)
+	}
+	if n == 0 {
+		os.Remove(tmp)
+		return nil, nil
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	return &SeedChunk{Name: name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// seedReader reads the chunks of a seed in order as one stream, failing on
+// a chunk whose size or checksum does not match the manifest
+type seedReader struct {
+	dir    string
+	chunks []SeedChunk
+	file   *os.File
+	hash   hash.Hash
+	read   int64
+	err    error
+}
+
+func newSeedReader(dir string, manifest *SeedManifest) *seedReader {
+	return &seedReader{dir: dir, chunks: manifest.Chunks}
+}
+
+func (s *seedReader) Read(p []byte) (int, error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	for {
+		if s.file == nil {
+			if len(s.chunks) == 0 {
+				return 0, io.EOF
+			}
+			path := filepath.Join(s.dir, filepath.Base(s.chunks[0].Name))
+			f, err := os.Open(path)
+			if err != nil {
+				s.err = errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+				return 0, s.err
+			}
+			s.file, s.hash, s.read = f, sha256.New(), 0
+		}
+
+		n, err := s.file.Read(p)
+		s.hash.Write(p[:n])
+		s.read += int64(n)
+		if err == io.EOF {
+			s.file.Close()
+			s.file = nil
+			chunk := s.chunks[0]
+			s.chunks = s.chunks[1:]
+			if s.read != chunk.Size || hex.EncodeToString(s.hash.Sum(nil)) != chunk.SHA256 {
+				s.err = errors.New(errors.ZFSDatasetReceive, "Seed chunk is damaged").
+					WithMetadata("chunk", chunk.Name)
+				return 0, s.err
+			}
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		if err != nil {
+			s.file.Close()
+			s.err = errors.Wrap(err, errors.FSError)
+			return n, s.err
+		}
+		return n, nil
+	}
+}
+
+// localSnapshotGUID returns the GUID of a snapshot on this host
+func (tm *TransferManager) localSnapshotGUID(ctx context.Context, snapshot string) (string, error) {
+	out, err := tm.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders | command.FlagParsable},
+		"zfs get", "-o", "value", "guid", snapshot)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ZFSSnapshotList).
+			WithMetadata("snapshot", snapshot)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// readSeedManifest reads the manifest in dir, or nil if there is none
+func readSeedManifest(dir string) (*SeedManifest, error) {
+	path := filepath.Join(dir, seedManifestFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	var manifest SeedManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	return &manifest, nil
+}
+
+// writeSeedManifest replaces the manifest in dir
+func writeSeedManifest(dir string, manifest *SeedManifest) error {
+	manifest.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, errors.RodentMisc)
+	}
+	path := filepath.Join(dir, seedManifestFile)
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	return nil
+}
+
+// validateSeedExport checks a seed export and fills in its defaults
+func validateSeedExport(cfg *SeedExportConfig) error {
+	if !snapshotNameRegex.MatchString(cfg.Snapshot) || !strings.Contains(cfg.Snapshot, "@") {
+		return errors.New(errors.CommandInvalidInput, "Invalid snapshot name")
+	}
+	if err := validateSeedPath(cfg.Path); err != nil {
+		return err
+	}
+	if cfg.ChunkSize == 0 {
+		cfg.ChunkSize = DefaultSeedChunkSize
+	}
+	if cfg.ChunkSize < minSeedChunkSize {
+		return errors.New(errors.CommandInvalidInput, "Seed chunk size must be at least 1 MiB")
+	}
+	if cfg.Raw {
+		if err := command.RequireFeature(command.FeatureRawSend); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateSeedImport checks a seed import
+func validateSeedImport(cfg SeedImportConfig) error {
+	if !datasetNameRegex.MatchString(cfg.Target) {
+		return errors.New(errors.CommandInvalidInput, "Invalid target dataset")
+	}
+	return validateSeedPath(cfg.Path)
+}
+
+// validateSeedPath requires a clean absolute path
+func validateSeedPath(path string) error {
+	if !filepath.IsAbs(path) || filepath.Clean(path) != path {
+		return errors.New(errors.CommandInvalidInput, "Seed path must be a clean absolute path").
+			WithMetadata("path", path)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Values of the snapdir property. Hidden keeps the .zfs directory of a
+// filesystem reachable by path but out of listings of its root; visible
+// lists it, so clients can browse snapshots. Disabled, from OpenZFS 2.3,
+// removes it, and with it restores from snapshots through the filesystem.
+const (
+	SnapdirHidden   = "hidden"
+	SnapdirVisible  = "visible"
+	SnapdirDisabled = "disabled"
+)
+
+// SnapdirVisibilities are the snapdir values that can be set
+var SnapdirVisibilities = []string{SnapdirHidden, SnapdirVisible}
+
+// SnapdirConfig sets whether the .zfs directory of a filesystem is listed
+type SnapdirConfig struct {
+	NameConfig
+	Visibility string `json:"visibility" binding:"required"`
+}
+
+// SnapdirStatus is the snapdir of a filesystem and where it is set
+type SnapdirStatus struct {
+	Name       string `json:"name"`
+	Visibility string `json:"visibility"`
+	Source     string `json:"source"`              // local, inherited, default, ...
+	Inherited  string `json:"inherited,omitempty"` // Dataset it is inherited from
+}
+
+// Browsable reports whether clients can list the snapshots of the
+// filesystem in its root
+func (s *SnapdirStatus) Browsable() bool {
+	return s.Visibility == SnapdirVisible
+}
+
+// Restorable reports whether the snapshots of the filesystem can be read
+// through its .zfs directory
+func (s *SnapdirStatus) Restorable() bool {
+	return s.Visibility != SnapdirDisabled
+}
+
+// Snapdir returns the snapdir of a filesystem
+func (m *Manager) Snapdir(ctx context.Context, cfg NameConfig) (*SnapdirStatus, error) {
+	result, err := m.GetProperty(ctx, PropertyConfig{NameConfig: cfg, Property: "snapdir"})
+	if err != nil {
+		return nil, err
+	}
+	prop := result.Datasets[cfg.Name].Properties["snapdir"]
+
+	status := &SnapdirStatus{
+		Name:       cfg.Name,
+		Visibility: fmt.Sprint(prop.Value),
+		Source:     prop.Source.Type,
+	}
+	if strings.EqualFold(prop.Source.Type, "inherited") {
+		status.Inherited = prop.Source.Data
+	}
+	return status, nil
+}
+
+// SetSnapdir sets the snapdir of a filesystem to hidden or visible. Its
+// children inherit it unless they set their own.
+func (m *Manager) SetSnapdir(ctx context.Context, cfg SnapdirConfig) error {
+	if !slices.Contains(SnapdirVisibilities, cfg.Visibility) {
+		return errors.New(errors.ZFSRequestValidationError,
+			"snapdir must be hidden or visible").
+			WithMetadata("name", cfg.Name).
+			WithMetadata("visibility", cfg.Visibility)
+	}
+	return m.SetProperty(ctx, SetPropertyConfig{
+		PropertyConfig: PropertyConfig{NameConfig: cfg.NameConfig, Property: "snapdir"},
+		Value:          cfg.Visibility,
+	})
+}
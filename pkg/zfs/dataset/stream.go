@@ -0,0 +1,709 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	generalCmd "github.com/stratastor/rodent/internal/command"
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// A stream session holds a zfs send or receive whose stream is carried over
+// HTTP by an external orchestrator, for pipelines with neither SSH nor
+// Rodent on both ends. Sends are read in ranges from a running zfs send,
+// which is restarted and read forward when a range goes back, as after a
+// dropped download. Receives are written in order into a running zfs
+// receive; a dropped upload continues at the session's offset.
+
+const (
+	// MaxStreamChunk bounds the bytes read from a send for one range
+	MaxStreamChunk int64 = 64 << 20
+
+	// Sessions unused this long are closed and their zfs process stopped
+	streamIdleTimeout = 30 * time.Minute
+)
+
+// StreamKind is the direction of a stream session
+type StreamKind string
+
+const (
+	StreamKindSend    StreamKind = "send"
+	StreamKindReceive StreamKind = "receive"
+)
+
+// StreamState is the state of a stream session
+type StreamState string
+
+const (
+	StreamStateOpen     StreamState = "open"
+	StreamStateComplete StreamState = "complete" // A send read to its end, or a receive that finished
+	StreamStateFailed   StreamState = "failed"
+)
+
+// StreamSession describes a stream session
+type StreamSession struct {
+	ID       string      `json:"id"`
+	Kind     StreamKind  `json:"kind"`
+	State    StreamState `json:"state"`
+	Snapshot string      `json:"snapshot,omitempty"` // Snapshot of a send
+	Target   string      `json:"target,omitempty"`   // Dataset of a receive
+
+	// Sends: the end of the last range read; receives: the bytes written
+	Offset         int64  `json:"offset"`
+	TotalBytes     *int64 `json:"total_bytes,omitempty"`     // Known once a send is read to its end
+	EstimatedBytes int64  `json:"estimated_bytes,omitempty"` // Predicted by `zfs send -nP`
+	ETag           string `json:"etag,omitempty"`            // Differs when the sent snapshot is recreated
+
+	Error       string `json:"error,omitempty"`
+	ResumeToken string `json:"resume_token,omitempty"` // Left on the target by a failed resumable receive
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// streamSession is a stream session and its zfs process
+type streamSession struct {
+	mu      sync.Mutex
+	info    StreamSession
+	sendCfg SendConfig
+	recvCfg ReceiveConfig
+
+	unprivileged bool // The send is delegated to the service user
+
+	cmd     *exec.Cmd
+	cancel  context.CancelFunc // Stops cmd's process group
+	release func()             // Called once cmd has been waited for
+	output  bytes.Buffer       // Read only after the process has exited
+
+	// Sends
+	stdout  io.ReadCloser
+	pos     int64                      // Bytes read from the running send
+	audit   func(time.Duration, error) // Records the send's outcome with the privilege broker
+	started time.Time                  // When the running send started
+
+	// Receives
+	stdin   io.WriteCloser
+	done    chan struct{} // Closed when the receive exits
+	waitErr error
+}
+
+// OpenSendStream opens a session that serves the send stream of cfg. The
+// send runs on this host and starts with the first read.
+func (tm *TransferManager) OpenSendStream(ctx context.Context, cfg SendConfig) (*StreamSession, error) {
+	if cfg.RemoteConfig.Host != "" {
+		return nil, errors.New(errors.CommandInvalidInput, "Streams are sent from this host")
+	}
+	if cfg.DryRun {
+		return nil, errors.New(errors.CommandInvalidInput, "Dry runs have no stream")
+	}
+	if err := validateSendConfig(cfg); err != nil {
+		return nil, err
+	}
+	if err := tm.checkBlocked(); err != nil {
+		return nil, err
+	}
+
+	// The snapshot's GUID ties ranges read later to the same stream
+	tag := sha256.New()
+	tag.Write([]byte(strings.Join(streamSendArgs(cfg), " ")))
+	if cfg.ResumeToken == "" {
+		guid, err := tm.localSnapshotGUID(ctx, cfg.Snapshot)
+		if err != nil {
+			return nil, err
+		}
+		tag.Write([]byte(guid))
+	}
+
+	s := &streamSession{
+		sendCfg:      cfg,
+		unprivileged: tm.sendSudo(cfg, false) == "",
+		info: StreamSession{
+			Kind:     StreamKindSend,
+			Snapshot: cfg.Snapshot,
+			ETag:     hex.EncodeToString(tag.Sum(nil))[:16],
+		},
+	}
+	if cfg.ResumeToken == "" {
+		if size, _ := tm.calculateTransferSize(TransferConfig{SendConfig: cfg}); size != nil {
+			s.info.EstimatedBytes = size.CalculatedTransferSize
+		}
+	}
+
+	tm.addStream(s)
+	tm.logger.Info("Opened send stream", "id", s.info.ID, "snapshot", cfg.Snapshot)
+	return s.snapshot(), nil
+}
+
+// OpenReceiveStream opens a session that receives an uploaded stream into
+// cfg.Target. The receive starts now and waits for the first chunk.
+func (tm *TransferManager) OpenReceiveStream(ctx context.Context, cfg ReceiveConfig) (*StreamSession, error) {
+	if cfg.RemoteConfig.Host != "" {
+		return nil, errors.New(errors.CommandInvalidInput, "Streams are received on this host")
+	}
+	if err := validateReceiveConfig(cfg); err != nil {
+		return nil, err
+	}
+	adaptReceiveConfig(&cfg)
+	if err := tm.checkBlocked(); err != nil {
+		return nil, err
+	}
+
+	s := &streamSession{
+		recvCfg: cfg,
+		info:    StreamSession{Kind: StreamKindReceive, Target: cfg.Target},
+	}
+	args := streamReceiveArgs(cfg)
+	audit, err := prepareStream(ctx, true, args)
+	if err != nil {
+		return nil, err
+	}
+	cmd := s.command("sudo", append([]string{command.BinZFS}, args...)...)
+	cmd.Stdout = &s.output
+	cmd.Stderr = &s.output
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		s.releaseGroup()
+		return nil, errors.Wrap(err, errors.ZFSDatasetReceive)
+	}
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		s.releaseGroup()
+		audit(time.Since(start), err)
+		return nil, errors.Wrap(err, errors.ZFSDatasetReceive).WithMetadata("target", cfg.Target)
+	}
+	s.cmd, s.stdin, s.done = cmd, stdin, make(chan struct{})
+	go func() {
+		s.waitErr = cmd.Wait()
+		audit(time.Since(start), s.waitErr)
+		s.releaseGroup()
+		close(s.done)
+	}()
+
+	tm.addStream(s)
+	tm.logger.Info("Opened receive stream", "id", s.info.ID, "target", cfg.Target)
+	return s.snapshot(), nil
+}
+
+// ReadSendStream reads up to length bytes of a send stream from offset,
+// at most MaxStreamChunk. Fewer bytes are returned at the end of the
+// stream, after which the session's total is known.
+func (tm *TransferManager) ReadSendStream(id string, offset, length int64) ([]byte, *StreamSession, error) {
+	if length <= 0 {
+		return nil, nil, errors.New(errors.StreamRangeUnsatisfied, "Range length must be positive").
+			WithMetadata("length", strconv.FormatInt(length, 10))
+	}
+	s, err := tm.stream(id, StreamKindSend)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := tm.seekSend(s, offset); err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, min(length, MaxStreamChunk))
+	n, err := io.ReadFull(s.stdout, buf)
+	s.pos += int64(n)
+	s.info.Offset = offset + int64(n)
+	s.info.UpdatedAt = time.Now()
+	switch err {
+	case nil:
+	case io.EOF, io.ErrUnexpectedEOF:
+		if err := tm.finishSend(s); err != nil {
+			return nil, nil, err
+		}
+		if n == 0 {
+			return nil, nil, rangeUnsatisfied(s)
+		}
+	default:
+		s.stopSend()
+		return nil, nil, errors.Wrap(err, errors.StreamFailed).WithMetadata("stream_id", id)
+	}
+	return buf[:n], s.snapshot(), nil
+}
+
+// CopySendStream writes a send stream from offset to its end into w. A
+// copy stopped by w leaves the send where it stopped, for a range request
+// to continue from.
+func (tm *TransferManager) CopySendStream(id string, offset int64, w io.Writer) (*StreamSession, error) {
+	s, err := tm.stream(id, StreamKindSend)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := tm.seekSend(s, offset); err != nil {
+		return nil, err
+	}
+
+	n, err := io.Copy(w, s.stdout)
+	s.pos += n
+	s.info.Offset = offset + n
+	s.info.UpdatedAt = time.Now()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.StreamFailed).WithMetadata("stream_id", id)
+	}
+	if err := tm.finishSend(s); err != nil {
+		return nil, err
+	}
+	return s.snapshot(), nil
+}
+
+// WriteReceiveStream writes a chunk of the stream, starting at offset, into
+// a receive. The offset must be the session's; after a dropped upload the
+// session says where to continue. A total of zero or more finishes the
+// receive once that many bytes are written.
+func (tm *TransferManager) WriteReceiveStream(
+	ctx context.Context,
+	id string,
+	offset, total int64,
+	r io.Reader,
+) (*StreamSession, error) {
+	s, err := tm.stream(id, StreamKindReceive)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.info.State != StreamStateOpen {
+		return nil, errors.New(errors.StreamClosed, "Receive has finished").
+			WithMetadata("state", string(s.info.State))
+	}
+	if offset != s.info.Offset {
+		return nil, errors.New(errors.StreamOffsetMismatch,
+			fmt.Sprintf("Chunk starts at %d; continue at %d", offset, s.info.Offset)).
+			WithMetadata("offset", strconv.FormatInt(s.info.Offset, 10))
+	}
+
+	n, err := io.Copy(s.stdin, r)
+	s.info.Offset += n
+	s.info.UpdatedAt = time.Now()
+	if err != nil {
+		select {
+		case <-s.done:
+			// The receive rejected the stream
+			return nil, tm.finishReceive(ctx, s)
+		default:
+			return nil, errors.Wrap(err, errors.StreamFailed).
+				WithMetadata("offset", strconv.FormatInt(s.info.Offset, 10))
+		}
+	}
+
+	if total >= 0 && s.info.Offset >= total {
+		if err := tm.finishReceive(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	return s.snapshot(), nil
+}
+
+// CompleteReceiveStream ends the uploaded stream and waits for the receive
+// to finish
+func (tm *TransferManager) CompleteReceiveStream(ctx context.Context, id string) (*StreamSession, error) {
+	s, err := tm.stream(id, StreamKindReceive)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.info.State == StreamStateOpen {
+		if err := tm.finishReceive(ctx, s); err != nil {
+			return nil, err
+		}
+	}
+	return s.snapshot(), nil
+}
+
+// GetStream returns a stream session
+func (tm *TransferManager) GetStream(id string) (*StreamSession, error) {
+	s, err := tm.stream(id, "")
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot(), nil
+}
+
+// ListStreams returns the open stream sessions, oldest first
+func (tm *TransferManager) ListStreams() []StreamSession {
+	tm.expireStreams()
+
+	tm.streamsMu.Lock()
+	sessions := make([]*streamSession, 0, len(tm.streams))
+	for _, s := range tm.streams {
+		sessions = append(sessions, s)
+	}
+	tm.streamsMu.Unlock()
+
+	list := make([]StreamSession, 0, len(sessions))
+	for _, s := range sessions {
+		s.mu.Lock()
+		list = append(list, *s.snapshot())
+		s.mu.Unlock()
+	}
+	slices.SortFunc(list, func(a, b StreamSession) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return list
+}
+
+// CloseStream stops a session's zfs process and forgets the session. A
+// resumable receive stopped midway leaves its partial state on the target.
+func (tm *TransferManager) CloseStream(id string) error {
+	tm.streamsMu.Lock()
+	s, ok := tm.streams[id]
+	delete(tm.streams, id)
+	tm.streamsMu.Unlock()
+	if !ok {
+		return errors.New(errors.StreamNotFound, "No stream session of the ID").
+			WithMetadata("stream_id", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	tm.logger.Info("Closed stream", "id", id, "kind", s.info.Kind, "offset", s.info.Offset)
+	return nil
+}
+
+// addStream registers a new session
+func (tm *TransferManager) addStream(s *streamSession) {
+	tm.expireStreams()
+
+	now := time.Now()
+	s.info.ID = common.UUID7()
+	s.info.State = StreamStateOpen
+	s.info.CreatedAt = now
+	s.info.UpdatedAt = now
+
+	tm.streamsMu.Lock()
+	if tm.streams == nil {
+		tm.streams = make(map[string]*streamSession)
+	}
+	tm.streams[s.info.ID] = s
+	tm.streamsMu.Unlock()
+}
+
+// stream returns a session of the kind, or of any kind when kind is empty
+func (tm *TransferManager) stream(id string, kind StreamKind) (*streamSession, error) {
+	tm.expireStreams()
+
+	tm.streamsMu.Lock()
+	s, ok := tm.streams[id]
+	tm.streamsMu.Unlock()
+	if !ok || (kind != "" && s.info.Kind != kind) {
+		return nil, errors.New(errors.StreamNotFound, "No stream session of the ID").
+			WithMetadata("stream_id", id)
+	}
+	return s, nil
+}
+
+// expireStreams closes sessions idle for longer than streamIdleTimeout.
+// Sessions in use are not idle.
+func (tm *TransferManager) expireStreams() {
+	tm.streamsMu.Lock()
+	defer tm.streamsMu.Unlock()
+
+	for id, s := range tm.streams {
+		if !s.mu.TryLock() {
+			continue
+		}
+		if time.Since(s.info.UpdatedAt) > streamIdleTimeout {
+			s.stop()
+			delete(tm.streams, id)
+			tm.logger.Info("Closed idle stream", "id", id, "kind", s.info.Kind, "offset", s.info.Offset)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// seekSend positions the session's send at offset, starting the send, or
+// restarting it when offset is behind it. The caller holds s.mu.
+func (tm *TransferManager) seekSend(s *streamSession, offset int64) error {
+	if offset < 0 {
+		return errors.New(errors.StreamRangeUnsatisfied, "Offset must not be negative")
+	}
+	if s.info.TotalBytes != nil && offset >= *s.info.TotalBytes {
+		return rangeUnsatisfied(s)
+	}
+
+	if s.cmd != nil && offset < s.pos {
+		s.stopSend()
+	}
+	if s.cmd == nil {
+		if err := s.startSend(); err != nil {
+			return err
+		}
+		if offset > 0 {
+			tm.logger.Debug("Restarted send stream", "id", s.info.ID, "offset", offset)
+		}
+	}
+
+	if skip := offset - s.pos; skip > 0 {
+		n, err := io.CopyN(io.Discard, s.stdout, skip)
+		s.pos += n
+		if err == io.EOF {
+			if err := tm.finishSend(s); err != nil {
+				return err
+			}
+			return rangeUnsatisfied(s)
+		}
+		if err != nil {
+			s.stopSend()
+			return errors.Wrap(err, errors.StreamFailed).WithMetadata("stream_id", s.info.ID)
+		}
+	}
+	return nil
+}
+
+// finishSend waits for a send read to its end and records its total. The
+// caller holds s.mu.
+func (tm *TransferManager) finishSend(s *streamSession) error {
+	err := s.cmd.Wait()
+	s.audit(time.Since(s.started), err)
+	s.releaseGroup()
+	s.cmd, s.stdout = nil, nil
+	if err != nil {
+		s.info.State = StreamStateFailed
+		s.info.Error = strings.TrimSpace(s.output.String())
+		tm.logger.Warn("Send stream failed", "id", s.info.ID, "error", err, "output", s.info.Error)
+		return errors.Wrap(err, errors.StreamFailed).
+			WithMetadata("snapshot", s.info.Snapshot).
+			WithMetadata("output", s.info.Error)
+	}
+
+	total := s.pos
+	s.info.TotalBytes = &total
+	s.info.State = StreamStateComplete
+	return nil
+}
+
+// finishReceive ends the uploaded stream and waits for the receive. A
+// failed resumable receive records the target's resume token. The caller
+// holds s.mu.
+func (tm *TransferManager) finishReceive(ctx context.Context, s *streamSession) error {
+	s.stdin.Close()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), errors.StreamFailed).
+			WithMetadata("target", s.info.Target)
+	}
+	s.info.UpdatedAt = time.Now()
+
+	if s.waitErr != nil {
+		s.info.State = StreamStateFailed
+		s.info.Error = strings.TrimSpace(s.output.String())
+		if s.recvCfg.Resumable {
+			out, err := tm.executor.Execute(ctx, command.CommandOptions{Flags: command.FlagNoHeaders},
+				"zfs get", "-o", "value", "receive_resume_token", s.info.Target)
+			if token := strings.TrimSpace(string(out)); err == nil && token != "-" {
+				s.info.ResumeToken = token
+			}
+		}
+		tm.logger.Warn("Receive stream failed",
+			"id", s.info.ID,
+			"target", s.info.Target,
+			"offset", s.info.Offset,
+			"error", s.waitErr)
+		return errors.Wrap(s.waitErr, errors.ZFSDatasetReceive).
+			WithMetadata("target", s.info.Target).
+			WithMetadata("output", s.info.Error)
+	}
+
+	s.info.State = StreamStateComplete
+	tm.logger.Info("Receive stream complete",
+		"id", s.info.ID,
+		"target", s.info.Target,
+		"bytes", s.info.Offset)
+	return nil
+}
+
+// startSend starts the session's send from the beginning of the stream
+func (s *streamSession) startSend() error {
+	sendArgs := streamSendArgs(s.sendCfg)
+	audit, err := prepareStream(context.Background(), !s.unprivileged, sendArgs)
+	if err != nil {
+		return err
+	}
+	args := append([]string{command.BinZFS}, sendArgs...)
+	if !s.unprivileged {
+		args = append([]string{"sudo"}, args...)
+	}
+	cmd := s.command(args[0], args[1:]...)
+	s.output.Reset()
+	cmd.Stderr = &s.output
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		s.releaseGroup()
+		return errors.Wrap(err, errors.ZFSDatasetSend)
+	}
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		s.releaseGroup()
+		audit(time.Since(start), err)
+		return errors.Wrap(err, errors.ZFSDatasetSend).WithMetadata("snapshot", s.info.Snapshot)
+	}
+	s.cmd, s.stdout, s.pos = cmd, stdout, 0
+	s.audit, s.started = audit, start
+	s.info.State, s.info.Error = StreamStateOpen, ""
+	return nil
+}
+
+// prepareStream authorizes a stream's zfs command and probes sudo for it,
+// as command.CommandExecutor does before running one. Dry-run mode, which
+// skips commands that change state, opens no stream. The returned audit
+// records the outcome of the command once it has exited.
+func prepareStream(ctx context.Context, useSudo bool, args []string) (func(time.Duration, error), error) {
+	run, audit, err := generalCmd.PrepareRun(ctx, nil, useSudo, command.BinZFS, args)
+	if err != nil {
+		return nil, err
+	}
+	if !run {
+		return nil, errors.New(errors.CommandInvalidInput, "Dry runs have no stream")
+	}
+	return audit, nil
+}
+
+// command creates the session's zfs process in a process group of its own,
+// stopped by s.cancel. Call s.releaseGroup once it has been waited for.
+func (s *streamSession) command(name string, args ...string) *exec.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd, release := generalCmd.GroupCommand(ctx, name, args...)
+	s.cancel, s.release = cancel, release
+	return cmd
+}
+
+// releaseGroup releases the process group of the session's exited zfs process
+func (s *streamSession) releaseGroup() {
+	s.release()
+	s.cancel()
+}
+
+// stopSend stops the session's send, if running
+func (s *streamSession) stopSend() {
+	if s.cmd == nil {
+		return
+	}
+	s.cancel()
+	s.audit(time.Since(s.started), s.cmd.Wait())
+	s.releaseGroup()
+	s.cmd, s.stdout = nil, nil
+}
+
+// stop stops the session's zfs process, if running: SIGTERM to its process
+// group, then SIGKILL after the kill grace
+func (s *streamSession) stop() {
+	if s.info.Kind == StreamKindSend {
+		s.stopSend()
+		return
+	}
+	select {
+	case <-s.done:
+	default:
+		s.cancel()
+		<-s.done
+	}
+}
+
+// snapshot returns a copy of the session's description
+func (s *streamSession) snapshot() *StreamSession {
+	info := s.info
+	if info.TotalBytes != nil {
+		total := *info.TotalBytes
+		info.TotalBytes = &total
+	}
+	return &info
+}
+
+// rangeUnsatisfied reports a range past the end of a send of known size
+func rangeUnsatisfied(s *streamSession) error {
+	return errors.New(errors.StreamRangeUnsatisfied, "Range starts past the end of the stream").
+		WithMetadata("total_bytes", strconv.FormatInt(*s.info.TotalBytes, 10))
+}
+
+// streamSendArgs returns the zfs send arguments of a send stream; options
+// that only change what zfs prints are left out
+func streamSendArgs(cfg SendConfig) []string {
+	if cfg.ResumeToken != "" {
+		return []string{"send", "-t", cfg.ResumeToken}
+	}
+
+	args := []string{"send"}
+	for _, opt := range []struct {
+		set  bool
+		flag string
+	}{
+		{cfg.Replicate, "-R"},
+		{cfg.Replicate && cfg.SkipMissing, "-s"},
+		{cfg.Properties, "-p"},
+		{cfg.Raw, "-w"},
+		{cfg.LargeBlocks, "-L"},
+		{cfg.EmbedData, "-e"},
+		{cfg.Holds, "-h"},
+		{cfg.BackupStream, "-b"},
+		{cfg.Compressed, "-c"},
+	} {
+		if opt.set {
+			args = append(args, opt.flag)
+		}
+	}
+	if cfg.FromSnapshot != "" && cfg.Intermediary {
+		args = append(args, "-I", cfg.FromSnapshot)
+	} else if cfg.FromSnapshot != "" {
+		args = append(args, "-i", cfg.FromSnapshot)
+	}
+	return append(args, cfg.Snapshot)
+}
+
+// streamReceiveArgs returns the zfs receive arguments of a receive stream
+func streamReceiveArgs(cfg ReceiveConfig) []string {
+	args := []string{"receive"}
+	for _, opt := range []struct {
+		set  bool
+		flag string
+	}{
+		{cfg.Force, "-F"},
+		{cfg.Unmounted, "-u"},
+		{cfg.Resumable, "-s"},
+		{cfg.UseParent, "-d"},
+		{cfg.DryRun, "-n"},
+	} {
+		if opt.set {
+			args = append(args, opt.flag)
+		}
+	}
+	if cfg.Origin != "" {
+		args = append(args, "-o", "origin="+cfg.Origin)
+	}
+	for _, k := range slices.Sorted(maps.Keys(cfg.Properties)) {
+		args = append(args, "-o", k+"="+cfg.Properties[k])
+	}
+	for _, prop := range cfg.ExcludeProps {
+		args = append(args, "-x", prop)
+	}
+	return append(args, cfg.Target)
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stratastor/logger"
+)
+
+func TestStreamSendArgs(t *testing.T) {
+	tests := []struct {
+		cfg  SendConfig
+		want []string
+	}{
+		{
+			cfg:  SendConfig{Snapshot: "tank/db@daily", Raw: true, Verbose: true, Parsable: true},
+			want: []string{"send", "-w", "tank/db@daily"},
+		},
+		{
+			cfg:  SendConfig{Snapshot: "tank/db@b", FromSnapshot: "tank/db@a", Intermediary: true, Replicate: true, SkipMissing: true},
+			want: []string{"send", "-R", "-s", "-I", "tank/db@a", "tank/db@b"},
+		},
+		{
+			cfg:  SendConfig{Snapshot: "tank/db@b", FromSnapshot: "tank/db@a", SkipMissing: true, Compressed: true},
+			want: []string{"send", "-c", "-i", "tank/db@a", "tank/db@b"},
+		},
+		{
+			cfg:  SendConfig{Snapshot: "tank/db@b", ResumeToken: "1-abc", Raw: true},
+			want: []string{"send", "-t", "1-abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := streamSendArgs(tt.cfg); !slices.Equal(got, tt.want) {
+			t.Errorf("streamSendArgs() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestStreamReceiveArgs(t *testing.T) {
+	got := streamReceiveArgs(ReceiveConfig{
+		Target:       "backup/db",
+		Force:        true,
+		Resumable:    true,
+		Properties:   map[string]string{"readonly": "on", "compression": "zstd"},
+		ExcludeProps: []string{"mountpoint"},
+	})
+	want := []string{
+		"receive", "-F", "-s",
+		"-o", "compression=zstd", "-o", "readonly=on",
+		"-x", "mountpoint",
+		"backup/db",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("streamReceiveArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestWriteReceiveStream(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-streams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := &TransferManager{logger: l}
+
+	// cat stands in for zfs receive, echoing what it is given
+	s := &streamSession{info: StreamSession{Kind: StreamKindReceive, Target: "backup/db"}}
+	cmd := s.command("cat")
+	cmd.Stdout = &s.output
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cat not available: %v", err)
+	}
+	s.cmd, s.stdin, s.done = cmd, stdin, make(chan struct{})
+	go func() {
+		s.waitErr = cmd.Wait()
+		s.releaseGroup()
+		close(s.done)
+	}()
+	tm.addStream(s)
+	id := s.info.ID
+	ctx := context.Background()
+
+	session, err := tm.WriteReceiveStream(ctx, id, 0, -1, strings.NewReader("hello "))
+	if err != nil {
+		t.Fatalf("first chunk: %v", err)
+	}
+	if session.Offset != 6 || session.State != StreamStateOpen {
+		t.Fatalf("after first chunk: offset %d, state %s", session.Offset, session.State)
+	}
+
+	// A chunk sent again after a dropped response does not continue the stream
+	if _, err := tm.WriteReceiveStream(ctx, id, 0, -1, strings.NewReader("hello ")); err == nil {
+		t.Fatal("expected an offset mismatch for a repeated chunk")
+	}
+
+	session, err = tm.WriteReceiveStream(ctx, id, 6, 11, strings.NewReader("world"))
+	if err != nil {
+		t.Fatalf("last chunk: %v", err)
+	}
+	if session.State != StreamStateComplete {
+		t.Fatalf("reaching the total should finish the receive, state %s", session.State)
+	}
+	if got := s.output.String(); got != "hello world" {
+		t.Errorf("received %q, want %q", got, "hello world")
+	}
+
+	if _, err := tm.WriteReceiveStream(ctx, id, 11, -1, strings.NewReader("!")); err == nil {
+		t.Error("expected writes after completion to fail")
+	}
+	if err := tm.CloseStream(id); err != nil {
+		t.Errorf("CloseStream() = %v", err)
+	}
+	if _, err := tm.GetStream(id); err == nil {
+		t.Error("expected a closed stream to be gone")
+	}
+}
+
+func TestCloseStreamStopsProcessGroup(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-streams")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := &TransferManager{logger: l}
+
+	// sleep stands in for a receive waiting on a stream that never comes
+	s := &streamSession{info: StreamSession{Kind: StreamKindReceive, Target: "backup/db"}}
+	cmd := s.command("sleep", "60")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	s.cmd, s.done = cmd, make(chan struct{})
+	go func() {
+		s.waitErr = cmd.Wait()
+		s.releaseGroup()
+		close(s.done)
+	}()
+	tm.addStream(s)
+
+	start := time.Now()
+	if err := tm.CloseStream(s.info.ID); err != nil {
+		t.Fatalf("CloseStream() = %v", err)
+	}
+	if s.waitErr == nil {
+		t.Error("expected the receive to be stopped")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("stopping took %v", elapsed)
+	}
+}
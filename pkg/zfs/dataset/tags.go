@@ -0,0 +1,136 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kballard/go-shellquote"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// TagPrefix namespaces the user properties Rodent stamps on datasets and
+// snapshots
+const TagPrefix = "rodent:"
+
+// TagQuery selects snapshots by their user properties
+type TagQuery struct {
+	Name string `json:"name"` // Dataset whose snapshots, and its children's, are searched; empty searches all pools
+
+	// Tags maps user properties to the values snapshots must all have. An
+	// empty value matches any snapshot with the property set.
+	Tags map[string]string `json:"tags"`
+}
+
+// TagKey returns the user property of a tag, adding the rodent: namespace
+// to short keys such as policy_id
+func TagKey(key string) string {
+	if strings.Contains(key, ":") {
+		return key
+	}
+	return TagPrefix + key
+}
+
+// ParseTag parses a tag query of the form key=value, or key alone to match
+// any value
+func ParseTag(tag string) (key, value string, err error) {
+	key, value, _ = strings.Cut(tag, "=")
+	key = TagKey(strings.TrimSpace(key))
+	if key == TagPrefix || strings.HasPrefix(key, ":") {
+		return "", "", errors.New(errors.ZFSRequestValidationError,
+			"tag must be key=value or key").WithMetadata("tag", tag)
+	}
+	return key, value, nil
+}
+
+// ListSnapshotsByTags lists the snapshots having every tag of the query
+func (m *Manager) ListSnapshotsByTags(ctx context.Context, q TagQuery) (ListResult, error) {
+	if len(q.Tags) == 0 {
+		return ListResult{}, errors.New(errors.ZFSRequestValidationError,
+			"at least one tag is required")
+	}
+
+	props := []string{"name", "creation", "used", "referenced"}
+	for _, key := range slices.Sorted(maps.Keys(q.Tags)) {
+		if !slices.Contains(props, key) {
+			props = append(props, key)
+		}
+	}
+
+	result, err := m.List(ctx, ListConfig{
+		Name:       q.Name,
+		Type:       "snapshot",
+		Recursive:  true,
+		Properties: props,
+		Parsable:   true,
+	})
+	if err != nil {
+		return ListResult{}, err
+	}
+
+	maps.DeleteFunc(result.Datasets, func(_ string, ds Dataset) bool {
+		return !HasTags(ds, q.Tags)
+	})
+	return result, nil
+}
+
+// HasTags reports whether a dataset has every tag. An empty value matches
+// any value set on the dataset.
+func HasTags(ds Dataset, tags map[string]string) bool {
+	for key, want := range tags {
+		got, ok := TagValue(ds, key)
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+// TagValue returns the value of a user property, and whether it is set.
+// zfs reports unset user properties as "-".
+func TagValue(ds Dataset, key string) (string, bool) {
+	prop, ok := ds.Properties[key]
+	if !ok || prop.Value == nil {
+		return "", false
+	}
+	value := fmt.Sprint(prop.Value)
+	if value == "-" || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+// SetTags sets user properties on a dataset or snapshot with a single zfs
+// set, so they are applied together
+func (m *Manager) SetTags(ctx context.Context, name string, tags map[string]string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	args := []string{"set"}
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if !strings.Contains(key, ":") {
+			return errors.New(errors.ZFSRequestValidationError,
+				"tags must be user properties").WithMetadata("property", key)
+		}
+		args = append(args, fmt.Sprintf("%s=%s", key, shellquote.Join(tags[key])))
+	}
+	args = append(args, name)
+
+	out, err := m.executor.Execute(ctx, command.CommandOptions{}, "zfs set", args...)
+	if err != nil {
+		if len(out) > 0 {
+			return errors.Wrap(err, errors.ZFSDatasetSetProperty).
+				WithMetadata("output", string(out))
+		}
+		return errors.Wrap(err, errors.ZFSDatasetSetProperty)
+	}
+	return nil
+}
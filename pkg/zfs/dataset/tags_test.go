@@ -0,0 +1,76 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"testing"
+)
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag       string
+		wantKey   string
+		wantValue string
+		wantErr   bool
+	}{
+		{tag: "policy_id=abc", wantKey: "rodent:policy_id", wantValue: "abc"},
+		{tag: "retention_class", wantKey: "rodent:retention_class"},
+		{tag: "com.example:owner=ci=1", wantKey: "com.example:owner", wantValue: "ci=1"},
+		{tag: "=abc", wantErr: true},
+		{tag: ":x=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		key, value, err := ParseTag(tt.tag)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			continue
+		}
+		if key != tt.wantKey || value != tt.wantValue {
+			t.Errorf("ParseTag(%q) = %q, %q, want %q, %q", tt.tag, key, value, tt.wantKey, tt.wantValue)
+		}
+	}
+}
+
+func TestHasTags(t *testing.T) {
+	ds := Dataset{
+		Name: "tank/data@auto-2025-05-15-0-d1f36875b92f",
+		Properties: map[string]Property{
+			"rodent:policy_id":       {Value: "p1"},
+			"rodent:retention_class": {Value: "daily"},
+			"rodent:schedule":        {Value: "-"}, // Unset
+		},
+	}
+
+	tests := []struct {
+		tags map[string]string
+		want bool
+	}{
+		{tags: map[string]string{"rodent:policy_id": "p1"}, want: true},
+		{tags: map[string]string{"rodent:policy_id": "p1", "rodent:retention_class": "daily"}, want: true},
+		{tags: map[string]string{"rodent:policy_id": ""}, want: true},
+		{tags: map[string]string{"rodent:policy_id": "p2"}, want: false},
+		{tags: map[string]string{"rodent:schedule": ""}, want: false},
+		{tags: map[string]string{"com.example:owner": ""}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := HasTags(ds, tt.tags); got != tt.want {
+			t.Errorf("HasTags(%v) = %v, want %v", tt.tags, got, tt.want)
+		}
+	}
+}
+
+func TestIsPinned(t *testing.T) {
+	pinned := Dataset{Properties: map[string]Property{TagPinned: {Value: "legal hold"}}}
+	unset := Dataset{Properties: map[string]Property{TagPinned: {Value: "-"}}}
+
+	if !IsPinned(pinned) {
+		t.Error("IsPinned() = false for a pinned snapshot")
+	}
+	if IsPinned(unset) || IsPinned(Dataset{}) {
+		t.Error("IsPinned() = true for an unpinned snapshot")
+	}
+}
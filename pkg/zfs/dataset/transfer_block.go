@@ -0,0 +1,33 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// BlockTransfers refuses new transfers, seeds and streams until
+// UnblockTransfers is called, such as while the node is in maintenance.
+// Transfers already running carry on and can still be paused and resumed.
+func (tm *TransferManager) BlockTransfers(reason string) {
+	tm.blocked.Store(&reason)
+	tm.logger.Info("New transfers are blocked", "reason", reason)
+}
+
+// UnblockTransfers lets new transfers start again
+func (tm *TransferManager) UnblockTransfers() {
+	if tm.blocked.Swap(nil) != nil {
+		tm.logger.Info("New transfers are allowed again")
+	}
+}
+
+// checkBlocked returns an error while new transfers are blocked
+func (tm *TransferManager) checkBlocked() error {
+	reason := tm.blocked.Load()
+	if reason == nil {
+		return nil
+	}
+	return errors.New(errors.MaintenanceActive, "New transfers are blocked: "+*reason)
+}
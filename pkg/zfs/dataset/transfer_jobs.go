@@ -0,0 +1,120 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
+)
+
+// JobTypeTransferVerify confirms that a completed transfer's snapshot exists
+// on the target
+const JobTypeTransferVerify = "transfer-verify"
+
+// transferJobPayload identifies the transfer a job works on
+type transferJobPayload struct {
+	TransferID string `json:"transfer_id"`
+}
+
+// UseJobQueue runs post-transfer verification through the job queue, so a
+// target that is briefly unreachable is checked again later. Without a queue
+// verification is attempted once in a plain goroutine, and seeds are not
+// available.
+func (tm *TransferManager) UseJobQueue(q *jobs.Queue) {
+	q.Register(JobTypeTransferVerify, tm.runVerifyJob, jobs.RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Minute,
+		MaxBackoff:     15 * time.Minute,
+		Timeout:        2 * time.Minute,
+	})
+	tm.registerSeedJobs(q)
+	tm.jobQueue.Store(q)
+}
+
+// scheduleVerification verifies a completed transfer in the background
+func (tm *TransferManager) scheduleVerification(transferID string) {
+	q := tm.jobQueue.Load()
+	if q != nil {
+		_, err := q.Enqueue(JobTypeTransferVerify, transferJobPayload{TransferID: transferID})
+		if err == nil {
+			return
+		}
+		tm.logger.Warn("Failed to enqueue transfer verification, verifying directly",
+			"id", transferID,
+			"error", err)
+	}
+
+	go func() {
+		if err := tm.verifyTransfer(transferID); err != nil {
+			tm.logger.Warn("Transfer verification failed", "id", transferID, "error", err)
+		}
+	}()
+}
+
+// runVerifyJob verifies the transfer in the payload
+func (tm *TransferManager) runVerifyJob(ctx context.Context, payload json.RawMessage) error {
+	var p transferJobPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return jobs.Permanent(errors.Wrap(err, errors.JobInvalidPayload))
+	}
+	return tm.verifyTransfer(p.TransferID)
+}
+
+// verifyTransfer checks that the snapshot sent by a completed transfer exists
+// on the target and records the result on the transfer. Errors reaching the
+// target are returned for a retry; a missing snapshot is a permanent failure.
+func (tm *TransferManager) verifyTransfer(transferID string) error {
+	info, err := tm.GetTransfer(transferID)
+	if err != nil {
+		// Deleted since it completed
+		return jobs.Permanent(err)
+	}
+	if info.Status != TransferStatusCompleted {
+		return nil
+	}
+
+	exists, err := tm.snapshotExistsOnTarget(info.Config.SendConfig.Snapshot, info.Config.ReceiveConfig)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		verifyErr := errors.New(errors.ZFSDatasetReceive, "Transferred snapshot not found on target").
+			WithMetadata("transfer_id", transferID).
+			WithMetadata("snapshot", info.Config.SendConfig.Snapshot).
+			WithMetadata("target", info.Config.ReceiveConfig.Target)
+		info.VerifiedAt = nil
+		info.VerificationError = verifyErr.Error()
+		tm.recordVerification(info)
+		return jobs.Permanent(verifyErr)
+	}
+
+	now := time.Now()
+	info.VerifiedAt = &now
+	info.VerificationError = ""
+	if err := tm.recordVerification(info); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	tm.logger.Debug("Transfer verified on target", "id", transferID)
+	return nil
+}
+
+// recordVerification saves the verification result unless the transfer was
+// deleted while it was being verified
+func (tm *TransferManager) recordVerification(info *TransferInfo) error {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if _, err := os.Stat(info.ConfigFile); os.IsNotExist(err) {
+		return nil
+	}
+	return tm.saveTransferConfig(info)
+}
@@ -0,0 +1,27 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+// TransferListener is called with a snapshot of a transfer's state once it
+// has completed, failed or been cancelled
+type TransferListener func(info TransferInfo)
+
+// OnTransferFinished registers a listener for finished transfers. Listeners
+// run in their own goroutine so a slow listener cannot hold up transfers.
+func (tm *TransferManager) OnTransferFinished(fn TransferListener) {
+	tm.listenersMu.Lock()
+	defer tm.listenersMu.Unlock()
+	tm.listeners = append(tm.listeners, fn)
+}
+
+// notifyTransferFinished passes a finished transfer to the registered listeners
+func (tm *TransferManager) notifyTransferFinished(info TransferInfo) {
+	tm.listenersMu.RLock()
+	defer tm.listenersMu.RUnlock()
+
+	for _, fn := range tm.listeners {
+		go fn(info)
+	}
+}
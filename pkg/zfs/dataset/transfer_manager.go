@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -24,9 +25,13 @@ import (
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
+	generalCmd "github.com/stratastor/rodent/internal/command"
 	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/internal/crash"
 	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/internal/lockwatch"
 	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/jobs"
 	"github.com/stratastor/rodent/pkg/zfs/command"
 	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
 )
@@ -75,6 +80,16 @@ type TransferInfo struct {
 	ProgressFile string            `json:"progress_file"            yaml:"progress_file"`
 	ErrorMessage string            `json:"error_message,omitempty"  yaml:"error_message,omitempty"`
 	SizeInfo     *TransferSizeInfo `json:"size_info,omitempty"      yaml:"size_info,omitempty"` // Transfer size calculated via dry-run
+	// Resume token of partially received state left on the target after a failure
+	PartialReceiveToken string `json:"partial_receive_token,omitempty" yaml:"partial_receive_token,omitempty"`
+	// Kind of failure of a failed transfer, such as network or no_space
+	ErrorCategory errors.Category `json:"error_category,omitempty" yaml:"error_category,omitempty"`
+	// When the sent snapshot was last confirmed on the target after completion
+	VerifiedAt *time.Time `json:"verified_at,omitempty" yaml:"verified_at,omitempty"`
+	// Why the sent snapshot could not be confirmed on the target
+	VerificationError string `json:"verification_error,omitempty" yaml:"verification_error,omitempty"`
+	// Set on records of replication done before Rodent managed it; nothing was sent
+	Imported bool `json:"imported,omitempty" yaml:"imported,omitempty"`
 	// Internal state for action flow tracking
 	pendingAction TransferAction `json:"-"                        yaml:"-"`
 }
@@ -89,6 +104,16 @@ type TransferProgress struct {
 	EstimatedETA     int64     `json:"estimated_eta,omitempty"     yaml:"estimated_eta,omitempty"`
 	Phase            string    `json:"phase,omitempty"             yaml:"phase,omitempty"`
 	PhaseDescription string    `json:"phase_description,omitempty" yaml:"phase_description,omitempty"`
+	PercentComplete  float64   `json:"percent_complete,omitempty"  yaml:"percent_complete,omitempty"`
+	// Snapshot being sent and its dataset, one of several in a replication stream
+	CurrentSnapshot string `json:"current_snapshot,omitempty" yaml:"current_snapshot,omitempty"`
+	CurrentDataset  string `json:"current_dataset,omitempty"  yaml:"current_dataset,omitempty"`
+	SnapshotsSent   int    `json:"snapshots_sent,omitempty"   yaml:"snapshots_sent,omitempty"`
+	SnapshotsTotal  int    `json:"snapshots_total,omitempty"  yaml:"snapshots_total,omitempty"`
+	// Progress of each dataset of a replication stream, in the order they are sent
+	Datasets []DatasetProgress `json:"datasets,omitempty" yaml:"datasets,omitempty"`
+	// When bytes were last sent; a transfer that is not stuck keeps it recent
+	LastAdvanceAt *time.Time `json:"last_advance_at,omitempty" yaml:"last_advance_at,omitempty"`
 }
 
 // TransferSizeInfo represents size calculation details for transfer metrics.
@@ -112,10 +137,24 @@ const (
 
 // TransferManager manages enterprise-grade ZFS transfer operations
 type TransferManager struct {
-	mu              sync.RWMutex
+	mu              lockwatch.RWMutex
 	activeTransfers map[string]*TransferInfo
 	transfersDir    string
 	logger          logger.Logger
+	executor        *command.CommandExecutor
+	jobQueue        atomic.Pointer[jobs.Queue]
+	delegation      atomic.Pointer[Delegation]
+
+	// blocked is why new transfers are refused; nil while they are allowed
+	blocked atomic.Pointer[string]
+
+	// listeners are notified of finished transfers
+	listenersMu sync.RWMutex
+	listeners   []TransferListener
+
+	// streams are the sends and receives carried over HTTP
+	streamsMu sync.Mutex
+	streams   map[string]*streamSession
 }
 
 // NewTransferManager creates a new transfer manager instance
@@ -127,9 +166,12 @@ func NewTransferManager(logCfg logger.Config) (*TransferManager, error) {
 
 	tm := &TransferManager{
 		activeTransfers: make(map[string]*TransferInfo),
+		streams:         make(map[string]*streamSession),
 		transfersDir:    config.GetTransfersDir(),
-		logger:          l,
+		logger:          common.WithRedaction(l),
+		executor:        command.NewCommandExecutor(true, logCfg),
 	}
+	lockwatch.Watch("transfer-manager", &tm.mu)
 
 	// Load existing transfers from disk
 	if err := tm.loadExistingTransfers(); err != nil {
@@ -181,12 +223,38 @@ func (tm *TransferManager) startTransferInternal(
 	if err := validateReceiveConfig(cfg.ReceiveConfig); err != nil {
 		return "", err
 	}
+	if err := validateResourceLimits(cfg.ResourceLimits); err != nil {
+		return "", err
+	}
+	if err := validateDirection(cfg); err != nil {
+		return "", err
+	}
 	if cfg.ReceiveConfig.RemoteConfig.Host != "" {
 		if err := validateSSHConfig(cfg.ReceiveConfig.RemoteConfig); err != nil {
 			return "", err
 		}
 	}
 
+	if err := tm.checkBlocked(); err != nil {
+		return "", err
+	}
+
+	// Transfers run zfs send and receive in a detached shell pipeline, which
+	// cannot be simulated
+	if generalCmd.DryRun() {
+		tm.logger.Info("Dry run: would start transfer",
+			"snapshot", cfg.SendConfig.Snapshot,
+			"target", cfg.ReceiveConfig.Target,
+			"remote_host", cfg.ReceiveConfig.RemoteConfig.Host)
+		return "", errors.New(errors.OperationFailed, "Transfers are not run in dry-run mode")
+	}
+
+	if adaptReceiveConfig(&cfg.ReceiveConfig) {
+		transferInfo.Config = cfg
+		tm.logger.Warn("Installed OpenZFS cannot resume receives; transfer will not be resumable",
+			"target", cfg.ReceiveConfig.Target)
+	}
+
 	// Ensure receive config has resumable flag for pause/resume functionality
 	if !cfg.ReceiveConfig.Resumable {
 		tm.logger.Warn(
@@ -200,6 +268,15 @@ func (tm *TransferManager) startTransferInternal(
 		transferInfo.SizeInfo = sizeInfo
 	}
 
+	// Refuse transfers that would not fit on the target instead of failing
+	// hours into the stream
+	if transferInfo.SizeInfo != nil && !cfg.SkipSpaceCheck && !cfg.SendConfig.DryRun &&
+		!cfg.ReceiveConfig.DryRun {
+		if err := tm.checkTargetFreeSpace(cfg, transferInfo.SizeInfo.CalculatedTransferSize); err != nil {
+			return "", err
+		}
+	}
+
 	// Save transfer configuration
 	if err := tm.saveTransferConfig(transferInfo); err != nil {
 		return "", err
@@ -210,8 +287,10 @@ func (tm *TransferManager) startTransferInternal(
 	tm.activeTransfers[transferID] = transferInfo
 	tm.mu.Unlock()
 
-	// Start transfer in background
-	go tm.executeTransfer(ctx, transferInfo)
+	// Start transfer in background. It outlives the request or job starting
+	// it and is stopped with PauseTransfer or StopTransfer instead.
+	runCtx := context.WithoutCancel(ctx)
+	crash.Go("transfers", func() { tm.executeTransfer(runCtx, transferInfo) })
 
 	tm.logger.Info("Transfer initiated", "id", transferID)
 
@@ -275,6 +354,52 @@ func (tm *TransferManager) CreateSkippedTransfer(
 	return transferID, nil
 }
 
+// CreateImportedTransfer records replication done outside Rodent as a
+// completed transfer of cfg's snapshot, at the time the snapshot was taken,
+// so the policy's history starts from it. Nothing is sent, and transfer
+// listeners are not told, as no run took place.
+func (tm *TransferManager) CreateImportedTransfer(
+	cfg TransferConfig,
+	policyID string,
+	at time.Time,
+) (string, error) {
+	transferID := common.UUID7()
+	now := time.Now()
+
+	transferInfo := &TransferInfo{
+		ID:           transferID,
+		PolicyID:     policyID,
+		Status:       TransferStatusCompleted,
+		Config:       cfg,
+		Progress:     TransferProgress{LastUpdate: now},
+		CreatedAt:    at,
+		StartedAt:    &at,
+		CompletedAt:  &at,
+		VerifiedAt:   &now, // The snapshot was found on the target by the import
+		Imported:     true,
+		LogFile:      filepath.Join(tm.transfersDir, fmt.Sprintf("%s.log", transferID)),
+		PIDFile:      filepath.Join(tm.transfersDir, fmt.Sprintf("%s.pid", transferID)),
+		ConfigFile:   filepath.Join(tm.transfersDir, fmt.Sprintf("%s.yaml", transferID)),
+		ProgressFile: filepath.Join(tm.transfersDir, fmt.Sprintf("%s.progress", transferID)),
+	}
+
+	if err := tm.saveTransferConfig(transferInfo); err != nil {
+		return "", err
+	}
+
+	tm.mu.Lock()
+	tm.activeTransfers[transferID] = transferInfo
+	tm.mu.Unlock()
+
+	tm.logger.Info("Imported existing replication as a completed transfer",
+		"id", transferID,
+		"policy_id", policyID,
+		"snapshot", cfg.SendConfig.Snapshot,
+		"target", cfg.ReceiveConfig.Target)
+
+	return transferID, nil
+}
+
 // executeTransfer runs the actual ZFS transfer operation
 func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferInfo) {
 	defer tm.handleTransferCompletion(info)
@@ -310,7 +435,7 @@ func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferIn
 			tm.logger.Info("Initial snapshot missing on target, performing automatic initial send", "id", info.ID, "snapshot", sendCfg.FromSnapshot)
 
 			// Update progress to show initial send phase
-			info.Progress.Phase = "initial_send"
+			info.Progress.Phase = ProgressPhaseInitialSend
 			info.Progress.PhaseDescription = fmt.Sprintf("Sending initial snapshot: %s", sendCfg.FromSnapshot)
 			info.Progress.LastUpdate = time.Now()
 			tm.saveProgress(info)
@@ -335,7 +460,7 @@ func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferIn
 			}
 
 			// Update progress to show incremental phase
-			info.Progress.Phase = "incremental_send"
+			info.Progress.Phase = ProgressPhaseIncrementalSend
 			info.Progress.PhaseDescription = fmt.Sprintf("Sending incremental changes from %s to %s", sendCfg.FromSnapshot, sendCfg.Snapshot)
 			info.Progress.LastUpdate = time.Now()
 			tm.saveProgress(info)
@@ -345,14 +470,14 @@ func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferIn
 			tm.logger.Debug("Initial snapshot exists on target, proceeding with incremental transfer", "id", info.ID)
 
 			// Update progress to show incremental phase
-			info.Progress.Phase = "incremental_send"
+			info.Progress.Phase = ProgressPhaseIncrementalSend
 			info.Progress.PhaseDescription = fmt.Sprintf("Sending incremental changes from %s to %s", sendCfg.FromSnapshot, sendCfg.Snapshot)
 			info.Progress.LastUpdate = time.Now()
 			tm.saveProgress(info)
 		}
 	} else {
 		// Not an incremental transfer - set phase for full send
-		info.Progress.Phase = "full_send"
+		info.Progress.Phase = ProgressPhaseFullSend
 		if sendCfg.ResumeToken != "" {
 			info.Progress.PhaseDescription = "Resuming transfer from saved state"
 		} else {
@@ -438,7 +563,7 @@ func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferIn
 	tm.logger.Debug("Transfer PID saved", "id", info.ID, "pid", info.PID)
 
 	// Monitor progress in background
-	go tm.monitorTransferProgress(info, logFile)
+	crash.Go("transfers", func() { tm.monitorTransferProgress(info, logFile) })
 
 	// Setup signal handling for verbose output and system interrupts
 	sigChan := make(chan os.Signal, 1)
@@ -534,7 +659,18 @@ func (tm *TransferManager) executeTransfer(ctx context.Context, info *TransferIn
 				return
 			}
 
-			tm.updateTransferStatusLocked(info, TransferStatusFailed, fmt.Sprintf("Transfer failed: %v", err))
+			partialToken := tm.cleanupFailedReceive(info, err)
+			tm.mu.Lock()
+			info.PartialReceiveToken = partialToken
+			// The command's own error only holds the exit status; the log has the cause
+			info.ErrorCategory = errors.Classify(logContent)
+			tm.mu.Unlock()
+
+			reason := err.Error()
+			if failure := hostKeyFailure(logContent); failure != "" {
+				reason = failure
+			}
+			tm.updateTransferStatusLocked(info, TransferStatusFailed, "Transfer failed: "+reason)
 			tm.logger.Error("Status Update: Transfer failed", "id", info.ID, "error", err)
 		}
 	} else {
@@ -680,23 +816,48 @@ func (tm *TransferManager) buildTransferCommand(info *TransferInfo) (*exec.Cmd,
 	sendPart = sanitizeCommandArgs(sendPart)
 	recvPart = sanitizeCommandArgs(recvPart)
 
+	// Resource limits only apply to processes on this host; a remote sender
+	// or receiver is governed by the remote host's own policy
+	limitPrefix := buildResourceLimitPrefix(info.Config.ResourceLimits)
+	if len(limitPrefix) > 0 {
+		if !info.Config.IsPull() {
+			sendPart = append(append([]string{}, limitPrefix...), sendPart...)
+		}
+		if recvCfg.RemoteConfig.Host == "" {
+			recvPart = append(append([]string{}, limitPrefix...), recvPart...)
+		}
+	}
+
 	// Build full command
 	var cmdStr string
-	if recvCfg.RemoteConfig.Host != "" {
+	if info.Config.IsPull() {
+		// The remote zfs is found through the remote PATH
+		sendPart[0] = "zfs"
+		sshPart, err := BuildSSHCommand(sendCfg.RemoteConfig)
+		if err != nil {
+			return nil, err
+		}
+		cmdStr = fmt.Sprintf("%s sudo %s | sudo %s",
+			shellquote.Join(sshPart...),
+			shellquote.Join(sendPart...),
+			shellquote.Join(recvPart...))
+	} else if recvCfg.RemoteConfig.Host != "" {
 		sshPart, err := BuildSSHCommand(recvCfg.RemoteConfig)
 		if err != nil {
 			return nil, err
 		}
-		cmdStr = fmt.Sprintf("sudo %s | %s sudo %s",
+		cmdStr = fmt.Sprintf("%s%s | %s sudo %s",
+			tm.sendSudo(sendCfg, len(limitPrefix) > 0),
 			shellquote.Join(sendPart...),
 			shellquote.Join(sshPart...),
 			shellquote.Join(recvPart...))
 	} else {
-		cmdStr = fmt.Sprintf("sudo %s | sudo %s",
+		cmdStr = fmt.Sprintf("%s%s | sudo %s",
+			tm.sendSudo(sendCfg, len(limitPrefix) > 0),
 			shellquote.Join(sendPart...),
 			shellquote.Join(recvPart...))
 	}
-	tm.logger.Debug("Built transfer command", "command", cmdStr)
+	tm.logger.Debug("Built transfer command", "command", generalCmd.RedactCommandLine(cmdStr))
 	return exec.Command("bash", "-c", cmdStr), nil
 }
 
@@ -747,9 +908,20 @@ func (tm *TransferManager) calculateTransferSize(cfg TransferConfig) (*TransferS
 
 	// Sanitize and build command
 	sendPart = sanitizeCommandArgs(sendPart)
-	cmdStr := fmt.Sprintf("sudo %s", shellquote.Join(sendPart...))
+	var cmdStr string
+	if cfg.IsPull() {
+		// Pull transfers size the stream on the source host
+		sendPart[0] = "zfs"
+		sshPart, err := BuildSSHCommand(sendCfg.RemoteConfig)
+		if err != nil {
+			return nil, err
+		}
+		cmdStr = fmt.Sprintf("%s sudo %s", shellquote.Join(sshPart...), shellquote.Join(sendPart...))
+	} else {
+		cmdStr = tm.sendSudo(sendCfg, false) + shellquote.Join(sendPart...)
+	}
 
-	tm.logger.Debug("Calculating transfer size via dry-run", "command", cmdStr)
+	tm.logger.Debug("Calculating transfer size via dry-run", "command", generalCmd.RedactCommandLine(cmdStr))
 
 	// Execute dry-run
 	cmd := exec.Command("bash", "-c", cmdStr)
@@ -809,6 +981,114 @@ func (tm *TransferManager) calculateTransferSize(cfg TransferConfig) (*TransferS
 	return sizeInfo, nil
 }
 
+// spaceCheckHeadroomPercent is the extra space, relative to the estimated
+// stream size, required on the target before a transfer is started
+const spaceCheckHeadroomPercent = 5
+
+// checkTargetFreeSpace compares the estimated stream size against the space
+// available to the receiving dataset. Failures to query the target are logged
+// and ignored; the receive itself will surface connectivity problems.
+func (tm *TransferManager) checkTargetFreeSpace(cfg TransferConfig, streamSize int64) error {
+	recvCfg := cfg.ReceiveConfig
+
+	// The receive creates the target under its parent, unless -d is used in
+	// which case the target itself is the parent
+	spaceDataset := recvCfg.Target
+	if !recvCfg.UseParent {
+		if idx := strings.LastIndex(recvCfg.Target, "/"); idx > 0 {
+			spaceDataset = recvCfg.Target[:idx]
+		}
+	}
+
+	available, err := tm.getTargetAvailableSpace(spaceDataset, recvCfg.RemoteConfig)
+	if err != nil {
+		tm.logger.Warn("Could not determine free space on target, skipping space check",
+			"dataset", spaceDataset,
+			"error", err)
+		return nil
+	}
+
+	required := streamSize + streamSize*spaceCheckHeadroomPercent/100
+	if available < required {
+		return errors.New(errors.TransferInsufficientSpace,
+			fmt.Sprintf("target %s has %d bytes available, transfer requires %d bytes",
+				spaceDataset, available, required)).
+			WithMetadata("target", spaceDataset).
+			WithMetadata("available_bytes", strconv.FormatInt(available, 10)).
+			WithMetadata("required_bytes", strconv.FormatInt(required, 10))
+	}
+
+	tm.logger.Debug("Target free space check passed",
+		"dataset", spaceDataset,
+		"available", available,
+		"required", required)
+	return nil
+}
+
+// getTargetAvailableSpace returns the space a new child of a dataset on the
+// receiving side can use, querying over SSH when the target is remote. The
+// unused part of the dataset's refreservation counts towards `available` but
+// is held for the dataset's own data, so it is left out. A reservation covers
+// children as well and needs no adjustment.
+func (tm *TransferManager) getTargetAvailableSpace(
+	datasetName string,
+	remoteCfg RemoteConfig,
+) (int64, error) {
+	values, err := tm.getTargetSpace(datasetName, remoteCfg, "available", "usedbyrefreservation")
+	if err != nil {
+		return 0, err
+	}
+	return max(values[0]-values[1], 0), nil
+}
+
+// TargetPoolFreePercent returns the free space of the pool a transfer
+// receives into, as a percentage of the pool's usable space
+func (tm *TransferManager) TargetPoolFreePercent(cfg TransferConfig) (float64, error) {
+	pool, _, _ := strings.Cut(cfg.ReceiveConfig.Target, "/")
+	values, err := tm.getTargetSpace(pool, cfg.ReceiveConfig.RemoteConfig, "used", "available")
+	if err != nil {
+		return 0, err
+	}
+	used, available := values[0], values[1]
+	if used+available <= 0 {
+		return 0, fmt.Errorf("pool %s reports no usable space", pool)
+	}
+	return float64(available) * 100 / float64(used+available), nil
+}
+
+// getTargetSpace returns numeric space properties of a dataset on the
+// receiving side, in the order asked for, querying over SSH when the target
+// is remote
+func (tm *TransferManager) getTargetSpace(
+	datasetName string,
+	remoteCfg RemoteConfig,
+	props ...string,
+) ([]int64, error) {
+	propList := strings.Join(props, ",")
+
+	output, err := runTargetZFS(tm, context.Background(), remoteCfg, "get", "-Hp", "-o", "value", propList, datasetName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s for %s: %w", propList, datasetName, err)
+	}
+
+	lines := strings.Fields(string(output))
+	if len(lines) != len(props) {
+		return nil, fmt.Errorf("failed to parse %s %q", propList, string(output))
+	}
+	values := make([]int64, len(props))
+	for i, line := range lines {
+		// zfs reports properties without a value, such as space on an
+		// unavailable dataset, as "-"
+		if line == "-" {
+			return nil, fmt.Errorf("%s of %s has no value", props[i], datasetName)
+		}
+		if values[i], err = strconv.ParseInt(line, 10, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse %s %q: %w", props[i], line, err)
+		}
+	}
+	return values, nil
+}
+
 // PauseTransfer pauses a running transfer gracefully without fetching resume token
 func (tm *TransferManager) PauseTransfer(transferID string) error {
 	tm.mu.Lock()
@@ -958,11 +1238,7 @@ func (tm *TransferManager) ResumeTransfer(ctx context.Context, transferID string
 	)
 	if err != nil {
 		// Check if error is due to dataset being busy (transient - don't fail transfer)
-		errStr := err.Error()
-		isBusy := strings.Contains(errStr, "dataset is busy") ||
-			strings.Contains(errStr, "resource busy")
-
-		if isBusy {
+		if errors.CategoryOf(err) == errors.CategoryBusy {
 			// Dataset still cleaning up from pause - don't fail, let user retry
 			tm.logger.Warn("Dataset busy, resume can be retried",
 				"id", transferID,
@@ -971,7 +1247,7 @@ func (tm *TransferManager) ResumeTransfer(ctx context.Context, transferID string
 			return errors.New(
 				errors.TransferResumeFailed,
 				"Dataset busy - ZFS is still processing the paused receive. Please retry resume in a few seconds",
-			)
+			).WithCategory(errors.CategoryBusy)
 		}
 
 		// Non-transient error - fail the transfer
@@ -996,12 +1272,14 @@ func (tm *TransferManager) ResumeTransfer(ctx context.Context, transferID string
 	info.pendingAction = TransferActionNone
 	info.Status = TransferStatusRunning
 	info.ErrorMessage = ""
+	info.ErrorCategory = ""
 
 	// Emit transfer resumed event before starting execution
 	tm.emitTransferEvent(info, eventspb.DataTransferTransferPayload_DATA_TRANSFER_OPERATION_RESUMED)
 
-	// Start transfer in background
-	go tm.executeTransfer(ctx, info)
+	// Start transfer in background, detached from the caller like a new one
+	runCtx := context.WithoutCancel(ctx)
+	crash.Go("transfers", func() { tm.executeTransfer(runCtx, info) })
 
 	tm.logger.Info(
 		"Transfer resumed",
@@ -1175,6 +1453,51 @@ func (tm *TransferManager) GetTransfer(transferID string) (*TransferInfo, error)
 	return nil, errors.New(errors.TransferNotFound, "Transfer not found")
 }
 
+// GetTransferProgress returns the versioned progress of a transfer: that of
+// a running transfer as last read from its log, or the one saved with it
+func (tm *TransferManager) GetTransferProgress(transferID string) (*TransferProgressReport, error) {
+	tm.mu.RLock()
+	if info, exists := tm.activeTransfers[transferID]; exists {
+		report := progressReport(info)
+		tm.mu.RUnlock()
+		return report, nil
+	}
+	tm.mu.RUnlock()
+
+	report, err := ReadProgressFile(tm.transfersDir, transferID)
+	if err == nil && report.Version == ProgressSchemaVersion {
+		return report, nil
+	}
+
+	// Transfers without a current progress document keep their progress
+	// in their record
+	info, infoErr := tm.GetTransfer(transferID)
+	if infoErr != nil {
+		if err != nil {
+			return nil, err
+		}
+		return nil, infoErr
+	}
+	return progressReport(info), nil
+}
+
+// Redacted returns a copy of the transfer for API responses, with its
+// configuration redacted
+func (t *TransferInfo) Redacted() *TransferInfo {
+	redacted := *t
+	redacted.Config = t.Config.Redacted()
+	return &redacted
+}
+
+// RedactTransfers returns redacted copies of the transfers for API responses
+func RedactTransfers(transfers []*TransferInfo) []*TransferInfo {
+	redacted := make([]*TransferInfo, len(transfers))
+	for i, t := range transfers {
+		redacted[i] = t.Redacted()
+	}
+	return redacted
+}
+
 // ListTransfers returns a list of all transfers
 func (tm *TransferManager) ListTransfers() []*TransferInfo {
 	return tm.ListTransfersByType(TransferTypeActive)
@@ -1462,29 +1785,13 @@ func (tm *TransferManager) snapshotExistsOnTarget(
 	// Build target snapshot name using receive target
 	targetSnapshot := fmt.Sprintf("%s@%s", recvCfg.Target, parts[1])
 
-	var cmd *exec.Cmd
-
-	if recvCfg.RemoteConfig.Host != "" {
-		// Remote target - use SSH
-		sshPart, err := BuildSSHCommand(recvCfg.RemoteConfig)
-		if err != nil {
-			return false, fmt.Errorf("failed to build SSH command: %w", err)
-		}
-
-		cmdStr := fmt.Sprintf("%s sudo zfs list -H -t snapshot %s",
-			shellquote.Join(sshPart...), shellquote.Join(targetSnapshot))
-		tm.logger.Debug("Checking remote snapshot existence", "command", cmdStr)
-		cmd = exec.Command("bash", "-c", cmdStr)
-	} else {
-		// Local target
-		tm.logger.Debug("Checking local snapshot existence", "snapshot", targetSnapshot)
-		cmd = exec.Command("sudo", "zfs", "list", "-H", "-t", "snapshot", targetSnapshot)
-	}
-
-	err := cmd.Run()
+	tm.logger.Debug("Checking snapshot existence on target",
+		"snapshot", targetSnapshot,
+		"remote_host", recvCfg.RemoteConfig.Host)
+	_, err := tm.TargetZFS(context.Background(), recvCfg.RemoteConfig, "list", "-H", "-t", "snapshot", targetSnapshot)
 	if err != nil {
-		// Exit code 1 typically means snapshot doesn't exist
-		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+		// A missing snapshot is reported as not found
+		if errors.CategoryOf(err) == errors.CategoryNotFound {
 			return false, nil
 		}
 		// Other errors (network, permissions, etc.)
@@ -1520,12 +1827,15 @@ func (tm *TransferManager) performInitialSend(
 			Parsable:     info.Config.SendConfig.Parsable,
 			Timeout:      info.Config.SendConfig.Timeout,
 			LogLevel:     info.Config.SendConfig.LogLevel,
+			RemoteConfig: info.Config.SendConfig.RemoteConfig,
 			// Explicitly clear incremental settings
 			FromSnapshot: "",
 			Intermediary: false,
 			Incremental:  false,
 		},
-		ReceiveConfig: info.Config.ReceiveConfig, // Use same receive config
+		Direction:      info.Config.Direction,
+		ReceiveConfig:  info.Config.ReceiveConfig, // Use same receive config
+		ResourceLimits: info.Config.ResourceLimits,
 	}
 
 	// Create temporary transfer info for initial send
@@ -1652,6 +1962,11 @@ func (tm *TransferManager) updateTransferStatusUnlocked(
 ) {
 	info.Status = status
 	info.ErrorMessage = errorMsg
+	if status != TransferStatusFailed {
+		info.ErrorCategory = ""
+	} else if info.ErrorCategory == "" {
+		info.ErrorCategory = errors.Classify(errorMsg)
+	}
 
 	// Map transfer status to event operation
 	var operation eventspb.DataTransferTransferPayload_DataTransferOperation
@@ -1684,6 +1999,16 @@ func (tm *TransferManager) updateTransferStatusUnlocked(
 
 	// Save updated config
 	tm.saveTransferConfig(info)
+
+	// Keep the progress document's status in step
+	if status == TransferStatusCompleted {
+		info.Progress.PercentComplete = 100
+		info.Progress.EstimatedETA = 0
+		markSent(&info.Progress)
+	}
+	if info.ProgressFile != "" {
+		writeProgressFile(info.ProgressFile, progressReport(info))
+	}
 }
 
 // getReceiveResumeTokenWithRetry gets the resume token with retry logic for network resilience
@@ -1717,40 +2042,12 @@ func (tm *TransferManager) getReceiveResumeToken(
 	target string,
 	remoteConfig RemoteConfig,
 ) (string, error) {
-	var cmd *exec.Cmd
-
-	if remoteConfig.Host != "" {
-		// Remote dataset
-		sshPart, err := BuildSSHCommand(remoteConfig)
-		if err != nil {
-			return "", err
-		}
-
-		cmdStr := fmt.Sprintf("%s sudo zfs get -H -o value receive_resume_token %s",
-			shellquote.Join(sshPart...), shellquote.Join(target))
-		tm.logger.Debug("Executing remote command to get resume token", "command", cmdStr)
-		cmd = exec.Command("bash", "-c", cmdStr)
-	} else {
-		tm.logger.Debug("Executing local command to get resume token", "target", target)
-		// Local dataset
-		cmd = exec.Command("sudo", "zfs", "get", "-H", "-o", "value", "receive_resume_token", target)
-	}
-
-	// Capture both stdout and stderr for better error reporting
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		stderrStr := strings.TrimSpace(stderr.String())
-		if stderrStr != "" {
-			tm.logger.Debug("Get resume token stderr", "stderr", stderrStr, "target", target)
-			return "", fmt.Errorf("%w: %s", err, stderrStr)
-		}
+	output, err := tm.TargetZFS(context.Background(), remoteConfig, "get", "-H", "-o", "value", "receive_resume_token", target)
+	if err != nil {
 		return "", err
 	}
 
-	token := strings.TrimSpace(stdout.String())
+	token := strings.TrimSpace(string(output))
 	if token == "-" {
 		return "", errors.New(errors.ZFSDatasetNoReceiveToken, "No resume token available")
 	}
@@ -1774,13 +2071,9 @@ func (tm *TransferManager) abortPartialReceive(target string, remoteConfig Remot
 		}
 
 		lastErr = err
-		errStr := err.Error()
 
 		// Check if error is due to dataset being busy (needs retry)
-		isBusy := strings.Contains(errStr, "dataset is busy") ||
-			strings.Contains(errStr, "resource busy")
-
-		if !isBusy {
+		if errors.CategoryOf(err) != errors.CategoryBusy {
 			// Non-busy error - return immediately
 			return err
 		}
@@ -1798,60 +2091,122 @@ func (tm *TransferManager) abortPartialReceive(target string, remoteConfig Remot
 	return fmt.Errorf("failed to abort after %d attempts (dataset busy): %w", maxRetries, lastErr)
 }
 
+// cleanupFailedReceive inspects the target for partially received state after
+// a failed transfer. When the receive config asks for it, the partial state is
+// aborted; otherwise, or when the abort is unsafe or fails, the leftover
+// resume token is returned so it can be surfaced in the transfer status.
+func (tm *TransferManager) cleanupFailedReceive(info *TransferInfo, cmdErr error) string {
+	recvCfg := info.Config.ReceiveConfig
+	if !recvCfg.Resumable || recvCfg.DryRun || info.Config.SendConfig.DryRun {
+		// Without -s a failed receive leaves no resumable state behind
+		return ""
+	}
+
+	token, err := tm.getReceiveResumeToken(recvCfg.Target, recvCfg.RemoteConfig)
+	if err != nil || token == "" {
+		return ""
+	}
+
+	if !recvCfg.AbortPartialOnFailure {
+		tm.logger.Warn("Failed transfer left partial receive state on target",
+			"id", info.ID,
+			"target", recvCfg.Target)
+		return token
+	}
+
+	// ssh exits with 255 when the connection drops. The partial state is then
+	// still resumable, so keep it rather than discard the data already sent.
+	if exitErr, ok := cmdErr.(*exec.ExitError); ok && recvCfg.RemoteConfig.Host != "" &&
+		exitErr.ExitCode() == 255 {
+		tm.logger.Info("Keeping partial receive state after connection loss",
+			"id", info.ID,
+			"target", recvCfg.Target)
+		return token
+	}
+
+	if err := tm.abortPartialReceive(recvCfg.Target, recvCfg.RemoteConfig); err != nil {
+		tm.logger.Warn("Failed to abort partial receive after transfer failure",
+			"id", info.ID,
+			"target", recvCfg.Target,
+			"error", err)
+		return token
+	}
+
+	tm.logger.Info("Aborted partial receive after transfer failure",
+		"id", info.ID,
+		"target", recvCfg.Target)
+	return ""
+}
+
 // abortPartialReceiveOnce attempts to abort a partial receive once
 func (tm *TransferManager) abortPartialReceiveOnce(target string, remoteConfig RemoteConfig) error {
-	var cmd *exec.Cmd
+	_, err := tm.TargetZFS(context.Background(), remoteConfig, "receive", "-A", target)
+	return err
+}
 
-	if remoteConfig.Host != "" {
-		// Remote dataset
-		sshPart, err := BuildSSHCommand(remoteConfig)
-		if err != nil {
-			return err
-		}
+// runTargetZFS runs zfs on the receiving side for the space checks;
+// replaced in tests
+var runTargetZFS = (*TransferManager).TargetZFS
 
-		cmdStr := fmt.Sprintf("%s sudo zfs receive -A %s",
-			shellquote.Join(sshPart...), shellquote.Join(target))
-		tm.logger.Debug("Executing remote command to abort partial receive", "command", cmdStr)
-		cmd = exec.Command("bash", "-c", cmdStr)
-	} else {
-		tm.logger.Debug("Executing local command to abort partial receive", "target", target)
-		// Local dataset
-		cmd = exec.Command("sudo", "zfs", "receive", "-A", target)
+// TargetZFS runs a zfs command on the receiving side of a transfer and
+// returns its output: through the ZFS executor for a local target, or with
+// sudo over SSH for a remote one. Like the executor, it only runs read-only
+// commands in dry-run mode.
+func (tm *TransferManager) TargetZFS(
+	ctx context.Context,
+	remoteConfig RemoteConfig,
+	subcommand string,
+	args ...string,
+) ([]byte, error) {
+	if remoteConfig.Host == "" {
+		return tm.executor.Execute(ctx, command.CommandOptions{}, "zfs "+subcommand, args...)
+	}
+
+	zfsArgs := append([]string{subcommand}, args...)
+	if generalCmd.SkipInDryRun(tm.logger, "zfs", zfsArgs) {
+		return nil, nil
+	}
+
+	sshPart, err := BuildSSHCommand(remoteConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build SSH command: %w", err)
 	}
+	cmdStr := fmt.Sprintf("%s sudo zfs %s", shellquote.Join(sshPart...), shellquote.Join(zfsArgs...))
+	tm.logger.Debug("Executing remote command", "command", generalCmd.RedactCommandLine(cmdStr))
 
-	// Capture both stdout and stderr for better error reporting
 	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "bash", "-c", cmdStr)
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
-
 	if err := cmd.Run(); err != nil {
-		// Include stderr in error message for debugging
-		stderrStr := strings.TrimSpace(stderr.String())
-		if stderrStr != "" {
-			tm.logger.Debug("Abort command stderr", "stderr", stderrStr, "target", target)
-			return fmt.Errorf("%w: %s", err, stderrStr)
+		exitCode := -1
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
 		}
-		return err
+		return nil, errors.NewCommandError(generalCmd.RedactCommandLine(cmdStr), exitCode,
+			strings.TrimSpace(stderr.String())).
+			WithMetadata("remote_host", remoteConfig.Host)
 	}
-
-	return nil
+	return stdout.Bytes(), nil
 }
 
 // monitorTransferProgress monitors and updates transfer progress
 func (tm *TransferManager) monitorTransferProgress(info *TransferInfo, logFile *os.File) {
-	ticker := time.NewTicker(5 * time.Second)
+	ticker := time.NewTicker(progressInterval)
 	defer ticker.Stop()
 
+	progress := newSendProgress(logFile.Name())
 	for {
 		select {
 		case <-ticker.C:
-			if info.Status != TransferStatusRunning {
+			tm.mu.RLock()
+			running := info.Status == TransferStatusRunning
+			tm.mu.RUnlock()
+			if !running {
 				return
 			}
 
-			// TODO: Parse verbose output for actual progress data
-			// This requires parsing the verbose output format from ZFS send
-			tm.updateProgressFromLog(info, logFile)
+			tm.updateProgressFromLog(info, progress)
 
 			// Save progress to file
 			tm.saveProgress(info)
@@ -1872,15 +2227,25 @@ func (tm *TransferManager) monitorTransferProgress(info *TransferInfo, logFile *
 	}
 }
 
-// updateProgressFromLog parses log file for progress information
-func (tm *TransferManager) updateProgressFromLog(info *TransferInfo, logFile *os.File) {
-	// TODO: Implement actual progress parsing from verbose output
-	// The -P flag provides parsable progress information that can be parsed
-	// For now, just update timestamp and elapsed time
-	_ = logFile
-	info.Progress.LastUpdate = time.Now()
+// updateProgressFromLog updates progress from the verbose output of zfs
+// send in the transfer log; without -v only the elapsed time is known
+func (tm *TransferManager) updateProgressFromLog(info *TransferInfo, progress *sendProgress) {
+	if err := progress.poll(); err != nil {
+		tm.logger.Debug("Failed to read transfer log for progress", "id", info.ID, "error", err)
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	now := time.Now()
+	var sizeHint int64
+	if info.SizeInfo != nil {
+		sizeHint = info.SizeInfo.CalculatedTransferSize
+	}
+	progress.apply(&info.Progress, now, sizeHint)
+	info.Progress.LastUpdate = now
 	if info.StartedAt != nil {
-		info.Progress.ElapsedTime = int64(time.Since(*info.StartedAt).Seconds())
+		info.Progress.ElapsedTime = int64(now.Sub(*info.StartedAt).Seconds())
 	}
 }
 
@@ -1903,12 +2268,15 @@ func (tm *TransferManager) savePID(info *TransferInfo) error {
 	return tm.saveTransferConfig(info)
 }
 
+// saveProgress writes the versioned progress document of a transfer
 func (tm *TransferManager) saveProgress(info *TransferInfo) error {
-	data, err := json.Marshal(info.Progress)
-	if err != nil {
-		return err
+	if info.ProgressFile == "" {
+		return nil
 	}
-	return os.WriteFile(info.ProgressFile, data, 0644)
+	tm.mu.RLock()
+	report := progressReport(info)
+	tm.mu.RUnlock()
+	return writeProgressFile(info.ProgressFile, report)
 }
 
 func (tm *TransferManager) isProcessRunning(pid int) bool {
@@ -2038,6 +2406,10 @@ func (tm *TransferManager) emitTransferEvent(
 		action = "completed"
 	case eventspb.DataTransferTransferPayload_DATA_TRANSFER_OPERATION_FAILED:
 		level = eventspb.EventLevel_EVENT_LEVEL_ERROR
+		if info.ErrorCategory.Retryable() {
+			// Network blips and busy datasets usually clear on the next run
+			level = eventspb.EventLevel_EVENT_LEVEL_WARN
+		}
 		action = "failed"
 	case eventspb.DataTransferTransferPayload_DATA_TRANSFER_OPERATION_CANCELLED:
 		level = eventspb.EventLevel_EVENT_LEVEL_WARN
@@ -2059,6 +2431,9 @@ func (tm *TransferManager) emitTransferEvent(
 		"transfer_id": info.ID,
 		"status":      string(info.Status),
 	}
+	if info.ErrorCategory != "" {
+		transferMeta["error_category"] = string(info.ErrorCategory)
+	}
 
 	// Add duration to metadata if available
 	if info.CompletedAt != nil && info.StartedAt != nil {
@@ -2077,12 +2452,18 @@ func (tm *TransferManager) handleTransferCompletion(info *TransferInfo) {
 	info.pendingAction = TransferActionNone
 
 	// Remove completed/failed transfers from active transfers so they become historical
-	if info.Status == TransferStatusCompleted || info.Status == TransferStatusFailed ||
-		info.Status == TransferStatusCancelled {
+	finished := info.Status == TransferStatusCompleted || info.Status == TransferStatusFailed ||
+		info.Status == TransferStatusCancelled
+	if finished {
 		delete(tm.activeTransfers, info.ID)
 	}
+	final := *info
 	tm.mu.Unlock()
 
+	if finished {
+		tm.notifyTransferFinished(final)
+	}
+
 	// Process log truncation if transfer is completed or failed
 	if info.Status == TransferStatusCompleted || info.Status == TransferStatusFailed {
 		tm.processLogOnCompletion(info)
@@ -2093,6 +2474,10 @@ func (tm *TransferManager) handleTransferCompletion(info *TransferInfo) {
 		os.Remove(info.PIDFile)
 	}
 
+	if info.Status == TransferStatusCompleted {
+		tm.scheduleVerification(info.ID)
+	}
+
 	tm.logger.Info("Transfer completed", "id", info.ID, "status", info.Status)
 }
 
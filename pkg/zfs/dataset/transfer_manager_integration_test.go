@@ -15,6 +15,7 @@ import (
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/config"
 	"github.com/stratastor/rodent/pkg/zfs/command"
+	"github.com/stratastor/rodent/pkg/zfs/testutil"
 )
 
 // Test configuration from environment variables
@@ -24,24 +25,37 @@ type TestConfig struct {
 	TargetFilesystem string // RODENT_TEST_TARGET_FILESYSTEM
 	SSHKeyPath       string // RODENT_TEST_SSH_KEY_PATH
 	SourceFilesystem string // RODENT_TEST_SOURCE_FILESYSTEM
+
+	// Target is the sandbox pool holding TargetFilesystem, when the tests
+	// provision their own pools
+	Target *testutil.Sandbox
+}
+
+// sandboxFixtures are the pools the transfer tests provision when no target
+// host is configured: a source filesystem with data and a target to receive
+// into. RODENT_TEST_FIXTURES may name a file with others, such as a target
+// pool on a remote host.
+var sandboxFixtures = testutil.Fixtures{
+	Pools: []testutil.PoolSpec{
+		{Name: "source", Datasets: []string{"standardFS"}, DataMB: 16},
+		{Name: "target", Datasets: []string{"newFS"}},
+	},
 }
 
 func getTestConfig(t *testing.T) TestConfig {
+	if os.Getenv("RODENT_TEST_TARGET_IP") == "" {
+		return getSandboxConfig(t)
+	}
+
 	config := TestConfig{
 		TargetUsername:   getEnvOrDefault("RODENT_TEST_TARGET_USERNAME", "rodent"),
-		TargetIP:         getEnvOrDefault("RODENT_TEST_TARGET_IP", "172.31.14.189"),
+		TargetIP:         os.Getenv("RODENT_TEST_TARGET_IP"),
 		TargetFilesystem: getEnvOrDefault("RODENT_TEST_TARGET_FILESYSTEM", "store/newFS"),
-		SSHKeyPath: getEnvOrDefault(
-			"RODENT_TEST_SSH_KEY_PATH",
-			"/home/rodent/.rodent/ssh/01978d99-b37f-7032-8f59-94d42795652f/id_ed25519",
-		),
+		SSHKeyPath:       os.Getenv("RODENT_TEST_SSH_KEY_PATH"),
 		SourceFilesystem: getEnvOrDefault("RODENT_TEST_SOURCE_FILESYSTEM", "tank/standardFS"),
 	}
 
 	// Validate required configuration
-	if config.TargetIP == "" {
-		t.Skip("RODENT_TEST_TARGET_IP not set, skipping integration tests")
-	}
 	if config.SSHKeyPath == "" {
 		t.Skip("RODENT_TEST_SSH_KEY_PATH not set, skipping integration tests")
 	}
@@ -58,6 +72,32 @@ func getTestConfig(t *testing.T) TestConfig {
 	return config
 }
 
+// getSandboxConfig provisions the sandbox fixtures and points the tests at
+// them. A target on this host is received into directly; a remote one over
+// SSH.
+func getSandboxConfig(t *testing.T) TestConfig {
+	sandboxes := testutil.LoadFixtures(t, sandboxFixtures).Provision(t)
+	source, target := sandboxes["source"], sandboxes["target"]
+	if source == nil || target == nil {
+		t.Fatalf("fixtures need pools named source and target")
+	}
+	if !source.Host.Local() {
+		t.Fatalf("the source pool must be on this host")
+	}
+
+	config := TestConfig{
+		TargetUsername:   target.Host.User,
+		TargetIP:         target.Host.Address,
+		TargetFilesystem: target.Name("newFS"),
+		SSHKeyPath:       target.Host.KeyPath,
+		SourceFilesystem: source.Name("standardFS"),
+		Target:           target,
+	}
+	t.Logf("Sandbox test config: Source=%s, Target=%s on %s",
+		config.SourceFilesystem, config.TargetFilesystem, target.Host)
+	return config
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -127,12 +167,21 @@ func cleanupSnapshot(t *testing.T, manager *Manager, snapshotName string) {
 }
 
 func verifyRemoteFilesystem(t *testing.T, config TestConfig, targetPath string) {
+	t.Logf("Verifying remote filesystem exists: %s", targetPath)
+
+	if config.Target != nil {
+		if !config.Target.Exists(targetPath) {
+			t.Errorf("Remote filesystem %s was not created successfully", targetPath)
+		} else {
+			t.Logf("✅ Confirmed: Remote filesystem %s exists", targetPath)
+		}
+		return
+	}
+
 	// Verify the filesystem exists on the remote target
 	checkCmd := fmt.Sprintf("ssh -i %s %s@%s 'zfs list %s'",
 		config.SSHKeyPath, config.TargetUsername, config.TargetIP, targetPath)
 
-	t.Logf("Verifying remote filesystem exists: %s", targetPath)
-
 	// Execute the verification command
 	if err := exec.Command("bash", "-c", checkCmd).Run(); err != nil {
 		t.Errorf("Remote filesystem %s was not created successfully: %v", targetPath, err)
@@ -142,12 +191,18 @@ func verifyRemoteFilesystem(t *testing.T, config TestConfig, targetPath string)
 }
 
 func cleanupRemoteFilesystem(t *testing.T, config TestConfig, targetPath string) {
+	t.Logf("Cleaning up remote filesystem: %s", targetPath)
+
+	// Sandbox pools are destroyed with the test anyway
+	if config.Target != nil {
+		config.Target.Destroy(targetPath)
+		return
+	}
+
 	// Clean up the transferred filesystem on the remote target
 	cleanupCmd := fmt.Sprintf("ssh -i %s %s@%s 'sudo zfs destroy -r %s'",
 		config.SSHKeyPath, config.TargetUsername, config.TargetIP, targetPath)
 
-	t.Logf("Cleaning up remote filesystem: %s", targetPath)
-
 	// Execute the cleanup command
 	if err := exec.Command("bash", "-c", cleanupCmd).Run(); err != nil {
 		t.Logf("Warning: Failed to cleanup remote filesystem %s: %v", targetPath, err)
@@ -0,0 +1,392 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// ProgressSchemaVersion is the version of the progress document written to
+// <id>.progress and returned by the progress API. Fields are only added
+// within a version; removing or changing one needs a new version.
+const ProgressSchemaVersion = 1
+
+// Phases of a transfer reported in its progress
+const (
+	ProgressPhaseInitialSend     = "initial_send"
+	ProgressPhaseIncrementalSend = "incremental_send"
+	ProgressPhaseFullSend        = "full_send"
+)
+
+const (
+	// progressInterval is how often the progress of a running transfer is
+	// read from its log and saved
+	progressInterval = 2 * time.Second
+
+	// progressRateWeight is the weight of the latest sample in the averaged
+	// transfer rate
+	progressRateWeight = 0.3
+)
+
+// States of a dataset in a replication stream
+const (
+	DatasetProgressPending = "pending"
+	DatasetProgressSending = "sending"
+	DatasetProgressSent    = "sent"
+)
+
+// DatasetProgress is the progress of one dataset of a replication stream,
+// which sends a dataset's snapshots one after another
+type DatasetProgress struct {
+	Dataset        string `json:"dataset"                   yaml:"dataset"`
+	State          string `json:"state"                     yaml:"state"`
+	SnapshotsSent  int    `json:"snapshots_sent"            yaml:"snapshots_sent"`
+	SnapshotsTotal int    `json:"snapshots_total"           yaml:"snapshots_total"`
+	BytesSent      int64  `json:"bytes_sent"                yaml:"bytes_sent"`
+	EstimatedBytes int64  `json:"estimated_bytes,omitempty" yaml:"estimated_bytes,omitempty"`
+}
+
+// TransferProgressReport is the versioned progress document of a transfer
+type TransferProgressReport struct {
+	Version    int            `json:"version"`
+	TransferID string         `json:"transfer_id"`
+	Status     TransferStatus `json:"status"`
+	TransferProgress
+}
+
+// progressReport returns the progress document of a transfer
+func progressReport(info *TransferInfo) *TransferProgressReport {
+	return &TransferProgressReport{
+		Version:          ProgressSchemaVersion,
+		TransferID:       info.ID,
+		Status:           info.Status,
+		TransferProgress: info.Progress,
+	}
+}
+
+// writeProgressFile saves a progress document atomically, so readers never
+// see a partly written one
+func writeProgressFile(path string, report *TransferProgressReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return errors.Wrap(err, errors.RodentMisc)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", tmp)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+	return nil
+}
+
+// ReadProgressFile reads the progress document of a transfer from the
+// transfers directory. Files written before the schema was versioned hold
+// only the progress fields and are read as version 0.
+func ReadProgressFile(transfersDir, transferID string) (*TransferProgressReport, error) {
+	path := filepath.Join(transfersDir, fmt.Sprintf("%s.progress", transferID))
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New(errors.TransferNotFound, "Transfer progress not found")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, errors.FSError).WithMetadata("path", path)
+	}
+
+	var report TransferProgressReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, errors.Wrap(err, errors.RodentMisc).WithMetadata("path", path)
+	}
+	if report.Version > ProgressSchemaVersion {
+		return nil, errors.New(errors.RodentMisc, "Transfer progress was written by a newer Rodent").
+			WithMetadata("version", strconv.Itoa(report.Version))
+	}
+	if report.TransferID == "" {
+		report.TransferID = transferID
+	}
+	return &report, nil
+}
+
+// sendProgress follows the verbose output of zfs send in a transfer log.
+// It understands both the parsable (-P) and the human readable form:
+//
+//	full	tank/data@s1	1048576           full send of tank/data@s1 estimated size is 1.00M
+//	incremental	@s1	tank/data@s2	4096  send from @s1 to tank/data@s2 estimated size is 4K
+//	size	1052672                           total estimated size is 1.00M
+//	12:00:01	524288	tank/data@s1          12:00:01   512K   tank/data@s1
+//
+// A replication stream (-R) lists every snapshot it sends up front and
+// reports each one's progress in turn, from zero, which is summed by dataset.
+type sendProgress struct {
+	path    string
+	offset  int64
+	partial string
+
+	estimates map[string]int64 // Estimated size of each snapshot to send
+	order     []string         // Snapshots to send, in order
+	total     int64            // Estimated size of the whole stream
+
+	current      string           // Snapshot being sent
+	currentBytes int64            // Bytes of it sent so far
+	sentBytes    int64            // Bytes of the snapshots sent before it
+	sent         map[string]int64 // Bytes of each snapshot sent before it
+
+	sampledAt    time.Time
+	sampledBytes int64
+	rate         float64
+	advancedAt   time.Time // When bytes were last sent
+}
+
+func newSendProgress(path string) *sendProgress {
+	return &sendProgress{
+		path:      path,
+		estimates: make(map[string]int64),
+		sent:      make(map[string]int64),
+	}
+}
+
+// poll reads what was added to the log since the last poll
+func (p *sendProgress) poll() error {
+	f, err := os.Open(p.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// A log that shrank was truncated and is read again
+	if info, err := f.Stat(); err == nil && info.Size() < p.offset {
+		*p = *newSendProgress(p.path)
+	}
+	if _, err := f.Seek(p.offset, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	p.offset += int64(len(data))
+	p.feed(string(data))
+	return nil
+}
+
+// feed parses output, keeping an incomplete last line for the next call
+func (p *sendProgress) feed(output string) {
+	output = p.partial + output
+	lines := strings.Split(output, "\n")
+	p.partial = lines[len(lines)-1]
+	for _, line := range lines[:len(lines)-1] {
+		p.parseLine(line)
+	}
+}
+
+func (p *sendProgress) parseLine(line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+
+	switch {
+	case fields[0] == "full" && len(fields) == 3:
+		p.addEstimate(fields[1], fields[2])
+	case fields[0] == "incremental" && len(fields) == 4:
+		p.addEstimate(fields[2], fields[3])
+	case fields[0] == "size" && len(fields) == 2:
+		if size, ok := parseSendBytes(fields[1]); ok {
+			p.total = size
+		}
+	case strings.Contains(line, "estimated size is"):
+		size := fields[len(fields)-1]
+		switch {
+		case fields[0] == "total":
+			if size, ok := parseSendBytes(size); ok {
+				p.total = size
+			}
+		case fields[0] == "full" && len(fields) > 3:
+			p.addEstimate(fields[3], size)
+		case fields[0] == "send" && len(fields) > 4:
+			p.addEstimate(fields[4], size)
+		}
+	case len(fields) == 3 && isSendTimestamp(fields[0]):
+		if sent, ok := parseSendBytes(fields[1]); ok {
+			p.update(fields[2], sent)
+		}
+	}
+}
+
+func (p *sendProgress) addEstimate(snapshot, size string) {
+	estimate, ok := parseSendBytes(size)
+	if !ok {
+		return
+	}
+	if _, seen := p.estimates[snapshot]; !seen {
+		p.order = append(p.order, snapshot)
+	}
+	p.estimates[snapshot] = estimate
+}
+
+// update records the bytes of snapshot sent so far; a new snapshot means
+// the one before it was sent in full
+func (p *sendProgress) update(snapshot string, sent int64) {
+	if snapshot != p.current {
+		if p.current != "" {
+			p.sentBytes += p.currentBytes
+			p.sent[p.current] = p.currentBytes
+		}
+		p.current = snapshot
+		if _, listed := p.estimates[snapshot]; !listed {
+			p.estimates[snapshot] = 0
+			p.order = append(p.order, snapshot)
+		}
+	}
+	p.currentBytes = sent
+}
+
+// apply updates progress from what was parsed. sizeHint is the size of the
+// stream calculated before it started, used when the log gives none.
+func (p *sendProgress) apply(progress *TransferProgress, now time.Time, sizeHint int64) {
+	transferred := p.sentBytes + p.currentBytes
+	progress.BytesTransferred = transferred
+
+	total := p.total
+	if total == 0 {
+		for _, estimate := range p.estimates {
+			total += estimate
+		}
+	}
+	if total == 0 {
+		total = sizeHint
+	}
+	if total > 0 {
+		progress.TotalBytes = total
+		progress.PercentComplete = min(100, float64(transferred)*100/float64(total))
+	}
+
+	if !p.sampledAt.IsZero() && now.After(p.sampledAt) && transferred >= p.sampledBytes {
+		sample := float64(transferred-p.sampledBytes) / now.Sub(p.sampledAt).Seconds()
+		if p.rate == 0 {
+			p.rate = sample
+		} else {
+			p.rate = progressRateWeight*sample + (1-progressRateWeight)*p.rate
+		}
+	}
+	if transferred > p.sampledBytes {
+		p.advancedAt = now
+	}
+	p.sampledAt, p.sampledBytes = now, transferred
+	progress.TransferRate = int64(p.rate)
+	if !p.advancedAt.IsZero() {
+		advancedAt := p.advancedAt
+		progress.LastAdvanceAt = &advancedAt
+	}
+
+	progress.EstimatedETA = 0
+	if p.rate > 0 && total > transferred {
+		progress.EstimatedETA = int64(float64(total-transferred) / p.rate)
+	}
+
+	if p.current != "" {
+		progress.CurrentSnapshot = p.current
+		progress.CurrentDataset, _, _ = strings.Cut(p.current, "@")
+	}
+	progress.SnapshotsSent = len(p.sent)
+	progress.SnapshotsTotal = len(p.order)
+	progress.Datasets = p.datasets()
+}
+
+// datasets sums the snapshots of a replication stream by dataset, in the
+// order they are sent. A stream of a single dataset has none.
+func (p *sendProgress) datasets() []DatasetProgress {
+	var datasets []DatasetProgress
+	index := make(map[string]int)
+	for _, snapshot := range p.order {
+		name, _, _ := strings.Cut(snapshot, "@")
+		i, ok := index[name]
+		if !ok {
+			i = len(datasets)
+			index[name] = i
+			datasets = append(datasets, DatasetProgress{Dataset: name})
+		}
+
+		ds := &datasets[i]
+		ds.SnapshotsTotal++
+		ds.EstimatedBytes += p.estimates[snapshot]
+		if sent, done := p.sent[snapshot]; done {
+			ds.SnapshotsSent++
+			ds.BytesSent += sent
+		} else if snapshot == p.current {
+			ds.BytesSent += p.currentBytes
+		}
+	}
+	if len(datasets) < 2 {
+		return nil
+	}
+
+	current, _, _ := strings.Cut(p.current, "@")
+	for i := range datasets {
+		ds := &datasets[i]
+		switch {
+		case ds.Dataset == current:
+			ds.State = DatasetProgressSending
+		case ds.SnapshotsSent == ds.SnapshotsTotal:
+			ds.State = DatasetProgressSent
+		default:
+			ds.State = DatasetProgressPending
+		}
+	}
+	return datasets
+}
+
+// markSent marks every dataset of a finished replication stream as sent
+func markSent(progress *TransferProgress) {
+	for i := range progress.Datasets {
+		ds := &progress.Datasets[i]
+		ds.State = DatasetProgressSent
+		ds.SnapshotsSent = ds.SnapshotsTotal
+	}
+	if progress.SnapshotsTotal > 0 {
+		progress.SnapshotsSent = progress.SnapshotsTotal
+	}
+}
+
+// isSendTimestamp reports whether field is the HH:MM:SS time of a progress
+// line
+func isSendTimestamp(field string) bool {
+	_, err := time.Parse(time.TimeOnly, field)
+	return err == nil
+}
+
+// parseSendBytes parses a byte count as printed by zfs send: exact with -P,
+// or with a binary suffix such as 1.50M otherwise
+func parseSendBytes(field string) (int64, bool) {
+	if n, err := strconv.ParseInt(field, 10, 64); err == nil {
+		return n, n >= 0
+	}
+
+	field = strings.ToUpper(field)
+	n := len(field)
+	if n < 2 {
+		return 0, false
+	}
+	shift := strings.IndexByte("KMGTPE", field[n-1])
+	if shift < 0 {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(field[:n-1], 64)
+	if err != nil || value < 0 {
+		return 0, false
+	}
+	return int64(value * float64(uint64(1)<<(10*(shift+1)))), true
+}
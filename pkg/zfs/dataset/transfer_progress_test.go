@@ -0,0 +1,173 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSendProgressParsable(t *testing.T) {
+	p := newSendProgress("")
+	p.feed("full\ttank/data@s1\t1000\n" +
+		"full\ttank/data/child@s1\t3000\n" +
+		"size\t4000\n" +
+		"12:00:01\t400\ttank/data@s1\n" +
+		"12:00:02\t1000\ttank/data@s1\n" +
+		"12:00:03\t500\ttank/data/chi")
+
+	start := time.Now()
+	var progress TransferProgress
+	p.apply(&progress, start, 0)
+	if progress.BytesTransferred != 1000 || progress.TotalBytes != 4000 {
+		t.Fatalf("bytes = %d/%d, want 1000/4000", progress.BytesTransferred, progress.TotalBytes)
+	}
+	if progress.CurrentDataset != "tank/data" || progress.SnapshotsTotal != 2 || progress.SnapshotsSent != 0 {
+		t.Fatalf("current = %s (%d/%d), want tank/data (0/2)",
+			progress.CurrentDataset, progress.SnapshotsSent, progress.SnapshotsTotal)
+	}
+
+	// The rest of the line arrives with the next read
+	p.feed("ld@s1\n")
+	p.apply(&progress, start.Add(2*time.Second), 0)
+	if progress.BytesTransferred != 1500 {
+		t.Errorf("bytes = %d, want 1500", progress.BytesTransferred)
+	}
+	if progress.CurrentSnapshot != "tank/data/child@s1" || progress.CurrentDataset != "tank/data/child" {
+		t.Errorf("current = %s in %s", progress.CurrentSnapshot, progress.CurrentDataset)
+	}
+	if progress.SnapshotsSent != 1 {
+		t.Errorf("snapshots sent = %d, want 1", progress.SnapshotsSent)
+	}
+	if progress.TransferRate != 250 {
+		t.Errorf("rate = %d, want 250", progress.TransferRate)
+	}
+	if progress.EstimatedETA != 10 {
+		t.Errorf("ETA = %d, want 10", progress.EstimatedETA)
+	}
+	if progress.PercentComplete != 37.5 {
+		t.Errorf("percent = %v, want 37.5", progress.PercentComplete)
+	}
+	if progress.LastAdvanceAt == nil || !progress.LastAdvanceAt.Equal(start.Add(2*time.Second)) {
+		t.Errorf("last advance = %v", progress.LastAdvanceAt)
+	}
+}
+
+func TestSendProgressDatasets(t *testing.T) {
+	p := newSendProgress("")
+	p.feed("incremental\ttank/data@s1\ttank/data@s2\t100\n" +
+		"incremental\ttank/data@s2\ttank/data@s3\t200\n" +
+		"incremental\ttank/data/a@s1\ttank/data/a@s2\t300\n" +
+		"incremental\ttank/data/b@s1\ttank/data/b@s2\t400\n" +
+		"size\t1000\n" +
+		"12:00:01\t100\ttank/data@s2\n" +
+		"12:00:02\t200\ttank/data@s3\n" +
+		"12:00:03\t120\ttank/data/a@s2\n")
+
+	var progress TransferProgress
+	p.apply(&progress, time.Now(), 0)
+	want := []DatasetProgress{
+		{Dataset: "tank/data", State: DatasetProgressSent, SnapshotsSent: 2, SnapshotsTotal: 2, BytesSent: 300, EstimatedBytes: 300},
+		{Dataset: "tank/data/a", State: DatasetProgressSending, SnapshotsSent: 0, SnapshotsTotal: 1, BytesSent: 120, EstimatedBytes: 300},
+		{Dataset: "tank/data/b", State: DatasetProgressPending, SnapshotsSent: 0, SnapshotsTotal: 1, BytesSent: 0, EstimatedBytes: 400},
+	}
+	if !slices.Equal(progress.Datasets, want) {
+		t.Errorf("datasets = %+v, want %+v", progress.Datasets, want)
+	}
+	if progress.SnapshotsSent != 2 || progress.SnapshotsTotal != 4 {
+		t.Errorf("snapshots = %d/%d, want 2/4", progress.SnapshotsSent, progress.SnapshotsTotal)
+	}
+
+	markSent(&progress)
+	for _, ds := range progress.Datasets {
+		if ds.State != DatasetProgressSent || ds.SnapshotsSent != ds.SnapshotsTotal {
+			t.Errorf("dataset %s not marked sent: %+v", ds.Dataset, ds)
+		}
+	}
+
+	// A stream of one dataset is not broken down
+	single := newSendProgress("")
+	single.feed("full\ttank/db@s1\t100\n12:00:01\t50\ttank/db@s1\n")
+	single.apply(&progress, time.Now(), 0)
+	if progress.Datasets != nil {
+		t.Errorf("datasets = %+v, want none", progress.Datasets)
+	}
+}
+
+func TestSendProgressHumanReadable(t *testing.T) {
+	p := newSendProgress("")
+	p.feed("send from @s1 to tank/data@s2 estimated size is 1.50M\n" +
+		"total estimated size is 1.50M\n" +
+		"TIME        SENT   SNAPSHOT tank/data@s2\n" +
+		"12:00:01    512K   tank/data@s2\n")
+
+	var progress TransferProgress
+	p.apply(&progress, time.Now(), 0)
+	if progress.BytesTransferred != 512*1024 {
+		t.Errorf("bytes = %d, want %d", progress.BytesTransferred, 512*1024)
+	}
+	if progress.TotalBytes != 1536*1024 {
+		t.Errorf("total = %d, want %d", progress.TotalBytes, 1536*1024)
+	}
+	if progress.CurrentSnapshot != "tank/data@s2" {
+		t.Errorf("current = %s", progress.CurrentSnapshot)
+	}
+}
+
+func TestSendProgressSizeHint(t *testing.T) {
+	p := newSendProgress("")
+	p.feed("12:00:01\t100\ttank/data@s1\n")
+
+	var progress TransferProgress
+	p.apply(&progress, time.Now(), 400)
+	if progress.TotalBytes != 400 || progress.PercentComplete != 25 {
+		t.Errorf("total = %d, percent = %v, want 400, 25", progress.TotalBytes, progress.PercentComplete)
+	}
+}
+
+func TestProgressFile(t *testing.T) {
+	dir := t.TempDir()
+	info := &TransferInfo{
+		ID:     "t1",
+		Status: TransferStatusRunning,
+		Progress: TransferProgress{
+			BytesTransferred: 10,
+			Phase:            ProgressPhaseFullSend,
+			CurrentSnapshot:  "tank/data@s1",
+		},
+	}
+	if err := writeProgressFile(filepath.Join(dir, "t1.progress"), progressReport(info)); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ReadProgressFile(dir, "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Version != ProgressSchemaVersion || report.Status != TransferStatusRunning ||
+		report.BytesTransferred != 10 || report.CurrentSnapshot != "tank/data@s1" {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Files from before the schema was versioned hold only the progress
+	legacy := `{"bytes_transferred":5,"transfer_rate":0,"elapsed_time":3,"last_update":"2025-01-01T00:00:00Z"}`
+	if err := os.WriteFile(filepath.Join(dir, "t2.progress"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report, err = ReadProgressFile(dir, "t2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Version != 0 || report.TransferID != "t2" || report.BytesTransferred != 5 {
+		t.Errorf("legacy report = %+v", report)
+	}
+
+	if _, err := ReadProgressFile(dir, "missing"); err == nil {
+		t.Error("expected an error for a missing progress file")
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+func TestCheckTargetFreeSpace(t *testing.T) {
+	l, err := logger.NewTag(logger.Config{LogLevel: "error"}, "test-transfer-space")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := &TransferManager{logger: l}
+
+	remote := RemoteConfig{Host: "backup.example", User: "rodent"}
+
+	tests := []struct {
+		name        string
+		recv        ReceiveConfig
+		streamSize  int64
+		output      string
+		queryErr    error
+		wantDataset string
+		wantErr     bool
+		wantAvail   string
+	}{
+		{
+			name:        "local target with room",
+			recv:        ReceiveConfig{Target: "tank/backup/db"},
+			streamSize:  900,
+			output:      "1000\n0\n",
+			wantDataset: "tank/backup",
+		},
+		{
+			name:        "remote target without room",
+			recv:        ReceiveConfig{Target: "tank/backup/db", RemoteConfig: remote},
+			streamSize:  1000,
+			output:      "1000\n0\n",
+			wantDataset: "tank/backup",
+			wantErr:     true,
+			wantAvail:   "1000",
+		},
+		{
+			name:        "headroom is required",
+			recv:        ReceiveConfig{Target: "tank/backup/db"},
+			streamSize:  1000,
+			output:      "1049\n0\n",
+			wantDataset: "tank/backup",
+			wantErr:     true,
+			wantAvail:   "1049",
+		},
+		{
+			name:        "use parent checks the target itself",
+			recv:        ReceiveConfig{Target: "tank/backup", UseParent: true},
+			streamSize:  100,
+			output:      "1000\n0\n",
+			wantDataset: "tank/backup",
+		},
+		{
+			name:        "unused refreservation is held for the parent",
+			recv:        ReceiveConfig{Target: "tank/backup/db", RemoteConfig: remote},
+			streamSize:  1000,
+			output:      "3000\n2000\n",
+			wantDataset: "tank/backup",
+			wantErr:     true,
+			wantAvail:   "1000",
+		},
+		{
+			name:        "refreservation larger than available",
+			recv:        ReceiveConfig{Target: "tank/backup/db"},
+			streamSize:  1,
+			output:      "500\n800\n",
+			wantDataset: "tank/backup",
+			wantErr:     true,
+			wantAvail:   "0",
+		},
+		{
+			name:        "values without a value skip the check",
+			recv:        ReceiveConfig{Target: "tank/backup/db"},
+			streamSize:  1 << 40,
+			output:      "-\n-\n",
+			wantDataset: "tank/backup",
+		},
+		{
+			name:        "unparsable output skips the check",
+			recv:        ReceiveConfig{Target: "tank/backup/db"},
+			streamSize:  1 << 40,
+			output:      "1000\n",
+			wantDataset: "tank/backup",
+		},
+		{
+			name:        "failed query skips the check",
+			recv:        ReceiveConfig{Target: "tank/backup/db", RemoteConfig: remote},
+			streamSize:  1 << 40,
+			queryErr:    fmt.Errorf("ssh: connect to host backup.example: Connection refused"),
+			wantDataset: "tank/backup",
+		},
+	}
+
+	saved := runTargetZFS
+	t.Cleanup(func() { runTargetZFS = saved })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotHost string
+			var gotArgs []string
+			runTargetZFS = func(
+				_ *TransferManager,
+				_ context.Context,
+				remoteCfg RemoteConfig,
+				subcommand string,
+				args ...string,
+			) ([]byte, error) {
+				gotHost = remoteCfg.Host
+				gotArgs = append([]string{subcommand}, args...)
+				return []byte(tt.output), tt.queryErr
+			}
+
+			err := tm.checkTargetFreeSpace(TransferConfig{ReceiveConfig: tt.recv}, tt.streamSize)
+
+			if gotHost != tt.recv.RemoteConfig.Host {
+				t.Errorf("queried host %q, want %q", gotHost, tt.recv.RemoteConfig.Host)
+			}
+			wantArgs := []string{"get", "-Hp", "-o", "value", "available,usedbyrefreservation", tt.wantDataset}
+			if !slices.Equal(gotArgs, wantArgs) {
+				t.Errorf("zfs args = %q, want %q", gotArgs, wantArgs)
+			}
+
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			re, ok := err.(*errors.RodentError)
+			if !ok || re.Code != errors.TransferInsufficientSpace {
+				t.Fatalf("error = %v, want TransferInsufficientSpace", err)
+			}
+			if re.Metadata["target"] != tt.wantDataset {
+				t.Errorf("target = %q, want %q", re.Metadata["target"], tt.wantDataset)
+			}
+			if re.Metadata["available_bytes"] != tt.wantAvail {
+				t.Errorf("available_bytes = %q, want %q", re.Metadata["available_bytes"], tt.wantAvail)
+			}
+		})
+	}
+}
+
+func TestGetTargetSpace(t *testing.T) {
+	tm := &TransferManager{}
+
+	saved := runTargetZFS
+	t.Cleanup(func() { runTargetZFS = saved })
+	runTargetZFS = func(
+		_ *TransferManager,
+		_ context.Context,
+		_ RemoteConfig,
+		_ string,
+		_ ...string,
+	) ([]byte, error) {
+		return []byte("7000\t3000\n"), nil
+	}
+
+	values, err := tm.getTargetSpace("tank", RemoteConfig{}, "used", "available")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(values, []int64{7000, 3000}) {
+		t.Errorf("values = %v, want [7000 3000]", values)
+	}
+
+	percent, err := tm.TargetPoolFreePercent(TransferConfig{
+		ReceiveConfig: ReceiveConfig{Target: "tank/backup/db"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if percent != 30 {
+		t.Errorf("free percent = %v, want 30", percent)
+	}
+}
@@ -17,6 +17,11 @@
 
 package dataset
 
+import (
+	"fmt"
+	"strconv"
+)
+
 // Dataset represents a ZFS dataset (filesystem, volume, snapshot, bookmark and clone)
 type Dataset struct {
 	Name       string              `json:"name"`
@@ -41,6 +46,24 @@ type Source struct {
 	Data string `json:"data"` // Additional source info
 }
 
+// PropertyString returns a property value as a string, or "" if unset
+func PropertyString(ds Dataset, name string) string {
+	prop, ok := ds.Properties[name]
+	if !ok || prop.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(prop.Value)
+}
+
+// PropertyUint returns a parsable numeric property value, or 0
+func PropertyUint(ds Dataset, name string) uint64 {
+	value, err := strconv.ParseUint(PropertyString(ds, name), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
 type ListResult struct {
 	Datasets map[string]Dataset `json:"datasets"`
 }
@@ -97,7 +120,8 @@ type DestroyConfig struct {
 
 // DestroyResult represents the output of a destroy operation
 type DestroyResult struct {
-	Destroyed []string `json:"destroyed"` // List of datasets that would be/were destroyed
+	Destroyed    []string `json:"destroyed"`               // List of datasets that would be/were destroyed
+	ReclaimBytes int64    `json:"reclaim_bytes,omitempty"` // Space that would be/was reclaimed, as reported by -p -v
 }
 
 // CreateResult represents the output of a create operation
@@ -136,6 +160,45 @@ type VolumeConfig struct {
 	Parsable  bool   `json:"parsable"` // -P  Print machine-parsable  verbose  information  about  the  created dataset
 }
 
+// VolumeResizeConfig for changing a volume's size
+type VolumeResizeConfig struct {
+	NameConfig
+	Size string `json:"size" binding:"required"` // New volsize
+	// Shrinking a volume discards data beyond the new size and is refused
+	// unless explicitly allowed
+	AllowShrink bool `json:"allow_shrink"`
+}
+
+// VolumeCloneConfig for cloning a volume snapshot
+type VolumeCloneConfig struct {
+	CloneConfig
+	// Clones are thin-provisioned; Reserve sets refreservation=auto so the
+	// clone's full size is reserved like a non-sparse volume
+	Reserve bool   `json:"reserve"`
+	VolMode string `json:"volmode,omitempty"` // default, full, dev or none
+}
+
+// VolumeInfo describes a volume and how it is consumed
+type VolumeInfo struct {
+	Name           string           `json:"name"`
+	DevicePath     string           `json:"device_path"`
+	VolSize        uint64           `json:"volsize"`
+	VolBlockSize   uint64           `json:"volblocksize"`
+	Sparse         bool             `json:"sparse"`
+	RefReservation uint64           `json:"refreservation"`
+	VolMode        string           `json:"volmode"`
+	Origin         string           `json:"origin,omitempty"`
+	Used           uint64           `json:"used"`
+	Consumers      []VolumeConsumer `json:"consumers"`
+}
+
+// VolumeConsumer is something using a volume's block device
+type VolumeConsumer struct {
+	Type   string `json:"type"`             // "iscsi" or "holder"
+	Name   string `json:"name"`             // Target IQN or holding device
+	Detail string `json:"detail,omitempty"` // Backstore and LUN for iSCSI
+}
+
 type SnapshotConfig struct {
 	NameConfig
 	SnapName   string            `json:"snap_name"            binding:"required"`
@@ -159,6 +222,20 @@ type CloneConfig struct {
 	Parents    bool              `json:"parents,omitempty"`
 }
 
+// HoldConfig for placing or releasing a snapshot hold
+type HoldConfig struct {
+	NameConfig
+	Tag       string `json:"tag"       binding:"required"`
+	Recursive bool   `json:"recursive"`
+}
+
+// SnapshotHold is a user hold on a snapshot
+type SnapshotHold struct {
+	Snapshot  string `json:"snapshot"`
+	Tag       string `json:"tag"`
+	Timestamp string `json:"timestamp"`
+}
+
 // BookmarkConfig for bookmark creation
 type BookmarkConfig struct {
 	NameConfig
@@ -0,0 +1,286 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+const (
+	// zvolDevDir is where udev exposes volumes as block devices
+	zvolDevDir = "/dev/zvol"
+
+	// lioConfigRoot is the LIO target configfs tree used by targetcli
+	lioConfigRoot = "/sys/kernel/config/target"
+
+	// sysBlockRoot exposes block device holders
+	sysBlockRoot = "/sys/class/block"
+)
+
+// Volume consumer types
+const (
+	VolumeConsumerISCSI  = "iscsi"
+	VolumeConsumerHolder = "holder"
+)
+
+// volModes are the accepted values of the volmode property
+var volModes = []string{"default", "full", "geom", "dev", "none"}
+
+// volumeProperties are fetched for VolumeInfo
+var volumeProperties = []string{
+	"name", "volsize", "volblocksize", "refreservation", "volmode", "origin", "used",
+}
+
+// GetVolume returns size, provisioning and consumer details of a volume
+func (m *Manager) GetVolume(ctx context.Context, cfg NameConfig) (VolumeInfo, error) {
+	ds, err := m.getVolumeDataset(ctx, cfg.Name)
+	if err != nil {
+		return VolumeInfo{}, err
+	}
+
+	info := VolumeInfo{
+		Name:           cfg.Name,
+		DevicePath:     filepath.Join(zvolDevDir, cfg.Name),
+		VolSize:        PropertyUint(ds, "volsize"),
+		VolBlockSize:   PropertyUint(ds, "volblocksize"),
+		RefReservation: PropertyUint(ds, "refreservation"),
+		VolMode:        PropertyString(ds, "volmode"),
+		Origin:         PropertyString(ds, "origin"),
+		Used:           PropertyUint(ds, "used"),
+	}
+	if info.Origin == "-" {
+		info.Origin = ""
+	}
+	// A volume without a reservation covering its size is thin-provisioned
+	info.Sparse = info.RefReservation < info.VolSize
+
+	info.Consumers = findVolumeConsumers(lioConfigRoot, sysBlockRoot, volumeDevicePaths(cfg.Name))
+	return info, nil
+}
+
+// ListVolumeConsumers returns iSCSI LUNs and block device holders using a volume
+func (m *Manager) ListVolumeConsumers(ctx context.Context, cfg NameConfig) ([]VolumeConsumer, error) {
+	if _, err := m.getVolumeDataset(ctx, cfg.Name); err != nil {
+		return nil, err
+	}
+
+	return findVolumeConsumers(lioConfigRoot, sysBlockRoot, volumeDevicePaths(cfg.Name)), nil
+}
+
+// ResizeVolume changes a volume's size. Shrinking is refused unless allowed
+// since it discards data past the new end of the device.
+func (m *Manager) ResizeVolume(ctx context.Context, cfg VolumeResizeConfig) error {
+	newSize, err := parseVolumeSize(cfg.Size)
+	if err != nil {
+		return err
+	}
+
+	ds, err := m.getVolumeDataset(ctx, cfg.Name)
+	if err != nil {
+		return err
+	}
+
+	if blockSize := PropertyUint(ds, "volblocksize"); blockSize > 0 && newSize%blockSize != 0 {
+		return errors.New(
+			errors.ZFSInvalidSize,
+			fmt.Sprintf("volume size must be a multiple of volblocksize (%d)", blockSize),
+		)
+	}
+
+	if current := PropertyUint(ds, "volsize"); newSize < current && !cfg.AllowShrink {
+		return errors.New(
+			errors.ZFSVolumeOperationFailed,
+			"shrinking a volume discards data; set allow_shrink to proceed",
+		).WithMetadata("current_size", strconv.FormatUint(current, 10)).
+			WithMetadata("requested_size", strconv.FormatUint(newSize, 10))
+	}
+
+	return m.SetProperty(ctx, SetPropertyConfig{
+		PropertyConfig: PropertyConfig{
+			NameConfig: NameConfig{Name: cfg.Name},
+			Property:   "volsize",
+		},
+		Value: strconv.FormatUint(newSize, 10),
+	})
+}
+
+// CloneVolume clones a volume snapshot into a new volume
+func (m *Manager) CloneVolume(ctx context.Context, cfg VolumeCloneConfig) error {
+	origin, _, found := strings.Cut(cfg.Name, "@")
+	if !found {
+		return errors.New(errors.ZFSSnapshotInvalidName, "clone source must be a snapshot")
+	}
+	if _, err := m.getVolumeDataset(ctx, origin); err != nil {
+		return err
+	}
+
+	cloneCfg := cfg.CloneConfig
+	cloneCfg.Properties = make(map[string]string, len(cfg.Properties)+2)
+	for k, v := range cfg.Properties {
+		cloneCfg.Properties[k] = v
+	}
+	if cfg.Reserve {
+		cloneCfg.Properties["refreservation"] = "auto"
+	}
+	if cfg.VolMode != "" {
+		if !slices.Contains(volModes, cfg.VolMode) {
+			return errors.New(errors.ZFSRequestValidationError, "invalid volmode").
+				WithMetadata("volmode", cfg.VolMode)
+		}
+		cloneCfg.Properties["volmode"] = cfg.VolMode
+	}
+
+	return m.Clone(ctx, cloneCfg)
+}
+
+// getVolumeDataset lists a single volume with the properties VolumeInfo needs
+func (m *Manager) getVolumeDataset(ctx context.Context, name string) (Dataset, error) {
+	result, err := m.List(ctx, ListConfig{
+		Name:       name,
+		Type:       "volume",
+		Properties: volumeProperties,
+		Parsable:   true,
+	})
+	if err != nil {
+		return Dataset{}, err
+	}
+
+	ds, ok := result.Datasets[name]
+	if !ok {
+		return Dataset{}, errors.New(errors.ZFSDatasetNotFound, "volume not found").
+			WithMetadata("name", name)
+	}
+	return ds, nil
+}
+
+// parseVolumeSize converts a size such as "10G", "1.5T" or "10GiB" into bytes.
+// It takes the units zfs does, K through Z with an optional "B" or "iB", and
+// fractions of them; sizes that do not fit in 64 bits are rejected.
+func parseVolumeSize(size string) (uint64, error) {
+	invalid := func(reason string) error {
+		return errors.New(errors.ZFSInvalidSize, reason).WithMetadata("size", size)
+	}
+
+	s := strings.ToUpper(strings.TrimSpace(size))
+	number, unit := s, ""
+	if i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' }); i >= 0 {
+		number, unit = s[:i], s[i:]
+	}
+
+	shift := 0
+	if unit != "" && unit != "B" {
+		i := strings.IndexByte("KMGTPEZ", unit[0])
+		if i < 0 || (unit[1:] != "" && unit[1:] != "B" && unit[1:] != "IB") {
+			return 0, invalid("invalid volume size unit")
+		}
+		shift = 10 * (i + 1)
+	}
+
+	var value uint64
+	if strings.Contains(number, ".") {
+		f, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return 0, invalid("invalid volume size")
+		}
+		if f = math.Ldexp(f, shift); f >= math.Ldexp(1, 64) {
+			return 0, invalid("volume size is too large")
+		}
+		value = uint64(f)
+	} else {
+		n, err := strconv.ParseUint(number, 10, 64)
+		if ne, ok := err.(*strconv.NumError); ok && ne.Err == strconv.ErrRange {
+			return 0, invalid("volume size is too large")
+		} else if err != nil {
+			return 0, invalid("invalid volume size")
+		}
+		if shift >= 64 || n > math.MaxUint64>>shift {
+			return 0, invalid("volume size is too large")
+		}
+		value = n << shift
+	}
+
+	if value == 0 {
+		return 0, invalid("invalid volume size")
+	}
+	return value, nil
+}
+
+// volumeDevicePaths returns the udev path of a volume and the zd device it
+// resolves to; LIO backstores may reference either
+func volumeDevicePaths(name string) []string {
+	devPath := filepath.Join(zvolDevDir, name)
+	paths := []string{devPath}
+	if resolved, err := filepath.EvalSymlinks(devPath); err == nil && resolved != devPath {
+		paths = append(paths, resolved)
+	}
+	return paths
+}
+
+// findVolumeConsumers looks for LIO block backstores whose udev_path is one of
+// devPaths and reports the iSCSI LUNs exporting them, along with kernel holders
+// (device-mapper, md) of the zd device
+func findVolumeConsumers(lioRoot, blockRoot string, devPaths []string) []VolumeConsumer {
+	consumers := []VolumeConsumer{}
+
+	// Backstores live at core/<hba>/<name>/udev_path
+	backstores := make(map[string]bool)
+	udevPaths, _ := filepath.Glob(filepath.Join(lioRoot, "core", "*", "*", "udev_path"))
+	for _, udevPath := range udevPaths {
+		data, err := os.ReadFile(udevPath)
+		if err != nil || !slices.Contains(devPaths, strings.TrimSpace(string(data))) {
+			continue
+		}
+		backstore := filepath.Dir(udevPath)
+		backstores[filepath.Join(filepath.Base(filepath.Dir(backstore)), filepath.Base(backstore))] = true
+	}
+
+	// LUNs link to their backstore from iscsi/<iqn>/<tpgt>/lun/<lun>/<link>
+	if len(backstores) > 0 {
+		links, _ := filepath.Glob(filepath.Join(lioRoot, "iscsi", "*", "tpgt_*", "lun", "lun_*", "*"))
+		for _, link := range links {
+			target, err := os.Readlink(link)
+			if err != nil {
+				continue
+			}
+			backstore := filepath.Join(filepath.Base(filepath.Dir(target)), filepath.Base(target))
+			if !backstores[backstore] {
+				continue
+			}
+
+			lunDir := filepath.Dir(link)
+			tpgDir := filepath.Dir(filepath.Dir(lunDir))
+			consumers = append(consumers, VolumeConsumer{
+				Type: VolumeConsumerISCSI,
+				Name: filepath.Base(filepath.Dir(tpgDir)),
+				Detail: fmt.Sprintf("%s/%s backstore=%s",
+					filepath.Base(tpgDir), filepath.Base(lunDir), backstore),
+			})
+		}
+	}
+
+	for _, devPath := range devPaths {
+		if !strings.HasPrefix(filepath.Base(devPath), "zd") {
+			continue
+		}
+		holders, _ := os.ReadDir(filepath.Join(blockRoot, filepath.Base(devPath), "holders"))
+		for _, holder := range holders {
+			consumers = append(consumers, VolumeConsumer{
+				Type: VolumeConsumerHolder,
+				Name: holder.Name(),
+			})
+		}
+	}
+
+	return consumers
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package dataset
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseVolumeSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    uint64
+		wantErr bool
+	}{
+		{size: "4096", want: 4096},
+		{size: "8K", want: 8 << 10},
+		{size: "10G", want: 10 << 30},
+		{size: "1t", want: 1 << 40},
+		{size: "512B", want: 512},
+		{size: "10GB", want: 10 << 30},
+		{size: "10GiB", want: 10 << 30},
+		{size: " 2mb ", want: 2 << 20},
+		{size: "1.5G", want: 3 << 29},
+		{size: "0.5K", want: 512},
+		{size: "16E", wantErr: true},
+		{size: "15E", want: 15 << 60},
+		{size: "1Z", wantErr: true},
+		{size: "18446744073709551616", wantErr: true},
+		{size: "17179869184G", wantErr: true},
+		{size: "16.0E", wantErr: true},
+		{size: "0", wantErr: true},
+		{size: "0.1", wantErr: true},
+		{size: "G", wantErr: true},
+		{size: "", wantErr: true},
+		{size: "1.2.3G", wantErr: true},
+		{size: "10X", wantErr: true},
+		{size: "10GX", wantErr: true},
+		{size: "-1G", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseVolumeSize(tt.size)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseVolumeSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseVolumeSize(%q) = %d, want %d", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFindVolumeConsumers(t *testing.T) {
+	root := t.TempDir()
+	lioRoot := filepath.Join(root, "target")
+	blockRoot := filepath.Join(root, "block")
+
+	mustMkdir := func(p string) {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Backstore for the volume, plus an unrelated one
+	backstore := filepath.Join(lioRoot, "core", "iblock_0", "vm1")
+	mustMkdir(backstore)
+	if err := os.WriteFile(filepath.Join(backstore, "udev_path"), []byte("/dev/zvol/tank/vm1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(lioRoot, "core", "iblock_1", "vm2")
+	mustMkdir(other)
+	if err := os.WriteFile(filepath.Join(other, "udev_path"), []byte("/dev/zvol/tank/vm2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	iqn := "iqn.2025-01.in.stratastor:vm1"
+	lunDir := filepath.Join(lioRoot, "iscsi", iqn, "tpgt_1", "lun", "lun_0")
+	mustMkdir(lunDir)
+	if err := os.Symlink(backstore, filepath.Join(lunDir, "a1b2c3")); err != nil {
+		t.Fatal(err)
+	}
+	otherLun := filepath.Join(lioRoot, "iscsi", "iqn.2025-01.in.stratastor:vm2", "tpgt_1", "lun", "lun_0")
+	mustMkdir(otherLun)
+	if err := os.Symlink(other, filepath.Join(otherLun, "d4e5f6")); err != nil {
+		t.Fatal(err)
+	}
+
+	mustMkdir(filepath.Join(blockRoot, "zd0", "holders", "dm-3"))
+
+	consumers := findVolumeConsumers(lioRoot, blockRoot, []string{"/dev/zvol/tank/vm1", "/dev/zd0"})
+	if len(consumers) != 2 {
+		t.Fatalf("expected 2 consumers, got %d: %+v", len(consumers), consumers)
+	}
+	if consumers[0].Type != VolumeConsumerISCSI || consumers[0].Name != iqn {
+		t.Errorf("unexpected iSCSI consumer: %+v", consumers[0])
+	}
+	if consumers[1].Type != VolumeConsumerHolder || consumers[1].Name != "dm-3" {
+		t.Errorf("unexpected holder consumer: %+v", consumers[1])
+	}
+}
@@ -0,0 +1,301 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// AuxClass is a class of auxiliary vdev: a separate intent log (SLOG), an
+// L2ARC cache or an allocation class vdev for metadata and small blocks
+type AuxClass string
+
+const (
+	AuxLog     AuxClass = "log"
+	AuxCache   AuxClass = "cache"
+	AuxSpecial AuxClass = "special"
+	AuxDedup   AuxClass = "dedup"
+)
+
+// AuxVDevConfig adds log, cache, special or dedup vdevs to a pool
+type AuxVDevConfig struct {
+	Name    string   `json:"name"`
+	Class   AuxClass `json:"class" binding:"required"`
+	Devices []string `json:"devices" binding:"required"`
+	Mirror  bool     `json:"mirror"` // Mirror the devices; cache devices are always striped
+	Force   bool     `json:"force"`  // Add an unmirrored special or dedup vdev
+}
+
+// AuxValidation is the outcome of checking auxiliary vdevs before adding
+// them, with the command that adds them
+type AuxValidation struct {
+	Valid       bool     `json:"valid"`
+	Errors      []string `json:"errors,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Command     string   `json:"command"`
+}
+
+// AuxVDevStatus is an auxiliary vdev of a pool with the risks of its layout
+type AuxVDevStatus struct {
+	Class      AuxClass `json:"class"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // mirror, disk, ...
+	State      string   `json:"state"`
+	Devices    []string `json:"devices"`
+	AllocSpace string   `json:"alloc_space,omitempty"`
+	TotalSpace string   `json:"total_space,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// auxSections returns the auxiliary vdevs of a pool by class
+func (pool Pool) auxSections() map[AuxClass]map[string]*VDev {
+	return map[AuxClass]map[string]*VDev{
+		AuxLog:     pool.Logs,
+		AuxCache:   pool.L2Cache,
+		AuxSpecial: pool.Special,
+		AuxDedup:   pool.Dedup,
+	}
+}
+
+// AuxStatus summarizes the auxiliary vdevs of a pool, logs first
+func AuxStatus(pool Pool) []AuxVDevStatus {
+	var statuses []AuxVDevStatus
+	sections := pool.auxSections()
+	for _, class := range []AuxClass{AuxLog, AuxSpecial, AuxDedup, AuxCache} {
+		vdevs := sections[class]
+		for _, name := range slices.Sorted(maps.Keys(vdevs)) {
+			vdev := vdevs[name]
+			status := AuxVDevStatus{
+				Class:      class,
+				Name:       name,
+				Type:       vdev.VDevType,
+				State:      vdev.State,
+				Devices:    leafPaths(vdev),
+				AllocSpace: vdev.AllocSpace,
+				TotalSpace: vdev.TotalSpace,
+			}
+			if vdev.State != "" && vdev.State != "ONLINE" {
+				status.Warnings = append(status.Warnings, fmt.Sprintf("%s is %s", name, vdev.State))
+			}
+			if vdev.VDevType != "mirror" {
+				switch class {
+				case AuxLog:
+					status.Warnings = append(status.Warnings,
+						"log is not mirrored; sync writes of the last seconds are lost if it fails with a crash")
+				case AuxSpecial, AuxDedup:
+					status.Warnings = append(status.Warnings,
+						"vdev is not mirrored; losing it loses the pool")
+				}
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return statuses
+}
+
+// leafPaths returns the device paths of a vdev's disks
+func leafPaths(vdev *VDev) []string {
+	if len(vdev.VDevs) == 0 {
+		if vdev.Path != "" {
+			return []string{vdev.Path}
+		}
+		return []string{vdev.Name}
+	}
+	var paths []string
+	for _, name := range slices.Sorted(maps.Keys(vdev.VDevs)) {
+		paths = append(paths, leafPaths(vdev.VDevs[name])...)
+	}
+	return paths
+}
+
+// dataRedundancy returns how many disks the least redundant data vdev of a
+// pool can lose, and whether any data vdev is raidz or dRAID
+func dataRedundancy(pool Pool) (redundancy int, parityVdevs bool) {
+	redundancy = -1
+	for _, root := range pool.VDevs {
+		for _, vdev := range root.VDevs {
+			var parity int
+			switch {
+			case vdev.VDevType == "mirror":
+				parity = len(vdev.VDevs) - 1
+			case strings.HasPrefix(vdev.VDevType, "raidz"), strings.HasPrefix(vdev.VDevType, "draid"):
+				parityVdevs = true
+				parity = vdevParity(vdev.Name)
+			}
+			if redundancy < 0 || parity < redundancy {
+				redundancy = parity
+			}
+		}
+	}
+	return max(redundancy, 0), parityVdevs
+}
+
+// vdevParity returns the parity of a raidz or dRAID vdev from its name, such
+// as raidz2-0 or draid2:8d:12c:1s-0. raidz-0 is single parity.
+func vdevParity(name string) int {
+	name = strings.TrimPrefix(strings.TrimPrefix(name, "raidz"), "draid")
+	if name != "" && name[0] >= '1' && name[0] <= '3' {
+		return int(name[0] - '0')
+	}
+	return 1
+}
+
+// auxSpec returns the vdev spec adding auxiliary vdevs
+func auxSpec(cfg AuxVDevConfig) []VDevSpec {
+	if cfg.Mirror {
+		return []VDevSpec{{Type: string(cfg.Class), Children: []VDevSpec{{Type: "mirror", Devices: cfg.Devices}}}}
+	}
+	return []VDevSpec{{Type: string(cfg.Class), Devices: cfg.Devices}}
+}
+
+// ValidateAux checks auxiliary vdevs to add against a pool
+func ValidateAux(cfg AuxVDevConfig, pool Pool) *AuxValidation {
+	v := &AuxValidation{}
+
+	switch cfg.Class {
+	case AuxLog, AuxCache, AuxSpecial, AuxDedup:
+	default:
+		v.Errors = append(v.Errors, fmt.Sprintf("class must be log, cache, special or dedup, not %q", cfg.Class))
+		return v
+	}
+	if len(cfg.Devices) == 0 {
+		v.Errors = append(v.Errors, "no devices given")
+	}
+	if cfg.Mirror && len(cfg.Devices) < 2 {
+		v.Errors = append(v.Errors, "a mirror needs at least two devices")
+	}
+
+	redundancy, parityVdevs := dataRedundancy(pool)
+	switch cfg.Class {
+	case AuxLog:
+		if !cfg.Mirror {
+			v.Warnings = append(v.Warnings,
+				"log is not mirrored; if it fails together with a crash or power loss, the last seconds of sync writes are lost")
+		}
+		v.Suggestions = append(v.Suggestions,
+			"a log holds a few seconds of sync writes, so a small SSD with power-loss protection is enough; it only helps sync-heavy loads such as NFS and databases")
+
+	case AuxCache:
+		if cfg.Mirror {
+			v.Errors = append(v.Errors, "cache devices cannot be mirrored; ZFS stripes them and tolerates their loss")
+		}
+		v.Suggestions = append(v.Suggestions,
+			"L2ARC helps when the working set exceeds RAM; its headers take ARC memory, so check the ARC hit ratio before adding a large cache")
+
+	case AuxSpecial, AuxDedup:
+		width := len(cfg.Devices)
+		if !cfg.Mirror {
+			width = 1
+		}
+		switch {
+		case !cfg.Mirror && cfg.Force:
+			v.Warnings = append(v.Warnings, fmt.Sprintf(
+				"%s vdev is not mirrored; it holds pool metadata, so losing it loses the pool", cfg.Class))
+		case !cfg.Mirror:
+			v.Errors = append(v.Errors, fmt.Sprintf(
+				"%s vdev holds pool metadata, so losing it loses the pool; mirror it, or force to add it unmirrored", cfg.Class))
+		case width-1 < redundancy:
+			v.Warnings = append(v.Warnings, fmt.Sprintf(
+				"a %d-way mirror survives %d failed devices but the data vdevs survive %d; use a %d-way mirror",
+				width, width-1, redundancy, redundancy+1))
+		}
+		if parityVdevs {
+			v.Warnings = append(v.Warnings, fmt.Sprintf(
+				"the pool has raidz or dRAID vdevs, so the %s vdev cannot be removed once added", cfg.Class))
+		}
+		if cfg.Class == AuxSpecial {
+			v.Suggestions = append(v.Suggestions,
+				"the special vdev stores metadata; to also store small blocks on it, set special_small_blocks on datasets, "+
+					"such as 32K, below their recordsize so file data still goes to the data vdevs")
+		}
+	}
+
+	command := []string{"zpool", "add"}
+	if cfg.Force {
+		command = append(command, "-f")
+	}
+	command = append(command, pool.Name)
+	v.Command = strings.Join(append(command, buildVDevArgs(auxSpec(cfg))...), " ")
+	v.Valid = len(v.Errors) == 0
+	return v
+}
+
+// poolStatus returns the status of one pool
+func (p *Manager) poolStatus(ctx context.Context, name string) (Pool, error) {
+	status, err := p.Status(ctx, name)
+	if err != nil {
+		return Pool{}, err
+	}
+	pool, ok := status.Pools[name]
+	if !ok {
+		return Pool{}, errors.New(errors.ZFSPoolNotFound, "pool not found").WithMetadata("pool", name)
+	}
+	return pool, nil
+}
+
+// ValidateAuxVDevs checks auxiliary vdevs to add to a pool
+func (p *Manager) ValidateAuxVDevs(ctx context.Context, cfg AuxVDevConfig) (*AuxValidation, error) {
+	pool, err := p.poolStatus(ctx, cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateAux(cfg, pool), nil
+}
+
+// AddAuxVDevs adds log, cache, special or dedup vdevs to a pool once they
+// pass validation
+func (p *Manager) AddAuxVDevs(ctx context.Context, cfg AuxVDevConfig) (*AuxValidation, error) {
+	validation, err := p.ValidateAuxVDevs(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !validation.Valid {
+		return validation, errors.New(errors.ZFSPoolInvalidDevice, strings.Join(validation.Errors, "; ")).
+			WithMetadata("pool", cfg.Name).
+			WithMetadata("class", string(cfg.Class))
+	}
+
+	if err := p.Add(ctx, AddConfig{Name: cfg.Name, VDevSpec: auxSpec(cfg), Force: cfg.Force}); err != nil {
+		return validation, err
+	}
+	return validation, nil
+}
+
+// RemoveAuxVDev removes a log, cache, special or dedup vdev from a pool, by
+// its name or, for single disks, its path. Disks of mirrors are detached
+// instead. Special and dedup vdevs cannot be removed from pools with raidz
+// or dRAID vdevs.
+func (p *Manager) RemoveAuxVDev(ctx context.Context, name, vdev string) error {
+	pool, err := p.poolStatus(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	for class, vdevs := range pool.auxSections() {
+		for top, v := range vdevs {
+			if top != vdev && (len(v.VDevs) > 0 || v.Path != vdev) {
+				continue
+			}
+			if _, parityVdevs := dataRedundancy(pool); parityVdevs && (class == AuxSpecial || class == AuxDedup) {
+				return errors.New(errors.ZFSPoolDeviceOperation, fmt.Sprintf(
+					"%s vdevs cannot be removed from pools with raidz or dRAID vdevs", class)).
+					WithMetadata("pool", name).
+					WithMetadata("vdev", vdev)
+			}
+			return p.Remove(ctx, name, []string{vdev})
+		}
+	}
+
+	return errors.New(errors.ZFSPoolInvalidDevice, "not a log, cache, special or dedup vdev of the pool").
+		WithMetadata("pool", name).
+		WithMetadata("vdev", vdev)
+}
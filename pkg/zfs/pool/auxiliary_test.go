@@ -0,0 +1,144 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// auxStatusJSON is zpool status -j output of a raidz2 pool with a mirrored
+// log, an unmirrored special vdev and a cache device
+const auxStatusJSON = `{
+  "pools": {
+    "tank": {
+      "name": "tank",
+      "state": "ONLINE",
+      "vdevs": {
+        "tank": {
+          "name": "tank", "vdev_type": "root", "state": "ONLINE",
+          "vdevs": {
+            "raidz2-0": {
+              "name": "raidz2-0", "vdev_type": "raidz", "state": "ONLINE",
+              "vdevs": {
+                "/dev/sda1": {"name": "/dev/sda1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/sda1"},
+                "/dev/sdb1": {"name": "/dev/sdb1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/sdb1"},
+                "/dev/sdc1": {"name": "/dev/sdc1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/sdc1"},
+                "/dev/sdd1": {"name": "/dev/sdd1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/sdd1"}
+              }
+            }
+          }
+        }
+      },
+      "logs": {
+        "mirror-1": {
+          "name": "mirror-1", "vdev_type": "mirror", "state": "ONLINE", "class": "log",
+          "vdevs": {
+            "/dev/nvme0n1p1": {"name": "/dev/nvme0n1p1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/nvme0n1p1"},
+            "/dev/nvme1n1p1": {"name": "/dev/nvme1n1p1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/nvme1n1p1"}
+          }
+        }
+      },
+      "special": {
+        "/dev/nvme2n1p1": {"name": "/dev/nvme2n1p1", "vdev_type": "disk", "state": "DEGRADED", "class": "special", "path": "/dev/nvme2n1p1"}
+      },
+      "l2cache": {
+        "/dev/sde1": {"name": "/dev/sde1", "vdev_type": "disk", "state": "ONLINE", "path": "/dev/sde1"}
+      }
+    }
+  }
+}`
+
+func auxTestPool(t *testing.T) Pool {
+	t.Helper()
+	var status PoolStatus
+	if err := json.Unmarshal([]byte(auxStatusJSON), &status); err != nil {
+		t.Fatalf("failed to parse status: %v", err)
+	}
+	return status.Pools["tank"]
+}
+
+func TestAuxStatus(t *testing.T) {
+	statuses := AuxStatus(auxTestPool(t))
+	if len(statuses) != 3 {
+		t.Fatalf("got %d auxiliary vdevs, want 3", len(statuses))
+	}
+
+	log, special, cache := statuses[0], statuses[1], statuses[2]
+	if log.Class != AuxLog || log.Type != "mirror" || len(log.Devices) != 2 || len(log.Warnings) != 0 {
+		t.Errorf("unexpected log status: %+v", log)
+	}
+	if special.Class != AuxSpecial || len(special.Warnings) != 2 {
+		t.Errorf("special vdev should warn that it is degraded and unmirrored: %+v", special)
+	}
+	if cache.Class != AuxCache || len(cache.Warnings) != 0 {
+		t.Errorf("unexpected cache status: %+v", cache)
+	}
+}
+
+func TestValidateAux(t *testing.T) {
+	pool := auxTestPool(t)
+
+	tests := []struct {
+		name      string
+		cfg       AuxVDevConfig
+		valid     bool
+		warning   string
+		command   string
+		suggested string
+	}{
+		{
+			name:    "unmirrored log",
+			cfg:     AuxVDevConfig{Class: AuxLog, Devices: []string{"/dev/nvme3n1"}},
+			valid:   true,
+			warning: "log is not mirrored",
+			command: "zpool add tank log /dev/nvme3n1",
+		},
+		{
+			name:  "mirrored cache",
+			cfg:   AuxVDevConfig{Class: AuxCache, Devices: []string{"/dev/sdf", "/dev/sdg"}, Mirror: true},
+			valid: false,
+		},
+		{
+			name:  "unmirrored special",
+			cfg:   AuxVDevConfig{Class: AuxSpecial, Devices: []string{"/dev/nvme3n1"}},
+			valid: false,
+		},
+		{
+			name:      "two-way special on raidz2",
+			cfg:       AuxVDevConfig{Class: AuxSpecial, Devices: []string{"/dev/nvme3n1", "/dev/nvme4n1"}, Mirror: true},
+			valid:     true,
+			warning:   "use a 3-way mirror",
+			command:   "zpool add tank special mirror /dev/nvme3n1 /dev/nvme4n1",
+			suggested: "special_small_blocks",
+		},
+		{
+			name:    "forced special",
+			cfg:     AuxVDevConfig{Class: AuxSpecial, Devices: []string{"/dev/nvme3n1"}, Force: true},
+			valid:   true,
+			warning: "cannot be removed",
+			command: "zpool add -f tank special /dev/nvme3n1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := ValidateAux(tt.cfg, pool)
+			if v.Valid != tt.valid {
+				t.Fatalf("valid = %v, want %v (errors %v)", v.Valid, tt.valid, v.Errors)
+			}
+			if tt.warning != "" && !strings.Contains(strings.Join(v.Warnings, "\n"), tt.warning) {
+				t.Errorf("warnings %v do not mention %q", v.Warnings, tt.warning)
+			}
+			if tt.command != "" && v.Command != tt.command {
+				t.Errorf("command = %q, want %q", v.Command, tt.command)
+			}
+			if tt.suggested != "" && !strings.Contains(strings.Join(v.Suggestions, "\n"), tt.suggested) {
+				t.Errorf("suggestions %v do not mention %q", v.Suggestions, tt.suggested)
+			}
+		})
+	}
+}
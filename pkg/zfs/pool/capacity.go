@@ -0,0 +1,216 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Capacity levels and the usage at which they start
+const (
+	CapacityLevelOK       = "ok"
+	CapacityLevelWarning  = "warning"
+	CapacityLevelCritical = "critical"
+
+	CapacityWarningPercent  = 80
+	CapacityCriticalPercent = 90
+)
+
+const (
+	// FragmentationRiseThreshold is the rise in fragmentation percentage
+	// points over the trend window that counts as a sharp rise
+	FragmentationRiseThreshold = 10
+
+	// fragmentationHighPercent is the fragmentation at which rewriting data
+	// is recommended regardless of the trend
+	fragmentationHighPercent = 50
+
+	// capacityTrendWindow is the span of history trends are computed over
+	capacityTrendWindow = 7 * 24 * time.Hour
+
+	// capacityRunwayDays is the time to full below which growing the pool is
+	// recommended before it reaches the warning level
+	capacityRunwayDays = 30
+)
+
+// Recommendation actions
+const (
+	RecommendAddVdev   = "add_vdev"
+	RecommendRebalance = "rebalance"
+	RecommendFreeSpace = "free_space"
+)
+
+// CapacitySample is a pool's space usage at a point in time
+type CapacitySample struct {
+	At            time.Time `json:"at"`
+	SizeBytes     uint64    `json:"size_bytes"`
+	AllocBytes    uint64    `json:"alloc_bytes"`
+	FreeBytes     uint64    `json:"free_bytes"`
+	Capacity      int       `json:"capacity"`      // Percent used
+	Fragmentation int       `json:"fragmentation"` // Percent of free space fragmented; -1 when unknown
+}
+
+// Recommendation is a suggested action for a pool's capacity
+type Recommendation struct {
+	Action   string `json:"action"`
+	Severity string `json:"severity"` // Capacity level that prompted it
+	Reason   string `json:"reason"`
+}
+
+// CapacityAnalytics is a pool's current usage, its trends and the actions
+// recommended for it
+type CapacityAnalytics struct {
+	Pool    string         `json:"pool"`
+	Current CapacitySample `json:"current"`
+	Level   string         `json:"level"`
+
+	// Trends over the last week of history
+	GrowthBytesPerDay   int64            `json:"growth_bytes_per_day"`
+	DaysUntilFull       *float64         `json:"days_until_full,omitempty"` // Nil when usage is not growing
+	FragmentationChange int              `json:"fragmentation_change"`      // Percentage points
+	FragmentationRising bool             `json:"fragmentation_rising"`
+	Recommendations     []Recommendation `json:"recommendations"`
+	History             []CapacitySample `json:"history,omitempty"`
+}
+
+// CapacitySamples samples the space usage of every pool
+func (p *Manager) CapacitySamples(ctx context.Context) (map[string]CapacitySample, error) {
+	result, err := p.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	samples := make(map[string]CapacitySample, len(result.Pools))
+	for name, pool := range result.Pools {
+		samples[name] = CapacitySample{
+			At:            now,
+			SizeBytes:     uint64(max(0, poolPropertyInt(pool, "size"))),
+			AllocBytes:    uint64(max(0, poolPropertyInt(pool, "allocated"))),
+			FreeBytes:     uint64(max(0, poolPropertyInt(pool, "free"))),
+			Capacity:      int(poolPropertyInt(pool, "capacity")),
+			Fragmentation: int(poolPropertyInt(pool, "fragmentation")),
+		}
+	}
+	return samples, nil
+}
+
+// CapacityLevel returns the capacity level of a usage percentage
+func CapacityLevel(capacity int) string {
+	switch {
+	case capacity >= CapacityCriticalPercent:
+		return CapacityLevelCritical
+	case capacity >= CapacityWarningPercent:
+		return CapacityLevelWarning
+	default:
+		return CapacityLevelOK
+	}
+}
+
+// AnalyzeCapacity computes the trends and recommendations of a pool from its
+// samples, oldest first. It returns nil without samples.
+func AnalyzeCapacity(pool string, history []CapacitySample) *CapacityAnalytics {
+	if len(history) == 0 {
+		return nil
+	}
+
+	current := history[len(history)-1]
+	a := &CapacityAnalytics{
+		Pool:            pool,
+		Current:         current,
+		Level:           CapacityLevel(current.Capacity),
+		Recommendations: []Recommendation{},
+		History:         history,
+	}
+
+	// Oldest sample within the trend window
+	base := current
+	for _, s := range history {
+		if current.At.Sub(s.At) <= capacityTrendWindow {
+			base = s
+			break
+		}
+	}
+
+	if days := current.At.Sub(base.At).Hours() / 24; days > 0 {
+		a.GrowthBytesPerDay = int64(float64(int64(current.AllocBytes)-int64(base.AllocBytes)) / days)
+		if a.GrowthBytesPerDay > 0 {
+			full := float64(current.FreeBytes) / float64(a.GrowthBytesPerDay)
+			a.DaysUntilFull = &full
+		}
+	}
+	if current.Fragmentation >= 0 && base.Fragmentation >= 0 {
+		a.FragmentationChange = current.Fragmentation - base.Fragmentation
+		a.FragmentationRising = a.FragmentationChange >= FragmentationRiseThreshold
+	}
+
+	a.Recommendations = recommend(a)
+	return a
+}
+
+// recommend suggests actions for the pool's usage and fragmentation
+func recommend(a *CapacityAnalytics) []Recommendation {
+	recs := []Recommendation{}
+
+	switch {
+	case a.Level != CapacityLevelOK:
+		recs = append(recs, Recommendation{
+			Action:   RecommendAddVdev,
+			Severity: a.Level,
+			Reason: fmt.Sprintf(
+				"Pool is %d%% full; add a vdev to grow it, as ZFS allocation slows above %d%%",
+				a.Current.Capacity, CapacityWarningPercent),
+		})
+	case a.DaysUntilFull != nil && *a.DaysUntilFull < capacityRunwayDays:
+		recs = append(recs, Recommendation{
+			Action:   RecommendAddVdev,
+			Severity: CapacityLevelOK,
+			Reason: fmt.Sprintf(
+				"At the current growth the pool fills in %.0f days; add a vdev before it does",
+				*a.DaysUntilFull),
+		})
+	}
+
+	if a.Level == CapacityLevelCritical {
+		recs = append(recs, Recommendation{
+			Action:   RecommendFreeSpace,
+			Severity: a.Level,
+			Reason:   "Destroy unneeded snapshots or data to free space until the pool is grown",
+		})
+	}
+
+	if a.FragmentationRising || a.Current.Fragmentation >= fragmentationHighPercent {
+		recs = append(recs, Recommendation{
+			Action:   RecommendRebalance,
+			Severity: a.Level,
+			Reason: fmt.Sprintf(
+				"Free space is %d%% fragmented (%+d points this week); rewrite busy "+
+					"datasets with send/receive to rebalance them across vdevs",
+				a.Current.Fragmentation, a.FragmentationChange),
+		})
+	}
+
+	return recs
+}
+
+// poolPropertyInt returns a parsable numeric pool property, -1 for "-" and
+// other non-numeric values
+func poolPropertyInt(pool Pool, name string) int64 {
+	prop, ok := pool.Properties[name]
+	if !ok || prop.Value == nil {
+		return -1
+	}
+
+	value := strings.TrimSuffix(strings.TrimSpace(fmt.Sprint(prop.Value)), "%")
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
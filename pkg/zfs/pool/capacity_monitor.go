@@ -0,0 +1,288 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/events"
+	"github.com/stratastor/rodent/pkg/errors"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+const (
+	// capacityPollInterval is how often pool usage is checked against the
+	// capacity thresholds
+	capacityPollInterval = 15 * time.Minute
+
+	// capacitySampleSpacing is the minimum time between kept samples
+	capacitySampleSpacing = time.Hour
+
+	// maxCapacitySamples bounds the history kept per pool, 30 days of hourly
+	// samples
+	maxCapacitySamples = 30 * 24
+
+	capacityHistoryFile = "pool-capacity.json"
+	capacityPollTimeout = 30 * time.Second
+)
+
+// poolCapacity is the tracked history of a pool and the alerts raised for it
+type poolCapacity struct {
+	History             []CapacitySample `json:"history"`
+	Level               string           `json:"level"`
+	FragmentationRising bool             `json:"fragmentation_rising"`
+}
+
+// CapacityMonitor samples pool usage and fragmentation over time, warns when
+// usage crosses the capacity thresholds or fragmentation rises sharply, and
+// serves capacity analytics from the kept history
+type CapacityMonitor struct {
+	manager *Manager
+	logger  logger.Logger
+	path    string
+
+	mu    sync.RWMutex
+	pools map[string]*poolCapacity
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewCapacityMonitor creates a capacity monitor keeping its history in
+// configDir; call Start to begin sampling
+func NewCapacityMonitor(manager *Manager, l logger.Logger, configDir string) *CapacityMonitor {
+	m := &CapacityMonitor{
+		manager: manager,
+		logger:  l,
+		path:    filepath.Join(configDir, capacityHistoryFile),
+		pools:   make(map[string]*poolCapacity),
+		stop:    make(chan struct{}),
+	}
+	if err := m.load(); err != nil {
+		l.Warn("Failed to load pool capacity history, starting a new one", "error", err)
+	}
+	return m
+}
+
+// Start samples in the background until Stop is called
+func (m *CapacityMonitor) Start() {
+	go m.run()
+}
+
+// Stop ends sampling
+func (m *CapacityMonitor) Stop() {
+	m.stopOnce.Do(func() { close(m.stop) })
+}
+
+// Analytics returns the capacity analytics of a pool from its history,
+// with a fresh sample as its current usage
+func (m *CapacityMonitor) Analytics(ctx context.Context, pool string) (*CapacityAnalytics, error) {
+	samples, err := m.manager.CapacitySamples(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, ok := samples[pool]
+	if !ok {
+		return nil, errors.New(errors.ZFSPoolNotFound, "pool not found").
+			WithMetadata("pool", pool)
+	}
+
+	m.mu.RLock()
+	var history []CapacitySample
+	if pc, ok := m.pools[pool]; ok {
+		history = append(history, pc.History...)
+	}
+	m.mu.RUnlock()
+
+	return AnalyzeCapacity(pool, append(history, current)), nil
+}
+
+// AllAnalytics returns the capacity analytics of every pool, ordered by
+// name, without their sample history
+func (m *CapacityMonitor) AllAnalytics(ctx context.Context) ([]*CapacityAnalytics, error) {
+	samples, err := m.manager.CapacitySamples(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	analytics := make([]*CapacityAnalytics, 0, len(samples))
+	for name, current := range samples {
+		var history []CapacitySample
+		if pc, ok := m.pools[name]; ok {
+			history = append(history, pc.History...)
+		}
+		a := AnalyzeCapacity(name, append(history, current))
+		a.History = nil
+		analytics = append(analytics, a)
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(analytics, func(a, b *CapacityAnalytics) int {
+		return strings.Compare(a.Pool, b.Pool)
+	})
+	return analytics, nil
+}
+
+func (m *CapacityMonitor) run() {
+	ticker := time.NewTicker(capacityPollInterval)
+	defer ticker.Stop()
+
+	m.poll()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			m.poll()
+		}
+	}
+}
+
+// poll samples every pool, keeps samples at most hourly and raises alerts
+// when a pool's level or fragmentation trend changes
+func (m *CapacityMonitor) poll() {
+	ctx, cancel := context.WithTimeout(context.Background(), capacityPollTimeout)
+	defer cancel()
+
+	samples, err := m.manager.CapacitySamples(ctx)
+	if err != nil {
+		m.logger.Debug("Failed to sample pool capacity", "error", err)
+		return
+	}
+
+	var alerts []*CapacityAnalytics
+	var actions []string
+
+	m.mu.Lock()
+	for name, sample := range samples {
+		pc, ok := m.pools[name]
+		if !ok {
+			pc = &poolCapacity{Level: CapacityLevelOK}
+			m.pools[name] = pc
+		}
+
+		// Samples between kept ones still count as the current usage
+		history := pc.History
+		if n := len(history); n == 0 || sample.At.Sub(history[n-1].At) >= capacitySampleSpacing {
+			pc.History = append(pc.History, sample)
+			if len(pc.History) > maxCapacitySamples {
+				pc.History = pc.History[len(pc.History)-maxCapacitySamples:]
+			}
+			history = pc.History
+		} else {
+			history = append(history[:n:n], sample)
+		}
+
+		a := AnalyzeCapacity(name, history)
+		if a.Level != pc.Level {
+			action := "capacity_" + a.Level
+			if a.Level == CapacityLevelOK {
+				action = "capacity_recovered"
+			}
+			actions = append(actions, action)
+			alerts = append(alerts, a)
+			pc.Level = a.Level
+		}
+		if a.FragmentationRising != pc.FragmentationRising {
+			if a.FragmentationRising {
+				actions = append(actions, "fragmentation_rising")
+				alerts = append(alerts, a)
+			}
+			pc.FragmentationRising = a.FragmentationRising
+		}
+	}
+	m.mu.Unlock()
+
+	if err := m.save(); err != nil {
+		m.logger.Warn("Failed to save pool capacity history", "error", err)
+	}
+
+	for i, a := range alerts {
+		m.logger.Info("Pool capacity alert",
+			"pool", a.Pool,
+			"action", actions[i],
+			"capacity", a.Current.Capacity,
+			"fragmentation", a.Current.Fragmentation)
+		emitCapacityEvent(a, actions[i])
+	}
+}
+
+// load reads the kept history
+func (m *CapacityMonitor) load() error {
+	data, err := os.ReadFile(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, errors.ConfigLoadFailed).WithMetadata("path", m.path)
+	}
+
+	pools := make(map[string]*poolCapacity)
+	if err := json.Unmarshal(data, &pools); err != nil {
+		return errors.Wrap(err, errors.ConfigUnmarshalFailed).WithMetadata("path", m.path)
+	}
+
+	m.mu.Lock()
+	m.pools = pools
+	m.mu.Unlock()
+	return nil
+}
+
+// save writes the kept history
+func (m *CapacityMonitor) save() error {
+	m.mu.RLock()
+	data, err := json.Marshal(m.pools)
+	m.mu.RUnlock()
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigMarshalFailed).WithMetadata("path", m.path)
+	}
+
+	if err := os.WriteFile(m.path, data, 0644); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteFailed).WithMetadata("path", m.path)
+	}
+	return nil
+}
+
+// emitCapacityEvent publishes a capacity alert on the storage pool event
+// stream, with the recommended actions
+func emitCapacityEvent(a *CapacityAnalytics, action string) {
+	level := eventspb.EventLevel_EVENT_LEVEL_INFO
+	switch {
+	case a.Level == CapacityLevelCritical:
+		level = eventspb.EventLevel_EVENT_LEVEL_ERROR
+	case a.Level == CapacityLevelWarning || a.FragmentationRising:
+		level = eventspb.EventLevel_EVENT_LEVEL_WARN
+	}
+
+	recommendations := make([]string, 0, len(a.Recommendations))
+	for _, r := range a.Recommendations {
+		recommendations = append(recommendations, r.Action)
+	}
+
+	events.EmitStoragePool(level, &eventspb.StoragePoolPayload{
+		PoolName:  a.Pool,
+		SizeBytes: int64(a.Current.SizeBytes),
+		UsedBytes: int64(a.Current.AllocBytes),
+		Operation: eventspb.StoragePoolPayload_STORAGE_POOL_OPERATION_UNSPECIFIED,
+	}, map[string]string{
+		"component":            "zfs-pool-capacity",
+		"action":               action,
+		"level":                a.Level,
+		"capacity":             strconv.Itoa(a.Current.Capacity),
+		"fragmentation":        strconv.Itoa(a.Current.Fragmentation),
+		"fragmentation_change": strconv.Itoa(a.FragmentationChange),
+		"recommendations":      strings.Join(recommendations, ","),
+	})
+}
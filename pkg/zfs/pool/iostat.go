@@ -0,0 +1,212 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// VDevIOSample is the IO of a pool or vdev over a sampling interval
+type VDevIOSample struct {
+	ReadOps    uint64 `json:"read_ops"`    // Operations per second
+	WriteOps   uint64 `json:"write_ops"`   // Operations per second
+	ReadBytes  uint64 `json:"read_bytes"`  // Bytes per second
+	WriteBytes uint64 `json:"write_bytes"` // Bytes per second
+
+	// Average latencies in nanoseconds; 0 when there was no IO
+	TotalWaitRead  uint64 `json:"total_wait_read_ns"`
+	TotalWaitWrite uint64 `json:"total_wait_write_ns"`
+	DiskWaitRead   uint64 `json:"disk_wait_read_ns"`
+	DiskWaitWrite  uint64 `json:"disk_wait_write_ns"`
+}
+
+// PoolIOSample is the IO of a pool and each of its vdevs over a sampling
+// interval
+type PoolIOSample struct {
+	At       time.Time               `json:"at"`
+	Interval time.Duration           `json:"interval"`
+	Pool     string                  `json:"pool"`
+	Total    VDevIOSample            `json:"total"`
+	VDevs    map[string]VDevIOSample `json:"vdevs"` // By vdev name, leaf vdevs by full path
+}
+
+// LatencyHistogram counts the operations a pool or vdev completed since the
+// pool was imported, by latency. Counts are per bucket, not cumulative.
+type LatencyHistogram struct {
+	BucketsNS      []uint64 `json:"buckets_ns"` // Bucket upper bounds
+	TotalWaitRead  []uint64 `json:"total_wait_read"`
+	TotalWaitWrite []uint64 `json:"total_wait_write"`
+	DiskWaitRead   []uint64 `json:"disk_wait_read"`
+	DiskWaitWrite  []uint64 `json:"disk_wait_write"`
+}
+
+// iostatSections are the vdev class lines of zpool iostat -v, printed
+// without indentation like pools
+var iostatSections = map[string]bool{
+	"logs":    true,
+	"cache":   true,
+	"spares":  true,
+	"special": true,
+	"dedup":   true,
+}
+
+// SampleIOStats measures the IO of every pool and vdev over the interval,
+// blocking for its duration
+func (p *Manager) SampleIOStats(
+	ctx context.Context,
+	interval time.Duration,
+) (map[string]*PoolIOSample, error) {
+	seconds := max(1, int(interval.Seconds()))
+	args := []string{
+		"iostat",
+		"-P", // Full vdev paths, as in status
+		"-v", // Per-vdev statistics
+		"-l", // Average latencies
+		"-p", // Exact values
+		"-y", // Skip the statistics since import
+		strconv.Itoa(seconds), "1",
+	}
+
+	out, err := p.executor.Execute(ctx, command.CommandOptions{}, "zpool iostat", args...)
+	if err != nil {
+		if len(out) > 0 {
+			return nil, errors.Wrap(err, errors.ZFSPoolDeviceOperation).
+				WithMetadata("output", string(out))
+		}
+		return nil, errors.Wrap(err, errors.ZFSPoolDeviceOperation)
+	}
+
+	return parseIOStatSamples(string(out), time.Now(), time.Duration(seconds)*time.Second), nil
+}
+
+// LatencyHistograms returns the latency histograms of the pools and their
+// vdevs, by pool and then by pool or vdev name
+func (p *Manager) LatencyHistograms(
+	ctx context.Context,
+	pools []string,
+) (map[string]map[string]*LatencyHistogram, error) {
+	args := []string{"iostat", "-P", "-v", "-w", "-p"}
+	args = append(args, pools...)
+
+	out, err := p.executor.Execute(ctx, command.CommandOptions{}, "zpool iostat", args...)
+	if err != nil {
+		if len(out) > 0 {
+			return nil, errors.Wrap(err, errors.ZFSPoolDeviceOperation).
+				WithMetadata("output", string(out))
+		}
+		return nil, errors.Wrap(err, errors.ZFSPoolDeviceOperation)
+	}
+
+	return parseLatencyHistograms(string(out), pools), nil
+}
+
+// parseIOStatSamples parses zpool iostat -Pvlpy output. Pool lines are not
+// indented; vdev lines follow their pool, indented.
+func parseIOStatSamples(
+	output string,
+	at time.Time,
+	interval time.Duration,
+) map[string]*PoolIOSample {
+	samples := make(map[string]*PoolIOSample)
+
+	var current *PoolIOSample
+	pastHeader := false
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "---") {
+			pastHeader = true
+			continue
+		}
+		fields := strings.Fields(line)
+		if !pastHeader || len(fields) < 7 {
+			continue
+		}
+
+		indented := line[0] == ' ' || line[0] == '\t'
+		if !indented && iostatSections[fields[0]] {
+			continue
+		}
+
+		io := VDevIOSample{
+			ReadOps:    parseStatUint(fields[3]),
+			WriteOps:   parseStatUint(fields[4]),
+			ReadBytes:  parseStatUint(fields[5]),
+			WriteBytes: parseStatUint(fields[6]),
+		}
+		if len(fields) >= 11 {
+			io.TotalWaitRead = parseStatUint(fields[7])
+			io.TotalWaitWrite = parseStatUint(fields[8])
+			io.DiskWaitRead = parseStatUint(fields[9])
+			io.DiskWaitWrite = parseStatUint(fields[10])
+		}
+
+		if !indented {
+			current = &PoolIOSample{
+				At:       at,
+				Interval: interval,
+				Pool:     fields[0],
+				Total:    io,
+				VDevs:    make(map[string]VDevIOSample),
+			}
+			samples[current.Pool] = current
+		} else if current != nil {
+			current.VDevs[fields[0]] = io
+		}
+	}
+	return samples
+}
+
+// parseLatencyHistograms parses zpool iostat -Pvwp output: a block per pool
+// and vdev, headed by its name, with a row per latency bucket. Each pool's
+// block comes before those of its vdevs.
+func parseLatencyHistograms(output string, pools []string) map[string]map[string]*LatencyHistogram {
+	histograms := make(map[string]map[string]*LatencyHistogram)
+	for _, pool := range pools {
+		histograms[pool] = make(map[string]*LatencyHistogram)
+	}
+
+	var pool string
+	var current *LatencyHistogram
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "---") {
+			continue
+		}
+
+		// Block header: "<name>  total_wait  disk_wait  syncq_wait  asyncq_wait"
+		if len(fields) >= 2 && fields[1] == "total_wait" {
+			name := fields[0]
+			if _, ok := histograms[name]; ok {
+				pool = name
+			}
+			current = nil
+			if pool != "" {
+				current = &LatencyHistogram{}
+				histograms[pool][name] = current
+			}
+			continue
+		}
+
+		if current == nil || len(fields) < 5 {
+			continue
+		}
+		bound, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			// Column titles
+			continue
+		}
+		current.BucketsNS = append(current.BucketsNS, bound)
+		current.TotalWaitRead = append(current.TotalWaitRead, parseStatUint(fields[1]))
+		current.TotalWaitWrite = append(current.TotalWaitWrite, parseStatUint(fields[2]))
+		current.DiskWaitRead = append(current.DiskWaitRead, parseStatUint(fields[3]))
+		current.DiskWaitWrite = append(current.DiskWaitWrite, parseStatUint(fields[4]))
+	}
+	return histograms
+}
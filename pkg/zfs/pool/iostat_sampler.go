@@ -0,0 +1,252 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/metrics"
+)
+
+const (
+	// iostatSampleInterval is how often pool IO is sampled
+	iostatSampleInterval = time.Minute
+
+	// iostatSampleWindow is the interval each sample measures IO over
+	iostatSampleWindow = 10 * time.Second
+
+	// maxIOStatSamples bounds the samples kept per pool, a day at the
+	// sampling interval
+	maxIOStatSamples = 24 * 60
+
+	iostatSampleTimeout = iostatSampleWindow + 30*time.Second
+)
+
+// IOStatHistory is a pool's kept IO samples with its latency histograms
+type IOStatHistory struct {
+	Pool       string                       `json:"pool"`
+	Current    *PoolIOSample                `json:"current,omitempty"`
+	Samples    []*PoolIOSample              `json:"samples"` // Oldest first
+	Histograms map[string]*LatencyHistogram `json:"latency_histograms,omitempty"`
+}
+
+// IOStatSampler samples the IO of every pool and vdev at intervals and keeps
+// a day of samples, so IO hotspots can be found through the API or
+// Prometheus without running zpool iostat by hand
+type IOStatSampler struct {
+	manager *Manager
+	logger  logger.Logger
+
+	mu         sync.RWMutex
+	samples    map[string][]*PoolIOSample
+	histograms map[string]map[string]*LatencyHistogram
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewIOStatSampler creates an IO sampler; call Start to begin sampling
+func NewIOStatSampler(manager *Manager, l logger.Logger) *IOStatSampler {
+	return &IOStatSampler{
+		manager:    manager,
+		logger:     l,
+		samples:    make(map[string][]*PoolIOSample),
+		histograms: make(map[string]map[string]*LatencyHistogram),
+		stop:       make(chan struct{}),
+	}
+}
+
+// Start samples in the background until Stop is called
+func (s *IOStatSampler) Start() {
+	go s.run()
+}
+
+// Stop ends sampling
+func (s *IOStatSampler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// History returns the kept IO samples and latency histograms of a pool, and
+// false when the pool has not been sampled
+func (s *IOStatSampler) History(pool string) (*IOStatHistory, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples, ok := s.samples[pool]
+	if !ok {
+		return nil, false
+	}
+
+	history := &IOStatHistory{
+		Pool:       pool,
+		Samples:    slices.Clone(samples),
+		Histograms: s.histograms[pool],
+	}
+	if len(samples) > 0 {
+		history.Current = samples[len(samples)-1]
+	}
+	return history, true
+}
+
+func (s *IOStatSampler) run() {
+	ticker := time.NewTicker(iostatSampleInterval)
+	defer ticker.Stop()
+
+	s.sample()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+// sample records one IO sample and the latency histograms of every pool.
+// Pools no longer present are dropped.
+func (s *IOStatSampler) sample() {
+	ctx, cancel := context.WithTimeout(context.Background(), iostatSampleTimeout)
+	defer cancel()
+
+	samples, err := s.manager.SampleIOStats(ctx, iostatSampleWindow)
+	if err != nil {
+		s.logger.Debug("Failed to sample pool IO", "error", err)
+		return
+	}
+
+	pools := slices.Sorted(maps.Keys(samples))
+	var histograms map[string]map[string]*LatencyHistogram
+	if len(pools) > 0 {
+		histograms, err = s.manager.LatencyHistograms(ctx, pools)
+		if err != nil {
+			s.logger.Debug("Failed to read pool latency histograms", "error", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make(map[string][]*PoolIOSample, len(samples))
+	for pool, sample := range samples {
+		history := append(s.samples[pool], sample)
+		if len(history) > maxIOStatSamples {
+			history = history[len(history)-maxIOStatSamples:]
+		}
+		kept[pool] = history
+	}
+	s.samples = kept
+
+	// Keep the last histograms when they could not be read
+	if histograms != nil {
+		s.histograms = histograms
+	}
+}
+
+// WritePrometheus writes the latest IO sample and latency histograms of
+// every pool in the Prometheus text format. Pool totals carry the pool name
+// as their vdev label.
+func (s *IOStatSampler) WritePrometheus(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var b strings.Builder
+	pools := slices.Sorted(maps.Keys(s.samples))
+
+	gauges := []struct {
+		name  string
+		help  string
+		value func(VDevIOSample) float64
+	}{
+		{
+			"rodent_zpool_read_ops", "Read operations per second",
+			func(v VDevIOSample) float64 { return float64(v.ReadOps) },
+		},
+		{
+			"rodent_zpool_write_ops", "Write operations per second",
+			func(v VDevIOSample) float64 { return float64(v.WriteOps) },
+		},
+		{
+			"rodent_zpool_read_bytes", "Bytes read per second",
+			func(v VDevIOSample) float64 { return float64(v.ReadBytes) },
+		},
+		{
+			"rodent_zpool_write_bytes", "Bytes written per second",
+			func(v VDevIOSample) float64 { return float64(v.WriteBytes) },
+		},
+		{
+			"rodent_zpool_total_wait_read_seconds", "Average read latency, including queueing,",
+			func(v VDevIOSample) float64 { return nsToSeconds(v.TotalWaitRead) },
+		},
+		{
+			"rodent_zpool_total_wait_write_seconds", "Average write latency, including queueing,",
+			func(v VDevIOSample) float64 { return nsToSeconds(v.TotalWaitWrite) },
+		},
+		{
+			"rodent_zpool_disk_wait_read_seconds", "Average read latency of the disks",
+			func(v VDevIOSample) float64 { return nsToSeconds(v.DiskWaitRead) },
+		},
+		{
+			"rodent_zpool_disk_wait_write_seconds", "Average write latency of the disks",
+			func(v VDevIOSample) float64 { return nsToSeconds(v.DiskWaitWrite) },
+		},
+	}
+	for _, g := range gauges {
+		metrics.WriteHeader(&b, g.name, "gauge", g.help+" over the last sample interval")
+		for _, pool := range pools {
+			history := s.samples[pool]
+			current := history[len(history)-1]
+			metrics.WriteSample(&b, g.name, g.value(current.Total), "pool", pool, "vdev", pool)
+			for _, vdev := range slices.Sorted(maps.Keys(current.VDevs)) {
+				metrics.WriteSample(&b, g.name, g.value(current.VDevs[vdev]), "pool", pool, "vdev", vdev)
+			}
+		}
+	}
+
+	const histogramName = "rodent_zpool_latency_seconds_bucket"
+	metrics.WriteHeader(&b, histogramName, "counter",
+		"Operations completed since import by latency, cumulative by upper bound")
+	for _, pool := range slices.Sorted(maps.Keys(s.histograms)) {
+		for _, vdev := range slices.Sorted(maps.Keys(s.histograms[pool])) {
+			h := s.histograms[pool][vdev]
+			series := []struct {
+				queue, op string
+				counts    []uint64
+			}{
+				{"total_wait", "read", h.TotalWaitRead},
+				{"total_wait", "write", h.TotalWaitWrite},
+				{"disk_wait", "read", h.DiskWaitRead},
+				{"disk_wait", "write", h.DiskWaitWrite},
+			}
+			for _, sr := range series {
+				var cumulative uint64
+				for i, bound := range h.BucketsNS {
+					cumulative += sr.counts[i]
+					metrics.WriteSample(&b, histogramName, float64(cumulative),
+						"pool", pool, "vdev", vdev, "queue", sr.queue, "op", sr.op,
+						"le", strconv.FormatFloat(nsToSeconds(bound), 'g', -1, 64))
+				}
+				metrics.WriteSample(&b, histogramName, float64(cumulative),
+					"pool", pool, "vdev", vdev, "queue", sr.queue, "op", sr.op, "le", "+Inf")
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// nsToSeconds converts nanoseconds to seconds
+func nsToSeconds(ns uint64) float64 {
+	return float64(ns) / float64(time.Second)
+}
@@ -21,6 +21,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -52,18 +54,19 @@ func buildVDevArgs(specs []VDevSpec) []string {
 	return args
 }
 
-// Create creates a new ZFS pool
-func (p *Manager) Create(ctx context.Context, cfg CreateConfig) error {
+// CreateArgs returns the arguments zpool create is run with for a config,
+// after the subcommand and its flags
+func CreateArgs(cfg CreateConfig) []string {
 	args := []string{}
 
-	// Add properties
-	for k, v := range cfg.Properties {
-		args = append(args, "-o", fmt.Sprintf("%s=%s", k, v))
+	// Add properties, sorted so the command reads the same each time
+	for _, k := range slices.Sorted(maps.Keys(cfg.Properties)) {
+		args = append(args, "-o", fmt.Sprintf("%s=%s", k, cfg.Properties[k]))
 	}
 
 	// Add features
-	for feature, enabled := range cfg.Features {
-		if enabled {
+	for _, feature := range slices.Sorted(maps.Keys(cfg.Features)) {
+		if cfg.Features[feature] {
 			args = append(args, "-o", fmt.Sprintf("feature@%s=enabled", feature))
 		}
 	}
@@ -75,6 +78,17 @@ func (p *Manager) Create(ctx context.Context, cfg CreateConfig) error {
 	// Add pool name and vdev specs
 	args = append(args, cfg.Name)
 	args = append(args, buildVDevArgs(cfg.VDevSpec)...)
+	return args
+}
+
+// CreateCommand returns the zpool create command Create runs for a config
+func CreateCommand(cfg CreateConfig) string {
+	return "zpool create -f " + strings.Join(CreateArgs(cfg), " ")
+}
+
+// Create creates a new ZFS pool
+func (p *Manager) Create(ctx context.Context, cfg CreateConfig) error {
+	args := CreateArgs(cfg)
 
 	opts := command.CommandOptions{
 		Flags: command.FlagForce, // if cfg.Force is true
@@ -169,6 +183,11 @@ func (p *Manager) Status(ctx context.Context, name string) (PoolStatus, error) {
 		return status, errors.Wrap(err, errors.CommandOutputParse)
 	}
 
+	for name, pool := range status.Pools {
+		pool.Auxiliary = AuxStatus(pool)
+		status.Pools[name] = pool
+	}
+
 	return status, nil
 }
 
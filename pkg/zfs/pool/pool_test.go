@@ -21,6 +21,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stratastor/logger"
 	"github.com/stratastor/rodent/pkg/zfs/command"
@@ -125,3 +126,183 @@ func TestPoolOperations(t *testing.T) {
 		}
 	})
 }
+
+func TestNewScanProgress(t *testing.T) {
+	now := time.Unix(1_700_001_000, 0)
+
+	// 400 of 1000 bytes issued in a 100s pass, 20s of which were paused
+	scanning := &ScanStats{
+		Function:           "SCRUB",
+		State:              "SCANNING",
+		StartTime:          "1700000900",
+		EndTime:            "0",
+		ToExamine:          "1000",
+		Examined:           "600",
+		Issued:             "400",
+		Processed:          "16",
+		Errors:             "0",
+		PassStart:          "1700000900",
+		ScrubPause:         "-",
+		ScrubSpentPaused:   "20",
+		IssuedBytesPerScan: "400",
+	}
+
+	scan := newScanProgress("tank", scanning, now)
+	if scan == nil || !scan.Active() {
+		t.Fatalf("expected an active scan, got %+v", scan)
+	}
+	if scan.Function != ScanFunctionScrub || scan.Percent != 40 {
+		t.Errorf("unexpected function or percent: %s %.2f", scan.Function, scan.Percent)
+	}
+	if scan.BytesPerSec != 5 || scan.ETASeconds != 120 {
+		t.Errorf("expected 5 B/s with 120s to go, got %d B/s with %ds", scan.BytesPerSec, scan.ETASeconds)
+	}
+	if scan.RepairedBytes != 16 || scan.EndedAt != nil {
+		t.Errorf("unexpected repaired bytes or end time: %d %v", scan.RepairedBytes, scan.EndedAt)
+	}
+
+	paused := *scanning
+	paused.ScrubPause = "1700000990"
+	scan = newScanProgress("tank", &paused, now)
+	if !scan.Paused || scan.BytesPerSec != 0 || scan.ETASeconds != 0 {
+		t.Errorf("paused scrubs have no rate or ETA, got %+v", scan)
+	}
+
+	finished := *scanning
+	finished.State = "FINISHED"
+	finished.EndTime = "1700000990"
+	scan = newScanProgress("tank", &finished, now)
+	if scan.Active() || scan.Percent != 100 || scan.EndedAt == nil {
+		t.Errorf("unexpected finished scan: %+v", scan)
+	}
+
+	if newScanProgress("tank", &ScanStats{Function: "NONE"}, now) != nil {
+		t.Error("pools that were never scanned have no progress")
+	}
+}
+
+func TestAnalyzeCapacity(t *testing.T) {
+	if AnalyzeCapacity("tank", nil) != nil {
+		t.Error("pools without samples have no analytics")
+	}
+
+	start := time.Unix(1_700_000_000, 0)
+	day := 24 * time.Hour
+	sample := func(at time.Duration, alloc uint64, capacity, frag int) CapacitySample {
+		return CapacitySample{
+			At:            start.Add(at),
+			SizeBytes:     1000,
+			AllocBytes:    alloc,
+			FreeBytes:     1000 - alloc,
+			Capacity:      capacity,
+			Fragmentation: frag,
+		}
+	}
+
+	// Samples older than the trend window are ignored
+	history := []CapacitySample{
+		sample(0, 100, 10, 0),
+		sample(10*day, 700, 70, 20),
+		sample(14*day, 820, 82, 35),
+	}
+	a := AnalyzeCapacity("tank", history)
+	if a.Level != CapacityLevelWarning {
+		t.Errorf("level = %s, want %s", a.Level, CapacityLevelWarning)
+	}
+	if a.GrowthBytesPerDay != 30 || a.DaysUntilFull == nil || *a.DaysUntilFull != 6 {
+		t.Errorf("unexpected growth: %d bytes/day, %v days to full", a.GrowthBytesPerDay, a.DaysUntilFull)
+	}
+	if !a.FragmentationRising || a.FragmentationChange != 15 {
+		t.Errorf("expected rising fragmentation, got %+d", a.FragmentationChange)
+	}
+
+	actions := []string{}
+	for _, r := range a.Recommendations {
+		actions = append(actions, r.Action)
+	}
+	if strings.Join(actions, ",") != RecommendAddVdev+","+RecommendRebalance {
+		t.Errorf("unexpected recommendations: %v", actions)
+	}
+
+	// Unknown fragmentation has no trend
+	a = AnalyzeCapacity("tank", []CapacitySample{sample(0, 950, 95, -1), sample(day, 950, 95, -1)})
+	if a.Level != CapacityLevelCritical || a.FragmentationRising || a.DaysUntilFull != nil {
+		t.Errorf("unexpected analytics: %+v", a)
+	}
+	if len(a.Recommendations) != 2 || a.Recommendations[1].Action != RecommendFreeSpace {
+		t.Errorf("unexpected recommendations: %+v", a.Recommendations)
+	}
+}
+
+func TestParseIOStatSamples(t *testing.T) {
+	output := `                                capacity     operations     bandwidth    total_wait     disk_wait
+pool                          alloc   free   read  write   read  write   read  write   read  write
+----------------------------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+tank                          1048576  9437184     12     30  49152  122880  250000  1200000  200000  900000
+  mirror-0                    1048576  9437184     12     30  49152  122880  250000  1200000  200000  900000
+    /dev/sdb1                     -      -      6     15  24576  61440  240000  1100000  190000  850000
+    /dev/sdc1                     -      -      6     15  24576  61440  260000  1300000  210000  950000
+logs                              -      -      -      -      -      -      -      -      -      -
+  /dev/sdd1                       0  1048576      0      8      0  32768      -  400000      -  300000
+----------------------------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+`
+	at := time.Unix(1_700_000_000, 0)
+	samples := parseIOStatSamples(output, at, 10*time.Second)
+
+	tank, ok := samples["tank"]
+	if !ok || len(samples) != 1 {
+		t.Fatalf("expected only tank, got %v", samples)
+	}
+	if tank.Total.ReadOps != 12 || tank.Total.WriteBytes != 122880 || tank.Total.TotalWaitWrite != 1200000 {
+		t.Errorf("unexpected pool totals: %+v", tank.Total)
+	}
+	if !tank.At.Equal(at) || tank.Interval != 10*time.Second {
+		t.Errorf("unexpected sample time: %v over %v", tank.At, tank.Interval)
+	}
+	if len(tank.VDevs) != 4 {
+		t.Errorf("expected 4 vdevs, got %v", tank.VDevs)
+	}
+	if sdc := tank.VDevs["/dev/sdc1"]; sdc.DiskWaitRead != 210000 || sdc.WriteOps != 15 {
+		t.Errorf("unexpected leaf vdev IO: %+v", sdc)
+	}
+	// Log devices are kept with their pool, idle latencies as 0
+	if log := tank.VDevs["/dev/sdd1"]; log.WriteOps != 8 || log.TotalWaitRead != 0 {
+		t.Errorf("unexpected log vdev IO: %+v", log)
+	}
+}
+
+func TestParseLatencyHistograms(t *testing.T) {
+	output := `
+tank         total_wait     disk_wait    syncq_wait    asyncq_wait
+latency      read  write   read  write   read  write   read  write  scrub   trim
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+1024            0      0      0      0     40      0      0      0      0      0
+2048            5      1      7      2      3      0      0      0      0      0
+4096           10      4     12      6      0      0      0      0      0      0
+--------------------------------------------------------------------------------
+
+/dev/sdb1    total_wait     disk_wait    syncq_wait    asyncq_wait
+latency      read  write   read  write   read  write   read  write  scrub   trim
+----------  -----  -----  -----  -----  -----  -----  -----  -----  -----  -----
+1024            0      0      0      0     40      0      0      0      0      0
+2048            2      1      3      1      3      0      0      0      0      0
+4096            8      3      9      4      0      0      0      0      0      0
+--------------------------------------------------------------------------------
+`
+	histograms := parseLatencyHistograms(output, []string{"tank"})
+
+	tank := histograms["tank"]
+	if len(tank) != 2 {
+		t.Fatalf("expected the pool and one vdev, got %v", tank)
+	}
+	total := tank["tank"]
+	if len(total.BucketsNS) != 3 || total.BucketsNS[2] != 4096 {
+		t.Errorf("unexpected buckets: %v", total.BucketsNS)
+	}
+	if total.TotalWaitRead[1] != 5 || total.DiskWaitWrite[2] != 6 {
+		t.Errorf("unexpected pool counts: %+v", total)
+	}
+	if sdb := tank["/dev/sdb1"]; sdb == nil || sdb.DiskWaitRead[2] != 9 {
+		t.Errorf("unexpected vdev counts: %+v", sdb)
+	}
+}
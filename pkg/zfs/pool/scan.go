@@ -0,0 +1,162 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/command"
+)
+
+// Scan functions and states, lowercased from zpool status
+const (
+	ScanFunctionScrub    = "scrub"
+	ScanFunctionResilver = "resilver"
+
+	ScanStateScanning = "scanning"
+	ScanStateFinished = "finished"
+	ScanStateCanceled = "canceled"
+)
+
+// ScanProgress is the progress of a pool's current or last scrub or resilver
+type ScanProgress struct {
+	Pool     string `json:"pool"`
+	Function string `json:"function"` // "scrub" or "resilver"
+	State    string `json:"state"`    // "scanning", "finished" or "canceled"
+	Paused   bool   `json:"paused,omitempty"`
+
+	Percent       float64 `json:"percent"`
+	TotalBytes    uint64  `json:"total_bytes"`
+	ScannedBytes  uint64  `json:"scanned_bytes"`
+	IssuedBytes   uint64  `json:"issued_bytes"`
+	RepairedBytes uint64  `json:"repaired_bytes"` // Resilvered bytes, for resilvers
+	Errors        uint64  `json:"errors"`
+
+	// Rate of the current pass and time left at that rate, while scanning
+	BytesPerSec uint64 `json:"bytes_per_sec,omitempty"`
+	ETASeconds  int64  `json:"eta_seconds,omitempty"`
+
+	StartedAt *time.Time `json:"started_at,omitempty"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Active reports whether the scan is still running
+func (s *ScanProgress) Active() bool {
+	return s != nil && s.State == ScanStateScanning
+}
+
+// ScanProgress reports the scrub or resilver progress of a pool, or of every
+// pool when name is empty. Pools that were never scanned are left out.
+func (p *Manager) ScanProgress(ctx context.Context, name string) (map[string]*ScanProgress, error) {
+	args := []string{"status"}
+	if name != "" {
+		args = append(args, name)
+	}
+
+	// Parsable values give exact byte counts and epoch times
+	opts := command.CommandOptions{
+		Flags: command.FlagJSON | command.FlagParsable,
+	}
+
+	out, err := p.executor.Execute(ctx, opts, "zpool status", args...)
+	if err != nil {
+		if len(out) > 0 {
+			return nil, errors.Wrap(err, errors.ZFSPoolStatus).
+				WithMetadata("output", string(out))
+		}
+		return nil, errors.Wrap(err, errors.ZFSPoolStatus)
+	}
+
+	var status PoolStatus
+	if err := json.Unmarshal(out, &status); err != nil {
+		return nil, errors.Wrap(err, errors.CommandOutputParse)
+	}
+
+	now := time.Now()
+	progress := make(map[string]*ScanProgress)
+	for poolName, pool := range status.Pools {
+		if scan := newScanProgress(poolName, pool.ScanStats, now); scan != nil {
+			progress[poolName] = scan
+		}
+	}
+	return progress, nil
+}
+
+// newScanProgress derives scan progress from parsable scan stats, the same
+// way zpool status computes its rate and time to go
+func newScanProgress(pool string, stats *ScanStats, now time.Time) *ScanProgress {
+	if stats == nil {
+		return nil
+	}
+	function := strings.ToLower(stats.Function)
+	if function != ScanFunctionScrub && function != ScanFunctionResilver {
+		return nil
+	}
+
+	scan := &ScanProgress{
+		Pool:          pool,
+		Function:      function,
+		State:         strings.ToLower(stats.State),
+		TotalBytes:    parseStatUint(stats.ToExamine),
+		ScannedBytes:  parseStatUint(stats.Examined),
+		IssuedBytes:   parseStatUint(stats.Issued),
+		RepairedBytes: parseStatUint(stats.Processed),
+		Errors:        parseStatUint(stats.Errors),
+		UpdatedAt:     now,
+	}
+	if start := parseStatUint(stats.StartTime); start > 0 {
+		t := time.Unix(int64(start), 0)
+		scan.StartedAt = &t
+	}
+
+	if !scan.Active() {
+		if end := parseStatUint(stats.EndTime); end > 0 {
+			t := time.Unix(int64(end), 0)
+			scan.EndedAt = &t
+		}
+		if scan.State == ScanStateFinished {
+			scan.Percent = 100
+		}
+		return scan
+	}
+
+	if scan.TotalBytes > 0 {
+		scan.Percent = min(100, float64(scan.IssuedBytes)*100/float64(scan.TotalBytes))
+	}
+
+	// Resilvers cannot be paused
+	scan.Paused = function == ScanFunctionScrub && parseStatUint(stats.ScrubPause) > 0
+	if scan.Paused {
+		return scan
+	}
+
+	elapsed := now.Unix() -
+		int64(parseStatUint(stats.PassStart)) -
+		int64(parseStatUint(stats.ScrubSpentPaused))
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	scan.BytesPerSec = parseStatUint(stats.IssuedBytesPerScan) / uint64(elapsed)
+	if scan.BytesPerSec > 0 && scan.TotalBytes > scan.IssuedBytes {
+		scan.ETASeconds = int64((scan.TotalBytes - scan.IssuedBytes) / scan.BytesPerSec)
+	}
+	return scan
+}
+
+// parseStatUint parses a parsable zpool stat, treating "-" and other
+// placeholders as 0
+func parseStatUint(value string) uint64 {
+	n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
@@ -0,0 +1,191 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/internal/events"
+	eventspb "github.com/stratastor/toggle-rodent-proto/proto/events"
+)
+
+const (
+	// scanPollInterval is how often scan progress is refreshed while a scrub
+	// or resilver is running
+	scanPollInterval = 15 * time.Second
+
+	// scanIdlePollInterval is how often pools are checked for new scans
+	scanIdlePollInterval = time.Minute
+
+	scanPollTimeout = 30 * time.Second
+)
+
+// ScanMonitor polls the scrub and resilver progress of all pools, keeps the
+// latest for the pool API and streams it as storage pool events while scans
+// are in flight
+type ScanMonitor struct {
+	manager *Manager
+	logger  logger.Logger
+
+	mu       sync.RWMutex
+	progress map[string]*ScanProgress
+
+	listenersMu sync.RWMutex
+	listeners   []ScanListener
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewScanMonitor creates a scan monitor; call Start to begin polling
+func NewScanMonitor(manager *Manager, l logger.Logger) *ScanMonitor {
+	return &ScanMonitor{
+		manager:  manager,
+		logger:   l,
+		progress: make(map[string]*ScanProgress),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start polls in the background until Stop is called
+func (s *ScanMonitor) Start() {
+	go s.run()
+}
+
+// Stop ends polling
+func (s *ScanMonitor) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// Progress returns the latest scan progress of a pool. Pools the monitor has
+// not seen yet are queried directly.
+func (s *ScanMonitor) Progress(ctx context.Context, pool string) (*ScanProgress, error) {
+	s.mu.RLock()
+	scan, ok := s.progress[pool]
+	s.mu.RUnlock()
+	if ok {
+		return scan, nil
+	}
+
+	progress, err := s.manager.ScanProgress(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	return progress[pool], nil
+}
+
+// ScanListener is called after a scrub or resilver ends
+type ScanListener func(scan ScanProgress)
+
+// OnScanFinished registers a listener for scans that end, whether finished
+// or canceled. Only scans seen running are reported. Listeners run in their
+// own goroutine.
+func (s *ScanMonitor) OnScanFinished(fn ScanListener) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// notifyScanFinished passes an ended scan to the registered listeners
+func (s *ScanMonitor) notifyScanFinished(scan ScanProgress) {
+	s.listenersMu.RLock()
+	defer s.listenersMu.RUnlock()
+
+	for _, fn := range s.listeners {
+		go fn(scan)
+	}
+}
+
+func (s *ScanMonitor) run() {
+	for {
+		interval := scanIdlePollInterval
+		if s.poll() {
+			interval = scanPollInterval
+		}
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// poll refreshes the progress of every pool and reports whether any scan is
+// running
+func (s *ScanMonitor) poll() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), scanPollTimeout)
+	defer cancel()
+
+	progress, err := s.manager.ScanProgress(ctx, "")
+	if err != nil {
+		s.logger.Debug("Failed to poll pool scan progress", "error", err)
+		return false
+	}
+
+	s.mu.Lock()
+	previous := s.progress
+	s.progress = progress
+	s.mu.Unlock()
+
+	active := false
+	for pool, scan := range progress {
+		prev := previous[pool]
+		switch {
+		case scan.Active() && !prev.Active():
+			s.logger.Info("Pool scan started", "pool", pool, "function", scan.Function)
+			emitScanEvent(scan, eventspb.StoragePoolPayload_STORAGE_POOL_OPERATION_SCRUB_STARTED, "scan_started")
+		case scan.Active():
+			emitScanEvent(scan, eventspb.StoragePoolPayload_STORAGE_POOL_OPERATION_UNSPECIFIED, "scan_progress")
+		case prev.Active():
+			// Only report the end of scans that were seen running
+			s.logger.Info("Pool scan ended",
+				"pool", pool,
+				"function", scan.Function,
+				"state", scan.State,
+				"errors", scan.Errors)
+			emitScanEvent(scan, eventspb.StoragePoolPayload_STORAGE_POOL_OPERATION_SCRUB_COMPLETED, "scan_finished")
+			s.notifyScanFinished(*scan)
+		}
+		if scan.Active() {
+			active = true
+		}
+	}
+	return active
+}
+
+// emitScanEvent publishes scan progress on the storage pool event stream.
+// Resilvers share the scrub operations; the function metadata tells them apart.
+func emitScanEvent(
+	scan *ScanProgress,
+	operation eventspb.StoragePoolPayload_StoragePoolOperation,
+	action string,
+) {
+	level := eventspb.EventLevel_EVENT_LEVEL_INFO
+	if scan.Errors > 0 || scan.State == ScanStateCanceled {
+		level = eventspb.EventLevel_EVENT_LEVEL_WARN
+	}
+
+	events.EmitStoragePool(level, &eventspb.StoragePoolPayload{
+		PoolName:  scan.Pool,
+		Operation: operation,
+	}, map[string]string{
+		"component":      "zfs-pool-scan",
+		"action":         action,
+		"function":       scan.Function,
+		"state":          scan.State,
+		"paused":         strconv.FormatBool(scan.Paused),
+		"percent":        fmt.Sprintf("%.2f", scan.Percent),
+		"bytes_per_sec":  strconv.FormatUint(scan.BytesPerSec, 10),
+		"eta_seconds":    strconv.FormatInt(scan.ETASeconds, 10),
+		"repaired_bytes": strconv.FormatUint(scan.RepairedBytes, 10),
+		"errors":         strconv.FormatUint(scan.Errors, 10),
+	})
+}
@@ -64,6 +64,19 @@ type Pool struct {
 	RemovalStats     *RemovalStats      `json:"removal_stats,omitempty"`
 	VDevs            map[string]*VDev   `json:"vdevs,omitempty"`
 	ErrorCount       string             `json:"error_count,omitempty"`
+
+	// Vdevs zpool status lists apart from the data vdevs
+	Logs    map[string]*VDev `json:"logs,omitempty"`
+	Special map[string]*VDev `json:"special,omitempty"`
+	Dedup   map[string]*VDev `json:"dedup,omitempty"`
+	L2Cache map[string]*VDev `json:"l2cache,omitempty"`
+	Spares  map[string]*VDev `json:"spares,omitempty"`
+
+	// Log, cache, special and dedup vdevs with the risks of their layout
+	Auxiliary []AuxVDevStatus `json:"auxiliary,omitempty"`
+
+	// Scrub or resilver progress, added from the scan monitor
+	ScanProgress *ScanProgress `json:"scan_progress,omitempty"`
 }
 
 // ScanStats represents pool scanning status
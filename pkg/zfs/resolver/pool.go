@@ -0,0 +1,86 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package resolver
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// ShareRef is a share and the dataset holding its path
+type ShareRef struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Dataset string `json:"dataset"`
+}
+
+// PoolContents is what a pool stores: its datasets and the shares on them
+type PoolContents struct {
+	Pool     string     `json:"pool"`
+	Datasets []string   `json:"datasets"` // Filesystems and volumes
+	Shares   []ShareRef `json:"shares"`
+}
+
+// PoolContents lists the datasets of a pool and the shares whose paths are
+// on its mounted filesystems. Shares are only listed once the share
+// subsystem is set.
+func (r *Resolver) PoolContents(ctx context.Context, pool string) (*PoolContents, error) {
+	result, err := r.dsManager.List(ctx, dataset.ListConfig{
+		Name:       pool,
+		Type:       "filesystem,volume",
+		Recursive:  true,
+		Properties: []string{"name"},
+		Parsable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &PoolContents{
+		Pool:     pool,
+		Datasets: slices.Sorted(maps.Keys(result.Datasets)),
+		Shares:   []ShareRef{},
+	}
+
+	ptr := r.shares.Load()
+	if ptr == nil {
+		return contents, nil
+	}
+	paths, err := (*ptr).SharePaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Shares are matched against every mount, so a share on another pool
+	// mounted below this one is not counted
+	mounts, err := r.Mounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contents.Shares = poolShares(paths, mounts, pool)
+	return contents, nil
+}
+
+// poolShares returns the shares, by name with their paths, whose paths are
+// on a filesystem of the pool
+func poolShares(paths map[string]string, mounts []Mount, pool string) []ShareRef {
+	shares := []ShareRef{}
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		mount, ok := matchMount(mounts, paths[name])
+		if !ok || !inPool(mount.Dataset, pool) {
+			continue
+		}
+		shares = append(shares, ShareRef{Name: name, Path: paths[name], Dataset: mount.Dataset})
+	}
+	return shares
+}
+
+// inPool reports whether the dataset is the pool's root dataset or below it
+func inPool(name, pool string) bool {
+	return name == pool || strings.HasPrefix(name, pool+"/")
+}
@@ -0,0 +1,501 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// Kinds of objects referring to datasets
+const (
+	ReferenceSnapshotPolicy = "snapshot_policy"
+	ReferenceTransferPolicy = "transfer_policy"
+	ReferenceSMBShare       = "smb_share"
+)
+
+// Reference is a field of a policy or share naming a renamed dataset, or a
+// path on it, and the value it takes after the rename. New is empty when
+// the reference cannot follow the rename; Note says why.
+type Reference struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`             // Policy ID or share name
+	Name  string `json:"name,omitempty"` // Policy name
+	Field string `json:"field"`          // dataset, exclude_datasets, receive_target or path
+	Old   string `json:"old"`
+	New   string `json:"new,omitempty"`
+	Note  string `json:"note,omitempty"`
+}
+
+// RenameConfig renames a dataset along with the policies and shares
+// referring to it
+type RenameConfig struct {
+	dataset.RenameConfig
+	DryRun bool `json:"dry_run"` // Only report the references that would change
+}
+
+// RenameReport lists the references a managed rename updated, or would
+// update on a dry run
+type RenameReport struct {
+	Name       string      `json:"name"`
+	NewName    string      `json:"new_name"`
+	DryRun     bool        `json:"dry_run"`
+	References []Reference `json:"references"`
+}
+
+// SharePaths is a share subsystem whose share paths can follow a renamed
+// dataset to its new mountpoint
+type SharePaths interface {
+	SharePaths(ctx context.Context) (map[string]string, error)
+	SetSharePath(ctx context.Context, name, path string) error
+}
+
+// UseShares moves the shares on renamed datasets with managed renames
+func (r *Resolver) UseShares(s SharePaths) {
+	r.shares.Store(&s)
+}
+
+// treeMount is a filesystem of a renamed dataset tree and its mountpoint
+// property, which is "none" or "legacy" when it has no path
+type treeMount struct {
+	Dataset    string
+	Mountpoint string
+	Local      bool // Mountpoint set on the dataset rather than inherited
+	Mounted    bool
+}
+
+// change updates the references of one policy or share; apply is nil when
+// the references cannot follow the rename
+type change struct {
+	refs   []Reference
+	apply  func(ctx context.Context) error
+	revert func(ctx context.Context) error
+}
+
+// Rename renames a dataset and points the snapshot policies, transfer
+// policies and shares referring to it, or to its descendants and their
+// paths, at the new names. When an update fails, the updates made are
+// reverted and the dataset is renamed back.
+func (r *Resolver) Rename(ctx context.Context, cfg RenameConfig) (*RenameReport, error) {
+	if cfg.Name == "" || cfg.NewName == "" {
+		return nil, errors.New(errors.ZFSRequestValidationError, "name and new_name are required")
+	}
+	if strings.ContainsAny(cfg.Name+cfg.NewName, "@#") {
+		return nil, errors.New(errors.ZFSRequestValidationError,
+			"managed renames are for filesystems and volumes").
+			WithMetadata("name", cfg.Name)
+	}
+
+	mounts, err := r.Mounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := r.treeMounts(ctx, cfg.Name)
+	if err != nil {
+		return nil, err
+	}
+	changes := slices.Concat(
+		r.transferPolicyChanges(cfg.Name, cfg.NewName),
+		r.snapshotPolicyChanges(cfg.Name, cfg.NewName),
+	)
+
+	report := &RenameReport{Name: cfg.Name, NewName: cfg.NewName, DryRun: cfg.DryRun}
+	if cfg.DryRun {
+		parentMount := r.parentMountpoint(ctx, cfg.NewName)
+		moved := predictMounts(tree, cfg.NewName, parentMount, cfg.DoNotMount)
+		shareChanges, err := r.shareChanges(ctx, mounts, moved)
+		if err != nil {
+			return nil, err
+		}
+		report.References = references(append(changes, shareChanges...))
+		return report, nil
+	}
+
+	if err := r.dsManager.Rename(ctx, cfg.RenameConfig); err != nil {
+		return nil, err
+	}
+
+	// Shares follow where the datasets actually mounted
+	moved := make(map[string]string, len(tree))
+	renamed, err := r.treeMounts(ctx, cfg.NewName)
+	if err == nil {
+		for _, m := range renamed {
+			oldName, _ := dataset.RenamedName(m.Dataset, cfg.NewName, cfg.Name)
+			if m.Mounted && !cfg.DoNotMount && filepath.IsAbs(m.Mountpoint) {
+				moved[oldName] = filepath.Clean(m.Mountpoint)
+			} else {
+				moved[oldName] = ""
+			}
+		}
+	}
+	if err == nil {
+		var shareChanges []change
+		shareChanges, err = r.shareChanges(ctx, mounts, moved)
+		changes = append(changes, shareChanges...)
+	}
+	if err == nil {
+		err = applyChanges(ctx, changes)
+	}
+	if err != nil {
+		return nil, r.rollbackRename(ctx, cfg, err)
+	}
+
+	report.References = references(changes)
+	return report, nil
+}
+
+// applyChanges applies the changes in order, reverting those applied when
+// one fails
+func applyChanges(ctx context.Context, changes []change) error {
+	for i, c := range changes {
+		if c.apply == nil {
+			continue
+		}
+		if err := c.apply(ctx); err != nil {
+			for _, done := range slices.Backward(changes[:i]) {
+				if done.revert != nil {
+					_ = done.revert(context.WithoutCancel(ctx))
+				}
+			}
+			return errors.Wrap(err, errors.ZFSDatasetRename).
+				WithMetadata("reference", c.refs[0].Kind+" "+c.refs[0].ID)
+		}
+	}
+	return nil
+}
+
+// rollbackRename renames the dataset back after its references could not
+// be updated, returning the update error
+func (r *Resolver) rollbackRename(ctx context.Context, cfg RenameConfig, cause error) error {
+	back := dataset.RenameConfig{
+		NameConfig: dataset.NameConfig{Name: cfg.NewName},
+		NewName:    cfg.Name,
+		Force:      cfg.Force,
+		DoNotMount: cfg.DoNotMount,
+	}
+	rerr, ok := cause.(*errors.RodentError)
+	if !ok {
+		rerr = errors.Wrap(cause, errors.ZFSDatasetRename)
+	}
+	if err := r.dsManager.Rename(context.WithoutCancel(ctx), back); err != nil {
+		return rerr.WithMetadata("rollback_error", err.Error())
+	}
+	return rerr.WithMetadata("rolled_back", "true")
+}
+
+// references returns the references of the changes
+func references(changes []change) []Reference {
+	refs := []Reference{}
+	for _, c := range changes {
+		refs = append(refs, c.refs...)
+	}
+	return refs
+}
+
+// snapshotPolicyChanges points the snapshot policies on the renamed tree,
+// and the exclude patterns naming datasets in it in full, at the new names
+func (r *Resolver) snapshotPolicyChanges(oldName, newName string) []change {
+	snapshotMgr := r.snapshotManager.Load()
+	if snapshotMgr == nil {
+		return nil
+	}
+	policies, err := snapshotMgr.ListPolicies()
+	if err != nil {
+		return nil
+	}
+
+	var changes []change
+	for _, p := range policies {
+		refs, name, exclude := renameSnapshotPolicy(p, oldName, newName)
+		if len(refs) == 0 {
+			continue
+		}
+		id, oldDataset, oldExclude := p.ID, p.Dataset, p.ExcludeDatasets
+		changes = append(changes, change{
+			refs: refs,
+			apply: func(context.Context) error {
+				return snapshotMgr.SetPolicyDataset(id, name, exclude)
+			},
+			revert: func(context.Context) error {
+				return snapshotMgr.SetPolicyDataset(id, oldDataset, oldExclude)
+			},
+		})
+	}
+	return changes
+}
+
+// renameSnapshotPolicy returns the references of a snapshot policy to the
+// renamed tree, with its dataset and exclude patterns after the rename.
+// Relative exclude patterns need no change.
+func renameSnapshotPolicy(
+	p autosnapshots.SnapshotPolicy,
+	oldName, newName string,
+) (refs []Reference, name string, exclude []string) {
+	ref := Reference{Kind: ReferenceSnapshotPolicy, ID: p.ID, Name: p.Name}
+
+	name, ok := dataset.RenamedName(p.Dataset, oldName, newName)
+	if ok {
+		ref.Field, ref.Old, ref.New = "dataset", p.Dataset, name
+		refs = append(refs, ref)
+	}
+	for _, pattern := range p.ExcludeDatasets {
+		renamed, ok := dataset.RenamedName(pattern, oldName, newName)
+		if ok {
+			ref.Field, ref.Old, ref.New = "exclude_datasets", pattern, renamed
+			refs = append(refs, ref)
+		}
+		exclude = append(exclude, renamed)
+	}
+	return refs, name, exclude
+}
+
+// transferPolicyChanges points the transfer policies receiving into the
+// renamed tree on this host at the new names. Templated targets that would
+// expand to another dataset after the rename, because their source or
+// target moved, are fixed to the dataset they receive into, so incremental
+// sends continue.
+func (r *Resolver) transferPolicyChanges(oldName, newName string) []change {
+	transferMgr := r.transferManager.Load()
+	snapshotMgr := r.snapshotManager.Load()
+	if transferMgr == nil || snapshotMgr == nil {
+		return nil
+	}
+	policies, err := transferMgr.ListPolicies()
+	if err != nil {
+		return nil
+	}
+
+	var changes []change
+	for _, p := range policies {
+		source := ""
+		if sp, err := snapshotMgr.GetPolicy(p.SnapshotPolicyID); err == nil {
+			source = sp.Dataset
+		}
+		ref, ok := renameTransferTarget(p, source, oldName, newName)
+		if !ok {
+			continue
+		}
+
+		c := change{refs: []Reference{ref}}
+		if ref.New != "" {
+			id := p.ID
+			c.apply = func(context.Context) error {
+				return transferMgr.SetPolicyTarget(id, ref.New)
+			}
+			c.revert = func(context.Context) error {
+				return transferMgr.SetPolicyTarget(id, ref.Old)
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// renameTransferTarget returns the reference of a transfer policy's receive
+// target to the renamed tree, given the dataset its snapshot policy takes
+// snapshots of, and whether it has one
+func renameTransferTarget(p autotransfers.TransferPolicy, source, oldName, newName string) (Reference, bool) {
+	recvCfg := p.TransferConfig.ReceiveConfig
+	local := recvCfg.RemoteConfig.Host == ""
+	ref := Reference{
+		Kind:  ReferenceTransferPolicy,
+		ID:    p.ID,
+		Name:  p.Name,
+		Field: "receive_target",
+		Old:   recvCfg.Target,
+	}
+
+	newSource, sourceMoved := dataset.RenamedName(source, oldName, newName)
+	if !autotransfers.IsTargetTemplate(recvCfg.Target) {
+		if !local {
+			return ref, false
+		}
+		target, ok := dataset.RenamedName(recvCfg.Target, oldName, newName)
+		ref.New = target
+		return ref, ok
+	}
+
+	if source == "" {
+		return ref, false
+	}
+	current, err := autotransfers.ExpandTarget(recvCfg.Target, source)
+	if err != nil {
+		return ref, false
+	}
+	want, targetMoved := current, false
+	if local {
+		want, targetMoved = dataset.RenamedName(current, oldName, newName)
+	}
+	if !sourceMoved && !targetMoved {
+		return ref, false
+	}
+
+	next, err := autotransfers.ExpandTarget(recvCfg.Target, newSource)
+	if err != nil {
+		ref.Note = err.Error()
+		return ref, true
+	}
+	if next == want {
+		return ref, false
+	}
+	ref.New = want
+	ref.Note = fmt.Sprintf("templated target would expand to %s; fixed to the dataset it receives into", next)
+	return ref, true
+}
+
+// shareChanges moves the shares on the renamed tree to the new mountpoints.
+// mounts are the mounted filesystems before the rename; moved maps the old
+// names of the renamed datasets to their mountpoints after it, empty when
+// not mounted.
+func (r *Resolver) shareChanges(ctx context.Context, mounts []Mount, moved map[string]string) ([]change, error) {
+	ptr := r.shares.Load()
+	if ptr == nil {
+		return nil, nil
+	}
+	shares := *ptr
+	paths, err := shares.SharePaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []change
+	for _, ref := range movedShares(paths, mounts, moved) {
+		c := change{refs: []Reference{ref}}
+		if ref.New != "" {
+			c.apply = func(ctx context.Context) error {
+				return shares.SetSharePath(ctx, ref.ID, ref.New)
+			}
+			c.revert = func(ctx context.Context) error {
+				return shares.SetSharePath(ctx, ref.ID, ref.Old)
+			}
+		}
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
+
+// movedShares returns the references of the shares whose paths are on the
+// renamed tree, by share name
+func movedShares(paths map[string]string, mounts []Mount, moved map[string]string) []Reference {
+	var refs []Reference
+	for _, name := range slices.Sorted(maps.Keys(paths)) {
+		sharePath := paths[name]
+		mount, ok := matchMount(mounts, sharePath)
+		if !ok {
+			continue
+		}
+		newMount, ok := moved[mount.Dataset]
+		if !ok || newMount == mount.Mountpoint {
+			continue
+		}
+
+		ref := Reference{Kind: ReferenceSMBShare, ID: name, Field: "path", Old: sharePath}
+		if newMount == "" {
+			ref.Note = fmt.Sprintf("dataset %s is not mounted after the rename; update the share once it is",
+				mount.Dataset)
+		} else if rel, err := filepath.Rel(mount.Mountpoint, filepath.Clean(sharePath)); err == nil {
+			ref.New = filepath.Join(newMount, rel)
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// treeMounts lists the datasets of a tree with their mountpoints; volumes
+// have none
+func (r *Resolver) treeMounts(ctx context.Context, name string) ([]treeMount, error) {
+	result, err := r.dsManager.List(ctx, dataset.ListConfig{
+		Name:       name,
+		Recursive:  true,
+		Type:       "filesystem,volume",
+		Properties: []string{"name", "mountpoint", "mounted"},
+		Parsable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make([]treeMount, 0, len(result.Datasets))
+	for dsName, ds := range result.Datasets {
+		source := ds.Properties["mountpoint"].Source.Type
+		tree = append(tree, treeMount{
+			Dataset:    dsName,
+			Mountpoint: dataset.PropertyString(ds, "mountpoint"),
+			Local:      strings.EqualFold(source, "local") || strings.EqualFold(source, "received"),
+			Mounted:    dataset.PropertyString(ds, "mounted") == "yes",
+		})
+	}
+	return tree, nil
+}
+
+// parentMountpoint returns the mountpoint the parent of a dataset has, or
+// would have when the rename creates it, from its nearest existing ancestor
+func (r *Resolver) parentMountpoint(ctx context.Context, name string) string {
+	rel := ""
+	for parent := path.Dir(name); parent != "." && parent != "/"; parent = path.Dir(parent) {
+		result, err := r.dsManager.List(ctx, dataset.ListConfig{
+			Name:       parent,
+			Type:       "filesystem",
+			Properties: []string{"name", "mountpoint"},
+			Parsable:   true,
+		})
+		if err == nil {
+			if ds, ok := result.Datasets[parent]; ok {
+				mountpoint := dataset.PropertyString(ds, "mountpoint")
+				if !filepath.IsAbs(mountpoint) {
+					return mountpoint
+				}
+				return filepath.Join(mountpoint, rel)
+			}
+		}
+		rel = filepath.Join(path.Base(parent), rel)
+	}
+	return ""
+}
+
+// predictMounts returns where the filesystems of a tree will be mounted
+// once its root is renamed to newName, by old name, or empty when they will
+// not be mounted. Inherited mountpoints follow the new parent's mountpoint,
+// parentMount; local ones stay.
+func predictMounts(tree []treeMount, newName, parentMount string, doNotMount bool) map[string]string {
+	tree = slices.Clone(tree)
+	slices.SortFunc(tree, func(a, b treeMount) int {
+		return strings.Compare(a.Dataset, b.Dataset)
+	})
+
+	mountpoints := make(map[string]string, len(tree))
+	moved := make(map[string]string, len(tree))
+	for i, m := range tree {
+		mountpoint := m.Mountpoint
+		if !m.Local {
+			base, baseName := parentMount, path.Base(newName)
+			if i > 0 {
+				base, baseName = mountpoints[path.Dir(m.Dataset)], path.Base(m.Dataset)
+			}
+			mountpoint = base
+			if filepath.IsAbs(base) {
+				mountpoint = filepath.Join(base, baseName)
+			}
+		}
+		mountpoints[m.Dataset] = mountpoint
+
+		if m.Mounted && !doNotMount && filepath.IsAbs(mountpoint) {
+			moved[m.Dataset] = filepath.Clean(mountpoint)
+		} else {
+			moved[m.Dataset] = ""
+		}
+	}
+	return moved
+}
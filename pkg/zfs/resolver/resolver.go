@@ -0,0 +1,239 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package resolver maps filesystem paths to the ZFS datasets backing them and
+// datasets to their mountpoints, so shares can show the dataset, quota and
+// data protection policies behind the paths they export. It also renames
+// datasets together with the policies and shares referring to them.
+package resolver
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// Mount is a mounted filesystem dataset
+type Mount struct {
+	Dataset    string `json:"dataset"`
+	Mountpoint string `json:"mountpoint"`
+}
+
+// PolicyRef identifies a policy protecting a dataset
+type PolicyRef struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Storage describes the dataset backing a path, its space and the policies
+// protecting it
+type Storage struct {
+	Path         string `json:"path"`
+	Dataset      string `json:"dataset"`
+	Mountpoint   string `json:"mountpoint"`
+	RelativePath string `json:"relative_path,omitempty"` // Path below the mountpoint
+
+	// Space in bytes; quotas are 0 when unset
+	Quota     uint64 `json:"quota"`
+	RefQuota  uint64 `json:"refquota"`
+	Used      uint64 `json:"used"`
+	Available uint64 `json:"available"`
+
+	SnapshotPolicies []PolicyRef `json:"snapshot_policies"`
+	TransferPolicies []PolicyRef `json:"transfer_policies"` // Transfers of the snapshot policies' snapshots
+}
+
+// Resolver resolves paths and datasets against the mounted filesystems.
+// Policies are only reported once the policy managers are set.
+type Resolver struct {
+	dsManager       *dataset.Manager
+	snapshotManager atomic.Pointer[autosnapshots.Manager]
+	transferManager atomic.Pointer[autotransfers.Manager]
+	shares          atomic.Pointer[SharePaths]
+}
+
+// New creates a resolver listing datasets through the dataset manager
+func New(dsManager *dataset.Manager) *Resolver {
+	return &Resolver{dsManager: dsManager}
+}
+
+// UseSnapshotManager reports the snapshot policies covering resolved datasets
+func (r *Resolver) UseSnapshotManager(m *autosnapshots.Manager) {
+	r.snapshotManager.Store(m)
+}
+
+// UseTransferPolicyManager reports the names and state of the transfer
+// policies replicating resolved datasets
+func (r *Resolver) UseTransferPolicyManager(m *autotransfers.Manager) {
+	r.transferManager.Store(m)
+}
+
+// Mounts lists the mounted filesystem datasets
+func (r *Resolver) Mounts(ctx context.Context) ([]Mount, error) {
+	result, err := r.dsManager.List(ctx, dataset.ListConfig{
+		Type:       "filesystem",
+		Properties: []string{"name", "mountpoint", "mounted"},
+		Parsable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mounts := []Mount{}
+	for name, ds := range result.Datasets {
+		if dataset.PropertyString(ds, "mounted") != "yes" {
+			continue
+		}
+		mountpoint := dataset.PropertyString(ds, "mountpoint")
+		if !filepath.IsAbs(mountpoint) {
+			// "none", "legacy" and "-" have no path to resolve
+			continue
+		}
+		mounts = append(mounts, Mount{Dataset: name, Mountpoint: filepath.Clean(mountpoint)})
+	}
+	return mounts, nil
+}
+
+// ResolvePath returns the mounted dataset holding the path
+func (r *Resolver) ResolvePath(ctx context.Context, path string) (Mount, error) {
+	if !filepath.IsAbs(path) {
+		return Mount{}, errors.New(errors.ZFSInvalidMountPoint, "path must be absolute").
+			WithMetadata("path", path)
+	}
+
+	mounts, err := r.Mounts(ctx)
+	if err != nil {
+		return Mount{}, err
+	}
+
+	mount, ok := matchMount(mounts, path)
+	if !ok {
+		return Mount{}, errors.New(errors.ZFSDatasetNotFound, "path is not on a mounted dataset").
+			WithMetadata("path", path)
+	}
+	return mount, nil
+}
+
+// ResolveDataset returns the mountpoint of a mounted filesystem dataset
+func (r *Resolver) ResolveDataset(ctx context.Context, name string) (Mount, error) {
+	mounts, err := r.Mounts(ctx)
+	if err != nil {
+		return Mount{}, err
+	}
+
+	for _, m := range mounts {
+		if m.Dataset == name {
+			return m, nil
+		}
+	}
+	return Mount{}, errors.New(errors.ZFSDatasetNotFound, "dataset is not a mounted filesystem").
+		WithMetadata("name", name)
+}
+
+// Describe resolves the dataset holding the path and reports its space and
+// the policies protecting it
+func (r *Resolver) Describe(ctx context.Context, path string) (*Storage, error) {
+	mount, err := r.ResolvePath(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &Storage{
+		Path:             filepath.Clean(path),
+		Dataset:          mount.Dataset,
+		Mountpoint:       mount.Mountpoint,
+		SnapshotPolicies: []PolicyRef{},
+		TransferPolicies: []PolicyRef{},
+	}
+	if rel, err := filepath.Rel(mount.Mountpoint, storage.Path); err == nil && rel != "." {
+		storage.RelativePath = rel
+	}
+
+	result, err := r.dsManager.List(ctx, dataset.ListConfig{
+		Name:       mount.Dataset,
+		Type:       "filesystem",
+		Properties: []string{"quota", "refquota", "used", "available"},
+		Parsable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ds, ok := result.Datasets[mount.Dataset]; ok {
+		storage.Quota = dataset.PropertyUint(ds, "quota")
+		storage.RefQuota = dataset.PropertyUint(ds, "refquota")
+		storage.Used = dataset.PropertyUint(ds, "used")
+		storage.Available = dataset.PropertyUint(ds, "available")
+	}
+
+	r.addPolicies(storage)
+	return storage, nil
+}
+
+// addPolicies fills in the snapshot policies covering the storage's dataset
+// and the transfer policies built on them
+func (r *Resolver) addPolicies(storage *Storage) {
+	snapshotMgr := r.snapshotManager.Load()
+	if snapshotMgr == nil {
+		return
+	}
+	transferMgr := r.transferManager.Load()
+
+	seen := map[string]bool{}
+	for _, p := range snapshotMgr.PoliciesForDataset(storage.Dataset) {
+		storage.SnapshotPolicies = append(storage.SnapshotPolicies, PolicyRef{
+			ID:      p.ID,
+			Name:    p.Name,
+			Enabled: p.Enabled,
+		})
+
+		for _, id := range p.TransferPolicyIDs {
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			ref := PolicyRef{ID: id}
+			if transferMgr != nil {
+				if tp, err := transferMgr.GetPolicy(id); err == nil {
+					ref.Name = tp.Name
+					ref.Enabled = tp.Enabled
+				}
+			}
+			storage.TransferPolicies = append(storage.TransferPolicies, ref)
+		}
+	}
+}
+
+// matchMount returns the mount with the longest mountpoint holding the path
+func matchMount(mounts []Mount, path string) (Mount, bool) {
+	path = filepath.Clean(path)
+
+	var best Mount
+	found := false
+	for _, m := range mounts {
+		if !pathWithin(path, m.Mountpoint) {
+			continue
+		}
+		if !found || len(m.Mountpoint) > len(best.Mountpoint) {
+			best = m
+			found = true
+		}
+	}
+	return best, found
+}
+
+// pathWithin reports whether path is the directory dir or below it
+func pathWithin(path, dir string) bool {
+	if dir == "/" || path == dir {
+		return true
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
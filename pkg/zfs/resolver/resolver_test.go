@@ -0,0 +1,191 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package resolver
+
+import (
+	"testing"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/autotransfers"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchMount(t *testing.T) {
+	mounts := []Mount{
+		{Dataset: "rpool/ROOT", Mountpoint: "/"},
+		{Dataset: "tank", Mountpoint: "/tank"},
+		{Dataset: "tank/shares", Mountpoint: "/tank/shares"},
+		{Dataset: "tank/shares/eng", Mountpoint: "/srv/eng"},
+	}
+
+	tests := []struct {
+		path    string
+		dataset string
+	}{
+		{"/tank/shares/finance", "tank/shares"},
+		{"/tank/shares", "tank/shares"},
+		{"/tank/shares/", "tank/shares"},
+		{"/tank/sharesold", "tank"},
+		{"/srv/eng/docs", "tank/shares/eng"},
+		{"/srv/other", "rpool/ROOT"},
+	}
+	for _, tt := range tests {
+		m, ok := matchMount(mounts, tt.path)
+		assert.True(t, ok, tt.path)
+		assert.Equal(t, tt.dataset, m.Dataset, tt.path)
+	}
+
+	_, ok := matchMount(mounts[1:], "/srv/other")
+	assert.False(t, ok, "paths outside every mountpoint do not resolve")
+}
+
+func TestSnapshotPolicy(t *testing.T) {
+	policies := []autosnapshots.SnapshotPolicy{
+		{ID: "2f1c9c8e-6a1b-4f7e-9d3a-0b5e8c7d6a41", Name: "Hourly"},
+		{ID: "7a3d2e10-1c4b-4b8a-a2f5-91e6d4c3b2a0", Name: "Daily"},
+	}
+
+	p, ok := snapshotPolicy("autosnap-2025-06-01-120000-0-0b5e8c7d6a41", policies)
+	assert.True(t, ok)
+	assert.Equal(t, "Hourly", p.Name)
+
+	p, ok = snapshotPolicy("daily-2025-06-01-12-91e6d4c3b2a0", policies)
+	assert.True(t, ok)
+	assert.Equal(t, "Daily", p.Name)
+
+	_, ok = snapshotPolicy("before-upgrade", policies)
+	assert.False(t, ok)
+	_, ok = snapshotPolicy("x0b5e8c7d6a41", policies)
+	assert.False(t, ok, "the policy ID suffix follows a separator")
+}
+
+func TestPredictMounts(t *testing.T) {
+	tree := []treeMount{
+		{Dataset: "tank/proj/a/logs", Mountpoint: "/tank/proj/a/logs", Mounted: true},
+		{Dataset: "tank/proj/a", Mountpoint: "/tank/proj/a", Mounted: true},
+		{Dataset: "tank/proj/a/db", Mountpoint: "/srv/db", Local: true, Mounted: true},
+		{Dataset: "tank/proj/a/db/wal", Mountpoint: "/srv/db/wal", Mounted: true},
+		{Dataset: "tank/proj/a/off", Mountpoint: "/tank/proj/a/off"},
+	}
+
+	moved := predictMounts(tree, "tank/archive/b", "/tank/archive", false)
+	assert.Equal(t, map[string]string{
+		"tank/proj/a":        "/tank/archive/b",
+		"tank/proj/a/logs":   "/tank/archive/b/logs",
+		"tank/proj/a/db":     "/srv/db",
+		"tank/proj/a/db/wal": "/srv/db/wal",
+		"tank/proj/a/off":    "",
+	}, moved)
+
+	moved = predictMounts(tree, "tank/archive/b", "none", false)
+	assert.Empty(t, moved["tank/proj/a"], "children of unmountable parents are not mounted")
+	assert.Equal(t, "/srv/db", moved["tank/proj/a/db"])
+
+	moved = predictMounts(tree, "tank/archive/b", "/tank/archive", true)
+	assert.Empty(t, moved["tank/proj/a"])
+}
+
+func TestMovedShares(t *testing.T) {
+	mounts := []Mount{
+		{Dataset: "tank", Mountpoint: "/tank"},
+		{Dataset: "tank/proj/a", Mountpoint: "/tank/proj/a"},
+		{Dataset: "tank/proj/a/db", Mountpoint: "/srv/db"},
+		{Dataset: "tank/proj/a/off", Mountpoint: "/tank/proj/a/off"},
+	}
+	moved := map[string]string{
+		"tank/proj/a":     "/tank/archive/b",
+		"tank/proj/a/db":  "/srv/db",
+		"tank/proj/a/off": "",
+	}
+	paths := map[string]string{
+		"docs":   "/tank/proj/a/docs",
+		"root":   "/tank/proj/a",
+		"db":     "/srv/db/dumps",
+		"other":  "/tank/other",
+		"parked": "/tank/proj/a/off/x",
+	}
+
+	refs := movedShares(paths, mounts, moved)
+	assert.Equal(t, []Reference{
+		{Kind: ReferenceSMBShare, ID: "docs", Field: "path", Old: "/tank/proj/a/docs", New: "/tank/archive/b/docs"},
+		{Kind: ReferenceSMBShare, ID: "parked", Field: "path", Old: "/tank/proj/a/off/x",
+			Note: "dataset tank/proj/a/off is not mounted after the rename; update the share once it is"},
+		{Kind: ReferenceSMBShare, ID: "root", Field: "path", Old: "/tank/proj/a", New: "/tank/archive/b"},
+	}, refs)
+}
+
+func TestPoolShares(t *testing.T) {
+	mounts := []Mount{
+		{Dataset: "rpool/ROOT", Mountpoint: "/"},
+		{Dataset: "tank", Mountpoint: "/tank"},
+		{Dataset: "tank/eng", Mountpoint: "/tank/eng"},
+		{Dataset: "tank2/media", Mountpoint: "/tank/media"},
+		{Dataset: "tanker", Mountpoint: "/tanker"},
+	}
+	paths := map[string]string{
+		"eng":     "/tank/eng/docs",
+		"home":    "/tank",
+		"media":   "/tank/media",
+		"scratch": "/tmp/scratch",
+		"tanker":  "/tanker/x",
+	}
+
+	assert.Equal(t, []ShareRef{
+		{Name: "eng", Path: "/tank/eng/docs", Dataset: "tank/eng"},
+		{Name: "home", Path: "/tank", Dataset: "tank"},
+	}, poolShares(paths, mounts, "tank"))
+	assert.Empty(t, poolShares(paths, mounts, "backup"))
+}
+
+func TestRenameSnapshotPolicy(t *testing.T) {
+	p := autosnapshots.SnapshotPolicy{
+		ID:              "p1",
+		Name:            "Projects",
+		Dataset:         "tank/proj",
+		Recursive:       true,
+		ExcludeDatasets: []string{"tank/proj/a/*", "scratch", "tank/proj/ab"},
+	}
+
+	refs, name, exclude := renameSnapshotPolicy(p, "tank/proj/a", "tank/proj/b")
+	assert.Equal(t, "tank/proj", name)
+	assert.Equal(t, []string{"tank/proj/b/*", "scratch", "tank/proj/ab"}, exclude)
+	assert.Len(t, refs, 1)
+	assert.Equal(t, "exclude_datasets", refs[0].Field)
+
+	refs, name, _ = renameSnapshotPolicy(p, "tank", "data")
+	assert.Equal(t, "data/proj", name)
+	assert.Len(t, refs, 3)
+
+	refs, _, _ = renameSnapshotPolicy(p, "tank/pro", "tank/x")
+	assert.Empty(t, refs, "names are renamed by whole components")
+}
+
+func TestRenameTransferTarget(t *testing.T) {
+	policy := func(target, host string) autotransfers.TransferPolicy {
+		var p autotransfers.TransferPolicy
+		p.ID = "t1"
+		p.TransferConfig.ReceiveConfig.Target = target
+		p.TransferConfig.ReceiveConfig.RemoteConfig = dataset.RemoteConfig{Host: host}
+		return p
+	}
+
+	// Local literal targets follow the rename; remote ones are on another pool
+	ref, ok := renameTransferTarget(policy("tank/backup/a", ""), "tank/a", "tank/backup", "tank/bk")
+	assert.True(t, ok)
+	assert.Equal(t, "tank/bk/a", ref.New)
+	_, ok = renameTransferTarget(policy("tank/backup/a", "dr1"), "tank/a", "tank/backup", "tank/bk")
+	assert.False(t, ok)
+
+	// A template expanding from a renamed source is fixed to its dataset
+	ref, ok = renameTransferTarget(policy("backup/{source_dataset}", "dr1"), "tank/a", "tank/a", "tank/b")
+	assert.True(t, ok)
+	assert.Equal(t, "backup/a", ref.New)
+	assert.NotEmpty(t, ref.Note)
+
+	// Templates unaffected by the rename stay
+	_, ok = renameTransferTarget(policy("backup/{source_pool}", "dr1"), "tank/a", "tank/a", "tank/b")
+	assert.False(t, ok)
+}
@@ -0,0 +1,121 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package resolver
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/zfs/autosnapshots"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// manualSnapshotLabel labels snapshots not taken by a snapshot policy
+const manualSnapshotLabel = "Manual"
+
+// Snapshot is a snapshot of a dataset, labelled with the policy that took it
+type Snapshot struct {
+	Name       string    `json:"name"` // Snapshot name, without the dataset
+	Dataset    string    `json:"dataset"`
+	CreatedAt  time.Time `json:"created_at"`
+	Label      string    `json:"label"`
+	PolicyID   string    `json:"policy_id,omitempty"`
+	PolicyName string    `json:"policy_name,omitempty"`
+}
+
+// Snapshots lists the snapshots of a dataset, newest first. Snapshots taken
+// by a policy covering the dataset are labelled with the policy name once the
+// snapshot manager is set; the others are labelled Manual.
+func (r *Resolver) Snapshots(ctx context.Context, name string) ([]Snapshot, error) {
+	result, err := r.dsManager.List(ctx, dataset.ListConfig{
+		Name:       name,
+		Type:       "snapshot",
+		Properties: []string{"creation"},
+		Parsable:   true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var policies []autosnapshots.SnapshotPolicy
+	if m := r.snapshotManager.Load(); m != nil {
+		policies = m.PoliciesForDataset(name)
+	}
+
+	snapshots := []Snapshot{}
+	for full, ds := range result.Datasets {
+		dsName, snapName, ok := strings.Cut(full, "@")
+		if !ok || dsName != name {
+			continue
+		}
+
+		snap := Snapshot{
+			Name:    snapName,
+			Dataset: dsName,
+			Label:   manualSnapshotLabel,
+		}
+		// Parsable creation times are epoch seconds, decoded as a string or
+		// a number
+		if epoch, err := strconv.ParseFloat(dataset.PropertyString(ds, "creation"), 64); err == nil {
+			snap.CreatedAt = time.Unix(int64(epoch), 0)
+		}
+		if p, ok := snapshotPolicy(snapName, policies); ok {
+			snap.PolicyID = p.ID
+			snap.PolicyName = p.Name
+			snap.Label = p.Name
+			if snap.Label == "" {
+				snap.Label = p.ID
+			}
+		}
+		snapshots = append(snapshots, snap)
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return snapshots, nil
+}
+
+// snapshotPolicy returns the policy that took a snapshot. Policy snapshot
+// names end with the schedule index and the last part of the policy ID.
+func snapshotPolicy(snapName string, policies []autosnapshots.SnapshotPolicy) (autosnapshots.SnapshotPolicy, bool) {
+	for _, p := range policies {
+		parts := strings.Split(p.ID, "-")
+		suffix := parts[len(parts)-1]
+		if suffix != "" && strings.HasSuffix(snapName, "-"+suffix) {
+			return p, true
+		}
+	}
+	return autosnapshots.SnapshotPolicy{}, false
+}
+
+// SnapshotPolicies returns the snapshot policies covering a dataset, or none
+// until the snapshot manager is set
+func (r *Resolver) SnapshotPolicies(name string) []autosnapshots.SnapshotPolicy {
+	m := r.snapshotManager.Load()
+	if m == nil {
+		return nil
+	}
+	return m.PoliciesForDataset(name)
+}
+
+// Snapdir returns whether the .zfs directory of a filesystem is listed
+func (r *Resolver) Snapdir(ctx context.Context, name string) (*dataset.SnapdirStatus, error) {
+	return r.dsManager.Snapdir(ctx, dataset.NameConfig{Name: name})
+}
+
+// SetSnapdir hides or shows the .zfs directory of a filesystem
+func (r *Resolver) SetSnapdir(ctx context.Context, name, visibility string) error {
+	return r.dsManager.SetSnapdir(ctx, dataset.SnapdirConfig{
+		NameConfig: dataset.NameConfig{Name: name},
+		Visibility: visibility,
+	})
+}
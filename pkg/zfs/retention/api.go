@@ -0,0 +1,230 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package retention
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// Handler handles HTTP requests for snapshot retention
+type Handler struct {
+	manager *Manager
+}
+
+// APIResponse represents a standardized API response format
+type APIResponse struct {
+	Success bool              `json:"success"`
+	Result  interface{}       `json:"result,omitempty"`
+	Error   *APIErrorResponse `json:"error,omitempty"`
+}
+
+// APIErrorResponse represents error information in API responses
+type APIErrorResponse struct {
+	Code    int                    `json:"code"`
+	Domain  string                 `json:"domain"`
+	Message string                 `json:"message"`
+	Details string                 `json:"details,omitempty"`
+	Meta    map[string]interface{} `json:"meta,omitempty"`
+}
+
+// NewHandlerWithManager creates a new retention handler with an existing manager
+func NewHandlerWithManager(manager *Manager) *Handler {
+	return &Handler{
+		manager: manager,
+	}
+}
+
+// Manager returns the retention manager for use by other subsystems
+func (h *Handler) Manager() *Manager {
+	return h.manager
+}
+
+// RegisterRoutes registers HTTP routes for snapshot retention
+func (h *Handler) RegisterRoutes(router *gin.RouterGroup) {
+	retention := router.Group("/retention")
+	{
+		retention.GET("/compliance", h.listCompliance)
+		retention.PUT("/compliance", h.setCompliance)
+		retention.GET("/compliance/dataset", h.getCompliance)
+		retention.DELETE("/compliance/dataset", h.removeCompliance)
+
+		retention.GET("/snapshots", h.listRetained)
+		retention.POST("/snapshots", h.retain)
+		retention.GET("/snapshots/snapshot", h.getRetention)
+		retention.POST("/snapshots/extend", h.extend)
+
+		retention.POST("/reconcile", h.reconcile)
+	}
+}
+
+// StartManager starts periodic reconciliation
+func (h *Handler) StartManager() error {
+	return h.manager.Start()
+}
+
+// StopManager stops periodic reconciliation
+func (h *Handler) StopManager() error {
+	return h.manager.Stop()
+}
+
+// sendSuccess sends a successful response with the standardized format
+func (h *Handler) sendSuccess(c *gin.Context, statusCode int, result interface{}) {
+	c.JSON(statusCode, APIResponse{
+		Success: true,
+		Result:  result,
+	})
+}
+
+// sendError sends an error response with the standardized format
+func (h *Handler) sendError(c *gin.Context, err error) {
+	response := APIResponse{
+		Success: false,
+	}
+
+	if rodentErr, ok := err.(*errors.RodentError); ok {
+		response.Error = &APIErrorResponse{
+			Code:    int(rodentErr.Code),
+			Domain:  string(rodentErr.Domain),
+			Message: rodentErr.Message,
+			Details: rodentErr.Details,
+			Meta:    make(map[string]interface{}),
+		}
+		for k, v := range rodentErr.Metadata {
+			response.Error.Meta[k] = v
+		}
+		c.JSON(rodentErr.HTTPStatus, response)
+		return
+	}
+
+	response.Error = &APIErrorResponse{
+		Code:    http.StatusInternalServerError,
+		Domain:  "RETENTION",
+		Message: "Internal server error",
+		Details: err.Error(),
+	}
+	c.JSON(http.StatusInternalServerError, response)
+}
+
+// listCompliance lists datasets in compliance mode
+func (h *Handler) listCompliance(c *gin.Context) {
+	datasets := h.manager.ListCompliance()
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"datasets": datasets,
+		"count":    len(datasets),
+	})
+}
+
+// setCompliance enables compliance mode on a dataset or extends its retention
+func (h *Handler) setCompliance(c *gin.Context) {
+	var params ComplianceParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.RetentionInvalidConfig))
+		return
+	}
+
+	cd, err := h.manager.SetCompliance(c.Request.Context(), params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cd)
+}
+
+// getCompliance gets the compliance settings of the dataset in the name query
+// parameter
+func (h *Handler) getCompliance(c *gin.Context) {
+	cd, err := h.manager.GetCompliance(c.Query("name"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, cd)
+}
+
+// removeCompliance takes the dataset in the name query parameter out of
+// compliance mode. Snapshots already held keep their retention. The request
+// must carry a guard override token for the dataset.
+func (h *Handler) removeCompliance(c *gin.Context) {
+	name := c.Query("name")
+	if err := h.manager.RemoveCompliance(c.Request.Context(), name); err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"dataset": name,
+		"removed": true,
+	})
+}
+
+// listRetained lists retained snapshots, optionally filtered by dataset
+func (h *Handler) listRetained(c *gin.Context) {
+	snapshots := h.manager.ListRetained(c.Query("dataset"))
+	h.sendSuccess(c, http.StatusOK, map[string]interface{}{
+		"snapshots": snapshots,
+		"count":     len(snapshots),
+	})
+}
+
+// retain holds a snapshot for a retention period
+func (h *Handler) retain(c *gin.Context) {
+	var params RetainParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.RetentionInvalidConfig))
+		return
+	}
+
+	rs, err := h.manager.Retain(c.Request.Context(), params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusCreated, rs)
+}
+
+// getRetention gets the retention of the snapshot in the name query parameter
+func (h *Handler) getRetention(c *gin.Context) {
+	rs, err := h.manager.GetRetention(c.Query("name"))
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, rs)
+}
+
+// extend lengthens the retention of a held snapshot
+func (h *Handler) extend(c *gin.Context) {
+	var params ExtendParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		h.sendError(c, errors.Wrap(err, errors.RetentionInvalidConfig))
+		return
+	}
+
+	rs, err := h.manager.Extend(params)
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, rs)
+}
+
+// reconcile holds new compliance snapshots and releases expired holds immediately
+func (h *Handler) reconcile(c *gin.Context) {
+	result, err := h.manager.Reconcile(c.Request.Context())
+	if err != nil {
+		h.sendError(c, err)
+		return
+	}
+
+	h.sendSuccess(c, http.StatusOK, result)
+}
@@ -0,0 +1,588 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package retention
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/stratastor/logger"
+	"github.com/stratastor/rodent/config"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stratastor/rodent/pkg/zfs/dataset"
+)
+
+// reconcileInterval is how often new snapshots are held and expired holds released
+const reconcileInterval = 5 * time.Minute
+
+// Manager places and releases retention holds on snapshots
+type Manager struct {
+	logger         logger.Logger
+	configPath     string
+	config         RetentionConfig
+	datasetManager *dataset.Manager
+	stop           chan struct{}
+	mu             sync.RWMutex
+	reconcileMu    sync.Mutex // serializes reconcile passes and holds
+	started        bool
+}
+
+// Singleton instance
+var (
+	globalManager *Manager
+	initMutex     sync.Mutex
+)
+
+// GetManager returns the singleton retention manager instance
+func GetManager(dsManager *dataset.Manager, logCfg logger.Config) (*Manager, error) {
+	initMutex.Lock()
+	defer initMutex.Unlock()
+
+	if globalManager != nil {
+		return globalManager, nil
+	}
+
+	l, err := logger.NewTag(logCfg, "zfs-retention")
+	if err != nil {
+		return nil, errors.Wrap(err, errors.LoggerError)
+	}
+
+	retentionDir := filepath.Join(config.GetPoliciesDir(), "retention")
+	if err := os.MkdirAll(retentionDir, 0755); err != nil {
+		return nil, errors.New(
+			errors.ConfigWriteError,
+			fmt.Sprintf("failed to create retention directory: %v", err),
+		)
+	}
+
+	m := &Manager{
+		logger:         l,
+		configPath:     filepath.Join(retentionDir, "zfs.retention.rodent.yml"),
+		datasetManager: dsManager,
+		config: RetentionConfig{
+			Datasets:  []ComplianceDataset{},
+			Snapshots: []RetainedSnapshot{},
+		},
+	}
+
+	if err := m.LoadConfig(); err != nil {
+		l.Warn("Failed to load retention config, starting with empty config", "error", err)
+	}
+
+	globalManager = m
+	return m, nil
+}
+
+// Start begins periodic reconciliation
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.started {
+		return nil
+	}
+
+	m.stop = make(chan struct{})
+	go m.watch(m.stop)
+	m.started = true
+
+	m.logger.Info("Retention manager started",
+		"compliance_datasets", len(m.config.Datasets),
+		"retained_snapshots", len(m.config.Snapshots))
+	return nil
+}
+
+// Stop stops periodic reconciliation. Holds stay in place.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.started {
+		return nil
+	}
+
+	close(m.stop)
+	m.started = false
+	return nil
+}
+
+// watch reconciles every reconcileInterval until stop is closed, which also
+// cancels a reconcile in progress
+func (m *Manager) watch(stop <-chan struct{}) {
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := m.Reconcile(ctx); err != nil {
+				m.logger.Warn("Retention reconcile failed", "error", err)
+			}
+		}
+	}
+}
+
+// SetCompliance enables compliance mode on a dataset, or extends the retention
+// period of a dataset already in compliance mode. Existing snapshots are held
+// right away.
+func (m *Manager) SetCompliance(ctx context.Context, params ComplianceParams) (ComplianceDataset, error) {
+	if err := ValidateComplianceParams(params); err != nil {
+		return ComplianceDataset{}, err
+	}
+
+	exists, err := m.datasetManager.Exists(ctx, params.Dataset)
+	if err != nil || !exists {
+		return ComplianceDataset{}, errors.New(errors.ZFSDatasetNotFound, "dataset not found").
+			WithMetadata("dataset", params.Dataset)
+	}
+
+	m.mu.Lock()
+	now := time.Now()
+	cd := ComplianceDataset{
+		Dataset:       params.Dataset,
+		Recursive:     params.Recursive,
+		RetentionDays: params.RetentionDays,
+		Reason:        params.Reason,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	found := false
+	for i := range m.config.Datasets {
+		existing := &m.config.Datasets[i]
+		if existing.Dataset != params.Dataset {
+			continue
+		}
+		if params.RetentionDays < existing.RetentionDays {
+			m.mu.Unlock()
+			return ComplianceDataset{}, errors.New(
+				errors.RetentionShortenDenied,
+				"retention period of a compliance dataset cannot be shortened",
+			).WithMetadata("retention_days", strconv.Itoa(existing.RetentionDays))
+		}
+		if existing.Recursive && !params.Recursive {
+			m.mu.Unlock()
+			return ComplianceDataset{}, errors.New(
+				errors.RetentionShortenDenied,
+				"compliance cannot be narrowed from recursive to the dataset alone",
+			).WithMetadata("dataset", params.Dataset)
+		}
+		cd.CreatedAt = existing.CreatedAt
+		*existing = cd
+		found = true
+		break
+	}
+	if !found {
+		m.config.Datasets = append(m.config.Datasets, cd)
+	}
+
+	if err := m.SaveConfig(true); err != nil {
+		m.mu.Unlock()
+		return ComplianceDataset{}, err
+	}
+	m.mu.Unlock()
+
+	m.logger.Info("Compliance mode set on dataset",
+		"dataset", cd.Dataset,
+		"recursive", cd.Recursive,
+		"retention_days", cd.RetentionDays)
+
+	if _, err := m.Reconcile(ctx); err != nil {
+		return cd, err
+	}
+	return cd, nil
+}
+
+// RemoveCompliance takes a dataset out of compliance mode. New snapshots are
+// no longer held, but snapshots already held keep their retention. Since
+// compliance mode is meant to be permanent, ctx must carry a guard override
+// token issued for compliance.remove on the dataset.
+func (m *Manager) RemoveCompliance(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, cd := range m.config.Datasets {
+		if cd.Dataset != name {
+			continue
+		}
+		if err := guard.RequireOverride(ctx, guard.Request{
+			Operation: guard.OpComplianceRemove,
+			Targets:   []string{name},
+		}); err != nil {
+			return err
+		}
+
+		m.config.Datasets = append(m.config.Datasets[:i], m.config.Datasets[i+1:]...)
+		if err := m.SaveConfig(true); err != nil {
+			return err
+		}
+		m.logger.Warn("Compliance mode removed from dataset", "dataset", name)
+		return nil
+	}
+
+	return errors.New(errors.RetentionNotFound, "dataset is not in compliance mode").
+		WithMetadata("dataset", name)
+}
+
+// GetCompliance returns the compliance settings of a dataset
+func (m *Manager) GetCompliance(name string) (ComplianceDataset, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, cd := range m.config.Datasets {
+		if cd.Dataset == name {
+			return cd, nil
+		}
+	}
+
+	return ComplianceDataset{}, errors.New(errors.RetentionNotFound, "dataset is not in compliance mode").
+		WithMetadata("dataset", name)
+}
+
+// ListCompliance returns all datasets in compliance mode
+func (m *Manager) ListCompliance() []ComplianceDataset {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	datasets := make([]ComplianceDataset, len(m.config.Datasets))
+	copy(datasets, m.config.Datasets)
+	return datasets
+}
+
+// Retain holds a single snapshot for a number of days. Retaining a snapshot
+// that is already held extends its retention if the new period ends later.
+func (m *Manager) Retain(ctx context.Context, params RetainParams) (RetainedSnapshot, error) {
+	if !strings.Contains(params.Snapshot, "@") {
+		return RetainedSnapshot{}, errors.New(errors.ZFSSnapshotInvalidName, "a snapshot name is required").
+			WithMetadata("snapshot", params.Snapshot)
+	}
+	if params.RetentionDays <= 0 {
+		return RetainedSnapshot{}, errors.New(errors.RetentionInvalidConfig, "retention_days must be positive")
+	}
+
+	now := time.Now()
+	return m.hold(ctx, RetainedSnapshot{
+		Snapshot:    params.Snapshot,
+		Source:      SourceManual,
+		Reason:      params.Reason,
+		HeldAt:      now,
+		RetainUntil: now.AddDate(0, 0, params.RetentionDays),
+	})
+}
+
+// Extend lengthens the retention of a held snapshot
+func (m *Manager) Extend(params ExtendParams) (RetainedSnapshot, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.config.Snapshots {
+		rs := &m.config.Snapshots[i]
+		if rs.Snapshot != params.Snapshot {
+			continue
+		}
+
+		until, err := params.ExtendedUntil(rs.RetainUntil)
+		if err != nil {
+			return RetainedSnapshot{}, err
+		}
+		rs.RetainUntil = until
+		if err := m.SaveConfig(true); err != nil {
+			return RetainedSnapshot{}, err
+		}
+
+		m.logger.Info("Extended snapshot retention",
+			"snapshot", rs.Snapshot,
+			"retain_until", until)
+		return *rs, nil
+	}
+
+	return RetainedSnapshot{}, errors.New(errors.RetentionNotFound, "snapshot is not retained").
+		WithMetadata("snapshot", params.Snapshot)
+}
+
+// GetRetention returns the retention of a snapshot
+func (m *Manager) GetRetention(snapshot string) (RetainedSnapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, rs := range m.config.Snapshots {
+		if rs.Snapshot == snapshot {
+			return rs, nil
+		}
+	}
+
+	return RetainedSnapshot{}, errors.New(errors.RetentionNotFound, "snapshot is not retained").
+		WithMetadata("snapshot", snapshot)
+}
+
+// ListRetained returns retained snapshots, optionally filtered by dataset
+func (m *Manager) ListRetained(datasetName string) []RetainedSnapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	snapshots := []RetainedSnapshot{}
+	for _, rs := range m.config.Snapshots {
+		if datasetName == "" || rs.Dataset() == datasetName {
+			snapshots = append(snapshots, rs)
+		}
+	}
+	return snapshots
+}
+
+// Reconcile holds new snapshots of compliance datasets and releases holds
+// whose retention has expired
+func (m *Manager) Reconcile(ctx context.Context) (*ReconcileResult, error) {
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+
+	now := time.Now()
+	result := &ReconcileResult{
+		ReconciledAt: now,
+		Held:         []string{},
+		Released:     []string{},
+	}
+
+	for _, cd := range m.ListCompliance() {
+		created, err := m.listSnapshots(ctx, cd)
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", cd.Dataset, err))
+			continue
+		}
+
+		for _, name := range sortedKeys(created) {
+			until := created[name].AddDate(0, 0, cd.RetentionDays)
+			if !now.Before(until) {
+				continue
+			}
+			if existing, err := m.GetRetention(name); err == nil && !existing.RetainUntil.Before(until) {
+				continue
+			}
+
+			if _, err := m.holdLocked(ctx, RetainedSnapshot{
+				Snapshot:    name,
+				Source:      SourceCompliance,
+				Reason:      cd.Reason,
+				HeldAt:      now,
+				RetainUntil: until,
+			}); err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			result.Held = append(result.Held, name)
+		}
+	}
+
+	for _, rs := range m.ListRetained("") {
+		if !rs.Expired(now) {
+			continue
+		}
+		if err := m.release(ctx, rs.Snapshot); err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", rs.Snapshot, err))
+			continue
+		}
+		result.Released = append(result.Released, rs.Snapshot)
+	}
+
+	if len(result.Held) > 0 || len(result.Released) > 0 {
+		m.logger.Info("Retention reconciled",
+			"held", len(result.Held),
+			"released", len(result.Released))
+	}
+	for _, e := range result.Errors {
+		m.logger.Warn("Retention reconcile error", "error", e)
+	}
+
+	return result, nil
+}
+
+// listSnapshots returns the creation time of each snapshot covered by a
+// compliance dataset
+func (m *Manager) listSnapshots(ctx context.Context, cd ComplianceDataset) (map[string]time.Time, error) {
+	listCfg := dataset.ListConfig{
+		Name:       cd.Dataset,
+		Type:       "snapshot",
+		Recursive:  cd.Recursive,
+		Properties: []string{"creation"},
+		Parsable:   true,
+	}
+	if !cd.Recursive {
+		listCfg.Depth = "1"
+	}
+
+	list, err := m.datasetManager.List(ctx, listCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	created := make(map[string]time.Time, len(list.Datasets))
+	for name, ds := range list.Datasets {
+		prop, ok := ds.Properties["creation"]
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fmt.Sprint(prop.Value), 10, 64)
+		if err != nil {
+			continue
+		}
+		created[name] = time.Unix(seconds, 0)
+	}
+	return created, nil
+}
+
+// hold places the retention hold on a snapshot, unless already held, and
+// records or extends its retention. Retention is never shortened.
+func (m *Manager) hold(ctx context.Context, rs RetainedSnapshot) (RetainedSnapshot, error) {
+	m.reconcileMu.Lock()
+	defer m.reconcileMu.Unlock()
+	return m.holdLocked(ctx, rs)
+}
+
+// holdLocked is hold for callers that hold reconcileMu, so a snapshot is not
+// recorded twice or released while it is being held
+func (m *Manager) holdLocked(ctx context.Context, rs RetainedSnapshot) (RetainedSnapshot, error) {
+	existing, err := m.GetRetention(rs.Snapshot)
+	if err == nil {
+		if !rs.RetainUntil.After(existing.RetainUntil) {
+			return existing, nil
+		}
+		return m.Extend(ExtendParams{Snapshot: rs.Snapshot, RetainUntil: &rs.RetainUntil})
+	}
+
+	if err := m.datasetManager.Hold(ctx, dataset.HoldConfig{
+		NameConfig: dataset.NameConfig{Name: rs.Snapshot},
+		Tag:        HoldTag,
+	}); err != nil && !isHoldExists(err) {
+		return RetainedSnapshot{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config.Snapshots = append(m.config.Snapshots, rs)
+	if err := m.SaveConfig(true); err != nil {
+		return RetainedSnapshot{}, err
+	}
+
+	m.logger.Info("Snapshot retained",
+		"snapshot", rs.Snapshot,
+		"source", rs.Source,
+		"retain_until", rs.RetainUntil)
+	return rs, nil
+}
+
+// release removes the retention hold from a snapshot and forgets it
+func (m *Manager) release(ctx context.Context, snapshot string) error {
+	if err := m.datasetManager.Release(ctx, dataset.HoldConfig{
+		NameConfig: dataset.NameConfig{Name: snapshot},
+		Tag:        HoldTag,
+	}); err != nil && !isHoldMissing(err) {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, rs := range m.config.Snapshots {
+		if rs.Snapshot == snapshot {
+			m.config.Snapshots = append(m.config.Snapshots[:i], m.config.Snapshots[i+1:]...)
+			break
+		}
+	}
+	return m.SaveConfig(true)
+}
+
+// isHoldExists reports whether a hold failed because the tag is already held
+func isHoldExists(err error) bool {
+	var re *errors.RodentError
+	return stderrors.As(err, &re) && strings.Contains(re.Metadata["stderr"], "tag already exists")
+}
+
+// isHoldMissing reports whether a release failed because the snapshot or the
+// hold no longer exists
+func isHoldMissing(err error) bool {
+	return errors.CategoryOf(err) == errors.CategoryNotFound
+}
+
+func sortedKeys(m map[string]time.Time) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// LoadConfig loads compliance datasets and retained snapshots from disk
+func (m *Manager) LoadConfig() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := os.Stat(m.configPath); os.IsNotExist(err) {
+		m.logger.Info("Retention config file does not exist, starting with empty config")
+		return nil
+	}
+
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigReadError)
+	}
+
+	var cfg RetentionConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return errors.Wrap(err, errors.ConfigUnmarshalFailed)
+	}
+
+	if cfg.Datasets == nil {
+		cfg.Datasets = []ComplianceDataset{}
+	}
+	if cfg.Snapshots == nil {
+		cfg.Snapshots = []RetainedSnapshot{}
+	}
+
+	m.config = cfg
+	m.logger.Info("Retention config loaded",
+		"compliance_datasets", len(cfg.Datasets),
+		"retained_snapshots", len(cfg.Snapshots))
+	return nil
+}
+
+// SaveConfig saves compliance datasets and retained snapshots to disk
+func (m *Manager) SaveConfig(skipLock bool) error {
+	if !skipLock {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+
+	data, err := yaml.Marshal(&m.config)
+	if err != nil {
+		return errors.Wrap(err, errors.ConfigMarshalFailed)
+	}
+
+	if err := os.WriteFile(m.configPath, data, 0644); err != nil {
+		return errors.Wrap(err, errors.ConfigWriteError)
+	}
+
+	return nil
+}
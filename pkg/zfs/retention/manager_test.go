@@ -0,0 +1,69 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package retention
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stratastor/rodent/internal/common"
+	"github.com/stratastor/rodent/pkg/errors"
+	"github.com/stratastor/rodent/pkg/guard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// holdError is the error dataset.Manager returns when zfs fails with stderr
+func holdError(stderr string) error {
+	return errors.Wrap(errors.NewCommandError("zfs hold", 1, stderr), errors.ZFSHoldError)
+}
+
+func TestHoldErrors(t *testing.T) {
+	exists := holdError("cannot hold snapshot 'tank/audit@daily': tag already exists on this dataset\n")
+	noTag := holdError("cannot release hold from snapshot 'tank/audit@daily': no such tag on this dataset\n")
+	gone := holdError("cannot open 'tank/audit@daily': dataset does not exist\n")
+	denied := holdError("cannot hold 'tank/audit@daily': permission denied\n")
+
+	assert.True(t, isHoldExists(exists))
+	assert.False(t, isHoldExists(noTag))
+	assert.False(t, isHoldExists(fmt.Errorf("tag already exists")), "only command output is trusted")
+
+	assert.True(t, isHoldMissing(noTag))
+	assert.True(t, isHoldMissing(gone))
+	assert.False(t, isHoldMissing(exists))
+	assert.False(t, isHoldMissing(denied))
+}
+
+func TestRemoveComplianceNeedsOverride(t *testing.T) {
+	m := &Manager{
+		logger:     common.Log,
+		configPath: filepath.Join(t.TempDir(), "zfs.retention.rodent.yml"),
+		config: RetentionConfig{
+			Datasets:  []ComplianceDataset{{Dataset: "tank/audit", RetentionDays: 365}},
+			Snapshots: []RetainedSnapshot{},
+		},
+	}
+	g := guard.NewGuard(common.Log, nil, time.Minute)
+	guard.SetDefault(g)
+	t.Cleanup(func() { guard.SetDefault(nil) })
+	ctx := context.Background()
+
+	err := m.RemoveCompliance(ctx, "tank/other")
+	code, _ := errors.GetCode(err)
+	assert.Equal(t, errors.ErrorCode(errors.RetentionNotFound), code)
+
+	err = m.RemoveCompliance(guard.WithApproval(ctx, guard.Approval{Confirm: true}), "tank/audit")
+	code, _ = errors.GetCode(err)
+	assert.Equal(t, errors.ErrorCode(errors.ServerGuardDenied), code)
+	assert.Len(t, m.ListCompliance(), 1)
+
+	o, err := g.IssueOverride(ctx, guard.OpComplianceRemove, "tank/audit", "audit period ended")
+	require.NoError(t, err)
+	require.NoError(t, m.RemoveCompliance(guard.WithApproval(ctx, guard.Approval{OverrideToken: o.Token}), "tank/audit"))
+	assert.Empty(t, m.ListCompliance())
+}
@@ -0,0 +1,130 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package retention
+
+import (
+	"strings"
+	"time"
+
+	"github.com/stratastor/rodent/pkg/errors"
+)
+
+// HoldTag is the user hold placed on retained snapshots. Other holds on the
+// same snapshots are left alone.
+const HoldTag = "rodent-retention"
+
+// Retention sources
+const (
+	SourceCompliance = "compliance"
+	SourceManual     = "manual"
+)
+
+// ComplianceDataset puts a dataset in compliance mode: every snapshot of it
+// is held until RetentionDays after the snapshot was created. The retention
+// period can be extended but never shortened.
+type ComplianceDataset struct {
+	Dataset       string    `json:"dataset"        yaml:"dataset"`
+	Recursive     bool      `json:"recursive"      yaml:"recursive"`
+	RetentionDays int       `json:"retention_days" yaml:"retention_days"`
+	Reason        string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"     yaml:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"     yaml:"updated_at"`
+}
+
+// RetainedSnapshot is a snapshot held until RetainUntil
+type RetainedSnapshot struct {
+	Snapshot    string    `json:"snapshot"         yaml:"snapshot"`
+	Source      string    `json:"source"           yaml:"source"`
+	Reason      string    `json:"reason,omitempty" yaml:"reason,omitempty"`
+	HeldAt      time.Time `json:"held_at"          yaml:"held_at"`
+	RetainUntil time.Time `json:"retain_until"     yaml:"retain_until"`
+}
+
+// Dataset returns the dataset the snapshot belongs to
+func (r RetainedSnapshot) Dataset() string {
+	name, _, _ := strings.Cut(r.Snapshot, "@")
+	return name
+}
+
+// Expired reports whether the retention period has passed at now
+func (r RetainedSnapshot) Expired(now time.Time) bool {
+	return !now.Before(r.RetainUntil)
+}
+
+// RetentionConfig is the persisted state of the retention manager
+type RetentionConfig struct {
+	Datasets  []ComplianceDataset `yaml:"datasets"`
+	Snapshots []RetainedSnapshot  `yaml:"snapshots"`
+}
+
+// ComplianceParams are parameters for enabling or extending compliance mode
+type ComplianceParams struct {
+	Dataset       string `json:"dataset"        binding:"required"`
+	Recursive     bool   `json:"recursive"`
+	RetentionDays int    `json:"retention_days" binding:"required"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// RetainParams are parameters for holding a single snapshot
+type RetainParams struct {
+	Snapshot      string `json:"snapshot"       binding:"required"`
+	RetentionDays int    `json:"retention_days" binding:"required"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// ExtendParams extend the retention of a held snapshot, either to an absolute
+// time or by a number of days from the current expiry
+type ExtendParams struct {
+	Snapshot    string     `json:"snapshot"               binding:"required"`
+	RetainUntil *time.Time `json:"retain_until,omitempty"`
+	ExtendDays  int        `json:"extend_days,omitempty"`
+}
+
+// ReconcileResult summarizes a reconcile pass
+type ReconcileResult struct {
+	ReconciledAt time.Time `json:"reconciled_at"`
+	Held         []string  `json:"held"`
+	Released     []string  `json:"released"`
+	Errors       []string  `json:"errors,omitempty"`
+}
+
+// ValidateComplianceParams checks compliance parameters
+func ValidateComplianceParams(params ComplianceParams) error {
+	if params.Dataset == "" || strings.ContainsAny(params.Dataset, "@#") {
+		return errors.New(errors.RetentionInvalidConfig, "a filesystem or volume name is required").
+			WithMetadata("dataset", params.Dataset)
+	}
+	if params.RetentionDays <= 0 {
+		return errors.New(errors.RetentionInvalidConfig, "retention_days must be positive")
+	}
+	return nil
+}
+
+// ExtendedUntil returns the new expiry for a retained snapshot, refusing any
+// change that would shorten it
+func (p ExtendParams) ExtendedUntil(current time.Time) (time.Time, error) {
+	switch {
+	case p.RetainUntil != nil && p.ExtendDays != 0:
+		return time.Time{}, errors.New(
+			errors.RetentionInvalidConfig,
+			"set either retain_until or extend_days, not both",
+		)
+	case p.RetainUntil != nil:
+		if p.RetainUntil.Before(current) {
+			return time.Time{}, errors.New(
+				errors.RetentionShortenDenied,
+				"retain_until is earlier than the current retention",
+			).WithMetadata("retain_until", current.Format(time.RFC3339))
+		}
+		return *p.RetainUntil, nil
+	case p.ExtendDays > 0:
+		return current.AddDate(0, 0, p.ExtendDays), nil
+	default:
+		return time.Time{}, errors.New(
+			errors.RetentionInvalidConfig,
+			"retain_until or a positive extend_days is required",
+		)
+	}
+}
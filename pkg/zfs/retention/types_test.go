@@ -0,0 +1,50 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package retention
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtendedUntil(t *testing.T) {
+	current := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	later := current.AddDate(0, 1, 0)
+	earlier := current.AddDate(0, 0, -1)
+
+	until, err := ExtendParams{ExtendDays: 30}.ExtendedUntil(current)
+	assert.NoError(t, err)
+	assert.Equal(t, current.AddDate(0, 0, 30), until)
+
+	until, err = ExtendParams{RetainUntil: &later}.ExtendedUntil(current)
+	assert.NoError(t, err)
+	assert.Equal(t, later, until)
+
+	_, err = ExtendParams{RetainUntil: &earlier}.ExtendedUntil(current)
+	assert.Error(t, err)
+
+	_, err = ExtendParams{RetainUntil: &later, ExtendDays: 1}.ExtendedUntil(current)
+	assert.Error(t, err)
+
+	_, err = ExtendParams{ExtendDays: -5}.ExtendedUntil(current)
+	assert.Error(t, err)
+}
+
+func TestRetainedSnapshot(t *testing.T) {
+	now := time.Now()
+	rs := RetainedSnapshot{Snapshot: "tank/backup@daily-1", RetainUntil: now.Add(time.Hour)}
+
+	assert.Equal(t, "tank/backup", rs.Dataset())
+	assert.False(t, rs.Expired(now))
+	assert.True(t, rs.Expired(now.Add(time.Hour)))
+}
+
+func TestValidateComplianceParams(t *testing.T) {
+	assert.NoError(t, ValidateComplianceParams(ComplianceParams{Dataset: "tank/backup", RetentionDays: 30}))
+	assert.Error(t, ValidateComplianceParams(ComplianceParams{Dataset: "tank/backup@snap", RetentionDays: 30}))
+	assert.Error(t, ValidateComplianceParams(ComplianceParams{Dataset: "tank/backup"}))
+}
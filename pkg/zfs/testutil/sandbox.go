@@ -0,0 +1,306 @@
+// Copyright 2025 Raamsri Kumar <raam@tinkershack.in>
+// Copyright 2025 The StrataSTOR Authors and Contributors
+// SPDX-License-Identifier: Apache-2.0
+
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/kballard/go-shellquote"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// SandboxDiskSize is the default size in MB of a sandbox pool's disk
+	// images, above the 64MB minimum of a vdev
+	SandboxDiskSize = 128
+
+	// FixturesEnv names a YAML file of Fixtures to use instead of a test's
+	// defaults
+	FixturesEnv = "RODENT_TEST_FIXTURES"
+)
+
+// Host is where sandbox pools are created: this machine, or a remote one
+// reached over SSH with key authentication and passwordless sudo
+type Host struct {
+	Address string `yaml:"address"` // Empty for this machine
+	User    string `yaml:"user"`
+	Port    int    `yaml:"port"`
+	KeyPath string `yaml:"key_path"`
+}
+
+// Local reports whether the host is this machine
+func (h Host) Local() bool {
+	return h.Address == ""
+}
+
+// Command returns a command running args as root on the host
+func (h Host) Command(args ...string) *exec.Cmd {
+	if h.Local() {
+		if os.Geteuid() == 0 {
+			return exec.Command(args[0], args[1:]...)
+		}
+		return exec.Command("sudo", append([]string{"-n"}, args...)...)
+	}
+
+	ssh := []string{"ssh", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=accept-new"}
+	if h.KeyPath != "" {
+		ssh = append(ssh, "-i", h.KeyPath)
+	}
+	if h.Port != 0 {
+		ssh = append(ssh, "-p", strconv.Itoa(h.Port))
+	}
+	target := h.Address
+	if h.User != "" {
+		target = h.User + "@" + h.Address
+	}
+	ssh = append(ssh, target, "--", "sudo", "-n", shellquote.Join(args...))
+	return exec.Command(ssh[0], ssh[1:]...)
+}
+
+// Run runs args as root on the host and returns their combined output
+func (h Host) Run(args ...string) ([]byte, error) {
+	out, err := h.Command(args...).CombinedOutput()
+	if err != nil {
+		return out, fmt.Errorf("%s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
+
+// RequireZFS skips the test unless the host can run zfs and zpool as root:
+// Linux with the ZFS module loaded and root or passwordless sudo
+func RequireZFS(t *testing.T, host Host) {
+	t.Helper()
+	if host.Local() {
+		if runtime.GOOS != "linux" {
+			t.Skip("ZFS integration tests need Linux")
+		}
+		if _, err := os.Stat("/dev/zfs"); err != nil {
+			t.Skip("ZFS module is not loaded (/dev/zfs is missing)")
+		}
+	}
+	if _, err := host.Run("zpool", "version"); err != nil {
+		t.Skipf("cannot run zpool as root on %s: %v", host, err)
+	}
+}
+
+// String names the host in logs and skip messages
+func (h Host) String() string {
+	if h.Local() {
+		return "this host"
+	}
+	return h.Address
+}
+
+// PoolSpec describes a sandbox pool and what to provision on it
+type PoolSpec struct {
+	Name      string   `yaml:"name"`      // Key of the sandbox; the pool gets a unique name
+	Remote    bool     `yaml:"remote"`    // Create on the fixtures' remote host
+	Disks     int      `yaml:"disks"`     // Disk images; one by default
+	SizeMB    int      `yaml:"size_mb"`   // Size of each image; SandboxDiskSize by default
+	Layout    string   `yaml:"layout"`    // Empty for a stripe, or mirror, raidz1, ...
+	Datasets  []string `yaml:"datasets"`  // Filesystems, relative to the pool
+	DataMB    int      `yaml:"data_mb"`   // Random data written to each dataset
+	Snapshots []string `yaml:"snapshots"` // dataset@snapshot, relative to the pool
+}
+
+// Fixtures describe the sandbox pools a test runs against
+type Fixtures struct {
+	Remote *Host      `yaml:"remote"` // Host of pools marked remote
+	Pools  []PoolSpec `yaml:"pools"`
+}
+
+// LoadFixtures returns the fixtures in the file named by FixturesEnv, or
+// defaults when it is unset
+func LoadFixtures(t *testing.T, defaults Fixtures) Fixtures {
+	t.Helper()
+	path := os.Getenv(FixturesEnv)
+	if path == "" {
+		return defaults
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+	var fixtures Fixtures
+	if err := yaml.Unmarshal(data, &fixtures); err != nil {
+		t.Fatalf("failed to parse fixtures %s: %v", path, err)
+	}
+	return fixtures
+}
+
+// Provision creates the pools of the fixtures, keyed by their names. They
+// are destroyed when the test ends.
+func (f Fixtures) Provision(t *testing.T) map[string]*Sandbox {
+	t.Helper()
+	sandboxes := make(map[string]*Sandbox, len(f.Pools))
+	for _, spec := range f.Pools {
+		var host Host
+		if spec.Remote {
+			if f.Remote == nil {
+				t.Fatalf("pool %s is remote but the fixtures have no remote host", spec.Name)
+			}
+			host = *f.Remote
+		}
+		sandboxes[spec.Name] = NewSandbox(t, host, spec)
+	}
+	return sandboxes
+}
+
+// Sandbox is a throwaway pool backed by image files
+type Sandbox struct {
+	Pool string
+	Host Host
+
+	t   *testing.T
+	dir string // Holds the images and the pool's altroot
+}
+
+// NewSandbox creates a pool on the host from files, with the datasets, data
+// and snapshots of spec, and destroys it when the test ends. The test is
+// skipped when the host cannot run ZFS.
+func NewSandbox(t *testing.T, host Host, spec PoolSpec) *Sandbox {
+	t.Helper()
+	RequireZFS(t, host)
+
+	s := &Sandbox{Pool: GeneratePoolName(), Host: host, t: t}
+	if spec.Name != "" {
+		s.Pool += "-" + spec.Name
+	}
+
+	if host.Local() {
+		s.dir = t.TempDir()
+	} else {
+		out, err := host.Run("mktemp", "-d", "/var/tmp/rodent-sandbox.XXXXXX")
+		if err != nil {
+			t.Fatalf("failed to create sandbox directory: %v", err)
+		}
+		s.dir = strings.TrimSpace(string(out))
+	}
+	t.Cleanup(s.cleanup)
+
+	disks, size := max(spec.Disks, 1), spec.SizeMB
+	if size == 0 {
+		size = SandboxDiskSize
+	}
+	var images []string
+	for i := range disks {
+		image := filepath.Join(s.dir, fmt.Sprintf("disk%d.img", i))
+		if _, err := host.Run("truncate", "-s", fmt.Sprintf("%dM", size), image); err != nil {
+			t.Fatalf("failed to create disk image: %v", err)
+		}
+		images = append(images, image)
+	}
+
+	// The altroot keeps the sandbox's mounts under its directory
+	args := []string{"zpool", "create", "-f", "-R", filepath.Join(s.dir, "root"), s.Pool}
+	if spec.Layout != "" {
+		args = append(args, spec.Layout)
+	}
+	if _, err := host.Run(append(args, images...)...); err != nil {
+		t.Fatalf("failed to create sandbox pool: %v", err)
+	}
+
+	for _, dataset := range spec.Datasets {
+		s.CreateDataset(dataset)
+		if spec.DataMB > 0 {
+			s.WriteData(dataset, spec.DataMB)
+		}
+	}
+	for _, snapshot := range spec.Snapshots {
+		dataset, snap, ok := strings.Cut(snapshot, "@")
+		if !ok {
+			t.Fatalf("snapshot %q is not dataset@snapshot", snapshot)
+		}
+		s.Snapshot(dataset, snap)
+	}
+
+	t.Logf("Created sandbox pool %s on %s", s.Pool, host)
+	return s
+}
+
+// Name returns the full name of a dataset, snapshot or bookmark relative to
+// the pool
+func (s *Sandbox) Name(rel string) string {
+	switch {
+	case rel == "":
+		return s.Pool
+	case strings.HasPrefix(rel, "@"), strings.HasPrefix(rel, "#"):
+		return s.Pool + rel
+	}
+	return s.Pool + "/" + rel
+}
+
+// CreateDataset creates a filesystem and its parents, and returns its name
+func (s *Sandbox) CreateDataset(rel string) string {
+	s.t.Helper()
+	name := s.Name(rel)
+	if _, err := s.Host.Run("zfs", "create", "-p", name); err != nil {
+		s.t.Fatalf("failed to create dataset: %v", err)
+	}
+	return name
+}
+
+// Snapshot snapshots a dataset and returns the snapshot's name
+func (s *Sandbox) Snapshot(rel, snap string) string {
+	s.t.Helper()
+	name := s.Name(rel) + "@" + snap
+	if _, err := s.Host.Run("zfs", "snapshot", name); err != nil {
+		s.t.Fatalf("failed to create snapshot: %v", err)
+	}
+	return name
+}
+
+// WriteData writes a file of random data to a mounted filesystem, so sends
+// of its snapshots carry data
+func (s *Sandbox) WriteData(rel string, sizeMB int) {
+	s.t.Helper()
+	out, err := s.Host.Run("zfs", "get", "-H", "-o", "value", "mountpoint", s.Name(rel))
+	if err != nil {
+		s.t.Fatalf("failed to get mountpoint: %v", err)
+	}
+	file := filepath.Join(strings.TrimSpace(string(out)), GeneratePoolName()+".bin")
+	if _, err := s.Host.Run("dd", "if=/dev/urandom", "of="+file, "bs=1M",
+		fmt.Sprintf("count=%d", sizeMB), "status=none"); err != nil {
+		s.t.Fatalf("failed to write data: %v", err)
+	}
+}
+
+// Exists reports whether a dataset, snapshot or bookmark exists, by its full
+// name
+func (s *Sandbox) Exists(name string) bool {
+	_, err := s.Host.Run("zfs", "list", "-H", "-o", "name", name)
+	return err == nil
+}
+
+// Destroy destroys a dataset and its descendants by full name, logging
+// failures
+func (s *Sandbox) Destroy(name string) {
+	if _, err := s.Host.Run("zfs", "destroy", "-r", name); err != nil {
+		s.t.Logf("Warning: failed to destroy %s: %v", name, err)
+	}
+}
+
+// cleanup destroys the pool and removes its images
+func (s *Sandbox) cleanup() {
+	if _, err := s.Host.Run("zpool", "list", "-H", "-o", "name", s.Pool); err == nil {
+		if _, err := s.Host.Run("zpool", "destroy", "-f", s.Pool); err != nil {
+			s.t.Errorf("failed to destroy sandbox pool %s: %v", s.Pool, err)
+			return
+		}
+	}
+	// Local images go with the test's temporary directory
+	if !s.Host.Local() {
+		if _, err := s.Host.Run("rm", "-rf", s.dir); err != nil {
+			s.t.Logf("Warning: failed to remove %s: %v", s.dir, err)
+		}
+	}
+}
@@ -112,6 +112,8 @@ func (l *LoopDevice) Cleanup() error {
 }
 
 func NewTestEnv(t *testing.T, diskCount int) *TestEnv {
+	RequireZFS(t, Host{})
+
 	env := &TestEnv{
 		Devices: make([]*LoopDevice, diskCount),
 	}
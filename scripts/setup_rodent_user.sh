@@ -208,6 +208,9 @@ CHMOD_PATH=$(detect_binary "chmod" "/bin/chmod")
 TEST_PATH=$(detect_binary "test" "/usr/bin/test")
 MKDIR_PATH=$(detect_binary "mkdir" "/usr/bin/mkdir")
 
+# Rodent binary, which is also the privilege helper (see rodent.service)
+RODENT_PATH="/usr/local/bin/rodent"
+
 # ZFS binaries
 ZFS_PATH=$(detect_binary "zfs" "/usr/local/sbin/zfs")
 ZPOOL_PATH=$(detect_binary "zpool" "/usr/local/sbin/zpool")
@@ -419,9 +422,7 @@ Cmnd_Alias ZFS_COMMANDS = \\
 Cmnd_Alias SMB_COMMANDS = \\
     $SMBSTATUS_PATH *, \\
     $SMBCONTROL_PATH *, \\
-    $SMBCLIENT_PATH *, \\
     $TESTPARM_PATH *, \\
-    $PDBEDIT_PATH *, \\
     $SYSTEMCTL_PATH start smbd*, \\
     $SYSTEMCTL_PATH stop smbd*, \\
     $SYSTEMCTL_PATH restart smbd*, \\
@@ -455,10 +456,10 @@ Cmnd_Alias DOCKER_COMMANDS = \\
     $SYSTEMCTL_PATH disable docker*, \\
     $SYSTEMCTL_PATH status docker*
 
-# Command aliases for file access control operations
+# Command aliases for file access control operations; setfacl runs through
+# the helper
 Cmnd_Alias FACL_COMMANDS = \\
-    $GETFACL_PATH *, \\
-    $SETFACL_PATH *
+    $GETFACL_PATH *
 
 # Command aliases for system commands
 Cmnd_Alias SYSTEM_COMMANDS = \\
@@ -466,7 +467,6 @@ Cmnd_Alias SYSTEM_COMMANDS = \\
     $MOUNT_PATH -l, \\
     $WHICH_PATH *, \\
     $PING_PATH *, \\
-    $IP_PATH *, \\
     $SYSTEMCTL_PATH start rodent*, \\
     $SYSTEMCTL_PATH stop rodent*, \\
     $SYSTEMCTL_PATH restart rodent*, \\
@@ -492,26 +492,12 @@ Cmnd_Alias SYSTEM_COMMANDS = \\
     $UNAME_PATH *, \\
     $UPTIME_PATH *, \\
     $GROUPS_PATH *, \\
-    $PASSWD_PATH *, \\
-    $OPENSSL_PATH *, \\
     $LSCPU_PATH *, \\
     $DMIDECODE_PATH *, \\
     $SYSTEMD_DETECT_VIRT_PATH *, \\
     $REBOOT_PATH *, \\
     $SHUTDOWN_PATH *, \\
-    $USERADD_PATH *, \\
-    $USERDEL_PATH *, \\
-    $USERMOD_PATH *, \\
-    $GROUPADD_PATH *, \\
-    $GROUPDEL_PATH *, \\
-    $GROUPMOD_PATH *, \\
-    $GPASSWD_PATH *, \\
-    $CHPASSWD_PATH *, \\
-    $ECHO_PATH *, \\
-    $SED_PATH *, \\
-    $GREP_PATH *, \\
     $NET_PATH ads *, \\
-    $TEST_PATH -f *, \\
     $LSBLK_PATH *, \\
     $SMARTCTL_PATH *, \\
     $LSSCSI_PATH *, \\
@@ -521,77 +507,13 @@ Cmnd_Alias SYSTEM_COMMANDS = \\
     $UDEVADM_PATH monitor *, \\
     $SG_SESS_PATH *
 
-# Command aliases for file operations
-Cmnd_Alias FILE_OPERATIONS = \\
-    $CAT_PATH /etc/samba/smb.conf*, \\
-    $CAT_PATH /etc/samba/conf.d/*, \\
-    $CP_PATH * /etc/samba/smb.conf*, \\
-    $CP_PATH * /etc/samba/conf.d/*, \\
-    $TEE_PATH /etc/samba/smb.conf*, \\
-    $TEE_PATH -a /etc/samba/smb.conf*, \\
-    $TEE_PATH /etc/samba/conf.d/*, \\
-    $TEE_PATH -a /etc/samba/conf.d/*, \\
-    $RM_PATH -f /etc/samba/conf.d/*, \\
-    $CHMOD_PATH * /etc/samba/smb.conf*, \\
-    $CHMOD_PATH * /etc/samba/conf.d/*, \\
-    $TEST_PATH -e /etc/samba/smb.conf*, \\
-    $TEST_PATH -e /etc/samba/conf.d/*, \\
-    $CAT_PATH /etc/hosts, \\
-    $CP_PATH * /etc/hosts, \\
-    $TEE_PATH /etc/hosts, \\
-    $TEE_PATH -a /etc/hosts, \\
-    $TEST_PATH -e /etc/hosts, \\
-    $CAT_PATH /etc/resolv.conf, \\
-    $CP_PATH * /etc/resolv.conf, \\
-    $TEE_PATH /etc/resolv.conf, \\
-    $TEE_PATH -a /etc/resolv.conf, \\
-    $TEST_PATH -e /etc/resolv.conf, \\
-    $CAT_PATH /etc/krb5.conf, \\
-    $CP_PATH * /etc/krb5.conf, \\
-    $CP_PATH /etc/krb5.conf /etc/krb5.conf.backup.*, \\
-    $TEE_PATH /etc/krb5.conf, \\
-    $TEE_PATH -a /etc/krb5.conf, \\
-    $TEST_PATH -e /etc/krb5.conf, \\
-    $CP_PATH /etc/nsswitch.conf /etc/nsswitch.conf.backup.*, \\
-    $CAT_PATH /etc/systemd/network*, \\
-    $CP_PATH * /etc/systemd/network*, \\
-    $TEE_PATH /etc/systemd/network*, \\
-    $TEE_PATH -a /etc/systemd/network*, \\
-    $TEST_PATH -e /etc/systemd/network*, \\
-    $CHMOD_PATH * /etc/systemd/network*, \\
-    $RM_PATH -f /etc/systemd/network*, \\
-    $CAT_PATH /etc/systemd/resolve*, \\
-    $CP_PATH * /etc/systemd/resolve*, \\
-    $TEE_PATH /etc/systemd/resolve*, \\
-    $TEE_PATH -a /etc/systemd/resolve*, \\
-    $TEST_PATH -e /etc/systemd/resolve*, \\
-    $CHMOD_PATH * /etc/systemd/resolve*, \\
-    $RM_PATH -f /etc/systemd/resolve*, \\
-    $CAT_PATH /run/systemd/network*, \\
-    $CP_PATH * /run/systemd/network*, \\
-    $TEE_PATH /run/systemd/network*, \\
-    $TEE_PATH -a /run/systemd/network*, \\
-    $TEST_PATH -e /run/systemd/network*, \\
-    $CHMOD_PATH * /run/systemd/network*, \\
-    $RM_PATH -f /run/systemd/network*, \\
-    $CAT_PATH /run/systemd/resolve*, \\
-    $CP_PATH * /run/systemd/resolve*, \\
-    $TEE_PATH /run/systemd/resolve*, \\
-    $TEE_PATH -a /run/systemd/resolve*, \\
-    $TEST_PATH -e /run/systemd/resolve*, \\
-    $CHMOD_PATH * /run/systemd/resolve*, \\
-    $RM_PATH -f /run/systemd/resolve*, \\
-    $MKDIR_PATH -p /etc/systemd/resolved.conf.d, \\
-    $CAT_PATH /etc/netplan/*, \\
-    $CP_PATH * /etc/netplan/*, \\
-    $TEE_PATH /etc/netplan/*, \\
-    $TEE_PATH -a /etc/netplan/*, \\
-    $TEST_PATH -e /etc/netplan/*, \\
-    $CHMOD_PATH * /etc/netplan/*, \\
-    $RM_PATH -f /etc/netplan/*
+# The root helper checks the arguments of the commands sudoers cannot: file
+# edits, ownership and mode changes, account management and ip
+Cmnd_Alias RODENT_HELPER = \\
+    $RODENT_PATH privilege helper *
 
 # Grant permissions to the rodent user
-rodent ALL=(ALL) NOPASSWD: ZFS_COMMANDS, SMB_COMMANDS, DOCKER_COMMANDS, FACL_COMMANDS, SYSTEM_COMMANDS, FILE_OPERATIONS
+rodent ALL=(ALL) NOPASSWD: ZFS_COMMANDS, SMB_COMMANDS, DOCKER_COMMANDS, FACL_COMMANDS, SYSTEM_COMMANDS, RODENT_HELPER
 
 # Defaults specification for security
 Defaults:rodent !requiretty